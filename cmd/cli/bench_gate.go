@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/akeren/go-api-foundry/pkg/benchgate"
+)
+
+const benchGateUsage = "usage: cli bench-gate [-max-regression 10] [-alpha 0.05] <old.txt> <new.txt>"
+
+// BenchGateCommand compares two files of `go test -bench` output and fails
+// when a benchmark got significantly slower, or allocates significantly
+// more, than -max-regression percent.
+func BenchGateCommand(args []string) error {
+	flags := flag.NewFlagSet("bench-gate", flag.ContinueOnError)
+	maxRegression := flags.Float64("max-regression", benchgate.DefaultMaxRegression*100, "largest regression allowed, in percent of the old median")
+	alpha := flags.Float64("alpha", benchgate.DefaultAlpha, "p-value under which a difference is significant")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 || *maxRegression <= 0 || *alpha <= 0 || *alpha >= 1 {
+		return errors.New(benchGateUsage)
+	}
+
+	var results [2]benchgate.Results
+	for i, path := range flags.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		results[i], err = benchgate.Parse(f)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+
+	deltas := benchgate.Compare(results[0], results[1], benchgate.Config{MaxRegression: *maxRegression / 100, Alpha: *alpha})
+	if len(deltas) == 0 {
+		return errors.New("no benchmark appears in both files")
+	}
+	for _, d := range deltas {
+		fmt.Println(d)
+	}
+	if regressions := benchgate.Regressions(deltas); len(regressions) > 0 {
+		return fmt.Errorf("%d benchmark results regressed by more than %g%%", len(regressions), *maxRegression)
+	}
+	return nil
+}
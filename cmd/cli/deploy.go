@@ -0,0 +1,515 @@
+package main
+
+// Command: deploy.go
+//
+// Description:
+// `cli deploy scaffold` writes deployment manifests for the server: a
+// multi-stage Dockerfile, Kubernetes manifests (Deployment, Service, HPA,
+// ConfigMap, Secret and a migration Job) with probes on /health/live and
+// /health/ready, and a docker-compose file that runs the image next to
+// Postgres, Redis and an OpenTelemetry collector. The configuration comes from
+// the env file the app documents (.env.example), so the manifests set the
+// variables the app reads: secrets go in the Secret, other non-empty values
+// in the ConfigMap.
+//
+// Usage:
+//   go run ./cmd/cli deploy scaffold [-out deploy] [-name go-api-foundry] [-image ...] [-env .env.example] [-force]
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// deployOverrides replace the development values of the env file in the
+// generated configuration.
+var deployOverrides = map[string]string{
+	"APP_ENV":             "production",
+	"GIN_MODE":            "release",
+	"SKIP_DOTENV":         "true",
+	"OTEL_TRACES_ENABLED": "true",
+	"MIGRATIONS_DIR":      "/migrations",
+	// Matches the Deployment's replicas, so the scale-out audit runs.
+	"APP_REPLICAS": "2",
+}
+
+// secretSuffixes name the variables that go in the Secret rather than the
+// ConfigMap. Database URLs carry credentials.
+var secretSuffixes = []string{"_PASSWORD", "_SECRET", "_TOKEN", "_API_KEY", "_INDEX_KEY", "_KEYS", "DATABASE_URL", "DATABASE_REPLICA_URL"}
+
+type envVar struct {
+	Name  string
+	Value string
+}
+
+type deployConfig struct {
+	Name  string
+	Image string
+	Port  int
+	// GracePeriod covers SHUTDOWN_DRAIN_DELAY plus the 30s in-flight
+	// requests get to finish.
+	GracePeriod int
+	Config      []envVar
+	Secrets     []envVar
+}
+
+func DeployCommand(args []string) error {
+	if len(args) == 0 || args[0] != "scaffold" {
+		return errors.New("usage: cli deploy scaffold [-out dir] [-name name] [-image image] [-env file] [-force]")
+	}
+
+	flags := flag.NewFlagSet("deploy scaffold", flag.ContinueOnError)
+	out := flags.String("out", "deploy", "directory to write the manifests to")
+	name := flags.String("name", "go-api-foundry", "application name used for Kubernetes objects")
+	image := flags.String("image", "", "container image (default: <name>:latest)")
+	envFile := flags.String("env", ".env.example", "env file listing the variables the app reads")
+	force := flags.Bool("force", false, "overwrite existing files")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *image == "" {
+		*image = *name + ":latest"
+	}
+
+	vars, err := readEnvFile(*envFile)
+	if err != nil {
+		return err
+	}
+	cfg, err := newDeployConfig(*name, *image, vars)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		"Dockerfile":             deployDockerfile,
+		"docker-compose.yaml":    deployCompose,
+		"otel-collector.yaml":    deployOTelCollector,
+		"k8s/configmap.yaml":     deployConfigMap,
+		"k8s/secret.yaml":        deploySecret,
+		"k8s/deployment.yaml":    deployDeployment,
+		"k8s/service.yaml":       deployService,
+		"k8s/hpa.yaml":           deployHPA,
+		"k8s/migration-job.yaml": deployMigrationJob,
+	}
+	names := make([]string, 0, len(files))
+	for file := range files {
+		names = append(names, file)
+	}
+	slices.Sort(names)
+
+	if !*force {
+		for _, file := range names {
+			if _, err := os.Stat(filepath.Join(*out, file)); err == nil {
+				return fmt.Errorf("%s already exists; pass -force to overwrite", filepath.Join(*out, file))
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	funcs := template.FuncMap{"quote": strconv.Quote}
+	for _, file := range names {
+		tmpl, err := template.New(file).Funcs(funcs).Parse(files[file])
+		if err != nil {
+			return fmt.Errorf("parse %s template: %w", file, err)
+		}
+		var content strings.Builder
+		if err := tmpl.Execute(&content, cfg); err != nil {
+			return fmt.Errorf("render %s: %w", file, err)
+		}
+		path := filepath.Join(*out, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+			return err
+		}
+		fmt.Println("  wrote", path)
+	}
+
+	fmt.Printf("✅ Deployment manifests written to %s (%d settings, %d secrets from %s)\n", *out, len(cfg.Config), len(cfg.Secrets), *envFile)
+	fmt.Println("  ===> Next steps:")
+	fmt.Printf("   1) Fill in %s and review %s\n", filepath.Join(*out, "k8s/secret.yaml"), filepath.Join(*out, "k8s/configmap.yaml"))
+	fmt.Printf("   2) Build and push the image: docker build -f %s -t %s .\n", filepath.Join(*out, "Dockerfile"), *image)
+	fmt.Printf("   3) Run the migration job, then apply the rest: kubectl apply -f %s\n", filepath.Join(*out, "k8s"))
+	fmt.Printf("   Locally: docker compose -f %s up --build\n", filepath.Join(*out, "docker-compose.yaml"))
+	return nil
+}
+
+// readEnvFile returns the variables of a dotenv file in order, without
+// trailing comments. Commented-out assignments are skipped.
+func readEnvFile(path string) ([]envVar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var vars []envVar
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		vars = append(vars, envVar{Name: strings.TrimSpace(key), Value: strings.Trim(strings.TrimSpace(value), `"'`)})
+	}
+	return vars, scanner.Err()
+}
+
+func newDeployConfig(name, image string, vars []envVar) (*deployConfig, error) {
+	cfg := &deployConfig{Name: name, Image: image, Port: 8080}
+	drain := time.Duration(0)
+
+	for _, v := range vars {
+		if override, ok := deployOverrides[v.Name]; ok {
+			v.Value = override
+		}
+		switch v.Name {
+		case "APP_PORT":
+			if v.Value != "" {
+				port, err := strconv.Atoi(v.Value)
+				if err != nil || port <= 0 || port > 65535 {
+					return nil, fmt.Errorf("invalid APP_PORT %q", v.Value)
+				}
+				cfg.Port = port
+			}
+		case "SHUTDOWN_DRAIN_DELAY":
+			if parsed, err := time.ParseDuration(v.Value); err == nil {
+				drain = parsed
+			}
+		}
+
+		if isSecretVar(v.Name) {
+			// Example values are not real secrets.
+			cfg.Secrets = append(cfg.Secrets, envVar{Name: v.Name})
+		} else if v.Value != "" {
+			cfg.Config = append(cfg.Config, v)
+		}
+	}
+	cfg.GracePeriod = int((drain + 35*time.Second).Seconds())
+	return cfg, nil
+}
+
+func isSecretVar(name string) bool {
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+const deployDockerfile = `# Generated by cli deploy scaffold. Build from the repository root:
+#   docker build -f deploy/Dockerfile -t {{.Image}} .
+ARG GO_VERSION=1.25.0
+
+FROM golang:${GO_VERSION}-alpine AS build
+
+RUN apk add --no-cache ca-certificates git
+WORKDIR /app
+
+COPY go.mod go.sum ./
+RUN --mount=type=cache,target=/go/pkg/mod go mod download
+
+COPY . .
+RUN --mount=type=cache,target=/go/pkg/mod \
+    CGO_ENABLED=0 GOOS=linux go build -trimpath -ldflags='-s -w' -o /out/server ./cmd/server && \
+    CGO_ENABLED=0 GOOS=linux go build -trimpath -ldflags='-s -w' -o /out/cli ./cmd/cli
+
+FROM scratch
+
+COPY --from=build /etc/ssl/certs/ca-certificates.crt /etc/ssl/certs/
+COPY --from=build /out/server /server
+COPY --from=build /out/cli /cli
+COPY migrations /migrations
+
+ENV GIN_MODE=release
+ENV APP_ENV=production
+ENV APP_PORT={{.Port}}
+ENV SKIP_DOTENV=true
+ENV MIGRATIONS_DIR=/migrations
+
+EXPOSE {{.Port}}
+USER 65532:65532
+
+# /cli migrate runs the migrations; /server --self-test checks a deployment.
+ENTRYPOINT ["/server"]
+`
+
+const deployCompose = `# Generated by cli deploy scaffold. Runs the production image locally next
+# to Postgres, Redis and an OpenTelemetry collector:
+#   docker compose -f deploy/docker-compose.yaml up --build
+name: {{.Name}}
+
+services:
+  migrate:
+    build:
+      context: ..
+      dockerfile: deploy/Dockerfile
+    image: {{.Image}}
+    entrypoint: ["/cli", "migrate"]
+    env_file:
+      - path: ../.env
+        required: false
+    environment: &overrides
+      APP_ENV: docker
+      POSTGRES_HOST: postgres
+      POSTGRES_PORT: "5432"
+      POSTGRES_USER: app
+      POSTGRES_PASSWORD: app
+      POSTGRES_DB_NAME: app
+      POSTGRES_SSLMODE: disable
+      REDIS_HOST: redis
+      REDIS_PORT: "6379"
+      OTEL_TRACES_ENABLED: "true"
+      OTEL_EXPORTER_OTLP_ENDPOINT: http://otel-collector:4318
+    depends_on:
+      postgres:
+        condition: service_healthy
+
+  api:
+    image: {{.Image}}
+    env_file:
+      - path: ../.env
+        required: false
+    environment: *overrides
+    ports:
+      - "{{.Port}}:{{.Port}}"
+    depends_on:
+      migrate:
+        condition: service_completed_successfully
+      redis:
+        condition: service_healthy
+      otel-collector:
+        condition: service_started
+    restart: unless-stopped
+
+  postgres:
+    image: postgres:18.1-alpine
+    environment:
+      POSTGRES_DB: app
+      POSTGRES_USER: app
+      POSTGRES_PASSWORD: app
+    volumes:
+      - postgres-data:/var/lib/postgresql
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -U app -d app"]
+      interval: 5s
+      timeout: 5s
+      retries: 10
+
+  redis:
+    image: redis:8.4-alpine
+    healthcheck:
+      test: ["CMD", "redis-cli", "ping"]
+      interval: 5s
+      timeout: 3s
+      retries: 10
+
+  otel-collector:
+    image: otel/opentelemetry-collector-contrib:0.139.0
+    command: ["--config=/etc/otelcol/config.yaml"]
+    volumes:
+      - ./otel-collector.yaml:/etc/otelcol/config.yaml:ro
+
+volumes:
+  postgres-data:
+`
+
+const deployOTelCollector = `# Generated by cli deploy scaffold. Receives the server's OTLP/HTTP traces
+# and prints them; replace the debug exporter with your tracing backend.
+receivers:
+  otlp:
+    protocols:
+      http:
+        endpoint: 0.0.0.0:4318
+      grpc:
+        endpoint: 0.0.0.0:4317
+
+processors:
+  batch: {}
+
+exporters:
+  debug:
+    verbosity: basic
+
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [debug]
+`
+
+const deployConfigMap = `# Generated by cli deploy scaffold from the non-secret settings of the env
+# file. Point POSTGRES_HOST, REDIS_HOST and OTEL_EXPORTER_OTLP_ENDPOINT at
+# the cluster's services.
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{.Name}}-config
+  labels:
+    app.kubernetes.io/name: {{.Name}}
+data:
+{{- range .Config}}
+  {{.Name}}: {{quote .Value}}
+{{- end}}
+`
+
+const deploySecret = `# Generated by cli deploy scaffold. Fill in the values the deployment uses
+# and leave the rest empty; prefer a secret manager to committing this file.
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{.Name}}-secrets
+  labels:
+    app.kubernetes.io/name: {{.Name}}
+type: Opaque
+stringData:
+{{- range .Secrets}}
+  {{.Name}}: ""
+{{- end}}
+`
+
+const deployDeployment = `# Generated by cli deploy scaffold.
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.Name}}
+  labels:
+    app.kubernetes.io/name: {{.Name}}
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{.Name}}
+  strategy:
+    rollingUpdate:
+      maxUnavailable: 0
+      maxSurge: 1
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{.Name}}
+    spec:
+      # SHUTDOWN_DRAIN_DELAY, then 30s for in-flight requests, then cleanup.
+      terminationGracePeriodSeconds: {{.GracePeriod}}
+      securityContext:
+        runAsNonRoot: true
+        runAsUser: 65532
+      containers:
+        - name: server
+          image: {{.Image}}
+          ports:
+            - name: http
+              containerPort: {{.Port}}
+          envFrom:
+            - configMapRef:
+                name: {{.Name}}-config
+            - secretRef:
+                name: {{.Name}}-secrets
+          startupProbe:
+            httpGet: { path: /health/live, port: http }
+            periodSeconds: 2
+            failureThreshold: 30
+          livenessProbe:
+            httpGet: { path: /health/live, port: http }
+            periodSeconds: 10
+          readinessProbe:
+            httpGet: { path: /health/ready, port: http }
+            periodSeconds: 5
+          resources:
+            requests: { cpu: 100m, memory: 128Mi }
+            limits: { memory: 512Mi }
+          securityContext:
+            allowPrivilegeEscalation: false
+            readOnlyRootFilesystem: true
+            capabilities: { drop: [ALL] }
+`
+
+const deployService = `# Generated by cli deploy scaffold.
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{.Name}}
+  labels:
+    app.kubernetes.io/name: {{.Name}}
+spec:
+  selector:
+    app.kubernetes.io/name: {{.Name}}
+  ports:
+    - name: http
+      port: 80
+      targetPort: http
+`
+
+const deployHPA = `# Generated by cli deploy scaffold.
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{.Name}}
+  labels:
+    app.kubernetes.io/name: {{.Name}}
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{.Name}}
+  minReplicas: 2
+  maxReplicas: 10
+  metrics:
+    - type: Resource
+      resource:
+        name: cpu
+        target:
+          type: Utilization
+          averageUtilization: 70
+`
+
+const deployMigrationJob = `# Generated by cli deploy scaffold. Migrations take no lock, so run them once
+# per release from this Job rather than from every pod:
+#   kubectl delete job {{.Name}}-migrate --ignore-not-found && kubectl apply -f migration-job.yaml
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{.Name}}-migrate
+  labels:
+    app.kubernetes.io/name: {{.Name}}
+spec:
+  backoffLimit: 2
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{.Name}}-migrate
+    spec:
+      restartPolicy: Never
+      securityContext:
+        runAsNonRoot: true
+        runAsUser: 65532
+      containers:
+        - name: migrate
+          image: {{.Image}}
+          command: ["/cli", "migrate"]
+          envFrom:
+            - configMapRef:
+                name: {{.Name}}-config
+            - secretRef:
+                name: {{.Name}}-secrets
+`
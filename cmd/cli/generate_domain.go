@@ -87,6 +87,7 @@ import (
 
 	"github.com/akeren/go-api-foundry/internal/models"
 	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/txmanager"
 	"gorm.io/gorm"
 )
 
@@ -108,8 +109,8 @@ func New%sRepository(db *gorm.DB) %sRepository {
 }
 
 func (r *%sRepository) Create(ctx context.Context, entry *models.%s) (*models.%s, error) {
-	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
-		if isDuplicateKey(err) {
+	if err := txmanager.DB(ctx, r.db).Create(entry).Error; err != nil {
+		if apperrors.IsUniqueViolation(err) {
 			return nil, apperrors.NewConflictError("%s entry already exists", err)
 		}
 		return nil, apperrors.NewDatabaseError("unable to create %s entry", err)
@@ -119,7 +120,7 @@ func (r *%sRepository) Create(ctx context.Context, entry *models.%s) (*models.%s
 
 func (r *%sRepository) FindByID(ctx context.Context, id uint) (*models.%s, error) {
 	var entry models.%s
-	if err := r.db.WithContext(ctx).First(&entry, id).Error; err != nil {
+	if err := txmanager.DB(ctx, r.db).First(&entry, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, apperrors.NewNotFoundError("%s entry not found", err)
 		}
@@ -127,11 +128,6 @@ func (r *%sRepository) FindByID(ctx context.Context, id uint) (*models.%s, error
 	}
 	return &entry, nil
 }
-
-// isDuplicateKey checks if the error is a duplicate key constraint violation
-func isDuplicateKey(err error) bool {
-	return errors.Is(err, gorm.ErrDuplicatedKey) || apperrors.IsDuplicateKeyError(err)
-}
 `,
 		domain,               // package name
 		title, domain, title, // interface comments
@@ -252,7 +248,7 @@ func New%sController(db *gorm.DB, logger *log.Logger) *router.RESTController {
 		"%sController",
 		"v1",
 		"/%s",
-		func(rs *router.RouterService, c *router.RESTController) {
+		func(rs router.RouteRegistrar, c *router.RESTController) {
 			repository := New%sRepository(db)
 			service := New%sService(logger, repository)
 
@@ -265,21 +261,12 @@ func New%sController(db *gorm.DB, logger *log.Logger) *router.RESTController {
 
 func createHandler(service %sService) router.HandlerFunction {
 	return func(ctx *router.RequestContext) *router.ServiceResult {
-		logger := router.GetLogger(ctx)
-
-		var req Create%sRequest
-		if err := ctx.ShouldBindJSON(&req); err != nil {
-			logger.Error("Failed to bind request", "error", err)
-
-			validationErrors := apperrors.FormatValidationErrors(err, &req)
-			if len(validationErrors) > 0 {
-				return router.BadRequestResult("Invalid request payload", validationErrors)
-			}
-
-			return router.BadRequestResult("Invalid request body", nil)
+		req, errResult := router.BindJSON[Create%sRequest](ctx)
+		if errResult != nil {
+			return errResult
 		}
 
-		response, err := service.Create(ctx.Request.Context(), &req)
+		response, err := service.Create(ctx.Request.Context(), req)
 		if err != nil {
 			return router.ErrorResult(
 				apperrors.HTTPStatusCode(err),
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config"
+	"github.com/akeren/go-api-foundry/domain/ledger"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+)
+
+const ledgerChainUsage = "usage: cli ledger-chain verify [account-id ...] | cli ledger-chain rechain <account-id>"
+
+// LedgerChainCommand verifies accounts' entry hash chains, every account's
+// when none are named, or rechains one account after an approved correction.
+// verify fails when any chain is broken.
+func LedgerChainCommand(logger *log.Logger, args []string) error {
+	if len(args) == 0 || (args[0] != "verify" && args[0] != "rechain") || (args[0] == "rechain" && len(args) != 2) {
+		return errors.New(ledgerChainUsage)
+	}
+
+	db, err := config.NewDatabase(logger, &config.DBConfig{})
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer func() { _ = sqlDB.Close() }()
+	}
+	service := ledger.NewLedgerService(logger, ledger.NewLedgerRepository(db, nil), ledger.Pricing{}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	if args[0] == "rechain" {
+		rechain, err := service.RechainAccount(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: rewrote %d of %d entries; chain ends at %s (was %s)\n",
+			rechain.AccountID, rechain.Rewritten, rechain.Entries, rechain.LastEntryHash, rechain.PreviousLastEntryHash)
+		return nil
+	}
+
+	ids := args[1:]
+	if len(ids) == 0 {
+		if err := db.WithContext(ctx).Model(&models.Account{}).Order("id").Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("list accounts: %w", err)
+		}
+	}
+	broken := 0
+	for _, id := range ids {
+		result, err := service.VerifyAccountChain(ctx, id)
+		if err != nil {
+			return fmt.Errorf("verify %s: %w", id, err)
+		}
+		if result.Valid {
+			fmt.Printf("%s: ok, %d entries chained, %d unchained\n", id, result.Entries, result.UnchainedEntries)
+			continue
+		}
+		broken++
+		fmt.Fprintf(os.Stderr, "%s: BROKEN: %s\n", id, strings.Join(result.Problems, "; "))
+	}
+	if broken > 0 {
+		return fmt.Errorf("%d of %d accounts failed verification", broken, len(ids))
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/akeren/go-api-foundry/config"
 	"github.com/akeren/go-api-foundry/internal/log"
 	"github.com/akeren/go-api-foundry/pkg/migrations"
+	"github.com/akeren/go-api-foundry/pkg/rawsql"
 	"github.com/akeren/go-api-foundry/pkg/utils"
 )
 
@@ -57,10 +58,49 @@ func main() {
 		logger.Info("Database migrations completed")
 		return
 
+	case "lint-sql":
+		dir := "."
+		if len(args) > 1 {
+			dir = args[1]
+		}
+		issues, err := rawsql.Lint(dir)
+		if err != nil {
+			logger.Error("SQL lint failed", "error", err.Error())
+			os.Exit(1)
+		}
+		for _, issue := range issues {
+			fmt.Fprintln(os.Stderr, issue)
+		}
+		if len(issues) > 0 {
+			os.Exit(1)
+		}
+		return
+
 	case "generate-domain", "gendomain", "gen-domain":
 		GenerateDomain()
 		return
 
+	case "deploy":
+		if err := DeployCommand(args[1:]); err != nil {
+			logger.Error("Deploy command failed", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+
+	case "ledger-chain":
+		if err := LedgerChainCommand(logger, args[1:]); err != nil {
+			logger.Error("Ledger chain command failed", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+
+	case "bench-gate":
+		if err := BenchGateCommand(args[1:]); err != nil {
+			logger.Error("Benchmark gate failed", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+
 	case "help", "-h", "--help":
 		printUsage()
 		return
@@ -77,5 +117,10 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  migrate          Run database migrations and exit")
+	fmt.Println("  lint-sql [dir]   Report SQL calls whose query text is built at run time")
 	fmt.Println("  generate-domain  Interactively scaffolds a new domain/module (repository, service, controller, routes)")
+	fmt.Println("  deploy scaffold  Write a Dockerfile, Kubernetes manifests and a docker-compose file from .env.example")
+	fmt.Println("  ledger-chain verify [account-id ...]  Check accounts' ledger entry hash chains, all accounts by default")
+	fmt.Println("  ledger-chain rechain <account-id>     Rebuild an account's chain after an approved correction")
+	fmt.Println("  bench-gate <old.txt> <new.txt>        Fail when benchmarks regressed significantly between two go test -bench runs")
 }
@@ -19,11 +19,14 @@ func main() {
 	logger.Info("V2 Backend Server initialized ✅")
 
 	autoMigrate := false
+	selfTest := false
 
 	for _, arg := range os.Args[1:] {
-		if strings.ToLower(arg) == "--auto-migrate" || strings.ToLower(arg) == "-m" {
+		switch strings.ToLower(arg) {
+		case "--auto-migrate", "-m":
 			autoMigrate = true
-			break
+		case "--self-test":
+			selfTest = true
 		}
 	}
 
@@ -34,10 +37,23 @@ func main() {
 	}
 
 	domain.SetupCoreDomain(appConfig)
+
+	if selfTest {
+		// Checks the wiring and dependencies without serving, then exits
+		// non-zero when any check failed.
+		report := appConfig.SelfTest(context.Background(), 10*time.Second)
+		report.Write(os.Stdout)
+		appConfig.Cleanup()
+		if !report.Passed() {
+			os.Exit(1)
+		}
+		return
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	serverErr := make(chan error, 1)
+	serverErr := make(chan error, 2)
 	go func() {
 		logger.Info("Starting HTTP server...")
 		if err := appConfig.RouterService.RunHTTPServer(); err != nil {
@@ -45,6 +61,15 @@ func main() {
 		}
 	}()
 
+	if appConfig.GRPCServer != nil {
+		go func() {
+			logger.Info("Starting gRPC server...")
+			if err := appConfig.GRPCServer.Run(); err != nil {
+				serverErr <- err
+			}
+		}()
+	}
+
 	select {
 	case err := <-serverErr:
 		logger.Error("Server error", "error", err)
@@ -53,15 +78,15 @@ func main() {
 	case <-quit:
 		logger.Info("Shutdown signal received, shutting down gracefully...")
 
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		// In-flight requests get 30s once the drain delay has passed.
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), appConfig.Config.ShutdownDrainDelay+30*time.Second)
 		defer shutdownCancel()
 
-		if err := appConfig.RouterService.Shutdown(shutdownCtx); err != nil {
+		if err := appConfig.Shutdown(shutdownCtx); err != nil {
 			logger.Error("HTTP server shutdown error", "error", err)
 		} else {
 			logger.Info("HTTP server shut down gracefully")
 		}
-		appConfig.Cleanup()
 
 		logger.Info("Graceful shutdown completed")
 	}
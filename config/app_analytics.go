@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/analytics"
+	"github.com/akeren/go-api-foundry/pkg/messaging"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const (
+	defaultAnalyticsTopic = "analytics.events"
+	defaultAnalyticsTable = "analytics_events"
+)
+
+// NewAnalytics loads the event schemas from ANALYTICS_SCHEMAS_FILE and
+// buffers accepted events for the sink named by ANALYTICS_SINK: "broker"
+// publishes to ANALYTICS_TOPIC on the message broker, "clickhouse" inserts
+// into ANALYTICS_CLICKHOUSE_TABLE at ANALYTICS_CLICKHOUSE_URL, and "file"
+// writes under ANALYTICS_DIR. It returns nils when ANALYTICS_SCHEMAS_FILE is
+// not set, and an error when the schemas or the sink are misconfigured.
+func NewAnalytics(logger *log.Logger, broker messaging.Broker) (*analytics.Registry, *analytics.Buffer, error) {
+	path := utils.GetEnvTrimmed("ANALYTICS_SCHEMAS_FILE")
+	if path == "" {
+		logger.Info("Analytics ingestion disabled (ANALYTICS_SCHEMAS_FILE not set)")
+		return nil, nil, nil
+	}
+	registry, err := analytics.LoadRegistry(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sink analytics.Sink
+	switch kind := strings.ToLower(utils.GetEnvTrimmed("ANALYTICS_SINK")); kind {
+	case "broker":
+		if broker == nil {
+			return nil, nil, fmt.Errorf("ANALYTICS_SINK=broker needs a message broker; set KAFKA_BROKERS or NATS_URL")
+		}
+		topic := utils.GetEnvTrimmed("ANALYTICS_TOPIC")
+		if topic == "" {
+			topic = defaultAnalyticsTopic
+		}
+		sink = analytics.NewBrokerSink(broker, topic)
+		logger.Info("Analytics events go to the message broker", "topic", topic)
+	case "clickhouse":
+		table := utils.GetEnvTrimmed("ANALYTICS_CLICKHOUSE_TABLE")
+		if table == "" {
+			table = defaultAnalyticsTable
+		}
+		clickhouse, err := analytics.NewClickHouseSink(analytics.ClickHouseConfig{
+			URL:      utils.GetEnvTrimmed("ANALYTICS_CLICKHOUSE_URL"),
+			Table:    table,
+			Username: utils.GetEnvTrimmed("ANALYTICS_CLICKHOUSE_USER"),
+			Password: utils.GetEnvTrimmed("ANALYTICS_CLICKHOUSE_PASSWORD"),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		sink = clickhouse
+		logger.Info("Analytics events go to ClickHouse", "table", table)
+	case "file":
+		dir := utils.GetEnvTrimmed("ANALYTICS_DIR")
+		if dir == "" {
+			return nil, nil, fmt.Errorf("ANALYTICS_SINK=file needs ANALYTICS_DIR")
+		}
+		files, err := analytics.NewFileSink(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		sink = files
+		logger.Info("Analytics events go to files", "dir", dir)
+	default:
+		return nil, nil, fmt.Errorf("unknown ANALYTICS_SINK %q; use broker, clickhouse or file", kind)
+	}
+
+	cfg := analytics.DefaultBufferConfig()
+	for env, dst := range map[string]*int{
+		"ANALYTICS_BUFFER_SIZE": &cfg.Capacity,
+		"ANALYTICS_BATCH_SIZE":  &cfg.BatchSize,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid size; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+	if v := utils.GetEnvTrimmed("ANALYTICS_FLUSH_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			cfg.FlushInterval = parsed
+		} else {
+			logger.Warn("Invalid duration; using default", "env", "ANALYTICS_FLUSH_INTERVAL", "value", v, "default", cfg.FlushInterval)
+		}
+	}
+
+	logger.Info("Analytics ingestion enabled", "events", registry.Names())
+	return registry, analytics.NewBuffer(sink, logger, cfg), nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// Names of the breakers in the registry NewCircuitBreakers returns. Webhook
+// receivers each have one named "webhook:" and their host.
+const (
+	BreakerRedisCache     = "redis_cache"
+	BreakerRedisRateLimit = "redis_rate_limit"
+	BreakerMailer         = "mailer"
+)
+
+// NewCircuitBreakers returns the registry of breakers guarding Redis, the
+// mail provider and webhook receivers. A breaker opens after
+// CIRCUIT_BREAKER_FAILURES consecutive failures (default 5) and tries again
+// after CIRCUIT_BREAKER_OPEN_TIMEOUT (default 30s). It returns nil when
+// CIRCUIT_BREAKERS_ENABLED is false; every call then goes through.
+func NewCircuitBreakers(logger *log.Logger) *circuitbreaker.Registry {
+	if v := utils.GetEnvTrimmed("CIRCUIT_BREAKERS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil && !enabled {
+			logger.Info("Circuit breakers disabled (CIRCUIT_BREAKERS_ENABLED=false)")
+			return nil
+		}
+	}
+
+	cfg := circuitbreaker.DefaultConfig()
+	if v := utils.GetEnvTrimmed("CIRCUIT_BREAKER_FAILURES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			cfg.FailureThreshold = parsed
+		} else {
+			logger.Warn("Invalid CIRCUIT_BREAKER_FAILURES; using default", "value", v, "default", cfg.FailureThreshold)
+		}
+	}
+	if v := utils.GetEnvTrimmed("CIRCUIT_BREAKER_OPEN_TIMEOUT"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			cfg.OpenTimeout = parsed
+		} else {
+			logger.Warn("Invalid CIRCUIT_BREAKER_OPEN_TIMEOUT; using default", "value", v, "default", cfg.OpenTimeout)
+		}
+	}
+	return circuitbreaker.NewRegistry(logger, cfg)
+}
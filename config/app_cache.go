@@ -3,10 +3,14 @@ package config
 import (
 	"context"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
+	"github.com/akeren/go-api-foundry/pkg/lrucache"
 	pkgredis "github.com/akeren/go-api-foundry/pkg/redis"
+	"github.com/akeren/go-api-foundry/pkg/tiercache"
 	"github.com/akeren/go-api-foundry/pkg/utils"
 	"github.com/go-redis/redis/v8"
 )
@@ -14,9 +18,27 @@ import (
 type Cache interface {
 	// Get returns ("", nil) when a key is not found.
 	Get(ctx context.Context, key string) (string, error)
+	// GetWithTTL also returns how long the key has left, 0 when it does not
+	// expire. It returns ("", 0, nil) when the key is not found.
+	GetWithTTL(ctx context.Context, key string) (string, time.Duration, error)
+	// MGet returns the values of keys in order, "" for those not found.
+	MGet(ctx context.Context, keys ...string) ([]string, error)
 	// Set uses ttl=0 for no expiry.
 	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	// SetNX sets key only when it does not exist and reports whether it did.
+	// It uses ttl=0 for no expiry.
+	SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+	// Incr adds one to the counter at key; see IncrBy.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// IncrBy adds delta to the counter at key, created at 0, and returns the
+	// new value. A counter without an expiry is given ttl, so one created by
+	// the call expires ttl after it; ttl=0 leaves the expiry alone.
+	IncrBy(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
 	Delete(ctx context.Context, key string) error
+	// DeleteByPattern deletes the keys matching a glob-style pattern, such as
+	// "session:*", and returns how many it deleted. Keys are found by
+	// scanning, so ones written meanwhile may survive.
+	DeleteByPattern(ctx context.Context, pattern string) (int64, error)
 	Ping(ctx context.Context) error
 	Close() error
 }
@@ -31,6 +53,8 @@ type CacheConfig struct {
 	Host     string
 	Port     string
 	Password string
+	// Breaker, when set, fails cache calls fast while Redis keeps failing.
+	Breaker *circuitbreaker.Breaker
 }
 
 func NewCacheConfig() *CacheConfig {
@@ -56,6 +80,7 @@ func (cc *CacheConfig) NewCache(logger *log.Logger) (Cache, error) {
 		Port:     cc.Port,
 		Password: cc.Password,
 		DB:       0, // Always use DB 0 for cache
+		Breaker:  cc.Breaker,
 	}
 
 	cache, err := pkgredis.NewRedisCache(cfg)
@@ -65,26 +90,87 @@ func (cc *CacheConfig) NewCache(logger *log.Logger) (Cache, error) {
 	}
 
 	logger.Info("Cache (Redis) connected successfully")
-	return cache, nil
+	return newTieredCache(logger, cache), nil
 }
 
+// newTieredCache puts an in-memory tier in front of the Redis cache when
+// CACHE_LOCAL_TIER_ENABLED is true. Values are served locally for at most
+// CACHE_LOCAL_TIER_TTL (default 30s), and writes are published on Redis so
+// every instance drops its copy. Without the invalidation subscription, it
+// keeps the Redis cache alone.
+func newTieredCache(logger *log.Logger, cache *pkgredis.RedisCache) Cache {
+	enabled, err := strconv.ParseBool(utils.GetEnvTrimmed("CACHE_LOCAL_TIER_ENABLED"))
+	if err != nil || !enabled {
+		return cache
+	}
+
+	cfg := tiercache.Config{LocalTTL: tiercache.DefaultLocalTTL, LocalMaxEntries: memoryCacheConfig(logger).MaxEntries}
+	if v := utils.GetEnvTrimmed("CACHE_LOCAL_TIER_TTL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			cfg.LocalTTL = parsed
+		} else {
+			logger.Warn("Invalid CACHE_LOCAL_TIER_TTL; using default", "value", v, "default", cfg.LocalTTL)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tiered, err := tiercache.New(ctx, cache, tiercache.NewRedisBus(cache.GetClient(), tiercache.DefaultChannel, logger), logger, cfg)
+	if err != nil {
+		logger.Error("Failed to subscribe to cache invalidations; using Redis without an in-memory tier", "error", err)
+		return cache
+	}
+	logger.Info("Cache has an in-memory tier", "local_ttl", cfg.LocalTTL, "local_max_entries", cfg.LocalMaxEntries)
+	return tiered
+}
+
+// NewCacheOrNil returns the Redis cache, or an in-memory LRU cache when Redis
+// is not configured or cannot be reached at startup, so callers always get a
+// cache. Only the Redis cache is shared between instances; GetRedisClient
+// tells the two apart.
 func (cc *CacheConfig) NewCacheOrNil(logger *log.Logger) Cache {
 	if !cc.IsConfigured() {
-		logger.Info("Cache (Redis) is not configured; proceeding without external cache")
-		return nil
+		logger.Info("Cache (Redis) is not configured; using an in-memory cache")
+		return NewMemoryCache(logger)
 	}
 
 	cache, err := cc.NewCache(logger)
 
 	if err != nil {
-		// Log error but don't fail - allow fallback to in-memory
-		logger.Error("Failed to create Cache (Redis)", "error", err)
-		return nil
+		// Log error but don't fail - fall back to in-memory
+		logger.Error("Failed to create Cache (Redis); using an in-memory cache", "error", err)
+		return NewMemoryCache(logger)
 	}
 
 	return cache
 }
 
+// NewMemoryCache returns a process-local LRU cache holding up to
+// CACHE_MEMORY_MAX_ENTRIES keys (default 10000), each for at most
+// CACHE_MEMORY_MAX_TTL (unset: as long as it was set for).
+func NewMemoryCache(logger *log.Logger) *lrucache.LRUCache {
+	return lrucache.NewLRUCache(memoryCacheConfig(logger))
+}
+
+func memoryCacheConfig(logger *log.Logger) *lrucache.Config {
+	cfg := &lrucache.Config{MaxEntries: lrucache.DefaultMaxEntries}
+	if v := utils.GetEnvTrimmed("CACHE_MEMORY_MAX_ENTRIES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			cfg.MaxEntries = parsed
+		} else {
+			logger.Warn("Invalid CACHE_MEMORY_MAX_ENTRIES; using default", "value", v, "default", cfg.MaxEntries)
+		}
+	}
+	if v := utils.GetEnvTrimmed("CACHE_MEMORY_MAX_TTL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			cfg.MaxTTL = parsed
+		} else {
+			logger.Warn("Invalid CACHE_MEMORY_MAX_TTL; keys keep their own expiry", "value", v)
+		}
+	}
+	return cfg
+}
+
 func GetRedisClient(cache Cache) *redis.Client {
 	if cache == nil {
 		return nil
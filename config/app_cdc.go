@@ -0,0 +1,45 @@
+package config
+
+import (
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/cdc"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// archivedNote explains the deletes consumers see on the append-only tables.
+const archivedNote = "Rows are deleted only by the ledger archiver, which moves them to the archive tables; treat a delete as archival, not reversal."
+
+// CapturedTables lists the tables the migrations publish for change data
+// capture.
+var CapturedTables = []cdc.Table{
+	{Name: "accounts", Key: []string{"id"}, Sequence: "change_seq", Operations: []string{"insert", "update"}},
+	{Name: "transactions", Key: []string{"id"}, Sequence: "change_seq", Operations: []string{"insert", "delete"}, Note: archivedNote},
+	{Name: "ledger_entries", Key: []string{"id", "created_at"}, Sequence: "change_seq", Operations: []string{"insert", "delete"}, Note: archivedNote},
+	{Name: "holds", Key: []string{"id"}, Sequence: "change_seq", Operations: []string{"insert", "update"}},
+}
+
+// NewCDCInspector describes CapturedTables on the publication CDC_PUBLICATION
+// (default ledger_cdc, created by the migrations) read through the
+// replication slot CDC_SLOT (default ledger_cdc). It returns nil when the
+// schema is auto-migrated, which has neither the publication nor the
+// change_seq columns.
+func NewCDCInspector(logger *log.Logger, db *gorm.DB, autoMigrate bool) *cdc.Inspector {
+	if autoMigrate {
+		logger.Info("Schema is auto-migrated; change data capture is not set up")
+		return nil
+	}
+	cfg := cdc.Config{
+		Publication: "ledger_cdc",
+		Slot:        "ledger_cdc",
+		Plugin:      "pgoutput",
+		Tables:      CapturedTables,
+	}
+	if v := utils.GetEnvTrimmed("CDC_PUBLICATION"); v != "" {
+		cfg.Publication = v
+	}
+	if v := utils.GetEnvTrimmed("CDC_SLOT"); v != "" {
+		cfg.Slot = v
+	}
+	return cdc.NewInspector(db, cfg)
+}
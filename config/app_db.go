@@ -1,23 +1,62 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/migrations"
+	"github.com/akeren/go-api-foundry/pkg/probe"
+	"github.com/akeren/go-api-foundry/pkg/querycount"
+	"github.com/akeren/go-api-foundry/pkg/utils"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// DBConfig holds connection pool settings; a zero value keeps the
+// database/sql default.
 type DBConfig struct {
 	MaxIdleConns    int
 	MaxOpenConns    int
 	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 	SSLMode         string // Default: "require" for prod safety
 }
 
+// PoolFromEnv overrides the pool settings with the environment variables
+// prefix+MAX_OPEN_CONNS, MAX_IDLE_CONNS, CONN_MAX_LIFETIME and
+// CONN_MAX_IDLE_TIME, e.g. DATABASE_MAX_OPEN_CONNS for prefix "DATABASE_".
+func (cfg *DBConfig) PoolFromEnv(logger *log.Logger, prefix string) {
+	for env, dst := range map[string]*int{
+		prefix + "MAX_OPEN_CONNS": &cfg.MaxOpenConns,
+		prefix + "MAX_IDLE_CONNS": &cfg.MaxIdleConns,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid integer; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+	for env, dst := range map[string]*time.Duration{
+		prefix + "CONN_MAX_LIFETIME":  &cfg.ConnMaxLifetime,
+		prefix + "CONN_MAX_IDLE_TIME": &cfg.ConnMaxIdleTime,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid duration; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+}
+
 func NewDatabase(logger *log.Logger, cfg *DBConfig) (*gorm.DB, error) {
 	if cfg == nil {
 		cfg = &DBConfig{
@@ -35,28 +74,49 @@ func NewDatabase(logger *log.Logger, cfg *DBConfig) (*gorm.DB, error) {
 		return db, err
 	}
 
-	gdb, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	gdb, err := openPostgres(dsn, cfg)
 	if err != nil {
 		logger.Error("Failed to connect to database", "error", err)
+		return nil, err
+	}
+
+	logger.Info("Database connection established successfully")
+	return gdb, nil
+}
+
+// openPostgres connects to dsn with cfg's pool settings and pings it.
+func openPostgres(dsn string, cfg *DBConfig) (*gorm.DB, error) {
+	gdb, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	// Counts the statements each request runs; see router.QueryCountHeader.
+	if err := gdb.Use(querycount.Plugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register query counter: %w", err)
+	}
 
 	sqlDB, err := gdb.DB()
 	if err != nil {
-		logger.Error("Failed to get database instance", "error", err)
 		return nil, fmt.Errorf("failed to get database instance: %w", err)
 	}
 
-	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
 
 	if err := sqlDB.Ping(); err != nil {
-		logger.Error("Database ping failed", "error", err)
+		sqlDB.Close()
 		return nil, fmt.Errorf("database ping failed: %w", err)
 	}
-
-	logger.Info("Database connection established successfully")
 	return gdb, nil
 }
 
@@ -153,6 +213,32 @@ func AutoMigrate(logger *log.Logger, db *gorm.DB, models ...interface{}) error {
 	return nil
 }
 
+// NewMigrationCheck reports whether the versioned migrations in MIGRATIONS_DIR
+// have been applied, for /health/ready. It returns nil when the schema is
+// managed by --auto-migrate instead. Once the check passes it is not repeated,
+// since applied migrations stay applied.
+func NewMigrationCheck(db *gorm.DB, autoMigrate bool) probe.Check {
+	if autoMigrate {
+		return nil
+	}
+	cfg := migrations.Config{Dir: utils.GetEnvTrimmedOrDefault("MIGRATIONS_DIR", "migrations")}
+	var applied atomic.Bool
+	return func(ctx context.Context) error {
+		if applied.Load() {
+			return nil
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		if err := migrations.Check(ctx, sqlDB, cfg); err != nil {
+			return err
+		}
+		applied.Store(true)
+		return nil
+	}
+}
+
 func CloseDatabase(db *gorm.DB, logger *log.Logger) {
 	if db == nil {
 		return
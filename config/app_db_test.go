@@ -0,0 +1,32 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+)
+
+func TestDBConfig_PoolFromEnv(t *testing.T) {
+	t.Setenv("DATABASE_MAX_OPEN_CONNS", "50")
+	t.Setenv("DATABASE_MAX_IDLE_CONNS", "ten")
+	t.Setenv("DATABASE_CONN_MAX_LIFETIME", "30m")
+	t.Setenv("DATABASE_REPLICA_MAX_OPEN_CONNS", "200")
+	t.Setenv("DATABASE_REPLICA_CONN_MAX_IDLE_TIME", "1m")
+	logger := log.NewLoggerWithJSONOutput()
+
+	primary := &DBConfig{MaxIdleConns: 5}
+	primary.PoolFromEnv(logger, "DATABASE_")
+	want := DBConfig{MaxOpenConns: 50, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute}
+	if *primary != want {
+		t.Fatalf("primary: expected %+v, got %+v", want, *primary)
+	}
+
+	// The replica starts from the primary's settings.
+	replica := *primary
+	replica.PoolFromEnv(logger, "DATABASE_REPLICA_")
+	want = DBConfig{MaxOpenConns: 200, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute, ConnMaxIdleTime: time.Minute}
+	if replica != want {
+		t.Fatalf("replica: expected %+v, got %+v", want, replica)
+	}
+}
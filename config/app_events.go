@@ -0,0 +1,48 @@
+package config
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// NewEventBus creates the in-process event bus. When EVENTS_WEBHOOK_URL is
+// set, events matching EVENTS_WEBHOOK_TYPES (comma-separated patterns,
+// default all) are also POSTed there so external systems need not poll.
+func NewEventBus(logger *log.Logger) *events.Bus {
+	bus := events.NewBus(logger)
+
+	url := utils.GetEnvTrimmed("EVENTS_WEBHOOK_URL")
+	if url == "" {
+		return bus
+	}
+
+	webhook := events.Webhook(events.WebhookConfig{
+		URL:    url,
+		Secret: utils.GetEnvTrimmed("EVENTS_WEBHOOK_SECRET"),
+	}, logger)
+
+	patterns := []string{"*"}
+	if v := utils.GetEnvTrimmed("EVENTS_WEBHOOK_TYPES"); v != "" {
+		patterns = patterns[:0]
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				patterns = append(patterns, p)
+			}
+		}
+	}
+
+	// One subscription keeps deliveries in publish order and sends each event
+	// once even when several patterns match it.
+	bus.Subscribe("*", func(ctx context.Context, event events.Event) {
+		if slices.ContainsFunc(patterns, func(p string) bool { return events.Matches(p, event.Type) }) {
+			webhook(ctx, event)
+		}
+	})
+	logger.Info("Event webhook enabled", "types", patterns)
+	return bus
+}
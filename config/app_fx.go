@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/fx"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// NewFXUpdater keeps the fx_rates table current for cross-currency
+// transfers. Rates come from FX_RATES_URL, a rates API polled every
+// FX_REFRESH_INTERVAL (default 1h), or else from the fixed FX_RATES list
+// ("EUR/USD=1.08,GBP/USD=1.27"), which is written once at startup and again
+// on each interval. It returns nil when neither is set.
+func NewFXUpdater(logger *log.Logger, db *gorm.DB) (*fx.Updater, error) {
+	url := utils.GetEnvTrimmed("FX_RATES_URL")
+	static := utils.GetEnvTrimmed("FX_RATES")
+	if url == "" && static == "" {
+		return nil, nil
+	}
+
+	cfg := fx.DefaultUpdaterConfig()
+	for env, dst := range map[string]*time.Duration{
+		"FX_REFRESH_INTERVAL": &cfg.Interval,
+		"FX_TIMEOUT":          &cfg.Timeout,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid duration; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+
+	var provider fx.Provider
+	if url != "" {
+		if static != "" {
+			logger.Warn("Both FX_RATES_URL and FX_RATES set; using FX_RATES_URL")
+		}
+		provider = fx.NewHTTPProvider(url, cfg.Timeout)
+		logger.Info("Exchange rates fetched from FX_RATES_URL", "interval", cfg.Interval)
+	} else {
+		rates, err := fx.ParseRates(static)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FX_RATES: %w", err)
+		}
+		provider = rates
+		logger.Info("Exchange rates seeded from FX_RATES", "rates", len(rates))
+	}
+
+	return fx.NewUpdater(logger, provider, fx.NewGormStore(db), cfg), nil
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/goroutines"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// NewGoroutineSampler reports sustained goroutine growth when
+// GOROUTINE_SAMPLER_ENABLED is true, and returns nil otherwise.
+func NewGoroutineSampler(logger *log.Logger) *goroutines.Sampler {
+	enabled, err := strconv.ParseBool(utils.GetEnvTrimmed("GOROUTINE_SAMPLER_ENABLED"))
+	if err != nil || !enabled {
+		return nil
+	}
+
+	cfg := goroutines.DefaultConfig()
+	if v := utils.GetEnvTrimmed("GOROUTINE_SAMPLER_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			cfg.Interval = parsed
+		} else {
+			logger.Warn("Invalid GOROUTINE_SAMPLER_INTERVAL; using default", "value", v, "default", cfg.Interval)
+		}
+	}
+	for env, dst := range map[string]*int{
+		"GOROUTINE_SAMPLER_WINDOW":     &cfg.Window,
+		"GOROUTINE_SAMPLER_MIN_GROWTH": &cfg.MinGrowth,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid integer; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+	return goroutines.NewSampler(logger, cfg)
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/akeren/go-api-foundry/config/grpcserver"
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// NewGRPCServer creates the gRPC server listening on GRPC_PORT. Calls are
+// rate limited with RATE_LIMIT_REQUESTS per RATE_LIMIT_WINDOW per client IP,
+// in Redis with RATE_LIMIT_ALGORITHM when the cache is Redis, and served over
+// TLS with the HTTP server's TLS_CERT_FILE, TLS_KEY_FILE and client CA
+// settings when those are set. The Redis limiter falls back to counting in
+// memory while its breaker in breakers is open. It returns nil when
+// GRPC_PORT is not set.
+func NewGRPCServer(logger *log.Logger, appConfig *AppConfig, cache Cache, breakers *circuitbreaker.Registry) (*grpcserver.Server, error) {
+	port := utils.GetEnvTrimmed("GRPC_PORT")
+	if port == "" {
+		return nil, nil
+	}
+
+	cfg := grpcserver.DefaultConfig()
+	cfg.Addr = ":" + port
+	cfg.Tracing = utils.IsTracingEnabled()
+
+	cfg.RateLimiter = ratelimit.NewRateLimiter(&ratelimit.RateLimitConfig{
+		Requests:  appConfig.RateLimitRequests,
+		Window:    appConfig.RateLimitWindow,
+		Redis:     GetRedisClient(cache),
+		Logger:    logger,
+		Algorithm: appConfig.RateLimitAlgorithm,
+		Breaker:   breakers.Get(BreakerRedisRateLimit),
+	})
+
+	certFile := utils.GetEnvTrimmed("TLS_CERT_FILE")
+	keyFile := utils.GetEnvTrimmed("TLS_KEY_FILE")
+	if certFile != "" && keyFile != "" {
+		tlsConfig, err := router.TLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure gRPC TLS: %w", err)
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load gRPC TLS certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		cfg.TLS = tlsConfig
+	}
+
+	return grpcserver.New(logger, cfg), nil
+}
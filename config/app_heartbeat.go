@@ -0,0 +1,59 @@
+package config
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/heartbeat"
+	"github.com/akeren/go-api-foundry/pkg/probe"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// NewHeartbeat pings HEARTBEAT_URL every HEARTBEAT_INTERVAL (default 1m) while
+// the service is ready and every probed dependency is healthy. It returns nil
+// when HEARTBEAT_URL is not set.
+func NewHeartbeat(logger *log.Logger, rs *router.RouterService, probes *probe.Prober) *heartbeat.Sender {
+	url := utils.GetEnvTrimmed("HEARTBEAT_URL")
+	if url == "" {
+		return nil
+	}
+
+	cfg := heartbeat.DefaultConfig()
+	cfg.URL = url
+	switch method := strings.ToUpper(utils.GetEnvTrimmed("HEARTBEAT_METHOD")); method {
+	case "":
+	case http.MethodGet, http.MethodPost:
+		cfg.Method = method
+	default:
+		logger.Warn("Invalid HEARTBEAT_METHOD; using default", "value", method, "default", cfg.Method)
+	}
+	for env, dst := range map[string]*time.Duration{
+		"HEARTBEAT_INTERVAL": &cfg.Interval,
+		"HEARTBEAT_TIMEOUT":  &cfg.Timeout,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid duration; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+
+	return heartbeat.NewSender(logger, cfg, func() bool {
+		if rs != nil && !rs.IsReady() {
+			return false
+		}
+		if probes != nil {
+			for _, s := range probes.Snapshot() {
+				if s.Samples > 0 && !s.Healthy {
+					return false
+				}
+			}
+		}
+		return true
+	})
+}
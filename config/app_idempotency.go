@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/idempotency"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// DefaultIdempotencyTTL is how long responses are replayed for by default.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// NewIdempotencyStore keeps the responses replayed for retried
+// Idempotency-Key requests in Redis when the cache is Redis, and in the
+// idempotency_records table otherwise, for IDEMPOTENCY_TTL. It returns nil
+// when IDEMPOTENCY_ENABLED is false.
+func NewIdempotencyStore(logger *log.Logger, db *gorm.DB, cache Cache) (idempotency.Store, time.Duration) {
+	if v := utils.GetEnvTrimmed("IDEMPOTENCY_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil && !enabled {
+			logger.Info("Idempotency keys disabled (IDEMPOTENCY_ENABLED=false)")
+			return nil, 0
+		}
+	}
+
+	ttl := DefaultIdempotencyTTL
+	if v := utils.GetEnvTrimmed("IDEMPOTENCY_TTL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			ttl = parsed
+		} else {
+			logger.Warn("Invalid IDEMPOTENCY_TTL; using default", "value", v, "default", ttl)
+		}
+	}
+
+	if client := GetRedisClient(cache); client != nil {
+		logger.Info("Idempotency keys stored in Redis", "ttl", ttl)
+		return idempotency.NewRedisStore(client), ttl
+	}
+	logger.Info("Idempotency keys stored in the database", "ttl", ttl)
+	return idempotency.NewGormStore(db), ttl
+}
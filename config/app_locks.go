@@ -0,0 +1,17 @@
+package config
+
+import (
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/distlock"
+)
+
+// NewLocker keeps distributed locks in the Redis behind cache, so a
+// scheduled task runs on one replica at a time. Without Redis, locks are
+// kept in memory and only exclude runs within this process.
+func NewLocker(logger *log.Logger, cache Cache) distlock.Locker {
+	if client := GetRedisClient(cache); client != nil {
+		return distlock.NewRedisLocker(client)
+	}
+	logger.Info("Distributed locks are in memory; scheduled tasks run on every instance")
+	return distlock.NewMemoryLocker()
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
+	"github.com/akeren/go-api-foundry/pkg/mailer"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// NewMailer sends email through the provider named by MAIL_PROVIDER:
+// "smtp" (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD), "sendgrid"
+// (SENDGRID_API_KEY) or "log" for development, from MAIL_FROM. It returns
+// nil when MAIL_PROVIDER is not set, and an error when it is misconfigured.
+// The queue is started, and stops calling the provider while its breaker in
+// breakers is open.
+func NewMailer(logger *log.Logger, breakers *circuitbreaker.Registry) (*mailer.Queue, error) {
+	from := utils.GetEnvTrimmed("MAIL_FROM")
+
+	var sender mailer.Sender
+	switch provider := strings.ToLower(utils.GetEnvTrimmed("MAIL_PROVIDER")); provider {
+	case "":
+		logger.Info("Email disabled (MAIL_PROVIDER not set)")
+		return nil, nil
+	case "log":
+		sender = mailer.LogSender{Logger: logger, From: from}
+	case "smtp":
+		cfg := mailer.SMTPConfig{
+			Host:     utils.GetEnvTrimmed("SMTP_HOST"),
+			Username: utils.GetEnvTrimmed("SMTP_USERNAME"),
+			Password: utils.GetEnvTrimmed("SMTP_PASSWORD"),
+			From:     from,
+		}
+		if cfg.Host == "" {
+			return nil, fmt.Errorf("MAIL_PROVIDER=smtp needs SMTP_HOST")
+		}
+		if raw := utils.GetEnvTrimmed("SMTP_PORT"); raw != "" {
+			port, err := strconv.Atoi(raw)
+			if err != nil || port <= 0 || port > 65535 {
+				return nil, fmt.Errorf("invalid SMTP_PORT %q", raw)
+			}
+			cfg.Port = port
+		}
+		sender = mailer.NewSMTPSender(cfg)
+	case "sendgrid":
+		apiKey := utils.GetEnvTrimmed("SENDGRID_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("MAIL_PROVIDER=sendgrid needs SENDGRID_API_KEY")
+		}
+		sender = mailer.NewSendGridSender(mailer.SendGridConfig{APIKey: apiKey, From: from})
+	default:
+		return nil, fmt.Errorf("unknown MAIL_PROVIDER %q; use smtp, sendgrid or log", provider)
+	}
+	if from == "" {
+		logger.Warn("MAIL_FROM not set; emails without a sender will fail")
+	}
+
+	queue := mailer.NewQueue(sender, logger, mailer.QueueConfig{Breaker: breakers.Get(BreakerMailer)})
+	queue.Start()
+	logger.Info("Email enabled", "provider", utils.GetEnvTrimmed("MAIL_PROVIDER"))
+	return queue, nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/messaging"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// NewMessaging connects to the broker named by MESSAGE_BROKER: "kafka" (the
+// default) at KAFKA_BROKERS (comma-separated host:port), or "nats" for NATS
+// JetStream at NATS_URL. When MESSAGE_EVENTS_TOPIC is set, bus events
+// matching MESSAGE_EVENT_TYPES (comma-separated patterns, default all) are
+// published there. It returns nil when the chosen broker has no address.
+func NewMessaging(logger *log.Logger, bus *events.Bus) messaging.Broker {
+	var broker messaging.Broker
+	switch kind := strings.ToLower(utils.GetEnvTrimmed("MESSAGE_BROKER")); kind {
+	case "", "kafka":
+		brokers := splitList(utils.GetEnvTrimmed("KAFKA_BROKERS"))
+		if len(brokers) == 0 {
+			return nil
+		}
+		cfg := messaging.DefaultKafkaConfig()
+		cfg.Brokers = brokers
+		if v := utils.GetEnvTrimmed("KAFKA_CLIENT_ID"); v != "" {
+			cfg.ClientID = v
+		}
+		broker = messaging.NewKafka(logger, cfg)
+		logger.Info("Kafka messaging enabled", "brokers", brokers)
+	case "nats":
+		url := utils.GetEnvTrimmed("NATS_URL")
+		if url == "" {
+			return nil
+		}
+		cfg := messaging.DefaultNATSConfig()
+		cfg.URL = url
+		if v := utils.GetEnvTrimmed("NATS_CLIENT_NAME"); v != "" {
+			cfg.Name = v
+		}
+		nats, err := messaging.NewNATS(logger, cfg)
+		if err != nil {
+			logger.Error("Failed to set up NATS messaging; messaging disabled", "error", err)
+			return nil
+		}
+		broker = nats
+		logger.Info("NATS JetStream messaging enabled", "url", url)
+	default:
+		logger.Warn("Unknown MESSAGE_BROKER; messaging disabled", "value", kind)
+		return nil
+	}
+
+	topic := utils.GetEnvTrimmed("MESSAGE_EVENTS_TOPIC")
+	if topic == "" || bus == nil {
+		return broker
+	}
+	patterns := splitList(utils.GetEnvTrimmed("MESSAGE_EVENT_TYPES"))
+	if len(patterns) == 0 {
+		patterns = []string{"*"}
+	}
+	forward := messaging.Forward(broker, topic, logger)
+	bus.Subscribe("*", func(ctx context.Context, event events.Event) {
+		if slices.ContainsFunc(patterns, func(p string) bool { return events.Matches(p, event.Type) }) {
+			forward(ctx, event)
+		}
+	})
+	logger.Info("Publishing events to the message broker", "topic", topic, "types", patterns)
+	return broker
+}
+
+// splitList splits a comma-separated setting, dropping empty items.
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
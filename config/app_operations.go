@@ -0,0 +1,27 @@
+package config
+
+import (
+	"strconv"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/operations"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// NewOperationsManager runs long-running operations with state kept in the
+// operations table, so any instance can answer status polls.
+func NewOperationsManager(logger *log.Logger, db *gorm.DB) *operations.Manager {
+	cfg := operations.DefaultConfig()
+
+	if v := utils.GetEnvTrimmed("OPERATIONS_MAX_CONCURRENT"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			cfg.MaxConcurrent = parsed
+		} else {
+			logger.Warn("Invalid OPERATIONS_MAX_CONCURRENT; using default", "value", v, "default", cfg.MaxConcurrent)
+		}
+	}
+	cfg.WebhookSecret = utils.GetEnvTrimmed("OPERATIONS_WEBHOOK_SECRET")
+
+	return operations.NewManager(operations.NewGormStore(db), logger, cfg)
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/probe"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// NewDependencyProber pings the database and, when configured, the cache in
+// the background for /health/detailed. Domains can Register further
+// dependencies such as external providers. Call Start once registration is done.
+func NewDependencyProber(logger *log.Logger, db *gorm.DB, cache Cache) *probe.Prober {
+	cfg := probe.DefaultConfig()
+	for env, dst := range map[string]*time.Duration{
+		"HEALTH_PROBE_INTERVAL": &cfg.Interval,
+		"HEALTH_PROBE_TIMEOUT":  &cfg.Timeout,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid duration; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+	if v := utils.GetEnvTrimmed("HEALTH_PROBE_WINDOW"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			cfg.Window = parsed
+		} else {
+			logger.Warn("Invalid HEALTH_PROBE_WINDOW; using default", "value", v, "default", cfg.Window)
+		}
+	}
+
+	prober := probe.NewProber(logger, cfg)
+	prober.Register("database", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	if cache != nil {
+		prober.Register("cache", cache.Ping)
+	}
+	return prober
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/rbac"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// NewRoleStore serves role bindings from the role_bindings table through an
+// in-memory cache, so authorizing a request rarely touches the database.
+func NewRoleStore(logger *log.Logger, db *gorm.DB) *rbac.CachedStore {
+	ttl := rbac.DefaultCacheTTL
+	if v := utils.GetEnvTrimmed("RBAC_CACHE_TTL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			ttl = parsed
+		} else {
+			logger.Warn("Invalid RBAC_CACHE_TTL; using default", "value", v, "default", ttl)
+		}
+	}
+	return rbac.NewCachedStore(rbac.NewGormStore(db), ttl)
+}
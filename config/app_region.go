@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// NewRegion reads the region this instance runs in from REGION, its name, and
+// REGION_CODE, the number between 1 and 4095 embedded in the IDs it
+// generates. Every region of a deployment needs a distinct name and code. It
+// returns nil when REGION is not set.
+func NewRegion(logger *log.Logger) (*region.Region, error) {
+	name := utils.GetEnvTrimmed("REGION")
+	if name == "" {
+		return nil, nil
+	}
+	code, err := strconv.ParseUint(utils.GetEnvTrimmed("REGION_CODE"), 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("REGION_CODE must be a number between 1 and %d when REGION is set", region.MaxCode)
+	}
+	r, err := region.New(name, uint16(code))
+	if err != nil {
+		return nil, fmt.Errorf("invalid region configuration: %w", err)
+	}
+	logger.Info("Region configured", "region", r.Name, "code", r.Code)
+	return r, nil
+}
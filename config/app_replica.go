@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/replica"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// NewReplicaMonitor connects to the read replica at APP_DATABASE_REPLICA_URL
+// and measures how far it trails primary every
+// DATABASE_REPLICA_CHECK_INTERVAL (default 5s). Reads that must see recent
+// writes fall back to the primary while the lag exceeds
+// DATABASE_REPLICA_MAX_LAG (default 1s). The replica's pool takes cfg's
+// settings unless DATABASE_REPLICA_MAX_OPEN_CONNS and the like override them.
+// It returns nil when APP_DATABASE_REPLICA_URL is not set.
+func NewReplicaMonitor(logger *log.Logger, primary *gorm.DB, cfg *DBConfig) (*replica.Monitor, error) {
+	dsn := sanitizeEnv(utils.GetEnvTrimmed("APP_DATABASE_REPLICA_URL"))
+	if dsn == "" {
+		return nil, nil
+	}
+
+	monitorCfg := replica.DefaultConfig()
+	for env, dst := range map[string]*time.Duration{
+		"DATABASE_REPLICA_CHECK_INTERVAL": &monitorCfg.Interval,
+		"DATABASE_REPLICA_CHECK_TIMEOUT":  &monitorCfg.Timeout,
+		"DATABASE_REPLICA_MAX_LAG":        &monitorCfg.MaxLag,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid duration; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+
+	replicaCfg := *cfg
+	replicaCfg.PoolFromEnv(logger, "DATABASE_REPLICA_")
+	replicaDB, err := openPostgres(dsn, &replicaCfg)
+	if err != nil {
+		logger.Error("Failed to connect to database replica", "error", err)
+		return nil, fmt.Errorf("replica: %w", err)
+	}
+	primarySQL, err := primary.DB()
+	if err != nil {
+		return nil, err
+	}
+	replicaSQL, err := replicaDB.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Database replica connected", "max_lag", monitorCfg.MaxLag)
+	measure := replica.PostgresReadAfterWrite(primarySQL, replicaSQL)
+	return replica.NewMonitor(logger, primary, replicaDB, measure, monitorCfg), nil
+}
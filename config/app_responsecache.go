@@ -0,0 +1,31 @@
+package config
+
+import (
+	"strconv"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/respcache"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// NewResponseCache keeps the responses of routes decorated with
+// router.CacheFor in Redis. It returns nil, leaving those routes uncached,
+// when the cache is not Redis or RESPONSE_CACHE_ENABLED is false: a cache
+// in one instance's memory would keep serving what another instance's
+// writes evicted.
+func NewResponseCache(logger *log.Logger, cache Cache) respcache.Store {
+	if v := utils.GetEnvTrimmed("RESPONSE_CACHE_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil && !enabled {
+			logger.Info("Response cache disabled (RESPONSE_CACHE_ENABLED=false)")
+			return nil
+		}
+	}
+
+	client := GetRedisClient(cache)
+	if client == nil {
+		logger.Info("Response cache disabled (no Redis cache)")
+		return nil
+	}
+	logger.Info("Response cache stored in Redis")
+	return respcache.NewRedisStore(client)
+}
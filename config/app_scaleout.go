@@ -0,0 +1,91 @@
+package config
+
+import (
+	"github.com/akeren/go-api-foundry/pkg/distlock"
+	"github.com/akeren/go-api-foundry/pkg/lrucache"
+	"github.com/akeren/go-api-foundry/pkg/scaleout"
+	"github.com/akeren/go-api-foundry/pkg/search"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// ScaleOutReport describes where each component keeps its state and, when
+// APP_REPLICAS is above one, which of them are not shared between replicas.
+// Uploads on local disk count as shared when UPLOAD_DIR_SHARED=true says
+// UPLOAD_DIR is a volume every replica mounts.
+func (ac *ApplicationConfig) ScaleOutReport() scaleout.Report {
+	var components []scaleout.Component
+	if ac.RouterService != nil {
+		components = ac.RouterService.ScaleOutComponents()
+	}
+
+	switch ac.Cache.(type) {
+	case nil:
+	case *lrucache.LRUCache:
+		components = append(components, scaleout.Component{
+			Name:    "cache",
+			Backend: "memory",
+			Impact:  "each replica caches on its own, so a value written or deleted on one replica is not seen by the others",
+		})
+	default:
+		components = append(components, scaleout.Component{Name: "cache", Backend: "redis", Shared: true})
+	}
+
+	switch ac.Locks.(type) {
+	case nil:
+	case *distlock.MemoryLocker:
+		components = append(components, scaleout.Component{
+			Name:    "locks",
+			Backend: "memory",
+			Impact:  "every replica runs the scheduled tasks, such as the hold expiry sweep, that should run on one",
+		})
+	default:
+		components = append(components, scaleout.Component{Name: "locks", Backend: "redis", Shared: true})
+	}
+
+	switch ac.Search.(type) {
+	case nil:
+	case *search.MemoryIndexer:
+		components = append(components, scaleout.Component{
+			Name:    "search",
+			Backend: "memory",
+			Impact:  "each replica only indexes the transactions it posted",
+		})
+	default:
+		components = append(components, scaleout.Component{Name: "search", Backend: "search engine", Shared: true})
+	}
+
+	if ac.Uploads != nil {
+		uploads := scaleout.Component{Name: "uploads", Backend: "disk", Shared: utils.GetEnvTrimmed("UPLOAD_DIR_SHARED") == "true"}
+		if !uploads.Shared {
+			uploads.Impact = "every chunk of an upload must reach the replica holding its file; set UPLOAD_DIR_SHARED=true if UPLOAD_DIR is a shared volume"
+		}
+		components = append(components, uploads)
+	}
+
+	replicas := 1
+	if ac.Config != nil {
+		replicas = ac.Config.Replicas
+	}
+	return scaleout.Audit(replicas, components)
+}
+
+// AuditScaleOut logs the scale-out report once the domains are set up: one
+// warning per component that is not shared when APP_REPLICAS is above one.
+func (ac *ApplicationConfig) AuditScaleOut() scaleout.Report {
+	report := ac.ScaleOutReport()
+	if report.Ready {
+		ac.Logger.Info("Scale-out audit passed", "replicas", report.Replicas, "components", len(report.Components))
+		return report
+	}
+	for _, component := range report.Components {
+		if !component.Shared {
+			ac.Logger.Warn("Component state is not shared between replicas",
+				"component", component.Name,
+				"backend", component.Backend,
+				"impact", component.Impact,
+				"replicas", report.Replicas,
+			)
+		}
+	}
+	return report
+}
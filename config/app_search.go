@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/search"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const defaultSearchIndex = "documents"
+
+// NewSearch connects to the search engine named by SEARCH_BACKEND:
+// "opensearch" or "meilisearch" at SEARCH_URL, with documents in
+// SEARCH_INDEX, or "memory" for development. OpenSearch authenticates with
+// SEARCH_USERNAME and SEARCH_PASSWORD, Meilisearch with SEARCH_API_KEY. It
+// returns nil when SEARCH_BACKEND is not set, and an error when it is
+// misconfigured. An engine that cannot be set up at startup is only logged:
+// indexing and queries fail until it is reachable.
+func NewSearch(logger *log.Logger) (search.Indexer, error) {
+	index := utils.GetEnvTrimmed("SEARCH_INDEX")
+	if index == "" {
+		index = defaultSearchIndex
+	}
+
+	var setup func(context.Context) error
+	var indexer search.Indexer
+	switch backend := strings.ToLower(utils.GetEnvTrimmed("SEARCH_BACKEND")); backend {
+	case "":
+		logger.Info("Search disabled (SEARCH_BACKEND not set)")
+		return nil, nil
+	case "memory":
+		logger.Warn("Search index is in memory; it is lost on restart and not shared between instances")
+		return search.NewMemoryIndexer(), nil
+	case "opensearch":
+		opensearch, err := search.NewOpenSearchIndexer(search.OpenSearchConfig{
+			URL:      utils.GetEnvTrimmed("SEARCH_URL"),
+			Index:    index,
+			Username: utils.GetEnvTrimmed("SEARCH_USERNAME"),
+			Password: utils.GetEnvTrimmed("SEARCH_PASSWORD"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		indexer, setup = opensearch, opensearch.EnsureIndex
+	case "meilisearch":
+		meilisearch, err := search.NewMeilisearchIndexer(search.MeilisearchConfig{
+			URL:    utils.GetEnvTrimmed("SEARCH_URL"),
+			Index:  index,
+			APIKey: utils.GetEnvTrimmed("SEARCH_API_KEY"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		indexer, setup = meilisearch, meilisearch.EnsureIndex
+	default:
+		return nil, fmt.Errorf("unknown SEARCH_BACKEND %q; use opensearch, meilisearch or memory", backend)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := setup(ctx); err != nil {
+		logger.Error("Failed to set up the search index", "index", index, "error", err)
+	} else {
+		logger.Info("Search enabled", "index", index)
+	}
+	return indexer, nil
+}
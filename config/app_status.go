@@ -0,0 +1,22 @@
+package config
+
+import (
+	"strconv"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/status"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// NewStatusRecorder keeps the health-check history behind /status in the
+// database. It returns nil when STATUS_PAGE_ENABLED is false.
+func NewStatusRecorder(logger *log.Logger, db *gorm.DB) *status.Recorder {
+	if v := utils.GetEnvTrimmed("STATUS_PAGE_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil && !enabled {
+			logger.Info("Status page disabled (STATUS_PAGE_ENABLED=false)")
+			return nil
+		}
+	}
+	return status.NewRecorder(status.NewGormStore(db), logger, status.DefaultConfig())
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/uploads"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// NewUploadStore returns the resumable upload store, or nil when UPLOAD_DIR
+// is not set and uploads are disabled.
+func NewUploadStore(logger *log.Logger) (*uploads.FileStore, uploads.Config, error) {
+	cfg := uploads.DefaultConfig()
+
+	dir := utils.GetEnvTrimmed("UPLOAD_DIR")
+	if dir == "" {
+		logger.Info("Resumable uploads disabled (UPLOAD_DIR not set)")
+		return nil, cfg, nil
+	}
+
+	if v := utils.GetEnvTrimmed("UPLOAD_MAX_SIZE"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil && parsed > 0 {
+			cfg.MaxSize = parsed
+		} else {
+			logger.Warn("Invalid UPLOAD_MAX_SIZE; using default", "value", v, "default", cfg.MaxSize)
+		}
+	}
+	if v := utils.GetEnvTrimmed("UPLOAD_EXPIRY"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			cfg.Expiry = parsed
+		} else {
+			logger.Warn("Invalid UPLOAD_EXPIRY; using default", "value", v, "default", cfg.Expiry.String())
+		}
+	}
+
+	store, err := uploads.NewFileStore(dir)
+	if err != nil {
+		return nil, cfg, err
+	}
+	return store, cfg, nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/matview"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// MaterializedViews lists the views created by the migrations that the
+// refresher keeps current.
+var MaterializedViews = []matview.View{
+	// Daily credits, debits and closing balance per account, for reports.
+	{Name: "account_daily_balances"},
+}
+
+// NewViewRefresher refreshes MaterializedViews every VIEW_REFRESH_INTERVAL
+// (default 5m), bounding each refresh by VIEW_REFRESH_TIMEOUT (default 2m).
+// It returns nil when the schema is auto-migrated, which creates no views.
+func NewViewRefresher(logger *log.Logger, db *gorm.DB, autoMigrate bool) *matview.Refresher {
+	if autoMigrate {
+		logger.Warn("Schema is auto-migrated; materialized views and the reports that read them are unavailable")
+		return nil
+	}
+
+	cfg := matview.DefaultRefresherConfig()
+	for env, dst := range map[string]*time.Duration{
+		"VIEW_REFRESH_INTERVAL": &cfg.Interval,
+		"VIEW_REFRESH_TIMEOUT":  &cfg.Timeout,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid duration; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+	return matview.NewRefresher(logger, db, MaterializedViews, cfg)
+}
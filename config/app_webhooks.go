@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/fieldcrypt"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/akeren/go-api-foundry/pkg/webhooks"
+	"gorm.io/gorm"
+)
+
+// NewWebhookDispatcher delivers bus events to the webhook endpoints operators
+// register through the admin API. Endpoint secrets are encrypted with cipher
+// when it is set. Each receiver host has a breaker in breakers. It returns
+// nil when WEBHOOKS_ENABLED is false or there is no bus.
+func NewWebhookDispatcher(logger *log.Logger, db *gorm.DB, cipher *fieldcrypt.Cipher, bus *events.Bus, breakers *circuitbreaker.Registry) *webhooks.Dispatcher {
+	if v := utils.GetEnvTrimmed("WEBHOOKS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil && !enabled {
+			logger.Info("Webhooks disabled (WEBHOOKS_ENABLED=false)")
+			return nil
+		}
+	}
+	if bus == nil {
+		return nil
+	}
+
+	cfg := webhooks.DefaultConfig()
+	cfg.Breakers = breakers
+	for env, dst := range map[string]*int{
+		"WEBHOOK_MAX_ATTEMPTS":  &cfg.MaxAttempts,
+		"WEBHOOK_MAX_IN_FLIGHT": &cfg.MaxInFlight,
+		"WEBHOOK_MAX_PER_HOST":  &cfg.MaxPerHost,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid integer; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+	for env, dst := range map[string]*time.Duration{
+		"WEBHOOK_TIMEOUT":           &cfg.Timeout,
+		"WEBHOOK_POLL_INTERVAL":     &cfg.PollInterval,
+		"WEBHOOK_RETRY_INITIAL":     &cfg.Backoff.Initial,
+		"WEBHOOK_RETRY_MAX":         &cfg.Backoff.Max,
+		"WEBHOOK_IDLE_CONN_TIMEOUT": &cfg.IdleConnTimeout,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid duration; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+
+	dispatcher := webhooks.NewDispatcher(webhooks.NewGormStore(db, cipher), logger, cfg)
+	bus.Subscribe("*", dispatcher.Handle)
+	return dispatcher
+}
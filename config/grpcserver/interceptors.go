@@ -0,0 +1,167 @@
+package grpcserver
+
+import (
+	"context"
+	"math"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// CorrelationIDKey is the metadata key carrying the correlation ID, the gRPC
+// counterpart of the X-Correlation-ID header.
+const CorrelationIDKey = "x-correlation-id"
+
+func (s *Server) recoveryUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer s.recoverPanic(ctx, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+func (s *Server) recoveryStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer s.recoverPanic(ss.Context(), info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
+
+// recoverPanic turns a panicking handler into an Internal error, so one bad
+// call cannot take the process down.
+func (s *Server) recoverPanic(ctx context.Context, method string, err *error) {
+	if r := recover(); r != nil {
+		log.GetLoggerInstanceFromContext(ctx, s.logger).Error("gRPC handler panicked",
+			"method", method, "panic", r, "stack", string(debug.Stack()))
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+func (s *Server) correlationUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(s.correlate(ctx), req)
+	}
+}
+
+func (s *Server) correlationStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextStream{ServerStream: ss, ctx: s.correlate(ss.Context())})
+	}
+}
+
+// correlate takes the caller's correlation ID or generates one, echoes it in
+// the response header and puts it and a correlated logger on the context.
+func (s *Server) correlate(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(CorrelationIDKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = log.GenerateCorrelationID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(CorrelationIDKey, id))
+
+	ctx = context.WithValue(ctx, log.CorrelatedIDKey, id)
+	return context.WithValue(ctx, log.LoggerKeyForContext, s.logger.WithCorrelationID(ctx))
+}
+
+func (s *Server) loggingUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		s.logCall(ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func (s *Server) loggingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		s.logCall(ss.Context(), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func (s *Server) logCall(ctx context.Context, method string, start time.Time, err error) {
+	log.GetLoggerInstanceFromContext(ctx, s.logger).Info("gRPC request",
+		"method", method,
+		"code", status.Code(err).String(),
+		"latency_ms", time.Since(start).Milliseconds(),
+		"remote_addr", peerIP(ctx),
+	)
+}
+
+func (s *Server) rateLimitUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := s.checkRateLimit(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (s *Server) rateLimitStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := s.checkRateLimit(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// checkRateLimit applies the limiter per client IP. As over HTTP, a limiter
+// error lets the call through rather than failing healthy traffic.
+func (s *Server) checkRateLimit(ctx context.Context) error {
+	limiter := s.cfg.RateLimiter
+	if limiter == nil {
+		return nil
+	}
+
+	clientIP := peerIP(ctx)
+	decision, err := limiter.IsLimited("ratelimit:grpc:" + clientIP)
+	if err != nil {
+		s.logger.Error("Rate limiter error", "error", err, "client_ip", clientIP)
+		return nil
+	}
+	if !decision.Limited {
+		return nil
+	}
+
+	s.logger.Warn("Rate limit exceeded", "client_ip", clientIP, "transport", "grpc")
+	retryAfter := max(int(math.Ceil(decision.RetryAfter.Seconds())), 1)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+	return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+}
+
+// peerIP returns the caller's IP, or its full address when it has no port.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// contextStream replaces a stream's context.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
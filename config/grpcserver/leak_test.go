@@ -0,0 +1,11 @@
+package grpcserver
+
+import (
+	"testing"
+
+	"github.com/akeren/go-api-foundry/internal/testkit"
+)
+
+func TestMain(m *testing.M) {
+	testkit.VerifyTestMain(m)
+}
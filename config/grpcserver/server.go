@@ -0,0 +1,141 @@
+// Package grpcserver runs a gRPC server next to the HTTP router for internal
+// callers. Every call passes through the same concerns as an HTTP request:
+// panic recovery, correlation IDs, a correlated logger, request logging, rate
+// limiting and, when enabled, OpenTelemetry tracing.
+package grpcserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type Config struct {
+	Addr string
+	// RateLimiter limits calls per client IP; nil disables rate limiting.
+	// The server closes it on Shutdown.
+	RateLimiter ratelimit.RateLimiter
+	// TLS serves over TLS when set; client certificates are verified as
+	// it specifies.
+	TLS *tls.Config
+	// Tracing records a span per call with the global tracer provider.
+	Tracing bool
+}
+
+func DefaultConfig() Config {
+	return Config{Addr: ":9090"}
+}
+
+// Server is a gRPC server with the shared interceptors installed. It also
+// serves the standard grpc.health.v1 service.
+type Server struct {
+	cfg    Config
+	logger *log.Logger
+	server *grpc.Server
+	health *health.Server
+
+	closeOnce sync.Once
+}
+
+func New(logger *log.Logger, cfg Config) *Server {
+	if cfg.Addr == "" {
+		cfg.Addr = DefaultConfig().Addr
+	}
+
+	s := &Server{cfg: cfg, logger: logger, health: health.NewServer()}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			s.recoveryUnaryInterceptor(),
+			s.correlationUnaryInterceptor(),
+			s.loggingUnaryInterceptor(),
+			s.rateLimitUnaryInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			s.recoveryStreamInterceptor(),
+			s.correlationStreamInterceptor(),
+			s.loggingStreamInterceptor(),
+			s.rateLimitStreamInterceptor(),
+		),
+	}
+	if cfg.TLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg.TLS)))
+	}
+	if cfg.Tracing {
+		opts = append(opts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	}
+
+	s.server = grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(s.server, s.health)
+	return s
+}
+
+// RegisterService registers a generated service implementation, so Server can
+// be passed to the generated Register*Server functions. Services must be
+// registered before Run.
+func (s *Server) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	s.server.RegisterService(desc, impl)
+	s.health.SetServingStatus(desc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	s.logger.Info("gRPC service registered", "service", desc.ServiceName)
+}
+
+// Run listens on the configured address and serves until Shutdown.
+func (s *Server) Run() error {
+	lis, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
+	return s.Serve(lis)
+}
+
+// Serve serves on lis until Shutdown.
+func (s *Server) Serve(lis net.Listener) error {
+	s.logger.Info("Starting gRPC server", "addr", lis.Addr().String(), "tls", s.cfg.TLS != nil)
+	if err := s.server.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		s.logger.Error("Failed to start gRPC server", "error", err)
+		return fmt.Errorf("failed to start gRPC server: %w", err)
+	}
+	return nil
+}
+
+// Shutdown reports NOT_SERVING to health checks and waits for in-flight calls
+// to finish. When ctx ends first the remaining calls are cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.logger.Info("Shutting down gRPC server gracefully...")
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.server.Stop()
+		<-done
+		err = ctx.Err()
+	}
+
+	s.closeOnce.Do(func() {
+		if s.cfg.RateLimiter != nil {
+			if closeErr := s.cfg.RateLimiter.Close(); closeErr != nil {
+				s.logger.Error("Failed to close gRPC rate limiter", "error", closeErr)
+			}
+		}
+	})
+	return err
+}
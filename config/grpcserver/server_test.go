@@ -0,0 +1,179 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// probeService is a hand-written service taking and returning Empty: Echo
+// succeeds and Panic panics.
+var probeService = grpc.ServiceDesc{
+	ServiceName: "grpcserver.test.Probe",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Echo", Handler: probeHandler("Echo", func() {})},
+		{MethodName: "Panic", Handler: probeHandler("Panic", func() { panic("boom") })},
+	},
+}
+
+func probeHandler(method string, fn func()) grpc.MethodHandler {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := new(emptypb.Empty)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		handler := func(context.Context, any) (any, error) {
+			fn()
+			return new(emptypb.Empty), nil
+		}
+		return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/grpcserver.test.Probe/" + method}, handler)
+	}
+}
+
+func startServer(t *testing.T, cfg Config) *grpc.ClientConn {
+	t.Helper()
+	logger := &log.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	server := New(logger, cfg)
+	server.RegisterService(&probeService, struct{}{})
+
+	lis := bufconn.Listen(1 << 20)
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(lis) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+		if err := <-served; err != nil {
+			t.Errorf("serve: %v", err)
+		}
+	})
+	return conn
+}
+
+func TestCorrelationID(t *testing.T) {
+	conn := startServer(t, DefaultConfig())
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), CorrelationIDKey, "corr-123")
+	var header metadata.MD
+	if err := conn.Invoke(ctx, "/grpcserver.test.Probe/Echo", &emptypb.Empty{}, &emptypb.Empty{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("Echo: %v", err)
+	}
+	if got := header.Get(CorrelationIDKey); len(got) != 1 || got[0] != "corr-123" {
+		t.Fatalf("expected the caller's correlation ID to be echoed, got %v", got)
+	}
+
+	header = nil
+	if err := conn.Invoke(context.Background(), "/grpcserver.test.Probe/Echo", &emptypb.Empty{}, &emptypb.Empty{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("Echo: %v", err)
+	}
+	if got := header.Get(CorrelationIDKey); len(got) != 1 || got[0] == "" {
+		t.Fatalf("expected a generated correlation ID, got %v", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	conn := startServer(t, Config{RateLimiter: ratelimit.NewInMemoryRateLimiter(1, time.Minute)})
+
+	if err := conn.Invoke(context.Background(), "/grpcserver.test.Probe/Echo", &emptypb.Empty{}, &emptypb.Empty{}); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	var header metadata.MD
+	err := conn.Invoke(context.Background(), "/grpcserver.test.Probe/Echo", &emptypb.Empty{}, &emptypb.Empty{}, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	if got := header.Get("retry-after"); len(got) != 1 || got[0] != "60" {
+		t.Fatalf("expected retry-after 60, got %v", got)
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	conn := startServer(t, DefaultConfig())
+
+	err := conn.Invoke(context.Background(), "/grpcserver.test.Probe/Panic", &emptypb.Empty{}, &emptypb.Empty{})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected Internal, got %v", err)
+	}
+	// The server keeps serving.
+	if err := conn.Invoke(context.Background(), "/grpcserver.test.Probe/Echo", &emptypb.Empty{}, &emptypb.Empty{}); err != nil {
+		t.Fatalf("Echo after panic: %v", err)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	conn := startServer(t, DefaultConfig())
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: probeService.ServiceName})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %v", resp.GetStatus())
+	}
+}
+
+func TestCodeFromHTTPStatus(t *testing.T) {
+	tests := map[int]codes.Code{
+		http.StatusBadRequest:          codes.InvalidArgument,
+		http.StatusUnauthorized:        codes.Unauthenticated,
+		http.StatusForbidden:           codes.PermissionDenied,
+		http.StatusNotFound:            codes.NotFound,
+		http.StatusConflict:            codes.FailedPrecondition,
+		http.StatusMisdirectedRequest:  codes.FailedPrecondition,
+		http.StatusTooManyRequests:     codes.ResourceExhausted,
+		http.StatusServiceUnavailable:  codes.Unavailable,
+		http.StatusInternalServerError: codes.Internal,
+		http.StatusTeapot:              codes.Unknown,
+	}
+	for httpStatus, want := range tests {
+		if got := CodeFromHTTPStatus(httpStatus); got != want {
+			t.Errorf("CodeFromHTTPStatus(%d) = %v, want %v", httpStatus, got, want)
+		}
+	}
+}
+
+func TestInvalidArgument(t *testing.T) {
+	err := InvalidArgument("Invalid request payload", []apperrors.ValidationErrorResponse{{Field: "amount", Message: "Must be greater than 0"}})
+
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument || st.Message() != "Invalid request payload" {
+		t.Fatalf("unexpected status %v", st)
+	}
+	details := st.Details()
+	if len(details) != 1 {
+		t.Fatalf("expected one detail, got %v", details)
+	}
+	badRequest, ok := details[0].(*errdetails.BadRequest)
+	if !ok || len(badRequest.GetFieldViolations()) != 1 || badRequest.GetFieldViolations()[0].GetField() != "amount" {
+		t.Fatalf("unexpected detail %v", details[0])
+	}
+}
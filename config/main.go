@@ -2,30 +2,127 @@ package config
 
 import (
 	"context"
+	"errors"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/akeren/go-api-foundry/config/grpcserver"
 	"github.com/akeren/go-api-foundry/config/router"
 	"github.com/akeren/go-api-foundry/internal/log"
 	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/analytics"
+	"github.com/akeren/go-api-foundry/pkg/cdc"
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
 	"github.com/akeren/go-api-foundry/pkg/constants"
+	"github.com/akeren/go-api-foundry/pkg/distlock"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/fieldcrypt"
+	"github.com/akeren/go-api-foundry/pkg/fx"
+	"github.com/akeren/go-api-foundry/pkg/goroutines"
+	"github.com/akeren/go-api-foundry/pkg/heartbeat"
+	"github.com/akeren/go-api-foundry/pkg/mailer"
+	"github.com/akeren/go-api-foundry/pkg/matview"
+	"github.com/akeren/go-api-foundry/pkg/messaging"
+	"github.com/akeren/go-api-foundry/pkg/operations"
+	"github.com/akeren/go-api-foundry/pkg/probe"
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+	"github.com/akeren/go-api-foundry/pkg/rbac"
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"github.com/akeren/go-api-foundry/pkg/replica"
+	"github.com/akeren/go-api-foundry/pkg/search"
+	"github.com/akeren/go-api-foundry/pkg/shutdown"
+	"github.com/akeren/go-api-foundry/pkg/status"
+	"github.com/akeren/go-api-foundry/pkg/uploads"
+	"github.com/akeren/go-api-foundry/pkg/webhooks"
 	"gorm.io/gorm"
 )
 
 type ApplicationConfig struct {
-	DB              *gorm.DB
+	DB *gorm.DB
+	// Replica serves reads while it keeps up with DB; nil when
+	// APP_DATABASE_REPLICA_URL is not set.
+	Replica         *replica.Monitor
 	RouterService   *router.RouterService
 	Logger          *log.Logger
 	Cache           Cache
 	Config          *AppConfig
 	TracingShutdown func(context.Context) error
+	// FieldCipher encrypts sensitive free-text columns; nil when not configured.
+	FieldCipher *fieldcrypt.Cipher
+	Operations  *operations.Manager
+	// Uploads stores resumable uploads; nil when UPLOAD_DIR is not set.
+	Uploads      uploads.Store
+	UploadConfig uploads.Config
+	// Roles holds role bindings granted on top of credential roles.
+	Roles rbac.Store
+	// Events carries domain events to in-process subscribers and the
+	// EVENTS_WEBHOOK_URL subscriber.
+	Events *events.Bus
+	// Probes checks dependencies in the background for /health/detailed.
+	Probes *probe.Prober
+	// Status records probe results for the /status page; nil when disabled.
+	Status *status.Recorder
+	// Heartbeat pings an external uptime monitor; nil when not configured.
+	Heartbeat *heartbeat.Sender
+	// Goroutines reports sustained goroutine growth; nil when disabled.
+	Goroutines *goroutines.Sampler
+	// Migrations reports whether versioned migrations are applied, for
+	// /health/ready; nil when the schema is auto-migrated.
+	Migrations probe.Check
+	// Webhooks delivers events to endpoints registered through the admin
+	// API; nil when disabled.
+	Webhooks *webhooks.Dispatcher
+	// Messaging publishes to and consumes from Kafka or NATS JetStream,
+	// chosen by MESSAGE_BROKER; nil when the broker has no address.
+	Messaging messaging.Broker
+	// GRPCServer serves internal callers next to the HTTP router; nil when
+	// GRPC_PORT is not set.
+	GRPCServer *grpcserver.Server
+	// FX keeps the fx_rates table used by cross-currency transfers current;
+	// nil when neither FX_RATES_URL nor FX_RATES is set.
+	FX *fx.Updater
+	// Views refreshes the materialized views behind the reporting endpoints;
+	// nil when the schema is auto-migrated.
+	Views *matview.Refresher
+	// CDC describes the change data capture publication for the admin API;
+	// nil when the schema is auto-migrated.
+	CDC *cdc.Inspector
+	// Analytics buffers product analytics events for their sink, and
+	// AnalyticsSchemas validates them; both nil when ANALYTICS_SCHEMAS_FILE
+	// is not set.
+	Analytics        *analytics.Buffer
+	AnalyticsSchemas *analytics.Registry
+	// Search indexes documents for full-text search; nil when SEARCH_BACKEND
+	// is not set.
+	Search search.Indexer
+	// Mailer queues email for the provider chosen by MAIL_PROVIDER; nil when
+	// it is not set.
+	Mailer *mailer.Queue
+	// Locks lets one replica at a time run a scheduled task; see NewLocker.
+	Locks distlock.Locker
+	// Breakers guards Redis, the mail provider and webhook receivers; nil
+	// when CIRCUIT_BREAKERS_ENABLED is false.
+	Breakers *circuitbreaker.Registry
+
+	hooks     *shutdown.Manager
+	hooksOnce sync.Once
+	selfTests []selfTest
 }
 
 type AppConfig struct {
 	RateLimitRequests int
 	RateLimitWindow   time.Duration
-	RequestTimeout    time.Duration
+	// RateLimitAlgorithm selects the Redis limiter (RATE_LIMIT_ALGORITHM).
+	RateLimitAlgorithm ratelimit.Algorithm
+	RequestTimeout     time.Duration
+	// ShutdownDrainDelay is how long /health/ready fails before the server
+	// stops accepting connections, so load balancers can deregister it.
+	ShutdownDrainDelay time.Duration
+	// Replicas is how many instances the deployment runs; the scale-out
+	// audit warns about per-instance state when it is above one.
+	Replicas int
 }
 
 func NewAppConfig() *AppConfig {
@@ -33,6 +130,7 @@ func NewAppConfig() *AppConfig {
 		RateLimitRequests: constants.DefaultRateLimitRequests,
 		RateLimitWindow:   constants.DefaultRateLimitWindow(),
 		RequestTimeout:    30 * time.Second, // Default request timeout
+		Replicas:          1,
 	}
 
 	// Override from environment variables
@@ -48,37 +146,230 @@ func NewAppConfig() *AppConfig {
 		}
 	}
 
+	if algorithm, err := ratelimit.ParseAlgorithm(os.Getenv("RATE_LIMIT_ALGORITHM")); err == nil {
+		config.RateLimitAlgorithm = algorithm
+	}
+
 	if timeoutStr := os.Getenv("REQUEST_TIMEOUT"); timeoutStr != "" {
 		if parsed, err := time.ParseDuration(timeoutStr); err == nil && parsed > 0 {
 			config.RequestTimeout = parsed
 		}
 	}
 
+	if drainStr := os.Getenv("SHUTDOWN_DRAIN_DELAY"); drainStr != "" {
+		if parsed, err := time.ParseDuration(drainStr); err == nil && parsed >= 0 {
+			config.ShutdownDrainDelay = parsed
+		}
+	}
+
+	if replicasStr := os.Getenv("APP_REPLICAS"); replicasStr != "" {
+		if parsed, err := strconv.Atoi(replicasStr); err == nil && parsed > 0 {
+			config.Replicas = parsed
+		}
+	}
+
 	return config
 }
 
+// Shutdown stops the application in order: /health/ready starts failing, the
+// drain delay gives load balancers time to stop sending traffic, the HTTP and
+// gRPC servers finish in-flight requests (bounded by ctx), and Cleanup then
+// releases background work, the database and the cache.
+func (ac *ApplicationConfig) Shutdown(ctx context.Context) error {
+	ac.RouterService.SetReady(false)
+
+	if delay := ac.Config.ShutdownDrainDelay; delay > 0 {
+		ac.Logger.Info("Draining before shutdown", "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	grpcErr := make(chan error, 1)
+	if ac.GRPCServer != nil {
+		go func() { grpcErr <- ac.GRPCServer.Shutdown(ctx) }()
+	} else {
+		grpcErr <- nil
+	}
+
+	err := errors.Join(ac.RouterService.Shutdown(ctx), <-grpcErr)
+	ac.Cleanup()
+	return err
+}
+
+// Teardown priorities for ShutdownHooks. Lower priorities run first and hooks
+// with equal priorities run concurrently.
+const (
+	// ShutdownPriorityIntake stops work from starting: heartbeats and probes.
+	ShutdownPriorityIntake = 10
+	// ShutdownPriorityWork finishes running work while the DB is open.
+	ShutdownPriorityWork = 20
+	// ShutdownPriorityEvents drains subscribers after the work that publishes.
+	ShutdownPriorityEvents = 30
+	// ShutdownPriorityTelemetry flushes spans recorded by everything above.
+	ShutdownPriorityTelemetry = 40
+	// ShutdownPriorityConnections closes the database, cache and router resources.
+	ShutdownPriorityConnections = 50
+)
+
+// ShutdownHooks returns the manager Cleanup runs. Domains register teardown
+// for their own components on it with one of the ShutdownPriority levels.
+func (ac *ApplicationConfig) ShutdownHooks() *shutdown.Manager {
+	ac.hooksOnce.Do(func() { ac.hooks = shutdown.NewManager(ac.Logger) })
+	return ac.hooks
+}
+
+// Cleanup releases every component through ShutdownHooks. Each component has
+// its own timeout; one that hangs is logged by name and left behind so the
+// rest still close.
 func (ac *ApplicationConfig) Cleanup() {
+	hooks := ac.ShutdownHooks()
+	ac.registerShutdownHooks(hooks)
+	if err := hooks.Run(context.Background()); err != nil {
+		ac.Logger.Warn("Application cleanup finished with errors", "error", err)
+		return
+	}
+	ac.Logger.Info("Application cleanup completed")
+}
+
+func (ac *ApplicationConfig) registerShutdownHooks(hooks *shutdown.Manager) {
+	if ac.Heartbeat != nil {
+		hooks.Register("heartbeat", ShutdownPriorityIntake, 15*time.Second, func(context.Context) error {
+			ac.Heartbeat.Stop()
+			return nil
+		})
+	}
+
+	if ac.Goroutines != nil {
+		hooks.Register("goroutine-sampler", ShutdownPriorityIntake, time.Second, func(context.Context) error {
+			ac.Goroutines.Stop()
+			return nil
+		})
+	}
+
+	if ac.Messaging != nil {
+		hooks.Register("message-consumers", ShutdownPriorityIntake, 5*time.Second, func(context.Context) error {
+			return ac.Messaging.CloseConsumers()
+		})
+	}
+
+	if ac.GRPCServer != nil {
+		// Already stopped by Shutdown unless the process is exiting on a
+		// server error.
+		hooks.Register("grpc-server", ShutdownPriorityIntake, 5*time.Second, ac.GRPCServer.Shutdown)
+	}
+
+	if ac.Replica != nil {
+		hooks.Register("replica-monitor", ShutdownPriorityIntake, 5*time.Second, func(context.Context) error {
+			ac.Replica.Stop()
+			return nil
+		})
+	}
+
+	if ac.FX != nil {
+		hooks.Register("fx-rates", ShutdownPriorityIntake, 5*time.Second, func(context.Context) error {
+			ac.FX.Stop()
+			return nil
+		})
+	}
+
+	if ac.Views != nil {
+		hooks.Register("view-refresher", ShutdownPriorityIntake, 5*time.Second, func(context.Context) error {
+			ac.Views.Stop()
+			return nil
+		})
+	}
+
+	if ac.Probes != nil {
+		hooks.Register("probes", ShutdownPriorityIntake, 15*time.Second, func(context.Context) error {
+			ac.Probes.Stop()
+			return nil
+		})
+	}
+
+	if ac.Status != nil {
+		// Write the last counted checks while the DB is still open.
+		hooks.Register("status", ShutdownPriorityWork, 5*time.Second, func(ctx context.Context) error {
+			ac.Status.Stop(ctx)
+			return nil
+		})
+	}
+
+	if ac.Operations != nil {
+		// Running operations record their outcome, so stop them before the DB closes.
+		hooks.Register("operations", ShutdownPriorityWork, 30*time.Second, ac.Operations.Shutdown)
+	}
+
+	if ac.Analytics != nil {
+		// Events accepted before the servers stopped still reach the sink;
+		// the broker it may publish to closes later.
+		hooks.Register("analytics", ShutdownPriorityWork, 15*time.Second, func(ctx context.Context) error {
+			ac.Analytics.Stop(ctx)
+			return nil
+		})
+	}
+
+	if ac.Events != nil {
+		// Operations may still publish while finishing, so drain events after them.
+		hooks.Register("events", ShutdownPriorityEvents, 10*time.Second, ac.Events.Close)
+	}
+
+	if ac.Webhooks != nil {
+		// Queued deliveries are persisted, so stopping alongside the bus loses
+		// nothing; another instance or the next start sends them.
+		hooks.Register("webhooks", ShutdownPriorityEvents, 15*time.Second, func(ctx context.Context) error {
+			ac.Webhooks.Stop(ctx)
+			return nil
+		})
+	}
+
+	if ac.Mailer != nil {
+		// Event subscribers queue email until the bus drains, so send what
+		// is queued after it, with the other flushes.
+		hooks.Register("mailer", ShutdownPriorityTelemetry, 15*time.Second, func(ctx context.Context) error {
+			ac.Mailer.Stop(ctx)
+			return nil
+		})
+	}
+
 	if ac.TracingShutdown != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := ac.TracingShutdown(ctx); err != nil {
-			ac.Logger.Error("Failed to shutdown tracer provider", "error", err)
-		}
+		hooks.Register("tracing", ShutdownPriorityTelemetry, 5*time.Second, ac.TracingShutdown)
 	}
 
 	if ac.DB != nil {
-		CloseDatabase(ac.DB, ac.Logger)
+		hooks.Register("database", ShutdownPriorityConnections, 5*time.Second, func(context.Context) error {
+			CloseDatabase(ac.DB, ac.Logger)
+			return nil
+		})
+	}
+
+	if ac.Replica != nil {
+		hooks.Register("database-replica", ShutdownPriorityConnections, 5*time.Second, func(context.Context) error {
+			CloseDatabase(ac.Replica.Replica(), ac.Logger)
+			return nil
+		})
 	}
 
 	if ac.RouterService != nil {
-		ac.RouterService.Cleanup()
+		hooks.Register("router", ShutdownPriorityConnections, 5*time.Second, func(context.Context) error {
+			ac.RouterService.Cleanup()
+			return nil
+		})
 	}
 
-	if ac.Cache != nil {
-		CloseCache(ac.Cache, ac.Logger)
+	if ac.Messaging != nil {
+		// Events drained above may still be publishing, so flush the writers last.
+		hooks.Register("message-broker", ShutdownPriorityConnections, 10*time.Second, func(context.Context) error {
+			return ac.Messaging.Close()
+		})
 	}
 
-	ac.Logger.Info("Application cleanup completed")
+	if ac.Cache != nil {
+		hooks.Register("cache", ShutdownPriorityConnections, 5*time.Second, func(context.Context) error {
+			return CloseCache(ac.Cache, ac.Logger)
+		})
+	}
 }
 
 func LoadApplicationConfiguration(logger *log.Logger, autoMigrate bool) (*ApplicationConfig, error) {
@@ -94,12 +385,20 @@ func LoadApplicationConfiguration(logger *log.Logger, autoMigrate bool) (*Applic
 		}
 	}
 
+	// The region must be known before any IDs are generated.
+	appRegion, err := NewRegion(logger)
+	if err != nil {
+		return nil, err
+	}
+	region.SetLocal(appRegion)
+
 	tracingShutdown, err := SetupTracing(logger)
 	if err != nil {
 		return nil, err
 	}
 
 	dbCfg := &DBConfig{}
+	dbCfg.PoolFromEnv(logger, "DATABASE_")
 	db, err := NewDatabase(logger, dbCfg)
 	if err != nil {
 		return nil, err
@@ -111,23 +410,107 @@ func LoadApplicationConfiguration(logger *log.Logger, autoMigrate bool) (*Applic
 		}
 	}
 
+	replicaMonitor, err := NewReplicaMonitor(logger, db, dbCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldCipher, err := fieldcrypt.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if fieldCipher == nil {
+		logger.Warn("FIELD_ENCRYPTION_KEYS not set; transaction descriptions are stored in plaintext")
+	}
+
+	uploadStore, uploadConfig, err := NewUploadStore(logger)
+	if err != nil {
+		return nil, err
+	}
+
 	appConfig := NewAppConfig()
-	cache := NewCacheConfig().NewCacheOrNil(logger)
+	breakers := NewCircuitBreakers(logger)
+	cacheConfig := NewCacheConfig()
+	cacheConfig.Breaker = breakers.Get(BreakerRedisCache)
+	cache := cacheConfig.NewCacheOrNil(logger)
 
 	routerService := router.CreateRouterService(logger, cache, &router.RouterConfig{
-		RateLimitRequests: appConfig.RateLimitRequests,
-		RateLimitWindow:   appConfig.RateLimitWindow,
-		RequestTimeout:    appConfig.RequestTimeout,
+		RateLimitRequests:  appConfig.RateLimitRequests,
+		RateLimitWindow:    appConfig.RateLimitWindow,
+		RateLimitAlgorithm: appConfig.RateLimitAlgorithm,
+		RequestTimeout:     appConfig.RequestTimeout,
+		CORS:               router.CORSConfigFromEnv(logger),
+		RateLimitBreaker:   breakers.Get(BreakerRedisRateLimit),
 	})
+	roles := NewRoleStore(logger, db)
+	routerService.SetRoleStore(roles)
+	if store, ttl := NewIdempotencyStore(logger, db, cache); store != nil {
+		routerService.SetIdempotencyStore(store, ttl)
+	}
+	if store := NewResponseCache(logger, cache); store != nil {
+		routerService.SetResponseCache(store)
+	}
+
+	bus := NewEventBus(logger)
+
+	grpcServer, err := NewGRPCServer(logger, appConfig, cache, breakers)
+	if err != nil {
+		return nil, err
+	}
+
+	fxUpdater, err := NewFXUpdater(logger, db)
+	if err != nil {
+		return nil, err
+	}
 
 	logger.Info("Application configuration loaded successfully")
 
-	return &ApplicationConfig{
+	application := &ApplicationConfig{
 		DB:              db,
+		Replica:         replicaMonitor,
 		RouterService:   routerService,
 		Logger:          logger,
 		Cache:           cache,
 		Config:          appConfig,
 		TracingShutdown: tracingShutdown,
-	}, nil
+		FieldCipher:     fieldCipher,
+		Operations:      NewOperationsManager(logger, db),
+		UploadConfig:    uploadConfig,
+		Roles:           roles,
+		Events:          bus,
+		Probes:          NewDependencyProber(logger, db, cache),
+		Status:          NewStatusRecorder(logger, db),
+		Migrations:      NewMigrationCheck(db, autoMigrate),
+		Goroutines:      NewGoroutineSampler(logger),
+		Webhooks:        NewWebhookDispatcher(logger, db, fieldCipher, bus, breakers),
+		Messaging:       NewMessaging(logger, bus),
+		GRPCServer:      grpcServer,
+		FX:              fxUpdater,
+		Views:           NewViewRefresher(logger, db, autoMigrate),
+		CDC:             NewCDCInspector(logger, db, autoMigrate),
+		Locks:           NewLocker(logger, cache),
+		Breakers:        breakers,
+	}
+	if application.Messaging != nil {
+		application.Probes.Register("message_queue", application.Messaging.Ping)
+	}
+	if replicaMonitor != nil {
+		application.Probes.Register("database_replica", replicaMonitor.Check)
+	}
+	application.Heartbeat = NewHeartbeat(logger, routerService, application.Probes)
+	application.AnalyticsSchemas, application.Analytics, err = NewAnalytics(logger, application.Messaging)
+	if err != nil {
+		return nil, err
+	}
+	if application.Search, err = NewSearch(logger); err != nil {
+		return nil, err
+	}
+	if application.Mailer, err = NewMailer(logger, breakers); err != nil {
+		return nil, err
+	}
+	if uploadStore != nil {
+		// Assigned separately so a nil *FileStore never becomes a non-nil interface.
+		application.Uploads = uploadStore
+	}
+	return application, nil
 }
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+)
+
+func TestNewAppConfig_ShutdownDrainDelay(t *testing.T) {
+	t.Setenv("SHUTDOWN_DRAIN_DELAY", "")
+	if got := NewAppConfig().ShutdownDrainDelay; got != 0 {
+		t.Fatalf("expected no drain delay by default, got %s", got)
+	}
+
+	t.Setenv("SHUTDOWN_DRAIN_DELAY", "15s")
+	if got := NewAppConfig().ShutdownDrainDelay; got != 15*time.Second {
+		t.Fatalf("expected 15s, got %s", got)
+	}
+
+	t.Setenv("SHUTDOWN_DRAIN_DELAY", "soon")
+	if got := NewAppConfig().ShutdownDrainDelay; got != 0 {
+		t.Fatalf("expected invalid values to be ignored, got %s", got)
+	}
+}
+
+func TestShutdown_FailsReadinessBeforeDraining(t *testing.T) {
+	logger := log.NewLoggerWithJSONOutput()
+	ac := &ApplicationConfig{
+		Logger: logger,
+		Config: &AppConfig{ShutdownDrainDelay: 50 * time.Millisecond},
+		RouterService: router.CreateRouterService(logger, nil, &router.RouterConfig{
+			RateLimitRequests: 10,
+			RateLimitWindow:   time.Minute,
+			RequestTimeout:    time.Second,
+		}),
+	}
+	if !ac.RouterService.IsReady() {
+		t.Fatal("expected a new router to be ready")
+	}
+
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() { done <- ac.Shutdown(context.Background()) }()
+
+	deadline := time.Now().Add(time.Second)
+	for ac.RouterService.IsReady() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if ac.RouterService.IsReady() {
+		t.Fatal("expected readiness to fail once shutdown starts")
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected shutdown to wait for the drain delay, took %s", elapsed)
+	}
+}
+
+func TestShutdown_DrainDelayBoundedByContext(t *testing.T) {
+	logger := log.NewLoggerWithJSONOutput()
+	ac := &ApplicationConfig{
+		Logger: logger,
+		Config: &AppConfig{ShutdownDrainDelay: time.Hour},
+		RouterService: router.CreateRouterService(logger, nil, &router.RouterConfig{
+			RateLimitRequests: 10,
+			RateLimitWindow:   time.Minute,
+			RequestTimeout:    time.Second,
+		}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		_ = ac.Shutdown(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the context deadline to cut the drain delay short")
+	}
+}
+
+func TestNewHeartbeat_WithheldWhileNotReady(t *testing.T) {
+	logger := log.NewLoggerWithJSONOutput()
+	t.Setenv("HEARTBEAT_URL", "")
+	if NewHeartbeat(logger, nil, nil) != nil {
+		t.Fatal("expected no heartbeat without HEARTBEAT_URL")
+	}
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits.Add(1) }))
+	defer srv.Close()
+	t.Setenv("HEARTBEAT_URL", srv.URL)
+
+	rs := router.CreateRouterService(logger, nil, &router.RouterConfig{
+		RateLimitRequests: 10,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    time.Second,
+	})
+	sender := NewHeartbeat(logger, rs, nil)
+	if err := sender.Beat(context.Background()); err != nil || hits.Load() != 1 {
+		t.Fatalf("expected a heartbeat while ready, got %d (err %v)", hits.Load(), err)
+	}
+
+	rs.SetReady(false)
+	_ = sender.Beat(context.Background())
+	if hits.Load() != 1 {
+		t.Fatal("expected heartbeats to stop once shutdown starts")
+	}
+}
+
+func TestCleanup_ContinuesPastBlockedHook(t *testing.T) {
+	logger := log.NewLoggerWithJSONOutput()
+	ac := &ApplicationConfig{Logger: logger}
+
+	release := make(chan struct{})
+	defer close(release)
+	ac.ShutdownHooks().Register("hung-client", ShutdownPriorityIntake, 20*time.Millisecond, func(context.Context) error {
+		<-release
+		return nil
+	})
+	closed := false
+	ac.ShutdownHooks().Register("last", ShutdownPriorityConnections, time.Second, func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		ac.Cleanup()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Cleanup to move past the blocked hook")
+	}
+	if !closed {
+		t.Fatal("expected later hooks to run")
+	}
+}
@@ -0,0 +1,69 @@
+package router
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/anomaly"
+	"github.com/akeren/go-api-foundry/pkg/tarpit"
+	"github.com/gin-gonic/gin"
+)
+
+// anomalyConfigFromEnv reads ANOMALY_* settings on top of anomaly.DefaultConfig.
+func anomalyConfigFromEnv() anomaly.Config {
+	cfg := anomaly.DefaultConfig()
+
+	if v := strings.TrimSpace(os.Getenv("ANOMALY_WINDOW")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.Window = d
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("ANOMALY_SUSPICIOUS_SCORE")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.SuspiciousScore = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("ANOMALY_AUTOBAN")); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.AutoBan = b
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("ANOMALY_BAN_SCORE")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.BanScore = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("ANOMALY_BAN_TTL")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.BanTTL = d
+		}
+	}
+
+	return cfg
+}
+
+func (routerService *RouterService) initAnomalyScoring() {
+	cfg := anomalyConfigFromEnv()
+	routerService.anomalies = anomaly.NewScorer(cfg, routerService.logger, routerService.ipFilter)
+
+	routerService.logger.Info("Unknown route anomaly scoring initialized",
+		"window", cfg.Window,
+		"autoban", cfg.AutoBan,
+		"ban_score", cfg.BanScore,
+	)
+}
+
+func (routerService *RouterService) recordUnknownRoute(c *gin.Context) {
+	score := routerService.anomalies.Record(c.ClientIP(), c.Request.Method, c.Request.URL.Path)
+	routerService.logger.WithCorrelationID(c.Request.Context()).Debug("Unknown route",
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"client_ip", c.ClientIP(),
+		"score", score,
+	)
+	if routerService.anomalies.IsSuspicious(score) {
+		routerService.holdAbusiveClient(c, tarpit.SeveritySuspicious)
+	}
+}
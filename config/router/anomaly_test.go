@@ -0,0 +1,70 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUnknownRoute_Returns404AndIsScored(t *testing.T) {
+	rs := newTestRouterService(t)
+	mountTestController(rs)
+
+	req := httptest.NewRequest(http.MethodGet, "/does-not-exist", nil)
+	req.RemoteAddr = "10.0.0.9:1234"
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-RateLimit-Limit") == "" {
+		t.Fatalf("expected unknown routes to still be rate limited")
+	}
+	if score := rs.anomalies.Record("10.0.0.9", http.MethodGet, "/again"); score != 2 {
+		t.Fatalf("expected the 404 to be recorded for the client, score=%d", score)
+	}
+}
+
+func TestUnknownMethod_Returns405(t *testing.T) {
+	rs := newTestRouterService(t)
+	mountTestController(rs)
+
+	req := httptest.NewRequest(http.MethodDelete, "/ip", nil)
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAnomalyAutoBan_BlocksClient(t *testing.T) {
+	t.Setenv("ANOMALY_AUTOBAN", "true")
+	t.Setenv("ANOMALY_BAN_SCORE", "10")
+
+	rs := newTestRouterService(t)
+	mountTestController(rs)
+
+	for _, path := range []string{"/.env", "/wp-login.php"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "10.0.0.7:1234"
+		rs.GetEngine().ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "10.0.0.7:1234"
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected banned client to get 403, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "10.0.0.8:1234"
+	w = httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected other clients to be unaffected, got %d", w.Code)
+	}
+}
@@ -0,0 +1,40 @@
+package router
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const apiKeyContextKey = "router.api_key"
+
+// APIKeyValidator reports whether key belongs to a known client.
+type APIKeyValidator func(ctx context.Context, key string) bool
+
+// SetAPIKeyValidator makes the router check API keys sent in header with
+// validate. Only keys it accepts count: KeyByAPIKey keys rate limits by
+// them, and other keys are treated as absent. Call it before controllers
+// are mounted.
+func (routerService *RouterService) SetAPIKeyValidator(header string, validate APIKeyValidator) {
+	routerService.apiKeyHeader = header
+	routerService.apiKeyValidator = validate
+}
+
+// apiKeyMiddleware validates the request's API key, if it has one, for the
+// middlewares and key functions that run after it.
+func (routerService *RouterService) apiKeyMiddleware() gin.HandlerFunc {
+	header, validate := routerService.apiKeyHeader, routerService.apiKeyValidator
+	return func(c *gin.Context) {
+		if key := strings.TrimSpace(c.GetHeader(header)); key != "" && validate(c.Request.Context(), key) {
+			c.Set(apiKeyContextKey, key)
+		}
+		c.Next()
+	}
+}
+
+// validAPIKey returns the request's API key once the router's validator
+// accepted it, or "".
+func validAPIKey(c *RequestContext) string {
+	return c.GetString(apiKeyContextKey)
+}
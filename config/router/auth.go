@@ -0,0 +1,139 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/akeren/go-api-foundry/pkg/auth"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"github.com/gin-gonic/gin"
+)
+
+// initAuth loads the JWT validator from AUTH_JWT_* settings. Without one,
+// controllers that require authentication only accept mTLS service callers.
+func (routerService *RouterService) initAuth() {
+	validator, err := auth.FromEnv()
+	if err != nil {
+		// Fail closed: bearer tokens are rejected until the configuration is fixed.
+		routerService.logger.Error("Invalid JWT configuration; bearer tokens will be rejected", "error", err)
+		return
+	}
+	if validator != nil {
+		routerService.tokenValidator = validator
+		routerService.logger.Info("JWT authentication enabled")
+	}
+}
+
+// RequireAuth makes every handler registered on the controller reject
+// unauthenticated callers. Call it before the controller is mounted.
+func (controller *RESTController) RequireAuth() *RESTController {
+	controller.requireAuth = true
+	return controller
+}
+
+// routeMiddlewares wraps a handler's own middlewares in the controller-wide
+// ones: API key validation, authentication and the CSRF check run first,
+// then rate limiting for routes with a RateLimitKey, and idempotency last, so
+// a replayed response is only served to callers the handler's middlewares
+// admit.
+func (routerService *RouterService) routeMiddlewares(controller *RESTController, method string, middlewares []MiddlewareFunc) []MiddlewareFunc {
+	var chain []MiddlewareFunc
+	if routerService.apiKeyValidator != nil {
+		chain = append(chain, routerService.apiKeyMiddleware())
+	}
+	if controller.requireAuth {
+		chain = append(chain, routerService.authMiddleware())
+	}
+	if controller.requireCSRF {
+		chain = append(chain, csrfMiddleware())
+	}
+	chain = append(chain, routerService.keyedRateLimitMiddleware())
+	if routerService.responseCache != nil {
+		chain = append(chain, routerService.responseCacheContext())
+	}
+	chain = append(chain, middlewares...)
+	if routerService.idempotencyEnabled(controller, method) {
+		chain = append(chain, routerService.idempotencyMiddleware(controller.idempotency))
+	}
+	return chain
+}
+
+// authMiddleware accepts callers already identified by a client certificate,
+// or a valid "Authorization: Bearer <jwt>" header, whose claims become a user
+// principal.
+func (routerService *RouterService) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// CORS preflight and tus discovery requests never carry credentials.
+		if c.Request.Method == http.MethodOptions || principal.FromContext(c.Request.Context()) != nil {
+			c.Next()
+			return
+		}
+
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			routerService.rejectUnauthenticated(c, `Bearer`, "Authentication required")
+			return
+		}
+		if routerService.tokenValidator == nil {
+			routerService.rejectUnauthenticated(c, `Bearer error="invalid_token"`, "Invalid or expired token")
+			return
+		}
+
+		claims, err := routerService.tokenValidator.Validate(c.Request.Context(), token)
+		if errors.Is(err, auth.ErrKeySetUnavailable) {
+			GetLogger(c).Error("Failed to fetch JWT signing keys", "error", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResult(apperrors.StatusServiceUnavailable, "Authentication is temporarily unavailable", nil).ToJSON())
+			return
+		}
+		if err != nil {
+			GetLogger(c).Warn("Rejected bearer token", "error", err)
+			routerService.rejectUnauthenticated(c, `Bearer error="invalid_token"`, "Invalid or expired token")
+			return
+		}
+
+		p := &principal.Principal{
+			Subject: claims.Subject,
+			Kind:    principal.KindUser,
+			Roles:   claims.Roles,
+		}
+		if claims.Issuer != "" {
+			p.Attributes = map[string]string{"issuer": claims.Issuer}
+		}
+		routerService.resolveRoles(c, p)
+		ctx := auth.WithClaims(c.Request.Context(), claims)
+		c.Request = c.Request.WithContext(principal.WithPrincipal(ctx, p))
+		routerService.withTenantLabel(c, claims, c.Next)
+	}
+}
+
+func (routerService *RouterService) rejectUnauthenticated(c *gin.Context, challenge, message string) {
+	routerService.recordBlockedRequest("unauthenticated")
+	c.Header("WWW-Authenticate", challenge)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResult(apperrors.StatusUnauthorized, message, nil).ToJSON())
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// GetClaims returns the JWT claims of the caller, or nil when the request was
+// not authenticated with a bearer token.
+func GetClaims(ctx *RequestContext) *auth.Claims {
+	return auth.ClaimsFromContext(ctx.Request.Context())
+}
+
+// GetSubject returns the authenticated caller's subject: the token "sub" for
+// users, the service name for mTLS callers, or "" for anonymous requests.
+func GetSubject(ctx *RequestContext) string {
+	if p := principal.FromContext(ctx.Request.Context()); p != nil {
+		return p.Subject
+	}
+	return ""
+}
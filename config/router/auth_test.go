@@ -0,0 +1,127 @@
+package router
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/principal"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+func testToken(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	enc := func(v any) string {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	input := enc(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + enc(claims)
+	mac := hmac.New(sha256.New, []byte(testJWTSecret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newAuthTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	t.Setenv("AUTH_JWT_HMAC_SECRET", testJWTSecret)
+
+	rs := newTestRouterService(t)
+	mountTestController(rs)
+	rs.MountController(NewRESTController("Me", "/me", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(map[string]any{
+				"subject": GetSubject(ctx),
+				"scopes":  GetClaims(ctx).Scopes,
+				"admin":   principal.FromContext(ctx.Request.Context()).HasRole("admin"),
+			}, "ok")
+		})
+	}).RequireAuth())
+	return rs
+}
+
+func serveWithToken(rs *RouterService, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireAuth_RejectsMissingToken(t *testing.T) {
+	rs := newAuthTestRouter(t)
+
+	w := serveWithToken(rs, "/me", "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); got != "Bearer" {
+		t.Fatalf("expected Bearer challenge, got %q", got)
+	}
+
+	// Controllers that do not require auth are unaffected.
+	if w := serveWithToken(rs, "/ip", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for open route, got %d", w.Code)
+	}
+}
+
+func TestRequireAuth_RejectsInvalidToken(t *testing.T) {
+	rs := newAuthTestRouter(t)
+
+	expired := testToken(t, map[string]any{"sub": "user-1", "exp": time.Now().Add(-time.Hour).Unix()})
+	w := serveWithToken(rs, "/me", expired)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+	if got := w.Header().Get("WWW-Authenticate"); !strings.Contains(got, "invalid_token") {
+		t.Fatalf("expected invalid_token challenge, got %q", got)
+	}
+}
+
+func TestRequireAuth_ExposesClaims(t *testing.T) {
+	rs := newAuthTestRouter(t)
+
+	token := testToken(t, map[string]any{
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"admin"},
+		"scope": "ledger:read",
+	})
+	w := serveWithToken(rs, "/me", token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{`"subject":"user-1"`, `"scopes":["ledger:read"]`, `"admin":true`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %s in %s", want, body)
+		}
+	}
+}
+
+func TestRequireAuth_WithoutValidatorRejectsTokens(t *testing.T) {
+	t.Setenv("AUTH_JWT_HMAC_SECRET", "")
+
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("Me", "/me", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(nil, "ok")
+		})
+	}).RequireAuth())
+
+	token := testToken(t, map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	if w := serveWithToken(rs, "/me", token); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	pkgredis "github.com/akeren/go-api-foundry/pkg/redis"
+	"github.com/gin-gonic/gin"
+)
+
+// The benchmarks cover the hot path of every request. Compare them across
+// changes with `make bench-compare`; see the developer guide.
+
+type benchAccount struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Currency  string    `json:"currency"`
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func benchAccounts(n int) []benchAccount {
+	accounts := make([]benchAccount, n)
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range accounts {
+		accounts[i] = benchAccount{ID: "acc-" + strconv.Itoa(i), Name: "Operating account", Currency: "NGN", Balance: int64(i) * 1000, CreatedAt: created}
+	}
+	return accounts
+}
+
+// newBenchRouter returns a router whose logs are formatted, as in
+// production, and discarded.
+func newBenchRouter(b *testing.B, cache Cache) *RouterService {
+	b.Helper()
+	mode := gin.Mode()
+	gin.SetMode(gin.ReleaseMode)
+	b.Cleanup(func() { gin.SetMode(mode) })
+
+	logger := &log.Logger{Logger: slog.New(slog.NewJSONHandler(io.Discard, nil))}
+	rs := CreateRouterService(logger, cache, &RouterConfig{
+		RateLimitRequests: 1 << 30,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+	})
+	account := benchAccounts(1)[0]
+	rs.MountController(NewVersionedRESTController("Accounts", "v1", "/accounts", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/:id", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(account, "Account retrieved successfully")
+		})
+	}))
+	b.Cleanup(rs.Cleanup)
+	return rs
+}
+
+func benchmarkChain(b *testing.B, rs *RouterService) {
+	engine := rs.GetEngine()
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/acc-1", nil)
+	b.ReportAllocs()
+	for b.Loop() {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+// BenchmarkMiddlewareChain serves a GET through every global middleware and
+// the route's handler. The Redis case needs BENCH_REDIS_ADDR (host:port) and
+// is skipped without it.
+func BenchmarkMiddlewareChain(b *testing.B) {
+	b.Run("memory_limiter", func(b *testing.B) {
+		benchmarkChain(b, newBenchRouter(b, nil))
+	})
+
+	b.Run("redis_limiter", func(b *testing.B) {
+		addr := os.Getenv("BENCH_REDIS_ADDR")
+		if addr == "" {
+			b.Skip("Set BENCH_REDIS_ADDR to a Redis server to benchmark the Redis rate limiter")
+		}
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			b.Fatalf("BENCH_REDIS_ADDR: %v", err)
+		}
+		cache, err := pkgredis.NewRedisCache(&pkgredis.Config{Host: host, Port: port})
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { _ = cache.Close() })
+		benchmarkChain(b, newBenchRouter(b, cache))
+	})
+}
+
+// BenchmarkJSONEnvelope encodes results in the response envelope, as
+// handlers' results are written: with encoding/json, and into pooled
+// buffers as routes with PooledJSON are, with and without a registered
+// encoder.
+func BenchmarkJSONEnvelope(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		data any
+	}{
+		{"object", benchAccounts(1)[0]},
+		{"list_100", benchAccounts(100)},
+		{"registered", &pooledAccount{ID: "acc-1", Balance: 1000}},
+	} {
+		result := OKResult(bc.data, "Accounts retrieved successfully")
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := json.Marshal(result.ToJSON()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(bc.name+"_pooled", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				buf := getJSONBuffer()
+				if err := encodeEnvelope(buf, result); err != nil {
+					b.Fatal(err)
+				}
+				putJSONBuffer(buf)
+			}
+		})
+	}
+}
+
+// BenchmarkNormalizePath joins mount points and relative paths, as route
+// registration and RESTController.Path do.
+func BenchmarkNormalizePath(b *testing.B) {
+	controller := &RESTController{mountPoint: "/v1/ledger/accounts"}
+	for _, bc := range []struct{ name, path string }{
+		{"empty", ""},
+		{"param", ":id"},
+		{"nested", "/:id/entries/"},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				normalizePath(controller, bc.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package router
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// BindJSON binds the JSON body into a T and validates it with binding tags.
+// Invalid bodies get a 400 result listing each violation. Numbers are decoded
+// exactly: integer fields reject fractions and out-of-range values by name,
+// and interface fields receive json.Number rather than a rounded float64.
+// Routes with strict JSON (see Route.StrictJSON) also list the keys no field
+// of T decodes, so a misspelt field is rejected rather than left at its zero
+// value.
+func BindJSON[T any](ctx *RequestContext) (*T, *ServiceResult) {
+	var document any
+	body, err := ctx.GetRawData()
+	if err == nil {
+		err = decodeJSON(body, &document)
+	}
+	if err != nil {
+		GetLogger(ctx).Warn("Failed to bind request", "error", err)
+		return nil, BadRequestResult("Invalid request body", nil)
+	}
+
+	var req T
+	// A field that fails these checks would also fail to decode, but
+	// encoding/json reports only the first such field and in Go terms.
+	violations := numberViolations(reflect.TypeFor[T](), document)
+	if len(violations) == 0 {
+		err = decodeJSON(body, &req)
+		if err == nil {
+			err = binding.Validator.ValidateStruct(&req)
+		}
+		if err != nil {
+			GetLogger(ctx).Warn("Failed to bind request", "error", err)
+			violations = apperrors.FormatValidationErrors(err, &req)
+			if len(violations) == 0 {
+				return nil, BadRequestResult("Invalid request body", nil)
+			}
+		}
+	}
+	if ctx.GetBool(strictJSONContextKey) {
+		for _, field := range unknownFields(reflect.TypeFor[T](), document) {
+			violations = append(violations, apperrors.ValidationErrorResponse{Field: field, Message: "Unknown field"})
+		}
+	}
+	if len(violations) > 0 {
+		return nil, BadRequestResult("Invalid request payload", violations)
+	}
+	return &req, nil
+}
+
+// BindQuery binds the query string into a T, naming parameters with form
+// tags and validating them with binding tags. Invalid parameters get a 400
+// result listing each one, in the same shape as JSON body validation errors:
+//
+//	type listParams struct {
+//		Status string    `form:"status" binding:"omitempty,oneof=PENDING FAILED"`
+//		From   time.Time `form:"from" time_format:"2006-01-02"`
+//		To     time.Time `form:"to" time_format:"2006-01-02" binding:"omitempty,gtefield=From"`
+//	}
+func BindQuery[T any](ctx *RequestContext) (*T, *ServiceResult) {
+	return bindValues[T](ctx, ctx.Request.URL.Query(), "form", "Invalid query parameters")
+}
+
+// BindURI binds the route's path parameters into a T, naming them with uri
+// tags. Failures are reported as by BindQuery.
+func BindURI[T any](ctx *RequestContext) (*T, *ServiceResult) {
+	params := make(map[string][]string, len(ctx.Params))
+	for _, p := range ctx.Params {
+		params[p.Key] = []string{p.Value}
+	}
+	return bindValues[T](ctx, params, "uri", "Invalid path parameters")
+}
+
+func bindValues[T any](ctx *RequestContext, values map[string][]string, tag, message string) (*T, *ServiceResult) {
+	var req T
+	if err := binding.MapFormWithTag(&req, values, tag); err != nil {
+		GetLogger(ctx).Warn("Failed to bind request parameters", "error", err)
+		return nil, BadRequestResult(message, parseErrors[T](values, tag))
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		violations := apperrors.FormatValidationErrorsByTag(err, &req, tag)
+		if len(violations) == 0 {
+			return nil, BadRequestResult(message, nil)
+		}
+		return nil, BadRequestResult(message, violations)
+	}
+	return &req, nil
+}
+
+// parseErrors finds the parameters that failed to convert to their field's
+// type. Gin reports only the first conversion error and without its
+// parameter, so each parameter is bound on its own.
+func parseErrors[T any](values map[string][]string, tag string) []apperrors.ValidationErrorResponse {
+	var violations []apperrors.ValidationErrorResponse
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		var probe T
+		if binding.MapFormWithTag(&probe, map[string][]string{key: values[key]}, tag) == nil {
+			continue
+		}
+		violations = append(violations, apperrors.ValidationErrorResponse{
+			Field:   key,
+			Message: expectedValue(reflect.TypeFor[T](), key, tag),
+		})
+	}
+	return violations
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// expectedValue describes what the parameter named key accepts.
+func expectedValue(structType reflect.Type, key, tag string) string {
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != key {
+			continue
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer || fieldType.Kind() == reflect.Slice {
+			fieldType = fieldType.Elem()
+		}
+		switch {
+		case fieldType == timeType:
+			if layout := field.Tag.Get("time_format"); layout != "" && layout != "unix" && layout != "unixnano" {
+				return "Must be a time in the format " + layout
+			}
+			return "Must be a valid time"
+		case fieldType == durationType:
+			return "Must be a duration such as 30s or 1h"
+		}
+		switch fieldType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return "Value must be a whole number"
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return "Value must be a non-negative whole number"
+		case reflect.Float32, reflect.Float64:
+			return "Value must be numeric"
+		case reflect.Bool:
+			return "Value must be true or false"
+		}
+	}
+	return "Invalid value"
+}
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+)
+
+type bindingTestQuery struct {
+	Limit  int       `form:"limit,default=20" binding:"gte=1,lte=100"`
+	Status string    `form:"status" binding:"omitempty,oneof=PENDING FAILED"`
+	From   time.Time `form:"from" time_format:"2006-01-02"`
+	To     time.Time `form:"to" time_format:"2006-01-02" binding:"omitempty,gtefield=From"`
+}
+
+type bindingTestPath struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+func newBindingTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("Items", "/items", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			query, errResult := BindQuery[bindingTestQuery](ctx)
+			if errResult != nil {
+				return errResult
+			}
+			return OKResult(query, "ok")
+		})
+		rs.AddGetHandler(c, nil, "/:id", func(ctx *RequestContext) *ServiceResult {
+			path, errResult := BindURI[bindingTestPath](ctx)
+			if errResult != nil {
+				return errResult
+			}
+			return OKResult(path.ID, "ok")
+		})
+	}))
+	return rs
+}
+
+func fetchViolations(t *testing.T, rs *RouterService, target string) []apperrors.ValidationErrorResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("%s: expected 400, got %d: %s", target, w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []apperrors.ValidationErrorResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return body.Data
+}
+
+func TestBindQuery_Valid(t *testing.T) {
+	rs := newBindingTestRouter(t)
+
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?status=FAILED&from=2026-01-01&to=2026-01-31", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data struct {
+			Limit  int
+			Status string
+			To     time.Time
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body.Data.Limit != 20 || body.Data.Status != "FAILED" || body.Data.To.Day() != 31 {
+		t.Fatalf("unexpected bound query: %+v", body.Data)
+	}
+}
+
+func TestBindQuery_ReportsEachInvalidParameter(t *testing.T) {
+	rs := newBindingTestRouter(t)
+
+	violations := fetchViolations(t, rs, "/items?limit=500&status=DONE&from=2026-02-01&to=2026-01-01")
+	want := map[string]string{
+		"limit":  "Must be less than or equal to 100",
+		"status": "Must be one of: PENDING, FAILED",
+		"to":     "Must be greater than or equal to from",
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("expected %d violations, got %+v", len(want), violations)
+	}
+	for _, v := range violations {
+		if want[v.Field] != v.Message {
+			t.Errorf("%s: expected %q, got %q", v.Field, want[v.Field], v.Message)
+		}
+	}
+}
+
+func TestBindQuery_ReportsUnparsableParameters(t *testing.T) {
+	rs := newBindingTestRouter(t)
+
+	violations := fetchViolations(t, rs, "/items?limit=ten&from=yesterday&status=FAILED")
+	if len(violations) != 2 {
+		t.Fatalf("expected 2 violations, got %+v", violations)
+	}
+	if violations[0].Field != "from" || violations[0].Message != "Must be a time in the format 2006-01-02" {
+		t.Errorf("unexpected violation: %+v", violations[0])
+	}
+	if violations[1].Field != "limit" || violations[1].Message != "Value must be a whole number" {
+		t.Errorf("unexpected violation: %+v", violations[1])
+	}
+}
+
+func TestBindURI(t *testing.T) {
+	rs := newBindingTestRouter(t)
+
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/6f1c1d7e-1b8a-4e5a-9d3c-2f0b7c1a9e01", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	violations := fetchViolations(t, rs, "/items/42")
+	if len(violations) != 1 || violations[0].Field != "id" || violations[0].Message != "Value must be a UUID" {
+		t.Fatalf("unexpected violations: %+v", violations)
+	}
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newBodyLimitTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	t.Setenv("MAX_REQUEST_BODY_BYTES", "100")
+
+	read := func(ctx *RequestContext) *ServiceResult {
+		if _, err := io.ReadAll(ctx.Request.Body); err != nil {
+			return ErrorResult(http.StatusRequestEntityTooLarge, "too large", nil)
+		}
+		return OKResult(nil, "ok")
+	}
+
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("Small", "/small", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddPostHandler(c, nil, "", read)
+		rs.AddPostHandler(c, nil, "/import", read).MaxBodyBytes(1000)
+	}).MaxBodyBytes(10))
+	rs.MountController(NewRESTController("Default", "/default", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddPostHandler(c, nil, "", read)
+	}))
+	return rs
+}
+
+func serveBody(rs *RouterService, path string, size int, knownLength bool) int {
+	var body io.Reader = bytes.NewReader(bytes.Repeat([]byte{'a'}, size))
+	if !knownLength {
+		// Hide the length so only MaxBytesReader can stop the body.
+		body = io.MultiReader(body)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestMaxBodyBytes_Precedence(t *testing.T) {
+	rs := newBodyLimitTestRouter(t)
+
+	cases := []struct {
+		path string
+		size int
+		want int
+	}{
+		{"/small", 10, http.StatusOK},
+		{"/small", 11, http.StatusRequestEntityTooLarge},
+		// A route may allow more than the global limit.
+		{"/small/import", 1000, http.StatusOK},
+		{"/small/import", 1001, http.StatusRequestEntityTooLarge},
+		{"/default", 100, http.StatusOK},
+		{"/default", 101, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		for _, knownLength := range []bool{true, false} {
+			if got := serveBody(rs, tc.path, tc.size, knownLength); got != tc.want {
+				t.Fatalf("%s with %d bytes (known length %v): expected %d, got %d", tc.path, tc.size, knownLength, tc.want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/akeren/go-api-foundry/pkg/botdetect"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/tarpit"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// BotCheckFailure is returned as the data of a 403 when bot verification fails.
+type BotCheckFailure struct {
+	Reason    string               `json:"reason"`
+	Challenge *botdetect.Challenge `json:"challenge,omitempty"`
+}
+
+func envBool(name string, fallback bool) bool {
+	v := utils.GetEnvTrimmed(name)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
+func (routerService *RouterService) initBotDetection() {
+	if envBool("BOTDETECT_ENABLED", false) {
+		heuristic := botdetect.NewHeuristicDetector(strings.Split(utils.GetEnvTrimmed("BOTDETECT_BLOCKED_AGENTS"), ",")...)
+		heuristic.RequireUserAgent = envBool("BOTDETECT_REQUIRE_USER_AGENT", false)
+		routerService.AddBotDetector(heuristic)
+	}
+
+	provider := utils.GetEnvTrimmed("CAPTCHA_PROVIDER")
+	if provider == "" {
+		return
+	}
+
+	minScore := 0.5
+	if v := utils.GetEnvTrimmed("CAPTCHA_MIN_SCORE"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			minScore = f
+		}
+	}
+
+	verifier, err := botdetect.NewCaptchaVerifier(botdetect.CaptchaConfig{
+		Provider:  provider,
+		Secret:    utils.GetEnvTrimmed("CAPTCHA_SECRET"),
+		SiteKey:   utils.GetEnvTrimmed("CAPTCHA_SITE_KEY"),
+		VerifyURL: utils.GetEnvTrimmed("CAPTCHA_VERIFY_URL"),
+		MinScore:  minScore,
+	})
+	if err != nil {
+		routerService.logger.Error("Invalid captcha configuration; captcha verification disabled", "error", err)
+		return
+	}
+	routerService.captcha = verifier
+	routerService.logger.Info("Captcha verification configured", "provider", verifier.Name())
+}
+
+// AddBotDetector registers a detector that runs on every request.
+func (routerService *RouterService) AddBotDetector(detector botdetect.Detector) {
+	routerService.botDetectors = append(routerService.botDetectors, detector)
+	routerService.logger.Info("Bot detector registered", "detector", detector.Name())
+}
+
+// RequireCaptcha returns a per-route middleware that verifies the configured
+// captcha token. It is a no-op when no captcha provider is configured.
+func (routerService *RouterService) RequireCaptcha() MiddlewareFunc {
+	if routerService.captcha == nil {
+		return func(c *RequestContext) { c.Next() }
+	}
+	return routerService.BotProtection(false, routerService.captcha)
+}
+
+// BotProtection returns a per-route middleware running the given detectors.
+// failOpen decides what happens when a detector cannot reach a verdict.
+func (routerService *RouterService) BotProtection(failOpen bool, detectors ...botdetect.Detector) MiddlewareFunc {
+	return func(c *RequestContext) {
+		if routerService.checkBots(c, detectors, failOpen) {
+			c.Next()
+		}
+	}
+}
+
+func (routerService *RouterService) botDetectionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(routerService.botDetectors) == 0 || routerService.checkBots(c, routerService.botDetectors, true) {
+			c.Next()
+		}
+	}
+}
+
+// checkBots runs detectors in order and aborts the request on the first bot
+// verdict. It returns true when the request may continue.
+func (routerService *RouterService) checkBots(c *gin.Context, detectors []botdetect.Detector, failOpen bool) bool {
+	for _, detector := range detectors {
+		verdict, err := detector.Detect(c.Request.Context(), c.Request, c.ClientIP())
+		if err != nil {
+			routerService.logger.Error("Bot detector error", "detector", detector.Name(), "error", err, "client_ip", c.ClientIP())
+			if failOpen {
+				continue
+			}
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResult(
+				apperrors.StatusServiceUnavailable,
+				"Bot verification is temporarily unavailable",
+				nil,
+			).ToJSON())
+			return false
+		}
+
+		if verdict.Bot {
+			routerService.recordBlockedRequest("bot")
+			routerService.logger.Info("Request blocked by bot detection",
+				"detector", detector.Name(),
+				"reason", verdict.Reason,
+				"client_ip", c.ClientIP(),
+			)
+			// Clients offered a challenge may be humans who have not solved it yet.
+			if verdict.Challenge == nil {
+				routerService.holdAbusiveClient(c, tarpit.SeverityBot)
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResult(
+				apperrors.StatusForbidden,
+				"Bot verification failed",
+				BotCheckFailure{Reason: verdict.Reason, Challenge: verdict.Challenge},
+			).ToJSON())
+			return false
+		}
+	}
+	return true
+}
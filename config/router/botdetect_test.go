@@ -0,0 +1,90 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBotDetection_BlocksScannerUserAgents(t *testing.T) {
+	t.Setenv("BOTDETECT_ENABLED", "true")
+
+	rs := newTestRouterService(t)
+	mountTestController(rs)
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.Header.Set("User-Agent", "sqlmap/1.7")
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+
+	var resp struct {
+		Data BotCheckFailure `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Data.Reason != "blocked_user_agent" || resp.Data.Challenge != nil {
+		t.Fatalf("unexpected failure payload: %+v", resp.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	w = httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a regular client, got %d", w.Code)
+	}
+}
+
+func TestRequireCaptcha_ReturnsChallengeHint(t *testing.T) {
+	verify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": r.PostForm.Get("response") == "good"})
+	}))
+	defer verify.Close()
+
+	t.Setenv("CAPTCHA_PROVIDER", "turnstile")
+	t.Setenv("CAPTCHA_SECRET", "secret")
+	t.Setenv("CAPTCHA_SITE_KEY", "site-key")
+	t.Setenv("CAPTCHA_VERIFY_URL", verify.URL)
+
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("Form", "/", func(r RouteRegistrar, c *RESTController) {
+		r.AddPostHandler(c, nil, "signup", func(ctx *RequestContext) *ServiceResult {
+			return CreatedResult(nil, "Signup")
+		}, rs.RequireCaptcha())
+	}))
+
+	serve := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/signup", nil)
+		if token != "" {
+			req.Header.Set("X-Captcha-Token", token)
+		}
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("")
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without a token, got %d", w.Code)
+	}
+	var resp struct {
+		Data BotCheckFailure `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Data.Challenge == nil || resp.Data.Challenge.Provider != "turnstile" || resp.Data.Challenge.SiteKey != "site-key" {
+		t.Fatalf("expected challenge hint, got %+v", resp.Data)
+	}
+
+	if w := serve("bad"); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a rejected token, got %d", w.Code)
+	}
+	if w := serve("good"); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for a valid token, got %d", w.Code)
+	}
+}
@@ -0,0 +1,163 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/respcache"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CacheStatusHeader reports whether a cached route was answered from the
+	// cache (HIT) or by its handler (MISS).
+	CacheStatusHeader = "X-Cache"
+
+	responseCacheContextKey = "router.responseCache"
+)
+
+// cachedHeaders are the response headers kept alongside the body.
+var cachedHeaders = []string{"Content-Type", "Content-Disposition", "ETag"}
+
+// SetResponseCache lets handlers decorated with CacheFor serve their stored
+// responses from store. Call it before controllers are mounted; without a
+// store CacheFor and InvalidatesCache do nothing.
+func (routerService *RouterService) SetResponseCache(store respcache.Store) {
+	routerService.responseCache = store
+}
+
+// responseCacheContext hands the store to the route's CacheFor and
+// InvalidatesCache decorators, which are built without the RouterService.
+func (routerService *RouterService) responseCacheContext() gin.HandlerFunc {
+	store := routerService.responseCache
+	return func(c *gin.Context) {
+		c.Set(responseCacheContextKey, store)
+		c.Next()
+	}
+}
+
+func responseCacheFrom(c *gin.Context) respcache.Store {
+	store, _ := c.Value(responseCacheContextKey).(respcache.Store)
+	return store
+}
+
+// CacheFor serves a GET handler's successful responses from the response
+// cache for ttl. Responses are kept per path and query, Accept header and
+// caller, so callers never see one another's responses or fields. Writes
+// evict them through InvalidatesCache with one of tags, in which "{name}"
+// stands for the route parameter name. Requests with "Cache-Control:
+// no-cache" skip the lookup but refresh the stored response. The store is
+// not consulted when it fails, and responses over 1 MiB are not stored.
+func CacheFor(ttl time.Duration, tags ...string) MiddlewareFunc {
+	return func(c *gin.Context) {
+		store := responseCacheFrom(c)
+		if store == nil || ttl <= 0 || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		generation, err := store.Generation(ctx, expandCacheTags(c, tags))
+		if err != nil {
+			GetLogger(c).Warn("Response cache unavailable; running handler", "error", err)
+			c.Next()
+			return
+		}
+		key := responseCacheKey(c, generation)
+
+		if !strings.Contains(c.GetHeader("Cache-Control"), "no-cache") {
+			stored, err := store.Get(ctx, key)
+			if err != nil {
+				GetLogger(c).Warn("Response cache unavailable; running handler", "error", err)
+				c.Next()
+				return
+			}
+			if stored != nil {
+				writeCachedResponse(c, stored)
+				return
+			}
+		}
+
+		c.Header(CacheStatusHeader, "MISS")
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		c.Writer = recorder.ResponseWriter
+
+		if c.Writer.Status() != http.StatusOK || recorder.truncated {
+			return
+		}
+		resp := respcache.Response{StatusCode: http.StatusOK, Header: map[string]string{}, Body: recorder.body.Bytes()}
+		for _, name := range cachedHeaders {
+			if value := c.Writer.Header().Get(name); value != "" {
+				resp.Header[name] = value
+			}
+		}
+		if err := store.Set(ctx, key, resp, ttl); err != nil {
+			GetLogger(c).Warn("Failed to store cached response", "error", err)
+		}
+	}
+}
+
+// InvalidatesCache evicts the responses CacheFor stored under any of tags
+// once the handler succeeds, with "{name}" standing for the route parameter
+// name as in CacheFor. A failed eviction is logged; the responses then live
+// out their TTL.
+func InvalidatesCache(tags ...string) MiddlewareFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		store := responseCacheFrom(c)
+		if store == nil {
+			return
+		}
+		if status := c.Writer.Status(); status < http.StatusOK || status >= http.StatusMultipleChoices {
+			return
+		}
+		if err := store.Invalidate(context.WithoutCancel(c.Request.Context()), expandCacheTags(c, tags)...); err != nil {
+			GetLogger(c).Error("Failed to invalidate cached responses", "tags", tags, "error", err)
+		}
+	}
+}
+
+func writeCachedResponse(c *gin.Context, stored *respcache.Response) {
+	for name, value := range stored.Header {
+		c.Header(name, value)
+	}
+	c.Header(CacheStatusHeader, "HIT")
+	if tag := stored.Header["ETag"]; tag != "" && etagMatches(c.GetHeader("If-None-Match"), tag) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+	c.Status(stored.StatusCode)
+	_, _ = c.Writer.Write(stored.Body)
+	c.Abort()
+}
+
+// responseCacheKey identifies the response to the request for the caller,
+// under the generation of the route's tags.
+func responseCacheKey(c *gin.Context, generation string) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.URL.RequestURI() + "\n" + c.GetHeader("Accept") + "\n" + idempotencyScope(c)))
+	return hex.EncodeToString(h.Sum(nil)) + ":" + generation
+}
+
+func expandCacheTags(c *gin.Context, tags []string) []string {
+	if len(c.Params) == 0 {
+		return tags
+	}
+	pairs := make([]string, 0, 2*len(c.Params))
+	for _, param := range c.Params {
+		pairs = append(pairs, "{"+param.Key+"}", param.Value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+	expanded := make([]string, len(tags))
+	for i, tag := range tags {
+		expanded[i] = replacer.Replace(tag)
+	}
+	return expanded
+}
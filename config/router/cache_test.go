@@ -0,0 +1,152 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"github.com/akeren/go-api-foundry/pkg/respcache"
+	"github.com/gin-gonic/gin"
+)
+
+// newCacheTestRouter mounts a list whose GET handler returns a new version on
+// every call, so a cached response is told apart from a second run. The
+// X-Subject header stands in for an authenticated caller.
+func newCacheTestRouter(t *testing.T, store respcache.Store) (*RouterService, *int) {
+	t.Helper()
+	calls := 0
+	rs := newTestRouterService(t)
+	if store != nil {
+		rs.SetResponseCache(store)
+	}
+	asSubject := func(c *gin.Context) {
+		if subject := c.GetHeader("X-Subject"); subject != "" {
+			c.Request = c.Request.WithContext(principal.WithPrincipal(c.Request.Context(), &principal.Principal{Subject: subject, Kind: principal.KindUser}))
+		}
+		c.Next()
+	}
+	rs.MountController(NewRESTController("Lists", "/lists", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/:id/items", func(ctx *RequestContext) *ServiceResult {
+			calls++
+			if ctx.Query("fail") != "" {
+				return NotFoundResult("List")
+			}
+			return OKResult(map[string]int{"version": calls}, "ok")
+		}, asSubject, CacheFor(time.Hour, "lists:{id}"))
+		rs.AddPostHandler(c, nil, "/:id/items", func(ctx *RequestContext) *ServiceResult {
+			if ctx.Query("fail") != "" {
+				return BadRequestResult("invalid", nil)
+			}
+			return CreatedResult(map[string]string{"id": "1"}, "Item")
+		}, InvalidatesCache("lists:{id}"))
+	}))
+	return rs, &calls
+}
+
+func serveCached(rs *RouterService, method, target, subject string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if subject != "" {
+		req.Header.Set("X-Subject", subject)
+	}
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	return w
+}
+
+func TestCacheFor_ServesStoredResponse(t *testing.T) {
+	rs, calls := newCacheTestRouter(t, respcache.NewMemoryStore())
+
+	first := serveCached(rs, http.MethodGet, "/lists/a/items", "")
+	if first.Code != http.StatusOK || first.Header().Get(CacheStatusHeader) != "MISS" {
+		t.Fatalf("expected a 200 miss, got %d %q", first.Code, first.Header().Get(CacheStatusHeader))
+	}
+	again := serveCached(rs, http.MethodGet, "/lists/a/items", "")
+	if again.Code != http.StatusOK || again.Header().Get(CacheStatusHeader) != "HIT" || again.Body.String() != first.Body.String() {
+		t.Fatalf("expected the stored response, got %d %q: %s", again.Code, again.Header().Get(CacheStatusHeader), again.Body.String())
+	}
+	if again.Header().Get("ETag") != first.Header().Get("ETag") || again.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Fatalf("expected the stored headers, got %v", again.Header())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/lists/a/items", nil)
+	req.Header.Set("If-None-Match", first.Header().Get("ETag"))
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("expected 304 for the stored tag, got %d", w.Code)
+	}
+	if *calls != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", *calls)
+	}
+
+	// Other queries, callers and lists have their own responses.
+	serveCached(rs, http.MethodGet, "/lists/a/items?page=2", "")
+	serveCached(rs, http.MethodGet, "/lists/a/items", "alice")
+	serveCached(rs, http.MethodGet, "/lists/b/items", "")
+	if *calls != 4 {
+		t.Fatalf("expected a run per query, caller and list, ran %d times", *calls)
+	}
+
+	// Errors are not stored.
+	serveCached(rs, http.MethodGet, "/lists/a/items?fail=1", "")
+	if w := serveCached(rs, http.MethodGet, "/lists/a/items?fail=1", ""); w.Code != http.StatusNotFound || *calls != 6 {
+		t.Fatalf("expected errors to run the handler every time, got %d after %d runs", w.Code, *calls)
+	}
+
+	// no-cache refreshes the stored response.
+	req = httptest.NewRequest(http.MethodGet, "/lists/a/items", nil)
+	req.Header.Set("Cache-Control", "no-cache")
+	fresh := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(fresh, req)
+	if fresh.Header().Get(CacheStatusHeader) != "MISS" || fresh.Body.String() == first.Body.String() {
+		t.Fatalf("expected no-cache to run the handler, got %q: %s", fresh.Header().Get(CacheStatusHeader), fresh.Body.String())
+	}
+	if w := serveCached(rs, http.MethodGet, "/lists/a/items", ""); w.Body.String() != fresh.Body.String() {
+		t.Fatalf("expected the refreshed response to be stored, got %s", w.Body.String())
+	}
+}
+
+func TestCacheFor_InvalidatedBySuccessfulWrites(t *testing.T) {
+	rs, calls := newCacheTestRouter(t, respcache.NewMemoryStore())
+
+	serveCached(rs, http.MethodGet, "/lists/a/items", "")
+	serveCached(rs, http.MethodGet, "/lists/b/items", "")
+
+	if w := serveCached(rs, http.MethodPost, "/lists/a/items?fail=1", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if w := serveCached(rs, http.MethodGet, "/lists/a/items", ""); w.Header().Get(CacheStatusHeader) != "HIT" {
+		t.Fatalf("expected a failed write to keep the stored response, got %q", w.Header().Get(CacheStatusHeader))
+	}
+
+	if w := serveCached(rs, http.MethodPost, "/lists/a/items", ""); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	if w := serveCached(rs, http.MethodGet, "/lists/a/items", "alice"); w.Header().Get(CacheStatusHeader) != "MISS" {
+		t.Fatalf("expected the write to evict every caller's response, got %q", w.Header().Get(CacheStatusHeader))
+	}
+	if w := serveCached(rs, http.MethodGet, "/lists/a/items", ""); w.Header().Get(CacheStatusHeader) != "MISS" {
+		t.Fatalf("expected the write to evict the list, got %q", w.Header().Get(CacheStatusHeader))
+	}
+	if w := serveCached(rs, http.MethodGet, "/lists/b/items", ""); w.Header().Get(CacheStatusHeader) != "HIT" {
+		t.Fatalf("expected other lists to stay cached, got %q", w.Header().Get(CacheStatusHeader))
+	}
+	if *calls != 4 {
+		t.Fatalf("expected 4 runs, got %d", *calls)
+	}
+}
+
+func TestCacheFor_WithoutStore(t *testing.T) {
+	rs, calls := newCacheTestRouter(t, nil)
+
+	serveCached(rs, http.MethodGet, "/lists/a/items", "")
+	w := serveCached(rs, http.MethodGet, "/lists/a/items", "")
+	if w.Code != http.StatusOK || w.Header().Get(CacheStatusHeader) != "" || *calls != 2 {
+		t.Fatalf("expected every request to run the handler, got %d %q after %d runs", w.Code, w.Header().Get(CacheStatusHeader), *calls)
+	}
+	if w := serveCached(rs, http.MethodPost, "/lists/a/items", ""); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+}
@@ -59,8 +59,12 @@ func (routerService *RouterService) bindHandlerRateLimiter(path, method string,
 	routerService.bindOverrideRateLimiter(key, limiter)
 }
 
-func createHandler(handler HandlerFunction) MiddlewareFunc {
+func (routerService *RouterService) createHandler(route *Route, handler HandlerFunction) MiddlewareFunc {
 	return func(c *RequestContext) {
+		// Read per request: StrictJSON is called after the route is added.
+		if routerService.strictJSONFor(route) {
+			c.Set(strictJSONContextKey, true)
+		}
 		result := handler(c)
 
 		if result == nil {
@@ -68,11 +72,26 @@ func createHandler(handler HandlerFunction) MiddlewareFunc {
 			return
 		}
 
-		c.JSON(result.StatusCode, result.ToJSON())
+		result = routerService.maskFields(c, result)
+
+		for key, value := range result.headers {
+			c.Header(key, value)
+		}
+		if writeNotModified(c, result) {
+			return
+		}
+
+		if result.write != nil {
+			result.write(c, result.StatusCode)
+			routerService.checkBudget(c, routerService.budgetFor(route))
+			return
+		}
+
+		routerService.writeJSON(c, route, result)
 	}
 }
 
-func NewRESTController(name, mountPoint string, prepare func(*RouterService, *RESTController)) *RESTController {
+func NewRESTController(name, mountPoint string, prepare func(RouteRegistrar, *RESTController)) *RESTController {
 	mountPoint = strings.ReplaceAll("/"+mountPoint, "//", "/")
 
 	return &RESTController{
@@ -83,7 +102,7 @@ func NewRESTController(name, mountPoint string, prepare func(*RouterService, *RE
 	}
 }
 
-func NewVersionedRESTController(name, version, mountPoint string, prepare func(*RouterService, *RESTController)) *RESTController {
+func NewVersionedRESTController(name, version, mountPoint string, prepare func(RouteRegistrar, *RESTController)) *RESTController {
 	// Prefixing the version to the mount point at controller creation clarifies routing and leaves no room for ambiguity.
 	finalPath := strings.ReplaceAll("/"+version+"/"+mountPoint, "//", "/")
 
@@ -95,6 +114,22 @@ func NewVersionedRESTController(name, version, mountPoint string, prepare func(*
 	}
 }
 
+// Name returns the name the controller was created with.
+func (controller *RESTController) Name() string {
+	return controller.name
+}
+
+// Path returns the full path of a route registered at relativePath.
+func (controller *RESTController) Path(relativePath string) string {
+	return normalizePath(controller, relativePath)
+}
+
+// Register runs the controller's prepare function against registrar.
+// MountController registers with the RouterService; tests can pass a fake.
+func (controller *RESTController) Register(registrar RouteRegistrar) {
+	controller.prepare(registrar, controller)
+}
+
 func (controller *RESTController) RateLimitWith(routerService *RouterService, limiter ratelimit.RateLimiter) *RESTController {
 	routerService.bindOverrideRateLimiter(controller.mountPoint, limiter)
 	return controller
@@ -106,13 +141,15 @@ func (routerService *RouterService) AddPostHandler(
 	path string,
 	handler HandlerFunction,
 	middlewares ...MiddlewareFunc,
-) {
+) *Route {
 	controller.handlerCount++
 	mountPoint := normalizePath(controller, path)
 	controller.bindHandlerToController(routerService, mountPoint, "POST")
 	routerService.bindHandlerRateLimiter(mountPoint, "POST", limiter)
-	routerService.engine.POST(mountPoint, append(middlewares, createHandler(handler))...)
+	route := routerService.recordRoute(controller, "POST", mountPoint)
+	routerService.engine.POST(mountPoint, append(routerService.routeMiddlewares(controller, "POST", middlewares), routerService.createHandler(route, handler))...)
 	routerService.logger.Debug("Handler registered", "method", "POST", "path", mountPoint)
+	return route
 }
 
 func (routerService *RouterService) AddGetHandler(
@@ -121,13 +158,15 @@ func (routerService *RouterService) AddGetHandler(
 	path string,
 	handler HandlerFunction,
 	middlewares ...MiddlewareFunc,
-) {
+) *Route {
 	controller.handlerCount++
 	mountPoint := normalizePath(controller, path)
 	controller.bindHandlerToController(routerService, mountPoint, "GET")
 	routerService.bindHandlerRateLimiter(mountPoint, "GET", limiter)
-	routerService.engine.GET(mountPoint, append(middlewares, createHandler(handler))...)
+	route := routerService.recordRoute(controller, "GET", mountPoint)
+	routerService.engine.GET(mountPoint, append(routerService.routeMiddlewares(controller, "GET", middlewares), routerService.createHandler(route, handler))...)
 	routerService.logger.Debug("Handler registered", "method", "GET", "path", mountPoint)
+	return route
 }
 
 func (routerService *RouterService) AddPutHandler(
@@ -136,13 +175,15 @@ func (routerService *RouterService) AddPutHandler(
 	path string,
 	handler HandlerFunction,
 	middlewares ...MiddlewareFunc,
-) {
+) *Route {
 	controller.handlerCount++
 	mountPoint := normalizePath(controller, path)
 	controller.bindHandlerToController(routerService, mountPoint, "PUT")
 	routerService.bindHandlerRateLimiter(mountPoint, "PUT", limiter)
-	routerService.engine.PUT(mountPoint, append(middlewares, createHandler(handler))...)
+	route := routerService.recordRoute(controller, "PUT", mountPoint)
+	routerService.engine.PUT(mountPoint, append(routerService.routeMiddlewares(controller, "PUT", middlewares), routerService.createHandler(route, handler))...)
 	routerService.logger.Debug("Handler registered", "method", "PUT", "path", mountPoint)
+	return route
 }
 
 func (routerService *RouterService) AddDeleteHandler(
@@ -151,13 +192,15 @@ func (routerService *RouterService) AddDeleteHandler(
 	path string,
 	handler HandlerFunction,
 	middlewares ...MiddlewareFunc,
-) {
+) *Route {
 	controller.handlerCount++
 	mountPoint := normalizePath(controller, path)
 	controller.bindHandlerToController(routerService, mountPoint, "DELETE")
 	routerService.bindHandlerRateLimiter(mountPoint, "DELETE", limiter)
-	routerService.engine.DELETE(mountPoint, append(middlewares, createHandler(handler))...)
+	route := routerService.recordRoute(controller, "DELETE", mountPoint)
+	routerService.engine.DELETE(mountPoint, append(routerService.routeMiddlewares(controller, "DELETE", middlewares), routerService.createHandler(route, handler))...)
 	routerService.logger.Debug("Handler registered", "method", "DELETE", "path", mountPoint)
+	return route
 }
 
 func (routerService *RouterService) AddPatchHandler(
@@ -166,13 +209,15 @@ func (routerService *RouterService) AddPatchHandler(
 	path string,
 	handler HandlerFunction,
 	middlewares ...MiddlewareFunc,
-) {
+) *Route {
 	controller.handlerCount++
 	mountPoint := normalizePath(controller, path)
 	controller.bindHandlerToController(routerService, mountPoint, "PATCH")
 	routerService.bindHandlerRateLimiter(mountPoint, "PATCH", limiter)
-	routerService.engine.PATCH(mountPoint, append(middlewares, createHandler(handler))...)
+	route := routerService.recordRoute(controller, "PATCH", mountPoint)
+	routerService.engine.PATCH(mountPoint, append(routerService.routeMiddlewares(controller, "PATCH", middlewares), routerService.createHandler(route, handler))...)
 	routerService.logger.Debug("Handler registered", "method", "PATCH", "path", mountPoint)
+	return route
 }
 
 func (routerService *RouterService) AddHeadHandler(
@@ -181,11 +226,30 @@ func (routerService *RouterService) AddHeadHandler(
 	path string,
 	handler HandlerFunction,
 	middlewares ...MiddlewareFunc,
-) {
+) *Route {
 	controller.handlerCount++
 	mountPoint := normalizePath(controller, path)
 	controller.bindHandlerToController(routerService, mountPoint, "HEAD")
 	routerService.bindHandlerRateLimiter(mountPoint, "HEAD", limiter)
-	routerService.engine.HEAD(mountPoint, append(middlewares, createHandler(handler))...)
+	route := routerService.recordRoute(controller, "HEAD", mountPoint)
+	routerService.engine.HEAD(mountPoint, append(routerService.routeMiddlewares(controller, "HEAD", middlewares), routerService.createHandler(route, handler))...)
 	routerService.logger.Debug("Handler registered", "method", "HEAD", "path", mountPoint)
+	return route
+}
+
+func (routerService *RouterService) AddOptionsHandler(
+	controller *RESTController,
+	limiter ratelimit.RateLimiter,
+	path string,
+	handler HandlerFunction,
+	middlewares ...MiddlewareFunc,
+) *Route {
+	controller.handlerCount++
+	mountPoint := normalizePath(controller, path)
+	controller.bindHandlerToController(routerService, mountPoint, "OPTIONS")
+	routerService.bindHandlerRateLimiter(mountPoint, "OPTIONS", limiter)
+	route := routerService.recordRoute(controller, "OPTIONS", mountPoint)
+	routerService.engine.OPTIONS(mountPoint, append(routerService.routeMiddlewares(controller, "OPTIONS", middlewares), routerService.createHandler(route, handler))...)
+	routerService.logger.Debug("Handler registered", "method", "OPTIONS", "path", mountPoint)
+	return route
 }
@@ -0,0 +1,345 @@
+package router
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig controls the CORS headers of responses to browsers on allowed
+// origins. Requests from other origins get no CORS headers, so browsers
+// refuse to hand the response to the page.
+type CORSConfig struct {
+	// AllowedOrigins are full origins such as https://app.example.com, or
+	// "*" for any origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders are the request headers a page may send; "*" allows
+	// whatever the preflight asks for.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers a page may read.
+	ExposedHeaders []string
+	// AllowCredentials lets pages send cookies and read the response. It is
+	// ignored when AllowedOrigins contains "*": any site could otherwise
+	// make credentialed requests.
+	AllowCredentials bool
+	// MaxAge is how long browsers cache a preflight response; zero leaves
+	// it to the browser (5 seconds in most).
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig allows the methods and headers the built-in routes use
+// and exposes the headers clients of long-running operations, idempotency
+// keys, conditional requests, regions and resumable uploads read. It allows no origin.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{
+			"Content-Type", "Content-Length", "Accept-Encoding", "Accept", "Accept-Language", "Origin",
+			"Authorization", "Cache-Control", "X-Requested-With", "X-CSRF-Token", "X-Dry-Run", "Idempotency-Key", "If-None-Match",
+			"Tus-Resumable", "Upload-Length", "Upload-Metadata", "Upload-Offset",
+		},
+		ExposedHeaders: []string{
+			"Location", "Link", "ETag", "Operation-Location", "Idempotent-Replayed", "X-Region", "X-Home-Region",
+			"Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size",
+			"Upload-Offset", "Upload-Length", "Upload-Metadata", "Upload-Expires",
+		},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+}
+
+// CORSConfigFromEnv starts from DefaultCORSConfig and reads:
+//
+//	CORS_ALLOWED_ORIGIN      comma-separated origins, or * (default: none)
+//	CORS_ALLOWED_METHODS     comma-separated methods
+//	CORS_ALLOWED_HEADERS     comma-separated request headers, or *
+//	CORS_EXPOSED_HEADERS     comma-separated response headers
+//	CORS_ALLOW_CREDENTIALS   true or false (default true)
+//	CORS_MAX_AGE             preflight cache duration (default 10m)
+func CORSConfigFromEnv(logger *log.Logger) *CORSConfig {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = splitList(utils.GetEnvTrimmed("CORS_ALLOWED_ORIGIN"))
+	if v := splitList(utils.GetEnvTrimmed("CORS_ALLOWED_METHODS")); v != nil {
+		cfg.AllowedMethods = v
+	}
+	if v := splitList(utils.GetEnvTrimmed("CORS_ALLOWED_HEADERS")); v != nil {
+		cfg.AllowedHeaders = v
+	}
+	if v := splitList(utils.GetEnvTrimmed("CORS_EXPOSED_HEADERS")); v != nil {
+		cfg.ExposedHeaders = v
+	}
+	cfg.AllowCredentials = envBool("CORS_ALLOW_CREDENTIALS", true)
+	if v := utils.GetEnvTrimmed("CORS_MAX_AGE"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= 0 {
+			cfg.MaxAge = parsed
+		} else {
+			logger.Warn("Invalid CORS_MAX_AGE; using default", "value", v, "default", cfg.MaxAge)
+		}
+	}
+
+	if slices.Contains(cfg.AllowedOrigins, "*") && cfg.AllowCredentials {
+		logger.Warn("CORS_ALLOWED_ORIGIN is *; credentials are not allowed for cross-origin requests")
+	}
+	if len(cfg.AllowedOrigins) == 0 {
+		logger.Info("CORS_ALLOWED_ORIGIN not set; cross-origin requests are denied")
+	}
+	return &cfg
+}
+
+// CORS overrides the router's CORS configuration for the route's path, for
+// every method registered on it. Nil lists and a zero MaxAge keep the
+// router's values; AllowCredentials always comes from cfg. It returns the
+// route for chaining.
+func (route *Route) CORS(cfg CORSConfig) *Route {
+	route.cors = &cfg
+	return route
+}
+
+// corsPolicy is a CORSConfig with its header values joined once.
+type corsPolicy struct {
+	origins       map[string]bool
+	anyOrigin     bool
+	methods       map[string]bool
+	allowMethods  string
+	anyHeader     bool
+	allowHeaders  string
+	exposeHeaders string
+	credentials   bool
+	maxAge        string
+}
+
+func newCORSPolicy(cfg CORSConfig) *corsPolicy {
+	policy := &corsPolicy{
+		origins:       make(map[string]bool, len(cfg.AllowedOrigins)),
+		methods:       make(map[string]bool, len(cfg.AllowedMethods)),
+		allowMethods:  strings.Join(cfg.AllowedMethods, ", "),
+		anyHeader:     slices.Contains(cfg.AllowedHeaders, "*"),
+		allowHeaders:  strings.Join(cfg.AllowedHeaders, ", "),
+		exposeHeaders: strings.Join(cfg.ExposedHeaders, ", "),
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			policy.anyOrigin = true
+		}
+		policy.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	for _, method := range cfg.AllowedMethods {
+		policy.methods[strings.ToUpper(method)] = true
+	}
+	policy.credentials = cfg.AllowCredentials && !policy.anyOrigin
+	if cfg.MaxAge > 0 {
+		policy.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+	return policy
+}
+
+// mergeCORSConfig fills the empty fields of override from base.
+func mergeCORSConfig(base, override CORSConfig) CORSConfig {
+	if override.AllowedOrigins == nil {
+		override.AllowedOrigins = base.AllowedOrigins
+	}
+	if override.AllowedMethods == nil {
+		override.AllowedMethods = base.AllowedMethods
+	}
+	if override.AllowedHeaders == nil {
+		override.AllowedHeaders = base.AllowedHeaders
+	}
+	if override.ExposedHeaders == nil {
+		override.ExposedHeaders = base.ExposedHeaders
+	}
+	if override.MaxAge == 0 {
+		override.MaxAge = base.MaxAge
+	}
+	return override
+}
+
+// routeCORS caches the policy of a route with a CORS override.
+type routeCORS struct {
+	once   sync.Once
+	policy *corsPolicy
+}
+
+// corsPolicyFor returns the policy of the route serving the request. A
+// preflight has no route of its own, so it takes the route its path matches,
+// preferring static segments over parameters as gin does.
+func (routerService *RouterService) corsPolicyFor(c *gin.Context) *corsPolicy {
+	path := c.FullPath()
+	if path == "" {
+		path = routerService.routePaths.match(splitRoutePath(c.Request.URL.Path))
+	}
+
+	var matched *Route
+	for _, route := range routerService.routesByPath[path] {
+		if route.cors != nil {
+			matched = route
+			break
+		}
+	}
+	if matched == nil {
+		return routerService.cors
+	}
+	matched.corsPolicy.once.Do(func() {
+		matched.corsPolicy.policy = newCORSPolicy(mergeCORSConfig(routerService.corsConfig, *matched.cors))
+	})
+	return matched.corsPolicy.policy
+}
+
+// indexRoutePath records route under its path, so corsPolicyFor finds it
+// without scanning every route.
+func (routerService *RouterService) indexRoutePath(route *Route) {
+	if _, ok := routerService.routesByPath[route.Path]; !ok {
+		routerService.routePaths.insert(route.Path)
+	}
+	routerService.routesByPath[route.Path] = append(routerService.routesByPath[route.Path], route)
+}
+
+// routePathNode is a segment of the registered route paths, with :param and
+// *wildcard segments, for matching request paths to them.
+type routePathNode struct {
+	static map[string]*routePathNode
+	param  *routePathNode
+	// pattern and wildcard are the paths of routes that end at this node,
+	// or continue with a *wildcard segment.
+	pattern  string
+	wildcard string
+}
+
+func newRoutePathNode() *routePathNode {
+	return &routePathNode{static: make(map[string]*routePathNode)}
+}
+
+func (n *routePathNode) insert(pattern string) {
+	for _, segment := range splitRoutePath(pattern) {
+		switch {
+		case strings.HasPrefix(segment, "*"):
+			n.wildcard = pattern
+			return
+		case strings.HasPrefix(segment, ":"):
+			if n.param == nil {
+				n.param = newRoutePathNode()
+			}
+			n = n.param
+		default:
+			child, ok := n.static[segment]
+			if !ok {
+				child = newRoutePathNode()
+				n.static[segment] = child
+			}
+			n = child
+		}
+	}
+	n.pattern = pattern
+}
+
+// match returns the path of the route segments match, trying static
+// segments before parameters and wildcards, or "" when none does.
+func (n *routePathNode) match(segments []string) string {
+	if len(segments) == 0 {
+		if n.pattern != "" {
+			return n.pattern
+		}
+		return n.wildcard
+	}
+	if child, ok := n.static[segments[0]]; ok {
+		if pattern := child.match(segments[1:]); pattern != "" {
+			return pattern
+		}
+	}
+	if n.param != nil {
+		if pattern := n.param.match(segments[1:]); pattern != "" {
+			return pattern
+		}
+	}
+	return n.wildcard
+}
+
+func splitRoutePath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func (routerService *RouterService) initCORS(cfg *CORSConfig) {
+	if cfg == nil {
+		cfg = CORSConfigFromEnv(routerService.logger)
+	}
+	routerService.corsConfig = *cfg
+	routerService.cors = newCORSPolicy(*cfg)
+}
+
+// corsMiddleware adds CORS headers for allowed origins and answers preflight
+// requests. Requests without an Origin header are not cross-origin and pass
+// through untouched.
+func (routerService *RouterService) corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		policy := routerService.corsPolicyFor(c)
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		if !policy.anyOrigin && !policy.origins[origin] {
+			routerService.logger.Warn("CORS origin not allowed", "origin", origin)
+			c.Next()
+			return
+		}
+
+		if policy.anyOrigin {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.credentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		requestMethod := c.GetHeader("Access-Control-Request-Method")
+		if c.Request.Method != http.MethodOptions || requestMethod == "" {
+			if policy.exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", policy.exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		// Preflight: the browser asks before sending the real request.
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		if !policy.methods[strings.ToUpper(requestMethod)] {
+			c.AbortWithStatus(apperrors.StatusNoContent)
+			return
+		}
+		header.Set("Access-Control-Allow-Methods", policy.allowMethods)
+		if policy.anyHeader {
+			if requested := c.GetHeader("Access-Control-Request-Headers"); requested != "" {
+				header.Set("Access-Control-Allow-Headers", requested)
+			}
+		} else if policy.allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", policy.allowHeaders)
+		}
+		if policy.maxAge != "" {
+			header.Set("Access-Control-Max-Age", policy.maxAge)
+		}
+		c.AbortWithStatus(apperrors.StatusNoContent)
+	}
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,184 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+)
+
+func newCORSTestRouter(t *testing.T, cfg CORSConfig) *RouterService {
+	t.Helper()
+	rs := CreateRouterService(log.NewLoggerWithJSONOutput(), nil, &RouterConfig{
+		RateLimitRequests: 1000,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+		CORS:              &cfg,
+	})
+	rs.MountController(NewRESTController("Widgets", "/widgets", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(nil, "ok")
+		})
+		rs.AddGetHandler(c, nil, "/:id", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(nil, "ok")
+		})
+		rs.AddPostHandler(c, nil, "/public", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(nil, "ok")
+		}).CORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"POST"}})
+	}))
+	return rs
+}
+
+func serveCORS(rs *RouterService, method, path, origin string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	return w
+}
+
+func testCORSConfig() CORSConfig {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"https://app.example.com"}
+	return cfg
+}
+
+func TestCORS_AllowedOrigin(t *testing.T) {
+	rs := newCORSTestRouter(t, testCORSConfig())
+
+	w := serveCORS(rs, http.MethodGet, "/widgets", "https://app.example.com", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("expected the origin to be allowed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("expected credentials, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got == "" {
+		t.Fatal("expected exposed headers")
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Fatalf("expected Vary: Origin, got %q", got)
+	}
+
+	w = serveCORS(rs, http.MethodGet, "/widgets", "https://evil.example.com", nil)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no CORS headers for another origin, got %q", got)
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	cfg := testCORSConfig()
+	cfg.MaxAge = time.Hour
+	rs := newCORSTestRouter(t, cfg)
+
+	w := serveCORS(rs, http.MethodOptions, "/widgets/42", "https://app.example.com", map[string]string{
+		"Access-Control-Request-Method": "GET",
+	})
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "3600" {
+		t.Fatalf("expected a one hour preflight cache, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got == "" {
+		t.Fatal("expected allowed methods")
+	}
+
+	// Methods outside the list get no Allow-Methods, so the browser refuses.
+	cfg.AllowedMethods = []string{"GET"}
+	rs = newCORSTestRouter(t, cfg)
+	w = serveCORS(rs, http.MethodOptions, "/widgets", "https://app.example.com", map[string]string{
+		"Access-Control-Request-Method": "DELETE",
+	})
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "" {
+		t.Fatalf("expected DELETE to be refused, got %q", got)
+	}
+}
+
+func TestCORS_WildcardNeverAllowsCredentials(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"*"}
+	rs := newCORSTestRouter(t, cfg)
+
+	w := serveCORS(rs, http.MethodGet, "/widgets", "https://anyone.example.com", nil)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected *, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("expected no credentials with a wildcard origin, got %q", got)
+	}
+}
+
+func TestCORS_RouteOverride(t *testing.T) {
+	rs := newCORSTestRouter(t, testCORSConfig())
+
+	// The override allows any origin on its route only.
+	w := serveCORS(rs, http.MethodOptions, "/widgets/public", "https://partner.example.com", map[string]string{
+		"Access-Control-Request-Method": "POST",
+	})
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected the override to allow any origin, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "POST" {
+		t.Fatalf("expected the override's methods, got %q", got)
+	}
+	// It inherits the router's headers.
+	if got := w.Header().Get("Access-Control-Allow-Headers"); got == "" {
+		t.Fatal("expected the router's allowed headers")
+	}
+
+	w = serveCORS(rs, http.MethodGet, "/widgets/7", "https://partner.example.com", nil)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected other routes to keep the router's origins, got %q", got)
+	}
+}
+
+func TestRoutePathNode_Match(t *testing.T) {
+	root := newRoutePathNode()
+	for _, pattern := range []string{"/", "/widgets", "/widgets/:id", "/widgets/public", "/widgets/:id/parts", "/files/*path"} {
+		root.insert(pattern)
+	}
+
+	for path, want := range map[string]string{
+		"/":                 "/",
+		"/widgets":          "/widgets",
+		"/widgets/7":        "/widgets/:id",
+		"/widgets/public":   "/widgets/public",
+		"/widgets/7/parts":  "/widgets/:id/parts",
+		"/files/a/b.txt":    "/files/*path",
+		"/widgets/7/wheels": "",
+		"/gadgets":          "",
+	} {
+		if got := root.match(splitRoutePath(path)); got != want {
+			t.Errorf("%s: expected %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestCORSConfigFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGIN", " https://a.example.com, https://b.example.com ,")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "false")
+	t.Setenv("CORS_MAX_AGE", "30s")
+	t.Setenv("CORS_EXPOSED_HEADERS", "X-Total")
+
+	cfg := CORSConfigFromEnv(log.NewLoggerWithJSONOutput())
+	if len(cfg.AllowedOrigins) != 2 || cfg.AllowedOrigins[1] != "https://b.example.com" {
+		t.Fatalf("unexpected origins %q", cfg.AllowedOrigins)
+	}
+	if cfg.AllowCredentials || cfg.MaxAge != 30*time.Second {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if len(cfg.ExposedHeaders) != 1 || len(cfg.AllowedMethods) == 0 {
+		t.Fatalf("expected exposed headers from env and default methods, got %+v", cfg)
+	}
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CSRFCookieName holds the token issued by GET /v1/csrf-token.
+	CSRFCookieName = "csrf_token"
+	// CSRFHeader must repeat the cookie's token on unsafe requests to
+	// controllers that call RequireCSRF.
+	CSRFHeader = "X-CSRF-Token"
+
+	csrfTokenBytes = 32
+)
+
+// CSRFToken is returned by the token endpoint. The cookie is HttpOnly, so
+// clients read the token from here and send it back in HeaderName.
+type CSRFToken struct {
+	Token      string `json:"token"`
+	HeaderName string `json:"header_name"`
+}
+
+// RequireCSRF makes unsafe requests (POST, PUT, PATCH, DELETE) to the
+// controller's handlers carry the csrf_token cookie's value in the
+// X-CSRF-Token header. Callers authenticated with a bearer token or a client
+// certificate, and requests with an API key the router's APIKeyValidator
+// accepts, are exempt: browsers do not attach those to cross-site requests
+// on their own. Other Authorization headers, such as cached Basic
+// credentials, are not. Call it before the controller is mounted, and mount
+// NewCSRFController so browsers can get a token.
+func (controller *RESTController) RequireCSRF() *RESTController {
+	controller.requireCSRF = true
+	return controller
+}
+
+// csrfMiddleware checks the double-submitted token. A cross-site page can make
+// the browser send the cookie but cannot read it to set the header. It runs
+// after authentication, so the principal it exempts has been verified.
+func csrfMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			c.Next()
+			return
+		}
+		if principal.FromContext(c.Request.Context()) != nil || validAPIKey(c) != "" {
+			c.Next()
+			return
+		}
+
+		cookie, err := c.Cookie(CSRFCookieName)
+		header := c.GetHeader(CSRFHeader)
+		if err != nil || !validCSRFToken(cookie) || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			GetLogger(c).Warn("Rejected request without a valid CSRF token", "cookie_present", err == nil, "header_present", header != "")
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResult(http.StatusForbidden, "Missing or invalid CSRF token", nil).ToJSON())
+			return
+		}
+		c.Next()
+	}
+}
+
+func validCSRFToken(token string) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	return err == nil && len(raw) == csrfTokenBytes
+}
+
+// NewCSRFController serves GET /v1/csrf-token, which sets the csrf_token
+// cookie and returns its value. A valid cookie is reused so tabs sharing it
+// keep working.
+func NewCSRFController() *RESTController {
+	return NewVersionedRESTController("CSRFController", "v1", "/csrf-token", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			token, err := ctx.Cookie(CSRFCookieName)
+			if err != nil || !validCSRFToken(token) {
+				raw := make([]byte, csrfTokenBytes)
+				if _, err := rand.Read(raw); err != nil {
+					GetLogger(ctx).Error("Failed to generate CSRF token", "error", err)
+					return InternalServerErrorResult("Failed to generate CSRF token")
+				}
+				token = base64.RawURLEncoding.EncodeToString(raw)
+			}
+
+			http.SetCookie(ctx.Writer, &http.Cookie{
+				Name:     CSRFCookieName,
+				Value:    token,
+				Path:     "/",
+				Secure:   requestIsHTTPS(ctx),
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			ctx.Header("Cache-Control", "no-store")
+			return OKResult(CSRFToken{Token: token, HeaderName: CSRFHeader}, "CSRF token issued")
+		}).Describe(OperationDoc{
+			Summary:  "Issue a CSRF token for cookie-authenticated browser clients",
+			Response: CSRFToken{},
+		})
+	})
+}
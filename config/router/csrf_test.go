@@ -0,0 +1,163 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCSRFTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	t.Setenv("AUTH_JWT_HMAC_SECRET", testJWTSecret)
+	rs := newTestRouterService(t)
+	rs.SetAPIKeyValidator("X-API-Key", func(_ context.Context, key string) bool {
+		return key == "valid-key"
+	})
+	ok := func(ctx *RequestContext) *ServiceResult {
+		return OKResult(nil, "ok")
+	}
+	rs.MountController(NewCSRFController())
+	rs.MountController(NewRESTController("Forms", "/forms", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "", ok)
+		rs.AddPostHandler(c, nil, "", ok)
+	}).RequireCSRF())
+	rs.MountController(NewRESTController("Account", "/account", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddPostHandler(c, nil, "", ok)
+	}).RequireAuth().RequireCSRF())
+	return rs
+}
+
+func issueCSRFToken(t *testing.T, rs *RouterService, cookie *http.Cookie) (*http.Cookie, CSRFToken) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/v1/csrf-token", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Data CSRFToken `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CSRFCookieName {
+		t.Fatalf("expected the %s cookie, got %v", CSRFCookieName, cookies)
+	}
+	return cookies[0], body.Data
+}
+
+func postForm(rs *RouterService, cookie *http.Cookie, header, authorization string) int {
+	return post(rs, "/forms", cookie, header, http.Header{"Authorization": {authorization}})
+}
+
+func post(rs *RouterService, target string, cookie *http.Cookie, header string, extra http.Header) int {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	if header != "" {
+		req.Header.Set(CSRFHeader, header)
+	}
+	for name, values := range extra {
+		if values[0] != "" {
+			req.Header[name] = values
+		}
+	}
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestCSRF_IssuesTokenInCookieAndBody(t *testing.T) {
+	rs := newCSRFTestRouter(t)
+
+	cookie, token := issueCSRFToken(t, rs, nil)
+	if token.Token == "" || token.Token != cookie.Value {
+		t.Fatalf("expected body token to match cookie, got %q and %q", token.Token, cookie.Value)
+	}
+	if token.HeaderName != CSRFHeader {
+		t.Fatalf("expected header name %q, got %q", CSRFHeader, token.HeaderName)
+	}
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("expected an HttpOnly SameSite=Strict cookie, got %+v", cookie)
+	}
+
+	// A valid cookie is reused.
+	again, _ := issueCSRFToken(t, rs, cookie)
+	if again.Value != cookie.Value {
+		t.Fatalf("expected the token to be reused, got %q and %q", again.Value, cookie.Value)
+	}
+}
+
+func TestCSRF_RequiresMatchingHeaderOnUnsafeRequests(t *testing.T) {
+	rs := newCSRFTestRouter(t)
+	cookie, token := issueCSRFToken(t, rs, nil)
+
+	if code := postForm(rs, cookie, token.Token, ""); code != http.StatusOK {
+		t.Fatalf("expected 200 with matching token, got %d", code)
+	}
+	if code := postForm(rs, cookie, "", ""); code != http.StatusForbidden {
+		t.Fatalf("expected 403 without header, got %d", code)
+	}
+	if code := postForm(rs, nil, token.Token, ""); code != http.StatusForbidden {
+		t.Fatalf("expected 403 without cookie, got %d", code)
+	}
+	other, _ := issueCSRFToken(t, rs, nil)
+	if code := postForm(rs, cookie, other.Value, ""); code != http.StatusForbidden {
+		t.Fatalf("expected 403 with mismatched token, got %d", code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/forms", nil)
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected safe methods to pass, got %d", w.Code)
+	}
+}
+
+func TestCSRF_ExemptsAuthenticatedBearerTokens(t *testing.T) {
+	rs := newCSRFTestRouter(t)
+	token := testToken(t, map[string]any{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()})
+
+	if code := post(rs, "/account", nil, "", http.Header{"Authorization": {"Bearer " + token}}); code != http.StatusOK {
+		t.Fatalf("expected an authenticated bearer token to be exempt, got %d", code)
+	}
+	// Headers that were not authenticated do not exempt the request.
+	for _, authorization := range []string{"Bearer some-token", "Basic YWxpY2U6c2VjcmV0", "x"} {
+		if code := postForm(rs, nil, "", authorization); code != http.StatusForbidden {
+			t.Fatalf("expected Authorization %q not to be exempt, got %d", authorization, code)
+		}
+	}
+
+	// Controllers without RequireCSRF are unaffected.
+	mountTestController(rs)
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for unprotected controller, got %d", w.Code)
+	}
+}
+
+func TestCSRF_ExemptsValidAPIKeys(t *testing.T) {
+	rs := newCSRFTestRouter(t)
+
+	if code := post(rs, "/forms", nil, "", http.Header{"X-Api-Key": {"valid-key"}}); code != http.StatusOK {
+		t.Fatalf("expected a valid API key to be exempt, got %d", code)
+	}
+	if code := post(rs, "/forms", nil, "", http.Header{"X-Api-Key": {"made-up"}}); code != http.StatusForbidden {
+		t.Fatalf("expected an unknown API key not to be exempt, got %d", code)
+	}
+}
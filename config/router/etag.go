@@ -0,0 +1,99 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// initETags reads ETAGS_ENABLED. When set, successful JSON responses to GET
+// and HEAD requests are tagged with a hash of their body, and requests whose
+// If-None-Match carries the tag are answered 304 without it. Handlers that
+// set their own tag with WithETag are answered 304 either way.
+func (routerService *RouterService) initETags() {
+	routerService.etags = envBool("ETAGS_ENABLED", true)
+}
+
+// WithETag tags the result with version, a value that changes whenever the
+// response does, such as a row version. The response body is then not
+// hashed, and a request whose If-None-Match carries the tag is answered 304.
+// version must not contain double quotes.
+func (result *ServiceResult) WithETag(version string) *ServiceResult {
+	return result.WithHeader("ETag", weakETag(version))
+}
+
+// NotModified returns a 304 result when the GET or HEAD request's
+// If-None-Match carries the tag WithETag would give version, and nil
+// otherwise. Handlers call it before the work of building a response the
+// client already has.
+func NotModified(ctx *RequestContext, version string) *ServiceResult {
+	tag := weakETag(version)
+	if !conditionalMethod(ctx.Request.Method) || !etagMatches(ctx.GetHeader("If-None-Match"), tag) {
+		return nil
+	}
+	return notModifiedResult().WithHeader("ETag", tag)
+}
+
+func notModifiedResult() *ServiceResult {
+	return &ServiceResult{
+		StatusCode: http.StatusNotModified,
+		write:      func(c *gin.Context, status int) { c.Status(status) },
+	}
+}
+
+// weakETag is a weak tag: equal tags promise the same content, not the same
+// bytes, which is all a JSON response re-encoded per request can promise.
+func weakETag(version string) string {
+	return `W/"` + version + `"`
+}
+
+// hashETag is the tag of a response body: the first 16 bytes of its SHA-256.
+func hashETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return weakETag(hex.EncodeToString(sum[:16]))
+}
+
+func conditionalMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// etagMatches reports whether an If-None-Match header carries tag or is
+// "*". Tags are compared weakly, as RFC 9110 requires for If-None-Match.
+func etagMatches(ifNoneMatch, tag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	opaque := strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified answers 304 when the result carries an ETag the GET or
+// HEAD request's If-None-Match matches. The tag is already set on c.
+func writeNotModified(c *RequestContext, result *ServiceResult) bool {
+	tag := result.headers["ETag"]
+	if tag == "" || result.StatusCode != http.StatusOK || !conditionalMethod(c.Request.Method) {
+		return false
+	}
+	if !etagMatches(c.GetHeader("If-None-Match"), tag) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// hashesBody reports whether the result's JSON body is hashed into its ETag.
+func (routerService *RouterService) hashesBody(c *RequestContext, result *ServiceResult) bool {
+	return routerService.etags &&
+		result.StatusCode == http.StatusOK &&
+		conditionalMethod(c.Request.Method) &&
+		result.headers["ETag"] == ""
+}
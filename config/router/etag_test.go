@@ -0,0 +1,104 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newETagTestRouter(t *testing.T) (*RouterService, *int) {
+	t.Helper()
+	rs := newTestRouterService(t)
+	built := 0
+	rs.MountController(NewRESTController("Tagged", "/tagged", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/hashed", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(map[string]string{"name": "first"}, "ok")
+		})
+		rs.AddGetHandler(c, nil, "/versioned", func(ctx *RequestContext) *ServiceResult {
+			if notModified := NotModified(ctx, "v7"); notModified != nil {
+				return notModified
+			}
+			built++
+			return OKResult(map[string]string{"name": "first"}, "ok").WithETag("v7")
+		})
+		rs.AddGetHandler(c, nil, "/missing", func(ctx *RequestContext) *ServiceResult {
+			return NotFoundResult("missing")
+		})
+		rs.AddPostHandler(c, nil, "/hashed", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(map[string]string{"name": "first"}, "ok")
+		})
+	}))
+	return rs, &built
+}
+
+func serveConditional(rs *RouterService, method, target, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	return w
+}
+
+func TestETags_HashedResponses(t *testing.T) {
+	rs, _ := newETagTestRouter(t)
+
+	first := serveConditional(rs, http.MethodGet, "/tagged/hashed", "")
+	tag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || len(tag) < 4 || tag[:3] != `W/"` {
+		t.Fatalf("expected 200 with a weak ETag, got %d %q", first.Code, tag)
+	}
+	if again := serveConditional(rs, http.MethodGet, "/tagged/hashed", "").Header().Get("ETag"); again != tag {
+		t.Fatalf("expected the same tag for the same body, got %q and %q", tag, again)
+	}
+
+	w := serveConditional(rs, http.MethodGet, "/tagged/hashed", `"other", `+tag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != tag {
+		t.Fatalf("expected an empty 304 carrying the tag, got %d %q %q", w.Code, w.Body.String(), w.Header().Get("ETag"))
+	}
+	// If-None-Match compares weakly, so the strong form matches too.
+	if w := serveConditional(rs, http.MethodGet, "/tagged/hashed", tag[2:]); w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for the strong form of the tag, got %d", w.Code)
+	}
+	if w := serveConditional(rs, http.MethodGet, "/tagged/hashed", `W/"stale"`); w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("expected 200 with a body for a stale tag, got %d", w.Code)
+	}
+
+	if w := serveConditional(rs, http.MethodPost, "/tagged/hashed", tag); w.Code != http.StatusOK || w.Header().Get("ETag") != "" {
+		t.Fatalf("expected POST to be neither tagged nor conditional, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := serveConditional(rs, http.MethodGet, "/tagged/missing", "*"); w.Code != http.StatusNotFound || w.Header().Get("ETag") != "" {
+		t.Fatalf("expected errors to be neither tagged nor conditional, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestETags_HandlerVersion(t *testing.T) {
+	rs, built := newETagTestRouter(t)
+
+	w := serveConditional(rs, http.MethodGet, "/tagged/versioned", "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `W/"v7"` {
+		t.Fatalf("expected 200 tagged with the handler's version, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	w = serveConditional(rs, http.MethodGet, "/tagged/versioned", `W/"v7"`)
+	if w.Code != http.StatusNotModified || w.Header().Get("ETag") != `W/"v7"` {
+		t.Fatalf("expected 304 carrying the handler's tag, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	if *built != 1 {
+		t.Fatalf("expected NotModified to skip building the response, built %d times", *built)
+	}
+}
+
+func TestETags_Disabled(t *testing.T) {
+	t.Setenv("ETAGS_ENABLED", "false")
+	rs, _ := newETagTestRouter(t)
+
+	if w := serveConditional(rs, http.MethodGet, "/tagged/hashed", "*"); w.Code != http.StatusOK || w.Header().Get("ETag") != "" {
+		t.Fatalf("expected no hashing when disabled, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	// Handler versions cost nothing to compare, so they still apply.
+	if w := serveConditional(rs, http.MethodGet, "/tagged/versioned", `W/"v7"`); w.Code != http.StatusNotModified {
+		t.Fatalf("expected handler tags to apply when hashing is disabled, got %d", w.Code)
+	}
+}
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"slices"
+
+	"github.com/akeren/go-api-foundry/pkg/fieldmask"
+	"github.com/akeren/go-api-foundry/pkg/principal"
+)
+
+// initFieldAccess reads FIELD_ACCESS_ENFORCED. Enforcement is opt-in because
+// without an authenticated principal nobody holds a scope, and every tagged
+// field would be hidden from all callers.
+func (routerService *RouterService) initFieldAccess() {
+	routerService.enforceFieldAccess = envBool("FIELD_ACCESS_ENFORCED", false)
+	if routerService.enforceFieldAccess {
+		routerService.logger.Info("Response field access enforcement enabled")
+	}
+}
+
+// GrantScopes gives the caller extra field access scopes for this request,
+// e.g. "owner" once the handler has verified the caller owns the resource.
+func GrantScopes(ctx *RequestContext, scopes ...string) {
+	ctx.Request = ctx.Request.WithContext(fieldmask.WithScopes(ctx.Request.Context(), scopes...))
+}
+
+// viewerAllows reports whether the caller holds scope, either as a role of
+// the request principal or as a scope granted by the handler.
+func viewerAllows(ctx *RequestContext) fieldmask.Allowed {
+	p := principal.FromContext(ctx.Request.Context())
+	granted := fieldmask.Scopes(ctx.Request.Context())
+	return func(scope string) bool {
+		return p.HasRole(scope) || slices.Contains(granted, scope)
+	}
+}
+
+// maskFields applies `access` tags on the result data for the caller.
+func (routerService *RouterService) maskFields(ctx *RequestContext, result *ServiceResult) *ServiceResult {
+	if !routerService.enforceFieldAccess || result.write != nil || result.Data == nil {
+		return result
+	}
+
+	data, err := fieldmask.Apply(result.Data, viewerAllows(ctx))
+	if err != nil {
+		GetLogger(ctx).Error("Failed to apply response field access", "error", err)
+		return InternalServerErrorResult("Failed to render response")
+	}
+	result.Data = data
+	return result
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akeren/go-api-foundry/pkg/principal"
+)
+
+type maskedAccount struct {
+	ID      string `json:"id"`
+	Balance int64  `json:"balance" access:"owner,admin"`
+}
+
+func mountFieldAccessController(rs *RouterService) {
+	asRole := func(ctx *RequestContext) {
+		if role := ctx.GetHeader("X-Test-Role"); role != "" {
+			p := &principal.Principal{Subject: "tester", Kind: principal.KindUser, Roles: []string{role}}
+			ctx.Request = ctx.Request.WithContext(principal.WithPrincipal(ctx.Request.Context(), p))
+		}
+		ctx.Next()
+	}
+
+	rs.MountController(NewRESTController("FieldAccessController", "/", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "accounts/:id", func(ctx *RequestContext) *ServiceResult {
+			if ctx.Param("id") == "mine" {
+				GrantScopes(ctx, "owner")
+			}
+			return OKResult(maskedAccount{ID: ctx.Param("id"), Balance: 42}, "ok")
+		}, asRole)
+	}))
+}
+
+func TestFieldAccess_HidesTaggedFieldsWithoutScope(t *testing.T) {
+	t.Setenv("FIELD_ACCESS_ENFORCED", "true")
+	rs := newTestRouterService(t)
+	mountFieldAccessController(rs)
+
+	cases := []struct {
+		path, role  string
+		wantBalance bool
+	}{
+		{"/accounts/other", "", false},
+		{"/accounts/other", "support", false},
+		{"/accounts/other", "admin", true},
+		{"/accounts/mine", "", true},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.role != "" {
+			req.Header.Set("X-Test-Role", tc.role)
+		}
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s as %q: expected 200, got %d", tc.path, tc.role, w.Code)
+		}
+		if got := strings.Contains(w.Body.String(), `"balance":42`); got != tc.wantBalance {
+			t.Fatalf("%s as %q: balance visible=%v, want %v: %s", tc.path, tc.role, got, tc.wantBalance, w.Body.String())
+		}
+	}
+}
+
+func TestFieldAccess_DisabledByDefault(t *testing.T) {
+	t.Setenv("FIELD_ACCESS_ENFORCED", "")
+	rs := newTestRouterService(t)
+	mountFieldAccessController(rs)
+
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts/other", nil))
+	if !strings.Contains(w.Body.String(), `"balance":42`) {
+		t.Fatalf("expected balance without enforcement, got %s", w.Body.String())
+	}
+}
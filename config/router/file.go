@@ -0,0 +1,55 @@
+package router
+
+import (
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// FileResult serves the file at path as a download named after it. The
+// content type comes from the extension, and range and conditional requests
+// are answered as net/http does. A missing file is a JSON 404. Set
+// Content-Disposition with WithAttachment or WithHeader to use another name.
+func FileResult(path string) *ServiceResult {
+	return &ServiceResult{
+		StatusCode: http.StatusOK,
+		Message:    http.StatusText(http.StatusOK),
+		write: func(c *RequestContext, _ int) {
+			f, err := os.Open(path)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					c.JSON(http.StatusNotFound, NotFoundResult("File not found").ToJSON())
+					return
+				}
+				GetLogger(c).Error("Failed to open file", "path", path, "error", err)
+				c.JSON(http.StatusInternalServerError, InternalServerErrorResult("Failed to read file").ToJSON())
+				return
+			}
+			defer f.Close()
+
+			info, err := f.Stat()
+			if err != nil || info.IsDir() {
+				c.JSON(http.StatusNotFound, NotFoundResult("File not found").ToJSON())
+				return
+			}
+			if c.Writer.Header().Get("Content-Disposition") == "" {
+				c.Header("Content-Disposition", attachment(filepath.Base(path)))
+			}
+			http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), f)
+		},
+	}
+}
+
+// WithAttachment tells the client to save the response as filename.
+func (result *ServiceResult) WithAttachment(filename string) *ServiceResult {
+	return result.WithHeader("Content-Disposition", attachment(filename))
+}
+
+// attachment formats a Content-Disposition header, encoding names that are
+// not plain ASCII as RFC 2231 allows.
+func attachment(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
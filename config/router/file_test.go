@@ -0,0 +1,62 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileResult(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.csv")
+	if err := os.WriteFile(path, []byte("a,b\n1,2\n"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("FilesController", "/files", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "report", func(ctx *RequestContext) *ServiceResult {
+			return FileResult(path)
+		})
+		rs.AddGetHandler(c, nil, "renamed", func(ctx *RequestContext) *ServiceResult {
+			return FileResult(path).WithAttachment("relevé 2026.csv")
+		})
+		rs.AddGetHandler(c, nil, "missing", func(ctx *RequestContext) *ServiceResult {
+			return FileResult(filepath.Join(dir, "missing.csv"))
+		})
+	}))
+
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/report", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "a,b\n1,2\n" {
+		t.Fatalf("unexpected response %d: %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != "attachment; filename=report.csv" {
+		t.Fatalf("Content-Disposition = %q", cd)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/files/report", nil)
+	req.Header.Set("Range", "bytes=4-")
+	w = httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "1,2\n" {
+		t.Fatalf("unexpected range response %d: %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/renamed", nil))
+	if cd := w.Header().Get("Content-Disposition"); cd != "attachment; filename*=utf-8''relev%C3%A9%202026.csv" {
+		t.Fatalf("Content-Disposition = %q", cd)
+	}
+
+	w = httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing file, got %d: %s", w.Code, w.Body.String())
+	}
+}
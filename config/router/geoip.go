@@ -0,0 +1,112 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/geoip"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var ErrGeoIPReloadUnsupported = errors.New("GeoIP provider does not support reloading")
+
+type geoIPReloader interface {
+	Reload() error
+}
+
+func (routerService *RouterService) initGeoIP() {
+	countryDB := utils.GetEnvTrimmed("GEOIP_COUNTRY_DB")
+	asnDB := utils.GetEnvTrimmed("GEOIP_ASN_DB")
+
+	if countryDB != "" || asnDB != "" {
+		provider, err := geoip.OpenMaxMind(countryDB, asnDB)
+		if err != nil {
+			routerService.logger.Error("Failed to open GeoIP databases; GeoIP disabled", "error", err)
+		} else {
+			routerService.geoProvider = provider
+			routerService.logger.Info("GeoIP enrichment enabled", "country_db", countryDB, "asn_db", asnDB)
+		}
+	}
+
+	if spec := utils.GetEnvTrimmed("GEOFENCE_RULES"); spec != "" {
+		fence, err := geoip.ParseFence(spec)
+		if err != nil {
+			routerService.logger.Error("Invalid GEOFENCE_RULES; geo-fencing disabled", "error", err)
+			return
+		}
+		routerService.geoFence = fence
+		if routerService.geoProvider == nil {
+			routerService.logger.Warn("GEOFENCE_RULES set but no GeoIP database configured; rules are ignored")
+		}
+	}
+}
+
+// SetGeoIPProvider replaces the GeoIP provider, e.g. with a commercial lookup service.
+func (routerService *RouterService) SetGeoIPProvider(provider geoip.Provider) {
+	routerService.geoProvider = provider
+}
+
+// ReloadGeoIP reopens the GeoIP databases without restarting the process.
+func (routerService *RouterService) ReloadGeoIP() error {
+	reloader, ok := routerService.geoProvider.(geoIPReloader)
+	if !ok {
+		return ErrGeoIPReloadUnsupported
+	}
+	if err := reloader.Reload(); err != nil {
+		return err
+	}
+	routerService.logger.Info("GeoIP databases reloaded")
+	return nil
+}
+
+// geoIPMiddleware stores the client location in the request context and
+// enforces geo-fencing rules. Lookup failures are treated as an unknown location.
+func (routerService *RouterService) geoIPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if routerService.geoProvider == nil {
+			c.Next()
+			return
+		}
+
+		var loc geoip.Location
+		if addr, err := netip.ParseAddr(c.ClientIP()); err == nil {
+			loc, err = routerService.geoProvider.Lookup(addr)
+			if err != nil {
+				routerService.logger.Debug("GeoIP lookup failed", "error", err, "client_ip", c.ClientIP())
+			}
+		}
+		c.Request = c.Request.WithContext(geoip.WithLocation(c.Request.Context(), loc))
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		if rule, allowed := routerService.geoFence.Check(c.Request.Method, route, loc.Country); !allowed {
+			routerService.recordBlockedRequest("geofence")
+			routerService.logger.Info("Request blocked by geo-fence",
+				"client_ip", c.ClientIP(),
+				"country", loc.Country,
+				"route", route,
+				"rule", rule.Path,
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResult(
+				apperrors.StatusForbidden,
+				"This resource is not available in your region",
+				nil,
+			).ToJSON())
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func closeGeoIPProvider(provider geoip.Provider) error {
+	if closer, ok := provider.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
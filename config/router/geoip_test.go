@@ -0,0 +1,70 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/akeren/go-api-foundry/pkg/geoip"
+)
+
+type stubGeoProvider map[string]geoip.Location
+
+func (s stubGeoProvider) Lookup(addr netip.Addr) (geoip.Location, error) {
+	return s[addr.String()], nil
+}
+
+func TestGeoIP_InjectsLocationIntoContext(t *testing.T) {
+	rs := newTestRouterService(t)
+	rs.SetGeoIPProvider(stubGeoProvider{"192.0.2.1": {Country: "NG", ASN: 64500}})
+
+	var got geoip.Location
+	rs.MountController(NewRESTController("Geo", "/", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "where", func(ctx *RequestContext) *ServiceResult {
+			got, _ = geoip.FromContext(ctx.Request.Context())
+			return OKResult(nil, "ok")
+		})
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/where", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rs.GetEngine().ServeHTTP(httptest.NewRecorder(), req)
+
+	if got.Country != "NG" || got.ASN != 64500 {
+		t.Fatalf("expected location in context, got %+v", got)
+	}
+}
+
+func TestGeoFence_BlocksMatchingRoutes(t *testing.T) {
+	t.Setenv("GEOFENCE_RULES", "POST /echo=deny:KP")
+
+	rs := newTestRouterService(t)
+	rs.SetGeoIPProvider(stubGeoProvider{"192.0.2.1": {Country: "KP"}, "192.0.2.2": {Country: "NG"}})
+	mountTestController(rs)
+
+	serve := func(method, path, remote string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve(http.MethodPost, "/echo", "192.0.2.1:1"); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for fenced country, got %d", code)
+	}
+	if code := serve(http.MethodPost, "/echo", "192.0.2.2:1"); code == http.StatusForbidden {
+		t.Fatalf("expected other countries to pass")
+	}
+	if code := serve(http.MethodGet, "/ip", "192.0.2.1:1"); code != http.StatusOK {
+		t.Fatalf("expected unfenced routes to pass, got %d", code)
+	}
+}
+
+func TestReloadGeoIP_UnsupportedProvider(t *testing.T) {
+	rs := newTestRouterService(t)
+	if err := rs.ReloadGeoIP(); err != ErrGeoIPReloadUnsupported {
+		t.Fatalf("expected ErrGeoIPReloadUnsupported, got %v", err)
+	}
+}
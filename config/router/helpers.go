@@ -103,3 +103,48 @@ func ParseIDParam(ctx *RequestContext, paramName string) (uint, *ServiceResult)
 
 	return uint(id), nil
 }
+
+// DataResult writes data as-is with the given content type instead of the JSON envelope.
+func DataResult(statusCode int, contentType string, data []byte) *ServiceResult {
+	return &ServiceResult{
+		StatusCode: statusCode,
+		Message:    http.StatusText(statusCode),
+		write: func(c *RequestContext, status int) {
+			c.Data(status, contentType, data)
+		},
+	}
+}
+
+func TextResult(statusCode int, body string) *ServiceResult {
+	return DataResult(statusCode, "text/plain; charset=utf-8", []byte(body))
+}
+
+func RedirectResult(statusCode int, location string) *ServiceResult {
+	return &ServiceResult{
+		StatusCode: statusCode,
+		Message:    http.StatusText(statusCode),
+		write: func(c *RequestContext, status int) {
+			c.Redirect(status, location)
+		},
+	}
+}
+
+func NoContentResult() *ServiceResult {
+	return &ServiceResult{
+		StatusCode: http.StatusNoContent,
+		write: func(c *RequestContext, status int) {
+			c.Status(status)
+		},
+	}
+}
+
+// AcceptedResult acknowledges work that completes asynchronously and points
+// the client at the resource to poll via the Location header.
+func AcceptedResult(data any, location string) *ServiceResult {
+	result := &ServiceResult{
+		StatusCode: http.StatusAccepted,
+		Data:       data,
+		Message:    "Request accepted for processing",
+	}
+	return result.WithHeader("Location", location)
+}
@@ -0,0 +1,33 @@
+package router
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// HTMLRenderer is satisfied by *render.Engine.
+type HTMLRenderer interface {
+	Render(w io.Writer, name string, data any) error
+}
+
+// HTMLResult renders the named page with data as the response body. The page is
+// rendered into a buffer first so template errors surface as a JSON 500 rather
+// than a half-written document.
+func HTMLResult(statusCode int, renderer HTMLRenderer, name string, data any) *ServiceResult {
+	return &ServiceResult{
+		StatusCode: statusCode,
+		Data:       data,
+		Message:    name,
+		write: func(c *RequestContext, status int) {
+			var buf bytes.Buffer
+			if err := renderer.Render(&buf, name, data); err != nil {
+				GetLogger(c).Error("Failed to render HTML page", "page", name, "error", err)
+				c.JSON(http.StatusInternalServerError, InternalServerErrorResult("Failed to render page").ToJSON())
+				return
+			}
+
+			c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type stubRenderer struct {
+	err error
+}
+
+func (r stubRenderer) Render(w io.Writer, name string, data any) error {
+	if r.err != nil {
+		return r.err
+	}
+	_, err := io.WriteString(w, "<h1>"+name+":"+data.(string)+"</h1>")
+	return err
+}
+
+func TestHTMLResult_RendersPage(t *testing.T) {
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("PagesController", "/pages", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "status", func(ctx *RequestContext) *ServiceResult {
+			return HTMLResult(http.StatusOK, stubRenderer{}, "status", "up")
+		})
+	}))
+
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pages/status", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("expected text/html content type, got %q", ct)
+	}
+	if w.Body.String() != "<h1>status:up</h1>" {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestHTMLResult_RenderErrorReturns500(t *testing.T) {
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("PagesController", "/pages", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "broken", func(ctx *RequestContext) *ServiceResult {
+			return HTMLResult(http.StatusOK, stubRenderer{err: errors.New("boom")}, "broken", "x")
+		})
+	}))
+
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pages/broken", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Failed to render page") {
+		t.Fatalf("expected JSON error envelope, got %s", w.Body.String())
+	}
+}
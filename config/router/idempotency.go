@@ -0,0 +1,246 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/idempotency"
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client's key for a mutating request.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed from the store.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentResponseBytes caps the response kept for replay; larger
+	// responses are not stored and a retry runs the request again.
+	maxIdempotentResponseBytes = 1 << 20
+)
+
+// replayedHeaders are the response headers kept alongside the body.
+var replayedHeaders = []string{"Content-Type", "Location", "Operation-Location", "ETag"}
+
+// IdempotencyOptions configures how a controller's POST, PUT, PATCH and
+// DELETE handlers treat the Idempotency-Key header.
+type IdempotencyOptions struct {
+	// Disabled turns the middleware off, for controllers with their own
+	// retry semantics.
+	Disabled bool
+	// Required rejects mutating requests without a key.
+	Required bool
+	// TTL overrides how long responses are replayed for.
+	TTL time.Duration
+}
+
+// Idempotency configures the controller's Idempotency-Key handling. Call it
+// before the controller is mounted.
+func (controller *RESTController) Idempotency(opts IdempotencyOptions) *RESTController {
+	controller.idempotency = opts
+	return controller
+}
+
+// SetIdempotencyStore makes mutating handlers replay the stored response when
+// a request is retried with the same Idempotency-Key, for ttl after it first
+// completed. Without a store the header is ignored.
+func (routerService *RouterService) SetIdempotencyStore(store idempotency.Store, ttl time.Duration) {
+	routerService.idempotencyStore = store
+	routerService.idempotencyTTL = ttl
+}
+
+func (routerService *RouterService) idempotencyEnabled(controller *RESTController, method string) bool {
+	if routerService.idempotencyStore == nil || controller.idempotency.Disabled {
+		return false
+	}
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// idempotencyMiddleware claims the request's key before the handler runs and
+// stores the response afterwards. Retries with the same key and request get
+// the stored response; a different request reusing the key gets 422, and a
+// retry while the first request is still running gets 409. Keys are scoped
+// to the authenticated principal, or to the client IP for anonymous callers,
+// so one caller cannot replay another's response.
+func (routerService *RouterService) idempotencyMiddleware(opts IdempotencyOptions) gin.HandlerFunc {
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = routerService.idempotencyTTL
+	}
+	// The claim outlives the request timeout so a slow request is not run twice.
+	lockTTL := 2 * DefaultTimeoutDuration
+	if timeout := routerService.middlewareConfig.TimeoutDuration; timeout > 0 {
+		lockTTL = 2 * timeout
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			if opts.Required {
+				c.AbortWithStatusJSON(http.StatusBadRequest, BadRequestResult("Idempotency-Key header is required", nil).ToJSON())
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, BadRequestResult("Idempotency-Key must be at most 255 characters", nil).ToJSON())
+			return
+		}
+
+		body, err := readRequestBody(c)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorResult(http.StatusRequestEntityTooLarge, "Request payload too large", nil).ToJSON())
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, BadRequestResult("Failed to read request body", nil).ToJSON())
+			return
+		}
+
+		key = idempotencyScope(c) + ":" + key
+		fingerprint := requestFingerprint(c.Request, body)
+		store := routerService.idempotencyStore
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		stored, err := store.Begin(ctx, key, fingerprint, lockTTL)
+		switch {
+		case errors.Is(err, idempotency.ErrMismatch):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, ErrorResult(http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request", nil).ToJSON())
+			return
+		case errors.Is(err, idempotency.ErrInProgress):
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusConflict, ConflictResult("A request with this Idempotency-Key is still in progress").ToJSON())
+			return
+		case err != nil:
+			// Fail open: the request runs as if it carried no key.
+			GetLogger(c).Error("Idempotency store unavailable; processing request without replay protection", "error", err)
+			c.Next()
+			return
+		case stored != nil:
+			for name, value := range stored.Header {
+				c.Header(name, value)
+			}
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Status(stored.StatusCode)
+			_, _ = c.Writer.Write(stored.Body)
+			c.Abort()
+			return
+		}
+
+		finished := false
+		defer func() {
+			// Also runs when the handler panics, so the key can be retried.
+			if !finished {
+				if err := store.Release(ctx, key); err != nil {
+					GetLogger(c).Error("Failed to release idempotency key", "error", err)
+				}
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		c.Writer = recorder.ResponseWriter
+
+		status := c.Writer.Status()
+		if !storableStatus(status) {
+			return
+		}
+		if recorder.truncated {
+			GetLogger(c).Warn("Response too large to store for idempotent replay", "status", status)
+			return
+		}
+		resp := idempotency.Response{StatusCode: status, Header: map[string]string{}, Body: recorder.body.Bytes()}
+		for _, name := range replayedHeaders {
+			if value := c.Writer.Header().Get(name); value != "" {
+				resp.Header[name] = value
+			}
+		}
+		if err := store.Complete(ctx, key, fingerprint, resp, ttl); err != nil {
+			GetLogger(c).Error("Failed to store idempotent response", "error", err)
+			return
+		}
+		finished = true
+	}
+}
+
+// storableStatus reports whether a response is final for its request. Server
+// errors, timeouts and rate limiting are transient, so a retry runs again.
+func storableStatus(status int) bool {
+	return status < http.StatusInternalServerError &&
+		status != http.StatusRequestTimeout &&
+		status != http.StatusTooManyRequests
+}
+
+// readRequestBody reads the body and puts it back for the handler.
+func readRequestBody(c *gin.Context) ([]byte, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyScope prefixes the caller's keys. Anonymous callers are told
+// apart by client IP, so callers behind one address still share keys.
+func idempotencyScope(c *gin.Context) string {
+	if p := principal.FromContext(c.Request.Context()); p != nil {
+		return string(p.Kind) + ":" + p.Subject
+	}
+	return "anonymous:" + c.ClientIP()
+}
+
+// responseRecorder copies the response body as it is written, up to
+// maxIdempotentResponseBytes.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.record(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *responseRecorder) record(b []byte) {
+	if r.truncated {
+		return
+	}
+	if r.body.Len()+len(b) > maxIdempotentResponseBytes {
+		r.truncated = true
+		r.body.Reset()
+		return
+	}
+	r.body.Write(b)
+}
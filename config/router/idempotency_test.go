@@ -0,0 +1,139 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/idempotency"
+)
+
+// newIdempotencyTestRouter mounts a counter whose POST handler returns a new
+// ID on every call, so a replay is told apart from a second run.
+func newIdempotencyTestRouter(t *testing.T, opts IdempotencyOptions) (*RouterService, *int) {
+	t.Helper()
+	calls := 0
+	rs := newTestRouterService(t)
+	rs.SetIdempotencyStore(idempotency.NewMemoryStore(), time.Hour)
+	rs.MountController(NewRESTController("Counter", "/counter", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddPostHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			calls++
+			if ctx.Query("fail") != "" {
+				return InternalServerErrorResult("boom")
+			}
+			return CreatedResult(map[string]int{"id": calls}, "Counter").WithHeader("Location", "/counter/1")
+		})
+	}).Idempotency(opts))
+	return rs, &calls
+}
+
+func postWithKey(rs *RouterService, target, body, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	rs, calls := newIdempotencyTestRouter(t, IdempotencyOptions{})
+
+	first := postWithKey(rs, "/counter", `{"n":1}`, "key-1")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+	retry := postWithKey(rs, "/counter", `{"n":1}`, "key-1")
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected the first response to be replayed, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" || retry.Header().Get("Location") != "/counter/1" {
+		t.Fatalf("unexpected replay headers %v", retry.Header())
+	}
+	if *calls != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", *calls)
+	}
+
+	// Requests without a key, or with a new one, always run.
+	postWithKey(rs, "/counter", `{"n":1}`, "")
+	postWithKey(rs, "/counter", `{"n":1}`, "key-2")
+	if *calls != 3 {
+		t.Fatalf("expected 3 handler calls, got %d", *calls)
+	}
+}
+
+func TestIdempotency_ScopesAnonymousKeysByClientIP(t *testing.T) {
+	rs, calls := newIdempotencyTestRouter(t, IdempotencyOptions{})
+
+	postWithKey(rs, "/counter", `{"n":1}`, "key-1")
+	req := httptest.NewRequest(http.MethodPost, "/counter", strings.NewReader(`{"n":1}`))
+	req.RemoteAddr = "198.51.100.7:1234"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if w.Header().Get(IdempotentReplayedHeader) != "" || *calls != 2 {
+		t.Fatalf("expected another client's key not to replay the first response, ran %d times", *calls)
+	}
+}
+
+func TestIdempotency_RejectsReusedKey(t *testing.T) {
+	rs, calls := newIdempotencyTestRouter(t, IdempotencyOptions{})
+
+	postWithKey(rs, "/counter", `{"n":1}`, "key-1")
+	w := postWithKey(rs, "/counter", `{"n":2}`, "key-1")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a different body, got %d: %s", w.Code, w.Body.String())
+	}
+	if *calls != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", *calls)
+	}
+}
+
+func TestIdempotency_ServerErrorsAreRetried(t *testing.T) {
+	rs, calls := newIdempotencyTestRouter(t, IdempotencyOptions{})
+
+	for range 2 {
+		if w := postWithKey(rs, "/counter?fail=1", `{}`, "key-1"); w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected 500, got %d", w.Code)
+		}
+	}
+	if *calls != 2 {
+		t.Fatalf("expected a failed request to run again, ran %d times", *calls)
+	}
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	rs, _ := newIdempotencyTestRouter(t, IdempotencyOptions{})
+
+	// A concurrent request holds the claim for the same key and request.
+	fingerprint := requestFingerprint(httptest.NewRequest(http.MethodPost, "/counter", nil), []byte(`{}`))
+	if _, err := rs.idempotencyStore.Begin(t.Context(), "anonymous:192.0.2.1:key-1", fingerprint, time.Minute); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+
+	w := postWithKey(rs, "/counter", `{}`, "key-1")
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 409 with Retry-After, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestIdempotency_ControllerOptions(t *testing.T) {
+	rs, _ := newIdempotencyTestRouter(t, IdempotencyOptions{Required: true})
+	if w := postWithKey(rs, "/counter", `{}`, ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a required key, got %d", w.Code)
+	}
+	if w := postWithKey(rs, "/counter", `{}`, strings.Repeat("k", 256)); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an oversized key, got %d", w.Code)
+	}
+
+	rs, calls := newIdempotencyTestRouter(t, IdempotencyOptions{Disabled: true})
+	postWithKey(rs, "/counter", `{}`, "key-1")
+	if w := postWithKey(rs, "/counter", `{}`, "key-1"); w.Header().Get(IdempotentReplayedHeader) != "" || *calls != 2 {
+		t.Fatalf("expected a disabled controller to ignore the key, ran %d times", *calls)
+	}
+}
@@ -0,0 +1,73 @@
+package router
+
+import (
+	"net/http"
+	"os"
+
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/ipfilter"
+	"github.com/akeren/go-api-foundry/pkg/tarpit"
+	"github.com/gin-gonic/gin"
+)
+
+func (routerService *RouterService) initIPFilter() {
+	allow, invalidAllow := ipfilter.ParsePrefixes(os.Getenv("IPFILTER_ALLOW"))
+	if len(invalidAllow) > 0 {
+		routerService.logger.Error("Ignoring invalid IPFILTER_ALLOW entries", "entries", invalidAllow)
+	}
+	deny, invalidDeny := ipfilter.ParsePrefixes(os.Getenv("IPFILTER_DENY"))
+	if len(invalidDeny) > 0 {
+		routerService.logger.Error("Ignoring invalid IPFILTER_DENY entries", "entries", invalidDeny)
+	}
+
+	var store ipfilter.BanStore
+	if routerService.redisClient != nil {
+		store = ipfilter.NewRedisBanStore(routerService.redisClient)
+	} else {
+		store = ipfilter.NewMemoryBanStore()
+	}
+
+	routerService.ipFilter = ipfilter.New(ipfilter.Config{Allow: allow, Deny: deny}, store)
+
+	routerService.logger.Info("IP filter initialized",
+		"allow_ranges", len(allow),
+		"deny_ranges", len(deny),
+		"distributed_bans", routerService.redisClient != nil,
+	)
+}
+
+// IPFilter exposes the filter so admin endpoints can manage bans.
+func (routerService *RouterService) IPFilter() *ipfilter.Filter {
+	return routerService.ipFilter
+}
+
+// ipFilterMiddleware runs before rate limiting so blocked clients never
+// consume limiter capacity. Ban store failures fail open, like the rate limiter.
+func (routerService *RouterService) ipFilterMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientIP := c.ClientIP()
+
+		blocked, reason, err := routerService.ipFilter.Check(c.Request.Context(), clientIP)
+		if err != nil {
+			routerService.logger.Error("IP filter error", "error", err, "client_ip", clientIP)
+			c.Next()
+			return
+		}
+
+		if blocked {
+			routerService.recordBlockedRequest(reason)
+			routerService.logger.Debug("Request blocked by IP filter", "client_ip", clientIP, "reason", reason)
+			if reason == ipfilter.ReasonBanned || reason == ipfilter.ReasonDenied {
+				routerService.holdAbusiveClient(c, tarpit.SeverityBanned)
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResult(
+				apperrors.StatusForbidden,
+				"Access denied",
+				nil,
+			).ToJSON())
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIPFilter_DenyRangeReturns403(t *testing.T) {
+	t.Setenv("IPFILTER_DENY", "203.0.113.0/24")
+
+	rs := newTestRouterService(t)
+	mountTestController(rs)
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "203.0.113.5:1234"
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Fatalf("blocked requests must be rejected before rate limiting")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w = httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `http_blocked_requests_total{reason="denylist"} 1`) {
+		t.Fatalf("expected blocked request metric, got:\n%s", w.Body.String())
+	}
+}
+
+func TestIPFilter_BanAppliesUntilUnban(t *testing.T) {
+	rs := newTestRouterService(t)
+	mountTestController(rs)
+
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.RemoteAddr = "198.51.100.7:1234"
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if _, err := rs.IPFilter().Ban(context.Background(), "198.51.100.7", time.Minute, "test"); err != nil {
+		t.Fatalf("ban: %v", err)
+	}
+	if code := serve(); code != http.StatusForbidden {
+		t.Fatalf("expected banned client to get 403, got %d", code)
+	}
+
+	if err := rs.IPFilter().Unban(context.Background(), "198.51.100.7"); err != nil {
+		t.Fatalf("unban: %v", err)
+	}
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("expected unbanned client to get 200, got %d", code)
+	}
+}
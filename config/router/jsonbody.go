@@ -0,0 +1,131 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// decodeJSON decodes a request body into v, keeping numbers bound to
+// interface values as json.Number so large integers are not rounded.
+func decodeJSON(body []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+// walkJSON calls visit for each value in a decoded JSON document with the
+// type of the Go value it decodes into, pointers removed, and its path such
+// as "entries[1].amount". The type is nil for keys no struct field decodes.
+// Keys match fields regardless of case, as in encoding/json, and values of
+// types that decode themselves are not descended into.
+func walkJSON(t reflect.Type, value any, path string, visit func(path string, t reflect.Type, value any)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	visit(path, t, value)
+	if t.Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			field, ok := lookupJSONField(fields, key)
+			if !ok {
+				visit(joinJSONPath(path, key), nil, object[key])
+				continue
+			}
+			walkJSON(field.Type, object[key], joinJSONPath(path, key), visit)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			walkJSON(t.Elem(), object[key], joinJSONPath(path, key), visit)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			walkJSON(t.Elem(), item, path+"["+strconv.Itoa(i)+"]", visit)
+		}
+	}
+}
+
+// jsonFields returns the fields encoding/json decodes into t by key,
+// including those promoted from embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for key, field := range jsonFields(ft) {
+					if _, shadowed := fields[key]; !shadowed {
+						fields[key] = field
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func lookupJSONField(fields map[string]reflect.StructField, key string) (reflect.StructField, bool) {
+	if field, ok := fields[key]; ok {
+		return field, true
+	}
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinJSONPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
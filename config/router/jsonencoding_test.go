@@ -0,0 +1,110 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type pooledAccount struct {
+	ID      string `json:"id"`
+	Balance int64  `json:"balance"`
+}
+
+func appendPooledAccount(dst []byte, a *pooledAccount) []byte {
+	dst = append(dst, `{"id":`...)
+	dst = AppendJSONString(dst, a.ID)
+	dst = append(dst, `,"balance":`...)
+	dst = strconv.AppendInt(dst, a.Balance, 10)
+	return append(dst, '}')
+}
+
+func init() {
+	RegisterJSONEncoder(appendPooledAccount)
+}
+
+func TestEncodeEnvelope_MatchesEncodingJSON(t *testing.T) {
+	for name, result := range map[string]*ServiceResult{
+		"registered":       OKResult(&pooledAccount{ID: "acc-1", Balance: 42}, "ok"),
+		"registered value": OKResult(pooledAccount{ID: "acc-1", Balance: 42}, "ok"),
+		"registered nil":   OKResult((*pooledAccount)(nil), "ok"),
+		"escaped":          OKResult(&pooledAccount{ID: "<a&b> \"q\"   \x01 \xff"}, "tom & jerry"),
+		"unregistered":     OKResult(map[string]any{"b": []int{1, 2}, "a": "<x>"}, "ok"),
+		"nil data":         NotFoundResult("missing"),
+		"warnings":         OKResult([]string{"x"}, "ok").WithWarning("deprecated <param>").WithWarning("second"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			want, err := json.Marshal(result.ToJSON())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := encodeEnvelope(&buf, result); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Fatalf("encoded\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeEnvelope_RegisteredEncoderDoesNotAllocate(t *testing.T) {
+	result := OKResult(&pooledAccount{ID: "acc-1", Balance: 42}, "ok")
+	allocs := testing.AllocsPerRun(100, func() {
+		buf := getJSONBuffer()
+		if err := encodeEnvelope(buf, result); err != nil {
+			t.Fatal(err)
+		}
+		putJSONBuffer(buf)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+}
+
+func TestEncodeEnvelope_UnsupportedValue(t *testing.T) {
+	var buf bytes.Buffer
+	if err := encodeEnvelope(&buf, OKResult(make(chan int), "ok")); err == nil {
+		t.Fatal("expected an error for a value encoding/json cannot encode")
+	}
+}
+
+func TestPooledJSON_Routes(t *testing.T) {
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("Pooled", "/pooled", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/account", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(&pooledAccount{ID: "acc-1", Balance: 42}, "ok")
+		}).PooledJSON(true)
+		rs.AddGetHandler(c, nil, "/default", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(&pooledAccount{ID: "acc-1", Balance: 42}, "ok")
+		})
+	}))
+
+	var bodies []string
+	for _, target := range []string{"/pooled/account", "/pooled/default"} {
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+			t.Fatalf("%s: expected a 200 JSON response, got %d %q", target, w.Code, w.Header().Get("Content-Type"))
+		}
+		bodies = append(bodies, w.Body.String())
+	}
+	if bodies[0] != bodies[1] {
+		t.Fatalf("expected pooled encoding not to change the body, got %s and %s", bodies[0], bodies[1])
+	}
+}
+
+func TestPooledJSON_EnvDefault(t *testing.T) {
+	t.Setenv("POOLED_JSON_ENCODING", "true")
+	rs := newTestRouterService(t)
+	if !rs.pooledJSONFor(&Route{}) {
+		t.Fatal("expected POOLED_JSON_ENCODING to pool routes by default")
+	}
+	if rs.pooledJSONFor((&Route{}).PooledJSON(false)) {
+		t.Fatal("expected Route.PooledJSON to override the default")
+	}
+}
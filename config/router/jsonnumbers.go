@@ -0,0 +1,85 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// MaxSafeInteger is the largest integer every JSON client reads exactly.
+// JavaScript and other parsers decode numbers as float64, so larger ones may
+// be rounded before they are sent or after they are received.
+const MaxSafeInteger = 1<<53 - 1
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		_ = v.RegisterValidation("safeint", validateSafeInt)
+	}
+}
+
+// validateSafeInt implements the safeint binding rule, which keeps integer
+// fields such as amounts within ±MaxSafeInteger. A client that parsed a
+// larger value as float64 may already have changed it.
+func validateSafeInt(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := field.Int()
+		return n >= -MaxSafeInteger && n <= MaxSafeInteger
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return field.Uint() <= MaxSafeInteger
+	}
+	return false
+}
+
+// numberViolations checks the numbers document gives integer fields of t,
+// naming each one that is not a whole number or does not fit the field.
+func numberViolations(t reflect.Type, document any) []apperrors.ValidationErrorResponse {
+	var violations []apperrors.ValidationErrorResponse
+	walkJSON(t, document, "", func(path string, t reflect.Type, value any) {
+		n, ok := value.(json.Number)
+		if !ok || t == nil {
+			return
+		}
+		if message := integerViolation(t, n); message != "" {
+			violations = append(violations, apperrors.ValidationErrorResponse{Field: path, Message: message})
+		}
+	})
+	return violations
+}
+
+func integerViolation(t reflect.Type, n json.Number) string {
+	var err error
+	var minimum, maximum string
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = strconv.ParseInt(n.String(), 10, t.Bits())
+		minimum = strconv.FormatInt(math.MinInt64>>(64-t.Bits()), 10)
+		maximum = strconv.FormatInt(math.MaxInt64>>(64-t.Bits()), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		_, err = strconv.ParseUint(n.String(), 10, t.Bits())
+		minimum = "0"
+		maximum = strconv.FormatUint(math.MaxUint64>>(64-t.Bits()), 10)
+	default:
+		return ""
+	}
+	if err == nil {
+		return ""
+	}
+	if strings.ContainsAny(n.String(), ".eE") {
+		if f, err := strconv.ParseFloat(n.String(), 64); err == nil && f == math.Trunc(f) {
+			return "Must be written as a whole number without a decimal point or exponent"
+		}
+		return "Must be a whole number"
+	}
+	// An integer literal that fails to parse is out of range; for unsigned
+	// fields that includes negative values.
+	return fmt.Sprintf("Must be between %s and %s", minimum, maximum)
+}
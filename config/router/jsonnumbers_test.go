@@ -0,0 +1,82 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+type numberTestRequest struct {
+	Amount   int64          `json:"amount" binding:"required,safeint"`
+	Count    uint8          `json:"count"`
+	Lines    []int32        `json:"lines"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+func newNumberTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("Numbers", "/numbers", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddPostHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			req, errResult := BindJSON[numberTestRequest](ctx)
+			if errResult != nil {
+				return errResult
+			}
+			// Echo the metadata value's Go type to show it kept every digit.
+			id, ok := req.Metadata["id"].(json.Number)
+			if !ok {
+				return InternalServerErrorResult("metadata id is not a json.Number")
+			}
+			return OKResult(id.String(), "ok")
+		})
+	}))
+	return rs
+}
+
+func TestBindJSON_KeepsLargeNumbersExact(t *testing.T) {
+	rs := newNumberTestRouter(t)
+
+	code, _ := postJSON(t, rs, "/numbers", `{"amount":9007199254740991,"metadata":{"id":9007199254740993}}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+}
+
+func TestBindJSON_NamesImpreciseNumbers(t *testing.T) {
+	rs := newNumberTestRouter(t)
+
+	code, violations := postJSON(t, rs, "/numbers", `{"amount":10.5,"count":300,"lines":[1,2e3,-1]}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", code)
+	}
+	want := map[string]string{
+		"amount":   "Must be a whole number",
+		"count":    "Must be between 0 and 255",
+		"lines[1]": "Must be written as a whole number without a decimal point or exponent",
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("expected %d violations, got %+v", len(want), violations)
+	}
+	for _, v := range violations {
+		if want[v.Field] != v.Message {
+			t.Errorf("%s: expected %q, got %q", v.Field, want[v.Field], v.Message)
+		}
+	}
+}
+
+func TestBindJSON_SafeInt(t *testing.T) {
+	rs := newNumberTestRouter(t)
+
+	code, violations := postJSON(t, rs, "/numbers", `{"amount":9007199254740992,"metadata":{"id":1}}`)
+	if code != http.StatusBadRequest || len(violations) != 1 || violations[0].Field != "amount" {
+		t.Fatalf("expected a safeint violation on amount, got %d %+v", code, violations)
+	}
+	if violations[0].Message != "Must be between -9007199254740991 and 9007199254740991" {
+		t.Errorf("unexpected message: %q", violations[0].Message)
+	}
+
+	code, violations = postJSON(t, rs, "/numbers", `{"amount":92233720368547758070}`)
+	if code != http.StatusBadRequest || len(violations) != 1 || violations[0].Message != "Must be between -9223372036854775808 and 9223372036854775807" {
+		t.Fatalf("expected an int64 range violation, got %d %+v", code, violations)
+	}
+}
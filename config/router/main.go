@@ -1,6 +1,7 @@
 package router
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"math"
@@ -8,11 +9,25 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/anomaly"
+	"github.com/akeren/go-api-foundry/pkg/auth"
+	"github.com/akeren/go-api-foundry/pkg/botdetect"
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
 	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/geoip"
+	"github.com/akeren/go-api-foundry/pkg/idempotency"
+	"github.com/akeren/go-api-foundry/pkg/ipfilter"
+	"github.com/akeren/go-api-foundry/pkg/principal"
 	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+	"github.com/akeren/go-api-foundry/pkg/rbac"
+	"github.com/akeren/go-api-foundry/pkg/respcache"
+	"github.com/akeren/go-api-foundry/pkg/slowprof"
+	"github.com/akeren/go-api-foundry/pkg/tarpit"
 	"github.com/akeren/go-api-foundry/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -28,7 +43,6 @@ type MiddlewareConfig struct {
 	TimeoutDuration time.Duration
 }
 
-
 type Cache interface {
 	Ping(ctx context.Context) error
 }
@@ -44,17 +58,101 @@ type RouterService struct {
 	rateLimiter       ratelimit.RateLimiter
 	rateLimitRequests int
 	rateLimitWindow   time.Duration
-	redisClient       *redis.Client
-	middlewareConfig  *MiddlewareConfig
+	// rateLimitAlgorithm selects the Redis limiters the router creates.
+	rateLimitAlgorithm ratelimit.Algorithm
+	rateLimitBreaker   *circuitbreaker.Breaker
+	redisClient        *redis.Client
+	middlewareConfig   *MiddlewareConfig
 
 	handlerToControllerMap map[string]*RESTController
-	rateLimitOverrides     map[string]ratelimit.RateLimiter
+	routes                 []*Route
+	// routeIndex finds a route by keyForPathAndMethod.
+	routeIndex         map[string]*Route
+	rateLimitOverrides map[string]ratelimit.RateLimiter
+	// routesByPath holds the routes registered on each path, for every
+	// method; routePaths matches request paths to those paths.
+	routesByPath map[string][]*Route
+	routePaths   *routePathNode
+
+	metrics     *metrics
+	ipFilter    *ipfilter.Filter
+	anomalies   *anomaly.Scorer
+	geoProvider geoip.Provider
+	geoFence    geoip.Fence
+
+	botDetectors []botdetect.Detector
+	captcha      *botdetect.CaptchaVerifier
+	tarpit       *tarpit.Tarpit
+
+	serviceIdentities map[string]*serviceIdentity
+	tokenValidator    *auth.Validator
+	roleStore         rbac.Store
+
+	// apiKeyHeader carries the API keys apiKeyValidator accepts.
+	apiKeyHeader    string
+	apiKeyValidator APIKeyValidator
+
+	idempotencyStore idempotency.Store
+	idempotencyTTL   time.Duration
+
+	// responseCache serves the routes decorated with CacheFor; nil disables it.
+	responseCache respcache.Store
+
+	enforceFieldAccess bool
+	// strictJSON makes BindJSON reject unknown fields on routes that do not
+	// set Route.StrictJSON.
+	strictJSON bool
+	// pooledJSON encodes envelopes into pooled buffers on routes that do not
+	// set Route.PooledJSON.
+	pooledJSON bool
+	// payloadBudget applies to routes that do not set Route.Budget.
+	payloadBudget PayloadBudget
+	// queryBudget applies to routes that do not set Route.QueryBudget; zero
+	// means no budget.
+	queryBudget int
+	// queryCountHeader sets QueryCountHeader on every response.
+	queryCountHeader bool
+	// slowRequestThreshold is the latency over which requests are logged as
+	// slow; zero disables it. slowProfiler, when set, profiles them.
+	slowRequestThreshold time.Duration
+	slowProfiler         *slowprof.Profiler
+	// profileLabels runs requests under pprof labels; profileTenantClaim
+	// names the JWT claim for their tenant label.
+	profileLabels      bool
+	profileTenantClaim string
+	// etags hashes successful GET responses into an ETag.
+	etags bool
+	// ready is reported at /health/ready; it is cleared when shutdown starts.
+	ready atomic.Bool
+	// unmappedRoutes remembers registered routes without a controller mapping
+	// so the misconfiguration is reported once rather than on every request.
+	unmappedRoutes sync.Map
+
+	// messages overrides error messages; nil when no catalog is registered.
+	messages *messageCatalog
+
+	// maxBodyBytes applies to routes without their own MaxBodyBytes.
+	maxBodyBytes int64
+
+	// corsConfig is the base for routes with a CORS override; cors is its
+	// policy for every other route.
+	corsConfig CORSConfig
+	cors       *corsPolicy
 }
 
 type RouterConfig struct {
 	RateLimitRequests int
 	RateLimitWindow   time.Duration
-	RequestTimeout    time.Duration
+	// RateLimitAlgorithm selects the Redis limiter; sliding window when empty.
+	RateLimitAlgorithm ratelimit.Algorithm
+	RequestTimeout     time.Duration
+	// Messages optionally overrides the messages of error responses.
+	Messages *MessageCatalog
+	// CORS defaults to CORSConfigFromEnv when nil.
+	CORS *CORSConfig
+	// RateLimitBreaker, when set, guards the Redis rate limiters: while it
+	// is open, requests are counted in memory instead.
+	RateLimitBreaker *circuitbreaker.Breaker
 }
 
 func CreateRouterService(logger *log.Logger, cache Cache, routerConfig *RouterConfig) *RouterService {
@@ -93,39 +191,77 @@ func CreateRouterService(logger *log.Logger, cache Cache, routerConfig *RouterCo
 	}
 
 	rs := &RouterService{
-		engine:            ginRouter,
-		logger:            logger,
-		rateLimitRequests: routerConfig.RateLimitRequests,
-		rateLimitWindow:   routerConfig.RateLimitWindow,
-		redisClient:       redisClient,
-		middlewareConfig:  &MiddlewareConfig{TimeoutDuration: routerConfig.RequestTimeout},
+		engine:             ginRouter,
+		logger:             logger,
+		rateLimitRequests:  routerConfig.RateLimitRequests,
+		rateLimitWindow:    routerConfig.RateLimitWindow,
+		rateLimitAlgorithm: routerConfig.RateLimitAlgorithm,
+		rateLimitBreaker:   routerConfig.RateLimitBreaker,
+		redisClient:        redisClient,
+		middlewareConfig:   &MiddlewareConfig{TimeoutDuration: routerConfig.RequestTimeout},
 
 		// Maps to track controller-specific and handler-specific rate limit overrides
 		rateLimitOverrides:     make(map[string]ratelimit.RateLimiter),
 		handlerToControllerMap: make(map[string]*RESTController),
+		routeIndex:             make(map[string]*Route),
+		routesByPath:           make(map[string][]*Route),
+		routePaths:             newRoutePathNode(),
 	}
 
+	rs.ready.Store(true)
 	rs.initRateLimiting()
+	rs.initIPFilter()
+	rs.initAnomalyScoring()
+	rs.initGeoIP()
+	rs.initBotDetection()
+	rs.initTarpit()
+	rs.initServiceIdentities()
+	rs.initAuth()
+	rs.initFieldAccess()
+	rs.initStrictJSON()
+	rs.initPooledJSON()
+	rs.initPayloadBudget()
+	rs.initQueryBudget()
+	rs.initSlowRequests()
+	rs.initProfileLabels()
+	rs.initETags()
+	rs.initMessageCatalog(routerConfig.Messages)
+	rs.initCORS(routerConfig.CORS)
+	rs.initMaxBodySize()
 
 	// Observability (opt-out): /metrics
 	rs.mountMetrics()
 
+	// Ahead of every middleware that may reject a request.
+	if rs.messages != nil {
+		ginRouter.Use(rs.messageCatalogMiddleware())
+	}
+
+	ginRouter.Use(rs.regionHeaderMiddleware())
 	ginRouter.Use(rs.securityHeadersMiddleware())
 	ginRouter.Use(rs.maxBodySizeMiddleware())
 	ginRouter.Use(rs.corsMiddleware())
+	ginRouter.Use(rs.ipFilterMiddleware())
+	ginRouter.Use(rs.geoIPMiddleware())
+	ginRouter.Use(rs.botDetectionMiddleware())
+	ginRouter.Use(rs.serviceIdentityMiddleware())
 	ginRouter.Use(rs.rateLimitMiddleware()) // Add rate limiting before other middleware
 	ginRouter.Use(rs.timeoutMiddleware())
 
 	ginRouter.Use(rs.correlationIDMiddleware())
+	ginRouter.Use(rs.profileLabelsMiddleware())
 	ginRouter.Use(rs.loggerInjectionMiddleware())
 	ginRouter.Use(rs.requestLoggingMiddleware())
+	ginRouter.Use(rs.queryCountMiddleware())
+	ginRouter.Use(rs.slowRequestMiddleware())
 
 	ginRouter.HandleMethodNotAllowed = true
 	ginRouter.RedirectTrailingSlash = true
 
+	// Unknown routes are ordinary traffic (typos, crawlers, scanners). They are
+	// aggregated by the anomaly scorer instead of being logged one by one.
 	ginRouter.NoRoute(func(c *gin.Context) {
-		correlatedLogger := logger.WithCorrelationID(c.Request.Context())
-		correlatedLogger.Error("Route not found")
+		rs.recordUnknownRoute(c)
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    apperrors.StatusNotFound,
 			"message": "Route not found",
@@ -134,8 +270,7 @@ func CreateRouterService(logger *log.Logger, cache Cache, routerConfig *RouterCo
 	})
 
 	ginRouter.NoMethod(func(c *gin.Context) {
-		correlatedLogger := logger.WithCorrelationID(c.Request.Context())
-		correlatedLogger.Error("Method not allowed")
+		rs.recordUnknownRoute(c)
 		c.JSON(http.StatusMethodNotAllowed, gin.H{
 			"code":    apperrors.StatusMethodNotAllowed,
 			"message": "Method not allowed",
@@ -196,15 +331,18 @@ func (routerService *RouterService) initRateLimiting() {
 		if err := redisClient.Ping(context.Background()).Err(); err != nil {
 			routerService.logger.Warn("Failed to connect to Redis for rate limiting, falling back to in-memory", "error", err)
 			redisClient = nil
+			routerService.redisClient = nil
 		}
 	}
 
 	// Create rate limiter using strategy pattern
 	config := &ratelimit.RateLimitConfig{
-		Requests: requests,
-		Window:   window,
-		Redis:    redisClient,
-		Logger:   routerService.logger,
+		Requests:  requests,
+		Window:    window,
+		Redis:     redisClient,
+		Logger:    routerService.logger,
+		Algorithm: routerService.rateLimitAlgorithm,
+		Breaker:   routerService.rateLimitBreaker,
 	}
 
 	routerService.rateLimiter = ratelimit.NewRateLimiter(config)
@@ -212,7 +350,8 @@ func (routerService *RouterService) initRateLimiting() {
 	if redisClient != nil {
 		routerService.logger.Info("Rate limiting initialized with Redis",
 			"requests", requests,
-			"window", window)
+			"window", window,
+			"algorithm", cmp.Or(routerService.rateLimitAlgorithm, ratelimit.AlgorithmSlidingWindow))
 	} else {
 		routerService.logger.Info("Rate limiting initialized with in-memory limiter",
 			"requests", requests,
@@ -238,6 +377,22 @@ func (routerService *RouterService) Cleanup() {
 			routerService.logger.Error("Failed to close rate limiter", "error", err)
 		}
 	}
+	if routerService.anomalies != nil {
+		routerService.anomalies.Flush()
+	}
+	if err := closeGeoIPProvider(routerService.geoProvider); err != nil {
+		routerService.logger.Error("Failed to close GeoIP provider", "error", err)
+	}
+	if routerService.slowProfiler != nil {
+		routerService.slowProfiler.Close()
+	}
+	closed := make(map[*serviceIdentity]bool)
+	for _, id := range routerService.serviceIdentities {
+		if id.limiter != nil && !closed[id] {
+			closed[id] = true
+			_ = id.limiter.Close()
+		}
+	}
 	routerService.logger.Info("Router service cleanup completed")
 }
 
@@ -248,7 +403,7 @@ func (routerService *RouterService) MountController(controller *RESTController)
 		"version", controller.version,
 	)
 
-	controller.prepare(routerService, controller)
+	controller.Register(routerService)
 
 	routerService.logger.Info("Controller mounted",
 		"name", controller.name,
@@ -266,9 +421,29 @@ func (routerService *RouterService) RunHTTPServer() error {
 	// Update server address
 	routerService.server.Addr = addr
 
-	routerService.logger.Info("Starting HTTP server", "addr", addr)
+	certFile := utils.GetEnvTrimmed("TLS_CERT_FILE")
+	keyFile := utils.GetEnvTrimmed("TLS_KEY_FILE")
+	if certFile == "" || keyFile == "" {
+		if utils.GetEnvTrimmed("MTLS_CLIENT_CA_FILE") != "" {
+			return fmt.Errorf("MTLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+
+		routerService.logger.Info("Starting HTTP server", "addr", addr)
+		if err := routerService.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			routerService.logger.Error("Failed to start HTTP server", "error", err)
+			return fmt.Errorf("failed to start HTTP server: %w", err)
+		}
+		return nil
+	}
+
+	tlsConfig, err := TLSConfig()
+	if err != nil {
+		return fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	routerService.server.TLSConfig = tlsConfig
 
-	if err := routerService.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	routerService.logger.Info("Starting HTTPS server", "addr", addr, "client_auth", tlsConfig.ClientAuth.String())
+	if err := routerService.server.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
 		routerService.logger.Error("Failed to start HTTP server", "error", err)
 		return fmt.Errorf("failed to start HTTP server: %w", err)
 	}
@@ -276,6 +451,19 @@ func (routerService *RouterService) RunHTTPServer() error {
 	return nil
 }
 
+// SetReady controls whether the instance reports itself ready for traffic.
+// Clearing it before Shutdown lets load balancers deregister the instance
+// while it still serves requests.
+func (routerService *RouterService) SetReady(ready bool) {
+	if routerService.ready.Swap(ready) != ready {
+		routerService.logger.Info("Readiness changed", "ready", ready)
+	}
+}
+
+func (routerService *RouterService) IsReady() bool {
+	return routerService.ready.Load()
+}
+
 func (routerService *RouterService) Shutdown(ctx context.Context) error {
 	routerService.logger.Info("Shutting down HTTP server gracefully...")
 	return routerService.server.Shutdown(ctx)
@@ -298,6 +486,12 @@ func (routerService *RouterService) correlationIDMiddleware() gin.HandlerFunc {
 func (routerService *RouterService) loggerInjectionMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		correlatedLogger := routerService.logger.WithCorrelationID(c.Request.Context())
+		if loc, ok := geoip.FromContext(c.Request.Context()); ok {
+			correlatedLogger = correlatedLogger.WithFields(loc.LogAttrs()...)
+		}
+		if p := principal.FromContext(c.Request.Context()); p != nil {
+			correlatedLogger = correlatedLogger.WithFields("principal", p.Subject, "principal_kind", string(p.Kind))
+		}
 		ctx := context.WithValue(c.Request.Context(), log.LoggerKeyForContext, correlatedLogger)
 		c.Request = c.Request.WithContext(ctx)
 		c.Next()
@@ -310,7 +504,7 @@ func (routerService *RouterService) requestLoggingMiddleware() gin.HandlerFunc {
 		c.Next()
 		latency := time.Since(start)
 
-		correlatedLogger := routerService.logger.WithCorrelationID(c.Request.Context())
+		correlatedLogger := log.GetLoggerInstanceFromContext(c.Request.Context(), routerService.logger)
 		correlatedLogger.Info("HTTP request",
 			"method", c.Request.Method,
 			"path", c.Request.URL.Path,
@@ -354,6 +548,10 @@ func shouldSetHSTS(c *gin.Context) bool {
 		return false
 	}
 
+	return requestIsHTTPS(c)
+}
+
+func requestIsHTTPS(c *gin.Context) bool {
 	if c.Request.TLS != nil {
 		return true
 	}
@@ -384,84 +582,6 @@ func buildHSTSValue() string {
 	return value
 }
 
-func (routerService *RouterService) maxBodySizeMiddleware() gin.HandlerFunc {
-	// Default: 1 MiB. Adjust via MAX_REQUEST_BODY_BYTES.
-	maxBytes := int64(1 << 20)
-	if raw := strings.TrimSpace(os.Getenv("MAX_REQUEST_BODY_BYTES")); raw != "" {
-		if parsed, err := strconv.ParseInt(raw, 10, 64); err == nil && parsed > 0 {
-			maxBytes = parsed
-		}
-	}
-
-	return func(c *gin.Context) {
-		// Fast-path for known-size bodies.
-		if c.Request.ContentLength > maxBytes {
-			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorResult(
-				http.StatusRequestEntityTooLarge,
-				"Request payload too large",
-				nil,
-			).ToJSON())
-			return
-		}
-		if c.Request.Body != nil {
-			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
-		}
-		c.Next()
-	}
-}
-
-func (routerService *RouterService) corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		origin := c.GetHeader("Origin")
-
-		allowedOriginsStr := os.Getenv("CORS_ALLOWED_ORIGIN")
-
-		if allowedOriginsStr == "" {
-
-			routerService.logger.Warn("CORS_ALLOWED_ORIGIN not set, denying cross-origin request", "origin", origin)
-
-			c.Next()
-
-			return
-		}
-
-		// Parse comma-separated origins
-		allowedOrigins := strings.Split(allowedOriginsStr, ",")
-		for i, o := range allowedOrigins {
-			allowedOrigins[i] = strings.TrimSpace(o)
-		}
-
-		originAllowed := false
-		for _, allowedOrigin := range allowedOrigins {
-			if allowedOrigin == "*" || allowedOrigin == origin {
-				originAllowed = true
-				break
-			}
-		}
-
-		if !originAllowed {
-			routerService.logger.Warn("CORS origin not allowed", "origin", origin, "allowed_origins", allowedOrigins)
-
-			c.Next()
-
-			return
-		}
-
-		// Set CORS headers
-		c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(apperrors.StatusNoContent)
-			return
-		}
-
-		c.Next()
-	}
-}
-
 func (routerService *RouterService) timeoutMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Create a context with timeout from config
@@ -495,26 +615,34 @@ func (routerService *RouterService) timeoutMiddleware() gin.HandlerFunc {
 func (routerService *RouterService) rateLimitMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		clientIP := c.ClientIP()
-		key := fmt.Sprintf("ratelimit:%s", clientIP)
+		key := ClientRateLimitKey(clientIP)
 		handlerPath := c.Request.URL.Path
 		handlerKey := routerService.keyForPathAndMethod(c.FullPath(), c.Request.Method)
 		handlerController, controllerFound := routerService.handlerToControllerMap[handlerKey]
 		handlerOverride, handlerRouterFound := routerService.rateLimitOverrides[handlerKey]
 
+		// Make room for the context to override the limiter
+		var usedLimiter ratelimit.RateLimiter = routerService.rateLimiter
+
 		if !controllerFound || handlerController == nil {
-			routerService.logger.Error("Possible development anomaly detected. A handler might have been configured without a controller mapping", "path", handlerPath, "cases", []string{
-				"Incorrect mounting of controller, direct handler registration without controller, improper handler path normalization, or misconfiguration in route definitions",
-				"Usage of a non-existent handler. Possible round robin brute force attack or incorrect utilization by an engineer",
-			})
-			c.AbortWithStatusJSON(http.StatusNotFound, NotFoundResult(fmt.Sprintf("There is no handler configured to handle any resource at the path %s", handlerPath)).ToJSON())
+			if c.FullPath() != "" {
+				if _, reported := routerService.unmappedRoutes.LoadOrStore(handlerKey, struct{}{}); !reported {
+					routerService.logger.Error("Possible development anomaly detected. A handler was configured without a controller mapping", "path", c.FullPath(), "method", c.Request.Method, "cases", []string{
+						"Incorrect mounting of controller, direct handler registration without controller, improper handler path normalization, or misconfiguration in route definitions",
+					})
+				}
+				c.AbortWithStatusJSON(http.StatusNotFound, NotFoundResult(fmt.Sprintf("There is no handler configured to handle any resource at the path %s", handlerPath)).ToJSON())
+				return
+			}
+
+			// No route matched: rate limit with the default limiter and let
+			// NoRoute/NoMethod answer.
+			routerService.applyRateLimit(c, usedLimiter, key, clientIP)
 			return
 		}
 
 		controllerOverride, controllerRouterFound := routerService.rateLimitOverrides[handlerController.mountPoint]
 
-		// Make room for the context to override the limiter
-		var usedLimiter ratelimit.RateLimiter = routerService.rateLimiter
-
 		// If there is a controller override, first use it.
 		if controllerRouterFound {
 			usedLimiter = controllerOverride
@@ -526,41 +654,69 @@ func (routerService *RouterService) rateLimitMiddleware() gin.HandlerFunc {
 			usedLimiter = handlerOverride
 		}
 
-		limit, window := usedLimiter.GetLimitDetails()
-
-		// Use strategy pattern to check rate limit
-		if usedLimiter != nil {
-			limited, err := usedLimiter.IsLimited(key)
-			if err != nil {
-				routerService.logger.Error("Rate limiter error", "error", err, "client_ip", clientIP)
-				// On rate limiter error, allow request but log the issue
-				// This prevents blocking legitimate traffic due to infrastructure issues
-				c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
-				c.Header("X-RateLimit-Window", window.String())
-				c.Next()
-				return
+		// Services authenticated with a client certificate are limited per
+		// service instead of per IP, using their own quota when configured.
+		if serviceLimiter, serviceKey, isService := serviceRateLimit(c); isService {
+			key = serviceKey
+			if serviceLimiter != nil {
+				usedLimiter = serviceLimiter
 			}
-			if limited {
-				routerService.logger.Warn("Rate limit exceeded", "client_ip", clientIP)
-				c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
-				c.Header("X-RateLimit-Window", window.String())
-				retryAfterSeconds := int(math.Ceil(window.Seconds()))
-				if retryAfterSeconds < 1 {
-					retryAfterSeconds = 1
-				}
-				c.Header("Retry-After", strconv.Itoa(retryAfterSeconds))
-				c.AbortWithStatusJSON(http.StatusTooManyRequests, TooManyRequestsResult(RateLimitResponse{
-					Limit:      limit,
-					Window:     window.String(),
-					RetryAfter: strconv.Itoa(retryAfterSeconds),
-				}).ToJSON())
+		} else if keyFunc := routerService.rateLimitKeyFor(handlerKey, handlerController); keyFunc != nil {
+			// Keyed routes are limited once the caller is authenticated. The
+			// default limit still counts the client IP first, so requests
+			// that CSRF or authentication reject are throttled too.
+			if !routerService.checkRateLimit(c, routerService.rateLimiter, key, clientIP) {
 				return
 			}
+			c.Set(keyedRateLimitContextKey, keyedRateLimit{limiter: usedLimiter, key: keyFunc})
+			c.Next()
+			return
 		}
 
-		// Add rate limit headers to successful requests
-		c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limit))
-		c.Header("X-RateLimit-Window", window.String())
+		routerService.applyRateLimit(c, usedLimiter, key, clientIP)
+	}
+}
+
+func (routerService *RouterService) applyRateLimit(c *gin.Context, usedLimiter ratelimit.RateLimiter, key, clientIP string) {
+	if routerService.checkRateLimit(c, usedLimiter, key, clientIP) {
 		c.Next()
 	}
 }
+
+// checkRateLimit counts the request against key and sets the rate limit
+// headers. It aborts the request with 429 and returns false when key's quota
+// is used up.
+func (routerService *RouterService) checkRateLimit(c *gin.Context, usedLimiter ratelimit.RateLimiter, key, clientIP string) bool {
+	limit, window := usedLimiter.GetLimitDetails()
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+	c.Header("X-RateLimit-Window", window.String())
+
+	decision, err := usedLimiter.IsLimited(key)
+	if err != nil {
+		routerService.logger.Error("Rate limiter error", "error", err, "client_ip", clientIP)
+		// On rate limiter error, allow request but log the issue
+		// This prevents blocking legitimate traffic due to infrastructure issues
+		return true
+	}
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+	if decision.Limited {
+		routerService.logger.Warn("Rate limit exceeded", "client_ip", clientIP, "key", key)
+		routerService.holdAbusiveClient(c, tarpit.SeverityRateLimited)
+		retryAfter := strconv.Itoa(max(ceilSeconds(decision.RetryAfter), 1))
+		c.Header("Retry-After", retryAfter)
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, TooManyRequestsResult(RateLimitResponse{
+			Limit:      limit,
+			Window:     window.String(),
+			RetryAfter: retryAfter,
+		}).ToJSON())
+		return false
+	}
+	return true
+}
+
+// ceilSeconds rounds d up to whole seconds, as Retry-After and
+// X-RateLimit-Reset carry it.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -12,7 +12,7 @@ import (
 )
 
 func mountTestController(rs *RouterService) {
-	ctrl := NewRESTController("TestController", "/", func(rs *RouterService, c *RESTController) {
+	ctrl := NewRESTController("TestController", "/", func(rs RouteRegistrar, c *RESTController) {
 		rs.AddGetHandler(c, nil, "ip", func(ctx *RequestContext) *ServiceResult {
 			return OKResult(ctx.ClientIP(), "ok")
 		})
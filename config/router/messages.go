@@ -0,0 +1,217 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// MessageCatalog overrides the messages of error responses without changing
+// the code that produces them, to rebrand or localize them. Keys are the
+// default messages, such as "Route not found" or "insufficient funds", and
+// match whole messages only. The messages of validation errors in the data
+// of a response are replaced too.
+type MessageCatalog struct {
+	// Messages replaces messages for every client.
+	Messages map[string]string `json:"messages"`
+	// Languages replaces messages for clients whose Accept-Language prefers
+	// one of its tags, such as "fr" or "pt-BR", ahead of Messages.
+	Languages map[string]map[string]string `json:"languages"`
+}
+
+// messageCatalog is a MessageCatalog ready to match clients' languages.
+type messageCatalog struct {
+	messages  map[string]string
+	tags      []language.Tag
+	languages []map[string]string
+	matcher   language.Matcher
+}
+
+// initMessageCatalog compiles catalog or, when it is nil, the catalog in
+// MESSAGE_CATALOG_FILE.
+func (routerService *RouterService) initMessageCatalog(catalog *MessageCatalog) {
+	if path := utils.GetEnvTrimmed("MESSAGE_CATALOG_FILE"); catalog == nil && path != "" {
+		loaded, err := loadMessageCatalog(path)
+		if err != nil {
+			routerService.logger.Error("Failed to load MESSAGE_CATALOG_FILE; using default messages", "error", err)
+			return
+		}
+		catalog = loaded
+	}
+	if catalog == nil || (len(catalog.Messages) == 0 && len(catalog.Languages) == 0) {
+		return
+	}
+	// The first tag is what the matcher returns for clients it cannot match.
+	compiled := &messageCatalog{messages: catalog.Messages, tags: []language.Tag{language.Und}, languages: []map[string]string{nil}}
+	for name, messages := range catalog.Languages {
+		tag, err := language.Parse(name)
+		if err != nil {
+			routerService.logger.Warn("Invalid message catalog language; ignoring it", "language", name, "error", err)
+			continue
+		}
+		compiled.tags = append(compiled.tags, tag)
+		compiled.languages = append(compiled.languages, messages)
+	}
+	compiled.matcher = language.NewMatcher(compiled.tags)
+	routerService.messages = compiled
+	routerService.logger.Info("Message catalog enabled", "messages", len(catalog.Messages), "languages", len(compiled.tags)-1)
+}
+
+func loadMessageCatalog(path string) (*MessageCatalog, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var catalog MessageCatalog
+	if err := json.Unmarshal(raw, &catalog); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &catalog, nil
+}
+
+// lookup returns the replacement of message for the client's languages and
+// the language it is in, "" when it is from Messages.
+func (m *messageCatalog) lookup(acceptLanguage, message string) (string, string) {
+	if acceptLanguage != "" && len(m.tags) > 1 {
+		if prefs, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil && len(prefs) > 0 {
+			_, index, confidence := m.matcher.Match(prefs...)
+			if confidence != language.No && index > 0 {
+				if replacement, ok := m.languages[index][message]; ok {
+					return replacement, m.tags[index].String()
+				}
+			}
+		}
+	}
+	if replacement, ok := m.messages[message]; ok {
+		return replacement, ""
+	}
+	return message, ""
+}
+
+// messageCatalogMiddleware holds back JSON error responses and writes them
+// with their messages replaced from the catalog. Successful responses pass
+// through untouched.
+func (routerService *RouterService) messageCatalogMiddleware() gin.HandlerFunc {
+	catalog := routerService.messages
+	return func(c *gin.Context) {
+		writer := &catalogWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.held == nil {
+			return
+		}
+		body, lang := catalog.translate(writer.held.Bytes(), c.GetHeader("Accept-Language"))
+		if lang != "" {
+			c.Writer.Header().Set("Content-Language", lang)
+			c.Writer.Header().Add("Vary", "Accept-Language")
+		}
+		c.Writer.Header().Del("Content-Length")
+		_, _ = c.Writer.Write(body)
+	}
+}
+
+// translate replaces the message of a response envelope and of the
+// validation errors in its data. A body it cannot parse is returned as is.
+func (m *messageCatalog) translate(body []byte, acceptLanguage string) ([]byte, string) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return body, ""
+	}
+	var lang string
+	replace := func(raw json.RawMessage) (json.RawMessage, bool) {
+		var message string
+		if json.Unmarshal(raw, &message) != nil {
+			return raw, false
+		}
+		replacement, from := m.lookup(acceptLanguage, message)
+		if replacement == message {
+			return raw, false
+		}
+		if from != "" {
+			lang = from
+		}
+		encoded, _ := json.Marshal(replacement)
+		return encoded, true
+	}
+
+	changed := false
+	if raw, ok := envelope["message"]; ok {
+		envelope["message"], changed = replace(raw)
+	}
+	var violations []map[string]json.RawMessage
+	if raw, ok := envelope["data"]; ok && json.Unmarshal(raw, &violations) == nil {
+		dataChanged := false
+		for _, v := range violations {
+			if raw, ok := v["message"]; ok {
+				var replaced bool
+				if v["message"], replaced = replace(raw); replaced {
+					dataChanged = true
+				}
+			}
+		}
+		if dataChanged {
+			envelope["data"], _ = json.Marshal(violations)
+			changed = true
+		}
+	}
+	if !changed {
+		return body, ""
+	}
+	translated, err := json.Marshal(envelope)
+	if err != nil {
+		return body, ""
+	}
+	return translated, lang
+}
+
+// catalogWriter holds back the body of JSON error responses.
+type catalogWriter struct {
+	gin.ResponseWriter
+	held *bytes.Buffer
+}
+
+func (w *catalogWriter) holds() bool {
+	if w.held != nil {
+		return true
+	}
+	if w.ResponseWriter.Status() < http.StatusBadRequest || w.ResponseWriter.Written() ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return false
+	}
+	w.held = &bytes.Buffer{}
+	return true
+}
+
+func (w *catalogWriter) Write(b []byte) (int, error) {
+	if w.holds() {
+		return w.held.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *catalogWriter) WriteString(s string) (int, error) {
+	if w.holds() {
+		return w.held.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *catalogWriter) Written() bool {
+	return w.held != nil || w.ResponseWriter.Written()
+}
+
+func (w *catalogWriter) Size() int {
+	if w.held != nil {
+		return w.held.Len()
+	}
+	return w.ResponseWriter.Size()
+}
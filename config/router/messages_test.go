@@ -0,0 +1,145 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+)
+
+type catalogTestRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func newCatalogTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	rs := CreateRouterService(log.NewLoggerWithJSONOutput(), nil, &RouterConfig{
+		RateLimitRequests: 1000,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+		Messages: &MessageCatalog{
+			Messages: map[string]string{
+				"Route not found":    "Nothing lives here",
+				"insufficient funds": "Your balance is too low",
+			},
+			Languages: map[string]map[string]string{
+				"fr": {
+					"insufficient funds":     "Solde insuffisant",
+					"This field is required": "Ce champ est obligatoire",
+				},
+				"not a tag!": {"Route not found": "ignored"},
+			},
+		},
+	})
+	rs.MountController(NewRESTController("Items", "/items", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/ok", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(nil, "insufficient funds")
+		})
+		rs.AddGetHandler(c, nil, "/broke", func(ctx *RequestContext) *ServiceResult {
+			return BadRequestResult("insufficient funds", nil)
+		})
+		rs.AddPostHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			req, errResult := BindJSON[catalogTestRequest](ctx)
+			if errResult != nil {
+				return errResult
+			}
+			return OKResult(req.Name, "ok")
+		})
+	}))
+	return rs
+}
+
+func serveCatalog(t *testing.T, rs *RouterService, method, target, body, acceptLanguage string) (*httptest.ResponseRecorder, ServiceResult) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	var result ServiceResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	return w, result
+}
+
+func TestMessageCatalog_ReplacesErrorMessages(t *testing.T) {
+	rs := newCatalogTestRouter(t)
+
+	w, result := serveCatalog(t, rs, http.MethodGet, "/nowhere", "", "")
+	if w.Code != http.StatusNotFound || result.Message != "Nothing lives here" {
+		t.Fatalf("unknown route: got %d %q", w.Code, result.Message)
+	}
+
+	_, result = serveCatalog(t, rs, http.MethodGet, "/items/broke", "", "en-GB")
+	if result.Message != "Your balance is too low" {
+		t.Fatalf("default catalog: got %q", result.Message)
+	}
+
+	w, result = serveCatalog(t, rs, http.MethodGet, "/items/broke", "", "fr-CA,fr;q=0.9,en;q=0.5")
+	if result.Message != "Solde insuffisant" || w.Header().Get("Content-Language") != "fr" {
+		t.Fatalf("French catalog: got %q, Content-Language %q", result.Message, w.Header().Get("Content-Language"))
+	}
+
+	// Messages a language does not override fall back to the default catalog.
+	_, result = serveCatalog(t, rs, http.MethodGet, "/nowhere", "", "fr")
+	if result.Message != "Nothing lives here" {
+		t.Fatalf("fallback: got %q", result.Message)
+	}
+
+	_, result = serveCatalog(t, rs, http.MethodGet, "/items/ok", "", "fr")
+	if result.Message != "insufficient funds" {
+		t.Fatalf("successful responses must pass through, got %q", result.Message)
+	}
+}
+
+func TestMessageCatalog_ReplacesValidationMessages(t *testing.T) {
+	rs := newCatalogTestRouter(t)
+
+	w, result := serveCatalog(t, rs, http.MethodPost, "/items", `{}`, "fr")
+	raw, _ := json.Marshal(result.Data)
+	var violations []apperrors.ValidationErrorResponse
+	if err := json.Unmarshal(raw, &violations); err != nil {
+		t.Fatalf("invalid violations: %v", err)
+	}
+	if w.Code != http.StatusBadRequest || len(violations) != 1 || violations[0].Message != "Ce champ est obligatoire" {
+		t.Fatalf("expected a localized violation, got %d %+v", w.Code, violations)
+	}
+}
+
+func TestMessageCatalog_DisabledByDefault(t *testing.T) {
+	rs := newTestRouterService(t)
+	if rs.messages != nil {
+		t.Fatalf("expected no catalog without configuration")
+	}
+	_, result := serveCatalog(t, rs, http.MethodGet, "/nowhere", "", "")
+	if result.Message != "Route not found" {
+		t.Fatalf("got %q", result.Message)
+	}
+}
+
+func TestMessageCatalog_LoadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.json")
+	catalog := `{"messages":{"Route not found":"Lost?"},"languages":{"de":{"Route not found":"Nicht gefunden"}}}`
+	if err := os.WriteFile(path, []byte(catalog), 0o600); err != nil {
+		t.Fatalf("write catalog: %v", err)
+	}
+	t.Setenv("MESSAGE_CATALOG_FILE", path)
+	rs := newTestRouterService(t)
+
+	if _, result := serveCatalog(t, rs, http.MethodGet, "/nowhere", "", ""); result.Message != "Lost?" {
+		t.Fatalf("got %q", result.Message)
+	}
+	if _, result := serveCatalog(t, rs, http.MethodGet, "/nowhere", "", "de-AT"); result.Message != "Nicht gefunden" {
+		t.Fatalf("got %q", result.Message)
+	}
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,10 +13,21 @@ import (
 )
 
 type metrics struct {
+	registry        prometheus.Registerer
 	requestsTotal   *prometheus.CounterVec
 	requestDuration *prometheus.HistogramVec
+	requestSize     *prometheus.HistogramVec
+	responseSize    *prometheus.HistogramVec
+	blockedRequests *prometheus.CounterVec
+	tarpitted       *prometheus.CounterVec
+	overBudget      *prometheus.CounterVec
+	dbQueries       *prometheus.HistogramVec
+	dbOverBudget    *prometheus.CounterVec
 }
 
+// sizeBuckets run from 100 B to about 6.5 MB.
+var sizeBuckets = prometheus.ExponentialBuckets(100, 4, 9)
+
 func metricsEnabled() bool {
 	v := utils.GetEnvTrimmed("METRICS_ENABLED")
 	if v == "" {
@@ -30,6 +42,7 @@ func metricsEnabled() bool {
 
 func newMetrics(reg prometheus.Registerer) *metrics {
 	m := &metrics{
+		registry: reg,
 		requestsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "http_requests_total",
@@ -45,9 +58,61 @@ func newMetrics(reg prometheus.Registerer) *metrics {
 			},
 			[]string{"method", "route", "status"},
 		),
+		requestSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_request_size_bytes",
+				Help:    "HTTP request body size in bytes.",
+				Buckets: sizeBuckets,
+			},
+			[]string{"method", "route"},
+		),
+		responseSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_response_size_bytes",
+				Help:    "HTTP response body size in bytes.",
+				Buckets: sizeBuckets,
+			},
+			[]string{"method", "route"},
+		),
+		blockedRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_blocked_requests_total",
+				Help: "Total number of HTTP requests rejected before reaching a handler.",
+			},
+			[]string{"reason"},
+		),
+		tarpitted: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_tarpitted_requests_total",
+				Help: "Total number of abusive requests delayed by the tarpit before being rejected.",
+			},
+			[]string{"severity"},
+		),
+		overBudget: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_response_over_budget_total",
+				Help: "Total number of responses larger than their route's payload budget, by whether they were rejected or only logged.",
+			},
+			[]string{"method", "route", "action"},
+		),
+		dbQueries: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_request_db_queries",
+				Help:    "Database statements run per HTTP request.",
+				Buckets: []float64{0, 1, 2, 5, 10, 20, 50, 100, 200, 500},
+			},
+			[]string{"method", "route"},
+		),
+		dbOverBudget: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_request_db_queries_over_budget_total",
+				Help: "Total number of HTTP requests that ran more database statements than their route's query budget.",
+			},
+			[]string{"method", "route"},
+		),
 	}
 
-	reg.MustRegister(m.requestsTotal, m.requestDuration)
+	reg.MustRegister(m.requestsTotal, m.requestDuration, m.requestSize, m.responseSize, m.blockedRequests, m.tarpitted, m.overBudget, m.dbQueries, m.dbOverBudget)
 	return m
 }
 
@@ -62,10 +127,15 @@ func (routerService *RouterService) mountMetrics() {
 	reg.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
 
 	m := newMetrics(reg)
+	routerService.metrics = m
 
 	// Middleware
 	routerService.engine.Use(func(c *gin.Context) {
 		start := time.Now()
+		body := &countingReader{ReadCloser: c.Request.Body}
+		if c.Request.Body != nil {
+			c.Request.Body = body
+		}
 		c.Next()
 
 		route := c.FullPath()
@@ -77,6 +147,14 @@ func (routerService *RouterService) mountMetrics() {
 
 		m.requestsTotal.WithLabelValues(method, route, status).Inc()
 		m.requestDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+
+		// Chunked requests have no Content-Length; count what was read.
+		requestBytes := c.Request.ContentLength
+		if requestBytes < 0 {
+			requestBytes = body.n
+		}
+		m.requestSize.WithLabelValues(method, route).Observe(float64(requestBytes))
+		m.responseSize.WithLabelValues(method, route).Observe(float64(max(c.Writer.Size(), 0)))
 	})
 
 	// Endpoint
@@ -90,3 +168,63 @@ func (routerService *RouterService) mountMetrics() {
 
 	routerService.logger.Info("Metrics endpoint mounted", "path", "/metrics")
 }
+
+// RegisterMetrics exposes extra collectors, such as dependency probes, at
+// /metrics. It does nothing when metrics are disabled.
+func (routerService *RouterService) RegisterMetrics(collectors ...prometheus.Collector) {
+	if routerService.metrics == nil {
+		return
+	}
+	for _, c := range collectors {
+		if err := routerService.metrics.registry.Register(c); err != nil {
+			routerService.logger.Error("Failed to register metrics collector", "error", err)
+		}
+	}
+}
+
+func (routerService *RouterService) recordBlockedRequest(reason string) {
+	if routerService.metrics == nil {
+		return
+	}
+	routerService.metrics.blockedRequests.WithLabelValues(reason).Inc()
+}
+
+func (routerService *RouterService) recordTarpittedRequest(severity string) {
+	if routerService.metrics == nil {
+		return
+	}
+	routerService.metrics.tarpitted.WithLabelValues(severity).Inc()
+}
+
+func (routerService *RouterService) recordOverBudget(method, route, action string) {
+	if routerService.metrics == nil {
+		return
+	}
+	routerService.metrics.overBudget.WithLabelValues(method, route, action).Inc()
+}
+
+func (routerService *RouterService) recordQueries(method, route string, queries int64) {
+	if routerService.metrics == nil {
+		return
+	}
+	routerService.metrics.dbQueries.WithLabelValues(method, route).Observe(float64(queries))
+}
+
+func (routerService *RouterService) recordQueriesOverBudget(method, route string) {
+	if routerService.metrics == nil {
+		return
+	}
+	routerService.metrics.dbOverBudget.WithLabelValues(method, route).Inc()
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
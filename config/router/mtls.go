@@ -0,0 +1,273 @@
+package router
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const serviceIdentityContextKey = "router.service_identity"
+
+// ServiceIdentity maps client certificates to an internal service.
+type ServiceIdentity struct {
+	Name string `json:"name"`
+	// Match lists URI SANs (e.g. SPIFFE IDs), DNS SANs or subject common names.
+	Match []string `json:"match"`
+	Roles []string `json:"roles"`
+	// Allow lists "METHODS PATH" patterns such as "GET,POST /v1/ledger/*".
+	// An empty list allows every route.
+	Allow     []string `json:"allow"`
+	RateLimit *struct {
+		Requests int    `json:"requests"`
+		Window   string `json:"window"`
+	} `json:"rate_limit"`
+}
+
+type serviceIdentity struct {
+	name    string
+	roles   []string
+	allow   []routePattern
+	limiter ratelimit.RateLimiter
+}
+
+// routePattern matches a method list ("*" for any) and a route template or a
+// prefix ending in "*".
+type routePattern struct {
+	methods []string
+	path    string
+}
+
+func parseRoutePattern(v string) (routePattern, error) {
+	v = strings.TrimSpace(v)
+	sep := strings.LastIndex(v, " ")
+	if sep < 0 {
+		return routePattern{}, fmt.Errorf("route pattern %q: expected \"METHODS PATH\"", v)
+	}
+
+	p := routePattern{path: strings.TrimSpace(v[sep+1:])}
+	if methods := strings.TrimSpace(v[:sep]); methods != "*" {
+		for _, m := range strings.Split(methods, ",") {
+			if m = strings.ToUpper(strings.TrimSpace(m)); m != "" {
+				p.methods = append(p.methods, m)
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p routePattern) matches(method, route string) bool {
+	if len(p.methods) > 0 {
+		found := false
+		for _, m := range p.methods {
+			if m == method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if prefix, ok := strings.CutSuffix(p.path, "*"); ok {
+		return strings.HasPrefix(route, prefix)
+	}
+	return route == p.path
+}
+
+func (id *serviceIdentity) allows(method, route string) bool {
+	if len(id.allow) == 0 {
+		return true
+	}
+	for _, p := range id.allow {
+		if p.matches(method, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// certificateNames lists the identities a client certificate asserts, most
+// specific first.
+func certificateNames(cert *x509.Certificate) []string {
+	names := make([]string, 0, len(cert.URIs)+len(cert.DNSNames)+1)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	names = append(names, cert.DNSNames...)
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	return names
+}
+
+func (routerService *RouterService) initServiceIdentities() {
+	path := utils.GetEnvTrimmed("MTLS_IDENTITIES_FILE")
+	if path == "" {
+		return
+	}
+
+	identities, err := routerService.loadServiceIdentities(path)
+	if err != nil {
+		// Fail closed: client certificates without a mapping are rejected.
+		routerService.logger.Error("Failed to load MTLS_IDENTITIES_FILE; certificate callers will be rejected", "error", err)
+		return
+	}
+	routerService.serviceIdentities = identities
+	routerService.logger.Info("Service identities loaded", "count", len(identities))
+}
+
+func (routerService *RouterService) loadServiceIdentities(path string) (map[string]*serviceIdentity, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []ServiceIdentity
+	if err := json.Unmarshal(raw, &defs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	identities := make(map[string]*serviceIdentity)
+	for _, def := range defs {
+		if def.Name == "" || len(def.Match) == 0 {
+			return nil, errors.New("service identities require a name and at least one match")
+		}
+
+		id := &serviceIdentity{name: def.Name, roles: def.Roles}
+		for _, a := range def.Allow {
+			p, err := parseRoutePattern(a)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", def.Name, err)
+			}
+			id.allow = append(id.allow, p)
+		}
+
+		if def.RateLimit != nil {
+			window, err := time.ParseDuration(def.RateLimit.Window)
+			if err != nil || window <= 0 || def.RateLimit.Requests <= 0 {
+				return nil, fmt.Errorf("service %s: invalid rate_limit", def.Name)
+			}
+			id.limiter = ratelimit.NewRateLimiter(&ratelimit.RateLimitConfig{
+				Requests:  def.RateLimit.Requests,
+				Window:    window,
+				Redis:     routerService.redisClient,
+				Logger:    routerService.logger,
+				Algorithm: routerService.rateLimitAlgorithm,
+				Breaker:   routerService.rateLimitBreaker,
+			})
+		}
+
+		for _, m := range def.Match {
+			if _, dup := identities[m]; dup {
+				return nil, fmt.Errorf("certificate name %q is mapped to more than one service", m)
+			}
+			identities[m] = id
+		}
+	}
+
+	return identities, nil
+}
+
+// TLSConfig builds the server TLS configuration. Client certificates are
+// verified against MTLS_CLIENT_CA_FILE when set; MTLS_REQUIRED=true rejects
+// connections without one, otherwise public clients can still connect.
+func TLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	caFile := utils.GetEnvTrimmed("MTLS_CLIENT_CA_FILE")
+	if caFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("read MTLS_CLIENT_CA_FILE: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("MTLS_CLIENT_CA_FILE contains no PEM certificates")
+	}
+
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	if envBool("MTLS_REQUIRED", false) {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// serviceIdentityMiddleware turns a verified client certificate into a service
+// principal and enforces the service's route allow list.
+func (routerService *RouterService) serviceIdentityMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 || len(c.Request.TLS.VerifiedChains[0]) == 0 {
+			c.Next()
+			return
+		}
+
+		cert := c.Request.TLS.VerifiedChains[0][0]
+		names := certificateNames(cert)
+
+		var id *serviceIdentity
+		for _, name := range names {
+			if id = routerService.serviceIdentities[name]; id != nil {
+				break
+			}
+		}
+
+		if id == nil {
+			routerService.recordBlockedRequest("unknown_service")
+			routerService.logger.Warn("Client certificate does not map to a service identity", "names", names)
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResult(apperrors.StatusForbidden, "Unknown service identity", nil).ToJSON())
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		if !id.allows(c.Request.Method, route) {
+			routerService.recordBlockedRequest("service_unauthorized")
+			routerService.logger.Warn("Service is not authorized for route", "service", id.name, "method", c.Request.Method, "route", route)
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResult(apperrors.StatusForbidden, "Service is not authorized for this route", nil).ToJSON())
+			return
+		}
+
+		p := &principal.Principal{
+			Subject: id.name,
+			Kind:    principal.KindService,
+			Roles:   id.roles,
+			Attributes: map[string]string{
+				"cert_subject": cert.Subject.String(),
+				"cert_serial":  cert.SerialNumber.String(),
+			},
+		}
+		routerService.resolveRoles(c, p)
+		c.Request = c.Request.WithContext(principal.WithPrincipal(c.Request.Context(), p))
+		c.Set(serviceIdentityContextKey, id)
+		c.Next()
+	}
+}
+
+// serviceRateLimit returns the limiter and key for service callers, which are
+// limited per service rather than per IP.
+func serviceRateLimit(c *gin.Context) (ratelimit.RateLimiter, string, bool) {
+	v, ok := c.Get(serviceIdentityContextKey)
+	if !ok {
+		return nil, "", false
+	}
+	id := v.(*serviceIdentity)
+	return id.limiter, ServiceRateLimitKey(id.name), true
+}
@@ -0,0 +1,150 @@
+package router
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/principal"
+)
+
+const testIdentities = `[
+  {"name": "billing", "match": ["spiffe://corp/billing"], "roles": ["ledger:write"], "allow": ["GET,POST /whoami"], "rate_limit": {"requests": 1, "window": "1m"}},
+  {"name": "reporting", "match": ["reporting.internal"], "allow": ["GET /ip"]}
+]`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func newMTLSTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	t.Setenv("MTLS_IDENTITIES_FILE", writeFile(t, "identities.json", testIdentities))
+
+	rs := newTestRouterService(t)
+	mountTestController(rs)
+	rs.MountController(NewRESTController("WhoAmI", "/", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "whoami", func(ctx *RequestContext) *ServiceResult {
+			p := principal.FromContext(ctx.Request.Context())
+			if p == nil {
+				return OKResult(nil, "anonymous")
+			}
+			return OKResult(p, "ok")
+		})
+	}))
+	return rs
+}
+
+func serveWithCert(rs *RouterService, method, path string, cert *x509.Certificate) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if cert != nil {
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	return w
+}
+
+func TestServiceIdentity_MapsCertificateToPrincipal(t *testing.T) {
+	rs := newMTLSTestRouter(t)
+
+	spiffe, _ := url.Parse("spiffe://corp/billing")
+	cert := &x509.Certificate{URIs: []*url.URL{spiffe}, SerialNumber: big.NewInt(7)}
+
+	w := serveWithCert(rs, http.MethodGet, "/whoami", cert)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"subject":"billing"`) || !strings.Contains(body, `"kind":"service"`) {
+		t.Fatalf("expected service principal, got %s", body)
+	}
+
+	// Service callers are limited per service with their own quota (1/min).
+	if w := serveWithCert(rs, http.MethodGet, "/whoami", cert); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected per-service rate limit, got %d", w.Code)
+	}
+}
+
+func TestServiceIdentity_EnforcesAllowList(t *testing.T) {
+	rs := newMTLSTestRouter(t)
+	cert := &x509.Certificate{DNSNames: []string{"reporting.internal"}, SerialNumber: big.NewInt(1)}
+
+	if w := serveWithCert(rs, http.MethodGet, "/ip", cert); w.Code != http.StatusOK {
+		t.Fatalf("expected allowed route to pass, got %d", w.Code)
+	}
+	if w := serveWithCert(rs, http.MethodGet, "/whoami", cert); w.Code != http.StatusForbidden {
+		t.Fatalf("expected route outside the allow list to be rejected, got %d", w.Code)
+	}
+}
+
+func TestServiceIdentity_UnknownCertificateRejected(t *testing.T) {
+	rs := newMTLSTestRouter(t)
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "intruder"}, SerialNumber: big.NewInt(1)}
+
+	if w := serveWithCert(rs, http.MethodGet, "/ip", cert); w.Code != http.StatusForbidden {
+		t.Fatalf("expected unknown certificate to be rejected, got %d", w.Code)
+	}
+	if w := serveWithCert(rs, http.MethodGet, "/whoami", nil); w.Code != http.StatusOK {
+		t.Fatalf("expected requests without a client certificate to pass, got %d", w.Code)
+	}
+}
+
+func TestTLSConfig_ClientAuth(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("cert: %v", err)
+	}
+	caFile := writeFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+
+	cfg, err := TLSConfig()
+	if err != nil || cfg.ClientAuth != tls.NoClientCert {
+		t.Fatalf("expected plain TLS without a client CA, got %v err=%v", cfg.ClientAuth, err)
+	}
+
+	t.Setenv("MTLS_CLIENT_CA_FILE", caFile)
+	cfg, err = TLSConfig()
+	if err != nil || cfg.ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Fatalf("expected optional client certs, got %v err=%v", cfg.ClientAuth, err)
+	}
+
+	t.Setenv("MTLS_REQUIRED", "true")
+	cfg, err = TLSConfig()
+	if err != nil || cfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("expected required client certs, got %v err=%v", cfg.ClientAuth, err)
+	}
+
+	t.Setenv("MTLS_CLIENT_CA_FILE", writeFile(t, "empty.pem", "not a cert"))
+	if _, err := TLSConfig(); err == nil {
+		t.Fatalf("expected error for a CA file without certificates")
+	}
+}
@@ -0,0 +1,305 @@
+package router
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const swaggerUIVersion = "5.17.14"
+
+// Route is a registered handler. Describe attaches the request and response
+// types used to document it in the OpenAPI spec.
+type Route struct {
+	Method     string
+	Path       string
+	controller *RESTController
+	doc        OperationDoc
+	// strictJSON overrides RouterService.strictJSON when set.
+	strictJSON *bool
+	// budget overrides RouterService.payloadBudget when set.
+	budget *PayloadBudget
+	// cors overrides RouterService.corsConfig when set.
+	cors       *CORSConfig
+	corsPolicy routeCORS
+	// maxBodyBytes overrides the controller's and RouterService.maxBodyBytes
+	// when positive.
+	maxBodyBytes int64
+	// queryBudget overrides RouterService.queryBudget when set.
+	queryBudget *int
+	// pooledJSON overrides RouterService.pooledJSON when set.
+	pooledJSON *bool
+	// rateLimitKey overrides the controller's rateLimitKey when set.
+	rateLimitKey RateLimitKeyFunc
+}
+
+// OperationDoc documents one route. Request and Response are values (or
+// pointers) of the DTO types; their json and binding tags become the schema.
+type OperationDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Request     any
+	Response    any
+	Query       []QueryParam
+	// Status is the success status code (default 200).
+	Status int
+	// ContentTypes are the media types of a success response that is not
+	// a JSON envelope, such as a file download.
+	ContentTypes []string
+	// Hidden leaves the route out of the spec.
+	Hidden bool
+}
+
+type QueryParam struct {
+	Name        string
+	Description string
+	// Type is an OpenAPI primitive type (default "string").
+	Type     string
+	Required bool
+}
+
+// Describe documents the route. It returns the route for chaining.
+func (route *Route) Describe(doc OperationDoc) *Route {
+	route.doc = doc
+	return route
+}
+
+func (routerService *RouterService) recordRoute(controller *RESTController, method, path string) *Route {
+	route := &Route{Method: method, Path: path, controller: controller}
+	routerService.routes = append(routerService.routes, route)
+	routerService.routeIndex[routerService.keyForPathAndMethod(path, method)] = route
+	routerService.indexRoutePath(route)
+	return route
+}
+
+// Routes returns every registered route in registration order.
+func (routerService *RouterService) Routes() []*Route {
+	return routerService.routes
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIInfoFromEnv titles the spec after OTEL_SERVICE_NAME and versions it
+// with API_VERSION (default "1.0.0"). It returns nil when OPENAPI_ENABLED is
+// false, so the spec and docs are not served.
+func OpenAPIInfoFromEnv() *OpenAPIInfo {
+	if !envBool("OPENAPI_ENABLED", true) {
+		return nil
+	}
+	version := utils.GetEnvTrimmed("API_VERSION")
+	if version == "" {
+		version = "1.0.0"
+	}
+	return &OpenAPIInfo{Title: utils.OTelServiceName(), Version: version}
+}
+
+var pathParamPattern = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// OpenAPISpec builds an OpenAPI 3.0 document from the registered routes.
+func (routerService *RouterService) OpenAPISpec(info OpenAPIInfo) map[string]any {
+	schemas := newSchemaBuilder()
+	paths := make(map[string]map[string]any)
+	bearer := false
+
+	for _, route := range routerService.routes {
+		if route.doc.Hidden {
+			continue
+		}
+		specPath := pathParamPattern.ReplaceAllString(route.Path, "{$1}")
+		if paths[specPath] == nil {
+			paths[specPath] = make(map[string]any)
+		}
+
+		op := routerService.openAPIOperation(route, schemas)
+		if route.controller.requireAuth {
+			op["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+			bearer = true
+		}
+		paths[specPath][strings.ToLower(route.Method)] = op
+	}
+
+	schemas.components["ErrorResponse"] = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"code":    map[string]any{"type": "integer"},
+			"message": map[string]any{"type": "string"},
+			"data":    map[string]any{},
+		},
+	}
+	components := map[string]any{"schemas": schemas.components}
+	if bearer {
+		components["securitySchemes"] = map[string]any{
+			"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+		}
+	}
+
+	return map[string]any{
+		"openapi":    "3.0.3",
+		"info":       info,
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+func (routerService *RouterService) openAPIOperation(route *Route, schemas *schemaBuilder) map[string]any {
+	doc := route.doc
+	tags := doc.Tags
+	if len(tags) == 0 {
+		tags = []string{strings.TrimSuffix(route.controller.name, "Controller")}
+	}
+
+	op := map[string]any{
+		"operationId": operationID(route),
+		"tags":        tags,
+	}
+	if doc.Summary != "" {
+		op["summary"] = doc.Summary
+	}
+	if doc.Description != "" {
+		op["description"] = doc.Description
+	}
+
+	var params []any
+	for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		params = append(params, map[string]any{
+			"name": match[1], "in": "path", "required": true,
+			"schema": map[string]any{"type": "string"},
+		})
+	}
+	for _, q := range doc.Query {
+		typ := q.Type
+		if typ == "" {
+			typ = "string"
+		}
+		param := map[string]any{"name": q.Name, "in": "query", "required": q.Required, "schema": map[string]any{"type": typ}}
+		if q.Description != "" {
+			param["description"] = q.Description
+		}
+		params = append(params, param)
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if doc.Request != nil {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": schemas.schemaOf(doc.Request)}},
+		}
+	}
+
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	envelope := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"code":     map[string]any{"type": "integer"},
+			"message":  map[string]any{"type": "string"},
+			"warnings": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	if doc.Response != nil {
+		envelope["properties"].(map[string]any)["data"] = schemas.schemaOf(doc.Response)
+	}
+	content := map[string]any{"application/json": map[string]any{"schema": envelope}}
+	if len(doc.ContentTypes) > 0 {
+		content = make(map[string]any, len(doc.ContentTypes))
+		for _, contentType := range doc.ContentTypes {
+			content[contentType] = map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}
+		}
+	}
+	op["responses"] = map[string]any{
+		strconv.Itoa(status): map[string]any{
+			"description": http.StatusText(status),
+			"content":     content,
+		},
+		"default": map[string]any{
+			"description": "Error",
+			"content": map[string]any{"application/json": map[string]any{
+				"schema": map[string]any{"$ref": "#/components/schemas/ErrorResponse"},
+			}},
+		},
+	}
+	return op
+}
+
+// operationID derives a stable, unique ID such as "post_v1_ledger_accounts_id_deposit".
+func operationID(route *Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, part := range strings.Split(route.Path, "/") {
+		part = strings.Trim(part, ":*")
+		if part == "" {
+			continue
+		}
+		b.WriteByte('_')
+		b.WriteString(strings.NewReplacer("-", "_", ".", "_").Replace(part))
+	}
+	return b.String()
+}
+
+// NewOpenAPIController serves the spec of every mounted controller at
+// /openapi.json and a Swagger UI for it at /docs. The spec is built on the
+// first request, once all controllers are mounted. Only a RouterService knows
+// its routes; mounted elsewhere, /openapi.json serves an empty spec.
+func NewOpenAPIController(info OpenAPIInfo) *RESTController {
+	return NewRESTController("OpenAPIController", "/", func(rs RouteRegistrar, c *RESTController) {
+		var (
+			once sync.Once
+			spec []byte
+			err  error
+		)
+		hidden := OperationDoc{Hidden: true}
+		routes, _ := rs.(*RouterService)
+		if routes == nil {
+			routes = &RouterService{}
+		}
+
+		rs.AddGetHandler(c, nil, "openapi.json", func(ctx *RequestContext) *ServiceResult {
+			once.Do(func() { spec, err = json.Marshal(routes.OpenAPISpec(info)) })
+			if err != nil {
+				GetLogger(ctx).Error("Failed to build OpenAPI spec", "error", err)
+				return InternalServerErrorResult("Failed to build OpenAPI spec")
+			}
+			return DataResult(http.StatusOK, "application/json", spec)
+		}).Describe(hidden)
+
+		page := []byte(swaggerUIPage(info.Title))
+		rs.AddGetHandler(c, nil, "docs", func(ctx *RequestContext) *ServiceResult {
+			return DataResult(http.StatusOK, "text/html; charset=utf-8", page)
+		}).Describe(hidden)
+	})
+}
+
+func swaggerUIPage(title string) string {
+	cdn := "https://unpkg.com/swagger-ui-dist@" + swaggerUIVersion
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>` + html.EscapeString(title) + ` API</title>
+  <link rel="stylesheet" href="` + cdn + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="` + cdn + `/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => { window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" }); };
+  </script>
+</body>
+</html>
+`
+}
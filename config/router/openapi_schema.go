@@ -0,0 +1,247 @@
+package router
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemaBuilder derives OpenAPI 3.0 schemas from Go types. Named structs
+// become components referenced by $ref; field names follow the json tags and
+// validation rules follow the binding tags used by gin.
+type schemaBuilder struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{components: make(map[string]any), names: make(map[reflect.Type]string)}
+}
+
+func (b *schemaBuilder) schemaOf(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	return b.schema(t)
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := b.schema(t.Elem())
+		if _, isRef := s["$ref"]; isRef {
+			// Siblings of $ref are ignored in OpenAPI 3.0.
+			return map[string]any{"allOf": []any{s}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + b.component(t)}
+	default:
+		// Interfaces and anything else accept any value.
+		return map[string]any{}
+	}
+}
+
+// component registers a named struct once and returns its component name.
+// Types sharing a name across packages are qualified with the package name.
+func (b *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+
+	name := componentName(t.Name())
+	if _, taken := b.components[name]; taken {
+		name = exportedName(path.Base(t.PkgPath())) + name
+	}
+	b.names[t] = name
+	// Reserve the name before descending so recursive types terminate.
+	b.components[name] = map[string]any{}
+	b.components[name] = b.structSchema(t)
+	return name
+}
+
+// componentName flattens instantiated generic types, whose names carry full
+// package paths, e.g. PaginatedResult[example.com/ledger.TransactionResponse]
+// becomes PaginatedResultTransactionResponse.
+func componentName(name string) string {
+	base, args, generic := strings.Cut(name, "[")
+	if !generic {
+		return name
+	}
+	for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+		arg = strings.TrimLeft(arg, "*[]")
+		base += exportedName(arg[strings.LastIndex(arg, ".")+1:])
+	}
+	return base
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	b.addFields(t, properties, &required)
+
+	s := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (b *schemaBuilder) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(ft, properties, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s := b.schema(f.Type)
+		if applyBindingRules(s, f.Tag.Get("binding")) {
+			*required = append(*required, name)
+		}
+		properties[name] = s
+	}
+}
+
+// applyBindingRules copies the validator rules that have an OpenAPI
+// equivalent onto s and reports whether the field is required.
+func applyBindingRules(s map[string]any, binding string) (required bool) {
+	if binding == "" {
+		return false
+	}
+	// Rules after "dive" apply to slice elements.
+	binding, _, _ = strings.Cut(binding, ",dive")
+
+	isString := s["type"] == "string"
+	isArray := s["type"] == "array"
+	for _, rule := range strings.Split(binding, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "uuid":
+			s["format"] = "uuid"
+		case "email":
+			s["format"] = "email"
+		case "url":
+			s["format"] = "uri"
+		case "uppercase":
+			s["pattern"] = "^[^a-z]*$"
+		case "safeint":
+			s["maximum"] = MaxSafeInteger
+			if _, bounded := s["minimum"]; !bounded {
+				s["minimum"] = -MaxSafeInteger
+			}
+		case "oneof":
+			values := make([]any, 0)
+			for _, v := range strings.Fields(arg) {
+				values = append(values, v)
+			}
+			s["enum"] = values
+		case "len", "min", "max", "gt", "gte", "lt", "lte":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				continue
+			}
+			applyBound(s, name, n, isString, isArray)
+		}
+	}
+	return required
+}
+
+func applyBound(s map[string]any, rule string, n float64, isString, isArray bool) {
+	switch {
+	case isString || isArray:
+		minKey, maxKey := "minLength", "maxLength"
+		if isArray {
+			minKey, maxKey = "minItems", "maxItems"
+		}
+		switch rule {
+		case "len":
+			s[minKey], s[maxKey] = int(n), int(n)
+		case "min", "gte":
+			s[minKey] = int(n)
+		case "max", "lte":
+			s[maxKey] = int(n)
+		}
+	default:
+		switch rule {
+		case "min", "gte":
+			s["minimum"] = n
+		case "max", "lte":
+			s["maximum"] = n
+		case "gt":
+			s["minimum"], s["exclusiveMinimum"] = n, true
+		case "lt":
+			s["maximum"], s["exclusiveMaximum"] = n, true
+		}
+	}
+}
+
+func exportedName(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,166 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testWidgetRequest struct {
+	Name  string   `json:"name" binding:"required,min=1,max=50"`
+	Kind  string   `json:"kind" binding:"omitempty,oneof=small large"`
+	Count int64    `json:"count" binding:"required,gt=0"`
+	Tags  []string `json:"tags" binding:"omitempty,max=5,dive,min=1"`
+	Debug bool     `json:"-"`
+}
+
+type testWidget struct {
+	ID        string      `json:"id"`
+	Parent    *testWidget `json:"parent,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+func newOpenAPITestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	rs := newTestRouterService(t)
+	rs.MountController(NewVersionedRESTController("WidgetController", "v1", "/widgets", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddPostHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			return CreatedResult(nil, "Widget")
+		}).Describe(OperationDoc{Summary: "Create a widget", Request: testWidgetRequest{}, Response: testWidget{}, Status: http.StatusCreated})
+		rs.AddGetHandler(c, nil, "/:id", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(nil, "ok")
+		}).Describe(OperationDoc{Response: &testWidget{}, Query: []QueryParam{{Name: "expand", Type: "boolean"}}})
+		rs.AddGetHandler(c, nil, "/:id/export", func(ctx *RequestContext) *ServiceResult {
+			return DataResult(http.StatusOK, "text/csv", nil)
+		}).Describe(OperationDoc{ContentTypes: []string{"text/csv"}})
+	}).RequireAuth())
+	rs.MountController(NewWellKnownController(nil))
+	rs.MountController(NewOpenAPIController(OpenAPIInfo{Title: "test", Version: "1.2.3"}))
+	return rs
+}
+
+func fetchSpec(t *testing.T, rs *RouterService) map[string]any {
+	t.Helper()
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var spec map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("invalid spec JSON: %v", err)
+	}
+	return spec
+}
+
+// dig walks nested maps by key.
+func dig(t *testing.T, v any, keys ...string) any {
+	t.Helper()
+	for _, k := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("expected object at %q, got %T", k, v)
+		}
+		v = m[k]
+	}
+	return v
+}
+
+func TestOpenAPISpec_DocumentsRoutes(t *testing.T) {
+	spec := fetchSpec(t, newOpenAPITestRouter(t))
+
+	if spec["openapi"] != "3.0.3" || dig(t, spec, "info", "version") != "1.2.3" {
+		t.Fatalf("unexpected header %v %v", spec["openapi"], spec["info"])
+	}
+
+	paths := spec["paths"].(map[string]any)
+	for _, hidden := range []string{"/openapi.json", "/docs", "/robots.txt"} {
+		if _, ok := paths[hidden]; ok {
+			t.Fatalf("expected %s to be hidden", hidden)
+		}
+	}
+
+	create := dig(t, paths, "/v1/widgets", "post")
+	if dig(t, create, "summary") != "Create a widget" || dig(t, create, "tags").([]any)[0] != "Widget" {
+		t.Fatalf("unexpected operation %v", create)
+	}
+	if dig(t, create, "requestBody", "content", "application/json", "schema", "$ref") != "#/components/schemas/testWidgetRequest" {
+		t.Fatalf("unexpected request body %v", dig(t, create, "requestBody"))
+	}
+	if dig(t, create, "responses", "201", "content", "application/json", "schema", "properties", "data", "$ref") != "#/components/schemas/testWidget" {
+		t.Fatalf("unexpected 201 response %v", dig(t, create, "responses"))
+	}
+	if dig(t, create, "security") == nil || dig(t, spec, "components", "securitySchemes", "bearerAuth", "scheme") != "bearer" {
+		t.Fatal("expected bearer security for a RequireAuth controller")
+	}
+
+	get := dig(t, paths, "/v1/widgets/{id}", "get")
+	params := dig(t, get, "parameters").([]any)
+	if len(params) != 2 || dig(t, params[0], "in") != "path" || dig(t, params[0], "name") != "id" || dig(t, params[1], "name") != "expand" {
+		t.Fatalf("unexpected parameters %v", params)
+	}
+	if dig(t, get, "operationId") != "get_v1_widgets_id" {
+		t.Fatalf("unexpected operationId %v", dig(t, get, "operationId"))
+	}
+
+	export := dig(t, paths, "/v1/widgets/{id}/export", "get")
+	if dig(t, export, "responses", "200", "content", "text/csv", "schema", "format") != "binary" {
+		t.Fatalf("unexpected export response %v", dig(t, export, "responses"))
+	}
+}
+
+func TestOpenAPISpec_SchemasFollowTags(t *testing.T) {
+	spec := fetchSpec(t, newOpenAPITestRouter(t))
+	schemas := dig(t, spec, "components", "schemas")
+
+	req := dig(t, schemas, "testWidgetRequest").(map[string]any)
+	required, _ := json.Marshal(req["required"])
+	if string(required) != `["name","count"]` {
+		t.Fatalf("unexpected required fields %s", required)
+	}
+	props := req["properties"].(map[string]any)
+	if _, ok := props["Debug"]; ok {
+		t.Fatal(`json:"-" fields must be skipped`)
+	}
+	if dig(t, props, "name", "maxLength") != float64(50) || dig(t, props, "name", "minLength") != float64(1) {
+		t.Fatalf("unexpected name schema %v", props["name"])
+	}
+	if enum, _ := json.Marshal(dig(t, props, "kind", "enum")); string(enum) != `["small","large"]` {
+		t.Fatalf("unexpected enum %s", enum)
+	}
+	if dig(t, props, "count", "exclusiveMinimum") != true || dig(t, props, "count", "format") != "int64" {
+		t.Fatalf("unexpected count schema %v", props["count"])
+	}
+	if dig(t, props, "tags", "maxItems") != float64(5) {
+		t.Fatalf("rules before dive apply to the slice, got %v", props["tags"])
+	}
+
+	widget := dig(t, schemas, "testWidget", "properties")
+	if dig(t, widget, "created_at", "format") != "date-time" || dig(t, widget, "parent", "nullable") != true {
+		t.Fatalf("unexpected widget schema %v", widget)
+	}
+}
+
+func TestOpenAPIController_ServesSwaggerUI(t *testing.T) {
+	rs := newOpenAPITestRouter(t)
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `url: "/openapi.json"`) {
+		t.Fatalf("unexpected docs page %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestOpenAPIInfoFromEnv(t *testing.T) {
+	t.Setenv("OPENAPI_ENABLED", "false")
+	if OpenAPIInfoFromEnv() != nil {
+		t.Fatal("expected docs to be disabled")
+	}
+	t.Setenv("OPENAPI_ENABLED", "")
+	t.Setenv("API_VERSION", "2.0.0")
+	if info := OpenAPIInfoFromEnv(); info == nil || info.Version != "2.0.0" {
+		t.Fatalf("unexpected info %+v", info)
+	}
+}
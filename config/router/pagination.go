@@ -0,0 +1,153 @@
+package router
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Pagination is the page requested by a list endpoint. Page is 1-based.
+type Pagination struct {
+	Page    int
+	PerPage int
+	Offset  int
+
+	// byOffset is set when the caller paged with the older limit/offset
+	// parameters, so the links keep using them.
+	byOffset bool
+}
+
+// ParsePagination reads page and per_page from the query string. limit and
+// offset are still accepted for existing clients. Missing or invalid values
+// fall back to page 1 and defaultPerPage; per_page is capped at maxPerPage.
+func ParsePagination(ctx *RequestContext, defaultPerPage, maxPerPage int) Pagination {
+	p := Pagination{Page: 1, PerPage: defaultPerPage}
+
+	perPage := ctx.Query("per_page")
+	if perPage == "" {
+		perPage = ctx.Query("limit")
+	}
+	if n, err := strconv.Atoi(perPage); err == nil && n > 0 {
+		p.PerPage = min(n, maxPerPage)
+	}
+
+	if n, err := strconv.Atoi(ctx.Query("page")); err == nil && n > 0 {
+		p.Page = n
+	} else if n, err := strconv.Atoi(ctx.Query("offset")); err == nil && n > 0 {
+		p.Page = n/p.PerPage + 1
+		p.Offset = n
+		p.byOffset = true
+		return p
+	}
+	p.Offset = (p.Page - 1) * p.PerPage
+	return p
+}
+
+// PaginatedResult is the response body of a list endpoint. Its links are
+// relative links to the neighbouring and outermost pages, omitted at either
+// end.
+type PaginatedResult[T any] struct {
+	Data       []T             `json:"data"`
+	Page       int             `json:"page"`
+	PerPage    int             `json:"per_page"`
+	Total      int64           `json:"total"`
+	TotalPages int             `json:"total_pages"`
+	Links      PaginationLinks `json:"links"`
+}
+
+type PaginationLinks struct {
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	First string `json:"first,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// Header formats the links as an RFC 8288 Link header value, "" when there
+// are none.
+func (links PaginationLinks) Header() string {
+	var parts []string
+	for _, link := range []struct{ rel, target string }{
+		{"first", links.First}, {"prev", links.Prev}, {"next", links.Next}, {"last", links.Last},
+	} {
+		if link.target != "" {
+			parts = append(parts, "<"+link.target+`>; rel="`+link.rel+`"`)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// NewPaginatedResult wraps one page of items. Links keep the request's other
+// query parameters, such as filters.
+func NewPaginatedResult[T any](ctx *RequestContext, items []T, p Pagination, total int64) PaginatedResult[T] {
+	if items == nil {
+		items = []T{}
+	}
+	result := PaginatedResult[T]{
+		Data:    items,
+		Page:    p.Page,
+		PerPage: p.PerPage,
+		Total:   total,
+	}
+	if p.PerPage > 0 {
+		result.TotalPages = int((total + int64(p.PerPage) - 1) / int64(p.PerPage))
+	}
+
+	if int64(p.Offset+p.PerPage) < total {
+		result.Links.Next = pageLink(ctx, p, p.Offset+p.PerPage)
+	}
+	if p.Offset > 0 {
+		result.Links.Prev = pageLink(ctx, p, max(p.Offset-p.PerPage, 0))
+		result.Links.First = pageLink(ctx, p, 0)
+	}
+	if lastOffset := (result.TotalPages - 1) * p.PerPage; result.TotalPages > 0 && p.Offset != lastOffset {
+		result.Links.Last = pageLink(ctx, p, lastOffset)
+	}
+	return result
+}
+
+// WithLinkHeader sets the Link header to the pagination links, so clients
+// can page without parsing the body:
+//
+//	page := router.NewPaginatedResult(ctx, items, p, total)
+//	return router.OKResult(page, "Items retrieved successfully").WithLinkHeader(page.Links)
+func (result *ServiceResult) WithLinkHeader(links PaginationLinks) *ServiceResult {
+	if header := links.Header(); header != "" {
+		return result.WithHeader("Link", header)
+	}
+	return result
+}
+
+// Links are the hypermedia links of a resource by relation, such as "self",
+// for DTOs that embed them as `json:"_links,omitempty"`.
+type Links map[string]string
+
+// ResourceLink fills the parameters of a route template, such as
+// "/v1/ledger/accounts/:id", with values in order. Values are path-escaped.
+func ResourceLink(template string, values ...string) string {
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if len(values) == 0 {
+			break
+		}
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = url.PathEscape(values[0])
+			values = values[1:]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func pageLink(ctx *RequestContext, p Pagination, offset int) string {
+	query := ctx.Request.URL.Query()
+	for _, key := range []string{"page", "per_page", "limit", "offset"} {
+		query.Del(key)
+	}
+	if p.byOffset {
+		query.Set("limit", strconv.Itoa(p.PerPage))
+		query.Set("offset", strconv.Itoa(offset))
+	} else {
+		query.Set("page", strconv.Itoa(offset/p.PerPage+1))
+		query.Set("per_page", strconv.Itoa(p.PerPage))
+	}
+	return (&url.URL{Path: ctx.Request.URL.Path, RawQuery: query.Encode()}).String()
+}
@@ -0,0 +1,152 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testItemCount = 7
+
+func newPaginationTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("Items", "/items", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			page := ParsePagination(ctx, 3, 5)
+			var items []int
+			for i := page.Offset; i < min(page.Offset+page.PerPage, testItemCount); i++ {
+				items = append(items, i)
+			}
+			result := NewPaginatedResult(ctx, items, page, testItemCount)
+			return OKResult(result, "ok").WithLinkHeader(result.Links)
+		})
+	}))
+	return rs
+}
+
+func fetchPage(t *testing.T, rs *RouterService, target string) PaginatedResult[int] {
+	t.Helper()
+	page, _ := fetchPageWithHeader(t, rs, target)
+	return page
+}
+
+func fetchPageWithHeader(t *testing.T, rs *RouterService, target string) (PaginatedResult[int], string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data PaginatedResult[int] `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return body.Data, w.Header().Get("Link")
+}
+
+func TestPagination_PageLinksKeepFilters(t *testing.T) {
+	rs := newPaginationTestRouter(t)
+
+	page := fetchPage(t, rs, "/items?q=abc&page=2&per_page=3")
+	if len(page.Data) != 3 || page.Data[0] != 3 {
+		t.Fatalf("expected items 3..5, got %v", page.Data)
+	}
+	if page.Page != 2 || page.PerPage != 3 || page.Total != testItemCount || page.TotalPages != 3 {
+		t.Fatalf("unexpected page metadata: %+v", page)
+	}
+	if page.Links.Next != "/items?page=3&per_page=3&q=abc" {
+		t.Fatalf("unexpected next link %q", page.Links.Next)
+	}
+	if page.Links.Prev != "/items?page=1&per_page=3&q=abc" {
+		t.Fatalf("unexpected prev link %q", page.Links.Prev)
+	}
+
+	if page.Links.First != "/items?page=1&per_page=3&q=abc" || page.Links.Last != "/items?page=3&per_page=3&q=abc" {
+		t.Fatalf("unexpected first and last links %+v", page.Links)
+	}
+
+	last := fetchPage(t, rs, page.Links.Next)
+	if len(last.Data) != 1 || last.Links.Next != "" || last.Links.Last != "" {
+		t.Fatalf("expected a final page of one item without next and last links, got %+v", last)
+	}
+}
+
+func TestPagination_LinkHeader(t *testing.T) {
+	rs := newPaginationTestRouter(t)
+
+	_, header := fetchPageWithHeader(t, rs, "/items?page=2&per_page=3")
+	want := `</items?page=1&per_page=3>; rel="first", </items?page=1&per_page=3>; rel="prev", ` +
+		`</items?page=3&per_page=3>; rel="next", </items?page=3&per_page=3>; rel="last"`
+	if header != want {
+		t.Fatalf("unexpected Link header:\n got %s\nwant %s", header, want)
+	}
+
+	if _, header := fetchPageWithHeader(t, rs, "/items?per_page=5&page=1"); header != `</items?page=2&per_page=5>; rel="next", </items?page=2&per_page=5>; rel="last"` {
+		t.Fatalf("unexpected first page Link header %s", header)
+	}
+	if header := (PaginationLinks{}).Header(); header != "" {
+		t.Fatalf("expected no header without links, got %q", header)
+	}
+}
+
+func TestResourceLink_FillsTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		values   []string
+		want     string
+	}{
+		{"/v1/ledger/transactions/:id", []string{"abc"}, "/v1/ledger/transactions/abc"},
+		{"/v1/accounts/:id/holds/:hold", []string{"a 1", "h/2"}, "/v1/accounts/a%201/holds/h%2F2"},
+		{"/v1/accounts/:id", nil, "/v1/accounts/:id"},
+	}
+	for _, tt := range tests {
+		if got := ResourceLink(tt.template, tt.values...); got != tt.want {
+			t.Fatalf("ResourceLink(%q, %q) = %q, want %q", tt.template, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestPagination_Defaults(t *testing.T) {
+	rs := newPaginationTestRouter(t)
+
+	page := fetchPage(t, rs, "/items?page=zero&per_page=-1")
+	if page.Page != 1 || page.PerPage != 3 || page.Links.Prev != "" {
+		t.Fatalf("expected the default first page, got %+v", page)
+	}
+
+	if page := fetchPage(t, rs, "/items?per_page=1000"); page.PerPage != 5 {
+		t.Fatalf("expected per_page capped at 5, got %d", page.PerPage)
+	}
+
+	empty := fetchPage(t, rs, "/items?page=9")
+	if empty.Data == nil || len(empty.Data) != 0 || empty.Links.Next != "" {
+		t.Fatalf("expected an empty page past the end, got %+v", empty)
+	}
+}
+
+func TestPagination_LegacyLimitOffset(t *testing.T) {
+	rs := newPaginationTestRouter(t)
+
+	page := fetchPage(t, rs, "/items?limit=2&offset=3")
+	if len(page.Data) != 2 || page.Data[0] != 3 || page.Page != 2 {
+		t.Fatalf("expected items 3..4 on page 2, got %+v", page)
+	}
+	if page.Links.Next != "/items?limit=2&offset=5" || page.Links.Prev != "/items?limit=2&offset=1" ||
+		page.Links.First != "/items?limit=2&offset=0" || page.Links.Last != "/items?limit=2&offset=6" {
+		t.Fatalf("expected offset links, got %+v", page.Links)
+	}
+}
+
+func TestComponentName_FlattensGenerics(t *testing.T) {
+	got := componentName("PaginatedResult[github.com/example/api/domain/ledger.TransactionResponse]")
+	if got != "PaginatedResultTransactionResponse" {
+		t.Fatalf("unexpected component name %q", got)
+	}
+	if got := componentName("Widget"); got != "Widget" {
+		t.Fatalf("unexpected component name %q", got)
+	}
+}
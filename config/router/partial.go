@@ -0,0 +1,40 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Partial is the data of a result whose items can succeed or fail one by
+// one, such as a bulk import. Items are the successful items and Failures
+// describe the others.
+type Partial[T, F any] struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Items     []T `json:"items"`
+	Failures  []F `json:"failures"`
+}
+
+// PartialResult reports the outcome of a bulk operation: 200 when every item
+// succeeded, 207 Multi-Status with a warning when only some did, and 422 when
+// none did. Handlers return it for mixed outcomes instead of choosing their
+// own shape.
+func PartialResult[T, F any](items []T, failures []F) *ServiceResult {
+	if items == nil {
+		items = []T{}
+	}
+	if failures == nil {
+		failures = []F{}
+	}
+	data := Partial[T, F]{Succeeded: len(items), Failed: len(failures), Items: items, Failures: failures}
+
+	switch {
+	case len(failures) == 0:
+		return OKResult(data, "All items succeeded")
+	case len(items) == 0:
+		return ErrorResult(http.StatusUnprocessableEntity, "All items failed", data)
+	default:
+		result := &ServiceResult{StatusCode: http.StatusMultiStatus, Data: data, Message: "Some items failed"}
+		return result.WithWarning(fmt.Sprintf("%d of %d items failed", len(failures), len(items)+len(failures)))
+	}
+}
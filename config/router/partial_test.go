@@ -0,0 +1,83 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type importFailure struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+func TestPartialResult_StatusFollowsOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    []string
+		failures []importFailure
+		status   int
+		warnings int
+	}{
+		{"all succeeded", []string{"a", "b"}, nil, http.StatusOK, 0},
+		{"some failed", []string{"a", "b", "c"}, []importFailure{{Line: 4, Error: "bad amount"}}, http.StatusMultiStatus, 1},
+		{"all failed", nil, []importFailure{{Line: 2, Error: "bad amount"}}, http.StatusUnprocessableEntity, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := PartialResult(tt.items, tt.failures)
+			if result.StatusCode != tt.status || len(result.Warnings) != tt.warnings {
+				t.Fatalf("expected %d with %d warnings, got %d %v", tt.status, tt.warnings, result.StatusCode, result.Warnings)
+			}
+			data := result.Data.(Partial[string, importFailure])
+			if data.Succeeded != len(tt.items) || data.Failed != len(tt.failures) || data.Items == nil || data.Failures == nil {
+				t.Fatalf("unexpected data %+v", data)
+			}
+		})
+	}
+}
+
+func TestPartialResult_WritesWarnings(t *testing.T) {
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("Imports", "/imports", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddPostHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			return PartialResult([]string{"a", "b", "c"}, []importFailure{{Line: 4, Error: "bad amount"}}).
+				WithWarning("column memo is ignored")
+		})
+		rs.AddGetHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(nil, "ok")
+		})
+	}))
+
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/imports", nil))
+	var body struct {
+		Code     int                            `json:"code"`
+		Data     Partial[string, importFailure] `json:"data"`
+		Warnings []string                       `json:"warnings"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusMultiStatus || body.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d (%d)", w.Code, body.Code)
+	}
+	if len(body.Warnings) != 2 || body.Warnings[0] != "1 of 4 items failed" || body.Warnings[1] != "column memo is ignored" {
+		t.Fatalf("unexpected warnings %v", body.Warnings)
+	}
+	if body.Data.Failed != 1 || body.Data.Failures[0].Line != 4 {
+		t.Fatalf("unexpected data %+v", body.Data)
+	}
+
+	// Envelopes without warnings keep their shape.
+	w = httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/imports", nil))
+	var envelope map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if _, ok := envelope["warnings"]; ok {
+		t.Fatalf("expected no warnings key, got %v", envelope)
+	}
+}
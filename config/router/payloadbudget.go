@@ -0,0 +1,109 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// PayloadBudget is how large a route's responses are expected to get. A
+// response over budget usually means a query fetched more than intended,
+// such as every entry of every transaction in a listing.
+type PayloadBudget struct {
+	// MaxResponseBytes is the largest expected response body; zero means
+	// no budget.
+	MaxResponseBytes int
+	// Reject answers 500 instead of sending an over-budget JSON response.
+	// Files and streams are written as they are produced, so they are only
+	// logged.
+	Reject bool
+}
+
+// initPayloadBudget reads RESPONSE_BUDGET_BYTES and RESPONSE_BUDGET_REJECT,
+// the budget of routes that do not set their own.
+func (routerService *RouterService) initPayloadBudget() {
+	if raw := utils.GetEnvTrimmed("RESPONSE_BUDGET_BYTES"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			routerService.payloadBudget.MaxResponseBytes = parsed
+		} else {
+			routerService.logger.Warn("Invalid RESPONSE_BUDGET_BYTES; routes have no default budget", "value", raw)
+		}
+	}
+	routerService.payloadBudget.Reject = envBool("RESPONSE_BUDGET_REJECT", false)
+}
+
+// Budget sets the route's payload budget in place of RESPONSE_BUDGET_BYTES;
+// a zero budget exempts the route. It returns the route for chaining.
+func (route *Route) Budget(budget PayloadBudget) *Route {
+	route.budget = &budget
+	return route
+}
+
+func (routerService *RouterService) budgetFor(route *Route) PayloadBudget {
+	if route.budget != nil {
+		return *route.budget
+	}
+	return routerService.payloadBudget
+}
+
+// writeJSON writes result's JSON envelope. When the route's budget rejects,
+// the envelope is encoded first so an oversized one is never sent; when the
+// body is hashed into an ETag, it is encoded first so a client that has it
+// is answered 304 instead. Routes with PooledJSON encode into a pooled
+// buffer (see encodeEnvelope).
+func (routerService *RouterService) writeJSON(c *RequestContext, route *Route, result *ServiceResult) {
+	budget := routerService.budgetFor(route)
+	rejects := budget.MaxResponseBytes > 0 && budget.Reject
+	hashes := routerService.hashesBody(c, result)
+	pooled := routerService.pooledJSONFor(route)
+	if !rejects && !hashes && !pooled {
+		c.JSON(result.StatusCode, result.ToJSON())
+		routerService.checkBudget(c, budget)
+		return
+	}
+
+	var body []byte
+	var err error
+	if pooled {
+		buf := getJSONBuffer()
+		defer putJSONBuffer(buf)
+		err = encodeEnvelope(buf, result)
+		body = buf.Bytes()
+	} else {
+		body, err = json.Marshal(result.ToJSON())
+	}
+	if err != nil {
+		// Let gin report the encoding failure as it would without a budget.
+		c.JSON(result.StatusCode, result.ToJSON())
+		return
+	}
+	if rejects && len(body) > budget.MaxResponseBytes {
+		GetLogger(c).Error("Response exceeds payload budget; rejected",
+			"method", c.Request.Method, "route", c.FullPath(), "bytes", len(body), "budget", budget.MaxResponseBytes)
+		routerService.recordOverBudget(c.Request.Method, c.FullPath(), "rejected")
+		c.JSON(http.StatusInternalServerError, InternalServerErrorResult("Response exceeds the payload budget of this endpoint").ToJSON())
+		return
+	}
+	if hashes {
+		tag := hashETag(body)
+		c.Header("ETag", tag)
+		if etagMatches(c.GetHeader("If-None-Match"), tag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	c.Data(result.StatusCode, "application/json; charset=utf-8", body)
+	routerService.checkBudget(c, budget)
+}
+
+// checkBudget logs a response already written over the route's budget.
+func (routerService *RouterService) checkBudget(c *RequestContext, budget PayloadBudget) {
+	if budget.MaxResponseBytes == 0 || c.Writer.Size() <= budget.MaxResponseBytes {
+		return
+	}
+	GetLogger(c).Warn("Response exceeds payload budget",
+		"method", c.Request.Method, "route", c.FullPath(), "bytes", c.Writer.Size(), "budget", budget.MaxResponseBytes)
+	routerService.recordOverBudget(c.Request.Method, c.FullPath(), "logged")
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newBudgetTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	rs := newTestRouterService(t)
+	large := func(ctx *RequestContext) *ServiceResult {
+		return OKResult(strings.Repeat("x", 500), "ok")
+	}
+	rs.MountController(NewRESTController("Budget", "/budget", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/default", large)
+		rs.AddGetHandler(c, nil, "/logged", large).Budget(PayloadBudget{MaxResponseBytes: 100})
+		rs.AddGetHandler(c, nil, "/rejected", large).Budget(PayloadBudget{MaxResponseBytes: 100, Reject: true})
+		rs.AddGetHandler(c, nil, "/roomy", large).Budget(PayloadBudget{MaxResponseBytes: 1000, Reject: true})
+		rs.AddPostHandler(c, nil, "/echo", func(ctx *RequestContext) *ServiceResult {
+			body, _ := ctx.GetRawData()
+			return OKResult(len(body), "ok")
+		})
+	}))
+	return rs
+}
+
+func serve(rs *RouterService, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+func TestPayloadBudget(t *testing.T) {
+	t.Setenv("RESPONSE_BUDGET_BYTES", "200")
+	rs := newBudgetTestRouter(t)
+
+	for path, want := range map[string]int{
+		"/budget/default":  http.StatusOK,
+		"/budget/logged":   http.StatusOK,
+		"/budget/rejected": http.StatusInternalServerError,
+		"/budget/roomy":    http.StatusOK,
+	} {
+		w := serve(rs, http.MethodGet, path, "")
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, w.Code)
+		}
+		if want == http.StatusOK && !strings.Contains(w.Body.String(), strings.Repeat("x", 500)) {
+			t.Errorf("%s: response body was not sent", path)
+		}
+	}
+
+	metrics := serve(rs, http.MethodGet, "/metrics", "").Body.String()
+	for _, want := range []string{
+		`http_response_over_budget_total{action="logged",method="GET",route="/budget/default"} 1`,
+		`http_response_over_budget_total{action="logged",method="GET",route="/budget/logged"} 1`,
+		`http_response_over_budget_total{action="rejected",method="GET",route="/budget/rejected"} 1`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("expected %s in metrics", want)
+		}
+	}
+	if strings.Contains(metrics, `over_budget_total{action="rejected",method="GET",route="/budget/roomy"}`) {
+		t.Error("a response within budget was counted")
+	}
+}
+
+func TestSizeMetrics(t *testing.T) {
+	rs := newBudgetTestRouter(t)
+
+	serve(rs, http.MethodPost, "/budget/echo", strings.Repeat("y", 300))
+
+	metrics := serve(rs, http.MethodGet, "/metrics", "").Body.String()
+	for _, want := range []string{
+		`http_request_size_bytes_sum{method="POST",route="/budget/echo"} 300`,
+		`http_response_size_bytes_count{method="POST",route="/budget/echo"} 1`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("expected %s in metrics", want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package router
+
+import (
+	"context"
+	"runtime/pprof"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/auth"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultProfileTenantClaim = "tenant_id"
+
+// initProfileLabels reads PROFILE_LABELS_ENABLED (default true) and
+// PROFILE_TENANT_CLAIM, the JWT claim whose value labels authenticated
+// requests ("tenant_id" by default).
+func (routerService *RouterService) initProfileLabels() {
+	routerService.profileLabels = envBool("PROFILE_LABELS_ENABLED", true)
+	routerService.profileTenantClaim = utils.GetEnvTrimmed("PROFILE_TENANT_CLAIM")
+	if routerService.profileTenantClaim == "" {
+		routerService.profileTenantClaim = defaultProfileTenantClaim
+	}
+}
+
+// profileLabelsMiddleware runs the rest of the request under pprof labels
+// naming its method, route template and correlation ID, so CPU and goroutine
+// profiles can be sliced by endpoint, for example with
+// "go tool pprof -tagfocus route=/v1/accounts/:id". Requests that match no
+// route are labelled "unmatched", keeping one label value per route.
+func (routerService *RouterService) profileLabelsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !routerService.profileLabels {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		id, _ := c.Request.Context().Value(log.CorrelatedIDKey).(string)
+		labels := pprof.Labels("method", c.Request.Method, "route", route, "correlation_id", id)
+		pprof.Do(c.Request.Context(), labels, func(ctx context.Context) {
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+		})
+	}
+}
+
+// withTenantLabel runs next with a "tenant" label added to the request's
+// profile labels, when the caller's claims name a tenant.
+func (routerService *RouterService) withTenantLabel(c *gin.Context, claims *auth.Claims, next func()) {
+	if !routerService.profileLabels {
+		next()
+		return
+	}
+	tenant := claims.String(routerService.profileTenantClaim)
+	if tenant == "" {
+		next()
+		return
+	}
+	pprof.Do(c.Request.Context(), pprof.Labels("tenant", tenant), func(ctx context.Context) {
+		c.Request = c.Request.WithContext(ctx)
+		next()
+	})
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"runtime/pprof"
+	"testing"
+	"time"
+)
+
+func profileLabelsOf(ctx context.Context) map[string]string {
+	labels := make(map[string]string)
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		labels[key] = value
+		return true
+	})
+	return labels
+}
+
+func TestProfileLabels(t *testing.T) {
+	t.Setenv("PROFILE_TENANT_CLAIM", "org")
+	rs := newAuthTestRouter(t)
+
+	var got map[string]string
+	labelled := func(ctx *RequestContext) *ServiceResult {
+		got = profileLabelsOf(ctx.Request.Context())
+		return OKResult(nil, "ok")
+	}
+	rs.MountController(NewRESTController("Labelled", "/labelled", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/:id", labelled)
+	}))
+	rs.MountController(NewRESTController("Tenanted", "/tenanted", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "", labelled)
+	}).RequireAuth())
+
+	w := serve(rs, http.MethodGet, "/labelled/42", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	want := map[string]string{
+		"method":         http.MethodGet,
+		"route":          "/labelled/:id",
+		"correlation_id": w.Header().Get("X-Correlation-ID"),
+	}
+	if !maps.Equal(got, want) {
+		t.Fatalf("expected labels %v, got %v", want, got)
+	}
+
+	token := testToken(t, map[string]any{"sub": "user-1", "org": "acme", "exp": time.Now().Add(time.Hour).Unix()})
+	w = serveWithToken(rs, "/tenanted", token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got["tenant"] != "acme" || got["route"] != "/tenanted" {
+		t.Fatalf("expected the route and tenant labels, got %v", got)
+	}
+}
+
+func TestProfileLabels_Disabled(t *testing.T) {
+	t.Setenv("PROFILE_LABELS_ENABLED", "false")
+	rs := newTestRouterService(t)
+
+	got := map[string]string{"unset": ""}
+	rs.MountController(NewRESTController("Labelled", "/labelled", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			got = profileLabelsOf(ctx.Request.Context())
+			return OKResult(nil, "ok")
+		})
+	}))
+	if w := serve(rs, http.MethodGet, "/labelled", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no labels, got %v", got)
+	}
+}
@@ -0,0 +1,106 @@
+package router
+
+import (
+	"strconv"
+
+	"github.com/akeren/go-api-foundry/pkg/querycount"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// QueryCountHeader carries the number of database statements a request ran
+// before its response started, when DB_QUERY_COUNT_HEADER is set.
+const QueryCountHeader = "X-DB-Query-Count"
+
+// initQueryBudget reads DB_QUERY_BUDGET, the soft limit on the statements a
+// request to a route that does not set its own QueryBudget may run, and
+// DB_QUERY_COUNT_HEADER.
+func (routerService *RouterService) initQueryBudget() {
+	if raw := utils.GetEnvTrimmed("DB_QUERY_BUDGET"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			routerService.queryBudget = parsed
+		} else {
+			routerService.logger.Warn("Invalid DB_QUERY_BUDGET; routes have no default query budget", "value", raw)
+		}
+	}
+	routerService.queryCountHeader = envBool("DB_QUERY_COUNT_HEADER", false)
+}
+
+// QueryBudget sets how many database statements a request to the route is
+// expected to run, in place of DB_QUERY_BUDGET; zero exempts the route.
+// Requests over budget are logged, not rejected. It returns the route for
+// chaining.
+func (route *Route) QueryBudget(statements int) *Route {
+	route.queryBudget = &statements
+	return route
+}
+
+func (routerService *RouterService) queryBudgetFor(c *gin.Context) int {
+	key := routerService.keyForPathAndMethod(c.FullPath(), c.Request.Method)
+	if route, ok := routerService.routeIndex[key]; ok && route.queryBudget != nil {
+		return *route.queryBudget
+	}
+	return routerService.queryBudget
+}
+
+// queryCountMiddleware counts the statements each request runs through GORM
+// with its context. Counts of routed requests are observed in
+// http_request_db_queries, and those over the route's budget are logged, so
+// a handler that queries once per row shows up before it reaches
+// production traffic.
+func (routerService *RouterService) queryCountMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, counter := querycount.WithCounter(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		if routerService.queryCountHeader {
+			c.Writer = &queryCountWriter{ResponseWriter: c.Writer, counter: counter}
+		}
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			return
+		}
+		queries := counter.Count()
+		routerService.recordQueries(c.Request.Method, route, queries)
+		if budget := routerService.queryBudgetFor(c); budget > 0 && queries > int64(budget) {
+			GetLogger(c).Warn("Request exceeds database query budget",
+				"method", c.Request.Method, "route", route, "queries", queries, "budget", budget)
+			routerService.recordQueriesOverBudget(c.Request.Method, route)
+		}
+	}
+}
+
+// queryCountWriter sets QueryCountHeader as the response starts, to the
+// statements run until then.
+type queryCountWriter struct {
+	gin.ResponseWriter
+	counter *querycount.Counter
+}
+
+func (w *queryCountWriter) setHeader() {
+	if !w.Written() {
+		w.Header().Set(QueryCountHeader, strconv.FormatInt(w.counter.Count(), 10))
+	}
+}
+
+func (w *queryCountWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *queryCountWriter) Write(b []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *queryCountWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *queryCountWriter) Flush() {
+	w.setHeader()
+	w.ResponseWriter.Flush()
+}
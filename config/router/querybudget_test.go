@@ -0,0 +1,70 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/akeren/go-api-foundry/pkg/querycount"
+)
+
+// newQueryBudgetTestRouter mounts handlers that count the statements given
+// in ?n as the query counter plugin would.
+func newQueryBudgetTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	rs := newTestRouterService(t)
+	queries := func(ctx *RequestContext) *ServiceResult {
+		n, _ := strconv.Atoi(ctx.Query("n"))
+		for range n {
+			querycount.FromContext(ctx.Request.Context()).Inc()
+		}
+		return OKResult(n, "ok")
+	}
+	rs.MountController(NewRESTController("Queries", "/queries", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/default", queries)
+		rs.AddGetHandler(c, nil, "/roomy", queries).QueryBudget(50)
+		rs.AddGetHandler(c, nil, "/exempt", queries).QueryBudget(0)
+	}))
+	return rs
+}
+
+func TestQueryBudget(t *testing.T) {
+	t.Setenv("DB_QUERY_BUDGET", "10")
+	t.Setenv("DB_QUERY_COUNT_HEADER", "true")
+	rs := newQueryBudgetTestRouter(t)
+
+	for _, target := range []string{"/queries/default?n=3", "/queries/default?n=12", "/queries/roomy?n=12", "/queries/exempt?n=200"} {
+		w := serve(rs, http.MethodGet, target, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected over-budget requests to be served, got %d", target, w.Code)
+		}
+		if want := target[strings.Index(target, "=")+1:]; w.Header().Get(QueryCountHeader) != want {
+			t.Errorf("%s: expected %s=%s, got %q", target, QueryCountHeader, want, w.Header().Get(QueryCountHeader))
+		}
+	}
+
+	metrics := serve(rs, http.MethodGet, "/metrics", "").Body.String()
+	for _, want := range []string{
+		`http_request_db_queries_sum{method="GET",route="/queries/default"} 15`,
+		`http_request_db_queries_count{method="GET",route="/queries/default"} 2`,
+		`http_request_db_queries_over_budget_total{method="GET",route="/queries/default"} 1`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("expected %s in metrics", want)
+		}
+	}
+	for _, route := range []string{"/queries/roomy", "/queries/exempt"} {
+		if strings.Contains(metrics, `over_budget_total{method="GET",route="`+route+`"}`) {
+			t.Errorf("%s: a request within its own budget was counted", route)
+		}
+	}
+}
+
+func TestQueryCountHeader_OffByDefault(t *testing.T) {
+	rs := newQueryBudgetTestRouter(t)
+
+	if w := serve(rs, http.MethodGet, "/queries/default?n=3", ""); w.Header().Get(QueryCountHeader) != "" {
+		t.Fatalf("expected no %s header, got %q", QueryCountHeader, w.Header().Get(QueryCountHeader))
+	}
+}
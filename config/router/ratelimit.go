@@ -0,0 +1,100 @@
+package router
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+)
+
+const (
+	clientRateLimitPrefix  = "ratelimit:"
+	serviceRateLimitPrefix = "ratelimit:service:"
+)
+
+// ClientRateLimitKey is the limiter key of requests from a client IP.
+func ClientRateLimitKey(ip string) string {
+	return clientRateLimitPrefix + ip
+}
+
+// ServiceRateLimitKey is the limiter key of a service authenticated with a
+// client certificate.
+func ServiceRateLimitKey(name string) string {
+	return serviceRateLimitPrefix + name
+}
+
+// UserRateLimitKey is the limiter key of an authenticated user on routes
+// keyed with KeyByUser.
+func UserRateLimitKey(subject string) string {
+	return userRateLimitPrefix + subject
+}
+
+// RateLimitState is a key's standing with one of the router's limiters.
+// Scope is "default", "service", or the controller mount point or route the
+// limiter overrides the default for.
+type RateLimitState struct {
+	Scope string `json:"scope"`
+	ratelimit.KeyState
+}
+
+type scopedLimiter struct {
+	scope   string
+	limiter ratelimit.Inspector
+}
+
+// inspectableLimiters returns the limiters that may count key, skipping
+// those that cannot be inspected.
+func (routerService *RouterService) inspectableLimiters(key string) []scopedLimiter {
+	var limiters []scopedLimiter
+	add := func(scope string, limiter ratelimit.RateLimiter) {
+		if inspector, ok := limiter.(ratelimit.Inspector); ok {
+			limiters = append(limiters, scopedLimiter{scope: scope, limiter: inspector})
+		}
+	}
+
+	add("default", routerService.rateLimiter)
+	for _, path := range slices.Sorted(maps.Keys(routerService.rateLimitOverrides)) {
+		scope := path
+		if method, route, ok := strings.Cut(path, "-"); ok && !strings.HasPrefix(path, "/") {
+			scope = method + " " + route
+		}
+		add(scope, routerService.rateLimitOverrides[path])
+	}
+	if name, ok := strings.CutPrefix(key, serviceRateLimitPrefix); ok {
+		for _, id := range routerService.serviceIdentities {
+			if id.name == name && id.limiter != nil {
+				add("service", id.limiter)
+				break
+			}
+		}
+	}
+	return limiters
+}
+
+// RateLimitStates reports key's standing with every limiter that may count
+// it.
+func (routerService *RouterService) RateLimitStates(ctx context.Context, key string) ([]RateLimitState, error) {
+	limiters := routerService.inspectableLimiters(key)
+	states := make([]RateLimitState, 0, len(limiters))
+	for _, l := range limiters {
+		state, err := l.limiter.Inspect(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, RateLimitState{Scope: l.scope, KeyState: state})
+	}
+	return states, nil
+}
+
+// ResetRateLimit restores key's full quota with every limiter that may count
+// it.
+func (routerService *RouterService) ResetRateLimit(ctx context.Context, key string) error {
+	for _, l := range routerService.inspectableLimiters(key) {
+		if err := l.limiter.Reset(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
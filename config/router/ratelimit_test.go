@@ -0,0 +1,215 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitHeaders(t *testing.T) {
+	rs := CreateRouterService(log.NewLoggerWithJSONOutput(), nil, &RouterConfig{
+		RateLimitRequests: 2,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+	})
+	mountTestController(rs)
+
+	w := serve(rs, http.MethodGet, "/ip", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+		t.Fatalf("expected X-RateLimit-Limit 2, got %q", got)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "1" {
+		t.Fatalf("expected X-RateLimit-Remaining 1, got %q", got)
+	}
+	// One request comes back every 30s.
+	if got := w.Header().Get("X-RateLimit-Reset"); got != "30" {
+		t.Fatalf("expected X-RateLimit-Reset 30, got %q", got)
+	}
+
+	serve(rs, http.MethodGet, "/ip", "")
+	w = serve(rs, http.MethodGet, "/ip", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Fatalf("expected X-RateLimit-Remaining 0, got %q", got)
+	}
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 29 || retryAfter > 30 {
+		t.Fatalf("expected to retry once one request came back, in 30s rather than the whole window, got %q", w.Header().Get("Retry-After"))
+	}
+	if got := w.Header().Get("X-RateLimit-Reset"); got != "60" {
+		t.Fatalf("expected X-RateLimit-Reset 60, got %q", got)
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	t.Setenv("AUTH_JWT_HMAC_SECRET", testJWTSecret)
+	rs := CreateRouterService(log.NewLoggerWithJSONOutput(), nil, &RouterConfig{
+		RateLimitRequests: 100,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+	})
+	ok := func(ctx *RequestContext) *ServiceResult { return OKResult(nil, "ok") }
+	rs.MountController(NewRESTController("Keyed", "/keyed", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/user", ok)
+		rs.AddGetHandler(c, nil, "/tenant", ok).RateLimitKey(KeyByHeader("X-Tenant-ID"))
+	}).RequireAuth().RateLimitKey(KeyByUser()).RateLimitWith(rs, ratelimit.NewInMemoryRateLimiter(1, time.Minute)))
+	rs.MountController(NewRESTController("Open", "/open", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/user", ok)
+	}).RateLimitKey(KeyByUser()).RateLimitWith(rs, ratelimit.NewInMemoryRateLimiter(1, time.Minute)))
+
+	request := func(path, subject string, header http.Header) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if subject != "" {
+			req.Header.Set("Authorization", "Bearer "+testToken(t, map[string]any{"sub": subject, "exp": time.Now().Add(time.Hour).Unix()}))
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Every request comes from the same IP.
+	if code := request("/keyed/user", "alice", nil); code != http.StatusOK {
+		t.Fatalf("expected alice's first request to pass, got %d", code)
+	}
+	if code := request("/keyed/user", "bob", nil); code != http.StatusOK {
+		t.Fatalf("expected bob to have his own quota, got %d", code)
+	}
+	if code := request("/keyed/user", "alice", nil); code != http.StatusTooManyRequests {
+		t.Fatalf("expected alice's second request to be limited, got %d", code)
+	}
+
+	tenant := func(id string) http.Header { return http.Header{"X-Tenant-Id": {id}} }
+	if code := request("/keyed/tenant", "alice", tenant("t-1")); code != http.StatusOK {
+		t.Fatalf("expected the route's key to replace the controller's, got %d", code)
+	}
+	if code := request("/keyed/tenant", "bob", tenant("t-2")); code != http.StatusOK {
+		t.Fatalf("expected another tenant to have its own quota, got %d", code)
+	}
+	if code := request("/keyed/tenant", "bob", tenant("t-1")); code != http.StatusTooManyRequests {
+		t.Fatalf("expected tenant t-1 to be limited, got %d", code)
+	}
+
+	// Anonymous callers fall back to the client IP.
+	if code := request("/open/user", "", nil); code != http.StatusOK {
+		t.Fatalf("expected the first anonymous request to pass, got %d", code)
+	}
+	if code := request("/open/user", "", nil); code != http.StatusTooManyRequests {
+		t.Fatalf("expected anonymous requests to share the IP's quota, got %d", code)
+	}
+}
+
+func TestRateLimitKey_KeepsTheIPLimit(t *testing.T) {
+	t.Setenv("AUTH_JWT_HMAC_SECRET", testJWTSecret)
+	rs := CreateRouterService(log.NewLoggerWithJSONOutput(), nil, &RouterConfig{
+		RateLimitRequests: 2,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+	})
+	ok := func(ctx *RequestContext) *ServiceResult { return OKResult(nil, "ok") }
+	rs.MountController(NewRESTController("Keyed", "/keyed", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/user", ok)
+	}).RequireAuth().RateLimitKey(KeyByUser()).RateLimitWith(rs, ratelimit.NewInMemoryRateLimiter(100, time.Minute)))
+
+	// Requests that authentication rejects count against the client IP.
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if w := serve(rs, http.MethodGet, "/keyed/user", ""); w.Code != want {
+			t.Fatalf("request %d: expected %d, got %d", i+1, want, w.Code)
+		}
+	}
+}
+
+func TestRateLimitKey_CountsTheIPOnceOnTheDefaultLimiter(t *testing.T) {
+	rs := CreateRouterService(log.NewLoggerWithJSONOutput(), nil, &RouterConfig{
+		RateLimitRequests: 3,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+	})
+	ok := func(ctx *RequestContext) *ServiceResult { return OKResult(nil, "ok") }
+	rs.MountController(NewRESTController("Keyed", "/keyed", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/user", ok)
+		rs.AddGetHandler(c, nil, "/ip", ok).RateLimitKey(KeyByClientIP())
+	}).RateLimitKey(KeyByUser()))
+
+	// Anonymous callers under KeyByUser and KeyByClientIP both fall back to
+	// the IP the default limiter already counted.
+	for _, path := range []string{"/keyed/user", "/keyed/ip"} {
+		if err := rs.ResetRateLimit(t.Context(), ClientRateLimitKey("192.0.2.1")); err != nil {
+			t.Fatalf("reset: %v", err)
+		}
+		for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+			if w := serve(rs, http.MethodGet, path, ""); w.Code != want {
+				t.Fatalf("%s request %d: expected %d, got %d", path, i+1, want, w.Code)
+			}
+		}
+	}
+}
+
+func TestRateLimitKeyFuncs(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set(apiKeyContextKey, "secret-key")
+
+	key := KeyByAPIKey()(c)
+	if !strings.HasPrefix(key, apiKeyRateLimitPrefix) || strings.Contains(key, "secret-key") {
+		t.Fatalf("expected a hashed API key, got %q", key)
+	}
+	if got := FirstKey(KeyByUser(), KeyByHeader("X-Missing"), KeyByAPIKey())(c); got != key {
+		t.Fatalf("expected the first key found, got %q", got)
+	}
+	if got := FirstKey(KeyByUser(), KeyByHeader("X-Missing"))(c); got != "" {
+		t.Fatalf("expected no key, got %q", got)
+	}
+}
+
+func TestRateLimitKey_OnlyValidAPIKeys(t *testing.T) {
+	rs := CreateRouterService(log.NewLoggerWithJSONOutput(), nil, &RouterConfig{
+		RateLimitRequests: 100,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+	})
+	rs.SetAPIKeyValidator("X-API-Key", func(_ context.Context, key string) bool {
+		return strings.HasPrefix(key, "valid-")
+	})
+	ok := func(ctx *RequestContext) *ServiceResult { return OKResult(nil, "ok") }
+	rs.MountController(NewRESTController("Keyed", "/keyed", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/imports", ok)
+	}).RateLimitKey(KeyByAPIKey()).RateLimitWith(rs, ratelimit.NewInMemoryRateLimiter(1, time.Minute)))
+
+	request := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/keyed/imports", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Every request comes from the same IP.
+	if code := request("valid-a"); code != http.StatusOK {
+		t.Fatalf("expected the first key's request to pass, got %d", code)
+	}
+	if code := request("valid-b"); code != http.StatusOK {
+		t.Fatalf("expected another valid key to have its own quota, got %d", code)
+	}
+	if code := request("made-up-1"); code != http.StatusOK {
+		t.Fatalf("expected the first request with an unknown key to pass, got %d", code)
+	}
+	if code := request("made-up-2"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected unknown keys to share the IP's quota, got %d", code)
+	}
+}
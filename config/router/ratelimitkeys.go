@@ -0,0 +1,142 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	userRateLimitPrefix   = "ratelimit:user:"
+	apiKeyRateLimitPrefix = "ratelimit:apikey:"
+	headerRateLimitPrefix = "ratelimit:header:"
+	routeRateLimitPrefix  = "ratelimit:route:"
+
+	keyedRateLimitContextKey = "router.keyed_rate_limit"
+)
+
+// RateLimitKeyFunc returns the key a request is rate limited under, or ""
+// to fall back to its client IP. Requests with the same key share a quota.
+type RateLimitKeyFunc func(c *RequestContext) string
+
+// KeyByClientIP limits each client IP, as routes without a key function are.
+func KeyByClientIP() RateLimitKeyFunc {
+	return func(c *RequestContext) string {
+		return ClientRateLimitKey(c.ClientIP())
+	}
+}
+
+// KeyByUser limits each authenticated caller by subject, so users behind a
+// shared address do not share a quota. Anonymous requests fall back to the
+// client IP.
+func KeyByUser() RateLimitKeyFunc {
+	return func(c *RequestContext) string {
+		if subject := GetSubject(c); subject != "" {
+			return UserRateLimitKey(subject)
+		}
+		return ""
+	}
+}
+
+// KeyByAPIKey limits each API key the router's APIKeyValidator accepts.
+// Requests with a missing or unknown key fall back to the client IP, so
+// sending a new key does not buy a new quota. Keys are hashed, so the
+// limiter's store never holds one.
+func KeyByAPIKey() RateLimitKeyFunc {
+	return func(c *RequestContext) string {
+		value := validAPIKey(c)
+		if value == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(value))
+		return apiKeyRateLimitPrefix + hex.EncodeToString(sum[:16])
+	}
+}
+
+// KeyByHeader limits each value of header, such as a tenant ID. Clients
+// choose their headers, so use it only for one a trusted gateway sets.
+func KeyByHeader(name string) RateLimitKeyFunc {
+	return func(c *RequestContext) string {
+		if value := strings.TrimSpace(c.GetHeader(name)); value != "" {
+			return headerRateLimitPrefix + strings.ToLower(name) + ":" + value
+		}
+		return ""
+	}
+}
+
+// KeyByRoute gives the route one quota shared by every client, keyed by
+// method and route template.
+func KeyByRoute() RateLimitKeyFunc {
+	return func(c *RequestContext) string {
+		return routeRateLimitPrefix + c.Request.Method + " " + c.FullPath()
+	}
+}
+
+// FirstKey uses the first of keys to return a key, such as
+// FirstKey(KeyByUser(), KeyByAPIKey()).
+func FirstKey(keys ...RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(c *RequestContext) string {
+		for _, key := range keys {
+			if k := key(c); k != "" {
+				return k
+			}
+		}
+		return ""
+	}
+}
+
+// RateLimitKey sets how requests to the controller's handlers are keyed by
+// its rate limiter, in place of the client IP. It returns the controller for
+// chaining.
+func (controller *RESTController) RateLimitKey(key RateLimitKeyFunc) *RESTController {
+	controller.rateLimitKey = key
+	return controller
+}
+
+// RateLimitKey sets how requests to the route are keyed by its rate
+// limiter, in place of the controller's key function or the client IP. It
+// returns the route for chaining.
+func (route *Route) RateLimitKey(key RateLimitKeyFunc) *Route {
+	route.rateLimitKey = key
+	return route
+}
+
+func (routerService *RouterService) rateLimitKeyFor(handlerKey string, controller *RESTController) RateLimitKeyFunc {
+	if route, ok := routerService.routeIndex[handlerKey]; ok && route.rateLimitKey != nil {
+		return route.rateLimitKey
+	}
+	return controller.rateLimitKey
+}
+
+// keyedRateLimitMiddleware applies the limiter rateLimitMiddleware left to
+// a route with a key function. It runs after authentication, so the key
+// function sees the caller.
+func (routerService *RouterService) keyedRateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v, ok := c.Get(keyedRateLimitContextKey)
+		if !ok {
+			c.Next()
+			return
+		}
+		keyed := v.(keyedRateLimit)
+		clientIP := c.ClientIP()
+		key := keyed.key(c)
+		if key == "" {
+			key = ClientRateLimitKey(clientIP)
+		}
+		if keyed.limiter == routerService.rateLimiter && key == ClientRateLimitKey(clientIP) {
+			// rateLimitMiddleware already counted this key on this limiter.
+			c.Next()
+			return
+		}
+		routerService.applyRateLimit(c, keyed.limiter, key, clientIP)
+	}
+}
+
+type keyedRateLimit struct {
+	limiter ratelimit.RateLimiter
+	key     RateLimitKeyFunc
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"net/http"
+	"slices"
+
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"github.com/akeren/go-api-foundry/pkg/rbac"
+	"github.com/gin-gonic/gin"
+)
+
+// SetRoleStore adds the roles bound in store to every authenticated caller,
+// on top of the roles carried by its token or service identity.
+func (routerService *RouterService) SetRoleStore(store rbac.Store) {
+	routerService.roleStore = store
+}
+
+// resolveRoles merges the caller's stored role bindings into p. Bindings only
+// ever add roles, so when the store is unavailable the caller keeps the roles
+// from its credentials rather than being rejected outright.
+func (routerService *RouterService) resolveRoles(c *gin.Context, p *principal.Principal) {
+	if routerService.roleStore == nil {
+		return
+	}
+	roles, err := routerService.roleStore.Roles(c.Request.Context(), p.Kind, p.Subject)
+	if err != nil {
+		GetLogger(c).Error("Failed to load role bindings; using credential roles only", "subject", p.Subject, "error", err)
+		return
+	}
+	p.Roles = rbac.Merge(p.Roles, roles)
+}
+
+// RequireRoles admits callers holding at least one of roles. It relies on the
+// caller already being authenticated, either by a client certificate or by a
+// controller marked with RequireAuth; anonymous callers get 401.
+func RequireRoles(roles ...string) MiddlewareFunc {
+	return func(c *gin.Context) {
+		p := principal.FromContext(c.Request.Context())
+		if p == nil {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, UnauthorizedResult("Authentication required").ToJSON())
+			return
+		}
+		if slices.ContainsFunc(roles, p.HasRole) {
+			c.Next()
+			return
+		}
+
+		GetLogger(c).Warn("Caller lacks required role", "subject", p.Subject, "kind", p.Kind, "required", roles)
+		err := apperrors.NewForbiddenError("Missing required role", nil)
+		c.AbortWithStatusJSON(apperrors.HTTPStatusCode(err), ErrorResult(apperrors.HTTPStatusCode(err), apperrors.GetHumanReadableMessage(err), nil).ToJSON())
+	}
+}
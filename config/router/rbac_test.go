@@ -0,0 +1,93 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"github.com/akeren/go-api-foundry/pkg/rbac"
+)
+
+func newRBACTestRouter(t *testing.T, store rbac.Store) *RouterService {
+	t.Helper()
+	t.Setenv("AUTH_JWT_HMAC_SECRET", testJWTSecret)
+
+	rs := newTestRouterService(t)
+	if store != nil {
+		rs.SetRoleStore(store)
+	}
+	rs.MountController(NewRESTController("Admin", "/admin", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "", func(ctx *RequestContext) *ServiceResult {
+			return OKResult(nil, "ok")
+		}, RequireRoles("admin", "operator"))
+	}).RequireAuth())
+	return rs
+}
+
+func userToken(t *testing.T, roles ...string) string {
+	return testToken(t, map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix(), "roles": roles})
+}
+
+func TestRequireRoles_TokenRoles(t *testing.T) {
+	rs := newRBACTestRouter(t, nil)
+
+	if w := serveWithToken(rs, "/admin", userToken(t, "operator")); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := serveWithToken(rs, "/admin", userToken(t, "viewer"))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Missing required role") {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+
+	if w := serveWithToken(rs, "/admin", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestRequireRoles_StoredBindings(t *testing.T) {
+	store := rbac.NewMemoryStore()
+	rs := newRBACTestRouter(t, store)
+
+	if w := serveWithToken(rs, "/admin", userToken(t)); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 before grant, got %d", w.Code)
+	}
+
+	if err := store.Grant(context.Background(), rbac.Binding{Kind: principal.KindUser, Subject: "user-1", Role: "admin"}); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+	if w := serveWithToken(rs, "/admin", userToken(t)); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 after grant, got %d", w.Code)
+	}
+
+	// A service binding for the same subject name does not apply to users.
+	_ = store.Revoke(context.Background(), rbac.Binding{Kind: principal.KindUser, Subject: "user-1", Role: "admin"})
+	_ = store.Grant(context.Background(), rbac.Binding{Kind: principal.KindService, Subject: "user-1", Role: "admin"})
+	if w := serveWithToken(rs, "/admin", userToken(t)); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for service binding, got %d", w.Code)
+	}
+}
+
+type failingRoleStore struct{ rbac.Store }
+
+func (failingRoleStore) Roles(context.Context, principal.Kind, string) ([]string, error) {
+	return nil, errors.New("db down")
+}
+
+func TestRequireRoles_StoreFailureKeepsTokenRoles(t *testing.T) {
+	rs := newRBACTestRouter(t, failingRoleStore{})
+
+	if w := serveWithToken(rs, "/admin", userToken(t, "admin")); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with token role, got %d", w.Code)
+	}
+	if w := serveWithToken(rs, "/admin", userToken(t)); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without token role, got %d", w.Code)
+	}
+}
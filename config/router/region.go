@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"github.com/gin-gonic/gin"
+)
+
+// regionHeaderMiddleware names the region that served each response, so
+// clients and proxies can tell regions apart in an active-active deployment.
+func (routerService *RouterService) regionHeaderMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if name := region.LocalName(); name != "" {
+			c.Header(region.Header, name)
+		}
+		c.Next()
+	}
+}
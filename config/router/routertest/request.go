@@ -0,0 +1,185 @@
+package routertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/gin-gonic/gin"
+)
+
+// Request describes the request a handler is called with.
+type Request struct {
+	Method string
+	// Path may carry a query string.
+	Path string
+	// Body is sent as is when it is a string, []byte or io.Reader, and encoded
+	// as JSON otherwise. A JSON body sets Content-Type unless Headers does.
+	Body    any
+	Headers map[string]string
+	// Params are the path parameters, such as "id" for "/accounts/:id".
+	// Registrar.Serve fills them in from the route.
+	Params map[string]string
+	// Keys are set on the context as middlewares would, e.g. claims.
+	Keys map[string]any
+	// Context defaults to context.Background.
+	Context context.Context
+}
+
+// quiet keeps router.GetLogger from writing to stdout during tests.
+var quiet = &log.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+// NewContext builds the RequestContext a handler would see for req, without a
+// server or engine. The recorder holds anything the handler writes directly.
+func NewContext(t testing.TB, req Request) (*router.RequestContext, *httptest.ResponseRecorder) {
+	t.Helper()
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	path := req.Path
+	if path == "" {
+		path = "/"
+	}
+
+	var body io.Reader
+	contentType := ""
+	switch b := req.Body.(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(b)
+	case []byte:
+		body = bytes.NewReader(b)
+	case io.Reader:
+		body = b
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("routertest: encode request body: %v", err)
+		}
+		body = bytes.NewReader(encoded)
+		contentType = "application/json"
+	}
+
+	ctx := req.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Value(log.LoggerKeyForContext) == nil {
+		ctx = context.WithValue(ctx, log.LoggerKeyForContext, quiet)
+	}
+
+	httpReq := httptest.NewRequestWithContext(ctx, method, path, body)
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httpReq
+	for k, v := range req.Params {
+		c.Params = append(c.Params, gin.Param{Key: k, Value: v})
+	}
+	for k, v := range req.Keys {
+		c.Set(k, v)
+	}
+	return c, w
+}
+
+// Call calls handler with req and fails the test if it returns no result.
+func Call(t testing.TB, handler router.HandlerFunction, req Request) *router.ServiceResult {
+	t.Helper()
+	if handler == nil {
+		t.Fatalf("routertest: no handler for %s %s", req.Method, req.Path)
+	}
+	c, _ := NewContext(t, req)
+	result := handler(c)
+	if result == nil {
+		t.Fatalf("routertest: %s %s returned no result", req.Method, req.Path)
+	}
+	return result
+}
+
+// Serve calls the handler whose route matches req's method and path, with the
+// path parameters taken from the route. Middlewares are not run.
+func (r *Registrar) Serve(t testing.TB, req Request) *router.ServiceResult {
+	t.Helper()
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	path, _, _ := strings.Cut(req.Path, "?")
+	for _, route := range r.routes {
+		if route.Method != method {
+			continue
+		}
+		params, ok := matchPath(route.Path, path)
+		if !ok {
+			continue
+		}
+		for k, v := range req.Params {
+			params[k] = v
+		}
+		req.Params = params
+		return Call(t, route.Handler, req)
+	}
+	t.Fatalf("routertest: no route matches %s %s", method, req.Path)
+	return nil
+}
+
+// matchPath matches path against a gin pattern with :name and *name
+// segments, returning the parameters.
+func matchPath(pattern, path string) (map[string]string, bool) {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	params := make(map[string]string)
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "*") {
+			params[part[1:]] = "/" + strings.Join(pathParts[i:], "/")
+			return params, true
+		}
+		if i >= len(pathParts) {
+			return nil, false
+		}
+		switch {
+		case strings.HasPrefix(part, ":"):
+			params[part[1:]] = pathParts[i]
+		case part != pathParts[i]:
+			return nil, false
+		}
+	}
+	return params, len(patternParts) == len(pathParts)
+}
+
+// ExpectStatus fails the test unless result has the status code want.
+func ExpectStatus(t testing.TB, result *router.ServiceResult, want int) {
+	t.Helper()
+	if result.StatusCode != want {
+		t.Fatalf("expected status %d, got %d (%s)", want, result.StatusCode, result.Message)
+	}
+}
+
+// DecodeData round-trips result.Data through JSON into v, so tests see the
+// data as a client would.
+func DecodeData(t testing.TB, result *router.ServiceResult, v any) {
+	t.Helper()
+	encoded, err := json.Marshal(result.Data)
+	if err != nil {
+		t.Fatalf("routertest: encode result data: %v", err)
+	}
+	if err := json.Unmarshal(encoded, v); err != nil {
+		t.Fatalf("routertest: decode result data: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package routertest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/akeren/go-api-foundry/config/router"
+)
+
+type widgetRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func widgetController() *router.RESTController {
+	return router.NewVersionedRESTController("Widgets", "v1", "/widgets", func(rs router.RouteRegistrar, c *router.RESTController) {
+		rs.AddGetHandler(c, nil, "/:id", func(ctx *router.RequestContext) *router.ServiceResult {
+			return router.OKResult(map[string]string{"id": ctx.Param("id"), "view": ctx.Query("view"), "tenant": ctx.GetHeader("X-Tenant")}, "Widget")
+		})
+		rs.AddPostHandler(c, nil, "", func(ctx *router.RequestContext) *router.ServiceResult {
+			var req widgetRequest
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				return router.BadRequestResult("Invalid request body", nil)
+			}
+			return router.CreatedResult(map[string]any{"name": req.Name, "owner": ctx.GetString("subject")}, "Widget")
+		})
+		rs.AddGetHandler(c, nil, "/files/*path", func(ctx *router.RequestContext) *router.ServiceResult {
+			return router.OKResult(ctx.Param("path"), "File")
+		})
+	})
+}
+
+func TestServe(t *testing.T) {
+	routes := Mount(widgetController())
+
+	tests := []struct {
+		name   string
+		req    Request
+		status int
+		data   map[string]any
+	}{
+		{
+			name:   "path params, query and headers",
+			req:    Request{Path: "/v1/widgets/42?view=full", Headers: map[string]string{"X-Tenant": "acme"}},
+			status: http.StatusOK,
+			data:   map[string]any{"id": "42", "view": "full", "tenant": "acme"},
+		},
+		{
+			name:   "JSON body and context keys",
+			req:    Request{Method: http.MethodPost, Path: "/v1/widgets", Body: widgetRequest{Name: "gear"}, Keys: map[string]any{"subject": "user-1"}},
+			status: http.StatusCreated,
+			data:   map[string]any{"name": "gear", "owner": "user-1"},
+		},
+		{
+			name:   "invalid body",
+			req:    Request{Method: http.MethodPost, Path: "/v1/widgets", Body: `{"name":`},
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := routes.Serve(t, tt.req)
+			ExpectStatus(t, result, tt.status)
+			if tt.data == nil {
+				return
+			}
+			var data map[string]any
+			DecodeData(t, result, &data)
+			for k, want := range tt.data {
+				if data[k] != want {
+					t.Fatalf("expected %s=%v, got %v", k, want, data[k])
+				}
+			}
+		})
+	}
+}
+
+func TestServe_CatchAllParam(t *testing.T) {
+	result := Mount(widgetController()).Serve(t, Request{Path: "/v1/widgets/files/a/b.txt"})
+	ExpectStatus(t, result, http.StatusOK)
+	if result.Data != "/a/b.txt" {
+		t.Fatalf("expected the catch-all path, got %v", result.Data)
+	}
+}
+
+func TestCall(t *testing.T) {
+	handler := func(ctx *router.RequestContext) *router.ServiceResult {
+		return router.OKResult(ctx.Param("id"), strings.ToLower(ctx.Request.Method))
+	}
+	result := Call(t, handler, Request{Method: http.MethodDelete, Path: "/x/7", Params: map[string]string{"id": "7"}})
+	if result.Data != "7" || result.Message != "delete" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		ok            bool
+	}{
+		{"/", "/", true},
+		{"/v1/widgets", "/v1/widgets", true},
+		{"/v1/widgets", "/v1/widgets/1", false},
+		{"/v1/widgets/:id", "/v1/widgets", false},
+		{"/v1/widgets/:id/archive", "/v1/widgets/1/archive", true},
+		{"/v1/widgets/:id/archive", "/v1/widgets/1/restore", false},
+	}
+	for _, tt := range tests {
+		if _, ok := matchPath(tt.pattern, tt.path); ok != tt.ok {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, ok, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package routertest registers controllers with a fake router and calls
+// their HandlerFunctions with a built RequestContext, so handler tests run
+// without a RouterService, gin engine or HTTP server.
+package routertest
+
+import (
+	"fmt"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+)
+
+// Route is one registered handler. Path is the full path, including the
+// controller's mount point and version.
+type Route struct {
+	Method      string
+	Path        string
+	Handler     router.HandlerFunction
+	Limiter     ratelimit.RateLimiter
+	Middlewares []router.MiddlewareFunc
+}
+
+// Registrar records the routes registered with it. It implements
+// router.RouteRegistrar. Middlewares and rate limiters are recorded but never
+// run.
+type Registrar struct {
+	routes []Route
+}
+
+func NewRegistrar() *Registrar {
+	return &Registrar{}
+}
+
+// Mount registers controller with a new Registrar.
+func Mount(controller *router.RESTController) *Registrar {
+	r := NewRegistrar()
+	r.Mount(controller)
+	return r
+}
+
+// Mount registers controller's routes.
+func (r *Registrar) Mount(controller *router.RESTController) {
+	controller.Register(r)
+}
+
+// Routes returns every registered route in registration order.
+func (r *Registrar) Routes() []Route {
+	return r.routes
+}
+
+// Route returns the route registered for method and path, if any.
+func (r *Registrar) Route(method, path string) (Route, bool) {
+	for _, route := range r.routes {
+		if route.Method == method && route.Path == path {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// Handler returns the handler registered for method and path, or nil.
+func (r *Registrar) Handler(method, path string) router.HandlerFunction {
+	route, _ := r.Route(method, path)
+	return route.Handler
+}
+
+func (r *Registrar) add(controller *router.RESTController, method string, limiter ratelimit.RateLimiter, path string, handler router.HandlerFunction, middlewares []router.MiddlewareFunc) *router.Route {
+	path = controller.Path(path)
+	if _, ok := r.Route(method, path); ok {
+		panic(fmt.Sprintf("A handler is already registered for %s '%s'", method, path))
+	}
+	r.routes = append(r.routes, Route{
+		Method:      method,
+		Path:        path,
+		Handler:     handler,
+		Limiter:     limiter,
+		Middlewares: middlewares,
+	})
+	// Only returned so Describe can be chained; the fake keeps no docs.
+	return &router.Route{Method: method, Path: path}
+}
+
+func (r *Registrar) AddGetHandler(controller *router.RESTController, limiter ratelimit.RateLimiter, path string, handler router.HandlerFunction, middlewares ...router.MiddlewareFunc) *router.Route {
+	return r.add(controller, "GET", limiter, path, handler, middlewares)
+}
+
+func (r *Registrar) AddPostHandler(controller *router.RESTController, limiter ratelimit.RateLimiter, path string, handler router.HandlerFunction, middlewares ...router.MiddlewareFunc) *router.Route {
+	return r.add(controller, "POST", limiter, path, handler, middlewares)
+}
+
+func (r *Registrar) AddPutHandler(controller *router.RESTController, limiter ratelimit.RateLimiter, path string, handler router.HandlerFunction, middlewares ...router.MiddlewareFunc) *router.Route {
+	return r.add(controller, "PUT", limiter, path, handler, middlewares)
+}
+
+func (r *Registrar) AddPatchHandler(controller *router.RESTController, limiter ratelimit.RateLimiter, path string, handler router.HandlerFunction, middlewares ...router.MiddlewareFunc) *router.Route {
+	return r.add(controller, "PATCH", limiter, path, handler, middlewares)
+}
+
+func (r *Registrar) AddDeleteHandler(controller *router.RESTController, limiter ratelimit.RateLimiter, path string, handler router.HandlerFunction, middlewares ...router.MiddlewareFunc) *router.Route {
+	return r.add(controller, "DELETE", limiter, path, handler, middlewares)
+}
+
+func (r *Registrar) AddHeadHandler(controller *router.RESTController, limiter ratelimit.RateLimiter, path string, handler router.HandlerFunction, middlewares ...router.MiddlewareFunc) *router.Route {
+	return r.add(controller, "HEAD", limiter, path, handler, middlewares)
+}
+
+func (r *Registrar) AddOptionsHandler(controller *router.RESTController, limiter ratelimit.RateLimiter, path string, handler router.HandlerFunction, middlewares ...router.MiddlewareFunc) *router.Route {
+	return r.add(controller, "OPTIONS", limiter, path, handler, middlewares)
+}
+
+var _ router.RouteRegistrar = (*Registrar)(nil)
@@ -0,0 +1,55 @@
+package routertest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/akeren/go-api-foundry/config/router"
+)
+
+func TestMountRecordsRoutes(t *testing.T) {
+	auth := func(*router.RequestContext) {}
+	ctrl := router.NewVersionedRESTController("Widgets", "v1", "/widgets", func(rs router.RouteRegistrar, c *router.RESTController) {
+		rs.AddGetHandler(c, nil, "", func(*router.RequestContext) *router.ServiceResult {
+			return router.OKResult(nil, "list")
+		})
+		rs.AddPostHandler(c, nil, "/:id/archive", func(*router.RequestContext) *router.ServiceResult {
+			return router.OKResult(nil, "archive")
+		}, auth).Describe(router.OperationDoc{Summary: "Archive a widget"})
+	})
+
+	r := Mount(ctrl)
+
+	routes := r.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(routes))
+	}
+	if routes[0].Method != http.MethodGet || routes[0].Path != "/v1/widgets" {
+		t.Fatalf("unexpected first route %s %s", routes[0].Method, routes[0].Path)
+	}
+	route, ok := r.Route(http.MethodPost, "/v1/widgets/:id/archive")
+	if !ok || len(route.Middlewares) != 1 {
+		t.Fatalf("expected archive route with one middleware, got %+v (found %v)", route, ok)
+	}
+	if got := r.Handler(http.MethodPost, "/v1/widgets/:id/archive")(nil); got.Message != "archive" {
+		t.Fatalf("expected the archive handler, got %q", got.Message)
+	}
+	if r.Handler(http.MethodDelete, "/v1/widgets") != nil {
+		t.Fatalf("expected no handler for an unregistered route")
+	}
+}
+
+func TestMountRejectsDuplicateRoutes(t *testing.T) {
+	ctrl := router.NewRESTController("Dup", "/", func(rs router.RouteRegistrar, c *router.RESTController) {
+		handler := func(*router.RequestContext) *router.ServiceResult { return router.OKResult(nil, "ok") }
+		rs.AddGetHandler(c, nil, "ping", handler)
+		rs.AddGetHandler(c, nil, "ping", handler)
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for a duplicate route")
+		}
+	}()
+	Mount(ctrl)
+}
@@ -0,0 +1,56 @@
+package router
+
+import (
+	"github.com/akeren/go-api-foundry/pkg/idempotency"
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
+	"github.com/akeren/go-api-foundry/pkg/scaleout"
+)
+
+// ScaleOutComponents describes where the router keeps rate limit counters,
+// IP bans and idempotency records. Without Redis, or when Redis was not
+// reachable at startup, counters and bans stay in process memory.
+func (routerService *RouterService) ScaleOutComponents() []scaleout.Component {
+	limiter := scaleout.Component{Name: "rate_limiter", Backend: "redis", Shared: true}
+	limiters := []ratelimit.RateLimiter{routerService.rateLimiter}
+	for _, override := range routerService.rateLimitOverrides {
+		limiters = append(limiters, override)
+	}
+	for _, l := range limiters {
+		if _, ok := l.(*ratelimit.InMemoryRateLimiter); ok {
+			limiter = scaleout.Component{
+				Name:    "rate_limiter",
+				Backend: "memory",
+				Impact:  "each replica counts requests on its own, so clients get up to one limit per replica",
+			}
+			break
+		}
+	}
+
+	bans := scaleout.Component{Name: "ip_bans", Backend: "redis", Shared: true}
+	if routerService.redisClient == nil {
+		bans = scaleout.Component{
+			Name:    "ip_bans",
+			Backend: "memory",
+			Impact:  "bans from the admin API and anomaly scoring only apply on the replica that made them",
+		}
+	}
+
+	components := []scaleout.Component{limiter, bans}
+	switch routerService.idempotencyStore.(type) {
+	case nil:
+	case *idempotency.RedisStore:
+		components = append(components, scaleout.Component{Name: "idempotency", Backend: "redis", Shared: true})
+	case *idempotency.GormStore:
+		components = append(components, scaleout.Component{Name: "idempotency", Backend: "database", Shared: true})
+	case *idempotency.MemoryStore:
+		components = append(components, scaleout.Component{
+			Name:    "idempotency",
+			Backend: "memory",
+			Impact:  "a retry that reaches another replica runs the request again",
+		})
+	default:
+		// A custom store is assumed to be shared.
+		components = append(components, scaleout.Component{Name: "idempotency", Backend: "custom", Shared: true})
+	}
+	return components
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/akeren/go-api-foundry/pkg/idempotency"
+)
+
+func TestScaleOutComponents_WithoutRedis(t *testing.T) {
+	rs := newTestRouterService(t)
+	rs.SetIdempotencyStore(idempotency.NewMemoryStore(), 0)
+
+	backends := map[string]string{}
+	for _, component := range rs.ScaleOutComponents() {
+		if component.Shared {
+			t.Fatalf("expected %s to be per-instance without Redis", component.Name)
+		}
+		backends[component.Name] = component.Backend
+	}
+	for _, name := range []string{"rate_limiter", "ip_bans", "idempotency"} {
+		if backends[name] != "memory" {
+			t.Fatalf("expected %s in memory, got %v", name, backends)
+		}
+	}
+}
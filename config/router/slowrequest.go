@@ -0,0 +1,126 @@
+package router
+
+import (
+	"runtime/trace"
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/slowprof"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// initSlowRequests reads SLOW_REQUEST_THRESHOLD, the latency over which a
+// request is logged as slow, and SLOW_REQUEST_PROFILE_DIR, where slow
+// requests are profiled while they run. Both are off while unset.
+func (routerService *RouterService) initSlowRequests() {
+	raw := utils.GetEnvTrimmed("SLOW_REQUEST_THRESHOLD")
+	if raw == "" {
+		return
+	}
+	threshold, err := time.ParseDuration(raw)
+	if err != nil || threshold <= 0 {
+		routerService.logger.Warn("Invalid SLOW_REQUEST_THRESHOLD; slow requests are not logged", "value", raw)
+		return
+	}
+	routerService.slowRequestThreshold = threshold
+
+	dir := utils.GetEnvTrimmed("SLOW_REQUEST_PROFILE_DIR")
+	if dir == "" {
+		return
+	}
+	cfg := slowprof.DefaultConfig()
+	cfg.Dir = dir
+	for env, dst := range map[string]*time.Duration{
+		"SLOW_REQUEST_TRACE_DURATION":   &cfg.TraceDuration,
+		"SLOW_REQUEST_PROFILE_INTERVAL": &cfg.MinInterval,
+	} {
+		if v := utils.GetEnvTrimmed(env); v != "" {
+			if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				routerService.logger.Warn("Invalid duration; using default", "env", env, "value", v, "default", *dst)
+			}
+		}
+	}
+	if v := utils.GetEnvTrimmed("SLOW_REQUEST_PROFILE_KEEP"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			cfg.Keep = parsed
+		} else {
+			routerService.logger.Warn("Invalid SLOW_REQUEST_PROFILE_KEEP; using default", "value", v, "default", cfg.Keep)
+		}
+	}
+	profiler, err := slowprof.New(cfg)
+	if err != nil {
+		routerService.logger.Error("Failed to set up slow request profiling", "error", err)
+		return
+	}
+	routerService.slowProfiler = profiler
+	routerService.logger.Info("Slow request profiling enabled", "threshold", threshold, "dir", dir)
+}
+
+// slowRequestMiddleware logs requests slower than SLOW_REQUEST_THRESHOLD.
+// With profiling on, each request runs as an execution trace task logging
+// its correlation ID, and one still running at the threshold triggers a
+// capture; the log line names its files. The goroutine dump shows each
+// request's profile labels (see profileLabelsMiddleware).
+func (routerService *RouterService) slowRequestMiddleware() gin.HandlerFunc {
+	threshold := routerService.slowRequestThreshold
+	profiler := routerService.slowProfiler
+	return func(c *gin.Context) {
+		if threshold == 0 {
+			c.Next()
+			return
+		}
+		start := time.Now()
+
+		var capture *slowprof.Capture
+		if profiler != nil {
+			id, _ := c.Request.Context().Value(log.CorrelatedIDKey).(string)
+			ctx, task := trace.NewTask(c.Request.Context(), "HTTP "+c.Request.Method+" "+c.FullPath())
+			trace.Log(ctx, "correlation_id", id)
+
+			logger := GetLogger(c)
+			captured := make(chan struct{})
+			timer := time.AfterFunc(threshold, func() {
+				defer close(captured)
+				var err error
+				if capture, err = profiler.Capture(id); err != nil {
+					logger.Error("Failed to profile slow request", "error", err)
+				}
+			})
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+			task.End()
+			if !timer.Stop() {
+				<-captured
+			}
+		} else {
+			c.Next()
+		}
+
+		latency := time.Since(start)
+		if latency < threshold {
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		args := []any{
+			"method", c.Request.Method,
+			"route", route,
+			"status", c.Writer.Status(),
+			"latency_ms", latency.Milliseconds(),
+			"threshold_ms", threshold.Milliseconds(),
+		}
+		if capture != nil {
+			args = append(args, "profile_goroutines", capture.Goroutines)
+			if capture.Trace != "" {
+				args = append(args, "profile_trace", capture.Trace)
+			}
+		}
+		GetLogger(c).Warn("Slow request", args...)
+	}
+}
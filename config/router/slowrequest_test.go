@@ -0,0 +1,60 @@
+package router
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowRequests_ProfiledWhileRunning(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "slow")
+	t.Setenv("SLOW_REQUEST_THRESHOLD", "20ms")
+	t.Setenv("SLOW_REQUEST_PROFILE_DIR", dir)
+	t.Setenv("SLOW_REQUEST_TRACE_DURATION", "50ms")
+	rs := newTestRouterService(t)
+	defer rs.Cleanup()
+
+	var label string
+	rs.MountController(NewRESTController("Slow", "/slow", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "/:ms", func(ctx *RequestContext) *ServiceResult {
+			label, _ = pprof.Label(ctx.Request.Context(), "correlation_id")
+			ms, _ := time.ParseDuration(ctx.Param("ms") + "ms")
+			time.Sleep(ms)
+			return OKResult(nil, "ok")
+		})
+	}))
+
+	if w := serve(rs, http.MethodGet, "/slow/0", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected a fast request not to be profiled, got %d files", len(entries))
+	}
+
+	w := serve(rs, http.MethodGet, "/slow/60", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	id := w.Header().Get("X-Correlation-ID")
+	if id == "" || label != id {
+		t.Fatalf("expected the handler to run labelled with its correlation ID %q, got %q", id, label)
+	}
+
+	rs.Cleanup()
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 2 || !strings.HasSuffix(names[0], id+".goroutines.txt") || !strings.HasSuffix(names[1], id+".trace") {
+		t.Fatalf("expected a goroutine dump and a trace named after the request, got %v", names)
+	}
+	dump, _ := os.ReadFile(filepath.Join(dir, names[0]))
+	if !strings.Contains(string(dump), `"correlation_id":"`+id+`"`) {
+		t.Fatal("expected the goroutine dump to show the request's labels")
+	}
+}
@@ -0,0 +1,76 @@
+package router
+
+import (
+	"io"
+	"net/http"
+)
+
+// StreamResult writes the body as write produces it, for responses too large
+// to buffer such as exports. The status and headers go out with the first
+// byte. An error before then gets the usual JSON 500; an error after it can
+// only cut the response short, so the connection is closed and the client
+// sees a failed download rather than a truncated file that looks complete.
+func StreamResult(statusCode int, contentType string, write func(w io.Writer) error) *ServiceResult {
+	return &ServiceResult{
+		StatusCode: statusCode,
+		Message:    http.StatusText(statusCode),
+		write: func(c *RequestContext, status int) {
+			w := &streamWriter{ctx: c, status: status, contentType: contentType}
+			err := write(w)
+			if err == nil {
+				w.start()
+				return
+			}
+
+			GetLogger(c).Error("Failed to stream response", "error", err, "started", w.started)
+			if !w.started {
+				c.JSON(http.StatusInternalServerError, InternalServerErrorResult("Failed to produce response").ToJSON())
+				return
+			}
+			// gin refuses to hijack a connection it has written to; the
+			// http.ResponseWriter beneath it does not.
+			var rw http.ResponseWriter = c.Writer
+			if u, ok := rw.(interface{ Unwrap() http.ResponseWriter }); ok {
+				rw = u.Unwrap()
+			}
+			if conn, _, err := http.NewResponseController(rw).Hijack(); err == nil {
+				conn.Close()
+			}
+		},
+	}
+}
+
+// streamWriter sends the status and content type before the first byte.
+type streamWriter struct {
+	ctx         *RequestContext
+	status      int
+	contentType string
+	started     bool
+}
+
+func (w *streamWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	w.ctx.Header("Content-Type", w.contentType)
+	w.ctx.Status(w.status)
+	w.ctx.Writer.WriteHeaderNow()
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.ctx.Writer.Write(p)
+}
+
+// ReaderResult streams r as the body, closing it afterwards if it is an
+// io.Closer. Errors are handled as in StreamResult.
+func ReaderResult(statusCode int, contentType string, r io.Reader) *ServiceResult {
+	return StreamResult(statusCode, contentType, func(w io.Writer) error {
+		if closer, ok := r.(io.Closer); ok {
+			defer closer.Close()
+		}
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
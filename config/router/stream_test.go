@@ -0,0 +1,115 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStreamResult(t *testing.T) {
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("ExportsController", "/exports", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "ok", func(ctx *RequestContext) *ServiceResult {
+			return StreamResult(http.StatusOK, "text/csv", func(w io.Writer) error {
+				for _, line := range []string{"a,b\n", "1,2\n"} {
+					if _, err := io.WriteString(w, line); err != nil {
+						return err
+					}
+				}
+				return nil
+			}).WithHeader("Content-Disposition", `attachment; filename="export.csv"`)
+		})
+		rs.AddGetHandler(c, nil, "empty", func(ctx *RequestContext) *ServiceResult {
+			return StreamResult(http.StatusOK, "text/csv", func(io.Writer) error { return nil })
+		})
+		rs.AddGetHandler(c, nil, "broken", func(ctx *RequestContext) *ServiceResult {
+			return StreamResult(http.StatusOK, "text/csv", func(io.Writer) error { return errors.New("boom") })
+		})
+	}))
+
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/exports/ok", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "a,b\n1,2\n" {
+		t.Fatalf("unexpected response %d: %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, "export.csv") {
+		t.Fatalf("Content-Disposition = %q", cd)
+	}
+
+	w = httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/exports/empty", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("unexpected empty response %d %q: %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	// Nothing was written, so the failure can still be reported.
+	w = httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/exports/broken", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "Failed to produce response") {
+		t.Fatalf("unexpected error response %d: %s", w.Code, w.Body.String())
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestReaderResult(t *testing.T) {
+	body := &closeRecorder{Reader: strings.NewReader("%PDF-1.4")}
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("ExportsController", "/exports", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "pdf", func(ctx *RequestContext) *ServiceResult {
+			return ReaderResult(http.StatusOK, "application/pdf", body)
+		})
+	}))
+
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/exports/pdf", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "%PDF-1.4" || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Fatalf("unexpected response %d %q: %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if !body.closed {
+		t.Fatal("expected the reader to be closed")
+	}
+}
+
+func TestStreamResult_FailureMidStreamClosesConnection(t *testing.T) {
+	rs := newTestRouterService(t)
+	rs.MountController(NewRESTController("ExportsController", "/exports", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddGetHandler(c, nil, "partial", func(ctx *RequestContext) *ServiceResult {
+			return StreamResult(http.StatusOK, "text/csv", func(w io.Writer) error {
+				// More than the server buffers, so the headers are sent.
+				if _, err := io.WriteString(w, strings.Repeat("row\n", 4096)); err != nil {
+					return err
+				}
+				return errors.New("database went away")
+			})
+		})
+	}))
+	server := httptest.NewServer(rs.GetEngine())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/exports/partial")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Fatal("expected the truncated body to fail to read")
+	}
+}
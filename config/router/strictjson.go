@@ -0,0 +1,44 @@
+package router
+
+import "reflect"
+
+// strictJSONContextKey is set on requests whose route rejects unknown JSON
+// fields; BindJSON reads it.
+const strictJSONContextKey = "router.strict_json"
+
+// initStrictJSON reads STRICT_JSON_BINDING, the default for routes that do
+// not set StrictJSON. It is opt-in because clients sending fields an older
+// release accepted would start failing.
+func (routerService *RouterService) initStrictJSON() {
+	routerService.strictJSON = envBool("STRICT_JSON_BINDING", false)
+	if routerService.strictJSON {
+		routerService.logger.Info("Strict JSON binding enabled; unknown body fields are rejected")
+	}
+}
+
+// StrictJSON overrides STRICT_JSON_BINDING for the route: when strict,
+// BindJSON rejects bodies with keys the request type does not declare. It
+// returns the route for chaining.
+func (route *Route) StrictJSON(strict bool) *Route {
+	route.strictJSON = &strict
+	return route
+}
+
+func (routerService *RouterService) strictJSONFor(route *Route) bool {
+	if route.strictJSON != nil {
+		return *route.strictJSON
+	}
+	return routerService.strictJSON
+}
+
+// unknownFields lists the keys of document that no field of t decodes, as
+// paths such as "entries[1].amout".
+func unknownFields(t reflect.Type, document any) []string {
+	var unknown []string
+	walkJSON(t, document, "", func(path string, t reflect.Type, _ any) {
+		if t == nil {
+			unknown = append(unknown, path)
+		}
+	})
+	return unknown
+}
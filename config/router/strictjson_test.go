@@ -0,0 +1,117 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+)
+
+type strictTestLine struct {
+	Amount int64 `json:"amount"`
+}
+
+type strictTestAudit struct {
+	Note string `json:"note"`
+}
+
+type strictTestRequest struct {
+	strictTestAudit
+	Name     string            `json:"name" binding:"required"`
+	Lines    []strictTestLine  `json:"lines"`
+	Metadata map[string]string `json:"metadata"`
+	Raw      json.RawMessage   `json:"raw"`
+}
+
+func newStrictTestRouter(t *testing.T) *RouterService {
+	t.Helper()
+	rs := newTestRouterService(t)
+	handler := func(ctx *RequestContext) *ServiceResult {
+		req, errResult := BindJSON[strictTestRequest](ctx)
+		if errResult != nil {
+			return errResult
+		}
+		return OKResult(req.Name, "ok")
+	}
+	rs.MountController(NewRESTController("Items", "/items", func(rs RouteRegistrar, c *RESTController) {
+		rs.AddPostHandler(c, nil, "/lenient", handler)
+		rs.AddPostHandler(c, nil, "/strict", handler).StrictJSON(true)
+		rs.AddPostHandler(c, nil, "/exempt", handler).StrictJSON(false)
+	}))
+	return rs
+}
+
+func postJSON(t *testing.T, rs *RouterService, target, body string) (int, []apperrors.ValidationErrorResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	var violations []apperrors.ValidationErrorResponse
+	_ = json.Unmarshal(resp.Data, &violations)
+	return w.Code, violations
+}
+
+func TestBindJSON_StrictRouteListsUnknownFields(t *testing.T) {
+	rs := newStrictTestRouter(t)
+	body := `{"Name":"a","idempotencyKey":"k","note":"n","lines":[{"amount":1},{"amout":2}],` +
+		`"metadata":{"any":"key"},"raw":{"free":"form"}}`
+
+	code, _ := postJSON(t, rs, "/items/lenient", body)
+	if code != http.StatusOK {
+		t.Fatalf("lenient route: expected 200, got %d", code)
+	}
+
+	code, violations := postJSON(t, rs, "/items/strict", body)
+	if code != http.StatusBadRequest {
+		t.Fatalf("strict route: expected 400, got %d", code)
+	}
+	want := []string{"idempotencyKey", "lines[1].amout"}
+	if len(violations) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, violations)
+	}
+	for i, field := range want {
+		if violations[i].Field != field || violations[i].Message != "Unknown field" {
+			t.Errorf("violation %d: expected unknown %s, got %+v", i, field, violations[i])
+		}
+	}
+}
+
+func TestBindJSON_StrictRouteKeepsValidationErrors(t *testing.T) {
+	rs := newStrictTestRouter(t)
+
+	code, violations := postJSON(t, rs, "/items/strict", `{"nmae":"a"}`)
+	if code != http.StatusBadRequest || len(violations) != 2 {
+		t.Fatalf("expected 400 with 2 violations, got %d %+v", code, violations)
+	}
+	if violations[0].Field != "name" || violations[1].Field != "nmae" {
+		t.Errorf("unexpected violations: %+v", violations)
+	}
+
+	code, violations = postJSON(t, rs, "/items/strict", `{"name":`)
+	if code != http.StatusBadRequest || violations != nil {
+		t.Errorf("malformed body: expected 400 without violations, got %d %+v", code, violations)
+	}
+}
+
+func TestBindJSON_GlobalStrictness(t *testing.T) {
+	t.Setenv("STRICT_JSON_BINDING", "true")
+	rs := newStrictTestRouter(t)
+	body := `{"name":"a","extra":true}`
+
+	if code, _ := postJSON(t, rs, "/items/lenient", body); code != http.StatusBadRequest {
+		t.Errorf("route without override: expected 400, got %d", code)
+	}
+	if code, _ := postJSON(t, rs, "/items/exempt", body); code != http.StatusOK {
+		t.Errorf("exempt route: expected 200, got %d", code)
+	}
+}
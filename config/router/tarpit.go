@@ -0,0 +1,54 @@
+package router
+
+import (
+	"strconv"
+
+	"github.com/akeren/go-api-foundry/pkg/tarpit"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultTarpitMaxConcurrent = 64
+
+func (routerService *RouterService) initTarpit() {
+	spec := utils.GetEnvTrimmed("TARPIT_DELAYS")
+	if spec == "" {
+		return
+	}
+
+	delays, err := tarpit.ParseDelays(spec)
+	if err != nil {
+		routerService.logger.Error("Invalid TARPIT_DELAYS; tarpit disabled", "error", err)
+		return
+	}
+
+	maxConcurrent := defaultTarpitMaxConcurrent
+	if v := utils.GetEnvTrimmed("TARPIT_MAX_CONCURRENT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxConcurrent = n
+		}
+	}
+
+	// The server's WriteTimeout is the request timeout; a longer hold would
+	// just drop the connection without an answer.
+	for severity, delay := range delays {
+		if timeout := routerService.middlewareConfig.TimeoutDuration; timeout > 0 && delay >= timeout {
+			routerService.logger.Warn("Tarpit delay exceeds the request timeout; clients will see a dropped connection",
+				"severity", severity,
+				"delay", delay,
+				"request_timeout", timeout,
+			)
+		}
+	}
+
+	routerService.tarpit = tarpit.New(delays, maxConcurrent)
+	routerService.logger.Info("Tarpit enabled", "delays", spec, "max_concurrent", maxConcurrent)
+}
+
+// holdAbusiveClient delays the rejection of an abusive client when a tarpit
+// delay is configured for the severity. Legitimate traffic never reaches here.
+func (routerService *RouterService) holdAbusiveClient(c *gin.Context, severity tarpit.Severity) {
+	if routerService.tarpit.Hold(c.Request.Context(), severity) {
+		routerService.recordTarpittedRequest(string(severity))
+	}
+}
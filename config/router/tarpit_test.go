@@ -0,0 +1,47 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTarpit_DelaysBannedClientsOnly(t *testing.T) {
+	t.Setenv("TARPIT_DELAYS", "banned=100ms")
+
+	rs := newTestRouterService(t)
+	mountTestController(rs)
+
+	if _, err := rs.IPFilter().Ban(context.Background(), "198.51.100.9", time.Minute, "test"); err != nil {
+		t.Fatalf("ban: %v", err)
+	}
+
+	serve := func(remote string) (int, time.Duration) {
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		start := time.Now()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w.Code, time.Since(start)
+	}
+
+	code, elapsed := serve("198.51.100.9:1")
+	if code != http.StatusForbidden || elapsed < 100*time.Millisecond {
+		t.Fatalf("expected delayed 403 for banned client, got %d after %s", code, elapsed)
+	}
+
+	code, elapsed = serve("198.51.100.10:1")
+	if code != http.StatusOK || elapsed >= 100*time.Millisecond {
+		t.Fatalf("expected immediate 200 for regular client, got %d after %s", code, elapsed)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `http_tarpitted_requests_total{severity="banned"} 1`) {
+		t.Fatalf("expected tarpit metric, got:\n%s", w.Body.String())
+	}
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"github.com/akeren/go-api-foundry/pkg/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,6 +13,14 @@ type ServiceResult struct {
 	StatusCode int    `json:"code"`
 	Data       any    `json:"data"`
 	Message    string `json:"message"`
+	// Warnings are non-fatal problems the client should know about, such as
+	// a deprecated parameter or the failed items of a partial result.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// write replaces the default JSON envelope for non-JSON results (HTML, files, ...).
+	write func(c *RequestContext, statusCode int)
+	// headers are set on the response before the result is written.
+	headers map[string]string
 }
 
 type RateLimitResponse struct {
@@ -22,20 +31,57 @@ type RateLimitResponse struct {
 
 type HandlerFunction func(*RequestContext) *ServiceResult
 
+// RouteRegistrar registers a controller's handlers. RouterService implements
+// it; controller tests can register against a fake instead (see routertest)
+// and call the handlers without starting gin.
+type RouteRegistrar interface {
+	AddGetHandler(controller *RESTController, limiter ratelimit.RateLimiter, path string, handler HandlerFunction, middlewares ...MiddlewareFunc) *Route
+	AddPostHandler(controller *RESTController, limiter ratelimit.RateLimiter, path string, handler HandlerFunction, middlewares ...MiddlewareFunc) *Route
+	AddPutHandler(controller *RESTController, limiter ratelimit.RateLimiter, path string, handler HandlerFunction, middlewares ...MiddlewareFunc) *Route
+	AddPatchHandler(controller *RESTController, limiter ratelimit.RateLimiter, path string, handler HandlerFunction, middlewares ...MiddlewareFunc) *Route
+	AddDeleteHandler(controller *RESTController, limiter ratelimit.RateLimiter, path string, handler HandlerFunction, middlewares ...MiddlewareFunc) *Route
+	AddHeadHandler(controller *RESTController, limiter ratelimit.RateLimiter, path string, handler HandlerFunction, middlewares ...MiddlewareFunc) *Route
+	AddOptionsHandler(controller *RESTController, limiter ratelimit.RateLimiter, path string, handler HandlerFunction, middlewares ...MiddlewareFunc) *Route
+}
+
 type RESTController struct {
 	name         string
 	mountPoint   string
 	version      string
 	handlerCount int
-	prepare      func(*RouterService, *RESTController)
+	requireAuth  bool
+	requireCSRF  bool
+	idempotency  IdempotencyOptions
+	maxBodyBytes int64
+	rateLimitKey RateLimitKeyFunc
+	prepare      func(RouteRegistrar, *RESTController)
 }
 
 func (result *ServiceResult) ToJSON() gin.H {
-	return gin.H{
+	envelope := gin.H{
 		"code":    result.StatusCode,
 		"data":    result.Data,
 		"message": result.Message,
 	}
+	if len(result.Warnings) > 0 {
+		envelope["warnings"] = result.Warnings
+	}
+	return envelope
+}
+
+// WithWarning adds a warning to the envelope without changing the status.
+func (result *ServiceResult) WithWarning(message string) *ServiceResult {
+	result.Warnings = append(result.Warnings, message)
+	return result
+}
+
+// WithHeader sets a response header written alongside the result.
+func (result *ServiceResult) WithHeader(key, value string) *ServiceResult {
+	if result.headers == nil {
+		result.headers = make(map[string]string)
+	}
+	result.headers[key] = value
+	return result
 }
 
 func (result *ServiceResult) IsSuccess() bool {
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const (
+	defaultRobotsTxt      = "User-agent: *\nDisallow: /\n"
+	defaultSecurityTxtTTL = 180 * 24 * time.Hour
+	wellKnownCacheControl = "public, max-age=86400"
+)
+
+// WellKnownConfig holds the content served for paths that crawlers, browsers,
+// and password managers request on their own. Serving them from a controller
+// keeps them out of the "Route not found" logs.
+type WellKnownConfig struct {
+	RobotsTxt         string
+	SecurityTxt       string // Empty: /.well-known/security.txt returns 404
+	ChangePasswordURL string // Empty: /.well-known/change-password returns 404
+	Favicon           []byte // Empty: /favicon.ico returns 204
+}
+
+// WellKnownConfigFromEnv builds the configuration from:
+//
+//	ROBOTS_TXT_FILE        robots.txt body (default: disallow everything)
+//	SECURITY_TXT_FILE      complete security.txt body, or build one from
+//	SECURITY_TXT_CONTACT   Contact field (e.g. mailto:security@example.com)
+//	SECURITY_TXT_EXPIRES   RFC 3339 expiry (default: 180 days after startup)
+//	SECURITY_TXT_POLICY    optional Policy URL
+//	CHANGE_PASSWORD_URL    redirect target for /.well-known/change-password
+//	FAVICON_FILE           icon served at /favicon.ico
+func WellKnownConfigFromEnv(logger *log.Logger) *WellKnownConfig {
+	cfg := &WellKnownConfig{
+		RobotsTxt:         defaultRobotsTxt,
+		ChangePasswordURL: utils.GetEnvTrimmed("CHANGE_PASSWORD_URL"),
+	}
+
+	if body, ok := readWellKnownFile(logger, "ROBOTS_TXT_FILE"); ok {
+		cfg.RobotsTxt = string(body)
+	}
+
+	if body, ok := readWellKnownFile(logger, "SECURITY_TXT_FILE"); ok {
+		cfg.SecurityTxt = string(body)
+	} else if contact := utils.GetEnvTrimmed("SECURITY_TXT_CONTACT"); contact != "" {
+		expires := time.Now().Add(defaultSecurityTxtTTL)
+		if raw := utils.GetEnvTrimmed("SECURITY_TXT_EXPIRES"); raw != "" {
+			if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+				expires = parsed
+			} else {
+				logger.Warn("Invalid SECURITY_TXT_EXPIRES; using default", "value", raw, "error", err)
+			}
+		}
+		cfg.SecurityTxt = BuildSecurityTxt(contact, expires, utils.GetEnvTrimmed("SECURITY_TXT_POLICY"))
+	}
+
+	if body, ok := readWellKnownFile(logger, "FAVICON_FILE"); ok {
+		cfg.Favicon = body
+	}
+
+	return cfg
+}
+
+// BuildSecurityTxt renders a minimal RFC 9116 document.
+func BuildSecurityTxt(contact string, expires time.Time, policy string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Contact: %s\n", contact)
+	fmt.Fprintf(&b, "Expires: %s\n", expires.UTC().Format(time.RFC3339))
+	if policy != "" {
+		fmt.Fprintf(&b, "Policy: %s\n", policy)
+	}
+	return b.String()
+}
+
+func readWellKnownFile(logger *log.Logger, envKey string) ([]byte, bool) {
+	path := utils.GetEnvTrimmed(envKey)
+	if path == "" {
+		return nil, false
+	}
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		logger.Warn("Failed to read well-known file; using default", "env", envKey, "path", path, "error", err)
+		return nil, false
+	}
+	return body, true
+}
+
+// NewWellKnownController serves /robots.txt, /favicon.ico,
+// /.well-known/security.txt and /.well-known/change-password.
+func NewWellKnownController(cfg *WellKnownConfig) *RESTController {
+	if cfg == nil {
+		cfg = &WellKnownConfig{RobotsTxt: defaultRobotsTxt}
+	}
+
+	return NewRESTController("WellKnownController", "/", func(rs RouteRegistrar, c *RESTController) {
+		hidden := OperationDoc{Hidden: true}
+
+		rs.AddGetHandler(c, nil, "robots.txt", func(ctx *RequestContext) *ServiceResult {
+			ctx.Header("Cache-Control", wellKnownCacheControl)
+			return TextResult(http.StatusOK, cfg.RobotsTxt)
+		}).Describe(hidden)
+
+		rs.AddGetHandler(c, nil, "favicon.ico", func(ctx *RequestContext) *ServiceResult {
+			ctx.Header("Cache-Control", wellKnownCacheControl)
+			if len(cfg.Favicon) == 0 {
+				return NoContentResult()
+			}
+			return DataResult(http.StatusOK, "image/x-icon", cfg.Favicon)
+		}).Describe(hidden)
+
+		rs.AddGetHandler(c, nil, ".well-known/security.txt", func(ctx *RequestContext) *ServiceResult {
+			if cfg.SecurityTxt == "" {
+				return NotFoundResult("security.txt is not configured")
+			}
+			return TextResult(http.StatusOK, cfg.SecurityTxt)
+		}).Describe(hidden)
+
+		rs.AddGetHandler(c, nil, ".well-known/change-password", func(ctx *RequestContext) *ServiceResult {
+			if cfg.ChangePasswordURL == "" {
+				return NotFoundResult("change-password is not configured")
+			}
+			return RedirectResult(http.StatusFound, cfg.ChangePasswordURL)
+		}).Describe(hidden)
+	})
+}
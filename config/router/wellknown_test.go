@@ -0,0 +1,77 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serveWellKnown(t *testing.T, cfg *WellKnownConfig, path string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rs := newTestRouterService(t)
+	rs.MountController(NewWellKnownController(cfg))
+
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestWellKnown_RobotsTxtDefault(t *testing.T) {
+	w := serveWellKnown(t, nil, "/robots.txt")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Body.String() != defaultRobotsTxt {
+		t.Fatalf("unexpected body: %q", w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("expected text/plain, got %q", ct)
+	}
+}
+
+func TestWellKnown_FaviconWithoutIconIsNoContent(t *testing.T) {
+	w := serveWellKnown(t, &WellKnownConfig{}, "/favicon.ico")
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+}
+
+func TestWellKnown_SecurityTxt(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := &WellKnownConfig{SecurityTxt: BuildSecurityTxt("mailto:sec@example.com", expires, "")}
+
+	w := serveWellKnown(t, cfg, "/.well-known/security.txt")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	want := "Contact: mailto:sec@example.com\nExpires: 2030-01-02T03:04:05Z\n"
+	if w.Body.String() != want {
+		t.Fatalf("unexpected body: %q", w.Body.String())
+	}
+}
+
+func TestWellKnown_UnconfiguredPathsReturn404(t *testing.T) {
+	for _, path := range []string{"/.well-known/security.txt", "/.well-known/change-password"} {
+		w := serveWellKnown(t, &WellKnownConfig{}, path)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestWellKnown_ChangePasswordRedirects(t *testing.T) {
+	w := serveWellKnown(t, &WellKnownConfig{ChangePasswordURL: "https://example.com/account/password"}, "/.well-known/change-password")
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "https://example.com/account/password" {
+		t.Fatalf("unexpected Location: %q", loc)
+	}
+}
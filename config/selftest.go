@@ -0,0 +1,158 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/probe"
+)
+
+// selfTestLivenessPath is requested in-process to check that the router
+// serves the monitoring routes.
+const selfTestLivenessPath = "/health/live"
+
+type selfTest struct {
+	name  string
+	check probe.Check
+}
+
+// SelfTestResult is the outcome of one self-test check. Skipped checks need
+// a component that is not configured.
+type SelfTestResult struct {
+	Name     string
+	Passed   bool
+	Skipped  bool
+	Duration time.Duration
+	Err      error
+}
+
+type SelfTestReport struct {
+	Results []SelfTestResult
+}
+
+// Passed reports whether no check failed.
+func (r SelfTestReport) Passed() bool {
+	for _, result := range r.Results {
+		if !result.Passed && !result.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// Write prints one line per check and a verdict.
+func (r SelfTestReport) Write(w io.Writer) {
+	for _, result := range r.Results {
+		switch {
+		case result.Skipped:
+			fmt.Fprintf(w, "SKIP  %s: %v\n", result.Name, result.Err)
+		case result.Passed:
+			fmt.Fprintf(w, "PASS  %s (%s)\n", result.Name, result.Duration.Round(time.Millisecond))
+		default:
+			fmt.Fprintf(w, "FAIL  %s (%s): %v\n", result.Name, result.Duration.Round(time.Millisecond), result.Err)
+		}
+	}
+	if r.Passed() {
+		fmt.Fprintln(w, "self-test passed")
+	} else {
+		fmt.Fprintln(w, "self-test failed")
+	}
+}
+
+// errSelfTestSkipped marks a check whose component is not configured.
+var errSelfTestSkipped = errors.New("not configured")
+
+// RegisterSelfTest adds a check that --self-test runs after the built-in
+// ones. Domains use it to exercise their own components, such as a write
+// that is rolled back.
+func (ac *ApplicationConfig) RegisterSelfTest(name string, check probe.Check) {
+	ac.selfTests = append(ac.selfTests, selfTest{name: name, check: check})
+}
+
+// SelfTest runs quick checks against the configured components and reports
+// each: a database round-trip, a cache write and read, the route table, and
+// the checks domains registered. Checks run one at a time, each bounded by
+// timeout.
+func (ac *ApplicationConfig) SelfTest(ctx context.Context, timeout time.Duration) SelfTestReport {
+	checks := append([]selfTest{
+		{name: "database", check: ac.selfTestDatabase},
+		{name: "cache", check: ac.selfTestCache},
+		{name: "routes", check: ac.selfTestRoutes},
+	}, ac.selfTests...)
+
+	var report SelfTestReport
+	for _, test := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		err := test.check(checkCtx)
+		cancel()
+
+		result := SelfTestResult{Name: test.name, Passed: err == nil, Duration: time.Since(start), Err: err}
+		if errors.Is(err, errSelfTestSkipped) {
+			result.Skipped = true
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+func (ac *ApplicationConfig) selfTestDatabase(ctx context.Context) error {
+	if ac.DB == nil {
+		return errSelfTestSkipped
+	}
+	var one int
+	if err := ac.DB.WithContext(ctx).Raw("SELECT 1").Scan(&one).Error; err != nil {
+		return err
+	}
+	if one != 1 {
+		return fmt.Errorf("SELECT 1 returned %d", one)
+	}
+	return nil
+}
+
+func (ac *ApplicationConfig) selfTestCache(ctx context.Context) error {
+	if ac.Cache == nil {
+		return errSelfTestSkipped
+	}
+	key := "selftest:" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	want := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := ac.Cache.Set(ctx, key, want, time.Minute); err != nil {
+		return fmt.Errorf("set: %w", err)
+	}
+	defer ac.Cache.Delete(context.WithoutCancel(ctx), key)
+
+	got, err := ac.Cache.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+	if got != want {
+		return fmt.Errorf("read %q back as %q", want, got)
+	}
+	return nil
+}
+
+// selfTestRoutes checks that domains registered routes and that the router
+// answers the liveness route through its middleware.
+func (ac *ApplicationConfig) selfTestRoutes(ctx context.Context) error {
+	if ac.RouterService == nil {
+		return errSelfTestSkipped
+	}
+	if len(ac.RouterService.Routes()) == 0 {
+		return errors.New("no routes registered")
+	}
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, selfTestLivenessPath, nil)
+	req.Header.Set("User-Agent", "go-api-foundry-self-test")
+	w := httptest.NewRecorder()
+	ac.RouterService.GetEngine().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", selfTestLivenessPath, w.Code)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+)
+
+func TestSelfTest_ReportsEachCheck(t *testing.T) {
+	logger := log.NewLoggerWithJSONOutput()
+	rs := router.CreateRouterService(logger, nil, &router.RouterConfig{
+		RateLimitRequests: 10,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    time.Second,
+	})
+	ac := &ApplicationConfig{Logger: logger, RouterService: rs}
+	ac.RegisterSelfTest("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := ac.SelfTest(context.Background(), 10*time.Millisecond)
+	if report.Passed() {
+		t.Fatalf("expected the self-test to fail, got %+v", report.Results)
+	}
+	var out strings.Builder
+	report.Write(&out)
+	for _, want := range []string{
+		"SKIP  database: not configured",
+		"SKIP  cache: not configured",
+		"FAIL  routes",
+		"no routes registered",
+		"FAIL  slow",
+		"context deadline exceeded",
+		"self-test failed",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("report lacks %q:\n%s", want, out.String())
+		}
+	}
+	if !errors.Is(report.Results[3].Err, context.DeadlineExceeded) {
+		t.Fatalf("expected the slow check to be bounded by the timeout, got %v", report.Results[3].Err)
+	}
+
+	if passed := (SelfTestReport{Results: []SelfTestResult{{Name: "cache", Skipped: true}}}); !passed.Passed() {
+		t.Fatalf("expected skipped checks not to fail the self-test")
+	}
+}
@@ -0,0 +1,481 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/cdc"
+	"github.com/akeren/go-api-foundry/pkg/ipfilter"
+	"github.com/akeren/go-api-foundry/pkg/operations"
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"github.com/akeren/go-api-foundry/pkg/rbac"
+	"github.com/akeren/go-api-foundry/pkg/scaleout"
+	"github.com/akeren/go-api-foundry/pkg/status"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"github.com/akeren/go-api-foundry/pkg/webhooks"
+)
+
+const defaultBanTTL = time.Hour
+
+type BanRequest struct {
+	IP     string `json:"ip" binding:"required"`
+	TTL    string `json:"ttl"`
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+type RoleBindingRequest struct {
+	Kind    string `json:"kind" binding:"required,oneof=user service"`
+	Subject string `json:"subject" binding:"required,max=255"`
+	Role    string `json:"role" binding:"required,max=64"`
+}
+
+// IncidentRequest posts a status page incident. StartedAt defaults to now.
+type IncidentRequest struct {
+	Title      string     `json:"title" binding:"required,max=255"`
+	Message    string     `json:"message" binding:"max=5000"`
+	Impact     string     `json:"impact" binding:"required,oneof=minor major critical"`
+	Components []string   `json:"components" binding:"max=20,dive,max=64"`
+	StartedAt  *time.Time `json:"started_at"`
+}
+
+// IncidentUpdateRequest changes the fields that are set. Resolved marks the
+// incident resolved now, or reopens it when false.
+type IncidentUpdateRequest struct {
+	Title      string   `json:"title" binding:"max=255"`
+	Message    *string  `json:"message" binding:"omitempty,max=5000"`
+	Impact     string   `json:"impact" binding:"omitempty,oneof=minor major critical"`
+	Components []string `json:"components" binding:"omitempty,max=20,dive,max=64"`
+	Resolved   *bool    `json:"resolved"`
+}
+
+// Network is the part of the router operators manage through the IP ban,
+// GeoIP and rate limit endpoints. RouterService implements it.
+type Network interface {
+	IPFilter() *ipfilter.Filter
+	ReloadGeoIP() error
+	RateLimitStates(ctx context.Context, key string) ([]router.RateLimitState, error)
+	ResetRateLimit(ctx context.Context, key string) error
+}
+
+// NewAdminController mounts operator endpoints under /v1/admin. It returns nil
+// when ADMIN_API_TOKEN is not set so the endpoints are never exposed unprotected.
+// IP ban, GeoIP and rate limit, role binding, incident, webhook, change data
+// capture and scale-out endpoints are only mounted when network, roles,
+// incidents, hooks, changes and scaleOut are non-nil; replaying a webhook
+// endpoint's events over a time range also needs ops.
+func NewAdminController(logger *log.Logger, network Network, roles rbac.Store, incidents status.Store, hooks *webhooks.Dispatcher, ops *operations.Manager, changes *cdc.Inspector, scaleOut func() scaleout.Report) *router.RESTController {
+	token := utils.GetEnvTrimmed("ADMIN_API_TOKEN")
+	if token == "" {
+		logger.Info("Admin API disabled (ADMIN_API_TOKEN not set)")
+		return nil
+	}
+
+	return router.NewVersionedRESTController(
+		"AdminController",
+		"v1",
+		"/admin",
+		func(rs router.RouteRegistrar, c *router.RESTController) {
+			auth := requireAdminToken(token)
+
+			if network != nil {
+				filter := network.IPFilter()
+				rs.AddGetHandler(c, nil, "/ip-bans", listBansHandler(filter), auth).Describe(router.OperationDoc{
+					Summary: "List active IP bans", Response: []ipfilter.Ban{},
+				})
+				rs.AddPostHandler(c, nil, "/ip-bans", banHandler(filter), auth).Describe(router.OperationDoc{
+					Summary: "Ban an IP address", Request: BanRequest{}, Response: ipfilter.Ban{}, Status: http.StatusCreated,
+				})
+				rs.AddDeleteHandler(c, nil, "/ip-bans/:ip", unbanHandler(filter), auth).Describe(router.OperationDoc{
+					Summary: "Lift an IP ban",
+				})
+				rs.AddPostHandler(c, nil, "/geoip/reload", reloadGeoIPHandler(network), auth).Describe(router.OperationDoc{
+					Summary: "Reload the GeoIP databases",
+				})
+				rs.AddGetHandler(c, nil, "/rate-limits", rateLimitStatesHandler(network), auth).Describe(router.OperationDoc{
+					Summary:     "Inspect a client's rate limit counters",
+					Description: "The client's usage of the default limiter, each route or controller override and, for services, the service's own limiter.",
+					Response:    []router.RateLimitState{}, Query: rateLimitParams,
+				})
+				rs.AddDeleteHandler(c, nil, "/rate-limits", resetRateLimitHandler(network), auth).Describe(router.OperationDoc{
+					Summary: "Reset a client's rate limit counters", Query: rateLimitParams,
+				})
+			}
+
+			if roles != nil {
+				rs.AddGetHandler(c, nil, "/role-bindings", listRoleBindingsHandler(roles), auth).Describe(router.OperationDoc{
+					Summary: "List role bindings", Response: []rbac.Binding{},
+					Query: []router.QueryParam{{Name: "kind", Description: "user or service"}, {Name: "subject"}},
+				})
+				rs.AddPostHandler(c, nil, "/role-bindings", grantRoleHandler(roles), auth).Describe(router.OperationDoc{
+					Summary: "Grant a role", Request: RoleBindingRequest{}, Response: rbac.Binding{}, Status: http.StatusCreated,
+				})
+				rs.AddDeleteHandler(c, nil, "/role-bindings", revokeRoleHandler(roles), auth).Describe(router.OperationDoc{
+					Summary: "Revoke a role",
+					Query: []router.QueryParam{
+						{Name: "kind", Description: "user or service", Required: true},
+						{Name: "subject", Required: true},
+						{Name: "role", Required: true},
+					},
+				})
+			}
+
+			if incidents != nil {
+				rs.AddGetHandler(c, nil, "/incidents", listIncidentsHandler(incidents), auth).Describe(router.OperationDoc{
+					Summary: "List status page incidents", Response: []status.Incident{},
+					Query: []router.QueryParam{{Name: "days", Type: "integer", Description: "Include incidents resolved within this many days (default 30)"}},
+				})
+				rs.AddPostHandler(c, nil, "/incidents", createIncidentHandler(incidents), auth).Describe(router.OperationDoc{
+					Summary: "Post a status page incident", Request: IncidentRequest{}, Response: status.Incident{}, Status: http.StatusCreated,
+				})
+				rs.AddPatchHandler(c, nil, "/incidents/:id", updateIncidentHandler(incidents), auth).Describe(router.OperationDoc{
+					Summary: "Update or resolve a status page incident", Request: IncidentUpdateRequest{}, Response: status.Incident{},
+				})
+			}
+
+			if hooks != nil {
+				mountWebhookRoutes(rs, c, hooks, ops, auth)
+			}
+
+			if changes != nil {
+				rs.AddGetHandler(c, nil, "/cdc", describeCDCHandler(changes), auth).Describe(router.OperationDoc{
+					Summary:     "Describe change data capture",
+					Description: "The publication, replication slot and tables a logical decoding consumer such as Debezium subscribes to, with the database's readiness for it.",
+					Response:    cdc.Description{},
+				})
+			}
+
+			if scaleOut != nil {
+				rs.AddGetHandler(c, nil, "/scale-out", func(ctx *router.RequestContext) *router.ServiceResult {
+					return router.OKResult(scaleOut(), "Scale-out audit completed")
+				}, auth).Describe(router.OperationDoc{
+					Summary:     "Audit per-instance state",
+					Description: "Where each component keeps its state and, when APP_REPLICAS is above one, which of them are not shared between replicas.",
+					Response:    scaleout.Report{},
+				})
+			}
+		},
+	)
+}
+
+func requireAdminToken(token string) router.MiddlewareFunc {
+	expected := []byte(token)
+	return func(ctx *router.RequestContext) {
+		header := ctx.GetHeader("Authorization")
+		provided, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), expected) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, router.UnauthorizedResult("Invalid or missing admin token").ToJSON())
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func describeCDCHandler(changes *cdc.Inspector) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		description, err := changes.Describe(ctx.Request.Context())
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to describe change data capture", "error", err)
+			return router.InternalServerErrorResult("Failed to describe change data capture")
+		}
+		return router.OKResult(description, "Change data capture described successfully")
+	}
+}
+
+func listBansHandler(filter *ipfilter.Filter) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		bans, err := filter.Bans(ctx.Request.Context())
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to list IP bans", "error", err)
+			return router.InternalServerErrorResult("Failed to list IP bans")
+		}
+		return router.OKResult(bans, "IP bans retrieved successfully")
+	}
+}
+
+func banHandler(filter *ipfilter.Filter) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		req, errResult := router.BindJSON[BanRequest](ctx)
+		if errResult != nil {
+			return errResult
+		}
+
+		ttl := defaultBanTTL
+		if req.TTL != "" {
+			d, err := time.ParseDuration(req.TTL)
+			if err != nil || d <= 0 {
+				return router.BadRequestResult("ttl must be a positive duration such as 30m or 24h", nil)
+			}
+			ttl = d
+		}
+
+		ban, err := filter.Ban(ctx.Request.Context(), req.IP, ttl, req.Reason)
+		if err != nil {
+			if errors.Is(err, ipfilter.ErrInvalidIP) {
+				return router.BadRequestResult("ip must be a valid IPv4 or IPv6 address", nil)
+			}
+			router.GetLogger(ctx).Error("Failed to ban IP", "error", err, "ip", req.IP)
+			return router.InternalServerErrorResult("Failed to ban IP")
+		}
+
+		router.GetLogger(ctx).Warn("IP banned by operator", "ip", ban.IP, "ttl", ttl.String(), "reason", ban.Reason)
+		return router.CreatedResult(ban, "IP ban")
+	}
+}
+
+func unbanHandler(filter *ipfilter.Filter) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		ip := ctx.Param("ip")
+		if err := filter.Unban(ctx.Request.Context(), ip); err != nil {
+			if errors.Is(err, ipfilter.ErrInvalidIP) {
+				return router.BadRequestResult("ip must be a valid IPv4 or IPv6 address", nil)
+			}
+			router.GetLogger(ctx).Error("Failed to unban IP", "error", err, "ip", ip)
+			return router.InternalServerErrorResult("Failed to unban IP")
+		}
+
+		router.GetLogger(ctx).Info("IP unbanned by operator", "ip", ip)
+		return router.OKResult(nil, "IP ban removed")
+	}
+}
+
+var rateLimitParams = []router.QueryParam{
+	{Name: "ip", Description: "Client IP address"},
+	{Name: "service", Description: "Service name from the service identities file; instead of ip"},
+	{Name: "user", Description: "Token subject, on routes limited per user; instead of ip"},
+}
+
+// rateLimitKey returns the limiter key named by exactly one of the ip,
+// service and user query parameters.
+func rateLimitKey(ctx *router.RequestContext) (string, *router.ServiceResult) {
+	ip, service, user := ctx.Query("ip"), ctx.Query("service"), ctx.Query("user")
+	named := 0
+	for _, v := range []string{ip, service, user} {
+		if v != "" {
+			named++
+		}
+	}
+	switch {
+	case named != 1:
+		return "", router.BadRequestResult("Exactly one of ip, service and user is required", nil)
+	case service != "":
+		return router.ServiceRateLimitKey(service), nil
+	case user != "":
+		return router.UserRateLimitKey(user), nil
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", router.BadRequestResult("ip must be a valid IPv4 or IPv6 address", nil)
+	}
+	return router.ClientRateLimitKey(addr.String()), nil
+}
+
+func rateLimitStatesHandler(network Network) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		key, errResult := rateLimitKey(ctx)
+		if errResult != nil {
+			return errResult
+		}
+		states, err := network.RateLimitStates(ctx.Request.Context(), key)
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to inspect rate limits", "error", err, "key", key)
+			return router.InternalServerErrorResult("Failed to inspect rate limits")
+		}
+		return router.OKResult(states, "Rate limits retrieved successfully")
+	}
+}
+
+func resetRateLimitHandler(network Network) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		key, errResult := rateLimitKey(ctx)
+		if errResult != nil {
+			return errResult
+		}
+		if err := network.ResetRateLimit(ctx.Request.Context(), key); err != nil {
+			router.GetLogger(ctx).Error("Failed to reset rate limits", "error", err, "key", key)
+			return router.InternalServerErrorResult("Failed to reset rate limits")
+		}
+
+		router.GetLogger(ctx).Warn("Rate limits reset by operator", "key", key)
+		return router.OKResult(nil, "Rate limits reset")
+	}
+}
+
+func reloadGeoIPHandler(network Network) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		if err := network.ReloadGeoIP(); err != nil {
+			if errors.Is(err, router.ErrGeoIPReloadUnsupported) {
+				return router.ErrorResult(http.StatusConflict, "GeoIP is not configured with reloadable databases", nil)
+			}
+			router.GetLogger(ctx).Error("Failed to reload GeoIP databases", "error", err)
+			return router.InternalServerErrorResult("Failed to reload GeoIP databases")
+		}
+		return router.OKResult(nil, "GeoIP databases reloaded")
+	}
+}
+
+// listRoleBindingsHandler lists bindings, optionally filtered by the kind and
+// subject query parameters.
+func listRoleBindingsHandler(roles rbac.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		bindings, err := roles.Bindings(ctx.Request.Context(), principal.Kind(ctx.Query("kind")), ctx.Query("subject"))
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to list role bindings", "error", err)
+			return router.InternalServerErrorResult("Failed to list role bindings")
+		}
+		return router.OKResult(bindings, "Role bindings retrieved successfully")
+	}
+}
+
+func grantRoleHandler(roles rbac.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		req, errResult := router.BindJSON[RoleBindingRequest](ctx)
+		if errResult != nil {
+			return errResult
+		}
+
+		binding := rbac.Binding{Kind: principal.Kind(req.Kind), Subject: req.Subject, Role: req.Role}
+		if err := binding.Validate(); err != nil {
+			return router.BadRequestResult(err.Error(), nil)
+		}
+		if err := roles.Grant(ctx.Request.Context(), binding); err != nil {
+			router.GetLogger(ctx).Error("Failed to grant role", "error", err, "subject", binding.Subject, "role", binding.Role)
+			return router.InternalServerErrorResult("Failed to grant role")
+		}
+
+		router.GetLogger(ctx).Warn("Role granted by operator", "kind", binding.Kind, "subject", binding.Subject, "role", binding.Role)
+		return router.CreatedResult(binding, "Role binding")
+	}
+}
+
+// revokeRoleHandler takes the binding from the kind, subject and role query
+// parameters, since subjects may contain characters awkward in a path.
+func revokeRoleHandler(roles rbac.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		binding := rbac.Binding{Kind: principal.Kind(ctx.Query("kind")), Subject: ctx.Query("subject"), Role: ctx.Query("role")}
+		if err := binding.Validate(); err != nil {
+			return router.BadRequestResult(err.Error(), nil)
+		}
+		if err := roles.Revoke(ctx.Request.Context(), binding); err != nil {
+			if errors.Is(err, rbac.ErrNotFound) {
+				return router.NotFoundResult("Role binding not found")
+			}
+			router.GetLogger(ctx).Error("Failed to revoke role", "error", err, "subject", binding.Subject, "role", binding.Role)
+			return router.InternalServerErrorResult("Failed to revoke role")
+		}
+
+		router.GetLogger(ctx).Warn("Role revoked by operator", "kind", binding.Kind, "subject", binding.Subject, "role", binding.Role)
+		return router.OKResult(nil, "Role binding removed")
+	}
+}
+
+func listIncidentsHandler(incidents status.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		days := 30
+		if raw := ctx.Query("days"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				return router.BadRequestResult("days must be a positive integer", nil)
+			}
+			days = parsed
+		}
+
+		list, err := incidents.Incidents(ctx.Request.Context(), time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to list incidents", "error", err)
+			return router.InternalServerErrorResult("Failed to list incidents")
+		}
+		return router.OKResult(list, "Incidents retrieved successfully")
+	}
+}
+
+func createIncidentHandler(incidents status.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		req, errResult := router.BindJSON[IncidentRequest](ctx)
+		if errResult != nil {
+			return errResult
+		}
+
+		incident := &status.Incident{
+			Title:      req.Title,
+			Message:    req.Message,
+			Impact:     req.Impact,
+			Components: req.Components,
+			StartedAt:  time.Now().UTC(),
+		}
+		if req.StartedAt != nil {
+			incident.StartedAt = req.StartedAt.UTC()
+		}
+		if err := incidents.SaveIncident(ctx.Request.Context(), incident); err != nil {
+			if errors.Is(err, status.ErrInvalidIncident) {
+				return router.BadRequestResult(err.Error(), nil)
+			}
+			router.GetLogger(ctx).Error("Failed to create incident", "error", err)
+			return router.InternalServerErrorResult("Failed to create incident")
+		}
+
+		router.GetLogger(ctx).Warn("Incident posted by operator", "id", incident.ID, "impact", incident.Impact, "title", incident.Title)
+		return router.CreatedResult(incident, "Incident")
+	}
+}
+
+func updateIncidentHandler(incidents status.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		req, errResult := router.BindJSON[IncidentUpdateRequest](ctx)
+		if errResult != nil {
+			return errResult
+		}
+
+		incident, err := incidents.Incident(ctx.Request.Context(), ctx.Param("id"))
+		if errors.Is(err, status.ErrNotFound) {
+			return router.NotFoundResult("Incident not found")
+		}
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to load incident", "error", err)
+			return router.InternalServerErrorResult("Failed to update incident")
+		}
+
+		if req.Title != "" {
+			incident.Title = req.Title
+		}
+		if req.Message != nil {
+			incident.Message = *req.Message
+		}
+		if req.Impact != "" {
+			incident.Impact = req.Impact
+		}
+		if req.Components != nil {
+			incident.Components = req.Components
+		}
+		if req.Resolved != nil {
+			switch {
+			case *req.Resolved && incident.ResolvedAt == nil:
+				now := time.Now().UTC()
+				incident.ResolvedAt = &now
+			case !*req.Resolved:
+				incident.ResolvedAt = nil
+			}
+		}
+
+		if err := incidents.SaveIncident(ctx.Request.Context(), incident); err != nil {
+			if errors.Is(err, status.ErrInvalidIncident) {
+				return router.BadRequestResult(err.Error(), nil)
+			}
+			if errors.Is(err, status.ErrNotFound) {
+				return router.NotFoundResult("Incident not found")
+			}
+			router.GetLogger(ctx).Error("Failed to update incident", "error", err)
+			return router.InternalServerErrorResult("Failed to update incident")
+		}
+
+		router.GetLogger(ctx).Warn("Incident updated by operator", "id", incident.ID, "resolved", !incident.Ongoing())
+		return router.OKResult(incident, "Incident updated successfully")
+	}
+}
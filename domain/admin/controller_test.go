@@ -0,0 +1,445 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/config/router/routertest"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/cdc"
+	"github.com/akeren/go-api-foundry/pkg/ipfilter"
+	"github.com/akeren/go-api-foundry/pkg/operations"
+	"github.com/akeren/go-api-foundry/pkg/rbac"
+	"github.com/akeren/go-api-foundry/pkg/scaleout"
+	"github.com/akeren/go-api-foundry/pkg/status"
+	"github.com/akeren/go-api-foundry/pkg/webhooks"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRouter(t *testing.T) *router.RouterService {
+	t.Helper()
+	return newTestRouterWithWebhooks(t, webhooks.NewMemoryStore(), nil)
+}
+
+func newTestRouterWithWebhooks(t *testing.T, hooks webhooks.Store, ops *operations.Manager) *router.RouterService {
+	t.Helper()
+	t.Setenv("ADMIN_API_TOKEN", "s3cret")
+
+	logger := log.NewLoggerWithJSONOutput()
+	rs := router.CreateRouterService(logger, nil, &router.RouterConfig{
+		RateLimitRequests: 1000,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+	})
+	dispatcher := webhooks.NewDispatcher(hooks, logger, webhooks.Config{})
+	rs.MountController(NewAdminController(logger, rs, rbac.NewMemoryStore(), status.NewMemoryStore(), dispatcher, ops, nil, nil))
+	return rs
+}
+
+func TestNewAdminController_DisabledWithoutToken(t *testing.T) {
+	t.Setenv("ADMIN_API_TOKEN", "")
+	if NewAdminController(log.NewLoggerWithJSONOutput(), nil, nil, nil, nil, nil, nil, nil) != nil {
+		t.Fatalf("expected admin controller to be disabled without a token")
+	}
+}
+
+func TestAdminBans_RequireToken(t *testing.T) {
+	rs := newTestRouter(t)
+
+	for _, header := range []string{"", "Bearer wrong", "s3cret"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/ip-bans", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("Authorization %q: expected 401, got %d", header, w.Code)
+		}
+	}
+}
+
+func TestAdminBans_BanAndUnban(t *testing.T) {
+	rs := newTestRouter(t)
+
+	do := func(method, path, body, remote string) int {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer s3cret")
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := do(http.MethodPost, "/v1/admin/ip-bans", `{"ip":"nope"}`, "10.0.0.1:1"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid IP, got %d", code)
+	}
+	if code := do(http.MethodPost, "/v1/admin/ip-bans", `{"ip":"192.0.2.9","ttl":"forever"}`, "10.0.0.1:1"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid ttl, got %d", code)
+	}
+	if code := do(http.MethodPost, "/v1/admin/ip-bans", `{"ip":"192.0.2.9","ttl":"10m","reason":"abuse"}`, "10.0.0.1:1"); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if code := do(http.MethodGet, "/v1/admin/ip-bans", "", "192.0.2.9:1"); code != http.StatusForbidden {
+		t.Fatalf("expected banned client to be blocked, got %d", code)
+	}
+	if code := do(http.MethodDelete, "/v1/admin/ip-bans/192.0.2.9", "", "10.0.0.1:1"); code != http.StatusOK {
+		t.Fatalf("expected 200 on unban, got %d", code)
+	}
+	if code := do(http.MethodGet, "/v1/admin/ip-bans", "", "192.0.2.9:1"); code != http.StatusOK {
+		t.Fatalf("expected unbanned client to pass, got %d", code)
+	}
+}
+
+func TestAdminRateLimits(t *testing.T) {
+	t.Setenv("ADMIN_API_TOKEN", "s3cret")
+	logger := log.NewLoggerWithJSONOutput()
+	rs := router.CreateRouterService(logger, nil, &router.RouterConfig{
+		RateLimitRequests: 2,
+		RateLimitWindow:   time.Hour,
+		RequestTimeout:    5 * time.Second,
+	})
+	rs.MountController(NewAdminController(logger, rs, nil, nil, nil, nil, nil, nil))
+
+	do := func(method, path, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w
+	}
+
+	for range 2 {
+		do(http.MethodGet, "/v1/admin/ip-bans", "192.0.2.7:1")
+	}
+	if w := do(http.MethodGet, "/v1/admin/ip-bans", "192.0.2.7:1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the client to be limited, got %d", w.Code)
+	}
+
+	w := do(http.MethodGet, "/v1/admin/rate-limits?ip=192.0.2.7", "10.0.0.1:1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Data []router.RateLimitState `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Scope != "default" || !resp.Data[0].Limited || resp.Data[0].Used != 2 {
+		t.Fatalf("expected the exhausted default limiter, got %+v", resp.Data)
+	}
+
+	for i, query := range []string{"", "?ip=nope", "?ip=192.0.2.7&service=billing", "?user=alice&service=billing"} {
+		if w := do(http.MethodGet, "/v1/admin/rate-limits"+query, fmt.Sprintf("10.0.1.%d:1", i)); w.Code != http.StatusBadRequest {
+			t.Fatalf("query %q: expected 400, got %d", query, w.Code)
+		}
+	}
+
+	if w := do(http.MethodDelete, "/v1/admin/rate-limits?ip=192.0.2.7", "10.0.0.3:1"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on reset, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/admin/ip-bans", "192.0.2.7:1"); w.Code != http.StatusOK {
+		t.Fatalf("expected the reset client to pass, got %d", w.Code)
+	}
+}
+
+func TestAdminRoleBindings(t *testing.T) {
+	rs := newTestRouter(t)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer s3cret")
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/v1/admin/role-bindings", `{"kind":"robot","subject":"user-1","role":"admin"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid kind, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/v1/admin/role-bindings", `{"kind":"user","subject":"user-1","role":"admin"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	w := do(http.MethodGet, "/v1/admin/role-bindings?subject=user-1", "")
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"role":"admin"`)) {
+		t.Fatalf("expected binding in list, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, "/v1/admin/role-bindings?kind=user&subject=user-1&role=admin", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on revoke, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/v1/admin/role-bindings?kind=user&subject=user-1&role=admin", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing binding, got %d", w.Code)
+	}
+}
+
+func TestAdminIncidents(t *testing.T) {
+	rs := newTestRouter(t)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer s3cret")
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/v1/admin/incidents", `{"title":"DB slow","impact":"apocalyptic"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid impact, got %d", w.Code)
+	}
+	w := do(http.MethodPost, "/v1/admin/incidents", `{"title":"DB slow","impact":"major","components":["database"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data status.Incident `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || created.Data.ID == "" {
+		t.Fatalf("expected the created incident, got %s", w.Body.String())
+	}
+
+	w = do(http.MethodPatch, "/v1/admin/incidents/"+created.Data.ID, `{"message":"Failed over to the replica","resolved":true}`)
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"resolved_at"`)) {
+		t.Fatalf("expected the incident resolved, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do(http.MethodGet, "/v1/admin/incidents", "")
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`Failed over to the replica`)) {
+		t.Fatalf("expected the updated incident in the list, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPatch, "/v1/admin/incidents/missing", `{"resolved":true}`); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown incident, got %d", w.Code)
+	}
+}
+
+func TestAdminWebhooks(t *testing.T) {
+	store := webhooks.NewMemoryStore()
+	ops := operations.NewManager(operations.NewMemoryStore(), log.NewLoggerWithJSONOutput(), operations.DefaultConfig())
+	t.Cleanup(func() { ops.Shutdown(context.Background()) })
+	rs := newTestRouterWithWebhooks(t, store, ops)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer s3cret")
+		w := httptest.NewRecorder()
+		rs.GetEngine().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/v1/admin/webhooks", `{"url":"ftp://example.com","event_types":["*"]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a non-HTTP URL, got %d", w.Code)
+	}
+	w := do(http.MethodPost, "/v1/admin/webhooks", `{"url":"https://example.com/hooks","event_types":["transaction.posted"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data webhooks.Endpoint `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || created.Data.Secret == "" || !created.Data.Active {
+		t.Fatalf("expected the created endpoint with its secret, got %s", w.Body.String())
+	}
+	w = do(http.MethodGet, "/v1/admin/webhooks", "")
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte(created.Data.Secret)) {
+		t.Fatalf("expected endpoints listed without secrets, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Seed a delivery that has used up its attempts.
+	ctx := context.Background()
+	now := time.Now().UTC()
+	if err := store.Enqueue(ctx, []webhooks.Delivery{{EndpointID: created.Data.ID, EventID: "evt-1", EventType: "transaction.posted", Payload: []byte(`{}`)}}, now); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	queued, _, _ := store.Deliveries(ctx, webhooks.DeliveryFilter{})
+	failed := queued[0]
+	failed.Status, failed.Attempts, failed.LastError = webhooks.StatusFailed, 8, "endpoint responded with status 502"
+	if err := store.Record(ctx, &failed, webhooks.Attempt{Attempt: 8, StatusCode: 502, AttemptedAt: now}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	if w := do(http.MethodGet, "/v1/admin/webhooks/deliveries?status=lost", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown status, got %d", w.Code)
+	}
+	w = do(http.MethodGet, "/v1/admin/webhooks/deliveries?status=failed", "")
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"total":1`)) {
+		t.Fatalf("expected one failed delivery, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do(http.MethodGet, "/v1/admin/webhooks/deliveries/"+failed.ID, "")
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"attempt_log"`)) {
+		t.Fatalf("expected the delivery with its attempts, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := do(http.MethodPost, "/v1/admin/webhooks/deliveries/"+failed.ID+"/replay", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on replay, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/v1/admin/webhooks/deliveries/"+failed.ID+"/replay", ""); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 replaying a pending delivery, got %d", w.Code)
+	}
+	w = do(http.MethodPost, "/v1/admin/webhooks/deliveries/replay?endpoint_id="+created.Data.ID, "")
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"replayed":0`)) {
+		t.Fatalf("expected nothing left to replay, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Deliver it, then send the endpoint the events of the last hour again.
+	delivered := failed
+	delivered.Status, delivered.Attempts = webhooks.StatusSucceeded, 1
+	if err := store.Record(ctx, &delivered, webhooks.Attempt{Attempt: 1, StatusCode: 200, AttemptedAt: now}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	rangePath := "/v1/admin/webhooks/" + created.Data.ID + "/replay"
+	for _, query := range []string{"", "?from=yesterday", "?from=" + now.Format(time.RFC3339) + "&to=" + now.Add(-time.Hour).Format(time.RFC3339), "?from=" + now.Add(-time.Hour).Format(time.RFC3339) + "&rate=1000"} {
+		if w := do(http.MethodPost, rangePath+query, ""); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %q, got %d", query, w.Code)
+		}
+	}
+	since := "?from=" + now.Add(-time.Hour).Format(time.RFC3339)
+	if w := do(http.MethodPost, "/v1/admin/webhooks/missing/replay"+since, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown endpoint, got %d", w.Code)
+	}
+	w = do(http.MethodPost, rangePath+since, "")
+	if w.Code != http.StatusAccepted || !strings.HasPrefix(w.Header().Get("Location"), "/v1/operations/") {
+		t.Fatalf("expected 202 with the operation's location, got %d: %s", w.Code, w.Body.String())
+	}
+	opID := strings.TrimPrefix(w.Header().Get("Location"), "/v1/operations/")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		op, err := ops.Get(ctx, opID)
+		if err != nil {
+			t.Fatalf("get operation: %v", err)
+		}
+		if op.Status.Done() {
+			if op.Status != operations.StatusSucceeded || op.Progress != 100 || !bytes.Contains(op.Result, []byte(`"replayed":1`)) {
+				t.Fatalf("expected the replay to queue the delivery, got %+v", op)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("replay did not finish")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got, _ := store.Delivery(ctx, failed.ID); got.Status != webhooks.StatusPending || got.Attempts != 0 {
+		t.Fatalf("expected the delivered event pending again, got %+v", got)
+	}
+
+	w = do(http.MethodPatch, "/v1/admin/webhooks/"+created.Data.ID, `{"active":false}`)
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"active":false`)) {
+		t.Fatalf("expected the endpoint disabled, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, "/v1/admin/webhooks/"+created.Data.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on delete, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/v1/admin/webhooks/"+created.Data.ID, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a deleted endpoint, got %d", w.Code)
+	}
+}
+
+type fakeNetwork struct {
+	reloadErr error
+}
+
+func (fakeNetwork) IPFilter() *ipfilter.Filter { return nil }
+
+func (n fakeNetwork) ReloadGeoIP() error { return n.reloadErr }
+
+func (fakeNetwork) RateLimitStates(context.Context, string) ([]router.RateLimitState, error) {
+	return nil, nil
+}
+
+func (fakeNetwork) ResetRateLimit(context.Context, string) error { return nil }
+
+func TestNewAdminController_OptionalRoutes(t *testing.T) {
+	t.Setenv("ADMIN_API_TOKEN", "s3cret")
+	logger := log.NewLoggerWithJSONOutput()
+
+	routes := routertest.Mount(NewAdminController(logger, nil, rbac.NewMemoryStore(), nil, nil, nil, nil, nil))
+	if _, ok := routes.Route(http.MethodGet, "/v1/admin/role-bindings"); !ok {
+		t.Fatalf("expected role binding routes")
+	}
+	if _, ok := routes.Route(http.MethodGet, "/v1/admin/ip-bans"); ok {
+		t.Fatalf("expected no IP ban routes without a network")
+	}
+
+	routes = routertest.Mount(NewAdminController(logger, fakeNetwork{reloadErr: router.ErrGeoIPReloadUnsupported}, nil, nil, nil, nil, nil, nil))
+	if len(routes.Routes()) != 6 {
+		t.Fatalf("expected only the IP ban, GeoIP and rate limit routes, got %d", len(routes.Routes()))
+	}
+
+	result := routes.Serve(t, routertest.Request{Method: http.MethodPost, Path: "/v1/admin/geoip/reload"})
+	routertest.ExpectStatus(t, result, http.StatusConflict)
+}
+
+func TestDescribeCDCHandler(t *testing.T) {
+	t.Setenv("ADMIN_API_TOKEN", "s3cret")
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	changes := cdc.NewInspector(db, cdc.Config{Publication: "ledger_cdc", Slot: "ledger_cdc", Plugin: "pgoutput", Tables: []cdc.Table{{Name: "accounts", Key: []string{"id"}}}})
+	routes := routertest.Mount(NewAdminController(log.NewLoggerWithJSONOutput(), nil, nil, nil, nil, nil, changes, nil))
+
+	var description cdc.Description
+	routertest.DecodeData(t, routes.Serve(t, routertest.Request{Path: "/v1/admin/cdc"}), &description)
+	if description.Publication != "ledger_cdc" || description.Connector["table.include.list"] != "public.accounts" {
+		t.Fatalf("unexpected description %+v", description)
+	}
+}
+
+func TestScaleOutHandler(t *testing.T) {
+	t.Setenv("ADMIN_API_TOKEN", "s3cret")
+	audit := func() scaleout.Report {
+		return scaleout.Audit(2, []scaleout.Component{{Name: "rate_limiter", Backend: "memory", Impact: "limits multiply"}})
+	}
+	routes := routertest.Mount(NewAdminController(log.NewLoggerWithJSONOutput(), nil, nil, nil, nil, nil, nil, audit))
+
+	var report scaleout.Report
+	routertest.DecodeData(t, routes.Serve(t, routertest.Request{Path: "/v1/admin/scale-out"}), &report)
+	if report.Ready || report.Replicas != 2 || len(report.Warnings) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestRoleBindingHandlers(t *testing.T) {
+	t.Setenv("ADMIN_API_TOKEN", "s3cret")
+	routes := routertest.Mount(NewAdminController(log.NewLoggerWithJSONOutput(), nil, rbac.NewMemoryStore(), nil, nil, nil, nil, nil))
+
+	tests := []struct {
+		name   string
+		req    routertest.Request
+		status int
+	}{
+		{"grant", routertest.Request{Method: http.MethodPost, Path: "/v1/admin/role-bindings", Body: RoleBindingRequest{Kind: "user", Subject: "user-1", Role: "admin"}}, http.StatusCreated},
+		{"grant with unknown kind", routertest.Request{Method: http.MethodPost, Path: "/v1/admin/role-bindings", Body: RoleBindingRequest{Kind: "robot", Subject: "user-1", Role: "admin"}}, http.StatusBadRequest},
+		{"grant with malformed body", routertest.Request{Method: http.MethodPost, Path: "/v1/admin/role-bindings", Body: `{"kind":`}, http.StatusBadRequest},
+		{"list", routertest.Request{Path: "/v1/admin/role-bindings?kind=user"}, http.StatusOK},
+		{"revoke without role", routertest.Request{Method: http.MethodDelete, Path: "/v1/admin/role-bindings?kind=user&subject=user-1"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routertest.ExpectStatus(t, routes.Serve(t, tt.req), tt.status)
+		})
+	}
+
+	var bindings []rbac.Binding
+	routertest.DecodeData(t, routes.Serve(t, routertest.Request{Path: "/v1/admin/role-bindings"}), &bindings)
+	if len(bindings) != 1 || bindings[0].Subject != "user-1" {
+		t.Fatalf("expected the granted binding, got %+v", bindings)
+	}
+}
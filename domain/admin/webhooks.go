@@ -0,0 +1,327 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/pkg/operations"
+	"github.com/akeren/go-api-foundry/pkg/webhooks"
+)
+
+const (
+	defaultDeliveriesPerPage = 50
+	maxDeliveriesPerPage     = 200
+
+	operationKindWebhookReplay = "webhooks.replay"
+	// Range replays queue this many deliveries a second by default, so a
+	// receiver back from an outage is not flooded with its backlog.
+	defaultReplayRate = 10
+	maxReplayRate     = 100
+)
+
+// WebhookEndpointRequest registers an endpoint. Active defaults to true;
+// Ordered, for one-at-a-time delivery in event order, to false.
+type WebhookEndpointRequest struct {
+	URL         string   `json:"url" binding:"required,max=2048"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1,max=20,dive,max=128"`
+	Description string   `json:"description" binding:"max=255"`
+	Active      *bool    `json:"active"`
+	Ordered     bool     `json:"ordered"`
+}
+
+// WebhookEndpointUpdateRequest changes the fields that are set.
+type WebhookEndpointUpdateRequest struct {
+	URL         string   `json:"url" binding:"max=2048"`
+	EventTypes  []string `json:"event_types" binding:"omitempty,min=1,max=20,dive,max=128"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Active      *bool    `json:"active"`
+	Ordered     *bool    `json:"ordered"`
+}
+
+// ReplayResult reports how many failed deliveries were replayed.
+type ReplayResult struct {
+	Replayed int64 `json:"replayed"`
+}
+
+func mountWebhookRoutes(rs router.RouteRegistrar, c *router.RESTController, dispatcher *webhooks.Dispatcher, ops *operations.Manager, auth router.MiddlewareFunc) {
+	store := dispatcher.Store()
+
+	rs.AddGetHandler(c, nil, "/webhooks", listWebhookEndpointsHandler(store), auth).Describe(router.OperationDoc{
+		Summary: "List webhook endpoints", Response: []webhooks.Endpoint{},
+	})
+	rs.AddPostHandler(c, nil, "/webhooks", createWebhookEndpointHandler(store), auth).Describe(router.OperationDoc{
+		Summary: "Register a webhook endpoint; the response holds its signing secret", Request: WebhookEndpointRequest{}, Response: webhooks.Endpoint{}, Status: http.StatusCreated,
+	})
+	rs.AddPatchHandler(c, nil, "/webhooks/:id", updateWebhookEndpointHandler(store), auth).Describe(router.OperationDoc{
+		Summary: "Update or disable a webhook endpoint", Request: WebhookEndpointUpdateRequest{}, Response: webhooks.Endpoint{},
+	})
+	rs.AddDeleteHandler(c, nil, "/webhooks/:id", deleteWebhookEndpointHandler(store), auth).Describe(router.OperationDoc{
+		Summary: "Delete a webhook endpoint and its deliveries",
+	})
+	rs.AddGetHandler(c, nil, "/webhooks/deliveries", listWebhookDeliveriesHandler(store), auth).Describe(router.OperationDoc{
+		Summary: "List webhook deliveries, newest first", Response: router.PaginatedResult[webhooks.Delivery]{},
+		Query: []router.QueryParam{
+			{Name: "endpoint_id"},
+			{Name: "status", Description: "pending, succeeded or failed"},
+			{Name: "page", Type: "integer"},
+			{Name: "per_page", Type: "integer"},
+		},
+	})
+	rs.AddGetHandler(c, nil, "/webhooks/deliveries/:id", getWebhookDeliveryHandler(store), auth).Describe(router.OperationDoc{
+		Summary: "Get a webhook delivery with its attempts", Response: webhooks.Delivery{},
+	})
+	rs.AddPostHandler(c, nil, "/webhooks/deliveries/:id/replay", replayWebhookDeliveryHandler(dispatcher), auth).Describe(router.OperationDoc{
+		Summary: "Replay a failed webhook delivery",
+	})
+	rs.AddPostHandler(c, nil, "/webhooks/deliveries/replay", replayFailedWebhooksHandler(dispatcher), auth).Describe(router.OperationDoc{
+		Summary: "Replay every failed webhook delivery", Response: ReplayResult{},
+		Query: []router.QueryParam{{Name: "endpoint_id", Description: "Only replay this endpoint's deliveries"}},
+	})
+	if ops != nil {
+		rs.AddPostHandler(c, nil, "/webhooks/:id/replay", replayWebhookRangeHandler(dispatcher, ops), auth).Describe(router.OperationDoc{
+			Summary:     "Send a webhook endpoint the events of a time range again",
+			Description: "Re-queues the endpoint's deliveries of events that occurred in [from, to), oldest first and at most rate a second, whatever their state. Runs as an operation; poll its Location for progress.",
+			Response:    operations.Operation{}, Status: http.StatusAccepted,
+			Query: []router.QueryParam{
+				{Name: "from", Required: true, Description: "RFC 3339 time of the first event"},
+				{Name: "to", Description: "RFC 3339 time events occurred before; default now"},
+				{Name: "rate", Type: "integer", Description: "Deliveries queued a second, up to 100; default 10"},
+			},
+		})
+	}
+}
+
+func listWebhookEndpointsHandler(store webhooks.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		endpoints, err := store.Endpoints(ctx.Request.Context())
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to list webhook endpoints", "error", err)
+			return router.InternalServerErrorResult("Failed to list webhook endpoints")
+		}
+		for i := range endpoints {
+			endpoints[i].Secret = ""
+		}
+		return router.OKResult(endpoints, "Webhook endpoints retrieved successfully")
+	}
+}
+
+func createWebhookEndpointHandler(store webhooks.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		req, errResult := router.BindJSON[WebhookEndpointRequest](ctx)
+		if errResult != nil {
+			return errResult
+		}
+
+		endpoint := &webhooks.Endpoint{
+			URL:         req.URL,
+			EventTypes:  req.EventTypes,
+			Description: req.Description,
+			Active:      req.Active == nil || *req.Active,
+			Ordered:     req.Ordered,
+		}
+		if err := store.CreateEndpoint(ctx.Request.Context(), endpoint); err != nil {
+			if errors.Is(err, webhooks.ErrInvalidEndpoint) {
+				return router.BadRequestResult(err.Error(), nil)
+			}
+			router.GetLogger(ctx).Error("Failed to create webhook endpoint", "error", err)
+			return router.InternalServerErrorResult("Failed to create webhook endpoint")
+		}
+
+		router.GetLogger(ctx).Warn("Webhook endpoint registered by operator", "id", endpoint.ID, "url", endpoint.URL, "event_types", endpoint.EventTypes)
+		return router.CreatedResult(endpoint, "Webhook endpoint")
+	}
+}
+
+func updateWebhookEndpointHandler(store webhooks.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		req, errResult := router.BindJSON[WebhookEndpointUpdateRequest](ctx)
+		if errResult != nil {
+			return errResult
+		}
+
+		endpoint, err := store.Endpoint(ctx.Request.Context(), ctx.Param("id"))
+		if errors.Is(err, webhooks.ErrNotFound) {
+			return router.NotFoundResult("Webhook endpoint not found")
+		}
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to load webhook endpoint", "error", err)
+			return router.InternalServerErrorResult("Failed to update webhook endpoint")
+		}
+
+		if req.URL != "" {
+			endpoint.URL = req.URL
+		}
+		if req.EventTypes != nil {
+			endpoint.EventTypes = req.EventTypes
+		}
+		if req.Description != nil {
+			endpoint.Description = *req.Description
+		}
+		if req.Active != nil {
+			endpoint.Active = *req.Active
+		}
+		if req.Ordered != nil {
+			endpoint.Ordered = *req.Ordered
+		}
+
+		if err := store.UpdateEndpoint(ctx.Request.Context(), endpoint); err != nil {
+			if errors.Is(err, webhooks.ErrInvalidEndpoint) {
+				return router.BadRequestResult(err.Error(), nil)
+			}
+			if errors.Is(err, webhooks.ErrNotFound) {
+				return router.NotFoundResult("Webhook endpoint not found")
+			}
+			router.GetLogger(ctx).Error("Failed to update webhook endpoint", "error", err)
+			return router.InternalServerErrorResult("Failed to update webhook endpoint")
+		}
+
+		router.GetLogger(ctx).Warn("Webhook endpoint updated by operator", "id", endpoint.ID, "active", endpoint.Active, "ordered", endpoint.Ordered)
+		endpoint.Secret = ""
+		return router.OKResult(endpoint, "Webhook endpoint updated successfully")
+	}
+}
+
+func deleteWebhookEndpointHandler(store webhooks.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if err := store.DeleteEndpoint(ctx.Request.Context(), id); err != nil {
+			if errors.Is(err, webhooks.ErrNotFound) {
+				return router.NotFoundResult("Webhook endpoint not found")
+			}
+			router.GetLogger(ctx).Error("Failed to delete webhook endpoint", "error", err, "id", id)
+			return router.InternalServerErrorResult("Failed to delete webhook endpoint")
+		}
+
+		router.GetLogger(ctx).Warn("Webhook endpoint deleted by operator", "id", id)
+		return router.OKResult(nil, "Webhook endpoint removed")
+	}
+}
+
+func listWebhookDeliveriesHandler(store webhooks.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		status := webhooks.Status(strings.ToUpper(ctx.Query("status")))
+		switch status {
+		case "", webhooks.StatusPending, webhooks.StatusSucceeded, webhooks.StatusFailed:
+		default:
+			return router.BadRequestResult("status must be pending, succeeded or failed", nil)
+		}
+
+		page := router.ParsePagination(ctx, defaultDeliveriesPerPage, maxDeliveriesPerPage)
+		deliveries, total, err := store.Deliveries(ctx.Request.Context(), webhooks.DeliveryFilter{
+			EndpointID: ctx.Query("endpoint_id"),
+			Status:     status,
+			Offset:     page.Offset,
+			Limit:      page.PerPage,
+		})
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to list webhook deliveries", "error", err)
+			return router.InternalServerErrorResult("Failed to list webhook deliveries")
+		}
+		result := router.NewPaginatedResult(ctx, deliveries, page, total)
+		return router.OKResult(result, "Webhook deliveries retrieved successfully").WithLinkHeader(result.Links)
+	}
+}
+
+func getWebhookDeliveryHandler(store webhooks.Store) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		delivery, err := store.Delivery(ctx.Request.Context(), ctx.Param("id"))
+		if errors.Is(err, webhooks.ErrNotFound) {
+			return router.NotFoundResult("Webhook delivery not found")
+		}
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to load webhook delivery", "error", err)
+			return router.InternalServerErrorResult("Failed to load webhook delivery")
+		}
+		return router.OKResult(delivery, "Webhook delivery retrieved successfully")
+	}
+}
+
+func replayWebhookDeliveryHandler(dispatcher *webhooks.Dispatcher) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if err := dispatcher.Replay(ctx.Request.Context(), id); err != nil {
+			if errors.Is(err, webhooks.ErrNotFound) {
+				return router.NotFoundResult("Webhook delivery not found")
+			}
+			if errors.Is(err, webhooks.ErrNotFailed) {
+				return router.ErrorResult(http.StatusConflict, err.Error(), nil)
+			}
+			router.GetLogger(ctx).Error("Failed to replay webhook delivery", "error", err, "id", id)
+			return router.InternalServerErrorResult("Failed to replay webhook delivery")
+		}
+
+		router.GetLogger(ctx).Info("Webhook delivery replayed by operator", "id", id)
+		return router.OKResult(nil, "Webhook delivery queued for replay")
+	}
+}
+
+func replayFailedWebhooksHandler(dispatcher *webhooks.Dispatcher) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		endpointID := ctx.Query("endpoint_id")
+		n, err := dispatcher.ReplayFailed(ctx.Request.Context(), endpointID)
+		if err != nil {
+			router.GetLogger(ctx).Error("Failed to replay webhook deliveries", "error", err, "endpoint_id", endpointID)
+			return router.InternalServerErrorResult("Failed to replay webhook deliveries")
+		}
+
+		router.GetLogger(ctx).Info("Failed webhook deliveries replayed by operator", "endpoint_id", endpointID, "replayed", n)
+		return router.OKResult(ReplayResult{Replayed: n}, "Failed webhook deliveries queued for replay")
+	}
+}
+
+func replayWebhookRangeHandler(dispatcher *webhooks.Dispatcher, ops *operations.Manager) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		r := webhooks.ReplayRange{EndpointID: ctx.Param("id"), To: time.Now().UTC()}
+		from, err := time.Parse(time.RFC3339, ctx.Query("from"))
+		if err != nil {
+			return router.BadRequestResult("from must be an RFC 3339 time", nil)
+		}
+		r.From = from
+		if v := ctx.Query("to"); v != "" {
+			if r.To, err = time.Parse(time.RFC3339, v); err != nil {
+				return router.BadRequestResult("to must be an RFC 3339 time", nil)
+			}
+		}
+		if !r.To.After(r.From) {
+			return router.BadRequestResult("to must be after from", nil)
+		}
+		rate := defaultReplayRate
+		if v := ctx.Query("rate"); v != "" {
+			if rate, err = strconv.Atoi(v); err != nil || rate < 1 || rate > maxReplayRate {
+				return router.BadRequestResult("rate must be between 1 and 100", nil)
+			}
+		}
+
+		if _, err := dispatcher.Store().Endpoint(ctx.Request.Context(), r.EndpointID); err != nil {
+			if errors.Is(err, webhooks.ErrNotFound) {
+				return router.NotFoundResult("Webhook endpoint not found")
+			}
+			router.GetLogger(ctx).Error("Failed to load webhook endpoint", "error", err, "id", r.EndpointID)
+			return router.InternalServerErrorResult("Failed to load webhook endpoint")
+		}
+
+		op, err := ops.Start(ctx.Request.Context(), operationKindWebhookReplay, "",
+			func(opCtx context.Context, progress *operations.Progress) (any, error) {
+				return dispatcher.ReplayRange(opCtx, r, rate, func(p webhooks.ReplayProgress) {
+					if p.Total > 0 {
+						progress.Set(opCtx, int(min(p.Done, p.Total)*100/p.Total))
+					}
+				})
+			})
+		if err != nil {
+			if errors.Is(err, operations.ErrShuttingDown) {
+				return router.ErrorResult(http.StatusServiceUnavailable, "Server is shutting down; retry shortly", nil)
+			}
+			router.GetLogger(ctx).Error("Failed to start webhook replay", "error", err, "id", r.EndpointID)
+			return router.InternalServerErrorResult("Failed to start webhook replay")
+		}
+
+		router.GetLogger(ctx).Info("Webhook endpoint replay started by operator", "id", r.EndpointID, "from", r.From, "to", r.To, "rate", rate, "operation_id", op.ID)
+		return router.AcceptedResult(op, operations.Location(op.ID))
+	}
+}
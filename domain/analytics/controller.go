@@ -0,0 +1,125 @@
+// Package analytics accepts batches of product analytics events from clients
+// and hands them to the buffer that writes them to the analytics sink.
+package analytics
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/pkg/analytics"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/region"
+)
+
+// retryAfterSeconds is how long clients wait after a 503 while the sink
+// catches up.
+const retryAfterSeconds = "5"
+
+type EventRequest struct {
+	// ID deduplicates retried sends; one is assigned when it is empty.
+	ID          string         `json:"id" binding:"omitempty,max=64"`
+	Name        string         `json:"name" binding:"required,max=100"`
+	AnonymousID string         `json:"anonymous_id" binding:"omitempty,max=128"`
+	UserID      string         `json:"user_id" binding:"omitempty,max=128"`
+	Properties  map[string]any `json:"properties"`
+	// Timestamp is when the event happened on the client; the time it is
+	// received when empty.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type IngestRequest struct {
+	Events []EventRequest `json:"events" binding:"required,min=1,max=100,dive"`
+}
+
+// Rejection is an event that failed its schema, by its index in the request.
+type Rejection struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+type IngestResponse struct {
+	Accepted int         `json:"accepted"`
+	Rejected []Rejection `json:"rejected"`
+}
+
+// NewAnalyticsController mounts POST /v1/analytics/events. Events that fail
+// their schema are reported back and the rest are accepted; the request
+// fails only when none is valid or the buffer is full.
+func NewAnalyticsController(schemas *analytics.Registry, buffer *analytics.Buffer) *router.RESTController {
+	// Events carry their own IDs for deduplication, so idempotency keys
+	// would only add a write per request.
+	return router.NewVersionedRESTController(
+		"AnalyticsController",
+		"v1",
+		"/analytics",
+		func(rs router.RouteRegistrar, c *router.RESTController) {
+			rs.AddPostHandler(c, nil, "/events", ingestHandler(schemas, buffer)).Describe(router.OperationDoc{
+				Summary: "Send analytics events",
+				Description: "Accepts up to 100 events, each checked against the schema registered for its name, " +
+					"and writes them to the analytics sink asynchronously. Invalid events are listed in rejected.",
+				Request:  IngestRequest{},
+				Response: IngestResponse{},
+				Status:   http.StatusAccepted,
+			})
+		},
+	).Idempotency(router.IdempotencyOptions{Disabled: true})
+}
+
+func ingestHandler(schemas *analytics.Registry, buffer *analytics.Buffer) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		req, errResult := router.BindJSON[IngestRequest](ctx)
+		if errResult != nil {
+			return errResult
+		}
+
+		receivedAt := time.Now().UTC()
+		subject := router.GetSubject(ctx)
+		accepted := make([]analytics.Event, 0, len(req.Events))
+		response := IngestResponse{Rejected: []Rejection{}}
+		for i, e := range req.Events {
+			event := analytics.Event{
+				ID:          e.ID,
+				Name:        e.Name,
+				AnonymousID: e.AnonymousID,
+				UserID:      e.UserID,
+				Properties:  e.Properties,
+				Timestamp:   e.Timestamp.UTC(),
+				ReceivedAt:  receivedAt,
+			}
+			if event.ID == "" {
+				event.ID = region.NewID()
+			}
+			// An authenticated caller cannot send events as someone else.
+			if subject != "" {
+				event.UserID = subject
+			}
+			if e.Timestamp.IsZero() {
+				event.Timestamp = receivedAt
+			}
+			if err := schemas.Validate(event); err != nil {
+				response.Rejected = append(response.Rejected, Rejection{Index: i, ID: e.ID, Error: err.Error()})
+				continue
+			}
+			accepted = append(accepted, event)
+		}
+
+		if len(accepted) == 0 {
+			return router.BadRequestResult("No event matches its schema", response)
+		}
+		if err := buffer.Add(accepted...); err != nil {
+			if errors.Is(err, analytics.ErrBufferFull) || errors.Is(err, analytics.ErrStopped) {
+				router.GetLogger(ctx).Warn("Refused analytics events", "count", len(accepted), "error", err)
+				return router.ErrorResult(apperrors.StatusServiceUnavailable, "Analytics ingestion is busy; retry later", nil).
+					WithHeader("Retry-After", retryAfterSeconds)
+			}
+			router.GetLogger(ctx).Error("Failed to buffer analytics events", "error", err)
+			return router.InternalServerErrorResult("Failed to accept events")
+		}
+
+		response.Accepted = len(accepted)
+		return &router.ServiceResult{StatusCode: http.StatusAccepted, Data: response, Message: "Events accepted"}
+	}
+}
@@ -0,0 +1,123 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/analytics"
+)
+
+type memorySink struct {
+	mu     sync.Mutex
+	events []analytics.Event
+}
+
+func (s *memorySink) Write(_ context.Context, events []analytics.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func newTestRouter(t *testing.T, capacity int) (*router.RouterService, *analytics.Buffer, *memorySink) {
+	t.Helper()
+	logger := log.NewLoggerWithJSONOutput()
+
+	schemas, err := analytics.NewRegistry(analytics.Schema{
+		Name:       "signup",
+		Properties: map[string]analytics.Property{"plan": {Type: analytics.TypeString, Required: true}},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	sink := &memorySink{}
+	buffer := analytics.NewBuffer(sink, logger, analytics.BufferConfig{Capacity: capacity, FlushInterval: time.Hour})
+	t.Cleanup(func() { buffer.Stop(context.Background()) })
+
+	rs := router.CreateRouterService(logger, nil, &router.RouterConfig{
+		RateLimitRequests: 1000,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+	})
+	rs.MountController(NewAnalyticsController(schemas, buffer))
+	return rs, buffer, sink
+}
+
+func post(rs *router.RouterService, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/analytics/events", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	return w
+}
+
+func TestIngestEvents(t *testing.T) {
+	rs, buffer, sink := newTestRouter(t, 100)
+
+	w := post(rs, `{"events": [
+		{"id": "e1", "name": "signup", "anonymous_id": "anon-1", "properties": {"plan": "team"}, "timestamp": "2026-03-01T09:00:00Z"},
+		{"id": "e2", "name": "signup", "properties": {"plan": 3}},
+		{"name": "signup", "properties": {"plan": "solo"}}
+	]}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data IngestResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Data.Accepted != 2 || len(resp.Data.Rejected) != 1 || resp.Data.Rejected[0].Index != 1 || resp.Data.Rejected[0].ID != "e2" {
+		t.Fatalf("unexpected response %+v", resp.Data)
+	}
+
+	buffer.Flush(context.Background())
+	if len(sink.events) != 2 {
+		t.Fatalf("sink has %d events, want 2", len(sink.events))
+	}
+	first, second := sink.events[0], sink.events[1]
+	if first.ID != "e1" || first.AnonymousID != "anon-1" || !first.Timestamp.Equal(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)) || first.ReceivedAt.IsZero() {
+		t.Fatalf("unexpected first event %+v", first)
+	}
+	if second.ID == "" || !second.Timestamp.Equal(second.ReceivedAt) {
+		t.Fatalf("expected an assigned ID and the received time, got %+v", second)
+	}
+}
+
+func TestIngestEvents_RejectsBatchWithNoValidEvent(t *testing.T) {
+	rs, buffer, _ := newTestRouter(t, 100)
+
+	w := post(rs, `{"events": [{"name": "unknown"}]}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "is not registered") {
+		t.Fatalf("expected 400 naming the problem, got %d: %s", w.Code, w.Body.String())
+	}
+	if w = post(rs, `{"events": []}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an empty batch, got %d", w.Code)
+	}
+	if buffer.Len() != 0 {
+		t.Fatalf("buffer has %d events, want none", buffer.Len())
+	}
+}
+
+func TestIngestEvents_BufferFull(t *testing.T) {
+	rs, _, _ := newTestRouter(t, 1)
+
+	event := `{"events": [{"name": "signup", "properties": {"plan": "team"}}]}`
+	if w := post(rs, event); w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	w := post(rs, event)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 503 with Retry-After, got %d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,392 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const (
+	defaultAccrualInterval = time.Hour
+
+	// daysInYear is the day count of every accrual: a day accrues 1/365 of
+	// the annual rate, leap years included.
+	daysInYear = 365
+)
+
+// accrualAmount is one day's accrual on basis at rateBasisPoints a year,
+// rounded half up to a minor unit. Nothing accrues on a basis of zero or
+// less.
+func accrualAmount(basis, rateBasisPoints int64) int64 {
+	if basis <= 0 || rateBasisPoints <= 0 {
+		return 0
+	}
+	const divisor = 10000 * daysInYear
+	// Split the basis so basis*rate cannot overflow.
+	whole, rest := basis/divisor, basis%divisor
+	return whole*rateBasisPoints + (rest*rateBasisPoints+divisor/2)/divisor
+}
+
+// accrualCommand posts record: interest from the system account to the
+// plan's account, a fee the other way.
+func accrualCommand(plan *models.AccrualPlan, record *models.AccrualRecord) DoubleEntryCommand {
+	cmd := DoubleEntryCommand{
+		SourceAccountID: models.SystemAccountID,
+		DestAccountID:   plan.AccountID,
+		Amount:          record.Amount,
+		Currency:        record.Currency,
+		TransactionType: models.TransactionTypeAccrual,
+		IdempotencyKey:  accrualIdempotencyKey(record),
+		Description:     accrualDescription(record),
+	}
+	if plan.Kind == models.AccrualKindFee {
+		cmd.SourceAccountID, cmd.DestAccountID = plan.AccountID, models.SystemAccountID
+	}
+	return cmd
+}
+
+// accrualIdempotencyKey names the posting of one revision of a plan's day,
+// so however often a run repeats, the day is posted once.
+func accrualIdempotencyKey(record *models.AccrualRecord) string {
+	return fmt.Sprintf("accrual:%s:%s:%d", record.PlanID, record.Day.Format(time.DateOnly), record.Revision)
+}
+
+func accrualDescription(record *models.AccrualRecord) string {
+	what := "Interest"
+	if record.Kind == models.AccrualKindFee {
+		what = "Fee"
+	}
+	return fmt.Sprintf("%s for %s at %d bps", what, record.Day.Format(time.DateOnly), record.RateBasisPoints)
+}
+
+// utcToday is the current UTC day.
+func utcToday() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// CreateAccrualPlan starts accruing interest onto a user account, or
+// charging it a fee, from StartsOn. A plan may start in the past, though not
+// before the account was opened; its past days accrue on the next run.
+func (s *ledgerService) CreateAccrualPlan(ctx context.Context, accountID string, req *CreateAccrualPlanRequest) (*AccrualPlanResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if req == nil {
+		logger.Error("CreateAccrualPlan received nil request")
+		return nil, apperrors.NewInvalidRequestError("request cannot be nil", nil)
+	}
+	if accountID == "" {
+		logger.Error("CreateAccrualPlan received empty account ID")
+		return nil, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
+	if models.IsSystemAccountID(accountID) {
+		return nil, ErrSystemAccountForbidden
+	}
+
+	account, err := s.repository.GetAccountByID(ctx, accountID)
+	if err != nil {
+		logger.Error("Failed to verify account for accrual plan", "id", accountID, "error", err)
+		return nil, err
+	}
+
+	startsOn := utcToday()
+	if req.StartsOn != "" {
+		if startsOn, err = time.Parse(time.DateOnly, req.StartsOn); err != nil {
+			return nil, apperrors.NewInvalidRequestError("starts_on must be a date, 2006-01-02", err)
+		}
+	}
+	if startsOn.Before(account.CreatedAt.UTC().Truncate(24 * time.Hour)) {
+		return nil, apperrors.NewInvalidRequestError("starts_on cannot be before the account was opened", nil)
+	}
+
+	plan, err := s.repository.CreateAccrualPlan(ctx, &models.AccrualPlan{
+		AccountID:       accountID,
+		Kind:            req.Kind,
+		RateBasisPoints: req.RateBasisPoints,
+		StartsOn:        startsOn,
+	})
+	if err != nil {
+		logger.Error("Failed to create accrual plan", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	logger.Info("Accrual plan created", "plan_id", plan.ID, "account_id", accountID, "kind", plan.Kind)
+	resp := ToAccrualPlanResponse(plan)
+	return &resp, nil
+}
+
+func (s *ledgerService) ListAccrualPlans(ctx context.Context, accountID string) ([]AccrualPlanResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if accountID == "" {
+		logger.Error("ListAccrualPlans received empty account ID")
+		return nil, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
+	if _, err := s.repository.GetAccountByID(ctx, accountID); err != nil {
+		logger.Error("Failed to verify account for accrual plans", "id", accountID, "error", err)
+		return nil, err
+	}
+
+	plans, err := s.repository.ListAccrualPlans(ctx, accountID)
+	if err != nil {
+		logger.Error("Failed to list accrual plans", "account_id", accountID, "error", err)
+		return nil, err
+	}
+	resp := make([]AccrualPlanResponse, 0, len(plans))
+	for i := range plans {
+		resp = append(resp, ToAccrualPlanResponse(&plans[i]))
+	}
+	return resp, nil
+}
+
+// UpdateAccrualPlan changes a plan's rate from EffectiveFrom on. Days before
+// it that are still to accrue accrue at the old rate first. Days from it
+// that already accrued are corrected: each is recalculated, and a changed
+// amount posted and the original posting reversed. A correction that fails,
+// such as a reversal the account cannot fund, fails the update after the
+// rate has changed; repeating the request resumes it, since days already
+// corrected stand.
+func (s *ledgerService) UpdateAccrualPlan(ctx context.Context, accountID, planID string, req *UpdateAccrualPlanRequest) (*AccrualPlanResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if req == nil || req.RateBasisPoints == nil {
+		logger.Error("UpdateAccrualPlan received nil request")
+		return nil, apperrors.NewInvalidRequestError("rate_basis_points is required", nil)
+	}
+
+	plan, err := s.repository.GetAccrualPlan(ctx, accountID, planID)
+	if err != nil {
+		logger.Error("Failed to get accrual plan", "plan_id", planID, "error", err)
+		return nil, err
+	}
+
+	today := utcToday()
+	effective := today
+	if req.EffectiveFrom != "" {
+		if effective, err = time.Parse(time.DateOnly, req.EffectiveFrom); err != nil {
+			return nil, apperrors.NewInvalidRequestError("effective_from must be a date, 2006-01-02", err)
+		}
+	}
+	switch {
+	case effective.After(today):
+		return nil, apperrors.NewInvalidRequestError("effective_from cannot be in the future", nil)
+	case effective.Before(plan.StartsOn.UTC()):
+		return nil, apperrors.NewInvalidRequestError("effective_from cannot be before the plan starts", nil)
+	case today.Sub(effective) >= maxReportDays*24*time.Hour:
+		return nil, apperrors.NewInvalidRequestError("effective_from can be at most 366 days ago", nil)
+	}
+
+	if _, err := s.accruePlan(ctx, plan.ID, effective.AddDate(0, 0, -1)); err != nil {
+		logger.Error("Failed to accrue plan before its rate changed", "plan_id", plan.ID, "error", err)
+		return nil, err
+	}
+	updated, err := s.repository.UpdateAccrualPlanRate(ctx, accountID, plan.ID, *req.RateBasisPoints)
+	if err != nil {
+		logger.Error("Failed to update accrual plan", "plan_id", plan.ID, "error", err)
+		return nil, err
+	}
+
+	resp := ToAccrualPlanResponse(updated)
+	if updated.AccruedThrough != nil {
+		for day := effective; !day.After(updated.AccruedThrough.UTC()); day = day.AddDate(0, 0, 1) {
+			posting, err := s.repository.CorrectAccrual(ctx, plan.ID, day)
+			if err != nil {
+				logger.Error("Failed to correct accrual", "plan_id", plan.ID, "day", day.Format(time.DateOnly), "error", err)
+				return nil, err
+			}
+			if posting != nil {
+				s.publishAccrual(ctx, posting)
+				resp.Corrections = append(resp.Corrections, ToAccrualRecordResponse(posting.Record))
+			}
+		}
+	}
+
+	logger.Info("Accrual plan updated", "plan_id", plan.ID, "rate_basis_points", updated.RateBasisPoints,
+		"effective_from", effective.Format(time.DateOnly), "corrections", len(resp.Corrections))
+	return &resp, nil
+}
+
+// ListAccrualRecords reports the account's accrual calculations for the UTC
+// days from and to, inclusive, superseded revisions included.
+func (s *ledgerService) ListAccrualRecords(ctx context.Context, accountID string, from, to time.Time) (*AccrualRecordsResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if accountID == "" {
+		logger.Error("ListAccrualRecords received empty account ID")
+		return nil, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
+	if err := checkReportRange(from, to); err != nil {
+		return nil, err
+	}
+	if _, err := s.repository.GetAccountByID(ctx, accountID); err != nil {
+		logger.Error("Failed to verify account for accrual records", "id", accountID, "error", err)
+		return nil, err
+	}
+
+	records, err := s.repository.ListAccrualRecords(ctx, accountID, from, to)
+	if err != nil {
+		logger.Error("Failed to list accrual records", "account_id", accountID, "error", err)
+		return nil, err
+	}
+	resp := &AccrualRecordsResponse{
+		AccountID: accountID,
+		From:      from.Format(time.DateOnly),
+		To:        to.Format(time.DateOnly),
+		Records:   make([]AccrualRecordResponse, 0, len(records)),
+	}
+	for i := range records {
+		resp.Records = append(resp.Records, ToAccrualRecordResponse(&records[i]))
+	}
+	return resp, nil
+}
+
+// AccrueDueDays accrues every plan's days through the given day, oldest
+// first, and reports how many it accrued. A plan that fails is logged and
+// left for the next run, which resumes at its first day not accrued; the
+// other plans carry on.
+func (s *ledgerService) AccrueDueDays(ctx context.Context, through time.Time) (int, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	plans, err := s.repository.ListDueAccrualPlans(ctx, through)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	var failures []error
+	for _, plan := range plans {
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+		accrued, err := s.accruePlan(ctx, plan.ID, through)
+		total += accrued
+		if err != nil {
+			logger.Warn("Accrual failed", "plan_id", plan.ID, "account_id", plan.AccountID, "error", err)
+			failures = append(failures, err)
+		}
+	}
+	return total, errors.Join(failures...)
+}
+
+// accruePlan accrues the plan's days through the given day and reports how
+// many it accrued.
+func (s *ledgerService) accruePlan(ctx context.Context, planID string, through time.Time) (int, error) {
+	accrued := 0
+	for {
+		posting, err := s.repository.AccrueNextDay(ctx, planID, through)
+		if err != nil || posting == nil {
+			return accrued, err
+		}
+		s.publishAccrual(ctx, posting)
+		accrued++
+	}
+}
+
+// publishAccrual announces what accruing or correcting a day posted.
+func (s *ledgerService) publishAccrual(ctx context.Context, posting *AccrualPosting) {
+	if posting.Transaction != nil {
+		s.publishTransactionPosted(ctx, posting.SourceAccountID, posting.DestAccountID, posting.Transaction)
+	}
+	if posting.Reversal != nil {
+		s.publishTransactionPosted(ctx, posting.Reversal.SourceAccountID, posting.Reversal.DestAccountID, posting.Reversal.Transaction)
+	}
+}
+
+// AccrualIntervalFromEnv reads LEDGER_ACCRUAL_INTERVAL (default one hour).
+func AccrualIntervalFromEnv(logger *log.Logger) time.Duration {
+	raw := utils.GetEnvTrimmed("LEDGER_ACCRUAL_INTERVAL")
+	if raw == "" {
+		return defaultAccrualInterval
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval <= 0 {
+		logger.Warn("Invalid duration; using default", "name", "LEDGER_ACCRUAL_INTERVAL", "value", raw, "default", defaultAccrualInterval)
+		return defaultAccrualInterval
+	}
+	return interval
+}
+
+// Accruer accrues every plan's finished days when it starts and every
+// interval after. A day is accrued once, whichever instance gets to it, so
+// every instance may run one.
+type Accruer struct {
+	logger   *log.Logger
+	service  LedgerService
+	interval time.Duration
+
+	mu      sync.Mutex
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewAccruer(logger *log.Logger, service LedgerService, interval time.Duration) *Accruer {
+	if interval <= 0 {
+		interval = defaultAccrualInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Accruer{
+		logger:   logger,
+		service:  service,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start accrues now and then every interval until Stop.
+func (a *Accruer) Start() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.started {
+		return
+	}
+	a.started = true
+	go func() {
+		defer close(a.done)
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+		for {
+			_, _ = a.Run(a.ctx)
+			select {
+			case <-ticker.C:
+			case <-a.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the runs and waits for one in flight, which stops after the
+// plan it is accruing.
+func (a *Accruer) Stop() {
+	a.mu.Lock()
+	started := a.started
+	a.mu.Unlock()
+
+	a.cancel()
+	if started {
+		<-a.done
+	}
+}
+
+// Run accrues every plan's days through yesterday, the last finished UTC
+// day, and reports how many it accrued.
+func (a *Accruer) Run(ctx context.Context) (int, error) {
+	accrued, err := a.service.AccrueDueDays(ctx, utcToday().AddDate(0, 0, -1))
+	if err != nil && a.ctx.Err() == nil {
+		a.logger.Warn("Accrual run failed", "accrued", accrued, "error", err)
+	}
+	if accrued > 0 {
+		a.logger.Info("Accrued plan days", "count", accrued)
+	}
+	return accrued, err
+}
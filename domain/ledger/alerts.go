@@ -0,0 +1,86 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/mailer"
+)
+
+// AlertMailer emails the address of every balance alert that has one when it
+// triggers. Its Handle method subscribes to EventBalanceAlertTriggered.
+type AlertMailer struct {
+	logger *log.Logger
+	repo   LedgerRepository
+	queue  *mailer.Queue
+}
+
+func NewAlertMailer(logger *log.Logger, repo LedgerRepository, queue *mailer.Queue) *AlertMailer {
+	return &AlertMailer{logger: logger, repo: repo, queue: queue}
+}
+
+// Handle queues the email of the trigger an EventBalanceAlertTriggered names.
+// The address is read from the alert rather than carried by the event, and
+// an alert deleted since it triggered is not emailed. Each trigger is
+// claimed before its email is queued, so replayed events send nothing; an
+// email that then fails is logged, not retried.
+func (m *AlertMailer) Handle(ctx context.Context, event events.Event) {
+	triggered, ok := event.Data.(BalanceAlertTriggeredEvent)
+	if !ok {
+		return
+	}
+	alert, err := m.repo.GetBalanceAlert(ctx, triggered.AlertID)
+	if errors.Is(err, ErrBalanceAlertNotFound) {
+		return
+	}
+	if err != nil {
+		m.logger.Error("Failed to read balance alert", "alert_id", triggered.AlertID, "error", err)
+		return
+	}
+	if alert.NotifyEmail == "" {
+		return
+	}
+
+	claimed, err := m.repo.ClaimAlertNotification(ctx, triggered.TriggerID)
+	if err != nil {
+		m.logger.Error("Failed to claim balance alert email", "trigger_id", triggered.TriggerID, "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	msg := alertMessage(triggered)
+	msg.To = []string{alert.NotifyEmail}
+	err = m.queue.Enqueue(msg, func(err error) {
+		if err != nil {
+			m.logger.Error("Failed to send balance alert email", "trigger_id", triggered.TriggerID, "error", err)
+		}
+	})
+	if err != nil {
+		m.logger.Error("Failed to queue balance alert email", "trigger_id", triggered.TriggerID, "error", err)
+	}
+}
+
+// alertMessage is the email of a triggered alert, without recipients.
+func alertMessage(t BalanceAlertTriggeredEvent) mailer.Message {
+	balance := formatAmount(t.Balance, t.Currency) + " " + t.Currency
+	threshold := formatAmount(t.Threshold, t.Currency) + " " + t.Currency
+	var msg mailer.Message
+	switch t.Kind {
+	case models.AlertKindLargeDebit:
+		msg.Subject = "Large debit on account " + t.AccountID
+		msg.Text = fmt.Sprintf("Account %s was debited %s %s, at or above your alert threshold of %s.\nThe balance is now %s.\n",
+			t.AccountID, formatAmount(t.Amount, t.Currency), t.Currency, threshold, balance)
+	default:
+		msg.Subject = "Low balance on account " + t.AccountID
+		msg.Text = fmt.Sprintf("The balance of account %s fell below your alert threshold of %s.\nThe balance is now %s.\n",
+			t.AccountID, threshold, balance)
+	}
+	msg.Text += fmt.Sprintf("\nTransaction: %s\nAt: %s\n", t.TransactionID, t.TriggeredAt.UTC().Format("2006-01-02 15:04:05 MST"))
+	return msg
+}
@@ -0,0 +1,73 @@
+package ledger
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (s *recordingSender) Send(_ context.Context, msg mailer.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestAlertMailer_EmailsEachTriggerOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := NewMockLedgerRepository(ctrl)
+	sender := &recordingSender{}
+	queue := mailer.NewQueue(sender, log.NewLoggerWithJSONOutput(), mailer.QueueConfig{})
+	queue.Start()
+	alerts := NewAlertMailer(log.NewLoggerWithJSONOutput(), mockRepo, queue)
+
+	event := events.Event{Type: EventBalanceAlertTriggered, Data: BalanceAlertTriggeredEvent{
+		TriggerID:     "trigger-1",
+		AlertID:       "alert-1",
+		AccountID:     "acc-1",
+		TransactionID: "txn-1",
+		Kind:          models.AlertKindLargeDebit,
+		Threshold:     50000,
+		Amount:        125000,
+		Balance:       2500,
+		Currency:      "USD",
+		TriggeredAt:   time.Now(),
+	}}
+	alert := &models.BalanceAlert{ID: "alert-1", AccountID: "acc-1", Kind: models.AlertKindLargeDebit, Threshold: 50000, NotifyEmail: "owner@example.com"}
+	mockRepo.EXPECT().GetBalanceAlert(gomock.Any(), "alert-1").Return(alert, nil).Times(2)
+	mockRepo.EXPECT().ClaimAlertNotification(gomock.Any(), "trigger-1").Return(true, nil)
+	mockRepo.EXPECT().ClaimAlertNotification(gomock.Any(), "trigger-1").Return(false, nil)
+
+	alerts.Handle(context.Background(), event)
+	// A replayed event was already claimed.
+	alerts.Handle(context.Background(), event)
+	// Alerts deleted since they triggered are not emailed.
+	mockRepo.EXPECT().GetBalanceAlert(gomock.Any(), "alert-2").Return(nil, ErrBalanceAlertNotFound)
+	alerts.Handle(context.Background(), events.Event{Type: EventBalanceAlertTriggered, Data: BalanceAlertTriggeredEvent{TriggerID: "trigger-2", AlertID: "alert-2"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	queue.Stop(ctx)
+
+	require.Len(t, sender.sent, 1)
+	msg := sender.sent[0]
+	assert.Equal(t, []string{"owner@example.com"}, msg.To)
+	assert.Equal(t, "Large debit on account acc-1", msg.Subject)
+	assert.Contains(t, msg.Text, "debited 1250.00 USD")
+	assert.Contains(t, msg.Text, "threshold of 500.00 USD")
+	assert.Contains(t, msg.Text, "now 25.00 USD")
+}
@@ -0,0 +1,143 @@
+package ledger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const (
+	defaultArchiveInterval = 24 * time.Hour
+
+	// archiveBatchSize is how many transactions one database transaction
+	// moves, which bounds how long their rows stay locked.
+	archiveBatchSize = 500
+)
+
+// ArchiveConfig sets which transactions the Archiver moves and how often.
+type ArchiveConfig struct {
+	// Retention is how long transactions stay live before they are archived.
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+// ArchiveConfigFromEnv reads LEDGER_ARCHIVE_RETENTION, the age at which
+// transactions are archived, and LEDGER_ARCHIVE_INTERVAL (default 24h).
+// Archiving is off while the retention is unset.
+func ArchiveConfigFromEnv(logger *log.Logger) (ArchiveConfig, bool) {
+	cfg := ArchiveConfig{Interval: defaultArchiveInterval}
+
+	raw := utils.GetEnvTrimmed("LEDGER_ARCHIVE_RETENTION")
+	if raw == "" {
+		return cfg, false
+	}
+	retention, err := time.ParseDuration(raw)
+	if err != nil || retention <= 0 {
+		logger.Warn("Invalid archive retention; archiving is off", "name", "LEDGER_ARCHIVE_RETENTION", "value", raw)
+		return cfg, false
+	}
+	cfg.Retention = retention
+
+	if raw := utils.GetEnvTrimmed("LEDGER_ARCHIVE_INTERVAL"); raw != "" {
+		if interval, err := time.ParseDuration(raw); err == nil && interval > 0 {
+			cfg.Interval = interval
+		} else {
+			logger.Warn("Invalid duration; using default", "name", "LEDGER_ARCHIVE_INTERVAL", "value", raw, "default", defaultArchiveInterval)
+		}
+	}
+	return cfg, true
+}
+
+// Archiver moves transactions older than the retention, with their entries,
+// into the archive tables when it starts and every interval after. Their
+// daily totals stay online, so balances and reports are unchanged.
+type Archiver struct {
+	logger     *log.Logger
+	repository LedgerRepository
+	cfg        ArchiveConfig
+
+	mu      sync.Mutex
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewArchiver(logger *log.Logger, repository LedgerRepository, cfg ArchiveConfig) *Archiver {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultArchiveInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Archiver{
+		logger:     logger,
+		repository: repository,
+		cfg:        cfg,
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+}
+
+// Start archives now and then every interval until Stop.
+func (a *Archiver) Start() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.started {
+		return
+	}
+	a.started = true
+	go func() {
+		defer close(a.done)
+		ticker := time.NewTicker(a.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			_, _ = a.Run(a.ctx)
+			select {
+			case <-ticker.C:
+			case <-a.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the runs and waits for one in flight, which stops after its
+// current batch.
+func (a *Archiver) Stop() {
+	a.mu.Lock()
+	started := a.started
+	a.mu.Unlock()
+
+	a.cancel()
+	if started {
+		<-a.done
+	}
+}
+
+// Run archives every transaction older than the retention, a batch at a time,
+// and reports how many it moved.
+func (a *Archiver) Run(ctx context.Context) (int, error) {
+	before := time.Now().UTC().Add(-a.cfg.Retention)
+	total := 0
+	for ctx.Err() == nil {
+		moved, err := a.repository.ArchiveTransactions(ctx, before, archiveBatchSize)
+		total += moved
+		if err != nil {
+			if a.ctx.Err() == nil {
+				a.logger.Warn("Ledger archival failed", "archived", total, "error", err)
+			}
+			return total, err
+		}
+		if moved == 0 {
+			break
+		}
+	}
+	if total > 0 {
+		a.logger.Info("Archived ledger transactions", "count", total, "before", before)
+	}
+	return total, ctx.Err()
+}
@@ -0,0 +1,277 @@
+package ledger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const (
+	defaultClosingInterval = time.Hour
+
+	// closingDelay is how long a day stays open after it ends, so postings
+	// that started before midnight have committed by the time it is sealed.
+	closingDelay = 10 * time.Minute
+)
+
+// dayChain hashes a day's entries, in order, onto the digest of the day
+// before. The seed is SHA-256 of "<day>|<previous digest>", and each entry
+// replaces the running digest d with SHA-256 of
+// "<hex d>|<id>|<transaction id>|<account id>|<entry type>|<amount>|<balance after>|<created at>",
+// the time in RFC 3339 UTC with only the fractional digits it needs. The day's
+// digest is the hex of the last, which auditors can recompute from an export.
+type dayChain struct {
+	hash  hash.Hash
+	sum   []byte
+	count int64
+}
+
+func newDayChain(day time.Time, previousDigest string) *dayChain {
+	c := &dayChain{hash: sha256.New()}
+	c.hash.Write([]byte(day.Format(time.DateOnly) + "|" + previousDigest))
+	c.sum = c.hash.Sum(nil)
+	return c
+}
+
+func (c *dayChain) add(entry *models.LedgerEntry) {
+	c.hash.Reset()
+	buf := hex.AppendEncode(make([]byte, 0, 256), c.sum)
+	for _, field := range []string{
+		entry.ID,
+		entry.TransactionID,
+		entry.AccountID,
+		entry.EntryType,
+		strconv.FormatInt(entry.Amount, 10),
+		strconv.FormatInt(entry.BalanceAfter, 10),
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	} {
+		buf = append(append(buf, '|'), field...)
+	}
+	c.hash.Write(buf)
+	c.sum = c.hash.Sum(c.sum[:0])
+	c.count++
+}
+
+func (c *dayChain) digest() string {
+	return hex.EncodeToString(c.sum)
+}
+
+// chainDay hashes the day's entries as they are now.
+func (s *ledgerService) chainDay(ctx context.Context, day time.Time, previousDigest string) (*dayChain, error) {
+	chain := newDayChain(day, previousDigest)
+	err := s.repository.StreamDayEntries(ctx, day, func(entry *models.LedgerEntry) error {
+		chain.add(entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// SealDays seals each day after the last one sealed through the given day,
+// oldest first, and reports how many it sealed. The first day sealed is the
+// day of the oldest entry. Days without entries are sealed too, so the chain
+// has no gaps. A day another instance sealed first ends the run.
+func (s *ledgerService) SealDays(ctx context.Context, through time.Time) (int, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	sealed := 0
+	for ctx.Err() == nil {
+		latest, err := s.repository.GetLatestDailySeal(ctx)
+		if err != nil {
+			return sealed, err
+		}
+		var day time.Time
+		var previousDigest string
+		if latest != nil {
+			day, previousDigest = latest.Day.UTC().AddDate(0, 0, 1), latest.Digest
+		} else {
+			first, err := s.repository.GetFirstEntryTime(ctx)
+			if err != nil || first == nil {
+				return sealed, err
+			}
+			day = first.UTC().Truncate(24 * time.Hour)
+		}
+		if day.After(through) {
+			return sealed, nil
+		}
+
+		chain, err := s.chainDay(ctx, day, previousDigest)
+		if err != nil {
+			return sealed, err
+		}
+		seal := &models.DailySeal{
+			Day:            day,
+			EntryCount:     chain.count,
+			PreviousDigest: previousDigest,
+			Digest:         chain.digest(),
+			SealedAt:       time.Now().UTC(),
+		}
+		if err := s.repository.CreateDailySeal(ctx, seal); err != nil {
+			if errors.Is(err, ErrConcurrentUpdate) {
+				return sealed, nil
+			}
+			return sealed, err
+		}
+		logger.Info("Day sealed", "day", day.Format(time.DateOnly), "entries", seal.EntryCount, "digest", seal.Digest)
+		sealed++
+	}
+	return sealed, ctx.Err()
+}
+
+// VerifyDay checks a sealed day against the ledger as it is now: its entries
+// must hash to its digest, and its seal must chain onto the day before's and,
+// once sealed, into the day after's.
+func (s *ledgerService) VerifyDay(ctx context.Context, day time.Time) (*DayVerificationResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	seal, err := s.repository.GetDailySeal(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := s.chainDay(ctx, day, seal.PreviousDigest)
+	if err != nil {
+		logger.Error("Failed to hash day entries", "day", day.Format(time.DateOnly), "error", err)
+		return nil, err
+	}
+
+	resp := ToDayVerificationResponse(seal)
+	resp.ComputedEntryCount = chain.count
+	resp.ComputedDigest = chain.digest()
+	if resp.ComputedEntryCount != seal.EntryCount {
+		resp.Problems = append(resp.Problems, fmt.Sprintf("the day has %d entries but %d were sealed", resp.ComputedEntryCount, seal.EntryCount))
+	}
+	if resp.ComputedDigest != seal.Digest {
+		resp.Problems = append(resp.Problems, "the day's entries do not hash to its sealed digest")
+	}
+
+	previous, err := s.repository.GetDailySeal(ctx, day.AddDate(0, 0, -1))
+	switch {
+	case errors.Is(err, ErrDayNotSealed):
+		if seal.PreviousDigest != "" {
+			resp.Problems = append(resp.Problems, "the seal chains onto a previous day that has no seal")
+		}
+	case err != nil:
+		return nil, err
+	case previous.Digest != seal.PreviousDigest:
+		resp.Problems = append(resp.Problems, "the seal does not chain onto the previous day's")
+	}
+
+	next, err := s.repository.GetDailySeal(ctx, day.AddDate(0, 0, 1))
+	switch {
+	case errors.Is(err, ErrDayNotSealed):
+	case err != nil:
+		return nil, err
+	case next.PreviousDigest != seal.Digest:
+		resp.Problems = append(resp.Problems, "the next day's seal does not chain onto this one")
+	}
+
+	resp.Valid = len(resp.Problems) == 0
+	if !resp.Valid {
+		logger.Warn("Sealed day failed verification", "day", resp.Day, "problems", resp.Problems)
+	}
+	return &resp, nil
+}
+
+// ClosingIntervalFromEnv reads LEDGER_CLOSING_INTERVAL (default one hour).
+func ClosingIntervalFromEnv(logger *log.Logger) time.Duration {
+	raw := utils.GetEnvTrimmed("LEDGER_CLOSING_INTERVAL")
+	if raw == "" {
+		return defaultClosingInterval
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval <= 0 {
+		logger.Warn("Invalid duration; using default", "name", "LEDGER_CLOSING_INTERVAL", "value", raw, "default", defaultClosingInterval)
+		return defaultClosingInterval
+	}
+	return interval
+}
+
+// Closer seals the days that have ended when it starts and every interval
+// after. A day is sealed once, whichever instance gets to it, so every
+// instance may run one.
+type Closer struct {
+	logger   *log.Logger
+	service  LedgerService
+	interval time.Duration
+
+	mu      sync.Mutex
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewCloser(logger *log.Logger, service LedgerService, interval time.Duration) *Closer {
+	if interval <= 0 {
+		interval = defaultClosingInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Closer{
+		logger:   logger,
+		service:  service,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start seals now and then every interval until Stop.
+func (c *Closer) Start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return
+	}
+	c.started = true
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			_, _ = c.Run(c.ctx)
+			select {
+			case <-ticker.C:
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the runs and waits for one in flight, which stops after the day
+// it is sealing.
+func (c *Closer) Stop() {
+	c.mu.Lock()
+	started := c.started
+	c.mu.Unlock()
+
+	c.cancel()
+	if started {
+		<-c.done
+	}
+}
+
+// Run seals every day that ended at least closingDelay ago and reports how
+// many it sealed.
+func (c *Closer) Run(ctx context.Context) (int, error) {
+	through := time.Now().UTC().Add(-closingDelay).Truncate(24*time.Hour).AddDate(0, 0, -1)
+	sealed, err := c.service.SealDays(ctx, through)
+	if err != nil && c.ctx.Err() == nil {
+		c.logger.Warn("Closing run failed", "sealed", sealed, "error", err)
+	}
+	return sealed, err
+}
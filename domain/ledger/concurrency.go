@@ -0,0 +1,139 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/retry"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConcurrencyMode decides how ledger writes keep concurrent postings to the
+// same account apart.
+type ConcurrencyMode string
+
+const (
+	// ConcurrencyPessimistic locks the accounts a write touches with SELECT
+	// ... FOR UPDATE, so writes to the same account wait for each other.
+	ConcurrencyPessimistic ConcurrencyMode = "pessimistic"
+	// ConcurrencyOptimistic reads the accounts without locking them and
+	// updates each only if its version is unchanged. A write that loses the
+	// race is rolled back and run again.
+	ConcurrencyOptimistic ConcurrencyMode = "optimistic"
+)
+
+const optimisticAttempts = 8
+
+// optimisticBackoff spaces the attempts of a write that lost a race, with
+// jitter so the writes that collided do not collide again.
+var optimisticBackoff = retry.Backoff{Initial: 2 * time.Millisecond, Max: 100 * time.Millisecond, Multiplier: 2, Jitter: 0.5}
+
+// errVersionConflict rolls back an optimistic write whose account changed
+// after it was read.
+var errVersionConflict = errors.New("account version changed")
+
+// ConcurrencyModeFromEnv reads LEDGER_CONCURRENCY_MODE. Unset or invalid
+// values are pessimistic, the latter with a warning.
+func ConcurrencyModeFromEnv(logger *log.Logger) ConcurrencyMode {
+	raw := utils.GetEnvTrimmed("LEDGER_CONCURRENCY_MODE")
+	switch mode := ConcurrencyMode(strings.ToLower(raw)); mode {
+	case "", ConcurrencyPessimistic:
+		return ConcurrencyPessimistic
+	case ConcurrencyOptimistic:
+		return ConcurrencyOptimistic
+	default:
+		logger.Warn("Invalid concurrency mode; using default", "name", "LEDGER_CONCURRENCY_MODE", "value", raw, "default", ConcurrencyPessimistic)
+		return ConcurrencyPessimistic
+	}
+}
+
+func (r *ledgerRepository) optimistic() bool {
+	return r.concurrency == ConcurrencyOptimistic
+}
+
+// transaction runs fn in a database transaction, or in a savepoint of the
+// transaction of the unit of work ctx belongs to. In optimistic mode a run
+// that loses a race to another write is retried from the start; after
+// optimisticAttempts it fails with ErrConcurrentUpdate.
+func (r *ledgerRepository) transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if !r.optimistic() {
+		err := r.conn(ctx).Transaction(fn)
+		if errors.Is(err, errVersionConflict) {
+			return ErrConcurrentUpdate
+		}
+		return err
+	}
+
+	err := retry.Do(ctx, optimisticBackoff, optimisticAttempts, func(ctx context.Context) error {
+		err := r.conn(ctx).Transaction(fn)
+		if err != nil && !errors.Is(err, errVersionConflict) {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	if errors.Is(err, errVersionConflict) {
+		return ErrConcurrentUpdate
+	}
+	return err
+}
+
+// lockAccounts reads the accounts with ids, in order, into accounts. In
+// pessimistic mode it locks them until the transaction ends; in optimistic
+// mode saveBalance later checks that they did not change.
+func (r *ledgerRepository) lockAccounts(tx *gorm.DB, accounts map[string]*models.Account, ids []string) error {
+	for _, id := range ids {
+		query := tx
+		if !r.optimistic() {
+			query = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		var acc models.Account
+		if err := query.Where("id = ?", id).First(&acc).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAccountNotFound
+			}
+			return apperrors.NewDatabaseError("failed to lock account", err)
+		}
+		accounts[id] = &acc
+	}
+	return nil
+}
+
+// saveBalance writes acc's balance and the end of its hash chain and bumps
+// its version, provided the version is still the one read. A locked account
+// always matches; an unlocked one fails with errVersionConflict if another
+// write got there first.
+func saveBalance(tx *gorm.DB, acc *models.Account) error {
+	result := tx.Model(acc).Where("version = ?", acc.Version).Updates(map[string]any{
+		"balance":         acc.Balance,
+		"last_entry_hash": acc.LastEntryHash,
+		"version":         acc.Version + 1,
+	})
+	if result.Error != nil {
+		return apperrors.NewDatabaseError("failed to update account balance", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+	return nil
+}
+
+// claimAccount bumps acc's version without changing its balance, so a write
+// that read the account before fails its saveBalance. Optimistic holds use
+// it: the hold depends on the balance it checked without changing it.
+func claimAccount(tx *gorm.DB, acc *models.Account) error {
+	result := tx.Model(acc).Where("version = ?", acc.Version).Update("version", acc.Version+1)
+	if result.Error != nil {
+		return apperrors.NewDatabaseError("failed to update account version", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+	return nil
+}
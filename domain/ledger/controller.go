@@ -1,19 +1,39 @@
 package ledger
 
 import (
+	"context"
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/akeren/go-api-foundry/config/router"
 	"github.com/akeren/go-api-foundry/internal/log"
 	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
-	"gorm.io/gorm"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/operations"
+	"github.com/akeren/go-api-foundry/pkg/region"
 )
 
 const (
 	defaultPageLimit = 50
 	maxPageLimit     = 100
+
+	operationKindBatchTransfer = "ledger.batch_transfer"
+
+	// Ledger writes are small JSON documents; batches carry up to 1000
+	// transfers.
+	writeBodyLimit = 64 << 10
+	batchBodyLimit = 1 << 20
+
+	transactionRoute = "/v1/ledger/transactions/:id"
+
+	// An account's alerts only change through its alert routes, which evict
+	// the cached list.
+	alertsCacheTag = "ledger:accounts:{id}:alerts"
+	alertsCacheTTL = 5 * time.Minute
 )
 
 // mapDomainError translates domain sentinel errors into HTTP status codes
@@ -29,12 +49,60 @@ func mapDomainError(err error) (int, string) {
 		return http.StatusBadRequest, ErrCurrencyMismatch.Error()
 	case errors.Is(err, ErrSelfTransfer):
 		return http.StatusBadRequest, ErrSelfTransfer.Error()
+	case errors.Is(err, ErrInvalidSplit):
+		return http.StatusBadRequest, ErrInvalidSplit.Error()
+	case errors.Is(err, ErrDuplicateDestination):
+		return http.StatusBadRequest, ErrDuplicateDestination.Error()
 	case errors.Is(err, ErrInvalidAmount):
 		return http.StatusBadRequest, ErrInvalidAmount.Error()
 	case errors.Is(err, ErrSystemAccountForbidden):
 		return http.StatusBadRequest, ErrSystemAccountForbidden.Error()
 	case errors.Is(err, ErrIdempotencyConflict):
 		return http.StatusConflict, ErrIdempotencyConflict.Error()
+	case errors.Is(err, ErrQuoteNotFound):
+		return http.StatusNotFound, ErrQuoteNotFound.Error()
+	case errors.Is(err, ErrQuoteExpired):
+		return http.StatusConflict, ErrQuoteExpired.Error()
+	case errors.Is(err, ErrQuoteUsed):
+		return http.StatusConflict, ErrQuoteUsed.Error()
+	case errors.Is(err, ErrQuoteMismatch):
+		return http.StatusBadRequest, ErrQuoteMismatch.Error()
+	case errors.Is(err, ErrParentAccountNotFound):
+		return http.StatusBadRequest, ErrParentAccountNotFound.Error()
+	case errors.Is(err, ErrHierarchyTooDeep):
+		return http.StatusBadRequest, ErrHierarchyTooDeep.Error()
+	case errors.Is(err, ErrSiblingTransferBlocked):
+		return http.StatusForbidden, ErrSiblingTransferBlocked.Error()
+	case errors.Is(err, ErrWrongRegion):
+		return http.StatusMisdirectedRequest, ErrWrongRegion.Error()
+	case errors.Is(err, ErrExchangeRateNotFound):
+		return http.StatusUnprocessableEntity, ErrExchangeRateNotFound.Error()
+	case errors.Is(err, ErrTransactionNotFound):
+		return http.StatusNotFound, ErrTransactionNotFound.Error()
+	case errors.Is(err, ErrAlreadyReversed):
+		return http.StatusConflict, ErrAlreadyReversed.Error()
+	case errors.Is(err, ErrReversalNotReversible):
+		return http.StatusBadRequest, ErrReversalNotReversible.Error()
+	case errors.Is(err, ErrHoldNotFound):
+		return http.StatusNotFound, ErrHoldNotFound.Error()
+	case errors.Is(err, ErrBalanceAlertNotFound):
+		return http.StatusNotFound, ErrBalanceAlertNotFound.Error()
+	case errors.Is(err, ErrAccrualPlanNotFound):
+		return http.StatusNotFound, ErrAccrualPlanNotFound.Error()
+	case errors.Is(err, ErrAccrualNotReversible):
+		return http.StatusBadRequest, ErrAccrualNotReversible.Error()
+	case errors.Is(err, ErrDayNotSealed):
+		return http.StatusNotFound, ErrDayNotSealed.Error()
+	case errors.Is(err, ErrHoldNotActive):
+		return http.StatusConflict, ErrHoldNotActive.Error()
+	case errors.Is(err, ErrHoldExpired):
+		return http.StatusConflict, ErrHoldExpired.Error()
+	case errors.Is(err, ErrCaptureExceedsHold):
+		return http.StatusBadRequest, ErrCaptureExceedsHold.Error()
+	case errors.Is(err, ErrHoldMismatch):
+		return http.StatusBadRequest, ErrHoldMismatch.Error()
+	case errors.Is(err, ErrConcurrentUpdate):
+		return http.StatusConflict, ErrConcurrentUpdate.Error()
 	default:
 		return apperrors.HTTPStatusCode(err), apperrors.GetHumanReadableMessage(err)
 	}
@@ -42,56 +110,221 @@ func mapDomainError(err error) (int, string) {
 
 func errorResult(err error) *router.ServiceResult {
 	code, msg := mapDomainError(err)
-	return router.ErrorResult(code, msg, nil)
+	result := router.ErrorResult(code, msg, nil)
+	// Tell the client, or the proxy in front of it, where to send the write.
+	var wrongRegion *WrongRegionError
+	if errors.As(err, &wrongRegion) {
+		result.WithHeader(region.HomeHeader, wrongRegion.HomeRegion)
+	}
+	return result
 }
 
-func bindJSON[T any](ctx *router.RequestContext) (*T, *router.ServiceResult) {
-	var req T
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		router.GetLogger(ctx).Error("Failed to bind request", "error", err)
-		validationErrors := apperrors.FormatValidationErrors(err, &req)
-		if len(validationErrors) > 0 {
-			return nil, router.BadRequestResult("Invalid request payload", validationErrors)
-		}
-		return nil, router.BadRequestResult("Invalid request body", nil)
+// dryRunRequested reads the dry_run query parameter or X-Dry-Run header.
+func dryRunRequested(ctx *router.RequestContext) (bool, *router.ServiceResult) {
+	raw := ctx.Query("dry_run")
+	if raw == "" {
+		raw = ctx.GetHeader("X-Dry-Run")
+	}
+	if raw == "" {
+		return false, nil
 	}
-	return &req, nil
+	dryRun, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, router.BadRequestResult("dry_run must be true or false", nil)
+	}
+	return dryRun, nil
+}
+
+// mutationResult reports a completed mutation, or its dry-run preview.
+func mutationResult(response any, dryRun bool, resourceName string) *router.ServiceResult {
+	if dryRun {
+		return router.OKResult(response, resourceName+" dry run succeeded; nothing was persisted")
+	}
+	return router.CreatedResult(response, resourceName)
 }
 
-func NewLedgerController(db *gorm.DB, logger *log.Logger) *router.RESTController {
+var (
+	dryRunParams = []router.QueryParam{
+		{Name: "dry_run", Type: "boolean", Description: "Validate and execute without persisting (also X-Dry-Run header)"},
+	}
+	pageParams = []router.QueryParam{
+		{Name: "page", Type: "integer", Description: "1-based page number"},
+		{Name: "per_page", Type: "integer", Description: "Page size, default 50, max 100 (limit is an alias)"},
+	}
+)
+
+// NewLedgerController mounts the ledger API over repository. Batch transfers
+// are only available when ops is non-nil; domain events go to publisher when
+// non-nil.
+func NewLedgerController(repository LedgerRepository, logger *log.Logger, ops *operations.Manager, publisher events.Publisher) *router.RESTController {
 	return router.NewVersionedRESTController(
 		"LedgerController",
 		"v1",
 		"/ledger",
-		func(rs *router.RouterService, c *router.RESTController) {
-			repository := NewLedgerRepository(db)
-			service := NewLedgerService(logger, repository)
-
-			rs.AddPostHandler(c, nil, "/accounts", createAccountHandler(service))
-			rs.AddGetHandler(c, nil, "/accounts/:id", getAccountHandler(service))
-			rs.AddPostHandler(c, nil, "/accounts/:id/deposit", depositHandler(service))
-			rs.AddPostHandler(c, nil, "/accounts/:id/withdraw", withdrawHandler(service))
-			rs.AddPostHandler(c, nil, "/transfers", transferHandler(service))
-			rs.AddGetHandler(c, nil, "/accounts/:id/balance", getBalanceHandler(service))
-			rs.AddGetHandler(c, nil, "/accounts/:id/transactions", getTransactionsHandler(service))
-			rs.AddGetHandler(c, nil, "/reconciliation", reconciliationHandler(service))
+		func(rs router.RouteRegistrar, c *router.RESTController) {
+			service := NewLedgerService(logger, repository, PricingFromEnv(logger), publisher)
+
+			rs.AddPostHandler(c, nil, "/accounts", createAccountHandler(service)).Describe(router.OperationDoc{
+				Summary: "Create an account or sub-account", Request: CreateAccountRequest{}, Response: AccountResponse{},
+				Query: dryRunParams, Status: http.StatusCreated,
+			})
+			rs.AddGetHandler(c, nil, "/accounts/:id", getAccountHandler(service)).Describe(router.OperationDoc{
+				Summary: "Get an account", Response: AccountResponse{},
+			})
+			rs.AddPatchHandler(c, nil, "/accounts/:id", updateAccountHandler(service)).Describe(router.OperationDoc{
+				Summary: "Update an account's sibling transfer policy", Request: UpdateAccountRequest{}, Response: AccountResponse{},
+			})
+			rs.AddPostHandler(c, nil, "/accounts/:id/deposit", depositHandler(service)).Describe(router.OperationDoc{
+				Summary: "Deposit into an account", Request: DepositRequest{}, Response: TransactionResponse{},
+				Query: dryRunParams, Status: http.StatusCreated,
+			})
+			rs.AddPostHandler(c, nil, "/accounts/:id/withdraw", withdrawHandler(service)).Describe(router.OperationDoc{
+				Summary: "Withdraw from an account", Request: WithdrawRequest{}, Response: TransactionResponse{},
+				Query: dryRunParams, Status: http.StatusCreated,
+			})
+			rs.AddPostHandler(c, nil, "/accounts/:id/holds", createHoldHandler(service)).Describe(router.OperationDoc{
+				Summary: "Hold funds on an account for a later capture", Request: CreateHoldRequest{}, Response: HoldResponse{},
+				Query: dryRunParams, Status: http.StatusCreated,
+			})
+			rs.AddPostHandler(c, nil, "/accounts/:id/alerts", createBalanceAlertHandler(service), router.InvalidatesCache(alertsCacheTag)).Describe(router.OperationDoc{
+				Summary: "Add a low balance or large debit alert to an account", Request: CreateBalanceAlertRequest{}, Response: BalanceAlertResponse{},
+				Status: http.StatusCreated,
+			})
+			rs.AddGetHandler(c, nil, "/accounts/:id/alerts", listBalanceAlertsHandler(service), router.CacheFor(alertsCacheTTL, alertsCacheTag)).Describe(router.OperationDoc{
+				Summary: "List an account's balance alerts", Response: []BalanceAlertResponse{},
+			})
+			rs.AddDeleteHandler(c, nil, "/accounts/:id/alerts/:alert_id", deleteBalanceAlertHandler(service), router.InvalidatesCache(alertsCacheTag)).Describe(router.OperationDoc{
+				Summary: "Delete a balance alert",
+			})
+			rs.AddPostHandler(c, nil, "/accounts/:id/accrual-plans", createAccrualPlanHandler(service)).Describe(router.OperationDoc{
+				Summary: "Accrue daily interest onto, or fees from, an account", Request: CreateAccrualPlanRequest{}, Response: AccrualPlanResponse{},
+				Status: http.StatusCreated,
+			})
+			rs.AddGetHandler(c, nil, "/accounts/:id/accrual-plans", listAccrualPlansHandler(service)).Describe(router.OperationDoc{
+				Summary: "List an account's accrual plans", Response: []AccrualPlanResponse{},
+			})
+			rs.AddPatchHandler(c, nil, "/accounts/:id/accrual-plans/:plan_id", updateAccrualPlanHandler(service)).Describe(router.OperationDoc{
+				Summary: "Change an accrual plan's rate, correcting days already accrued", Request: UpdateAccrualPlanRequest{}, Response: AccrualPlanResponse{},
+			})
+			rs.AddGetHandler(c, nil, "/accounts/:id/accruals", listAccrualRecordsHandler(service)).Describe(router.OperationDoc{
+				Summary: "Report an account's daily accrual calculations", Response: AccrualRecordsResponse{},
+				Query: []router.QueryParam{
+					{Name: "from", Description: "First UTC day, 2006-01-02; default 29 days before to"},
+					{Name: "to", Description: "Last UTC day, 2006-01-02; default today"},
+				},
+			})
+			rs.AddGetHandler(c, nil, "/holds/:id", getHoldHandler(service)).Describe(router.OperationDoc{
+				Summary: "Get a hold", Response: HoldResponse{},
+			})
+			rs.AddPostHandler(c, nil, "/holds/:id/capture", captureHoldHandler(service)).Describe(router.OperationDoc{
+				Summary: "Capture a hold, posting its funds", Request: CaptureHoldRequest{}, Response: HoldResponse{},
+				Query: dryRunParams, Status: http.StatusCreated,
+			})
+			rs.AddPostHandler(c, nil, "/holds/:id/release", releaseHoldHandler(service)).Describe(router.OperationDoc{
+				Summary: "Release a hold's funds", Response: HoldResponse{}, Query: dryRunParams,
+			})
+			rs.AddPostHandler(c, nil, "/transfers", transferHandler(service)).Describe(router.OperationDoc{
+				Summary: "Transfer between accounts", Request: TransferRequest{}, Response: TransactionResponse{},
+				Query: dryRunParams, Status: http.StatusCreated,
+			})
+			rs.AddGetHandler(c, nil, "/transactions/:id", getTransactionHandler(service)).Describe(router.OperationDoc{
+				Summary: "Get a transaction with its entries", Response: TransactionResponse{},
+			})
+			rs.AddPostHandler(c, nil, "/transactions/:id/reverse", reverseTransactionHandler(service)).Describe(router.OperationDoc{
+				Summary: "Reverse a transaction", Request: ReverseTransactionRequest{}, Response: TransactionResponse{},
+				Query: dryRunParams, Status: http.StatusCreated,
+			})
+			rs.AddPostHandler(c, nil, "/transfers/split", splitTransferHandler(service)).Describe(router.OperationDoc{
+				Summary: "Transfer from one account to several in one transaction", Request: SplitTransferRequest{}, Response: SplitTransferResponse{},
+				Query: dryRunParams, Status: http.StatusCreated,
+			})
+			rs.AddPostHandler(c, nil, "/transfers/quote", quoteTransferHandler(service)).Describe(router.OperationDoc{
+				Summary: "Quote a transfer, locking its fee and exchange rate", Request: TransferQuoteRequest{}, Response: TransferQuoteResponse{},
+				Status: http.StatusCreated,
+			})
+			if ops != nil {
+				rs.AddPostHandler(c, nil, "/transfers/batch", batchTransferHandler(service, ops)).Describe(router.OperationDoc{
+					Summary: "Start a batch transfer operation", Request: BatchTransferRequest{}, Response: operations.Operation{},
+					Status: http.StatusAccepted,
+				}).MaxBodyBytes(batchBodyLimit)
+			}
+			rs.AddGetHandler(c, nil, "/transactions/search", searchTransactionsHandler(service)).Describe(router.OperationDoc{
+				Summary: "Search transactions by description", Response: []TransactionResponse{},
+				Query: append([]router.QueryParam{
+					{Name: "q", Description: "Words that must all appear in the description", Required: true},
+					{Name: "account_id", Description: "Only transactions touching this account"},
+				}, pageParams...),
+			})
+			router.RegisterJSONEncoder(appendBalanceJSON)
+			rs.AddGetHandler(c, nil, "/accounts/:id/balance", getBalanceHandler(service)).Describe(router.OperationDoc{
+				Summary: "Get an account's cached and derived balance", Response: BalanceResponse{},
+			}).PooledJSON(true)
+			rs.AddGetHandler(c, nil, "/accounts/:id/aggregate-balance", getAggregateBalanceHandler(service)).Describe(router.OperationDoc{
+				Summary: "Get an account's balance including sub-accounts", Response: AggregateBalanceResponse{},
+			})
+			rs.AddGetHandler(c, nil, "/accounts/:id/daily-balances", getDailyBalancesHandler(service)).Describe(router.OperationDoc{
+				Summary: "Report an account's daily credits, debits and closing balance", Response: DailyBalancesResponse{},
+				Query: []router.QueryParam{
+					{Name: "from", Description: "First UTC day, 2006-01-02; default 29 days before to"},
+					{Name: "to", Description: "Last UTC day, 2006-01-02; default today"},
+				},
+			})
+			rs.AddGetHandler(c, nil, "/accounts/:id/statement", getStatementHandler(service)).Describe(router.OperationDoc{
+				Summary:      "Download an account's statement",
+				Description:  "Streams the account's entries with a running balance as text/csv or application/pdf.",
+				ContentTypes: []string{"text/csv", "application/pdf"},
+				Query: []router.QueryParam{
+					{Name: "from", Description: "First UTC day, 2006-01-02; default 29 days before to"},
+					{Name: "to", Description: "Last UTC day, 2006-01-02; default today"},
+					{Name: "format", Description: "csv (default) or pdf"},
+				},
+			})
+			rs.AddGetHandler(c, nil, "/accounts/:id/transactions", getTransactionsHandler(service)).Describe(router.OperationDoc{
+				Summary:     "List an account's transactions",
+				Description: "Each transaction summarizes its entries; include=entries lists them too, as the transaction detail does.",
+				Response:    router.PaginatedResult[TransactionResponse]{},
+				Query: append([]router.QueryParam{
+					{Name: "include", Description: "entries to list each transaction's ledger entries"},
+				}, pageParams...),
+			})
+			rs.AddGetHandler(c, nil, "/accounts/:id/archived-transactions", getArchivedTransactionsHandler(service)).Describe(router.OperationDoc{
+				Summary: "List an account's archived transactions", Response: router.PaginatedResult[TransactionResponse]{}, Query: pageParams,
+			})
+			rs.AddGetHandler(c, nil, "/archive/transactions/:id", getArchivedTransactionHandler(service)).Describe(router.OperationDoc{
+				Summary: "Get an archived transaction", Response: TransactionResponse{},
+			})
+			rs.AddGetHandler(c, nil, "/accounts/:id/chain/verify", verifyAccountChainHandler(service)).Describe(router.OperationDoc{
+				Summary: "Verify an account's entries against their hash chain", Response: AccountChainVerificationResponse{},
+			})
+			rs.AddGetHandler(c, nil, "/closings/:day/verify", verifyDayHandler(service)).Describe(router.OperationDoc{
+				Summary: "Verify a sealed day's entries against its seal", Response: DayVerificationResponse{},
+			})
+			rs.AddGetHandler(c, nil, "/reconciliation", reconciliationHandler(service)).Describe(router.OperationDoc{
+				Summary: "Reconcile cached balances against ledger entries", Response: ReconciliationResponse{},
+			})
 		},
-	)
+	).MaxBodyBytes(writeBodyLimit)
 }
 
 func createAccountHandler(service LedgerService) router.HandlerFunction {
 	return func(ctx *router.RequestContext) *router.ServiceResult {
-		req, bindErr := bindJSON[CreateAccountRequest](ctx)
+		dryRun, dryRunErr := dryRunRequested(ctx)
+		if dryRunErr != nil {
+			return dryRunErr
+		}
+
+		req, bindErr := router.BindJSON[CreateAccountRequest](ctx)
 		if bindErr != nil {
 			return bindErr
 		}
+		req.DryRun = dryRun
 
 		response, err := service.CreateAccount(ctx.Request.Context(), req)
 		if err != nil {
 			return errorResult(err)
 		}
 
-		return router.CreatedResult(response, "Account")
+		return mutationResult(response, dryRun, "Account")
 	}
 }
 
@@ -111,6 +344,27 @@ func getAccountHandler(service LedgerService) router.HandlerFunction {
 	}
 }
 
+func updateAccountHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+
+		req, bindErr := router.BindJSON[UpdateAccountRequest](ctx)
+		if bindErr != nil {
+			return bindErr
+		}
+
+		response, err := service.UpdateAccount(ctx.Request.Context(), id, req)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Account updated successfully")
+	}
+}
+
 func depositHandler(service LedgerService) router.HandlerFunction {
 	return func(ctx *router.RequestContext) *router.ServiceResult {
 		id := ctx.Param("id")
@@ -118,17 +372,23 @@ func depositHandler(service LedgerService) router.HandlerFunction {
 			return router.BadRequestResult("Account ID is required", nil)
 		}
 
-		req, bindErr := bindJSON[DepositRequest](ctx)
+		dryRun, dryRunErr := dryRunRequested(ctx)
+		if dryRunErr != nil {
+			return dryRunErr
+		}
+
+		req, bindErr := router.BindJSON[DepositRequest](ctx)
 		if bindErr != nil {
 			return bindErr
 		}
+		req.DryRun = dryRun
 
 		response, err := service.Deposit(ctx.Request.Context(), id, req)
 		if err != nil {
 			return errorResult(err)
 		}
 
-		return router.CreatedResult(response, "Deposit")
+		return mutationResult(response, dryRun, "Deposit")
 	}
 }
 
@@ -139,33 +399,373 @@ func withdrawHandler(service LedgerService) router.HandlerFunction {
 			return router.BadRequestResult("Account ID is required", nil)
 		}
 
-		req, bindErr := bindJSON[WithdrawRequest](ctx)
+		dryRun, dryRunErr := dryRunRequested(ctx)
+		if dryRunErr != nil {
+			return dryRunErr
+		}
+
+		req, bindErr := router.BindJSON[WithdrawRequest](ctx)
 		if bindErr != nil {
 			return bindErr
 		}
+		req.DryRun = dryRun
 
 		response, err := service.Withdraw(ctx.Request.Context(), id, req)
 		if err != nil {
 			return errorResult(err)
 		}
 
-		return router.CreatedResult(response, "Withdrawal")
+		return mutationResult(response, dryRun, "Withdrawal")
+	}
+}
+
+func createHoldHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+
+		dryRun, dryRunErr := dryRunRequested(ctx)
+		if dryRunErr != nil {
+			return dryRunErr
+		}
+
+		req, bindErr := router.BindJSON[CreateHoldRequest](ctx)
+		if bindErr != nil {
+			return bindErr
+		}
+		req.DryRun = dryRun
+
+		response, err := service.CreateHold(ctx.Request.Context(), id, req)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return mutationResult(response, dryRun, "Hold")
+	}
+}
+
+func createBalanceAlertHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+
+		req, bindErr := router.BindJSON[CreateBalanceAlertRequest](ctx)
+		if bindErr != nil {
+			return bindErr
+		}
+
+		response, err := service.CreateBalanceAlert(ctx.Request.Context(), id, req)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.CreatedResult(response, "Balance alert")
+	}
+}
+
+func listBalanceAlertsHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+
+		response, err := service.ListBalanceAlerts(ctx.Request.Context(), id)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Balance alerts retrieved successfully")
+	}
+}
+
+func deleteBalanceAlertHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		path, pathErr := router.BindURI[alertPath](ctx)
+		if pathErr != nil {
+			return pathErr
+		}
+
+		if err := service.DeleteBalanceAlert(ctx.Request.Context(), path.AccountID, path.AlertID); err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(nil, "Balance alert deleted successfully")
+	}
+}
+
+func createAccrualPlanHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+
+		req, bindErr := router.BindJSON[CreateAccrualPlanRequest](ctx)
+		if bindErr != nil {
+			return bindErr
+		}
+
+		response, err := service.CreateAccrualPlan(ctx.Request.Context(), id, req)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.CreatedResult(response, "Accrual plan")
+	}
+}
+
+func listAccrualPlansHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+
+		response, err := service.ListAccrualPlans(ctx.Request.Context(), id)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Accrual plans retrieved successfully")
+	}
+}
+
+func updateAccrualPlanHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		path, pathErr := router.BindURI[accrualPlanPath](ctx)
+		if pathErr != nil {
+			return pathErr
+		}
+
+		req, bindErr := router.BindJSON[UpdateAccrualPlanRequest](ctx)
+		if bindErr != nil {
+			return bindErr
+		}
+
+		response, err := service.UpdateAccrualPlan(ctx.Request.Context(), path.AccountID, path.PlanID, req)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Accrual plan updated successfully")
+	}
+}
+
+func listAccrualRecordsHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+		params, errResult := router.BindQuery[DailyBalancesParams](ctx)
+		if errResult != nil {
+			return errResult
+		}
+		if params.To.IsZero() {
+			params.To = time.Now().UTC().Truncate(24 * time.Hour)
+		}
+		if params.From.IsZero() {
+			params.From = params.To.AddDate(0, 0, -29)
+		}
+
+		response, err := service.ListAccrualRecords(ctx.Request.Context(), id, params.From, params.To)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Accruals retrieved successfully")
+	}
+}
+
+func getHoldHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		path, pathErr := router.BindURI[idPath](ctx)
+		if pathErr != nil {
+			return pathErr
+		}
+
+		response, err := service.GetHold(ctx.Request.Context(), path.ID)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Hold retrieved successfully")
+	}
+}
+
+func captureHoldHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		path, pathErr := router.BindURI[idPath](ctx)
+		if pathErr != nil {
+			return pathErr
+		}
+
+		dryRun, dryRunErr := dryRunRequested(ctx)
+		if dryRunErr != nil {
+			return dryRunErr
+		}
+
+		req, bindErr := router.BindJSON[CaptureHoldRequest](ctx)
+		if bindErr != nil {
+			return bindErr
+		}
+		req.DryRun = dryRun
+
+		response, err := service.CaptureHold(ctx.Request.Context(), path.ID, req)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return mutationResult(response, dryRun, "Capture")
+	}
+}
+
+func releaseHoldHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		path, pathErr := router.BindURI[idPath](ctx)
+		if pathErr != nil {
+			return pathErr
+		}
+
+		dryRun, dryRunErr := dryRunRequested(ctx)
+		if dryRunErr != nil {
+			return dryRunErr
+		}
+
+		response, err := service.ReleaseHold(ctx.Request.Context(), path.ID, dryRun)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		if dryRun {
+			return router.OKResult(response, "Release dry run succeeded; nothing was persisted")
+		}
+		return router.OKResult(response, "Hold released successfully")
 	}
 }
 
 func transferHandler(service LedgerService) router.HandlerFunction {
 	return func(ctx *router.RequestContext) *router.ServiceResult {
-		req, bindErr := bindJSON[TransferRequest](ctx)
+		dryRun, dryRunErr := dryRunRequested(ctx)
+		if dryRunErr != nil {
+			return dryRunErr
+		}
+
+		req, bindErr := router.BindJSON[TransferRequest](ctx)
 		if bindErr != nil {
 			return bindErr
 		}
+		req.DryRun = dryRun
 
 		response, err := service.Transfer(ctx.Request.Context(), req)
 		if err != nil {
 			return errorResult(err)
 		}
 
-		return router.CreatedResult(response, "Transfer")
+		return mutationResult(response, dryRun, "Transfer")
+	}
+}
+
+func splitTransferHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		dryRun, dryRunErr := dryRunRequested(ctx)
+		if dryRunErr != nil {
+			return dryRunErr
+		}
+
+		req, bindErr := router.BindJSON[SplitTransferRequest](ctx)
+		if bindErr != nil {
+			return bindErr
+		}
+		req.DryRun = dryRun
+
+		response, err := service.SplitTransfer(ctx.Request.Context(), req)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return mutationResult(response, dryRun, "Split transfer")
+	}
+}
+
+func reverseTransactionHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		path, pathErr := router.BindURI[idPath](ctx)
+		if pathErr != nil {
+			return pathErr
+		}
+
+		dryRun, dryRunErr := dryRunRequested(ctx)
+		if dryRunErr != nil {
+			return dryRunErr
+		}
+
+		req, bindErr := router.BindJSON[ReverseTransactionRequest](ctx)
+		if bindErr != nil {
+			return bindErr
+		}
+		req.DryRun = dryRun
+
+		response, err := service.ReverseTransaction(ctx.Request.Context(), path.ID, req)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return mutationResult(response, dryRun, "Reversal")
+	}
+}
+
+func quoteTransferHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		req, bindErr := router.BindJSON[TransferQuoteRequest](ctx)
+		if bindErr != nil {
+			return bindErr
+		}
+
+		response, err := service.QuoteTransfer(ctx.Request.Context(), req)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.CreatedResult(response, "Transfer quote")
+	}
+}
+
+func batchTransferHandler(service LedgerService, ops *operations.Manager) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		if dryRun, _ := dryRunRequested(ctx); dryRun {
+			return router.BadRequestResult("dry_run is not supported for batch transfers", nil)
+		}
+
+		req, bindErr := router.BindJSON[BatchTransferRequest](ctx)
+		if bindErr != nil {
+			return bindErr
+		}
+
+		total := len(req.Transfers)
+		op, err := ops.Start(ctx.Request.Context(), operationKindBatchTransfer, req.WebhookURL,
+			func(opCtx context.Context, progress *operations.Progress) (any, error) {
+				return service.TransferBatch(opCtx, req.Transfers, func(done int) {
+					progress.Set(opCtx, done*100/total)
+				})
+			})
+		if err != nil {
+			switch {
+			case errors.Is(err, operations.ErrInvalidWebhookURL):
+				return router.BadRequestResult("webhook_url must be an absolute http(s) URL", nil)
+			case errors.Is(err, operations.ErrShuttingDown):
+				return router.ErrorResult(http.StatusServiceUnavailable, "Server is shutting down; retry shortly", nil)
+			}
+			router.GetLogger(ctx).Error("Failed to start batch transfer", "error", err)
+			return router.InternalServerErrorResult("Failed to start batch transfer")
+		}
+
+		return router.AcceptedResult(op, operations.Location(op.ID))
 	}
 }
 
@@ -176,12 +776,105 @@ func getBalanceHandler(service LedgerService) router.HandlerFunction {
 			return router.BadRequestResult("Account ID is required", nil)
 		}
 
+		// Read before the balance, so a posting in between leaves the
+		// response tagged older than it is and the next request fetches it
+		// again, never the other way round.
+		version, err := service.GetBalanceVersion(ctx.Request.Context(), id)
+		if err != nil {
+			return errorResult(err)
+		}
+		if notModified := router.NotModified(ctx, version); notModified != nil {
+			return notModified
+		}
+
 		response, err := service.GetBalance(ctx.Request.Context(), id)
 		if err != nil {
 			return errorResult(err)
 		}
 
-		return router.OKResult(response, "Balance retrieved successfully")
+		return router.OKResult(response, "Balance retrieved successfully").WithETag(version)
+	}
+}
+
+func getAggregateBalanceHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+
+		response, err := service.GetAggregateBalance(ctx.Request.Context(), id)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Aggregate balance retrieved successfully")
+	}
+}
+
+func getDailyBalancesHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+		params, errResult := router.BindQuery[DailyBalancesParams](ctx)
+		if errResult != nil {
+			return errResult
+		}
+		if params.To.IsZero() {
+			params.To = time.Now().UTC().Truncate(24 * time.Hour)
+		}
+		if params.From.IsZero() {
+			params.From = params.To.AddDate(0, 0, -29)
+		}
+
+		response, err := service.GetDailyBalances(ctx.Request.Context(), id, params.From, params.To)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Daily balances retrieved successfully")
+	}
+}
+
+func getStatementHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+		params, errResult := router.BindQuery[StatementParams](ctx)
+		if errResult != nil {
+			return errResult
+		}
+		if params.To.IsZero() {
+			params.To = time.Now().UTC().Truncate(24 * time.Hour)
+		}
+		if params.From.IsZero() {
+			params.From = params.To.AddDate(0, 0, -29)
+		}
+		if params.Format == "" {
+			params.Format = StatementFormatCSV
+		}
+
+		statement, err := service.GetStatement(ctx.Request.Context(), id, params.From, params.To)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		reqCtx := ctx.Request.Context()
+		var result *router.ServiceResult
+		if params.Format == StatementFormatPDF {
+			result = router.StreamResult(http.StatusOK, "application/pdf", func(w io.Writer) error {
+				return statement.WritePDF(reqCtx, w)
+			})
+		} else {
+			result = router.StreamResult(http.StatusOK, "text/csv; charset=utf-8", func(w io.Writer) error {
+				return statement.WriteCSV(reqCtx, w)
+			})
+		}
+		return result.WithAttachment(statement.Filename(params.Format))
 	}
 }
 
@@ -192,21 +885,105 @@ func getTransactionsHandler(service LedgerService) router.HandlerFunction {
 			return router.BadRequestResult("Account ID is required", nil)
 		}
 
-		limit := defaultPageLimit
-		offset := 0
+		params, errResult := router.BindQuery[TransactionListParams](ctx)
+		if errResult != nil {
+			return errResult
+		}
+		page := router.ParsePagination(ctx, defaultPageLimit, maxPageLimit)
 
-		if l := ctx.Query("limit"); l != "" {
-			if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= maxPageLimit {
-				limit = parsed
-			}
+		response, total, err := service.GetTransactions(ctx.Request.Context(), id, page.PerPage, page.Offset, params.Include == "entries")
+		if err != nil {
+			return errorResult(err)
 		}
-		if o := ctx.Query("offset"); o != "" {
-			if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
-				offset = parsed
-			}
+		for i := range response {
+			response[i].Links = transactionLinks(&response[i])
+		}
+
+		result := router.NewPaginatedResult(ctx, response, page, total)
+		return router.OKResult(result, "Transactions retrieved successfully").WithLinkHeader(result.Links)
+	}
+}
+
+func getArchivedTransactionsHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+
+		page := router.ParsePagination(ctx, defaultPageLimit, maxPageLimit)
+
+		response, total, err := service.GetArchivedTransactions(ctx.Request.Context(), id, page.PerPage, page.Offset)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		result := router.NewPaginatedResult(ctx, response, page, total)
+		return router.OKResult(result, "Archived transactions retrieved successfully").WithLinkHeader(result.Links)
+	}
+}
+
+// transactionLinks links a listed transaction to its own resource and to the
+// transaction it reverses.
+func transactionLinks(tx *TransactionResponse) router.Links {
+	links := router.Links{"self": router.ResourceLink(transactionRoute, tx.ID)}
+	if tx.ReversedTransactionID != "" {
+		links["reverses"] = router.ResourceLink(transactionRoute, tx.ReversedTransactionID)
+	}
+	return links
+}
+
+func getTransactionHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Transaction ID is required", nil)
+		}
+
+		response, err := service.GetTransaction(ctx.Request.Context(), id)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Transaction retrieved successfully")
+	}
+}
+
+func getArchivedTransactionHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Transaction ID is required", nil)
+		}
+
+		response, err := service.GetArchivedTransaction(ctx.Request.Context(), id)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Archived transaction retrieved successfully")
+	}
+}
+
+func searchTransactionsHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		params, errResult := router.BindQuery[SearchTransactionsParams](ctx)
+		if errResult != nil {
+			return errResult
 		}
+		q := strings.TrimSpace(params.Q)
+		if q == "" {
+			return router.BadRequestResult("Search text (q) is required", nil)
+		}
+
+		page := router.ParsePagination(ctx, defaultPageLimit, maxPageLimit)
 
-		response, err := service.GetTransactions(ctx.Request.Context(), id, limit, offset)
+		response, err := service.SearchTransactions(ctx.Request.Context(), TransactionSearchQuery{
+			Text:      q,
+			AccountID: params.AccountID,
+			Limit:     page.PerPage,
+			Offset:    page.Offset,
+		})
 		if err != nil {
 			return errorResult(err)
 		}
@@ -215,6 +992,42 @@ func getTransactionsHandler(service LedgerService) router.HandlerFunction {
 	}
 }
 
+func verifyDayHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		path, pathErr := router.BindURI[closingPath](ctx)
+		if pathErr != nil {
+			return pathErr
+		}
+		day, err := time.Parse(time.DateOnly, path.Day)
+		if err != nil {
+			return router.BadRequestResult("day must be a date, 2006-01-02", nil)
+		}
+
+		response, err := service.VerifyDay(ctx.Request.Context(), day)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Day verified successfully")
+	}
+}
+
+func verifyAccountChainHandler(service LedgerService) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if id == "" {
+			return router.BadRequestResult("Account ID is required", nil)
+		}
+
+		response, err := service.VerifyAccountChain(ctx.Request.Context(), id)
+		if err != nil {
+			return errorResult(err)
+		}
+
+		return router.OKResult(response, "Account chain verified successfully")
+	}
+}
+
 func reconciliationHandler(service LedgerService) router.HandlerFunction {
 	return func(ctx *router.RequestContext) *router.ServiceResult {
 		response, err := service.Reconcile(ctx.Request.Context())
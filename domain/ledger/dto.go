@@ -2,7 +2,10 @@ package ledger
 
 import (
 	"cmp"
+	"strconv"
+	"time"
 
+	"github.com/akeren/go-api-foundry/config/router"
 	"github.com/akeren/go-api-foundry/internal/models"
 	"github.com/akeren/go-api-foundry/pkg/constants"
 )
@@ -11,32 +14,191 @@ import (
 // Request DTOs
 // ========================================
 
+// Mutating requests carry DryRun, set by the controller from the dry_run
+// query parameter or X-Dry-Run header: the request is fully validated and
+// executed inside a transaction that is rolled back.
+
+// CreateAccountRequest creates a sub-account when ParentID is set. A
+// sub-account defaults to its parent's currency and must not differ from it.
+// SiblingTransfers applies to the new account's own children.
 type CreateAccountRequest struct {
-	Name     string `json:"name" binding:"required,min=1,max=255"`
-	Currency string `json:"currency" binding:"omitempty,len=3,uppercase"`
+	Name             string `json:"name" binding:"required,min=1,max=255"`
+	Currency         string `json:"currency" binding:"omitempty,len=3,uppercase"`
+	ParentID         string `json:"parent_id" binding:"omitempty,uuid"`
+	SiblingTransfers string `json:"sibling_transfers" binding:"omitempty,oneof=ALLOW DENY"`
+	DryRun           bool   `json:"-"`
+}
+
+type UpdateAccountRequest struct {
+	SiblingTransfers string `json:"sibling_transfers" binding:"required,oneof=ALLOW DENY"`
 }
 
 type DepositRequest struct {
-	Amount         int64  `json:"amount" binding:"required,gt=0"`
+	Amount         int64  `json:"amount" binding:"required,gt=0,safeint"`
 	Currency       string `json:"currency" binding:"omitempty,len=3,uppercase"`
 	IdempotencyKey string `json:"idempotency_key" binding:"required,min=1,max=255"`
 	Description    string `json:"description" binding:"omitempty,max=500"`
+	DryRun         bool   `json:"-"`
 }
 
 type WithdrawRequest struct {
-	Amount         int64  `json:"amount" binding:"required,gt=0"`
+	Amount         int64  `json:"amount" binding:"required,gt=0,safeint"`
 	Currency       string `json:"currency" binding:"omitempty,len=3,uppercase"`
 	IdempotencyKey string `json:"idempotency_key" binding:"required,min=1,max=255"`
 	Description    string `json:"description" binding:"omitempty,max=500"`
+	DryRun         bool   `json:"-"`
 }
 
 type TransferRequest struct {
 	SourceAccountID string `json:"source_account_id" binding:"required,min=1"`
 	DestAccountID   string `json:"dest_account_id" binding:"required,min=1"`
-	Amount          int64  `json:"amount" binding:"required,gt=0"`
+	Amount          int64  `json:"amount" binding:"required,gt=0,safeint"`
 	Currency        string `json:"currency" binding:"omitempty,len=3,uppercase"`
 	IdempotencyKey  string `json:"idempotency_key" binding:"required,min=1,max=255"`
 	Description     string `json:"description" binding:"omitempty,max=500"`
+	// QuoteID charges the fee and exchange rate of an unexpired quote for
+	// the same accounts and amount, instead of the current prices.
+	QuoteID string `json:"quote_id" binding:"omitempty,uuid"`
+	DryRun  bool   `json:"-"`
+}
+
+// ReverseTransactionRequest undoes a transaction with a compensating one.
+type ReverseTransactionRequest struct {
+	IdempotencyKey string `json:"idempotency_key" binding:"required,min=1,max=255"`
+	Description    string `json:"description" binding:"omitempty,max=500"`
+	DryRun         bool   `json:"-"`
+}
+
+// CreateHoldRequest reserves Amount of the account's funds. Capturing the
+// hold transfers to DestAccountID, or withdraws when it is empty.
+type CreateHoldRequest struct {
+	Amount         int64  `json:"amount" binding:"required,gt=0,safeint"`
+	Currency       string `json:"currency" binding:"omitempty,len=3,uppercase"`
+	DestAccountID  string `json:"dest_account_id" binding:"omitempty,uuid"`
+	IdempotencyKey string `json:"idempotency_key" binding:"required,min=1,max=255"`
+	DryRun         bool   `json:"-"`
+}
+
+// CaptureHoldRequest posts a hold. Amount defaults to the whole hold; a
+// smaller amount releases the rest.
+type CaptureHoldRequest struct {
+	Amount         int64  `json:"amount" binding:"omitempty,gt=0,safeint"`
+	IdempotencyKey string `json:"idempotency_key" binding:"required,min=1,max=255"`
+	Description    string `json:"description" binding:"omitempty,max=500"`
+	DryRun         bool   `json:"-"`
+}
+
+// CreateBalanceAlertRequest watches an account's postings. Threshold is in
+// minor units of the account's currency. NotifyEmail, when set, is emailed
+// each time the alert triggers.
+type CreateBalanceAlertRequest struct {
+	Kind        string `json:"kind" binding:"required,oneof=LOW_BALANCE LARGE_DEBIT"`
+	Threshold   int64  `json:"threshold" binding:"required,gt=0,safeint"`
+	NotifyEmail string `json:"notify_email" binding:"omitempty,email,max=254"`
+}
+
+// alertPath is the path of routes under /accounts/:id/alerts/:alert_id.
+type alertPath struct {
+	AccountID string `uri:"id" binding:"required"`
+	AlertID   string `uri:"alert_id" binding:"required,uuid"`
+}
+
+// CreateAccrualPlanRequest accrues interest onto an account, or charges it a
+// fee, each UTC day from StartsOn (default today): the day's closing balance
+// times RateBasisPoints a year, over 365 days.
+type CreateAccrualPlanRequest struct {
+	Kind            string `json:"kind" binding:"required,oneof=INTEREST FEE"`
+	RateBasisPoints int64  `json:"rate_basis_points" binding:"gte=0,lte=10000"`
+	StartsOn        string `json:"starts_on" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// UpdateAccrualPlanRequest changes a plan's rate from EffectiveFrom (default
+// today). Days from then that already accrued are corrected.
+type UpdateAccrualPlanRequest struct {
+	RateBasisPoints *int64 `json:"rate_basis_points" binding:"required,gte=0,lte=10000"`
+	EffectiveFrom   string `json:"effective_from" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// accrualPlanPath is the path of routes under
+// /accounts/:id/accrual-plans/:plan_id.
+type accrualPlanPath struct {
+	AccountID string `uri:"id" binding:"required"`
+	PlanID    string `uri:"plan_id" binding:"required,uuid"`
+}
+
+// closingPath is the path of routes under /closings/:day.
+type closingPath struct {
+	Day string `uri:"day" binding:"required,datetime=2006-01-02"`
+}
+
+// idPath is the path of routes under /transactions/:id and /holds/:id.
+type idPath struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+type TransferQuoteRequest struct {
+	SourceAccountID string `json:"source_account_id" binding:"required,min=1"`
+	DestAccountID   string `json:"dest_account_id" binding:"required,min=1"`
+	Amount          int64  `json:"amount" binding:"required,gt=0,safeint"`
+	Currency        string `json:"currency" binding:"omitempty,len=3,uppercase"`
+}
+
+// BatchTransferRequest runs many transfers as one long-running operation.
+// Each transfer keeps its own idempotency key, so a failed or interrupted
+// batch can be resubmitted as-is.
+type BatchTransferRequest struct {
+	Transfers  []TransferRequest `json:"transfers" binding:"required,min=1,max=1000,dive"`
+	WebhookURL string            `json:"webhook_url" binding:"omitempty,url"`
+}
+
+// SplitTransferRequest moves Amount from one account to several in a single
+// transaction. Each destination takes either a fixed Amount or BasisPoints of
+// what the fixed amounts leave. Basis points then add up to 10000; without
+// them the fixed amounts add up to Amount.
+type SplitTransferRequest struct {
+	SourceAccountID string             `json:"source_account_id" binding:"required,min=1"`
+	Amount          int64              `json:"amount" binding:"required,gt=0,safeint"`
+	Currency        string             `json:"currency" binding:"omitempty,len=3,uppercase"`
+	Destinations    []SplitDestination `json:"destinations" binding:"required,min=2,max=100,dive"`
+	IdempotencyKey  string             `json:"idempotency_key" binding:"required,min=1,max=255"`
+	Description     string             `json:"description" binding:"omitempty,max=500"`
+	DryRun          bool               `json:"-"`
+}
+
+// SplitDestination sets exactly one of Amount and BasisPoints.
+type SplitDestination struct {
+	AccountID   string `json:"account_id" binding:"required,min=1"`
+	Amount      int64  `json:"amount" binding:"omitempty,gt=0,safeint"`
+	BasisPoints int64  `json:"basis_points" binding:"omitempty,gt=0,lte=10000"`
+}
+
+// SearchTransactionsParams is the query string of the transaction search;
+// paging parameters are read by router.ParsePagination.
+type SearchTransactionsParams struct {
+	Q         string `form:"q" binding:"required"`
+	AccountID string `form:"account_id" binding:"omitempty,uuid"`
+}
+
+// TransactionListParams is the query string of a transaction listing,
+// besides paging. Listings summarize entries unless include=entries.
+type TransactionListParams struct {
+	Include string `form:"include" binding:"omitempty,oneof=entries"`
+}
+
+// DailyBalancesParams is the query string of the daily balance and accrual
+// reports. Both dates are UTC days; the range defaults to the 30 days ending
+// today.
+type DailyBalancesParams struct {
+	From time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To   time.Time `form:"to" time_format:"2006-01-02" time_utc:"1" binding:"omitempty,gtefield=From"`
+}
+
+// StatementParams is the query string of a statement download. The dates
+// default as for the daily balance report, and the format to CSV.
+type StatementParams struct {
+	From   time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To     time.Time `form:"to" time_format:"2006-01-02" time_utc:"1" binding:"omitempty,gtefield=From"`
+	Format string    `form:"format" binding:"omitempty,oneof=csv pdf"`
 }
 
 // ========================================
@@ -44,23 +206,42 @@ type TransferRequest struct {
 // ========================================
 
 type AccountResponse struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	AccountType string `json:"account_type"`
-	Currency    string `json:"currency"`
-	Balance     int64  `json:"balance"`
-	CreatedAt   string `json:"created_at"`
+	ID               string `json:"id"`
+	ParentID         string `json:"parent_id,omitempty"`
+	Name             string `json:"name"`
+	AccountType      string `json:"account_type"`
+	Currency         string `json:"currency"`
+	Balance          int64  `json:"balance" access:"owner,admin"`
+	SiblingTransfers string `json:"sibling_transfers"`
+	HomeRegion       string `json:"home_region,omitempty"`
+	CreatedAt        string `json:"created_at"`
+	DryRun           bool   `json:"dry_run,omitempty"`
 }
 
 type TransactionResponse struct {
-	ID              string               `json:"id"`
-	IdempotencyKey  string               `json:"idempotency_key"`
-	TransactionType string               `json:"transaction_type"`
-	Amount          int64                `json:"amount"`
-	Currency        string               `json:"currency"`
-	Description     string               `json:"description"`
-	Entries         []LedgerEntryResponse `json:"entries"`
-	CreatedAt       string               `json:"created_at"`
+	ID                    string                `json:"id"`
+	IdempotencyKey        string                `json:"idempotency_key"`
+	TransactionType       string                `json:"transaction_type"`
+	Amount                int64                 `json:"amount"`
+	Currency              string                `json:"currency"`
+	DestAmount            int64                 `json:"dest_amount"`
+	DestCurrency          string                `json:"dest_currency"`
+	ExchangeRate          string                `json:"exchange_rate"`
+	Fee                   int64                 `json:"fee"`
+	Description           string                `json:"description"`
+	ReversedTransactionID string                `json:"reversed_transaction_id,omitempty"`
+	Entries               []LedgerEntryResponse `json:"entries,omitempty"`
+	Summary               *TransactionSummary   `json:"summary,omitempty"`
+	CreatedAt             string                `json:"created_at"`
+	DryRun                bool                  `json:"dry_run,omitempty"`
+	Links                 router.Links          `json:"_links,omitempty"`
+}
+
+// TransactionSummary stands in for a listed transaction's entries: how many
+// there are and by how much they changed the listed account's balance.
+type TransactionSummary struct {
+	EntryCount int   `json:"entry_count"`
+	NetEffect  int64 `json:"net_effect"`
 }
 
 type LedgerEntryResponse struct {
@@ -68,24 +249,241 @@ type LedgerEntryResponse struct {
 	AccountID    string `json:"account_id"`
 	EntryType    string `json:"entry_type"`
 	Amount       int64  `json:"amount"`
-	BalanceAfter int64  `json:"balance_after"`
+	BalanceAfter int64  `json:"balance_after" access:"owner,admin"`
 	CreatedAt    string `json:"created_at"`
 }
 
+// TransferQuoteResponse previews a transfer. The resulting balances reflect
+// the balances when the quote was made; only the fee and exchange rate are
+// locked by the quote.
+type TransferQuoteResponse struct {
+	QuoteID            string `json:"quote_id"`
+	SourceAccountID    string `json:"source_account_id"`
+	DestAccountID      string `json:"dest_account_id"`
+	Amount             int64  `json:"amount"`
+	Currency           string `json:"currency"`
+	Fee                int64  `json:"fee"`
+	TotalDebit         int64  `json:"total_debit"`
+	ExchangeRate       string `json:"exchange_rate"`
+	DestAmount         int64  `json:"dest_amount"`
+	DestCurrency       string `json:"dest_currency"`
+	SourceBalanceAfter int64  `json:"source_balance_after" access:"owner,admin"`
+	DestBalanceAfter   int64  `json:"dest_balance_after" access:"owner,admin"`
+	ExpiresAt          string `json:"expires_at"`
+}
+
+// SplitTransferResponse is the posted transaction with what each
+// destination received, in request order.
+type SplitTransferResponse struct {
+	TransactionResponse
+	Destinations []SplitDestinationResponse `json:"destinations"`
+}
+
+type SplitDestinationResponse struct {
+	AccountID   string              `json:"account_id"`
+	Amount      int64               `json:"amount"`
+	BasisPoints int64               `json:"basis_points,omitempty"`
+	Entry       LedgerEntryResponse `json:"entry"`
+}
+
+// BalanceResponse reports the posted balance, cached and derived from the
+// entries, and the available balance: the posted balance less active holds.
 type BalanceResponse struct {
-	AccountID      string `json:"account_id"`
-	CachedBalance  int64  `json:"cached_balance"`
-	DerivedBalance int64  `json:"derived_balance"`
-	Currency       string `json:"currency"`
-	IsConsistent   bool   `json:"is_consistent"`
+	AccountID        string `json:"account_id"`
+	CachedBalance    int64  `json:"cached_balance" access:"owner,admin"`
+	DerivedBalance   int64  `json:"derived_balance" access:"owner,admin"`
+	HeldBalance      int64  `json:"held_balance" access:"owner,admin"`
+	AvailableBalance int64  `json:"available_balance" access:"owner,admin"`
+	Currency         string `json:"currency"`
+	IsConsistent     bool   `json:"is_consistent"`
+}
+
+// appendBalanceJSON encodes a BalanceResponse as encoding/json does, without
+// reflection; the balance is read far more than any other route.
+func appendBalanceJSON(dst []byte, b *BalanceResponse) []byte {
+	dst = append(dst, `{"account_id":`...)
+	dst = router.AppendJSONString(dst, b.AccountID)
+	dst = append(dst, `,"cached_balance":`...)
+	dst = strconv.AppendInt(dst, b.CachedBalance, 10)
+	dst = append(dst, `,"derived_balance":`...)
+	dst = strconv.AppendInt(dst, b.DerivedBalance, 10)
+	dst = append(dst, `,"held_balance":`...)
+	dst = strconv.AppendInt(dst, b.HeldBalance, 10)
+	dst = append(dst, `,"available_balance":`...)
+	dst = strconv.AppendInt(dst, b.AvailableBalance, 10)
+	dst = append(dst, `,"currency":`...)
+	dst = router.AppendJSONString(dst, b.Currency)
+	dst = append(dst, `,"is_consistent":`...)
+	dst = strconv.AppendBool(dst, b.IsConsistent)
+	return append(dst, '}')
+}
+
+// HoldResponse describes a hold. A capture includes the transaction it
+// posted.
+type HoldResponse struct {
+	ID             string               `json:"id"`
+	AccountID      string               `json:"account_id"`
+	DestAccountID  string               `json:"dest_account_id,omitempty"`
+	Amount         int64                `json:"amount"`
+	Currency       string               `json:"currency"`
+	Status         string               `json:"status"`
+	IdempotencyKey string               `json:"idempotency_key"`
+	CapturedAmount int64                `json:"captured_amount"`
+	TransactionID  string               `json:"transaction_id,omitempty"`
+	Transaction    *TransactionResponse `json:"transaction,omitempty"`
+	ExpiresAt      string               `json:"expires_at"`
+	CreatedAt      string               `json:"created_at"`
+	DryRun         bool                 `json:"dry_run,omitempty"`
+}
+
+type BalanceAlertResponse struct {
+	ID          string `json:"id"`
+	AccountID   string `json:"account_id"`
+	Kind        string `json:"kind"`
+	Threshold   int64  `json:"threshold" access:"owner,admin"`
+	NotifyEmail string `json:"notify_email,omitempty" access:"owner,admin"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type AccrualPlanResponse struct {
+	ID              string `json:"id"`
+	AccountID       string `json:"account_id"`
+	Kind            string `json:"kind"`
+	RateBasisPoints int64  `json:"rate_basis_points"`
+	StartsOn        string `json:"starts_on"`
+	AccruedThrough  string `json:"accrued_through,omitempty"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+	// Corrections are the revisions an update posted for days it corrected.
+	Corrections []AccrualRecordResponse `json:"corrections,omitempty"`
+}
+
+// AccrualRecordResponse is one revision of a day's accrual and the inputs
+// it was calculated from. ClosingBalance is the day's closing balance when
+// it was calculated; Basis takes out the accruals that balance included and
+// puts in the plan's own for earlier days. Amount is Basis times
+// RateBasisPoints over 10000 and DaysInYear, rounded half up.
+type AccrualRecordResponse struct {
+	ID                    string `json:"id"`
+	PlanID                string `json:"plan_id"`
+	Day                   string `json:"day"`
+	Revision              int    `json:"revision"`
+	Kind                  string `json:"kind"`
+	ClosingBalance        int64  `json:"closing_balance" access:"owner,admin"`
+	Basis                 int64  `json:"basis" access:"owner,admin"`
+	RateBasisPoints       int64  `json:"rate_basis_points"`
+	DaysInYear            int64  `json:"days_in_year"`
+	Amount                int64  `json:"amount"`
+	Currency              string `json:"currency"`
+	TransactionID         string `json:"transaction_id,omitempty"`
+	ReversalTransactionID string `json:"reversal_transaction_id,omitempty"`
+	SupersededAt          string `json:"superseded_at,omitempty"`
+	CreatedAt             string `json:"created_at"`
+}
+
+type AccrualRecordsResponse struct {
+	AccountID string                  `json:"account_id"`
+	From      string                  `json:"from"`
+	To        string                  `json:"to"`
+	Records   []AccrualRecordResponse `json:"records"`
+}
+
+// DayVerificationResponse compares a sealed day's seal with its entries as
+// they are now. Valid is false, and Problems says why, when anything sealed
+// has changed since.
+type DayVerificationResponse struct {
+	Day                string   `json:"day"`
+	SealedAt           string   `json:"sealed_at"`
+	EntryCount         int64    `json:"entry_count"`
+	PreviousDigest     string   `json:"previous_digest"`
+	Digest             string   `json:"digest"`
+	ComputedEntryCount int64    `json:"computed_entry_count"`
+	ComputedDigest     string   `json:"computed_digest"`
+	Valid              bool     `json:"valid"`
+	Problems           []string `json:"problems,omitempty"`
+}
+
+// AccountChainVerificationResponse checks an account's entries against their
+// hash chain. Entries counts the hashed entries and UnchainedEntries those
+// posted before hashing was introduced. Valid is false, and Problems says
+// why, when an entry was changed, added or removed outside the ledger.
+type AccountChainVerificationResponse struct {
+	AccountID        string   `json:"account_id"`
+	Entries          int64    `json:"entries"`
+	UnchainedEntries int64    `json:"unchained_entries"`
+	LastEntryHash    string   `json:"last_entry_hash"`
+	Valid            bool     `json:"valid"`
+	Problems         []string `json:"problems,omitempty"`
+}
+
+// AccountRechainResponse reports a rechain: how many of the account's entries
+// had their hashes rewritten, and where its chain ended before and after.
+type AccountRechainResponse struct {
+	AccountID             string `json:"account_id"`
+	Entries               int    `json:"entries"`
+	Rewritten             int    `json:"rewritten"`
+	PreviousLastEntryHash string `json:"previous_last_entry_hash"`
+	LastEntryHash         string `json:"last_entry_hash"`
+}
+
+// AggregateBalanceResponse reports an account's own balance and the total of
+// its whole sub-account tree, with one entry per direct child.
+type AggregateBalanceResponse struct {
+	AccountID       string              `json:"account_id"`
+	Currency        string              `json:"currency"`
+	Balance         int64               `json:"balance" access:"owner,admin"`
+	TotalBalance    int64               `json:"total_balance" access:"owner,admin"`
+	SubAccountCount int                 `json:"sub_account_count"`
+	Children        []SubAccountBalance `json:"children"`
+}
+
+type SubAccountBalance struct {
+	AccountID       string `json:"account_id"`
+	Name            string `json:"name"`
+	Balance         int64  `json:"balance" access:"owner,admin"`
+	TotalBalance    int64  `json:"total_balance" access:"owner,admin"`
+	SubAccountCount int    `json:"sub_account_count"`
+}
+
+// DailyBalancesResponse reports an account's credits, debits and closing
+// balance for each UTC day in From..To that had entries. It is read from a
+// materialized view, so the most recent entries may not be counted yet.
+type DailyBalancesResponse struct {
+	AccountID string                 `json:"account_id"`
+	Currency  string                 `json:"currency"`
+	From      string                 `json:"from"`
+	To        string                 `json:"to"`
+	Days      []DailyBalanceResponse `json:"days"`
+}
+
+type DailyBalanceResponse struct {
+	Date           string `json:"date"`
+	Credits        int64  `json:"credits" access:"owner,admin"`
+	Debits         int64  `json:"debits" access:"owner,admin"`
+	EntryCount     int64  `json:"entry_count"`
+	ClosingBalance int64  `json:"closing_balance" access:"owner,admin"`
 }
 
 type ReconciliationResponse struct {
-	Accounts      []AccountReconciliation `json:"accounts"`
-	AllConsistent bool                    `json:"all_consistent"`
-	TotalDebits   int64                  `json:"total_debits"`
-	TotalCredits  int64                  `json:"total_credits"`
-	LedgerBalanced bool                  `json:"ledger_balanced"`
+	Accounts       []AccountReconciliation `json:"accounts"`
+	AllConsistent  bool                    `json:"all_consistent"`
+	TotalDebits    int64                   `json:"total_debits"`
+	TotalCredits   int64                   `json:"total_credits"`
+	LedgerBalanced bool                    `json:"ledger_balanced"`
+}
+
+type BatchTransferItemResult struct {
+	IdempotencyKey string `json:"idempotency_key"`
+	TransactionID  string `json:"transaction_id,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// BatchTransferResult is the result of a batch transfer operation, with one
+// item per submitted transfer in request order.
+type BatchTransferResult struct {
+	Succeeded int                       `json:"succeeded"`
+	Failed    int                       `json:"failed"`
+	Items     []BatchTransferItemResult `json:"items"`
 }
 
 // ========================================
@@ -93,21 +491,117 @@ type ReconciliationResponse struct {
 // ========================================
 
 func ToAccountModel(req *CreateAccountRequest) *models.Account {
-	return &models.Account{
-		Name:        req.Name,
-		AccountType: models.AccountTypeUser,
-		Currency:    cmp.Or(req.Currency, "USD"),
+	account := &models.Account{
+		Name:             req.Name,
+		AccountType:      models.AccountTypeUser,
+		Currency:         cmp.Or(req.Currency, "USD"),
+		SiblingTransfers: cmp.Or(req.SiblingTransfers, models.SiblingTransfersAllow),
 	}
+	if req.ParentID != "" {
+		account.ParentID = &req.ParentID
+	}
+	return account
 }
 
 func ToAccountResponse(acc *models.Account) AccountResponse {
-	return AccountResponse{
-		ID:          acc.ID,
-		Name:        acc.Name,
-		AccountType: acc.AccountType,
-		Currency:    acc.Currency,
-		Balance:     acc.Balance,
-		CreatedAt:   acc.CreatedAt.Format(constants.RFC3339DateTimeFormat),
+	resp := AccountResponse{
+		ID:               acc.ID,
+		Name:             acc.Name,
+		AccountType:      acc.AccountType,
+		Currency:         acc.Currency,
+		Balance:          acc.Balance,
+		SiblingTransfers: acc.SiblingTransfers,
+		HomeRegion:       acc.HomeRegion,
+		CreatedAt:        acc.CreatedAt.Format(constants.RFC3339DateTimeFormat),
+	}
+	if acc.ParentID != nil {
+		resp.ParentID = *acc.ParentID
+	}
+	return resp
+}
+
+func ToHoldResponse(hold *models.Hold) HoldResponse {
+	resp := HoldResponse{
+		ID:             hold.ID,
+		AccountID:      hold.AccountID,
+		Amount:         hold.Amount,
+		Currency:       hold.Currency,
+		Status:         hold.Status,
+		IdempotencyKey: hold.IdempotencyKey,
+		CapturedAmount: hold.CapturedAmount,
+		ExpiresAt:      hold.ExpiresAt.Format(constants.RFC3339DateTimeFormat),
+		CreatedAt:      hold.CreatedAt.Format(constants.RFC3339DateTimeFormat),
+	}
+	if hold.DestAccountID != nil {
+		resp.DestAccountID = *hold.DestAccountID
+	}
+	if hold.TransactionID != nil {
+		resp.TransactionID = *hold.TransactionID
+	}
+	return resp
+}
+
+func ToBalanceAlertResponse(alert *models.BalanceAlert) BalanceAlertResponse {
+	return BalanceAlertResponse{
+		ID:          alert.ID,
+		AccountID:   alert.AccountID,
+		Kind:        alert.Kind,
+		Threshold:   alert.Threshold,
+		NotifyEmail: alert.NotifyEmail,
+		CreatedAt:   alert.CreatedAt.Format(constants.RFC3339DateTimeFormat),
+	}
+}
+
+func ToAccrualPlanResponse(plan *models.AccrualPlan) AccrualPlanResponse {
+	resp := AccrualPlanResponse{
+		ID:              plan.ID,
+		AccountID:       plan.AccountID,
+		Kind:            plan.Kind,
+		RateBasisPoints: plan.RateBasisPoints,
+		StartsOn:        plan.StartsOn.Format(time.DateOnly),
+		CreatedAt:       plan.CreatedAt.Format(constants.RFC3339DateTimeFormat),
+		UpdatedAt:       plan.UpdatedAt.Format(constants.RFC3339DateTimeFormat),
+	}
+	if plan.AccruedThrough != nil {
+		resp.AccruedThrough = plan.AccruedThrough.Format(time.DateOnly)
+	}
+	return resp
+}
+
+func ToAccrualRecordResponse(record *models.AccrualRecord) AccrualRecordResponse {
+	resp := AccrualRecordResponse{
+		ID:              record.ID,
+		PlanID:          record.PlanID,
+		Day:             record.Day.Format(time.DateOnly),
+		Revision:        record.Revision,
+		Kind:            record.Kind,
+		ClosingBalance:  record.ClosingBalance,
+		Basis:           record.Basis,
+		RateBasisPoints: record.RateBasisPoints,
+		DaysInYear:      record.DaysInYear,
+		Amount:          record.Amount,
+		Currency:        record.Currency,
+		CreatedAt:       record.CreatedAt.Format(constants.RFC3339DateTimeFormat),
+	}
+	if record.TransactionID != nil {
+		resp.TransactionID = *record.TransactionID
+	}
+	if record.ReversalTransactionID != nil {
+		resp.ReversalTransactionID = *record.ReversalTransactionID
+	}
+	if record.SupersededAt != nil {
+		resp.SupersededAt = record.SupersededAt.Format(constants.RFC3339DateTimeFormat)
+	}
+	return resp
+}
+
+func ToDayVerificationResponse(seal *models.DailySeal) DayVerificationResponse {
+	return DayVerificationResponse{
+		Day:            seal.Day.Format(time.DateOnly),
+		SealedAt:       seal.SealedAt.Format(constants.RFC3339DateTimeFormat),
+		EntryCount:     seal.EntryCount,
+		PreviousDigest: seal.PreviousDigest,
+		Digest:         seal.Digest,
 	}
 }
 
@@ -116,16 +610,40 @@ func ToTransactionResponse(txn *models.Transaction) TransactionResponse {
 	for _, e := range txn.Entries {
 		entries = append(entries, ToLedgerEntryResponse(&e))
 	}
-	return TransactionResponse{
+	resp := TransactionResponse{
 		ID:              txn.ID,
 		IdempotencyKey:  txn.IdempotencyKey,
 		TransactionType: txn.TransactionType,
 		Amount:          txn.Amount,
 		Currency:        txn.Currency,
+		DestAmount:      txn.DestAmount,
+		DestCurrency:    txn.DestCurrency,
+		ExchangeRate:    txn.ExchangeRate,
+		Fee:             txn.Fee,
 		Description:     txn.Description,
 		Entries:         entries,
 		CreatedAt:       txn.CreatedAt.Format(constants.RFC3339DateTimeFormat),
 	}
+	if txn.ReversedTransactionID != nil {
+		resp.ReversedTransactionID = *txn.ReversedTransactionID
+	}
+	return resp
+}
+
+// summarizeEntries summarizes entries for accountID: credits to it add to
+// the net effect and debits from it subtract.
+func summarizeEntries(accountID string, entries []models.LedgerEntry) TransactionSummary {
+	summary := TransactionSummary{EntryCount: len(entries)}
+	for _, e := range entries {
+		switch {
+		case e.AccountID != accountID:
+		case e.EntryType == models.EntryTypeCredit:
+			summary.NetEffect += e.Amount
+		default:
+			summary.NetEffect -= e.Amount
+		}
+	}
+	return summary
 }
 
 func ToLedgerEntryResponse(entry *models.LedgerEntry) LedgerEntryResponse {
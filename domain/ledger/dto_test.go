@@ -0,0 +1,22 @@
+package ledger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAppendBalanceJSON_MatchesEncodingJSON(t *testing.T) {
+	for _, balance := range []BalanceResponse{
+		{},
+		{AccountID: "acc-1", CachedBalance: 1500, DerivedBalance: 1500, HeldBalance: 200, AvailableBalance: 1300, Currency: "NGN", IsConsistent: true},
+		{AccountID: "<acc & 2>", CachedBalance: -9007199254740991, DerivedBalance: 3, Currency: "US D"},
+	} {
+		want, err := json.Marshal(balance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := appendBalanceJSON(nil, &balance); string(got) != string(want) {
+			t.Fatalf("encoded\n%s\nwant\n%s", got, want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package ledger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/region"
+)
+
+// maxChainProblems caps the problems VerifyAccountChain lists; one broken
+// entry usually breaks every link after it.
+const maxChainProblems = 20
+
+// entryHash is the hex SHA-256 of
+// "<prev hash>|<id>|<transaction id>|<account id>|<entry type>|<amount>|<balance after>|<created at>",
+// the time in RFC 3339 UTC with only the fractional digits it needs, as
+// dayChain formats it.
+func entryHash(prevHash string, entry *models.LedgerEntry) string {
+	buf := make([]byte, 0, 256)
+	buf = append(buf, prevHash...)
+	for _, field := range []string{
+		entry.ID,
+		entry.TransactionID,
+		entry.AccountID,
+		entry.EntryType,
+		strconv.FormatInt(entry.Amount, 10),
+		strconv.FormatInt(entry.BalanceAfter, 10),
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	} {
+		buf = append(append(buf, '|'), field...)
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// chainEntries links a posting's entries, in order, onto the chains of the
+// accounts they post to, and moves each account's LastEntryHash to its last
+// one. The accounts must be locked, so no other posting extends their chains
+// meanwhile, and saved with saveBalance after the entries are created.
+func chainEntries(entries []models.LedgerEntry, accounts map[string]*models.Account) {
+	for i := range entries {
+		entry := &entries[i]
+		if entry.ID == "" {
+			entry.ID = region.NewID()
+		}
+		acc := accounts[entry.AccountID]
+		entry.PrevHash = acc.LastEntryHash
+		entry.EntryHash = entryHash(entry.PrevHash, entry)
+		acc.LastEntryHash = entry.EntryHash
+	}
+}
+
+// rechainEntries recomputes the chain of an account's entries, sorted by
+// created_at and id, and returns the indexes of the entries whose hashes
+// changed and the hash the chain now ends at. Entries of one posting share
+// a time; they are kept in the order their links give where those are
+// intact, so an intact chain is left as it is.
+func rechainEntries(entries []models.LedgerEntry) (changed []int, last string) {
+	for start := 0; start < len(entries); {
+		end := start + 1
+		for end < len(entries) && entries[end].CreatedAt.Equal(entries[start].CreatedAt) {
+			end++
+		}
+		for i := start; i < end; i++ {
+			next := slices.IndexFunc(entries[i:end], func(e models.LedgerEntry) bool {
+				return e.EntryHash != "" && e.PrevHash == last
+			})
+			if next > 0 {
+				entries[i], entries[i+next] = entries[i+next], entries[i]
+			}
+			entry := &entries[i]
+			hash := entryHash(last, entry)
+			if entry.PrevHash != last || entry.EntryHash != hash {
+				entry.PrevHash, entry.EntryHash = last, hash
+				changed = append(changed, i)
+			}
+			last = hash
+		}
+		start = end
+	}
+	return changed, last
+}
+
+// VerifyAccountChain checks an account's entries, live and archived, against
+// their hash chain: each entry must hash to its entry_hash, the chain must
+// run from the account's first entry through every hashed entry without
+// forking, and the account's last entry hash must be on it. Entries posted
+// before hashing was introduced are counted, not checked, but none may come
+// after the chain starts. Entries posted while the check runs extend the
+// chain past the account's last entry hash and are checked too.
+func (s *ledgerService) VerifyAccountChain(ctx context.Context, accountID string) (*AccountChainVerificationResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	account, err := s.repository.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &AccountChainVerificationResponse{AccountID: account.ID, LastEntryHash: account.LastEntryHash}
+	problem := func(format string, args ...any) {
+		if len(resp.Problems) < maxChainProblems {
+			resp.Problems = append(resp.Problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	// next maps each hash to the entry chained onto it.
+	next := make(map[string]*models.LedgerEntry)
+	var chainStart time.Time
+	var unchained []*models.LedgerEntry
+	err = s.repository.StreamAccountEntries(ctx, accountID, func(entry *models.LedgerEntry) error {
+		if entry.EntryHash == "" {
+			resp.UnchainedEntries++
+			unchained = append(unchained, entry)
+			return nil
+		}
+		resp.Entries++
+		if chainStart.IsZero() {
+			chainStart = entry.CreatedAt
+		}
+		if entryHash(entry.PrevHash, entry) != entry.EntryHash {
+			problem("entry %s does not hash to its entry_hash", entry.ID)
+		}
+		if other, ok := next[entry.PrevHash]; ok {
+			problem("entries %s and %s both follow the same entry", other.ID, entry.ID)
+			return nil
+		}
+		next[entry.PrevHash] = entry
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to read account entries", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	for _, entry := range unchained {
+		if !chainStart.IsZero() && entry.CreatedAt.After(chainStart) {
+			problem("entry %s has no hash but was posted after the chain started", entry.ID)
+		}
+	}
+
+	var linked int64
+	reachedLast := account.LastEntryHash == ""
+	for hash := ""; ; {
+		entry, ok := next[hash]
+		if !ok {
+			break
+		}
+		linked++
+		hash = entry.EntryHash
+		reachedLast = reachedLast || hash == account.LastEntryHash
+	}
+	if linked < int64(len(next)) {
+		problem("%d entries are not linked to the account's first entry", int64(len(next))-linked)
+	}
+	if !reachedLast {
+		problem("the account's last entry hash is not on the chain")
+	}
+
+	resp.Valid = len(resp.Problems) == 0
+	if !resp.Valid {
+		logger.Warn("Account failed hash chain verification", "account_id", accountID, "problems", resp.Problems)
+	}
+	return resp, nil
+}
+
+// RechainAccount recomputes an account's hash chain from its entries as they
+// are now, after a correction made outside the ledger has been approved. It
+// is a recovery step: the chain no longer shows what was changed, so the
+// correction must be recorded elsewhere first. It also chains entries posted
+// before hashing was introduced.
+func (s *ledgerService) RechainAccount(ctx context.Context, accountID string) (*AccountRechainResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	rechain, err := s.repository.RechainAccount(ctx, accountID)
+	if err != nil {
+		logger.Error("Failed to rechain account", "account_id", accountID, "error", err)
+		return nil, err
+	}
+	logger.Warn("Account rechained",
+		"account_id", accountID,
+		"rewritten", rechain.Rewritten,
+		"previous_last_entry_hash", rechain.PreviousLastEntryHash,
+		"last_entry_hash", rechain.LastEntryHash)
+	return &AccountRechainResponse{
+		AccountID:             accountID,
+		Entries:               rechain.Entries,
+		Rewritten:             rechain.Rewritten,
+		PreviousLastEntryHash: rechain.PreviousLastEntryHash,
+		LastEntryHash:         rechain.LastEntryHash,
+	}, nil
+}
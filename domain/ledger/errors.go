@@ -11,4 +11,43 @@ var (
 	ErrInvalidAmount          = errors.New("amount must be greater than zero")
 	ErrIdempotencyConflict    = errors.New("idempotency key already used with different parameters")
 	ErrSystemAccountForbidden = errors.New("operations on the system account are not allowed")
+	ErrQuoteNotFound          = errors.New("quote not found")
+	ErrQuoteExpired           = errors.New("quote has expired")
+	ErrQuoteUsed              = errors.New("quote has already been used")
+	ErrQuoteMismatch          = errors.New("transfer does not match the quote")
+	ErrParentAccountNotFound  = errors.New("parent account not found")
+	ErrHierarchyTooDeep       = errors.New("account hierarchy is too deep")
+	ErrSiblingTransferBlocked = errors.New("transfers between these sibling accounts are not allowed")
+	ErrWrongRegion            = errors.New("account is homed in another region")
+	ErrExchangeRateNotFound   = errors.New("no current exchange rate between these currencies")
+	ErrTransactionNotFound    = errors.New("transaction not found")
+	ErrAlreadyReversed        = errors.New("transaction has already been reversed")
+	ErrReversalNotReversible  = errors.New("a reversal cannot be reversed")
+	ErrHoldNotFound           = errors.New("hold not found")
+	ErrHoldNotActive          = errors.New("hold has already been captured, released or expired")
+	ErrHoldExpired            = errors.New("hold has expired")
+	ErrCaptureExceedsHold     = errors.New("capture amount exceeds the hold")
+	ErrHoldMismatch           = errors.New("capture does not match the hold")
+	ErrConcurrentUpdate       = errors.New("account is being updated by other requests; retry")
+	ErrInvalidSplit           = errors.New("split allocations must add up to the amount")
+	ErrDuplicateDestination   = errors.New("each destination may appear only once in a split")
+	ErrBalanceAlertNotFound   = errors.New("balance alert not found")
+	ErrAccrualPlanNotFound    = errors.New("accrual plan not found")
+	ErrAccrualNotReversible   = errors.New("accruals are corrected by changing their plan's rate, not reversed")
+	ErrDayNotSealed           = errors.New("day has not been sealed")
 )
+
+// WrongRegionError is returned for writes to an account homed in another
+// region. It matches ErrWrongRegion.
+type WrongRegionError struct {
+	AccountID  string
+	HomeRegion string
+}
+
+func (e *WrongRegionError) Error() string {
+	return ErrWrongRegion.Error() + ": " + e.HomeRegion
+}
+
+func (e *WrongRegionError) Is(target error) bool {
+	return target == ErrWrongRegion
+}
@@ -0,0 +1,149 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/events"
+)
+
+// Ledger event types published on the event bus. The payload of each is the
+// matching *Event struct below; fields are only ever added, never renamed.
+const (
+	EventAccountCreated              = "account.created"
+	EventTransactionPosted           = "transaction.posted"
+	EventReconciliationDriftDetected = "reconciliation.drift_detected"
+	EventBalanceAlertTriggered       = "balance.alert_triggered"
+)
+
+type AccountCreatedEvent struct {
+	AccountID   string    `json:"account_id"`
+	ParentID    string    `json:"parent_id,omitempty"`
+	AccountType string    `json:"account_type"`
+	Currency    string    `json:"currency"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TransactionPostedEvent is published for every committed deposit,
+// withdrawal, transfer, split transfer and reversal. Amounts are in minor
+// units; the destination receives DestAmount of DestCurrency. A reversal's
+// source and destination are the original transaction's destination and
+// source. A split transfer has no DestAccountID; DestAccountIDs lists its
+// destinations.
+type TransactionPostedEvent struct {
+	TransactionID         string    `json:"transaction_id"`
+	TransactionType       string    `json:"transaction_type"`
+	SourceAccountID       string    `json:"source_account_id"`
+	DestAccountID         string    `json:"dest_account_id"`
+	DestAccountIDs        []string  `json:"dest_account_ids,omitempty"`
+	Amount                int64     `json:"amount"`
+	Fee                   int64     `json:"fee"`
+	Currency              string    `json:"currency"`
+	DestAmount            int64     `json:"dest_amount"`
+	DestCurrency          string    `json:"dest_currency"`
+	ExchangeRate          string    `json:"exchange_rate"`
+	PostedAt              time.Time `json:"posted_at"`
+	ReversedTransactionID string    `json:"reversed_transaction_id,omitempty"`
+}
+
+// BalanceAlertTriggeredEvent is published after the posting that triggered
+// an account's balance alert commits. Balance is the account's balance after
+// the posting; Amount is the debit that triggered a LARGE_DEBIT alert.
+type BalanceAlertTriggeredEvent struct {
+	TriggerID     string    `json:"trigger_id"`
+	AlertID       string    `json:"alert_id"`
+	AccountID     string    `json:"account_id"`
+	TransactionID string    `json:"transaction_id"`
+	Kind          string    `json:"kind"`
+	Threshold     int64     `json:"threshold"`
+	Amount        int64     `json:"amount,omitempty"`
+	Balance       int64     `json:"balance"`
+	Currency      string    `json:"currency"`
+	TriggeredAt   time.Time `json:"triggered_at"`
+}
+
+// ReconciliationDriftDetectedEvent lists the accounts whose cached balance
+// disagrees with their entries, and whether debits still equal credits.
+type ReconciliationDriftDetectedEvent struct {
+	Accounts       []AccountReconciliation `json:"accounts"`
+	TotalDebits    int64                   `json:"total_debits"`
+	TotalCredits   int64                   `json:"total_credits"`
+	LedgerBalanced bool                    `json:"ledger_balanced"`
+}
+
+func (s *ledgerService) publish(ctx context.Context, event events.Event) {
+	if s.events != nil {
+		s.events.Publish(ctx, event)
+	}
+}
+
+func (s *ledgerService) publishAccountCreated(ctx context.Context, account *models.Account) {
+	data := AccountCreatedEvent{
+		AccountID:   account.ID,
+		AccountType: account.AccountType,
+		Currency:    account.Currency,
+		CreatedAt:   account.CreatedAt,
+	}
+	if account.ParentID != nil {
+		data.ParentID = *account.ParentID
+	}
+	s.publish(ctx, events.Event{ID: EventAccountCreated + ":" + account.ID, Type: EventAccountCreated, Data: data})
+}
+
+// publishTransactionPosted keys the event ID on the transaction, so an
+// idempotent replay republishes the same event and subscribers can drop it.
+// The system side of a deposit or withdrawal is reported as the system
+// account of the transaction's currency.
+func (s *ledgerService) publishTransactionPosted(ctx context.Context, sourceID, destID string, txn *models.Transaction) {
+	if sourceID == models.SystemAccountID {
+		sourceID = models.SystemAccountIDFor(txn.Currency)
+	}
+	if destID == models.SystemAccountID {
+		destID = models.SystemAccountIDFor(txn.Currency)
+	}
+	data := TransactionPostedEvent{
+		TransactionID:   txn.ID,
+		TransactionType: txn.TransactionType,
+		SourceAccountID: sourceID,
+		DestAccountID:   destID,
+		Amount:          txn.Amount,
+		Fee:             txn.Fee,
+		Currency:        txn.Currency,
+		DestAmount:      txn.DestAmount,
+		DestCurrency:    txn.DestCurrency,
+		ExchangeRate:    txn.ExchangeRate,
+		PostedAt:        txn.CreatedAt,
+	}
+	if txn.ReversedTransactionID != nil {
+		data.ReversedTransactionID = *txn.ReversedTransactionID
+	}
+	if txn.TransactionType == models.TransactionTypeSplitTransfer {
+		for _, e := range txn.Entries {
+			if e.EntryType == models.EntryTypeCredit && !models.IsSystemAccountID(e.AccountID) {
+				data.DestAccountIDs = append(data.DestAccountIDs, e.AccountID)
+			}
+		}
+	}
+	s.publish(ctx, events.Event{ID: EventTransactionPosted + ":" + txn.ID, Type: EventTransactionPosted, Data: data})
+	s.publishAlertsTriggered(ctx, txn)
+}
+
+// publishAlertsTriggered publishes the balance alerts txn triggered, keyed on
+// the trigger so a replay republishes the same events.
+func (s *ledgerService) publishAlertsTriggered(ctx context.Context, txn *models.Transaction) {
+	for _, t := range txn.AlertTriggers {
+		s.publish(ctx, events.Event{ID: EventBalanceAlertTriggered + ":" + t.ID, Type: EventBalanceAlertTriggered, Data: BalanceAlertTriggeredEvent{
+			TriggerID:     t.ID,
+			AlertID:       t.AlertID,
+			AccountID:     t.AccountID,
+			TransactionID: t.TransactionID,
+			Kind:          t.Kind,
+			Threshold:     t.Threshold,
+			Amount:        t.Amount,
+			Balance:       t.Balance,
+			Currency:      t.Currency,
+			TriggeredAt:   t.CreatedAt,
+		}})
+	}
+}
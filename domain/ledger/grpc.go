@@ -0,0 +1,180 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/akeren/go-api-foundry/config/grpcserver"
+	"github.com/akeren/go-api-foundry/domain/ledger/ledgerpb"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServer serves ledgerpb.LedgerService for internal callers. Requests are
+// validated with the same rules as the HTTP API and domain errors map to the
+// gRPC equivalents of their HTTP statuses. Responses are not filtered by
+// field access rules, so the gRPC port must only be reachable by trusted
+// services.
+type GRPCServer struct {
+	ledgerpb.UnimplementedLedgerServiceServer
+	service LedgerService
+}
+
+func NewGRPCServer(service LedgerService) *GRPCServer {
+	return &GRPCServer{service: service}
+}
+
+func (s *GRPCServer) CreateAccount(ctx context.Context, in *ledgerpb.CreateAccountRequest) (*ledgerpb.Account, error) {
+	req := &CreateAccountRequest{
+		Name:             in.GetName(),
+		Currency:         in.GetCurrency(),
+		ParentID:         in.GetParentId(),
+		SiblingTransfers: in.GetSiblingTransfers(),
+		DryRun:           in.GetDryRun(),
+	}
+	if err := validateGRPC(req); err != nil {
+		return nil, err
+	}
+	resp, err := s.service.CreateAccount(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toAccountPB(resp), nil
+}
+
+func (s *GRPCServer) GetAccount(ctx context.Context, in *ledgerpb.GetAccountRequest) (*ledgerpb.Account, error) {
+	resp, err := s.service.GetAccount(ctx, in.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toAccountPB(resp), nil
+}
+
+func (s *GRPCServer) GetBalance(ctx context.Context, in *ledgerpb.GetBalanceRequest) (*ledgerpb.Balance, error) {
+	resp, err := s.service.GetBalance(ctx, in.GetAccountId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &ledgerpb.Balance{
+		AccountId:      resp.AccountID,
+		CachedBalance:  resp.CachedBalance,
+		DerivedBalance: resp.DerivedBalance,
+		Currency:       resp.Currency,
+		IsConsistent:   resp.IsConsistent,
+	}, nil
+}
+
+func (s *GRPCServer) Deposit(ctx context.Context, in *ledgerpb.DepositRequest) (*ledgerpb.Transaction, error) {
+	req := &DepositRequest{
+		Amount:         in.GetAmount(),
+		Currency:       in.GetCurrency(),
+		IdempotencyKey: in.GetIdempotencyKey(),
+		Description:    in.GetDescription(),
+		DryRun:         in.GetDryRun(),
+	}
+	if err := validateGRPC(req); err != nil {
+		return nil, err
+	}
+	resp, err := s.service.Deposit(ctx, in.GetAccountId(), req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toTransactionPB(resp), nil
+}
+
+func (s *GRPCServer) Withdraw(ctx context.Context, in *ledgerpb.WithdrawRequest) (*ledgerpb.Transaction, error) {
+	req := &WithdrawRequest{
+		Amount:         in.GetAmount(),
+		Currency:       in.GetCurrency(),
+		IdempotencyKey: in.GetIdempotencyKey(),
+		Description:    in.GetDescription(),
+		DryRun:         in.GetDryRun(),
+	}
+	if err := validateGRPC(req); err != nil {
+		return nil, err
+	}
+	resp, err := s.service.Withdraw(ctx, in.GetAccountId(), req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toTransactionPB(resp), nil
+}
+
+func (s *GRPCServer) Transfer(ctx context.Context, in *ledgerpb.TransferRequest) (*ledgerpb.Transaction, error) {
+	req := &TransferRequest{
+		SourceAccountID: in.GetSourceAccountId(),
+		DestAccountID:   in.GetDestAccountId(),
+		Amount:          in.GetAmount(),
+		Currency:        in.GetCurrency(),
+		IdempotencyKey:  in.GetIdempotencyKey(),
+		Description:     in.GetDescription(),
+		QuoteID:         in.GetQuoteId(),
+		DryRun:          in.GetDryRun(),
+	}
+	if err := validateGRPC(req); err != nil {
+		return nil, err
+	}
+	resp, err := s.service.Transfer(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toTransactionPB(resp), nil
+}
+
+// validateGRPC applies the request's binding rules, as router.BindJSON does
+// for the HTTP handlers.
+func validateGRPC(req any) error {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return grpcserver.InvalidArgument("Invalid request payload", apperrors.FormatValidationErrors(err, req))
+	}
+	return nil
+}
+
+func grpcError(err error) error {
+	code, msg := mapDomainError(err)
+	return status.Error(grpcserver.CodeFromHTTPStatus(code), msg)
+}
+
+func toAccountPB(resp *AccountResponse) *ledgerpb.Account {
+	return &ledgerpb.Account{
+		Id:               resp.ID,
+		ParentId:         resp.ParentID,
+		Name:             resp.Name,
+		AccountType:      resp.AccountType,
+		Currency:         resp.Currency,
+		Balance:          resp.Balance,
+		SiblingTransfers: resp.SiblingTransfers,
+		HomeRegion:       resp.HomeRegion,
+		CreatedAt:        resp.CreatedAt,
+		DryRun:           resp.DryRun,
+	}
+}
+
+func toTransactionPB(resp *TransactionResponse) *ledgerpb.Transaction {
+	entries := make([]*ledgerpb.LedgerEntry, 0, len(resp.Entries))
+	for _, e := range resp.Entries {
+		entries = append(entries, &ledgerpb.LedgerEntry{
+			Id:           e.ID,
+			AccountId:    e.AccountID,
+			EntryType:    e.EntryType,
+			Amount:       e.Amount,
+			BalanceAfter: e.BalanceAfter,
+			CreatedAt:    e.CreatedAt,
+		})
+	}
+	return &ledgerpb.Transaction{
+		Id:              resp.ID,
+		IdempotencyKey:  resp.IdempotencyKey,
+		TransactionType: resp.TransactionType,
+		Amount:          resp.Amount,
+		Currency:        resp.Currency,
+		Fee:             resp.Fee,
+		Description:     resp.Description,
+		Entries:         entries,
+		CreatedAt:       resp.CreatedAt,
+		DryRun:          resp.DryRun,
+		DestAmount:      resp.DestAmount,
+		DestCurrency:    resp.DestCurrency,
+		ExchangeRate:    resp.ExchangeRate,
+	}
+}
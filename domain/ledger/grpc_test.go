@@ -0,0 +1,96 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/domain/ledger/ledgerpb"
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCServer_Deposit(t *testing.T) {
+	mockRepo, service := newTestService(t)
+	server := NewGRPCServer(service)
+
+	mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, cmd DoubleEntryCommand) (*models.Transaction, error) {
+			assert.Equal(t, "acc-1", cmd.DestAccountID)
+			assert.Equal(t, int64(5000), cmd.Amount)
+			assert.Equal(t, "dep-1", cmd.IdempotencyKey)
+			return &models.Transaction{
+				ID:              "txn-1",
+				IdempotencyKey:  "dep-1",
+				TransactionType: models.TransactionTypeDeposit,
+				Amount:          5000,
+				Currency:        "USD",
+				CreatedAt:       time.Now(),
+				Entries: []models.LedgerEntry{
+					{ID: "e1", AccountID: models.SystemAccountID, EntryType: models.EntryTypeDebit, Amount: 5000, CreatedAt: time.Now()},
+					{ID: "e2", AccountID: "acc-1", EntryType: models.EntryTypeCredit, Amount: 5000, BalanceAfter: 5000, CreatedAt: time.Now()},
+				},
+			}, nil
+		},
+	)
+
+	resp, err := server.Deposit(context.Background(), &ledgerpb.DepositRequest{AccountId: "acc-1", Amount: 5000, IdempotencyKey: "dep-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "txn-1", resp.GetId())
+	assert.Len(t, resp.GetEntries(), 2)
+	assert.Equal(t, int64(5000), resp.GetEntries()[1].GetBalanceAfter())
+}
+
+func TestGRPCServer_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(s *GRPCServer) error
+		repo func(m *MockLedgerRepository)
+		code codes.Code
+	}{
+		{
+			name: "invalid request",
+			call: func(s *GRPCServer) error {
+				_, err := s.Deposit(context.Background(), &ledgerpb.DepositRequest{AccountId: "acc-1", Amount: -1})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "account not found",
+			call: func(s *GRPCServer) error {
+				_, err := s.GetAccount(context.Background(), &ledgerpb.GetAccountRequest{Id: "missing"})
+				return err
+			},
+			repo: func(m *MockLedgerRepository) {
+				m.EXPECT().GetAccountByID(gomock.Any(), "missing").Return(nil, ErrAccountNotFound)
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "idempotency conflict",
+			call: func(s *GRPCServer) error {
+				_, err := s.Withdraw(context.Background(), &ledgerpb.WithdrawRequest{AccountId: "acc-1", Amount: 10, IdempotencyKey: "wd-1"})
+				return err
+			},
+			repo: func(m *MockLedgerRepository) {
+				m.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).Return(nil, ErrIdempotencyConflict)
+			},
+			code: codes.FailedPrecondition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo, service := newTestService(t)
+			if tt.repo != nil {
+				tt.repo(mockRepo)
+			}
+			err := tt.call(NewGRPCServer(service))
+			assert.Equal(t, tt.code, status.Code(err), "error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package ledger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/distlock"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const (
+	defaultHoldSweepInterval = time.Minute
+	// holdSweepLock is the distributed lock a sweep runs under.
+	holdSweepLock = "ledger:hold-sweep"
+)
+
+// HoldSweepIntervalFromEnv reads LEDGER_HOLD_SWEEP_INTERVAL (default one
+// minute).
+func HoldSweepIntervalFromEnv(logger *log.Logger) time.Duration {
+	raw := utils.GetEnvTrimmed("LEDGER_HOLD_SWEEP_INTERVAL")
+	if raw == "" {
+		return defaultHoldSweepInterval
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval <= 0 {
+		logger.Warn("Invalid duration; using default", "name", "LEDGER_HOLD_SWEEP_INTERVAL", "value", raw, "default", defaultHoldSweepInterval)
+		return defaultHoldSweepInterval
+	}
+	return interval
+}
+
+// HoldSweeper marks expired holds EXPIRED every interval. Available balances
+// ignore expired holds whether or not they were swept, so a late sweep only
+// delays the status change. With a locker, one instance of a multi-replica
+// deployment sweeps at a time.
+type HoldSweeper struct {
+	logger     *log.Logger
+	repository LedgerRepository
+	interval   time.Duration
+	locks      distlock.Locker
+
+	mu      sync.Mutex
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewHoldSweeper returns a sweeper; locks may be nil, and every instance then
+// sweeps.
+func NewHoldSweeper(logger *log.Logger, repository LedgerRepository, interval time.Duration, locks distlock.Locker) *HoldSweeper {
+	if interval <= 0 {
+		interval = defaultHoldSweepInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HoldSweeper{
+		logger:     logger,
+		repository: repository,
+		interval:   interval,
+		locks:      locks,
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+}
+
+// Start sweeps every interval until Stop.
+func (s *HoldSweeper) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_, _ = s.Sweep(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the sweeps and waits for one in flight.
+func (s *HoldSweeper) Stop() {
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+
+	s.cancel()
+	if started {
+		<-s.done
+	}
+}
+
+// Sweep expires the holds that are due and reports how many there were. It
+// reports none when another instance holds the sweep lock.
+func (s *HoldSweeper) Sweep(ctx context.Context) (int64, error) {
+	var expired int64
+	sweep := func(ctx context.Context) error {
+		var err error
+		expired, err = s.repository.ExpireHolds(ctx, time.Now().UTC())
+		return err
+	}
+	var err error
+	if s.locks == nil {
+		err = sweep(ctx)
+	} else {
+		// The lock outlives a sweep that overruns the interval, and expires
+		// soon after an instance dies mid-sweep.
+		_, err = distlock.Run(ctx, s.locks, holdSweepLock, s.interval, sweep)
+	}
+	if err != nil {
+		if s.ctx.Err() == nil {
+			s.logger.Warn("Hold expiry sweep failed", "error", err)
+		}
+		return 0, err
+	}
+	if expired > 0 {
+		s.logger.Info("Expired holds", "count", expired)
+	}
+	return expired, nil
+}
@@ -0,0 +1,142 @@
+package ledger
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+)
+
+// ImportKindTransfers names the CSV transfers importer in upload metadata.
+const ImportKindTransfers = "ledger.transfers"
+
+// maxImportErrors caps the row errors kept in an import result.
+const maxImportErrors = 100
+
+var requiredTransferColumns = []string{"source_account_id", "dest_account_id", "amount", "idempotency_key"}
+
+type ImportRowError struct {
+	Line           int    `json:"line"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	Error          string `json:"error"`
+}
+
+type ImportResult struct {
+	Rows            int              `json:"rows"`
+	Succeeded       int              `json:"succeeded"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `json:"errors,omitempty"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+}
+
+func (r *ImportResult) fail(line int, key, msg string) {
+	r.Failed++
+	if len(r.Errors) >= maxImportErrors {
+		r.ErrorsTruncated = true
+		return
+	}
+	r.Errors = append(r.Errors, ImportRowError{Line: line, IdempotencyKey: key, Error: msg})
+}
+
+// ImportTransfersCSV executes one transfer per CSV row. The header row names
+// the columns: source_account_id, dest_account_id, amount and idempotency_key
+// are required; currency and description are optional. Invalid or failed rows
+// are reported in the result without stopping the import, and rows are
+// idempotent so a failed import can be re-run with the same file.
+func (s *ledgerService) ImportTransfersCSV(ctx context.Context, r io.Reader) (*ImportResult, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("import file is empty")
+		}
+		return nil, errors.New("import file is not valid CSV")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredTransferColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("import file is missing the %s column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	result := &ImportResult{}
+	for {
+		if err := ctx.Err(); err != nil {
+			logger.Warn("Transfer import interrupted", "rows", result.Rows)
+			return nil, err
+		}
+
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, errors.New("import file could not be read")
+			}
+			if errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				result.Rows++
+				result.fail(parseErr.StartLine, "", "wrong number of columns")
+				continue
+			}
+			return nil, fmt.Errorf("import file is not valid CSV near line %d", parseErr.StartLine)
+		}
+		result.Rows++
+		line, _ := reader.FieldPos(0)
+
+		req := TransferRequest{
+			SourceAccountID: field(record, "source_account_id"),
+			DestAccountID:   field(record, "dest_account_id"),
+			Currency:        strings.ToUpper(field(record, "currency")),
+			IdempotencyKey:  field(record, "idempotency_key"),
+			Description:     field(record, "description"),
+		}
+		amount, err := strconv.ParseInt(field(record, "amount"), 10, 64)
+		if err != nil {
+			result.fail(line, req.IdempotencyKey, "amount must be an integer number of minor units")
+			continue
+		}
+		req.Amount = amount
+		if req.IdempotencyKey == "" || len(req.IdempotencyKey) > 255 {
+			result.fail(line, req.IdempotencyKey, "idempotency_key is required and must be at most 255 characters")
+			continue
+		}
+		if len(req.Description) > 500 {
+			result.fail(line, req.IdempotencyKey, "description must be at most 500 characters")
+			continue
+		}
+		if req.Currency != "" && len(req.Currency) != 3 {
+			result.fail(line, req.IdempotencyKey, "currency must be a 3-letter code")
+			continue
+		}
+
+		if _, err := s.Transfer(ctx, &req); err != nil {
+			_, msg := mapDomainError(err)
+			result.fail(line, req.IdempotencyKey, msg)
+			continue
+		}
+		result.Succeeded++
+	}
+
+	logger.Info("Transfer import completed", "rows", result.Rows, "succeeded", result.Succeeded, "failed", result.Failed)
+	return result, nil
+}
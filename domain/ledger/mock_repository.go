@@ -12,8 +12,10 @@ package ledger
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/akeren/go-api-foundry/internal/models"
+	fx "github.com/akeren/go-api-foundry/pkg/fx"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -41,6 +43,96 @@ func (m *MockLedgerRepository) EXPECT() *MockLedgerRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AccrueNextDay mocks base method.
+func (m *MockLedgerRepository) AccrueNextDay(ctx context.Context, planID string, through time.Time) (*AccrualPosting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccrueNextDay", ctx, planID, through)
+	ret0, _ := ret[0].(*AccrualPosting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccrueNextDay indicates an expected call of AccrueNextDay.
+func (mr *MockLedgerRepositoryMockRecorder) AccrueNextDay(ctx, planID, through any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccrueNextDay", reflect.TypeOf((*MockLedgerRepository)(nil).AccrueNextDay), ctx, planID, through)
+}
+
+// ArchiveTransactions mocks base method.
+func (m *MockLedgerRepository) ArchiveTransactions(ctx context.Context, before time.Time, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveTransactions", ctx, before, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveTransactions indicates an expected call of ArchiveTransactions.
+func (mr *MockLedgerRepositoryMockRecorder) ArchiveTransactions(ctx, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveTransactions", reflect.TypeOf((*MockLedgerRepository)(nil).ArchiveTransactions), ctx, before, limit)
+}
+
+// ClaimAlertNotification mocks base method.
+func (m *MockLedgerRepository) ClaimAlertNotification(ctx context.Context, triggerID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimAlertNotification", ctx, triggerID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimAlertNotification indicates an expected call of ClaimAlertNotification.
+func (mr *MockLedgerRepositoryMockRecorder) ClaimAlertNotification(ctx, triggerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimAlertNotification", reflect.TypeOf((*MockLedgerRepository)(nil).ClaimAlertNotification), ctx, triggerID)
+}
+
+// CorrectAccrual mocks base method.
+func (m *MockLedgerRepository) CorrectAccrual(ctx context.Context, planID string, day time.Time) (*AccrualPosting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CorrectAccrual", ctx, planID, day)
+	ret0, _ := ret[0].(*AccrualPosting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CorrectAccrual indicates an expected call of CorrectAccrual.
+func (mr *MockLedgerRepositoryMockRecorder) CorrectAccrual(ctx, planID, day any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CorrectAccrual", reflect.TypeOf((*MockLedgerRepository)(nil).CorrectAccrual), ctx, planID, day)
+}
+
+// CountArchivedTransactionsByAccountID mocks base method.
+func (m *MockLedgerRepository) CountArchivedTransactionsByAccountID(ctx context.Context, accountID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountArchivedTransactionsByAccountID", ctx, accountID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountArchivedTransactionsByAccountID indicates an expected call of CountArchivedTransactionsByAccountID.
+func (mr *MockLedgerRepositoryMockRecorder) CountArchivedTransactionsByAccountID(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountArchivedTransactionsByAccountID", reflect.TypeOf((*MockLedgerRepository)(nil).CountArchivedTransactionsByAccountID), ctx, accountID)
+}
+
+// CountTransactionsByAccountID mocks base method.
+func (m *MockLedgerRepository) CountTransactionsByAccountID(ctx context.Context, accountID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTransactionsByAccountID", ctx, accountID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTransactionsByAccountID indicates an expected call of CountTransactionsByAccountID.
+func (mr *MockLedgerRepositoryMockRecorder) CountTransactionsByAccountID(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTransactionsByAccountID", reflect.TypeOf((*MockLedgerRepository)(nil).CountTransactionsByAccountID), ctx, accountID)
+}
+
 // CreateAccount mocks base method.
 func (m *MockLedgerRepository) CreateAccount(ctx context.Context, account *models.Account) (*models.Account, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +148,109 @@ func (mr *MockLedgerRepositoryMockRecorder) CreateAccount(ctx, account any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockLedgerRepository)(nil).CreateAccount), ctx, account)
 }
 
+// CreateAccrualPlan mocks base method.
+func (m *MockLedgerRepository) CreateAccrualPlan(ctx context.Context, plan *models.AccrualPlan) (*models.AccrualPlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccrualPlan", ctx, plan)
+	ret0, _ := ret[0].(*models.AccrualPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccrualPlan indicates an expected call of CreateAccrualPlan.
+func (mr *MockLedgerRepositoryMockRecorder) CreateAccrualPlan(ctx, plan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccrualPlan", reflect.TypeOf((*MockLedgerRepository)(nil).CreateAccrualPlan), ctx, plan)
+}
+
+// CreateBalanceAlert mocks base method.
+func (m *MockLedgerRepository) CreateBalanceAlert(ctx context.Context, alert *models.BalanceAlert) (*models.BalanceAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBalanceAlert", ctx, alert)
+	ret0, _ := ret[0].(*models.BalanceAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBalanceAlert indicates an expected call of CreateBalanceAlert.
+func (mr *MockLedgerRepositoryMockRecorder) CreateBalanceAlert(ctx, alert any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBalanceAlert", reflect.TypeOf((*MockLedgerRepository)(nil).CreateBalanceAlert), ctx, alert)
+}
+
+// CreateDailySeal mocks base method.
+func (m *MockLedgerRepository) CreateDailySeal(ctx context.Context, seal *models.DailySeal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDailySeal", ctx, seal)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDailySeal indicates an expected call of CreateDailySeal.
+func (mr *MockLedgerRepositoryMockRecorder) CreateDailySeal(ctx, seal any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDailySeal", reflect.TypeOf((*MockLedgerRepository)(nil).CreateDailySeal), ctx, seal)
+}
+
+// CreateHold mocks base method.
+func (m *MockLedgerRepository) CreateHold(ctx context.Context, cmd HoldCommand) (*models.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateHold", ctx, cmd)
+	ret0, _ := ret[0].(*models.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateHold indicates an expected call of CreateHold.
+func (mr *MockLedgerRepositoryMockRecorder) CreateHold(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHold", reflect.TypeOf((*MockLedgerRepository)(nil).CreateHold), ctx, cmd)
+}
+
+// CreateTransferQuote mocks base method.
+func (m *MockLedgerRepository) CreateTransferQuote(ctx context.Context, quote *models.TransferQuote) (*models.TransferQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransferQuote", ctx, quote)
+	ret0, _ := ret[0].(*models.TransferQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransferQuote indicates an expected call of CreateTransferQuote.
+func (mr *MockLedgerRepositoryMockRecorder) CreateTransferQuote(ctx, quote any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransferQuote", reflect.TypeOf((*MockLedgerRepository)(nil).CreateTransferQuote), ctx, quote)
+}
+
+// DeleteBalanceAlert mocks base method.
+func (m *MockLedgerRepository) DeleteBalanceAlert(ctx context.Context, accountID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBalanceAlert", ctx, accountID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBalanceAlert indicates an expected call of DeleteBalanceAlert.
+func (mr *MockLedgerRepositoryMockRecorder) DeleteBalanceAlert(ctx, accountID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBalanceAlert", reflect.TypeOf((*MockLedgerRepository)(nil).DeleteBalanceAlert), ctx, accountID, id)
+}
+
+// EnsureEntryPartitions mocks base method.
+func (m *MockLedgerRepository) EnsureEntryPartitions(ctx context.Context, from, through time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnsureEntryPartitions", ctx, from, through)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnsureEntryPartitions indicates an expected call of EnsureEntryPartitions.
+func (mr *MockLedgerRepositoryMockRecorder) EnsureEntryPartitions(ctx, from, through any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureEntryPartitions", reflect.TypeOf((*MockLedgerRepository)(nil).EnsureEntryPartitions), ctx, from, through)
+}
+
 // ExecuteDoubleEntry mocks base method.
 func (m *MockLedgerRepository) ExecuteDoubleEntry(ctx context.Context, cmd DoubleEntryCommand) (*models.Transaction, error) {
 	m.ctrl.T.Helper()
@@ -71,6 +266,36 @@ func (mr *MockLedgerRepositoryMockRecorder) ExecuteDoubleEntry(ctx, cmd any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteDoubleEntry", reflect.TypeOf((*MockLedgerRepository)(nil).ExecuteDoubleEntry), ctx, cmd)
 }
 
+// ExecuteSplitTransfer mocks base method.
+func (m *MockLedgerRepository) ExecuteSplitTransfer(ctx context.Context, cmd SplitTransferCommand) (*models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteSplitTransfer", ctx, cmd)
+	ret0, _ := ret[0].(*models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteSplitTransfer indicates an expected call of ExecuteSplitTransfer.
+func (mr *MockLedgerRepositoryMockRecorder) ExecuteSplitTransfer(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteSplitTransfer", reflect.TypeOf((*MockLedgerRepository)(nil).ExecuteSplitTransfer), ctx, cmd)
+}
+
+// ExpireHolds mocks base method.
+func (m *MockLedgerRepository) ExpireHolds(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHolds", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHolds indicates an expected call of ExpireHolds.
+func (mr *MockLedgerRepositoryMockRecorder) ExpireHolds(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHolds", reflect.TypeOf((*MockLedgerRepository)(nil).ExpireHolds), ctx, now)
+}
+
 // GetAccountByID mocks base method.
 func (m *MockLedgerRepository) GetAccountByID(ctx context.Context, id string) (*models.Account, error) {
 	m.ctrl.T.Helper()
@@ -86,6 +311,111 @@ func (mr *MockLedgerRepositoryMockRecorder) GetAccountByID(ctx, id any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByID", reflect.TypeOf((*MockLedgerRepository)(nil).GetAccountByID), ctx, id)
 }
 
+// GetAccountSubtree mocks base method.
+func (m *MockLedgerRepository) GetAccountSubtree(ctx context.Context, id string, maxDepth int) ([]models.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountSubtree", ctx, id, maxDepth)
+	ret0, _ := ret[0].([]models.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountSubtree indicates an expected call of GetAccountSubtree.
+func (mr *MockLedgerRepositoryMockRecorder) GetAccountSubtree(ctx, id, maxDepth any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountSubtree", reflect.TypeOf((*MockLedgerRepository)(nil).GetAccountSubtree), ctx, id, maxDepth)
+}
+
+// GetAccrualPlan mocks base method.
+func (m *MockLedgerRepository) GetAccrualPlan(ctx context.Context, accountID, id string) (*models.AccrualPlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccrualPlan", ctx, accountID, id)
+	ret0, _ := ret[0].(*models.AccrualPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccrualPlan indicates an expected call of GetAccrualPlan.
+func (mr *MockLedgerRepositoryMockRecorder) GetAccrualPlan(ctx, accountID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccrualPlan", reflect.TypeOf((*MockLedgerRepository)(nil).GetAccrualPlan), ctx, accountID, id)
+}
+
+// GetAllAccountsForReconciliation mocks base method.
+func (m *MockLedgerRepository) GetAllAccountsForReconciliation(ctx context.Context) ([]AccountReconciliation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllAccountsForReconciliation", ctx)
+	ret0, _ := ret[0].([]AccountReconciliation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllAccountsForReconciliation indicates an expected call of GetAllAccountsForReconciliation.
+func (mr *MockLedgerRepositoryMockRecorder) GetAllAccountsForReconciliation(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllAccountsForReconciliation", reflect.TypeOf((*MockLedgerRepository)(nil).GetAllAccountsForReconciliation), ctx)
+}
+
+// GetArchivedTransaction mocks base method.
+func (m *MockLedgerRepository) GetArchivedTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchivedTransaction", ctx, id)
+	ret0, _ := ret[0].(*models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArchivedTransaction indicates an expected call of GetArchivedTransaction.
+func (mr *MockLedgerRepositoryMockRecorder) GetArchivedTransaction(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchivedTransaction", reflect.TypeOf((*MockLedgerRepository)(nil).GetArchivedTransaction), ctx, id)
+}
+
+// GetArchivedTransactionsByAccountID mocks base method.
+func (m *MockLedgerRepository) GetArchivedTransactionsByAccountID(ctx context.Context, accountID string, limit, offset int) ([]models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArchivedTransactionsByAccountID", ctx, accountID, limit, offset)
+	ret0, _ := ret[0].([]models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArchivedTransactionsByAccountID indicates an expected call of GetArchivedTransactionsByAccountID.
+func (mr *MockLedgerRepositoryMockRecorder) GetArchivedTransactionsByAccountID(ctx, accountID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArchivedTransactionsByAccountID", reflect.TypeOf((*MockLedgerRepository)(nil).GetArchivedTransactionsByAccountID), ctx, accountID, limit, offset)
+}
+
+// GetBalanceAlert mocks base method.
+func (m *MockLedgerRepository) GetBalanceAlert(ctx context.Context, id string) (*models.BalanceAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceAlert", ctx, id)
+	ret0, _ := ret[0].(*models.BalanceAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceAlert indicates an expected call of GetBalanceAlert.
+func (mr *MockLedgerRepositoryMockRecorder) GetBalanceAlert(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceAlert", reflect.TypeOf((*MockLedgerRepository)(nil).GetBalanceAlert), ctx, id)
+}
+
+// GetBalanceBefore mocks base method.
+func (m *MockLedgerRepository) GetBalanceBefore(ctx context.Context, accountID string, at time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceBefore", ctx, accountID, at)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceBefore indicates an expected call of GetBalanceBefore.
+func (mr *MockLedgerRepositoryMockRecorder) GetBalanceBefore(ctx, accountID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceBefore", reflect.TypeOf((*MockLedgerRepository)(nil).GetBalanceBefore), ctx, accountID, at)
+}
+
 // GetBalanceSnapshot mocks base method.
 func (m *MockLedgerRepository) GetBalanceSnapshot(ctx context.Context, accountID string) (*BalanceSnapshot, error) {
 	m.ctrl.T.Helper()
@@ -101,6 +431,111 @@ func (mr *MockLedgerRepositoryMockRecorder) GetBalanceSnapshot(ctx, accountID an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceSnapshot", reflect.TypeOf((*MockLedgerRepository)(nil).GetBalanceSnapshot), ctx, accountID)
 }
 
+// GetBalanceVersion mocks base method.
+func (m *MockLedgerRepository) GetBalanceVersion(ctx context.Context, accountID string) (*BalanceVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceVersion", ctx, accountID)
+	ret0, _ := ret[0].(*BalanceVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceVersion indicates an expected call of GetBalanceVersion.
+func (mr *MockLedgerRepositoryMockRecorder) GetBalanceVersion(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceVersion", reflect.TypeOf((*MockLedgerRepository)(nil).GetBalanceVersion), ctx, accountID)
+}
+
+// GetDailyBalances mocks base method.
+func (m *MockLedgerRepository) GetDailyBalances(ctx context.Context, accountID, from, to string) ([]DailyBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDailyBalances", ctx, accountID, from, to)
+	ret0, _ := ret[0].([]DailyBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDailyBalances indicates an expected call of GetDailyBalances.
+func (mr *MockLedgerRepositoryMockRecorder) GetDailyBalances(ctx, accountID, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailyBalances", reflect.TypeOf((*MockLedgerRepository)(nil).GetDailyBalances), ctx, accountID, from, to)
+}
+
+// GetDailySeal mocks base method.
+func (m *MockLedgerRepository) GetDailySeal(ctx context.Context, day time.Time) (*models.DailySeal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDailySeal", ctx, day)
+	ret0, _ := ret[0].(*models.DailySeal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDailySeal indicates an expected call of GetDailySeal.
+func (mr *MockLedgerRepositoryMockRecorder) GetDailySeal(ctx, day any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailySeal", reflect.TypeOf((*MockLedgerRepository)(nil).GetDailySeal), ctx, day)
+}
+
+// GetExchangeRate mocks base method.
+func (m *MockLedgerRepository) GetExchangeRate(ctx context.Context, base, quote string, maxAge time.Duration) (*fx.Rate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExchangeRate", ctx, base, quote, maxAge)
+	ret0, _ := ret[0].(*fx.Rate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExchangeRate indicates an expected call of GetExchangeRate.
+func (mr *MockLedgerRepositoryMockRecorder) GetExchangeRate(ctx, base, quote, maxAge any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeRate", reflect.TypeOf((*MockLedgerRepository)(nil).GetExchangeRate), ctx, base, quote, maxAge)
+}
+
+// GetFirstEntryTime mocks base method.
+func (m *MockLedgerRepository) GetFirstEntryTime(ctx context.Context) (*time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFirstEntryTime", ctx)
+	ret0, _ := ret[0].(*time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFirstEntryTime indicates an expected call of GetFirstEntryTime.
+func (mr *MockLedgerRepositoryMockRecorder) GetFirstEntryTime(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirstEntryTime", reflect.TypeOf((*MockLedgerRepository)(nil).GetFirstEntryTime), ctx)
+}
+
+// GetHold mocks base method.
+func (m *MockLedgerRepository) GetHold(ctx context.Context, id string) (*models.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", ctx, id)
+	ret0, _ := ret[0].(*models.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHold indicates an expected call of GetHold.
+func (mr *MockLedgerRepositoryMockRecorder) GetHold(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockLedgerRepository)(nil).GetHold), ctx, id)
+}
+
+// GetLatestDailySeal mocks base method.
+func (m *MockLedgerRepository) GetLatestDailySeal(ctx context.Context) (*models.DailySeal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestDailySeal", ctx)
+	ret0, _ := ret[0].(*models.DailySeal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestDailySeal indicates an expected call of GetLatestDailySeal.
+func (mr *MockLedgerRepositoryMockRecorder) GetLatestDailySeal(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestDailySeal", reflect.TypeOf((*MockLedgerRepository)(nil).GetLatestDailySeal), ctx)
+}
+
 // GetLedgerTotals mocks base method.
 func (m *MockLedgerRepository) GetLedgerTotals(ctx context.Context) (int64, int64, error) {
 	m.ctrl.T.Helper()
@@ -117,32 +552,239 @@ func (mr *MockLedgerRepositoryMockRecorder) GetLedgerTotals(ctx any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLedgerTotals", reflect.TypeOf((*MockLedgerRepository)(nil).GetLedgerTotals), ctx)
 }
 
-// GetAllAccountsForReconciliation mocks base method.
-func (m *MockLedgerRepository) GetAllAccountsForReconciliation(ctx context.Context) ([]AccountReconciliation, error) {
+// GetTransaction mocks base method.
+func (m *MockLedgerRepository) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllAccountsForReconciliation", ctx)
-	ret0, _ := ret[0].([]AccountReconciliation)
+	ret := m.ctrl.Call(m, "GetTransaction", ctx, id)
+	ret0, _ := ret[0].(*models.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllAccountsForReconciliation indicates an expected call of GetAllAccountsForReconciliation.
-func (mr *MockLedgerRepositoryMockRecorder) GetAllAccountsForReconciliation(ctx any) *gomock.Call {
+// GetTransaction indicates an expected call of GetTransaction.
+func (mr *MockLedgerRepositoryMockRecorder) GetTransaction(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllAccountsForReconciliation", reflect.TypeOf((*MockLedgerRepository)(nil).GetAllAccountsForReconciliation), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransaction", reflect.TypeOf((*MockLedgerRepository)(nil).GetTransaction), ctx, id)
 }
 
 // GetTransactionsByAccountID mocks base method.
-func (m *MockLedgerRepository) GetTransactionsByAccountID(ctx context.Context, accountID string, limit, offset int) ([]models.Transaction, error) {
+func (m *MockLedgerRepository) GetTransactionsByAccountID(ctx context.Context, accountID string, limit, offset int, includeEntries bool) ([]models.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransactionsByAccountID", ctx, accountID, limit, offset)
+	ret := m.ctrl.Call(m, "GetTransactionsByAccountID", ctx, accountID, limit, offset, includeEntries)
 	ret0, _ := ret[0].([]models.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTransactionsByAccountID indicates an expected call of GetTransactionsByAccountID.
-func (mr *MockLedgerRepositoryMockRecorder) GetTransactionsByAccountID(ctx, accountID, limit, offset any) *gomock.Call {
+func (mr *MockLedgerRepositoryMockRecorder) GetTransactionsByAccountID(ctx, accountID, limit, offset, includeEntries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsByAccountID", reflect.TypeOf((*MockLedgerRepository)(nil).GetTransactionsByAccountID), ctx, accountID, limit, offset, includeEntries)
+}
+
+// ListAccrualPlans mocks base method.
+func (m *MockLedgerRepository) ListAccrualPlans(ctx context.Context, accountID string) ([]models.AccrualPlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccrualPlans", ctx, accountID)
+	ret0, _ := ret[0].([]models.AccrualPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccrualPlans indicates an expected call of ListAccrualPlans.
+func (mr *MockLedgerRepositoryMockRecorder) ListAccrualPlans(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccrualPlans", reflect.TypeOf((*MockLedgerRepository)(nil).ListAccrualPlans), ctx, accountID)
+}
+
+// ListAccrualRecords mocks base method.
+func (m *MockLedgerRepository) ListAccrualRecords(ctx context.Context, accountID string, from, to time.Time) ([]models.AccrualRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccrualRecords", ctx, accountID, from, to)
+	ret0, _ := ret[0].([]models.AccrualRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccrualRecords indicates an expected call of ListAccrualRecords.
+func (mr *MockLedgerRepositoryMockRecorder) ListAccrualRecords(ctx, accountID, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccrualRecords", reflect.TypeOf((*MockLedgerRepository)(nil).ListAccrualRecords), ctx, accountID, from, to)
+}
+
+// ListBalanceAlerts mocks base method.
+func (m *MockLedgerRepository) ListBalanceAlerts(ctx context.Context, accountID string) ([]models.BalanceAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBalanceAlerts", ctx, accountID)
+	ret0, _ := ret[0].([]models.BalanceAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBalanceAlerts indicates an expected call of ListBalanceAlerts.
+func (mr *MockLedgerRepositoryMockRecorder) ListBalanceAlerts(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBalanceAlerts", reflect.TypeOf((*MockLedgerRepository)(nil).ListBalanceAlerts), ctx, accountID)
+}
+
+// ListDueAccrualPlans mocks base method.
+func (m *MockLedgerRepository) ListDueAccrualPlans(ctx context.Context, through time.Time) ([]models.AccrualPlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueAccrualPlans", ctx, through)
+	ret0, _ := ret[0].([]models.AccrualPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueAccrualPlans indicates an expected call of ListDueAccrualPlans.
+func (mr *MockLedgerRepositoryMockRecorder) ListDueAccrualPlans(ctx, through any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueAccrualPlans", reflect.TypeOf((*MockLedgerRepository)(nil).ListDueAccrualPlans), ctx, through)
+}
+
+// RechainAccount mocks base method.
+func (m *MockLedgerRepository) RechainAccount(ctx context.Context, accountID string) (*Rechain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RechainAccount", ctx, accountID)
+	ret0, _ := ret[0].(*Rechain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RechainAccount indicates an expected call of RechainAccount.
+func (mr *MockLedgerRepositoryMockRecorder) RechainAccount(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RechainAccount", reflect.TypeOf((*MockLedgerRepository)(nil).RechainAccount), ctx, accountID)
+}
+
+// ReleaseHold mocks base method.
+func (m *MockLedgerRepository) ReleaseHold(ctx context.Context, id string, dryRun bool) (*models.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseHold", ctx, id, dryRun)
+	ret0, _ := ret[0].(*models.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseHold indicates an expected call of ReleaseHold.
+func (mr *MockLedgerRepositoryMockRecorder) ReleaseHold(ctx, id, dryRun any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseHold", reflect.TypeOf((*MockLedgerRepository)(nil).ReleaseHold), ctx, id, dryRun)
+}
+
+// ReverseTransaction mocks base method.
+func (m *MockLedgerRepository) ReverseTransaction(ctx context.Context, cmd ReversalCommand) (*Reversal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransaction", ctx, cmd)
+	ret0, _ := ret[0].(*Reversal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransaction indicates an expected call of ReverseTransaction.
+func (mr *MockLedgerRepositoryMockRecorder) ReverseTransaction(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransaction", reflect.TypeOf((*MockLedgerRepository)(nil).ReverseTransaction), ctx, cmd)
+}
+
+// SearchTransactions mocks base method.
+func (m *MockLedgerRepository) SearchTransactions(ctx context.Context, query TransactionSearchQuery) ([]models.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchTransactions", ctx, query)
+	ret0, _ := ret[0].([]models.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchTransactions indicates an expected call of SearchTransactions.
+func (mr *MockLedgerRepositoryMockRecorder) SearchTransactions(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTransactions", reflect.TypeOf((*MockLedgerRepository)(nil).SearchTransactions), ctx, query)
+}
+
+// StreamAccountEntries mocks base method.
+func (m *MockLedgerRepository) StreamAccountEntries(ctx context.Context, accountID string, fn func(*models.LedgerEntry) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamAccountEntries", ctx, accountID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamAccountEntries indicates an expected call of StreamAccountEntries.
+func (mr *MockLedgerRepositoryMockRecorder) StreamAccountEntries(ctx, accountID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamAccountEntries", reflect.TypeOf((*MockLedgerRepository)(nil).StreamAccountEntries), ctx, accountID, fn)
+}
+
+// StreamDayEntries mocks base method.
+func (m *MockLedgerRepository) StreamDayEntries(ctx context.Context, day time.Time, fn func(*models.LedgerEntry) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamDayEntries", ctx, day, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamDayEntries indicates an expected call of StreamDayEntries.
+func (mr *MockLedgerRepositoryMockRecorder) StreamDayEntries(ctx, day, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamDayEntries", reflect.TypeOf((*MockLedgerRepository)(nil).StreamDayEntries), ctx, day, fn)
+}
+
+// StreamStatementEntries mocks base method.
+func (m *MockLedgerRepository) StreamStatementEntries(ctx context.Context, accountID string, from, until time.Time, fn func(StatementEntry) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamStatementEntries", ctx, accountID, from, until, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamStatementEntries indicates an expected call of StreamStatementEntries.
+func (mr *MockLedgerRepositoryMockRecorder) StreamStatementEntries(ctx, accountID, from, until, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamStatementEntries", reflect.TypeOf((*MockLedgerRepository)(nil).StreamStatementEntries), ctx, accountID, from, until, fn)
+}
+
+// SummarizeEntries mocks base method.
+func (m *MockLedgerRepository) SummarizeEntries(ctx context.Context, accountID string, transactions []models.Transaction) (map[string]TransactionSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SummarizeEntries", ctx, accountID, transactions)
+	ret0, _ := ret[0].(map[string]TransactionSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SummarizeEntries indicates an expected call of SummarizeEntries.
+func (mr *MockLedgerRepositoryMockRecorder) SummarizeEntries(ctx, accountID, transactions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SummarizeEntries", reflect.TypeOf((*MockLedgerRepository)(nil).SummarizeEntries), ctx, accountID, transactions)
+}
+
+// UpdateAccrualPlanRate mocks base method.
+func (m *MockLedgerRepository) UpdateAccrualPlanRate(ctx context.Context, accountID, id string, rateBasisPoints int64) (*models.AccrualPlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccrualPlanRate", ctx, accountID, id, rateBasisPoints)
+	ret0, _ := ret[0].(*models.AccrualPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccrualPlanRate indicates an expected call of UpdateAccrualPlanRate.
+func (mr *MockLedgerRepositoryMockRecorder) UpdateAccrualPlanRate(ctx, accountID, id, rateBasisPoints any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccrualPlanRate", reflect.TypeOf((*MockLedgerRepository)(nil).UpdateAccrualPlanRate), ctx, accountID, id, rateBasisPoints)
+}
+
+// UpdateSiblingTransfers mocks base method.
+func (m *MockLedgerRepository) UpdateSiblingTransfers(ctx context.Context, id, policy string) (*models.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSiblingTransfers", ctx, id, policy)
+	ret0, _ := ret[0].(*models.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSiblingTransfers indicates an expected call of UpdateSiblingTransfers.
+func (mr *MockLedgerRepositoryMockRecorder) UpdateSiblingTransfers(ctx, id, policy any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsByAccountID", reflect.TypeOf((*MockLedgerRepository)(nil).GetTransactionsByAccountID), ctx, accountID, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSiblingTransfers", reflect.TypeOf((*MockLedgerRepository)(nil).UpdateSiblingTransfers), ctx, id, policy)
 }
@@ -0,0 +1,115 @@
+package ledger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const (
+	defaultPartitionInterval = 24 * time.Hour
+
+	// partitionMonthsAhead is how many months past the current one have
+	// ledger_entries partitions ready, so a missed run or two never leaves
+	// entries to the default partition.
+	partitionMonthsAhead = 3
+)
+
+// PartitionIntervalFromEnv reads LEDGER_PARTITION_INTERVAL (default 24h).
+func PartitionIntervalFromEnv(logger *log.Logger) time.Duration {
+	raw := utils.GetEnvTrimmed("LEDGER_PARTITION_INTERVAL")
+	if raw == "" {
+		return defaultPartitionInterval
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval <= 0 {
+		logger.Warn("Invalid duration; using default", "name", "LEDGER_PARTITION_INTERVAL", "value", raw, "default", defaultPartitionInterval)
+		return defaultPartitionInterval
+	}
+	return interval
+}
+
+// PartitionMaintainer creates the monthly ledger_entries partitions for the
+// current month and the next few when it starts and every interval after.
+type PartitionMaintainer struct {
+	logger     *log.Logger
+	repository LedgerRepository
+	interval   time.Duration
+
+	mu      sync.Mutex
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewPartitionMaintainer(logger *log.Logger, repository LedgerRepository, interval time.Duration) *PartitionMaintainer {
+	if interval <= 0 {
+		interval = defaultPartitionInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PartitionMaintainer{
+		logger:     logger,
+		repository: repository,
+		interval:   interval,
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+}
+
+// Start creates the partitions now and then every interval until Stop.
+func (m *PartitionMaintainer) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return
+	}
+	m.started = true
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			_, _ = m.Run(m.ctx)
+			select {
+			case <-ticker.C:
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the runs and waits for one in flight.
+func (m *PartitionMaintainer) Stop() {
+	m.mu.Lock()
+	started := m.started
+	m.mu.Unlock()
+
+	m.cancel()
+	if started {
+		<-m.done
+	}
+}
+
+// Run creates any missing partitions up to partitionMonthsAhead months from
+// now and reports how many there were.
+func (m *PartitionMaintainer) Run(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	created, err := m.repository.EnsureEntryPartitions(ctx, now, now.AddDate(0, partitionMonthsAhead, 0))
+	if err != nil {
+		if m.ctx.Err() == nil {
+			m.logger.Warn("Ledger entry partition maintenance failed", "error", err)
+		}
+		return created, err
+	}
+	if created > 0 {
+		m.logger.Info("Created ledger entry partitions", "count", created)
+	}
+	return created, nil
+}
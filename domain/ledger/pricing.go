@@ -0,0 +1,89 @@
+package ledger
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const (
+	defaultQuoteTTL = time.Minute
+
+	// defaultHoldTTL is how long a hold reserves funds before it expires.
+	defaultHoldTTL = 7 * 24 * time.Hour
+
+	// defaultMaxRateAge is how old an exchange rate may be before transfers
+	// between currencies are refused.
+	defaultMaxRateAge = 24 * time.Hour
+
+	// identityExchangeRate is recorded for postings within one currency.
+	identityExchangeRate = "1"
+)
+
+// FeeSchedule prices a transfer as Fixed plus BasisPoints of the amount, in
+// minor units. The zero value charges nothing.
+type FeeSchedule struct {
+	BasisPoints int64
+	Fixed       int64
+}
+
+// Fee rounds the percentage part half up to the nearest minor unit.
+func (f FeeSchedule) Fee(amount int64) int64 {
+	if amount <= 0 {
+		return f.Fixed
+	}
+	// Split the amount so amount*BasisPoints cannot overflow.
+	whole, rest := amount/10000, amount%10000
+	return f.Fixed + whole*f.BasisPoints + (rest*f.BasisPoints+5000)/10000
+}
+
+// Pricing configures transfer fees, how long quotes and holds stay valid and
+// how old an exchange rate may be. Zero durations take the defaults.
+type Pricing struct {
+	Fees       FeeSchedule
+	QuoteTTL   time.Duration
+	HoldTTL    time.Duration
+	MaxRateAge time.Duration
+}
+
+// PricingFromEnv reads LEDGER_TRANSFER_FEE_BPS, LEDGER_TRANSFER_FEE_FIXED,
+// LEDGER_QUOTE_TTL, LEDGER_HOLD_TTL and LEDGER_FX_MAX_RATE_AGE. Invalid values
+// are logged and replaced by the defaults: no fees, one-minute quotes,
+// week-long holds and rates up to a day old.
+func PricingFromEnv(logger *log.Logger) Pricing {
+	pricing := Pricing{QuoteTTL: defaultQuoteTTL, HoldTTL: defaultHoldTTL, MaxRateAge: defaultMaxRateAge}
+
+	for name, dst := range map[string]*int64{
+		"LEDGER_TRANSFER_FEE_BPS":   &pricing.Fees.BasisPoints,
+		"LEDGER_TRANSFER_FEE_FIXED": &pricing.Fees.Fixed,
+	} {
+		raw := utils.GetEnvTrimmed(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 || (name == "LEDGER_TRANSFER_FEE_BPS" && parsed > 10000) {
+			logger.Warn("Invalid transfer fee setting; charging no fee", "name", name, "value", raw)
+			continue
+		}
+		*dst = parsed
+	}
+
+	for name, dst := range map[string]*time.Duration{
+		"LEDGER_QUOTE_TTL":       &pricing.QuoteTTL,
+		"LEDGER_HOLD_TTL":        &pricing.HoldTTL,
+		"LEDGER_FX_MAX_RATE_AGE": &pricing.MaxRateAge,
+	} {
+		if raw := utils.GetEnvTrimmed(name); raw != "" {
+			if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+				*dst = parsed
+			} else {
+				logger.Warn("Invalid duration; using default", "name", name, "value", raw, "default", *dst)
+			}
+		}
+	}
+
+	return pricing
+}
@@ -0,0 +1,18 @@
+package ledger
+
+import (
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/region"
+)
+
+// checkHomeRegion rejects writes to an account homed in a region other than
+// this instance's, so each account has a single writer in an active-active
+// deployment. Accounts without a home region, such as the system account and
+// accounts created before regions were configured, are writable anywhere.
+func checkHomeRegion(account *models.Account) error {
+	local := region.LocalName()
+	if local == "" || account.HomeRegion == "" || account.HomeRegion == local {
+		return nil
+	}
+	return &WrongRegionError{AccountID: account.ID, HomeRegion: account.HomeRegion}
+}
@@ -0,0 +1,64 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setLocalRegion(t *testing.T, name string) {
+	t.Helper()
+	r, err := region.New(name, 1)
+	assert.NoError(t, err)
+	region.SetLocal(r)
+	t.Cleanup(func() { region.SetLocal(nil) })
+}
+
+func TestHomeRegion(t *testing.T) {
+	parentID := "6f1c1d7e-1b8a-4e5a-9d3c-2f0b7c1a9e01"
+
+	t.Run("new accounts are homed in the local region", func(t *testing.T) {
+		setLocalRegion(t, "eu-west")
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, acc *models.Account) (*models.Account, error) {
+				return acc, nil
+			},
+		)
+
+		result, err := service.CreateAccount(context.Background(), &CreateAccountRequest{Name: "Alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, "eu-west", result.HomeRegion)
+	})
+
+	t.Run("sub-accounts cannot be created under another region's account", func(t *testing.T) {
+		setLocalRegion(t, "eu-west")
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), parentID).Return(
+			&models.Account{ID: parentID, AccountType: models.AccountTypeUser, Currency: "USD", HomeRegion: "us-east"}, nil)
+
+		_, err := service.CreateAccount(context.Background(), &CreateAccountRequest{Name: "Store 1", ParentID: parentID})
+		assert.ErrorIs(t, err, ErrWrongRegion)
+		code, _ := mapDomainError(err)
+		assert.Equal(t, 421, code)
+	})
+
+	t.Run("accounts without a home region are writable anywhere", func(t *testing.T) {
+		setLocalRegion(t, "eu-west")
+
+		assert.NoError(t, checkHomeRegion(&models.Account{ID: models.SystemAccountID}))
+		assert.NoError(t, checkHomeRegion(&models.Account{ID: "acc-1", HomeRegion: "eu-west"}))
+		assert.ErrorIs(t, checkHomeRegion(&models.Account{ID: "acc-2", HomeRegion: "us-east"}), ErrWrongRegion)
+	})
+
+	t.Run("single-region deployments ignore home regions", func(t *testing.T) {
+		region.SetLocal(nil)
+		assert.NoError(t, checkHomeRegion(&models.Account{ID: "acc-2", HomeRegion: "us-east"}))
+	})
+}
@@ -3,10 +3,17 @@ package ledger
 import (
 	"context"
 	"errors"
+	"maps"
 	"slices"
+	"time"
 
 	"github.com/akeren/go-api-foundry/internal/models"
 	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/fieldcrypt"
+	"github.com/akeren/go-api-foundry/pkg/fx"
+	"github.com/akeren/go-api-foundry/pkg/rawsql"
+	"github.com/akeren/go-api-foundry/pkg/replica"
+	"github.com/akeren/go-api-foundry/pkg/txmanager"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -14,11 +21,63 @@ import (
 type LedgerRepository interface {
 	CreateAccount(ctx context.Context, account *models.Account) (*models.Account, error)
 	GetAccountByID(ctx context.Context, id string) (*models.Account, error)
+	GetAccountSubtree(ctx context.Context, id string, maxDepth int) ([]models.Account, error)
+	UpdateSiblingTransfers(ctx context.Context, id, policy string) (*models.Account, error)
 	ExecuteDoubleEntry(ctx context.Context, cmd DoubleEntryCommand) (*models.Transaction, error)
-	GetTransactionsByAccountID(ctx context.Context, accountID string, limit, offset int) ([]models.Transaction, error)
+	ExecuteSplitTransfer(ctx context.Context, cmd SplitTransferCommand) (*models.Transaction, error)
+	ReverseTransaction(ctx context.Context, cmd ReversalCommand) (*Reversal, error)
+	CreateTransferQuote(ctx context.Context, quote *models.TransferQuote) (*models.TransferQuote, error)
+	CreateHold(ctx context.Context, cmd HoldCommand) (*models.Hold, error)
+	GetHold(ctx context.Context, id string) (*models.Hold, error)
+	ReleaseHold(ctx context.Context, id string, dryRun bool) (*models.Hold, error)
+	ExpireHolds(ctx context.Context, now time.Time) (int64, error)
+	EnsureEntryPartitions(ctx context.Context, from, through time.Time) (int, error)
+	ArchiveTransactions(ctx context.Context, before time.Time, limit int) (int, error)
+	GetTransaction(ctx context.Context, id string) (*models.Transaction, error)
+	GetArchivedTransaction(ctx context.Context, id string) (*models.Transaction, error)
+	GetArchivedTransactionsByAccountID(ctx context.Context, accountID string, limit, offset int) ([]models.Transaction, error)
+	CountArchivedTransactionsByAccountID(ctx context.Context, accountID string) (int64, error)
+	GetExchangeRate(ctx context.Context, base, quote string, maxAge time.Duration) (*fx.Rate, error)
+	GetTransactionsByAccountID(ctx context.Context, accountID string, limit, offset int, includeEntries bool) ([]models.Transaction, error)
+	SummarizeEntries(ctx context.Context, accountID string, transactions []models.Transaction) (map[string]TransactionSummary, error)
+	CountTransactionsByAccountID(ctx context.Context, accountID string) (int64, error)
 	GetBalanceSnapshot(ctx context.Context, accountID string) (*BalanceSnapshot, error)
+	GetBalanceVersion(ctx context.Context, accountID string) (*BalanceVersion, error)
+	GetDailyBalances(ctx context.Context, accountID, from, to string) ([]DailyBalance, error)
+	GetBalanceBefore(ctx context.Context, accountID string, at time.Time) (int64, error)
+	StreamStatementEntries(ctx context.Context, accountID string, from, until time.Time, fn func(StatementEntry) error) error
 	GetAllAccountsForReconciliation(ctx context.Context) ([]AccountReconciliation, error)
 	GetLedgerTotals(ctx context.Context) (totalDebits, totalCredits int64, err error)
+	SearchTransactions(ctx context.Context, query TransactionSearchQuery) ([]models.Transaction, error)
+	CreateBalanceAlert(ctx context.Context, alert *models.BalanceAlert) (*models.BalanceAlert, error)
+	GetBalanceAlert(ctx context.Context, id string) (*models.BalanceAlert, error)
+	ListBalanceAlerts(ctx context.Context, accountID string) ([]models.BalanceAlert, error)
+	DeleteBalanceAlert(ctx context.Context, accountID, id string) error
+	ClaimAlertNotification(ctx context.Context, triggerID string) (bool, error)
+	CreateAccrualPlan(ctx context.Context, plan *models.AccrualPlan) (*models.AccrualPlan, error)
+	GetAccrualPlan(ctx context.Context, accountID, id string) (*models.AccrualPlan, error)
+	ListAccrualPlans(ctx context.Context, accountID string) ([]models.AccrualPlan, error)
+	ListDueAccrualPlans(ctx context.Context, through time.Time) ([]models.AccrualPlan, error)
+	UpdateAccrualPlanRate(ctx context.Context, accountID, id string, rateBasisPoints int64) (*models.AccrualPlan, error)
+	AccrueNextDay(ctx context.Context, planID string, through time.Time) (*AccrualPosting, error)
+	CorrectAccrual(ctx context.Context, planID string, day time.Time) (*AccrualPosting, error)
+	ListAccrualRecords(ctx context.Context, accountID string, from, to time.Time) ([]models.AccrualRecord, error)
+	GetFirstEntryTime(ctx context.Context) (*time.Time, error)
+	GetLatestDailySeal(ctx context.Context) (*models.DailySeal, error)
+	GetDailySeal(ctx context.Context, day time.Time) (*models.DailySeal, error)
+	CreateDailySeal(ctx context.Context, seal *models.DailySeal) error
+	StreamDayEntries(ctx context.Context, day time.Time, fn func(*models.LedgerEntry) error) error
+	StreamAccountEntries(ctx context.Context, accountID string, fn func(*models.LedgerEntry) error) error
+	RechainAccount(ctx context.Context, accountID string) (*Rechain, error)
+}
+
+// TransactionSearchQuery matches transactions whose description contains every
+// word of Text. AccountID optionally restricts results to one account.
+type TransactionSearchQuery struct {
+	Text      string
+	AccountID string
+	Limit     int
+	Offset    int
 }
 
 // DoubleEntryCommand encapsulates all data needed for a double-entry transaction.
@@ -30,6 +89,82 @@ type DoubleEntryCommand struct {
 	TransactionType string
 	IdempotencyKey  string
 	Description     string
+	// Fee is debited from the source on top of Amount and credited to the
+	// system account. A quote's fee replaces it.
+	Fee     int64
+	QuoteID string
+	// HoldID captures the hold: its funds pay for the posting, and the hold
+	// is marked CAPTURED by it.
+	HoldID string
+	// MaxRateAge refuses exchange rates older than this for transfers
+	// between currencies; zero accepts any age. A quote's rate replaces the
+	// current one.
+	MaxRateAge time.Duration
+	// DryRun executes every check and write, then rolls the transaction back.
+	DryRun bool
+}
+
+// SplitTransferCommand debits SourceAccountID once and credits each leg's
+// account with its amount, all in one currency.
+type SplitTransferCommand struct {
+	SourceAccountID string
+	Legs            []SplitLeg
+	Currency        string
+	IdempotencyKey  string
+	Description     string
+	// Fee is debited from the source on top of the legs and credited to the
+	// system account.
+	Fee    int64
+	DryRun bool
+}
+
+type SplitLeg struct {
+	DestAccountID string
+	Amount        int64
+}
+
+// HoldCommand reserves Amount of AccountID's funds until ExpiresAt.
+type HoldCommand struct {
+	AccountID      string
+	DestAccountID  string
+	Amount         int64
+	Currency       string
+	IdempotencyKey string
+	ExpiresAt      time.Time
+	DryRun         bool
+}
+
+// ReversalCommand undoes the transaction TransactionID.
+type ReversalCommand struct {
+	TransactionID  string
+	IdempotencyKey string
+	Description    string
+	DryRun         bool
+	// AccrualCorrection lets an accrual correction reverse an ACCRUAL
+	// posting, which other reversals may not.
+	AccrualCorrection bool
+}
+
+// Reversal is a posted reversal. Its source is the original transaction's
+// destination and its destination the original's source.
+type Reversal struct {
+	Transaction     *models.Transaction
+	SourceAccountID string
+	DestAccountID   string
+}
+
+// AccrualPosting is what accruing or correcting one day of a plan wrote.
+// Transaction posts Record, from SourceAccountID to DestAccountID; it is nil
+// when the day came to nothing or a correction kept the posting. A
+// correction also returns the record it superseded and, when it reversed
+// that record's posting, the reversal.
+type AccrualPosting struct {
+	Record          *models.AccrualRecord
+	Transaction     *models.Transaction
+	SourceAccountID string
+	DestAccountID   string
+	Superseded      *models.AccrualRecord
+	Reversal        *Reversal
 }
 
 // AccountReconciliation holds both cached and derived balances for an account.
@@ -42,25 +177,113 @@ type AccountReconciliation struct {
 	IsConsistent   bool   `json:"is_consistent"`
 }
 
-// BalanceSnapshot holds cached and derived balances read within a single transaction.
+// BalanceSnapshot holds cached and derived balances read within a single
+// transaction, with the total of the account's active holds.
 type BalanceSnapshot struct {
 	AccountID      string
 	CachedBalance  int64
 	DerivedBalance int64
+	HeldBalance    int64
 	Currency       string
 }
 
+// Rechain is what RechainAccount did: Entries is how many entries the
+// account has, Rewritten how many of them got new hashes.
+type Rechain struct {
+	Entries               int
+	Rewritten             int
+	PreviousLastEntryHash string
+	LastEntryHash         string
+}
+
+// BalanceVersion is what changes whenever an account's balance snapshot
+// does: its version, bumped with every balance write, and its held total,
+// which holds change without a balance write.
+type BalanceVersion struct {
+	Version     int64
+	HeldBalance int64
+}
+
+// DailyBalance is one account's totals for one UTC day, as refreshed into the
+// account_daily_balances materialized view. Day is formatted 2006-01-02.
+type DailyBalance struct {
+	Day            string
+	Credits        int64
+	Debits         int64
+	EntryCount     int64
+	ClosingBalance int64
+}
+
+// StatementEntry is one of an account's ledger entries with the transaction
+// that posted it.
+type StatementEntry struct {
+	TransactionID   string
+	TransactionType string
+	Description     string
+	EntryType       string
+	Amount          int64
+	CreatedAt       time.Time
+}
+
+// errDryRun rolls back a dry-run transaction after all of its writes succeeded.
+var errDryRun = errors.New("dry run rollback")
+
+// descriptionAAD binds encrypted descriptions and their blind indexes to the column.
+const descriptionAAD = "transactions.description"
+
 type ledgerRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	cipher      *fieldcrypt.Cipher
+	reads       *replica.Monitor
+	concurrency ConcurrencyMode
+}
+
+// NewLedgerRepository creates the repository, which locks the accounts it
+// writes. With a nil cipher, descriptions are stored in plaintext and
+// searched with LIKE.
+func NewLedgerRepository(db *gorm.DB, cipher *fieldcrypt.Cipher) LedgerRepository {
+	return &ledgerRepository{db: db, cipher: cipher, concurrency: ConcurrencyPessimistic}
+}
+
+// NewReplicatedLedgerRepository creates a repository that serves reads from
+// the replica behind reads when it can. Reads of balances and account
+// history fall back to db while the replica lags; searches and
+// reconciliation accept a lagging replica. Writes, and the reads inside
+// them, always use db. Writes keep concurrent postings apart as mode says.
+// With a nil reads and pessimistic mode it is NewLedgerRepository.
+func NewReplicatedLedgerRepository(db *gorm.DB, cipher *fieldcrypt.Cipher, reads *replica.Monitor, mode ConcurrencyMode) LedgerRepository {
+	return &ledgerRepository{db: db, cipher: cipher, reads: reads, concurrency: mode}
+}
+
+// conn returns the transaction of the unit of work ctx belongs to, or db.
+func (r *ledgerRepository) conn(ctx context.Context) *gorm.DB {
+	return txmanager.DB(ctx, r.db)
+}
+
+// reader returns the connection for a read outside a transaction. Consistent
+// reads are those a client expects to reflect its own writes. Reads within a
+// unit of work use its transaction, so they see its writes.
+func (r *ledgerRepository) reader(ctx context.Context, consistent bool) *gorm.DB {
+	if _, ok := txmanager.From(ctx); ok || r.reads == nil {
+		return r.conn(ctx)
+	}
+	return r.reads.DB(consistent).WithContext(ctx)
 }
 
-func NewLedgerRepository(db *gorm.DB) LedgerRepository {
-	return &ledgerRepository{db: db}
+func (r *ledgerRepository) decryptDescriptions(transactions []models.Transaction) error {
+	for i := range transactions {
+		plaintext, err := r.cipher.Decrypt(transactions[i].Description, descriptionAAD)
+		if err != nil {
+			return apperrors.NewDatabaseError("failed to decrypt transaction description", err)
+		}
+		transactions[i].Description = plaintext
+	}
+	return nil
 }
 
 func (r *ledgerRepository) CreateAccount(ctx context.Context, account *models.Account) (*models.Account, error) {
-	if err := r.db.WithContext(ctx).Create(account).Error; err != nil {
-		if isDuplicateKey(err) {
+	if err := r.conn(ctx).Create(account).Error; err != nil {
+		if apperrors.IsUniqueViolation(err) {
 			return nil, apperrors.NewConflictError("account already exists", err)
 		}
 		return nil, apperrors.NewDatabaseError("unable to create account", err)
@@ -69,8 +292,12 @@ func (r *ledgerRepository) CreateAccount(ctx context.Context, account *models.Ac
 }
 
 func (r *ledgerRepository) GetAccountByID(ctx context.Context, id string) (*models.Account, error) {
+	return getAccount(r.reader(ctx, true), id)
+}
+
+func getAccount(db *gorm.DB, id string) (*models.Account, error) {
 	var account models.Account
-	if err := r.db.WithContext(ctx).First(&account, "id = ?", id).Error; err != nil {
+	if err := db.First(&account, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrAccountNotFound
 		}
@@ -79,26 +306,101 @@ func (r *ledgerRepository) GetAccountByID(ctx context.Context, id string) (*mode
 	return &account, nil
 }
 
+const accountSubtreeQuery = `
+	WITH RECURSIVE subtree (id, depth) AS (
+		SELECT id, 0 FROM accounts WHERE id = @id
+		UNION ALL
+		SELECT a.id, s.depth + 1 FROM accounts a JOIN subtree s ON a.parent_id = s.id WHERE s.depth < @max_depth
+	)
+	SELECT accounts.* FROM accounts JOIN subtree ON accounts.id = subtree.id
+	ORDER BY subtree.depth, accounts.created_at`
+
+// GetAccountSubtree returns the account followed by its descendants, at most
+// maxDepth levels below it, read in one statement so the balances agree.
+func (r *ledgerRepository) GetAccountSubtree(ctx context.Context, id string, maxDepth int) ([]models.Account, error) {
+	var accounts []models.Account
+	err := rawsql.Select(ctx, r.reader(ctx, true), &accounts, accountSubtreeQuery, rawsql.Params{"id": id, "max_depth": maxDepth})
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("failed to fetch account hierarchy", err)
+	}
+	if len(accounts) == 0 || accounts[0].ID != id {
+		return nil, ErrAccountNotFound
+	}
+	return accounts, nil
+}
+
+func (r *ledgerRepository) UpdateSiblingTransfers(ctx context.Context, id, policy string) (*models.Account, error) {
+	db := r.conn(ctx)
+	account, err := getAccount(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHomeRegion(account); err != nil {
+		return nil, err
+	}
+
+	res := db.Model(&models.Account{}).
+		Where("id = ? AND account_type = ?", id, models.AccountTypeUser).
+		Update("sibling_transfers", policy)
+	if res.Error != nil {
+		return nil, apperrors.NewDatabaseError("failed to update account", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrAccountNotFound
+	}
+	return getAccount(db, id)
+}
+
 func (r *ledgerRepository) ExecuteDoubleEntry(ctx context.Context, cmd DoubleEntryCommand) (*models.Transaction, error) {
 	var result *models.Transaction
 
-	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Step 1: Deterministic lock ordering — sort account IDs to prevent deadlocks
-		accountIDs := []string{cmd.SourceAccountID, cmd.DestAccountID}
-		slices.Sort(accountIDs)
-
-		// Step 2: Lock accounts in sorted order (FOR UPDATE on PostgreSQL, no-op on SQLite)
-		accounts := make(map[string]*models.Account, 2)
-		for _, id := range accountIDs {
-			var acc models.Account
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		// Step 0: Lock the quote, if any, before the accounts. Its fee decides
+		// whether the system account takes part in the posting.
+		var quote *models.TransferQuote
+		if cmd.QuoteID != "" {
+			var q models.TransferQuote
 			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-				Where("id = ?", id).First(&acc).Error; err != nil {
+				Where("id = ?", cmd.QuoteID).First(&q).Error; err != nil {
 				if errors.Is(err, gorm.ErrRecordNotFound) {
-					return ErrAccountNotFound
+					return ErrQuoteNotFound
 				}
-				return apperrors.NewDatabaseError("failed to lock account", err)
+				return apperrors.NewDatabaseError("failed to lock quote", err)
+			}
+			quote = &q
+			cmd.Fee = q.Fee
+		}
+		var hold *models.Hold
+		if cmd.HoldID != "" {
+			h, err := lockHold(tx, cmd.HoldID)
+			if err != nil {
+				return err
+			}
+			hold = h
+		}
+		if cmd.Fee > 0 && (models.IsSystemAccountID(cmd.SourceAccountID) || models.IsSystemAccountID(cmd.DestAccountID)) {
+			return ErrSystemAccountForbidden
+		}
+
+		// Step 1: Lock the user accounts in sorted order. The system accounts a
+		// posting needs are locked after them (Step 4b), also sorted, so every
+		// posting takes its locks in the same order and none can deadlock.
+		var userIDs []string
+		for _, id := range []string{cmd.SourceAccountID, cmd.DestAccountID} {
+			if !models.IsSystemAccountID(id) {
+				userIDs = append(userIDs, id)
 			}
-			accounts[id] = &acc
+		}
+		if len(userIDs) == 0 {
+			return ErrSystemAccountForbidden
+		}
+		slices.Sort(userIDs)
+
+		// Step 2: Lock accounts in sorted order (FOR UPDATE on PostgreSQL, no-op on SQLite,
+		// plain reads in optimistic mode)
+		accounts := make(map[string]*models.Account, 4)
+		if err := r.lockAccounts(tx, accounts, userIDs); err != nil {
+			return err
 		}
 
 		// Step 3: Check idempotency AFTER acquiring locks. Because all operations
@@ -107,206 +409,2302 @@ func (r *ledgerRepository) ExecuteDoubleEntry(ctx context.Context, cmd DoubleEnt
 		// the PostgreSQL "current transaction is aborted" problem that occurs when
 		// a UNIQUE constraint violation is handled with a fallback SELECT.
 		if cmd.IdempotencyKey != "" {
-			var existing models.Transaction
-			if err := tx.Where("idempotency_key = ?", cmd.IdempotencyKey).
-				Preload("Entries").
-				First(&existing).Error; err == nil {
-				if existing.Amount != cmd.Amount || existing.TransactionType != cmd.TransactionType {
-					return ErrIdempotencyConflict
-				}
-				result = &existing
+			replayed, err := r.replayByIdempotencyKey(tx, cmd.IdempotencyKey, cmd.TransactionType, cmd.Amount)
+			if err != nil {
+				return err
+			}
+			if replayed != nil {
+				result = replayed
 				return nil // Idempotent return
 			}
 		}
 
-		source := accounts[cmd.SourceAccountID]
-		dest := accounts[cmd.DestAccountID]
+		// Quotes are checked after the idempotency replay, so retrying a
+		// quoted transfer returns its result instead of ErrQuoteUsed.
+		if quote != nil {
+			switch {
+			case quote.TransactionID != nil:
+				return ErrQuoteUsed
+			case time.Now().After(quote.ExpiresAt):
+				return ErrQuoteExpired
+			case quote.SourceAccountID != cmd.SourceAccountID || quote.DestAccountID != cmd.DestAccountID || quote.Amount != cmd.Amount:
+				return ErrQuoteMismatch
+			}
+		}
+
+		if hold != nil {
+			if err := checkCapture(hold, cmd); err != nil {
+				return err
+			}
+		}
 
-		// Step 4: Validate currencies match
-		if source.Currency != dest.Currency {
+		// Step 3b: Only an account's home region posts to it
+		for _, id := range userIDs {
+			if err := checkHomeRegion(accounts[id]); err != nil {
+				return err
+			}
+		}
+
+		// Step 4: Validate currencies. The system side of a deposit or
+		// withdrawal takes the user account's currency; only transfers may
+		// cross currencies.
+		source, dest := accounts[cmd.SourceAccountID], accounts[cmd.DestAccountID]
+		var sourceCurrency, destCurrency string
+		switch {
+		case source == nil:
+			sourceCurrency, destCurrency = dest.Currency, dest.Currency
+		case dest == nil:
+			sourceCurrency, destCurrency = source.Currency, source.Currency
+		default:
+			sourceCurrency, destCurrency = source.Currency, dest.Currency
+		}
+		if cmd.Currency != "" && cmd.Currency != sourceCurrency {
 			return ErrCurrencyMismatch
 		}
-		if cmd.Currency != "" && cmd.Currency != source.Currency {
+		crossCurrency := sourceCurrency != destCurrency
+		if crossCurrency && cmd.TransactionType != models.TransactionTypeTransfer {
 			return ErrCurrencyMismatch
 		}
 
-		// Step 5: Balance check — only USER accounts cannot go negative
-		if source.AccountType == models.AccountTypeUser && source.Balance < cmd.Amount {
-			return ErrInsufficientFunds
+		// Step 4a: Convert the amount, at the quoted rate when there is one
+		rate := fx.Rate{Base: sourceCurrency, Quote: destCurrency, Value: identityExchangeRate}
+		if crossCurrency {
+			if quote != nil {
+				rate.Value = quote.ExchangeRate
+			} else {
+				var err error
+				if rate, err = exchangeRate(ctx, tx, sourceCurrency, destCurrency, cmd.MaxRateAge); err != nil {
+					return err
+				}
+			}
+		}
+		destAmount, err := fx.Convert(cmd.Amount, rate)
+		if err != nil {
+			return apperrors.NewInvalidRequestError("amount cannot be converted", err)
+		}
+		if destAmount <= 0 {
+			return ErrInvalidAmount
+		}
+
+		// Step 4b: Lock the system accounts the posting touches: the
+		// counterparty of a deposit or withdrawal, the fee's recipient, and
+		// both sides of a currency exchange.
+		var systemCurrencies []string
+		if source == nil || cmd.Fee > 0 || crossCurrency {
+			systemCurrencies = append(systemCurrencies, sourceCurrency)
+		}
+		if (dest == nil || crossCurrency) && !slices.Contains(systemCurrencies, destCurrency) {
+			systemCurrencies = append(systemCurrencies, destCurrency)
+		}
+		systemIDs, err := ensureSystemAccounts(tx, systemCurrencies)
+		if err != nil {
+			return err
+		}
+		if err := r.lockAccounts(tx, accounts, systemIDs); err != nil {
+			return err
+		}
+		sourceSystem := accounts[models.SystemAccountIDFor(sourceCurrency)]
+		destSystem := accounts[models.SystemAccountIDFor(destCurrency)]
+		if source == nil {
+			source = sourceSystem
+		}
+		if dest == nil {
+			dest = destSystem
+		}
+
+		// Step 4c: Sub-accounts of the same parent follow the parent's policy
+		if source.ParentID != nil && dest.ParentID != nil && *source.ParentID == *dest.ParentID {
+			var parent models.Account
+			if err := tx.Select("sibling_transfers").Where("id = ?", *source.ParentID).First(&parent).Error; err != nil {
+				return apperrors.NewDatabaseError("failed to read parent account", err)
+			}
+			if parent.SiblingTransfers == models.SiblingTransfersDeny {
+				return ErrSiblingTransferBlocked
+			}
+		}
+
+		// Step 5: Balance check — only USER accounts cannot go negative, and
+		// funds held for other captures are not available. A captured hold's
+		// own funds are.
+		if source.AccountType == models.AccountTypeUser {
+			held, err := heldAmount(tx, source.ID, cmd.HoldID)
+			if err != nil {
+				return err
+			}
+			if source.Balance-held < cmd.Amount+cmd.Fee {
+				return ErrInsufficientFunds
+			}
 		}
 
-		// Step 6: Create transaction record
+		// Step 6: Create transaction record (description encrypted at rest when configured)
+		description, err := r.cipher.Encrypt(cmd.Description, descriptionAAD)
+		if err != nil {
+			return apperrors.NewDatabaseError("failed to encrypt transaction description", err)
+		}
 		txn := models.Transaction{
 			IdempotencyKey:  cmd.IdempotencyKey,
 			TransactionType: cmd.TransactionType,
 			Amount:          cmd.Amount,
-			Currency:        source.Currency,
-			Description:     cmd.Description,
+			Currency:        sourceCurrency,
+			DestAmount:      destAmount,
+			DestCurrency:    destCurrency,
+			ExchangeRate:    rate.Value,
+			Fee:             cmd.Fee,
+			Description:     description,
 		}
 		if err := tx.Create(&txn).Error; err != nil {
+			// Without locks, a concurrent request with the same idempotency
+			// key can get past Step 3; running again replays its result.
+			if r.optimistic() && apperrors.IsUniqueViolation(err) {
+				return errVersionConflict
+			}
 			return apperrors.NewDatabaseError("failed to create transaction", err)
 		}
+		txn.Description = cmd.Description
 
-		// Step 7: Create DEBIT entry (source account)
-		sourceBalanceAfter := source.Balance - cmd.Amount
-		debitEntry := models.LedgerEntry{
-			TransactionID: txn.ID,
-			AccountID:     source.ID,
-			EntryType:     models.EntryTypeDebit,
-			Amount:        cmd.Amount,
-			BalanceAfter:  sourceBalanceAfter,
+		if err := r.indexDescription(tx, txn.ID, cmd.Description); err != nil {
+			return err
+		}
+
+		// Step 7: Build the entries, keeping each account's running balance.
+		// Every currency balances on its own: an exchange sells the source
+		// currency to its system account and buys the destination currency
+		// from the other system account.
+		var posted []*models.Account
+		post := func(acc *models.Account, entryType string, amount int64) {
+			if entryType == models.EntryTypeDebit {
+				acc.Balance -= amount
+			} else {
+				acc.Balance += amount
+			}
+			txn.Entries = append(txn.Entries, models.LedgerEntry{
+				TransactionID: txn.ID,
+				AccountID:     acc.ID,
+				EntryType:     entryType,
+				Amount:        amount,
+				BalanceAfter:  acc.Balance,
+				CreatedAt:     txn.CreatedAt,
+			})
+			if !slices.Contains(posted, acc) {
+				posted = append(posted, acc)
+			}
+		}
+		post(source, models.EntryTypeDebit, cmd.Amount+cmd.Fee)
+		if crossCurrency {
+			post(sourceSystem, models.EntryTypeCredit, cmd.Amount)
+			post(destSystem, models.EntryTypeDebit, destAmount)
 		}
-		if err := tx.Create(&debitEntry).Error; err != nil {
-			return apperrors.NewDatabaseError("failed to create debit entry", err)
+		post(dest, models.EntryTypeCredit, destAmount)
+		if cmd.Fee > 0 {
+			post(sourceSystem, models.EntryTypeCredit, cmd.Fee)
 		}
 
-		// Step 8: Create CREDIT entry (dest account)
-		destBalanceAfter := dest.Balance + cmd.Amount
-		creditEntry := models.LedgerEntry{
-			TransactionID: txn.ID,
-			AccountID:     dest.ID,
-			EntryType:     models.EntryTypeCredit,
-			Amount:        cmd.Amount,
-			BalanceAfter:  destBalanceAfter,
+		// Step 8: Chain and create the entries
+		chainEntries(txn.Entries, accounts)
+		if err := tx.Create(&txn.Entries).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to create ledger entries", err)
+		}
+
+		// Step 9: Update the balance and version of every account posted to
+		for _, acc := range posted {
+			if err := saveBalance(tx, acc); err != nil {
+				return err
+			}
 		}
-		if err := tx.Create(&creditEntry).Error; err != nil {
-			return apperrors.NewDatabaseError("failed to create credit entry", err)
+		if err := triggerAlerts(tx, &txn, accounts); err != nil {
+			return err
 		}
 
-		// Step 9: Update source account balance and version
-		if err := tx.Model(source).Updates(map[string]any{
-			"balance": sourceBalanceAfter,
-			"version": source.Version + 1,
-		}).Error; err != nil {
-			return apperrors.NewDatabaseError("failed to update source account", err)
+		// Step 10: Mark the quote as used by this transaction
+		if quote != nil {
+			if err := tx.Model(quote).Update("transaction_id", txn.ID).Error; err != nil {
+				return apperrors.NewDatabaseError("failed to mark quote as used", err)
+			}
 		}
 
-		// Step 10: Update dest account balance and version
-		if err := tx.Model(dest).Updates(map[string]any{
-			"balance": destBalanceAfter,
-			"version": dest.Version + 1,
-		}).Error; err != nil {
-			return apperrors.NewDatabaseError("failed to update destination account", err)
+		// Step 11: Mark the hold as captured by this transaction
+		if hold != nil {
+			if err := tx.Model(hold).Updates(map[string]any{
+				"status":          models.HoldStatusCaptured,
+				"captured_amount": cmd.Amount,
+				"transaction_id":  txn.ID,
+			}).Error; err != nil {
+				return apperrors.NewDatabaseError("failed to capture hold", err)
+			}
 		}
 
-		txn.Entries = []models.LedgerEntry{debitEntry, creditEntry}
 		result = &txn
+		if cmd.DryRun {
+			return errDryRun
+		}
 		return nil
 	})
 
-	if err != nil {
+	if err != nil && !errors.Is(err, errDryRun) {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (r *ledgerRepository) GetTransactionsByAccountID(ctx context.Context, accountID string, limit, offset int) ([]models.Transaction, error) {
-	// Subquery: find transaction IDs that involve this account
-	subQuery := r.db.WithContext(ctx).
-		Model(&models.LedgerEntry{}).
-		Select("DISTINCT transaction_id").
-		Where("account_id = ?", accountID)
-
-	query := r.db.WithContext(ctx).
-		Where("id IN (?)", subQuery).
-		Preload("Entries").
-		Order("created_at DESC")
-
-	if limit > 0 {
-		query = query.Limit(limit)
+// ExecuteSplitTransfer posts a SPLIT_TRANSFER: one debit from the source for
+// the legs and the fee, one credit per leg and one to the system account for
+// the fee. It takes its locks as ExecuteDoubleEntry does. Every account must
+// be in the source's currency.
+func (r *ledgerRepository) ExecuteSplitTransfer(ctx context.Context, cmd SplitTransferCommand) (*models.Transaction, error) {
+	var amount int64
+	userIDs := []string{cmd.SourceAccountID}
+	for _, leg := range cmd.Legs {
+		amount += leg.Amount
+		userIDs = append(userIDs, leg.DestAccountID)
 	}
-	if offset > 0 {
-		query = query.Offset(offset)
+	if slices.ContainsFunc(userIDs, models.IsSystemAccountID) {
+		return nil, ErrSystemAccountForbidden
 	}
+	slices.Sort(userIDs)
 
-	var transactions []models.Transaction
-	if err := query.Find(&transactions).Error; err != nil {
-		return nil, apperrors.NewDatabaseError("failed to fetch transactions", err)
-	}
+	var result *models.Transaction
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		// Step 1: Lock the user accounts in sorted order
+		accounts := make(map[string]*models.Account, len(userIDs)+1)
+		if err := r.lockAccounts(tx, accounts, userIDs); err != nil {
+			return err
+		}
 
-	return transactions, nil
-}
+		// Step 2: Check idempotency after acquiring locks. A replay must
+		// credit the same accounts with the same amounts.
+		if cmd.IdempotencyKey != "" {
+			replayed, err := r.replayByIdempotencyKey(tx, cmd.IdempotencyKey, models.TransactionTypeSplitTransfer, amount)
+			if err != nil {
+				return err
+			}
+			if replayed != nil {
+				if !creditsLegs(replayed, cmd.Legs) {
+					return ErrIdempotencyConflict
+				}
+				result = replayed
+				return nil // Idempotent return
+			}
+		}
 
-func (r *ledgerRepository) GetBalanceSnapshot(ctx context.Context, accountID string) (*BalanceSnapshot, error) {
-	var snapshot BalanceSnapshot
+		// Step 3: Only an account's home region posts to it, and a split
+		// never converts currencies.
+		source := accounts[cmd.SourceAccountID]
+		if cmd.Currency != "" && cmd.Currency != source.Currency {
+			return ErrCurrencyMismatch
+		}
+		for _, id := range userIDs {
+			if err := checkHomeRegion(accounts[id]); err != nil {
+				return err
+			}
+			if accounts[id].Currency != source.Currency {
+				return ErrCurrencyMismatch
+			}
+		}
 
-	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var account models.Account
-		if err := tx.First(&account, "id = ?", accountID).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return ErrAccountNotFound
+		// Step 4: Lock the system account that collects the fee
+		var system *models.Account
+		if cmd.Fee > 0 {
+			systemIDs, err := ensureSystemAccounts(tx, []string{source.Currency})
+			if err != nil {
+				return err
 			}
-			return apperrors.NewDatabaseError("failed to fetch account", err)
+			if err := r.lockAccounts(tx, accounts, systemIDs); err != nil {
+				return err
+			}
+			system = accounts[systemIDs[0]]
 		}
 
-		var derived int64
-		if err := tx.Model(&models.LedgerEntry{}).
-			Where("account_id = ?", accountID).
-			Select("COALESCE(SUM(CASE WHEN entry_type = ? THEN amount ELSE -amount END), 0)", models.EntryTypeCredit).
-			Scan(&derived).Error; err != nil {
-			return apperrors.NewDatabaseError("failed to calculate derived balance", err)
+		// Step 5: Sub-accounts of the source's parent follow its policy
+		if source.ParentID != nil {
+			for _, leg := range cmd.Legs {
+				dest := accounts[leg.DestAccountID]
+				if dest.ParentID == nil || *dest.ParentID != *source.ParentID {
+					continue
+				}
+				var parent models.Account
+				if err := tx.Select("sibling_transfers").Where("id = ?", *source.ParentID).First(&parent).Error; err != nil {
+					return apperrors.NewDatabaseError("failed to read parent account", err)
+				}
+				if parent.SiblingTransfers == models.SiblingTransfersDeny {
+					return ErrSiblingTransferBlocked
+				}
+				break
+			}
 		}
 
-		snapshot = BalanceSnapshot{
-			AccountID:      account.ID,
-			CachedBalance:  account.Balance,
-			DerivedBalance: derived,
-			Currency:       account.Currency,
+		// Step 6: Balance check against the funds not held
+		held, err := heldAmount(tx, source.ID, "")
+		if err != nil {
+			return err
+		}
+		if source.Balance-held < amount+cmd.Fee {
+			return ErrInsufficientFunds
+		}
+
+		// Step 7: Create the transaction record
+		description, err := r.cipher.Encrypt(cmd.Description, descriptionAAD)
+		if err != nil {
+			return apperrors.NewDatabaseError("failed to encrypt transaction description", err)
+		}
+		txn := models.Transaction{
+			IdempotencyKey:  cmd.IdempotencyKey,
+			TransactionType: models.TransactionTypeSplitTransfer,
+			Amount:          amount,
+			Currency:        source.Currency,
+			DestAmount:      amount,
+			DestCurrency:    source.Currency,
+			ExchangeRate:    identityExchangeRate,
+			Fee:             cmd.Fee,
+			Description:     description,
+		}
+		if err := tx.Create(&txn).Error; err != nil {
+			if r.optimistic() && apperrors.IsUniqueViolation(err) {
+				return errVersionConflict
+			}
+			return apperrors.NewDatabaseError("failed to create transaction", err)
+		}
+		txn.Description = cmd.Description
+		if err := r.indexDescription(tx, txn.ID, cmd.Description); err != nil {
+			return err
+		}
+
+		// Step 8: Build the entries in leg order, keeping running balances
+		posted := []*models.Account{source}
+		post := func(acc *models.Account, entryType string, amount int64) {
+			if entryType == models.EntryTypeDebit {
+				acc.Balance -= amount
+			} else {
+				acc.Balance += amount
+			}
+			txn.Entries = append(txn.Entries, models.LedgerEntry{
+				TransactionID: txn.ID,
+				AccountID:     acc.ID,
+				EntryType:     entryType,
+				Amount:        amount,
+				BalanceAfter:  acc.Balance,
+				CreatedAt:     txn.CreatedAt,
+			})
+			if !slices.Contains(posted, acc) {
+				posted = append(posted, acc)
+			}
+		}
+		post(source, models.EntryTypeDebit, amount+cmd.Fee)
+		for _, leg := range cmd.Legs {
+			post(accounts[leg.DestAccountID], models.EntryTypeCredit, leg.Amount)
+		}
+		if system != nil {
+			post(system, models.EntryTypeCredit, cmd.Fee)
+		}
+
+		// Step 9: Chain and create the entries and update the balances they
+		// changed
+		chainEntries(txn.Entries, accounts)
+		if err := tx.Create(&txn.Entries).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to create ledger entries", err)
+		}
+		for _, acc := range posted {
+			if err := saveBalance(tx, acc); err != nil {
+				return err
+			}
+		}
+		if err := triggerAlerts(tx, &txn, accounts); err != nil {
+			return err
+		}
+
+		result = &txn
+		if cmd.DryRun {
+			return errDryRun
 		}
 		return nil
 	})
 
-	if err != nil {
+	if err != nil && !errors.Is(err, errDryRun) {
 		return nil, err
 	}
-	return &snapshot, nil
+	return result, nil
 }
 
-func (r *ledgerRepository) GetAllAccountsForReconciliation(ctx context.Context) ([]AccountReconciliation, error) {
-	var results []AccountReconciliation
-
-	err := r.db.WithContext(ctx).
-		Table("accounts a").
-		Select(`a.id AS account_id,
-			a.name AS account_name,
-			a.account_type,
-			a.balance AS cached_balance,
-			COALESCE(SUM(CASE WHEN le.entry_type = ? THEN le.amount ELSE 0 END), 0) -
-			COALESCE(SUM(CASE WHEN le.entry_type = ? THEN le.amount ELSE 0 END), 0) AS derived_balance`,
-			models.EntryTypeCredit, models.EntryTypeDebit).
-		Joins("LEFT JOIN ledger_entries le ON le.account_id = a.id").
-		Group("a.id, a.name, a.account_type, a.balance").
-		Scan(&results).Error
-	if err != nil {
-		return nil, apperrors.NewDatabaseError("failed to run reconciliation", err)
+// creditsLegs reports whether txn credits each leg's account with exactly
+// the leg's amount.
+func creditsLegs(txn *models.Transaction, legs []SplitLeg) bool {
+	credited := make(map[string]int64, len(legs))
+	for _, e := range txn.Entries {
+		if e.EntryType == models.EntryTypeCredit && !models.IsSystemAccountID(e.AccountID) {
+			credited[e.AccountID] += e.Amount
+		}
 	}
-
-	for i := range results {
-		results[i].IsConsistent = results[i].CachedBalance == results[i].DerivedBalance
+	if len(credited) != len(legs) {
+		return false
 	}
-
-	return results, nil
-}
-
-func (r *ledgerRepository) GetLedgerTotals(ctx context.Context) (totalDebits, totalCredits int64, err error) {
-	type totals struct {
-		TotalDebits  int64
-		TotalCredits int64
+	for _, leg := range legs {
+		if credited[leg.DestAccountID] != leg.Amount {
+			return false
+		}
 	}
-	var t totals
+	return true
+}
 
-	if err := r.db.WithContext(ctx).
-		Model(&models.LedgerEntry{}).
-		Select(`COALESCE(SUM(CASE WHEN entry_type = ? THEN amount ELSE 0 END), 0) AS total_debits,
-			COALESCE(SUM(CASE WHEN entry_type = ? THEN amount ELSE 0 END), 0) AS total_credits`,
-			models.EntryTypeDebit, models.EntryTypeCredit).
-		Scan(&t).Error; err != nil {
-		return 0, 0, apperrors.NewDatabaseError("failed to calculate ledger totals", err)
-	}
+// ReverseTransaction posts a REVERSAL that debits every account the original
+// credited and credits every account it debited, by the same amounts, so
+// fees are refunded and exchanges undone at the original rate. User accounts
+// must hold enough to give back what they received.
+func (r *ledgerRepository) ReverseTransaction(ctx context.Context, cmd ReversalCommand) (*Reversal, error) {
+	var result *Reversal
 
-	return t.TotalDebits, t.TotalCredits, nil
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		var original models.Transaction
+		if err := tx.Where("id = ?", cmd.TransactionID).First(&original).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTransactionNotFound
+			}
+			return apperrors.NewDatabaseError("failed to fetch transaction", err)
+		}
+		loaded := []models.Transaction{original}
+		if err := loadEntries(tx, loaded); err != nil {
+			return err
+		}
+		original = loaded[0]
+
+		// Step 1: Lock the user accounts, then the system accounts, each in
+		// sorted order, as ExecuteDoubleEntry does.
+		var userIDs, systemIDs []string
+		for _, e := range original.Entries {
+			ids := &userIDs
+			if models.IsSystemAccountID(e.AccountID) {
+				ids = &systemIDs
+			}
+			if !slices.Contains(*ids, e.AccountID) {
+				*ids = append(*ids, e.AccountID)
+			}
+		}
+		slices.Sort(userIDs)
+		slices.Sort(systemIDs)
+		accounts := make(map[string]*models.Account, len(userIDs)+len(systemIDs))
+		if err := r.lockAccounts(tx, accounts, append(userIDs, systemIDs...)); err != nil {
+			return err
+		}
+		source, dest := transactionParties(&original, accounts)
+
+		// Step 2: Check idempotency after acquiring locks
+		if cmd.IdempotencyKey != "" {
+			var existing models.Transaction
+			if err := tx.Where("idempotency_key = ?", cmd.IdempotencyKey).
+				First(&existing).Error; err == nil {
+				if existing.ReversedTransactionID == nil || *existing.ReversedTransactionID != original.ID {
+					return ErrIdempotencyConflict
+				}
+				replayed := []models.Transaction{existing}
+				if err := loadEntries(tx, replayed); err != nil {
+					return err
+				}
+				if err := r.decryptDescriptions(replayed); err != nil {
+					return err
+				}
+				if err := loadAlertTriggers(tx, &replayed[0]); err != nil {
+					return err
+				}
+				result = &Reversal{Transaction: &replayed[0], SourceAccountID: dest, DestAccountID: source}
+				return nil // Idempotent return
+			}
+		}
+
+		// Step 3: Each transaction is reversed once, and reversals not at all
+		if original.TransactionType == models.TransactionTypeReversal {
+			return ErrReversalNotReversible
+		}
+		if original.TransactionType == models.TransactionTypeAccrual && !cmd.AccrualCorrection {
+			return ErrAccrualNotReversible
+		}
+		var reversals int64
+		if err := tx.Model(&models.Transaction{}).
+			Where("reversed_transaction_id = ?", original.ID).
+			Count(&reversals).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to check for reversals", err)
+		}
+		if reversals > 0 {
+			return ErrAlreadyReversed
+		}
+
+		// Step 3b: Only an account's home region posts to it
+		for _, id := range userIDs {
+			if err := checkHomeRegion(accounts[id]); err != nil {
+				return err
+			}
+		}
+
+		// Step 4: Build the opposite entries, keeping each account's running
+		// balance; user accounts cannot go negative.
+		var entries []models.LedgerEntry
+		var posted []*models.Account
+		for _, e := range original.Entries {
+			acc := accounts[e.AccountID]
+			entryType := models.EntryTypeCredit
+			if e.EntryType == models.EntryTypeCredit {
+				entryType = models.EntryTypeDebit
+				acc.Balance -= e.Amount
+			} else {
+				acc.Balance += e.Amount
+			}
+			entries = append(entries, models.LedgerEntry{
+				AccountID:    acc.ID,
+				EntryType:    entryType,
+				Amount:       e.Amount,
+				BalanceAfter: acc.Balance,
+			})
+			if !slices.Contains(posted, acc) {
+				posted = append(posted, acc)
+			}
+		}
+		for _, acc := range posted {
+			if acc.AccountType != models.AccountTypeUser {
+				continue
+			}
+			held, err := heldAmount(tx, acc.ID, "")
+			if err != nil {
+				return err
+			}
+			if acc.Balance-held < 0 {
+				return ErrInsufficientFunds
+			}
+		}
+
+		// Step 5: Create the reversal (description encrypted at rest when configured)
+		description, err := r.cipher.Encrypt(cmd.Description, descriptionAAD)
+		if err != nil {
+			return apperrors.NewDatabaseError("failed to encrypt transaction description", err)
+		}
+		txn := models.Transaction{
+			IdempotencyKey:        cmd.IdempotencyKey,
+			TransactionType:       models.TransactionTypeReversal,
+			Amount:                original.Amount,
+			Currency:              original.Currency,
+			DestAmount:            original.DestAmount,
+			DestCurrency:          original.DestCurrency,
+			ExchangeRate:          original.ExchangeRate,
+			Fee:                   original.Fee,
+			Description:           description,
+			ReversedTransactionID: &original.ID,
+		}
+		if err := tx.Create(&txn).Error; err != nil {
+			// Optimistically, the duplicate may be the idempotency key of a
+			// concurrent reversal; running again tells the two apart.
+			if r.optimistic() && apperrors.IsUniqueViolation(err) {
+				return errVersionConflict
+			}
+			if apperrors.IsUniqueViolation(err) {
+				return ErrAlreadyReversed
+			}
+			return apperrors.NewDatabaseError("failed to create transaction", err)
+		}
+		txn.Description = cmd.Description
+
+		if err := r.indexDescription(tx, txn.ID, cmd.Description); err != nil {
+			return err
+		}
+
+		// Step 6: Chain and create the entries and update the balances they
+		// changed
+		for i := range entries {
+			entries[i].TransactionID = txn.ID
+			entries[i].CreatedAt = txn.CreatedAt
+		}
+		chainEntries(entries, accounts)
+		if err := tx.Create(&entries).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to create ledger entries", err)
+		}
+		txn.Entries = entries
+		for _, acc := range posted {
+			if err := saveBalance(tx, acc); err != nil {
+				return err
+			}
+		}
+		if err := triggerAlerts(tx, &txn, accounts); err != nil {
+			return err
+		}
+
+		result = &Reversal{Transaction: &txn, SourceAccountID: dest, DestAccountID: source}
+		if cmd.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// triggerAlerts records the balance alerts txn triggers on the user accounts
+// it posted to and attaches them to txn. A LOW_BALANCE alert triggers when
+// the posting takes the balance from at least the threshold to below it, so
+// it fires once per crossing rather than on every posting below it. A
+// LARGE_DEBIT alert triggers when one entry debits at least the threshold.
+// It runs in the posting's transaction, after the entries are built.
+func triggerAlerts(tx *gorm.DB, txn *models.Transaction, accounts map[string]*models.Account) error {
+	type movement struct {
+		opening, closing, largestDebit int64
+	}
+	var ids []string
+	moved := make(map[string]*movement, 2)
+	for _, e := range txn.Entries {
+		if models.IsSystemAccountID(e.AccountID) {
+			continue
+		}
+		m, ok := moved[e.AccountID]
+		if !ok {
+			m = &movement{opening: e.BalanceAfter}
+			if e.EntryType == models.EntryTypeDebit {
+				m.opening += e.Amount
+			} else {
+				m.opening -= e.Amount
+			}
+			moved[e.AccountID] = m
+			ids = append(ids, e.AccountID)
+		}
+		m.closing = e.BalanceAfter
+		if e.EntryType == models.EntryTypeDebit {
+			m.largestDebit = max(m.largestDebit, e.Amount)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var alerts []models.BalanceAlert
+	if err := tx.Where("account_id IN ?", ids).Order("created_at, id").Find(&alerts).Error; err != nil {
+		return apperrors.NewDatabaseError("failed to read balance alerts", err)
+	}
+	var triggers []models.BalanceAlertTrigger
+	for _, alert := range alerts {
+		m := moved[alert.AccountID]
+		trigger := models.BalanceAlertTrigger{
+			AlertID:       alert.ID,
+			AccountID:     alert.AccountID,
+			TransactionID: txn.ID,
+			Kind:          alert.Kind,
+			Threshold:     alert.Threshold,
+			Balance:       m.closing,
+			Currency:      accounts[alert.AccountID].Currency,
+			CreatedAt:     txn.CreatedAt,
+		}
+		switch alert.Kind {
+		case models.AlertKindLowBalance:
+			if m.opening < alert.Threshold || m.closing >= alert.Threshold {
+				continue
+			}
+		case models.AlertKindLargeDebit:
+			if m.largestDebit < alert.Threshold {
+				continue
+			}
+			trigger.Amount = m.largestDebit
+		default:
+			continue
+		}
+		triggers = append(triggers, trigger)
+	}
+	if len(triggers) == 0 {
+		return nil
+	}
+	if err := tx.Create(&triggers).Error; err != nil {
+		return apperrors.NewDatabaseError("failed to record balance alerts", err)
+	}
+	txn.AlertTriggers = triggers
+	return nil
+}
+
+// loadAlertTriggers attaches the alerts txn triggered when it was posted, so
+// a replay republishes them.
+func loadAlertTriggers(db *gorm.DB, txn *models.Transaction) error {
+	if err := db.Where("transaction_id = ?", txn.ID).Order("created_at, id").Find(&txn.AlertTriggers).Error; err != nil {
+		return apperrors.NewDatabaseError("failed to read balance alert triggers", err)
+	}
+	return nil
+}
+
+// transactionParties finds the source and destination of a posted
+// transaction from its entries: the user account debited and the user
+// account credited, or the system account on that side of a deposit or
+// withdrawal, which has only one entry per side.
+func transactionParties(txn *models.Transaction, accounts map[string]*models.Account) (source, dest string) {
+	for _, e := range txn.Entries {
+		isUser := accounts[e.AccountID].AccountType == models.AccountTypeUser
+		switch {
+		case e.EntryType == models.EntryTypeDebit && (isUser || source == ""):
+			source = e.AccountID
+		case e.EntryType == models.EntryTypeCredit && (isUser || dest == ""):
+			dest = e.AccountID
+		}
+	}
+	return source, dest
+}
+
+// CreateHold reserves funds on an account. The account's available balance,
+// its balance less its active holds, must cover the hold.
+func (r *ledgerRepository) CreateHold(ctx context.Context, cmd HoldCommand) (*models.Hold, error) {
+	var result *models.Hold
+
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		accounts := make(map[string]*models.Account, 1)
+		if err := r.lockAccounts(tx, accounts, []string{cmd.AccountID}); err != nil {
+			return err
+		}
+		account := accounts[cmd.AccountID]
+
+		// Checked after the lock, as in ExecuteDoubleEntry
+		var existing models.Hold
+		if err := tx.Where("idempotency_key = ?", cmd.IdempotencyKey).First(&existing).Error; err == nil {
+			if existing.AccountID != cmd.AccountID || existing.Amount != cmd.Amount {
+				return ErrIdempotencyConflict
+			}
+			result = &existing
+			return nil // Idempotent return
+		}
+
+		if err := checkHomeRegion(account); err != nil {
+			return err
+		}
+		if cmd.Currency != "" && cmd.Currency != account.Currency {
+			return ErrCurrencyMismatch
+		}
+		var dest *string
+		if cmd.DestAccountID != "" {
+			if _, err := getAccount(tx, cmd.DestAccountID); err != nil {
+				return err
+			}
+			dest = &cmd.DestAccountID
+		}
+
+		held, err := heldAmount(tx, account.ID, "")
+		if err != nil {
+			return err
+		}
+		if account.Balance-held < cmd.Amount {
+			return ErrInsufficientFunds
+		}
+		// Unlocked, a posting could spend the funds between the check and
+		// the insert; claiming the account makes one of the two run again.
+		if r.optimistic() {
+			if err := claimAccount(tx, account); err != nil {
+				return err
+			}
+		}
+
+		hold := models.Hold{
+			AccountID:      account.ID,
+			DestAccountID:  dest,
+			Amount:         cmd.Amount,
+			Currency:       account.Currency,
+			Status:         models.HoldStatusActive,
+			IdempotencyKey: cmd.IdempotencyKey,
+			ExpiresAt:      cmd.ExpiresAt,
+		}
+		if err := tx.Create(&hold).Error; err != nil {
+			if r.optimistic() && apperrors.IsUniqueViolation(err) {
+				return errVersionConflict
+			}
+			return apperrors.NewDatabaseError("failed to create hold", err)
+		}
+
+		result = &hold
+		if cmd.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *ledgerRepository) CreateBalanceAlert(ctx context.Context, alert *models.BalanceAlert) (*models.BalanceAlert, error) {
+	if err := r.conn(ctx).Create(alert).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to create balance alert", err)
+	}
+	return alert, nil
+}
+
+func (r *ledgerRepository) GetBalanceAlert(ctx context.Context, id string) (*models.BalanceAlert, error) {
+	var alert models.BalanceAlert
+	if err := r.reader(ctx, true).First(&alert, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBalanceAlertNotFound
+		}
+		return nil, apperrors.NewDatabaseError("failed to fetch balance alert", err)
+	}
+	return &alert, nil
+}
+
+// ListBalanceAlerts returns the account's alerts, oldest first.
+func (r *ledgerRepository) ListBalanceAlerts(ctx context.Context, accountID string) ([]models.BalanceAlert, error) {
+	var alerts []models.BalanceAlert
+	if err := r.reader(ctx, true).Where("account_id = ?", accountID).Order("created_at, id").Find(&alerts).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to list balance alerts", err)
+	}
+	return alerts, nil
+}
+
+// DeleteBalanceAlert deletes one of the account's alerts. The alerts it
+// already triggered are kept.
+func (r *ledgerRepository) DeleteBalanceAlert(ctx context.Context, accountID, id string) error {
+	result := r.conn(ctx).Where("id = ? AND account_id = ?", id, accountID).Delete(&models.BalanceAlert{})
+	if result.Error != nil {
+		return apperrors.NewDatabaseError("failed to delete balance alert", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrBalanceAlertNotFound
+	}
+	return nil
+}
+
+// ClaimAlertNotification marks a trigger's email as sent and reports whether
+// this call marked it, so each trigger is emailed at most once however often
+// its event is delivered.
+func (r *ledgerRepository) ClaimAlertNotification(ctx context.Context, triggerID string) (bool, error) {
+	result := r.conn(ctx).Model(&models.BalanceAlertTrigger{}).
+		Where("id = ? AND notified_at IS NULL", triggerID).
+		Update("notified_at", time.Now().UTC())
+	if result.Error != nil {
+		return false, apperrors.NewDatabaseError("failed to claim balance alert notification", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *ledgerRepository) CreateAccrualPlan(ctx context.Context, plan *models.AccrualPlan) (*models.AccrualPlan, error) {
+	if err := r.conn(ctx).Create(plan).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to create accrual plan", err)
+	}
+	return plan, nil
+}
+
+func (r *ledgerRepository) GetAccrualPlan(ctx context.Context, accountID, id string) (*models.AccrualPlan, error) {
+	var plan models.AccrualPlan
+	if err := r.reader(ctx, true).First(&plan, "id = ? AND account_id = ?", id, accountID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccrualPlanNotFound
+		}
+		return nil, apperrors.NewDatabaseError("failed to fetch accrual plan", err)
+	}
+	return &plan, nil
+}
+
+// ListAccrualPlans returns the account's plans, oldest first.
+func (r *ledgerRepository) ListAccrualPlans(ctx context.Context, accountID string) ([]models.AccrualPlan, error) {
+	var plans []models.AccrualPlan
+	if err := r.reader(ctx, true).Where("account_id = ?", accountID).Order("created_at, id").Find(&plans).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to list accrual plans", err)
+	}
+	return plans, nil
+}
+
+// ListDueAccrualPlans returns the plans with a day on or before through that
+// is not accrued yet.
+func (r *ledgerRepository) ListDueAccrualPlans(ctx context.Context, through time.Time) ([]models.AccrualPlan, error) {
+	var plans []models.AccrualPlan
+	if err := r.conn(ctx).
+		Where("(accrued_through IS NULL AND starts_on <= ?) OR accrued_through < ?", through, through).
+		Order("id").
+		Find(&plans).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to list due accrual plans", err)
+	}
+	return plans, nil
+}
+
+// UpdateAccrualPlanRate sets the rate of the days the plan accrues from now
+// on. Days already accrued keep theirs until corrected.
+func (r *ledgerRepository) UpdateAccrualPlanRate(ctx context.Context, accountID, id string, rateBasisPoints int64) (*models.AccrualPlan, error) {
+	var result *models.AccrualPlan
+
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		plan, err := r.lockAccrualPlan(tx, id)
+		if err != nil {
+			return err
+		}
+		if plan.AccountID != accountID {
+			return ErrAccrualPlanNotFound
+		}
+		if err := tx.Model(plan).Update("rate_basis_points", rateBasisPoints).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to update accrual plan", err)
+		}
+		result = plan
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AccrueNextDay accrues the plan's first day not yet accrued, provided it is
+// through or earlier, and returns nil when there is no such day. The posting,
+// its record and the plan's AccruedThrough commit together.
+func (r *ledgerRepository) AccrueNextDay(ctx context.Context, planID string, through time.Time) (*AccrualPosting, error) {
+	var result *AccrualPosting
+
+	err := txmanager.New(r.db).WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+		plan, err := r.lockAccrualPlan(tx, planID)
+		if err != nil {
+			return err
+		}
+		day := plan.StartsOn.UTC()
+		if plan.AccruedThrough != nil {
+			day = plan.AccruedThrough.UTC().AddDate(0, 0, 1)
+		}
+		if day.After(through) {
+			return nil
+		}
+
+		posting, err := r.postAccrual(ctx, plan, day, 1)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(plan).Update("accrued_through", day).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to update accrual plan", err)
+		}
+		result = posting
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CorrectAccrual recalculates a day the plan already accrued, at the plan's
+// current rate. When the calculation changed, the day's record is superseded
+// by its next revision; when the amount changed too, the corrected amount is
+// posted before the original posting is reversed, so the account needs funds
+// for the difference only. It returns nil when the day is not accrued or its
+// calculation stands.
+func (r *ledgerRepository) CorrectAccrual(ctx context.Context, planID string, day time.Time) (*AccrualPosting, error) {
+	var result *AccrualPosting
+
+	err := txmanager.New(r.db).WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+		plan, err := r.lockAccrualPlan(tx, planID)
+		if err != nil {
+			return err
+		}
+		var current models.AccrualRecord
+		if err := tx.Where("plan_id = ? AND day = ? AND superseded_at IS NULL", plan.ID, day).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return apperrors.NewDatabaseError("failed to fetch accrual record", err)
+		}
+
+		record, err := r.calculateAccrual(ctx, tx, plan, day, current.Revision+1)
+		if err != nil {
+			return err
+		}
+		if record.Basis == current.Basis && record.RateBasisPoints == current.RateBasisPoints {
+			return nil
+		}
+
+		posting := &AccrualPosting{Record: record, Superseded: &current}
+		supersededAt := record.CreatedAt
+		if record.Amount == current.Amount {
+			// The posting stands for the new calculation.
+			record.TransactionID = current.TransactionID
+		} else {
+			if err := r.postAccrualRecord(ctx, plan, posting); err != nil {
+				return err
+			}
+			if current.TransactionID != nil {
+				reversal, err := r.ReverseTransaction(ctx, ReversalCommand{
+					TransactionID:     *current.TransactionID,
+					IdempotencyKey:    accrualIdempotencyKey(&current) + ":reversal",
+					Description:       "Correction of " + accrualDescription(&current),
+					AccrualCorrection: true,
+				})
+				if err != nil {
+					return err
+				}
+				posting.Reversal = reversal
+				current.ReversalTransactionID = &reversal.Transaction.ID
+				supersededAt = reversal.Transaction.CreatedAt
+			}
+		}
+
+		current.SupersededAt = &supersededAt
+		if err := tx.Model(&current).Updates(map[string]any{
+			"superseded_at":           current.SupersededAt,
+			"reversal_transaction_id": current.ReversalTransactionID,
+		}).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to supersede accrual record", err)
+		}
+		if err := createAccrualRecord(tx, record); err != nil {
+			return err
+		}
+		result = posting
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListAccrualRecords returns the account's accrual records for the days from
+// and to, inclusive, superseded ones included, by day, plan and revision.
+func (r *ledgerRepository) ListAccrualRecords(ctx context.Context, accountID string, from, to time.Time) ([]models.AccrualRecord, error) {
+	var records []models.AccrualRecord
+	if err := r.reader(ctx, true).
+		Where("account_id = ? AND day >= ? AND day <= ?", accountID, from, to).
+		Order("day, plan_id, revision").
+		Find(&records).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to list accrual records", err)
+	}
+	return records, nil
+}
+
+// lockAccrualPlan reads the plan for the rest of the transaction. In
+// pessimistic mode it is locked, so one run accrues or corrects it at a
+// time; in optimistic mode the unique revision of each day's record stops a
+// second run.
+func (r *ledgerRepository) lockAccrualPlan(tx *gorm.DB, id string) (*models.AccrualPlan, error) {
+	query := tx
+	if !r.optimistic() {
+		query = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var plan models.AccrualPlan
+	if err := query.First(&plan, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccrualPlanNotFound
+		}
+		return nil, apperrors.NewDatabaseError("failed to lock accrual plan", err)
+	}
+	return &plan, nil
+}
+
+// postAccrual calculates, posts and records revision of the plan's day. ctx
+// carries the transaction.
+func (r *ledgerRepository) postAccrual(ctx context.Context, plan *models.AccrualPlan, day time.Time, revision int) (*AccrualPosting, error) {
+	tx := r.conn(ctx)
+	record, err := r.calculateAccrual(ctx, tx, plan, day, revision)
+	if err != nil {
+		return nil, err
+	}
+	posting := &AccrualPosting{Record: record}
+	if err := r.postAccrualRecord(ctx, plan, posting); err != nil {
+		return nil, err
+	}
+	if err := createAccrualRecord(tx, record); err != nil {
+		return nil, err
+	}
+	return posting, nil
+}
+
+// postAccrualRecord posts the amount of the posting's record, if any, and
+// dates the record by the posting.
+func (r *ledgerRepository) postAccrualRecord(ctx context.Context, plan *models.AccrualPlan, posting *AccrualPosting) error {
+	record := posting.Record
+	if record.Amount == 0 {
+		return nil
+	}
+	cmd := accrualCommand(plan, record)
+	txn, err := r.ExecuteDoubleEntry(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	record.TransactionID = &txn.ID
+	record.CreatedAt = txn.CreatedAt
+	posting.Transaction = txn
+	posting.SourceAccountID, posting.DestAccountID = cmd.SourceAccountID, cmd.DestAccountID
+	return nil
+}
+
+func createAccrualRecord(tx *gorm.DB, record *models.AccrualRecord) error {
+	if err := tx.Create(record).Error; err != nil {
+		// Another run recorded the day first.
+		if apperrors.IsUniqueViolation(err) {
+			return ErrConcurrentUpdate
+		}
+		return apperrors.NewDatabaseError("failed to record accrual", err)
+	}
+	return nil
+}
+
+// calculateAccrual returns revision of the record of the plan's day,
+// unposted and dated now.
+func (r *ledgerRepository) calculateAccrual(ctx context.Context, tx *gorm.DB, plan *models.AccrualPlan, day time.Time, revision int) (*models.AccrualRecord, error) {
+	var account models.Account
+	if err := tx.Select("id", "currency").First(&account, "id = ?", plan.AccountID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, apperrors.NewDatabaseError("failed to fetch account", err)
+	}
+
+	end := day.AddDate(0, 0, 1)
+	var closing, adjustment int64
+	if err := rawsql.Get(ctx, tx, &closing, balanceBeforeQuery, rawsql.Params{"id": plan.AccountID, "at": end}); err != nil {
+		return nil, apperrors.NewDatabaseError("failed to calculate closing balance", err)
+	}
+	if err := rawsql.Get(ctx, tx, &adjustment, accrualAdjustmentQuery, rawsql.Params{
+		"account_id": plan.AccountID, "plan_id": plan.ID, "day": day, "end": end,
+	}); err != nil {
+		return nil, apperrors.NewDatabaseError("failed to calculate accrual basis", err)
+	}
+
+	basis := closing + adjustment
+	return &models.AccrualRecord{
+		PlanID:          plan.ID,
+		AccountID:       plan.AccountID,
+		Day:             day,
+		Revision:        revision,
+		Kind:            plan.Kind,
+		ClosingBalance:  closing,
+		Basis:           basis,
+		RateBasisPoints: plan.RateBasisPoints,
+		DaysInYear:      daysInYear,
+		Amount:          accrualAmount(basis, plan.RateBasisPoints),
+		Currency:        account.Currency,
+		CreatedAt:       time.Now().UTC(),
+	}, nil
+}
+
+func (r *ledgerRepository) GetHold(ctx context.Context, id string) (*models.Hold, error) {
+	var hold models.Hold
+	if err := r.reader(ctx, true).First(&hold, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHoldNotFound
+		}
+		return nil, apperrors.NewDatabaseError("failed to fetch hold", err)
+	}
+	return &hold, nil
+}
+
+// ReleaseHold frees an active hold's funds. Releasing a hold that is already
+// released or expired returns it unchanged; a captured hold cannot be
+// released.
+func (r *ledgerRepository) ReleaseHold(ctx context.Context, id string, dryRun bool) (*models.Hold, error) {
+	var result *models.Hold
+
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		hold, err := lockHold(tx, id)
+		if err != nil {
+			return err
+		}
+		result = hold
+
+		switch hold.Status {
+		case models.HoldStatusReleased, models.HoldStatusExpired:
+			return nil
+		case models.HoldStatusCaptured:
+			return ErrHoldNotActive
+		}
+
+		account, err := getAccount(tx, hold.AccountID)
+		if err != nil {
+			return err
+		}
+		if err := checkHomeRegion(account); err != nil {
+			return err
+		}
+		if err := tx.Model(hold).Update("status", models.HoldStatusReleased).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to release hold", err)
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ExpireHolds marks active holds that expired by now as EXPIRED. Expired
+// holds stop counting against the available balance at ExpiresAt whether or
+// not they have been swept; sweeping records it.
+func (r *ledgerRepository) ExpireHolds(ctx context.Context, now time.Time) (int64, error) {
+	result := r.conn(ctx).Model(&models.Hold{}).
+		Where("status = ? AND expires_at <= ?", models.HoldStatusActive, now).
+		Updates(map[string]any{"status": models.HoldStatusExpired, "updated_at": now})
+	if result.Error != nil {
+		return 0, apperrors.NewDatabaseError("failed to expire holds", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+const createEntryPartitionQuery = `SELECT create_ledger_entries_partition(@day)`
+
+// EnsureEntryPartitions creates the monthly ledger_entries partitions for the
+// UTC months from through through and reports how many were missing. SQLite,
+// used in tests, has no partitions.
+func (r *ledgerRepository) EnsureEntryPartitions(ctx context.Context, from, through time.Time) (int, error) {
+	if r.db.Dialector.Name() != "postgres" {
+		return 0, nil
+	}
+	from, through = from.UTC(), through.UTC()
+	created := 0
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(through); month = month.AddDate(0, 1, 0) {
+		var missing bool
+		if err := rawsql.Get(ctx, r.conn(ctx), &missing, createEntryPartitionQuery, rawsql.Params{"day": month.Format(time.DateOnly)}); err != nil {
+			return created, apperrors.NewDatabaseError("failed to create ledger entry partition", err)
+		}
+		if missing {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// ArchiveTransactions moves up to limit transactions created before before,
+// oldest first, out of transactions and ledger_entries into the archive
+// tables, and adds their entries to archived_daily_totals. A reversal is
+// moved together with the transaction it reverses, so neither is left live to
+// be reversed or replayed without the other. It reports how many it moved.
+func (r *ledgerRepository) ArchiveTransactions(ctx context.Context, before time.Time, limit int) (int, error) {
+	moved := 0
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		locked := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+		// A reversed transaction waits for its reversal, which is newer.
+		var transactions []models.Transaction
+		if err := locked.
+			Where("created_at < ?", before).
+			Where("NOT EXISTS (SELECT 1 FROM transactions r WHERE r.reversed_transaction_id = transactions.id)").
+			Order("created_at").
+			Limit(limit).
+			Find(&transactions).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to select transactions to archive", err)
+		}
+		var reversed []string
+		for _, txn := range transactions {
+			if txn.ReversedTransactionID != nil {
+				reversed = append(reversed, *txn.ReversedTransactionID)
+			}
+		}
+		if len(reversed) > 0 {
+			var originals []models.Transaction
+			if err := locked.Where("id IN ?", reversed).Find(&originals).Error; err != nil {
+				return apperrors.NewDatabaseError("failed to select transactions to archive", err)
+			}
+			transactions = append(transactions, originals...)
+		}
+		if len(transactions) == 0 {
+			return nil
+		}
+		if err := loadEntries(tx, transactions); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		ids := make([]string, len(transactions))
+		archived := make([]models.ArchivedTransaction, len(transactions))
+		var entries []models.ArchivedLedgerEntry
+		totals := make(map[string]*models.ArchivedDailyTotal)
+		from, to := transactions[0].CreatedAt, transactions[0].CreatedAt
+		for i, txn := range transactions {
+			ids[i] = txn.ID
+			if txn.CreatedAt.Before(from) {
+				from = txn.CreatedAt
+			}
+			if txn.CreatedAt.After(to) {
+				to = txn.CreatedAt
+			}
+			archived[i] = models.ArchivedTransaction{
+				ID:                    txn.ID,
+				IdempotencyKey:        txn.IdempotencyKey,
+				TransactionType:       txn.TransactionType,
+				Amount:                txn.Amount,
+				Currency:              txn.Currency,
+				DestAmount:            txn.DestAmount,
+				DestCurrency:          txn.DestCurrency,
+				ExchangeRate:          txn.ExchangeRate,
+				Fee:                   txn.Fee,
+				Description:           txn.Description,
+				ReversedTransactionID: txn.ReversedTransactionID,
+				CreatedAt:             txn.CreatedAt,
+				ArchivedAt:            now,
+			}
+			for _, e := range txn.Entries {
+				entries = append(entries, models.ArchivedLedgerEntry{
+					ID:            e.ID,
+					TransactionID: e.TransactionID,
+					AccountID:     e.AccountID,
+					EntryType:     e.EntryType,
+					Amount:        e.Amount,
+					BalanceAfter:  e.BalanceAfter,
+					CreatedAt:     e.CreatedAt,
+					PrevHash:      e.PrevHash,
+					EntryHash:     e.EntryHash,
+				})
+				day := e.CreatedAt.UTC().Truncate(24 * time.Hour)
+				key := e.AccountID + "/" + day.Format(time.DateOnly)
+				total := totals[key]
+				if total == nil {
+					total = &models.ArchivedDailyTotal{AccountID: e.AccountID, Day: day}
+					totals[key] = total
+				}
+				if e.EntryType == models.EntryTypeCredit {
+					total.Credits += e.Amount
+				} else {
+					total.Debits += e.Amount
+				}
+				total.EntryCount++
+			}
+		}
+
+		if err := tx.CreateInBatches(archived, archiveInsertBatch).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to archive transactions", err)
+		}
+		if len(entries) > 0 {
+			if err := tx.CreateInBatches(entries, archiveInsertBatch).Error; err != nil {
+				return apperrors.NewDatabaseError("failed to archive ledger entries", err)
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(totals)) {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "account_id"}, {Name: "day"}},
+				DoUpdates: clause.Assignments(map[string]any{
+					"credits":     gorm.Expr("archived_daily_totals.credits + excluded.credits"),
+					"debits":      gorm.Expr("archived_daily_totals.debits + excluded.debits"),
+					"entry_count": gorm.Expr("archived_daily_totals.entry_count + excluded.entry_count"),
+				}),
+			}).Create(totals[key]).Error; err != nil {
+				return apperrors.NewDatabaseError("failed to update archived daily totals", err)
+			}
+		}
+
+		// The immutability trigger lets this transaction delete entries.
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT set_config('ledger.archiving', 'on', true)").Error; err != nil {
+				return apperrors.NewDatabaseError("failed to allow archiving", err)
+			}
+		}
+		if err := tx.Where("transaction_id IN ?", ids).Delete(&models.TransactionSearchToken{}).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to delete archived search tokens", err)
+		}
+		if err := tx.Where("transaction_id IN ? AND created_at BETWEEN ? AND ?", ids, from, to).Delete(&models.LedgerEntry{}).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to delete archived ledger entries", err)
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&models.Transaction{}).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to delete archived transactions", err)
+		}
+		moved = len(transactions)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// archiveInsertBatch keeps each archive insert well under the databases'
+// limits on bound parameters.
+const archiveInsertBatch = 500
+
+// GetTransaction returns a live transaction with its entries and plaintext
+// description.
+func (r *ledgerRepository) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	db := r.reader(ctx, true)
+	var transactions []models.Transaction
+	if err := db.Where("id = ?", id).Limit(1).Find(&transactions).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to fetch transaction", err)
+	}
+	if len(transactions) == 0 {
+		return nil, ErrTransactionNotFound
+	}
+	if err := loadEntries(db, transactions); err != nil {
+		return nil, err
+	}
+	if err := r.decryptDescriptions(transactions); err != nil {
+		return nil, err
+	}
+	return &transactions[0], nil
+}
+
+// GetArchivedTransaction returns an archived transaction with its entries.
+func (r *ledgerRepository) GetArchivedTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	db := r.reader(ctx, false)
+	var archived []models.ArchivedTransaction
+	if err := db.Where("id = ?", id).Limit(1).Find(&archived).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to fetch archived transaction", err)
+	}
+	if len(archived) == 0 {
+		return nil, ErrTransactionNotFound
+	}
+	transactions, err := r.restoreArchived(db, archived)
+	if err != nil {
+		return nil, err
+	}
+	return &transactions[0], nil
+}
+
+// replayByIdempotencyKey returns the transaction, live or archived, posted
+// with key, or nil when there is none. A transaction of another type or
+// amount is ErrIdempotencyConflict. Callers hold the locks of the accounts
+// the key's transaction would post to, so any earlier request with the key
+// has committed.
+func (r *ledgerRepository) replayByIdempotencyKey(tx *gorm.DB, key, transactionType string, amount int64) (*models.Transaction, error) {
+	var existing []models.Transaction
+	if err := tx.Where("idempotency_key = ?", key).Limit(1).Find(&existing).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to check idempotency key", err)
+	}
+	if len(existing) == 0 {
+		// Keys stay used after their transaction is archived.
+		archived, err := r.archivedByIdempotencyKey(tx, key)
+		if err != nil || archived == nil {
+			return nil, err
+		}
+		existing = append(existing, *archived)
+	} else {
+		if err := loadEntries(tx, existing); err != nil {
+			return nil, err
+		}
+		if err := r.decryptDescriptions(existing); err != nil {
+			return nil, err
+		}
+	}
+	if existing[0].Amount != amount || existing[0].TransactionType != transactionType {
+		return nil, ErrIdempotencyConflict
+	}
+	if err := loadAlertTriggers(tx, &existing[0]); err != nil {
+		return nil, err
+	}
+	return &existing[0], nil
+}
+
+// indexDescription stores the blind index tokens of a transaction's
+// description, when descriptions are encrypted.
+func (r *ledgerRepository) indexDescription(tx *gorm.DB, transactionID, description string) error {
+	tokens := r.cipher.SearchTokens(description, descriptionAAD)
+	if len(tokens) == 0 {
+		return nil
+	}
+	rows := make([]models.TransactionSearchToken, len(tokens))
+	for i, token := range tokens {
+		rows[i] = models.TransactionSearchToken{TransactionID: transactionID, Token: token}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return apperrors.NewDatabaseError("failed to index transaction description", err)
+	}
+	return nil
+}
+
+// archivedByIdempotencyKey returns the archived transaction with the key, or
+// nil when there is none.
+func (r *ledgerRepository) archivedByIdempotencyKey(db *gorm.DB, key string) (*models.Transaction, error) {
+	var archived []models.ArchivedTransaction
+	if err := db.Where("idempotency_key = ?", key).Limit(1).Find(&archived).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to check archived idempotency key", err)
+	}
+	if len(archived) == 0 {
+		return nil, nil
+	}
+	transactions, err := r.restoreArchived(db, archived)
+	if err != nil {
+		return nil, err
+	}
+	return &transactions[0], nil
+}
+
+// GetArchivedTransactionsByAccountID returns one page of the archived
+// transactions touching the account, newest first.
+func (r *ledgerRepository) GetArchivedTransactionsByAccountID(ctx context.Context, accountID string, limit, offset int) ([]models.Transaction, error) {
+	db := r.reader(ctx, false)
+	query := db.
+		Where("id IN (?)", db.Model(&models.ArchivedLedgerEntry{}).Select("transaction_id").Where("account_id = ?", accountID)).
+		Order("created_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	var archived []models.ArchivedTransaction
+	if err := query.Find(&archived).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to fetch archived transactions", err)
+	}
+	return r.restoreArchived(db, archived)
+}
+
+func (r *ledgerRepository) CountArchivedTransactionsByAccountID(ctx context.Context, accountID string) (int64, error) {
+	var count int64
+	err := r.reader(ctx, false).
+		Model(&models.ArchivedLedgerEntry{}).
+		Where("account_id = ?", accountID).
+		Distinct("transaction_id").
+		Count(&count).Error
+	if err != nil {
+		return 0, apperrors.NewDatabaseError("failed to count archived transactions", err)
+	}
+	return count, nil
+}
+
+// restoreArchived returns archived transactions in their live form, with
+// their entries and decrypted descriptions.
+func (r *ledgerRepository) restoreArchived(db *gorm.DB, archived []models.ArchivedTransaction) ([]models.Transaction, error) {
+	transactions := make([]models.Transaction, len(archived))
+	if len(archived) == 0 {
+		return transactions, nil
+	}
+	ids := make([]string, len(archived))
+	for i, a := range archived {
+		ids[i] = a.ID
+		transactions[i] = models.Transaction{
+			ID:                    a.ID,
+			IdempotencyKey:        a.IdempotencyKey,
+			TransactionType:       a.TransactionType,
+			Amount:                a.Amount,
+			Currency:              a.Currency,
+			DestAmount:            a.DestAmount,
+			DestCurrency:          a.DestCurrency,
+			ExchangeRate:          a.ExchangeRate,
+			Fee:                   a.Fee,
+			Description:           a.Description,
+			ReversedTransactionID: a.ReversedTransactionID,
+			CreatedAt:             a.CreatedAt,
+		}
+	}
+
+	var entries []models.ArchivedLedgerEntry
+	if err := db.Where("transaction_id IN ?", ids).Find(&entries).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to fetch archived ledger entries", err)
+	}
+	byTransaction := make(map[string][]models.LedgerEntry, len(archived))
+	for _, e := range entries {
+		byTransaction[e.TransactionID] = append(byTransaction[e.TransactionID], models.LedgerEntry{
+			ID:            e.ID,
+			TransactionID: e.TransactionID,
+			AccountID:     e.AccountID,
+			EntryType:     e.EntryType,
+			Amount:        e.Amount,
+			BalanceAfter:  e.BalanceAfter,
+			CreatedAt:     e.CreatedAt,
+			PrevHash:      e.PrevHash,
+			EntryHash:     e.EntryHash,
+		})
+	}
+	for i := range transactions {
+		transactions[i].Entries = byTransaction[transactions[i].ID]
+	}
+
+	if err := r.decryptDescriptions(transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func lockHold(tx *gorm.DB, id string) (*models.Hold, error) {
+	var hold models.Hold
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&hold).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHoldNotFound
+		}
+		return nil, apperrors.NewDatabaseError("failed to lock hold", err)
+	}
+	return &hold, nil
+}
+
+// checkCapture reports whether cmd may capture hold. It runs after the
+// idempotency replay, so retrying a capture returns its result.
+func checkCapture(hold *models.Hold, cmd DoubleEntryCommand) error {
+	dest := models.SystemAccountID
+	if hold.DestAccountID != nil {
+		dest = *hold.DestAccountID
+	}
+	switch {
+	case hold.Status != models.HoldStatusActive:
+		return ErrHoldNotActive
+	case !time.Now().Before(hold.ExpiresAt):
+		return ErrHoldExpired
+	case hold.AccountID != cmd.SourceAccountID || dest != cmd.DestAccountID:
+		return ErrHoldMismatch
+	case cmd.Amount > hold.Amount:
+		return ErrCaptureExceedsHold
+	}
+	return nil
+}
+
+// heldAmount totals the account's active, unexpired holds other than
+// exceptID.
+func heldAmount(tx *gorm.DB, accountID, exceptID string) (int64, error) {
+	query := tx.Model(&models.Hold{}).
+		Where("account_id = ? AND status = ? AND expires_at > ?", accountID, models.HoldStatusActive, time.Now().UTC())
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+	var held int64
+	if err := query.Select("COALESCE(SUM(amount), 0)").Scan(&held).Error; err != nil {
+		return 0, apperrors.NewDatabaseError("failed to total held funds", err)
+	}
+	return held, nil
+}
+
+// ensureSystemAccounts returns the sorted IDs of the system accounts holding
+// currencies, creating any but the seeded one the first time it is needed.
+func ensureSystemAccounts(tx *gorm.DB, currencies []string) ([]string, error) {
+	ids := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		id := models.SystemAccountIDFor(currency)
+		ids = append(ids, id)
+		if id == models.SystemAccountID {
+			continue
+		}
+		account := models.Account{
+			ID:          id,
+			Name:        "External Funding Source (" + currency + ")",
+			AccountType: models.AccountTypeSystem,
+			Currency:    currency,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+			return nil, apperrors.NewDatabaseError("failed to create system account", err)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// exchangeRate reads the rate from base to quote within tx, refusing rates
+// older than maxAge unless maxAge is zero.
+func exchangeRate(ctx context.Context, tx *gorm.DB, base, quote string, maxAge time.Duration) (fx.Rate, error) {
+	rate, err := fx.NewGormStore(tx).Rate(ctx, base, quote)
+	if errors.Is(err, fx.ErrRateNotFound) {
+		return fx.Rate{}, ErrExchangeRateNotFound
+	}
+	if err != nil {
+		return fx.Rate{}, apperrors.NewDatabaseError("failed to read exchange rate", err)
+	}
+	if maxAge > 0 && time.Since(rate.AsOf) > maxAge {
+		return fx.Rate{}, ErrExchangeRateNotFound
+	}
+	return rate, nil
+}
+
+// GetExchangeRate returns the current rate from base to quote, refusing
+// rates older than maxAge unless maxAge is zero.
+func (r *ledgerRepository) GetExchangeRate(ctx context.Context, base, quote string, maxAge time.Duration) (*fx.Rate, error) {
+	rate, err := exchangeRate(ctx, r.conn(ctx), base, quote, maxAge)
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// CreateTransferQuote stores a quote. Expired quotes that were never used are
+// removed first, which keeps the table small without a background job.
+func (r *ledgerRepository) CreateTransferQuote(ctx context.Context, quote *models.TransferQuote) (*models.TransferQuote, error) {
+	db := r.conn(ctx)
+	if err := db.Where("transaction_id IS NULL AND expires_at < ?", time.Now().UTC()).
+		Delete(&models.TransferQuote{}).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to purge expired quotes", err)
+	}
+	if err := db.Create(quote).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to create quote", err)
+	}
+	return quote, nil
+}
+
+// GetTransactionsByAccountID returns one page of the transactions touching
+// the account, newest first, with their entries only when includeEntries.
+func (r *ledgerRepository) GetTransactionsByAccountID(ctx context.Context, accountID string, limit, offset int, includeEntries bool) ([]models.Transaction, error) {
+	// Subquery: find transaction IDs that involve this account
+	db := r.reader(ctx, true)
+	subQuery := db.
+		Model(&models.LedgerEntry{}).
+		Select("DISTINCT transaction_id").
+		Where("account_id = ?", accountID)
+
+	query := db.
+		Where("id IN (?)", subQuery).
+		Order("created_at DESC")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	var transactions []models.Transaction
+	if err := query.Find(&transactions).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to fetch transactions", err)
+	}
+	if includeEntries {
+		if err := loadEntries(db, transactions); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.decryptDescriptions(transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// loadEntries fills in the entries of transactions. Entries are stamped with
+// their transaction's created_at, so bounding the query by the transactions'
+// times lets PostgreSQL read only the ledger_entries partitions for those
+// months.
+func loadEntries(db *gorm.DB, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	ids, from, to := entryBounds(transactions)
+
+	var entries []models.LedgerEntry
+	if err := db.Where("transaction_id IN ? AND created_at BETWEEN ? AND ?", ids, from, to).Find(&entries).Error; err != nil {
+		return apperrors.NewDatabaseError("failed to fetch ledger entries", err)
+	}
+	byTransaction := make(map[string][]models.LedgerEntry, len(transactions))
+	for _, e := range entries {
+		byTransaction[e.TransactionID] = append(byTransaction[e.TransactionID], e)
+	}
+	for i := range transactions {
+		transactions[i].Entries = byTransaction[transactions[i].ID]
+	}
+	return nil
+}
+
+// SummarizeEntries counts the entries of each transaction and nets those of
+// accountID, without reading the entries themselves.
+func (r *ledgerRepository) SummarizeEntries(ctx context.Context, accountID string, transactions []models.Transaction) (map[string]TransactionSummary, error) {
+	summaries := make(map[string]TransactionSummary, len(transactions))
+	if len(transactions) == 0 {
+		return summaries, nil
+	}
+	ids, from, to := entryBounds(transactions)
+
+	var rows []struct {
+		TransactionID string
+		EntryCount    int
+		NetEffect     int64
+	}
+	err := r.reader(ctx, true).
+		Model(&models.LedgerEntry{}).
+		Select("transaction_id, COUNT(*) AS entry_count, "+
+			"COALESCE(SUM(CASE WHEN account_id <> ? THEN 0 WHEN entry_type = ? THEN amount ELSE -amount END), 0) AS net_effect",
+			accountID, models.EntryTypeCredit).
+		Where("transaction_id IN ? AND created_at BETWEEN ? AND ?", ids, from, to).
+		Group("transaction_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("failed to summarize ledger entries", err)
+	}
+	for _, row := range rows {
+		summaries[row.TransactionID] = TransactionSummary{EntryCount: row.EntryCount, NetEffect: row.NetEffect}
+	}
+	return summaries, nil
+}
+
+// entryBounds returns the IDs of transactions and the range of their
+// created_at, which bounds the ledger_entries partitions holding their
+// entries.
+func entryBounds(transactions []models.Transaction) (ids []string, from, to time.Time) {
+	ids = make([]string, len(transactions))
+	from, to = transactions[0].CreatedAt, transactions[0].CreatedAt
+	for i, txn := range transactions {
+		ids[i] = txn.ID
+		if txn.CreatedAt.Before(from) {
+			from = txn.CreatedAt
+		}
+		if txn.CreatedAt.After(to) {
+			to = txn.CreatedAt
+		}
+	}
+	return ids, from, to
+}
+
+func (r *ledgerRepository) CountTransactionsByAccountID(ctx context.Context, accountID string) (int64, error) {
+	var total int64
+	err := r.reader(ctx, true).
+		Model(&models.LedgerEntry{}).
+		Where("account_id = ?", accountID).
+		Distinct("transaction_id").
+		Count(&total).Error
+	if err != nil {
+		return 0, apperrors.NewDatabaseError("failed to count transactions", err)
+	}
+	return total, nil
+}
+
+func (r *ledgerRepository) SearchTransactions(ctx context.Context, q TransactionSearchQuery) ([]models.Transaction, error) {
+	words := fieldcrypt.Tokenize(q.Text)
+	if len(words) == 0 {
+		return []models.Transaction{}, nil
+	}
+
+	db := r.reader(ctx, false)
+	query := db.Order("created_at DESC")
+
+	if r.cipher != nil {
+		// Every searched word must have a matching blind index.
+		tokens := r.cipher.SearchTokens(q.Text, descriptionAAD)
+		matches := db.
+			Model(&models.TransactionSearchToken{}).
+			Select("transaction_id").
+			Where("token IN ?", tokens).
+			Group("transaction_id").
+			Having("COUNT(DISTINCT token) = ?", len(tokens))
+		query = query.Where("id IN (?)", matches)
+	} else {
+		for _, w := range words {
+			query = query.Where("LOWER(description) LIKE ?", "%"+w+"%")
+		}
+	}
+
+	if q.AccountID != "" {
+		involved := db.
+			Model(&models.LedgerEntry{}).
+			Select("DISTINCT transaction_id").
+			Where("account_id = ?", q.AccountID)
+		query = query.Where("id IN (?)", involved)
+	}
+
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	if q.Offset > 0 {
+		query = query.Offset(q.Offset)
+	}
+
+	var transactions []models.Transaction
+	if err := query.Find(&transactions).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to search transactions", err)
+	}
+	if err := loadEntries(db, transactions); err != nil {
+		return nil, err
+	}
+
+	if err := r.decryptDescriptions(transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (r *ledgerRepository) GetBalanceSnapshot(ctx context.Context, accountID string) (*BalanceSnapshot, error) {
+	var snapshot BalanceSnapshot
+
+	err := r.reader(ctx, true).Transaction(func(tx *gorm.DB) error {
+		var account models.Account
+		if err := tx.First(&account, "id = ?", accountID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAccountNotFound
+			}
+			return apperrors.NewDatabaseError("failed to fetch account", err)
+		}
+
+		var derived int64
+		if err := tx.Model(&models.LedgerEntry{}).
+			Where("account_id = ?", accountID).
+			Select("COALESCE(SUM(CASE WHEN entry_type = ? THEN amount ELSE -amount END), 0)", models.EntryTypeCredit).
+			Scan(&derived).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to calculate derived balance", err)
+		}
+		var archived int64
+		if err := tx.Model(&models.ArchivedDailyTotal{}).
+			Where("account_id = ?", accountID).
+			Select("COALESCE(SUM(credits - debits), 0)").
+			Scan(&archived).Error; err != nil {
+			return apperrors.NewDatabaseError("failed to calculate archived balance", err)
+		}
+		derived += archived
+
+		held, err := heldAmount(tx, accountID, "")
+		if err != nil {
+			return err
+		}
+
+		snapshot = BalanceSnapshot{
+			AccountID:      account.ID,
+			CachedBalance:  account.Balance,
+			DerivedBalance: derived,
+			HeldBalance:    held,
+			Currency:       account.Currency,
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// GetBalanceVersion reads the account's BalanceVersion without summing its
+// entries.
+func (r *ledgerRepository) GetBalanceVersion(ctx context.Context, accountID string) (*BalanceVersion, error) {
+	var version BalanceVersion
+
+	err := r.reader(ctx, true).Transaction(func(tx *gorm.DB) error {
+		var account models.Account
+		if err := tx.Select("id", "version").First(&account, "id = ?", accountID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAccountNotFound
+			}
+			return apperrors.NewDatabaseError("failed to fetch account", err)
+		}
+		held, err := heldAmount(tx, accountID, "")
+		if err != nil {
+			return err
+		}
+		version = BalanceVersion{Version: account.Version, HeldBalance: held}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+const dailyBalancesQuery = `
+	SELECT CAST(day AS TEXT) AS day, credits, debits, entry_count, closing_balance
+	FROM account_daily_balances
+	WHERE account_id = @account_id AND day >= @from AND day <= @to
+	ORDER BY day`
+
+// GetDailyBalances returns the account's rows of the account_daily_balances
+// view for the days from and to (2006-01-02), inclusive. Days without entries
+// have no row. The view is only as current as its last refresh.
+func (r *ledgerRepository) GetDailyBalances(ctx context.Context, accountID, from, to string) ([]DailyBalance, error) {
+	var days []DailyBalance
+	err := rawsql.Select(ctx, r.reader(ctx, false), &days, dailyBalancesQuery, rawsql.Params{"account_id": accountID, "from": from, "to": to})
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("failed to fetch daily balances", err)
+	}
+	return days, nil
+}
+
+const balanceBeforeQuery = `
+	SELECT a.balance - COALESCE((
+		SELECT SUM(CASE WHEN le.entry_type = 'CREDIT' THEN le.amount ELSE -le.amount END)
+		FROM ledger_entries le
+		WHERE le.account_id = a.id AND le.created_at >= @at
+	), 0)
+	FROM accounts a WHERE a.id = @id`
+
+// accrualAdjustmentQuery turns an account's closing balance for @day into
+// the basis of a plan's accrual for it: accruals the balance includes, which
+// are those posted before @end and not reversed by then, are taken out, and
+// the plan's own current accruals for earlier days put in. The basis is then
+// the same whenever the day is calculated.
+const accrualAdjustmentQuery = `
+	SELECT COALESCE(SUM(CASE WHEN kind = 'INTEREST' THEN amount ELSE -amount END * (
+		CASE WHEN plan_id = @plan_id AND day < @day AND superseded_at IS NULL THEN 1 ELSE 0 END
+		- CASE WHEN created_at < @end THEN 1 ELSE 0 END
+		+ CASE WHEN superseded_at < @end THEN 1 ELSE 0 END
+	)), 0)
+	FROM accrual_records WHERE account_id = @account_id`
+
+// GetBalanceBefore returns the account's balance just before at. It works
+// back from the current balance, so it reads only the entries since at, and
+// only their partitions.
+func (r *ledgerRepository) GetBalanceBefore(ctx context.Context, accountID string, at time.Time) (int64, error) {
+	var balance int64
+	err := rawsql.Get(ctx, r.reader(ctx, true), &balance, balanceBeforeQuery, rawsql.Params{"id": accountID, "at": at})
+	if errors.Is(err, rawsql.ErrNoRows) {
+		return 0, ErrAccountNotFound
+	}
+	if err != nil {
+		return 0, apperrors.NewDatabaseError("failed to calculate opening balance", err)
+	}
+	return balance, nil
+}
+
+// StreamStatementEntries calls fn with each of the account's entries created
+// in [from, until), oldest first, reading rows as fn consumes them. An error
+// from fn stops the stream and is returned as is.
+func (r *ledgerRepository) StreamStatementEntries(ctx context.Context, accountID string, from, until time.Time, fn func(StatementEntry) error) error {
+	db := r.reader(ctx, true)
+	rows, err := db.
+		Table("ledger_entries le").
+		Select("le.transaction_id, t.transaction_type, t.description, le.entry_type, le.amount, le.created_at").
+		Joins("JOIN transactions t ON t.id = le.transaction_id").
+		Where("le.account_id = ? AND le.created_at >= ? AND le.created_at < ?", accountID, from, until).
+		Order("le.created_at, le.transaction_id, le.entry_type").
+		Rows()
+	if err != nil {
+		return apperrors.NewDatabaseError("failed to fetch statement entries", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry StatementEntry
+		if err := db.ScanRows(rows, &entry); err != nil {
+			return apperrors.NewDatabaseError("failed to read statement entry", err)
+		}
+		if entry.Description, err = r.cipher.Decrypt(entry.Description, descriptionAAD); err != nil {
+			return apperrors.NewDatabaseError("failed to decrypt transaction description", err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return apperrors.NewDatabaseError("failed to fetch statement entries", err)
+	}
+	return nil
+}
+
+// dayEntriesQuery reads the entries created in [from, until), live and
+// archived, in the order days are sealed in.
+const dayEntriesQuery = `
+	SELECT id, transaction_id, account_id, entry_type, amount, balance_after, created_at
+	FROM ledger_entries WHERE created_at >= ? AND created_at < ?
+	UNION ALL
+	SELECT id, transaction_id, account_id, entry_type, amount, balance_after, created_at
+	FROM archived_ledger_entries WHERE created_at >= ? AND created_at < ?
+	ORDER BY created_at, id`
+
+// GetFirstEntryTime returns when the oldest entry, live or archived, was
+// created, and nil when there are none.
+func (r *ledgerRepository) GetFirstEntryTime(ctx context.Context) (*time.Time, error) {
+	db := r.reader(ctx, true)
+	var first *time.Time
+	for _, model := range []any{&models.LedgerEntry{}, &models.ArchivedLedgerEntry{}} {
+		var oldest []time.Time
+		if err := db.Model(model).Order("created_at").Limit(1).Pluck("created_at", &oldest).Error; err != nil {
+			return nil, apperrors.NewDatabaseError("failed to fetch first entry time", err)
+		}
+		if len(oldest) == 1 && (first == nil || oldest[0].Before(*first)) {
+			first = &oldest[0]
+		}
+	}
+	return first, nil
+}
+
+// GetLatestDailySeal returns the seal of the last day sealed, and nil when
+// no day has been.
+func (r *ledgerRepository) GetLatestDailySeal(ctx context.Context) (*models.DailySeal, error) {
+	var seals []models.DailySeal
+	if err := r.reader(ctx, true).Order("day DESC").Limit(1).Find(&seals).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to fetch latest daily seal", err)
+	}
+	if len(seals) == 0 {
+		return nil, nil
+	}
+	return &seals[0], nil
+}
+
+func (r *ledgerRepository) GetDailySeal(ctx context.Context, day time.Time) (*models.DailySeal, error) {
+	var seal models.DailySeal
+	if err := r.reader(ctx, true).First(&seal, "day = ?", day).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDayNotSealed
+		}
+		return nil, apperrors.NewDatabaseError("failed to fetch daily seal", err)
+	}
+	return &seal, nil
+}
+
+// CreateDailySeal stores a day's seal. It returns ErrConcurrentUpdate when
+// the day was sealed first by another instance.
+func (r *ledgerRepository) CreateDailySeal(ctx context.Context, seal *models.DailySeal) error {
+	if err := r.conn(ctx).Create(seal).Error; err != nil {
+		if apperrors.IsUniqueViolation(err) {
+			return ErrConcurrentUpdate
+		}
+		return apperrors.NewDatabaseError("failed to seal day", err)
+	}
+	return nil
+}
+
+// StreamDayEntries calls fn with each entry created on the UTC day, live or
+// archived, by creation time and then ID. The archiver moves entries in one
+// database transaction, so each is read exactly once. An error from fn stops
+// the stream and is returned as is.
+func (r *ledgerRepository) StreamDayEntries(ctx context.Context, day time.Time, fn func(*models.LedgerEntry) error) error {
+	from, until := day, day.AddDate(0, 0, 1)
+	db := r.reader(ctx, true)
+	rows, err := db.Raw(dayEntriesQuery, from, until, from, until).Rows()
+	if err != nil {
+		return apperrors.NewDatabaseError("failed to fetch day entries", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.LedgerEntry
+		if err := db.ScanRows(rows, &entry); err != nil {
+			return apperrors.NewDatabaseError("failed to read day entry", err)
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return apperrors.NewDatabaseError("failed to fetch day entries", err)
+	}
+	return nil
+}
+
+// accountEntriesQuery reads an account's entries, live and archived, in the
+// order its chain is rebuilt in.
+const accountEntriesQuery = `
+	SELECT id, transaction_id, account_id, entry_type, amount, balance_after, created_at, prev_hash, entry_hash
+	FROM ledger_entries WHERE account_id = ?
+	UNION ALL
+	SELECT id, transaction_id, account_id, entry_type, amount, balance_after, created_at, prev_hash, entry_hash
+	FROM archived_ledger_entries WHERE account_id = ?
+	ORDER BY created_at, id`
+
+// StreamAccountEntries calls fn with each of the account's entries, live and
+// archived, oldest first, stopping at the first error.
+func (r *ledgerRepository) StreamAccountEntries(ctx context.Context, accountID string, fn func(*models.LedgerEntry) error) error {
+	return streamAccountEntries(r.reader(ctx, true), accountID, fn)
+}
+
+func streamAccountEntries(db *gorm.DB, accountID string, fn func(*models.LedgerEntry) error) error {
+	rows, err := db.Raw(accountEntriesQuery, accountID, accountID).Rows()
+	if err != nil {
+		return apperrors.NewDatabaseError("failed to fetch account entries", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.LedgerEntry
+		if err := db.ScanRows(rows, &entry); err != nil {
+			return apperrors.NewDatabaseError("failed to read account entry", err)
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return apperrors.NewDatabaseError("failed to fetch account entries", err)
+	}
+	return nil
+}
+
+// RechainAccount rewrites the hashes of the account's entries that no longer
+// chain, and the account's last entry hash, under the account's lock. It
+// holds every entry of the account in memory.
+func (r *ledgerRepository) RechainAccount(ctx context.Context, accountID string) (*Rechain, error) {
+	var result *Rechain
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		accounts := make(map[string]*models.Account, 1)
+		if err := r.lockAccounts(tx, accounts, []string{accountID}); err != nil {
+			return err
+		}
+		acc := accounts[accountID]
+		if err := checkHomeRegion(acc); err != nil {
+			return err
+		}
+
+		archivedIDs, err := archivedEntryIDs(tx, accountID)
+		if err != nil {
+			return err
+		}
+		var entries []models.LedgerEntry
+		if err := streamAccountEntries(tx, accountID, func(entry *models.LedgerEntry) error {
+			entries = append(entries, *entry)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		changed, last := rechainEntries(entries)
+		result = &Rechain{
+			Entries:               len(entries),
+			Rewritten:             len(changed),
+			PreviousLastEntryHash: acc.LastEntryHash,
+			LastEntryHash:         last,
+		}
+		if len(changed) == 0 && last == acc.LastEntryHash {
+			return nil
+		}
+
+		// The immutability trigger lets this transaction rewrite hashes.
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT set_config('ledger.rechaining', 'on', true)").Error; err != nil {
+				return apperrors.NewDatabaseError("failed to allow rechaining", err)
+			}
+		}
+		for _, i := range changed {
+			entry := entries[i]
+			hashes := map[string]any{"prev_hash": entry.PrevHash, "entry_hash": entry.EntryHash}
+			var model any = &models.LedgerEntry{}
+			if archivedIDs[entry.ID] {
+				model = &models.ArchivedLedgerEntry{}
+			}
+			if err := tx.Model(model).Where("id = ?", entry.ID).Updates(hashes).Error; err != nil {
+				return apperrors.NewDatabaseError("failed to rewrite entry hashes", err)
+			}
+		}
+		acc.LastEntryHash = last
+		return saveBalance(tx, acc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func archivedEntryIDs(tx *gorm.DB, accountID string) (map[string]bool, error) {
+	var ids []string
+	if err := tx.Model(&models.ArchivedLedgerEntry{}).Where("account_id = ?", accountID).Pluck("id", &ids).Error; err != nil {
+		return nil, apperrors.NewDatabaseError("failed to fetch archived entry ids", err)
+	}
+	archived := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		archived[id] = true
+	}
+	return archived, nil
+}
+
+func (r *ledgerRepository) GetAllAccountsForReconciliation(ctx context.Context) ([]AccountReconciliation, error) {
+	var results []AccountReconciliation
+
+	err := r.reader(ctx, false).
+		Table("accounts a").
+		Select(`a.id AS account_id,
+			a.name AS account_name,
+			a.account_type,
+			a.balance AS cached_balance,
+			COALESCE(SUM(CASE WHEN le.entry_type = ? THEN le.amount ELSE 0 END), 0) -
+			COALESCE(SUM(CASE WHEN le.entry_type = ? THEN le.amount ELSE 0 END), 0) +
+			COALESCE((SELECT SUM(adt.credits - adt.debits) FROM archived_daily_totals adt WHERE adt.account_id = a.id), 0) AS derived_balance`,
+			models.EntryTypeCredit, models.EntryTypeDebit).
+		Joins("LEFT JOIN ledger_entries le ON le.account_id = a.id").
+		Group("a.id, a.name, a.account_type, a.balance").
+		Scan(&results).Error
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("failed to run reconciliation", err)
+	}
+
+	for i := range results {
+		results[i].IsConsistent = results[i].CachedBalance == results[i].DerivedBalance
+	}
+
+	return results, nil
 }
 
-func isDuplicateKey(err error) bool {
-	return errors.Is(err, gorm.ErrDuplicatedKey) || apperrors.IsDuplicateKeyError(err)
+func (r *ledgerRepository) GetLedgerTotals(ctx context.Context) (totalDebits, totalCredits int64, err error) {
+	type totals struct {
+		TotalDebits  int64
+		TotalCredits int64
+	}
+	var t totals
+
+	if err := r.reader(ctx, false).
+		Model(&models.LedgerEntry{}).
+		Select(`COALESCE(SUM(CASE WHEN entry_type = ? THEN amount ELSE 0 END), 0) AS total_debits,
+			COALESCE(SUM(CASE WHEN entry_type = ? THEN amount ELSE 0 END), 0) AS total_credits`,
+			models.EntryTypeDebit, models.EntryTypeCredit).
+		Scan(&t).Error; err != nil {
+		return 0, 0, apperrors.NewDatabaseError("failed to calculate ledger totals", err)
+	}
+	var archived totals
+	if err := r.reader(ctx, false).
+		Model(&models.ArchivedDailyTotal{}).
+		Select("COALESCE(SUM(debits), 0) AS total_debits, COALESCE(SUM(credits), 0) AS total_credits").
+		Scan(&archived).Error; err != nil {
+		return 0, 0, apperrors.NewDatabaseError("failed to calculate archived ledger totals", err)
+	}
+
+	return t.TotalDebits + archived.TotalDebits, t.TotalCredits + archived.TotalCredits, nil
 }
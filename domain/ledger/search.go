@@ -0,0 +1,85 @@
+package ledger
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/retry"
+	"github.com/akeren/go-api-foundry/pkg/search"
+)
+
+// SearchTypeTransaction is the search document type of ledger transactions.
+const SearchTypeTransaction = "transaction"
+
+const searchIndexAttempts = 3
+
+var searchIndexBackoff = retry.Backoff{Initial: 500 * time.Millisecond, Max: 5 * time.Second, Multiplier: 2, Jitter: 0.2}
+
+// SearchIndexer indexes every posted transaction for full-text search. Its
+// Handle method subscribes to EventTransactionPosted.
+type SearchIndexer struct {
+	logger  *log.Logger
+	repo    LedgerRepository
+	indexer search.Indexer
+}
+
+func NewSearchIndexer(logger *log.Logger, repo LedgerRepository, indexer search.Indexer) *SearchIndexer {
+	return &SearchIndexer{logger: logger, repo: repo, indexer: indexer}
+}
+
+// Handle indexes the transaction an EventTransactionPosted names. The event
+// does not carry the description, so the transaction is read back. A
+// replayed event indexes the same document again.
+func (s *SearchIndexer) Handle(ctx context.Context, event events.Event) {
+	posted, ok := event.Data.(TransactionPostedEvent)
+	if !ok {
+		return
+	}
+	txn, err := s.repo.GetTransaction(ctx, posted.TransactionID)
+	if err != nil {
+		s.logger.Error("Failed to read transaction for the search index", "transaction_id", posted.TransactionID, "error", err)
+		return
+	}
+	doc := TransactionDocument(txn)
+	err = retry.Do(ctx, searchIndexBackoff, searchIndexAttempts, func(ctx context.Context) error {
+		return s.indexer.Index(ctx, doc)
+	})
+	if err != nil {
+		s.logger.Error("Failed to index transaction", "transaction_id", txn.ID, "error", err)
+	}
+}
+
+// TransactionDocument is the search document of txn. Its tenants are the
+// user accounts it moved money between, so an account's holder finds the
+// transactions touching that account; system accounts are never tenants.
+func TransactionDocument(txn *models.Transaction) search.Document {
+	var tenants []string
+	for _, e := range txn.Entries {
+		if !models.IsSystemAccountID(e.AccountID) && !slices.Contains(tenants, e.AccountID) {
+			tenants = append(tenants, e.AccountID)
+		}
+	}
+	fields := map[string]any{
+		"amount":        txn.Amount,
+		"currency":      txn.Currency,
+		"dest_amount":   txn.DestAmount,
+		"dest_currency": txn.DestCurrency,
+		"fee":           txn.Fee,
+	}
+	if txn.ReversedTransactionID != nil {
+		fields["reversed_transaction_id"] = *txn.ReversedTransactionID
+	}
+	return search.Document{
+		ID:        txn.ID,
+		Type:      SearchTypeTransaction,
+		Tenants:   tenants,
+		Title:     txn.TransactionType,
+		Text:      txn.Description,
+		Fields:    fields,
+		CreatedAt: txn.CreatedAt,
+	}
+}
@@ -0,0 +1,53 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSearchIndexer_IndexesPostedTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := NewMockLedgerRepository(ctrl)
+	index := search.NewMemoryIndexer()
+	indexer := NewSearchIndexer(log.NewLoggerWithJSONOutput(), mockRepo, index)
+
+	txn := &models.Transaction{
+		ID:              "txn-1",
+		TransactionType: models.TransactionTypeTransfer,
+		Amount:          1000,
+		Currency:        "USD",
+		Fee:             25,
+		Description:     "Invoice 2291 for consulting",
+		CreatedAt:       time.Now(),
+		Entries: []models.LedgerEntry{
+			{AccountID: "acc-1", EntryType: models.EntryTypeDebit, Amount: 1025},
+			{AccountID: "acc-2", EntryType: models.EntryTypeCredit, Amount: 1000},
+			{AccountID: models.SystemAccountID, EntryType: models.EntryTypeCredit, Amount: 25},
+		},
+	}
+	mockRepo.EXPECT().GetTransaction(gomock.Any(), "txn-1").Return(txn, nil)
+
+	indexer.Handle(context.Background(), events.Event{Type: EventTransactionPosted, Data: TransactionPostedEvent{TransactionID: "txn-1"}})
+	// Other payloads are ignored.
+	indexer.Handle(context.Background(), events.Event{Type: EventAccountCreated, Data: AccountCreatedEvent{AccountID: "acc-1"}})
+
+	for tenant, want := range map[string]int{"acc-1": 1, "acc-2": 1, models.SystemAccountID: 0} {
+		result, err := index.Search(context.Background(), search.Query{Text: "consulting", Tenant: tenant, Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, result.Hits, want, "tenant %s", tenant)
+	}
+
+	doc := TransactionDocument(txn)
+	assert.Equal(t, SearchTypeTransaction, doc.Type)
+	assert.Equal(t, models.TransactionTypeTransfer, doc.Title)
+	assert.Equal(t, int64(25), doc.Fields["fee"])
+}
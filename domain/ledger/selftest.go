@@ -0,0 +1,57 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/akeren/go-api-foundry/pkg/probe"
+	"github.com/akeren/go-api-foundry/pkg/txmanager"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errSelfTestRollback ends the self-test's unit of work so none of its
+// writes are kept.
+var errSelfTestRollback = errors.New("ledger self-test rolled back")
+
+const selfTestAmount = 100
+
+// SelfTest returns a check that opens an account, posts a dry-run deposit to
+// it and checks that the balance did not move, all in one transaction that
+// is rolled back. service must not publish events, since the writes it
+// reports never happen.
+func SelfTest(db *gorm.DB, service LedgerService) probe.Check {
+	manager := txmanager.New(db)
+	return func(ctx context.Context) error {
+		err := manager.WithinTransaction(ctx, func(ctx context.Context) error {
+			account, err := service.CreateAccount(ctx, &CreateAccountRequest{Name: "self-test"})
+			if err != nil {
+				return fmt.Errorf("create account: %w", err)
+			}
+			deposit, err := service.Deposit(ctx, account.ID, &DepositRequest{
+				Amount:         selfTestAmount,
+				IdempotencyKey: "self-test-" + uuid.NewString(),
+				DryRun:         true,
+			})
+			if err != nil {
+				return fmt.Errorf("dry-run deposit: %w", err)
+			}
+			if deposit.Amount != selfTestAmount || !deposit.DryRun {
+				return fmt.Errorf("dry-run deposit returned amount %d, dry run %t", deposit.Amount, deposit.DryRun)
+			}
+			balance, err := service.GetBalance(ctx, account.ID)
+			if err != nil {
+				return fmt.Errorf("get balance: %w", err)
+			}
+			if balance.CachedBalance != 0 || balance.DerivedBalance != 0 {
+				return fmt.Errorf("dry-run deposit left balances of %d cached, %d derived", balance.CachedBalance, balance.DerivedBalance)
+			}
+			return errSelfTestRollback
+		})
+		if errors.Is(err, errSelfTestRollback) {
+			return nil
+		}
+		return err
+	}
+}
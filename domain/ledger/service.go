@@ -1,31 +1,83 @@
 package ledger
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/akeren/go-api-foundry/internal/log"
 	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/constants"
 	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/fx"
+	"github.com/akeren/go-api-foundry/pkg/region"
 )
 
 type LedgerService interface {
 	CreateAccount(ctx context.Context, req *CreateAccountRequest) (*AccountResponse, error)
 	GetAccount(ctx context.Context, id string) (*AccountResponse, error)
+	UpdateAccount(ctx context.Context, id string, req *UpdateAccountRequest) (*AccountResponse, error)
+	GetAggregateBalance(ctx context.Context, id string) (*AggregateBalanceResponse, error)
 	Deposit(ctx context.Context, accountID string, req *DepositRequest) (*TransactionResponse, error)
 	Withdraw(ctx context.Context, accountID string, req *WithdrawRequest) (*TransactionResponse, error)
 	Transfer(ctx context.Context, req *TransferRequest) (*TransactionResponse, error)
+	SplitTransfer(ctx context.Context, req *SplitTransferRequest) (*SplitTransferResponse, error)
+	ReverseTransaction(ctx context.Context, transactionID string, req *ReverseTransactionRequest) (*TransactionResponse, error)
+	QuoteTransfer(ctx context.Context, req *TransferQuoteRequest) (*TransferQuoteResponse, error)
+	CreateHold(ctx context.Context, accountID string, req *CreateHoldRequest) (*HoldResponse, error)
+	GetHold(ctx context.Context, id string) (*HoldResponse, error)
+	CaptureHold(ctx context.Context, id string, req *CaptureHoldRequest) (*HoldResponse, error)
+	ReleaseHold(ctx context.Context, id string, dryRun bool) (*HoldResponse, error)
+	CreateBalanceAlert(ctx context.Context, accountID string, req *CreateBalanceAlertRequest) (*BalanceAlertResponse, error)
+	ListBalanceAlerts(ctx context.Context, accountID string) ([]BalanceAlertResponse, error)
+	DeleteBalanceAlert(ctx context.Context, accountID, id string) error
+	CreateAccrualPlan(ctx context.Context, accountID string, req *CreateAccrualPlanRequest) (*AccrualPlanResponse, error)
+	ListAccrualPlans(ctx context.Context, accountID string) ([]AccrualPlanResponse, error)
+	UpdateAccrualPlan(ctx context.Context, accountID, planID string, req *UpdateAccrualPlanRequest) (*AccrualPlanResponse, error)
+	ListAccrualRecords(ctx context.Context, accountID string, from, to time.Time) (*AccrualRecordsResponse, error)
+	AccrueDueDays(ctx context.Context, through time.Time) (int, error)
+	SealDays(ctx context.Context, through time.Time) (int, error)
+	VerifyDay(ctx context.Context, day time.Time) (*DayVerificationResponse, error)
+	VerifyAccountChain(ctx context.Context, accountID string) (*AccountChainVerificationResponse, error)
+	RechainAccount(ctx context.Context, accountID string) (*AccountRechainResponse, error)
+	TransferBatch(ctx context.Context, transfers []TransferRequest, progress func(done int)) (*BatchTransferResult, error)
+	ImportTransfersCSV(ctx context.Context, r io.Reader) (*ImportResult, error)
 	GetBalance(ctx context.Context, accountID string) (*BalanceResponse, error)
-	GetTransactions(ctx context.Context, accountID string, limit, offset int) ([]TransactionResponse, error)
+	GetBalanceVersion(ctx context.Context, accountID string) (string, error)
+	GetDailyBalances(ctx context.Context, accountID string, from, to time.Time) (*DailyBalancesResponse, error)
+	GetStatement(ctx context.Context, accountID string, from, to time.Time) (*Statement, error)
+	GetTransactions(ctx context.Context, accountID string, limit, offset int, includeEntries bool) ([]TransactionResponse, int64, error)
+	GetTransaction(ctx context.Context, id string) (*TransactionResponse, error)
+	GetArchivedTransaction(ctx context.Context, id string) (*TransactionResponse, error)
+	GetArchivedTransactions(ctx context.Context, accountID string, limit, offset int) ([]TransactionResponse, int64, error)
+	SearchTransactions(ctx context.Context, query TransactionSearchQuery) ([]TransactionResponse, error)
 	Reconcile(ctx context.Context) (*ReconciliationResponse, error)
 }
 
 type ledgerService struct {
 	logger     *log.Logger
 	repository LedgerRepository
+	pricing    Pricing
+	events     events.Publisher
 }
 
-func NewLedgerService(logger *log.Logger, repository LedgerRepository) LedgerService {
-	return &ledgerService{logger: logger, repository: repository}
+// NewLedgerService creates the ledger service. Domain events are published to
+// publisher when it is non-nil.
+func NewLedgerService(logger *log.Logger, repository LedgerRepository, pricing Pricing, publisher events.Publisher) LedgerService {
+	if pricing.QuoteTTL <= 0 {
+		pricing.QuoteTTL = defaultQuoteTTL
+	}
+	if pricing.HoldTTL <= 0 {
+		pricing.HoldTTL = defaultHoldTTL
+	}
+	if pricing.MaxRateAge <= 0 {
+		pricing.MaxRateAge = defaultMaxRateAge
+	}
+	return &ledgerService{logger: logger, repository: repository, pricing: pricing, events: publisher}
 }
 
 func (s *ledgerService) CreateAccount(ctx context.Context, req *CreateAccountRequest) (*AccountResponse, error) {
@@ -37,16 +89,150 @@ func (s *ledgerService) CreateAccount(ctx context.Context, req *CreateAccountReq
 	}
 
 	account := ToAccountModel(req)
+	account.HomeRegion = region.LocalName()
+	if req.ParentID != "" {
+		parent, err := s.parentForNewAccount(ctx, req.ParentID)
+		if err != nil {
+			logger.Error("Invalid parent account", "parent_id", req.ParentID, "error", err)
+			return nil, err
+		}
+		// A hierarchy lives in one region, so transfers within it never cross.
+		if err := checkHomeRegion(parent); err != nil {
+			return nil, err
+		}
+		account.HomeRegion = cmp.Or(parent.HomeRegion, account.HomeRegion)
+		if req.Currency == "" {
+			account.Currency = parent.Currency
+		} else if req.Currency != parent.Currency {
+			return nil, ErrCurrencyMismatch
+		}
+	}
+
+	if req.DryRun {
+		// Nothing beyond request validation can fail; report the account as it would be created.
+		account.CreatedAt = time.Now()
+		resp := ToAccountResponse(account)
+		resp.DryRun = true
+		return &resp, nil
+	}
+
 	created, err := s.repository.CreateAccount(ctx, account)
 	if err != nil {
 		logger.Error("Failed to create account", "error", err)
 		return nil, err
 	}
+	s.publishAccountCreated(ctx, created)
 
 	resp := ToAccountResponse(created)
 	return &resp, nil
 }
 
+// maxAccountDepth bounds the hierarchy, including the root account, so that
+// aggregation and ancestor walks stay cheap.
+const maxAccountDepth = 5
+
+// parentForNewAccount loads the parent of an account being created and checks
+// that one more level fits under it.
+func (s *ledgerService) parentForNewAccount(ctx context.Context, parentID string) (*models.Account, error) {
+	parent, err := s.repository.GetAccountByID(ctx, parentID)
+	if errors.Is(err, ErrAccountNotFound) {
+		return nil, ErrParentAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if parent.AccountType != models.AccountTypeUser {
+		return nil, ErrSystemAccountForbidden
+	}
+
+	// level is the new account's level, counting the root as 1, assuming
+	// the current ancestor is the root.
+	level := 2
+	for ancestor := parent; ancestor.ParentID != nil; level++ {
+		if level >= maxAccountDepth {
+			return nil, ErrHierarchyTooDeep
+		}
+		if ancestor, err = s.repository.GetAccountByID(ctx, *ancestor.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	return parent, nil
+}
+
+func (s *ledgerService) UpdateAccount(ctx context.Context, id string, req *UpdateAccountRequest) (*AccountResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if req == nil {
+		logger.Error("UpdateAccount received nil request")
+		return nil, apperrors.NewInvalidRequestError("request cannot be nil", nil)
+	}
+
+	if models.IsSystemAccountID(id) {
+		return nil, ErrSystemAccountForbidden
+	}
+
+	account, err := s.repository.UpdateSiblingTransfers(ctx, id, req.SiblingTransfers)
+	if err != nil {
+		logger.Error("Failed to update account", "id", id, "error", err)
+		return nil, err
+	}
+
+	resp := ToAccountResponse(account)
+	return &resp, nil
+}
+
+// GetAggregateBalance totals the balances of an account and all of its
+// sub-accounts. Accounts in a hierarchy share one currency, so the totals
+// are plain sums.
+func (s *ledgerService) GetAggregateBalance(ctx context.Context, id string) (*AggregateBalanceResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if id == "" {
+		logger.Error("GetAggregateBalance received empty ID")
+		return nil, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
+
+	accounts, err := s.repository.GetAccountSubtree(ctx, id, maxAccountDepth)
+	if err != nil {
+		logger.Error("Failed to get account hierarchy", "id", id, "error", err)
+		return nil, err
+	}
+
+	children := make(map[string][]*models.Account, len(accounts))
+	for i := range accounts {
+		if parentID := accounts[i].ParentID; parentID != nil {
+			children[*parentID] = append(children[*parentID], &accounts[i])
+		}
+	}
+
+	// total returns the subtree balance and the number of descendants.
+	var total func(acc *models.Account) (int64, int)
+	total = func(acc *models.Account) (int64, int) {
+		sum, count := acc.Balance, 0
+		for _, child := range children[acc.ID] {
+			childSum, childCount := total(child)
+			sum += childSum
+			count += childCount + 1
+		}
+		return sum, count
+	}
+
+	root := &accounts[0]
+	resp := &AggregateBalanceResponse{
+		AccountID: root.ID,
+		Currency:  root.Currency,
+		Balance:   root.Balance,
+		Children:  make([]SubAccountBalance, 0, len(children[root.ID])),
+	}
+	resp.TotalBalance, resp.SubAccountCount = total(root)
+	for _, child := range children[root.ID] {
+		item := SubAccountBalance{AccountID: child.ID, Name: child.Name, Balance: child.Balance}
+		item.TotalBalance, item.SubAccountCount = total(child)
+		resp.Children = append(resp.Children, item)
+	}
+	return resp, nil
+}
+
 func (s *ledgerService) GetAccount(ctx context.Context, id string) (*AccountResponse, error) {
 	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
 
@@ -82,7 +268,7 @@ func (s *ledgerService) Deposit(ctx context.Context, accountID string, req *Depo
 		return nil, ErrInvalidAmount
 	}
 
-	if accountID == models.SystemAccountID {
+	if models.IsSystemAccountID(accountID) {
 		return nil, ErrSystemAccountForbidden
 	}
 
@@ -94,6 +280,7 @@ func (s *ledgerService) Deposit(ctx context.Context, accountID string, req *Depo
 		TransactionType: models.TransactionTypeDeposit,
 		IdempotencyKey:  req.IdempotencyKey,
 		Description:     req.Description,
+		DryRun:          req.DryRun,
 	}
 
 	txn, err := s.repository.ExecuteDoubleEntry(ctx, cmd)
@@ -101,8 +288,12 @@ func (s *ledgerService) Deposit(ctx context.Context, accountID string, req *Depo
 		logger.Error("Failed to execute deposit", "account_id", accountID, "error", err)
 		return nil, err
 	}
+	if !req.DryRun {
+		s.publishTransactionPosted(ctx, cmd.SourceAccountID, cmd.DestAccountID, txn)
+	}
 
 	resp := ToTransactionResponse(txn)
+	resp.DryRun = req.DryRun
 	return &resp, nil
 }
 
@@ -123,7 +314,7 @@ func (s *ledgerService) Withdraw(ctx context.Context, accountID string, req *Wit
 		return nil, ErrInvalidAmount
 	}
 
-	if accountID == models.SystemAccountID {
+	if models.IsSystemAccountID(accountID) {
 		return nil, ErrSystemAccountForbidden
 	}
 
@@ -135,6 +326,7 @@ func (s *ledgerService) Withdraw(ctx context.Context, accountID string, req *Wit
 		TransactionType: models.TransactionTypeWithdrawal,
 		IdempotencyKey:  req.IdempotencyKey,
 		Description:     req.Description,
+		DryRun:          req.DryRun,
 	}
 
 	txn, err := s.repository.ExecuteDoubleEntry(ctx, cmd)
@@ -142,8 +334,12 @@ func (s *ledgerService) Withdraw(ctx context.Context, accountID string, req *Wit
 		logger.Error("Failed to execute withdrawal", "account_id", accountID, "error", err)
 		return nil, err
 	}
+	if !req.DryRun {
+		s.publishTransactionPosted(ctx, cmd.SourceAccountID, cmd.DestAccountID, txn)
+	}
 
 	resp := ToTransactionResponse(txn)
+	resp.DryRun = req.DryRun
 	return &resp, nil
 }
 
@@ -155,43 +351,534 @@ func (s *ledgerService) Transfer(ctx context.Context, req *TransferRequest) (*Tr
 		return nil, apperrors.NewInvalidRequestError("request cannot be nil", nil)
 	}
 
-	if req.SourceAccountID == "" || req.DestAccountID == "" {
-		logger.Error("Transfer received empty account IDs")
-		return nil, apperrors.NewInvalidRequestError("source and destination account IDs are required", nil)
+	if err := validateTransfer(req.SourceAccountID, req.DestAccountID, req.Amount); err != nil {
+		logger.Error("Transfer request rejected", "error", err)
+		return nil, err
+	}
+
+	cmd := DoubleEntryCommand{
+		SourceAccountID: req.SourceAccountID,
+		DestAccountID:   req.DestAccountID,
+		Amount:          req.Amount,
+		Currency:        req.Currency,
+		TransactionType: models.TransactionTypeTransfer,
+		IdempotencyKey:  req.IdempotencyKey,
+		Description:     req.Description,
+		Fee:             s.pricing.Fees.Fee(req.Amount),
+		QuoteID:         req.QuoteID,
+		MaxRateAge:      s.pricing.MaxRateAge,
+		DryRun:          req.DryRun,
+	}
+
+	txn, err := s.repository.ExecuteDoubleEntry(ctx, cmd)
+	if err != nil {
+		logger.Error("Failed to execute transfer", "error", err)
+		return nil, err
+	}
+	if !req.DryRun {
+		s.publishTransactionPosted(ctx, cmd.SourceAccountID, cmd.DestAccountID, txn)
+	}
+
+	resp := ToTransactionResponse(txn)
+	resp.DryRun = req.DryRun
+	return &resp, nil
+}
+
+// SplitTransfer moves an amount from one account to several in a single
+// balanced transaction. The fee is charged once, on the whole amount.
+func (s *ledgerService) SplitTransfer(ctx context.Context, req *SplitTransferRequest) (*SplitTransferResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if req == nil {
+		logger.Error("SplitTransfer received nil request")
+		return nil, apperrors.NewInvalidRequestError("request cannot be nil", nil)
+	}
+
+	legs, err := allocateSplit(req)
+	if err != nil {
+		logger.Error("Split transfer request rejected", "error", err)
+		return nil, err
 	}
 
-	if req.SourceAccountID == req.DestAccountID {
-		return nil, ErrSelfTransfer
+	cmd := SplitTransferCommand{
+		SourceAccountID: req.SourceAccountID,
+		Legs:            legs,
+		Currency:        req.Currency,
+		IdempotencyKey:  req.IdempotencyKey,
+		Description:     req.Description,
+		Fee:             s.pricing.Fees.Fee(req.Amount),
+		DryRun:          req.DryRun,
 	}
 
+	txn, err := s.repository.ExecuteSplitTransfer(ctx, cmd)
+	if err != nil {
+		logger.Error("Failed to execute split transfer", "error", err)
+		return nil, err
+	}
+	if !req.DryRun {
+		s.publishTransactionPosted(ctx, cmd.SourceAccountID, "", txn)
+	}
+
+	resp := SplitTransferResponse{TransactionResponse: ToTransactionResponse(txn)}
+	resp.DryRun = req.DryRun
+	credits := make(map[string]LedgerEntryResponse, len(legs))
+	for _, e := range resp.Entries {
+		if e.EntryType == models.EntryTypeCredit {
+			credits[e.AccountID] = e
+		}
+	}
+	for i, leg := range legs {
+		resp.Destinations = append(resp.Destinations, SplitDestinationResponse{
+			AccountID:   leg.DestAccountID,
+			Amount:      leg.Amount,
+			BasisPoints: req.Destinations[i].BasisPoints,
+			Entry:       credits[leg.DestAccountID],
+		})
+	}
+	return &resp, nil
+}
+
+// allocateSplit turns a split's destinations into legs. Fixed amounts are
+// taken first and basis points share the rest, rounded down; the minor
+// units left by rounding go one each to the first destinations with basis
+// points.
+func allocateSplit(req *SplitTransferRequest) ([]SplitLeg, error) {
+	if req.SourceAccountID == "" {
+		return nil, apperrors.NewInvalidRequestError("source account ID is required", nil)
+	}
 	if req.Amount <= 0 {
 		return nil, ErrInvalidAmount
 	}
+	if models.IsSystemAccountID(req.SourceAccountID) {
+		return nil, ErrSystemAccountForbidden
+	}
+
+	seen := make(map[string]bool, len(req.Destinations))
+	var fixed, basisPoints int64
+	for _, d := range req.Destinations {
+		switch {
+		case d.AccountID == "":
+			return nil, apperrors.NewInvalidRequestError("destination account IDs are required", nil)
+		case d.AccountID == req.SourceAccountID:
+			return nil, ErrSelfTransfer
+		case models.IsSystemAccountID(d.AccountID):
+			return nil, ErrSystemAccountForbidden
+		case seen[d.AccountID]:
+			return nil, ErrDuplicateDestination
+		case d.Amount < 0 || d.BasisPoints < 0:
+			return nil, ErrInvalidAmount
+		case (d.Amount > 0) == (d.BasisPoints > 0):
+			return nil, apperrors.NewInvalidRequestError("each destination needs either amount or basis_points", nil)
+		}
+		seen[d.AccountID] = true
+		fixed += d.Amount
+		basisPoints += d.BasisPoints
+	}
+
+	rest := req.Amount - fixed
+	if basisPoints == 0 && rest != 0 || basisPoints > 0 && (rest <= 0 || basisPoints != 10000) {
+		return nil, ErrInvalidSplit
+	}
+
+	legs := make([]SplitLeg, len(req.Destinations))
+	left := rest
+	for i, d := range req.Destinations {
+		amount := d.Amount
+		if d.BasisPoints > 0 {
+			// Split rest so rest*BasisPoints cannot overflow.
+			amount = rest/10000*d.BasisPoints + rest%10000*d.BasisPoints/10000
+			left -= amount
+		}
+		legs[i] = SplitLeg{DestAccountID: d.AccountID, Amount: amount}
+	}
+	for i := 0; left > 0; i++ {
+		if req.Destinations[i].BasisPoints > 0 {
+			legs[i].Amount++
+			left--
+		}
+	}
+	for _, leg := range legs {
+		if leg.Amount <= 0 {
+			return nil, ErrInvalidAmount
+		}
+	}
+	return legs, nil
+}
+
+// ReverseTransaction posts a compensating transaction that returns what the
+// original moved, fee included, to where it came from. A transaction is
+// reversed at most once and reversals cannot themselves be reversed.
+func (s *ledgerService) ReverseTransaction(ctx context.Context, transactionID string, req *ReverseTransactionRequest) (*TransactionResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if req == nil {
+		logger.Error("ReverseTransaction received nil request")
+		return nil, apperrors.NewInvalidRequestError("request cannot be nil", nil)
+	}
+
+	if transactionID == "" {
+		logger.Error("ReverseTransaction received empty transaction ID")
+		return nil, apperrors.NewInvalidRequestError("transaction ID cannot be empty", nil)
+	}
+
+	reversal, err := s.repository.ReverseTransaction(ctx, ReversalCommand{
+		TransactionID:  transactionID,
+		IdempotencyKey: req.IdempotencyKey,
+		Description:    req.Description,
+		DryRun:         req.DryRun,
+	})
+	if err != nil {
+		logger.Error("Failed to reverse transaction", "transaction_id", transactionID, "error", err)
+		return nil, err
+	}
+	if !req.DryRun {
+		s.publishTransactionPosted(ctx, reversal.SourceAccountID, reversal.DestAccountID, reversal.Transaction)
+	}
+
+	resp := ToTransactionResponse(reversal.Transaction)
+	resp.DryRun = req.DryRun
+	return &resp, nil
+}
+
+// validateTransfer holds the checks shared by transfers and quotes that need
+// no database access.
+func validateTransfer(sourceID, destID string, amount int64) error {
+	if sourceID == "" || destID == "" {
+		return apperrors.NewInvalidRequestError("source and destination account IDs are required", nil)
+	}
+	if sourceID == destID {
+		return ErrSelfTransfer
+	}
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if models.IsSystemAccountID(sourceID) || models.IsSystemAccountID(destID) {
+		return ErrSystemAccountForbidden
+	}
+	return nil
+}
+
+// QuoteTransfer prices a prospective transfer and stores the quote so the
+// transfer can reference it to be charged the quoted fee. Balances are read
+// without locks, so the resulting balances are a preview, not a reservation.
+func (s *ledgerService) QuoteTransfer(ctx context.Context, req *TransferQuoteRequest) (*TransferQuoteResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if req == nil {
+		logger.Error("QuoteTransfer received nil request")
+		return nil, apperrors.NewInvalidRequestError("request cannot be nil", nil)
+	}
+
+	if err := validateTransfer(req.SourceAccountID, req.DestAccountID, req.Amount); err != nil {
+		logger.Error("Transfer quote rejected", "error", err)
+		return nil, err
+	}
+
+	source, err := s.repository.GetAccountByID(ctx, req.SourceAccountID)
+	if err != nil {
+		logger.Error("Failed to get quote source account", "id", req.SourceAccountID, "error", err)
+		return nil, err
+	}
+	dest, err := s.repository.GetAccountByID(ctx, req.DestAccountID)
+	if err != nil {
+		logger.Error("Failed to get quote destination account", "id", req.DestAccountID, "error", err)
+		return nil, err
+	}
+
+	if req.Currency != "" && req.Currency != source.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	if source.ParentID != nil && dest.ParentID != nil && *source.ParentID == *dest.ParentID {
+		parent, err := s.repository.GetAccountByID(ctx, *source.ParentID)
+		if err != nil {
+			logger.Error("Failed to get parent account", "id", *source.ParentID, "error", err)
+			return nil, err
+		}
+		if parent.SiblingTransfers == models.SiblingTransfersDeny {
+			return nil, ErrSiblingTransferBlocked
+		}
+	}
+
+	fee := s.pricing.Fees.Fee(req.Amount)
+	if source.AccountType == models.AccountTypeUser && source.Balance < req.Amount+fee {
+		return nil, ErrInsufficientFunds
+	}
+
+	rate := &fx.Rate{Base: source.Currency, Quote: dest.Currency, Value: identityExchangeRate}
+	if source.Currency != dest.Currency {
+		if rate, err = s.repository.GetExchangeRate(ctx, source.Currency, dest.Currency, s.pricing.MaxRateAge); err != nil {
+			logger.Error("Failed to get exchange rate", "base", source.Currency, "quote", dest.Currency, "error", err)
+			return nil, err
+		}
+	}
+	destAmount, err := fx.Convert(req.Amount, *rate)
+	if err != nil {
+		return nil, apperrors.NewInvalidRequestError("amount cannot be converted", err)
+	}
+	if destAmount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	quote, err := s.repository.CreateTransferQuote(ctx, &models.TransferQuote{
+		SourceAccountID: source.ID,
+		DestAccountID:   dest.ID,
+		Amount:          req.Amount,
+		Currency:        source.Currency,
+		Fee:             fee,
+		ExchangeRate:    rate.Value,
+		ExpiresAt:       time.Now().UTC().Add(s.pricing.QuoteTTL),
+	})
+	if err != nil {
+		logger.Error("Failed to create transfer quote", "error", err)
+		return nil, err
+	}
 
-	if req.SourceAccountID == models.SystemAccountID || req.DestAccountID == models.SystemAccountID {
+	return &TransferQuoteResponse{
+		QuoteID:            quote.ID,
+		SourceAccountID:    quote.SourceAccountID,
+		DestAccountID:      quote.DestAccountID,
+		Amount:             quote.Amount,
+		Currency:           quote.Currency,
+		Fee:                quote.Fee,
+		TotalDebit:         quote.Amount + quote.Fee,
+		ExchangeRate:       quote.ExchangeRate,
+		DestAmount:         destAmount,
+		DestCurrency:       dest.Currency,
+		SourceBalanceAfter: source.Balance - quote.Amount - quote.Fee,
+		DestBalanceAfter:   dest.Balance + destAmount,
+		ExpiresAt:          quote.ExpiresAt.Format(constants.RFC3339DateTimeFormat),
+	}, nil
+}
+
+// CreateHold reserves funds on an account for a later capture. The hold
+// expires after the configured hold TTL.
+func (s *ledgerService) CreateHold(ctx context.Context, accountID string, req *CreateHoldRequest) (*HoldResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if req == nil {
+		logger.Error("CreateHold received nil request")
+		return nil, apperrors.NewInvalidRequestError("request cannot be nil", nil)
+	}
+
+	if accountID == "" {
+		logger.Error("CreateHold received empty account ID")
+		return nil, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
+
+	if req.DestAccountID != "" {
+		if err := validateTransfer(accountID, req.DestAccountID, req.Amount); err != nil {
+			return nil, err
+		}
+	} else if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	} else if models.IsSystemAccountID(accountID) {
 		return nil, ErrSystemAccountForbidden
 	}
 
+	hold, err := s.repository.CreateHold(ctx, HoldCommand{
+		AccountID:      accountID,
+		DestAccountID:  req.DestAccountID,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		IdempotencyKey: req.IdempotencyKey,
+		ExpiresAt:      time.Now().UTC().Add(s.pricing.HoldTTL),
+		DryRun:         req.DryRun,
+	})
+	if err != nil {
+		logger.Error("Failed to create hold", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	resp := ToHoldResponse(hold)
+	resp.DryRun = req.DryRun
+	return &resp, nil
+}
+
+func (s *ledgerService) GetHold(ctx context.Context, id string) (*HoldResponse, error) {
+	if id == "" {
+		return nil, apperrors.NewInvalidRequestError("hold ID cannot be empty", nil)
+	}
+
+	hold, err := s.repository.GetHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := ToHoldResponse(hold)
+	return &resp, nil
+}
+
+// CaptureHold posts a hold as a transfer to its destination, charged the
+// current transfer fee, or as a withdrawal when it has none. Capturing less
+// than the hold releases the rest.
+func (s *ledgerService) CaptureHold(ctx context.Context, id string, req *CaptureHoldRequest) (*HoldResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if req == nil {
+		logger.Error("CaptureHold received nil request")
+		return nil, apperrors.NewInvalidRequestError("request cannot be nil", nil)
+	}
+
+	hold, err := s.repository.GetHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	cmd := DoubleEntryCommand{
-		SourceAccountID: req.SourceAccountID,
-		DestAccountID:   req.DestAccountID,
-		Amount:          req.Amount,
-		Currency:        req.Currency,
-		TransactionType: models.TransactionTypeTransfer,
+		SourceAccountID: hold.AccountID,
+		DestAccountID:   models.SystemAccountID,
+		Amount:          cmp.Or(req.Amount, hold.Amount),
+		Currency:        hold.Currency,
+		TransactionType: models.TransactionTypeWithdrawal,
 		IdempotencyKey:  req.IdempotencyKey,
 		Description:     req.Description,
+		HoldID:          hold.ID,
+		DryRun:          req.DryRun,
+	}
+	if hold.DestAccountID != nil {
+		cmd.DestAccountID = *hold.DestAccountID
+		cmd.TransactionType = models.TransactionTypeTransfer
+		cmd.Fee = s.pricing.Fees.Fee(cmd.Amount)
+		cmd.MaxRateAge = s.pricing.MaxRateAge
 	}
 
 	txn, err := s.repository.ExecuteDoubleEntry(ctx, cmd)
 	if err != nil {
-		logger.Error("Failed to execute transfer", "error", err)
+		logger.Error("Failed to capture hold", "hold_id", id, "error", err)
 		return nil, err
 	}
+	if !req.DryRun {
+		s.publishTransactionPosted(ctx, cmd.SourceAccountID, cmd.DestAccountID, txn)
+	}
 
-	resp := ToTransactionResponse(txn)
+	hold.Status = models.HoldStatusCaptured
+	hold.CapturedAmount = txn.Amount
+	hold.TransactionID = &txn.ID
+	resp := ToHoldResponse(hold)
+	transaction := ToTransactionResponse(txn)
+	resp.Transaction = &transaction
+	resp.DryRun = req.DryRun
 	return &resp, nil
 }
 
+// ReleaseHold frees a hold's funds without posting anything.
+func (s *ledgerService) ReleaseHold(ctx context.Context, id string, dryRun bool) (*HoldResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	hold, err := s.repository.ReleaseHold(ctx, id, dryRun)
+	if err != nil {
+		logger.Error("Failed to release hold", "hold_id", id, "error", err)
+		return nil, err
+	}
+
+	resp := ToHoldResponse(hold)
+	resp.DryRun = dryRun
+	return &resp, nil
+}
+
+// TransferBatch executes transfers in order, recording a per-item outcome.
+// A failed transfer does not stop the batch; cancellation of ctx does.
+// CreateBalanceAlert adds an alert to a user account. It takes effect from
+// the account's next posting.
+func (s *ledgerService) CreateBalanceAlert(ctx context.Context, accountID string, req *CreateBalanceAlertRequest) (*BalanceAlertResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if req == nil {
+		logger.Error("CreateBalanceAlert received nil request")
+		return nil, apperrors.NewInvalidRequestError("request cannot be nil", nil)
+	}
+
+	if accountID == "" {
+		logger.Error("CreateBalanceAlert received empty account ID")
+		return nil, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
+
+	if models.IsSystemAccountID(accountID) {
+		return nil, ErrSystemAccountForbidden
+	}
+
+	if req.Threshold <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	if _, err := s.repository.GetAccountByID(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	alert, err := s.repository.CreateBalanceAlert(ctx, &models.BalanceAlert{
+		AccountID:   accountID,
+		Kind:        req.Kind,
+		Threshold:   req.Threshold,
+		NotifyEmail: req.NotifyEmail,
+	})
+	if err != nil {
+		logger.Error("Failed to create balance alert", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	resp := ToBalanceAlertResponse(alert)
+	return &resp, nil
+}
+
+func (s *ledgerService) ListBalanceAlerts(ctx context.Context, accountID string) ([]BalanceAlertResponse, error) {
+	if accountID == "" {
+		return nil, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
+
+	if _, err := s.repository.GetAccountByID(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	alerts, err := s.repository.ListBalanceAlerts(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]BalanceAlertResponse, len(alerts))
+	for i := range alerts {
+		resp[i] = ToBalanceAlertResponse(&alerts[i])
+	}
+	return resp, nil
+}
+
+func (s *ledgerService) DeleteBalanceAlert(ctx context.Context, accountID, id string) error {
+	if accountID == "" || id == "" {
+		return apperrors.NewInvalidRequestError("account and alert IDs cannot be empty", nil)
+	}
+	return s.repository.DeleteBalanceAlert(ctx, accountID, id)
+}
+
+func (s *ledgerService) TransferBatch(ctx context.Context, transfers []TransferRequest, progress func(done int)) (*BatchTransferResult, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	result := &BatchTransferResult{Items: make([]BatchTransferItemResult, 0, len(transfers))}
+	for i := range transfers {
+		if err := ctx.Err(); err != nil {
+			logger.Warn("Batch transfer interrupted", "completed", i, "total", len(transfers))
+			return nil, err
+		}
+
+		item := BatchTransferItemResult{IdempotencyKey: transfers[i].IdempotencyKey}
+		txn, err := s.Transfer(ctx, &transfers[i])
+		if err != nil {
+			_, item.Error = mapDomainError(err)
+			result.Failed++
+		} else {
+			item.TransactionID = txn.ID
+			result.Succeeded++
+		}
+		result.Items = append(result.Items, item)
+
+		if progress != nil {
+			progress(i + 1)
+		}
+	}
+
+	logger.Info("Batch transfer completed", "succeeded", result.Succeeded, "failed", result.Failed)
+	return result, nil
+}
+
 func (s *ledgerService) GetBalance(ctx context.Context, accountID string) (*BalanceResponse, error) {
 	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
 
@@ -207,31 +894,265 @@ func (s *ledgerService) GetBalance(ctx context.Context, accountID string) (*Bala
 	}
 
 	return &BalanceResponse{
-		AccountID:      snapshot.AccountID,
-		CachedBalance:  snapshot.CachedBalance,
-		DerivedBalance: snapshot.DerivedBalance,
-		Currency:       snapshot.Currency,
-		IsConsistent:   snapshot.CachedBalance == snapshot.DerivedBalance,
+		AccountID:        snapshot.AccountID,
+		CachedBalance:    snapshot.CachedBalance,
+		DerivedBalance:   snapshot.DerivedBalance,
+		HeldBalance:      snapshot.HeldBalance,
+		AvailableBalance: snapshot.CachedBalance - snapshot.HeldBalance,
+		Currency:         snapshot.Currency,
+		IsConsistent:     snapshot.CachedBalance == snapshot.DerivedBalance,
 	}, nil
 }
 
-func (s *ledgerService) GetTransactions(ctx context.Context, accountID string, limit, offset int) ([]TransactionResponse, error) {
+// GetBalanceVersion returns a value that changes whenever the account's
+// GetBalance response does, read without summing its entries. The balance
+// endpoint uses it as its ETag.
+func (s *ledgerService) GetBalanceVersion(ctx context.Context, accountID string) (string, error) {
 	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
 
 	if accountID == "" {
-		logger.Error("GetTransactions received empty account ID")
+		logger.Error("GetBalanceVersion received empty account ID")
+		return "", apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
+
+	version, err := s.repository.GetBalanceVersion(ctx, accountID)
+	if err != nil {
+		logger.Error("Failed to get balance version", "id", accountID, "error", err)
+		return "", err
+	}
+	return fmt.Sprintf("v%d-h%d", version.Version, version.HeldBalance), nil
+}
+
+// maxReportDays bounds the range of the daily balance report and statements.
+const maxReportDays = 366
+
+// checkReportRange rejects a range of UTC days that runs backwards or is too
+// long to report on.
+func checkReportRange(from, to time.Time) error {
+	if to.Before(from) || to.Sub(from) >= maxReportDays*24*time.Hour {
+		return apperrors.NewInvalidRequestError("date range must run forwards and cover at most 366 days", nil)
+	}
+	return nil
+}
+
+// GetDailyBalances reports the account's daily totals for the UTC days from
+// and to, inclusive, from the account_daily_balances materialized view.
+func (s *ledgerService) GetDailyBalances(ctx context.Context, accountID string, from, to time.Time) (*DailyBalancesResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if accountID == "" {
+		logger.Error("GetDailyBalances received empty account ID")
+		return nil, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
+	if err := checkReportRange(from, to); err != nil {
+		return nil, err
+	}
+
+	account, err := s.repository.GetAccountByID(ctx, accountID)
+	if err != nil {
+		logger.Error("Failed to verify account for daily balances", "id", accountID, "error", err)
+		return nil, err
+	}
+
+	response := &DailyBalancesResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		From:      from.Format(time.DateOnly),
+		To:        to.Format(time.DateOnly),
+	}
+	days, err := s.repository.GetDailyBalances(ctx, accountID, response.From, response.To)
+	if err != nil {
+		logger.Error("Failed to get daily balances", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	response.Days = make([]DailyBalanceResponse, 0, len(days))
+	for _, day := range days {
+		response.Days = append(response.Days, DailyBalanceResponse{
+			Date:           day.Day,
+			Credits:        day.Credits,
+			Debits:         day.Debits,
+			EntryCount:     day.EntryCount,
+			ClosingBalance: day.ClosingBalance,
+		})
+	}
+	return response, nil
+}
+
+// GetStatement prepares the account's statement for the UTC days from and to,
+// inclusive. Its entries are read when the statement is written, so a long
+// statement is never held in memory.
+func (s *ledgerService) GetStatement(ctx context.Context, accountID string, from, to time.Time) (*Statement, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if accountID == "" {
+		logger.Error("GetStatement received empty account ID")
 		return nil, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
 	}
+	if err := checkReportRange(from, to); err != nil {
+		return nil, err
+	}
+
+	account, err := s.repository.GetAccountByID(ctx, accountID)
+	if err != nil {
+		logger.Error("Failed to verify account for statement", "id", accountID, "error", err)
+		return nil, err
+	}
+	opening, err := s.repository.GetBalanceBefore(ctx, accountID, from)
+	if err != nil {
+		logger.Error("Failed to get opening balance for statement", "account_id", accountID, "error", err)
+		return nil, err
+	}
+
+	until := to.AddDate(0, 0, 1)
+	return &Statement{
+		AccountID:      account.ID,
+		AccountName:    account.Name,
+		Currency:       account.Currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: opening,
+		entries: func(ctx context.Context, fn func(StatementEntry) error) error {
+			return s.repository.StreamStatementEntries(ctx, accountID, from, until, fn)
+		},
+	}, nil
+}
+
+// GetTransactions returns one page of the account's transactions, newest
+// first, and the account's total transaction count. Each transaction carries
+// a summary of its entries, and the entries themselves when includeEntries.
+func (s *ledgerService) GetTransactions(ctx context.Context, accountID string, limit, offset int, includeEntries bool) ([]TransactionResponse, int64, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if accountID == "" {
+		logger.Error("GetTransactions received empty account ID")
+		return nil, 0, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
 
 	// Verify account exists
 	if _, err := s.repository.GetAccountByID(ctx, accountID); err != nil {
 		logger.Error("Failed to verify account for transactions", "id", accountID, "error", err)
-		return nil, err
+		return nil, 0, err
 	}
 
-	transactions, err := s.repository.GetTransactionsByAccountID(ctx, accountID, limit, offset)
+	transactions, err := s.repository.GetTransactionsByAccountID(ctx, accountID, limit, offset, includeEntries)
 	if err != nil {
 		logger.Error("Failed to get transactions", "account_id", accountID, "error", err)
+		return nil, 0, err
+	}
+	var summaries map[string]TransactionSummary
+	if !includeEntries {
+		if summaries, err = s.repository.SummarizeEntries(ctx, accountID, transactions); err != nil {
+			logger.Error("Failed to summarize transaction entries", "account_id", accountID, "error", err)
+			return nil, 0, err
+		}
+	}
+
+	total, err := s.repository.CountTransactionsByAccountID(ctx, accountID)
+	if err != nil {
+		logger.Error("Failed to count transactions", "account_id", accountID, "error", err)
+		return nil, 0, err
+	}
+
+	responses := make([]TransactionResponse, 0, len(transactions))
+	for _, txn := range transactions {
+		resp := ToTransactionResponse(&txn)
+		summary := summaries[txn.ID]
+		if includeEntries {
+			summary = summarizeEntries(accountID, txn.Entries)
+		}
+		resp.Summary = &summary
+		responses = append(responses, resp)
+	}
+
+	return responses, total, nil
+}
+
+// GetTransaction returns a transaction with all of its entries.
+func (s *ledgerService) GetTransaction(ctx context.Context, id string) (*TransactionResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if id == "" {
+		logger.Error("GetTransaction received empty transaction ID")
+		return nil, apperrors.NewInvalidRequestError("transaction ID cannot be empty", nil)
+	}
+
+	txn, err := s.repository.GetTransaction(ctx, id)
+	if err != nil {
+		logger.Error("Failed to get transaction", "id", id, "error", err)
+		return nil, err
+	}
+	response := ToTransactionResponse(txn)
+	return &response, nil
+}
+
+// GetArchivedTransaction returns a transaction the archiver has moved out of
+// the live tables.
+func (s *ledgerService) GetArchivedTransaction(ctx context.Context, id string) (*TransactionResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if id == "" {
+		logger.Error("GetArchivedTransaction received empty transaction ID")
+		return nil, apperrors.NewInvalidRequestError("transaction ID cannot be empty", nil)
+	}
+
+	txn, err := s.repository.GetArchivedTransaction(ctx, id)
+	if err != nil {
+		logger.Error("Failed to get archived transaction", "id", id, "error", err)
+		return nil, err
+	}
+	response := ToTransactionResponse(txn)
+	return &response, nil
+}
+
+// GetArchivedTransactions returns one page of the account's archived
+// transactions, newest first, and how many it has in all.
+func (s *ledgerService) GetArchivedTransactions(ctx context.Context, accountID string, limit, offset int) ([]TransactionResponse, int64, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if accountID == "" {
+		logger.Error("GetArchivedTransactions received empty account ID")
+		return nil, 0, apperrors.NewInvalidRequestError("account ID cannot be empty", nil)
+	}
+
+	if _, err := s.repository.GetAccountByID(ctx, accountID); err != nil {
+		logger.Error("Failed to verify account for archived transactions", "id", accountID, "error", err)
+		return nil, 0, err
+	}
+
+	transactions, err := s.repository.GetArchivedTransactionsByAccountID(ctx, accountID, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get archived transactions", "account_id", accountID, "error", err)
+		return nil, 0, err
+	}
+
+	total, err := s.repository.CountArchivedTransactionsByAccountID(ctx, accountID)
+	if err != nil {
+		logger.Error("Failed to count archived transactions", "account_id", accountID, "error", err)
+		return nil, 0, err
+	}
+
+	responses := make([]TransactionResponse, 0, len(transactions))
+	for _, txn := range transactions {
+		responses = append(responses, ToTransactionResponse(&txn))
+	}
+	return responses, total, nil
+}
+
+func (s *ledgerService) SearchTransactions(ctx context.Context, query TransactionSearchQuery) ([]TransactionResponse, error) {
+	logger := log.GetLoggerInstanceFromContext(ctx, s.logger)
+
+	if query.AccountID != "" {
+		if _, err := s.repository.GetAccountByID(ctx, query.AccountID); err != nil {
+			logger.Error("Failed to verify account for transaction search", "id", query.AccountID, "error", err)
+			return nil, err
+		}
+	}
+
+	transactions, err := s.repository.SearchTransactions(ctx, query)
+	if err != nil {
+		// The search text may contain sensitive notes; never log it.
+		logger.Error("Failed to search transactions", "account_id", query.AccountID, "error", err)
 		return nil, err
 	}
 
@@ -278,11 +1199,25 @@ func (s *ledgerService) Reconcile(ctx context.Context) (*ReconciliationResponse,
 		)
 	}
 
+	if !allConsistent || !ledgerBalanced {
+		drift := ReconciliationDriftDetectedEvent{
+			TotalDebits:    totalDebits,
+			TotalCredits:   totalCredits,
+			LedgerBalanced: ledgerBalanced,
+		}
+		for _, r := range results {
+			if !r.IsConsistent {
+				drift.Accounts = append(drift.Accounts, r)
+			}
+		}
+		s.publish(ctx, events.Event{Type: EventReconciliationDriftDetected, Data: drift})
+	}
+
 	return &ReconciliationResponse{
 		Accounts:       results,
 		AllConsistent:  allConsistent && ledgerBalanced,
-		TotalDebits:   totalDebits,
-		TotalCredits:  totalCredits,
+		TotalDebits:    totalDebits,
+		TotalCredits:   totalCredits,
 		LedgerBalanced: ledgerBalanced,
 	}, nil
 }
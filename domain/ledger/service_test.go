@@ -1,14 +1,22 @@
 package ledger
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/akeren/go-api-foundry/internal/log"
 	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/distlock"
 	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/fx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
@@ -18,7 +26,7 @@ func newTestService(t *testing.T) (*MockLedgerRepository, LedgerService) {
 	t.Cleanup(ctrl.Finish)
 	mockRepo := NewMockLedgerRepository(ctrl)
 	logger := log.NewLoggerWithJSONOutput()
-	service := NewLedgerService(logger, mockRepo)
+	service := NewLedgerService(logger, mockRepo, Pricing{}, nil)
 	return mockRepo, service
 }
 
@@ -82,6 +90,120 @@ func TestCreateAccount(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, result)
 	})
+
+	t.Run("dry run does not persist", func(t *testing.T) {
+		_, service := newTestService(t)
+
+		result, err := service.CreateAccount(context.Background(), &CreateAccountRequest{Name: "Alice", DryRun: true})
+		assert.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Empty(t, result.ID)
+		assert.Equal(t, "USD", result.Currency)
+	})
+}
+
+func TestCreateSubAccount(t *testing.T) {
+	parentID := "6f1c1d7e-1b8a-4e5a-9d3c-2f0b7c1a9e01"
+
+	t.Run("inherits the parent currency", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), parentID).Return(
+			&models.Account{ID: parentID, AccountType: models.AccountTypeUser, Currency: "EUR"}, nil)
+		mockRepo.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, acc *models.Account) (*models.Account, error) {
+				assert.Equal(t, "EUR", acc.Currency)
+				assert.Equal(t, parentID, *acc.ParentID)
+				assert.Equal(t, models.SiblingTransfersAllow, acc.SiblingTransfers)
+				return acc, nil
+			},
+		)
+
+		result, err := service.CreateAccount(context.Background(), &CreateAccountRequest{Name: "Store 1", ParentID: parentID})
+		assert.NoError(t, err)
+		assert.Equal(t, parentID, result.ParentID)
+	})
+
+	t.Run("currency must match the parent", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), parentID).Return(
+			&models.Account{ID: parentID, AccountType: models.AccountTypeUser, Currency: "EUR"}, nil)
+
+		_, err := service.CreateAccount(context.Background(), &CreateAccountRequest{Name: "Store 1", Currency: "USD", ParentID: parentID})
+		assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	})
+
+	t.Run("unknown parent", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), parentID).Return(nil, ErrAccountNotFound)
+
+		_, err := service.CreateAccount(context.Background(), &CreateAccountRequest{Name: "Store 1", ParentID: parentID})
+		assert.ErrorIs(t, err, ErrParentAccountNotFound)
+	})
+
+	t.Run("hierarchy depth is limited", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		// A chain of maxAccountDepth accounts; the parent is the deepest one.
+		ids := make([]string, maxAccountDepth)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("acc-%d", i)
+		}
+		for i := len(ids) - 1; i >= 0; i-- {
+			acc := &models.Account{ID: ids[i], AccountType: models.AccountTypeUser, Currency: "USD"}
+			if i > 0 {
+				acc.ParentID = &ids[i-1]
+			}
+			mockRepo.EXPECT().GetAccountByID(gomock.Any(), ids[i]).Return(acc, nil).MaxTimes(1)
+		}
+
+		_, err := service.CreateAccount(context.Background(), &CreateAccountRequest{Name: "Too deep", ParentID: ids[len(ids)-1]})
+		assert.ErrorIs(t, err, ErrHierarchyTooDeep)
+	})
+}
+
+func TestGetAggregateBalance(t *testing.T) {
+	mockRepo, service := newTestService(t)
+
+	root, store1, store2 := "root", "store-1", "store-2"
+	mockRepo.EXPECT().GetAccountSubtree(gomock.Any(), root, maxAccountDepth).Return([]models.Account{
+		{ID: root, Currency: "USD", Balance: 100},
+		{ID: store1, ParentID: &root, Name: "Store 1", Balance: 200},
+		{ID: store2, ParentID: &root, Name: "Store 2", Balance: 300},
+		{ID: "till-1", ParentID: &store1, Name: "Till 1", Balance: 50},
+	}, nil)
+
+	result, err := service.GetAggregateBalance(context.Background(), root)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), result.Balance)
+	assert.Equal(t, int64(650), result.TotalBalance)
+	assert.Equal(t, 3, result.SubAccountCount)
+	assert.Equal(t, []SubAccountBalance{
+		{AccountID: store1, Name: "Store 1", Balance: 200, TotalBalance: 250, SubAccountCount: 1},
+		{AccountID: store2, Name: "Store 2", Balance: 300, TotalBalance: 300, SubAccountCount: 0},
+	}, result.Children)
+}
+
+func TestUpdateAccount(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().UpdateSiblingTransfers(gomock.Any(), "acc-1", models.SiblingTransfersDeny).Return(
+			&models.Account{ID: "acc-1", SiblingTransfers: models.SiblingTransfersDeny}, nil)
+
+		result, err := service.UpdateAccount(context.Background(), "acc-1", &UpdateAccountRequest{SiblingTransfers: models.SiblingTransfersDeny})
+		assert.NoError(t, err)
+		assert.Equal(t, models.SiblingTransfersDeny, result.SiblingTransfers)
+	})
+
+	t.Run("system account", func(t *testing.T) {
+		_, service := newTestService(t)
+
+		_, err := service.UpdateAccount(context.Background(), models.SystemAccountID, &UpdateAccountRequest{SiblingTransfers: models.SiblingTransfersDeny})
+		assert.ErrorIs(t, err, ErrSystemAccountForbidden)
+	})
 }
 
 func TestGetAccount(t *testing.T) {
@@ -326,6 +448,590 @@ func TestTransfer(t *testing.T) {
 	}
 }
 
+func TestSplitTransfer(t *testing.T) {
+	t.Run("allocates fixed amounts then percentages", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		req := &SplitTransferRequest{
+			SourceAccountID: "acc-1",
+			Amount:          1001,
+			IdempotencyKey:  "split-1",
+			Destinations: []SplitDestination{
+				{AccountID: "acc-2", Amount: 1},
+				{AccountID: "acc-3", BasisPoints: 3333},
+				{AccountID: "acc-4", BasisPoints: 6667},
+			},
+		}
+
+		mockRepo.EXPECT().ExecuteSplitTransfer(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, cmd SplitTransferCommand) (*models.Transaction, error) {
+				// 1000 shared at 33.33% and 66.67% leaves 333 and 666, and
+				// the unit left over goes to the first percentage.
+				assert.Equal(t, []SplitLeg{{"acc-2", 1}, {"acc-3", 334}, {"acc-4", 666}}, cmd.Legs)
+				txn := &models.Transaction{ID: "txn-1", TransactionType: models.TransactionTypeSplitTransfer, Amount: 1001, CreatedAt: time.Now()}
+				txn.Entries = append(txn.Entries, models.LedgerEntry{AccountID: "acc-1", EntryType: models.EntryTypeDebit, Amount: 1001})
+				for _, leg := range cmd.Legs {
+					txn.Entries = append(txn.Entries, models.LedgerEntry{ID: "entry-" + leg.DestAccountID, AccountID: leg.DestAccountID, EntryType: models.EntryTypeCredit, Amount: leg.Amount})
+				}
+				return txn, nil
+			},
+		)
+
+		result, err := service.SplitTransfer(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Len(t, result.Destinations, 3)
+		assert.Equal(t, int64(334), result.Destinations[1].Amount)
+		assert.Equal(t, int64(3333), result.Destinations[1].BasisPoints)
+		assert.Equal(t, "entry-acc-3", result.Destinations[1].Entry.ID)
+	})
+
+	t.Run("charges the fee once", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := NewMockLedgerRepository(ctrl)
+		service := NewLedgerService(log.NewLoggerWithJSONOutput(), mockRepo, Pricing{Fees: FeeSchedule{Fixed: 30}}, nil)
+
+		mockRepo.EXPECT().ExecuteSplitTransfer(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, cmd SplitTransferCommand) (*models.Transaction, error) {
+				assert.Equal(t, int64(30), cmd.Fee)
+				return &models.Transaction{ID: "txn-1", CreatedAt: time.Now()}, nil
+			},
+		)
+
+		_, err := service.SplitTransfer(context.Background(), &SplitTransferRequest{
+			SourceAccountID: "acc-1", Amount: 100, IdempotencyKey: "split-2",
+			Destinations: []SplitDestination{{AccountID: "acc-2", Amount: 40}, {AccountID: "acc-3", Amount: 60}},
+		})
+		assert.NoError(t, err)
+	})
+
+	split := func(amount int64, destinations ...SplitDestination) *SplitTransferRequest {
+		return &SplitTransferRequest{SourceAccountID: "acc-1", Amount: amount, IdempotencyKey: "k", Destinations: destinations}
+	}
+	validationTests := []struct {
+		name    string
+		req     *SplitTransferRequest
+		wantErr error
+	}{
+		{"nil request", nil, nil},
+		{"fixed amounts short of the amount", split(100, SplitDestination{AccountID: "acc-2", Amount: 40}, SplitDestination{AccountID: "acc-3", Amount: 50}), ErrInvalidSplit},
+		{"fixed amounts over the amount", split(100, SplitDestination{AccountID: "acc-2", Amount: 100}, SplitDestination{AccountID: "acc-3", BasisPoints: 10000}), ErrInvalidSplit},
+		{"percentages under 100%", split(100, SplitDestination{AccountID: "acc-2", BasisPoints: 5000}, SplitDestination{AccountID: "acc-3", BasisPoints: 4000}), ErrInvalidSplit},
+		{"percentage share of zero", split(100, SplitDestination{AccountID: "acc-2", BasisPoints: 9999}, SplitDestination{AccountID: "acc-3", BasisPoints: 1}), ErrInvalidAmount},
+		{"both amount and percentage", split(100, SplitDestination{AccountID: "acc-2", Amount: 50, BasisPoints: 5000}, SplitDestination{AccountID: "acc-3", Amount: 50}), nil},
+		{"neither amount nor percentage", split(100, SplitDestination{AccountID: "acc-2"}, SplitDestination{AccountID: "acc-3", Amount: 100}), nil},
+		{"duplicate destination", split(100, SplitDestination{AccountID: "acc-2", Amount: 50}, SplitDestination{AccountID: "acc-2", Amount: 50}), ErrDuplicateDestination},
+		{"source as destination", split(100, SplitDestination{AccountID: "acc-1", Amount: 50}, SplitDestination{AccountID: "acc-2", Amount: 50}), ErrSelfTransfer},
+		{"system account as destination", split(100, SplitDestination{AccountID: models.SystemAccountID, Amount: 50}, SplitDestination{AccountID: "acc-2", Amount: 50}), ErrSystemAccountForbidden},
+	}
+
+	for _, tt := range validationTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, service := newTestService(t)
+			result, err := service.SplitTransfer(context.Background(), tt.req)
+			assert.Error(t, err)
+			assert.Nil(t, result)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReverseTransaction(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		original := "txn-1"
+		expectedTxn := &models.Transaction{
+			ID:                    "txn-2",
+			TransactionType:       models.TransactionTypeReversal,
+			Amount:                5000,
+			Fee:                   50,
+			Currency:              "USD",
+			ReversedTransactionID: &original,
+			CreatedAt:             time.Now(),
+		}
+
+		mockRepo.EXPECT().ReverseTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, cmd ReversalCommand) (*Reversal, error) {
+				assert.Equal(t, "txn-1", cmd.TransactionID)
+				assert.Equal(t, "rev-1", cmd.IdempotencyKey)
+				return &Reversal{Transaction: expectedTxn, SourceAccountID: "acc-2", DestAccountID: "acc-1"}, nil
+			},
+		)
+
+		result, err := service.ReverseTransaction(context.Background(), "txn-1", &ReverseTransactionRequest{IdempotencyKey: "rev-1"})
+		assert.NoError(t, err)
+		assert.Equal(t, models.TransactionTypeReversal, result.TransactionType)
+		assert.Equal(t, "txn-1", result.ReversedTransactionID)
+	})
+
+	tests := []struct {
+		name    string
+		repoErr error
+	}{
+		{name: "transaction not found", repoErr: ErrTransactionNotFound},
+		{name: "already reversed", repoErr: ErrAlreadyReversed},
+		{name: "reversal of a reversal", repoErr: ErrReversalNotReversible},
+		{name: "insufficient funds", repoErr: ErrInsufficientFunds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo, service := newTestService(t)
+			mockRepo.EXPECT().ReverseTransaction(gomock.Any(), gomock.Any()).Return(nil, tt.repoErr)
+
+			result, err := service.ReverseTransaction(context.Background(), "txn-1", &ReverseTransactionRequest{IdempotencyKey: "rev-1"})
+			assert.ErrorIs(t, err, tt.repoErr)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestHolds(t *testing.T) {
+	t.Run("create expires after the hold TTL", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().CreateHold(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, cmd HoldCommand) (*models.Hold, error) {
+				assert.Equal(t, "acc-1", cmd.AccountID)
+				assert.Equal(t, int64(2500), cmd.Amount)
+				assert.WithinDuration(t, time.Now().Add(defaultHoldTTL), cmd.ExpiresAt, time.Minute)
+				return &models.Hold{ID: "hold-1", AccountID: cmd.AccountID, Amount: cmd.Amount, Status: models.HoldStatusActive, ExpiresAt: cmd.ExpiresAt}, nil
+			},
+		)
+
+		result, err := service.CreateHold(context.Background(), "acc-1", &CreateHoldRequest{Amount: 2500, IdempotencyKey: "hold-1"})
+		assert.NoError(t, err)
+		assert.Equal(t, models.HoldStatusActive, result.Status)
+	})
+
+	t.Run("create rejects system accounts and self transfers", func(t *testing.T) {
+		_, service := newTestService(t)
+
+		_, err := service.CreateHold(context.Background(), models.SystemAccountID, &CreateHoldRequest{Amount: 100, IdempotencyKey: "h"})
+		assert.ErrorIs(t, err, ErrSystemAccountForbidden)
+		_, err = service.CreateHold(context.Background(), "acc-1", &CreateHoldRequest{Amount: 100, DestAccountID: "acc-1", IdempotencyKey: "h"})
+		assert.ErrorIs(t, err, ErrSelfTransfer)
+	})
+
+	t.Run("capture without a destination withdraws the whole hold", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetHold(gomock.Any(), "hold-1").Return(&models.Hold{ID: "hold-1", AccountID: "acc-1", Amount: 2500, Currency: "USD", Status: models.HoldStatusActive}, nil)
+		mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, cmd DoubleEntryCommand) (*models.Transaction, error) {
+				assert.Equal(t, "hold-1", cmd.HoldID)
+				assert.Equal(t, models.SystemAccountID, cmd.DestAccountID)
+				assert.Equal(t, models.TransactionTypeWithdrawal, cmd.TransactionType)
+				assert.Equal(t, int64(2500), cmd.Amount)
+				return &models.Transaction{ID: "txn-1", Amount: cmd.Amount, CreatedAt: time.Now()}, nil
+			},
+		)
+
+		result, err := service.CaptureHold(context.Background(), "hold-1", &CaptureHoldRequest{IdempotencyKey: "cap-1"})
+		assert.NoError(t, err)
+		assert.Equal(t, models.HoldStatusCaptured, result.Status)
+		assert.Equal(t, "txn-1", result.TransactionID)
+		assert.Equal(t, int64(2500), result.CapturedAmount)
+	})
+
+	t.Run("partial capture to a destination is a transfer", func(t *testing.T) {
+		mockRepo := NewMockLedgerRepository(gomock.NewController(t))
+		service := NewLedgerService(log.NewLoggerWithJSONOutput(), mockRepo, Pricing{Fees: FeeSchedule{Fixed: 25}}, nil)
+		dest := "acc-2"
+
+		mockRepo.EXPECT().GetHold(gomock.Any(), "hold-1").Return(&models.Hold{ID: "hold-1", AccountID: "acc-1", DestAccountID: &dest, Amount: 2500, Currency: "USD", Status: models.HoldStatusActive}, nil)
+		mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, cmd DoubleEntryCommand) (*models.Transaction, error) {
+				assert.Equal(t, "acc-2", cmd.DestAccountID)
+				assert.Equal(t, models.TransactionTypeTransfer, cmd.TransactionType)
+				assert.Equal(t, int64(1000), cmd.Amount)
+				assert.Equal(t, int64(25), cmd.Fee)
+				return &models.Transaction{ID: "txn-1", Amount: cmd.Amount, CreatedAt: time.Now()}, nil
+			},
+		)
+
+		result, err := service.CaptureHold(context.Background(), "hold-1", &CaptureHoldRequest{Amount: 1000, IdempotencyKey: "cap-1"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1000), result.CapturedAmount)
+	})
+
+	t.Run("capture of an expired hold", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetHold(gomock.Any(), "hold-1").Return(&models.Hold{ID: "hold-1", AccountID: "acc-1", Amount: 2500, Status: models.HoldStatusActive}, nil)
+		mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).Return(nil, ErrHoldExpired)
+
+		result, err := service.CaptureHold(context.Background(), "hold-1", &CaptureHoldRequest{IdempotencyKey: "cap-1"})
+		assert.ErrorIs(t, err, ErrHoldExpired)
+		assert.Nil(t, result)
+	})
+
+	t.Run("available balance excludes held funds", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetBalanceSnapshot(gomock.Any(), "acc-1").Return(&BalanceSnapshot{AccountID: "acc-1", CachedBalance: 10000, DerivedBalance: 10000, HeldBalance: 2500}, nil)
+
+		result, err := service.GetBalance(context.Background(), "acc-1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2500), result.HeldBalance)
+		assert.Equal(t, int64(7500), result.AvailableBalance)
+	})
+
+	t.Run("balance version changes with held funds", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetBalanceVersion(gomock.Any(), "acc-1").Return(&BalanceVersion{Version: 3}, nil)
+		mockRepo.EXPECT().GetBalanceVersion(gomock.Any(), "acc-1").Return(&BalanceVersion{Version: 3, HeldBalance: 2500}, nil)
+
+		before, err := service.GetBalanceVersion(context.Background(), "acc-1")
+		assert.NoError(t, err)
+		after, err := service.GetBalanceVersion(context.Background(), "acc-1")
+		assert.NoError(t, err)
+		assert.NotEqual(t, before, after)
+	})
+}
+
+func TestHoldSweeper(t *testing.T) {
+	mockRepo, _ := newTestService(t)
+	swept := make(chan struct{})
+	mockRepo.EXPECT().ExpireHolds(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, now time.Time) (int64, error) {
+			assert.WithinDuration(t, time.Now(), now, time.Second)
+			select {
+			case swept <- struct{}{}:
+			default:
+			}
+			return 1, nil
+		},
+	).MinTimes(1)
+
+	sweeper := NewHoldSweeper(log.NewLoggerWithJSONOutput(), mockRepo, 10*time.Millisecond, nil)
+	sweeper.Start()
+	select {
+	case <-swept:
+	case <-time.After(time.Second):
+		t.Fatal("no sweep within a second")
+	}
+	sweeper.Stop()
+}
+
+func TestHoldSweeper_OneInstanceAtATime(t *testing.T) {
+	mockRepo, _ := newTestService(t)
+	locks := distlock.NewMemoryLocker()
+	first := NewHoldSweeper(log.NewLoggerWithJSONOutput(), mockRepo, time.Minute, locks)
+	second := NewHoldSweeper(log.NewLoggerWithJSONOutput(), mockRepo, time.Minute, locks)
+
+	mockRepo.EXPECT().ExpireHolds(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ time.Time) (int64, error) {
+			// The other instance skips the sweep while this one runs it.
+			expired, err := second.Sweep(ctx)
+			assert.NoError(t, err)
+			assert.Zero(t, expired)
+			return 2, nil
+		},
+	)
+	expired, err := first.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), expired)
+
+	mockRepo.EXPECT().ExpireHolds(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	expired, err = second.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), expired, "expected the lock to be free after the first sweep")
+}
+
+func TestPartitionMaintainer(t *testing.T) {
+	mockRepo, _ := newTestService(t)
+	ran := make(chan struct{}, 1)
+	mockRepo.EXPECT().EnsureEntryPartitions(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, from, through time.Time) (int, error) {
+			assert.WithinDuration(t, time.Now(), from, time.Second)
+			assert.Equal(t, from.AddDate(0, partitionMonthsAhead, 0), through)
+			select {
+			case ran <- struct{}{}:
+			default:
+			}
+			return 0, nil
+		},
+	).MinTimes(1)
+
+	// The first run is immediate, well before the interval.
+	maintainer := NewPartitionMaintainer(log.NewLoggerWithJSONOutput(), mockRepo, time.Hour)
+	maintainer.Start()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("no run within a second")
+	}
+	maintainer.Stop()
+}
+
+func TestArchiver(t *testing.T) {
+	mockRepo, _ := newTestService(t)
+	retention := 90 * 24 * time.Hour
+
+	// Batches run until one moves nothing.
+	gomock.InOrder(
+		mockRepo.EXPECT().ArchiveTransactions(gomock.Any(), gomock.Any(), archiveBatchSize).DoAndReturn(
+			func(_ context.Context, before time.Time, _ int) (int, error) {
+				assert.WithinDuration(t, time.Now().Add(-retention), before, time.Second)
+				return archiveBatchSize, nil
+			}),
+		mockRepo.EXPECT().ArchiveTransactions(gomock.Any(), gomock.Any(), archiveBatchSize).Return(3, nil),
+		mockRepo.EXPECT().ArchiveTransactions(gomock.Any(), gomock.Any(), archiveBatchSize).Return(0, nil),
+	)
+
+	archiver := NewArchiver(log.NewLoggerWithJSONOutput(), mockRepo, ArchiveConfig{Retention: retention})
+	moved, err := archiver.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, archiveBatchSize+3, moved)
+
+	mockRepo.EXPECT().ArchiveTransactions(gomock.Any(), gomock.Any(), archiveBatchSize).Return(0, apperrors.NewDatabaseError("failed to archive transactions", nil))
+	_, err = archiver.Run(context.Background())
+	assert.Error(t, err)
+}
+
+func TestFeeSchedule(t *testing.T) {
+	tests := []struct {
+		name   string
+		fees   FeeSchedule
+		amount int64
+		want   int64
+	}{
+		{"zero schedule", FeeSchedule{}, 1000, 0},
+		{"fixed only", FeeSchedule{Fixed: 25}, 1000, 25},
+		{"percentage and fixed", FeeSchedule{BasisPoints: 100, Fixed: 25}, 1000, 35},
+		{"rounds half up", FeeSchedule{BasisPoints: 150}, 100, 2},
+		{"rounds down below half", FeeSchedule{BasisPoints: 150}, 33, 0},
+		{"large amount does not overflow", FeeSchedule{BasisPoints: 10000}, 1 << 62, 1 << 62},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.fees.Fee(tt.amount))
+		})
+	}
+}
+
+func TestQuoteTransfer(t *testing.T) {
+	newPricedService := func(t *testing.T) (*MockLedgerRepository, LedgerService) {
+		t.Helper()
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+		mockRepo := NewMockLedgerRepository(ctrl)
+		pricing := Pricing{Fees: FeeSchedule{BasisPoints: 100, Fixed: 25}, QuoteTTL: time.Minute}
+		return mockRepo, NewLedgerService(log.NewLoggerWithJSONOutput(), mockRepo, pricing, nil)
+	}
+	source := &models.Account{ID: "acc-1", AccountType: models.AccountTypeUser, Currency: "USD", Balance: 5000}
+	dest := &models.Account{ID: "acc-2", AccountType: models.AccountTypeUser, Currency: "USD", Balance: 100}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo, service := newPricedService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(source, nil)
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-2").Return(dest, nil)
+		mockRepo.EXPECT().CreateTransferQuote(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, quote *models.TransferQuote) (*models.TransferQuote, error) {
+				assert.Equal(t, int64(45), quote.Fee)
+				assert.WithinDuration(t, time.Now().Add(time.Minute), quote.ExpiresAt, 5*time.Second)
+				quote.ID = "quote-1"
+				return quote, nil
+			},
+		)
+
+		result, err := service.QuoteTransfer(context.Background(), &TransferQuoteRequest{
+			SourceAccountID: "acc-1",
+			DestAccountID:   "acc-2",
+			Amount:          2000,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "quote-1", result.QuoteID)
+		assert.Equal(t, int64(45), result.Fee)
+		assert.Equal(t, int64(2045), result.TotalDebit)
+		assert.Equal(t, "1", result.ExchangeRate)
+		assert.Equal(t, int64(2955), result.SourceBalanceAfter)
+		assert.Equal(t, int64(2100), result.DestBalanceAfter)
+	})
+
+	t.Run("fee counts towards insufficient funds", func(t *testing.T) {
+		mockRepo, service := newPricedService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(source, nil)
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-2").Return(dest, nil)
+
+		_, err := service.QuoteTransfer(context.Background(), &TransferQuoteRequest{
+			SourceAccountID: "acc-1",
+			DestAccountID:   "acc-2",
+			Amount:          4950,
+		})
+		assert.ErrorIs(t, err, ErrInsufficientFunds)
+	})
+
+	t.Run("currency mismatch", func(t *testing.T) {
+		mockRepo, service := newPricedService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(source, nil)
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-2").Return(dest, nil)
+
+		_, err := service.QuoteTransfer(context.Background(), &TransferQuoteRequest{
+			SourceAccountID: "acc-1",
+			DestAccountID:   "acc-2",
+			Amount:          100,
+			Currency:        "EUR",
+		})
+		assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	})
+
+	eur := &models.Account{ID: "acc-3", AccountType: models.AccountTypeUser, Currency: "EUR", Balance: 100}
+
+	t.Run("cross-currency quote converts at the current rate", func(t *testing.T) {
+		mockRepo, service := newPricedService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(source, nil)
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-3").Return(eur, nil)
+		mockRepo.EXPECT().GetExchangeRate(gomock.Any(), "USD", "EUR", 24*time.Hour).Return(
+			&fx.Rate{Base: "USD", Quote: "EUR", Value: "0.925"}, nil)
+		mockRepo.EXPECT().CreateTransferQuote(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, quote *models.TransferQuote) (*models.TransferQuote, error) {
+				assert.Equal(t, "0.925", quote.ExchangeRate)
+				quote.ID = "quote-2"
+				return quote, nil
+			},
+		)
+
+		result, err := service.QuoteTransfer(context.Background(), &TransferQuoteRequest{
+			SourceAccountID: "acc-1",
+			DestAccountID:   "acc-3",
+			Amount:          2000,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(45), result.Fee)
+		assert.Equal(t, "0.925", result.ExchangeRate)
+		assert.Equal(t, int64(1850), result.DestAmount)
+		assert.Equal(t, int64(1950), result.DestBalanceAfter)
+	})
+
+	t.Run("cross-currency quote without a current rate", func(t *testing.T) {
+		mockRepo, service := newPricedService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(source, nil)
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-3").Return(eur, nil)
+		mockRepo.EXPECT().GetExchangeRate(gomock.Any(), "USD", "EUR", gomock.Any()).Return(nil, ErrExchangeRateNotFound)
+
+		_, err := service.QuoteTransfer(context.Background(), &TransferQuoteRequest{
+			SourceAccountID: "acc-1",
+			DestAccountID:   "acc-3",
+			Amount:          100,
+		})
+		assert.ErrorIs(t, err, ErrExchangeRateNotFound)
+		code, _ := mapDomainError(err)
+		assert.Equal(t, 422, code)
+	})
+
+	t.Run("transfer charges the current fee and passes the quote", func(t *testing.T) {
+		mockRepo, service := newPricedService(t)
+
+		mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, cmd DoubleEntryCommand) (*models.Transaction, error) {
+				assert.Equal(t, int64(45), cmd.Fee)
+				assert.Equal(t, "quote-1", cmd.QuoteID)
+				return &models.Transaction{ID: "txn-1", Amount: cmd.Amount, Fee: cmd.Fee}, nil
+			},
+		)
+
+		result, err := service.Transfer(context.Background(), &TransferRequest{
+			SourceAccountID: "acc-1",
+			DestAccountID:   "acc-2",
+			Amount:          2000,
+			IdempotencyKey:  "xfr-q",
+			QuoteID:         "quote-1",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(45), result.Fee)
+	})
+}
+
+func TestTransferBatch(t *testing.T) {
+	t.Run("records per-item outcomes", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		transfers := []TransferRequest{
+			{SourceAccountID: "acc-1", DestAccountID: "acc-2", Amount: 1000, IdempotencyKey: "b-1"},
+			{SourceAccountID: "acc-1", DestAccountID: "acc-1", Amount: 1000, IdempotencyKey: "b-2"},
+			{SourceAccountID: "acc-1", DestAccountID: "acc-2", Amount: 9000, IdempotencyKey: "b-3"},
+		}
+
+		gomock.InOrder(
+			mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).Return(&models.Transaction{ID: "txn-1", CreatedAt: time.Now()}, nil),
+			mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).Return(nil, ErrInsufficientFunds),
+		)
+
+		var progress []int
+		result, err := service.TransferBatch(context.Background(), transfers, func(done int) {
+			progress = append(progress, done)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Succeeded)
+		assert.Equal(t, 2, result.Failed)
+		assert.Equal(t, []int{1, 2, 3}, progress)
+		assert.Equal(t, "txn-1", result.Items[0].TransactionID)
+		assert.Equal(t, ErrSelfTransfer.Error(), result.Items[1].Error)
+		assert.Equal(t, ErrInsufficientFunds.Error(), result.Items[2].Error)
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		_, service := newTestService(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := service.TransferBatch(ctx, []TransferRequest{{SourceAccountID: "acc-1", DestAccountID: "acc-2", Amount: 1, IdempotencyKey: "b-1"}}, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, result)
+	})
+}
+
+func TestImportTransfersCSV(t *testing.T) {
+	t.Run("reports row outcomes", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		csv := "source_account_id,dest_account_id,amount,idempotency_key,description\n" +
+			"acc-1,acc-2,1500,imp-1,rent\n" +
+			"acc-1,acc-2,lots,imp-2,\n" +
+			"acc-1,acc-2\n" +
+			"acc-1,acc-2,900,imp-4,fees\n"
+
+		gomock.InOrder(
+			mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, cmd DoubleEntryCommand) (*models.Transaction, error) {
+					assert.Equal(t, int64(1500), cmd.Amount)
+					assert.Equal(t, "imp-1", cmd.IdempotencyKey)
+					return &models.Transaction{ID: "txn-1", CreatedAt: time.Now()}, nil
+				},
+			),
+			mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).Return(nil, ErrInsufficientFunds),
+		)
+
+		result, err := service.ImportTransfersCSV(context.Background(), strings.NewReader(csv))
+		assert.NoError(t, err)
+		assert.Equal(t, 4, result.Rows)
+		assert.Equal(t, 1, result.Succeeded)
+		assert.Equal(t, 3, result.Failed)
+		assert.Equal(t, []int{3, 4, 5}, []int{result.Errors[0].Line, result.Errors[1].Line, result.Errors[2].Line})
+		assert.Equal(t, ErrInsufficientFunds.Error(), result.Errors[2].Error)
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		_, service := newTestService(t)
+
+		result, err := service.ImportTransfersCSV(context.Background(), strings.NewReader("source_account_id,amount\nacc-1,100\n"))
+		assert.ErrorContains(t, err, "dest_account_id")
+		assert.Nil(t, result)
+	})
+}
+
 func TestGetBalance(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockRepo, service := newTestService(t)
@@ -372,6 +1078,440 @@ func TestGetBalance(t *testing.T) {
 	})
 }
 
+func TestGetDailyBalances(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(&models.Account{ID: "acc-1", Currency: "USD"}, nil)
+		mockRepo.EXPECT().GetDailyBalances(gomock.Any(), "acc-1", "2026-10-01", "2026-10-31").Return([]DailyBalance{
+			{Day: "2026-10-02", Credits: 10000, EntryCount: 1, ClosingBalance: 10000},
+			{Day: "2026-10-05", Debits: 2500, EntryCount: 2, ClosingBalance: 7500},
+		}, nil)
+
+		result, err := service.GetDailyBalances(context.Background(), "acc-1", from, to)
+		assert.NoError(t, err)
+		assert.Equal(t, "USD", result.Currency)
+		assert.Equal(t, "2026-10-01", result.From)
+		assert.Equal(t, "2026-10-31", result.To)
+		assert.Len(t, result.Days, 2)
+		assert.Equal(t, DailyBalanceResponse{Date: "2026-10-05", Debits: 2500, EntryCount: 2, ClosingBalance: 7500}, result.Days[1])
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "missing").Return(nil, ErrAccountNotFound)
+
+		_, err := service.GetDailyBalances(context.Background(), "missing", from, to)
+		assert.ErrorIs(t, err, ErrAccountNotFound)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, service := newTestService(t)
+
+		_, err := service.GetDailyBalances(context.Background(), "acc-1", to, from)
+		assert.Error(t, err)
+		_, err = service.GetDailyBalances(context.Background(), "acc-1", from, from.AddDate(0, 0, 366))
+		assert.Error(t, err)
+	})
+}
+
+func TestGetStatement(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("csv with running balance", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(&models.Account{ID: "acc-1", Name: "Main", Currency: "USD"}, nil)
+		mockRepo.EXPECT().GetBalanceBefore(gomock.Any(), "acc-1", from).Return(int64(1000), nil)
+		mockRepo.EXPECT().StreamStatementEntries(gomock.Any(), "acc-1", from, to.AddDate(0, 0, 1), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _, _ time.Time, fn func(StatementEntry) error) error {
+				at := time.Date(2026, 10, 2, 9, 30, 0, 0, time.UTC)
+				if err := fn(StatementEntry{TransactionID: "txn-1", TransactionType: "DEPOSIT", Description: "Salary", EntryType: models.EntryTypeCredit, Amount: 12550, CreatedAt: at}); err != nil {
+					return err
+				}
+				return fn(StatementEntry{TransactionID: "txn-2", TransactionType: "WITHDRAWAL", Description: "=HYPERLINK(1)", EntryType: models.EntryTypeDebit, Amount: 50, CreatedAt: at})
+			})
+
+		statement, err := service.GetStatement(context.Background(), "acc-1", from, to)
+		assert.NoError(t, err)
+		assert.Equal(t, "statement-acc-1-2026-10-01-2026-10-31.csv", statement.Filename(StatementFormatCSV))
+
+		var out strings.Builder
+		assert.NoError(t, statement.WriteCSV(context.Background(), &out))
+		assert.Equal(t, "date,transaction_id,transaction_type,description,debit,credit,balance,currency\n"+
+			"2026-10-02T09:30:00Z,txn-1,DEPOSIT,Salary,,125.50,135.50,USD\n"+
+			"2026-10-02T09:30:00Z,txn-2,WITHDRAWAL,'=HYPERLINK(1),0.50,,135.00,USD\n", out.String())
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "missing").Return(nil, ErrAccountNotFound)
+
+		_, err := service.GetStatement(context.Background(), "missing", from, to)
+		assert.ErrorIs(t, err, ErrAccountNotFound)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, service := newTestService(t)
+
+		_, err := service.GetStatement(context.Background(), "acc-1", to, from)
+		assert.Error(t, err)
+	})
+}
+
+func TestBalanceAlerts(t *testing.T) {
+	t.Run("creates an alert on a user account", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(&models.Account{ID: "acc-1"}, nil)
+		mockRepo.EXPECT().CreateBalanceAlert(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, alert *models.BalanceAlert) (*models.BalanceAlert, error) {
+				alert.ID = "alert-1"
+				return alert, nil
+			},
+		)
+
+		resp, err := service.CreateBalanceAlert(context.Background(), "acc-1", &CreateBalanceAlertRequest{
+			Kind: models.AlertKindLowBalance, Threshold: 1000, NotifyEmail: "owner@example.com",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "alert-1", resp.ID)
+		assert.Equal(t, "owner@example.com", resp.NotifyEmail)
+	})
+
+	t.Run("rejects the system account and missing accounts", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+		req := &CreateBalanceAlertRequest{Kind: models.AlertKindLargeDebit, Threshold: 1000}
+
+		_, err := service.CreateBalanceAlert(context.Background(), models.SystemAccountID, req)
+		assert.ErrorIs(t, err, ErrSystemAccountForbidden)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "missing").Return(nil, ErrAccountNotFound)
+		_, err = service.CreateBalanceAlert(context.Background(), "missing", req)
+		assert.ErrorIs(t, err, ErrAccountNotFound)
+	})
+
+	t.Run("deleting another account's alert is not found", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+		mockRepo.EXPECT().DeleteBalanceAlert(gomock.Any(), "acc-2", "alert-1").Return(ErrBalanceAlertNotFound)
+
+		err := service.DeleteBalanceAlert(context.Background(), "acc-2", "alert-1")
+		assert.ErrorIs(t, err, ErrBalanceAlertNotFound)
+		code, _ := mapDomainError(err)
+		assert.Equal(t, 404, code)
+	})
+}
+
+func TestAccrualAmount(t *testing.T) {
+	tests := []struct {
+		name  string
+		basis int64
+		rate  int64
+		want  int64
+	}{
+		{"nothing on a zero rate", 36500000, 0, 0},
+		{"nothing on a negative balance", -36500000, 1000, 0},
+		{"a day of 10% on 365000.00", 36500000, 1000, 10000},
+		{"rounds half up", 183, 10000, 1},
+		{"rounds down below half", 182, 10000, 0},
+		{"large balance does not overflow", 1 << 62, 10000, (1<<62 + daysInYear/2) / daysInYear},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, accrualAmount(tt.basis, tt.rate))
+		})
+	}
+}
+
+func TestAccrualPlans(t *testing.T) {
+	t.Run("starts today by default", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(&models.Account{ID: "acc-1", CreatedAt: time.Now()}, nil)
+		mockRepo.EXPECT().CreateAccrualPlan(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, plan *models.AccrualPlan) (*models.AccrualPlan, error) {
+				plan.ID = "plan-1"
+				return plan, nil
+			},
+		)
+
+		resp, err := service.CreateAccrualPlan(context.Background(), "acc-1", &CreateAccrualPlanRequest{Kind: models.AccrualKindFee, RateBasisPoints: 150})
+		assert.NoError(t, err)
+		assert.Equal(t, "plan-1", resp.ID)
+		assert.Equal(t, utcToday().Format(time.DateOnly), resp.StartsOn)
+		assert.Empty(t, resp.AccruedThrough)
+	})
+
+	t.Run("rejects the system account and days before the account", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+		req := &CreateAccrualPlanRequest{Kind: models.AccrualKindInterest, RateBasisPoints: 100, StartsOn: "2024-01-01"}
+
+		_, err := service.CreateAccrualPlan(context.Background(), models.SystemAccountID, req)
+		assert.ErrorIs(t, err, ErrSystemAccountForbidden)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(&models.Account{ID: "acc-1", CreatedAt: time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)}, nil)
+		_, err = service.CreateAccrualPlan(context.Background(), "acc-1", req)
+		assert.Equal(t, apperrors.ErrorTypeInvalidRequest, apperrors.GetErrorType(err))
+	})
+
+	t.Run("accrues at the old rate before correcting", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+		startsOn := utcToday().AddDate(0, 0, -10)
+		yesterday := utcToday().AddDate(0, 0, -1)
+		plan := &models.AccrualPlan{ID: "plan-1", AccountID: "acc-1", Kind: models.AccrualKindInterest, RateBasisPoints: 100, StartsOn: startsOn}
+		updated := *plan
+		updated.RateBasisPoints = 200
+		updated.AccruedThrough = &yesterday
+
+		gomock.InOrder(
+			mockRepo.EXPECT().GetAccrualPlan(gomock.Any(), "acc-1", "plan-1").Return(plan, nil),
+			// Days up to the change accrue first, at the old rate.
+			mockRepo.EXPECT().AccrueNextDay(gomock.Any(), "plan-1", yesterday.AddDate(0, 0, -1)).Return(nil, nil),
+			mockRepo.EXPECT().UpdateAccrualPlanRate(gomock.Any(), "acc-1", "plan-1", int64(200)).Return(&updated, nil),
+			mockRepo.EXPECT().CorrectAccrual(gomock.Any(), "plan-1", yesterday).Return(&AccrualPosting{
+				Record: &models.AccrualRecord{ID: "record-2", PlanID: "plan-1", Day: yesterday, Revision: 2, Amount: 20},
+			}, nil),
+		)
+
+		rate := int64(200)
+		resp, err := service.UpdateAccrualPlan(context.Background(), "acc-1", "plan-1", &UpdateAccrualPlanRequest{
+			RateBasisPoints: &rate, EffectiveFrom: yesterday.Format(time.DateOnly),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(200), resp.RateBasisPoints)
+		if assert.Len(t, resp.Corrections, 1) {
+			assert.Equal(t, 2, resp.Corrections[0].Revision)
+		}
+	})
+
+	t.Run("rejects changes in the future or before the plan", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+		plan := &models.AccrualPlan{ID: "plan-1", AccountID: "acc-1", StartsOn: utcToday().AddDate(0, 0, -1)}
+		mockRepo.EXPECT().GetAccrualPlan(gomock.Any(), "acc-1", "plan-1").Return(plan, nil).Times(2)
+
+		rate := int64(200)
+		for _, effective := range []string{utcToday().AddDate(0, 0, 1).Format(time.DateOnly), utcToday().AddDate(0, 0, -2).Format(time.DateOnly)} {
+			_, err := service.UpdateAccrualPlan(context.Background(), "acc-1", "plan-1", &UpdateAccrualPlanRequest{RateBasisPoints: &rate, EffectiveFrom: effective})
+			assert.Equal(t, apperrors.ErrorTypeInvalidRequest, apperrors.GetErrorType(err), effective)
+		}
+	})
+
+	t.Run("a failing plan does not stop the others", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+		through := utcToday().AddDate(0, 0, -1)
+		mockRepo.EXPECT().ListDueAccrualPlans(gomock.Any(), through).Return([]models.AccrualPlan{{ID: "plan-1"}, {ID: "plan-2"}}, nil)
+		mockRepo.EXPECT().AccrueNextDay(gomock.Any(), "plan-1", through).Return(nil, ErrInsufficientFunds)
+		mockRepo.EXPECT().AccrueNextDay(gomock.Any(), "plan-2", through).Return(&AccrualPosting{Record: &models.AccrualRecord{}}, nil)
+		mockRepo.EXPECT().AccrueNextDay(gomock.Any(), "plan-2", through).Return(nil, nil)
+
+		accrued, err := service.AccrueDueDays(context.Background(), through)
+		assert.Equal(t, 1, accrued)
+		assert.ErrorIs(t, err, ErrInsufficientFunds)
+	})
+}
+
+func TestSealDays(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	entry := models.LedgerEntry{ID: "entry-1", TransactionID: "txn-1", AccountID: "acc-1", EntryType: models.EntryTypeCredit, Amount: 100, BalanceAfter: 100, CreatedAt: day.Add(time.Hour)}
+	streamEntries := func(entries ...models.LedgerEntry) func(context.Context, time.Time, func(*models.LedgerEntry) error) error {
+		return func(_ context.Context, _ time.Time, fn func(*models.LedgerEntry) error) error {
+			for i := range entries {
+				if err := fn(&entries[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	t.Run("starts at the first entry and chains each day onto the last", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+		var seals []*models.DailySeal
+		mockRepo.EXPECT().GetLatestDailySeal(gomock.Any()).DoAndReturn(func(context.Context) (*models.DailySeal, error) {
+			if len(seals) == 0 {
+				return nil, nil
+			}
+			return seals[len(seals)-1], nil
+		}).Times(3)
+		firstAt := entry.CreatedAt
+		mockRepo.EXPECT().GetFirstEntryTime(gomock.Any()).Return(&firstAt, nil)
+		mockRepo.EXPECT().StreamDayEntries(gomock.Any(), day, gomock.Any()).DoAndReturn(streamEntries(entry))
+		mockRepo.EXPECT().StreamDayEntries(gomock.Any(), day.AddDate(0, 0, 1), gomock.Any()).DoAndReturn(streamEntries())
+		mockRepo.EXPECT().CreateDailySeal(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, seal *models.DailySeal) error {
+			seals = append(seals, seal)
+			return nil
+		}).Times(2)
+
+		sealed, err := service.SealDays(context.Background(), day.AddDate(0, 0, 1))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, sealed)
+		assert.Equal(t, int64(1), seals[0].EntryCount)
+		assert.Empty(t, seals[0].PreviousDigest)
+		assert.Len(t, seals[0].Digest, 64)
+		assert.Equal(t, seals[0].Digest, seals[1].PreviousDigest)
+		assert.Zero(t, seals[1].EntryCount)
+	})
+
+	t.Run("stops when another instance sealed the day", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+		mockRepo.EXPECT().GetLatestDailySeal(gomock.Any()).Return(&models.DailySeal{Day: day.AddDate(0, 0, -1), Digest: "previous"}, nil)
+		mockRepo.EXPECT().StreamDayEntries(gomock.Any(), day, gomock.Any()).DoAndReturn(streamEntries(entry))
+		mockRepo.EXPECT().CreateDailySeal(gomock.Any(), gomock.Any()).Return(ErrConcurrentUpdate)
+
+		sealed, err := service.SealDays(context.Background(), day)
+		assert.NoError(t, err)
+		assert.Zero(t, sealed)
+	})
+
+	t.Run("any change to an entry changes the digest", func(t *testing.T) {
+		digest := func(previous string, entries ...models.LedgerEntry) string {
+			chain := newDayChain(day, previous)
+			for i := range entries {
+				chain.add(&entries[i])
+			}
+			return chain.digest()
+		}
+		changed := entry
+		changed.Amount = 101
+		other := entry
+		other.ID = "entry-2"
+
+		original := digest("", entry)
+		assert.Equal(t, original, digest("", entry))
+		assert.NotEqual(t, original, digest("", changed))
+		assert.NotEqual(t, original, digest("other", entry))
+		assert.NotEqual(t, digest("", entry, other), digest("", other, entry))
+	})
+}
+
+func TestAccountChain(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	account := &models.Account{ID: "acc-1"}
+	entries := []models.LedgerEntry{
+		{TransactionID: "txn-1", AccountID: "acc-1", EntryType: models.EntryTypeCredit, Amount: 500, BalanceAfter: 500, CreatedAt: at},
+		{TransactionID: "txn-2", AccountID: "acc-1", EntryType: models.EntryTypeDebit, Amount: 200, BalanceAfter: 300, CreatedAt: at.Add(time.Minute)},
+		{TransactionID: "txn-2", AccountID: "acc-1", EntryType: models.EntryTypeDebit, Amount: 10, BalanceAfter: 290, CreatedAt: at.Add(time.Minute)},
+	}
+	chainEntries(entries, map[string]*models.Account{"acc-1": account})
+	streamEntries := func(entries ...models.LedgerEntry) func(context.Context, string, func(*models.LedgerEntry) error) error {
+		return func(_ context.Context, _ string, fn func(*models.LedgerEntry) error) error {
+			for i := range entries {
+				if err := fn(&entries[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	verify := func(t *testing.T, last string, entries ...models.LedgerEntry) *AccountChainVerificationResponse {
+		t.Helper()
+		mockRepo, service := newTestService(t)
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(&models.Account{ID: "acc-1", LastEntryHash: last}, nil)
+		mockRepo.EXPECT().StreamAccountEntries(gomock.Any(), "acc-1", gomock.Any()).DoAndReturn(streamEntries(entries...))
+		resp, err := service.VerifyAccountChain(context.Background(), "acc-1")
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("chains each entry onto the account's last", func(t *testing.T) {
+		assert.Empty(t, entries[0].PrevHash)
+		assert.Len(t, entries[0].EntryHash, 64)
+		assert.Equal(t, entries[0].EntryHash, entries[1].PrevHash)
+		assert.Equal(t, entries[1].EntryHash, entries[2].PrevHash)
+		assert.Equal(t, entries[2].EntryHash, account.LastEntryHash)
+		assert.NotEmpty(t, entries[0].ID)
+	})
+
+	t.Run("an intact chain verifies", func(t *testing.T) {
+		// Entries of one posting share a time, so they may be read in either order.
+		resp := verify(t, account.LastEntryHash, entries[0], entries[2], entries[1])
+		assert.True(t, resp.Valid, resp.Problems)
+		assert.Equal(t, int64(3), resp.Entries)
+
+		// Postings after the account was read extend the chain.
+		resp = verify(t, entries[1].EntryHash, entries...)
+		assert.True(t, resp.Valid, resp.Problems)
+	})
+
+	t.Run("changed, removed and inserted entries break it", func(t *testing.T) {
+		changed := slices.Clone(entries)
+		changed[1].Amount = 20
+		resp := verify(t, account.LastEntryHash, changed...)
+		assert.False(t, resp.Valid)
+		assert.Contains(t, resp.Problems, "entry "+changed[1].ID+" does not hash to its entry_hash")
+
+		resp = verify(t, account.LastEntryHash, entries[0], entries[2])
+		assert.False(t, resp.Valid)
+		assert.Contains(t, resp.Problems, "1 entries are not linked to the account's first entry")
+
+		resp = verify(t, account.LastEntryHash, entries[:2]...)
+		assert.Equal(t, []string{"the account's last entry hash is not on the chain"}, resp.Problems)
+
+		forged := entries[2]
+		forged.ID, forged.Amount, forged.BalanceAfter = "forged", 5, 295
+		forged.EntryHash = entryHash(forged.PrevHash, &forged)
+		resp = verify(t, account.LastEntryHash, append(slices.Clone(entries), forged)...)
+		assert.Contains(t, resp.Problems, "entries "+entries[2].ID+" and forged both follow the same entry")
+
+		unhashed := models.LedgerEntry{ID: "late", AccountID: "acc-1", CreatedAt: at.Add(time.Hour)}
+		resp = verify(t, account.LastEntryHash, append(slices.Clone(entries), unhashed)...)
+		assert.Equal(t, []string{"entry late has no hash but was posted after the chain started"}, resp.Problems)
+	})
+
+	t.Run("entries from before hashing are counted, not checked", func(t *testing.T) {
+		legacy := models.LedgerEntry{ID: "legacy", AccountID: "acc-1", CreatedAt: at.Add(-time.Hour)}
+		resp := verify(t, account.LastEntryHash, append([]models.LedgerEntry{legacy}, entries...)...)
+		assert.True(t, resp.Valid, resp.Problems)
+		assert.Equal(t, int64(1), resp.UnchainedEntries)
+	})
+
+	t.Run("rechaining leaves an intact chain alone and repairs a broken one", func(t *testing.T) {
+		sorted := slices.Clone(entries)
+		slices.SortFunc(sorted, func(a, b models.LedgerEntry) int {
+			return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID, b.ID))
+		})
+		changed, last := rechainEntries(sorted)
+		assert.Empty(t, changed)
+		assert.Equal(t, account.LastEntryHash, last)
+
+		legacy := models.LedgerEntry{ID: "legacy", AccountID: "acc-1", EntryType: models.EntryTypeCredit, Amount: 1, BalanceAfter: 1, CreatedAt: at.Add(-time.Hour)}
+		corrected := append([]models.LedgerEntry{legacy}, sorted...)
+		corrected[2].Amount = 20
+		changed, last = rechainEntries(corrected)
+		assert.Len(t, changed, 4)
+		assert.Equal(t, corrected[3].EntryHash, last)
+		for i := range corrected {
+			prev := ""
+			if i > 0 {
+				prev = corrected[i-1].EntryHash
+			}
+			assert.Equal(t, prev, corrected[i].PrevHash)
+			assert.Equal(t, entryHash(prev, &corrected[i]), corrected[i].EntryHash)
+		}
+	})
+}
+
+func TestFormatAmount(t *testing.T) {
+	for _, tc := range []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{1250, "USD", "12.50"},
+		{5, "USD", "0.05"},
+		{-5, "USD", "-0.05"},
+		{1250, "JPY", "1250"},
+		{1, "KWD", "0.001"},
+	} {
+		assert.Equal(t, tc.want, formatAmount(tc.amount, tc.currency))
+	}
+}
+
 func TestGetTransactions(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockRepo, service := newTestService(t)
@@ -388,17 +1528,50 @@ func TestGetTransactions(t *testing.T) {
 		}
 
 		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(account, nil)
-		mockRepo.EXPECT().GetTransactionsByAccountID(gomock.Any(), "acc-1", 50, 0).Return(txns, nil)
+		mockRepo.EXPECT().GetTransactionsByAccountID(gomock.Any(), "acc-1", 50, 0, false).Return(txns, nil)
+		mockRepo.EXPECT().SummarizeEntries(gomock.Any(), "acc-1", txns).
+			Return(map[string]TransactionSummary{"txn-1": {EntryCount: 2, NetEffect: 5000}}, nil)
+		mockRepo.EXPECT().CountTransactionsByAccountID(gomock.Any(), "acc-1").Return(int64(1), nil)
 
-		result, err := service.GetTransactions(context.Background(), "acc-1", 50, 0)
+		result, total, err := service.GetTransactions(context.Background(), "acc-1", 50, 0, false)
 		assert.NoError(t, err)
 		assert.Len(t, result, 1)
+		assert.Equal(t, int64(1), total)
+		assert.Empty(t, result[0].Entries)
+		assert.Equal(t, &TransactionSummary{EntryCount: 2, NetEffect: 5000}, result[0].Summary)
+	})
+
+	t.Run("with entries", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		txns := []models.Transaction{{
+			ID:              "txn-1",
+			TransactionType: models.TransactionTypeTransfer,
+			Amount:          1500,
+			Fee:             100,
+			Currency:        "USD",
+			CreatedAt:       time.Now(),
+			Entries: []models.LedgerEntry{
+				{AccountID: "acc-1", EntryType: models.EntryTypeDebit, Amount: 1600},
+				{AccountID: "acc-2", EntryType: models.EntryTypeCredit, Amount: 1500},
+				{AccountID: models.SystemAccountID, EntryType: models.EntryTypeCredit, Amount: 100},
+			},
+		}}
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(&models.Account{ID: "acc-1"}, nil)
+		mockRepo.EXPECT().GetTransactionsByAccountID(gomock.Any(), "acc-1", 50, 0, true).Return(txns, nil)
+		mockRepo.EXPECT().CountTransactionsByAccountID(gomock.Any(), "acc-1").Return(int64(1), nil)
+
+		result, _, err := service.GetTransactions(context.Background(), "acc-1", 50, 0, true)
+		assert.NoError(t, err)
+		assert.Len(t, result[0].Entries, 3)
+		assert.Equal(t, &TransactionSummary{EntryCount: 3, NetEffect: -1600}, result[0].Summary)
 	})
 
 	t.Run("empty ID", func(t *testing.T) {
 		_, service := newTestService(t)
 
-		result, err := service.GetTransactions(context.Background(), "", 50, 0)
+		result, _, err := service.GetTransactions(context.Background(), "", 50, 0, false)
 		assert.Error(t, err)
 		assert.Nil(t, result)
 	})
@@ -408,13 +1581,60 @@ func TestGetTransactions(t *testing.T) {
 
 		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "nonexistent").Return(nil, ErrAccountNotFound)
 
-		result, err := service.GetTransactions(context.Background(), "nonexistent", 50, 0)
+		result, _, err := service.GetTransactions(context.Background(), "nonexistent", 50, 0, false)
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.ErrorIs(t, err, ErrAccountNotFound)
 	})
 }
 
+func TestSearchTransactions(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		query := TransactionSearchQuery{Text: "rent", AccountID: "acc-1", Limit: 50}
+		txns := []models.Transaction{
+			{
+				ID:              "txn-1",
+				TransactionType: models.TransactionTypeDeposit,
+				Amount:          5000,
+				Currency:        "USD",
+				Description:     "March rent",
+				CreatedAt:       time.Now(),
+			},
+		}
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "acc-1").Return(&models.Account{ID: "acc-1"}, nil)
+		mockRepo.EXPECT().SearchTransactions(gomock.Any(), query).Return(txns, nil)
+
+		result, err := service.SearchTransactions(context.Background(), query)
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, "March rent", result[0].Description)
+	})
+
+	t.Run("without account filter", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		query := TransactionSearchQuery{Text: "rent", Limit: 50}
+		mockRepo.EXPECT().SearchTransactions(gomock.Any(), query).Return([]models.Transaction{}, nil)
+
+		result, err := service.SearchTransactions(context.Background(), query)
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		mockRepo, service := newTestService(t)
+
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), "nonexistent").Return(nil, ErrAccountNotFound)
+
+		result, err := service.SearchTransactions(context.Background(), TransactionSearchQuery{Text: "rent", AccountID: "nonexistent"})
+		assert.ErrorIs(t, err, ErrAccountNotFound)
+		assert.Nil(t, result)
+	})
+}
+
 func TestReconcile(t *testing.T) {
 	t.Run("all consistent", func(t *testing.T) {
 		mockRepo, service := newTestService(t)
@@ -478,3 +1698,87 @@ func TestReconcile(t *testing.T) {
 		assert.Nil(t, resp)
 	})
 }
+
+type capturedEvents []events.Event
+
+func (c *capturedEvents) Publish(_ context.Context, e events.Event) { *c = append(*c, e) }
+
+func TestDomainEvents(t *testing.T) {
+	newService := func(t *testing.T) (*MockLedgerRepository, LedgerService, *capturedEvents) {
+		ctrl := gomock.NewController(t)
+		mockRepo := NewMockLedgerRepository(ctrl)
+		published := &capturedEvents{}
+		return mockRepo, NewLedgerService(log.NewLoggerWithJSONOutput(), mockRepo, Pricing{}, published), published
+	}
+
+	t.Run("account created", func(t *testing.T) {
+		mockRepo, service, published := newService(t)
+		mockRepo.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Return(&models.Account{ID: "acc-1", AccountType: models.AccountTypeUser, Currency: "USD"}, nil)
+
+		_, err := service.CreateAccount(context.Background(), &CreateAccountRequest{Name: "Alice", Currency: "USD"})
+		assert.NoError(t, err)
+		assert.Len(t, *published, 1)
+		assert.Equal(t, EventAccountCreated, (*published)[0].Type)
+		assert.Equal(t, "acc-1", (*published)[0].Data.(AccountCreatedEvent).AccountID)
+	})
+
+	t.Run("transaction posted with stable id", func(t *testing.T) {
+		mockRepo, service, published := newService(t)
+		txn := &models.Transaction{ID: "txn-1", TransactionType: models.TransactionTypeTransfer, Amount: 500, Currency: "USD"}
+		mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).Return(txn, nil).Times(2)
+
+		req := &TransferRequest{SourceAccountID: "acc-1", DestAccountID: "acc-2", Amount: 500, IdempotencyKey: "t-1"}
+		_, _ = service.Transfer(context.Background(), req)
+		_, _ = service.Transfer(context.Background(), req)
+
+		assert.Len(t, *published, 2)
+		assert.Equal(t, (*published)[0].ID, (*published)[1].ID, "a replay republishes the same event")
+		data := (*published)[0].Data.(TransactionPostedEvent)
+		assert.Equal(t, "acc-1", data.SourceAccountID)
+		assert.Equal(t, "acc-2", data.DestAccountID)
+		assert.Equal(t, int64(500), data.Amount)
+	})
+
+	t.Run("balance alert triggered", func(t *testing.T) {
+		mockRepo, service, published := newService(t)
+		txn := &models.Transaction{ID: "txn-1", TransactionType: models.TransactionTypeWithdrawal, Amount: 500, Currency: "USD"}
+		txn.AlertTriggers = []models.BalanceAlertTrigger{
+			{ID: "trigger-1", AlertID: "alert-1", AccountID: "acc-1", TransactionID: "txn-1", Kind: models.AlertKindLowBalance, Threshold: 1000, Balance: 700, Currency: "USD"},
+		}
+		mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).Return(txn, nil)
+
+		_, err := service.Withdraw(context.Background(), "acc-1", &WithdrawRequest{Amount: 500, IdempotencyKey: "w-1"})
+		assert.NoError(t, err)
+		assert.Len(t, *published, 2)
+		assert.Equal(t, EventBalanceAlertTriggered+":trigger-1", (*published)[1].ID)
+		alert := (*published)[1].Data.(BalanceAlertTriggeredEvent)
+		assert.Equal(t, "alert-1", alert.AlertID)
+		assert.Equal(t, int64(700), alert.Balance)
+	})
+
+	t.Run("dry run publishes nothing", func(t *testing.T) {
+		mockRepo, service, published := newService(t)
+		mockRepo.EXPECT().ExecuteDoubleEntry(gomock.Any(), gomock.Any()).Return(&models.Transaction{ID: "txn-1"}, nil)
+
+		_, err := service.Deposit(context.Background(), "acc-1", &DepositRequest{Amount: 100, IdempotencyKey: "d-1", DryRun: true})
+		assert.NoError(t, err)
+		assert.Empty(t, *published)
+	})
+
+	t.Run("reconciliation drift", func(t *testing.T) {
+		mockRepo, service, published := newService(t)
+		mockRepo.EXPECT().GetAllAccountsForReconciliation(gomock.Any()).Return([]AccountReconciliation{
+			{AccountID: "acc-1", IsConsistent: true},
+			{AccountID: "acc-2", CachedBalance: 5000, DerivedBalance: 4500},
+		}, nil)
+		mockRepo.EXPECT().GetLedgerTotals(gomock.Any()).Return(int64(100), int64(100), nil)
+
+		_, err := service.Reconcile(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, *published, 1)
+		drift := (*published)[0].Data.(ReconciliationDriftDetectedEvent)
+		assert.Len(t, drift.Accounts, 1)
+		assert.Equal(t, "acc-2", drift.Accounts[0].AccountID)
+		assert.True(t, drift.LedgerBalanced)
+	})
+}
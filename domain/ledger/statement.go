@@ -0,0 +1,144 @@
+package ledger
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/constants"
+	"github.com/akeren/go-api-foundry/pkg/fx"
+	"github.com/akeren/go-api-foundry/pkg/pdf"
+)
+
+const (
+	StatementFormatCSV = "csv"
+	StatementFormatPDF = "pdf"
+)
+
+// Statement is an account's statement for the UTC days From to To, ready to
+// be written. Its entries are read from the database as it is written.
+type Statement struct {
+	AccountID      string
+	AccountName    string
+	Currency       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance int64
+
+	entries func(ctx context.Context, fn func(StatementEntry) error) error
+}
+
+// Filename names the statement's download.
+func (s *Statement) Filename(format string) string {
+	return fmt.Sprintf("statement-%s-%s-%s.%s", s.AccountID, s.From.Format(time.DateOnly), s.To.Format(time.DateOnly), format)
+}
+
+// WriteCSV writes one row per entry with the running balance. Amounts are in
+// major units, such as 12.50.
+func (s *Statement) WriteCSV(ctx context.Context, w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"date", "transaction_id", "transaction_type", "description", "debit", "credit", "balance", "currency"}); err != nil {
+		return err
+	}
+	err := s.walk(ctx, func(e StatementEntry, debit, credit string, balance int64) error {
+		return out.Write([]string{
+			e.CreatedAt.UTC().Format(constants.RFC3339DateTimeFormat),
+			e.TransactionID,
+			e.TransactionType,
+			csvSafe(e.Description),
+			debit,
+			credit,
+			formatAmount(balance, s.Currency),
+			s.Currency,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// WritePDF writes the statement as a printable document with opening and
+// closing balances.
+func (s *Statement) WritePDF(ctx context.Context, w io.Writer) error {
+	doc := pdf.NewTextWriter(w, []string{
+		"Account statement",
+		fmt.Sprintf("Account: %s (%s)", s.AccountName, s.AccountID),
+		fmt.Sprintf("Period: %s to %s (UTC)    Currency: %s", s.From.Format(time.DateOnly), s.To.Format(time.DateOnly), s.Currency),
+		"",
+		statementLine("Date", "Type", "Description", "Debit", "Credit", "Balance"),
+		strings.Repeat("-", pdf.LineWidth),
+	})
+	if err := doc.WriteLine(statementLine("", "", "Opening balance", "", "", formatAmount(s.OpeningBalance, s.Currency))); err != nil {
+		return err
+	}
+	closing := s.OpeningBalance
+	err := s.walk(ctx, func(e StatementEntry, debit, credit string, balance int64) error {
+		closing = balance
+		return doc.WriteLine(statementLine(e.CreatedAt.UTC().Format(time.DateOnly), e.TransactionType, e.Description, debit, credit, formatAmount(balance, s.Currency)))
+	})
+	if err != nil {
+		return err
+	}
+	if err := doc.WriteLine(statementLine("", "", "Closing balance", "", "", formatAmount(closing, s.Currency))); err != nil {
+		return err
+	}
+	return doc.Close()
+}
+
+// walk calls fn with each entry, its debit or credit, and the balance after it.
+func (s *Statement) walk(ctx context.Context, fn func(e StatementEntry, debit, credit string, balance int64) error) error {
+	balance := s.OpeningBalance
+	return s.entries(ctx, func(e StatementEntry) error {
+		if e.EntryType == models.EntryTypeCredit {
+			balance += e.Amount
+			return fn(e, "", formatAmount(e.Amount, s.Currency), balance)
+		}
+		balance -= e.Amount
+		return fn(e, formatAmount(e.Amount, s.Currency), "", balance)
+	})
+}
+
+// statementLine lays out one row of the PDF statement in fixed-width columns
+// that fill pdf.LineWidth.
+func statementLine(date, txnType, description, debit, credit, balance string) string {
+	return fmt.Sprintf("%-10s %-10s %-31s %13s %13s %13s", date, txnType, truncate(description, 31), debit, credit, balance)
+}
+
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "~"
+	}
+	return s
+}
+
+// csvSafe stops spreadsheets from running a description as a formula.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// formatAmount writes an amount in minor units as a decimal in the currency's
+// major unit, such as 1250 USD as 12.50 and 1250 JPY as 1250.
+func formatAmount(amount int64, currency string) string {
+	digits := fx.MinorUnits(currency)
+	sign, abs := "", strconv.FormatInt(amount, 10)
+	if amount < 0 {
+		sign, abs = "-", abs[1:]
+	}
+	if digits == 0 {
+		return sign + abs
+	}
+	if len(abs) <= digits {
+		abs = strings.Repeat("0", digits-len(abs)+1) + abs
+	}
+	return sign + abs[:len(abs)-digits] + "." + abs[len(abs)-digits:]
+}
@@ -1,12 +1,190 @@
 package domain
 
 import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+
 	"github.com/akeren/go-api-foundry/config"
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/domain/admin"
+	"github.com/akeren/go-api-foundry/domain/analytics"
 	"github.com/akeren/go-api-foundry/domain/ledger"
+	"github.com/akeren/go-api-foundry/domain/ledger/ledgerpb"
 	"github.com/akeren/go-api-foundry/domain/monitoring"
+	"github.com/akeren/go-api-foundry/domain/operations"
+	"github.com/akeren/go-api-foundry/domain/search"
+	"github.com/akeren/go-api-foundry/domain/statuspage"
+	"github.com/akeren/go-api-foundry/domain/uploads"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/status"
+	"github.com/akeren/go-api-foundry/pkg/utils"
 )
 
 func SetupCoreDomain(appConfig *config.ApplicationConfig) {
-	appConfig.RouterService.MountController(monitoring.NewMonitoringController(appConfig.DB, appConfig.Logger, appConfig.Cache))
-	appConfig.RouterService.MountController(ledger.NewLedgerController(appConfig.DB, appConfig.Logger))
+	var publisher events.Publisher
+	if appConfig.Events != nil {
+		// Assigned separately so a nil *Bus never becomes a non-nil interface.
+		publisher = appConfig.Events
+	}
+
+	appConfig.RouterService.MountController(router.NewWellKnownController(router.WellKnownConfigFromEnv(appConfig.Logger)))
+	appConfig.RouterService.MountController(monitoring.NewMonitoringController(appConfig.DB, appConfig.Logger, appConfig.RouterService, appConfig.Cache, appConfig.Messaging, appConfig.Probes, appConfig.Migrations))
+	ledgerRepository := ledger.NewReplicatedLedgerRepository(appConfig.DB, appConfig.FieldCipher, appConfig.Replica, ledger.ConcurrencyModeFromEnv(appConfig.Logger))
+	appConfig.RouterService.MountController(ledger.NewLedgerController(ledgerRepository, appConfig.Logger, appConfig.Operations, publisher))
+
+	ledgerService := ledger.NewLedgerService(appConfig.Logger, ledgerRepository, ledger.PricingFromEnv(appConfig.Logger), publisher)
+	// The self-test's writes are rolled back, so its service publishes nothing.
+	appConfig.RegisterSelfTest("ledger", ledger.SelfTest(appConfig.DB, ledger.NewLedgerService(appConfig.Logger, ledgerRepository, ledger.Pricing{}, nil)))
+	if appConfig.GRPCServer != nil {
+		ledgerpb.RegisterLedgerServiceServer(appConfig.GRPCServer, ledger.NewGRPCServer(ledgerService))
+	}
+
+	if appConfig.Operations != nil {
+		appConfig.RouterService.MountController(operations.NewOperationsController(appConfig.Operations))
+
+		if appConfig.Uploads != nil {
+			appConfig.RouterService.MountController(uploads.NewUploadsController(
+				appConfig.Logger,
+				appConfig.Uploads,
+				appConfig.Operations,
+				appConfig.UploadConfig,
+				map[string]uploads.Importer{
+					ledger.ImportKindTransfers: func(ctx context.Context, r io.Reader) (any, error) {
+						return ledgerService.ImportTransfersCSV(ctx, r)
+					},
+				},
+			))
+		}
+	}
+
+	if appConfig.Analytics != nil {
+		appConfig.RouterService.MountController(analytics.NewAnalyticsController(appConfig.AnalyticsSchemas, appConfig.Analytics))
+		appConfig.RouterService.RegisterMetrics(appConfig.Analytics)
+		appConfig.Analytics.Start()
+	}
+
+	if appConfig.Search != nil {
+		appConfig.RouterService.MountController(search.NewSearchController(appConfig.Search, search.ConfigFromEnv()))
+		if appConfig.Events != nil {
+			appConfig.Events.Subscribe(ledger.EventTransactionPosted, ledger.NewSearchIndexer(appConfig.Logger, ledgerRepository, appConfig.Search).Handle)
+		} else {
+			appConfig.Logger.Warn("Event bus disabled; ledger transactions will not be indexed for search")
+		}
+	}
+
+	if appConfig.Mailer != nil {
+		if appConfig.Events != nil {
+			appConfig.Events.Subscribe(ledger.EventBalanceAlertTriggered, ledger.NewAlertMailer(appConfig.Logger, ledgerRepository, appConfig.Mailer).Handle)
+		} else {
+			appConfig.Logger.Warn("Event bus disabled; balance alerts will not be emailed")
+		}
+	}
+
+	var incidents status.Store
+	if appConfig.Status != nil {
+		incidents = appConfig.Status.Store()
+		appConfig.RouterService.MountController(statuspage.NewStatusController(appConfig.Logger, incidents, appConfig.Probes))
+	}
+
+	if adminController := admin.NewAdminController(appConfig.Logger, appConfig.RouterService, appConfig.Roles, incidents, appConfig.Webhooks, appConfig.Operations, appConfig.CDC, appConfig.ScaleOutReport); adminController != nil {
+		appConfig.RouterService.MountController(adminController)
+	}
+
+	// Browser clients that authenticate with cookies fetch a token for the
+	// controllers that call RequireCSRF.
+	if enabled, _ := strconv.ParseBool(utils.GetEnvTrimmed("CSRF_ENABLED")); enabled {
+		appConfig.RouterService.MountController(router.NewCSRFController())
+	}
+
+	if info := router.OpenAPIInfoFromEnv(); info != nil {
+		appConfig.RouterService.MountController(router.NewOpenAPIController(*info))
+	}
+
+	if appConfig.Probes != nil {
+		if opts, enabled := ledger.SyntheticProbeFromEnv(appConfig.Logger); enabled {
+			appConfig.Probes.RegisterWithOptions(ledger.SyntheticProbeName, ledger.SyntheticCheck(appConfig.DB, appConfig.Logger, appConfig.FieldCipher), opts)
+		}
+		appConfig.RouterService.RegisterMetrics(appConfig.Probes)
+		if appConfig.Status != nil {
+			appConfig.Probes.OnResult(func(name string, _ time.Duration, err error) {
+				appConfig.Status.Observe(name, err == nil)
+			})
+			appConfig.Status.Start()
+		}
+		appConfig.Probes.Start()
+	}
+
+	if appConfig.Heartbeat != nil {
+		appConfig.Heartbeat.Start()
+	}
+
+	if appConfig.Goroutines != nil {
+		appConfig.Goroutines.Start()
+	}
+
+	if appConfig.Webhooks != nil {
+		appConfig.Webhooks.Start()
+	}
+
+	if appConfig.FX != nil {
+		appConfig.FX.Start()
+	}
+
+	if appConfig.Views != nil {
+		appConfig.Views.Start()
+	}
+
+	holdSweeper := ledger.NewHoldSweeper(appConfig.Logger, ledgerRepository, ledger.HoldSweepIntervalFromEnv(appConfig.Logger), appConfig.Locks)
+	appConfig.ShutdownHooks().Register("ledger-hold-sweeper", config.ShutdownPriorityIntake, 5*time.Second, func(context.Context) error {
+		holdSweeper.Stop()
+		return nil
+	})
+	holdSweeper.Start()
+
+	accruer := ledger.NewAccruer(appConfig.Logger, ledgerService, ledger.AccrualIntervalFromEnv(appConfig.Logger))
+	appConfig.ShutdownHooks().Register("ledger-accruer", config.ShutdownPriorityIntake, 5*time.Second, func(context.Context) error {
+		accruer.Stop()
+		return nil
+	})
+	accruer.Start()
+
+	closer := ledger.NewCloser(appConfig.Logger, ledgerService, ledger.ClosingIntervalFromEnv(appConfig.Logger))
+	appConfig.ShutdownHooks().Register("ledger-closer", config.ShutdownPriorityIntake, 5*time.Second, func(context.Context) error {
+		closer.Stop()
+		return nil
+	})
+	closer.Start()
+
+	// Auto-migrated schemas have no partitions to maintain.
+	if appConfig.Migrations != nil {
+		partitions := ledger.NewPartitionMaintainer(appConfig.Logger, ledgerRepository, ledger.PartitionIntervalFromEnv(appConfig.Logger))
+		appConfig.ShutdownHooks().Register("ledger-partitions", config.ShutdownPriorityIntake, 5*time.Second, func(context.Context) error {
+			partitions.Stop()
+			return nil
+		})
+		partitions.Start()
+	}
+
+	if cfg, enabled := ledger.ArchiveConfigFromEnv(appConfig.Logger); enabled {
+		archiver := ledger.NewArchiver(appConfig.Logger, ledgerRepository, cfg)
+		appConfig.ShutdownHooks().Register("ledger-archiver", config.ShutdownPriorityIntake, 5*time.Second, func(context.Context) error {
+			archiver.Stop()
+			return nil
+		})
+		archiver.Start()
+	}
+
+	if appConfig.Replica != nil {
+		appConfig.RouterService.RegisterMetrics(appConfig.Replica)
+		appConfig.Replica.Start()
+	}
+
+	if appConfig.Breakers != nil {
+		appConfig.RouterService.RegisterMetrics(appConfig.Breakers)
+	}
+
+	// Every controller has its rate limiters now.
+	appConfig.AuditScaleOut()
 }
@@ -6,6 +6,8 @@ import (
 
 	"github.com/akeren/go-api-foundry/config/router"
 	"github.com/akeren/go-api-foundry/internal/log"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/probe"
 	"github.com/akeren/go-api-foundry/pkg/ratelimit"
 	"gorm.io/gorm"
 )
@@ -14,44 +16,112 @@ type Cache interface {
 	Ping(ctx context.Context) error
 }
 
+type MessageQueue interface {
+	Ping(ctx context.Context) error
+}
+
+// Readiness reports whether the instance still takes traffic. RouterService
+// implements it and stops being ready when shutdown starts.
+type Readiness interface {
+	IsReady() bool
+}
+
 type HealthStatus struct {
 	Database     int `json:"database"`      // 1 = healthy, 0 = unhealthy
 	Cache        int `json:"cache"`         // 1 = healthy, 0 = unhealthy/not configured
-	MessageQueue int `json:"message_queue"` // 1 = healthy, 0 = not implemented
+	MessageQueue int `json:"message_queue"` // 1 = healthy, 0 = unhealthy/not configured
 	Storage      int `json:"storage"`       // 1 = healthy, 0 = not implemented
 	Uptime       int `json:"uptime"`        // uptime in seconds
 }
 
+// LivenessStatus only says the process is up and serving requests.
+type LivenessStatus struct {
+	Alive  bool `json:"alive"`
+	Uptime int  `json:"uptime"`
+}
+
+// ReadinessStatus reports whether the instance should receive traffic. Checks
+// maps each dependency to "ok" or "failed"; it is empty during shutdown.
+type ReadinessStatus struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// readinessTimeout bounds the dependency checks of one readiness request.
+const readinessTimeout = 2 * time.Second
+
+// DetailedHealthStatus reports recent probe results per dependency. Status is
+// "degraded" when the latest check of any dependency failed.
+type DetailedHealthStatus struct {
+	Status       string        `json:"status"`
+	Uptime       int           `json:"uptime"`
+	Dependencies []probe.Stats `json:"dependencies"`
+}
+
 type MonitoringController struct {
-	db        *gorm.DB
-	logger    *log.Logger
-	cache     Cache
-	startTime time.Time
+	db         *gorm.DB
+	logger     *log.Logger
+	readiness  Readiness
+	cache      Cache
+	queue      MessageQueue
+	probes     *probe.Prober
+	migrations probe.Check
+	startTime  time.Time
 }
 
-func NewMonitoringController(db *gorm.DB, logger *log.Logger, cache Cache) *router.RESTController {
+// NewMonitoringController serves the health endpoints. /health/detailed is
+// only mounted when probes is non-nil, and /health/ready only checks
+// migrations when migrations is non-nil. queue may be nil when no message
+// queue is configured.
+func NewMonitoringController(db *gorm.DB, logger *log.Logger, readiness Readiness, cache Cache, queue MessageQueue, probes *probe.Prober, migrations probe.Check) *router.RESTController {
 	ctrl := &MonitoringController{
-		db:        db,
-		logger:    logger,
-		cache:     cache,
-		startTime: time.Now(),
+		db:         db,
+		logger:     logger,
+		readiness:  readiness,
+		cache:      cache,
+		queue:      queue,
+		probes:     probes,
+		migrations: migrations,
+		startTime:  time.Now(),
 	}
 
 	return router.NewRESTController(
 		"MonitoringController",
 		"/",
-		func(routerService *router.RouterService, controller *router.RESTController) {
+		func(routerService router.RouteRegistrar, controller *router.RESTController) {
 
-			monitoringRateLimiter := createMonitoringRateLimiter(routerService)
+			monitoringRateLimiter := createMonitoringRateLimiter()
 
 			routerService.AddGetHandler(controller, monitoringRateLimiter, "", func(c *router.RequestContext) *router.ServiceResult {
 				return ctrl.monitor(c)
 			})
 
 			routerService.AddGetHandler(controller, monitoringRateLimiter, "health", func(c *router.RequestContext) *router.ServiceResult {
-				return ctrl.healthCheck(routerService, c)
+				return ctrl.healthCheck(c)
+			})
+
+			// Liveness and readiness are polled by orchestrators every few
+			// seconds, so they are not rate limited.
+			routerService.AddGetHandler(controller, nil, "health/live", func(c *router.RequestContext) *router.ServiceResult {
+				return ctrl.livenessCheck()
+			}).Describe(router.OperationDoc{
+				Summary: "Whether the process is up; never checks dependencies", Response: LivenessStatus{},
+			})
+
+			routerService.AddGetHandler(controller, nil, "health/ready", func(c *router.RequestContext) *router.ServiceResult {
+				return ctrl.readinessCheck(c)
+			}).Describe(router.OperationDoc{
+				Summary: "Whether the instance accepts traffic: database, cache and migrations are ready and shutdown has not started", Response: ReadinessStatus{},
 			})
 
+			if probes != nil {
+				routerService.AddGetHandler(controller, monitoringRateLimiter, "health/detailed", func(c *router.RequestContext) *router.ServiceResult {
+					return ctrl.detailedHealthCheck()
+				}).Describe(router.OperationDoc{
+					Summary: "Latency percentiles and error rates per dependency", Response: DetailedHealthStatus{},
+				})
+			}
+
 			routerService.AddGetHandler(controller, nil, "extras/greet", func(c *router.RequestContext) *router.ServiceResult {
 				return ctrl.greet(c)
 			})
@@ -59,7 +129,7 @@ func NewMonitoringController(db *gorm.DB, logger *log.Logger, cache Cache) *rout
 	)
 }
 
-func createMonitoringRateLimiter(routerService *router.RouterService) ratelimit.RateLimiter {
+func createMonitoringRateLimiter() ratelimit.RateLimiter {
 
 	const monitoringRequestsPerMinute = 10 // More restrictive than default 100
 
@@ -73,11 +143,8 @@ func createMonitoringRateLimiter(routerService *router.RouterService) ratelimit.
 	return ratelimit.NewRateLimiter(config)
 }
 
-func (ctrl *MonitoringController) healthCheck(
-	routerService *router.RouterService,
-	c *router.RequestContext,
-) *router.ServiceResult {
-	logger := routerService.GetLogger(c)
+func (ctrl *MonitoringController) healthCheck(c *router.RequestContext) *router.ServiceResult {
+	logger := router.GetLogger(c)
 	logger.Info("Health check endpoint called")
 	healthStatus := ctrl.performHealthChecks(context.Background(), logger)
 
@@ -88,6 +155,76 @@ func (ctrl *MonitoringController) healthCheck(
 	}
 }
 
+// livenessCheck succeeds whenever the process can serve a request. It ignores
+// dependencies so a database blip never gets the process restarted.
+func (ctrl *MonitoringController) livenessCheck() *router.ServiceResult {
+	return router.OKResult(LivenessStatus{
+		Alive:  true,
+		Uptime: int(time.Since(ctrl.startTime).Seconds()),
+	}, "go-api-foundry is alive")
+}
+
+// readinessCheck fails with 503 once shutdown has started, or while the
+// database, the cache or the schema is not ready, so load balancers stop
+// routing new requests to this instance until it recovers.
+func (ctrl *MonitoringController) readinessCheck(c *router.RequestContext) *router.ServiceResult {
+	if !ctrl.readiness.IsReady() {
+		return router.ErrorResult(apperrors.StatusServiceUnavailable, "go-api-foundry is shutting down", ReadinessStatus{Ready: false})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	status := ReadinessStatus{Ready: true, Checks: make(map[string]string)}
+	check := func(name string, err error) {
+		if err == nil {
+			status.Checks[name] = "ok"
+			return
+		}
+		status.Ready = false
+		status.Checks[name] = "failed"
+		router.GetLogger(c).Warn("Readiness check failed", "check", name, "error", err)
+	}
+
+	sqlDB, err := ctrl.db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	check("database", err)
+	if ctrl.cache != nil {
+		check("cache", ctrl.cache.Ping(ctx))
+	}
+	if ctrl.migrations != nil {
+		check("migrations", ctrl.migrations(ctx))
+	}
+
+	if !status.Ready {
+		return router.ErrorResult(apperrors.StatusServiceUnavailable, "go-api-foundry is not ready", status)
+	}
+	return router.OKResult(status, "go-api-foundry is ready")
+}
+
+// detailedHealthCheck reads the background probe results, so it never waits
+// on a slow dependency.
+func (ctrl *MonitoringController) detailedHealthCheck() *router.ServiceResult {
+	status := DetailedHealthStatus{
+		Status:       "ok",
+		Uptime:       int(time.Since(ctrl.startTime).Seconds()),
+		Dependencies: ctrl.probes.Snapshot(),
+	}
+	for _, dep := range status.Dependencies {
+		if !dep.Healthy {
+			status.Status = "degraded"
+		}
+	}
+
+	return &router.ServiceResult{
+		StatusCode: 200,
+		Data:       status,
+		Message:    "go-api-foundry detailed health check completed",
+	}
+}
+
 func (ctrl *MonitoringController) greet(
 	c *router.RequestContext,
 ) *router.ServiceResult {
@@ -117,10 +254,11 @@ func (ctrl *MonitoringController) performHealthChecks(ctx context.Context, logge
 
 	checkCacheConnectivity(ctx, ctrl, &status, logger)
 
-	status.MessageQueue = 0 // Not implemented
-	status.Storage = 0      // Not implemented
+	checkMessageQueueConnectivity(ctx, ctrl, &status, logger)
 
-	logger.Info("Message queue and storage health checks not implemented")
+	status.Storage = 0 // Not implemented
+
+	logger.Info("Storage health check not implemented")
 
 	return status
 }
@@ -140,6 +278,21 @@ func checkCacheConnectivity(ctx context.Context, ctrl *MonitoringController, sta
 	}
 }
 
+func checkMessageQueueConnectivity(ctx context.Context, ctrl *MonitoringController, status *HealthStatus, logger *log.Logger) {
+	if ctrl.queue == nil {
+		status.MessageQueue = 0 // Message queue not configured
+		logger.Info("Message queue not configured, message queue health check skipped")
+		return
+	}
+	if err := ctrl.queue.Ping(ctx); err == nil {
+		status.MessageQueue = 1
+		logger.Info("Message queue health check passed")
+	} else {
+		status.MessageQueue = 0
+		logger.Error("Message queue health check failed", "error", err)
+	}
+}
+
 func checkDatabaseConnectivity(ctx context.Context, ctrl *MonitoringController, status *HealthStatus, logger *log.Logger) {
 	if ctrl.checkDatabase(ctx) {
 		status.Database = 1
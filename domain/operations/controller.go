@@ -0,0 +1,44 @@
+package operations
+
+import (
+	"errors"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/pkg/operations"
+	"github.com/google/uuid"
+)
+
+// NewOperationsController serves the status resource of long-running
+// operations started by other domains.
+func NewOperationsController(manager *operations.Manager) *router.RESTController {
+	return router.NewVersionedRESTController(
+		"OperationsController",
+		"v1",
+		"/operations",
+		func(rs router.RouteRegistrar, c *router.RESTController) {
+			rs.AddGetHandler(c, nil, "/:id", getOperationHandler(manager)).Describe(router.OperationDoc{
+				Summary: "Get the status of a long-running operation", Response: operations.Operation{},
+			})
+		},
+	)
+}
+
+func getOperationHandler(manager *operations.Manager) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		id := ctx.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			return router.NotFoundResult("Operation not found")
+		}
+
+		op, err := manager.Get(ctx.Request.Context(), id)
+		if err != nil {
+			if errors.Is(err, operations.ErrNotFound) {
+				return router.NotFoundResult("Operation not found")
+			}
+			router.GetLogger(ctx).Error("Failed to load operation", "operation_id", id, "error", err)
+			return router.InternalServerErrorResult("Failed to load operation")
+		}
+
+		return router.OKResult(op, "Operation retrieved successfully")
+	}
+}
@@ -0,0 +1,119 @@
+// Package search serves full-text queries over the documents other domains
+// index, scoped to the caller's tenant.
+package search
+
+import (
+	"strings"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/search"
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const (
+	defaultTenantClaim = "tenant_id"
+	defaultPageLimit   = 20
+	maxPageLimit       = 100
+)
+
+type Config struct {
+	// TenantClaim names the JWT claim holding the caller's tenant.
+	TenantClaim string
+}
+
+// ConfigFromEnv reads SEARCH_TENANT_CLAIM, "tenant_id" by default.
+func ConfigFromEnv() Config {
+	cfg := Config{TenantClaim: utils.GetEnvTrimmed("SEARCH_TENANT_CLAIM")}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = defaultTenantClaim
+	}
+	return cfg
+}
+
+// SearchParams is the query string of a search; paging parameters are read
+// by router.ParsePagination.
+type SearchParams struct {
+	Q     string   `form:"q" binding:"required,max=200"`
+	Types []string `form:"type" binding:"max=10,dive,max=64"`
+	// Tenant selects the tenant for service callers. Users are always scoped
+	// to the tenant in their token.
+	Tenant string `form:"tenant" binding:"max=255"`
+}
+
+// NewSearchController mounts GET /v1/search. Callers must be authenticated:
+// users search their token's tenant and services name one.
+func NewSearchController(indexer search.Indexer, cfg Config) *router.RESTController {
+	return router.NewVersionedRESTController(
+		"SearchController",
+		"v1",
+		"/search",
+		func(rs router.RouteRegistrar, c *router.RESTController) {
+			rs.AddGetHandler(c, nil, "", searchHandler(indexer, cfg)).Describe(router.OperationDoc{
+				Summary: "Search documents",
+				Description: "Full-text search with typo tolerance over the documents of the caller's tenant, " +
+					"such as ledger transactions, best match first.",
+				Response: router.PaginatedResult[search.Hit]{},
+				Query: []router.QueryParam{
+					{Name: "q", Description: "Search text", Required: true},
+					{Name: "type", Description: "Only documents of this type, e.g. transaction; repeatable"},
+					{Name: "tenant", Description: "Tenant to search; service callers only"},
+					{Name: "page", Type: "integer", Description: "1-based page number"},
+					{Name: "per_page", Type: "integer", Description: "Page size, default 20, max 100 (limit is an alias)"},
+				},
+			})
+		},
+	).RequireAuth()
+}
+
+func searchHandler(indexer search.Indexer, cfg Config) router.HandlerFunction {
+	return func(ctx *router.RequestContext) *router.ServiceResult {
+		params, errResult := router.BindQuery[SearchParams](ctx)
+		if errResult != nil {
+			return errResult
+		}
+		q := strings.TrimSpace(params.Q)
+		if q == "" {
+			return router.BadRequestResult("Search text (q) is required", nil)
+		}
+		tenant, errResult := resolveTenant(ctx, cfg, params.Tenant)
+		if errResult != nil {
+			return errResult
+		}
+
+		page := router.ParsePagination(ctx, defaultPageLimit, maxPageLimit)
+		result, err := indexer.Search(ctx.Request.Context(), search.Query{
+			Text:   q,
+			Tenant: tenant,
+			Types:  params.Types,
+			Limit:  page.PerPage,
+			Offset: page.Offset,
+		})
+		if err != nil {
+			router.GetLogger(ctx).Error("Search failed", "error", err)
+			return router.ErrorResult(apperrors.StatusServiceUnavailable, "Search is unavailable", nil)
+		}
+		paginated := router.NewPaginatedResult(ctx, result.Hits, page, result.Total)
+		return router.OKResult(paginated, "Search results retrieved successfully").WithLinkHeader(paginated.Links)
+	}
+}
+
+// resolveTenant returns the tenant a request searches: the tenant claim of a
+// bearer token, which a tenant parameter may only repeat, or the tenant
+// parameter of a service caller.
+func resolveTenant(ctx *router.RequestContext, cfg Config, requested string) (string, *router.ServiceResult) {
+	if claims := router.GetClaims(ctx); claims != nil {
+		tenant := claims.String(cfg.TenantClaim)
+		switch {
+		case tenant == "":
+			return "", router.ErrorResult(apperrors.StatusForbidden, "Token carries no tenant", nil)
+		case requested != "" && requested != tenant:
+			return "", router.ErrorResult(apperrors.StatusForbidden, "Cannot search another tenant", nil)
+		}
+		return tenant, nil
+	}
+	if requested == "" {
+		return "", router.BadRequestResult("Service callers must name a tenant", nil)
+	}
+	return requested, nil
+}
@@ -0,0 +1,119 @@
+package search
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"github.com/akeren/go-api-foundry/pkg/search"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+func testToken(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	enc := func(v any) string {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	input := enc(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + enc(claims)
+	mac := hmac.New(sha256.New, []byte(testJWTSecret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newTestRouter(t *testing.T) *router.RouterService {
+	t.Helper()
+	t.Setenv("AUTH_JWT_HMAC_SECRET", testJWTSecret)
+
+	indexer := search.NewMemoryIndexer()
+	_ = indexer.Index(context.Background(),
+		search.Document{ID: "t1", Type: "transaction", Tenants: []string{"acct-1"}, Title: "TRANSFER", Text: "Invoice 2291 for consulting"},
+		search.Document{ID: "t2", Type: "transaction", Tenants: []string{"acct-2"}, Title: "TRANSFER", Text: "Consulting invoice 77"},
+	)
+
+	rs := router.CreateRouterService(log.NewLoggerWithJSONOutput(), nil, &router.RouterConfig{
+		RateLimitRequests: 1000,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+	})
+	rs.MountController(NewSearchController(indexer, Config{TenantClaim: "tenant_id"}))
+	return rs
+}
+
+type searchResponse struct {
+	Data router.PaginatedResult[search.Hit] `json:"data"`
+}
+
+func get(t *testing.T, rs *router.RouterService, target string, prepare func(*http.Request) *http.Request) (int, searchResponse) {
+	t.Helper()
+	req := prepare(httptest.NewRequest(http.MethodGet, target, nil))
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	var resp searchResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+func bearer(token string) func(*http.Request) *http.Request {
+	return func(r *http.Request) *http.Request {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+}
+
+func TestSearch_ScopesUsersToTheirTenant(t *testing.T) {
+	rs := newTestRouter(t)
+	exp := time.Now().Add(time.Hour).Unix()
+	token := testToken(t, map[string]any{"sub": "user-1", "tenant_id": "acct-1", "exp": exp})
+
+	code, resp := get(t, rs, "/v1/search?q=invioce", bearer(token))
+	if code != http.StatusOK || resp.Data.Total != 1 || resp.Data.Data[0].ID != "t1" {
+		t.Fatalf("expected only acct-1's t1, got %d %+v", code, resp.Data)
+	}
+
+	if code, _ := get(t, rs, "/v1/search?q=invoice&tenant=acct-2", bearer(token)); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another tenant, got %d", code)
+	}
+	noTenant := testToken(t, map[string]any{"sub": "user-1", "exp": exp})
+	if code, _ := get(t, rs, "/v1/search?q=invoice", bearer(noTenant)); code != http.StatusForbidden {
+		t.Fatalf("expected 403 without a tenant claim, got %d", code)
+	}
+	if code, _ := get(t, rs, "/v1/search?q=invoice", func(r *http.Request) *http.Request { return r }); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without credentials, got %d", code)
+	}
+	if code, _ := get(t, rs, "/v1/search", bearer(token)); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without q, got %d", code)
+	}
+}
+
+func TestSearch_ServicesNameTheTenant(t *testing.T) {
+	rs := newTestRouter(t)
+	service := func(r *http.Request) *http.Request {
+		return r.WithContext(principal.WithPrincipal(r.Context(), &principal.Principal{Subject: "billing", Kind: principal.KindService}))
+	}
+
+	code, resp := get(t, rs, "/v1/search?q=consulting&tenant=acct-2&type=transaction", service)
+	if code != http.StatusOK || resp.Data.Total != 1 || resp.Data.Data[0].ID != "t2" {
+		t.Fatalf("expected acct-2's t2, got %d %+v", code, resp.Data)
+	}
+	if code, _ := get(t, rs, "/v1/search?q=consulting", service); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a tenant, got %d", code)
+	}
+}
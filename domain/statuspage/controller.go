@@ -0,0 +1,118 @@
+// Package statuspage serves a public status page built from stored
+// health-check history and operator incidents.
+package statuspage
+
+import (
+	"embed"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/probe"
+	"github.com/akeren/go-api-foundry/pkg/render"
+	"github.com/akeren/go-api-foundry/pkg/status"
+)
+
+// reportTTL bounds how often the public endpoints read the history.
+const reportTTL = 30 * time.Second
+
+//go:embed templates
+var templates embed.FS
+
+type controller struct {
+	store  status.Store
+	probes *probe.Prober
+	pages  *render.Engine
+
+	mu       sync.Mutex
+	report   *status.Report
+	loadedAt time.Time
+	now      func() time.Time
+}
+
+// NewStatusController mounts GET /status (JSON) and GET /status/page (HTML).
+// Current component health comes from probes, which may be nil.
+func NewStatusController(logger *log.Logger, store status.Store, probes *probe.Prober) *router.RESTController {
+	pages, err := render.New(render.Config{
+		FS:    templates,
+		Root:  "templates",
+		Funcs: map[string]any{"uptime": formatUptime, "dayClass": dayClass},
+	})
+	if err != nil {
+		// The templates are embedded, so this only fails on a broken build.
+		panic(fmt.Sprintf("statuspage: %v", err))
+	}
+	ctrl := &controller{store: store, probes: probes, pages: pages, now: time.Now}
+
+	return router.NewRESTController(
+		"StatusController",
+		"/status",
+		func(rs router.RouteRegistrar, c *router.RESTController) {
+			rs.AddGetHandler(c, nil, "", func(ctx *router.RequestContext) *router.ServiceResult {
+				report, result := ctrl.currentReport(ctx)
+				if result != nil {
+					return result
+				}
+				return router.OKResult(report, "Status retrieved successfully")
+			}).Describe(router.OperationDoc{
+				Summary: "Component uptime over the last 24 hours and 7 days, and recent incidents", Response: status.Report{},
+			})
+			rs.AddGetHandler(c, nil, "/page", func(ctx *router.RequestContext) *router.ServiceResult {
+				report, result := ctrl.currentReport(ctx)
+				if result != nil {
+					return result
+				}
+				return router.HTMLResult(http.StatusOK, ctrl.pages, "status", report)
+			}).Describe(router.OperationDoc{Summary: "Human-readable status page"})
+		},
+	)
+}
+
+// currentReport returns a report at most reportTTL old.
+func (ctrl *controller) currentReport(ctx *router.RequestContext) (*status.Report, *router.ServiceResult) {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+
+	now := ctrl.now()
+	if ctrl.report != nil && now.Sub(ctrl.loadedAt) < reportTTL {
+		return ctrl.report, nil
+	}
+
+	current := make(map[string]bool)
+	if ctrl.probes != nil {
+		for _, s := range ctrl.probes.Snapshot() {
+			if s.Samples > 0 {
+				current[s.Name] = s.Healthy
+			}
+		}
+	}
+	report, err := status.BuildReport(ctx.Request.Context(), ctrl.store, current, now)
+	if err != nil {
+		router.GetLogger(ctx).Error("Failed to build status report", "error", err)
+		return nil, router.InternalServerErrorResult("Failed to load status")
+	}
+	ctrl.report, ctrl.loadedAt = report, now
+	return report, nil
+}
+
+func formatUptime(v *float64) string {
+	if v == nil {
+		return "no data"
+	}
+	return fmt.Sprintf("%.2f%%", *v)
+}
+
+// dayClass colours a day of the history: up at 99% or more, down below it.
+func dayClass(v *float64) string {
+	switch {
+	case v == nil:
+		return ""
+	case *v >= 99:
+		return "up"
+	default:
+		return "down"
+	}
+}
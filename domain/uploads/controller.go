@@ -0,0 +1,343 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/operations"
+	"github.com/akeren/go-api-foundry/pkg/uploads"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+
+	// MetadataImport is the Upload-Metadata key naming the importer that
+	// processes the completed upload; MetadataWebhookURL optionally receives
+	// the import operation's outcome.
+	MetadataImport     = "import"
+	MetadataWebhookURL = "webhook_url"
+
+	purgeInterval = 10 * time.Minute
+
+	// chunkBodyLimit is the largest PATCH chunk, independent of
+	// MAX_REQUEST_BODY_BYTES.
+	chunkBodyLimit = 10 << 20
+)
+
+// Importer processes a completed upload inside a long-running operation.
+// Its result becomes the operation result.
+type Importer func(ctx context.Context, r io.Reader) (any, error)
+
+type purger interface {
+	PurgeExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type uploadsController struct {
+	logger    *log.Logger
+	store     uploads.Store
+	ops       *operations.Manager
+	cfg       uploads.Config
+	importers map[string]Importer
+	lastPurge atomic.Int64
+}
+
+// NewUploadsController serves tus 1.0.0 resumable uploads under /v1/uploads.
+// Each upload names an importer in its metadata; once the last byte arrives
+// the import runs as a long-running operation whose status URL is returned
+// in the Operation-Location header.
+func NewUploadsController(logger *log.Logger, store uploads.Store, ops *operations.Manager, cfg uploads.Config, importers map[string]Importer) *router.RESTController {
+	ctrl := &uploadsController{
+		logger:    logger,
+		store:     store,
+		ops:       ops,
+		cfg:       cfg,
+		importers: importers,
+	}
+
+	// tus clients resume with HEAD and Upload-Offset rather than
+	// Idempotency-Key, and buffering chunks to fingerprint them would defeat
+	// streaming.
+	return router.NewVersionedRESTController(
+		"UploadsController",
+		"v1",
+		"/uploads",
+		func(rs router.RouteRegistrar, c *router.RESTController) {
+			tus := requireTusResumable()
+
+			rs.AddOptionsHandler(c, nil, "", ctrl.options)
+			rs.AddPostHandler(c, nil, "", ctrl.create, tus)
+			rs.AddHeadHandler(c, nil, "/:id", ctrl.head, tus)
+			rs.AddPatchHandler(c, nil, "/:id", ctrl.patch, tus).MaxBodyBytes(chunkBodyLimit)
+			rs.AddDeleteHandler(c, nil, "/:id", ctrl.terminate, tus)
+		},
+	).Idempotency(router.IdempotencyOptions{Disabled: true})
+}
+
+func requireTusResumable() router.MiddlewareFunc {
+	return func(ctx *router.RequestContext) {
+		ctx.Header("Tus-Resumable", tusVersion)
+		if ctx.GetHeader("Tus-Resumable") != tusVersion {
+			ctx.Header("Tus-Version", tusVersion)
+			ctx.AbortWithStatusJSON(http.StatusPreconditionFailed, router.ErrorResult(
+				http.StatusPreconditionFailed,
+				"Unsupported Tus-Resumable version; this server supports "+tusVersion,
+				nil,
+			).ToJSON())
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func uploadLocation(id string) string {
+	return "/v1/uploads/" + id
+}
+
+func (c *uploadsController) options(ctx *router.RequestContext) *router.ServiceResult {
+	return router.NoContentResult().
+		WithHeader("Tus-Resumable", tusVersion).
+		WithHeader("Tus-Version", tusVersion).
+		WithHeader("Tus-Extension", tusExtensions).
+		WithHeader("Tus-Max-Size", strconv.FormatInt(c.cfg.MaxSize, 10))
+}
+
+func (c *uploadsController) create(ctx *router.RequestContext) *router.ServiceResult {
+	size, err := strconv.ParseInt(ctx.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		return router.BadRequestResult("Upload-Length must be a non-negative integer", nil)
+	}
+	if size > c.cfg.MaxSize {
+		return router.ErrorResult(http.StatusRequestEntityTooLarge, "Upload-Length exceeds Tus-Max-Size", nil)
+	}
+
+	meta, err := uploads.ParseMetadata(ctx.GetHeader("Upload-Metadata"))
+	if err != nil {
+		return router.BadRequestResult("Upload-Metadata is malformed", nil)
+	}
+	if _, ok := c.importers[meta[MetadataImport]]; !ok {
+		return router.BadRequestResult("Upload-Metadata import must be one of: "+strings.Join(c.importerNames(), ", "), nil)
+	}
+	if hook := meta[MetadataWebhookURL]; hook != "" {
+		if err := operations.ValidateWebhookURL(hook); err != nil {
+			return router.BadRequestResult("Upload-Metadata webhook_url must be an absolute http(s) URL", nil)
+		}
+	}
+
+	c.purgeExpired(ctx.Request.Context())
+
+	now := time.Now()
+	info := &uploads.Info{
+		Size:      size,
+		Metadata:  meta,
+		CreatedAt: now,
+		ExpiresAt: now.Add(c.cfg.Expiry),
+	}
+	if err := c.store.Create(ctx.Request.Context(), info); err != nil {
+		router.GetLogger(ctx).Error("Failed to create upload", "error", err)
+		return router.InternalServerErrorResult("Failed to create upload")
+	}
+
+	router.GetLogger(ctx).Info("Upload created", "upload_id", info.ID, "size", size, "import", meta[MetadataImport])
+	result := router.CreatedResult(nil, "Upload").
+		WithHeader("Location", uploadLocation(info.ID)).
+		WithHeader("Upload-Expires", info.ExpiresAt.UTC().Format(http.TimeFormat))
+
+	if info.Done() {
+		return c.complete(ctx, info, result)
+	}
+	return result
+}
+
+func (c *uploadsController) head(ctx *router.RequestContext) *router.ServiceResult {
+	info, failure := c.load(ctx)
+	if failure != nil {
+		return failure
+	}
+
+	result := router.OKResult(nil, "Upload status").
+		WithHeader("Cache-Control", "no-store").
+		WithHeader("Upload-Offset", strconv.FormatInt(info.Offset, 10)).
+		WithHeader("Upload-Length", strconv.FormatInt(info.Size, 10)).
+		WithHeader("Upload-Expires", info.ExpiresAt.UTC().Format(http.TimeFormat))
+	if len(info.Metadata) > 0 {
+		result.WithHeader("Upload-Metadata", uploads.EncodeMetadata(info.Metadata))
+	}
+	if info.OperationID != "" {
+		result.WithHeader("Operation-Location", operations.Location(info.OperationID))
+	}
+	return result
+}
+
+func (c *uploadsController) patch(ctx *router.RequestContext) *router.ServiceResult {
+	if ctx.ContentType() != "application/offset+octet-stream" {
+		return router.ErrorResult(http.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream", nil)
+	}
+	offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return router.BadRequestResult("Upload-Offset must be a non-negative integer", nil)
+	}
+
+	info, failure := c.load(ctx)
+	if failure != nil {
+		return failure
+	}
+	if offset != info.Offset {
+		return router.ConflictResult("Upload-Offset does not match the current upload offset")
+	}
+	if ctx.Request.ContentLength > 0 && offset+ctx.Request.ContentLength > info.Size {
+		return router.ErrorResult(http.StatusRequestEntityTooLarge, "Chunk extends past Upload-Length", nil)
+	}
+
+	updated, err := c.store.Append(ctx.Request.Context(), info.ID, offset, ctx.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, uploads.ErrOffsetMismatch):
+			return router.ConflictResult("Upload-Offset does not match the current upload offset")
+		case errors.As(err, &maxBytesErr):
+			return router.ErrorResult(http.StatusRequestEntityTooLarge, "Chunk exceeds the request body limit; use a smaller chunk size", nil)
+		case updated != nil:
+			// The client went away mid-chunk; the bytes received are kept.
+			router.GetLogger(ctx).Warn("Upload chunk interrupted", "upload_id", info.ID, "offset", updated.Offset, "error", err)
+			return router.BadRequestResult("Upload chunk was interrupted; resume from Upload-Offset", nil).
+				WithHeader("Upload-Offset", strconv.FormatInt(updated.Offset, 10))
+		}
+		router.GetLogger(ctx).Error("Failed to store upload chunk", "upload_id", info.ID, "error", err)
+		return router.InternalServerErrorResult("Failed to store upload chunk")
+	}
+
+	result := router.NoContentResult().
+		WithHeader("Upload-Offset", strconv.FormatInt(updated.Offset, 10)).
+		WithHeader("Upload-Expires", updated.ExpiresAt.UTC().Format(http.TimeFormat))
+
+	if updated.Done() && updated.OperationID == "" {
+		return c.complete(ctx, updated, result)
+	}
+	if updated.OperationID != "" {
+		result.WithHeader("Operation-Location", operations.Location(updated.OperationID))
+	}
+	return result
+}
+
+func (c *uploadsController) terminate(ctx *router.RequestContext) *router.ServiceResult {
+	info, failure := c.load(ctx)
+	if failure != nil {
+		return failure
+	}
+	if err := c.store.Delete(ctx.Request.Context(), info.ID); err != nil && !errors.Is(err, uploads.ErrNotFound) {
+		router.GetLogger(ctx).Error("Failed to delete upload", "upload_id", info.ID, "error", err)
+		return router.InternalServerErrorResult("Failed to delete upload")
+	}
+	return router.NoContentResult()
+}
+
+// load fetches the upload named by the :id param, treating expired uploads
+// as gone.
+func (c *uploadsController) load(ctx *router.RequestContext) (*uploads.Info, *router.ServiceResult) {
+	info, err := c.store.Get(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, uploads.ErrNotFound) {
+			return nil, router.NotFoundResult("Upload not found")
+		}
+		router.GetLogger(ctx).Error("Failed to load upload", "error", err)
+		return nil, router.InternalServerErrorResult("Failed to load upload")
+	}
+	if !time.Now().Before(info.ExpiresAt) {
+		return nil, router.ErrorResult(http.StatusGone, "Upload has expired", nil)
+	}
+	return info, nil
+}
+
+// complete starts the import of a fully received upload. If it cannot start,
+// the client retries the final PATCH (at offset == length) to try again.
+func (c *uploadsController) complete(ctx *router.RequestContext, info *uploads.Info, result *router.ServiceResult) *router.ServiceResult {
+	kind := info.Metadata[MetadataImport]
+	importer := c.importers[kind]
+	store := c.store
+	logger := c.logger
+
+	op, err := c.ops.Start(ctx.Request.Context(), "import."+kind, info.Metadata[MetadataWebhookURL],
+		func(opCtx context.Context, progress *operations.Progress) (any, error) {
+			file, err := store.Open(opCtx, info.ID)
+			if err != nil {
+				return nil, errors.New("uploaded file is no longer available")
+			}
+			defer file.Close()
+
+			out, err := importer(opCtx, &progressReader{ctx: opCtx, r: file, size: info.Size, progress: progress})
+			if err != nil {
+				return nil, err
+			}
+			// The data has been consumed; free the space now instead of at expiry.
+			if err := store.Delete(opCtx, info.ID); err != nil {
+				logger.Warn("Failed to delete imported upload", "upload_id", info.ID, "error", err)
+			}
+			return out, nil
+		})
+	if err != nil {
+		router.GetLogger(ctx).Error("Failed to start upload import", "upload_id", info.ID, "error", err)
+		return router.ErrorResult(http.StatusServiceUnavailable, "Upload received but the import could not be started; retry the final request", nil).
+			WithHeader("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	}
+
+	if err := c.store.SetOperation(ctx.Request.Context(), info.ID, op.ID); err != nil {
+		router.GetLogger(ctx).Warn("Failed to record import operation on upload", "upload_id", info.ID, "operation_id", op.ID, "error", err)
+	}
+	router.GetLogger(ctx).Info("Upload complete; import started", "upload_id", info.ID, "operation_id", op.ID, "import", kind)
+	return result.WithHeader("Operation-Location", operations.Location(op.ID))
+}
+
+// purgeExpired removes expired uploads at most once per purgeInterval.
+func (c *uploadsController) purgeExpired(ctx context.Context) {
+	p, ok := c.store.(purger)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	last := c.lastPurge.Load()
+	if now.UnixNano()-last < int64(purgeInterval) || !c.lastPurge.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	if n, err := p.PurgeExpired(ctx, now); err != nil {
+		c.logger.Warn("Failed to purge expired uploads", "error", err)
+	} else if n > 0 {
+		c.logger.Info("Purged expired uploads", "count", n)
+	}
+}
+
+func (c *uploadsController) importerNames() []string {
+	names := make([]string, 0, len(c.importers))
+	for name := range c.importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// progressReader reports import progress as the share of upload bytes read.
+type progressReader struct {
+	ctx      context.Context
+	r        io.Reader
+	size     int64
+	read     int64
+	progress *operations.Progress
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.size > 0 {
+		p.progress.Set(p.ctx, int(p.read*100/p.size))
+	}
+	return n, err
+}
@@ -0,0 +1,159 @@
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/config/router"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/operations"
+	"github.com/akeren/go-api-foundry/pkg/uploads"
+)
+
+func newTestRouter(t *testing.T) (*router.RouterService, *operations.Manager) {
+	t.Helper()
+	logger := log.NewLoggerWithJSONOutput()
+
+	store, err := uploads.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	ops := operations.NewManager(operations.NewMemoryStore(), logger, operations.DefaultConfig())
+	t.Cleanup(func() { ops.Shutdown(context.Background()) })
+
+	importers := map[string]Importer{
+		"lines": func(_ context.Context, r io.Reader) (any, error) {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]int{"lines": strings.Count(string(data), "\n")}, nil
+		},
+	}
+
+	rs := router.CreateRouterService(logger, nil, &router.RouterConfig{
+		RateLimitRequests: 1000,
+		RateLimitWindow:   time.Minute,
+		RequestTimeout:    5 * time.Second,
+	})
+	rs.MountController(NewUploadsController(logger, store, ops, uploads.Config{MaxSize: 1 << 20, Expiry: time.Hour}, importers))
+	return rs, ops
+}
+
+func tusRequest(method, path string, body []byte, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Tus-Resumable", tusVersion)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func serve(rs *router.RouterService, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	rs.GetEngine().ServeHTTP(w, req)
+	return w
+}
+
+func TestUploads_ResumableUploadStartsImport(t *testing.T) {
+	rs, ops := newTestRouter(t)
+	content := []byte("a\nb\nc\n")
+	meta := "import " + base64.StdEncoding.EncodeToString([]byte("lines"))
+
+	w := serve(rs, tusRequest(http.MethodPost, "/v1/uploads", nil, map[string]string{
+		"Upload-Length":   "6",
+		"Upload-Metadata": meta,
+	}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+	if !strings.HasPrefix(location, "/v1/uploads/") || w.Header().Get("Tus-Resumable") != tusVersion {
+		t.Fatalf("unexpected create headers: %v", w.Header())
+	}
+
+	patch := func(offset string, chunk []byte) *httptest.ResponseRecorder {
+		return serve(rs, tusRequest(http.MethodPatch, location, chunk, map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": offset,
+		}))
+	}
+
+	if w := patch("0", content[:4]); w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "4" {
+		t.Fatalf("first chunk: got %d offset %q", w.Code, w.Header().Get("Upload-Offset"))
+	}
+	if w := patch("0", content[:4]); w.Code != http.StatusConflict {
+		t.Fatalf("replayed chunk: expected 409, got %d", w.Code)
+	}
+
+	w = serve(rs, tusRequest(http.MethodHead, location, nil, nil))
+	if w.Code != http.StatusOK || w.Header().Get("Upload-Offset") != "4" || w.Header().Get("Upload-Length") != "6" {
+		t.Fatalf("head: got %d %v", w.Code, w.Header())
+	}
+
+	w = patch("4", content[4:])
+	if w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "6" {
+		t.Fatalf("last chunk: got %d offset %q", w.Code, w.Header().Get("Upload-Offset"))
+	}
+	opLocation := w.Header().Get("Operation-Location")
+	if !strings.HasPrefix(opLocation, "/v1/operations/") {
+		t.Fatalf("expected Operation-Location, got %q", opLocation)
+	}
+
+	id := strings.TrimPrefix(opLocation, "/v1/operations/")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		op, err := ops.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("get operation: %v", err)
+		}
+		if op.Status.Done() {
+			var result map[string]int
+			json.Unmarshal(op.Result, &result)
+			if op.Status != operations.StatusSucceeded || result["lines"] != 3 {
+				t.Fatalf("unexpected import outcome: %+v", op)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("import did not finish")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestUploads_ProtocolErrors(t *testing.T) {
+	rs, _ := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/uploads", nil)
+	req.Header.Set("Upload-Length", "6")
+	if w := serve(rs, req); w.Code != http.StatusPreconditionFailed || w.Header().Get("Tus-Version") != tusVersion {
+		t.Fatalf("missing Tus-Resumable: expected 412, got %d", w.Code)
+	}
+
+	w := serve(rs, tusRequest(http.MethodPost, "/v1/uploads", nil, map[string]string{
+		"Upload-Length":   "6",
+		"Upload-Metadata": "import " + base64.StdEncoding.EncodeToString([]byte("unknown")),
+	}))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown importer: expected 400, got %d", w.Code)
+	}
+
+	w = serve(rs, tusRequest(http.MethodPost, "/v1/uploads", nil, map[string]string{"Upload-Length": "99999999"}))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload: expected 413, got %d", w.Code)
+	}
+
+	w = serve(rs, httptest.NewRequest(http.MethodOptions, "/v1/uploads", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("Tus-Extension") != tusExtensions || w.Header().Get("Tus-Max-Size") != "1048576" {
+		t.Fatalf("options: got %d %v", w.Code, w.Header())
+	}
+}
@@ -2,20 +2,40 @@ package integration
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/akeren/go-api-foundry/config"
 	"github.com/akeren/go-api-foundry/config/router"
 	"github.com/akeren/go-api-foundry/domain"
+	"github.com/akeren/go-api-foundry/domain/ledger"
 	"github.com/akeren/go-api-foundry/internal/log"
 	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/internal/testkit"
+	apperrors "github.com/akeren/go-api-foundry/pkg/errors"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/fieldcrypt"
+	"github.com/akeren/go-api-foundry/pkg/operations"
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"github.com/akeren/go-api-foundry/pkg/rbac"
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"github.com/akeren/go-api-foundry/pkg/replica"
+	"github.com/akeren/go-api-foundry/pkg/status"
+	"github.com/akeren/go-api-foundry/pkg/txmanager"
+	"github.com/akeren/go-api-foundry/pkg/webhooks"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/driver/sqlite"
@@ -42,8 +62,29 @@ func (s *LedgerAPITestSuite) SetupSuite() {
 	s.Require().NoError(err)
 	sqlDB.SetMaxOpenConns(1)
 
-	err = s.db.AutoMigrate(&models.Account{}, &models.Transaction{}, &models.LedgerEntry{})
+	err = s.db.AutoMigrate(&models.Account{}, &models.Transaction{}, &models.LedgerEntry{}, &models.TransactionSearchToken{}, &models.Operation{}, &models.TransferQuote{}, &models.RoleBinding{}, &models.StatusCheck{}, &models.Incident{}, &models.WebhookEndpoint{}, &models.WebhookDelivery{}, &models.WebhookAttempt{}, &models.IdempotencyRecord{}, &models.FXRate{}, &models.Hold{}, &models.ArchivedTransaction{}, &models.ArchivedLedgerEntry{}, &models.ArchivedDailyTotal{}, &models.BalanceAlert{}, &models.BalanceAlertTrigger{}, &models.AccrualPlan{}, &models.AccrualRecord{}, &models.DailySeal{})
 	s.Require().NoError(err)
+	// Record the schema as migrated for the readiness check.
+	s.Require().NoError(s.db.Exec("CREATE TABLE schema_migrations (version bigint, dirty boolean)").Error)
+	s.Require().NoError(s.db.Exec("INSERT INTO schema_migrations VALUES (23, false)").Error)
+	// SQLite has no materialized views; a plain view with the same columns
+	// stands in for account_daily_balances and is always current.
+	s.Require().NoError(s.db.Exec(`CREATE VIEW account_daily_balances AS
+		SELECT account_id, day, credits, debits, entry_count,
+			SUM(credits - debits) OVER (PARTITION BY account_id ORDER BY day) AS closing_balance
+		FROM (
+			SELECT account_id, day, SUM(credits) AS credits, SUM(debits) AS debits, SUM(entry_count) AS entry_count
+			FROM (
+				SELECT account_id, date(created_at) AS day,
+					CASE WHEN entry_type = 'CREDIT' THEN amount ELSE 0 END AS credits,
+					CASE WHEN entry_type = 'DEBIT' THEN amount ELSE 0 END AS debits,
+					1 AS entry_count
+				FROM ledger_entries
+				UNION ALL
+				SELECT account_id, date(day), credits, debits, entry_count FROM archived_daily_totals
+			)
+			GROUP BY account_id, day
+		)`).Error)
 
 	// Seed system account
 	systemAccount := models.Account{
@@ -57,10 +98,18 @@ func (s *LedgerAPITestSuite) SetupSuite() {
 
 	s.logger = log.NewLoggerWithJSONOutput()
 
+	bus := events.NewBus(s.logger)
 	s.appConfig = &config.ApplicationConfig{
-		DB:     s.db,
-		Logger: s.logger,
+		DB:         s.db,
+		Logger:     s.logger,
+		Operations: operations.NewManager(operations.NewGormStore(s.db), s.logger, operations.DefaultConfig()),
+		Events:     bus,
+		Probes:     config.NewDependencyProber(s.logger, s.db, nil),
+		Status:     status.NewRecorder(status.NewGormStore(s.db), s.logger, status.DefaultConfig()),
+		Migrations: config.NewMigrationCheck(s.db, false),
+		Webhooks:   webhooks.NewDispatcher(webhooks.NewGormStore(s.db, nil), s.logger, webhooks.Config{}),
 	}
+	bus.Subscribe("*", s.appConfig.Webhooks.Handle)
 
 	s.appConfig.RouterService = router.CreateRouterService(s.logger, nil, &router.RouterConfig{
 		RateLimitRequests: 1000,
@@ -78,16 +127,27 @@ func (s *LedgerAPITestSuite) TearDownSuite() {
 	if s.server != nil {
 		s.server.Close()
 	}
-	if s.db != nil {
-		sqlDB, _ := s.db.DB()
-		sqlDB.Close()
-	}
+	// Stops background work and closes the database, as on server shutdown.
+	s.appConfig.Cleanup()
 }
 
 func (s *LedgerAPITestSuite) SetupTest() {
 	// Clean ledger data between tests (keep system account)
 	s.db.Exec("DELETE FROM ledger_entries")
+	s.db.Exec("DELETE FROM transaction_search_tokens")
+	s.db.Exec("DELETE FROM operations")
+	s.db.Exec("DELETE FROM transfer_quotes")
+	s.db.Exec("DELETE FROM fx_rates")
+	s.db.Exec("DELETE FROM holds")
 	s.db.Exec("DELETE FROM transactions")
+	s.db.Exec("DELETE FROM archived_ledger_entries")
+	s.db.Exec("DELETE FROM archived_transactions")
+	s.db.Exec("DELETE FROM archived_daily_totals")
+	s.db.Exec("DELETE FROM balance_alert_triggers")
+	s.db.Exec("DELETE FROM balance_alerts")
+	s.db.Exec("DELETE FROM accrual_records")
+	s.db.Exec("DELETE FROM accrual_plans")
+	s.db.Exec("DELETE FROM daily_seals")
 	s.db.Exec("DELETE FROM accounts WHERE id != ?", models.SystemAccountID)
 	s.db.Model(&models.Account{}).Where("id = ?", models.SystemAccountID).Updates(map[string]any{
 		"balance": 0,
@@ -141,6 +201,17 @@ func (s *LedgerAPITestSuite) withdraw(accountID string, amount int64, key string
 	return response
 }
 
+func (s *LedgerAPITestSuite) post(path string, payload any) (int, map[string]any) {
+	body, _ := json.Marshal(payload)
+	resp, err := http.Post(s.baseURL+path, "application/json", bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	var response map[string]any
+	json.NewDecoder(resp.Body).Decode(&response)
+	return resp.StatusCode, response
+}
+
 func (s *LedgerAPITestSuite) entriesByType(entries []any) map[string]map[string]any {
 	result := make(map[string]map[string]any, len(entries))
 	for _, e := range entries {
@@ -369,6 +440,43 @@ func (s *LedgerAPITestSuite) TestGetBalance() {
 	s.Equal(true, data["is_consistent"])
 }
 
+func (s *LedgerAPITestSuite) TestBalanceETag() {
+	account := s.createAccount("Gwen")
+	accountID := account["id"].(string)
+	s.deposit(accountID, 10000, "dep-etag-1")
+
+	getBalance := func(ifNoneMatch string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/ledger/accounts/%s/balance", s.baseURL, accountID), nil)
+		s.Require().NoError(err)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		return resp
+	}
+
+	first := getBalance("")
+	s.Equal(http.StatusOK, first.StatusCode)
+	tag := first.Header.Get("ETag")
+	s.NotEmpty(tag)
+	s.Equal(http.StatusNotModified, getBalance(tag).StatusCode)
+
+	// Postings and holds both change the balance response.
+	s.withdraw(accountID, 1000, "wd-etag-1")
+	afterPosting := getBalance(tag)
+	s.Equal(http.StatusOK, afterPosting.StatusCode)
+	s.NotEqual(tag, afterPosting.Header.Get("ETag"))
+
+	tag = afterPosting.Header.Get("ETag")
+	status, _ := s.post("/v1/ledger/accounts/"+accountID+"/holds", map[string]any{"amount": 2000, "idempotency_key": "hold-etag-1"})
+	s.Equal(http.StatusCreated, status)
+	afterHold := getBalance(tag)
+	s.Equal(http.StatusOK, afterHold.StatusCode)
+	s.NotEqual(tag, afterHold.Header.Get("ETag"))
+}
+
 func (s *LedgerAPITestSuite) TestGetTransactions() {
 	account := s.createAccount("Heidi")
 	accountID := account["id"].(string)
@@ -382,10 +490,499 @@ func (s *LedgerAPITestSuite) TestGetTransactions() {
 
 	s.Equal(http.StatusOK, resp.StatusCode)
 
+	var response map[string]any
+	json.NewDecoder(resp.Body).Decode(&response)
+	page := response["data"].(map[string]any)
+	s.Len(page["data"], 2)
+	s.Equal(float64(2), page["total"])
+
+	first := page["data"].([]any)[0].(map[string]any)
+	s.NotContains(first, "entries")
+	s.Equal(map[string]any{"entry_count": float64(2), "net_effect": float64(3000)}, first["summary"])
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/ledger/accounts/%s/transactions?include=entries", s.baseURL, accountID))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+
+	response = nil
+	json.NewDecoder(resp.Body).Decode(&response)
+	first = response["data"].(map[string]any)["data"].([]any)[0].(map[string]any)
+	s.Len(first["entries"], 2)
+	s.NotNil(first["summary"])
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/ledger/accounts/%s/transactions?include=everything", s.baseURL, accountID))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/ledger/transactions/%s", s.baseURL, first["id"]))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+	response = nil
+	json.NewDecoder(resp.Body).Decode(&response)
+	detail := response["data"].(map[string]any)
+	s.Len(detail["entries"], 2)
+	s.NotContains(detail, "summary")
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/ledger/transactions/%s", s.baseURL, uuid.NewString()))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *LedgerAPITestSuite) TestGetTransactionsPaginates() {
+	account := s.createAccount("Ivan")
+	accountID := account["id"].(string)
+
+	for i := range 3 {
+		s.deposit(accountID, 1000, fmt.Sprintf("dep-page-%d", i))
+	}
+
+	type transactionsPage struct {
+		Data       []map[string]any `json:"data"`
+		Page       int              `json:"page"`
+		Total      int64            `json:"total"`
+		TotalPages int              `json:"total_pages"`
+		Links      struct {
+			Next string `json:"next"`
+			Prev string `json:"prev"`
+		} `json:"links"`
+	}
+	get := func(path string) transactionsPage {
+		resp, err := http.Get(s.baseURL + path)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var body struct {
+			Data transactionsPage `json:"data"`
+		}
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&body))
+		return body.Data
+	}
+
+	first := get(fmt.Sprintf("/v1/ledger/accounts/%s/transactions?per_page=2", accountID))
+	s.Len(first.Data, 2)
+	s.Equal(int64(3), first.Total)
+	s.Equal(2, first.TotalPages)
+	s.Empty(first.Links.Prev)
+	s.Require().NotEmpty(first.Links.Next)
+
+	second := get(first.Links.Next)
+	s.Equal(2, second.Page)
+	s.Require().Len(second.Data, 1)
+	s.NotContains([]any{first.Data[0]["id"], first.Data[1]["id"]}, second.Data[0]["id"])
+	s.Empty(second.Links.Next)
+}
+
+func (s *LedgerAPITestSuite) TestSearchTransactions() {
+	account := s.createAccount("Hank")
+	accountID := account["id"].(string)
+
+	s.deposit(accountID, 5000, "dep-search-1")
+	s.withdraw(accountID, 1000, "wd-search-1")
+
+	resp, err := http.Get(fmt.Sprintf("%s/v1/ledger/transactions/search?q=Deposit&account_id=%s", s.baseURL, accountID))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Equal(http.StatusOK, resp.StatusCode)
+
 	var response map[string]any
 	json.NewDecoder(resp.Body).Decode(&response)
 	data := response["data"].([]any)
-	s.Len(data, 2)
+	s.Require().Len(data, 1)
+	s.Equal("test deposit", data[0].(map[string]any)["description"])
+}
+
+func (s *LedgerAPITestSuite) TestEncryptedDescriptionSearch() {
+	cipher, err := fieldcrypt.New([]fieldcrypt.Key{{ID: "k1", Secret: bytes.Repeat([]byte{7}, 32)}}, bytes.Repeat([]byte{9}, 32))
+	s.Require().NoError(err)
+
+	service := ledger.NewLedgerService(s.logger, ledger.NewLedgerRepository(s.db, cipher), ledger.Pricing{}, nil)
+	account := s.createAccount("Ida")
+	accountID := account["id"].(string)
+
+	ctx := context.Background()
+	created, err := service.Deposit(ctx, accountID, &ledger.DepositRequest{
+		Amount:         2500,
+		IdempotencyKey: "dep-encrypted-1",
+		Description:    "Refund for invoice 4411",
+	})
+	s.Require().NoError(err)
+	s.Equal("Refund for invoice 4411", created.Description)
+
+	var stored models.Transaction
+	s.Require().NoError(s.db.First(&stored, "id = ?", created.ID).Error)
+	s.NotContains(stored.Description, "invoice")
+	s.True(strings.HasPrefix(stored.Description, "enc:v1:k1:"))
+
+	found, err := service.SearchTransactions(ctx, ledger.TransactionSearchQuery{Text: "invoice refund", Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Equal("Refund for invoice 4411", found[0].Description)
+
+	missing, err := service.SearchTransactions(ctx, ledger.TransactionSearchQuery{Text: "invoice chargeback", Limit: 10})
+	s.Require().NoError(err)
+	s.Empty(missing)
+}
+
+func (s *LedgerAPITestSuite) TestSearchTransactionsRequiresQuery() {
+	resp, err := http.Get(s.baseURL + "/v1/ledger/transactions/search")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *LedgerAPITestSuite) TestBatchTransferOperation() {
+	alice := s.createAccount("Judy")["id"].(string)
+	bob := s.createAccount("Karl")["id"].(string)
+	s.deposit(alice, 5000, "dep-batch-1")
+
+	body, _ := json.Marshal(map[string]any{
+		"transfers": []map[string]any{
+			{"source_account_id": alice, "dest_account_id": bob, "amount": 1000, "idempotency_key": "batch-1"},
+			{"source_account_id": alice, "dest_account_id": bob, "amount": 9000, "idempotency_key": "batch-2"},
+		},
+	})
+	resp, err := http.Post(s.baseURL+"/v1/ledger/transfers/batch", "application/json", bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusAccepted, resp.StatusCode)
+	var accepted map[string]any
+	json.NewDecoder(resp.Body).Decode(&accepted)
+	operationID := accepted["data"].(map[string]any)["id"].(string)
+	s.Equal("/v1/operations/"+operationID, resp.Header.Get("Location"))
+
+	var op map[string]any
+	s.Eventually(func() bool {
+		pollResp, err := http.Get(s.baseURL + "/v1/operations/" + operationID)
+		if err != nil {
+			return false
+		}
+		defer pollResp.Body.Close()
+		var response map[string]any
+		json.NewDecoder(pollResp.Body).Decode(&response)
+		op, _ = response["data"].(map[string]any)
+		return op != nil && op["status"] == "SUCCEEDED"
+	}, 5*time.Second, 20*time.Millisecond)
+
+	s.Equal(float64(100), op["progress"])
+	result := op["result"].(map[string]any)
+	s.Equal(float64(1), result["succeeded"])
+	s.Equal(float64(1), result["failed"])
+
+	accountResp, err := http.Get(fmt.Sprintf("%s/v1/ledger/accounts/%s", s.baseURL, bob))
+	s.Require().NoError(err)
+	defer accountResp.Body.Close()
+	var account map[string]any
+	json.NewDecoder(accountResp.Body).Decode(&account)
+	s.Equal(float64(1000), account["data"].(map[string]any)["balance"])
+}
+
+func (s *LedgerAPITestSuite) TestGetOperationNotFound() {
+	resp, err := http.Get(s.baseURL + "/v1/operations/not-a-uuid")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *LedgerAPITestSuite) TestDryRunDepositPersistsNothing() {
+	accountID := s.createAccount("Lena")["id"].(string)
+
+	body, _ := json.Marshal(map[string]any{"amount": 2500, "idempotency_key": "dry-1"})
+	resp, err := http.Post(fmt.Sprintf("%s/v1/ledger/accounts/%s/deposit?dry_run=true", s.baseURL, accountID), "application/json", bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Equal(http.StatusOK, resp.StatusCode)
+	var response map[string]any
+	json.NewDecoder(resp.Body).Decode(&response)
+	data := response["data"].(map[string]any)
+	s.Equal(true, data["dry_run"])
+	credit := s.entriesByType(data["entries"].([]any))["CREDIT"]
+	s.Equal(float64(2500), credit["balance_after"])
+
+	var count int64
+	s.db.Model(&models.Transaction{}).Where("idempotency_key = ?", "dry-1").Count(&count)
+	s.Zero(count)
+
+	// The idempotency key is still free for the real request.
+	real := s.deposit(accountID, 1000, "dry-1")
+	s.Equal(float64(http.StatusCreated), real["code"])
+}
+
+func (s *LedgerAPITestSuite) TestDryRunWithdrawReportsInsufficientFunds() {
+	accountID := s.createAccount("Milo")["id"].(string)
+
+	body, _ := json.Marshal(map[string]any{"amount": 500, "idempotency_key": "dry-2"})
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v1/ledger/accounts/%s/withdraw", s.baseURL, accountID), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Dry-Run", "true")
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *LedgerAPITestSuite) TestTransferQuote() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	s.deposit(aliceID, 5000, "dep-quote-1")
+
+	status, response := s.post("/v1/ledger/transfers/quote", map[string]any{
+		"source_account_id": aliceID,
+		"dest_account_id":   bobID,
+		"amount":            1500,
+	})
+	s.Require().Equal(http.StatusCreated, status)
+	quote := response["data"].(map[string]any)
+	s.NotEmpty(quote["quote_id"])
+	s.Equal(float64(0), quote["fee"])
+	s.Equal("1", quote["exchange_rate"])
+	s.Equal(float64(1500), quote["dest_amount"])
+	s.Equal(float64(3500), quote["source_balance_after"])
+	s.Equal(float64(1500), quote["dest_balance_after"])
+
+	transfer := map[string]any{
+		"source_account_id": aliceID,
+		"dest_account_id":   bobID,
+		"amount":            1500,
+		"idempotency_key":   "xfr-quote-1",
+		"quote_id":          quote["quote_id"],
+	}
+	status, _ = s.post("/v1/ledger/transfers", transfer)
+	s.Equal(http.StatusCreated, status)
+
+	// Retrying the same request replays it; a new transfer cannot reuse the quote.
+	status, _ = s.post("/v1/ledger/transfers", transfer)
+	s.Equal(http.StatusCreated, status)
+
+	transfer["idempotency_key"] = "xfr-quote-2"
+	status, _ = s.post("/v1/ledger/transfers", transfer)
+	s.Equal(http.StatusConflict, status)
+}
+
+func (s *LedgerAPITestSuite) TestTransferQuoteRejected() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	s.deposit(aliceID, 5000, "dep-quote-2")
+
+	status, _ := s.post("/v1/ledger/transfers/quote", map[string]any{
+		"source_account_id": aliceID,
+		"dest_account_id":   bobID,
+		"amount":            9000,
+	})
+	s.Equal(http.StatusBadRequest, status)
+
+	_, response := s.post("/v1/ledger/transfers/quote", map[string]any{
+		"source_account_id": aliceID,
+		"dest_account_id":   bobID,
+		"amount":            1000,
+	})
+	quoteID := response["data"].(map[string]any)["quote_id"].(string)
+
+	status, _ = s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": aliceID,
+		"dest_account_id":   bobID,
+		"amount":            500,
+		"idempotency_key":   "xfr-quote-3",
+		"quote_id":          quoteID,
+	})
+	s.Equal(http.StatusBadRequest, status)
+
+	s.db.Model(&models.TransferQuote{}).Where("id = ?", quoteID).Update("expires_at", time.Now().Add(-time.Minute))
+	status, _ = s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": aliceID,
+		"dest_account_id":   bobID,
+		"amount":            1000,
+		"idempotency_key":   "xfr-quote-4",
+		"quote_id":          quoteID,
+	})
+	s.Equal(http.StatusConflict, status)
+
+	status, _ = s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": aliceID,
+		"dest_account_id":   bobID,
+		"amount":            1000,
+		"idempotency_key":   "xfr-quote-5",
+		"quote_id":          "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+	})
+	s.Equal(http.StatusNotFound, status)
+}
+
+func (s *LedgerAPITestSuite) TestUnitOfWorkSpansRepositoryCalls() {
+	repository := ledger.NewLedgerRepository(s.db, nil)
+	service := ledger.NewLedgerService(s.logger, repository, ledger.Pricing{}, nil)
+	manager := txmanager.New(s.db)
+
+	openAndFund := func(name, key string, fail error) (string, error) {
+		var accountID string
+		err := manager.WithinTransaction(context.Background(), func(ctx context.Context) error {
+			account, err := repository.CreateAccount(ctx, &models.Account{Name: name, AccountType: models.AccountTypeUser, Currency: "USD"})
+			if err != nil {
+				return err
+			}
+			accountID = account.ID
+			// The deposit reads the account created above, uncommitted.
+			if _, err := service.Deposit(ctx, account.ID, &ledger.DepositRequest{Amount: 2500, IdempotencyKey: key}); err != nil {
+				return err
+			}
+			return fail
+		})
+		return accountID, err
+	}
+
+	failure := errors.New("later step failed")
+	accountID, err := openAndFund("Rolled Back", "uow-dep-1", failure)
+	s.Require().ErrorIs(err, failure)
+	_, err = repository.GetAccountByID(context.Background(), accountID)
+	s.ErrorIs(err, ledger.ErrAccountNotFound)
+	var posted int64
+	s.Require().NoError(s.db.Model(&models.Transaction{}).Where("idempotency_key = ?", "uow-dep-1").Count(&posted).Error)
+	s.Zero(posted)
+
+	accountID, err = openAndFund("Committed", "uow-dep-2", nil)
+	s.Require().NoError(err)
+	account, err := repository.GetAccountByID(context.Background(), accountID)
+	s.Require().NoError(err)
+	s.Equal(int64(2500), account.Balance)
+}
+
+func (s *LedgerAPITestSuite) TestSelfTestLeavesNoTrace() {
+	var accountsBefore, transactionsBefore int64
+	s.Require().NoError(s.db.Model(&models.Account{}).Count(&accountsBefore).Error)
+	s.Require().NoError(s.db.Model(&models.Transaction{}).Count(&transactionsBefore).Error)
+
+	report := s.appConfig.SelfTest(context.Background(), 10*time.Second)
+	s.True(report.Passed(), "%+v", report.Results)
+	outcomes := map[string]string{}
+	for _, result := range report.Results {
+		switch {
+		case result.Skipped:
+			outcomes[result.Name] = "skipped"
+		case result.Passed:
+			outcomes[result.Name] = "passed"
+		default:
+			outcomes[result.Name] = "failed"
+		}
+	}
+	s.Equal(map[string]string{"database": "passed", "cache": "skipped", "routes": "passed", "ledger": "passed"}, outcomes)
+
+	var accountsAfter, transactionsAfter int64
+	s.Require().NoError(s.db.Model(&models.Account{}).Count(&accountsAfter).Error)
+	s.Require().NoError(s.db.Model(&models.Transaction{}).Count(&transactionsAfter).Error)
+	s.Equal(accountsBefore, accountsAfter)
+	s.Equal(transactionsBefore, transactionsAfter)
+}
+
+func (s *LedgerAPITestSuite) TestQuotedFeeIsLocked() {
+	ctx := context.Background()
+	repository := ledger.NewLedgerRepository(s.db, nil)
+	quoting := ledger.NewLedgerService(s.logger, repository, ledger.Pricing{Fees: ledger.FeeSchedule{BasisPoints: 100, Fixed: 25}}, nil)
+	// Fees changed after the quote was made.
+	executing := ledger.NewLedgerService(s.logger, repository, ledger.Pricing{Fees: ledger.FeeSchedule{Fixed: 500}}, nil)
+
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	s.deposit(aliceID, 10000, "dep-fee-1")
+
+	quote, err := quoting.QuoteTransfer(ctx, &ledger.TransferQuoteRequest{SourceAccountID: aliceID, DestAccountID: bobID, Amount: 1000})
+	s.Require().NoError(err)
+	s.Equal(int64(35), quote.Fee)
+
+	txn, err := executing.Transfer(ctx, &ledger.TransferRequest{
+		SourceAccountID: aliceID,
+		DestAccountID:   bobID,
+		Amount:          1000,
+		IdempotencyKey:  "xfr-fee-1",
+		QuoteID:         quote.QuoteID,
+	})
+	s.Require().NoError(err)
+	s.Equal(int64(35), txn.Fee)
+	s.Len(txn.Entries, 3)
+
+	alice, err := executing.GetBalance(ctx, aliceID)
+	s.Require().NoError(err)
+	s.Equal(int64(8965), alice.CachedBalance)
+	s.True(alice.IsConsistent)
+
+	// Without a quote the current fee applies.
+	txn, err = executing.Transfer(ctx, &ledger.TransferRequest{SourceAccountID: aliceID, DestAccountID: bobID, Amount: 1000, IdempotencyKey: "xfr-fee-2"})
+	s.Require().NoError(err)
+	s.Equal(int64(500), txn.Fee)
+
+	report, err := executing.Reconcile(ctx)
+	s.Require().NoError(err)
+	s.True(report.AllConsistent)
+	s.True(report.LedgerBalanced)
+}
+
+func (s *LedgerAPITestSuite) TestAccountHierarchy() {
+	merchantID := s.createAccount("Merchant")["id"].(string)
+
+	status, response := s.post("/v1/ledger/accounts", map[string]any{"name": "Store 1", "parent_id": merchantID})
+	s.Require().Equal(http.StatusCreated, status)
+	store1 := response["data"].(map[string]any)
+	s.Equal(merchantID, store1["parent_id"])
+	store1ID := store1["id"].(string)
+
+	_, response = s.post("/v1/ledger/accounts", map[string]any{"name": "Store 2", "parent_id": merchantID})
+	store2ID := response["data"].(map[string]any)["id"].(string)
+
+	status, _ = s.post("/v1/ledger/accounts", map[string]any{"name": "Orphan", "parent_id": "7c9e6679-7425-40de-944b-e07fc1f90ae7"})
+	s.Equal(http.StatusBadRequest, status)
+
+	s.deposit(merchantID, 1000, "dep-tree-1")
+	s.deposit(store1ID, 2000, "dep-tree-2")
+	s.deposit(store2ID, 500, "dep-tree-3")
+
+	resp, err := http.Get(fmt.Sprintf("%s/v1/ledger/accounts/%s/aggregate-balance", s.baseURL, merchantID))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+	var aggregate map[string]any
+	json.NewDecoder(resp.Body).Decode(&aggregate)
+	data := aggregate["data"].(map[string]any)
+	s.Equal(float64(1000), data["balance"])
+	s.Equal(float64(3500), data["total_balance"])
+	s.Equal(float64(2), data["sub_account_count"])
+	s.Len(data["children"], 2)
+
+	sibling := map[string]any{
+		"source_account_id": store1ID,
+		"dest_account_id":   store2ID,
+		"amount":            100,
+		"idempotency_key":   "xfr-sibling-1",
+	}
+	status, _ = s.post("/v1/ledger/transfers", sibling)
+	s.Equal(http.StatusCreated, status)
+
+	// Block transfers between the merchant's stores.
+	body, _ := json.Marshal(map[string]any{"sibling_transfers": "DENY"})
+	req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/v1/ledger/accounts/%s", s.baseURL, merchantID), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	patchResp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	patchResp.Body.Close()
+	s.Equal(http.StatusOK, patchResp.StatusCode)
+
+	sibling["idempotency_key"] = "xfr-sibling-2"
+	status, _ = s.post("/v1/ledger/transfers", sibling)
+	s.Equal(http.StatusForbidden, status)
+
+	// Transfers up the hierarchy are unaffected.
+	status, _ = s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": store1ID,
+		"dest_account_id":   merchantID,
+		"amount":            100,
+		"idempotency_key":   "xfr-sibling-3",
+	})
+	s.Equal(http.StatusCreated, status)
 }
 
 func (s *LedgerAPITestSuite) TestReconciliation() {
@@ -547,6 +1144,63 @@ func (s *LedgerAPITestSuite) TestConcurrentTransfers() {
 	s.Equal(true, bobData["is_consistent"])
 }
 
+func (s *LedgerAPITestSuite) TestOptimisticConcurrencyRetriesConflicts() {
+	ctx := context.Background()
+	service := ledger.NewLedgerService(s.logger, ledger.NewReplicatedLedgerRepository(s.db, nil, nil, ledger.ConcurrencyOptimistic), ledger.Pricing{}, nil)
+
+	aliceID := s.createAccount("OptAlice")["id"].(string)
+	bobID := s.createAccount("OptBob")["id"].(string)
+	s.deposit(aliceID, 10000, "opt-seed")
+
+	// Stand in for a concurrent writer: bump the version of the account
+	// about to be saved, inside the same transaction, so its compare-and-swap
+	// misses.
+	var races, bumps atomic.Int64
+	const callback = "test:concurrent_writer"
+	s.Require().NoError(s.db.Callback().Update().Before("gorm:update").Register(callback, func(db *gorm.DB) {
+		if db.Statement.Table != "accounts" || races.Load() == 0 {
+			return
+		}
+		races.Add(-1)
+		bumps.Add(1)
+		db.Session(&gorm.Session{NewDB: true}).Exec("UPDATE accounts SET version = version + 1 WHERE id = ?", aliceID)
+	}))
+	defer s.db.Callback().Update().Remove(callback)
+
+	races.Store(2)
+	txn, err := service.Transfer(ctx, &ledger.TransferRequest{SourceAccountID: aliceID, DestAccountID: bobID, Amount: 300, IdempotencyKey: "opt-xfr-1"})
+	s.Require().NoError(err)
+	s.Equal(int64(9700), txn.Entries[0].BalanceAfter)
+	s.Equal(int64(2), bumps.Load())
+
+	hold, err := service.CreateHold(ctx, aliceID, &ledger.CreateHoldRequest{Amount: 200, IdempotencyKey: "opt-hold-1"})
+	s.Require().NoError(err)
+	s.Equal(models.HoldStatusActive, hold.Status)
+
+	// A write that never wins gives up rather than retrying forever.
+	races.Store(1000)
+	_, err = service.Transfer(ctx, &ledger.TransferRequest{SourceAccountID: aliceID, DestAccountID: bobID, Amount: 300, IdempotencyKey: "opt-xfr-2"})
+	s.ErrorIs(err, ledger.ErrConcurrentUpdate)
+	races.Store(0)
+
+	alice, err := service.GetBalance(ctx, aliceID)
+	s.Require().NoError(err)
+	s.Equal(int64(9700), alice.CachedBalance)
+	s.True(alice.IsConsistent)
+
+	// Replays and holds still see the balance the retried write left.
+	txn, err = service.Transfer(ctx, &ledger.TransferRequest{SourceAccountID: aliceID, DestAccountID: bobID, Amount: 300, IdempotencyKey: "opt-xfr-1"})
+	s.Require().NoError(err)
+	s.Equal(int64(9700), txn.Entries[0].BalanceAfter)
+	_, err = service.Transfer(ctx, &ledger.TransferRequest{SourceAccountID: aliceID, DestAccountID: bobID, Amount: 9600, IdempotencyKey: "opt-xfr-3"})
+	s.ErrorIs(err, ledger.ErrInsufficientFunds)
+
+	report, err := service.Reconcile(ctx)
+	s.Require().NoError(err)
+	s.True(report.AllConsistent)
+	s.True(report.LedgerBalanced)
+}
+
 func (s *LedgerAPITestSuite) TestDepositToSystemAccountRejected() {
 	// Attempting to deposit to the system account should be rejected
 	response := s.deposit(models.SystemAccountID, 10000, "dep-sys-attack")
@@ -606,6 +1260,81 @@ func (s *LedgerAPITestSuite) TestTransferToSystemAccountRejected() {
 	s.Contains(response["message"], "system account")
 }
 
+func (s *LedgerAPITestSuite) TestLedgerEventsPublished() {
+	received := make(chan events.Event, 10)
+	unsubscribe := s.appConfig.Events.Subscribe("*", func(_ context.Context, e events.Event) { received <- e })
+
+	account := s.createAccount("Events")
+	accountID := account["id"].(string)
+	s.deposit(accountID, 1500, "evt-dep-1")
+	s.deposit(accountID, 1500, "evt-dep-1") // idempotent replay
+	unsubscribe()
+	close(received)
+
+	var got []events.Event
+	for e := range received {
+		got = append(got, e)
+	}
+	s.Require().Len(got, 3)
+	s.Equal(ledger.EventAccountCreated, got[0].Type)
+	s.Equal(accountID, got[0].Data.(ledger.AccountCreatedEvent).AccountID)
+
+	posted := got[1].Data.(ledger.TransactionPostedEvent)
+	s.Equal(ledger.EventTransactionPosted, got[1].Type)
+	s.Equal(models.SystemAccountID, posted.SourceAccountID)
+	s.Equal(accountID, posted.DestAccountID)
+	s.Equal(int64(1500), posted.Amount)
+	s.Equal(got[1].ID, got[2].ID, "replays carry the same event ID")
+}
+
+func (s *LedgerAPITestSuite) TestOpenAPISpecCoversLedger() {
+	resp, err := http.Get(s.baseURL + "/openapi.json")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var spec struct {
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&spec))
+
+	s.Contains(spec.Paths["/v1/ledger/accounts"], "post")
+	s.Contains(spec.Paths["/v1/ledger/accounts/{id}/deposit"], "post")
+	s.Contains(spec.Paths["/v1/ledger/transfers"], "post")
+	s.Contains(spec.Paths["/v1/ledger/transfers/split"], "post")
+	s.Contains(spec.Paths["/v1/operations/{id}"], "get")
+	for _, name := range []string{"CreateAccountRequest", "TransferRequest", "TransactionResponse", "LedgerEntryResponse", "PaginatedResultTransactionResponse"} {
+		s.Contains(spec.Components.Schemas, name)
+	}
+}
+
+func (s *LedgerAPITestSuite) TestRoleBindingStore() {
+	ctx := context.Background()
+	store := rbac.NewGormStore(s.db)
+	defer s.db.Exec("DELETE FROM role_bindings")
+
+	admin := rbac.Binding{Kind: principal.KindUser, Subject: "user-1", Role: "admin"}
+	s.Require().NoError(store.Grant(ctx, admin))
+	s.Require().NoError(store.Grant(ctx, admin), "granting twice is a no-op")
+	s.Require().NoError(store.Grant(ctx, rbac.Binding{Kind: principal.KindUser, Subject: "user-1", Role: "auditor"}))
+	s.Require().NoError(store.Grant(ctx, rbac.Binding{Kind: principal.KindService, Subject: "user-1", Role: "operator"}))
+
+	roles, err := store.Roles(ctx, principal.KindUser, "user-1")
+	s.Require().NoError(err)
+	s.Equal([]string{"admin", "auditor"}, roles)
+
+	bindings, err := store.Bindings(ctx, principal.KindService, "")
+	s.Require().NoError(err)
+	s.Require().Len(bindings, 1)
+	s.Equal("operator", bindings[0].Role)
+
+	s.Require().NoError(store.Revoke(ctx, admin))
+	s.ErrorIs(store.Revoke(ctx, admin), rbac.ErrNotFound)
+}
+
 func (s *LedgerAPITestSuite) TestCreateAccountValidationError() {
 	body, _ := json.Marshal(map[string]string{})
 	resp, err := http.Post(s.baseURL+"/v1/ledger/accounts", "application/json", bytes.NewBuffer(body))
@@ -620,5 +1349,1276 @@ func TestLedgerAPISuite(t *testing.T) {
 		t.Skip("Skipping integration tests. Set RUN_INTEGRATION_TESTS=true to run them")
 	}
 
+	defer testkit.VerifyNoLeaks(t)
 	suite.Run(t, new(LedgerAPITestSuite))
 }
+
+func (s *LedgerAPITestSuite) TestDetailedHealthReportsDatabaseProbe() {
+	var body struct {
+		Data struct {
+			Status       string `json:"status"`
+			Dependencies []struct {
+				Name      string  `json:"name"`
+				Healthy   bool    `json:"healthy"`
+				Samples   int     `json:"samples"`
+				ErrorRate float64 `json:"error_rate"`
+			} `json:"dependencies"`
+		} `json:"data"`
+	}
+
+	// The first probe runs in the background right after startup.
+	s.Eventually(func() bool {
+		resp, err := http.Get(s.baseURL + "/health/detailed")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&body) != nil {
+			return false
+		}
+		return len(body.Data.Dependencies) == 1 && body.Data.Dependencies[0].Samples > 0
+	}, 5*time.Second, 20*time.Millisecond)
+
+	dep := body.Data.Dependencies[0]
+	s.Equal("database", dep.Name)
+	s.True(dep.Healthy)
+	s.Zero(dep.ErrorRate)
+	s.Equal("ok", body.Data.Status)
+}
+
+func (s *LedgerAPITestSuite) TestSyntheticCheckLeavesNoTrace() {
+	count := func(model any) int64 {
+		var n int64
+		s.Require().NoError(s.db.Model(model).Count(&n).Error)
+		return n
+	}
+	accounts, transactions := count(&models.Account{}), count(&models.Transaction{})
+	var system models.Account
+	s.Require().NoError(s.db.First(&system, "id = ?", models.SystemAccountID).Error)
+
+	check := ledger.SyntheticCheck(s.db, s.logger, nil)
+	s.Require().NoError(check(context.Background()))
+	s.Require().NoError(check(context.Background()), "each run uses fresh idempotency keys")
+
+	s.Equal(accounts, count(&models.Account{}))
+	s.Equal(transactions, count(&models.Transaction{}))
+	var after models.Account
+	s.Require().NoError(s.db.First(&after, "id = ?", models.SystemAccountID).Error)
+	s.Equal(system.Balance, after.Balance)
+}
+
+func (s *LedgerAPITestSuite) TestLivenessAndReadiness() {
+	resp, err := http.Get(s.baseURL + "/health/live")
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(s.baseURL + "/health/ready")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data struct {
+			Ready  bool              `json:"ready"`
+			Checks map[string]string `json:"checks"`
+		} `json:"data"`
+	}
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&body))
+	s.True(body.Data.Ready)
+	s.Equal(map[string]string{"database": "ok", "migrations": "ok"}, body.Data.Checks)
+}
+
+func (s *LedgerAPITestSuite) TestReadinessFailsWhenNotReady() {
+	status := func() int {
+		resp, err := http.Get(s.baseURL + "/health/ready")
+		s.Require().NoError(err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	s.Equal(http.StatusOK, status())
+
+	s.appConfig.RouterService.SetReady(false)
+	defer s.appConfig.RouterService.SetReady(true)
+	s.Equal(http.StatusServiceUnavailable, status())
+
+	// Readiness only steers load balancers; requests are still served.
+	resp, err := http.Get(s.baseURL + "/health")
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (s *LedgerAPITestSuite) TestStatusStore() {
+	ctx := context.Background()
+	store := status.NewGormStore(s.db)
+	defer s.db.Exec("DELETE FROM status_checks")
+	defer s.db.Exec("DELETE FROM incidents")
+
+	hour := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	s.Require().NoError(store.AddChecks(ctx, []status.Bucket{{Component: "database", Hour: hour, Checks: 3, Failures: 1}}))
+	s.Require().NoError(store.AddChecks(ctx, []status.Bucket{
+		{Component: "database", Hour: hour, Checks: 2},
+		{Component: "database", Hour: hour.Add(time.Hour), Checks: 1, Failures: 1},
+	}))
+	buckets, err := store.Buckets(ctx, hour)
+	s.Require().NoError(err)
+	s.Require().Len(buckets, 2)
+	s.Equal(int64(5), buckets[0].Checks, "counts for the same hour add up")
+	s.Equal(int64(1), buckets[0].Failures)
+
+	incident := &status.Incident{Title: "Slow transfers", Impact: "minor", Components: []string{"ledger"}, StartedAt: hour}
+	s.Require().NoError(store.SaveIncident(ctx, incident))
+	s.NotEmpty(incident.ID)
+
+	resolved := hour.Add(time.Hour)
+	incident.ResolvedAt = &resolved
+	incident.Message = "Fixed"
+	s.Require().NoError(store.SaveIncident(ctx, incident))
+	saved, err := store.Incident(ctx, incident.ID)
+	s.Require().NoError(err)
+	s.Equal("Fixed", saved.Message)
+	s.Equal([]string{"ledger"}, saved.Components)
+	s.True(resolved.Equal(*saved.ResolvedAt))
+
+	incidents, err := store.Incidents(ctx, resolved.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Empty(incidents, "incidents resolved before since are left out")
+
+	s.ErrorIs(store.SaveIncident(ctx, &status.Incident{ID: "missing", Title: "x", Impact: "minor"}), status.ErrNotFound)
+	_, err = store.Incident(ctx, "missing")
+	s.ErrorIs(err, status.ErrNotFound)
+}
+
+func (s *LedgerAPITestSuite) TestWebhookStore() {
+	ctx := context.Background()
+	cipher, err := fieldcrypt.New([]fieldcrypt.Key{{ID: "k1", Secret: bytes.Repeat([]byte{7}, 32)}}, bytes.Repeat([]byte{9}, 32))
+	s.Require().NoError(err)
+	store := webhooks.NewGormStore(s.db, cipher)
+
+	endpoint := &webhooks.Endpoint{URL: "https://example.com/hooks", EventTypes: []string{"transaction.*"}, Active: true}
+	s.Require().NoError(store.CreateEndpoint(ctx, endpoint))
+	defer func() { _ = store.DeleteEndpoint(ctx, endpoint.ID) }()
+	var stored models.WebhookEndpoint
+	s.Require().NoError(s.db.First(&stored, "id = ?", endpoint.ID).Error)
+	s.NotContains(stored.Secret, endpoint.Secret, "secrets are encrypted at rest")
+	loaded, err := store.Endpoint(ctx, endpoint.ID)
+	s.Require().NoError(err)
+	s.Equal(endpoint.Secret, loaded.Secret)
+	s.Equal([]string{"transaction.*"}, loaded.EventTypes)
+
+	now := time.Now().UTC()
+	queued := []webhooks.Delivery{{EndpointID: endpoint.ID, EventID: "evt-1", EventType: "transaction.posted", Payload: []byte(`{"id":"evt-1"}`)}}
+	s.Require().NoError(store.Enqueue(ctx, queued, now))
+	s.Require().NoError(store.Enqueue(ctx, queued, now), "a republished event is skipped")
+
+	claimed, err := store.Claim(ctx, now, time.Minute, 10, nil)
+	s.Require().NoError(err)
+	s.Require().Len(claimed, 1)
+	s.JSONEq(`{"id":"evt-1"}`, string(claimed[0].Payload))
+	again, err := store.Claim(ctx, now, time.Minute, 10, nil)
+	s.Require().NoError(err)
+	s.Empty(again, "a claimed delivery is leased")
+
+	delivery := claimed[0]
+	delivery.Attempts, delivery.Status, delivery.LastError, delivery.LastStatusCode = 1, webhooks.StatusFailed, "endpoint responded with status 500", 500
+	s.Require().NoError(store.Record(ctx, &delivery, webhooks.Attempt{Attempt: 1, StatusCode: 500, Error: delivery.LastError, AttemptedAt: now}))
+
+	list, total, err := store.Deliveries(ctx, webhooks.DeliveryFilter{EndpointID: endpoint.ID, Status: webhooks.StatusFailed, Limit: 10})
+	s.Require().NoError(err)
+	s.Equal(int64(1), total)
+	s.Empty(list[0].Payload, "lists leave out payloads")
+	s.Nil(list[0].NextAttemptAt)
+
+	s.Require().NoError(store.Replay(ctx, delivery.ID, now))
+	s.ErrorIs(store.Replay(ctx, delivery.ID, now), webhooks.ErrNotFailed)
+	s.ErrorIs(store.Replay(ctx, "missing", now), webhooks.ErrNotFound)
+	replayed, err := store.Delivery(ctx, delivery.ID)
+	s.Require().NoError(err)
+	s.Equal(webhooks.StatusPending, replayed.Status)
+	s.Zero(replayed.Attempts)
+	s.Len(replayed.Log, 1, "the attempt log survives a replay")
+
+	s.Require().NoError(store.DeleteEndpoint(ctx, endpoint.ID))
+	_, err = store.Delivery(ctx, delivery.ID)
+	s.ErrorIs(err, webhooks.ErrNotFound, "deliveries are deleted with their endpoint")
+	var attempts int64
+	s.Require().NoError(s.db.Model(&models.WebhookAttempt{}).Where("delivery_id = ?", delivery.ID).Count(&attempts).Error)
+	s.Zero(attempts)
+}
+
+func (s *LedgerAPITestSuite) TestWebhookStore_OrderedEndpoints() {
+	ctx := context.Background()
+	store := webhooks.NewGormStore(s.db, nil)
+	ordered := &webhooks.Endpoint{URL: "https://example.com/ordered", EventTypes: []string{"*"}, Active: true, Ordered: true}
+	parallel := &webhooks.Endpoint{URL: "https://example.com/parallel", EventTypes: []string{"*"}, Active: true}
+	for _, e := range []*webhooks.Endpoint{ordered, parallel} {
+		s.Require().NoError(store.CreateEndpoint(ctx, e))
+		defer func() { _ = store.DeleteEndpoint(ctx, e.ID) }()
+	}
+
+	now := time.Now().UTC()
+	var queued []webhooks.Delivery
+	for i, e := range []string{"evt-o3", "evt-o1", "evt-o2"} {
+		at := now.Add(time.Duration([]int{3, 1, 2}[i]) * time.Second)
+		for _, endpoint := range []*webhooks.Endpoint{ordered, parallel} {
+			queued = append(queued, webhooks.Delivery{EndpointID: endpoint.ID, EventID: e, EventType: "transaction.posted", OccurredAt: at, Payload: []byte(`{}`)})
+		}
+	}
+	s.Require().NoError(store.Enqueue(ctx, queued, now))
+
+	claimOrdered := func() []webhooks.Delivery {
+		claimed, err := store.Claim(ctx, now, time.Minute, 10, []string{parallel.ID})
+		s.Require().NoError(err)
+		return claimed
+	}
+	first := claimOrdered()
+	s.Require().Len(first, 1, "an ordered endpoint has one delivery in flight")
+	s.Equal("evt-o1", first[0].EventID)
+	s.Empty(claimOrdered(), "later deliveries wait for the one in flight")
+
+	parallelClaims, err := store.Claim(ctx, now, time.Minute, 10, []string{ordered.ID})
+	s.Require().NoError(err)
+	s.Len(parallelClaims, 3, "other endpoints are claimed in parallel")
+
+	delivery := first[0]
+	delivery.Attempts, delivery.Status = 1, webhooks.StatusFailed
+	s.Require().NoError(store.Record(ctx, &delivery, webhooks.Attempt{Attempt: 1, AttemptedAt: now}))
+	next := claimOrdered()
+	s.Require().Len(next, 1, "a failed delivery does not hold back the rest")
+	s.Equal("evt-o2", next[0].EventID)
+	succeed := func(d webhooks.Delivery) {
+		d.Attempts, d.Status, d.DeliveredAt = 1, webhooks.StatusSucceeded, &now
+		s.Require().NoError(store.Record(ctx, &d, webhooks.Attempt{Attempt: 1, AttemptedAt: now}))
+	}
+	succeed(next[0])
+
+	s.Require().NoError(store.Replay(ctx, delivery.ID, now))
+	replayed := claimOrdered()
+	s.Require().Len(replayed, 1, "a replayed delivery goes before later pending ones")
+	s.Equal("evt-o1", replayed[0].EventID)
+	succeed(replayed[0])
+
+	next = claimOrdered()
+	s.Require().Len(next, 1)
+	s.Equal("evt-o3", next[0].EventID)
+}
+
+func (s *LedgerAPITestSuite) TestWebhookStore_ReplayRange() {
+	ctx := context.Background()
+	store := webhooks.NewGormStore(s.db, nil)
+	endpoint := &webhooks.Endpoint{URL: "https://example.com/replay", EventTypes: []string{"*"}, Active: true}
+	s.Require().NoError(store.CreateEndpoint(ctx, endpoint))
+	defer func() { _ = store.DeleteEndpoint(ctx, endpoint.ID) }()
+
+	base := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	var queued []webhooks.Delivery
+	for i := range 5 {
+		queued = append(queued, webhooks.Delivery{EndpointID: endpoint.ID, EventID: fmt.Sprintf("evt-r%d", i), EventType: "transaction.posted", OccurredAt: base.Add(time.Duration(i) * time.Second), Payload: []byte(`{}`)})
+	}
+	now := time.Now().UTC()
+	s.Require().NoError(store.Enqueue(ctx, queued, now))
+	s.Require().NoError(s.db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ? AND event_id <> ?", endpoint.ID, "evt-r2").
+		Update("status", string(webhooks.StatusSucceeded)).Error)
+
+	r := webhooks.ReplayRange{EndpointID: endpoint.ID, From: base.Add(time.Second), To: base.Add(4 * time.Second)}
+	total, err := store.CountRange(ctx, r)
+	s.Require().NoError(err)
+	s.Equal(int64(3), total)
+
+	first, err := store.ReplayRange(ctx, r, webhooks.ReplayCursor{}, 2, now)
+	s.Require().NoError(err)
+	s.Equal(2, first.Taken)
+	s.Equal(int64(1), first.Replayed, "a pending delivery is left queued")
+	second, err := store.ReplayRange(ctx, r, first.Next, 2, now)
+	s.Require().NoError(err)
+	s.Equal(1, second.Taken)
+	s.Equal(int64(1), second.Replayed)
+	last, err := store.ReplayRange(ctx, r, second.Next, 2, now)
+	s.Require().NoError(err)
+	s.Zero(last.Taken)
+
+	list, _, err := store.Deliveries(ctx, webhooks.DeliveryFilter{EndpointID: endpoint.ID, Status: webhooks.StatusPending})
+	s.Require().NoError(err)
+	var pending []string
+	for _, d := range list {
+		pending = append(pending, d.EventID)
+	}
+	s.ElementsMatch([]string{"evt-r1", "evt-r2", "evt-r3"}, pending, "only events in [from, to) are replayed")
+}
+
+func (s *LedgerAPITestSuite) TestWebhookDelivery() {
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	ctx := context.Background()
+	store := s.appConfig.Webhooks.Store()
+	endpoint := &webhooks.Endpoint{URL: receiver.URL, EventTypes: []string{ledger.EventTransactionPosted}, Active: true}
+	s.Require().NoError(store.CreateEndpoint(ctx, endpoint))
+	defer func() { _ = store.DeleteEndpoint(ctx, endpoint.ID) }()
+
+	account := s.createAccount("Webhooks")
+	s.deposit(account["id"].(string), 2500, "webhook-dep-1")
+
+	var req *http.Request
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		s.FailNow("expected the transaction.posted event to be delivered")
+	}
+	body := <-bodies
+	s.Equal(ledger.EventTransactionPosted, req.Header.Get("X-Event-Type"))
+	s.Equal(events.Sign([]byte(endpoint.Secret), body), req.Header.Get(events.SignatureHeader))
+
+	s.Eventually(func() bool {
+		delivery, err := store.Delivery(ctx, req.Header.Get(webhooks.DeliveryHeader))
+		return err == nil && delivery.Status == webhooks.StatusSucceeded && len(delivery.Log) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func (s *LedgerAPITestSuite) TestStatusPage() {
+	defer s.db.Exec("DELETE FROM status_checks")
+
+	// Wait for the first database probe, then write its result.
+	s.Eventually(func() bool {
+		for _, dep := range s.appConfig.Probes.Snapshot() {
+			if dep.Name == "database" && dep.Samples > 0 {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 20*time.Millisecond)
+	s.appConfig.Status.Flush(context.Background())
+
+	resp, err := http.Get(s.baseURL + "/status")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data status.Report `json:"data"`
+	}
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&body))
+	s.Equal(status.StatusOperational, body.Data.Status)
+	s.Require().Len(body.Data.Components, 1)
+	db := body.Data.Components[0]
+	s.Equal("database", db.Name)
+	s.Require().NotNil(db.Uptime24h)
+	s.Equal(100.0, *db.Uptime24h)
+	s.Len(db.Daily, 7)
+
+	page, err := http.Get(s.baseURL + "/status/page")
+	s.Require().NoError(err)
+	defer page.Body.Close()
+	s.Equal(http.StatusOK, page.StatusCode)
+	s.Contains(page.Header.Get("Content-Type"), "text/html")
+}
+
+func (s *LedgerAPITestSuite) TestCrossRegionWriteRejected() {
+	local, err := region.New("eu-west", 7)
+	s.Require().NoError(err)
+	region.SetLocal(local)
+	defer region.SetLocal(nil)
+
+	body, _ := json.Marshal(map[string]string{"name": "Regional"})
+	resp, err := http.Post(s.baseURL+"/v1/ledger/accounts", "application/json", bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusCreated, resp.StatusCode)
+	s.Equal("eu-west", resp.Header.Get(region.Header))
+
+	var created map[string]any
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&created))
+	account := created["data"].(map[string]any)
+	s.Equal("eu-west", account["home_region"])
+	code, ok := region.CodeOf(account["id"].(string))
+	s.True(ok)
+	s.Equal(uint16(7), code)
+	s.Equal(float64(201), s.deposit(account["id"].(string), 100, "region-dep-1")["code"])
+
+	// An account homed in another region only accepts writes there.
+	remote := models.Account{Name: "Remote", AccountType: models.AccountTypeUser, Currency: "USD", HomeRegion: "us-east"}
+	s.Require().NoError(s.db.Create(&remote).Error)
+
+	payload, _ := json.Marshal(map[string]any{"amount": 100, "idempotency_key": "region-dep-2"})
+	depositResp, err := http.Post(fmt.Sprintf("%s/v1/ledger/accounts/%s/deposit", s.baseURL, remote.ID), "application/json", bytes.NewBuffer(payload))
+	s.Require().NoError(err)
+	defer depositResp.Body.Close()
+	s.Equal(http.StatusMisdirectedRequest, depositResp.StatusCode)
+	s.Equal("us-east", depositResp.Header.Get(region.HomeHeader))
+
+	status, _ := s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": account["id"], "dest_account_id": remote.ID, "amount": 50, "idempotency_key": "region-tr-1",
+	})
+	s.Equal(http.StatusMisdirectedRequest, status)
+}
+
+func (s *LedgerAPITestSuite) TestSearchTransactionsValidatesQuery() {
+	resp, err := http.Get(s.baseURL + "/v1/ledger/transactions/search?account_id=42")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	var body struct {
+		Data []apperrors.ValidationErrorResponse `json:"data"`
+	}
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&body))
+	s.ElementsMatch([]apperrors.ValidationErrorResponse{
+		{Field: "account_id", Message: "Value must be a UUID"},
+		{Field: "q", Message: "This field is required"},
+	}, body.Data)
+}
+
+func (s *LedgerAPITestSuite) TestCrossCurrencyTransfer() {
+	s.Require().NoError(s.db.Create(&models.FXRate{Base: "EUR", Quote: "USD", Value: "1.08", AsOf: time.Now().UTC()}).Error)
+
+	status, created := s.post("/v1/ledger/accounts", map[string]any{"name": "Elena", "currency": "EUR"})
+	s.Require().Equal(http.StatusCreated, status)
+	elenaID := created["data"].(map[string]any)["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	s.Equal(float64(201), s.deposit(elenaID, 10000, "fx-dep-1")["code"])
+
+	status, response := s.post("/v1/ledger/transfers/quote", map[string]any{
+		"source_account_id": elenaID, "dest_account_id": bobID, "amount": 5000,
+	})
+	s.Require().Equal(http.StatusCreated, status)
+	quote := response["data"].(map[string]any)
+	s.Equal("1.08", quote["exchange_rate"])
+	s.Equal(float64(5400), quote["dest_amount"])
+
+	status, response = s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": elenaID, "dest_account_id": bobID, "amount": 5000,
+		"idempotency_key": "fx-xfr-1", "quote_id": quote["quote_id"],
+	})
+	s.Require().Equal(http.StatusCreated, status)
+	txn := response["data"].(map[string]any)
+	s.Equal("EUR", txn["currency"])
+	s.Equal(float64(5400), txn["dest_amount"])
+	s.Equal("USD", txn["dest_currency"])
+	s.Equal("1.08", txn["exchange_rate"])
+
+	// The reverse direction uses the inverted rate.
+	status, response = s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": bobID, "dest_account_id": elenaID, "amount": 1080, "idempotency_key": "fx-xfr-2",
+	})
+	s.Require().Equal(http.StatusCreated, status)
+	s.Equal(float64(1000), response["data"].(map[string]any)["dest_amount"])
+
+	var elena, bob models.Account
+	s.Require().NoError(s.db.First(&elena, "id = ?", elenaID).Error)
+	s.Require().NoError(s.db.First(&bob, "id = ?", bobID).Error)
+	s.Equal(int64(6000), elena.Balance)
+	s.Equal(int64(4320), bob.Balance)
+
+	resp, err := http.Get(s.baseURL + "/v1/ledger/reconciliation")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	var reconcile map[string]any
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&reconcile))
+	s.Equal(true, reconcile["data"].(map[string]any)["all_consistent"])
+	s.Equal(true, reconcile["data"].(map[string]any)["ledger_balanced"])
+
+	// A rate older than the maximum age is not used.
+	s.db.Model(&models.FXRate{}).Where("base = ?", "EUR").Update("as_of", time.Now().Add(-48*time.Hour))
+	status, _ = s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": elenaID, "dest_account_id": bobID, "amount": 100, "idempotency_key": "fx-xfr-3",
+	})
+	s.Equal(http.StatusUnprocessableEntity, status)
+}
+
+func (s *LedgerAPITestSuite) TestReverseTransaction() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	s.deposit(aliceID, 10000, "rev-dep-1")
+
+	status, response := s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": aliceID, "dest_account_id": bobID, "amount": 4000, "idempotency_key": "rev-xfr-1",
+	})
+	s.Require().Equal(http.StatusCreated, status)
+	transferID := response["data"].(map[string]any)["id"].(string)
+
+	reversePath := "/v1/ledger/transactions/" + transferID + "/reverse"
+	status, response = s.post(reversePath, map[string]any{"idempotency_key": "rev-1", "description": "refund"})
+	s.Require().Equal(http.StatusCreated, status)
+	reversal := response["data"].(map[string]any)
+	s.Equal("REVERSAL", reversal["transaction_type"])
+	s.Equal(transferID, reversal["reversed_transaction_id"])
+	entries := s.entriesByType(reversal["entries"].([]any))
+	s.Equal(bobID, entries["DEBIT"]["account_id"])
+	s.Equal(aliceID, entries["CREDIT"]["account_id"])
+
+	var alice, bob models.Account
+	s.Require().NoError(s.db.First(&alice, "id = ?", aliceID).Error)
+	s.Require().NoError(s.db.First(&bob, "id = ?", bobID).Error)
+	s.Equal(int64(10000), alice.Balance)
+	s.Equal(int64(0), bob.Balance)
+
+	// Replaying the request returns the same reversal.
+	status, response = s.post(reversePath, map[string]any{"idempotency_key": "rev-1", "description": "refund"})
+	s.Equal(http.StatusCreated, status)
+	s.Equal(reversal["id"], response["data"].(map[string]any)["id"])
+
+	// A transaction is reversed once, and a reversal not at all.
+	status, _ = s.post(reversePath, map[string]any{"idempotency_key": "rev-2"})
+	s.Equal(http.StatusConflict, status)
+	status, _ = s.post("/v1/ledger/transactions/"+reversal["id"].(string)+"/reverse", map[string]any{"idempotency_key": "rev-3"})
+	s.Equal(http.StatusBadRequest, status)
+
+	// The reversing account must still hold what it received.
+	status, response = s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": aliceID, "dest_account_id": bobID, "amount": 3000, "idempotency_key": "rev-xfr-2",
+	})
+	s.Require().Equal(http.StatusCreated, status)
+	secondID := response["data"].(map[string]any)["id"].(string)
+	s.withdraw(bobID, 2000, "rev-wd-1")
+	status, _ = s.post("/v1/ledger/transactions/"+secondID+"/reverse", map[string]any{"idempotency_key": "rev-4"})
+	s.Equal(http.StatusBadRequest, status)
+
+	status, _ = s.post("/v1/ledger/transactions/"+uuid.NewString()+"/reverse", map[string]any{"idempotency_key": "rev-5"})
+	s.Equal(http.StatusNotFound, status)
+	status, _ = s.post("/v1/ledger/transactions/not-a-uuid/reverse", map[string]any{"idempotency_key": "rev-6"})
+	s.Equal(http.StatusBadRequest, status)
+
+	resp, err := http.Get(s.baseURL + "/v1/ledger/reconciliation")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	var reconcile map[string]any
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&reconcile))
+	s.Equal(true, reconcile["data"].(map[string]any)["all_consistent"])
+	s.Equal(true, reconcile["data"].(map[string]any)["ledger_balanced"])
+}
+
+func (s *LedgerAPITestSuite) TestSplitTransfer() {
+	payerID := s.createAccount("Payer")["id"].(string)
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	carolID := s.createAccount("Carol")["id"].(string)
+	s.deposit(payerID, 10000, "split-dep-1")
+
+	request := map[string]any{
+		"source_account_id": payerID,
+		"amount":            1000,
+		"idempotency_key":   "split-1",
+		"destinations": []map[string]any{
+			{"account_id": aliceID, "amount": 100},
+			{"account_id": bobID, "basis_points": 5000},
+			{"account_id": carolID, "basis_points": 5000},
+		},
+	}
+	status, response := s.post("/v1/ledger/transfers/split", request)
+	s.Require().Equal(http.StatusCreated, status, response)
+	data := response["data"].(map[string]any)
+	s.Equal("SPLIT_TRANSFER", data["transaction_type"])
+	s.Equal(float64(1000), data["amount"])
+	s.Len(data["entries"], 4)
+
+	destinations := data["destinations"].([]any)
+	s.Require().Len(destinations, 3)
+	for i, want := range []struct {
+		id     string
+		amount float64
+	}{{aliceID, 100}, {bobID, 450}, {carolID, 450}} {
+		destination := destinations[i].(map[string]any)
+		s.Equal(want.id, destination["account_id"])
+		s.Equal(want.amount, destination["amount"])
+		entry := destination["entry"].(map[string]any)
+		s.Equal("CREDIT", entry["entry_type"])
+		s.Equal(want.amount, entry["balance_after"])
+	}
+
+	var payer models.Account
+	s.Require().NoError(s.db.First(&payer, "id = ?", payerID).Error)
+	s.Equal(int64(9000), payer.Balance)
+
+	// Replaying returns the same transaction; other allocations conflict.
+	status, response = s.post("/v1/ledger/transfers/split", request)
+	s.Equal(http.StatusCreated, status)
+	s.Equal(data["id"], response["data"].(map[string]any)["id"])
+	request["destinations"] = []map[string]any{
+		{"account_id": aliceID, "amount": 500},
+		{"account_id": bobID, "amount": 500},
+	}
+	status, _ = s.post("/v1/ledger/transfers/split", request)
+	s.Equal(http.StatusConflict, status)
+
+	// Allocations must add up, and the source must cover them.
+	status, response = s.post("/v1/ledger/transfers/split", map[string]any{
+		"source_account_id": payerID, "amount": 1000, "idempotency_key": "split-2",
+		"destinations": []map[string]any{{"account_id": aliceID, "amount": 500}, {"account_id": bobID, "amount": 400}},
+	})
+	s.Equal(http.StatusBadRequest, status)
+	s.Contains(response["message"], "must add up")
+	status, _ = s.post("/v1/ledger/transfers/split", map[string]any{
+		"source_account_id": payerID, "amount": 20000, "idempotency_key": "split-3",
+		"destinations": []map[string]any{{"account_id": aliceID, "basis_points": 2500}, {"account_id": bobID, "basis_points": 7500}},
+	})
+	s.Equal(http.StatusBadRequest, status)
+
+	// A split is reversed like any other transaction.
+	status, _ = s.post("/v1/ledger/transactions/"+data["id"].(string)+"/reverse", map[string]any{"idempotency_key": "split-rev-1"})
+	s.Require().Equal(http.StatusCreated, status)
+	s.Require().NoError(s.db.First(&payer, "id = ?", payerID).Error)
+	s.Equal(int64(10000), payer.Balance)
+
+	resp, err := http.Get(s.baseURL + "/v1/ledger/reconciliation")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	var reconcile map[string]any
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&reconcile))
+	s.Equal(true, reconcile["data"].(map[string]any)["all_consistent"])
+	s.Equal(true, reconcile["data"].(map[string]any)["ledger_balanced"])
+}
+
+func (s *LedgerAPITestSuite) TestBalanceAlerts() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	s.deposit(aliceID, 10000, "alert-dep-1")
+
+	alertsPath := "/v1/ledger/accounts/" + aliceID + "/alerts"
+	status, response := s.post(alertsPath, map[string]any{"kind": "LOW_BALANCE", "threshold": 5000, "notify_email": "alice@example.com"})
+	s.Require().Equal(http.StatusCreated, status, response)
+	lowID := response["data"].(map[string]any)["id"].(string)
+	status, response = s.post(alertsPath, map[string]any{"kind": "LARGE_DEBIT", "threshold": 3000})
+	s.Require().Equal(http.StatusCreated, status, response)
+	largeID := response["data"].(map[string]any)["id"].(string)
+	status, _ = s.post(alertsPath, map[string]any{"kind": "HIGH_BALANCE", "threshold": 5000})
+	s.Equal(http.StatusBadRequest, status)
+
+	triggered := func(transactionID string) []models.BalanceAlertTrigger {
+		var triggers []models.BalanceAlertTrigger
+		s.Require().NoError(s.db.Where("transaction_id = ?", transactionID).Order("kind").Find(&triggers).Error)
+		return triggers
+	}
+	transfer := func(amount int64, key string) string {
+		status, response := s.post("/v1/ledger/transfers", map[string]any{
+			"source_account_id": aliceID, "dest_account_id": bobID, "amount": amount, "idempotency_key": key,
+		})
+		s.Require().Equal(http.StatusCreated, status, response)
+		return response["data"].(map[string]any)["id"].(string)
+	}
+
+	// 10000 -> 6000 debits 4000: large, but still above the low balance.
+	first := triggered(transfer(4000, "alert-t-1"))
+	s.Require().Len(first, 1)
+	s.Equal(largeID, first[0].AlertID)
+	s.Equal(int64(4000), first[0].Amount)
+	s.Equal(int64(6000), first[0].Balance)
+
+	// 6000 -> 4000 crosses the low balance threshold.
+	second := triggered(transfer(2000, "alert-t-2"))
+	s.Require().Len(second, 1)
+	s.Equal(lowID, second[0].AlertID)
+	s.Equal(int64(4000), second[0].Balance)
+
+	// Already below it, so nothing triggers until it is crossed again.
+	s.Empty(triggered(transfer(1000, "alert-t-3")))
+
+	// A failed posting records nothing; a dry run's triggers are rolled back.
+	status, _ = s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": aliceID, "dest_account_id": bobID, "amount": 50000, "idempotency_key": "alert-t-4",
+	})
+	s.Equal(http.StatusBadRequest, status)
+	s.deposit(aliceID, 10000, "alert-dep-2")
+	status, _ = s.post("/v1/ledger/transfers?dry_run=true", map[string]any{
+		"source_account_id": aliceID, "dest_account_id": bobID, "amount": 9000, "idempotency_key": "alert-t-5",
+	})
+	s.Equal(http.StatusOK, status)
+	var count int64
+	s.Require().NoError(s.db.Model(&models.BalanceAlertTrigger{}).Count(&count).Error)
+	s.Equal(int64(2), count)
+
+	// Listing and deleting; another account cannot delete Alice's alerts.
+	resp, err := http.Get(s.baseURL + alertsPath)
+	s.Require().NoError(err)
+	var list map[string]any
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&list))
+	resp.Body.Close()
+	s.Len(list["data"], 2)
+
+	del := func(accountID, alertID string) int {
+		req, err := http.NewRequest(http.MethodDelete, s.baseURL+"/v1/ledger/accounts/"+accountID+"/alerts/"+alertID, nil)
+		s.Require().NoError(err)
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	s.Equal(http.StatusNotFound, del(bobID, largeID))
+	s.Equal(http.StatusOK, del(aliceID, largeID))
+	s.Equal(http.StatusNotFound, del(aliceID, largeID))
+	// 13000 -> 4000 crosses the low balance again; the deleted alert is gone.
+	last := triggered(transfer(9000, "alert-t-6"))
+	s.Require().Len(last, 1)
+	s.Equal(lowID, last[0].AlertID)
+}
+
+func (s *LedgerAPITestSuite) TestAccruals() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	depositID := s.deposit(aliceID, 36500000, "accrual-dep-1")["data"].(map[string]any)["id"].(string)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format(time.DateOnly) }
+
+	plansPath := "/v1/ledger/accounts/" + aliceID + "/accrual-plans"
+	status, _ := s.post(plansPath, map[string]any{"kind": "INTEREST", "rate_basis_points": 1000, "starts_on": day(-3)})
+	s.Equal(http.StatusBadRequest, status, "a plan cannot start before its account")
+
+	// Open the account, and fund it, three days ago.
+	opened := today.AddDate(0, 0, -3).Add(time.Hour)
+	s.Require().NoError(s.db.Model(&models.Account{}).Where("id = ?", aliceID).Update("created_at", opened).Error)
+	s.Require().NoError(s.db.Model(&models.Transaction{}).Where("id = ?", depositID).Update("created_at", opened).Error)
+	s.Require().NoError(s.db.Model(&models.LedgerEntry{}).Where("transaction_id = ?", depositID).Update("created_at", opened).Error)
+
+	status, response := s.post(plansPath, map[string]any{"kind": "INTEREST", "rate_basis_points": 1000, "starts_on": day(-3)})
+	s.Require().Equal(http.StatusCreated, status, response)
+	planID := response["data"].(map[string]any)["id"].(string)
+
+	service := ledger.NewLedgerService(s.logger, ledger.NewLedgerRepository(s.db, nil), ledger.Pricing{}, nil)
+	accrued, err := service.AccrueDueDays(context.Background(), today.AddDate(0, 0, -1))
+	s.Require().NoError(err)
+	s.Equal(3, accrued)
+	// Each day is accrued once, however often the run repeats.
+	accrued, err = service.AccrueDueDays(context.Background(), today.AddDate(0, 0, -1))
+	s.Require().NoError(err)
+	s.Zero(accrued)
+
+	records := func() []any {
+		resp, err := http.Get(fmt.Sprintf("%s/v1/ledger/accounts/%s/accruals?from=%s", s.baseURL, aliceID, day(-3)))
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		var report map[string]any
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&report))
+		return report["data"].(map[string]any)["records"].([]any)
+	}
+	first := records()
+	s.Require().Len(first, 3)
+	// 365000.00 at 10% a year is 10.00 a day, compounding.
+	for i, amount := range []float64{10000, 10003, 10005} {
+		s.Equal(amount, first[i].(map[string]any)["amount"], "day %d", i)
+	}
+	balance, err := ledger.NewLedgerRepository(s.db, nil).GetAccountByID(context.Background(), aliceID)
+	s.Require().NoError(err)
+	s.Equal(int64(36500000+10000+10003+10005), balance.Balance)
+
+	// Accruals are corrected through their plan, not reversed by hand.
+	status, _ = s.post("/v1/ledger/transactions/"+first[0].(map[string]any)["transaction_id"].(string)+"/reverse", map[string]any{"idempotency_key": "accrual-rev-1"})
+	s.Equal(http.StatusBadRequest, status)
+
+	// Doubling the rate from two days ago corrects those two days.
+	body, _ := json.Marshal(map[string]any{"rate_basis_points": 2000, "effective_from": day(-2)})
+	req, err := http.NewRequest(http.MethodPatch, s.baseURL+plansPath+"/"+planID, bytes.NewReader(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	var updated map[string]any
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&updated))
+	resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode, updated)
+	corrections := updated["data"].(map[string]any)["corrections"].([]any)
+	s.Require().Len(corrections, 2)
+	s.Equal(float64(20005), corrections[0].(map[string]any)["amount"])
+	s.Equal(float64(20016), corrections[1].(map[string]any)["amount"])
+
+	all := records()
+	s.Require().Len(all, 5)
+	for _, record := range all {
+		record := record.(map[string]any)
+		if record["revision"] == float64(1) && record["day"] != day(-3) {
+			s.NotEmpty(record["superseded_at"])
+			s.NotEmpty(record["reversal_transaction_id"])
+		} else {
+			s.Empty(record["superseded_at"])
+		}
+	}
+	balance, err = ledger.NewLedgerRepository(s.db, nil).GetAccountByID(context.Background(), aliceID)
+	s.Require().NoError(err)
+	s.Equal(int64(36500000+10000+20005+20016), balance.Balance)
+}
+
+func (s *LedgerAPITestSuite) TestDailyClosing() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	twoDaysAgo, yesterday := today.AddDate(0, 0, -2), today.AddDate(0, 0, -1)
+	for i, amount := range []int64{1000, 2000} {
+		id := s.deposit(aliceID, amount, fmt.Sprintf("close-dep-%d", i))["data"].(map[string]any)["id"].(string)
+		at := twoDaysAgo.Add(time.Duration(i+1) * time.Hour)
+		s.Require().NoError(s.db.Model(&models.Transaction{}).Where("id = ?", id).Update("created_at", at).Error)
+		s.Require().NoError(s.db.Model(&models.LedgerEntry{}).Where("transaction_id = ?", id).Update("created_at", at).Error)
+	}
+	s.deposit(aliceID, 500, "close-dep-today")
+
+	repository := ledger.NewLedgerRepository(s.db, nil)
+	service := ledger.NewLedgerService(s.logger, repository, ledger.Pricing{}, nil)
+	sealed, err := service.SealDays(context.Background(), yesterday)
+	s.Require().NoError(err)
+	s.Equal(2, sealed, "the first day with entries and the empty day after it")
+	sealed, err = service.SealDays(context.Background(), yesterday)
+	s.Require().NoError(err)
+	s.Zero(sealed)
+
+	verify := func(day time.Time) (int, map[string]any) {
+		resp, err := http.Get(s.baseURL + "/v1/ledger/closings/" + day.Format(time.DateOnly) + "/verify")
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		var response map[string]any
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, response
+		}
+		return resp.StatusCode, response["data"].(map[string]any)
+	}
+
+	// Archiving moves entries without breaking the seal.
+	_, err = repository.ArchiveTransactions(context.Background(), today, 100)
+	s.Require().NoError(err)
+	status, first := verify(twoDaysAgo)
+	s.Require().Equal(http.StatusOK, status, first)
+	s.Equal(true, first["valid"], first["problems"])
+	s.Equal(float64(4), first["entry_count"])
+	s.Empty(first["previous_digest"])
+	status, second := verify(yesterday)
+	s.Require().Equal(http.StatusOK, status, second)
+	s.Equal(true, second["valid"], second["problems"])
+	s.Equal(float64(0), second["entry_count"])
+	s.Equal(first["digest"], second["previous_digest"])
+	status, _ = verify(today)
+	s.Equal(http.StatusNotFound, status)
+
+	// Changing an entry of a sealed day is detected.
+	s.Require().NoError(s.db.Model(&models.ArchivedLedgerEntry{}).Where("account_id = ?", aliceID).Where("amount = ?", 1000).Update("amount", 100000).Error)
+	_, tampered := verify(twoDaysAgo)
+	s.Equal(false, tampered["valid"])
+	s.Contains(tampered["problems"], "the day's entries do not hash to its sealed digest")
+
+	// So is resealing it to match: the next day no longer chains onto it.
+	s.Require().NoError(s.db.Model(&models.DailySeal{}).Where("day = ?", twoDaysAgo).Update("digest", tampered["computed_digest"]).Error)
+	_, resealed := verify(twoDaysAgo)
+	s.Equal(false, resealed["valid"])
+	s.Equal([]any{"the next day's seal does not chain onto this one"}, resealed["problems"])
+	_, next := verify(yesterday)
+	s.Equal([]any{"the seal does not chain onto the previous day's"}, next["problems"])
+}
+
+func (s *LedgerAPITestSuite) TestEntryHashChain() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	s.deposit(aliceID, 10000, "chain-dep-1")
+	status, response := s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": aliceID, "dest_account_id": bobID, "amount": 2500, "idempotency_key": "chain-xfer-1",
+	})
+	s.Require().Equal(http.StatusCreated, status)
+	transferID := response["data"].(map[string]any)["id"].(string)
+	status, _ = s.post("/v1/ledger/transactions/"+transferID+"/reverse", map[string]any{"idempotency_key": "chain-rev-1"})
+	s.Require().Equal(http.StatusCreated, status)
+
+	verify := func(accountID string) map[string]any {
+		resp, err := http.Get(s.baseURL + "/v1/ledger/accounts/" + accountID + "/chain/verify")
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var response map[string]any
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+		return response["data"].(map[string]any)
+	}
+
+	alice := verify(aliceID)
+	s.Equal(true, alice["valid"], alice["problems"])
+	s.Equal(float64(3), alice["entries"])
+	s.Equal(float64(0), alice["unchained_entries"])
+	s.NotEmpty(alice["last_entry_hash"])
+	bob := verify(bobID)
+	s.Equal(true, bob["valid"], bob["problems"])
+	s.Equal(float64(2), bob["entries"])
+
+	// Archiving moves entries without breaking the chain.
+	repository := ledger.NewLedgerRepository(s.db, nil)
+	_, err := repository.ArchiveTransactions(context.Background(), time.Now().Add(time.Hour), 100)
+	s.Require().NoError(err)
+	alice = verify(aliceID)
+	s.Equal(true, alice["valid"], alice["problems"])
+	s.Equal(float64(3), alice["entries"])
+
+	// Changing an entry is detected, and only on its own account.
+	s.Require().NoError(s.db.Model(&models.ArchivedLedgerEntry{}).Where("account_id = ?", aliceID).Where("amount = ?", 10000).Update("amount", 1000000).Error)
+	tampered := verify(aliceID)
+	s.Equal(false, tampered["valid"])
+	s.Len(tampered["problems"], 1, tampered["problems"])
+	s.Contains(tampered["problems"].([]any)[0], "does not hash to its entry_hash")
+	s.Equal(true, verify(bobID)["valid"])
+
+	// Rechaining after an approved correction makes the chain valid again.
+	service := ledger.NewLedgerService(s.logger, repository, ledger.Pricing{}, nil)
+	rechain, err := service.RechainAccount(context.Background(), aliceID)
+	s.Require().NoError(err)
+	s.Equal(3, rechain.Entries)
+	s.Equal(3, rechain.Rewritten)
+	s.Equal(tampered["last_entry_hash"], rechain.PreviousLastEntryHash)
+	alice = verify(aliceID)
+	s.Equal(true, alice["valid"], alice["problems"])
+	s.Equal(rechain.LastEntryHash, alice["last_entry_hash"])
+
+	// Entries posted afterwards chain onto the new head.
+	s.deposit(aliceID, 500, "chain-dep-2")
+	alice = verify(aliceID)
+	s.Equal(true, alice["valid"], alice["problems"])
+	s.Equal(float64(4), alice["entries"])
+}
+
+func (s *LedgerAPITestSuite) TestEntriesShareTransactionTime() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	s.deposit(aliceID, 10000, "stamp-dep-1")
+	status, response := s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": aliceID, "dest_account_id": bobID, "amount": 2500, "idempotency_key": "stamp-xfer-1",
+	})
+	s.Require().Equal(http.StatusCreated, status)
+	transferID := response["data"].(map[string]any)["id"].(string)
+	status, _ = s.post("/v1/ledger/transactions/"+transferID+"/reverse", map[string]any{"idempotency_key": "stamp-rev-1"})
+	s.Require().Equal(http.StatusCreated, status)
+
+	// Entry lookups are bounded by their transaction's time, which is how
+	// they read only the partitions they need.
+	var mismatched int64
+	s.Require().NoError(s.db.Raw(`SELECT COUNT(*) FROM ledger_entries le
+		JOIN transactions t ON t.id = le.transaction_id
+		WHERE le.created_at <> t.created_at`).Scan(&mismatched).Error)
+	s.Zero(mismatched)
+
+	resp, err := http.Get(fmt.Sprintf("%s/v1/ledger/accounts/%s/transactions?include=entries", s.baseURL, aliceID))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	var page map[string]any
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&page))
+	items := page["data"].(map[string]any)["data"].([]any)
+	s.Require().Len(items, 3)
+	for _, item := range items {
+		s.Len(item.(map[string]any)["entries"], 2)
+	}
+
+	// Summaries are counted under the same bounds.
+	resp, err = http.Get(fmt.Sprintf("%s/v1/ledger/accounts/%s/transactions", s.baseURL, aliceID))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	page = nil
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&page))
+	items = page["data"].(map[string]any)["data"].([]any)
+	s.Require().Len(items, 3)
+	for _, item := range items {
+		s.Equal(float64(2), item.(map[string]any)["summary"].(map[string]any)["entry_count"])
+	}
+}
+
+func (s *LedgerAPITestSuite) TestDailyBalances() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	s.deposit(aliceID, 10000, "daily-dep-1")
+	s.deposit(aliceID, 5000, "daily-dep-2")
+	s.withdraw(aliceID, 3000, "daily-wd-1")
+	s.deposit(bobID, 700, "daily-dep-3")
+
+	// Move the first deposit back two days so the report spans several.
+	twoDaysAgo := time.Now().UTC().AddDate(0, 0, -2)
+	s.Require().NoError(s.db.Exec("UPDATE ledger_entries SET created_at = ? WHERE transaction_id IN (SELECT id FROM transactions WHERE idempotency_key = ?)", twoDaysAgo, "daily-dep-1").Error)
+
+	get := func(query string) (int, map[string]any) {
+		resp, err := http.Get(fmt.Sprintf("%s/v1/ledger/accounts/%s/daily-balances%s", s.baseURL, aliceID, query))
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		var response map[string]any
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+		return resp.StatusCode, response
+	}
+
+	status, response := get("")
+	s.Require().Equal(http.StatusOK, status)
+	data := response["data"].(map[string]any)
+	s.Equal(time.Now().UTC().Format(time.DateOnly), data["to"])
+	days := data["days"].([]any)
+	s.Require().Len(days, 2)
+	first, last := days[0].(map[string]any), days[1].(map[string]any)
+	s.Equal(twoDaysAgo.Format(time.DateOnly), first["date"])
+	s.Equal(float64(10000), first["closing_balance"])
+	s.Equal(float64(5000), last["credits"])
+	s.Equal(float64(3000), last["debits"])
+	s.Equal(float64(2), last["entry_count"])
+	s.Equal(float64(12000), last["closing_balance"])
+
+	// A range after the first deposit leaves it out but keeps the balance.
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	status, response = get("?from=" + yesterday)
+	s.Require().Equal(http.StatusOK, status)
+	days = response["data"].(map[string]any)["days"].([]any)
+	s.Require().Len(days, 1)
+	s.Equal(float64(12000), days[0].(map[string]any)["closing_balance"])
+
+	status, _ = get("?from=2026-10-10&to=2026-10-01")
+	s.Equal(http.StatusBadRequest, status)
+	status, _ = get("?from=2024-01-01&to=2026-01-01")
+	s.Equal(http.StatusBadRequest, status)
+	status, _ = get("?from=yesterday")
+	s.Equal(http.StatusBadRequest, status)
+
+	resp, err := http.Get(s.baseURL + "/v1/ledger/accounts/" + uuid.NewString() + "/daily-balances")
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *LedgerAPITestSuite) TestStatement() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	s.deposit(aliceID, 10000, "statement-dep-1")
+	s.deposit(aliceID, 5000, "statement-dep-2")
+	s.withdraw(aliceID, 3000, "statement-wd-1")
+
+	// Move the first deposit before the statement so it becomes the opening balance.
+	s.Require().NoError(s.db.Exec("UPDATE ledger_entries SET created_at = ? WHERE transaction_id IN (SELECT id FROM transactions WHERE idempotency_key = ?)", time.Now().UTC().AddDate(0, 0, -2), "statement-dep-1").Error)
+
+	get := func(query string) (*http.Response, []byte) {
+		resp, err := http.Get(fmt.Sprintf("%s/v1/ledger/accounts/%s/statement%s", s.baseURL, aliceID, query))
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		return resp, body
+	}
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	resp, body := get("?from=" + yesterday)
+	s.Require().Equal(http.StatusOK, resp.StatusCode, string(body))
+	s.Equal("text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	s.Contains(resp.Header.Get("Content-Disposition"), "statement-"+aliceID+"-"+yesterday)
+	rows, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	s.Require().NoError(err)
+	s.Require().Len(rows, 3)
+	s.Equal([]string{"date", "transaction_id", "transaction_type", "description", "debit", "credit", "balance", "currency"}, rows[0])
+	s.Equal("50.00", rows[1][5])
+	s.Equal("150.00", rows[1][6])
+	s.Equal("30.00", rows[2][4])
+	s.Equal("120.00", rows[2][6])
+
+	resp, body = get("?format=pdf")
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("application/pdf", resp.Header.Get("Content-Type"))
+	s.True(bytes.HasPrefix(body, []byte("%PDF-")))
+	s.Contains(string(body), "Closing balance")
+
+	resp, _ = get("?format=xlsx")
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+	resp, _ = get("?from=2026-10-10&to=2026-10-01")
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	missing, err := http.Get(s.baseURL + "/v1/ledger/accounts/" + uuid.NewString() + "/statement")
+	s.Require().NoError(err)
+	missing.Body.Close()
+	s.Equal(http.StatusNotFound, missing.StatusCode)
+}
+
+func (s *LedgerAPITestSuite) TestArchive() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	depositID := s.deposit(aliceID, 10000, "archive-dep-1")["data"].(map[string]any)["id"].(string)
+	status, transfer := s.post("/v1/ledger/transfers", map[string]any{
+		"source_account_id": aliceID, "dest_account_id": bobID, "amount": 2500, "idempotency_key": "archive-xfer-1",
+	})
+	s.Require().Equal(http.StatusCreated, status)
+	transferID := transfer["data"].(map[string]any)["id"].(string)
+	status, _ = s.post("/v1/ledger/transactions/"+transferID+"/reverse", map[string]any{"idempotency_key": "archive-rev-1"})
+	s.Require().Equal(http.StatusCreated, status)
+	s.withdraw(aliceID, 1000, "archive-wd-1")
+
+	// Age all but the withdrawal past a 30-day retention. The transfer can
+	// only move with its reversal.
+	aged := []string{"archive-dep-1", "archive-xfer-1", "archive-rev-1"}
+	old := time.Now().UTC().AddDate(0, -2, 0)
+	s.Require().NoError(s.db.Exec("UPDATE ledger_entries SET created_at = ? WHERE transaction_id IN (SELECT id FROM transactions WHERE idempotency_key IN ?)", old, aged).Error)
+	s.Require().NoError(s.db.Exec("UPDATE transactions SET created_at = ? WHERE idempotency_key IN ?", old, aged).Error)
+
+	// A one-transaction batch first, so the second adds to the same day's totals.
+	repository := ledger.NewLedgerRepository(s.db, nil)
+	first, err := repository.ArchiveTransactions(context.Background(), time.Now().AddDate(0, 0, -30), 1)
+	s.Require().NoError(err)
+	s.Positive(first)
+	archiver := ledger.NewArchiver(s.logger, repository, ledger.ArchiveConfig{Retention: 30 * 24 * time.Hour})
+	moved, err := archiver.Run(context.Background())
+	s.Require().NoError(err)
+	s.Equal(3, first+moved)
+
+	var live int64
+	s.Require().NoError(s.db.Model(&models.Transaction{}).Count(&live).Error)
+	s.Equal(int64(1), live)
+
+	get := func(path string) (int, map[string]any) {
+		resp, err := http.Get(s.baseURL + path)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		var response map[string]any
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+		return resp.StatusCode, response
+	}
+
+	// Balances and reconciliation still cover the archived history.
+	status, balance := get("/v1/ledger/accounts/" + aliceID + "/balance")
+	s.Require().Equal(http.StatusOK, status)
+	s.Equal(float64(9000), balance["data"].(map[string]any)["derived_balance"])
+	s.Equal(true, balance["data"].(map[string]any)["is_consistent"])
+	status, reconciliation := get("/v1/ledger/reconciliation")
+	s.Require().Equal(http.StatusOK, status)
+	s.Equal(true, reconciliation["data"].(map[string]any)["all_consistent"])
+	s.Equal(true, reconciliation["data"].(map[string]any)["ledger_balanced"])
+
+	status, daily := get("/v1/ledger/accounts/" + aliceID + "/daily-balances?from=" + old.Format(time.DateOnly))
+	s.Require().Equal(http.StatusOK, status)
+	days := daily["data"].(map[string]any)["days"].([]any)
+	s.Require().Len(days, 2)
+	s.Equal(float64(12500), days[0].(map[string]any)["credits"])
+	s.Equal(float64(3), days[0].(map[string]any)["entry_count"])
+	s.Equal(float64(9000), days[1].(map[string]any)["closing_balance"])
+
+	status, single := get("/v1/ledger/archive/transactions/" + depositID)
+	s.Require().Equal(http.StatusOK, status)
+	s.Equal(float64(10000), single["data"].(map[string]any)["amount"])
+	s.Len(single["data"].(map[string]any)["entries"], 2)
+
+	status, page := get("/v1/ledger/accounts/" + aliceID + "/archived-transactions")
+	s.Require().Equal(http.StatusOK, status)
+	s.Len(page["data"].(map[string]any)["data"], 3)
+
+	// A retried deposit replays the archived transaction instead of posting again.
+	replay := s.deposit(aliceID, 10000, "archive-dep-1")
+	s.Equal(depositID, replay["data"].(map[string]any)["id"])
+	_, balance = get("/v1/ledger/accounts/" + aliceID + "/balance")
+	s.Equal(float64(9000), balance["data"].(map[string]any)["cached_balance"])
+
+	status, _ = get("/v1/ledger/archive/transactions/" + uuid.NewString())
+	s.Equal(http.StatusNotFound, status)
+}
+
+func (s *LedgerAPITestSuite) TestHolds() {
+	aliceID := s.createAccount("Alice")["id"].(string)
+	bobID := s.createAccount("Bob")["id"].(string)
+	s.deposit(aliceID, 10000, "hold-dep-1")
+
+	balance := func() map[string]any {
+		resp, err := http.Get(fmt.Sprintf("%s/v1/ledger/accounts/%s/balance", s.baseURL, aliceID))
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		var response map[string]any
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+		return response["data"].(map[string]any)
+	}
+
+	status, response := s.post("/v1/ledger/accounts/"+aliceID+"/holds", map[string]any{
+		"amount": 6000, "dest_account_id": bobID, "idempotency_key": "hold-1",
+	})
+	s.Require().Equal(http.StatusCreated, status)
+	hold := response["data"].(map[string]any)
+	s.Equal("ACTIVE", hold["status"])
+	holdID := hold["id"].(string)
+
+	// Held funds stay posted but are not available.
+	data := balance()
+	s.Equal(float64(10000), data["cached_balance"])
+	s.Equal(float64(6000), data["held_balance"])
+	s.Equal(float64(4000), data["available_balance"])
+	status, _ = s.post("/v1/ledger/accounts/"+aliceID+"/holds", map[string]any{"amount": 5000, "idempotency_key": "hold-2"})
+	s.Equal(http.StatusBadRequest, status)
+	s.Equal(float64(400), s.withdraw(aliceID, 5000, "hold-wd-1")["code"])
+
+	// Capturing part of the hold transfers it and releases the rest.
+	status, response = s.post("/v1/ledger/holds/"+holdID+"/capture", map[string]any{"amount": 4500, "idempotency_key": "hold-cap-1"})
+	s.Require().Equal(http.StatusCreated, status)
+	captured := response["data"].(map[string]any)
+	s.Equal("CAPTURED", captured["status"])
+	s.Equal("TRANSFER", captured["transaction"].(map[string]any)["transaction_type"])
+	data = balance()
+	s.Equal(float64(5500), data["cached_balance"])
+	s.Equal(float64(0), data["held_balance"])
+	var bob models.Account
+	s.Require().NoError(s.db.First(&bob, "id = ?", bobID).Error)
+	s.Equal(int64(4500), bob.Balance)
+
+	status, _ = s.post("/v1/ledger/holds/"+holdID+"/capture", map[string]any{"idempotency_key": "hold-cap-2"})
+	s.Equal(http.StatusConflict, status)
+	status, _ = s.post("/v1/ledger/holds/"+holdID+"/release", nil)
+	s.Equal(http.StatusConflict, status)
+
+	// A released hold frees its funds; releasing again changes nothing.
+	status, response = s.post("/v1/ledger/accounts/"+aliceID+"/holds", map[string]any{"amount": 5000, "idempotency_key": "hold-3"})
+	s.Require().Equal(http.StatusCreated, status)
+	releasedID := response["data"].(map[string]any)["id"].(string)
+	status, _ = s.post("/v1/ledger/holds/"+releasedID+"/capture", map[string]any{"amount": 6000, "idempotency_key": "hold-cap-3"})
+	s.Equal(http.StatusBadRequest, status)
+	for range 2 {
+		status, response = s.post("/v1/ledger/holds/"+releasedID+"/release", nil)
+		s.Require().Equal(http.StatusOK, status)
+		s.Equal("RELEASED", response["data"].(map[string]any)["status"])
+	}
+	s.Equal(float64(5500), balance()["available_balance"])
+
+	// Expired holds stop counting at once, and the sweep records it.
+	status, response = s.post("/v1/ledger/accounts/"+aliceID+"/holds", map[string]any{"amount": 5000, "idempotency_key": "hold-4"})
+	s.Require().Equal(http.StatusCreated, status)
+	expiredID := response["data"].(map[string]any)["id"].(string)
+	s.Require().NoError(s.db.Model(&models.Hold{}).Where("id = ?", expiredID).Update("expires_at", time.Now().UTC().Add(-time.Minute)).Error)
+	s.Equal(float64(5500), balance()["available_balance"])
+	status, _ = s.post("/v1/ledger/holds/"+expiredID+"/capture", map[string]any{"idempotency_key": "hold-cap-4"})
+	s.Equal(http.StatusConflict, status)
+
+	expired, err := ledger.NewLedgerRepository(s.db, nil).ExpireHolds(context.Background(), time.Now().UTC())
+	s.Require().NoError(err)
+	s.Equal(int64(1), expired)
+	resp, err := http.Get(s.baseURL + "/v1/ledger/holds/" + expiredID)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+	s.Equal("EXPIRED", response["data"].(map[string]any)["status"])
+}
+
+func (s *LedgerAPITestSuite) TestReplicaLagFallsBackToPrimary() {
+	// An empty replica stands in for one that has not caught up yet.
+	replicaDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	s.Require().NoError(err)
+	s.Require().NoError(replicaDB.AutoMigrate(&models.Account{}, &models.Transaction{}, &models.LedgerEntry{}, &models.ArchivedDailyTotal{}))
+	sqlDB, err := replicaDB.DB()
+	s.Require().NoError(err)
+	defer sqlDB.Close()
+
+	var lag atomic.Int64
+	monitor := replica.NewMonitor(s.logger, s.db, replicaDB, func(context.Context) (time.Duration, error) {
+		return time.Duration(lag.Load()), nil
+	}, replica.Config{MaxLag: time.Second})
+	service := ledger.NewLedgerService(s.logger, ledger.NewReplicatedLedgerRepository(s.db, nil, monitor, ledger.ConcurrencyPessimistic), ledger.Pricing{}, nil)
+
+	ctx := context.Background()
+	account, err := service.CreateAccount(ctx, &ledger.CreateAccountRequest{Name: "Replicated"})
+	s.Require().NoError(err)
+	_, err = service.Deposit(ctx, account.ID, &ledger.DepositRequest{Amount: 500, IdempotencyKey: "replica-dep-1"})
+	s.Require().NoError(err)
+
+	// Within MaxLag, reads are served by the replica.
+	s.Require().NoError(monitor.Refresh(ctx))
+	_, err = service.GetAccount(ctx, account.ID)
+	s.ErrorIs(err, ledger.ErrAccountNotFound)
+
+	// Past it, consistent reads go to the primary and searches stay on the replica.
+	lag.Store(int64(5 * time.Second))
+	s.Require().NoError(monitor.Refresh(ctx))
+	s.ErrorIs(monitor.Check(ctx), replica.ErrLagging)
+	fetched, err := service.GetAccount(ctx, account.ID)
+	s.Require().NoError(err)
+	s.Equal(int64(500), fetched.Balance)
+	balance, err := service.GetBalance(ctx, account.ID)
+	s.Require().NoError(err)
+	s.True(balance.IsConsistent)
+
+	report, err := service.Reconcile(ctx)
+	s.Require().NoError(err)
+	s.Empty(report.Accounts)
+}
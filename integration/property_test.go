@@ -0,0 +1,351 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/akeren/go-api-foundry/domain/ledger"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The property tests generate random operation sequences, run each against a
+// fresh SQLite ledger and check the double-entry invariants after every step:
+//
+//   - total debits equal total credits;
+//   - no USER account goes negative;
+//   - every cached balance equals the balance derived from its entries;
+//   - each outcome matches a model of the ledger: operations succeed exactly
+//     when the model has the funds, and replays return the first transaction.
+//
+// Runs draw from PROPERTY_SEED (logged, random by default) and try
+// PROPERTY_CASES sequences (default 50). A failing sequence is printed one
+// operation per line; rerun with its seed to reproduce it.
+
+type propertyKind int
+
+const (
+	propertyDeposit propertyKind = iota
+	propertyWithdraw
+	propertyTransfer
+	propertyDryRun   // dry-run transfer; must not change anything
+	propertyReplay   // an earlier operation sent again with the same key
+	propertyConflict // an earlier key reused with a different amount
+)
+
+var propertyKindNames = map[propertyKind]string{
+	propertyDeposit:  "deposit",
+	propertyWithdraw: "withdraw",
+	propertyTransfer: "transfer",
+	propertyDryRun:   "dry-run",
+	propertyReplay:   "replay",
+	propertyConflict: "conflict",
+}
+
+type propertyOp struct {
+	kind     propertyKind
+	from, to int // account indexes; from is unused for deposits
+	amount   int64
+	// of is the index of the operation a replay or conflict reuses.
+	of int
+}
+
+// ledgerScript is one generated case: a number of accounts, a fee schedule
+// and the operations to run on them.
+type ledgerScript struct {
+	accounts int
+	fees     ledger.FeeSchedule
+	ops      []propertyOp
+}
+
+// Generate implements quick.Generator. Amounts are drawn up to a little more
+// than a typical deposit so that overdrafts are common.
+func (ledgerScript) Generate(r *rand.Rand, size int) reflect.Value {
+	s := ledgerScript{accounts: 2 + r.Intn(3)}
+	if r.Intn(2) == 0 {
+		s.fees = ledger.FeeSchedule{BasisPoints: int64(r.Intn(500)), Fixed: int64(r.Intn(50))}
+	}
+
+	n := 1 + r.Intn(max(size, 1))
+	for i := range n {
+		op := propertyOp{
+			from:   r.Intn(s.accounts),
+			to:     r.Intn(s.accounts),
+			amount: 1 + r.Int63n(5000),
+		}
+		switch roll := r.Intn(20); {
+		case roll < 6:
+			op.kind = propertyDeposit
+		case roll < 10:
+			op.kind = propertyWithdraw
+		case roll < 16:
+			op.kind = propertyTransfer
+		case roll < 17:
+			op.kind = propertyDryRun
+		case i > 0 && roll < 19:
+			op.kind = propertyReplay
+			op.of = s.original(r.Intn(i))
+		case i > 0:
+			op.kind = propertyConflict
+			op.of = s.original(r.Intn(i))
+		default:
+			op.kind = propertyDeposit
+		}
+		for (op.kind == propertyTransfer || op.kind == propertyDryRun) && op.to == op.from {
+			op.to = r.Intn(s.accounts)
+		}
+		s.ops = append(s.ops, op)
+	}
+	return reflect.ValueOf(s)
+}
+
+// original follows replays and conflicts back to the operation that first
+// used the key.
+func (s ledgerScript) original(i int) int {
+	if k := s.ops[i].kind; k == propertyReplay || k == propertyConflict {
+		return s.ops[i].of
+	}
+	return i
+}
+
+func (s ledgerScript) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d accounts, fee %d bps + %d", s.accounts, s.fees.BasisPoints, s.fees.Fixed)
+	for i, op := range s.ops {
+		fmt.Fprintf(&b, "\n  %d: %s", i, propertyKindNames[op.kind])
+		switch op.kind {
+		case propertyDeposit:
+			fmt.Fprintf(&b, " %d to #%d", op.amount, op.to)
+		case propertyWithdraw:
+			fmt.Fprintf(&b, " %d from #%d", op.amount, op.from)
+		case propertyTransfer, propertyDryRun:
+			fmt.Fprintf(&b, " %d from #%d to #%d", op.amount, op.from, op.to)
+		default:
+			fmt.Fprintf(&b, " of %d", op.of)
+		}
+	}
+	return b.String()
+}
+
+func TestLedgerProperties(t *testing.T) {
+	if os.Getenv("RUN_INTEGRATION_TESTS") != "true" {
+		t.Skip("Skipping integration tests. Set RUN_INTEGRATION_TESTS=true to run them")
+	}
+
+	seed := time.Now().UnixNano()
+	if v := os.Getenv("PROPERTY_SEED"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			t.Fatalf("PROPERTY_SEED must be an integer, got %q", v)
+		}
+		seed = parsed
+	}
+	cases := 50
+	if v := os.Getenv("PROPERTY_CASES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			t.Fatalf("PROPERTY_CASES must be a positive integer, got %q", v)
+		}
+		cases = n
+	}
+	t.Logf("PROPERTY_SEED=%d", seed)
+
+	var run atomic.Int64
+	property := func(s ledgerScript) bool {
+		if err := runLedgerScript(run.Add(1), s); err != nil {
+			t.Logf("%v\nscript: %s", err, s)
+			return false
+		}
+		return true
+	}
+	cfg := &quick.Config{MaxCount: cases, Rand: rand.New(rand.NewSource(seed))}
+	if err := quick.Check(property, cfg); err != nil {
+		var failed *quick.CheckError
+		if errors.As(err, &failed) {
+			t.Fatalf("invariant broken on case %d (PROPERTY_SEED=%d)", failed.Count, seed)
+		}
+		t.Fatal(err)
+	}
+}
+
+// propertyModel is what the ledger should hold after the operations so far.
+type propertyModel struct {
+	balances []int64
+	// posted maps each idempotency key the ledger has stored to its
+	// transaction.
+	posted map[string]postedKey
+}
+
+type postedKey struct {
+	transactionID string
+	amount        int64
+}
+
+// runLedgerScript runs s on a fresh ledger and returns the first broken
+// invariant.
+func runLedgerScript(run int64, s ledgerScript) error {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:property-%d?mode=memory&cache=shared", run)), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&models.Account{}, &models.Transaction{}, &models.LedgerEntry{}, &models.TransactionSearchToken{}, &models.TransferQuote{}, &models.Hold{}, &models.ArchivedTransaction{}, &models.ArchivedLedgerEntry{}, &models.ArchivedDailyTotal{}, &models.BalanceAlert{}, &models.BalanceAlertTrigger{}, &models.AccrualPlan{}, &models.AccrualRecord{}, &models.DailySeal{}); err != nil {
+		return err
+	}
+	system := models.Account{ID: models.SystemAccountID, Name: "External Funding Source", AccountType: models.AccountTypeSystem, Currency: "USD"}
+	if err := db.Create(&system).Error; err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	quiet := &log.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	service := ledger.NewLedgerService(quiet, ledger.NewLedgerRepository(db, nil), ledger.Pricing{Fees: s.fees}, nil)
+
+	accounts := make([]string, s.accounts)
+	for i := range accounts {
+		acc, err := service.CreateAccount(ctx, &ledger.CreateAccountRequest{Name: fmt.Sprintf("property-%d", i)})
+		if err != nil {
+			return fmt.Errorf("create account: %w", err)
+		}
+		accounts[i] = acc.ID
+	}
+
+	m := &propertyModel{balances: make([]int64, s.accounts), posted: make(map[string]postedKey)}
+	for i, op := range s.ops {
+		if err := applyPropertyOp(ctx, service, accounts, s, m, i, op); err != nil {
+			return fmt.Errorf("op %d (%s): %w", i, propertyKindNames[op.kind], err)
+		}
+		if err := checkLedgerProperties(ctx, service, accounts, m); err != nil {
+			return fmt.Errorf("after op %d (%s): %w", i, propertyKindNames[op.kind], err)
+		}
+	}
+	return nil
+}
+
+// applyPropertyOp runs op and checks its outcome against the model before
+// updating it.
+func applyPropertyOp(ctx context.Context, service ledger.LedgerService, accounts []string, s ledgerScript, m *propertyModel, i int, op propertyOp) error {
+	key := fmt.Sprintf("property-%d", i)
+	if op.kind == propertyReplay || op.kind == propertyConflict {
+		key = fmt.Sprintf("property-%d", op.of)
+		original := s.ops[op.of]
+		if op.kind == propertyConflict {
+			original.amount++
+		}
+		op = original
+	}
+
+	var txn *ledger.TransactionResponse
+	var err error
+	switch op.kind {
+	case propertyDeposit:
+		txn, err = service.Deposit(ctx, accounts[op.to], &ledger.DepositRequest{Amount: op.amount, IdempotencyKey: key})
+	case propertyWithdraw:
+		txn, err = service.Withdraw(ctx, accounts[op.from], &ledger.WithdrawRequest{Amount: op.amount, IdempotencyKey: key})
+	case propertyTransfer, propertyDryRun:
+		txn, err = service.Transfer(ctx, &ledger.TransferRequest{
+			SourceAccountID: accounts[op.from], DestAccountID: accounts[op.to], Amount: op.amount,
+			IdempotencyKey: key, DryRun: op.kind == propertyDryRun,
+		})
+	}
+
+	// A key the ledger has stored replays its transaction, or conflicts when
+	// the amount differs. A key whose first use failed is still free.
+	if posted, ok := m.posted[key]; ok {
+		if posted.amount != op.amount {
+			if !errors.Is(err, ledger.ErrIdempotencyConflict) {
+				return fmt.Errorf("reusing key %s with another amount: got %v, want %v", key, err, ledger.ErrIdempotencyConflict)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("replaying key %s: %w", key, err)
+		}
+		if txn.ID != posted.transactionID {
+			return fmt.Errorf("replay returned transaction %s, first attempt %s", txn.ID, posted.transactionID)
+		}
+		return nil
+	}
+
+	fee := int64(0)
+	if op.kind == propertyTransfer || op.kind == propertyDryRun {
+		fee = s.fees.Fee(op.amount)
+	}
+	funded := op.kind == propertyDeposit || m.balances[op.from] >= op.amount+fee
+	switch {
+	case !funded && errors.Is(err, ledger.ErrInsufficientFunds):
+		return nil
+	case !funded:
+		return fmt.Errorf("account #%d holds %d, needs %d: got %v, want %v", op.from, m.balances[op.from], op.amount+fee, err, ledger.ErrInsufficientFunds)
+	case err != nil:
+		return fmt.Errorf("account #%d holds %d, needs %d: %w", op.from, m.balances[op.from], op.amount+fee, err)
+	case txn.Fee != fee:
+		return fmt.Errorf("charged fee %d, want %d", txn.Fee, fee)
+	}
+	if op.kind == propertyDryRun {
+		return nil
+	}
+
+	m.posted[key] = postedKey{transactionID: txn.ID, amount: op.amount}
+	switch op.kind {
+	case propertyDeposit:
+		m.balances[op.to] += op.amount
+	case propertyWithdraw:
+		m.balances[op.from] -= op.amount
+	case propertyTransfer:
+		m.balances[op.from] -= op.amount + fee
+		m.balances[op.to] += op.amount
+	}
+	return nil
+}
+
+func checkLedgerProperties(ctx context.Context, service ledger.LedgerService, accounts []string, m *propertyModel) error {
+	recon, err := service.Reconcile(ctx)
+	if err != nil {
+		return fmt.Errorf("reconcile: %w", err)
+	}
+	if recon.TotalDebits != recon.TotalCredits || !recon.LedgerBalanced {
+		return fmt.Errorf("debits %d != credits %d", recon.TotalDebits, recon.TotalCredits)
+	}
+	for _, acc := range recon.Accounts {
+		if !acc.IsConsistent || acc.CachedBalance != acc.DerivedBalance {
+			return fmt.Errorf("account %s: cached balance %d, derived from entries %d", acc.AccountID, acc.CachedBalance, acc.DerivedBalance)
+		}
+	}
+	for i, id := range accounts {
+		balance, err := service.GetBalance(ctx, id)
+		if err != nil {
+			return fmt.Errorf("balance of #%d: %w", i, err)
+		}
+		if balance.CachedBalance < 0 {
+			return fmt.Errorf("account #%d overdrawn: %d", i, balance.CachedBalance)
+		}
+		if balance.CachedBalance != m.balances[i] {
+			return fmt.Errorf("account #%d: balance %d, model %d", i, balance.CachedBalance, m.balances[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/akeren/go-api-foundry/internal/testkit"
+)
+
+// snapshot sends a request and compares the response envelope with the golden
+// file testdata/snapshots/<name>.json. Run with UPDATE_SNAPSHOTS=true after an
+// intended change to a response shape.
+func (s *LedgerAPITestSuite) snapshot(name string, wantStatus int, method, path string, payload any) {
+	var body io.Reader
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		s.Require().NoError(err)
+		body = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, s.baseURL+path, body)
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+
+	s.Equal(wantStatus, resp.StatusCode, "%s: %s", name, raw)
+	testkit.MatchJSONSnapshot(s.T(), name, raw)
+}
+
+func (s *LedgerAPITestSuite) TestResponseSnapshots() {
+	account := s.createAccount("Snapshot")
+	accountID := account["id"].(string)
+	other := s.createAccount("Snapshot Peer")["id"].(string)
+
+	s.snapshot("ledger_create_account", http.StatusCreated, http.MethodPost, "/v1/ledger/accounts",
+		map[string]string{"name": "Snapshot Child", "parent_id": accountID})
+	s.snapshot("ledger_get_account", http.StatusOK, http.MethodGet, "/v1/ledger/accounts/"+accountID, nil)
+	s.snapshot("ledger_deposit", http.StatusCreated, http.MethodPost, fmt.Sprintf("/v1/ledger/accounts/%s/deposit", accountID),
+		map[string]any{"amount": 5000, "idempotency_key": "snap-dep-1", "description": "snapshot deposit"})
+	s.snapshot("ledger_transfer", http.StatusCreated, http.MethodPost, "/v1/ledger/transfers",
+		map[string]any{"source_account_id": accountID, "dest_account_id": other, "amount": 1500, "idempotency_key": "snap-tr-1"})
+	s.snapshot("ledger_transactions", http.StatusOK, http.MethodGet, fmt.Sprintf("/v1/ledger/accounts/%s/transactions?per_page=1", accountID), nil)
+
+	s.snapshot("ledger_validation_error", http.StatusBadRequest, http.MethodPost, "/v1/ledger/accounts", map[string]string{})
+	s.snapshot("ledger_not_found", http.StatusNotFound, http.MethodGet, "/v1/ledger/accounts/00000000-0000-0000-0000-00000000ffff", nil)
+	s.snapshot("ledger_insufficient_funds", http.StatusBadRequest, http.MethodPost, fmt.Sprintf("/v1/ledger/accounts/%s/withdraw", other),
+		map[string]any{"amount": 999999, "idempotency_key": "snap-wd-1"})
+}
@@ -0,0 +1,475 @@
+//go:build stress
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/domain/ledger"
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/migrations"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The stress suite runs thousands of interleaved deposits, withdrawals and
+// transfers against PostgreSQL and checks the ledger's invariants after each
+// phase. It needs a real database, so it is behind the stress build tag:
+//
+//	STRESS_DATABASE_URL=postgres://... go test -tags stress -run TestLedgerStress -v ./integration/
+//
+// or `make stress`. Each run migrates a fresh schema and drops it afterwards.
+// STRESS_CONCURRENCY_MODE=optimistic runs it with optimistic ledger writes;
+// writes that give up after losing too many races are counted, not failed.
+// BenchmarkLedgerConcurrency compares the two modes on the same database.
+//
+// Operations are generated from STRESS_SEED and dealt round-robin to the
+// workers, so every worker replays the same sequence for a given seed; only
+// the interleaving between workers is left to the scheduler.
+
+type stressConfig struct {
+	seed       uint64
+	accounts   int
+	workers    int
+	operations int
+	mode       ledger.ConcurrencyMode
+}
+
+func stressConfigFromEnv(t testing.TB) stressConfig {
+	cfg := stressConfig{
+		seed:       uint64(time.Now().UnixNano()),
+		accounts:   50,
+		workers:    32,
+		operations: 5000,
+		mode:       ledger.ConcurrencyPessimistic,
+	}
+	for env, dst := range map[string]*int{
+		"STRESS_ACCOUNTS":   &cfg.accounts,
+		"STRESS_WORKERS":    &cfg.workers,
+		"STRESS_OPERATIONS": &cfg.operations,
+	} {
+		if v := os.Getenv(env); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				t.Fatalf("%s must be a positive integer, got %q", env, v)
+			}
+			*dst = n
+		}
+	}
+	if v := os.Getenv("STRESS_SEED"); v != "" {
+		seed, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			t.Fatalf("STRESS_SEED must be an unsigned integer, got %q", v)
+		}
+		cfg.seed = seed
+	}
+	switch mode := ledger.ConcurrencyMode(os.Getenv("STRESS_CONCURRENCY_MODE")); mode {
+	case "":
+	case ledger.ConcurrencyPessimistic, ledger.ConcurrencyOptimistic:
+		cfg.mode = mode
+	default:
+		t.Fatalf("STRESS_CONCURRENCY_MODE must be pessimistic or optimistic, got %q", mode)
+	}
+	if cfg.accounts < 4 {
+		t.Fatal("STRESS_ACCOUNTS must be at least 4")
+	}
+	return cfg
+}
+
+type stressKind int
+
+const (
+	stressDeposit stressKind = iota
+	stressWithdraw
+	stressTransfer
+)
+
+type stressOp struct {
+	kind     stressKind
+	from, to int // account indexes; from is unused for deposits
+	amount   int64
+	key      string
+}
+
+type stressHarness struct {
+	t        *testing.T
+	db       *gorm.DB
+	service  ledger.LedgerService
+	cfg      stressConfig
+	accounts []string
+
+	mu       sync.Mutex
+	expected map[string]int64 // account ID -> balance implied by successful ops
+	applied  map[string]string
+	posted   int64 // transactions the ledger should hold
+	refused  atomic.Int64
+}
+
+func TestLedgerStress(t *testing.T) {
+	dsn := os.Getenv("STRESS_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("Set STRESS_DATABASE_URL to a PostgreSQL database to run the stress suite")
+	}
+	cfg := stressConfigFromEnv(t)
+	t.Logf("seed %d: %d accounts, %d workers, %d operations per phase, %s writes (rerun with STRESS_SEED=%d)",
+		cfg.seed, cfg.accounts, cfg.workers, cfg.operations, cfg.mode, cfg.seed)
+
+	db := openStressSchema(t, dsn, cfg)
+	h := &stressHarness{
+		t:        t,
+		db:       db,
+		service:  newStressService(db, cfg.mode),
+		cfg:      cfg,
+		expected: make(map[string]int64),
+		applied:  make(map[string]string),
+	}
+	h.createAccounts()
+
+	rng := rand.New(rand.NewPCG(cfg.seed, 0x5eed))
+	n := cfg.operations
+
+	h.phase("fund", h.generate(rng, "fund", n, func(r *rand.Rand) stressKind { return stressDeposit }, cfg.accounts), 1)
+
+	h.phase("mixed", h.generate(rng, "mixed", n, func(r *rand.Rand) stressKind {
+		switch p := r.IntN(10); {
+		case p < 4:
+			return stressTransfer
+		case p < 7:
+			return stressDeposit
+		default:
+			return stressWithdraw
+		}
+	}, cfg.accounts), 1)
+
+	// Transfers in both directions between four accounts maximise lock
+	// contention and would deadlock without a consistent lock order.
+	h.phase("hotspot", h.generate(rng, "hotspot", n, func(r *rand.Rand) stressKind {
+		if r.IntN(5) == 0 {
+			return stressWithdraw
+		}
+		return stressTransfer
+	}, 4), 1)
+
+	// Every operation is submitted twice at once; each key must take effect once.
+	h.phase("replay", h.generate(rng, "replay", n/2, func(r *rand.Rand) stressKind {
+		return stressKind(r.IntN(3))
+	}, cfg.accounts), 2)
+}
+
+func newStressService(db *gorm.DB, mode ledger.ConcurrencyMode) ledger.LedgerService {
+	quiet := &log.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	return ledger.NewLedgerService(quiet, ledger.NewReplicatedLedgerRepository(db, nil, nil, mode), ledger.Pricing{}, nil)
+}
+
+// openStressSchema migrates a schema of its own, so runs never see each
+// other's data and the immutable ledger_entries need no cleanup.
+func openStressSchema(t testing.TB, dsn string, cfg stressConfig) *gorm.DB {
+	schema := fmt.Sprintf("ledger_stress_%d", time.Now().UnixNano())
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if os.Getenv("STRESS_KEEP_SCHEMA") == "true" {
+			t.Logf("kept schema %s", schema)
+		} else if err := admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("STRESS_DATABASE_URL must be a URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	db, err := gorm.Open(postgres.Open(u.String()), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to schema: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.workers + 4)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := migrations.Up(ctx, sqlDB, migrations.Config{Dir: "../migrations"}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func (h *stressHarness) createAccounts() {
+	for i := range h.cfg.accounts {
+		acc, err := h.service.CreateAccount(context.Background(), &ledger.CreateAccountRequest{Name: fmt.Sprintf("stress-%d", i)})
+		if err != nil {
+			h.t.Fatalf("create account: %v", err)
+		}
+		h.accounts = append(h.accounts, acc.ID)
+		h.expected[acc.ID] = 0
+	}
+}
+
+// generate draws n operations over the first pool accounts.
+func (h *stressHarness) generate(r *rand.Rand, phase string, n int, kind func(*rand.Rand) stressKind, pool int) []stressOp {
+	ops := make([]stressOp, n)
+	for i := range ops {
+		op := stressOp{
+			kind:   kind(r),
+			from:   r.IntN(pool),
+			to:     r.IntN(pool),
+			amount: 1 + r.Int64N(10_000),
+			key:    fmt.Sprintf("stress-%d-%s-%d", h.cfg.seed, phase, i),
+		}
+		if op.kind == stressDeposit {
+			op.to = op.from
+		}
+		for op.kind == stressTransfer && op.to == op.from {
+			op.to = r.IntN(pool)
+		}
+		ops[i] = op
+	}
+	return ops
+}
+
+// phase runs ops across the workers, each submitted copies times, and then
+// checks the invariants. A failed phase ends the test.
+func (h *stressHarness) phase(name string, ops []stressOp, copies int) {
+	passed := h.t.Run(name, func(t *testing.T) {
+		schedule := make([][]stressOp, h.cfg.workers)
+		for i, op := range ops {
+			for c := range copies {
+				w := (i + c*(h.cfg.workers/2)) % h.cfg.workers
+				schedule[w] = append(schedule[w], op)
+			}
+		}
+
+		start := time.Now()
+		refusedBefore := h.refused.Load()
+		var failures atomic.Int64
+		var firstErr sync.Once
+		var wg sync.WaitGroup
+		for _, work := range schedule {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, op := range work {
+					if err := h.apply(op); err != nil {
+						failures.Add(1)
+						firstErr.Do(func() { t.Errorf("%s %s: %v", name, op.key, err) })
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		t.Logf("%d operations in %s", len(ops)*copies, time.Since(start).Round(time.Millisecond))
+		if n := h.refused.Load() - refusedBefore; n > 0 {
+			t.Logf("%d operations gave up after losing too many races", n)
+		}
+		if n := failures.Load(); n > 0 {
+			t.Fatalf("%d operations failed unexpectedly", n)
+		}
+
+		h.checkInvariants(t)
+	})
+	if !passed {
+		// Later phases would only report the same broken state.
+		h.t.FailNow()
+	}
+}
+
+// apply runs one operation and records its effect. Insufficient funds, and
+// an optimistic write giving up, are expected outcomes; any other error is a
+// failure.
+func (h *stressHarness) apply(op stressOp) error {
+	ctx := context.Background()
+	from, to := h.accounts[op.from], h.accounts[op.to]
+
+	var txn *ledger.TransactionResponse
+	var err error
+	switch op.kind {
+	case stressDeposit:
+		txn, err = h.service.Deposit(ctx, to, &ledger.DepositRequest{Amount: op.amount, IdempotencyKey: op.key})
+	case stressWithdraw:
+		txn, err = h.service.Withdraw(ctx, from, &ledger.WithdrawRequest{Amount: op.amount, IdempotencyKey: op.key})
+	case stressTransfer:
+		txn, err = h.service.Transfer(ctx, &ledger.TransferRequest{
+			SourceAccountID: from, DestAccountID: to, Amount: op.amount, IdempotencyKey: op.key,
+		})
+	}
+	if errors.Is(err, ledger.ErrInsufficientFunds) {
+		return nil
+	}
+	if errors.Is(err, ledger.ErrConcurrentUpdate) {
+		h.refused.Add(1)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if id, ok := h.applied[op.key]; ok {
+		if id != txn.ID {
+			return fmt.Errorf("replay returned transaction %s, first attempt %s", txn.ID, id)
+		}
+		return nil
+	}
+	h.applied[op.key] = txn.ID
+	h.posted++
+	switch op.kind {
+	case stressDeposit:
+		h.expected[to] += op.amount
+	case stressWithdraw:
+		h.expected[from] -= op.amount
+	case stressTransfer:
+		h.expected[from] -= op.amount
+		h.expected[to] += op.amount
+	}
+	return nil
+}
+
+// BenchmarkLedgerConcurrency measures transfers in each concurrency mode,
+// spread over STRESS_ACCOUNTS accounts and concentrated on four, from
+// STRESS_WORKERS concurrent callers:
+//
+//	STRESS_DATABASE_URL=postgres://... go test -tags stress -run '^$' -bench LedgerConcurrency ./integration/
+//
+// refused/op is the share of optimistic writes that gave up.
+func BenchmarkLedgerConcurrency(b *testing.B) {
+	dsn := os.Getenv("STRESS_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("Set STRESS_DATABASE_URL to a PostgreSQL database to run the benchmark")
+	}
+	cfg := stressConfigFromEnv(b)
+	ctx := context.Background()
+
+	for _, mode := range []ledger.ConcurrencyMode{ledger.ConcurrencyPessimistic, ledger.ConcurrencyOptimistic} {
+		b.Run(string(mode), func(b *testing.B) {
+			service := newStressService(openStressSchema(b, dsn, cfg), mode)
+			accounts := make([]string, cfg.accounts)
+			for i := range accounts {
+				acc, err := service.CreateAccount(ctx, &ledger.CreateAccountRequest{Name: fmt.Sprintf("bench-%d", i)})
+				if err != nil {
+					b.Fatalf("create account: %v", err)
+				}
+				accounts[i] = acc.ID
+				if _, err := service.Deposit(ctx, acc.ID, &ledger.DepositRequest{Amount: 1 << 40, IdempotencyKey: "bench-fund-" + acc.ID}); err != nil {
+					b.Fatalf("fund account: %v", err)
+				}
+			}
+
+			// Keys stay unique across the runs the benchmark makes of each
+			// workload, so none is a replay.
+			var seq atomic.Uint64
+			for _, workload := range []struct {
+				name string
+				pool int
+			}{{"spread", cfg.accounts}, {"hotspot", 4}} {
+				b.Run(workload.name, func(b *testing.B) {
+					var refused atomic.Int64
+					b.SetParallelism(max(1, cfg.workers/runtime.GOMAXPROCS(0)))
+					b.ResetTimer()
+					b.RunParallel(func(pb *testing.PB) {
+						rng := rand.New(rand.NewPCG(cfg.seed, seq.Add(1)))
+						for pb.Next() {
+							from := rng.IntN(workload.pool)
+							to := (from + 1 + rng.IntN(workload.pool-1)) % workload.pool
+							_, err := service.Transfer(ctx, &ledger.TransferRequest{
+								SourceAccountID: accounts[from],
+								DestAccountID:   accounts[to],
+								Amount:          1 + rng.Int64N(100),
+								IdempotencyKey:  fmt.Sprintf("bench-%d", seq.Add(1)),
+							})
+							if errors.Is(err, ledger.ErrConcurrentUpdate) {
+								refused.Add(1)
+							} else if err != nil {
+								b.Error(err)
+								return
+							}
+						}
+					})
+					b.ReportMetric(float64(refused.Load())/float64(b.N), "refused/op")
+				})
+			}
+		})
+	}
+}
+
+func (h *stressHarness) checkInvariants(t *testing.T) {
+	ctx := context.Background()
+
+	recon, err := h.service.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if !recon.LedgerBalanced {
+		t.Errorf("ledger not zero-sum: debits %d, credits %d", recon.TotalDebits, recon.TotalCredits)
+	}
+	for _, acc := range recon.Accounts {
+		if !acc.IsConsistent {
+			t.Errorf("account %s: cached balance %d, derived from entries %d", acc.AccountID, acc.CachedBalance, acc.DerivedBalance)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var total int64
+	for _, id := range h.accounts {
+		balance, err := h.service.GetBalance(ctx, id)
+		if err != nil {
+			t.Fatalf("balance of %s: %v", id, err)
+		}
+		if balance.CachedBalance != h.expected[id] {
+			t.Errorf("account %s: balance %d, successful operations imply %d", id, balance.CachedBalance, h.expected[id])
+		}
+		if balance.CachedBalance < 0 {
+			t.Errorf("account %s overdrawn: %d", id, balance.CachedBalance)
+		}
+		total += balance.CachedBalance
+	}
+
+	var system models.Account
+	if err := h.db.First(&system, "id = ?", models.SystemAccountID).Error; err != nil {
+		t.Fatalf("system account: %v", err)
+	}
+	if system.Balance != -total {
+		t.Errorf("system account holds %d, user accounts %d; money was created or lost", system.Balance, total)
+	}
+
+	var posted int64
+	if err := h.db.Model(&models.Transaction{}).Count(&posted).Error; err != nil {
+		t.Fatalf("count transactions: %v", err)
+	}
+	if posted != h.posted {
+		t.Errorf("%d transactions stored, %d operations succeeded; replays must not post twice", posted, h.posted)
+	}
+}
@@ -10,10 +10,10 @@ import (
 
 type contextKey string
 
-
-
 var CorrelatedIDKey contextKey = "correlation_id"
+
 const LoggerKeyForContext contextKey = "logger"
+
 type Logger struct {
 	*slog.Logger
 }
@@ -32,6 +32,16 @@ func (l *Logger) WithCorrelationID(ctx context.Context) *Logger {
 	}
 }
 
+// WithFields returns a logger that adds the given key/value pairs to every record.
+func (l *Logger) WithFields(args ...any) *Logger {
+	if len(args) == 0 {
+		return l
+	}
+	return &Logger{
+		Logger: l.Logger.With(args...),
+	}
+}
+
 func GetOrGenerateCorrelationID(ctx context.Context) string {
 	if id := ctx.Value(CorrelatedIDKey); id != nil {
 		if s, ok := id.(string); ok {
@@ -48,10 +58,6 @@ func GenerateCorrelationID() string {
 	return uuid.New().String()
 }
 
-
-
-
-
 func GetLoggerInstanceFromContext(ctx context.Context, fallbackLogger *Logger) *Logger {
 	if ctx != nil {
 		if logger := ctx.Value(LoggerKeyForContext); logger != nil {
@@ -60,14 +66,12 @@ func GetLoggerInstanceFromContext(ctx context.Context, fallbackLogger *Logger) *
 			}
 		}
 
-
 		if fallbackLogger != nil {
 			return fallbackLogger.WithCorrelationID(ctx)
 		}
 		return NewLoggerWithJSONOutput().WithCorrelationID(ctx)
 	}
 
-
 	if fallbackLogger != nil {
 		return fallbackLogger
 	}
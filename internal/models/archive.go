@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// ArchivedTransaction is a transaction moved out of transactions by the
+// ledger's archival job, unchanged apart from ArchivedAt. Its description
+// stays encrypted as it was.
+type ArchivedTransaction struct {
+	ID                    string `gorm:"type:text;primaryKey"`
+	IdempotencyKey        string `gorm:"index"`
+	TransactionType       string `gorm:"not null"`
+	Amount                int64  `gorm:"not null"`
+	Currency              string `gorm:"type:char(3);not null"`
+	DestAmount            int64  `gorm:"not null;default:0"`
+	DestCurrency          string `gorm:"type:char(3);not null"`
+	ExchangeRate          string `gorm:"not null;default:1"`
+	Fee                   int64  `gorm:"not null;default:0"`
+	Description           string
+	ReversedTransactionID *string   `gorm:"type:text"`
+	CreatedAt             time.Time `gorm:"not null;index"`
+	ArchivedAt            time.Time `gorm:"not null"`
+}
+
+// ArchivedLedgerEntry is a ledger entry moved out with its transaction.
+type ArchivedLedgerEntry struct {
+	ID            string    `gorm:"type:text;primaryKey"`
+	TransactionID string    `gorm:"type:text;not null;index"`
+	AccountID     string    `gorm:"type:text;not null;index:idx_archived_ledger_entries_account_created"`
+	EntryType     string    `gorm:"not null"`
+	Amount        int64     `gorm:"not null"`
+	BalanceAfter  int64     `gorm:"not null"`
+	CreatedAt     time.Time `gorm:"not null;index:idx_archived_ledger_entries_account_created"`
+	PrevHash      string    `gorm:"type:text;not null;default:''"`
+	EntryHash     string    `gorm:"type:text;not null;default:''"`
+}
+
+// ArchivedDailyTotal sums an account's archived entries for one UTC day. The
+// totals stay online so derived balances and the daily balance report still
+// cover archived history.
+type ArchivedDailyTotal struct {
+	AccountID  string    `gorm:"type:text;primaryKey"`
+	Day        time.Time `gorm:"type:date;primaryKey"`
+	Credits    int64     `gorm:"not null;default:0"`
+	Debits     int64     `gorm:"not null;default:0"`
+	EntryCount int64     `gorm:"not null;default:0"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// DailySeal closes a UTC day of the ledger. Digest chains every entry created
+// that day, live or archived, onto PreviousDigest, the digest of the day
+// before, which is empty for the first day sealed. Changing, adding or
+// removing a sealed day's entry, or a seal, breaks the chain from there on.
+type DailySeal struct {
+	Day            time.Time `gorm:"type:date;primaryKey" json:"day"`
+	EntryCount     int64     `gorm:"not null" json:"entry_count"`
+	PreviousDigest string    `gorm:"type:text;not null;default:''" json:"previous_digest"`
+	Digest         string    `gorm:"type:text;not null" json:"digest"`
+	SealedAt       time.Time `gorm:"not null" json:"sealed_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// FXRate is the latest known price of one unit of Base in units of Quote.
+// Value is a decimal string so it round-trips without float error; AsOf is
+// when the source published it.
+type FXRate struct {
+	Base      string    `gorm:"type:char(3);primaryKey"`
+	Quote     string    `gorm:"type:char(3);primaryKey"`
+	Value     string    `gorm:"type:text;not null"`
+	AsOf      time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is a claimed Idempotency-Key. CompletedAt is nil while the
+// request runs; afterwards the record holds the response to replay, with
+// Header as a JSON object, until ExpiresAt.
+type IdempotencyRecord struct {
+	IdempotencyKey string `gorm:"type:text;primaryKey"`
+	Fingerprint    string `gorm:"type:text;not null"`
+	StatusCode     int    `gorm:"not null;default:0"`
+	Header         string `gorm:"type:text"`
+	Body           []byte
+	CompletedAt    *time.Time
+	ExpiresAt      time.Time `gorm:"not null;index"`
+	CreatedAt      time.Time `gorm:"not null"`
+}
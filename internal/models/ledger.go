@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/akeren/go-api-foundry/pkg/region"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -15,9 +16,12 @@ const (
 
 // Transaction types
 const (
-	TransactionTypeDeposit    = "DEPOSIT"
-	TransactionTypeWithdrawal = "WITHDRAWAL"
-	TransactionTypeTransfer   = "TRANSFER"
+	TransactionTypeDeposit       = "DEPOSIT"
+	TransactionTypeWithdrawal    = "WITHDRAWAL"
+	TransactionTypeTransfer      = "TRANSFER"
+	TransactionTypeReversal      = "REVERSAL"
+	TransactionTypeSplitTransfer = "SPLIT_TRANSFER"
+	TransactionTypeAccrual       = "ACCRUAL"
 )
 
 // Entry types
@@ -27,45 +31,114 @@ const (
 )
 
 // SystemAccountID is the well-known UUID for the external funding source.
+// It holds SystemAccountCurrency; each other currency has its own system
+// account, see SystemAccountIDFor.
 const SystemAccountID = "00000000-0000-0000-0000-000000000001"
 
+// SystemAccountCurrency is the currency of the seeded system account.
+const SystemAccountCurrency = "USD"
+
+// SystemAccountIDFor returns the ID of the system account holding currency:
+// SystemAccountID for SystemAccountCurrency, and otherwise a version 5 UUID
+// derived from SystemAccountID and the currency, so every instance agrees on
+// it without a lookup.
+func SystemAccountIDFor(currency string) string {
+	if currency == SystemAccountCurrency {
+		return SystemAccountID
+	}
+	return uuid.NewSHA1(uuid.MustParse(SystemAccountID), []byte(currency)).String()
+}
+
+// IsSystemAccountID reports whether id names a system account of any
+// currency. Other IDs are never version 5 UUIDs.
+func IsSystemAccountID(id string) bool {
+	if id == SystemAccountID {
+		return true
+	}
+	parsed, err := uuid.Parse(id)
+	return err == nil && parsed.Version() == 5
+}
+
+// Sibling transfer policies, set on a parent account for its direct children
+const (
+	SiblingTransfersAllow = "ALLOW"
+	SiblingTransfersDeny  = "DENY"
+)
+
+// Account is a ledger account. ParentID links a sub-account (e.g. a merchant
+// location) to its parent; the parent's SiblingTransfers policy decides
+// whether its children may transfer to each other. HomeRegion is the region
+// that accepts writes to the account; empty means any region. LastEntryHash
+// is the EntryHash of the account's latest entry, where its chain ends.
 type Account struct {
-	ID          string    `gorm:"type:text;primaryKey" json:"id"`
-	Name        string    `gorm:"not null" json:"name"`
-	AccountType string    `gorm:"not null" json:"account_type"`
-	Currency    string    `gorm:"type:char(3);not null;default:USD" json:"currency"`
-	Balance     int64     `gorm:"not null;default:0" json:"balance"`
-	Version     int64     `gorm:"not null;default:0" json:"version"`
-	CreatedAt   time.Time `gorm:"not null" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"not null" json:"updated_at"`
+	ID               string    `gorm:"type:text;primaryKey" json:"id"`
+	ParentID         *string   `gorm:"type:text;index" json:"parent_id,omitempty"`
+	Name             string    `gorm:"not null" json:"name"`
+	AccountType      string    `gorm:"not null" json:"account_type"`
+	Currency         string    `gorm:"type:char(3);not null;default:USD" json:"currency"`
+	Balance          int64     `gorm:"not null;default:0" json:"balance"`
+	SiblingTransfers string    `gorm:"not null;default:ALLOW" json:"sibling_transfers"`
+	HomeRegion       string    `gorm:"not null;default:''" json:"home_region,omitempty"`
+	Version          int64     `gorm:"not null;default:0" json:"version"`
+	LastEntryHash    string    `gorm:"type:text;not null;default:''" json:"last_entry_hash"`
+	CreatedAt        time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"not null" json:"updated_at"`
 }
 
 func (a *Account) BeforeCreate(tx *gorm.DB) error {
 	if a.ID == "" {
-		a.ID = uuid.New().String()
+		a.ID = region.NewID()
 	}
 	return nil
 }
 
+// Transaction is one ledger posting. Fee is charged to the source account on
+// top of Amount and credited to the system account. Amount and Fee are in
+// Currency; the destination receives DestAmount of DestCurrency, converted at
+// ExchangeRate, which is "1" unless the transfer crosses currencies.
+//
+// A REVERSAL carries the amounts of the transaction it undoes, named by
+// ReversedTransactionID, and posts each of its entries the other way round.
+// A transaction is reversed at most once.
 type Transaction struct {
-	ID              string    `gorm:"type:text;primaryKey" json:"id"`
-	IdempotencyKey  string    `gorm:"uniqueIndex" json:"idempotency_key"`
-	TransactionType string    `gorm:"not null" json:"transaction_type"`
-	Amount          int64     `gorm:"not null" json:"amount"`
-	Currency        string    `gorm:"type:char(3);not null;default:USD" json:"currency"`
-	Description     string    `json:"description"`
-	CreatedAt       time.Time `gorm:"not null" json:"created_at"`
+	ID                    string    `gorm:"type:text;primaryKey" json:"id"`
+	IdempotencyKey        string    `gorm:"uniqueIndex" json:"idempotency_key"`
+	TransactionType       string    `gorm:"not null" json:"transaction_type"`
+	Amount                int64     `gorm:"not null" json:"amount"`
+	Currency              string    `gorm:"type:char(3);not null;default:USD" json:"currency"`
+	DestAmount            int64     `gorm:"not null;default:0" json:"dest_amount"`
+	DestCurrency          string    `gorm:"type:char(3);not null;default:USD" json:"dest_currency"`
+	ExchangeRate          string    `gorm:"not null;default:1" json:"exchange_rate"`
+	Fee                   int64     `gorm:"not null;default:0" json:"fee"`
+	Description           string    `json:"description"`
+	ReversedTransactionID *string   `gorm:"type:text;uniqueIndex" json:"reversed_transaction_id,omitempty"`
+	CreatedAt             time.Time `gorm:"not null" json:"created_at"`
 
 	Entries []LedgerEntry `gorm:"foreignKey:TransactionID" json:"entries,omitempty"`
+	// AlertTriggers are the balance alerts the posting triggered. They are
+	// stored on their own, not through the transaction.
+	AlertTriggers []BalanceAlertTrigger `gorm:"-" json:"-"`
 }
 
 func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
 	if t.ID == "" {
-		t.ID = uuid.New().String()
+		t.ID = region.NewID()
+	}
+	// Entries take their transaction's time and hash it, so it is kept at
+	// the precision the database stores and hashes the same when read back.
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now().Truncate(time.Microsecond)
 	}
 	return nil
 }
 
+// TransactionSearchToken is the blind index of one word of a transaction
+// description, used to search descriptions that are encrypted at rest.
+type TransactionSearchToken struct {
+	TransactionID string `gorm:"type:text;primaryKey"`
+	Token         string `gorm:"type:text;primaryKey;index"`
+}
+
 type LedgerEntry struct {
 	ID            string    `gorm:"type:text;primaryKey" json:"id"`
 	TransactionID string    `gorm:"not null;index" json:"transaction_id"`
@@ -74,6 +147,12 @@ type LedgerEntry struct {
 	Amount        int64     `gorm:"not null" json:"amount"`
 	BalanceAfter  int64     `gorm:"not null" json:"balance_after"`
 	CreatedAt     time.Time `gorm:"not null" json:"created_at"`
+	// PrevHash is the EntryHash of the account's entry before this one, ""
+	// for its first. EntryHash covers the entry's contents and PrevHash, so
+	// changing any entry breaks the account's chain from there on. Entries
+	// posted before hashing was introduced have neither.
+	PrevHash  string `gorm:"type:text;not null;default:''" json:"prev_hash"`
+	EntryHash string `gorm:"type:text;not null;default:''" json:"entry_hash"`
 
 	Transaction Transaction `gorm:"foreignKey:TransactionID" json:"-"`
 	Account     Account     `gorm:"foreignKey:AccountID" json:"-"`
@@ -81,7 +160,181 @@ type LedgerEntry struct {
 
 func (e *LedgerEntry) BeforeCreate(tx *gorm.DB) error {
 	if e.ID == "" {
-		e.ID = uuid.New().String()
+		e.ID = region.NewID()
+	}
+	return nil
+}
+
+// TransferQuote holds the fee and exchange rate offered for a prospective
+// transfer. A transfer that references the quote before it expires is charged
+// those terms; each quote can be used once. ExchangeRate is a decimal string
+// so it round-trips without float error.
+type TransferQuote struct {
+	ID              string    `gorm:"type:text;primaryKey" json:"id"`
+	SourceAccountID string    `gorm:"not null" json:"source_account_id"`
+	DestAccountID   string    `gorm:"not null" json:"dest_account_id"`
+	Amount          int64     `gorm:"not null" json:"amount"`
+	Currency        string    `gorm:"type:char(3);not null" json:"currency"`
+	Fee             int64     `gorm:"not null" json:"fee"`
+	ExchangeRate    string    `gorm:"not null" json:"exchange_rate"`
+	TransactionID   *string   `gorm:"type:text" json:"transaction_id,omitempty"`
+	ExpiresAt       time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt       time.Time `gorm:"not null" json:"created_at"`
+}
+
+func (q *TransferQuote) BeforeCreate(tx *gorm.DB) error {
+	if q.ID == "" {
+		q.ID = region.NewID()
+	}
+	return nil
+}
+
+// Hold statuses
+const (
+	HoldStatusActive   = "ACTIVE"
+	HoldStatusCaptured = "CAPTURED"
+	HoldStatusReleased = "RELEASED"
+	HoldStatusExpired  = "EXPIRED"
+)
+
+// Hold reserves Amount of an account's funds for a later capture. While it is
+// ACTIVE and unexpired it reduces the account's available balance, but not
+// its posted balance. Capturing posts a transfer to DestAccountID, or a
+// withdrawal when there is none, of at most Amount; releasing or expiring
+// frees the funds.
+type Hold struct {
+	ID             string    `gorm:"type:text;primaryKey" json:"id"`
+	AccountID      string    `gorm:"type:text;not null;index" json:"account_id"`
+	DestAccountID  *string   `gorm:"type:text" json:"dest_account_id,omitempty"`
+	Amount         int64     `gorm:"not null" json:"amount"`
+	Currency       string    `gorm:"type:char(3);not null" json:"currency"`
+	Status         string    `gorm:"not null;default:ACTIVE" json:"status"`
+	IdempotencyKey string    `gorm:"uniqueIndex;not null" json:"idempotency_key"`
+	CapturedAmount int64     `gorm:"not null;default:0" json:"captured_amount"`
+	TransactionID  *string   `gorm:"type:text" json:"transaction_id,omitempty"`
+	ExpiresAt      time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt      time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"not null" json:"updated_at"`
+}
+
+func (h *Hold) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == "" {
+		h.ID = region.NewID()
+	}
+	return nil
+}
+
+// Balance alert kinds
+const (
+	AlertKindLowBalance = "LOW_BALANCE"
+	AlertKindLargeDebit = "LARGE_DEBIT"
+)
+
+// BalanceAlert watches the postings to an account. A LOW_BALANCE alert
+// triggers when a posting takes the balance from at least Threshold to below
+// it, a LARGE_DEBIT alert when one posting debits at least Threshold.
+// NotifyEmail, when set, is emailed each time the alert triggers.
+type BalanceAlert struct {
+	ID          string    `gorm:"type:text;primaryKey" json:"id"`
+	AccountID   string    `gorm:"type:text;not null;index" json:"account_id"`
+	Kind        string    `gorm:"not null" json:"kind"`
+	Threshold   int64     `gorm:"not null" json:"threshold"`
+	NotifyEmail string    `gorm:"not null;default:''" json:"notify_email,omitempty"`
+	CreatedAt   time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"not null" json:"updated_at"`
+}
+
+func (a *BalanceAlert) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = region.NewID()
+	}
+	return nil
+}
+
+// BalanceAlertTrigger records that a posting triggered an alert. It is
+// written in the posting's database transaction, so it exists exactly when
+// the posting committed. Amount is the debit of a LARGE_DEBIT alert and
+// Balance the account's balance after the posting, both in Currency.
+// NotifiedAt is set when the alert's email is claimed for sending.
+type BalanceAlertTrigger struct {
+	ID            string     `gorm:"type:text;primaryKey" json:"id"`
+	AlertID       string     `gorm:"type:text;not null;index" json:"alert_id"`
+	AccountID     string     `gorm:"type:text;not null" json:"account_id"`
+	TransactionID string     `gorm:"type:text;not null;index" json:"transaction_id"`
+	Kind          string     `gorm:"not null" json:"kind"`
+	Threshold     int64      `gorm:"not null" json:"threshold"`
+	Amount        int64      `gorm:"not null;default:0" json:"amount"`
+	Balance       int64      `gorm:"not null" json:"balance"`
+	Currency      string     `gorm:"type:char(3);not null" json:"currency"`
+	NotifiedAt    *time.Time `json:"notified_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"not null" json:"created_at"`
+}
+
+func (t *BalanceAlertTrigger) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = region.NewID()
+	}
+	return nil
+}
+
+// Accrual kinds
+const (
+	AccrualKindInterest = "INTEREST"
+	AccrualKindFee      = "FEE"
+)
+
+// AccrualPlan accrues interest onto an account, or charges it a fee, for
+// every UTC day from StartsOn, at RateBasisPoints of the day's balance a
+// year. AccruedThrough is the last day accrued; nil until the first.
+type AccrualPlan struct {
+	ID              string     `gorm:"type:text;primaryKey" json:"id"`
+	AccountID       string     `gorm:"type:text;not null;index" json:"account_id"`
+	Kind            string     `gorm:"not null" json:"kind"`
+	RateBasisPoints int64      `gorm:"not null" json:"rate_basis_points"`
+	StartsOn        time.Time  `gorm:"type:date;not null" json:"starts_on"`
+	AccruedThrough  *time.Time `gorm:"type:date" json:"accrued_through,omitempty"`
+	CreatedAt       time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+func (p *AccrualPlan) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = region.NewID()
+	}
+	return nil
+}
+
+// AccrualRecord is the calculation of one day of an accrual plan: Amount is
+// Basis times RateBasisPoints over 10000 and DaysInYear, rounded half up.
+// ClosingBalance is the account's balance at the end of the day and Basis
+// that balance with every accrual for earlier days counted as posted by then.
+// TransactionID is the posting, nil when Amount is zero.
+//
+// Records are never changed but to be superseded: a correction sets
+// SupersededAt, and ReversalTransactionID when it reverses the posting, and
+// adds the day's next Revision.
+type AccrualRecord struct {
+	ID                    string     `gorm:"type:text;primaryKey" json:"id"`
+	PlanID                string     `gorm:"type:text;not null;uniqueIndex:idx_accrual_records_plan_day_revision" json:"plan_id"`
+	AccountID             string     `gorm:"type:text;not null;index" json:"account_id"`
+	Day                   time.Time  `gorm:"type:date;not null;uniqueIndex:idx_accrual_records_plan_day_revision" json:"day"`
+	Revision              int        `gorm:"not null;uniqueIndex:idx_accrual_records_plan_day_revision" json:"revision"`
+	Kind                  string     `gorm:"not null" json:"kind"`
+	ClosingBalance        int64      `gorm:"not null" json:"closing_balance"`
+	Basis                 int64      `gorm:"not null" json:"basis"`
+	RateBasisPoints       int64      `gorm:"not null" json:"rate_basis_points"`
+	DaysInYear            int64      `gorm:"not null" json:"days_in_year"`
+	Amount                int64      `gorm:"not null" json:"amount"`
+	Currency              string     `gorm:"type:char(3);not null" json:"currency"`
+	TransactionID         *string    `gorm:"type:text" json:"transaction_id,omitempty"`
+	ReversalTransactionID *string    `gorm:"type:text" json:"reversal_transaction_id,omitempty"`
+	SupersededAt          *time.Time `json:"superseded_at,omitempty"`
+	CreatedAt             time.Time  `gorm:"not null" json:"created_at"`
+}
+
+func (r *AccrualRecord) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = region.NewID()
 	}
 	return nil
 }
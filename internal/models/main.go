@@ -4,4 +4,24 @@ var ModelRegistry = []any{
 	&Account{},
 	&Transaction{},
 	&LedgerEntry{},
+	&TransactionSearchToken{},
+	&Operation{},
+	&TransferQuote{},
+	&RoleBinding{},
+	&StatusCheck{},
+	&Incident{},
+	&WebhookEndpoint{},
+	&WebhookDelivery{},
+	&WebhookAttempt{},
+	&IdempotencyRecord{},
+	&FXRate{},
+	&Hold{},
+	&ArchivedTransaction{},
+	&ArchivedLedgerEntry{},
+	&ArchivedDailyTotal{},
+	&BalanceAlert{},
+	&BalanceAlertTrigger{},
+	&AccrualPlan{},
+	&AccrualRecord{},
+	&DailySeal{},
 }
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"gorm.io/gorm"
+)
+
+// Operation statuses
+const (
+	OperationStatusPending   = "PENDING"
+	OperationStatusRunning   = "RUNNING"
+	OperationStatusSucceeded = "SUCCEEDED"
+	OperationStatusFailed    = "FAILED"
+)
+
+// Operation tracks a long-running request that completes asynchronously.
+// Result holds the JSON-encoded outcome once the operation succeeds.
+type Operation struct {
+	ID          string    `gorm:"type:text;primaryKey"`
+	Kind        string    `gorm:"not null;index"`
+	Status      string    `gorm:"not null"`
+	Progress    int       `gorm:"not null;default:0"`
+	Result      string    `gorm:"type:text"`
+	Error       string    `gorm:"type:text"`
+	WebhookURL  string    `gorm:"type:text"`
+	CreatedAt   time.Time `gorm:"not null"`
+	UpdatedAt   time.Time `gorm:"not null"`
+	CompletedAt *time.Time
+}
+
+func (o *Operation) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == "" {
+		o.ID = region.NewID()
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RoleBinding grants Role to the principal of the given kind ("user" or
+// "service") and subject, in addition to any roles its credentials carry.
+type RoleBinding struct {
+	Kind      string    `gorm:"type:text;primaryKey" json:"kind"`
+	Subject   string    `gorm:"type:text;primaryKey" json:"subject"`
+	Role      string    `gorm:"type:text;primaryKey" json:"role"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"gorm.io/gorm"
+)
+
+// Incident impacts, from least to most severe
+const (
+	IncidentImpactMinor    = "minor"
+	IncidentImpactMajor    = "major"
+	IncidentImpactCritical = "critical"
+)
+
+// StatusCheck counts the health checks of one component within one hour
+// (Hour is the UTC start of the hour). Instances add to the same row.
+type StatusCheck struct {
+	Component string    `gorm:"type:text;primaryKey"`
+	Hour      time.Time `gorm:"primaryKey"`
+	Checks    int64     `gorm:"not null;default:0"`
+	Failures  int64     `gorm:"not null;default:0"`
+}
+
+// Incident is an operator annotation shown on the status page. Components
+// is a comma-separated list of affected components; empty means all.
+type Incident struct {
+	ID         string    `gorm:"type:text;primaryKey"`
+	Title      string    `gorm:"not null"`
+	Message    string    `gorm:"type:text"`
+	Impact     string    `gorm:"not null"`
+	Components string    `gorm:"type:text"`
+	StartedAt  time.Time `gorm:"not null;index"`
+	ResolvedAt *time.Time
+	CreatedAt  time.Time `gorm:"not null"`
+	UpdatedAt  time.Time `gorm:"not null"`
+}
+
+func (i *Incident) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = region.NewID()
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"gorm.io/gorm"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliverySucceeded = "SUCCEEDED"
+	WebhookDeliveryFailed    = "FAILED"
+)
+
+// WebhookEndpoint receives the events matching EventTypes, a comma-separated
+// list of patterns such as "transaction.*". Secret signs each delivery and
+// may be stored encrypted. An Ordered endpoint receives its deliveries one
+// at a time, in OccurredAt order.
+type WebhookEndpoint struct {
+	ID          string    `gorm:"type:text;primaryKey"`
+	URL         string    `gorm:"type:text;not null"`
+	Secret      string    `gorm:"type:text;not null"`
+	EventTypes  string    `gorm:"type:text;not null"`
+	Description string    `gorm:"type:text"`
+	Active      bool      `gorm:"not null"`
+	Ordered     bool      `gorm:"not null;default:false"`
+	CreatedAt   time.Time `gorm:"not null"`
+	UpdatedAt   time.Time `gorm:"not null"`
+}
+
+func (e *WebhookEndpoint) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = region.NewID()
+	}
+	return nil
+}
+
+// WebhookDelivery is one event queued for one endpoint. Payload is the exact
+// JSON body sent on every attempt; pending deliveries are retried from
+// NextAttemptAt.
+type WebhookDelivery struct {
+	ID             string    `gorm:"type:text;primaryKey"`
+	EndpointID     string    `gorm:"type:text;not null;uniqueIndex:idx_webhook_deliveries_endpoint_event;index:idx_webhook_deliveries_endpoint_order,priority:1"`
+	EventID        string    `gorm:"type:text;not null;uniqueIndex:idx_webhook_deliveries_endpoint_event"`
+	EventType      string    `gorm:"type:text;not null"`
+	OccurredAt     time.Time `gorm:"not null;index:idx_webhook_deliveries_endpoint_order,priority:2"`
+	Payload        string    `gorm:"type:text;not null"`
+	Status         string    `gorm:"not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastError      string    `gorm:"type:text"`
+	LastStatusCode int       `gorm:"not null;default:0"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time `gorm:"not null;index"`
+	UpdatedAt      time.Time `gorm:"not null"`
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = region.NewID()
+	}
+	return nil
+}
+
+// WebhookAttempt records one HTTP request made for a delivery. StatusCode is
+// zero when no response was received.
+type WebhookAttempt struct {
+	ID          string    `gorm:"type:text;primaryKey"`
+	DeliveryID  string    `gorm:"type:text;not null;index"`
+	Attempt     int       `gorm:"not null"`
+	StatusCode  int       `gorm:"not null;default:0"`
+	Error       string    `gorm:"type:text"`
+	DurationMs  int64     `gorm:"not null;default:0"`
+	AttemptedAt time.Time `gorm:"not null"`
+}
+
+func (a *WebhookAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = region.NewID()
+	}
+	return nil
+}
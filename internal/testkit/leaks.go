@@ -0,0 +1,28 @@
+// Package testkit holds helpers shared by the repository's tests.
+package testkit
+
+import (
+	"testing"
+
+	"go.uber.org/goleak"
+)
+
+// ignored lists goroutines that dependencies start once per process and never
+// stop; they are not leaks of the code under test.
+var ignored = []goleak.Option{}
+
+// VerifyNoLeaks fails t when goroutines other than the test's own are still
+// running. goleak retries for a short while, so goroutines that are already
+// shutting down do not count. Call it deferred at the top of a test.
+func VerifyNoLeaks(t testing.TB, opts ...goleak.Option) {
+	t.Helper()
+	goleak.VerifyNone(t, append(ignored, opts...)...)
+}
+
+// VerifyTestMain runs the package's tests and then fails the run when any of
+// them left goroutines behind. Use it as the package's TestMain:
+//
+//	func TestMain(m *testing.M) { testkit.VerifyTestMain(m) }
+func VerifyTestMain(m *testing.M, opts ...goleak.Option) {
+	goleak.VerifyTestMain(m, append(ignored, opts...)...)
+}
@@ -0,0 +1,168 @@
+package testkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// UpdateSnapshotsEnv names the environment variable that makes
+// MatchJSONSnapshot rewrite golden files instead of comparing against them.
+const UpdateSnapshotsEnv = "UPDATE_SNAPSHOTS"
+
+// snapshotDir is where golden files live, relative to the test's package.
+const snapshotDir = "testdata/snapshots"
+
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// SnapshotOption adjusts how a document is redacted before it is compared.
+type SnapshotOption func(*snapshotConfig)
+
+type snapshotConfig struct {
+	fields []string
+}
+
+// RedactFields replaces the values of the named object fields, at any depth,
+// with "<redacted>". Use it for values that change between runs but are not
+// timestamps or UUIDs, such as generated tokens.
+func RedactFields(names ...string) SnapshotOption {
+	return func(cfg *snapshotConfig) {
+		cfg.fields = append(cfg.fields, names...)
+	}
+}
+
+// MatchJSONSnapshot fails t when body, a JSON document, differs from the
+// golden file testdata/snapshots/<name>.json, and prints a unified diff.
+//
+// Values that change between runs are redacted first: RFC 3339 timestamps
+// become "<timestamp>" and UUIDs, also inside longer strings such as links,
+// become "<uuid-1>", "<uuid-2>", ... in order of first appearance, so a
+// snapshot still shows which IDs are the same. Run the tests with
+// UPDATE_SNAPSHOTS=true to write missing or changed golden files.
+func MatchJSONSnapshot(t testing.TB, name string, body []byte, opts ...SnapshotOption) {
+	t.Helper()
+
+	var cfg snapshotConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	got, err := normalizeSnapshot(body, &cfg)
+	if err != nil {
+		t.Fatalf("snapshot %s: response is not JSON: %v\n%s", name, err, body)
+	}
+
+	path := filepath.Join(snapshotDir, name+".json")
+	if update, _ := strconv.ParseBool(os.Getenv(UpdateSnapshotsEnv)); update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("snapshot %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("snapshot %s: %v", name, err)
+		}
+		t.Logf("snapshot %s: wrote %s", name, path)
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("snapshot %s: %s does not exist; run with %s=true to create it", name, path, UpdateSnapshotsEnv)
+	}
+	if err != nil {
+		t.Fatalf("snapshot %s: %v", name, err)
+	}
+	if bytes.Equal(want, got) {
+		return
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(want)),
+		B:        difflib.SplitLines(string(got)),
+		FromFile: path,
+		ToFile:   "response",
+		Context:  3,
+	})
+	if err != nil {
+		t.Fatalf("snapshot %s: %v", name, err)
+	}
+	t.Errorf("snapshot %s does not match; run with %s=true if the change is intended\n%s", name, UpdateSnapshotsEnv, diff)
+}
+
+// normalizeSnapshot redacts body and formats it with sorted keys and a fixed
+// indent, so golden files only change when the document does.
+func normalizeSnapshot(body []byte, cfg *snapshotConfig) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	r := &redactor{fields: cfg.fields, uuids: make(map[string]string)}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.redact(doc)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+type redactor struct {
+	fields []string
+	uuids  map[string]string
+}
+
+func (r *redactor) redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		// Walk keys in the sorted order they are written in, so UUIDs are
+		// numbered the same way on every run.
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			if slices.Contains(r.fields, key) && v[key] != nil {
+				v[key] = "<redacted>"
+				continue
+			}
+			v[key] = r.redact(v[key])
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = r.redact(v[i])
+		}
+		return v
+	case string:
+		return r.redactString(v)
+	}
+	return v
+}
+
+func (r *redactor) redactString(s string) string {
+	if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return "<timestamp>"
+	}
+	// UUIDs are also replaced inside strings such as links.
+	return uuidPattern.ReplaceAllStringFunc(s, func(id string) string {
+		placeholder, ok := r.uuids[id]
+		if !ok {
+			placeholder = fmt.Sprintf("<uuid-%d>", len(r.uuids)+1)
+			r.uuids[id] = placeholder
+		}
+		return placeholder
+	})
+}
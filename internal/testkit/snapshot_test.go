@@ -0,0 +1,78 @@
+package testkit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeSnapshot_Redacts(t *testing.T) {
+	body := []byte(`{
+		"data": {
+			"id": "9b2f7c1e-3a41-4d8e-9f0a-6c5b4a3d2e1f",
+			"entries": [
+				{"account_id": "9b2f7c1e-3a41-4d8e-9f0a-6c5b4a3d2e1f", "created_at": "2026-10-16T09:30:00.123456Z"},
+				{"account_id": "00000000-0000-0000-0000-000000000001", "amount": 12345678901234567}
+			],
+			"links": {"self": "/v1/ledger/accounts/9b2f7c1e-3a41-4d8e-9f0a-6c5b4a3d2e1f"},
+			"token": "abc"
+		},
+		"message": "<ok>"
+	}`)
+
+	got, err := normalizeSnapshot(body, &snapshotConfig{fields: []string{"token"}})
+	if err != nil {
+		t.Fatalf("normalizeSnapshot: %v", err)
+	}
+	want := `{
+  "data": {
+    "entries": [
+      {
+        "account_id": "<uuid-1>",
+        "created_at": "<timestamp>"
+      },
+      {
+        "account_id": "<uuid-2>",
+        "amount": 12345678901234567
+      }
+    ],
+    "id": "<uuid-1>",
+    "links": {
+      "self": "/v1/ledger/accounts/<uuid-1>"
+    },
+    "token": "<redacted>"
+  },
+  "message": "<ok>"
+}
+`
+	if string(got) != want {
+		t.Fatalf("unexpected snapshot:\n%s", got)
+	}
+}
+
+func TestMatchJSONSnapshot_WritesAndCompares(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(UpdateSnapshotsEnv, "true")
+	MatchJSONSnapshot(t, "envelope", []byte(`{"code":200,"message":"ok"}`))
+
+	if _, err := os.Stat(filepath.Join(snapshotDir, "envelope.json")); err != nil {
+		t.Fatalf("expected the golden file to be written: %v", err)
+	}
+
+	t.Setenv(UpdateSnapshotsEnv, "")
+	MatchJSONSnapshot(t, "envelope", []byte(`{"message":"ok","code":200}`))
+
+	rec := &recordingTB{TB: t}
+	MatchJSONSnapshot(rec, "envelope", []byte(`{"code":200,"msg":"ok"}`))
+	if !rec.failed {
+		t.Fatal("expected a renamed field to fail the snapshot")
+	}
+}
+
+// recordingTB records failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recordingTB) Errorf(string, ...any) { r.failed = true }
@@ -0,0 +1,10 @@
+-- Blind indexes for searching encrypted transaction descriptions.
+-- Each row is an HMAC of one normalized word of the description.
+CREATE TABLE IF NOT EXISTS transaction_search_tokens (
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    token TEXT NOT NULL,
+    PRIMARY KEY (transaction_id, token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_search_tokens_token
+    ON transaction_search_tokens (token);
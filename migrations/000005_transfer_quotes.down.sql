@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS transfer_quotes;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fee;
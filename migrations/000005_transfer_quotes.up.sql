@@ -0,0 +1,17 @@
+-- Transfer fees and short-lived transfer quotes
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee BIGINT NOT NULL DEFAULT 0 CHECK (fee >= 0);
+
+CREATE TABLE IF NOT EXISTS transfer_quotes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source_account_id UUID NOT NULL REFERENCES accounts(id),
+    dest_account_id UUID NOT NULL REFERENCES accounts(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    fee BIGINT NOT NULL CHECK (fee >= 0),
+    exchange_rate TEXT NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_quotes_expires_at ON transfer_quotes (expires_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_accounts_parent_id;
+ALTER TABLE accounts DROP COLUMN IF EXISTS sibling_transfers;
+ALTER TABLE accounts DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,6 @@
+-- Parent/child accounts; a parent's policy controls transfers between its children
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES accounts(id);
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS sibling_transfers TEXT NOT NULL DEFAULT 'ALLOW'
+    CHECK (sibling_transfers IN ('ALLOW', 'DENY'));
+
+CREATE INDEX IF NOT EXISTS idx_accounts_parent_id ON accounts (parent_id);
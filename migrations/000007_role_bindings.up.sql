@@ -0,0 +1,8 @@
+-- Roles granted to users and services on top of the roles in their credentials
+CREATE TABLE IF NOT EXISTS role_bindings (
+    kind TEXT NOT NULL CHECK (kind IN ('user', 'service')),
+    subject TEXT NOT NULL,
+    role TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (kind, subject, role)
+);
@@ -0,0 +1,22 @@
+-- Hourly health-check counts and operator incident annotations for /status
+CREATE TABLE IF NOT EXISTS status_checks (
+    component TEXT NOT NULL,
+    hour TIMESTAMPTZ NOT NULL,
+    checks BIGINT NOT NULL DEFAULT 0,
+    failures BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (component, hour)
+);
+
+CREATE TABLE IF NOT EXISTS incidents (
+    id UUID PRIMARY KEY,
+    title TEXT NOT NULL,
+    message TEXT,
+    impact TEXT NOT NULL CHECK (impact IN ('minor', 'major', 'critical')),
+    components TEXT,
+    started_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_started_at ON incidents (started_at);
@@ -0,0 +1,2 @@
+-- Region that accepts writes to an account; empty means any region
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS home_region TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE transactions DROP COLUMN IF EXISTS dest_currency;
+ALTER TABLE transactions DROP COLUMN IF EXISTS dest_amount;
+DROP TABLE IF EXISTS fx_rates;
@@ -0,0 +1,16 @@
+-- Exchange rates and cross-currency transfers
+CREATE TABLE IF NOT EXISTS fx_rates (
+    base CHAR(3) NOT NULL,
+    quote CHAR(3) NOT NULL,
+    value TEXT NOT NULL,
+    as_of TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (base, quote)
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS dest_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS dest_currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS exchange_rate TEXT NOT NULL DEFAULT '1';
+
+-- Every existing transaction was in a single currency
+UPDATE transactions SET dest_amount = amount, dest_currency = currency;
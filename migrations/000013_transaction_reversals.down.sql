@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transactions_reversed_transaction_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reversed_transaction_id;
+-- NOT VALID keeps reversals already posted, so balances still reconcile
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('DEPOSIT', 'WITHDRAWAL', 'TRANSFER')) NOT VALID;
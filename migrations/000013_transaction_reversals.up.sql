@@ -0,0 +1,9 @@
+-- Reversals: compensating transactions that undo an earlier one, at most once
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('DEPOSIT', 'WITHDRAWAL', 'TRANSFER', 'REVERSAL'));
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversed_transaction_id UUID REFERENCES transactions(id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reversed_transaction_id
+    ON transactions (reversed_transaction_id);
@@ -0,0 +1,19 @@
+-- Holds reserve an account's funds until they are captured, released or expire
+CREATE TABLE IF NOT EXISTS holds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    dest_account_id UUID REFERENCES accounts(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'CAPTURED', 'RELEASED', 'EXPIRED')),
+    idempotency_key TEXT NOT NULL UNIQUE,
+    captured_amount BIGINT NOT NULL DEFAULT 0 CHECK (captured_amount >= 0 AND captured_amount <= amount),
+    transaction_id UUID REFERENCES transactions(id),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Available balances sum an account's active holds
+CREATE INDEX IF NOT EXISTS idx_holds_account_active ON holds (account_id) WHERE status = 'ACTIVE';
+CREATE INDEX IF NOT EXISTS idx_holds_expires_at ON holds (expires_at) WHERE status = 'ACTIVE';
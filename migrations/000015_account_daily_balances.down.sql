@@ -0,0 +1 @@
+DROP MATERIALIZED VIEW IF EXISTS account_daily_balances;
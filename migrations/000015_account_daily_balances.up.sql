@@ -0,0 +1,26 @@
+-- Daily credits, debits and closing balance per account (UTC days), read by
+-- the reporting endpoints. Created empty; the view refresher populates it on
+-- startup and refreshes it concurrently after that.
+CREATE MATERIALIZED VIEW IF NOT EXISTS account_daily_balances AS
+SELECT
+    account_id,
+    day,
+    credits,
+    debits,
+    entry_count,
+    SUM(credits - debits) OVER (PARTITION BY account_id ORDER BY day)::BIGINT AS closing_balance
+FROM (
+    SELECT
+        account_id,
+        (created_at AT TIME ZONE 'UTC')::DATE AS day,
+        SUM(CASE WHEN entry_type = 'CREDIT' THEN amount ELSE 0 END)::BIGINT AS credits,
+        SUM(CASE WHEN entry_type = 'DEBIT' THEN amount ELSE 0 END)::BIGINT AS debits,
+        COUNT(*) AS entry_count
+    FROM ledger_entries
+    GROUP BY account_id, (created_at AT TIME ZONE 'UTC')::DATE
+) daily
+WITH NO DATA;
+
+-- REFRESH MATERIALIZED VIEW CONCURRENTLY needs a unique index on plain columns
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_daily_balances_account_day
+    ON account_daily_balances (account_id, day);
@@ -0,0 +1,54 @@
+DROP MATERIALIZED VIEW IF EXISTS account_daily_balances;
+
+ALTER TABLE ledger_entries RENAME TO ledger_entries_partitioned;
+ALTER TABLE ledger_entries_partitioned RENAME CONSTRAINT ledger_entries_pkey TO ledger_entries_partitioned_pkey;
+DROP INDEX IF EXISTS idx_ledger_entries_account_created;
+DROP INDEX IF EXISTS idx_ledger_entries_transaction;
+
+CREATE TABLE ledger_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    entry_type TEXT NOT NULL CHECK (entry_type IN ('DEBIT', 'CREDIT')),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    balance_after BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO ledger_entries SELECT id, transaction_id, account_id, entry_type, amount, balance_after, created_at
+FROM ledger_entries_partitioned;
+
+DROP TABLE ledger_entries_partitioned;
+DROP FUNCTION IF EXISTS create_ledger_entries_partition(DATE);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_created
+    ON ledger_entries (account_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction
+    ON ledger_entries (transaction_id);
+
+CREATE TRIGGER trg_ledger_entries_immutable
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION prevent_ledger_entry_mutation();
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS account_daily_balances AS
+SELECT
+    account_id,
+    day,
+    credits,
+    debits,
+    entry_count,
+    SUM(credits - debits) OVER (PARTITION BY account_id ORDER BY day)::BIGINT AS closing_balance
+FROM (
+    SELECT
+        account_id,
+        (created_at AT TIME ZONE 'UTC')::DATE AS day,
+        SUM(CASE WHEN entry_type = 'CREDIT' THEN amount ELSE 0 END)::BIGINT AS credits,
+        SUM(CASE WHEN entry_type = 'DEBIT' THEN amount ELSE 0 END)::BIGINT AS debits,
+        COUNT(*) AS entry_count
+    FROM ledger_entries
+    GROUP BY account_id, (created_at AT TIME ZONE 'UTC')::DATE
+) daily
+WITH NO DATA;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_daily_balances_account_day
+    ON account_daily_balances (account_id, day);
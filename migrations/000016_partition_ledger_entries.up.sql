@@ -0,0 +1,100 @@
+-- Partition ledger_entries by month of created_at, so each month's entries
+-- and indexes live in their own table and queries bounded by time read only
+-- the months they need. An entry's created_at is its transaction's, which is
+-- how the repository bounds entry lookups by transaction.
+--
+-- transactions stays unpartitioned: idempotency keys must be unique across all
+-- time, and a unique constraint on a partitioned table has to include the
+-- partition key.
+--
+-- This copies every entry; on a large ledger, run it in a maintenance window.
+
+-- The daily balance view reads ledger_entries; it is recreated below
+DROP MATERIALIZED VIEW IF EXISTS account_daily_balances;
+
+ALTER TABLE ledger_entries RENAME TO ledger_entries_unpartitioned;
+ALTER TABLE ledger_entries_unpartitioned RENAME CONSTRAINT ledger_entries_pkey TO ledger_entries_unpartitioned_pkey;
+DROP INDEX IF EXISTS idx_ledger_entries_account_created;
+DROP INDEX IF EXISTS idx_ledger_entries_transaction;
+
+CREATE TABLE ledger_entries (
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    entry_type TEXT NOT NULL CHECK (entry_type IN ('DEBIT', 'CREDIT')),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    balance_after BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- Catches entries for a month whose partition was not created in time. A month
+-- cannot be given its own partition while the default holds rows for it.
+CREATE TABLE ledger_entries_default PARTITION OF ledger_entries DEFAULT;
+
+-- Creates the partition for the UTC month containing day, named
+-- ledger_entries_YYYY_MM, and reports whether it was missing. Run by the
+-- ledger's partition job a few months ahead.
+CREATE OR REPLACE FUNCTION create_ledger_entries_partition(day DATE) RETURNS BOOLEAN AS $$
+DECLARE
+    month_start DATE := date_trunc('month', day::TIMESTAMP)::DATE;
+    partition_name TEXT := 'ledger_entries_' || to_char(month_start, 'YYYY_MM');
+BEGIN
+    IF to_regclass(partition_name) IS NOT NULL THEN
+        RETURN FALSE;
+    END IF;
+    EXECUTE format(
+        'CREATE TABLE %I PARTITION OF ledger_entries FOR VALUES FROM (%L) TO (%L)',
+        partition_name,
+        month_start::TIMESTAMP AT TIME ZONE 'UTC',
+        (month_start + INTERVAL '1 month')::TIMESTAMP AT TIME ZONE 'UTC');
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT create_ledger_entries_partition(month::DATE)
+FROM generate_series(
+    date_trunc('month', COALESCE((SELECT MIN(created_at) FROM transactions), NOW()) AT TIME ZONE 'UTC'),
+    date_trunc('month', NOW() AT TIME ZONE 'UTC') + INTERVAL '3 months',
+    INTERVAL '1 month'
+) AS month;
+
+INSERT INTO ledger_entries (id, transaction_id, account_id, entry_type, amount, balance_after, created_at)
+SELECT le.id, le.transaction_id, le.account_id, le.entry_type, le.amount, le.balance_after, t.created_at
+FROM ledger_entries_unpartitioned le
+JOIN transactions t ON t.id = le.transaction_id;
+
+DROP TABLE ledger_entries_unpartitioned;
+
+-- Indexes and the trigger apply to every partition, including later ones
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_created
+    ON ledger_entries (account_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction
+    ON ledger_entries (transaction_id, created_at);
+
+CREATE TRIGGER trg_ledger_entries_immutable
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION prevent_ledger_entry_mutation();
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS account_daily_balances AS
+SELECT
+    account_id,
+    day,
+    credits,
+    debits,
+    entry_count,
+    SUM(credits - debits) OVER (PARTITION BY account_id ORDER BY day)::BIGINT AS closing_balance
+FROM (
+    SELECT
+        account_id,
+        (created_at AT TIME ZONE 'UTC')::DATE AS day,
+        SUM(CASE WHEN entry_type = 'CREDIT' THEN amount ELSE 0 END)::BIGINT AS credits,
+        SUM(CASE WHEN entry_type = 'DEBIT' THEN amount ELSE 0 END)::BIGINT AS debits,
+        COUNT(*) AS entry_count
+    FROM ledger_entries
+    GROUP BY account_id, (created_at AT TIME ZONE 'UTC')::DATE
+) daily
+WITH NO DATA;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_daily_balances_account_day
+    ON account_daily_balances (account_id, day);
@@ -0,0 +1,50 @@
+-- Moves archived history back into the live tables before dropping the
+-- archive. Entries go to their month's partition, or to the default one.
+DROP MATERIALIZED VIEW IF EXISTS account_daily_balances;
+
+INSERT INTO transactions (id, idempotency_key, transaction_type, amount, currency, dest_amount, dest_currency, exchange_rate, fee, description, reversed_transaction_id, created_at)
+SELECT id, idempotency_key, transaction_type, amount, currency, dest_amount, dest_currency, exchange_rate, fee, description, reversed_transaction_id, created_at
+FROM archived_transactions
+ORDER BY created_at;
+
+INSERT INTO ledger_entries (id, transaction_id, account_id, entry_type, amount, balance_after, created_at)
+SELECT id, transaction_id, account_id, entry_type, amount, balance_after, created_at
+FROM archived_ledger_entries;
+
+DROP TABLE IF EXISTS archived_daily_totals;
+DROP TABLE IF EXISTS archived_ledger_entries;
+DROP TABLE IF EXISTS archived_transactions;
+
+ALTER TABLE holds ADD CONSTRAINT holds_transaction_id_fkey
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id);
+ALTER TABLE transfer_quotes ADD CONSTRAINT transfer_quotes_transaction_id_fkey
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id);
+
+CREATE OR REPLACE FUNCTION prevent_ledger_entry_mutation() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger_entries are immutable: % not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS account_daily_balances AS
+SELECT
+    account_id,
+    day,
+    credits,
+    debits,
+    entry_count,
+    SUM(credits - debits) OVER (PARTITION BY account_id ORDER BY day)::BIGINT AS closing_balance
+FROM (
+    SELECT
+        account_id,
+        (created_at AT TIME ZONE 'UTC')::DATE AS day,
+        SUM(CASE WHEN entry_type = 'CREDIT' THEN amount ELSE 0 END)::BIGINT AS credits,
+        SUM(CASE WHEN entry_type = 'DEBIT' THEN amount ELSE 0 END)::BIGINT AS debits,
+        COUNT(*) AS entry_count
+    FROM ledger_entries
+    GROUP BY account_id, (created_at AT TIME ZONE 'UTC')::DATE
+) daily
+WITH NO DATA;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_daily_balances_account_day
+    ON account_daily_balances (account_id, day);
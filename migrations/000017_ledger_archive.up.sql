@@ -0,0 +1,102 @@
+-- Archive tables for ledger history older than the retention window. The
+-- ledger's archival job moves whole transactions, with their entries, out of
+-- transactions and ledger_entries, and adds their entries to
+-- archived_daily_totals, which stays online so derived balances and the daily
+-- balance report still cover archived history.
+CREATE TABLE IF NOT EXISTS archived_transactions (
+    id UUID PRIMARY KEY,
+    idempotency_key TEXT,
+    transaction_type TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    dest_amount BIGINT NOT NULL DEFAULT 0,
+    dest_currency CHAR(3) NOT NULL,
+    exchange_rate TEXT NOT NULL DEFAULT '1',
+    fee BIGINT NOT NULL DEFAULT 0,
+    description TEXT,
+    reversed_transaction_id UUID,
+    created_at TIMESTAMPTZ NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_transactions_idempotency_key
+    ON archived_transactions (idempotency_key);
+CREATE INDEX IF NOT EXISTS idx_archived_transactions_created_at
+    ON archived_transactions (created_at);
+
+CREATE TABLE IF NOT EXISTS archived_ledger_entries (
+    id UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES archived_transactions(id),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    entry_type TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    balance_after BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_ledger_entries_transaction_id
+    ON archived_ledger_entries (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_archived_ledger_entries_account_created
+    ON archived_ledger_entries (account_id, created_at);
+
+CREATE TABLE IF NOT EXISTS archived_daily_totals (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    day DATE NOT NULL,
+    credits BIGINT NOT NULL DEFAULT 0,
+    debits BIGINT NOT NULL DEFAULT 0,
+    entry_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (account_id, day)
+);
+
+-- Ledger entries stay immutable, except that the archival job may delete
+-- them after copying them, in a transaction that sets ledger.archiving.
+CREATE OR REPLACE FUNCTION prevent_ledger_entry_mutation() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('ledger.archiving', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'ledger_entries are immutable: % not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Holds and quotes are kept after the transaction they produced is archived,
+-- so their transaction_id may name an archived transaction.
+ALTER TABLE holds DROP CONSTRAINT IF EXISTS holds_transaction_id_fkey;
+ALTER TABLE transfer_quotes DROP CONSTRAINT IF EXISTS transfer_quotes_transaction_id_fkey;
+
+-- The daily balance view adds the archived totals to the live entries
+DROP MATERIALIZED VIEW IF EXISTS account_daily_balances;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS account_daily_balances AS
+SELECT
+    account_id,
+    day,
+    credits,
+    debits,
+    entry_count,
+    SUM(credits - debits) OVER (PARTITION BY account_id ORDER BY day)::BIGINT AS closing_balance
+FROM (
+    SELECT
+        account_id,
+        day,
+        SUM(credits)::BIGINT AS credits,
+        SUM(debits)::BIGINT AS debits,
+        SUM(entry_count)::BIGINT AS entry_count
+    FROM (
+        SELECT
+            account_id,
+            (created_at AT TIME ZONE 'UTC')::DATE AS day,
+            CASE WHEN entry_type = 'CREDIT' THEN amount ELSE 0 END AS credits,
+            CASE WHEN entry_type = 'DEBIT' THEN amount ELSE 0 END AS debits,
+            1 AS entry_count
+        FROM ledger_entries
+        UNION ALL
+        SELECT account_id, day, credits, debits, entry_count
+        FROM archived_daily_totals
+    ) entries
+    GROUP BY account_id, day
+) daily
+WITH NO DATA;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_daily_balances_account_day
+    ON account_daily_balances (account_id, day);
@@ -0,0 +1,20 @@
+DROP PUBLICATION IF EXISTS ledger_cdc;
+
+DROP TRIGGER IF EXISTS trg_accounts_change_seq ON accounts;
+DROP TRIGGER IF EXISTS trg_holds_change_seq ON holds;
+DROP FUNCTION IF EXISTS set_change_seq();
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS change_seq;
+ALTER TABLE transactions DROP COLUMN IF EXISTS change_seq;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS change_seq;
+ALTER TABLE holds DROP COLUMN IF EXISTS change_seq;
+DROP SEQUENCE IF EXISTS ledger_change_seq;
+
+DROP TRIGGER IF EXISTS trg_accounts_updated_at ON accounts;
+DROP TRIGGER IF EXISTS trg_holds_updated_at ON holds;
+DROP TRIGGER IF EXISTS trg_operations_updated_at ON operations;
+DROP TRIGGER IF EXISTS trg_incidents_updated_at ON incidents;
+DROP TRIGGER IF EXISTS trg_webhook_endpoints_updated_at ON webhook_endpoints;
+DROP TRIGGER IF EXISTS trg_webhook_deliveries_updated_at ON webhook_deliveries;
+DROP TRIGGER IF EXISTS trg_fx_rates_updated_at ON fx_rates;
+DROP FUNCTION IF EXISTS set_updated_at();
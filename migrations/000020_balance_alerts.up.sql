@@ -0,0 +1,31 @@
+-- Balance alerts notify account holders when a posting crosses a threshold
+CREATE TABLE IF NOT EXISTS balance_alerts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    kind TEXT NOT NULL CHECK (kind IN ('LOW_BALANCE', 'LARGE_DEBIT')),
+    threshold BIGINT NOT NULL CHECK (threshold > 0),
+    notify_email TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_alerts_account_id ON balance_alerts (account_id);
+
+-- Triggers outlive their alert and are not archived with their transaction,
+-- so neither is a foreign key
+CREATE TABLE IF NOT EXISTS balance_alert_triggers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id UUID NOT NULL,
+    account_id UUID NOT NULL,
+    transaction_id UUID NOT NULL,
+    kind TEXT NOT NULL,
+    threshold BIGINT NOT NULL,
+    amount BIGINT NOT NULL DEFAULT 0,
+    balance BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    notified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_alert_triggers_alert_id ON balance_alert_triggers (alert_id);
+CREATE INDEX IF NOT EXISTS idx_balance_alert_triggers_transaction_id ON balance_alert_triggers (transaction_id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS accrual_records;
+DROP TABLE IF EXISTS accrual_plans;
+
+-- NOT VALID keeps accruals already posted, so balances still reconcile
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('DEPOSIT', 'WITHDRAWAL', 'TRANSFER', 'REVERSAL', 'SPLIT_TRANSFER')) NOT VALID;
@@ -0,0 +1,41 @@
+-- Accruals post a plan's daily interest or fee as ACCRUAL transactions
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('DEPOSIT', 'WITHDRAWAL', 'TRANSFER', 'REVERSAL', 'SPLIT_TRANSFER', 'ACCRUAL'));
+
+CREATE TABLE IF NOT EXISTS accrual_plans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    kind TEXT NOT NULL CHECK (kind IN ('INTEREST', 'FEE')),
+    rate_basis_points BIGINT NOT NULL CHECK (rate_basis_points >= 0),
+    starts_on DATE NOT NULL,
+    accrued_through DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_accrual_plans_account_id ON accrual_plans (account_id);
+
+-- Postings are archived with their transactions, so neither transaction ID
+-- is a foreign key
+CREATE TABLE IF NOT EXISTS accrual_records (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    plan_id UUID NOT NULL REFERENCES accrual_plans(id),
+    account_id UUID NOT NULL,
+    day DATE NOT NULL,
+    revision INTEGER NOT NULL CHECK (revision > 0),
+    kind TEXT NOT NULL,
+    closing_balance BIGINT NOT NULL,
+    basis BIGINT NOT NULL,
+    rate_basis_points BIGINT NOT NULL,
+    days_in_year BIGINT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    currency CHAR(3) NOT NULL,
+    transaction_id UUID,
+    reversal_transaction_id UUID,
+    superseded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accrual_records_plan_day_revision ON accrual_records (plan_id, day, revision);
+CREATE INDEX IF NOT EXISTS idx_accrual_records_account_id ON accrual_records (account_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS daily_seals;
+DROP FUNCTION IF EXISTS prevent_daily_seal_mutation();
@@ -0,0 +1,20 @@
+-- Each UTC day of ledger entries is sealed once it has ended, with a SHA-256
+-- digest chained onto the day before's
+CREATE TABLE IF NOT EXISTS daily_seals (
+    day DATE PRIMARY KEY,
+    entry_count BIGINT NOT NULL CHECK (entry_count >= 0),
+    previous_digest TEXT NOT NULL DEFAULT '',
+    digest TEXT NOT NULL,
+    sealed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Immutability trigger: seals are only ever added
+CREATE OR REPLACE FUNCTION prevent_daily_seal_mutation() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'daily_seals are immutable: % not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_daily_seals_immutable
+    BEFORE UPDATE OR DELETE ON daily_seals
+    FOR EACH ROW EXECUTE FUNCTION prevent_daily_seal_mutation();
@@ -0,0 +1,12 @@
+CREATE OR REPLACE FUNCTION prevent_ledger_entry_mutation() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('ledger.archiving', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'ledger_entries are immutable: % not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS last_entry_hash;
+ALTER TABLE archived_ledger_entries DROP COLUMN IF EXISTS entry_hash, DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS entry_hash, DROP COLUMN IF EXISTS prev_hash;
@@ -0,0 +1,32 @@
+-- Each account's entries form a SHA-256 hash chain: an entry's entry_hash
+-- covers its contents and prev_hash, the entry_hash of the account's entry
+-- before it, and the account keeps the hash of its latest entry. Entries
+-- posted before this migration have empty hashes until the account is
+-- rechained.
+ALTER TABLE ledger_entries
+    ADD COLUMN IF NOT EXISTS prev_hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS entry_hash TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE archived_ledger_entries
+    ADD COLUMN IF NOT EXISTS prev_hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS entry_hash TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS last_entry_hash TEXT NOT NULL DEFAULT '';
+
+-- Ledger entries stay immutable, except that the archival job may delete
+-- them and a rechain may rewrite their hashes, in transactions that set
+-- ledger.archiving and ledger.rechaining respectively.
+CREATE OR REPLACE FUNCTION prevent_ledger_entry_mutation() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('ledger.archiving', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    IF TG_OP = 'UPDATE' AND current_setting('ledger.rechaining', true) = 'on'
+        AND (NEW.id, NEW.transaction_id, NEW.account_id, NEW.entry_type, NEW.amount, NEW.balance_after, NEW.created_at)
+            IS NOT DISTINCT FROM
+            (OLD.id, OLD.transaction_id, OLD.account_id, OLD.entry_type, OLD.amount, OLD.balance_after, OLD.created_at) THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'ledger_entries are immutable: % not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_endpoint_order;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS occurred_at;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS ordered;
@@ -0,0 +1,9 @@
+-- Ordered endpoints receive their deliveries one at a time, in the order
+-- their events occurred.
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS ordered BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS occurred_at TIMESTAMPTZ;
+UPDATE webhook_deliveries SET occurred_at = created_at WHERE occurred_at IS NULL;
+ALTER TABLE webhook_deliveries ALTER COLUMN occurred_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint_order ON webhook_deliveries (endpoint_id, occurred_at);
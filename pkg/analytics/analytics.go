@@ -0,0 +1,200 @@
+// Package analytics ingests product analytics events sent by clients. Each
+// event is checked against the schema registered for its name, held in a
+// Buffer and written to a Sink (a message broker topic, ClickHouse or files)
+// in batches, so the request that sent it never waits for the sink.
+//
+// Delivery is best effort: a batch the sink still refuses after its retries
+// is dropped and counted, and events still buffered when Stop gives up are
+// lost. Sinks downstream deduplicate by event ID.
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrBufferFull is returned by Buffer.Add when the sink has fallen so far
+	// behind that the buffer cannot take the events.
+	ErrBufferFull = errors.New("analytics: buffer full")
+	// ErrStopped is returned by Buffer.Add after Stop.
+	ErrStopped = errors.New("analytics: buffer stopped")
+)
+
+// Event is one analytics event. ID, chosen by the client, lets sinks drop
+// the copies a retried request sends again.
+type Event struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	AnonymousID string         `json:"anonymous_id,omitempty"`
+	UserID      string         `json:"user_id,omitempty"`
+	Properties  map[string]any `json:"properties,omitempty"`
+	// Timestamp is when the client says the event happened.
+	Timestamp  time.Time `json:"timestamp"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Sink stores batches of events. Write either stores the whole batch or
+// returns an error, after which the batch is written again.
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Property types a schema can require.
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeObject  = "object"
+	TypeArray   = "array"
+)
+
+var propertyTypes = []string{TypeString, TypeNumber, TypeInteger, TypeBoolean, TypeObject, TypeArray}
+
+var eventNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.]{0,99}$`)
+
+// Property describes one event property.
+type Property struct {
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// Schema lists the properties an event may carry. Properties it does not
+// list are rejected, so a misspelt property fails loudly at the client
+// instead of quietly splitting a dashboard.
+type Schema struct {
+	Name       string              `json:"name"`
+	Properties map[string]Property `json:"properties"`
+}
+
+// Registry holds the schema of every event name that is accepted.
+type Registry struct {
+	schemas map[string]Schema
+}
+
+// NewRegistry checks schemas and returns a registry of them.
+func NewRegistry(schemas ...Schema) (*Registry, error) {
+	r := &Registry{schemas: make(map[string]Schema, len(schemas))}
+	for _, schema := range schemas {
+		if !eventNamePattern.MatchString(schema.Name) {
+			return nil, fmt.Errorf("analytics: invalid event name %q; use lower case letters, digits, _ and .", schema.Name)
+		}
+		if _, ok := r.schemas[schema.Name]; ok {
+			return nil, fmt.Errorf("analytics: event %s is registered twice", schema.Name)
+		}
+		for name, property := range schema.Properties {
+			if !slices.Contains(propertyTypes, property.Type) {
+				return nil, fmt.Errorf("analytics: property %s of event %s has unknown type %q", name, schema.Name, property.Type)
+			}
+		}
+		r.schemas[schema.Name] = schema
+	}
+	return r, nil
+}
+
+// LoadRegistry reads a JSON array of schemas from path.
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("analytics: read schemas: %w", err)
+	}
+	var schemas []Schema
+	if err := json.Unmarshal(data, &schemas); err != nil {
+		return nil, fmt.Errorf("analytics: parse schemas in %s: %w", path, err)
+	}
+	return NewRegistry(schemas...)
+}
+
+// Names returns the registered event names in order.
+func (r *Registry) Names() []string {
+	return slices.Sorted(maps.Keys(r.schemas))
+}
+
+// Validate checks event against the schema registered for its name and
+// returns every problem it finds, or nil when there are none.
+func (r *Registry) Validate(event Event) error {
+	schema, ok := r.schemas[event.Name]
+	if !ok {
+		return fmt.Errorf("event %q is not registered", event.Name)
+	}
+
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(schema.Properties)) {
+		if property := schema.Properties[name]; property.Required {
+			if v, ok := event.Properties[name]; !ok || v == nil {
+				problems = append(problems, fmt.Sprintf("property %s is required", name))
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(event.Properties)) {
+		property, ok := schema.Properties[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("property %s is not in the schema", name))
+			continue
+		}
+		if v := event.Properties[name]; v != nil && !hasType(v, property.Type) {
+			problems = append(problems, fmt.Sprintf("property %s must be of type %s", name, property.Type))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// hasType reports whether a value decoded from JSON has the given type.
+// Numbers may be float64 or, when decoded with UseNumber, json.Number.
+func hasType(v any, typ string) bool {
+	switch typ {
+	case TypeString:
+		_, ok := v.(string)
+		return ok
+	case TypeNumber:
+		switch n := v.(type) {
+		case float64:
+			return true
+		case json.Number:
+			_, err := n.Float64()
+			return err == nil
+		}
+		return false
+	case TypeInteger:
+		switch n := v.(type) {
+		case float64:
+			return n == math.Trunc(n)
+		case json.Number:
+			if _, err := n.Int64(); err == nil {
+				return true
+			}
+			f, err := n.Float64()
+			return err == nil && f == math.Trunc(f)
+		}
+		return false
+	case TypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	case TypeObject:
+		_, ok := v.(map[string]any)
+		return ok
+	case TypeArray:
+		_, ok := v.([]any)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testRegistry(t *testing.T) *Registry {
+	t.Helper()
+	registry, err := NewRegistry(Schema{
+		Name: "checkout.completed",
+		Properties: map[string]Property{
+			"plan":  {Type: TypeString, Required: true},
+			"seats": {Type: TypeInteger},
+			"price": {Type: TypeNumber},
+			"trial": {Type: TypeBoolean},
+			"items": {Type: TypeArray},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	return registry
+}
+
+func TestRegistryValidate(t *testing.T) {
+	registry := testRegistry(t)
+
+	valid := Event{Name: "checkout.completed", Properties: map[string]any{
+		"plan": "team", "seats": float64(5), "price": 49.5, "trial": false, "items": []any{"a"},
+	}}
+	if err := registry.Validate(valid); err != nil {
+		t.Fatalf("Validate(valid) = %v", err)
+	}
+	exact := Event{Name: "checkout.completed", Properties: map[string]any{
+		"plan": "team", "seats": json.Number("9007199254740993"), "price": json.Number("49.5"),
+	}}
+	if err := registry.Validate(exact); err != nil {
+		t.Fatalf("Validate(json.Number) = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		event Event
+		want  []string
+	}{
+		{"unknown event", Event{Name: "checkout.started"}, []string{`event "checkout.started" is not registered`}},
+		{"missing required", Event{Name: "checkout.completed", Properties: map[string]any{"seats": float64(1)}}, []string{"property plan is required"}},
+		{"null required", Event{Name: "checkout.completed", Properties: map[string]any{"plan": nil}}, []string{"property plan is required"}},
+		{"unknown property", Event{Name: "checkout.completed", Properties: map[string]any{"plan": "team", "plna": "x"}}, []string{"property plna is not in the schema"}},
+		{"wrong types", Event{Name: "checkout.completed", Properties: map[string]any{"plan": "team", "seats": 1.5, "trial": "yes"}}, []string{
+			"property seats must be of type integer", "property trial must be of type boolean",
+		}},
+		{"fractional json.Number", Event{Name: "checkout.completed", Properties: map[string]any{"plan": "team", "seats": json.Number("1.5")}}, []string{
+			"property seats must be of type integer",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.Validate(tt.event)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewRegistryRejectsBadSchemas(t *testing.T) {
+	tests := map[string][]Schema{
+		"bad name":     {{Name: "Checkout Completed"}},
+		"duplicate":    {{Name: "signup"}, {Name: "signup"}},
+		"unknown type": {{Name: "signup", Properties: map[string]Property{"age": {Type: "int"}}}},
+	}
+	for name, schemas := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewRegistry(schemas...); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/retry"
+)
+
+type BufferConfig struct {
+	// Capacity is how many events the buffer holds before Add refuses more.
+	Capacity int
+	// BatchSize caps the events in one sink write. A full batch is written
+	// straight away instead of at the next flush.
+	BatchSize     int
+	FlushInterval time.Duration
+	// MaxAttempts is how many writes a batch gets before it is dropped.
+	MaxAttempts int
+	Backoff     retry.Backoff
+}
+
+func DefaultBufferConfig() BufferConfig {
+	return BufferConfig{
+		Capacity:      10000,
+		BatchSize:     500,
+		FlushInterval: 5 * time.Second,
+		MaxAttempts:   5,
+		Backoff:       retry.Backoff{Initial: 500 * time.Millisecond, Max: 30 * time.Second, Multiplier: 2, Jitter: 0.2},
+	}
+}
+
+// Buffer holds accepted events and writes them to its sink in batches, every
+// flush interval and whenever a batch fills, until Stop.
+type Buffer struct {
+	sink    Sink
+	logger  Logger
+	cfg     BufferConfig
+	metrics *metrics
+
+	mu      sync.Mutex
+	pending []Event
+	started bool
+	stopped bool
+
+	full chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+
+	// ctx is cancelled when Stop gives up waiting for the last flush.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewBuffer(sink Sink, logger Logger, cfg BufferConfig) *Buffer {
+	defaults := DefaultBufferConfig()
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = defaults.Capacity
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	cfg.BatchSize = min(cfg.BatchSize, cfg.Capacity)
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaults.FlushInterval
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaults.MaxAttempts
+	}
+	if cfg.Backoff == (retry.Backoff{}) {
+		cfg.Backoff = defaults.Backoff
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Buffer{
+		sink:    sink,
+		logger:  logger,
+		cfg:     cfg,
+		metrics: newMetrics(),
+		full:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Add queues events for the sink. It takes all of them or, with
+// ErrBufferFull or ErrStopped, none.
+func (b *Buffer) Add(events ...Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return ErrStopped
+	}
+	if len(b.pending)+len(events) > b.cfg.Capacity {
+		b.metrics.dropped.WithLabelValues(dropReasonFull).Add(float64(len(events)))
+		return ErrBufferFull
+	}
+	b.pending = append(b.pending, events...)
+	if len(b.pending) >= b.cfg.BatchSize {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Len returns how many events are waiting for the sink.
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// Start writes buffered events to the sink until Stop.
+func (b *Buffer) Start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.started || b.stopped {
+		return
+	}
+	b.started = true
+	go func() {
+		defer close(b.done)
+		ticker := time.NewTicker(b.cfg.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.Flush(b.ctx)
+			case <-b.full:
+				b.flushFull(b.ctx)
+			case <-b.stop:
+				b.Flush(b.ctx)
+				return
+			}
+		}
+	}()
+}
+
+// Stop refuses new events and writes the buffered ones until ctx ends. The
+// events not written by then are dropped.
+func (b *Buffer) Stop(ctx context.Context) {
+	b.mu.Lock()
+	started := b.started
+	b.stopped = true
+	b.mu.Unlock()
+
+	b.once.Do(func() { close(b.stop) })
+	defer b.cancel()
+	if started {
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+			b.cancel()
+			<-b.done
+		}
+	}
+
+	if lost := b.take(b.cfg.Capacity); len(lost) > 0 {
+		b.metrics.dropped.WithLabelValues(dropReasonShutdown).Add(float64(len(lost)))
+		b.logger.Warn("Dropped analytics events at shutdown", "count", len(lost))
+	}
+}
+
+// Flush writes every buffered event to the sink, a batch at a time.
+func (b *Buffer) Flush(ctx context.Context) {
+	for ctx.Err() == nil {
+		batch := b.take(b.cfg.BatchSize)
+		if len(batch) == 0 {
+			return
+		}
+		b.write(ctx, batch)
+	}
+}
+
+// flushFull writes full batches only, leaving a partial one for the next
+// flush so a busy buffer still writes batches of BatchSize.
+func (b *Buffer) flushFull(ctx context.Context) {
+	for ctx.Err() == nil && b.Len() >= b.cfg.BatchSize {
+		b.write(ctx, b.take(b.cfg.BatchSize))
+	}
+}
+
+func (b *Buffer) take(n int) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n = min(n, len(b.pending))
+	if n == 0 {
+		return nil
+	}
+	batch := make([]Event, n)
+	copy(batch, b.pending)
+	b.pending = append(b.pending[:0], b.pending[n:]...)
+	return batch
+}
+
+func (b *Buffer) write(ctx context.Context, batch []Event) {
+	start := time.Now()
+	err := retry.Do(ctx, b.cfg.Backoff, b.cfg.MaxAttempts, func(ctx context.Context) error {
+		return b.sink.Write(ctx, batch)
+	})
+	b.metrics.writeDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		if ctx.Err() != nil {
+			b.metrics.dropped.WithLabelValues(dropReasonShutdown).Add(float64(len(batch)))
+			b.logger.Warn("Dropped analytics events at shutdown", "count", len(batch))
+			return
+		}
+		b.metrics.dropped.WithLabelValues(dropReasonSink).Add(float64(len(batch)))
+		b.logger.Error("Dropped analytics events the sink refused", "count", len(batch), "error", err)
+		return
+	}
+	b.metrics.written.Add(float64(len(batch)))
+}
@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/log"
+	"github.com/akeren/go-api-foundry/pkg/retry"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]Event
+	fail    int
+	written chan struct{}
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{written: make(chan struct{}, 100)}
+}
+
+func (s *recordingSink) Write(_ context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail > 0 {
+		s.fail--
+		return errors.New("sink unavailable")
+	}
+	s.batches = append(s.batches, events)
+	s.written <- struct{}{}
+	return nil
+}
+
+func (s *recordingSink) sizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := make([]int, len(s.batches))
+	for i, batch := range s.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func testBufferConfig() BufferConfig {
+	return BufferConfig{
+		Capacity:      10,
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+		MaxAttempts:   3,
+		Backoff:       retry.Backoff{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1},
+	}
+}
+
+func events(n int) []Event {
+	out := make([]Event, n)
+	for i := range out {
+		out[i] = Event{ID: string(rune('a' + i)), Name: "signup"}
+	}
+	return out
+}
+
+func TestBuffer_WritesFullBatchesStraightAway(t *testing.T) {
+	sink := newRecordingSink()
+	buffer := NewBuffer(sink, log.NewLoggerWithJSONOutput(), testBufferConfig())
+	buffer.Start()
+	defer buffer.Stop(context.Background())
+
+	if err := buffer.Add(events(4)...); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	select {
+	case <-sink.written:
+	case <-time.After(time.Second):
+		t.Fatal("expected a full batch to be written before the flush interval")
+	}
+	if got := sink.sizes(); len(got) != 1 || got[0] != 3 {
+		t.Fatalf("batches = %v, want one of 3", got)
+	}
+	if got := buffer.Len(); got != 1 {
+		t.Fatalf("Len = %d, want the partial batch of 1 left", got)
+	}
+}
+
+func TestBuffer_StopFlushesAndRefusesEvents(t *testing.T) {
+	sink := newRecordingSink()
+	sink.fail = 2
+	buffer := NewBuffer(sink, log.NewLoggerWithJSONOutput(), testBufferConfig())
+	buffer.Start()
+
+	if err := buffer.Add(events(2)...); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	buffer.Stop(context.Background())
+
+	if got := sink.sizes(); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("batches = %v, want the 2 buffered events after retries", got)
+	}
+	if err := buffer.Add(events(1)...); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Add after Stop = %v, want ErrStopped", err)
+	}
+}
+
+func TestBuffer_RefusesEventsBeyondCapacity(t *testing.T) {
+	buffer := NewBuffer(newRecordingSink(), log.NewLoggerWithJSONOutput(), testBufferConfig())
+	defer buffer.Stop(context.Background())
+
+	if err := buffer.Add(events(8)...); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := buffer.Add(events(3)...); !errors.Is(err, ErrBufferFull) {
+		t.Fatalf("Add beyond capacity = %v, want ErrBufferFull", err)
+	}
+	if got := buffer.Len(); got != 8 {
+		t.Fatalf("Len = %d, want 8; a refused request adds nothing", got)
+	}
+}
+
+func TestBuffer_DropsBatchTheSinkKeepsRefusing(t *testing.T) {
+	sink := newRecordingSink()
+	sink.fail = 100
+	buffer := NewBuffer(sink, log.NewLoggerWithJSONOutput(), testBufferConfig())
+	defer buffer.Stop(context.Background())
+
+	if err := buffer.Add(events(2)...); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	buffer.Flush(context.Background())
+
+	if got := buffer.Len(); got != 0 {
+		t.Fatalf("Len = %d, want the refused batch dropped", got)
+	}
+	if got := sink.fail; got != 100-3 {
+		t.Fatalf("sink saw %d writes, want MaxAttempts", 100-got)
+	}
+}
@@ -0,0 +1,57 @@
+package analytics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Reasons for dropping events, as the reason label of
+// analytics_events_dropped_total.
+const (
+	dropReasonFull     = "buffer_full"
+	dropReasonSink     = "sink_error"
+	dropReasonShutdown = "shutdown"
+)
+
+var bufferedDesc = prometheus.NewDesc(
+	"analytics_events_buffered",
+	"Analytics events waiting to be written to the sink.",
+	nil, nil,
+)
+
+type metrics struct {
+	written       prometheus.Counter
+	dropped       *prometheus.CounterVec
+	writeDuration prometheus.Histogram
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		written: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "analytics_events_written_total",
+			Help: "Analytics events written to the sink.",
+		}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "analytics_events_dropped_total",
+			Help: "Analytics events lost, by reason.",
+		}, []string{"reason"}),
+		writeDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "analytics_sink_write_seconds",
+			Help:    "Time to write a batch to the sink, retries included.",
+			Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (b *Buffer) Describe(ch chan<- *prometheus.Desc) {
+	ch <- bufferedDesc
+	b.metrics.written.Describe(ch)
+	b.metrics.dropped.Describe(ch)
+	b.metrics.writeDuration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (b *Buffer) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(bufferedDesc, prometheus.GaugeValue, float64(b.Len()))
+	b.metrics.written.Collect(ch)
+	b.metrics.dropped.Collect(ch)
+	b.metrics.writeDuration.Collect(ch)
+}
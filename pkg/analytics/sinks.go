@@ -0,0 +1,195 @@
+package analytics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/messaging"
+	"github.com/akeren/go-api-foundry/pkg/region"
+)
+
+// HeaderEventName is set on each message BrokerSink publishes.
+const HeaderEventName = "analytics-event"
+
+// BrokerSink publishes each event to topic on a message broker as JSON,
+// keyed by event ID.
+type BrokerSink struct {
+	publisher messaging.Publisher
+	topic     string
+}
+
+func NewBrokerSink(publisher messaging.Publisher, topic string) *BrokerSink {
+	return &BrokerSink{publisher: publisher, topic: topic}
+}
+
+func (s *BrokerSink) Write(ctx context.Context, events []Event) error {
+	msgs := make([]messaging.Message, len(events))
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("analytics: encode event %s: %w", event.ID, err)
+		}
+		msgs[i] = messaging.Message{
+			Topic:   s.topic,
+			Key:     []byte(event.ID),
+			Value:   value,
+			Headers: map[string]string{HeaderEventName: event.Name},
+			Time:    event.ReceivedAt,
+		}
+	}
+	return s.publisher.Publish(ctx, msgs...)
+}
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+type ClickHouseConfig struct {
+	// URL is the ClickHouse HTTP interface, e.g. http://clickhouse:8123.
+	URL      string
+	Table    string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// ClickHouseSink inserts events into a ClickHouse table over the HTTP
+// interface, one INSERT per batch. Properties are stored as a JSON string.
+type ClickHouseSink struct {
+	endpoint string
+	cfg      ClickHouseConfig
+	client   *http.Client
+}
+
+// clickHouseRow is an event as a row of the table.
+type clickHouseRow struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	AnonymousID string    `json:"anonymous_id"`
+	UserID      string    `json:"user_id"`
+	Properties  string    `json:"properties"`
+	Timestamp   time.Time `json:"timestamp"`
+	ReceivedAt  time.Time `json:"received_at"`
+}
+
+func NewClickHouseSink(cfg ClickHouseConfig) (*ClickHouseSink, error) {
+	if !tableNamePattern.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("analytics: invalid ClickHouse table %q", cfg.Table)
+	}
+	base, err := url.Parse(cfg.URL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("analytics: invalid ClickHouse URL %q", cfg.URL)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	query := base.Query()
+	query.Set("query", "INSERT INTO "+cfg.Table+" FORMAT JSONEachRow")
+	query.Set("date_time_input_format", "best_effort")
+	base.RawQuery = query.Encode()
+	return &ClickHouseSink{endpoint: base.String(), cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}, nil
+}
+
+func (s *ClickHouseSink) Write(ctx context.Context, events []Event) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, event := range events {
+		properties, err := json.Marshal(event.Properties)
+		if err != nil {
+			return fmt.Errorf("analytics: encode event %s: %w", event.ID, err)
+		}
+		row := clickHouseRow{
+			ID:          event.ID,
+			Name:        event.Name,
+			AnonymousID: event.AnonymousID,
+			UserID:      event.UserID,
+			Properties:  string(properties),
+			Timestamp:   event.Timestamp,
+			ReceivedAt:  event.ReceivedAt,
+		}
+		if err := enc.Encode(row); err != nil {
+			return fmt.Errorf("analytics: encode event %s: %w", event.ID, err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.cfg.Username != "" {
+		req.Header.Set("X-ClickHouse-User", s.cfg.Username)
+		req.Header.Set("X-ClickHouse-Key", s.cfg.Password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("analytics: ClickHouse insert: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("analytics: ClickHouse insert: %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// FileSink writes each batch as a gzipped JSON-lines file under
+// dir/dt=YYYY-MM-DD/, the layout Athena, BigQuery and Spark read as a
+// date-partitioned table. Point it at a bucket mounted into the container
+// (s3fs, gcsfuse) or a directory a sidecar uploads. Files appear complete:
+// each is written under a temporary name and renamed.
+type FileSink struct {
+	dir string
+	now func() time.Time
+}
+
+func NewFileSink(dir string) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("analytics: create directory: %w", err)
+	}
+	return &FileSink{dir: dir, now: time.Now}, nil
+}
+
+func (s *FileSink) Write(_ context.Context, events []Event) error {
+	now := s.now().UTC()
+	partition := filepath.Join(s.dir, "dt="+now.Format(time.DateOnly))
+	if err := os.MkdirAll(partition, 0o750); err != nil {
+		return fmt.Errorf("analytics: create partition: %w", err)
+	}
+
+	name := now.Format("150405") + "-" + region.NewID() + ".json.gz"
+	tmp, err := os.CreateTemp(partition, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("analytics: create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	enc := json.NewEncoder(gz)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			tmp.Close()
+			return fmt.Errorf("analytics: encode event %s: %w", event.ID, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("analytics: write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("analytics: write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(partition, name)); err != nil {
+		return fmt.Errorf("analytics: write file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package analytics
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/messaging"
+)
+
+var sinkEvents = []Event{
+	{ID: "e1", Name: "signup", AnonymousID: "anon-1", Properties: map[string]any{"plan": "team"}, Timestamp: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)},
+	{ID: "e2", Name: "login", UserID: "user-1", Timestamp: time.Date(2026, 3, 1, 9, 5, 0, 0, time.UTC)},
+}
+
+func TestBrokerSink(t *testing.T) {
+	broker := messaging.NewMemoryBroker()
+	defer broker.Close()
+
+	if err := NewBrokerSink(broker, "analytics").Write(context.Background(), sinkEvents); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	msgs := broker.Messages("analytics")
+	if len(msgs) != 2 || string(msgs[0].Key) != "e1" || msgs[1].Headers[HeaderEventName] != "login" {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+	var decoded Event
+	if err := json.Unmarshal(msgs[0].Value, &decoded); err != nil || decoded.Properties["plan"] != "team" {
+		t.Fatalf("unexpected message value %s: %v", msgs[0].Value, err)
+	}
+}
+
+func TestClickHouseSink(t *testing.T) {
+	var query, user string
+	var rows []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		user = r.Header.Get("X-ClickHouse-User")
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				t.Errorf("row is not JSON: %s", scanner.Bytes())
+			}
+			rows = append(rows, row)
+		}
+	}))
+	defer server.Close()
+
+	sink, err := NewClickHouseSink(ClickHouseConfig{URL: server.URL, Table: "analytics.events", Username: "ingest"})
+	if err != nil {
+		t.Fatalf("NewClickHouseSink: %v", err)
+	}
+	if err := sink.Write(context.Background(), sinkEvents); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if query != "INSERT INTO analytics.events FORMAT JSONEachRow" || user != "ingest" {
+		t.Fatalf("query = %q, user = %q", query, user)
+	}
+	if len(rows) != 2 || rows[0]["properties"] != `{"plan":"team"}` || rows[1]["user_id"] != "user-1" {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+
+	if _, err := NewClickHouseSink(ClickHouseConfig{URL: server.URL, Table: "events; DROP TABLE x"}); err == nil {
+		t.Fatal("expected an invalid table name to be rejected")
+	}
+}
+
+func TestClickHouseSink_ReportsServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Code: 60. DB::Exception: Table analytics.events does not exist", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	sink, err := NewClickHouseSink(ClickHouseConfig{URL: server.URL, Table: "analytics.events"})
+	if err != nil {
+		t.Fatalf("NewClickHouseSink: %v", err)
+	}
+	if err := sink.Write(context.Background(), sinkEvents); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("Write = %v, want the server's error", err)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	sink.now = func() time.Time { return time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC) }
+	if err := sink.Write(context.Background(), sinkEvents); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "dt=2026-03-01", "*"))
+	if err != nil || len(files) != 1 || !strings.HasSuffix(files[0], ".json.gz") {
+		t.Fatalf("files = %v, %v; want one gzipped file in the day's partition", files, err)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 || !strings.Contains(lines[1], `"id":"e2"`) {
+		t.Fatalf("unexpected contents %s", data)
+	}
+}
@@ -0,0 +1,219 @@
+package anomaly
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+}
+
+// DenyList is where the scorer places clients it decides to ban.
+type DenyList interface {
+	Deny(ip string, ttl time.Duration, reason string) error
+}
+
+type Config struct {
+	// Window is the aggregation period. Scores reset and a summary is logged
+	// at the end of every window.
+	Window time.Duration
+	// SuspiciousScore is the score at which a client is reported individually
+	// in the window summary.
+	SuspiciousScore int
+	// AutoBan places clients that reach BanScore on the deny list for BanTTL.
+	AutoBan  bool
+	BanScore int
+	BanTTL   time.Duration
+	// MaxTrackedClients bounds memory under distributed scans. Hits from
+	// additional clients are only counted in the totals.
+	MaxTrackedClients int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Window:            time.Minute,
+		SuspiciousScore:   20,
+		AutoBan:           false,
+		BanScore:          100,
+		BanTTL:            15 * time.Minute,
+		MaxTrackedClients: 10000,
+	}
+}
+
+const (
+	weightUnknownPath = 1
+	weightProbePath   = 5
+	maxSamplePaths    = 5
+)
+
+// probeMarkers are path fragments that only show up in vulnerability scans
+// against an API like this one. Hits on them weigh more than a plain typo.
+var probeMarkers = []string{
+	".php", ".asp", ".env", ".git", "wp-", "cgi-bin", "phpmyadmin", "/etc/passwd", "..", "/actuator", "/.aws",
+}
+
+type clientScore struct {
+	score  int
+	hits   int
+	paths  []string
+	banned bool
+}
+
+// Scorer aggregates unknown-route hits per client IP. A single 404 is normal
+// traffic and only counts towards the window summary; repeated or probe-like
+// hits raise the score until the client is reported and, optionally, banned.
+type Scorer struct {
+	cfg    Config
+	logger Logger
+	deny   DenyList
+	now    func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	clients     map[string]*clientScore
+	totalHits   int
+	untracked   int
+}
+
+func NewScorer(cfg Config, logger Logger, deny DenyList) *Scorer {
+	defaults := DefaultConfig()
+	if cfg.Window <= 0 {
+		cfg.Window = defaults.Window
+	}
+	if cfg.SuspiciousScore <= 0 {
+		cfg.SuspiciousScore = defaults.SuspiciousScore
+	}
+	if cfg.BanScore <= 0 {
+		cfg.BanScore = defaults.BanScore
+	}
+	if cfg.BanTTL <= 0 {
+		cfg.BanTTL = defaults.BanTTL
+	}
+	if cfg.MaxTrackedClients <= 0 {
+		cfg.MaxTrackedClients = defaults.MaxTrackedClients
+	}
+
+	return &Scorer{
+		cfg:         cfg,
+		logger:      logger,
+		deny:        deny,
+		now:         time.Now,
+		windowStart: time.Now(),
+		clients:     make(map[string]*clientScore),
+	}
+}
+
+// Weight returns the score contribution of a hit on path.
+func Weight(path string) int {
+	lower := strings.ToLower(path)
+	for _, marker := range probeMarkers {
+		if strings.Contains(lower, marker) {
+			return weightProbePath
+		}
+	}
+	return weightUnknownPath
+}
+
+// Record registers a request that did not match any route (404) or used an
+// unsupported method (405). It returns the client's score in the current window.
+func (s *Scorer) Record(ip, method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Summaries are flushed opportunistically instead of from a background
+	// goroutine; an idle scorer has nothing to report anyway.
+	if now := s.now(); now.Sub(s.windowStart) >= s.cfg.Window {
+		s.flushLocked(now)
+	}
+
+	s.totalHits++
+
+	c, ok := s.clients[ip]
+	if !ok {
+		if len(s.clients) >= s.cfg.MaxTrackedClients {
+			s.untracked++
+			return 0
+		}
+		c = &clientScore{}
+		s.clients[ip] = c
+	}
+
+	c.hits++
+	c.score += Weight(path)
+	if len(c.paths) < maxSamplePaths {
+		c.paths = append(c.paths, method+" "+path)
+	}
+
+	if s.cfg.AutoBan && !c.banned && c.score >= s.cfg.BanScore && s.deny != nil {
+		c.banned = true
+		if err := s.deny.Deny(ip, s.cfg.BanTTL, "unknown route anomaly score exceeded"); err != nil {
+			s.logger.Warn("Failed to ban client after anomaly score threshold", "client_ip", ip, "error", err)
+		} else {
+			s.logger.Warn("Client banned after anomaly score threshold",
+				"client_ip", ip,
+				"score", c.score,
+				"hits", c.hits,
+				"ban_ttl", s.cfg.BanTTL.String(),
+				"sample_paths", c.paths,
+			)
+		}
+	}
+
+	return c.score
+}
+
+// IsSuspicious reports whether a score returned by Record crosses the
+// suspicious threshold.
+func (s *Scorer) IsSuspicious(score int) bool {
+	return score >= s.cfg.SuspiciousScore
+}
+
+// Flush logs the summary for the current window and starts a new one.
+func (s *Scorer) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked(s.now())
+}
+
+func (s *Scorer) flushLocked(now time.Time) {
+	if s.totalHits > 0 {
+		type offender struct {
+			ip string
+			*clientScore
+		}
+
+		var suspicious []offender
+		for ip, c := range s.clients {
+			if c.score >= s.cfg.SuspiciousScore {
+				suspicious = append(suspicious, offender{ip: ip, clientScore: c})
+			}
+		}
+		sort.Slice(suspicious, func(i, j int) bool { return suspicious[i].score > suspicious[j].score })
+
+		s.logger.Info("Unknown route activity summary",
+			"window", s.cfg.Window.String(),
+			"hits", s.totalHits,
+			"clients", len(s.clients)+s.untracked,
+			"suspicious_clients", len(suspicious),
+		)
+
+		for _, o := range suspicious {
+			s.logger.Warn("Suspicious unknown route activity",
+				"client_ip", o.ip,
+				"score", o.score,
+				"hits", o.hits,
+				"banned", o.banned,
+				"sample_paths", o.paths,
+			)
+		}
+	}
+
+	s.windowStart = now
+	s.clients = make(map[string]*clientScore)
+	s.totalHits = 0
+	s.untracked = 0
+}
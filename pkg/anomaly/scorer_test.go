@@ -0,0 +1,135 @@
+package anomaly
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	mu    sync.Mutex
+	infos []string
+	warns []string
+}
+
+func (l *recordingLogger) Info(msg string, _ ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.infos = append(l.infos, msg)
+}
+
+func (l *recordingLogger) Warn(msg string, _ ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warns = append(l.warns, msg)
+}
+
+type recordingDenyList struct {
+	denied map[string]time.Duration
+}
+
+func (d *recordingDenyList) Deny(ip string, ttl time.Duration, _ string) error {
+	if d.denied == nil {
+		d.denied = make(map[string]time.Duration)
+	}
+	d.denied[ip] = ttl
+	return nil
+}
+
+func TestWeight_ProbePathsWeighMore(t *testing.T) {
+	if Weight("/v1/ledger/acount") != weightUnknownPath {
+		t.Fatalf("typo should have the base weight")
+	}
+	for _, p := range []string{"/wp-admin/setup.php", "/.env", "/.git/config", "/../../etc/passwd"} {
+		if Weight(p) != weightProbePath {
+			t.Fatalf("expected probe weight for %q", p)
+		}
+	}
+}
+
+func TestScorer_OrdinaryNotFoundIsOnlySummarized(t *testing.T) {
+	logger := &recordingLogger{}
+	s := NewScorer(DefaultConfig(), logger, nil)
+
+	s.Record("10.0.0.1", "GET", "/favicon.png")
+	s.Flush()
+
+	if len(logger.warns) != 0 {
+		t.Fatalf("a single 404 must not be reported as suspicious, got %v", logger.warns)
+	}
+	if len(logger.infos) != 1 {
+		t.Fatalf("expected one summary line, got %v", logger.infos)
+	}
+}
+
+func TestScorer_ReportsSuspiciousClientsInSummary(t *testing.T) {
+	logger := &recordingLogger{}
+	cfg := DefaultConfig()
+	cfg.SuspiciousScore = 10
+	s := NewScorer(cfg, logger, nil)
+
+	for i := 0; i < 3; i++ {
+		s.Record("10.0.0.1", "GET", fmt.Sprintf("/admin%d.php", i))
+	}
+	s.Record("10.0.0.2", "GET", "/nope")
+	s.Flush()
+
+	if len(logger.warns) != 1 {
+		t.Fatalf("expected exactly one suspicious client, got %v", logger.warns)
+	}
+}
+
+func TestScorer_WindowResetsScores(t *testing.T) {
+	logger := &recordingLogger{}
+	s := NewScorer(DefaultConfig(), logger, nil)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.Record("10.0.0.1", "GET", "/.env")
+	now = now.Add(2 * time.Minute)
+
+	if score := s.Record("10.0.0.1", "GET", "/.env"); score != weightProbePath {
+		t.Fatalf("expected score to restart in a new window, got %d", score)
+	}
+	if len(logger.infos) != 1 {
+		t.Fatalf("expected previous window to be summarized, got %v", logger.infos)
+	}
+}
+
+func TestScorer_AutoBanAddsToDenyList(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AutoBan = true
+	cfg.BanScore = 10
+	cfg.BanTTL = time.Minute
+	deny := &recordingDenyList{}
+	s := NewScorer(cfg, &recordingLogger{}, deny)
+
+	s.Record("10.0.0.1", "GET", "/.env")
+	if _, ok := deny.denied["10.0.0.1"]; ok {
+		t.Fatalf("client should not be banned below the threshold")
+	}
+	s.Record("10.0.0.1", "GET", "/.git/config")
+	if ttl, ok := deny.denied["10.0.0.1"]; !ok || ttl != time.Minute {
+		t.Fatalf("client should be banned for the configured TTL once the threshold is reached")
+	}
+	s.Record("10.0.0.1", "GET", "/.git/HEAD")
+	if len(deny.denied) != 1 {
+		t.Fatalf("client must only be banned once per window")
+	}
+}
+
+func TestScorer_BoundsTrackedClients(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxTrackedClients = 2
+	s := NewScorer(cfg, &recordingLogger{}, nil)
+
+	s.Record("10.0.0.1", "GET", "/x")
+	s.Record("10.0.0.2", "GET", "/x")
+	if score := s.Record("10.0.0.3", "GET", "/x"); score != 0 {
+		t.Fatalf("expected untracked client to score 0, got %d", score)
+	}
+	if len(s.clients) != 2 {
+		t.Fatalf("expected 2 tracked clients, got %d", len(s.clients))
+	}
+}
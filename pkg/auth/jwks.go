@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSRefresh = 15 * time.Minute
+	// minJWKSRefetch bounds refetches triggered by unknown key IDs, so tokens
+	// with made-up kids cannot hammer the identity provider.
+	minJWKSRefetch = 30 * time.Second
+	maxJWKSBytes   = 1 << 20
+)
+
+// JWKS caches the RSA signing keys published at a JSON Web Key Set URL. Keys
+// are fetched lazily and refetched after the refresh interval, or earlier
+// when a token names a key ID that is not cached (key rotation).
+type JWKS struct {
+	url     string
+	client  *http.Client
+	refresh time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	now       func() time.Time
+}
+
+func NewJWKS(url string, client *http.Client, refresh time.Duration) *JWKS {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	if refresh <= 0 {
+		refresh = defaultJWKSRefresh
+	}
+	return &JWKS{url: url, client: client, refresh: refresh, now: time.Now}
+}
+
+// Key returns the key with the given ID. An empty kid matches only when the
+// set holds exactly one key.
+func (j *JWKS) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	age := j.now().Sub(j.fetchedAt)
+	stale := j.keys == nil || age >= j.refresh
+	if key, ok := j.lookup(kid); ok && !stale {
+		return key, nil
+	}
+
+	if stale || age >= minJWKSRefetch {
+		keys, err := j.fetch(ctx)
+		if err != nil {
+			// Keep serving cached keys while the provider is unreachable.
+			if key, ok := j.lookup(kid); ok {
+				return key, nil
+			}
+			return nil, fmt.Errorf("%w: %v", ErrKeySetUnavailable, err)
+		}
+		j.keys = keys
+		j.fetchedAt = j.now()
+	}
+
+	if key, ok := j.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (j *JWKS) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		// Encryption keys and other algorithms are skipped, not rejected:
+		// providers commonly publish them in the same set.
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != AlgRS256) {
+			continue
+		}
+		key, err := rsaKeyFromJWK(k)
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func rsaKeyFromJWK(k jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil || len(n) == 0 {
+		return nil, errors.New("invalid modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+
+	exponent := 0
+	for _, b := range e {
+		exponent = exponent<<8 | int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+}
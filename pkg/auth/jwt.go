@@ -0,0 +1,422 @@
+// Package auth validates JWT bearer tokens signed with HS256 or RS256.
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+
+	// minHMACSecretLength is the HS256 key size recommended by RFC 7518.
+	minHMACSecretLength = 32
+)
+
+var (
+	ErrMalformedToken       = errors.New("auth: malformed token")
+	ErrUnsupportedAlgorithm = errors.New("auth: unsupported signing algorithm")
+	ErrInvalidSignature     = errors.New("auth: invalid signature")
+	ErrUnknownKey           = errors.New("auth: unknown signing key")
+	ErrTokenExpired         = errors.New("auth: token expired")
+	ErrTokenNotYetValid     = errors.New("auth: token not yet valid")
+	ErrInvalidIssuer        = errors.New("auth: invalid issuer")
+	ErrInvalidAudience      = errors.New("auth: invalid audience")
+	ErrMissingClaim         = errors.New("auth: missing required claim")
+	// ErrKeySetUnavailable means the JWKS endpoint could not be reached, so
+	// the token could not be checked at all.
+	ErrKeySetUnavailable = errors.New("auth: key set unavailable")
+)
+
+// Config selects the accepted signing keys and claim checks. At least one of
+// HMACSecret, RSAPublicKeys or JWKSURL must be set; only the algorithms with a
+// configured key are accepted.
+type Config struct {
+	HMACSecret []byte
+	// RSAPublicKeys are keyed by "kid". A single key may use the empty kid to
+	// match tokens without one.
+	RSAPublicKeys map[string]*rsa.PublicKey
+	JWKSURL       string
+	// JWKSRefresh is how long fetched keys are trusted before refetching.
+	JWKSRefresh time.Duration
+	HTTPClient  *http.Client
+
+	// Issuer and Audience are checked when set.
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew on exp and nbf.
+	Leeway time.Duration
+	// RolesClaim names the claim holding the caller's roles (default "roles").
+	// A dotted path such as "realm_access.roles" reaches into nested objects.
+	RolesClaim string
+}
+
+// Validator checks bearer tokens against its configured keys.
+type Validator struct {
+	hmacSecret []byte
+	rsaKeys    map[string]*rsa.PublicKey
+	jwks       *JWKS
+	issuer     string
+	audience   string
+	leeway     time.Duration
+	rolesClaim string
+	now        func() time.Time
+}
+
+func NewValidator(cfg Config) (*Validator, error) {
+	if len(cfg.HMACSecret) == 0 && len(cfg.RSAPublicKeys) == 0 && cfg.JWKSURL == "" {
+		return nil, errors.New("auth: no signing keys configured")
+	}
+	if len(cfg.HMACSecret) > 0 && len(cfg.HMACSecret) < minHMACSecretLength {
+		return nil, fmt.Errorf("auth: HMAC secret must be at least %d bytes", minHMACSecretLength)
+	}
+
+	v := &Validator{
+		hmacSecret: cfg.HMACSecret,
+		rsaKeys:    cfg.RSAPublicKeys,
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+		leeway:     cfg.Leeway,
+		rolesClaim: cfg.RolesClaim,
+		now:        time.Now,
+	}
+	if v.rolesClaim == "" {
+		v.rolesClaim = "roles"
+	}
+	if cfg.JWKSURL != "" {
+		v.jwks = NewJWKS(cfg.JWKSURL, cfg.HTTPClient, cfg.JWKSRefresh)
+	}
+	return v, nil
+}
+
+// FromEnv builds a Validator from AUTH_JWT_* settings. It returns nil when no
+// signing key is configured.
+func FromEnv() (*Validator, error) {
+	cfg := Config{
+		HMACSecret: []byte(utils.GetEnvTrimmed("AUTH_JWT_HMAC_SECRET")),
+		JWKSURL:    utils.GetEnvTrimmed("AUTH_JWKS_URL"),
+		Issuer:     utils.GetEnvTrimmed("AUTH_JWT_ISSUER"),
+		Audience:   utils.GetEnvTrimmed("AUTH_JWT_AUDIENCE"),
+		RolesClaim: utils.GetEnvTrimmed("AUTH_JWT_ROLES_CLAIM"),
+		Leeway:     30 * time.Second,
+	}
+
+	if path := utils.GetEnvTrimmed("AUTH_JWT_RSA_PUBLIC_KEY_FILE"); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("auth: read AUTH_JWT_RSA_PUBLIC_KEY_FILE: %w", err)
+		}
+		key, err := ParseRSAPublicKeyPEM(raw)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RSAPublicKeys = map[string]*rsa.PublicKey{utils.GetEnvTrimmed("AUTH_JWT_RSA_KEY_ID"): key}
+	}
+
+	if len(cfg.HMACSecret) == 0 && len(cfg.RSAPublicKeys) == 0 && cfg.JWKSURL == "" {
+		return nil, nil
+	}
+
+	for name, dst := range map[string]*time.Duration{"AUTH_JWT_LEEWAY": &cfg.Leeway, "AUTH_JWKS_REFRESH": &cfg.JWKSRefresh} {
+		if raw := utils.GetEnvTrimmed(name); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("auth: invalid %s %q", name, raw)
+			}
+			*dst = d
+		}
+	}
+
+	return NewValidator(cfg)
+}
+
+// ParseRSAPublicKeyPEM reads a PKIX ("PUBLIC KEY") or PKCS#1 ("RSA PUBLIC
+// KEY") encoded RSA public key.
+func ParseRSAPublicKeyPEM(raw []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("auth: no PEM block found")
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("auth: parse public key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("auth: public key is not RSA")
+	}
+	return key, nil
+}
+
+// Claims are the validated claims of a token.
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	Scopes    []string
+	Roles     []string
+	// Raw holds every claim in the payload, including the registered ones.
+	Raw map[string]any
+}
+
+func (c *Claims) HasScope(scope string) bool {
+	return c != nil && slices.Contains(c.Scopes, scope)
+}
+
+// String returns a string claim, or "" when it is absent or not a string.
+func (c *Claims) String(name string) string {
+	if c == nil {
+		return ""
+	}
+	s, _ := c.Raw[name].(string)
+	return s
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Validate verifies the token signature and its exp, nbf, iss and aud claims.
+// Tokens must carry sub and exp.
+func (v *Validator) Validate(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	if err := v.verify(ctx, h, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, ErrMalformedToken
+	}
+	claims, err := v.parseClaims(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verify checks the signature with a key of the algorithm named in the
+// header. The algorithm only selects among configured keys, so an RS256 key
+// can never be used as an HMAC secret.
+func (v *Validator) verify(ctx context.Context, h header, signingInput string, signature []byte) error {
+	switch h.Alg {
+	case AlgHS256:
+		if len(v.hmacSecret) == 0 {
+			return ErrUnsupportedAlgorithm
+		}
+		mac := hmac.New(sha256.New, v.hmacSecret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case AlgRS256:
+		key, err := v.rsaKey(ctx, h.Kid)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	default:
+		return ErrUnsupportedAlgorithm
+	}
+}
+
+func (v *Validator) rsaKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if key, ok := v.rsaKeys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(v.rsaKeys) == 1 && v.jwks == nil {
+		for _, key := range v.rsaKeys {
+			return key, nil
+		}
+	}
+	if v.jwks != nil {
+		return v.jwks.Key(ctx, kid)
+	}
+	if len(v.rsaKeys) == 0 {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	return nil, ErrUnknownKey
+}
+
+func (v *Validator) parseClaims(raw map[string]any) (*Claims, error) {
+	claims := &Claims{Raw: raw}
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Issuer, _ = raw["iss"].(string)
+
+	var err error
+	if claims.Audience, err = stringList(raw["aud"], false); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if claims.Roles, err = stringList(claimAt(raw, v.rolesClaim), true); err != nil {
+		return nil, ErrMalformedToken
+	}
+	// "scope" is the OAuth 2.0 name; "scp" is used by some providers.
+	scopes := raw["scope"]
+	if scopes == nil {
+		scopes = raw["scp"]
+	}
+	if claims.Scopes, err = stringList(scopes, true); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	for name, dst := range map[string]*time.Time{"exp": &claims.ExpiresAt, "nbf": &claims.NotBefore, "iat": &claims.IssuedAt} {
+		if *dst, err = numericDate(raw[name]); err != nil {
+			return nil, ErrMalformedToken
+		}
+	}
+	return claims, nil
+}
+
+func (v *Validator) checkClaims(claims *Claims) error {
+	now := v.now()
+
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: sub", ErrMissingClaim)
+	}
+	if claims.ExpiresAt.IsZero() {
+		return fmt.Errorf("%w: exp", ErrMissingClaim)
+	}
+	if now.After(claims.ExpiresAt.Add(v.leeway)) {
+		return ErrTokenExpired
+	}
+	if !claims.NotBefore.IsZero() && now.Add(v.leeway).Before(claims.NotBefore) {
+		return ErrTokenNotYetValid
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return ErrInvalidIssuer
+	}
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+func decodeSegment(segment string, dst any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(dst)
+}
+
+// claimAt returns the claim named path. A claim whose name literally contains
+// dots wins over walking nested objects.
+func claimAt(raw map[string]any, path string) any {
+	if v, ok := raw[path]; ok {
+		return v
+	}
+	var cur any = raw
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = obj[key]
+	}
+	return cur
+}
+
+// stringList accepts a single string or an array of strings. Space-separated
+// strings are split when split is set, as for the OAuth "scope" claim.
+func stringList(v any, split bool) ([]string, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if split {
+			return strings.Fields(t), nil
+		}
+		return []string{t}, nil
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			s, ok := item.(string)
+			if !ok {
+				return nil, ErrMalformedToken
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, ErrMalformedToken
+	}
+}
+
+func numericDate(v any) (time.Time, error) {
+	if v == nil {
+		return time.Time{}, nil
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, ErrMalformedToken
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)), nil
+}
+
+type contextKey struct{}
+
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// ClaimsFromContext returns the token claims of the request, or nil when the
+// caller did not authenticate with a token.
+func ClaimsFromContext(ctx context.Context) *Claims {
+	c, _ := ctx.Value(contextKey{}).(*Claims)
+	return c
+}
@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func segment(t *testing.T, v any) string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func signHS256(t *testing.T, secret []byte, claims map[string]any) string {
+	t.Helper()
+	input := segment(t, map[string]string{"alg": AlgHS256, "typ": "JWT"}) + "." + segment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	input := segment(t, map[string]string{"alg": AlgRS256, "kid": kid}) + "." + segment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "user-1",
+		"iss":   "https://issuer.test",
+		"aud":   []string{"ledger-api"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"admin"},
+		"scope": "ledger:read ledger:write",
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func TestValidate_HS256(t *testing.T) {
+	v, err := NewValidator(Config{HMACSecret: testSecret, Issuer: "https://issuer.test", Audience: "ledger-api"})
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	claims, err := v.Validate(context.Background(), signHS256(t, testSecret, validClaims()))
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if claims.Subject != "user-1" || claims.String("iss") != "https://issuer.test" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	if len(claims.Roles) != 1 || claims.Roles[0] != "admin" {
+		t.Fatalf("expected admin role, got %v", claims.Roles)
+	}
+	if !claims.HasScope("ledger:write") || claims.HasScope("ledger") {
+		t.Fatalf("unexpected scopes %v", claims.Scopes)
+	}
+}
+
+func TestValidate_RejectsInvalidTokens(t *testing.T) {
+	v, err := NewValidator(Config{HMACSecret: testSecret, Issuer: "https://issuer.test", Audience: "ledger-api"})
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	with := func(key string, value any) map[string]any {
+		c := validClaims()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+	unsigned := segment(t, map[string]string{"alg": "none"}) + "." + segment(t, validClaims()) + "."
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"garbage", "not-a-token", ErrMalformedToken},
+		{"alg none", unsigned, ErrUnsupportedAlgorithm},
+		{"wrong secret", signHS256(t, []byte("another-secret-another-secret-xx"), validClaims()), ErrInvalidSignature},
+		{"expired", signHS256(t, testSecret, with("exp", time.Now().Add(-time.Hour).Unix())), ErrTokenExpired},
+		{"not yet valid", signHS256(t, testSecret, with("nbf", time.Now().Add(time.Hour).Unix())), ErrTokenNotYetValid},
+		{"wrong issuer", signHS256(t, testSecret, with("iss", "https://evil.test")), ErrInvalidIssuer},
+		{"wrong audience", signHS256(t, testSecret, with("aud", "other-api")), ErrInvalidAudience},
+		{"no subject", signHS256(t, testSecret, with("sub", nil)), ErrMissingClaim},
+		{"no expiry", signHS256(t, testSecret, with("exp", nil)), ErrMissingClaim},
+		{"non-numeric expiry", signHS256(t, testSecret, with("exp", "tomorrow")), ErrMalformedToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Validate(context.Background(), tt.token); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate_NestedRolesClaim(t *testing.T) {
+	v, err := NewValidator(Config{HMACSecret: testSecret, RolesClaim: "realm_access.roles"})
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	claims := validClaims()
+	claims["realm_access"] = map[string]any{"roles": []string{"auditor", "admin"}}
+	parsed, err := v.Validate(context.Background(), signHS256(t, testSecret, claims))
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if len(parsed.Roles) != 2 || parsed.Roles[0] != "auditor" {
+		t.Fatalf("expected nested roles, got %v", parsed.Roles)
+	}
+
+	claims["realm_access"] = "admin"
+	if parsed, err = v.Validate(context.Background(), signHS256(t, testSecret, claims)); err != nil || len(parsed.Roles) != 0 {
+		t.Fatalf("expected no roles when the path does not resolve, got %v, %v", parsed, err)
+	}
+}
+
+func TestValidate_Leeway(t *testing.T) {
+	v, err := NewValidator(Config{HMACSecret: testSecret, Leeway: time.Minute})
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	if _, err := v.Validate(context.Background(), signHS256(t, testSecret, claims)); err != nil {
+		t.Fatalf("expected token within leeway to pass, got %v", err)
+	}
+}
+
+func TestValidate_RS256StaticKey(t *testing.T) {
+	key := newRSAKey(t)
+	v, err := NewValidator(Config{RSAPublicKeys: map[string]*rsa.PublicKey{"": &key.PublicKey}})
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	if _, err := v.Validate(context.Background(), signRS256(t, key, "", validClaims())); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	// HS256 is not accepted when only RSA keys are configured, so the public
+	// key can never be abused as an HMAC secret.
+	if _, err := v.Validate(context.Background(), signHS256(t, testSecret, validClaims())); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Fatalf("expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+
+	other := newRSAKey(t)
+	if _, err := v.Validate(context.Background(), signRS256(t, other, "", validClaims())); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func jwk(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": AlgRS256,
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func TestValidate_JWKSRotation(t *testing.T) {
+	first, second := newRSAKey(t), newRSAKey(t)
+
+	var published atomic.Value
+	published.Store([]map[string]string{jwk("k1", &first.PublicKey)})
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": published.Load()})
+	}))
+	defer srv.Close()
+
+	v, err := NewValidator(Config{JWKSURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+	now := time.Now()
+	v.jwks.now = func() time.Time { return now }
+
+	if _, err := v.Validate(context.Background(), signRS256(t, first, "k1", validClaims())); err != nil {
+		t.Fatalf("Validate k1: %v", err)
+	}
+
+	// The provider rotates to k2. Until the refetch interval passes, the
+	// unknown kid is rejected without contacting the provider again.
+	published.Store([]map[string]string{jwk("k1", &first.PublicKey), jwk("k2", &second.PublicKey)})
+	token := signRS256(t, second, "k2", validClaims())
+	if _, err := v.Validate(context.Background(), token); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("expected 1 fetch, got %d", got)
+	}
+
+	now = now.Add(minJWKSRefetch)
+	if _, err := v.Validate(context.Background(), token); err != nil {
+		t.Fatalf("Validate k2 after refetch: %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("expected 2 fetches, got %d", got)
+	}
+}
+
+func TestValidate_JWKSUnavailable(t *testing.T) {
+	key := newRSAKey(t)
+	up := atomic.Bool{}
+	up.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{jwk("k1", &key.PublicKey)}})
+	}))
+	defer srv.Close()
+
+	v, err := NewValidator(Config{JWKSURL: srv.URL, JWKSRefresh: time.Minute})
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+	now := time.Now()
+	v.jwks.now = func() time.Time { return now }
+
+	token := signRS256(t, key, "k1", validClaims())
+	if _, err := v.Validate(context.Background(), token); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	// Cached keys keep working while the provider is down.
+	up.Store(false)
+	now = now.Add(2 * time.Minute)
+	if _, err := v.Validate(context.Background(), token); err != nil {
+		t.Fatalf("expected cached key to be used, got %v", err)
+	}
+	if _, err := v.Validate(context.Background(), signRS256(t, key, "k9", validClaims())); !errors.Is(err, ErrKeySetUnavailable) {
+		t.Fatalf("expected ErrKeySetUnavailable, got %v", err)
+	}
+}
+
+func TestNewValidator_RejectsShortSecret(t *testing.T) {
+	if _, err := NewValidator(Config{HMACSecret: []byte("short")}); err == nil || !strings.Contains(err.Error(), "at least") {
+		t.Fatalf("expected short secret error, got %v", err)
+	}
+	if _, err := NewValidator(Config{}); err == nil {
+		t.Fatal("expected error without keys")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("AUTH_JWT_HMAC_SECRET", "")
+	t.Setenv("AUTH_JWKS_URL", "")
+	t.Setenv("AUTH_JWT_RSA_PUBLIC_KEY_FILE", "")
+	if v, err := FromEnv(); v != nil || err != nil {
+		t.Fatalf("expected nil validator without configuration, got %v, %v", v, err)
+	}
+
+	t.Setenv("AUTH_JWT_HMAC_SECRET", string(testSecret))
+	t.Setenv("AUTH_JWT_LEEWAY", "bogus")
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("expected invalid leeway error")
+	}
+
+	t.Setenv("AUTH_JWT_LEEWAY", "1m")
+	v, err := FromEnv()
+	if err != nil || v == nil {
+		t.Fatalf("expected validator, got %v, %v", v, err)
+	}
+	if v.leeway != time.Minute {
+		t.Fatalf("expected 1m leeway, got %v", v.leeway)
+	}
+}
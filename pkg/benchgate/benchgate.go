@@ -0,0 +1,215 @@
+// Package benchgate compares two sets of `go test -bench` results and
+// reports the benchmarks that got significantly worse, so a performance
+// regression can fail a release check. benchstat shows the same comparison
+// for people; it has no exit status to gate on.
+package benchgate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultMaxRegression is the largest slowdown, as a fraction of the old
+	// median, that passes when Config leaves MaxRegression unset.
+	DefaultMaxRegression = 0.10
+	// DefaultAlpha is the significance level when Config leaves Alpha unset.
+	DefaultAlpha = 0.05
+)
+
+// lowerIsBetter lists the units compared, and whether a smaller value is an
+// improvement. Other units are ignored.
+var lowerIsBetter = map[string]bool{
+	"ns/op":     true,
+	"B/op":      true,
+	"allocs/op": true,
+	"MB/s":      false,
+}
+
+// procsSuffix is the GOMAXPROCS suffix go test appends to benchmark names.
+var procsSuffix = regexp.MustCompile(`-\d+$`)
+
+// Results holds every sample of each benchmark and unit, keyed by benchmark
+// name without its GOMAXPROCS suffix, then by unit.
+type Results map[string]map[string][]float64
+
+// Parse reads `go test -bench` output, typically from runs with -count 10
+// and -benchmem. Lines other than benchmark results are skipped.
+func Parse(r io.Reader) (Results, error) {
+	results := make(Results)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// Name, iterations, then value and unit pairs.
+		if len(fields) < 4 || len(fields)%2 != 0 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		name := procsSuffix.ReplaceAllString(fields[0], "")
+		for i := 2; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			unit := fields[i+1]
+			if results[name] == nil {
+				results[name] = make(map[string][]float64)
+			}
+			results[name][unit] = append(results[name][unit], value)
+		}
+	}
+	return results, scanner.Err()
+}
+
+type Config struct {
+	// MaxRegression is the slowdown allowed, as a fraction of the old
+	// median: 0.1 lets ns/op grow by 10%.
+	MaxRegression float64
+	// Alpha is the p-value under which a difference counts as real rather
+	// than noise.
+	Alpha float64
+}
+
+// Delta compares one unit of one benchmark.
+type Delta struct {
+	Benchmark string
+	Unit      string
+	Old       float64
+	New       float64
+	// Change is the change of the median as a fraction of Old; positive is
+	// worse, whichever way the unit goes.
+	Change float64
+	// P is the two-sided Mann-Whitney U test p-value.
+	P float64
+	// Regression is set when Change exceeds MaxRegression with P under Alpha.
+	Regression bool
+}
+
+func (d Delta) String() string {
+	verdict := fmt.Sprintf("%+.2f%% (p=%.3f)", d.Change*100, d.P)
+	if d.Regression {
+		verdict += " REGRESSION"
+	}
+	return fmt.Sprintf("%-60s %-10s %14.4g %14.4g  %s", d.Benchmark, d.Unit, d.Old, d.New, verdict)
+}
+
+// Compare compares every benchmark and unit found in both old and new,
+// sorted by benchmark and unit.
+func Compare(old, new Results, cfg Config) []Delta {
+	if cfg.MaxRegression <= 0 {
+		cfg.MaxRegression = DefaultMaxRegression
+	}
+	if cfg.Alpha <= 0 {
+		cfg.Alpha = DefaultAlpha
+	}
+	var deltas []Delta
+	for name, units := range new {
+		for unit, after := range units {
+			lower, known := lowerIsBetter[unit]
+			before := old[name][unit]
+			if !known || len(before) == 0 {
+				continue
+			}
+			d := Delta{Benchmark: name, Unit: unit, Old: median(before), New: median(after), P: mannWhitneyP(before, after)}
+			if d.Old != 0 {
+				d.Change = (d.New - d.Old) / d.Old
+				if !lower {
+					d.Change = -d.Change
+				}
+			}
+			d.Regression = d.Change > cfg.MaxRegression && d.P < cfg.Alpha
+			deltas = append(deltas, d)
+		}
+	}
+	slices.SortFunc(deltas, func(a, b Delta) int {
+		if c := strings.Compare(a.Benchmark, b.Benchmark); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Unit, b.Unit)
+	})
+	return deltas
+}
+
+// Regressions returns the deltas that are regressions.
+func Regressions(deltas []Delta) []Delta {
+	var regressions []Delta
+	for _, d := range deltas {
+		if d.Regression {
+			regressions = append(regressions, d)
+		}
+	}
+	return regressions
+}
+
+func median(samples []float64) float64 {
+	sorted := slices.Sorted(slices.Values(samples))
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// mannWhitneyP returns the two-sided p-value of the Mann-Whitney U test
+// that x and y come from the same distribution, with the normal
+// approximation corrected for ties and continuity. It does not assume the
+// samples are normally distributed, which benchmark timings rarely are.
+func mannWhitneyP(x, y []float64) float64 {
+	type sample struct {
+		value float64
+		fromX bool
+	}
+	all := make([]sample, 0, len(x)+len(y))
+	for _, v := range x {
+		all = append(all, sample{v, true})
+	}
+	for _, v := range y {
+		all = append(all, sample{v, false})
+	}
+	slices.SortFunc(all, func(a, b sample) int {
+		switch {
+		case a.value < b.value:
+			return -1
+		case a.value > b.value:
+			return 1
+		}
+		return 0
+	})
+
+	// Tied values share the average of their ranks.
+	var rankSumX, ties float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for _, s := range all[i:j] {
+			if s.fromX {
+				rankSumX += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	n1, n2 := float64(len(x)), float64(len(y))
+	n := n1 + n2
+	u := rankSumX - n1*(n1+1)/2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := math.Max(math.Abs(u-mean)-0.5, 0) / math.Sqrt(variance)
+	return math.Erfc(z / math.Sqrt2)
+}
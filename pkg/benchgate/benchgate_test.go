@@ -0,0 +1,111 @@
+package benchgate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchOutput formats go test -bench lines, one per ns/op sample.
+func benchOutput(name string, nsPerOp []float64, allocs int) string {
+	var b strings.Builder
+	b.WriteString("goos: linux\ngoarch: amd64\npkg: example\n")
+	for _, ns := range nsPerOp {
+		fmt.Fprintf(&b, "%s-8   \t  100000\t %.1f ns/op\t  512 B/op\t  %d allocs/op\n", name, ns, allocs)
+	}
+	b.WriteString("PASS\nok  \texample\t1.234s\n")
+	return b.String()
+}
+
+func mustParse(t *testing.T, output string) Results {
+	t.Helper()
+	results, err := Parse(strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return results
+}
+
+func TestParse(t *testing.T) {
+	results := mustParse(t, benchOutput("BenchmarkChain/memory", []float64{100, 110}, 12)+
+		"BenchmarkPlain-16 \t 50 \t 20.5 ns/op\n--- BENCH: BenchmarkPlain\n    note\n")
+
+	chain := results["BenchmarkChain/memory"]
+	if got := chain["ns/op"]; len(got) != 2 || got[0] != 100 || got[1] != 110 {
+		t.Fatalf("unexpected ns/op samples %v", got)
+	}
+	if got := chain["allocs/op"]; len(got) != 2 || got[0] != 12 {
+		t.Fatalf("unexpected allocs/op samples %v", got)
+	}
+	if got := results["BenchmarkPlain"]["ns/op"]; len(got) != 1 || got[0] != 20.5 {
+		t.Fatalf("expected the GOMAXPROCS suffix to be dropped, got %v", results)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected two benchmarks, got %v", results)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := mustParse(t, benchOutput("BenchmarkChain", []float64{100, 102, 98, 101, 99, 100, 103, 97, 100, 101}, 10))
+
+	t.Run("noise passes", func(t *testing.T) {
+		new := mustParse(t, benchOutput("BenchmarkChain", []float64{101, 99, 100, 104, 98, 100, 102, 99, 101, 100}, 10))
+		if regressions := Regressions(Compare(old, new, Config{})); len(regressions) != 0 {
+			t.Fatalf("expected no regressions, got %v", regressions)
+		}
+	})
+
+	t.Run("slowdown fails", func(t *testing.T) {
+		new := mustParse(t, benchOutput("BenchmarkChain", []float64{120, 122, 118, 121, 119, 120, 123, 117, 120, 121}, 10))
+		regressions := Regressions(Compare(old, new, Config{}))
+		if len(regressions) != 1 || regressions[0].Unit != "ns/op" {
+			t.Fatalf("expected an ns/op regression, got %v", regressions)
+		}
+		if d := regressions[0]; d.Old != 100 || d.New != 120 || d.Change < 0.19 || d.P >= DefaultAlpha {
+			t.Fatalf("unexpected delta %+v", d)
+		}
+		if regressions := Regressions(Compare(old, new, Config{MaxRegression: 0.25})); len(regressions) != 0 {
+			t.Fatalf("expected a 20%% slowdown to pass a 25%% limit, got %v", regressions)
+		}
+	})
+
+	t.Run("extra allocation fails", func(t *testing.T) {
+		new := mustParse(t, benchOutput("BenchmarkChain", []float64{100, 102, 98, 101, 99, 100, 103, 97, 100, 101}, 12))
+		regressions := Regressions(Compare(old, new, Config{}))
+		if len(regressions) != 1 || regressions[0].Unit != "allocs/op" {
+			t.Fatalf("expected an allocs/op regression, got %v", regressions)
+		}
+	})
+
+	t.Run("few samples are not significant", func(t *testing.T) {
+		before := mustParse(t, benchOutput("BenchmarkChain", []float64{100}, 10))
+		after := mustParse(t, benchOutput("BenchmarkChain", []float64{150}, 10))
+		if regressions := Regressions(Compare(before, after, Config{})); len(regressions) != 0 {
+			t.Fatalf("expected one sample each not to be significant, got %v", regressions)
+		}
+	})
+
+	t.Run("new benchmarks are skipped", func(t *testing.T) {
+		new := mustParse(t, benchOutput("BenchmarkOther", []float64{500, 500}, 99))
+		if deltas := Compare(old, new, Config{}); len(deltas) != 0 {
+			t.Fatalf("expected nothing to compare, got %v", deltas)
+		}
+	})
+}
+
+func TestMannWhitneyP(t *testing.T) {
+	same := []float64{1, 2, 3, 4, 5}
+	if p := mannWhitneyP(same, same); p < 0.99 {
+		t.Fatalf("expected identical samples to give p near 1, got %f", p)
+	}
+	if p := mannWhitneyP([]float64{7, 7, 7}, []float64{7, 7, 7}); p != 1 {
+		t.Fatalf("expected constant samples to give p=1, got %f", p)
+	}
+	// Completely separated samples of 10: the exact two-sided p is about
+	// 1.1e-5; the normal approximation is close.
+	low := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	high := []float64{11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	if p := mannWhitneyP(low, high); p > 0.001 {
+		t.Fatalf("expected separated samples to be significant, got %f", p)
+	}
+}
@@ -0,0 +1,113 @@
+package botdetect
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeuristicDetector(t *testing.T) {
+	d := NewHeuristicDetector("evilbot")
+
+	cases := []struct {
+		ua     string
+		bot    bool
+		reason string
+	}{
+		{"Mozilla/5.0", false, ""},
+		{"curl/8.5.0", false, ""},
+		{"sqlmap/1.7", true, "blocked_user_agent"},
+		{"EvilBot/2.0", true, "blocked_user_agent"},
+		{"", true, "missing_user_agent"},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", tc.ua)
+		v, err := d.Detect(context.Background(), r, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v.Bot != tc.bot || v.Reason != tc.reason {
+			t.Errorf("UA %q: got %+v", tc.ua, v)
+		}
+	}
+
+	d.RequireUserAgent = false
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Del("User-Agent")
+	if v, _ := d.Detect(context.Background(), r, ""); v.Bot {
+		t.Fatalf("missing UA must be allowed when RequireUserAgent is false")
+	}
+}
+
+func newSiteverify(t *testing.T, resp map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		if r.PostForm.Get("secret") != "secret" || r.PostForm.Get("remoteip") != "192.0.2.1" {
+			t.Errorf("unexpected form: %v", r.PostForm)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCaptchaVerifier(t *testing.T) {
+	cases := []struct {
+		name   string
+		token  string
+		resp   map[string]any
+		bot    bool
+		reason string
+	}{
+		{"missing token", "", nil, true, "captcha_missing"},
+		{"success", "tok", map[string]any{"success": true}, false, ""},
+		{"failure", "tok", map[string]any{"success": false, "error-codes": []string{"invalid-input-response"}}, true, "captcha_failed"},
+		{"low score", "tok", map[string]any{"success": true, "score": 0.1}, true, "captcha_low_score"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newSiteverify(t, tc.resp)
+			v, err := NewCaptchaVerifier(CaptchaConfig{
+				Provider:  "recaptcha",
+				Secret:    "secret",
+				SiteKey:   "site",
+				VerifyURL: srv.URL,
+				MinScore:  0.5,
+			})
+			if err != nil {
+				t.Fatalf("new verifier: %v", err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.token != "" {
+				r.Header.Set(DefaultTokenHeader, tc.token)
+			}
+			verdict, err := v.Detect(context.Background(), r, "192.0.2.1")
+			if err != nil {
+				t.Fatalf("detect: %v", err)
+			}
+			if verdict.Bot != tc.bot || verdict.Reason != tc.reason {
+				t.Fatalf("got %+v", verdict)
+			}
+			if verdict.Bot && (verdict.Challenge == nil || verdict.Challenge.SiteKey != "site") {
+				t.Fatalf("expected challenge hint, got %+v", verdict.Challenge)
+			}
+		})
+	}
+}
+
+func TestNewCaptchaVerifier_Validates(t *testing.T) {
+	if _, err := NewCaptchaVerifier(CaptchaConfig{Provider: "hcaptcha", Secret: "x"}); err == nil {
+		t.Fatalf("expected error for unknown provider")
+	}
+	if _, err := NewCaptchaVerifier(CaptchaConfig{Provider: "turnstile"}); err == nil {
+		t.Fatalf("expected error for missing secret")
+	}
+}
@@ -0,0 +1,122 @@
+package botdetect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	TurnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+	RecaptchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+
+	DefaultTokenHeader = "X-Captcha-Token"
+)
+
+type CaptchaConfig struct {
+	// Provider is "turnstile" or "recaptcha".
+	Provider  string
+	Secret    string
+	SiteKey   string
+	VerifyURL string
+	// MinScore applies to reCAPTCHA v3 responses only.
+	MinScore    float64
+	TokenHeader string
+	HTTPClient  *http.Client
+}
+
+// CaptchaVerifier validates challenge tokens with a siteverify endpoint.
+// Turnstile and reCAPTCHA share the same request and response shape.
+type CaptchaVerifier struct {
+	cfg CaptchaConfig
+}
+
+func NewCaptchaVerifier(cfg CaptchaConfig) (*CaptchaVerifier, error) {
+	cfg.Provider = strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if cfg.VerifyURL == "" {
+		switch cfg.Provider {
+		case "turnstile":
+			cfg.VerifyURL = TurnstileVerifyURL
+		case "recaptcha":
+			cfg.VerifyURL = RecaptchaVerifyURL
+		default:
+			return nil, fmt.Errorf("unknown captcha provider %q", cfg.Provider)
+		}
+	}
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("captcha provider %q requires a secret", cfg.Provider)
+	}
+	if cfg.TokenHeader == "" {
+		cfg.TokenHeader = DefaultTokenHeader
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &CaptchaVerifier{cfg: cfg}, nil
+}
+
+func (v *CaptchaVerifier) Name() string {
+	return v.cfg.Provider
+}
+
+func (v *CaptchaVerifier) challenge() *Challenge {
+	return &Challenge{
+		Provider:    v.cfg.Provider,
+		SiteKey:     v.cfg.SiteKey,
+		TokenHeader: v.cfg.TokenHeader,
+	}
+}
+
+type siteverifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func (v *CaptchaVerifier) Detect(ctx context.Context, r *http.Request, clientIP string) (Verdict, error) {
+	token := strings.TrimSpace(r.Header.Get(v.cfg.TokenHeader))
+	if token == "" {
+		return Verdict{Bot: true, Reason: "captcha_missing", Challenge: v.challenge()}, nil
+	}
+
+	form := url.Values{
+		"secret":   {v.cfg.Secret},
+		"response": {token},
+	}
+	if clientIP != "" {
+		form.Set("remoteip", clientIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.cfg.VerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("captcha verification request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("captcha verification returned status %d", resp.StatusCode)
+	}
+
+	var body siteverifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Verdict{}, fmt.Errorf("decode captcha verification response: %w", err)
+	}
+
+	if !body.Success {
+		return Verdict{Bot: true, Reason: "captcha_failed", Challenge: v.challenge()}, nil
+	}
+	if body.Score != nil && *body.Score < v.cfg.MinScore {
+		return Verdict{Bot: true, Reason: "captcha_low_score", Challenge: v.challenge()}, nil
+	}
+	return Verdict{}, nil
+}
@@ -0,0 +1,26 @@
+package botdetect
+
+import (
+	"context"
+	"net/http"
+)
+
+// Challenge tells the client how to prove it is human.
+type Challenge struct {
+	Provider    string `json:"provider"`
+	SiteKey     string `json:"site_key,omitempty"`
+	TokenHeader string `json:"token_header"`
+}
+
+type Verdict struct {
+	Bot       bool
+	Reason    string
+	Challenge *Challenge
+}
+
+// Detector inspects a request and decides whether it comes from a bot.
+// Errors mean the detector could not reach a decision.
+type Detector interface {
+	Name() string
+	Detect(ctx context.Context, r *http.Request, clientIP string) (Verdict, error)
+}
@@ -0,0 +1,51 @@
+package botdetect
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// defaultBlockedAgents are scanners and exploit tools that have no business
+// calling the API. Generic HTTP clients (curl, SDKs) are deliberately absent.
+var defaultBlockedAgents = []string{
+	"sqlmap", "nikto", "nmap", "masscan", "zgrab", "nuclei", "dirbuster", "gobuster", "wpscan", "acunetix", "netsparker",
+}
+
+// HeuristicDetector flags requests based on their headers alone.
+type HeuristicDetector struct {
+	blockedAgents []string
+	// RequireUserAgent flags requests without a User-Agent header.
+	RequireUserAgent bool
+}
+
+func NewHeuristicDetector(extraBlockedAgents ...string) *HeuristicDetector {
+	agents := append([]string{}, defaultBlockedAgents...)
+	for _, a := range extraBlockedAgents {
+		if a = strings.ToLower(strings.TrimSpace(a)); a != "" {
+			agents = append(agents, a)
+		}
+	}
+	return &HeuristicDetector{blockedAgents: agents, RequireUserAgent: true}
+}
+
+func (d *HeuristicDetector) Name() string {
+	return "heuristic"
+}
+
+func (d *HeuristicDetector) Detect(_ context.Context, r *http.Request, _ string) (Verdict, error) {
+	ua := strings.ToLower(strings.TrimSpace(r.UserAgent()))
+	if ua == "" {
+		if d.RequireUserAgent {
+			return Verdict{Bot: true, Reason: "missing_user_agent"}, nil
+		}
+		return Verdict{}, nil
+	}
+
+	for _, agent := range d.blockedAgents {
+		if strings.Contains(ua, agent) {
+			return Verdict{Bot: true, Reason: "blocked_user_agent"}, nil
+		}
+	}
+	return Verdict{}, nil
+}
@@ -0,0 +1,190 @@
+// Package cdc describes how downstream consumers capture changes to the
+// ledger with PostgreSQL logical decoding, as a Debezium connector does, and
+// checks that the database is ready for them.
+//
+// The migrations publish the captured tables as ledger_cdc and number every
+// insert and update with a change_seq column. Each consumer creates its own
+// replication slot on the publication when it first connects; an unread slot
+// holds WAL on the primary, so drop the slots of retired consumers.
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/akeren/go-api-foundry/pkg/rawsql"
+	"gorm.io/gorm"
+)
+
+// Table is a captured table.
+type Table struct {
+	Name string   `json:"name"`
+	Key  []string `json:"key"`
+	// Sequence orders the table's changes by when they were made, across
+	// every captured table.
+	Sequence string `json:"sequence_column,omitempty"`
+	// Operations are the kinds of change the table sees.
+	Operations []string `json:"operations"`
+	Note       string   `json:"note,omitempty"`
+}
+
+// Config is what a consumer needs to subscribe.
+type Config struct {
+	Publication string  `json:"publication"`
+	Slot        string  `json:"slot"`
+	Plugin      string  `json:"plugin"`
+	Tables      []Table `json:"tables"`
+}
+
+// Status is the database's side of the configuration.
+type Status struct {
+	Schema              string   `json:"schema"`
+	WALLevel            string   `json:"wal_level"`
+	MaxReplicationSlots int      `json:"max_replication_slots"`
+	PublicationExists   bool     `json:"publication_exists"`
+	PublishedTables     []string `json:"published_tables"`
+	// Slot is nil until a consumer has created it.
+	Slot *SlotStatus `json:"slot,omitempty"`
+	// Problems lists what stops a consumer from capturing every change.
+	Problems []string `json:"problems"`
+}
+
+// SlotStatus is the state of the consumer's replication slot.
+type SlotStatus struct {
+	Plugin string `json:"plugin"`
+	Active bool   `json:"active"`
+	// RetainedBytes is the WAL the primary keeps until the consumer reads it.
+	RetainedBytes int64 `json:"retained_bytes"`
+}
+
+// Description is the configuration, the matching Debezium connector
+// properties, and, on PostgreSQL, the status.
+type Description struct {
+	Config
+	Connector map[string]string `json:"debezium_connector"`
+	Status    *Status           `json:"status,omitempty"`
+}
+
+const (
+	settingsQuery = `
+		SELECT current_schema() AS schema,
+			current_setting('wal_level') AS wal_level,
+			current_setting('max_replication_slots')::INT AS max_replication_slots,
+			EXISTS (SELECT 1 FROM pg_publication WHERE pubname = @publication) AS publication_exists`
+
+	publishedTablesQuery = `
+		SELECT tablename FROM pg_publication_tables
+		WHERE pubname = @publication AND schemaname = current_schema()
+		ORDER BY tablename`
+
+	slotQuery = `
+		SELECT plugin, active,
+			COALESCE(pg_wal_lsn_diff(pg_current_wal_lsn(), restart_lsn), 0)::BIGINT AS retained_bytes
+		FROM pg_replication_slots
+		WHERE slot_name = @slot`
+)
+
+// Inspector describes the configuration against a database.
+type Inspector struct {
+	db  *gorm.DB
+	cfg Config
+}
+
+func NewInspector(db *gorm.DB, cfg Config) *Inspector {
+	return &Inspector{db: db, cfg: cfg}
+}
+
+// Describe returns the configuration and, on PostgreSQL, what the database
+// has of it. A database other than PostgreSQL has no status.
+func (i *Inspector) Describe(ctx context.Context) (*Description, error) {
+	description := &Description{Config: i.cfg}
+	schema := "public"
+	if i.db.Dialector.Name() == "postgres" {
+		status, err := i.status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		description.Status = status
+		schema = status.Schema
+	}
+	description.Connector = connector(i.cfg, schema)
+	return description, nil
+}
+
+func (i *Inspector) status(ctx context.Context) (*Status, error) {
+	db := i.db.WithContext(ctx)
+	var settings struct {
+		Schema              string
+		WALLevel            string `gorm:"column:wal_level"`
+		MaxReplicationSlots int
+		PublicationExists   bool
+	}
+	if err := rawsql.Get(ctx, db, &settings, settingsQuery, rawsql.Params{"publication": i.cfg.Publication}); err != nil {
+		return nil, fmt.Errorf("cdc: read settings: %w", err)
+	}
+	status := Status{
+		Schema:              settings.Schema,
+		WALLevel:            settings.WALLevel,
+		MaxReplicationSlots: settings.MaxReplicationSlots,
+		PublicationExists:   settings.PublicationExists,
+		PublishedTables:     []string{},
+	}
+	if err := rawsql.Select(ctx, db, &status.PublishedTables, publishedTablesQuery, rawsql.Params{"publication": i.cfg.Publication}); err != nil {
+		return nil, fmt.Errorf("cdc: read publication: %w", err)
+	}
+	var slots []SlotStatus
+	if err := rawsql.Select(ctx, db, &slots, slotQuery, rawsql.Params{"slot": i.cfg.Slot}); err != nil {
+		return nil, fmt.Errorf("cdc: read replication slot: %w", err)
+	}
+	if len(slots) > 0 {
+		status.Slot = &slots[0]
+	}
+	status.Problems = problems(i.cfg, &status)
+	return &status, nil
+}
+
+func problems(cfg Config, status *Status) []string {
+	found := []string{}
+	if status.WALLevel != "logical" {
+		found = append(found, fmt.Sprintf("wal_level is %s; logical decoding needs wal_level=logical and a restart", status.WALLevel))
+	}
+	if status.MaxReplicationSlots == 0 {
+		found = append(found, "max_replication_slots is 0; raise it to at least one per consumer")
+	}
+	if !status.PublicationExists {
+		found = append(found, fmt.Sprintf("publication %s does not exist; run the migrations or create it", cfg.Publication))
+	} else {
+		published := make(map[string]bool, len(status.PublishedTables))
+		for _, name := range status.PublishedTables {
+			published[name] = true
+		}
+		for _, table := range cfg.Tables {
+			if !published[table.Name] {
+				found = append(found, fmt.Sprintf("table %s is not in publication %s", table.Name, cfg.Publication))
+			}
+		}
+	}
+	if status.Slot != nil && status.Slot.Plugin != cfg.Plugin {
+		found = append(found, fmt.Sprintf("slot %s uses plugin %s, not %s", cfg.Slot, status.Slot.Plugin, cfg.Plugin))
+	}
+	return found
+}
+
+// connector returns the Debezium PostgreSQL connector properties that match
+// cfg. The publication is managed by the migrations, so Debezium must not
+// create or alter it.
+func connector(cfg Config, schema string) map[string]string {
+	tables := make([]string, len(cfg.Tables))
+	for i, table := range cfg.Tables {
+		tables[i] = schema + "." + table.Name
+	}
+	return map[string]string{
+		"connector.class":             "io.debezium.connector.postgresql.PostgresConnector",
+		"plugin.name":                 cfg.Plugin,
+		"slot.name":                   cfg.Slot,
+		"publication.name":            cfg.Publication,
+		"publication.autocreate.mode": "disabled",
+		"table.include.list":          strings.Join(tables, ","),
+	}
+}
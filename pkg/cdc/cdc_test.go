@@ -0,0 +1,79 @@
+package cdc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testConfig = Config{
+	Publication: "ledger_cdc",
+	Slot:        "ledger_cdc",
+	Plugin:      "pgoutput",
+	Tables: []Table{
+		{Name: "accounts", Key: []string{"id"}, Sequence: "change_seq", Operations: []string{"insert", "update"}},
+		{Name: "ledger_entries", Key: []string{"id", "created_at"}, Sequence: "change_seq", Operations: []string{"insert", "delete"}},
+	},
+}
+
+func TestDescribe_WithoutPostgresHasNoStatus(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	description, err := NewInspector(db, testConfig).Describe(context.Background())
+	if err != nil {
+		t.Fatalf("Describe: %v", err)
+	}
+	if description.Status != nil {
+		t.Fatalf("Status = %+v, want nil", description.Status)
+	}
+	if got := description.Connector["table.include.list"]; got != "public.accounts,public.ledger_entries" {
+		t.Fatalf("table.include.list = %q", got)
+	}
+	if got := description.Connector["publication.autocreate.mode"]; got != "disabled" {
+		t.Fatalf("publication.autocreate.mode = %q, want disabled", got)
+	}
+}
+
+func TestProblems(t *testing.T) {
+	ready := Status{
+		WALLevel:            "logical",
+		MaxReplicationSlots: 10,
+		PublicationExists:   true,
+		PublishedTables:     []string{"accounts", "ledger_entries"},
+		Slot:                &SlotStatus{Plugin: "pgoutput", Active: true},
+	}
+	if got := problems(testConfig, &ready); len(got) != 0 {
+		t.Fatalf("problems of a ready database = %v", got)
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Status)
+		want   string
+	}{
+		{"wal level", func(s *Status) { s.WALLevel = "replica" }, "wal_level is replica"},
+		{"no slots", func(s *Status) { s.MaxReplicationSlots = 0 }, "max_replication_slots is 0"},
+		{"no publication", func(s *Status) { s.PublicationExists = false }, "publication ledger_cdc does not exist"},
+		{"table missing", func(s *Status) { s.PublishedTables = []string{"accounts"} }, "table ledger_entries is not in publication"},
+		{"wrong plugin", func(s *Status) { s.Slot = &SlotStatus{Plugin: "wal2json"} }, "uses plugin wal2json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := ready
+			tt.change(&status)
+			got := problems(testConfig, &status)
+			if len(got) != 1 || !strings.Contains(got[0], tt.want) {
+				t.Fatalf("problems = %v, want one containing %q", got, tt.want)
+			}
+		})
+	}
+}
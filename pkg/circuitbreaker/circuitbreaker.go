@@ -0,0 +1,224 @@
+// Package circuitbreaker stops calls to a dependency that keeps failing, so
+// callers fail fast or fall back instead of each waiting out a timeout, and
+// lets a trial call through now and then to notice when it recovers:
+//
+//	err := breaker.Do(func() error { return client.Ping(ctx).Err() })
+//	if errors.Is(err, circuitbreaker.ErrOpen) {
+//		// Use the fallback.
+//	}
+//
+// A nil *Breaker allows every call, so optional breakers need no checks.
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned instead of calling a dependency whose breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is where a breaker is in its cycle.
+type State int
+
+const (
+	// StateClosed lets calls through and counts consecutive failures.
+	StateClosed State = iota
+	// StateHalfOpen lets one trial call through; its outcome closes or
+	// reopens the breaker.
+	StateHalfOpen
+	// StateOpen rejects calls until OpenTimeout has passed.
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	}
+	return "closed"
+}
+
+type Config struct {
+	// FailureThreshold is how many consecutive failures open the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before a trial call.
+	OpenTimeout time.Duration
+	// OnStateChange, when set, is called after each transition, with the
+	// breaker's lock released.
+	OnStateChange func(name string, from, to State)
+}
+
+func DefaultConfig() Config {
+	return Config{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// Breaker guards calls to one dependency. It is safe for concurrent use.
+type Breaker struct {
+	name string
+	cfg  Config
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// generation counts state changes; Done ignores calls allowed in an
+	// earlier one.
+	generation uint64
+	// trial is set while the half-open trial call is running.
+	trial bool
+	now   func() time.Time
+}
+
+func New(name string, cfg Config) *Breaker {
+	return &Breaker{name: name, cfg: withDefaults(cfg), now: time.Now}
+}
+
+func withDefaults(cfg Config) Config {
+	defaults := DefaultConfig()
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaults.FailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaults.OpenTimeout
+	}
+	return cfg
+}
+
+func (b *Breaker) Name() string {
+	if b == nil {
+		return ""
+	}
+	return b.name
+}
+
+// State returns the breaker's state. An open breaker whose OpenTimeout has
+// passed is reported half-open, as the next call would find it.
+func (b *Breaker) State() State {
+	if b == nil {
+		return StateClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && !b.now().Before(b.openedAt.Add(b.cfg.OpenTimeout)) {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// Allow returns ErrOpen when a call may not go ahead. A call it allows must
+// be followed by Done with the generation Allow returned and the call's
+// outcome.
+func (b *Breaker) Allow() (uint64, error) {
+	if b == nil {
+		return 0, nil
+	}
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case StateOpen:
+		if b.now().Before(b.openedAt.Add(b.cfg.OpenTimeout)) {
+			b.mu.Unlock()
+			return 0, ErrOpen
+		}
+		b.enter(StateHalfOpen)
+		b.trial = true
+	case StateHalfOpen:
+		if b.trial {
+			b.mu.Unlock()
+			return 0, ErrOpen
+		}
+		b.trial = true
+	}
+	to, generation := b.state, b.generation
+	b.mu.Unlock()
+	b.changed(from, to)
+	return generation, nil
+}
+
+// Done records the outcome of a call Allow let through. failed is true when
+// the call shows the dependency is unhealthy; errors it reports about the
+// request itself, such as a validation error, are not failures. Outcomes of
+// calls allowed before the breaker last changed state are ignored, so a
+// slow call started while it was closed cannot settle a half-open trial.
+func (b *Breaker) Done(generation uint64, failed bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+	from := b.state
+	switch {
+	case b.state == StateHalfOpen && failed:
+		b.open()
+	case b.state == StateHalfOpen:
+		b.enter(StateClosed)
+	case b.state == StateClosed && failed:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+	case b.state == StateClosed:
+		b.failures = 0
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.changed(from, to)
+}
+
+// enter moves the breaker to state and starts a new generation. The caller
+// holds b.mu.
+func (b *Breaker) enter(state State) {
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.trial = false
+}
+
+// open moves the breaker to StateOpen. The caller holds b.mu.
+func (b *Breaker) open() {
+	b.enter(StateOpen)
+	b.openedAt = b.now()
+}
+
+func (b *Breaker) changed(from, to State) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(b.name, from, to)
+	}
+}
+
+// Do calls fn unless the breaker is open, and returns its error or ErrOpen.
+// Every error counts as a failure except context.Canceled, which means the
+// caller gave up rather than the dependency failed. A panic in fn counts as
+// a failure and keeps unwinding.
+func (b *Breaker) Do(fn func() error) error {
+	generation, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	panicked := true
+	defer func() {
+		if panicked {
+			b.Done(generation, true)
+		}
+	}()
+	err = fn()
+	panicked = false
+	b.Done(generation, IsFailure(err))
+	return err
+}
+
+// IsFailure is Do's classification of err.
+func IsFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,158 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any) {}
+func (nopLogger) Warn(string, ...any) {}
+
+var errDown = errors.New("connection refused")
+
+func TestBreaker_OpensAndRecovers(t *testing.T) {
+	var transitions []string
+	b := New("redis", Config{FailureThreshold: 3, OpenTimeout: time.Minute, OnStateChange: func(name string, from, to State) {
+		transitions = append(transitions, from.String()+">"+to.String())
+	}})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	for range 2 {
+		_ = b.Do(func() error { return errDown })
+	}
+	_ = b.Do(func() error { return nil })
+	for range 2 {
+		_ = b.Do(func() error { return errDown })
+	}
+	if b.State() != StateClosed {
+		t.Fatal("expected a success to reset the consecutive failures")
+	}
+	_ = b.Do(func() error { return errDown })
+	if b.State() != StateOpen {
+		t.Fatalf("expected the third consecutive failure to open the breaker, got %s", b.State())
+	}
+
+	called := false
+	if err := b.Do(func() error { called = true; return nil }); !errors.Is(err, ErrOpen) || called {
+		t.Fatalf("expected an open breaker to reject calls, got %v (called %v)", err, called)
+	}
+
+	now = now.Add(time.Minute)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("expected the breaker to be half-open after its timeout, got %s", b.State())
+	}
+	trial, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected a trial call, got %v", err)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected one trial call at a time, got %v", err)
+	}
+	b.Done(trial, true)
+	if b.State() != StateOpen {
+		t.Fatalf("expected a failed trial to reopen the breaker, got %s", b.State())
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.Do(func() error { return nil }); err != nil {
+		t.Fatalf("expected the trial call to run, got %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("expected a successful trial to close the breaker, got %s", b.State())
+	}
+
+	want := "closed>open,open>half_open,half_open>open,open>half_open,half_open>closed"
+	if got := strings.Join(transitions, ","); got != want {
+		t.Fatalf("expected transitions %s, got %s", want, got)
+	}
+}
+
+func TestBreaker_IgnoresCallsFromEarlierStates(t *testing.T) {
+	b := New("redis", Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	slow, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected a closed breaker to allow calls, got %v", err)
+	}
+	_ = b.Do(func() error { return errDown })
+	now = now.Add(time.Minute)
+	trial, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected a trial call, got %v", err)
+	}
+
+	b.Done(slow, false)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("expected a call from before the breaker opened not to settle the trial, got %s", b.State())
+	}
+	b.Done(trial, false)
+	if b.State() != StateClosed {
+		t.Fatalf("expected a successful trial to close the breaker, got %s", b.State())
+	}
+}
+
+func TestBreaker_CountsPanicsAsFailures(t *testing.T) {
+	b := New("redis", Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	_ = b.Do(func() error { return errDown })
+	now = now.Add(time.Minute)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected the panic to reach the caller")
+			}
+		}()
+		_ = b.Do(func() error { panic("boom") })
+	}()
+	if b.State() != StateOpen {
+		t.Fatalf("expected a panicking trial to reopen the breaker, got %s", b.State())
+	}
+}
+
+func TestBreaker_IgnoresCancelledCalls(t *testing.T) {
+	b := New("mailer", Config{FailureThreshold: 1})
+	_ = b.Do(func() error { return context.Canceled })
+	if b.State() != StateClosed {
+		t.Fatal("expected a call the caller cancelled not to count as a failure")
+	}
+}
+
+func TestBreaker_NilAllowsCalls(t *testing.T) {
+	var b *Breaker
+	if err := b.Do(func() error { return errDown }); err != errDown {
+		t.Fatalf("expected a nil breaker to pass calls through, got %v", err)
+	}
+	if b.State() != StateClosed || (*Registry)(nil).Get("x") != nil {
+		t.Fatal("expected nil breakers and registries to be closed and empty")
+	}
+}
+
+func TestRegistry_CollectsStates(t *testing.T) {
+	r := NewRegistry(nopLogger{}, Config{FailureThreshold: 1})
+	if r.Get("redis_cache") != r.Get("redis_cache") {
+		t.Fatal("expected one breaker per name")
+	}
+	_ = r.Get("mailer").Do(func() error { return errDown })
+
+	expected := `
+# HELP circuit_breaker_state State of the circuit breaker: 0 closed, 1 half-open, 2 open.
+# TYPE circuit_breaker_state gauge
+circuit_breaker_state{name="mailer"} 2
+circuit_breaker_state{name="redis_cache"} 0
+`
+	if err := testutil.CollectAndCompare(r, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,90 @@
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var stateDesc = prometheus.NewDesc(
+	"circuit_breaker_state",
+	"State of the circuit breaker: 0 closed, 1 half-open, 2 open.",
+	[]string{"name"}, nil,
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+}
+
+// Registry creates breakers by name with one Config, and exports their
+// states as the circuit_breaker_state gauge. A nil *Registry returns nil
+// breakers, which allow every call.
+type Registry struct {
+	cfg Config
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewRegistry returns a registry whose breakers log each transition.
+// cfg.OnStateChange, when set, is called as well.
+func NewRegistry(logger Logger, cfg Config) *Registry {
+	cfg = withDefaults(cfg)
+	onStateChange := cfg.OnStateChange
+	openTimeout := cfg.OpenTimeout
+	cfg.OnStateChange = func(name string, from, to State) {
+		if to == StateOpen {
+			logger.Warn("Circuit breaker opened; failing calls fast", "breaker", name, "from", from.String(), "open_timeout", openTimeout)
+		} else {
+			logger.Info("Circuit breaker changed state", "breaker", name, "from", from.String(), "to", to.String())
+		}
+		if onStateChange != nil {
+			onStateChange(name, from, to)
+		}
+	}
+	return &Registry{cfg: cfg, breakers: make(map[string]*Breaker)}
+}
+
+// Get returns the breaker named name, creating it on first use.
+func (r *Registry) Get(name string) *Breaker {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[name]
+	if !ok {
+		b = New(name, r.cfg)
+		r.breakers[name] = b
+	}
+	return b
+}
+
+// Breakers returns the registry's breakers sorted by name.
+func (r *Registry) Breakers() []*Breaker {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	out := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		out = append(out, b)
+	}
+	r.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+// Describe implements prometheus.Collector.
+func (r *Registry) Describe(ch chan<- *prometheus.Desc) {
+	ch <- stateDesc
+}
+
+// Collect implements prometheus.Collector.
+func (r *Registry) Collect(ch chan<- prometheus.Metric) {
+	for _, b := range r.Breakers() {
+		ch <- prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, float64(b.State()), b.name)
+	}
+}
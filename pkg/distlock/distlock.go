@@ -0,0 +1,89 @@
+// Package distlock provides locks that let one instance of a multi-replica
+// deployment run a task, such as a scheduled sweep, while the others skip
+// it.
+package distlock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotAcquired is returned by Acquire when another holder has the lock.
+	ErrNotAcquired = errors.New("distlock: lock is held elsewhere")
+	// ErrLockLost is returned by Release and Extend when the lock expired,
+	// and may since have been taken by another holder.
+	ErrLockLost = errors.New("distlock: lock is no longer held")
+)
+
+type Locker interface {
+	// Acquire takes the lock at key for ttl. The lock expires after ttl
+	// unless it is extended, so a holder that dies frees it.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+}
+
+// Lock is held until it is released or expires. Its token tells it apart
+// from later holders of the same key, so it never releases or extends a
+// lock it no longer holds.
+type Lock interface {
+	Key() string
+	// Extend resets the lock's expiry to ttl from now.
+	Extend(ctx context.Context, ttl time.Duration) error
+	Release(ctx context.Context) error
+}
+
+// Run runs fn while holding the lock at key and reports whether it ran. It
+// returns false and no error when another holder has the lock. While fn
+// runs, the lock is extended every third of ttl; if it is lost anyway, fn's
+// context is cancelled with ErrLockLost as its cause.
+func Run(ctx context.Context, locker Locker, key string, ttl time.Duration, fn func(ctx context.Context) error) (bool, error) {
+	lock, err := locker.Acquire(ctx, key, ttl)
+	if errors.Is(err, ErrNotAcquired) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stop := make(chan struct{})
+	extended := make(chan struct{})
+	go func() {
+		defer close(extended)
+		ticker := time.NewTicker(max(ttl/3, time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// A failed extension is retried on the next tick; the lock
+				// only expires after ttl.
+				if err := lock.Extend(runCtx, ttl); errors.Is(err, ErrLockLost) {
+					cancel(ErrLockLost)
+					return
+				}
+			case <-stop:
+				return
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+
+	err = fn(runCtx)
+	close(stop)
+	<-extended
+	if rerr := lock.Release(context.WithoutCancel(ctx)); rerr != nil && err == nil {
+		err = rerr
+	}
+	return true, err
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,151 @@
+package distlock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestLocker returns a locker on a clock the test moves with advance.
+func newTestLocker() (*MemoryLocker, func(time.Duration)) {
+	l := NewMemoryLocker()
+	var mu sync.Mutex
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	return l, func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+}
+
+func TestMemoryLocker_Exclusive(t *testing.T) {
+	l, _ := newTestLocker()
+	ctx := context.Background()
+
+	lock, err := l.Acquire(ctx, "task", time.Minute)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := l.Acquire(ctx, "task", time.Minute); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("expected ErrNotAcquired while held, got %v", err)
+	}
+	if _, err := l.Acquire(ctx, "other", time.Minute); err != nil {
+		t.Fatalf("expected another key to be free, got %v", err)
+	}
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if err := lock.Release(ctx); !errors.Is(err, ErrLockLost) {
+		t.Fatalf("expected a second release to report ErrLockLost, got %v", err)
+	}
+	if _, err := l.Acquire(ctx, "task", time.Minute); err != nil {
+		t.Fatalf("expected the released lock to be free, got %v", err)
+	}
+}
+
+func TestMemoryLocker_ExpiredLockIsNotReleasedForItsNextHolder(t *testing.T) {
+	l, advance := newTestLocker()
+	ctx := context.Background()
+
+	first, _ := l.Acquire(ctx, "task", time.Minute)
+	advance(30 * time.Second)
+	if err := first.Extend(ctx, time.Minute); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	advance(45 * time.Second)
+	if _, err := l.Acquire(ctx, "task", time.Minute); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("expected the extended lock to be held, got %v", err)
+	}
+
+	advance(time.Minute)
+	second, err := l.Acquire(ctx, "task", time.Minute)
+	if err != nil {
+		t.Fatalf("expected the expired lock to be free, got %v", err)
+	}
+	if err := first.Extend(ctx, time.Minute); !errors.Is(err, ErrLockLost) {
+		t.Fatalf("expected the first holder's extend to report ErrLockLost, got %v", err)
+	}
+	if err := first.Release(ctx); !errors.Is(err, ErrLockLost) {
+		t.Fatalf("expected the first holder's release to report ErrLockLost, got %v", err)
+	}
+	if _, err := l.Acquire(ctx, "task", time.Minute); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("expected the second holder to keep the lock, got %v", err)
+	}
+	if err := second.Release(ctx); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	l := NewMemoryLocker()
+	ctx := context.Background()
+
+	ran, err := Run(ctx, l, "task", time.Minute, func(ctx context.Context) error {
+		if skipped, err := Run(ctx, l, "task", time.Minute, func(context.Context) error {
+			t.Error("expected the nested run to be skipped")
+			return nil
+		}); skipped || err != nil {
+			t.Errorf("expected the nested run to be skipped, got %v %v", skipped, err)
+		}
+		return nil
+	})
+	if !ran || err != nil {
+		t.Fatalf("expected the run, got %v %v", ran, err)
+	}
+	if _, err := l.Acquire(ctx, "task", time.Minute); err != nil {
+		t.Fatalf("expected Run to release the lock, got %v", err)
+	}
+
+	failure := errors.New("task failed")
+	if ran, err := Run(ctx, l, "failing", time.Minute, func(context.Context) error { return failure }); !ran || !errors.Is(err, failure) {
+		t.Fatalf("expected the task's error, got %v %v", ran, err)
+	}
+}
+
+func TestRun_ExtendsTheLock(t *testing.T) {
+	l := NewMemoryLocker()
+	ttl := 30 * time.Millisecond
+
+	ran, err := Run(context.Background(), l, "task", ttl, func(ctx context.Context) error {
+		select {
+		case <-time.After(4 * ttl):
+			return nil
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	})
+	if !ran || err != nil {
+		t.Fatalf("expected the lock to be held past its ttl, got %v %v", ran, err)
+	}
+}
+
+func TestRun_CancelsWhenTheLockIsLost(t *testing.T) {
+	l := NewMemoryLocker()
+	ttl := 30 * time.Millisecond
+
+	ran, err := Run(context.Background(), l, "task", ttl, func(ctx context.Context) error {
+		// Another holder takes over, as after a pause longer than ttl.
+		l.mu.Lock()
+		delete(l.locks, "task")
+		l.mu.Unlock()
+		if _, err := l.Acquire(ctx, "task", time.Minute); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+	if !ran || !errors.Is(err, ErrLockLost) {
+		t.Fatalf("expected the run to be cancelled with ErrLockLost, got %v %v", ran, err)
+	}
+}
@@ -0,0 +1,71 @@
+package distlock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLocker keeps locks in process memory. It only excludes holders in
+// one process, so it stands in for Redis on a single instance and in tests.
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]memoryEntry
+	now   func() time.Time
+}
+
+type memoryEntry struct {
+	token     string
+	expiresAt time.Time
+}
+
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: make(map[string]memoryEntry), now: time.Now}
+}
+
+func (l *MemoryLocker) Acquire(_ context.Context, key string, ttl time.Duration) (Lock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if held, ok := l.locks[key]; ok && now.Before(held.expiresAt) {
+		return nil, ErrNotAcquired
+	}
+	token := newToken()
+	l.locks[key] = memoryEntry{token: token, expiresAt: now.Add(ttl)}
+	return &memoryLock{locker: l, key: key, token: token}, nil
+}
+
+type memoryLock struct {
+	locker *MemoryLocker
+	key    string
+	token  string
+}
+
+func (l *memoryLock) Key() string {
+	return l.key
+}
+
+func (l *memoryLock) Extend(_ context.Context, ttl time.Duration) error {
+	m := l.locker
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	held, ok := m.locks[l.key]
+	if !ok || held.token != l.token || !now.Before(held.expiresAt) {
+		return ErrLockLost
+	}
+	m.locks[l.key] = memoryEntry{token: l.token, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (l *memoryLock) Release(_ context.Context) error {
+	m := l.locker
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	held, ok := m.locks[l.key]
+	if !ok || held.token != l.token || !m.now().Before(held.expiresAt) {
+		return ErrLockLost
+	}
+	delete(m.locks, l.key)
+	return nil
+}
@@ -0,0 +1,79 @@
+package distlock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const redisKeyPrefix = "lock:"
+
+// releaseScript and extendScript act only while the key still holds the
+// caller's token.
+var (
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+	extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+// RedisLocker keeps locks in Redis, shared by every instance, as keys
+// "lock:<key>" set with SET NX PX to a random token.
+type RedisLocker struct {
+	client *redis.Client
+}
+
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{client: client}
+}
+
+func (l *RedisLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	token := newToken()
+	ok, err := l.client.SetNX(ctx, redisKeyPrefix+key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotAcquired
+	}
+	return &redisLock{client: l.client, key: key, token: token}, nil
+}
+
+type redisLock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+func (l *redisLock) Key() string {
+	return l.key
+}
+
+func (l *redisLock) Extend(ctx context.Context, ttl time.Duration) error {
+	return l.run(ctx, extendScript, ttl.Milliseconds())
+}
+
+func (l *redisLock) Release(ctx context.Context) error {
+	return l.run(ctx, releaseScript)
+}
+
+func (l *redisLock) run(ctx context.Context, script *redis.Script, args ...any) error {
+	n, err := script.Run(ctx, l.client, []string{redisKeyPrefix + l.key}, append([]any{l.token}, args...)...).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	if n == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
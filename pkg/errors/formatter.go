@@ -27,6 +27,8 @@ func msgForTag(tag string) string {
 		return "Value must be exact length"
 	case "numeric":
 		return "Value must be numeric"
+	case "safeint":
+		return "Must be between -9007199254740991 and 9007199254740991"
 	case "alpha":
 		return "Value must contain only letters"
 	case "alphanum":
@@ -47,27 +49,46 @@ func msgForTag(tag string) string {
 		return "Value must be less than specified"
 	case "lte":
 		return "Value must be less than or equal to specified"
+	case "oneof":
+		return "Value is not one of the allowed values"
+	case "uuid":
+		return "Value must be a UUID"
+	case "gtfield":
+		return "Must be greater than"
+	case "gtefield":
+		return "Must be greater than or equal to"
+	case "ltfield":
+		return "Must be less than"
+	case "ltefield":
+		return "Must be less than or equal to"
 	default:
 		return "Invalid value"
 	}
 }
 
-func getJSONFieldName(structType reflect.Type, fieldName string) string {
+func getFieldName(structType reflect.Type, fieldName, tag string) string {
 	field, found := structType.FieldByName(fieldName)
 	if !found {
 		return fieldName
 	}
 
-	jsonTag := field.Tag.Get("json")
-	if jsonTag == "" {
+	nameTag := field.Tag.Get(tag)
+	if nameTag == "" {
 		return fieldName
 	}
 
-	parts := strings.Split(jsonTag, ",")
+	parts := strings.Split(nameTag, ",")
 	return parts[0]
 }
 
 func FormatValidationErrors(err error, model interface{}) []ValidationErrorResponse {
+	return FormatValidationErrorsByTag(err, model, "json")
+}
+
+// FormatValidationErrorsByTag is FormatValidationErrors for models whose
+// fields are named by another struct tag, such as "form" for query strings
+// or "uri" for path parameters.
+func FormatValidationErrorsByTag(err error, model interface{}, tag string) []ValidationErrorResponse {
 	var errorsList []ValidationErrorResponse
 
 	if err == nil {
@@ -98,7 +119,7 @@ func FormatValidationErrors(err error, model interface{}) []ValidationErrorRespo
 		for i, fieldError := range validationErrors {
 			jsonField := fieldError.Field()
 			if model != nil {
-				jsonField = getJSONFieldName(structType, fieldError.Field())
+				jsonField = getFieldName(structType, fieldError.Field(), tag)
 			}
 
 			message := msgForTag(fieldError.Tag())
@@ -119,6 +140,14 @@ func FormatValidationErrors(err error, model interface{}) []ValidationErrorRespo
 					message = fmt.Sprintf("Must be less than %s", fieldError.Param())
 				case "lte":
 					message = fmt.Sprintf("Must be less than or equal to %s", fieldError.Param())
+				case "oneof":
+					message = fmt.Sprintf("Must be one of: %s", strings.Join(strings.Fields(fieldError.Param()), ", "))
+				case "gtfield", "gtefield", "ltfield", "ltefield":
+					other := fieldError.Param()
+					if model != nil {
+						other = getFieldName(structType, other, tag)
+					}
+					message = fmt.Sprintf("%s %s", msgForTag(fieldError.Tag()), other)
 				}
 			}
 
@@ -19,6 +19,7 @@ const (
 	StatusConflict            = 409
 	StatusTooManyRequests     = 429
 	StatusInternalServerError = 500
+	StatusServiceUnavailable  = 503
 )
 
 const (
@@ -78,6 +79,13 @@ func NewConflictError(message string, err error) *AppError {
 	return NewAppError(ErrorTypeConflict, message, err)
 }
 
+func NewUnauthorizedError(message string, err error) *AppError {
+	return NewAppError(ErrorTypeUnauthorized, message, err)
+}
+
+func NewForbiddenError(message string, err error) *AppError {
+	return NewAppError(ErrorTypeForbidden, message, err)
+}
 
 func GetErrorType(err error) string {
 	if err == nil {
@@ -120,10 +128,18 @@ func DeduceErrorTypeFromErrorString(err error) string {
 	return ErrorTypeUnknown
 }
 
+// IsDuplicateKeyError guesses from the message whether err is a duplicate
+// key error, and also matches any error mentioning a conflict.
+//
+// Deprecated: use IsUniqueViolation, which matches driver error codes and so
+// recognises localized and reworded messages.
 func IsDuplicateKeyError(err error) bool {
 	if err == nil {
 		return false
 	}
+	if IsUniqueViolation(err) {
+		return true
+	}
 	errMsg := err.Error()
 	return DeduceErrorTypeFromErrorString(err) == ErrorTypeConflict ||
 		strings.Contains(strings.ToLower(errMsg), strings.ToLower("duplicate")) ||
@@ -0,0 +1,87 @@
+package errors
+
+import (
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// postgresUniqueViolation is the SQLSTATE of unique_violation.
+	postgresUniqueViolation = "23505"
+	// mysqlDuplicateEntry is ER_DUP_ENTRY.
+	mysqlDuplicateEntry = 1062
+	// SQLite extended result codes SQLITE_CONSTRAINT_PRIMARYKEY and
+	// SQLITE_CONSTRAINT_UNIQUE.
+	sqlitePrimaryKeyViolation = 1555
+	sqliteUniqueViolation     = 2067
+)
+
+// IsUniqueViolation reports whether err, or an error it wraps, is a unique or
+// primary key violation: gorm.ErrDuplicatedKey, PostgreSQL SQLSTATE 23505
+// (pgx and lib/pq), MySQL error 1062 or a SQLite UNIQUE or PRIMARY KEY
+// constraint. Errors are matched by code, so localized and reworded
+// messages are recognised.
+func IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) && state.SQLState() == postgresUniqueViolation {
+		return true
+	}
+	return anyInChain(err, isDriverUniqueViolation)
+}
+
+// isDriverUniqueViolation recognises the error types of the MySQL
+// (go-sql-driver/mysql) and SQLite (mattn/go-sqlite3) drivers by their
+// exported code fields, so this package does not link either driver.
+func isDriverUniqueViolation(err error) bool {
+	v := reflect.ValueOf(err)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	switch v.Type().Name() {
+	case "MySQLError":
+		number := v.FieldByName("Number")
+		return number.IsValid() && number.CanUint() && number.Uint() == mysqlDuplicateEntry
+	case "Error":
+		code := v.FieldByName("ExtendedCode")
+		if !code.IsValid() || !code.CanInt() {
+			return false
+		}
+		return code.Int() == sqliteUniqueViolation || code.Int() == sqlitePrimaryKeyViolation
+	}
+	return false
+}
+
+// anyInChain reports whether match holds for err or any error it wraps,
+// following both Unwrap() error and Unwrap() []error.
+func anyInChain(err error, match func(error) bool) bool {
+	for err != nil {
+		if match(err) {
+			return true
+		}
+		switch wrapped := err.(type) {
+		case interface{ Unwrap() error }:
+			err = wrapped.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, e := range wrapped.Unwrap() {
+				if anyInChain(e, match) {
+					return true
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+	return false
+}
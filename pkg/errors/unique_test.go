@@ -0,0 +1,71 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// MySQLError mirrors the go-sql-driver/mysql error type.
+type MySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *MySQLError) Error() string { return e.Message }
+
+func sqliteErrors(t *testing.T) (unique, notNull error) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if err := db.Exec("INSERT INTO items (id, name) VALUES (1, 'a')").Error; err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	unique = db.Exec("INSERT INTO items (id, name) VALUES (2, 'a')").Error
+	notNull = db.Exec("INSERT INTO items (id, name) VALUES (3, NULL)").Error
+	if unique == nil || notNull == nil {
+		t.Fatalf("expected constraint errors, got %v and %v", unique, notNull)
+	}
+	return unique, notNull
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	sqliteUnique, sqliteNotNull := sqliteErrors(t)
+	// A localized server message: only the code identifies it.
+	pgUnique := &pgconn.PgError{Code: "23505", Message: "doppelter Schlüsselwert verletzt Unique-Constraint"}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"gorm translated", gorm.ErrDuplicatedKey, true},
+		{"postgres", pgUnique, true},
+		{"postgres wrapped", fmt.Errorf("insert account: %w", pgUnique), true},
+		{"postgres joined", errors.Join(errors.New("rollback failed"), pgUnique), true},
+		{"postgres foreign key", &pgconn.PgError{Code: "23503", Message: "duplicate"}, false},
+		{"mysql", &MySQLError{Number: 1062, Message: "Duplicate entry 'a' for key 'name'"}, true},
+		{"mysql other", &MySQLError{Number: 1452, Message: "Cannot add or update a child row"}, false},
+		{"sqlite", sqliteUnique, true},
+		{"sqlite wrapped", fmt.Errorf("create: %w", sqliteUnique), true},
+		{"sqlite not null", sqliteNotNull, false},
+		{"message only", errors.New("duplicate key value violates unique constraint"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUniqueViolation(tt.err); got != tt.want {
+				t.Fatalf("IsUniqueViolation(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
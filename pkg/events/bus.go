@@ -0,0 +1,181 @@
+// Package events is an in-process publish/subscribe bus for domain events.
+// Delivery is asynchronous and at-least-once from the subscriber's point of
+// view: publishers may emit the same event (same ID) again, e.g. when an
+// idempotent request is replayed, so subscribers should deduplicate by ID.
+package events
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultQueueSize bounds the events buffered per subscriber. When a slow
+// subscriber's queue is full, further events for it are dropped rather than
+// blocking the publisher.
+const defaultQueueSize = 1024
+
+// Event is the envelope delivered to subscribers. Data holds the
+// type-specific payload.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// Handler receives events. Handlers of one subscription run one at a time,
+// in publish order.
+type Handler func(ctx context.Context, event Event)
+
+// Publisher is the side of the bus used by domain services.
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type subscription struct {
+	pattern string
+	handler Handler
+	queue   chan Event
+	done    chan struct{}
+}
+
+// Bus fans events out to subscribers. Each subscription has its own queue
+// and goroutine, so a slow subscriber does not delay the others.
+type Bus struct {
+	logger Logger
+
+	mu     sync.RWMutex
+	subs   map[*subscription]struct{}
+	closed bool
+	now    func() time.Time
+}
+
+func NewBus(logger Logger) *Bus {
+	return &Bus{logger: logger, subs: make(map[*subscription]struct{}), now: time.Now}
+}
+
+// Subscribe registers handler for events whose type matches pattern: an exact
+// type ("account.created"), a prefix ending in ".*" ("account.*"), or "*" for
+// every event. The returned function removes the subscription after its
+// queued events have been handled.
+func (b *Bus) Subscribe(pattern string, handler Handler) (unsubscribe func()) {
+	sub := &subscription{
+		pattern: pattern,
+		handler: handler,
+		queue:   make(chan Event, defaultQueueSize),
+		done:    make(chan struct{}),
+	}
+	go b.run(sub)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(sub.queue)
+		return func() {}
+	}
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			if _, ok := b.subs[sub]; ok {
+				delete(b.subs, sub)
+				close(sub.queue)
+			}
+			b.mu.Unlock()
+			<-sub.done
+		})
+	}
+}
+
+// Publish queues event for every matching subscriber. It never blocks on
+// subscribers. A missing ID or OccurredAt is filled in.
+func (b *Bus) Publish(_ context.Context, event Event) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = b.now().UTC()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		b.logger.Warn("Event published after bus shutdown; dropping", "type", event.Type, "id", event.ID)
+		return
+	}
+	for sub := range b.subs {
+		if !Matches(sub.pattern, event.Type) {
+			continue
+		}
+		select {
+		case sub.queue <- event:
+		default:
+			b.logger.Error("Event subscriber queue full; dropping event", "pattern", sub.pattern, "type", event.Type, "id", event.ID)
+		}
+	}
+}
+
+// Close stops accepting events and waits until subscribers have handled the
+// queued ones, or ctx is done.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	subs := make([]*subscription, 0, len(b.subs))
+	for sub := range b.subs {
+		close(sub.queue)
+		subs = append(subs, sub)
+	}
+	clear(b.subs)
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (b *Bus) run(sub *subscription) {
+	defer close(sub.done)
+	for event := range sub.queue {
+		b.deliver(sub, event)
+	}
+}
+
+func (b *Bus) deliver(sub *subscription, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("Event handler panicked", "pattern", sub.pattern, "type", event.Type, "id", event.ID, "panic", r)
+		}
+	}()
+	sub.handler(context.Background(), event)
+}
+
+// Matches reports whether eventType matches a subscription pattern.
+func Matches(pattern, eventType string) bool {
+	if pattern == "*" || pattern == eventType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "*")
+	return ok && strings.HasSuffix(prefix, ".") && strings.HasPrefix(eventType, prefix)
+}
@@ -0,0 +1,154 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) handle(_ context.Context, e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]string, 0, len(r.events))
+	for _, e := range r.events {
+		out = append(out, e.Type)
+	}
+	return out
+}
+
+func TestBus_DeliversMatchingEventsInOrder(t *testing.T) {
+	bus := NewBus(nopLogger{})
+	var all, accounts, created recorder
+	bus.Subscribe("*", all.handle)
+	bus.Subscribe("account.*", accounts.handle)
+	bus.Subscribe("account.created", created.handle)
+
+	ctx := context.Background()
+	bus.Publish(ctx, Event{Type: "account.created"})
+	bus.Publish(ctx, Event{Type: "transaction.posted"})
+	bus.Publish(ctx, Event{Type: "account.closed"})
+	bus.Publish(ctx, Event{Type: "accounts.other"})
+
+	if err := bus.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := all.types(); len(got) != 4 || got[1] != "transaction.posted" {
+		t.Fatalf("expected every event in order, got %v", got)
+	}
+	if got := accounts.types(); len(got) != 2 || got[0] != "account.created" || got[1] != "account.closed" {
+		t.Fatalf("expected account events, got %v", got)
+	}
+	if got := created.types(); len(got) != 1 {
+		t.Fatalf("expected one account.created, got %v", got)
+	}
+	if all.events[0].ID == "" || all.events[0].OccurredAt.IsZero() {
+		t.Fatalf("expected ID and timestamp to be filled in, got %+v", all.events[0])
+	}
+}
+
+func TestBus_UnsubscribeAndPanics(t *testing.T) {
+	bus := NewBus(nopLogger{})
+	var rec recorder
+	bus.Subscribe("*", func(context.Context, Event) { panic("boom") })
+	unsubscribe := bus.Subscribe("*", rec.handle)
+
+	bus.Publish(context.Background(), Event{Type: "a.b"})
+	unsubscribe()
+	unsubscribe()
+	bus.Publish(context.Background(), Event{Type: "a.c"})
+
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := rec.types(); len(got) != 1 || got[0] != "a.b" {
+		t.Fatalf("expected only the event before unsubscribe, got %v", got)
+	}
+
+	// Publishing after Close is dropped rather than panicking.
+	bus.Publish(context.Background(), Event{Type: "a.d"})
+}
+
+func TestBus_FullQueueDoesNotBlockPublisher(t *testing.T) {
+	bus := NewBus(nopLogger{})
+	release := make(chan struct{})
+	var handled atomic.Int32
+	bus.Subscribe("*", func(context.Context, Event) {
+		<-release
+		handled.Add(1)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		for range defaultQueueSize + 10 {
+			bus.Publish(context.Background(), Event{Type: "a.b"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+
+	close(release)
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := handled.Load(); got > defaultQueueSize+1 {
+		t.Fatalf("expected overflow to be dropped, handled %d", got)
+	}
+}
+
+func TestWebhook_SignsAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	var got Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign([]byte("secret"), body) {
+			t.Errorf("bad signature %q", r.Header.Get(SignatureHeader))
+		}
+		if r.Header.Get("X-Event-ID") != "evt-1" {
+			t.Errorf("missing event ID header")
+		}
+		_ = json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	handler := Webhook(WebhookConfig{URL: srv.URL, Secret: "secret"}, nopLogger{})
+	handler(context.Background(), Event{ID: "evt-1", Type: "account.created", Data: map[string]string{"account_id": "a1"}})
+
+	if calls.Load() != 2 {
+		t.Fatalf("expected a retry, got %d calls", calls.Load())
+	}
+	if got.Type != "account.created" {
+		t.Fatalf("unexpected delivered event %+v", got)
+	}
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const SignatureHeader = "X-Event-Signature"
+
+type WebhookConfig struct {
+	URL string
+	// Secret signs bodies (X-Event-Signature) when set.
+	Secret   string
+	Timeout  time.Duration
+	Attempts int
+}
+
+// Webhook returns a handler that POSTs each event as JSON to cfg.URL,
+// retrying with linear backoff. Receivers deduplicate by the X-Event-ID header.
+func Webhook(cfg WebhookConfig, logger Logger) Handler {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = 3
+	}
+	w := &webhookSender{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		retryDelay: time.Second,
+	}
+	return func(ctx context.Context, event Event) {
+		if err := w.send(ctx, event); err != nil {
+			logger.Error("Failed to deliver event webhook", "type", event.Type, "id", event.ID, "error", err)
+		}
+	}
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by
+// the hex HMAC-SHA256 of body under secret.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type webhookSender struct {
+	cfg        WebhookConfig
+	client     *http.Client
+	retryDelay time.Duration
+}
+
+func (w *webhookSender) send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= w.cfg.Attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(time.Duration(attempt-1) * w.retryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if lastErr = w.post(ctx, event, body); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func (w *webhookSender) post(ctx context.Context, event Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID)
+	req.Header.Set("X-Event-Type", event.Type)
+	if w.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign([]byte(w.cfg.Secret), body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
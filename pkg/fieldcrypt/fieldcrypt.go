@@ -0,0 +1,206 @@
+// Package fieldcrypt encrypts individual free-text columns with AES-256-GCM
+// and derives HMAC blind indexes so encrypted values can still be searched
+// by exact word.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/akeren/go-api-foundry/pkg/utils"
+)
+
+// prefix marks encrypted values: enc:v1:<key id>:<base64(nonce|ciphertext)>.
+// Values without it are treated as legacy plaintext.
+const prefix = "enc:v1:"
+
+const (
+	keySize         = 32
+	blindIndexBytes = 16
+	minTokenLength  = 2
+)
+
+var ErrUnknownKey = errors.New("fieldcrypt: value encrypted with an unknown key")
+
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Cipher encrypts with the first (active) key and decrypts with any known key,
+// which allows keys to be rotated without rewriting existing rows at once.
+// A nil *Cipher stores values as plaintext.
+type Cipher struct {
+	activeID string
+	aeads    map[string]cipher.AEAD
+	indexKey []byte
+}
+
+func New(keys []Key, indexKey []byte) (*Cipher, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("fieldcrypt: at least one key is required")
+	}
+	if len(indexKey) < keySize {
+		return nil, fmt.Errorf("fieldcrypt: blind index key must be at least %d bytes", keySize)
+	}
+
+	c := &Cipher{
+		activeID: keys[0].ID,
+		aeads:    make(map[string]cipher.AEAD, len(keys)),
+		indexKey: indexKey,
+	}
+	for _, k := range keys {
+		if k.ID == "" || strings.Contains(k.ID, ":") {
+			return nil, fmt.Errorf("fieldcrypt: invalid key id %q", k.ID)
+		}
+		if len(k.Secret) != keySize {
+			return nil, fmt.Errorf("fieldcrypt: key %q must be %d bytes", k.ID, keySize)
+		}
+		block, err := aes.NewCipher(k.Secret)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		c.aeads[k.ID] = aead
+	}
+	return c, nil
+}
+
+// ParseKeys parses "id:base64key,id:base64key"; the first key is active.
+func ParseKeys(spec string) ([]Key, error) {
+	var keys []Key
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("fieldcrypt: key %q must be id:base64", part)
+		}
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("fieldcrypt: key %q is not valid base64", id)
+		}
+		keys = append(keys, Key{ID: id, Secret: secret})
+	}
+	return keys, nil
+}
+
+// FromEnv builds a Cipher from FIELD_ENCRYPTION_KEYS and FIELD_BLIND_INDEX_KEY.
+// It returns nil without error when encryption is not configured.
+func FromEnv() (*Cipher, error) {
+	spec := utils.GetEnvTrimmed("FIELD_ENCRYPTION_KEYS")
+	if spec == "" {
+		return nil, nil
+	}
+	keys, err := ParseKeys(spec)
+	if err != nil {
+		return nil, err
+	}
+	indexKey, err := base64.StdEncoding.DecodeString(utils.GetEnvTrimmed("FIELD_BLIND_INDEX_KEY"))
+	if err != nil {
+		return nil, errors.New("fieldcrypt: FIELD_BLIND_INDEX_KEY is not valid base64")
+	}
+	return New(keys, indexKey)
+}
+
+// Encrypt seals plaintext. aad binds the ciphertext to its column (e.g.
+// "transactions.description") so values cannot be swapped between fields.
+func (c *Cipher) Encrypt(plaintext, aad string) (string, error) {
+	if c == nil || plaintext == "" {
+		return plaintext, nil
+	}
+
+	aead := c.aeads[c.activeID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return prefix + c.activeID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *Cipher) Decrypt(value, aad string) (string, error) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return value, nil
+	}
+	if c == nil {
+		return "", ErrUnknownKey
+	}
+
+	keyID, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("fieldcrypt: malformed encrypted value")
+	}
+	aead, ok := c.aeads[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("fieldcrypt: malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("fieldcrypt: decrypt: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Tokenize lowercases text and splits it into unique words.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]struct{}, len(words))
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if len([]rune(w)) < minTokenLength {
+			continue
+		}
+		if _, dup := seen[w]; dup {
+			continue
+		}
+		seen[w] = struct{}{}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// BlindIndex returns a keyed hash of a normalized token. The same token always
+// yields the same index, so equality search works without decrypting.
+func (c *Cipher) BlindIndex(token, aad string) string {
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(aad))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.ToLower(token)))
+	return hex.EncodeToString(mac.Sum(nil)[:blindIndexBytes])
+}
+
+// SearchTokens returns the blind indexes of every word in text.
+func (c *Cipher) SearchTokens(text, aad string) []string {
+	if c == nil {
+		return nil
+	}
+	tokens := Tokenize(text)
+	indexes := make([]string, len(tokens))
+	for i, t := range tokens {
+		indexes[i] = c.BlindIndex(t, aad)
+	}
+	return indexes
+}
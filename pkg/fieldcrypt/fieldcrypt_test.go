@@ -0,0 +1,104 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testCipher(t *testing.T, ids ...string) *Cipher {
+	t.Helper()
+	var keys []Key
+	for i, id := range ids {
+		keys = append(keys, Key{ID: id, Secret: bytes.Repeat([]byte{byte(i + 1)}, keySize)})
+	}
+	c, err := New(keys, bytes.Repeat([]byte{9}, keySize))
+	if err != nil {
+		t.Fatalf("new cipher: %v", err)
+	}
+	return c
+}
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	c := testCipher(t, "k1")
+
+	enc, err := c.Encrypt("rent for March", "transactions.description")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if !strings.HasPrefix(enc, "enc:v1:k1:") || strings.Contains(enc, "rent") {
+		t.Fatalf("unexpected ciphertext %q", enc)
+	}
+
+	dec, err := c.Decrypt(enc, "transactions.description")
+	if err != nil || dec != "rent for March" {
+		t.Fatalf("decrypt: %q err=%v", dec, err)
+	}
+
+	if _, err := c.Decrypt(enc, "accounts.name"); err == nil {
+		t.Fatalf("expected decryption with a different aad to fail")
+	}
+}
+
+func TestDecrypt_PlaintextPassesThrough(t *testing.T) {
+	c := testCipher(t, "k1")
+	if v, err := c.Decrypt("legacy note", "x"); err != nil || v != "legacy note" {
+		t.Fatalf("expected legacy plaintext, got %q err=%v", v, err)
+	}
+
+	var disabled *Cipher
+	if v, _ := disabled.Encrypt("note", "x"); v != "note" {
+		t.Fatalf("nil cipher must store plaintext")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	old := testCipher(t, "k1")
+	enc, _ := old.Encrypt("payroll", "x")
+
+	rotated, err := New([]Key{
+		{ID: "k2", Secret: bytes.Repeat([]byte{7}, keySize)},
+		{ID: "k1", Secret: bytes.Repeat([]byte{1}, keySize)},
+	}, bytes.Repeat([]byte{9}, keySize))
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if dec, err := rotated.Decrypt(enc, "x"); err != nil || dec != "payroll" {
+		t.Fatalf("expected old values to stay readable after rotation, got %q err=%v", dec, err)
+	}
+	if enc2, _ := rotated.Encrypt("payroll", "x"); !strings.HasPrefix(enc2, "enc:v1:k2:") {
+		t.Fatalf("expected new values to use the active key, got %q", enc2)
+	}
+
+	if _, err := testCipher(t, "k3").Decrypt(enc, "x"); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestSearchTokens_AreStableAndKeyed(t *testing.T) {
+	c := testCipher(t, "k1")
+
+	a := c.SearchTokens("Rent, rent & March!", "x")
+	if len(a) != 2 {
+		t.Fatalf("expected 2 unique tokens, got %v", a)
+	}
+	if c.BlindIndex("RENT", "x") != a[0] {
+		t.Fatalf("blind index must be case-insensitive")
+	}
+	if c.BlindIndex("rent", "y") == a[0] {
+		t.Fatalf("blind index must depend on aad")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, keySize))
+	keys, err := ParseKeys("k2:" + secret + ", k1:" + secret)
+	if err != nil || len(keys) != 2 || keys[0].ID != "k2" {
+		t.Fatalf("unexpected keys %v err=%v", keys, err)
+	}
+	if _, err := ParseKeys("nokey"); err == nil {
+		t.Fatalf("expected error for missing id")
+	}
+}
@@ -0,0 +1,92 @@
+package fieldmask
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+type Audit struct {
+	By string `json:"by" access:"admin"`
+}
+
+type entry struct {
+	Amount       int64  `json:"amount"`
+	BalanceAfter int64  `json:"balance_after" access:"owner,admin"`
+	Note         string `json:"note,omitempty" access:"admin;mask"`
+}
+
+type account struct {
+	Audit
+	ID        string           `json:"id"`
+	Balance   int64            `json:"balance" access:"owner,admin"`
+	Entries   []entry          `json:"entries"`
+	ByID      map[string]entry `json:"by_id,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	Hidden    string           `json:"-" access:"admin"`
+}
+
+type plain struct {
+	ID string `json:"id"`
+}
+
+func viewer(scopes ...string) Allowed {
+	return func(s string) bool { return slices.Contains(scopes, s) }
+}
+
+func encode(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(b)
+}
+
+func TestApply_UnrestrictedTypesPassThrough(t *testing.T) {
+	in := &plain{ID: "a"}
+	out, err := Apply(in, viewer())
+	if err != nil || out != any(in) {
+		t.Fatalf("expected the value unchanged, got %#v, %v", out, err)
+	}
+}
+
+func TestApply_OmitsAndMasksByScope(t *testing.T) {
+	acc := account{
+		Audit:   Audit{By: "ops"},
+		ID:      "acc-1",
+		Balance: 9007199254740993,
+		Entries: []entry{{Amount: 5, BalanceAfter: 10, Note: "refund"}},
+		ByID:    map[string]entry{"e1": {Amount: 5, BalanceAfter: 10}},
+	}
+
+	anonymous, err := Apply([]account{acc}, viewer())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	got := encode(t, anonymous)
+	want := `[{"by_id":{"e1":{"amount":5}},"created_at":"0001-01-01T00:00:00Z","entries":[{"amount":5,"note":"****"}],"id":"acc-1"}]`
+	if got != want {
+		t.Fatalf("anonymous view:\n got %s\nwant %s", got, want)
+	}
+
+	owner, _ := Apply(&acc, viewer("owner"))
+	if got := encode(t, owner); got != `{"balance":9007199254740993,"by_id":{"e1":{"amount":5,"balance_after":10}},"created_at":"0001-01-01T00:00:00Z","entries":[{"amount":5,"balance_after":10,"note":"****"}],"id":"acc-1"}` {
+		t.Fatalf("owner view keeps balances with full precision, got %s", got)
+	}
+
+	admin, _ := Apply(acc, viewer("admin"))
+	if got := encode(t, admin); got != `{"balance":9007199254740993,"by":"ops","by_id":{"e1":{"amount":5,"balance_after":10}},"created_at":"0001-01-01T00:00:00Z","entries":[{"amount":5,"balance_after":10,"note":"refund"}],"id":"acc-1"}` {
+		t.Fatalf("admin sees every field, got %s", got)
+	}
+}
+
+func TestWithScopes_Accumulates(t *testing.T) {
+	ctx := WithScopes(context.Background(), "owner")
+	ctx = WithScopes(ctx, "auditor")
+	if got := Scopes(ctx); !slices.Equal(got, []string{"owner", "auditor"}) {
+		t.Fatalf("unexpected scopes %v", got)
+	}
+}
@@ -0,0 +1,124 @@
+// Package fx supplies foreign exchange rates. A Provider fetches rates from a
+// source such as a fixed list or a rates API, and an Updater copies them into
+// the fx_rates table, where GormStore looks them up.
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ErrRateNotFound is returned when no rate, direct or derived, converts
+// between two currencies.
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// Rate is the price of one unit of Base in units of Quote. Value is a
+// decimal string so it round-trips without float error.
+type Rate struct {
+	Base  string
+	Quote string
+	Value string
+	AsOf  time.Time
+}
+
+// Provider fetches the rates a source currently offers.
+type Provider interface {
+	Rates(ctx context.Context) ([]Rate, error)
+}
+
+// valueDecimals is the precision of rates derived by inverting or crossing
+// stored rates.
+const valueDecimals = 12
+
+func parseValue(value string) (*big.Rat, error) {
+	v, ok := new(big.Rat).SetString(value)
+	if !ok || v.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate %q", value)
+	}
+	return v, nil
+}
+
+func formatValue(v *big.Rat) string {
+	s := v.FloatString(valueDecimals)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Invert returns the rate from r.Quote to r.Base.
+func (r Rate) Invert() (Rate, error) {
+	v, err := parseValue(r.Value)
+	if err != nil {
+		return Rate{}, err
+	}
+	return Rate{Base: r.Quote, Quote: r.Base, Value: formatValue(v.Inv(v)), AsOf: r.AsOf}, nil
+}
+
+// cross chains a rate from X to P with a rate from P to Y into one from X to
+// Y, as old as the older of the two.
+func cross(a, b Rate) (Rate, error) {
+	va, err := parseValue(a.Value)
+	if err != nil {
+		return Rate{}, err
+	}
+	vb, err := parseValue(b.Value)
+	if err != nil {
+		return Rate{}, err
+	}
+	asOf := a.AsOf
+	if b.AsOf.Before(asOf) {
+		asOf = b.AsOf
+	}
+	return Rate{Base: a.Base, Quote: b.Quote, Value: formatValue(va.Mul(va, vb)), AsOf: asOf}, nil
+}
+
+// Convert converts an amount in minor units of r.Base into minor units of
+// r.Quote, rounding halves away from zero.
+func Convert(amount int64, r Rate) (int64, error) {
+	v, err := parseValue(r.Value)
+	if err != nil {
+		return 0, err
+	}
+
+	num := new(big.Int).Mul(big.NewInt(amount), v.Num())
+	den := new(big.Int).Set(v.Denom())
+	scale := big.NewInt(10)
+	if shift := MinorUnits(r.Quote) - MinorUnits(r.Base); shift > 0 {
+		num.Mul(num, scale.Exp(scale, big.NewInt(int64(shift)), nil))
+	} else if shift < 0 {
+		den.Mul(den, scale.Exp(scale, big.NewInt(int64(-shift)), nil))
+	}
+
+	quo, rem := num.QuoRem(num, den, new(big.Int))
+	if rem.Abs(rem).Lsh(rem, 1).Cmp(den) >= 0 {
+		if amount < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows: %d %s at %s", amount, r.Base, r.Value)
+	}
+	return quo.Int64(), nil
+}
+
+// minorUnits lists the ISO 4217 currencies whose minor unit is not a cent.
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// MinorUnits returns how many decimal places a currency's minor unit has:
+// 2 for most currencies, 0 for JPY, 3 for KWD.
+func MinorUnits(currency string) int {
+	if n, ok := minorUnits[currency]; ok {
+		return n
+	}
+	return 2
+}
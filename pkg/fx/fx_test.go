@@ -0,0 +1,161 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+func TestConvert(t *testing.T) {
+	for _, tt := range []struct {
+		amount int64
+		rate   Rate
+		want   int64
+	}{
+		{10000, Rate{Base: "EUR", Quote: "USD", Value: "1.08"}, 10800},
+		{333, Rate{Base: "USD", Quote: "EUR", Value: "0.925925925926"}, 308},
+		{5, Rate{Base: "USD", Quote: "EUR", Value: "0.5"}, 3},
+		{12345, Rate{Base: "USD", Quote: "JPY", Value: "149.5"}, 18456},
+		{100, Rate{Base: "JPY", Quote: "USD", Value: "0.0067"}, 67},
+		{1000, Rate{Base: "USD", Quote: "KWD", Value: "0.307"}, 3070},
+	} {
+		got, err := Convert(tt.amount, tt.rate)
+		if err != nil || got != tt.want {
+			t.Errorf("Convert(%d %s->%s at %s) = %d, %v; want %d", tt.amount, tt.rate.Base, tt.rate.Quote, tt.rate.Value, got, err, tt.want)
+		}
+	}
+
+	if _, err := Convert(1<<62, Rate{Base: "USD", Quote: "JPY", Value: "1000"}); err == nil {
+		t.Error("expected an overflow error")
+	}
+}
+
+func TestParseRates(t *testing.T) {
+	rates, err := ParseRates(" eur/usd=1.08, GBP/USD=1.27 ,")
+	if err != nil {
+		t.Fatalf("ParseRates: %v", err)
+	}
+	if len(rates) != 2 || rates[0] != (Rate{Base: "EUR", Quote: "USD", Value: "1.08"}) {
+		t.Fatalf("unexpected rates: %+v", rates)
+	}
+
+	for _, invalid := range []string{"EUR=1.08", "EUR/USD", "EUR/USD=0", "EUR/USD=abc", "EUR/EUR=1", "EU/USD=1"} {
+		if _, err := ParseRates(invalid); err == nil {
+			t.Errorf("ParseRates(%q): expected an error", invalid)
+		}
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"base":"USD","timestamp":1760000000,"rates":{"EUR":0.9259,"USD":1}}`))
+	}))
+	defer srv.Close()
+
+	rates, err := NewHTTPProvider(srv.URL, time.Second).Rates(context.Background())
+	if err != nil {
+		t.Fatalf("Rates: %v", err)
+	}
+	want := Rate{Base: "USD", Quote: "EUR", Value: "0.9259", AsOf: time.Unix(1760000000, 0).UTC()}
+	if len(rates) != 1 || rates[0] != want {
+		t.Fatalf("expected %+v, got %+v", want, rates)
+	}
+}
+
+func newTestStore(t *testing.T) *GormStore {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.FXRate{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewGormStore(db)
+}
+
+func TestGormStore_Rate(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	old := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	recent := time.Now().UTC().Truncate(time.Second)
+
+	if err := store.Save(ctx, []Rate{
+		{Base: "EUR", Quote: "USD", Value: "1.1", AsOf: old},
+		{Base: "GBP", Quote: "USD", Value: "1.3", AsOf: old},
+	}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	// Saving a pair again replaces it.
+	if err := store.Save(ctx, []Rate{{Base: "EUR", Quote: "USD", Value: "1.08", AsOf: recent}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	for _, tt := range []struct {
+		base, quote, value string
+		asOf               time.Time
+	}{
+		{"EUR", "USD", "1.08", recent},
+		{"USD", "EUR", "0.925925925926", recent},
+		{"EUR", "GBP", "0.830769230769", old},
+		{"USD", "USD", "1", time.Time{}},
+	} {
+		r, err := store.Rate(ctx, tt.base, tt.quote)
+		if err != nil {
+			t.Fatalf("Rate(%s, %s): %v", tt.base, tt.quote, err)
+		}
+		if r.Value != tt.value || (!tt.asOf.IsZero() && !r.AsOf.Equal(tt.asOf)) {
+			t.Errorf("Rate(%s, %s) = %s as of %v; want %s as of %v", tt.base, tt.quote, r.Value, r.AsOf, tt.value, tt.asOf)
+		}
+	}
+
+	if _, err := store.Rate(ctx, "EUR", "JPY"); !errors.Is(err, ErrRateNotFound) {
+		t.Fatalf("expected ErrRateNotFound, got %v", err)
+	}
+}
+
+func TestUpdater_SeedsStore(t *testing.T) {
+	store := newTestStore(t)
+	provider, err := ParseRates("EUR/USD=1.08")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := NewUpdater(nopLogger{}, provider, store, UpdaterConfig{Interval: time.Hour})
+	u.Start()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if r, err := store.Rate(context.Background(), "EUR", "USD"); err == nil {
+			if r.Value != "1.08" {
+				t.Fatalf("unexpected rate %+v", r)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("rates were not seeded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	u.Stop()
+}
@@ -0,0 +1,81 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxResponseBytes bounds the rates document read from an HTTPProvider.
+const maxResponseBytes = 1 << 20
+
+// HTTPProvider fetches rates from a JSON endpoint in the shape most rates APIs
+// share (Open Exchange Rates, exchangerate.host, Frankfurter):
+//
+//	{"base": "USD", "timestamp": 1760000000, "rates": {"EUR": 0.92, "GBP": 0.79}}
+//
+// Each entry becomes a rate from base to that currency. The optional
+// timestamp, in Unix seconds, dates the rates; without it they are dated when
+// fetched.
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPProvider fetches rates from url, which may carry an API key in its
+// query string.
+func NewHTTPProvider(url string, timeout time.Duration) *HTTPProvider {
+	return &HTTPProvider{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (p *HTTPProvider) Rates(ctx context.Context) ([]Rate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "go-api-foundry-fx")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("rates endpoint returned %s", resp.Status)
+	}
+
+	var body struct {
+		Base      string                 `json:"base"`
+		Timestamp int64                  `json:"timestamp"`
+		Rates     map[string]json.Number `json:"rates"`
+	}
+	decoder := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid rates document: %w", err)
+	}
+
+	asOf := time.Now().UTC()
+	if body.Timestamp > 0 {
+		asOf = time.Unix(body.Timestamp, 0).UTC()
+	}
+	base := strings.ToUpper(body.Base)
+	rates := make([]Rate, 0, len(body.Rates))
+	for currency, value := range body.Rates {
+		r := Rate{Base: base, Quote: strings.ToUpper(currency), Value: value.String(), AsOf: asOf}
+		if r.Quote == r.Base {
+			continue
+		}
+		if err := validate(r); err != nil {
+			return nil, fmt.Errorf("invalid rates document: %w", err)
+		}
+		rates = append(rates, r)
+	}
+	return rates, nil
+}
@@ -0,0 +1,74 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StaticProvider offers a fixed list of rates, for development, tests and
+// currencies pegged by policy. Every fetch stamps them with the current time.
+type StaticProvider []Rate
+
+func (p StaticProvider) Rates(context.Context) ([]Rate, error) {
+	now := time.Now().UTC()
+	rates := make([]Rate, len(p))
+	for i, r := range p {
+		r.AsOf = now
+		rates[i] = r
+	}
+	return rates, nil
+}
+
+// ParseRates parses a comma-separated list of BASE/QUOTE=VALUE pairs, such as
+// "EUR/USD=1.08,GBP/USD=1.27".
+func ParseRates(s string) (StaticProvider, error) {
+	var rates StaticProvider
+	for item := range strings.SplitSeq(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pair, value, ok := strings.Cut(item, "=")
+		base, quote, okPair := strings.Cut(strings.TrimSpace(pair), "/")
+		if !ok || !okPair {
+			return nil, fmt.Errorf("invalid exchange rate %q: want BASE/QUOTE=VALUE", item)
+		}
+		r := Rate{
+			Base:  strings.ToUpper(strings.TrimSpace(base)),
+			Quote: strings.ToUpper(strings.TrimSpace(quote)),
+			Value: strings.TrimSpace(value),
+		}
+		if err := validate(r); err != nil {
+			return nil, err
+		}
+		rates = append(rates, r)
+	}
+	return rates, nil
+}
+
+func validate(r Rate) error {
+	for _, currency := range []string{r.Base, r.Quote} {
+		if !isCurrencyCode(currency) {
+			return fmt.Errorf("invalid currency code %q", currency)
+		}
+	}
+	if r.Base == r.Quote {
+		return fmt.Errorf("exchange rate from %s to itself", r.Base)
+	}
+	_, err := parseValue(r.Value)
+	return err
+}
+
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
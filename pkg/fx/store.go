@@ -0,0 +1,101 @@
+package fx
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore keeps the latest rate of each currency pair in the fx_rates table.
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore uses db, which may be a transaction.
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// Save stores rates, replacing older rates for the same pairs.
+func (s *GormStore) Save(ctx context.Context, rates []Rate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	rows := make([]models.FXRate, len(rates))
+	for i, r := range rates {
+		rows[i] = models.FXRate{Base: r.Base, Quote: r.Quote, Value: r.Value, AsOf: r.AsOf.UTC()}
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base"}, {Name: "quote"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "as_of", "updated_at"}),
+	}).Create(&rows).Error
+}
+
+// Rate returns the rate from base to quote. Without a stored rate for the
+// pair it is derived from the inverse pair, or else by crossing two rates
+// through a third currency, picking the freshest.
+func (s *GormStore) Rate(ctx context.Context, base, quote string) (Rate, error) {
+	if base == quote {
+		return Rate{Base: base, Quote: quote, Value: "1", AsOf: time.Now().UTC()}, nil
+	}
+
+	var rows []models.FXRate
+	if err := s.db.WithContext(ctx).
+		Where("base IN ? OR quote IN ?", []string{base, quote}, []string{base, quote}).
+		Find(&rows).Error; err != nil {
+		return Rate{}, err
+	}
+
+	// legs holds every known rate touching base or quote: the stored ones,
+	// and the inverse of each where the inverse is not stored too.
+	legs := make(map[[2]string]Rate, 2*len(rows))
+	for _, row := range rows {
+		legs[[2]string{row.Base, row.Quote}] = Rate{Base: row.Base, Quote: row.Quote, Value: row.Value, AsOf: row.AsOf}
+	}
+	for _, row := range rows {
+		if _, ok := legs[[2]string{row.Quote, row.Base}]; ok {
+			continue
+		}
+		inverted, err := legs[[2]string{row.Base, row.Quote}].Invert()
+		if err != nil {
+			return Rate{}, err
+		}
+		legs[[2]string{row.Quote, row.Base}] = inverted
+	}
+
+	if r, ok := legs[[2]string{base, quote}]; ok {
+		return r, nil
+	}
+
+	var pivots []string
+	for pair := range legs {
+		if pair[0] == base && pair[1] != quote {
+			pivots = append(pivots, pair[1])
+		}
+	}
+	slices.Sort(pivots)
+
+	var best Rate
+	found := false
+	for _, pivot := range pivots {
+		second, ok := legs[[2]string{pivot, quote}]
+		if !ok {
+			continue
+		}
+		r, err := cross(legs[[2]string{base, pivot}], second)
+		if err != nil {
+			return Rate{}, err
+		}
+		if !found || r.AsOf.After(best.AsOf) {
+			best, found = r, true
+		}
+	}
+	if !found {
+		return Rate{}, ErrRateNotFound
+	}
+	return best, nil
+}
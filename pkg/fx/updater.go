@@ -0,0 +1,119 @@
+package fx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type UpdaterConfig struct {
+	// Interval between updates. Rates older than the ledger's maximum rate
+	// age are refused, so keep it well below that.
+	Interval time.Duration
+	// Timeout bounds a single fetch and save.
+	Timeout time.Duration
+}
+
+func DefaultUpdaterConfig() UpdaterConfig {
+	return UpdaterConfig{
+		Interval: time.Hour,
+		Timeout:  30 * time.Second,
+	}
+}
+
+// Updater copies the provider's rates into the store when it starts, which
+// seeds an empty fx_rates table, and then every Interval.
+type Updater struct {
+	cfg      UpdaterConfig
+	logger   Logger
+	provider Provider
+	store    *GormStore
+
+	mu      sync.Mutex
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewUpdater(logger Logger, provider Provider, store *GormStore, cfg UpdaterConfig) *Updater {
+	defaults := DefaultUpdaterConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Updater{
+		cfg:      cfg,
+		logger:   logger,
+		provider: provider,
+		store:    store,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start updates the rates now and then every Interval until Stop.
+func (u *Updater) Start() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.started {
+		return
+	}
+	u.started = true
+	go func() {
+		defer close(u.done)
+		ticker := time.NewTicker(u.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			_ = u.Update(u.ctx)
+			select {
+			case <-ticker.C:
+			case <-u.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the updates and waits for one in flight.
+func (u *Updater) Stop() {
+	u.mu.Lock()
+	started := u.started
+	u.mu.Unlock()
+
+	u.cancel()
+	if started {
+		<-u.done
+	}
+}
+
+// Update fetches and stores the provider's rates once. On failure the stored
+// rates are kept, and they age until a later update succeeds.
+func (u *Updater) Update(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, u.cfg.Timeout)
+	defer cancel()
+
+	rates, err := u.provider.Rates(ctx)
+	if err == nil {
+		err = u.store.Save(ctx, rates)
+	}
+	if err != nil {
+		if u.ctx.Err() == nil {
+			u.logger.Warn("Exchange rate update failed", "error", err)
+		}
+		return err
+	}
+	u.logger.Info("Exchange rates updated", "rates", len(rates))
+	return nil
+}
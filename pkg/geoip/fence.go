@@ -0,0 +1,133 @@
+package geoip
+
+import (
+	"fmt"
+	"strings"
+)
+
+type FenceMode string
+
+const (
+	// FenceDeny rejects requests from the listed countries.
+	FenceDeny FenceMode = "deny"
+	// FenceAllow admits only requests from the listed countries. Clients whose
+	// country cannot be resolved are rejected.
+	FenceAllow FenceMode = "allow"
+)
+
+// FenceRule restricts a set of routes by client country. Path is a route
+// template (e.g. /v1/ledger/accounts/:id) or a prefix ending in "*".
+type FenceRule struct {
+	Methods   []string
+	Path      string
+	Mode      FenceMode
+	Countries map[string]struct{}
+}
+
+func (r FenceRule) Matches(method, route string) bool {
+	if len(r.Methods) > 0 {
+		found := false
+		for _, m := range r.Methods {
+			if m == method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if prefix, ok := strings.CutSuffix(r.Path, "*"); ok {
+		return strings.HasPrefix(route, prefix)
+	}
+	return route == r.Path
+}
+
+func (r FenceRule) Permits(country string) bool {
+	_, listed := r.Countries[strings.ToUpper(country)]
+	if r.Mode == FenceAllow {
+		return listed
+	}
+	return !listed
+}
+
+// Fence is a set of rules; a request must be permitted by every rule it matches.
+type Fence []FenceRule
+
+// Check returns the first rule that rejects the request, if any.
+func (f Fence) Check(method, route, country string) (FenceRule, bool) {
+	for _, rule := range f {
+		if rule.Matches(method, route) && !rule.Permits(country) {
+			return rule, false
+		}
+	}
+	return FenceRule{}, true
+}
+
+// ParseFence parses rules of the form
+//
+//	POST,PUT,PATCH,DELETE /v1/ledger/*=deny:KP,IR,CU;* /v1/admin/*=allow:NG,GB
+//
+// Rules are separated by ";". The method list may be "*" for any method.
+func ParseFence(spec string) (Fence, error) {
+	var fence Fence
+
+	for _, raw := range strings.Split(spec, ";") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		target, policy, ok := strings.Cut(raw, "=")
+		if !ok {
+			return nil, fmt.Errorf("geofence rule %q: missing '='", raw)
+		}
+
+		// Paths never contain spaces, so the last one separates the method list.
+		target = strings.TrimSpace(target)
+		sep := strings.LastIndex(target, " ")
+		if sep < 0 {
+			return nil, fmt.Errorf("geofence rule %q: expected \"METHODS PATH\"", raw)
+		}
+		methods, path := target[:sep], strings.TrimSpace(target[sep+1:])
+		if path == "" {
+			return nil, fmt.Errorf("geofence rule %q: expected \"METHODS PATH\"", raw)
+		}
+
+		mode, countries, ok := strings.Cut(strings.TrimSpace(policy), ":")
+		if !ok {
+			return nil, fmt.Errorf("geofence rule %q: expected \"allow:CC,..\" or \"deny:CC,..\"", raw)
+		}
+
+		rule := FenceRule{
+			Path:      path,
+			Mode:      FenceMode(strings.ToLower(strings.TrimSpace(mode))),
+			Countries: make(map[string]struct{}),
+		}
+		if rule.Mode != FenceAllow && rule.Mode != FenceDeny {
+			return nil, fmt.Errorf("geofence rule %q: unknown mode %q", raw, mode)
+		}
+
+		if methods = strings.TrimSpace(methods); methods != "*" {
+			for _, m := range strings.Split(methods, ",") {
+				if m = strings.ToUpper(strings.TrimSpace(m)); m != "" {
+					rule.Methods = append(rule.Methods, m)
+				}
+			}
+		}
+
+		for _, cc := range strings.Split(countries, ",") {
+			if cc = strings.ToUpper(strings.TrimSpace(cc)); cc != "" {
+				rule.Countries[cc] = struct{}{}
+			}
+		}
+		if len(rule.Countries) == 0 {
+			return nil, fmt.Errorf("geofence rule %q: no countries listed", raw)
+		}
+
+		fence = append(fence, rule)
+	}
+
+	return fence, nil
+}
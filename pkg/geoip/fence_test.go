@@ -0,0 +1,57 @@
+package geoip
+
+import "testing"
+
+func TestParseFence(t *testing.T) {
+	fence, err := ParseFence("POST, PUT /v1/ledger/*=deny:kp,IR ; * /v1/admin/*=allow:NG")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(fence) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(fence))
+	}
+	if len(fence[0].Methods) != 2 || fence[0].Mode != FenceDeny {
+		t.Fatalf("unexpected first rule: %+v", fence[0])
+	}
+	if fence[1].Methods != nil || fence[1].Mode != FenceAllow {
+		t.Fatalf("unexpected second rule: %+v", fence[1])
+	}
+}
+
+func TestParseFence_Errors(t *testing.T) {
+	for _, spec := range []string{
+		"/v1/ledger/*=deny:KP",
+		"POST /v1/ledger/*",
+		"POST /v1/ledger/*=block:KP",
+		"POST /v1/ledger/*=deny:",
+	} {
+		if _, err := ParseFence(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestFence_Check(t *testing.T) {
+	fence, err := ParseFence("POST /v1/ledger/*=deny:KP;GET /v1/admin/ip-bans=allow:NG")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	cases := []struct {
+		method, route, country string
+		allowed                bool
+	}{
+		{"POST", "/v1/ledger/transfers", "KP", false},
+		{"POST", "/v1/ledger/transfers", "NG", true},
+		{"GET", "/v1/ledger/accounts/:id", "KP", true},
+		{"GET", "/v1/admin/ip-bans", "NG", true},
+		{"GET", "/v1/admin/ip-bans", "GB", false},
+		{"GET", "/v1/admin/ip-bans", "", false},
+		{"GET", "/health", "KP", true},
+	}
+	for _, tc := range cases {
+		if _, allowed := fence.Check(tc.method, tc.route, tc.country); allowed != tc.allowed {
+			t.Errorf("%s %s from %q: expected allowed=%v", tc.method, tc.route, tc.country, tc.allowed)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location is what is known about a client IP. Fields are left empty when the
+// corresponding database is not configured or has no entry.
+type Location struct {
+	Country      string `json:"country,omitempty"`
+	ASN          uint   `json:"asn,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// LogAttrs returns the location as slog key/value pairs.
+func (l Location) LogAttrs() []any {
+	attrs := make([]any, 0, 6)
+	if l.Country != "" {
+		attrs = append(attrs, "geo_country", l.Country)
+	}
+	if l.ASN != 0 {
+		attrs = append(attrs, "geo_asn", l.ASN, "geo_org", l.Organization)
+	}
+	return attrs
+}
+
+type Provider interface {
+	Lookup(addr netip.Addr) (Location, error)
+}
+
+type contextKey struct{}
+
+func WithLocation(ctx context.Context, loc Location) context.Context {
+	return context.WithValue(ctx, contextKey{}, loc)
+}
+
+func FromContext(ctx context.Context) (Location, bool) {
+	loc, ok := ctx.Value(contextKey{}).(Location)
+	return loc, ok
+}
+
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+type asnRecord struct {
+	Number       uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// MaxMindProvider reads GeoLite2/GeoIP2 Country and ASN databases. Either
+// path may be empty. Reload swaps the readers atomically so updated database
+// files can be picked up without a restart.
+type MaxMindProvider struct {
+	countryPath string
+	asnPath     string
+
+	mu      sync.RWMutex
+	country *maxminddb.Reader
+	asn     *maxminddb.Reader
+}
+
+func OpenMaxMind(countryPath, asnPath string) (*MaxMindProvider, error) {
+	if countryPath == "" && asnPath == "" {
+		return nil, errors.New("at least one GeoIP database path is required")
+	}
+
+	p := &MaxMindProvider{countryPath: countryPath, asnPath: asnPath}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func openReader(path string) (*maxminddb.Reader, error) {
+	if path == "" {
+		return nil, nil
+	}
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open GeoIP database %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Reload reopens the database files. On failure the current readers stay in use.
+func (p *MaxMindProvider) Reload() error {
+	country, err := openReader(p.countryPath)
+	if err != nil {
+		return err
+	}
+	asn, err := openReader(p.asnPath)
+	if err != nil {
+		if country != nil {
+			_ = country.Close()
+		}
+		return err
+	}
+
+	p.mu.Lock()
+	oldCountry, oldASN := p.country, p.asn
+	p.country, p.asn = country, asn
+	p.mu.Unlock()
+
+	// Readers are memory-mapped; close the old ones only after the swap.
+	if oldCountry != nil {
+		_ = oldCountry.Close()
+	}
+	if oldASN != nil {
+		_ = oldASN.Close()
+	}
+	return nil
+}
+
+func (p *MaxMindProvider) Lookup(addr netip.Addr) (Location, error) {
+	var loc Location
+	ip := net.IP(addr.Unmap().AsSlice())
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.country != nil {
+		var rec countryRecord
+		if err := p.country.Lookup(ip, &rec); err != nil {
+			return loc, err
+		}
+		loc.Country = rec.Country.ISOCode
+	}
+	if p.asn != nil {
+		var rec asnRecord
+		if err := p.asn.Lookup(ip, &rec); err != nil {
+			return loc, err
+		}
+		loc.ASN = rec.Number
+		loc.Organization = rec.Organization
+	}
+	return loc, nil
+}
+
+func (p *MaxMindProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	if p.country != nil {
+		errs = append(errs, p.country.Close())
+		p.country = nil
+	}
+	if p.asn != nil {
+		errs = append(errs, p.asn.Close())
+		p.asn = nil
+	}
+	return errors.Join(errs...)
+}
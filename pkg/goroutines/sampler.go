@@ -0,0 +1,228 @@
+// Package goroutines samples the goroutine count at runtime and reports
+// sustained growth, the usual symptom of a leak in a background subsystem,
+// together with the stacks that account for most goroutines.
+package goroutines
+
+import (
+	"bufio"
+	"bytes"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type Config struct {
+	// Interval between samples.
+	Interval time.Duration
+	// Window is how many consecutive samples must not decrease to count as
+	// sustained growth.
+	Window int
+	// MinGrowth is how many goroutines the window must have grown by.
+	MinGrowth int
+	// TopStacks is how many stack signatures a report lists.
+	TopStacks int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval:  time.Minute,
+		Window:    10,
+		MinGrowth: 50,
+		TopStacks: 5,
+	}
+}
+
+// Signature groups goroutines parked in the same stack.
+type Signature struct {
+	Count int
+	// Stack lists the innermost non-runtime functions, innermost first.
+	Stack string
+}
+
+// Sampler reports growth through its logger until Stop.
+type Sampler struct {
+	cfg    Config
+	logger Logger
+
+	mu      sync.Mutex
+	samples []int
+	started bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+
+	count   func() int
+	profile func() []Signature
+}
+
+func NewSampler(logger Logger, cfg Config) *Sampler {
+	defaults := DefaultConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.Window < 2 {
+		cfg.Window = defaults.Window
+	}
+	if cfg.MinGrowth <= 0 {
+		cfg.MinGrowth = defaults.MinGrowth
+	}
+	if cfg.TopStacks <= 0 {
+		cfg.TopStacks = defaults.TopStacks
+	}
+	return &Sampler{
+		cfg:     cfg,
+		logger:  logger,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		count:   runtime.NumGoroutine,
+		profile: Profile,
+	}
+}
+
+// Start samples every Interval until Stop.
+func (s *Sampler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Sample()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends sampling.
+func (s *Sampler) Stop() {
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+
+	s.once.Do(func() { close(s.stop) })
+	if started {
+		<-s.done
+	}
+}
+
+// Sample records the current goroutine count and reports whether it showed
+// sustained growth; a report is logged at most once per Window samples.
+func (s *Sampler) Sample() bool {
+	n := s.count()
+
+	s.mu.Lock()
+	if len(s.samples) > 0 && n < s.samples[len(s.samples)-1] {
+		// Any drop restarts the window.
+		s.samples = s.samples[:0]
+	}
+	s.samples = append(s.samples, n)
+	if len(s.samples) > s.cfg.Window {
+		s.samples = s.samples[1:]
+	}
+	growing := len(s.samples) == s.cfg.Window && n-s.samples[0] >= s.cfg.MinGrowth
+	var from int
+	if growing {
+		from = s.samples[0]
+		s.samples = s.samples[:0]
+	}
+	s.mu.Unlock()
+
+	if !growing {
+		return false
+	}
+
+	top := s.profile()
+	if len(top) > s.cfg.TopStacks {
+		top = top[:s.cfg.TopStacks]
+	}
+	stacks := make([]string, 0, len(top))
+	for _, sig := range top {
+		stacks = append(stacks, strconv.Itoa(sig.Count)+" × "+sig.Stack)
+	}
+	s.logger.Warn("Sustained goroutine growth; possible leak",
+		"from", from, "to", n, "over", time.Duration(s.cfg.Window-1)*s.cfg.Interval, "top_stacks", stacks)
+	return true
+}
+
+// Profile groups the running goroutines by stack, largest group first.
+func Profile() []Signature {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil
+	}
+	return parseProfile(buf.Bytes())
+}
+
+// parseProfile reads the debug=1 goroutine profile format:
+//
+//	3 @ 0x43a0d6 0x4079a5 ...
+//	#	0x46d3a4	net/http.(*persistConn).readLoop+0x1a4	/usr/lib/go/src/net/http/transport.go:2205
+func parseProfile(profile []byte) []Signature {
+	const maxFrames = 3
+
+	bySignature := make(map[string]int)
+	var count int
+	var frames []string
+	flush := func() {
+		if count > 0 && len(frames) > 0 {
+			bySignature[strings.Join(frames, " <- ")] += count
+		}
+		count, frames = 0, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(profile))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "#"):
+			fields := strings.Fields(line)
+			if len(fields) < 3 || len(frames) >= maxFrames {
+				continue
+			}
+			fn := fields[2]
+			if i := strings.LastIndex(fn, "+0x"); i > 0 {
+				fn = fn[:i]
+			}
+			if !strings.HasPrefix(fn, "runtime.") && !strings.HasPrefix(fn, "internal/") {
+				frames = append(frames, fn)
+			}
+		case strings.Contains(line, " @ "):
+			flush()
+			count, _ = strconv.Atoi(strings.TrimSpace(line[:strings.Index(line, " @ ")]))
+		}
+	}
+	flush()
+
+	out := make([]Signature, 0, len(bySignature))
+	for stack, n := range bySignature {
+		out = append(out, Signature{Count: n, Stack: stack})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Stack < out[j].Stack
+	})
+	return out
+}
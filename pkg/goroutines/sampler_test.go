@@ -0,0 +1,89 @@
+package goroutines
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	mu    sync.Mutex
+	warns [][]any
+}
+
+func (l *recordingLogger) Info(string, ...any)  {}
+func (l *recordingLogger) Error(string, ...any) {}
+func (l *recordingLogger) Warn(_ string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warns = append(l.warns, args)
+}
+
+func TestSample_ReportsSustainedGrowthOnly(t *testing.T) {
+	logger := &recordingLogger{}
+	s := NewSampler(logger, Config{Window: 3, MinGrowth: 10, TopStacks: 1})
+	s.profile = func() []Signature {
+		return []Signature{{Count: 12, Stack: "app.leaky"}, {Count: 2, Stack: "app.other"}}
+	}
+
+	counts := []int{
+		100, 120, 90, // a drop restarts the window
+		92, 95, 99, // growing, but by less than MinGrowth per window
+		105, // 95 -> 105 over the last three samples
+	}
+	var reported []int
+	for _, n := range counts {
+		s.count = func() int { return n }
+		if s.Sample() {
+			reported = append(reported, n)
+		}
+	}
+	if len(reported) != 1 || reported[0] != 105 {
+		t.Fatalf("expected one report at 105, got %v", reported)
+	}
+
+	args := logger.warns[0]
+	stacks := args[len(args)-1].([]string)
+	if len(stacks) != 1 || stacks[0] != "12 × app.leaky" {
+		t.Fatalf("expected the top stack in the report, got %v", stacks)
+	}
+
+	// The window restarts after a report.
+	s.count = func() int { return 200 }
+	if s.Sample() {
+		t.Fatal("expected no second report right after the first")
+	}
+}
+
+func TestProfile_GroupsRunningGoroutines(t *testing.T) {
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go parked(&wg, release)
+	}
+	defer func() {
+		close(release)
+		wg.Wait()
+	}()
+
+	// The goroutines may not have parked yet; look again briefly.
+	var profile []Signature
+	for range 100 {
+		profile = Profile()
+		for _, sig := range profile {
+			if strings.HasPrefix(sig.Stack, "github.com/akeren/go-api-foundry/pkg/goroutines.parked") && sig.Count == 20 {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected 20 parked goroutines in the profile: %+v", profile)
+}
+
+//go:noinline
+func parked(wg *sync.WaitGroup, release chan struct{}) {
+	defer wg.Done()
+	<-release
+}
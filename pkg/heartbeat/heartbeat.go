@@ -0,0 +1,169 @@
+// Package heartbeat pings an external dead-man's-switch URL (Healthchecks.io,
+// Cronitor, Better Uptime and the like) while the process is healthy, so the
+// monitor alerts when the pings stop because the process died or hung.
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type Config struct {
+	// URL receives the heartbeats.
+	URL string
+	// Method is GET or POST.
+	Method string
+	// Interval between heartbeats; configure the monitor's grace period above it.
+	Interval time.Duration
+	// Timeout bounds a single request.
+	Timeout time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Method:   http.MethodGet,
+		Interval: time.Minute,
+		Timeout:  10 * time.Second,
+	}
+}
+
+// HealthFunc reports whether the process is healthy enough to send a beat.
+type HealthFunc func() bool
+
+// Sender sends a heartbeat every Interval while healthy reports true.
+type Sender struct {
+	cfg     Config
+	logger  Logger
+	healthy HealthFunc
+	client  *http.Client
+
+	mu       sync.Mutex
+	started  bool
+	skipping bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSender creates a sender; healthy may be nil to always send.
+func NewSender(logger Logger, cfg Config, healthy HealthFunc) *Sender {
+	defaults := DefaultConfig()
+	if cfg.Method == "" {
+		cfg.Method = defaults.Method
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if healthy == nil {
+		healthy = func() bool { return true }
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Sender{
+		cfg:     cfg,
+		logger:  logger,
+		healthy: healthy,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+}
+
+// Start sends a heartbeat now and then every Interval until Stop.
+func (s *Sender) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			s.Beat(s.ctx)
+			select {
+			case <-ticker.C:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the heartbeats and waits for an in-flight request. The monitor
+// then alerts after its grace period unless the process comes back.
+func (s *Sender) Stop() {
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+
+	s.cancel()
+	if started {
+		<-s.done
+	}
+}
+
+// Beat sends one heartbeat if the process is healthy. Failures are logged
+// and reported; the next tick simply tries again.
+func (s *Sender) Beat(ctx context.Context) error {
+	if !s.healthy() {
+		s.mu.Lock()
+		first := !s.skipping
+		s.skipping = true
+		s.mu.Unlock()
+		if first {
+			s.logger.Warn("Unhealthy; withholding heartbeats")
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	resumed := s.skipping
+	s.skipping = false
+	s.mu.Unlock()
+	if resumed {
+		s.logger.Info("Healthy again; resuming heartbeats")
+	}
+
+	err := s.send(ctx)
+	if err != nil && ctx.Err() == nil {
+		s.logger.Warn("Heartbeat failed", "error", err)
+	}
+	return err
+}
+
+func (s *Sender) send(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, s.cfg.Method, s.cfg.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "go-api-foundry-heartbeat")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("heartbeat returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package heartbeat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+func TestBeat_OnlyWhileHealthy(t *testing.T) {
+	var hits atomic.Int32
+	var method atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		method.Store(r.Method)
+	}))
+	defer srv.Close()
+
+	var healthy atomic.Bool
+	s := NewSender(nopLogger{}, Config{URL: srv.URL, Method: http.MethodPost}, healthy.Load)
+
+	if err := s.Beat(context.Background()); err != nil || hits.Load() != 0 {
+		t.Fatalf("expected no heartbeat while unhealthy, got %d (err %v)", hits.Load(), err)
+	}
+
+	healthy.Store(true)
+	if err := s.Beat(context.Background()); err != nil {
+		t.Fatalf("beat: %v", err)
+	}
+	if hits.Load() != 1 || method.Load() != http.MethodPost {
+		t.Fatalf("expected one POST, got %d %v", hits.Load(), method.Load())
+	}
+}
+
+func TestBeat_ReportsErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	s := NewSender(nopLogger{}, Config{URL: srv.URL}, nil)
+	if err := s.Beat(context.Background()); err == nil {
+		t.Fatal("expected an error for a 404 response")
+	}
+}
+
+func TestStartAndStop(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	s := NewSender(nopLogger{}, Config{URL: srv.URL, Interval: 10 * time.Millisecond}, nil)
+	s.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for hits.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.Stop()
+	if hits.Load() < 3 {
+		t.Fatalf("expected repeated heartbeats, got %d", hits.Load())
+	}
+
+	stopped := hits.Load()
+	time.Sleep(30 * time.Millisecond)
+	if hits.Load() != stopped {
+		t.Fatal("heartbeats continued after Stop")
+	}
+
+	// Stop without Start must not block.
+	NewSender(nopLogger{}, Config{URL: srv.URL}, nil).Stop()
+}
@@ -0,0 +1,118 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// purgeInterval spaces the deletes of expired records.
+const purgeInterval = time.Minute
+
+// GormStore keeps responses in the idempotency_records table, for
+// deployments without Redis. Expired records are deleted as new keys are
+// claimed.
+type GormStore struct {
+	db *gorm.DB
+	// lastPurge is the Unix time of the last purge of expired records.
+	lastPurge atomic.Int64
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*Response, error) {
+	now := time.Now().UTC()
+	db := s.db.WithContext(ctx)
+	s.purgeExpired(ctx, now)
+
+	// A claim that expires between the insert and the read is retried once.
+	for range 2 {
+		// Take over an abandoned claim or a response past its TTL.
+		if err := db.Where("idempotency_key = ? AND expires_at <= ?", key, now).Delete(&models.IdempotencyRecord{}).Error; err != nil {
+			return nil, err
+		}
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.IdempotencyRecord{
+			IdempotencyKey: key,
+			Fingerprint:    fingerprint,
+			ExpiresAt:      now.Add(lockTTL),
+			CreatedAt:      now,
+		})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var record models.IdempotencyRecord
+		err := db.First(&record, "idempotency_key = ?", key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return recordResponse(&record, fingerprint)
+	}
+	return nil, ErrInProgress
+}
+
+func recordResponse(record *models.IdempotencyRecord, fingerprint string) (*Response, error) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		return nil, ErrMismatch
+	case record.CompletedAt == nil:
+		return nil, ErrInProgress
+	}
+	resp := &Response{StatusCode: record.StatusCode, Body: record.Body}
+	if record.Header != "" {
+		if err := json.Unmarshal([]byte(record.Header), &resp.Header); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (s *GormStore) Complete(ctx context.Context, key, fingerprint string, resp Response, ttl time.Duration) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	record := &models.IdempotencyRecord{
+		IdempotencyKey: key,
+		Fingerprint:    fingerprint,
+		StatusCode:     resp.StatusCode,
+		Header:         string(header),
+		Body:           resp.Body,
+		CompletedAt:    &now,
+		ExpiresAt:      now.Add(ttl),
+		CreatedAt:      now,
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "idempotency_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"fingerprint", "status_code", "header", "body", "completed_at", "expires_at"}),
+	}).Create(record).Error
+}
+
+func (s *GormStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("idempotency_key = ? AND completed_at IS NULL", key).Delete(&models.IdempotencyRecord{}).Error
+}
+
+// purgeExpired deletes expired records at most once per purgeInterval. A
+// failed purge is retried on the next interval; Begin never depends on it.
+func (s *GormStore) purgeExpired(ctx context.Context, now time.Time) {
+	last := s.lastPurge.Load()
+	if now.Unix()-last < int64(purgeInterval/time.Second) || !s.lastPurge.CompareAndSwap(last, now.Unix()) {
+		return
+	}
+	s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.IdempotencyRecord{})
+}
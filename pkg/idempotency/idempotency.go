@@ -0,0 +1,103 @@
+// Package idempotency stores the responses to mutating requests by their
+// idempotency key, so a retried request gets the original response instead
+// of running again.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInProgress means another request holds the key and has not finished.
+	ErrInProgress = errors.New("a request with this idempotency key is in progress")
+	// ErrMismatch means the key was first used for a different request.
+	ErrMismatch = errors.New("idempotency key was used for a different request")
+)
+
+// Response is a stored response, replayed as is.
+type Response struct {
+	StatusCode int               `json:"status_code"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       []byte            `json:"body"`
+}
+
+// Store claims keys and keeps their responses. Implementations must be safe
+// for concurrent use.
+type Store interface {
+	// Begin claims key for the request identified by fingerprint. A nil
+	// response and error mean the caller holds the claim until lockTTL
+	// passes and must call Complete or Release. Otherwise Begin returns the
+	// stored response of a finished request with the same fingerprint,
+	// ErrMismatch when the fingerprint differs, or ErrInProgress while
+	// another claim is held.
+	Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*Response, error)
+	// Complete stores the response for key and keeps it for ttl.
+	Complete(ctx context.Context, key, fingerprint string, resp Response, ttl time.Duration) error
+	// Release drops an unfinished claim so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryStore keeps responses in process memory, for tests and
+// single-instance deployments.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	fingerprint string
+	response    *Response
+	expiresAt   time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Begin(_ context.Context, key, fingerprint string, lockTTL time.Duration) (*Response, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+
+	if e, ok := s.entries[key]; ok {
+		return e.result(fingerprint)
+	}
+	s.entries[key] = memoryEntry{fingerprint: fingerprint, expiresAt: now.Add(lockTTL)}
+	return nil, nil
+}
+
+func (e memoryEntry) result(fingerprint string) (*Response, error) {
+	switch {
+	case e.fingerprint != fingerprint:
+		return nil, ErrMismatch
+	case e.response == nil:
+		return nil, ErrInProgress
+	}
+	resp := *e.response
+	return &resp, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key, fingerprint string, resp Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryEntry{fingerprint: fingerprint, response: &resp, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok && e.response == nil {
+		delete(s.entries, key)
+	}
+	return nil
+}
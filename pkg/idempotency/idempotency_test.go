@@ -0,0 +1,100 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_ReplaysCompletedResponse(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	resp, err := store.Begin(ctx, "key-1", "fp-1", time.Minute)
+	if err != nil || resp != nil {
+		t.Fatalf("expected the first Begin to claim the key, got %v, %v", resp, err)
+	}
+	if _, err := store.Begin(ctx, "key-1", "fp-1", time.Minute); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("expected ErrInProgress while the claim is held, got %v", err)
+	}
+
+	want := Response{StatusCode: 201, Header: map[string]string{"Content-Type": "application/json"}, Body: []byte(`{"id":1}`)}
+	if err := store.Complete(ctx, "key-1", "fp-1", want, time.Hour); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	resp, err = store.Begin(ctx, "key-1", "fp-1", time.Minute)
+	if err != nil {
+		t.Fatalf("Begin after Complete: %v", err)
+	}
+	if resp == nil || resp.StatusCode != 201 || string(resp.Body) != `{"id":1}` || resp.Header["Content-Type"] != "application/json" {
+		t.Fatalf("unexpected replayed response %+v", resp)
+	}
+}
+
+func TestMemoryStore_RejectsDifferentRequest(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if _, err := store.Begin(ctx, "key-1", "fp-1", time.Minute); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := store.Begin(ctx, "key-1", "fp-2", time.Minute); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("expected ErrMismatch for a pending claim, got %v", err)
+	}
+
+	if err := store.Complete(ctx, "key-1", "fp-1", Response{StatusCode: 200}, time.Hour); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if _, err := store.Begin(ctx, "key-1", "fp-2", time.Minute); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("expected ErrMismatch for a stored response, got %v", err)
+	}
+}
+
+func TestMemoryStore_Release(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if _, err := store.Begin(ctx, "key-1", "fp-1", time.Minute); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := store.Release(ctx, "key-1"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if resp, err := store.Begin(ctx, "key-1", "fp-1", time.Minute); err != nil || resp != nil {
+		t.Fatalf("expected a released key to be claimable, got %v, %v", resp, err)
+	}
+
+	// Release never drops a stored response.
+	if err := store.Complete(ctx, "key-1", "fp-1", Response{StatusCode: 200}, time.Hour); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if err := store.Release(ctx, "key-1"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if resp, err := store.Begin(ctx, "key-1", "fp-1", time.Minute); err != nil || resp == nil {
+		t.Fatalf("expected the stored response to survive Release, got %v, %v", resp, err)
+	}
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	// An abandoned claim can be taken over once its lock expires.
+	if _, err := store.Begin(ctx, "key-1", "fp-1", time.Millisecond); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if resp, err := store.Begin(ctx, "key-1", "fp-2", time.Minute); err != nil || resp != nil {
+		t.Fatalf("expected an expired claim to be claimable, got %v, %v", resp, err)
+	}
+
+	if err := store.Complete(ctx, "key-1", "fp-2", Response{StatusCode: 200}, time.Millisecond); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if resp, err := store.Begin(ctx, "key-1", "fp-3", time.Minute); err != nil || resp != nil {
+		t.Fatalf("expected an expired response to be forgotten, got %v, %v", resp, err)
+	}
+}
@@ -0,0 +1,92 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	redisKeyPrefix = "idempotency:"
+	// pendingPrefix marks a claimed key whose request has not finished.
+	pendingPrefix = "pending:"
+)
+
+// releaseScript deletes a key only while it is still a pending claim, so a
+// late Release cannot drop a stored response.
+var releaseScript = redis.NewScript(`
+local v = redis.call("GET", KEYS[1])
+if v and string.sub(v, 1, string.len(ARGV[1])) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisStore keeps responses in Redis, shared by every instance. Keys expire
+// on their own.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+type redisEntry struct {
+	Fingerprint string   `json:"fingerprint"`
+	Response    Response `json:"response"`
+}
+
+func (s *RedisStore) Begin(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*Response, error) {
+	key = redisKeyPrefix + key
+	// A claim that expires between SETNX and GET is retried once.
+	for range 2 {
+		claimed, err := s.client.SetNX(ctx, key, pendingPrefix+fingerprint, lockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return nil, nil
+		}
+
+		raw, err := s.client.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if pending, ok := strings.CutPrefix(raw, pendingPrefix); ok {
+			if pending != fingerprint {
+				return nil, ErrMismatch
+			}
+			return nil, ErrInProgress
+		}
+		var entry redisEntry
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			return nil, err
+		}
+		if entry.Fingerprint != fingerprint {
+			return nil, ErrMismatch
+		}
+		return &entry.Response, nil
+	}
+	return nil, ErrInProgress
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key, fingerprint string, resp Response, ttl time.Duration) error {
+	raw, err := json.Marshal(redisEntry{Fingerprint: fingerprint, Response: resp})
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisKeyPrefix+key, raw, ttl).Err()
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return releaseScript.Run(ctx, s.client, []string{redisKeyPrefix + key}, pendingPrefix).Err()
+}
@@ -0,0 +1,157 @@
+package ipfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+var ErrInvalidIP = errors.New("invalid IP address")
+
+// Reasons reported for blocked requests.
+const (
+	ReasonDenied         = "denylist"
+	ReasonNotAllowlisted = "not_allowlisted"
+	ReasonBanned         = "banned"
+)
+
+type Config struct {
+	// Allow, when non-empty, admits only clients inside one of these ranges.
+	Allow []netip.Prefix
+	// Deny always rejects clients inside one of these ranges.
+	Deny []netip.Prefix
+}
+
+// Filter evaluates a client IP against static CIDR lists and dynamic TTL bans.
+// Static deny entries take precedence over the allow list; bans apply to every
+// client that passes the static lists.
+type Filter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+	bans  BanStore
+}
+
+func New(cfg Config, bans BanStore) *Filter {
+	if bans == nil {
+		bans = NewMemoryBanStore()
+	}
+	return &Filter{
+		allow: cfg.Allow,
+		deny:  cfg.Deny,
+		bans:  bans,
+	}
+}
+
+// ParsePrefixes parses a comma-separated list of CIDRs or bare IPs. Invalid
+// entries are returned separately so callers can decide how loud to be.
+func ParsePrefixes(v string) ([]netip.Prefix, []string) {
+	var prefixes []netip.Prefix
+	var invalid []string
+
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if strings.Contains(part, "/") {
+			p, err := netip.ParsePrefix(part)
+			if err != nil {
+				invalid = append(invalid, part)
+				continue
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(part)
+		if err != nil {
+			invalid = append(invalid, part)
+			continue
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes, invalid
+}
+
+func parseAddr(ip string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("%w: %q", ErrInvalidIP, ip)
+	}
+	return addr.Unmap(), nil
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check reports whether ip must be blocked and why. A ban store error is
+// returned together with blocked=false so callers can fail open.
+func (f *Filter) Check(ctx context.Context, ip string) (blocked bool, reason string, err error) {
+	addr, err := parseAddr(ip)
+	if err != nil {
+		return false, "", err
+	}
+
+	if contains(f.deny, addr) {
+		return true, ReasonDenied, nil
+	}
+	if len(f.allow) > 0 && !contains(f.allow, addr) {
+		return true, ReasonNotAllowlisted, nil
+	}
+
+	banned, err := f.bans.IsBanned(ctx, addr.String())
+	if err != nil {
+		return false, "", err
+	}
+	if banned {
+		return true, ReasonBanned, nil
+	}
+	return false, "", nil
+}
+
+func (f *Filter) Ban(ctx context.Context, ip string, ttl time.Duration, reason string) (Ban, error) {
+	addr, err := parseAddr(ip)
+	if err != nil {
+		return Ban{}, err
+	}
+	if ttl <= 0 {
+		return Ban{}, errors.New("ban ttl must be positive")
+	}
+	ban := Ban{
+		IP:        addr.String(),
+		Reason:    reason,
+		ExpiresAt: time.Now().Add(ttl).UTC(),
+	}
+	return ban, f.bans.Ban(ctx, ban)
+}
+
+func (f *Filter) Unban(ctx context.Context, ip string) error {
+	addr, err := parseAddr(ip)
+	if err != nil {
+		return err
+	}
+	return f.bans.Unban(ctx, addr.String())
+}
+
+func (f *Filter) Bans(ctx context.Context) ([]Ban, error) {
+	return f.bans.List(ctx)
+}
+
+// Deny bans ip using a background context. It lets the filter act as the
+// deny list for automated components such as the anomaly scorer.
+func (f *Filter) Deny(ip string, ttl time.Duration, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err := f.Ban(ctx, ip, ttl, reason)
+	return err
+}
@@ -0,0 +1,109 @@
+package ipfilter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustPrefixes(t *testing.T, v string) Config {
+	t.Helper()
+	prefixes, invalid := ParsePrefixes(v)
+	if len(invalid) > 0 {
+		t.Fatalf("unexpected invalid entries: %v", invalid)
+	}
+	return Config{Deny: prefixes}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	prefixes, invalid := ParsePrefixes(" 10.0.0.0/8, 192.168.1.7 ,, ::1, nope, 10.0.0.0/99")
+	if len(prefixes) != 3 {
+		t.Fatalf("expected 3 prefixes, got %v", prefixes)
+	}
+	if len(invalid) != 2 {
+		t.Fatalf("expected 2 invalid entries, got %v", invalid)
+	}
+}
+
+func TestFilter_DenyListBlocksRange(t *testing.T) {
+	f := New(mustPrefixes(t, "10.0.0.0/8"), nil)
+	ctx := context.Background()
+
+	blocked, reason, err := f.Check(ctx, "10.1.2.3")
+	if err != nil || !blocked || reason != ReasonDenied {
+		t.Fatalf("expected denied, got blocked=%v reason=%q err=%v", blocked, reason, err)
+	}
+	blocked, _, err = f.Check(ctx, "11.0.0.1")
+	if err != nil || blocked {
+		t.Fatalf("expected allowed, got blocked=%v err=%v", blocked, err)
+	}
+}
+
+func TestFilter_AllowListAdmitsOnlyListed(t *testing.T) {
+	allow, _ := ParsePrefixes("192.168.0.0/16")
+	deny, _ := ParsePrefixes("192.168.66.0/24")
+	f := New(Config{Allow: allow, Deny: deny}, nil)
+	ctx := context.Background()
+
+	if blocked, _, _ := f.Check(ctx, "192.168.1.1"); blocked {
+		t.Fatalf("allowlisted client should pass")
+	}
+	if _, reason, _ := f.Check(ctx, "8.8.8.8"); reason != ReasonNotAllowlisted {
+		t.Fatalf("expected not_allowlisted, got %q", reason)
+	}
+	if _, reason, _ := f.Check(ctx, "192.168.66.5"); reason != ReasonDenied {
+		t.Fatalf("deny must take precedence over allow, got %q", reason)
+	}
+}
+
+func TestFilter_BanAndUnban(t *testing.T) {
+	f := New(Config{}, nil)
+	ctx := context.Background()
+
+	ban, err := f.Ban(ctx, "::ffff:1.2.3.4", time.Minute, "manual")
+	if err != nil {
+		t.Fatalf("ban: %v", err)
+	}
+	if ban.IP != "1.2.3.4" {
+		t.Fatalf("expected normalized IP, got %q", ban.IP)
+	}
+	if _, reason, _ := f.Check(ctx, "1.2.3.4"); reason != ReasonBanned {
+		t.Fatalf("expected banned (IPv4-mapped address normalized), got %q", reason)
+	}
+
+	bans, err := f.Bans(ctx)
+	if err != nil || len(bans) != 1 || bans[0].IP != "1.2.3.4" {
+		t.Fatalf("unexpected bans: %v err=%v", bans, err)
+	}
+
+	if err := f.Unban(ctx, "1.2.3.4"); err != nil {
+		t.Fatalf("unban: %v", err)
+	}
+	if blocked, _, _ := f.Check(ctx, "1.2.3.4"); blocked {
+		t.Fatalf("expected unbanned client to pass")
+	}
+}
+
+func TestFilter_RejectsInvalidIP(t *testing.T) {
+	f := New(Config{}, nil)
+	if _, err := f.Ban(context.Background(), "not-an-ip", time.Minute, ""); !errors.Is(err, ErrInvalidIP) {
+		t.Fatalf("expected ErrInvalidIP, got %v", err)
+	}
+}
+
+func TestMemoryBanStore_Expires(t *testing.T) {
+	store := NewMemoryBanStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_ = store.Ban(ctx, Ban{IP: "1.2.3.4", ExpiresAt: now.Add(time.Minute)})
+	if banned, _ := store.IsBanned(ctx, "1.2.3.4"); !banned {
+		t.Fatalf("expected ban to be active")
+	}
+	now = now.Add(2 * time.Minute)
+	if banned, _ := store.IsBanned(ctx, "1.2.3.4"); banned {
+		t.Fatalf("expected ban to expire")
+	}
+}
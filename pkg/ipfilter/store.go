@@ -0,0 +1,149 @@
+package ipfilter
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type Ban struct {
+	IP        string    `json:"ip"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// BanStore holds temporary bans. Entries must expire on their own.
+type BanStore interface {
+	Ban(ctx context.Context, ban Ban) error
+	Unban(ctx context.Context, ip string) error
+	IsBanned(ctx context.Context, ip string) (bool, error)
+	List(ctx context.Context) ([]Ban, error)
+}
+
+// MemoryBanStore keeps bans in process memory for single-instance deployments.
+type MemoryBanStore struct {
+	mu   sync.Mutex
+	bans map[string]Ban
+	now  func() time.Time
+}
+
+func NewMemoryBanStore() *MemoryBanStore {
+	return &MemoryBanStore{
+		bans: make(map[string]Ban),
+		now:  time.Now,
+	}
+}
+
+func (s *MemoryBanStore) Ban(_ context.Context, ban Ban) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bans[ban.IP] = ban
+	return nil
+}
+
+func (s *MemoryBanStore) Unban(_ context.Context, ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.bans, ip)
+	return nil
+}
+
+func (s *MemoryBanStore) IsBanned(_ context.Context, ip string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ban, ok := s.bans[ip]
+	if !ok {
+		return false, nil
+	}
+	if !s.now().Before(ban.ExpiresAt) {
+		delete(s.bans, ip)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *MemoryBanStore) List(_ context.Context) ([]Ban, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	bans := make([]Ban, 0, len(s.bans))
+	for ip, ban := range s.bans {
+		if !now.Before(ban.ExpiresAt) {
+			delete(s.bans, ip)
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].IP < bans[j].IP })
+	return bans, nil
+}
+
+// RedisBanStore shares bans across instances. Each ban is a key with a
+// native Redis TTL, so expiry needs no cleanup.
+type RedisBanStore struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+func NewRedisBanStore(client *redis.Client) *RedisBanStore {
+	return &RedisBanStore{
+		client:    client,
+		keyPrefix: "ipfilter:ban:",
+	}
+}
+
+func (s *RedisBanStore) Ban(ctx context.Context, ban Ban) error {
+	ttl := time.Until(ban.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	payload, err := json.Marshal(ban)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.keyPrefix+ban.IP, payload, ttl).Err()
+}
+
+func (s *RedisBanStore) Unban(ctx context.Context, ip string) error {
+	return s.client.Del(ctx, s.keyPrefix+ip).Err()
+}
+
+func (s *RedisBanStore) IsBanned(ctx context.Context, ip string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.keyPrefix+ip).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *RedisBanStore) List(ctx context.Context) ([]Ban, error) {
+	var bans []Ban
+
+	iter := s.client.Scan(ctx, 0, s.keyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		raw, err := s.client.Get(ctx, iter.Val()).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var ban Ban
+		if err := json.Unmarshal(raw, &ban); err != nil {
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(bans, func(i, j int) bool { return bans[i].IP < bans[j].IP })
+	return bans, nil
+}
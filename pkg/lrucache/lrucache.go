@@ -0,0 +1,295 @@
+package lrucache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries bounds a cache whose Config leaves MaxEntries unset.
+const DefaultMaxEntries = 10000
+
+var ErrNotInteger = errors.New("value is not an integer")
+
+type Config struct {
+	// MaxEntries is how many keys the cache holds before it evicts the least
+	// recently used one. It defaults to DefaultMaxEntries.
+	MaxEntries int
+	// MaxTTL caps how long a key is kept, including keys set without an
+	// expiry. 0 leaves keys without an expiry until they are evicted.
+	MaxTTL time.Duration
+}
+
+// LRUCache keeps string keys in process memory, with the semantics of the
+// Redis cache: expiries, counters, SETNX and pattern deletes. It is not
+// shared between processes, so it stands in for Redis in development and
+// on a single instance. Expired keys are dropped when they are next read or
+// evicted.
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxTTL     time.Duration
+	order      *list.List // of *entry, most recently used first
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+type entry struct {
+	key       string
+	value     string
+	expiresAt time.Time // zero for no expiry
+}
+
+func NewLRUCache(cfg *Config) *LRUCache {
+	c := &LRUCache{
+		maxEntries: DefaultMaxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+	if cfg != nil {
+		if cfg.MaxEntries > 0 {
+			c.maxEntries = cfg.MaxEntries
+		}
+		c.maxTTL = max(cfg.MaxTTL, 0)
+	}
+	return c
+}
+
+func (c *LRUCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.lookup(key); e != nil {
+		return e.value, nil
+	}
+	return "", nil
+}
+
+func (c *LRUCache) GetWithTTL(_ context.Context, key string) (string, time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.lookup(key)
+	if e == nil {
+		return "", 0, nil
+	}
+	if e.expiresAt.IsZero() {
+		return e.value, 0, nil
+	}
+	return e.value, e.expiresAt.Sub(c.now()), nil
+}
+
+func (c *LRUCache) MGet(_ context.Context, keys ...string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]string, len(keys))
+	for i, key := range keys {
+		if e := c.lookup(key); e != nil {
+			out[i] = e.value
+		}
+	}
+	return out, nil
+}
+
+func (c *LRUCache) Set(_ context.Context, key string, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store(key, value, c.expiry(ttl))
+	return nil
+}
+
+func (c *LRUCache) SetNX(_ context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lookup(key) != nil {
+		return false, nil
+	}
+	c.store(key, value, c.expiry(ttl))
+	return true, nil
+}
+
+func (c *LRUCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return c.IncrBy(ctx, key, 1, ttl)
+}
+
+func (c *LRUCache) IncrBy(_ context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int64
+	var expiresAt time.Time
+	if e := c.lookup(key); e != nil {
+		parsed, err := strconv.ParseInt(e.value, 10, 64)
+		if err != nil {
+			return 0, ErrNotInteger
+		}
+		n, expiresAt = parsed, e.expiresAt
+	}
+	n += delta
+	if expiresAt.IsZero() {
+		expiresAt = c.expiry(ttl)
+	}
+	c.store(key, strconv.FormatInt(n, 10), expiresAt)
+	return n, nil
+}
+
+func (c *LRUCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	return nil
+}
+
+// DeleteByPattern matches keys as Redis does: * and ? match any run of
+// characters and any one character, [...] a class, and \ escapes.
+func (c *LRUCache) DeleteByPattern(_ context.Context, pattern string) (int64, error) {
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var deleted int64
+	now := c.now()
+	for key, el := range c.entries {
+		if !re.MatchString(key) {
+			continue
+		}
+		if !c.expired(el.Value.(*entry), now) {
+			deleted++
+		}
+		c.remove(el)
+	}
+	return deleted, nil
+}
+
+func (c *LRUCache) Ping(context.Context) error {
+	return nil
+}
+
+// Close drops every key.
+func (c *LRUCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+	return nil
+}
+
+// Len returns how many keys the cache holds, expired ones not yet dropped
+// included.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// lookup returns the live entry for key and marks it used. The caller holds
+// c.mu.
+func (c *LRUCache) lookup(key string) *entry {
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*entry)
+	if c.expired(e, c.now()) {
+		c.remove(el)
+		return nil
+	}
+	c.order.MoveToFront(el)
+	return e
+}
+
+// store sets key, evicting the least recently used keys past maxEntries. The
+// caller holds c.mu.
+func (c *LRUCache) store(key, value string, expiresAt time.Time) {
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *LRUCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
+
+// expiry returns when a key set now with ttl expires, capped by maxTTL, or
+// the zero time when it does not.
+func (c *LRUCache) expiry(ttl time.Duration) time.Time {
+	if c.maxTTL > 0 && (ttl <= 0 || ttl > c.maxTTL) {
+		ttl = c.maxTTL
+	}
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return c.now().Add(ttl)
+}
+
+func (c *LRUCache) expired(e *entry, now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// globRegexp translates a Redis glob-style pattern into an anchored regular
+// expression.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(pattern[i:]))
+				i = len(pattern)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			negate := strings.HasPrefix(class, "^")
+			class = strings.TrimPrefix(class, "^")
+			b.WriteByte('[')
+			if negate {
+				b.WriteByte('^')
+			}
+			for j := 0; j < len(class); j++ {
+				if class[j] == '-' && j > 0 && j < len(class)-1 {
+					b.WriteByte('-')
+					continue
+				}
+				if class[j] == '\\' && j+1 < len(class) {
+					j++
+				}
+				b.WriteString(regexp.QuoteMeta(class[j : j+1]))
+			}
+			b.WriteByte(']')
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteByte('$')
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,132 @@
+package lrucache
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestCache returns a cache on a clock the test moves with advance.
+func newTestCache(cfg *Config) (*LRUCache, func(time.Duration)) {
+	c := NewLRUCache(cfg)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c, _ := newTestCache(&Config{MaxEntries: 2})
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "a", "1", 0)
+	_ = c.Set(ctx, "b", "2", 0)
+	if v, _ := c.Get(ctx, "a"); v != "1" {
+		t.Fatalf("expected a=1, got %q", v)
+	}
+	_ = c.Set(ctx, "c", "3", 0)
+
+	if v, _ := c.Get(ctx, "b"); v != "" {
+		t.Fatalf("expected b, the least recently used key, to be evicted, got %q", v)
+	}
+	values, _ := c.MGet(ctx, "a", "b", "c")
+	if !slices.Equal(values, []string{"1", "", "3"}) {
+		t.Fatalf("unexpected values %q", values)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 keys, got %d", c.Len())
+	}
+}
+
+func TestLRUCache_Expiry(t *testing.T) {
+	c, advance := newTestCache(&Config{MaxTTL: time.Hour})
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "short", "1", time.Minute)
+	_ = c.Set(ctx, "forever", "2", 0)
+	_ = c.Set(ctx, "long", "3", 24*time.Hour)
+	if v, ttl, _ := c.GetWithTTL(ctx, "short"); v != "1" || ttl != time.Minute {
+		t.Fatalf("expected 1 with a minute left, got %q %v", v, ttl)
+	}
+	if _, ttl, _ := c.GetWithTTL(ctx, "forever"); ttl != time.Hour {
+		t.Fatalf("expected MaxTTL to cap a key without expiry, got %v", ttl)
+	}
+	if _, ttl, _ := c.GetWithTTL(ctx, "long"); ttl != time.Hour {
+		t.Fatalf("expected MaxTTL to cap a longer ttl, got %v", ttl)
+	}
+
+	advance(time.Minute)
+	if v, _ := c.Get(ctx, "short"); v != "" {
+		t.Fatalf("expected short to expire, got %q", v)
+	}
+	if ok, _ := c.SetNX(ctx, "short", "again", 0); !ok {
+		t.Fatal("expected SetNX to set an expired key")
+	}
+	if ok, _ := c.SetNX(ctx, "short", "other", 0); ok {
+		t.Fatal("expected SetNX to keep a live key")
+	}
+
+	uncapped, _ := newTestCache(nil)
+	_ = uncapped.Set(ctx, "k", "v", 0)
+	if v, ttl, _ := uncapped.GetWithTTL(ctx, "k"); v != "v" || ttl != 0 {
+		t.Fatalf("expected a key without expiry, got %q %v", v, ttl)
+	}
+}
+
+func TestLRUCache_Counters(t *testing.T) {
+	c, advance := newTestCache(nil)
+	ctx := context.Background()
+
+	if n, _ := c.Incr(ctx, "hits", time.Minute); n != 1 {
+		t.Fatalf("expected 1, got %d", n)
+	}
+	advance(30 * time.Second)
+	if n, _ := c.IncrBy(ctx, "hits", 4, time.Hour); n != 5 {
+		t.Fatalf("expected 5, got %d", n)
+	}
+	if _, ttl, _ := c.GetWithTTL(ctx, "hits"); ttl != 30*time.Second {
+		t.Fatalf("expected the counter to keep its first expiry, got %v", ttl)
+	}
+	advance(30 * time.Second)
+	if n, _ := c.Incr(ctx, "hits", 0); n != 1 {
+		t.Fatalf("expected the expired counter to restart, got %d", n)
+	}
+
+	_ = c.Set(ctx, "name", "alice", 0)
+	if _, err := c.Incr(ctx, "name", 0); !errors.Is(err, ErrNotInteger) {
+		t.Fatalf("expected ErrNotInteger, got %v", err)
+	}
+}
+
+func TestLRUCache_DeleteByPattern(t *testing.T) {
+	c, _ := newTestCache(nil)
+	ctx := context.Background()
+	for _, key := range []string{"session:1", "session:2", "session:ab", "sessions", "user:1", "a*b"} {
+		_ = c.Set(ctx, key, "x", 0)
+	}
+
+	for _, tc := range []struct {
+		pattern string
+		deleted int64
+		left    []string
+	}{
+		{"session:[12]", 2, []string{"session:ab", "sessions", "user:1", "a*b"}},
+		{`a\*b`, 1, []string{"session:ab", "sessions", "user:1"}},
+		{"session?*", 2, []string{"user:1"}},
+		{"*", 1, nil},
+	} {
+		deleted, err := c.DeleteByPattern(ctx, tc.pattern)
+		if err != nil || deleted != tc.deleted {
+			t.Fatalf("%s: expected %d deleted, got %d, %v", tc.pattern, tc.deleted, deleted, err)
+		}
+		for _, key := range tc.left {
+			if v, _ := c.Get(ctx, key); v == "" {
+				t.Fatalf("%s: expected %s to be kept", tc.pattern, key)
+			}
+		}
+		if c.Len() != len(tc.left) {
+			t.Fatalf("%s: expected %d keys left, got %d", tc.pattern, len(tc.left), c.Len())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Package mailer sends email through a pluggable provider. SMTPSender talks
+// to any SMTP relay, including Amazon SES through its SMTP interface, and
+// SendGridSender uses the SendGrid v3 API. Templates renders messages from
+// files, and Queue sends them in the background with retries:
+//
+//	msg, err := templates.Compose("welcome", data)
+//	msg.To = []string{user.Email}
+//	err = queue.Enqueue(msg, func(err error) { /* record delivery status */ })
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+var (
+	ErrNoSender     = errors.New("mailer: message has no sender")
+	ErrNoRecipients = errors.New("mailer: message has no recipients")
+	ErrNoBody       = errors.New("mailer: message has no body")
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Message is one email. Text and HTML are alternative bodies; at least one
+// is required, and clients that render HTML show it instead of Text.
+type Message struct {
+	From    string
+	To      []string
+	ReplyTo string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers messages through one provider. Errors that retrying cannot
+// fix, such as a rejected recipient, are marked with retry.Permanent.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// validate checks the addresses and body of msg, filling From with from
+// when it is empty.
+func (msg *Message) validate(from string) error {
+	if msg.From == "" {
+		msg.From = from
+	}
+	if msg.From == "" {
+		return ErrNoSender
+	}
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	if msg.Text == "" && msg.HTML == "" {
+		return ErrNoBody
+	}
+	for _, address := range append([]string{msg.From, msg.ReplyTo}, msg.To...) {
+		if address == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("mailer: invalid address %q: %w", address, err)
+		}
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return errors.New("mailer: subject contains a line break")
+	}
+	return nil
+}
+
+// LogSender logs messages instead of sending them, for development. From is
+// the sender of messages that do not name one.
+type LogSender struct {
+	Logger Logger
+	From   string
+}
+
+func (s LogSender) Send(_ context.Context, msg Message) error {
+	if err := msg.validate(s.From); err != nil {
+		return err
+	}
+	s.Logger.Info("Email not sent: no mail provider configured", "to", msg.To, "subject", msg.Subject)
+	return nil
+}
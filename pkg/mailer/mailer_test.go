@@ -0,0 +1,361 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
+	"github.com/akeren/go-api-foundry/pkg/retry"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+var fastBackoff = retry.Backoff{Initial: time.Millisecond, Max: time.Millisecond}
+
+// smtpServer accepts one session per connection and records what it was
+// sent. Recipients in reject get a permanent 550.
+type smtpServer struct {
+	addr   string
+	reject string
+
+	mu       sync.Mutex
+	from     string
+	rcpts    []string
+	data     string
+	sessions int
+}
+
+func startSMTPServer(t *testing.T, reject string) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpServer{addr: ln.Addr().String(), reject: reject}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.serve(conn)
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		wg.Wait()
+	})
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	s.mu.Lock()
+	s.sessions++
+	s.mu.Unlock()
+
+	tp.PrintfLine("220 test ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 test")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = arg
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			if s.reject != "" && strings.Contains(arg, s.reject) {
+				tp.PrintfLine("550 no such user")
+				continue
+			}
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, arg)
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 unknown")
+		}
+	}
+}
+
+func newTestSMTPSender(t *testing.T, s *smtpServer) *SMTPSender {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(s.addr)
+	n, _ := strconv.Atoi(port)
+	return NewSMTPSender(SMTPConfig{Host: host, Port: n, From: "Foundry <noreply@example.com>", Timeout: 5 * time.Second})
+}
+
+func TestSMTPSender_SendsMultipartMessage(t *testing.T) {
+	server := startSMTPServer(t, "")
+	sender := newTestSMTPSender(t, server)
+
+	err := sender.Send(context.Background(), Message{
+		To:      []string{"Zoë <zoe@example.com>", "ann@example.com"},
+		Subject: "Welcome, Zoë",
+		Text:    "Hello in text",
+		HTML:    "<p>Hello in HTML</p>",
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.from != "FROM:<noreply@example.com>" {
+		t.Fatalf("unexpected MAIL FROM %q", server.from)
+	}
+	if len(server.rcpts) != 2 || server.rcpts[0] != "TO:<zoe@example.com>" {
+		t.Fatalf("unexpected recipients %v", server.rcpts)
+	}
+	for _, want := range []string{
+		`From: "Foundry" <noreply@example.com>`,
+		"Subject: =?utf-8?q?Welcome,_Zo=C3=AB?=",
+		"Content-Type: multipart/alternative; boundary=",
+		"Content-Type: text/plain; charset=utf-8",
+		"Hello in text",
+		"<p>Hello in HTML</p>",
+	} {
+		if !strings.Contains(server.data, want) {
+			t.Fatalf("message lacks %q:\n%s", want, server.data)
+		}
+	}
+}
+
+func TestSMTPSender_RejectedRecipientIsPermanent(t *testing.T) {
+	server := startSMTPServer(t, "ghost@")
+	sender := newTestSMTPSender(t, server)
+
+	attempts := 0
+	err := retry.Do(context.Background(), fastBackoff, 3, func(ctx context.Context) error {
+		attempts++
+		return sender.Send(ctx, Message{To: []string{"ghost@example.com"}, Subject: "hi", Text: "hi"})
+	})
+	if err == nil || attempts != 1 {
+		t.Fatalf("expected one failed attempt, got %d: %v", attempts, err)
+	}
+}
+
+func TestMessage_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+		want error
+	}{
+		{"no sender", Message{To: []string{"a@example.com"}, Text: "x"}, ErrNoSender},
+		{"no recipients", Message{From: "a@example.com", Text: "x"}, ErrNoRecipients},
+		{"no body", Message{From: "a@example.com", To: []string{"b@example.com"}}, ErrNoBody},
+	}
+	for _, tt := range tests {
+		if err := tt.msg.validate(""); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	injected := Message{From: "a@example.com", To: []string{"b@example.com\r\nBcc: c@example.com"}, Text: "x"}
+	if err := injected.validate(""); err == nil {
+		t.Fatalf("expected an address with a line break to be rejected")
+	}
+	injected = Message{From: "a@example.com", To: []string{"b@example.com"}, Subject: "hi\r\nBcc: c@example.com", Text: "x"}
+	if err := injected.validate(""); err == nil {
+		t.Fatalf("expected a subject with a line break to be rejected")
+	}
+}
+
+func TestSendGridSender(t *testing.T) {
+	var requests atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusAccepted)
+	var got sendGridRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/v3/mail/send" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+	sender := NewSendGridSender(SendGridConfig{APIKey: "key", From: "Foundry <noreply@example.com>", BaseURL: server.URL})
+	msg := Message{To: []string{"ann@example.com"}, Subject: "hi", Text: "text", HTML: "<b>html</b>"}
+
+	if err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if got.From.Email != "noreply@example.com" || got.From.Name != "Foundry" || got.Personalizations[0].To[0].Email != "ann@example.com" {
+		t.Fatalf("unexpected payload %+v", got)
+	}
+	if len(got.Content) != 2 || got.Content[0].Type != "text/plain" || got.Content[1].Type != "text/html" {
+		t.Fatalf("unexpected content %+v", got.Content)
+	}
+
+	for _, tt := range []struct {
+		status   int
+		attempts int32
+	}{{http.StatusBadRequest, 1}, {http.StatusServiceUnavailable, 3}} {
+		status.Store(int32(tt.status))
+		requests.Store(0)
+		err := retry.Do(context.Background(), fastBackoff, 3, func(ctx context.Context) error {
+			return sender.Send(ctx, msg)
+		})
+		if err == nil || requests.Load() != tt.attempts {
+			t.Fatalf("status %d: expected %d attempts, got %d: %v", tt.status, tt.attempts, requests.Load(), err)
+		}
+	}
+}
+
+func TestTemplates_Compose(t *testing.T) {
+	fsys := fstest.MapFS{
+		"emails/welcome.subject.txt":         {Data: []byte("Welcome, {{.Name}}\n")},
+		"emails/welcome.txt":                 {Data: []byte("Hi {{.Name}}")},
+		"emails/welcome.html":                {Data: []byte("<p>Hi {{.Name}}</p>")},
+		"emails/billing/receipt.subject.txt": {Data: []byte("Receipt {{.ID}}")},
+		"emails/billing/receipt.txt":         {Data: []byte("Paid")},
+		"emails/README.md":                   {Data: []byte("ignored")},
+	}
+	templates, err := NewTemplates(fsys, "emails")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	msg, err := templates.Compose("welcome", map[string]string{"Name": "<Ann>"})
+	if err != nil {
+		t.Fatalf("compose: %v", err)
+	}
+	if msg.Subject != "Welcome, <Ann>" || msg.Text != "Hi <Ann>" || msg.HTML != "<p>Hi &lt;Ann&gt;</p>" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if msg, err := templates.Compose("billing/receipt", map[string]int{"ID": 7}); err != nil || msg.Subject != "Receipt 7" || msg.HTML != "" {
+		t.Fatalf("unexpected nested message %+v: %v", msg, err)
+	}
+	if _, err := templates.Compose("missing", nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("expected ErrTemplateNotFound, got %v", err)
+	}
+
+	if _, err := NewTemplates(fstest.MapFS{"t/x.txt": {Data: []byte("body")}}, "t"); err == nil {
+		t.Fatalf("expected a template without a subject to be rejected")
+	}
+}
+
+type flakySender struct {
+	mu       sync.Mutex
+	failures map[string]int
+	sent     []string
+}
+
+func (s *flakySender) Send(_ context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures[msg.Subject] > 0 {
+		s.failures[msg.Subject]--
+		return errors.New("provider unavailable")
+	}
+	s.sent = append(s.sent, msg.Subject)
+	return nil
+}
+
+func TestQueue_RetriesAndReportsDelivery(t *testing.T) {
+	sender := &flakySender{failures: map[string]int{"flaky": 2, "broken": 10}}
+	queue := NewQueue(sender, nopLogger{}, QueueConfig{Workers: 2, Attempts: 3, Backoff: fastBackoff})
+	queue.Start()
+
+	results := make(chan string, 3)
+	for _, subject := range []string{"ok", "flaky", "broken"} {
+		err := queue.Enqueue(Message{Subject: subject}, func(err error) {
+			if err != nil {
+				results <- subject + ": failed"
+				return
+			}
+			results <- subject + ": sent"
+		})
+		if err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	queue.Stop(context.Background())
+	close(results)
+
+	got := map[string]bool{}
+	for r := range results {
+		got[r] = true
+	}
+	for _, want := range []string{"ok: sent", "flaky: sent", "broken: failed"} {
+		if !got[want] {
+			t.Fatalf("expected %q, got %v", want, got)
+		}
+	}
+	if err := queue.Enqueue(Message{}, nil); !errors.Is(err, ErrQueueStopped) {
+		t.Fatalf("expected ErrQueueStopped, got %v", err)
+	}
+}
+
+func TestQueue_StopsCallingAFailingProvider(t *testing.T) {
+	sender := &flakySender{failures: map[string]int{"down": 10}}
+	breaker := circuitbreaker.New("mailer", circuitbreaker.Config{FailureThreshold: 2, OpenTimeout: time.Hour})
+	queue := NewQueue(sender, nopLogger{}, QueueConfig{Workers: 1, Attempts: 4, Backoff: fastBackoff, Breaker: breaker})
+	queue.Start()
+
+	var got error
+	if err := queue.Enqueue(Message{Subject: "down"}, func(err error) { got = err }); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	queue.Stop(context.Background())
+
+	if !errors.Is(got, circuitbreaker.ErrOpen) || sender.failures["down"] != 8 {
+		t.Fatalf("expected 2 calls to the provider before the breaker failed the rest, got %v with %d failures left", got, sender.failures["down"])
+	}
+}
+
+func TestQueue_RejectsWhenFull(t *testing.T) {
+	queue := NewQueue(&flakySender{}, nopLogger{}, QueueConfig{Size: 1})
+	defer queue.Stop(context.Background())
+
+	if err := queue.Enqueue(Message{}, nil); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := queue.Enqueue(Message{}, nil); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
+	"github.com/akeren/go-api-foundry/pkg/retry"
+)
+
+var (
+	ErrQueueFull    = errors.New("mailer: queue is full")
+	ErrQueueStopped = errors.New("mailer: queue is stopped")
+)
+
+type QueueConfig struct {
+	// Size caps the messages waiting to be sent. Default: 1000.
+	Size int
+	// Workers is how many messages are sent at once. Default: 4.
+	Workers int
+	// Attempts is how often a message is tried before it fails. Default: 5.
+	Attempts int
+	// Backoff spaces the attempts of a message.
+	Backoff retry.Backoff
+	// Breaker, when set, stops calls to the provider while it keeps
+	// failing; attempts then fail with circuitbreaker.ErrOpen. Permanent
+	// errors, such as a rejected recipient, do not count against it.
+	Breaker *circuitbreaker.Breaker
+}
+
+// Queue sends messages in the background so requests do not wait on the
+// provider. Messages are held in memory: those still queued when the process
+// dies are lost, so callers that must not lose one record it first and mark
+// it sent in the done callback.
+type Queue struct {
+	sender Sender
+	logger Logger
+	cfg    QueueConfig
+
+	mu      sync.RWMutex
+	started bool
+	stopped bool
+	jobs    chan queuedMessage
+	wg      sync.WaitGroup
+
+	// ctx is cancelled when Stop gives up waiting for the queue to drain.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type queuedMessage struct {
+	msg  Message
+	done func(error)
+}
+
+func NewQueue(sender Sender, logger Logger, cfg QueueConfig) *Queue {
+	if cfg.Size <= 0 {
+		cfg.Size = 1000
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = 5
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		sender: sender,
+		logger: logger,
+		cfg:    cfg,
+		jobs:   make(chan queuedMessage, cfg.Size),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Enqueue queues msg and returns at once. done, when non-nil, is called from
+// a worker with nil once msg is sent, or with the last error once every
+// attempt has failed or Stop gave up on it.
+func (q *Queue) Enqueue(msg Message, done func(error)) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.stopped {
+		return ErrQueueStopped
+	}
+	select {
+	case q.jobs <- queuedMessage{msg: msg, done: done}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Start sends queued messages until Stop.
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started || q.stopped {
+		return
+	}
+	q.started = true
+	for range q.cfg.Workers {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for job := range q.jobs {
+				q.send(job)
+			}
+		}()
+	}
+}
+
+func (q *Queue) send(job queuedMessage) {
+	err := q.ctx.Err()
+	if err == nil {
+		err = retry.Do(q.ctx, q.cfg.Backoff, q.cfg.Attempts, func(ctx context.Context) error {
+			generation, err := q.cfg.Breaker.Allow()
+			if err != nil {
+				return err
+			}
+			err = q.sender.Send(ctx, job.msg)
+			q.cfg.Breaker.Done(generation, circuitbreaker.IsFailure(err) && !retry.IsPermanent(err))
+			return err
+		})
+	}
+	if err != nil {
+		q.logger.Error("Failed to send email", "to", job.msg.To, "subject", job.msg.Subject, "error", err)
+	}
+	if job.done != nil {
+		job.done(err)
+	}
+}
+
+// Stop refuses new messages and sends those already queued until ctx ends.
+// Messages still queued or retrying then fail with the context's error.
+func (q *Queue) Stop(ctx context.Context) {
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return
+	}
+	q.stopped = true
+	close(q.jobs)
+	started := q.started
+	q.mu.Unlock()
+
+	defer q.cancel()
+	if !started {
+		return
+	}
+	drained := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		q.cancel()
+		<-drained
+	}
+}
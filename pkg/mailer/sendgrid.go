@@ -0,0 +1,128 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/retry"
+)
+
+type SendGridConfig struct {
+	APIKey string
+	// From is the sender of messages that do not name one.
+	From string
+	// BaseURL defaults to https://api.sendgrid.com.
+	BaseURL string
+	// Timeout bounds each request. Default: 10s.
+	Timeout time.Duration
+}
+
+// SendGridSender sends messages with the SendGrid v3 mail send API.
+type SendGridSender struct {
+	cfg    SendGridConfig
+	client *http.Client
+}
+
+func NewSendGridSender(cfg SendGridConfig) *SendGridSender {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.sendgrid.com"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	return &SendGridSender{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridRequest struct {
+	Personalizations []struct {
+		To []sendGridAddress `json:"to"`
+	} `json:"personalizations"`
+	From    sendGridAddress   `json:"from"`
+	ReplyTo *sendGridAddress  `json:"reply_to,omitempty"`
+	Subject string            `json:"subject"`
+	Content []sendGridContent `json:"content"`
+}
+
+func (s *SendGridSender) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(s.cfg.From); err != nil {
+		return retry.Permanent(err)
+	}
+	payload, err := json.Marshal(sendGridPayload(msg))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.BaseURL+"/v3/mail/send", bytes.NewReader(payload))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("mailer: sendgrid returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return retry.Permanent(err)
+}
+
+// sendGridPayload converts a validated message. SendGrid requires the plain
+// text body before the HTML one.
+func sendGridPayload(msg Message) sendGridRequest {
+	var req sendGridRequest
+	req.Personalizations = make([]struct {
+		To []sendGridAddress `json:"to"`
+	}, 1)
+	for _, to := range msg.To {
+		req.Personalizations[0].To = append(req.Personalizations[0].To, sendGridAddressOf(to))
+	}
+	req.From = sendGridAddressOf(msg.From)
+	if msg.ReplyTo != "" {
+		replyTo := sendGridAddressOf(msg.ReplyTo)
+		req.ReplyTo = &replyTo
+	}
+	req.Subject = msg.Subject
+	if msg.Text != "" {
+		req.Content = append(req.Content, sendGridContent{Type: "text/plain", Value: msg.Text})
+	}
+	if msg.HTML != "" {
+		req.Content = append(req.Content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+	return req
+}
+
+func sendGridAddressOf(address string) sendGridAddress {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return sendGridAddress{Email: address}
+	}
+	return sendGridAddress{Email: parsed.Address, Name: parsed.Name}
+}
@@ -0,0 +1,240 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/retry"
+)
+
+type SMTPConfig struct {
+	Host string
+	// Port defaults to 587, where the connection is upgraded with STARTTLS
+	// when the server offers it. Port 465 uses TLS from the start.
+	Port     int
+	Username string
+	Password string
+	// From is the sender of messages that do not name one.
+	From string
+	// Timeout bounds the whole exchange with the server. Default: 30s.
+	Timeout time.Duration
+}
+
+// SESConfig is the SMTP configuration of Amazon SES in region, with the SMTP
+// credentials created in the SES console (not the IAM access key).
+func SESConfig(region, username, password, from string) SMTPConfig {
+	return SMTPConfig{
+		Host:     "email-smtp." + region + ".amazonaws.com",
+		Port:     587,
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+// SMTPSender sends each message over a new connection to an SMTP relay.
+type SMTPSender struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &SMTPSender{cfg: cfg}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(s.cfg.From); err != nil {
+		return retry.Permanent(err)
+	}
+	body, err := msg.mime(time.Now())
+	if err != nil {
+		return retry.Permanent(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	// net/smtp takes no context, so closing the connection stands in for
+	// cancelling the exchange.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	err = s.deliver(conn, msg, body)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return retry.Permanent(err)
+	}
+	return err
+}
+
+func (s *SMTPSender) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	if s.cfg.Port == 465 {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+func (s *SMTPSender) deliver(conn net.Conn, msg Message, body []byte) error {
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted
+		// connection to anything but localhost.
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(envelopeAddress(msg.From)); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(envelopeAddress(to)); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// envelopeAddress strips the display name of a validated address.
+func envelopeAddress(address string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		return parsed.Address
+	}
+	return address
+}
+
+// headerAddress encodes the display name of a validated address for a
+// message header.
+func headerAddress(address string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		return parsed.String()
+	}
+	return address
+}
+
+// mime encodes msg as a MIME message: a single part for one body, or
+// multipart/alternative for both.
+func (msg *Message) mime(now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", headerAddress(msg.From))
+	to := make([]string, len(msg.To))
+	for i, address := range msg.To {
+		to[i] = headerAddress(address)
+	}
+	header("To", strings.Join(to, ", "))
+	if msg.ReplyTo != "" {
+		header("Reply-To", headerAddress(msg.ReplyTo))
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(msg.From))
+	header("MIME-Version", "1.0")
+
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, body := "text/plain; charset=utf-8", msg.Text
+		if msg.HTML != "" {
+			contentType, body = "text/html; charset=utf-8", msg.HTML
+		}
+		header("Content-Type", contentType)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if _, host, ok := strings.Cut(envelopeAddress(from), "@"); ok {
+		domain = host
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return "<" + hex.EncodeToString(id) + "@" + domain + ">"
+}
@@ -0,0 +1,127 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+var ErrTemplateNotFound = errors.New("mailer: template not found")
+
+const (
+	subjectExt = ".subject.txt"
+	textExt    = ".txt"
+	htmlExt    = ".html"
+)
+
+// Templates renders messages from files under a root directory. A message
+// named "welcome" is made of welcome.subject.txt and at least one of
+// welcome.txt and welcome.html; names may contain slashes. The subject and
+// text are text/template, the HTML body is html/template so data is escaped.
+type Templates struct {
+	messages map[string]*messageTemplate
+}
+
+type messageTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+func NewTemplates(fsys fs.FS, root string) (*Templates, error) {
+	root = strings.Trim(root, "/")
+	if root == "" {
+		root = "."
+	}
+	templates := &Templates{messages: make(map[string]*messageTemplate)}
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("mailer: walk %q: %w", p, err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel := strings.TrimPrefix(p, root+"/")
+		var name string
+		var ok bool
+		for _, ext := range []string{subjectExt, textExt, htmlExt} {
+			if name, ok = strings.CutSuffix(rel, ext); ok {
+				break
+			}
+		}
+		if !ok {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("mailer: read %q: %w", p, err)
+		}
+		message := templates.messages[name]
+		if message == nil {
+			message = &messageTemplate{}
+			templates.messages[name] = message
+		}
+		switch {
+		case strings.HasSuffix(rel, subjectExt):
+			message.subject, err = texttemplate.New(name).Parse(strings.TrimSpace(string(content)))
+		case strings.HasSuffix(rel, textExt):
+			message.text, err = texttemplate.New(name).Parse(string(content))
+		default:
+			message.html, err = htmltemplate.New(name).Parse(string(content))
+		}
+		if err != nil {
+			return fmt.Errorf("mailer: parse %q: %w", p, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name, message := range templates.messages {
+		if message.subject == nil {
+			return nil, fmt.Errorf("mailer: template %q has no %s", name, name+subjectExt)
+		}
+		if message.text == nil && message.html == nil {
+			return nil, fmt.Errorf("mailer: template %q has no body", name)
+		}
+	}
+	return templates, nil
+}
+
+// Compose renders the named message with data. The caller sets To.
+func (t *Templates) Compose(name string, data any) (Message, error) {
+	message, ok := t.messages[name]
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	var msg Message
+	var buf bytes.Buffer
+	if err := message.subject.Execute(&buf, data); err != nil {
+		return Message{}, fmt.Errorf("mailer: execute %q subject: %w", name, err)
+	}
+	// A subject is one header line.
+	msg.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	if message.text != nil {
+		buf.Reset()
+		if err := message.text.Execute(&buf, data); err != nil {
+			return Message{}, fmt.Errorf("mailer: execute %q text: %w", name, err)
+		}
+		msg.Text = buf.String()
+	}
+	if message.html != nil {
+		buf.Reset()
+		if err := message.html.Execute(&buf, data); err != nil {
+			return Message{}, fmt.Errorf("mailer: execute %q html: %w", name, err)
+		}
+		msg.HTML = buf.String()
+	}
+	return msg, nil
+}
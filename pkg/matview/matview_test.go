@@ -0,0 +1,113 @@
+package matview
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestRefresh_RequiresPostgres(t *testing.T) {
+	db := openSQLite(t)
+	if _, err := Refresh(context.Background(), db, "account_daily_balances"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Refresh on sqlite: got %v, want ErrUnsupported", err)
+	}
+	if err := Check(context.Background(), db, "account_daily_balances"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Check on sqlite: got %v, want ErrUnsupported", err)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	if got := quoteIdentifier(`daily"; DROP TABLE accounts; --`); got != `"daily""; DROP TABLE accounts; --"` {
+		t.Fatalf("quoteIdentifier = %s", got)
+	}
+}
+
+func TestRefresher(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	refreshed := make(chan string, 16)
+	refresh = func(ctx context.Context, _ *gorm.DB, name string) (bool, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("refresh ran without a deadline")
+		}
+		mu.Lock()
+		calls[name]++
+		mu.Unlock()
+		select {
+		case refreshed <- name:
+		default:
+		}
+		if name == "broken" {
+			return false, errors.New("boom")
+		}
+		return true, nil
+	}
+	checked := make(chan string, 3)
+	check = func(_ context.Context, _ *gorm.DB, name string) error {
+		checked <- name
+		return nil
+	}
+	t.Cleanup(func() { refresh, check = Refresh, Check })
+
+	r := NewRefresher(nopLogger{}, nil, []View{
+		{Name: "fast", Interval: 10 * time.Millisecond},
+		{Name: "slow"},
+		{Name: "broken", Interval: 10 * time.Millisecond},
+	}, RefresherConfig{Interval: time.Hour})
+	r.Start()
+	r.Start()
+
+	// Every view is refreshed at once, and the fast ones again on their
+	// interval; a failing view does not stop the others.
+	deadline := time.After(2 * time.Second)
+	for {
+		mu.Lock()
+		done := calls["fast"] >= 3 && calls["broken"] >= 3 && calls["slow"] >= 1
+		mu.Unlock()
+		if done {
+			break
+		}
+		select {
+		case <-refreshed:
+		case <-deadline:
+			t.Fatalf("views not refreshed: %v", calls)
+		}
+	}
+	r.Stop()
+	if len(checked) != 3 {
+		t.Fatalf("%d views checked, want 3", len(checked))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["slow"] != 1 {
+		t.Fatalf("slow view refreshed %d times, want 1", calls["slow"])
+	}
+}
+
+func TestRefresher_StopWithoutStart(t *testing.T) {
+	NewRefresher(nopLogger{}, nil, []View{{Name: "v"}}, RefresherConfig{}).Stop()
+}
@@ -0,0 +1,139 @@
+package matview
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// View is a materialized view kept fresh by a Refresher.
+type View struct {
+	Name string
+	// Interval between refreshes. Readers see rows up to this old.
+	Interval time.Duration
+}
+
+type RefresherConfig struct {
+	// Interval is used for views that do not set their own.
+	Interval time.Duration
+	// Timeout bounds a single refresh.
+	Timeout time.Duration
+}
+
+func DefaultRefresherConfig() RefresherConfig {
+	return RefresherConfig{
+		Interval: 5 * time.Minute,
+		Timeout:  2 * time.Minute,
+	}
+}
+
+// refresh and check are replaced in tests, which have no PostgreSQL.
+var (
+	refresh = Refresh
+	check   = Check
+)
+
+// Refresher refreshes each of its views when it starts, which populates views
+// created WITH NO DATA, and then every view's interval. A view that fails
+// Check is logged as an error but still refreshed.
+type Refresher struct {
+	cfg    RefresherConfig
+	logger Logger
+	db     *gorm.DB
+	views  []View
+
+	mu      sync.Mutex
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRefresher(logger Logger, db *gorm.DB, views []View, cfg RefresherConfig) *Refresher {
+	defaults := DefaultRefresherConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	views = append([]View(nil), views...)
+	for i := range views {
+		if views[i].Interval <= 0 {
+			views[i].Interval = cfg.Interval
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Refresher{
+		cfg:    cfg,
+		logger: logger,
+		db:     db,
+		views:  views,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start refreshes every view now and then on its interval until Stop.
+func (r *Refresher) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return
+	}
+	r.started = true
+	for _, view := range r.views {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			if err := check(r.ctx, r.db, view.Name); err != nil && r.ctx.Err() == nil {
+				r.logger.Error("Materialized view is not ready for concurrent refreshes", "view", view.Name, "error", err)
+			}
+			ticker := time.NewTicker(view.Interval)
+			defer ticker.Stop()
+			for {
+				_ = r.Refresh(r.ctx, view.Name)
+				select {
+				case <-ticker.C:
+				case <-r.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Stop ends the refreshes and waits for those in flight, which are cancelled.
+func (r *Refresher) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// Refresh refreshes one view now. On failure the view keeps its old rows,
+// which go on aging until a later refresh succeeds.
+func (r *Refresher) Refresh(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	refreshed, err := refresh(ctx, r.db, name)
+	if err != nil {
+		if r.ctx.Err() == nil {
+			r.logger.Warn("Materialized view refresh failed", "view", name, "error", err)
+		}
+		return err
+	}
+	if refreshed {
+		r.logger.Info("Materialized view refreshed", "view", name, "duration", time.Since(started))
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/retry"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+type KafkaConfig struct {
+	Brokers  []string
+	ClientID string
+	// WriteTimeout bounds each produce request.
+	WriteTimeout time.Duration
+	// HandlerAttempts is how often a consumer calls a failing handler for one
+	// message before logging it and moving on, so one bad message cannot stall
+	// its partition.
+	HandlerAttempts int
+	// HandlerBackoff spaces those calls.
+	HandlerBackoff retry.Backoff
+}
+
+func DefaultKafkaConfig() KafkaConfig {
+	return KafkaConfig{
+		ClientID:        "go-api-foundry",
+		WriteTimeout:    10 * time.Second,
+		HandlerAttempts: 5,
+		HandlerBackoff:  retry.Backoff{Initial: 200 * time.Millisecond, Max: 10 * time.Second, Multiplier: 2, Jitter: 0.2},
+	}
+}
+
+// Kafka publishes to and consumes from a Kafka cluster. Writers are created
+// per topic on first use.
+type Kafka struct {
+	cfg    KafkaConfig
+	logger Logger
+	dialer *kafka.Dialer
+
+	mu        sync.Mutex
+	writers   map[string]*kafka.Writer
+	consumers map[*kafkaConsumer]struct{}
+	closed    bool
+}
+
+func NewKafka(logger Logger, cfg KafkaConfig) *Kafka {
+	defaults := DefaultKafkaConfig()
+	if cfg.ClientID == "" {
+		cfg.ClientID = defaults.ClientID
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = defaults.WriteTimeout
+	}
+	if cfg.HandlerAttempts <= 0 {
+		cfg.HandlerAttempts = defaults.HandlerAttempts
+	}
+	if cfg.HandlerBackoff == (retry.Backoff{}) {
+		cfg.HandlerBackoff = defaults.HandlerBackoff
+	}
+	return &Kafka{
+		cfg:       cfg,
+		logger:    logger,
+		dialer:    &kafka.Dialer{ClientID: cfg.ClientID, Timeout: cfg.WriteTimeout},
+		writers:   make(map[string]*kafka.Writer),
+		consumers: make(map[*kafkaConsumer]struct{}),
+	}
+}
+
+// Publish writes msgs and waits for every in-sync replica to acknowledge them.
+func (k *Kafka) Publish(ctx context.Context, msgs ...Message) error {
+	byTopic := make(map[string][]kafka.Message)
+	for _, msg := range msgs {
+		byTopic[msg.Topic] = append(byTopic[msg.Topic], toKafkaMessage(msg))
+	}
+	for topic, batch := range byTopic {
+		w, err := k.writer(topic)
+		if err != nil {
+			return err
+		}
+		if err := w.WriteMessages(ctx, batch...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *Kafka) writer(topic string) (*kafka.Writer, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.closed {
+		return nil, ErrClosed
+	}
+	if w, ok := k.writers[topic]; ok {
+		return w, nil
+	}
+	w := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:      k.cfg.Brokers,
+		Topic:        topic,
+		Dialer:       k.dialer,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: -1,
+		// Publish waits for its batch, so send batches without lingering.
+		BatchTimeout: 10 * time.Millisecond,
+		WriteTimeout: k.cfg.WriteTimeout,
+	})
+	k.writers[topic] = w
+	return w, nil
+}
+
+// Ping reports whether any broker accepts connections.
+func (k *Kafka) Ping(ctx context.Context) error {
+	var errs []error
+	for _, broker := range k.cfg.Brokers {
+		conn, err := k.dialer.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			return conn.Close()
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return errors.New("messaging: no Kafka brokers configured")
+	}
+	return errors.Join(errs...)
+}
+
+// Consumer returns a consumer of topic as a member of group.
+func (k *Kafka) Consumer(topic, group string) Consumer {
+	c := &kafkaConsumer{
+		kafka: k,
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: k.cfg.Brokers,
+			GroupID: group,
+			Topic:   topic,
+			Dialer:  k.dialer,
+		}),
+		topic: topic,
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.closed {
+		_ = c.reader.Close()
+		c.closed = true
+		return c
+	}
+	k.consumers[c] = struct{}{}
+	return c
+}
+
+// CloseConsumers closes every consumer so no new messages are taken.
+func (k *Kafka) CloseConsumers() error {
+	k.mu.Lock()
+	consumers := make([]*kafkaConsumer, 0, len(k.consumers))
+	for c := range k.consumers {
+		consumers = append(consumers, c)
+	}
+	k.mu.Unlock()
+
+	var errs []error
+	for _, c := range consumers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the consumers and flushes and closes the writers.
+func (k *Kafka) Close() error {
+	err := k.CloseConsumers()
+
+	k.mu.Lock()
+	k.closed = true
+	writers := k.writers
+	k.writers = make(map[string]*kafka.Writer)
+	k.mu.Unlock()
+
+	errs := []error{err}
+	for _, w := range writers {
+		errs = append(errs, w.Close())
+	}
+	return errors.Join(errs...)
+}
+
+type kafkaConsumer struct {
+	kafka  *Kafka
+	reader *kafka.Reader
+	topic  string
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *kafkaConsumer) Consume(ctx context.Context, handler Handler) error {
+	for {
+		m, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if c.isClosed() {
+				return ErrClosed
+			}
+			return err
+		}
+
+		msg := fromKafkaMessage(m)
+		err = retry.Do(ctx, c.kafka.cfg.HandlerBackoff, c.kafka.cfg.HandlerAttempts, func(ctx context.Context) error {
+			return handler(ctx, msg)
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.kafka.logger.Error("Skipping message after failed handler attempts",
+				"topic", m.Topic, "partition", m.Partition, "offset", m.Offset, "attempts", c.kafka.cfg.HandlerAttempts, "error", err)
+		}
+		if err := c.reader.CommitMessages(ctx, m); err != nil {
+			if c.isClosed() {
+				return ErrClosed
+			}
+			return err
+		}
+	}
+}
+
+func (c *kafkaConsumer) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *kafkaConsumer) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.kafka.mu.Lock()
+	delete(c.kafka.consumers, c)
+	c.kafka.mu.Unlock()
+	return c.reader.Close()
+}
+
+func toKafkaMessage(msg Message) kafka.Message {
+	m := kafka.Message{Key: msg.Key, Value: msg.Value, Time: msg.Time}
+	for k, v := range msg.Headers {
+		m.Headers = append(m.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return m
+}
+
+func fromKafkaMessage(m kafka.Message) Message {
+	msg := Message{Topic: m.Topic, Key: m.Key, Value: m.Value, Time: m.Time}
+	if len(m.Headers) > 0 {
+		msg.Headers = make(map[string]string, len(m.Headers))
+		for _, h := range m.Headers {
+			msg.Headers[h.Key] = string(h.Value)
+		}
+	}
+	return msg
+}
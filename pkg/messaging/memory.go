@@ -0,0 +1,151 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBroker keeps topics in process memory, for tests and single-process
+// development. Each consumer group reads a topic from its first message and
+// retries a failing message until its handler succeeds.
+type MemoryBroker struct {
+	mu     sync.Mutex
+	topics map[string][]Message
+	// offsets holds the next index per topic and group.
+	offsets   map[string]int
+	changed   chan struct{}
+	consumers []*memoryConsumer
+	closed    bool
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		topics:  make(map[string][]Message),
+		offsets: make(map[string]int),
+		changed: make(chan struct{}),
+	}
+}
+
+func (b *MemoryBroker) Publish(_ context.Context, msgs ...Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	for _, msg := range msgs {
+		if msg.Time.IsZero() {
+			msg.Time = time.Now().UTC()
+		}
+		b.topics[msg.Topic] = append(b.topics[msg.Topic], msg)
+	}
+	close(b.changed)
+	b.changed = make(chan struct{})
+	return nil
+}
+
+// Messages returns what has been published to topic.
+func (b *MemoryBroker) Messages(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Message(nil), b.topics[topic]...)
+}
+
+// Ping reports whether the broker is open.
+func (b *MemoryBroker) Ping(context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
+// Consumer returns a consumer of topic for group.
+func (b *MemoryBroker) Consumer(topic, group string) Consumer {
+	c := &memoryConsumer{broker: b, topic: topic, key: topic + "\x00" + group, done: make(chan struct{})}
+	b.mu.Lock()
+	b.consumers = append(b.consumers, c)
+	b.mu.Unlock()
+	return c
+}
+
+// CloseConsumers ends every consumer and leaves publishing open.
+func (b *MemoryBroker) CloseConsumers() error {
+	b.mu.Lock()
+	consumers := b.consumers
+	b.consumers = nil
+	b.mu.Unlock()
+	for _, c := range consumers {
+		_ = c.Close()
+	}
+	return nil
+}
+
+// Close ends every consumer and rejects further publishes.
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.changed)
+	}
+	return nil
+}
+
+type memoryConsumer struct {
+	broker *MemoryBroker
+	topic  string
+	key    string
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (c *memoryConsumer) Consume(ctx context.Context, handler Handler) error {
+	for {
+		c.broker.mu.Lock()
+		closed := c.broker.closed
+		offset := c.broker.offsets[c.key]
+		var msg *Message
+		if offset < len(c.broker.topics[c.topic]) {
+			m := c.broker.topics[c.topic][offset]
+			msg = &m
+		}
+		changed := c.broker.changed
+		c.broker.mu.Unlock()
+
+		if closed {
+			return ErrClosed
+		}
+		if msg == nil {
+			select {
+			case <-changed:
+				continue
+			case <-c.done:
+				return ErrClosed
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err := handler(ctx, *msg); err != nil {
+			// Not committed; wait a little and hand it over again.
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-c.done:
+				return ErrClosed
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+		c.broker.mu.Lock()
+		c.broker.offsets[c.key] = offset + 1
+		c.broker.mu.Unlock()
+	}
+}
+
+func (c *memoryConsumer) Close() error {
+	c.once.Do(func() { close(c.done) })
+	return nil
+}
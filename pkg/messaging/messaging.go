@@ -0,0 +1,95 @@
+// Package messaging publishes and consumes messages on an external broker,
+// so events can leave the process and other services can feed work in. Kafka
+// and NATS JetStream implementations are used in production; MemoryBroker
+// stands in for them in tests.
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/events"
+)
+
+// ErrClosed is returned by publishers and consumers after Close.
+var ErrClosed = errors.New("messaging: closed")
+
+// Message is one record on a topic. Messages with the same Key go to the same
+// partition and so keep their order.
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+	Time    time.Time
+}
+
+// Publisher sends messages. Publish returns once the broker has accepted
+// every message, or with the first error.
+type Publisher interface {
+	Publish(ctx context.Context, msgs ...Message) error
+}
+
+// Handler processes a consumed message. Returning an error has the consumer
+// hand the message over again; the Kafka consumer gives up, logs and commits
+// it after KafkaConfig.HandlerAttempts.
+type Handler func(ctx context.Context, msg Message) error
+
+// Consumer reads one topic as a member of a consumer group.
+type Consumer interface {
+	// Consume calls handler for each message, one at a time, until ctx ends
+	// or the consumer is closed. A message is committed only after handler
+	// is done with it, so delivery is at-least-once.
+	Consume(ctx context.Context, handler Handler) error
+	Close() error
+}
+
+// Broker is a connection to a message broker: Kafka, NATS or MemoryBroker.
+type Broker interface {
+	Publisher
+	// Consumer returns a consumer of topic as a member of group.
+	Consumer(topic, group string) Consumer
+	// Ping reports whether the broker can be reached.
+	Ping(ctx context.Context) error
+	// CloseConsumers stops every consumer so no new messages are taken, and
+	// leaves publishing open.
+	CloseConsumers() error
+	Close() error
+}
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Header keys set on forwarded events.
+const (
+	HeaderEventID   = "event-id"
+	HeaderEventType = "event-type"
+)
+
+// Forward returns an event bus handler that publishes each event to topic as
+// its JSON envelope, keyed by event ID. Consumers deduplicate by the event-id
+// header, since the bus may deliver the same event twice.
+func Forward(publisher Publisher, topic string, logger Logger) events.Handler {
+	return func(ctx context.Context, event events.Event) {
+		value, err := json.Marshal(event)
+		if err != nil {
+			logger.Error("Failed to encode event for the message queue", "type", event.Type, "id", event.ID, "error", err)
+			return
+		}
+		msg := Message{
+			Topic:   topic,
+			Key:     []byte(event.ID),
+			Value:   value,
+			Headers: map[string]string{HeaderEventID: event.ID, HeaderEventType: event.Type},
+			Time:    event.OccurredAt,
+		}
+		if err := publisher.Publish(ctx, msg); err != nil {
+			logger.Error("Failed to publish event to the message queue", "topic", topic, "type", event.Type, "id", event.ID, "error", err)
+		}
+	}
+}
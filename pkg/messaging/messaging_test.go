@@ -0,0 +1,173 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/events"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+func TestForward_PublishesEventEnvelope(t *testing.T) {
+	broker := NewMemoryBroker()
+	defer broker.Close()
+
+	event := events.Event{ID: "evt-1", Type: "transaction.posted", OccurredAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Data: map[string]int{"amount": 100}}
+	Forward(broker, "ledger.events", nopLogger{})(context.Background(), event)
+
+	msgs := broker.Messages("ledger.events")
+	if len(msgs) != 1 {
+		t.Fatalf("expected one message, got %d", len(msgs))
+	}
+	msg := msgs[0]
+	if string(msg.Key) != "evt-1" || msg.Headers[HeaderEventType] != "transaction.posted" || !msg.Time.Equal(event.OccurredAt) {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	var decoded events.Event
+	if err := json.Unmarshal(msg.Value, &decoded); err != nil || decoded.ID != "evt-1" {
+		t.Fatalf("expected the event envelope, got %s (%v)", msg.Value, err)
+	}
+}
+
+func TestMemoryConsumer_RetriesUntilHandled(t *testing.T) {
+	broker := NewMemoryBroker()
+	defer broker.Close()
+	consumer := broker.Consumer("jobs", "workers")
+
+	var calls atomic.Int32
+	handled := make(chan string, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- consumer.Consume(ctx, func(_ context.Context, msg Message) error {
+			if string(msg.Value) == "first" && calls.Add(1) < 3 {
+				return errors.New("not yet")
+			}
+			handled <- string(msg.Value)
+			return nil
+		})
+	}()
+
+	if err := broker.Publish(context.Background(), Message{Topic: "jobs", Value: []byte("first")}, Message{Topic: "jobs", Value: []byte("second")}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	for _, want := range []string{"first", "second"} {
+		select {
+		case got := <-handled:
+			if got != want {
+				t.Fatalf("expected %q, got %q", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %q to be handled", want)
+		}
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("expected the failing message to be handed over 3 times, got %d", calls.Load())
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected Consume to end with the context, got %v", err)
+	}
+}
+
+func TestKafkaMessageConversion(t *testing.T) {
+	msg := Message{Topic: "t", Key: []byte("k"), Value: []byte("v"), Headers: map[string]string{HeaderEventID: "evt-1"}}
+	m := toKafkaMessage(msg)
+	m.Topic = "t"
+	back := fromKafkaMessage(m)
+	if string(back.Key) != "k" || string(back.Value) != "v" || back.Headers[HeaderEventID] != "evt-1" || back.Topic != "t" {
+		t.Fatalf("expected the message to survive conversion, got %+v", back)
+	}
+}
+
+func TestKafka_PingAndClose(t *testing.T) {
+	k := NewKafka(nopLogger{}, KafkaConfig{Brokers: []string{"127.0.0.1:1"}, WriteTimeout: time.Second})
+	if err := k.Ping(context.Background()); err == nil {
+		t.Fatal("expected ping to fail without a reachable broker")
+	}
+
+	consumer := k.Consumer("jobs", "workers")
+	if err := k.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := consumer.Consume(context.Background(), func(context.Context, Message) error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected a closed consumer, got %v", err)
+	}
+	if err := k.Publish(context.Background(), Message{Topic: "jobs"}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected publishing after Close to fail, got %v", err)
+	}
+}
+
+func TestMemoryBroker_CloseConsumersKeepsPublishing(t *testing.T) {
+	broker := NewMemoryBroker()
+	defer broker.Close()
+
+	consumer := broker.Consumer("jobs", "workers")
+	done := make(chan error, 1)
+	go func() {
+		done <- consumer.Consume(context.Background(), func(context.Context, Message) error { return nil })
+	}()
+
+	if err := broker.CloseConsumers(); err != nil {
+		t.Fatalf("close consumers: %v", err)
+	}
+	if err := <-done; !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected the consumer to stop, got %v", err)
+	}
+	if err := broker.Publish(context.Background(), Message{Topic: "jobs"}); err != nil {
+		t.Fatalf("expected publishing to stay open, got %v", err)
+	}
+}
+
+func TestNATSName(t *testing.T) {
+	if got := natsName("ledger.events.>"); got != "ledger_events__" {
+		t.Fatalf("unexpected name %q", got)
+	}
+}
+
+func TestToNATSMsg(t *testing.T) {
+	m := toNATSMsg(Message{Topic: "ledger.events", Key: []byte("evt-1"), Value: []byte("{}"), Headers: map[string]string{HeaderEventType: "transaction.posted"}})
+	if m.Subject != "ledger.events" || string(m.Data) != "{}" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+	if m.Header.Get(keyHeader) != "evt-1" || m.Header.Get(HeaderEventType) != "transaction.posted" {
+		t.Fatalf("expected key and headers to be carried in headers, got %v", m.Header)
+	}
+}
+
+func TestNATS_PingAndClose(t *testing.T) {
+	n, err := NewNATS(nopLogger{}, NATSConfig{URL: "nats://127.0.0.1:1", Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("expected an unreachable server to be retried, got %v", err)
+	}
+	if err := n.Ping(context.Background()); err == nil {
+		t.Fatal("expected ping to fail without a reachable server")
+	}
+
+	consumer := n.Consumer("jobs", "workers")
+	if err := n.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := consumer.Consume(context.Background(), func(context.Context, Message) error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected a closed consumer, got %v", err)
+	}
+	if err := n.Publish(context.Background(), Message{Topic: "jobs"}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected publishing after Close to fail, got %v", err)
+	}
+}
+
+func TestNewNATS_RejectsInvalidURL(t *testing.T) {
+	if _, err := NewNATS(nopLogger{}, NATSConfig{URL: "nats://%zz"}); err == nil {
+		t.Fatal("expected an invalid URL to be rejected")
+	}
+}
@@ -0,0 +1,351 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/retry"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// keyHeader carries Message.Key, which NATS has no field for.
+const keyHeader = "Messaging-Key"
+
+type NATSConfig struct {
+	URL  string
+	Name string
+	// Timeout bounds connecting and each publish.
+	Timeout time.Duration
+	// HandlerAttempts is how often a message is delivered to a failing handler
+	// before it is logged and terminated, so one bad message cannot stall its
+	// consumer.
+	HandlerAttempts int
+	// HandlerBackoff spaces those deliveries.
+	HandlerBackoff retry.Backoff
+}
+
+func DefaultNATSConfig() NATSConfig {
+	return NATSConfig{
+		URL:             nats.DefaultURL,
+		Name:            "go-api-foundry",
+		Timeout:         10 * time.Second,
+		HandlerAttempts: 5,
+		HandlerBackoff:  retry.Backoff{Initial: 200 * time.Millisecond, Max: 10 * time.Second, Multiplier: 2, Jitter: 0.2},
+	}
+}
+
+// NATS publishes to and consumes from NATS JetStream. Each topic is a subject
+// with a stream of its own, created on first use unless it already exists.
+type NATS struct {
+	cfg    NATSConfig
+	logger Logger
+	conn   *nats.Conn
+	js     jetstream.JetStream
+
+	mu        sync.Mutex
+	streams   map[string]bool
+	consumers map[*natsConsumer]struct{}
+	closed    bool
+}
+
+// NewNATS connects to cfg.URL. An unreachable server is not an error: the
+// connection keeps retrying in the background and Ping reports it as down.
+func NewNATS(logger Logger, cfg NATSConfig) (*NATS, error) {
+	defaults := DefaultNATSConfig()
+	if cfg.URL == "" {
+		cfg.URL = defaults.URL
+	}
+	if cfg.Name == "" {
+		cfg.Name = defaults.Name
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.HandlerAttempts <= 0 {
+		cfg.HandlerAttempts = defaults.HandlerAttempts
+	}
+	if cfg.HandlerBackoff == (retry.Backoff{}) {
+		cfg.HandlerBackoff = defaults.HandlerBackoff
+	}
+
+	conn, err := nats.Connect(cfg.URL,
+		nats.Name(cfg.Name),
+		nats.Timeout(cfg.Timeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logger.Warn("NATS connection lost", "error", err)
+			}
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			logger.Info("NATS connection restored", "url", c.ConnectedUrlRedacted())
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("messaging: connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("messaging: JetStream: %w", err)
+	}
+	return &NATS{
+		cfg:       cfg,
+		logger:    logger,
+		conn:      conn,
+		js:        js,
+		streams:   make(map[string]bool),
+		consumers: make(map[*natsConsumer]struct{}),
+	}, nil
+}
+
+// Publish writes msgs and waits for JetStream to store each of them.
+func (n *NATS) Publish(ctx context.Context, msgs ...Message) error {
+	for _, msg := range msgs {
+		if err := n.ensureStream(ctx, msg.Topic); err != nil {
+			return err
+		}
+		pubCtx, cancel := context.WithTimeout(ctx, n.cfg.Timeout)
+		_, err := n.js.PublishMsg(pubCtx, toNATSMsg(msg))
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureStream creates the stream for topic unless it exists, leaving any
+// limits an operator set on an existing stream alone.
+func (n *NATS) ensureStream(ctx context.Context, topic string) error {
+	n.mu.Lock()
+	closed, known := n.closed, n.streams[topic]
+	n.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	if known {
+		return nil
+	}
+
+	name := natsName(topic)
+	_, err := n.js.Stream(ctx, name)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		_, err = n.js.CreateStream(ctx, jetstream.StreamConfig{Name: name, Subjects: []string{topic}})
+		if errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+			err = nil // Created by another instance meanwhile.
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("messaging: stream %s: %w", name, err)
+	}
+
+	n.mu.Lock()
+	n.streams[topic] = true
+	n.mu.Unlock()
+	return nil
+}
+
+// Ping reports whether the connection is up and JetStream answers.
+func (n *NATS) Ping(ctx context.Context) error {
+	if !n.conn.IsConnected() {
+		return fmt.Errorf("messaging: NATS connection is %s", strings.ToLower(n.conn.Status().String()))
+	}
+	_, err := n.js.AccountInfo(ctx)
+	return err
+}
+
+// Consumer returns a consumer of topic as a member of group. Members of a
+// group share one durable consumer, so each message goes to one of them.
+func (n *NATS) Consumer(topic, group string) Consumer {
+	c := &natsConsumer{nats: n, topic: topic, group: group}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		c.closed = true
+		return c
+	}
+	n.consumers[c] = struct{}{}
+	return c
+}
+
+// CloseConsumers closes every consumer so no new messages are taken.
+func (n *NATS) CloseConsumers() error {
+	n.mu.Lock()
+	consumers := make([]*natsConsumer, 0, len(n.consumers))
+	for c := range n.consumers {
+		consumers = append(consumers, c)
+	}
+	n.mu.Unlock()
+
+	for _, c := range consumers {
+		_ = c.Close()
+	}
+	return nil
+}
+
+// Close closes the consumers and the connection.
+func (n *NATS) Close() error {
+	err := n.CloseConsumers()
+
+	n.mu.Lock()
+	n.closed = true
+	n.mu.Unlock()
+
+	n.conn.Close()
+	return err
+}
+
+type natsConsumer struct {
+	nats  *NATS
+	topic string
+	group string
+
+	mu     sync.Mutex
+	iter   jetstream.MessagesContext
+	closed bool
+}
+
+func (c *natsConsumer) Consume(ctx context.Context, handler Handler) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+	if err := c.nats.ensureStream(ctx, c.topic); err != nil {
+		return err
+	}
+	consumer, err := c.nats.js.CreateOrUpdateConsumer(ctx, natsName(c.topic), jetstream.ConsumerConfig{
+		Durable:       natsName(c.group),
+		FilterSubject: c.topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		MaxDeliver:    c.nats.cfg.HandlerAttempts,
+	})
+	if err != nil {
+		return fmt.Errorf("messaging: consumer %s: %w", c.group, err)
+	}
+	iter, err := consumer.Messages()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		iter.Stop()
+		return ErrClosed
+	}
+	c.iter = iter
+	c.mu.Unlock()
+	defer iter.Stop()
+
+	for {
+		m, err := iter.Next(jetstream.NextContext(ctx))
+		if err != nil {
+			if c.isClosed() || errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+				return ErrClosed
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		meta, err := m.Metadata()
+		if err != nil {
+			c.nats.logger.Error("Dropping message without JetStream metadata", "subject", m.Subject(), "error", err)
+			_ = m.Term()
+			continue
+		}
+		msg := fromNATSMsg(m, meta)
+		if err := handler(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				// Not acknowledged; redelivered after AckWait.
+				return ctx.Err()
+			}
+			delivered := int(meta.NumDelivered)
+			if delivered >= c.nats.cfg.HandlerAttempts {
+				c.nats.logger.Error("Skipping message after failed handler attempts",
+					"subject", m.Subject(), "sequence", meta.Sequence.Stream, "attempts", delivered, "error", err)
+				_ = m.Term()
+				continue
+			}
+			_ = m.NakWithDelay(c.nats.cfg.HandlerBackoff.Delay(delivered))
+			continue
+		}
+		if err := m.Ack(); err != nil {
+			c.nats.logger.Warn("Failed to acknowledge message; it will be redelivered", "subject", m.Subject(), "error", err)
+		}
+	}
+}
+
+func (c *natsConsumer) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *natsConsumer) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	iter := c.iter
+	c.mu.Unlock()
+
+	if iter != nil {
+		iter.Stop()
+	}
+	c.nats.mu.Lock()
+	delete(c.nats.consumers, c)
+	c.nats.mu.Unlock()
+	return nil
+}
+
+// natsName turns a topic or group into a stream or consumer name, which may
+// not contain '.', '*', '>' or whitespace.
+func natsName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', '/', '\\', ' ', '\t':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func toNATSMsg(msg Message) *nats.Msg {
+	m := nats.NewMsg(msg.Topic)
+	m.Data = msg.Value
+	for k, v := range msg.Headers {
+		m.Header.Set(k, v)
+	}
+	if len(msg.Key) > 0 {
+		m.Header.Set(keyHeader, string(msg.Key))
+	}
+	return m
+}
+
+func fromNATSMsg(m jetstream.Msg, meta *jetstream.MsgMetadata) Message {
+	msg := Message{Topic: m.Subject(), Value: m.Data(), Time: meta.Timestamp}
+	for k, v := range m.Headers() {
+		if len(v) == 0 {
+			continue
+		}
+		if k == keyHeader {
+			msg.Key = []byte(v[0])
+			continue
+		}
+		if msg.Headers == nil {
+			msg.Headers = make(map[string]string, len(m.Headers()))
+		}
+		msg.Headers[k] = v[0]
+	}
+	return msg
+}
@@ -0,0 +1,86 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrPending = errors.New("migrations: pending")
+	ErrDirty   = errors.New("migrations: dirty")
+)
+
+var upFile = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+
+// Check reports whether the database is at the latest migration in cfg.Dir,
+// returning ErrPending or ErrDirty when it is not. When cfg.Dir does not exist,
+// as in images that ship without migrations, it only checks that migrations
+// ran and did not fail halfway.
+func Check(ctx context.Context, db *sql.DB, cfg Config) error {
+	if db == nil {
+		return fmt.Errorf("migrations: db is nil")
+	}
+	if strings.TrimSpace(cfg.Dir) == "" {
+		cfg.Dir = "migrations"
+	}
+	if strings.TrimSpace(cfg.MigrationsTable) == "" {
+		cfg.MigrationsTable = "schema_migrations"
+	}
+
+	var version int64
+	var dirty bool
+	query := fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", quoteIdentifier(cfg.MigrationsTable))
+	//rawsql:trusted the table name is configuration, quoted as an identifier
+	if err := db.QueryRowContext(ctx, query).Scan(&version, &dirty); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: none applied", ErrPending)
+		}
+		return fmt.Errorf("migrations: read version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("%w: version %d failed to apply", ErrDirty, version)
+	}
+
+	latest, err := LatestVersion(cfg.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if version < latest {
+		return fmt.Errorf("%w: at version %d, latest is %d", ErrPending, version, latest)
+	}
+	return nil
+}
+
+// LatestVersion returns the highest version among the up migrations in dir,
+// or 0 when there are none.
+func LatestVersion(dir string) (int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var latest int64
+	for _, e := range entries {
+		m := upFile.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		if v, err := strconv.ParseInt(m[1], 10, 64); err == nil && v > latest {
+			latest = v
+		}
+	}
+	return latest, nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
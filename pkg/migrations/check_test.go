@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func writeMigrations(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLatestVersion(t *testing.T) {
+	dir := writeMigrations(t, "000002_ledger.up.sql", "000002_ledger.down.sql", "000010_more.up.sql", "000011_next.down.sql", "README.md")
+	latest, err := LatestVersion(dir)
+	if err != nil || latest != 10 {
+		t.Fatalf("expected 10, got %d (err %v)", latest, err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	dir := writeMigrations(t, "000001_init.up.sql", "000002_next.up.sql")
+	cfg := Config{Dir: dir}
+
+	if err := Check(ctx, db, cfg); err == nil {
+		t.Fatal("expected an error without a migrations table")
+	}
+
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version bigint, dirty boolean)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(ctx, db, cfg); !errors.Is(err, ErrPending) {
+		t.Fatalf("expected ErrPending with nothing applied, got %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO schema_migrations VALUES (1, false)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(ctx, db, cfg); !errors.Is(err, ErrPending) {
+		t.Fatalf("expected ErrPending behind the latest migration, got %v", err)
+	}
+
+	if _, err := db.Exec(`UPDATE schema_migrations SET version = 2, dirty = true`); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(ctx, db, cfg); !errors.Is(err, ErrDirty) {
+		t.Fatalf("expected ErrDirty, got %v", err)
+	}
+
+	if _, err := db.Exec(`UPDATE schema_migrations SET dirty = false`); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(ctx, db, cfg); err != nil {
+		t.Fatalf("expected the schema to be current, got %v", err)
+	}
+
+	// Without the migration files only a clean, applied state is required.
+	cfg.Dir = filepath.Join(dir, "missing")
+	if _, err := db.Exec(`UPDATE schema_migrations SET version = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(ctx, db, cfg); err != nil {
+		t.Fatalf("expected no error without a migrations dir, got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+// Package operations runs long-running work in the background and exposes
+// its progress as a pollable status resource, with optional webhooks on
+// completion.
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+var (
+	ErrShuttingDown      = errors.New("operations: manager is shutting down")
+	ErrInvalidWebhookURL = errors.New("operations: webhook URL must be an absolute http(s) URL")
+)
+
+// Func performs the work of an operation. The returned value is stored
+// JSON-encoded as the operation result; a returned error fails the operation
+// and its message is shown to clients, so keep it free of internal details.
+type Func func(ctx context.Context, progress *Progress) (any, error)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type Config struct {
+	// MaxConcurrent bounds how many operations run at once; others wait PENDING.
+	MaxConcurrent int
+	// WebhookSecret signs webhook bodies (X-Operation-Signature) when set.
+	WebhookSecret   string
+	WebhookTimeout  time.Duration
+	WebhookAttempts int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxConcurrent:   8,
+		WebhookTimeout:  10 * time.Second,
+		WebhookAttempts: 3,
+	}
+}
+
+type Manager struct {
+	store  Store
+	logger Logger
+	cfg    Config
+	slots  chan struct{}
+	hooks  *webhookSender
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+
+	now func() time.Time
+}
+
+func NewManager(store Store, logger Logger, cfg Config) *Manager {
+	defaults := DefaultConfig()
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = defaults.MaxConcurrent
+	}
+	if cfg.WebhookTimeout <= 0 {
+		cfg.WebhookTimeout = defaults.WebhookTimeout
+	}
+	if cfg.WebhookAttempts <= 0 {
+		cfg.WebhookAttempts = defaults.WebhookAttempts
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		store:  store,
+		logger: logger,
+		cfg:    cfg,
+		slots:  make(chan struct{}, cfg.MaxConcurrent),
+		hooks:  newWebhookSender(cfg),
+		ctx:    ctx,
+		cancel: cancel,
+		now:    time.Now,
+	}
+}
+
+// ValidateWebhookURL checks that raw is an absolute http or https URL.
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// Start records a PENDING operation and runs fn in the background. The
+// operation keeps the values of ctx (logger, trace) but not its deadline, so
+// it outlives the request that started it. webhookURL may be empty.
+func (m *Manager) Start(ctx context.Context, kind, webhookURL string, fn Func) (*Operation, error) {
+	if webhookURL != "" {
+		if err := ValidateWebhookURL(webhookURL); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrShuttingDown
+	}
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	now := m.now()
+	op := &Operation{
+		Kind:       kind,
+		Status:     StatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		webhookURL: webhookURL,
+	}
+	if err := m.store.Create(ctx, op); err != nil {
+		m.wg.Done()
+		return nil, err
+	}
+
+	snapshot := *op
+	go m.run(context.WithoutCancel(ctx), &Progress{m: m, op: op}, fn)
+	return &snapshot, nil
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (*Operation, error) {
+	return m.store.Get(ctx, id)
+}
+
+// Shutdown stops accepting operations and waits for running ones. When ctx
+// expires first, running operations are cancelled and ctx's error returned.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		m.cancel()
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) run(ctx context.Context, progress *Progress, fn Func) {
+	defer m.wg.Done()
+
+	select {
+	case m.slots <- struct{}{}:
+	case <-m.ctx.Done():
+		m.finish(ctx, progress, nil, errors.New("operation cancelled before it started"))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(m.ctx, cancel)
+
+	progress.transition(ctx, StatusRunning)
+	result, err := m.invoke(ctx, progress, fn)
+
+	stop()
+	cancel()
+	<-m.slots
+
+	m.finish(ctx, progress, result, err)
+}
+
+func (m *Manager) invoke(ctx context.Context, progress *Progress, fn Func) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error("Operation panicked", "operation_id", progress.op.ID, "kind", progress.op.Kind, "panic", fmt.Sprint(r))
+			result, err = nil, errors.New("operation failed unexpectedly")
+		}
+	}()
+	return fn(ctx, progress)
+}
+
+func (m *Manager) finish(ctx context.Context, progress *Progress, result any, runErr error) {
+	// Record the outcome even when the operation was cancelled.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	var encoded json.RawMessage
+	if runErr == nil && result != nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			m.logger.Error("Failed to encode operation result", "operation_id", progress.op.ID, "error", err)
+			runErr = errors.New("operation result could not be encoded")
+		} else {
+			encoded = b
+		}
+	}
+
+	progress.mu.Lock()
+	op := progress.op
+	now := m.now()
+	op.UpdatedAt = now
+	op.CompletedAt = &now
+	if runErr != nil {
+		op.Status = StatusFailed
+		op.Error = runErr.Error()
+	} else {
+		op.Status = StatusSucceeded
+		op.Progress = 100
+		op.Result = encoded
+	}
+	snapshot := *op
+	err := m.store.Update(ctx, op)
+	progress.mu.Unlock()
+
+	if err != nil {
+		m.logger.Error("Failed to record operation outcome", "operation_id", op.ID, "status", op.Status, "error", err)
+	}
+
+	if snapshot.webhookURL != "" {
+		if err := m.hooks.send(m.ctx, &snapshot); err != nil {
+			m.logger.Warn("Operation webhook delivery failed", "operation_id", snapshot.ID, "error", err)
+		}
+	}
+}
+
+// Progress lets a running operation report how far along it is.
+type Progress struct {
+	m  *Manager
+	mu sync.Mutex
+	op *Operation
+}
+
+// Set records percent complete. Values are clamped to 0-99; 100 is reserved
+// for a successful finish.
+func (p *Progress) Set(ctx context.Context, percent int) {
+	percent = max(0, min(percent, 99))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.op.Status.Done() || percent == p.op.Progress {
+		return
+	}
+	p.op.Progress = percent
+	p.op.UpdatedAt = p.m.now()
+	if err := p.m.store.Update(context.WithoutCancel(ctx), p.op); err != nil {
+		p.m.logger.Warn("Failed to record operation progress", "operation_id", p.op.ID, "error", err)
+	}
+}
+
+// ID returns the operation ID, e.g. to derive idempotency keys.
+func (p *Progress) ID() string {
+	return p.op.ID
+}
+
+func (p *Progress) transition(ctx context.Context, status Status) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.op.Status = status
+	p.op.UpdatedAt = p.m.now()
+	if err := p.m.store.Update(ctx, p.op); err != nil {
+		p.m.logger.Warn("Failed to record operation status", "operation_id", p.op.ID, "status", status, "error", err)
+	}
+}
@@ -0,0 +1,169 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+func waitDone(t *testing.T, m *Manager, id string) *Operation {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		op, err := m.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if op.Status.Done() {
+			return op
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("operation %s did not finish", id)
+	return nil
+}
+
+func TestStart_RecordsProgressAndResult(t *testing.T) {
+	m := NewManager(NewMemoryStore(), nopLogger{}, DefaultConfig())
+	release := make(chan struct{})
+
+	op, err := m.Start(context.Background(), "import", "", func(ctx context.Context, p *Progress) (any, error) {
+		p.Set(ctx, 40)
+		<-release
+		return map[string]int{"rows": 3}, nil
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if op.Status != StatusPending || op.ID == "" {
+		t.Fatalf("expected a pending operation with an ID, got %+v", op)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		current, _ := m.Get(context.Background(), op.ID)
+		if current.Status == StatusRunning && current.Progress == 40 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected running at 40%%, got %+v", current)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+
+	done := waitDone(t, m, op.ID)
+	if done.Status != StatusSucceeded || done.Progress != 100 || done.CompletedAt == nil {
+		t.Fatalf("unexpected final state: %+v", done)
+	}
+	if string(done.Result) != `{"rows":3}` {
+		t.Fatalf("unexpected result: %s", done.Result)
+	}
+}
+
+func TestStart_FailuresAndPanicsFailTheOperation(t *testing.T) {
+	m := NewManager(NewMemoryStore(), nopLogger{}, DefaultConfig())
+
+	failed, _ := m.Start(context.Background(), "export", "", func(context.Context, *Progress) (any, error) {
+		return nil, errors.New("source file is empty")
+	})
+	panicked, _ := m.Start(context.Background(), "export", "", func(context.Context, *Progress) (any, error) {
+		panic("boom")
+	})
+
+	if op := waitDone(t, m, failed.ID); op.Status != StatusFailed || op.Error != "source file is empty" {
+		t.Fatalf("unexpected failed operation: %+v", op)
+	}
+	if op := waitDone(t, m, panicked.ID); op.Status != StatusFailed || op.Error != "operation failed unexpectedly" {
+		t.Fatalf("panics must fail the operation without leaking details: %+v", op)
+	}
+}
+
+func TestStart_RejectsInvalidWebhookURL(t *testing.T) {
+	m := NewManager(NewMemoryStore(), nopLogger{}, DefaultConfig())
+	for _, raw := range []string{"ftp://example.com/hook", "/relative", "https://"} {
+		if _, err := m.Start(context.Background(), "import", raw, nil); !errors.Is(err, ErrInvalidWebhookURL) {
+			t.Fatalf("expected ErrInvalidWebhookURL for %q, got %v", raw, err)
+		}
+	}
+}
+
+func TestWebhook_SignedAndRetried(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	cfg.WebhookSecret = "s3cret"
+	m := NewManager(NewMemoryStore(), nopLogger{}, cfg)
+	m.hooks.retryDelay = time.Millisecond
+
+	op, err := m.Start(context.Background(), "batch_transfer", srv.URL, func(context.Context, *Progress) (any, error) {
+		return "ok", nil
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	select {
+	case r := <-received:
+		if r.Header.Get("X-Operation-ID") != op.ID {
+			t.Fatalf("expected operation ID header")
+		}
+		if r.Header.Get(SignatureHeader) != Sign([]byte("s3cret"), body) {
+			t.Fatalf("signature does not match body")
+		}
+		var payload Operation
+		if err := json.Unmarshal(body, &payload); err != nil || payload.Status != StatusSucceeded {
+			t.Fatalf("unexpected webhook payload %s: %v", body, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("webhook was not delivered")
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected one retry, got %d calls", calls.Load())
+	}
+}
+
+func TestShutdown_CancelsRunningOperationsAtDeadline(t *testing.T) {
+	m := NewManager(NewMemoryStore(), nopLogger{}, DefaultConfig())
+
+	op, _ := m.Start(context.Background(), "import", "", func(ctx context.Context, _ *Progress) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if op := waitDone(t, m, op.ID); op.Status != StatusFailed {
+		t.Fatalf("expected cancelled operation to fail, got %+v", op)
+	}
+
+	if _, err := m.Start(context.Background(), "import", "", nil); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("expected ErrShuttingDown after shutdown, got %v", err)
+	}
+}
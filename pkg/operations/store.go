@@ -0,0 +1,182 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"gorm.io/gorm"
+)
+
+var ErrNotFound = errors.New("operation not found")
+
+type Status string
+
+const (
+	StatusPending   Status = models.OperationStatusPending
+	StatusRunning   Status = models.OperationStatusRunning
+	StatusSucceeded Status = models.OperationStatusSucceeded
+	StatusFailed    Status = models.OperationStatusFailed
+)
+
+// Done reports whether the status is terminal.
+func (s Status) Done() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+// Operation is the status resource returned to clients.
+type Operation struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Status      Status          `json:"status"`
+	Progress    int             `json:"progress"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+
+	webhookURL string
+}
+
+// Store persists operations. Implementations must be safe for concurrent use.
+type Store interface {
+	Create(ctx context.Context, op *Operation) error
+	Get(ctx context.Context, id string) (*Operation, error)
+	Update(ctx context.Context, op *Operation) error
+}
+
+// MemoryStore keeps operations in process memory. Operations are lost on
+// restart and are not visible to other instances; use it for tests and
+// single-instance deployments.
+type MemoryStore struct {
+	mu  sync.Mutex
+	ops map[string]Operation
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{ops: make(map[string]Operation)}
+}
+
+func (s *MemoryStore) Create(_ context.Context, op *Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if op.ID == "" {
+		op.ID = region.NewID()
+	}
+	s.ops[op.ID] = *op
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (*Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op, ok := s.ops[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &op, nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, op *Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.ops[op.ID]; !ok {
+		return ErrNotFound
+	}
+	s.ops[op.ID] = *op
+	return nil
+}
+
+// GormStore persists operations in the operations table so any instance can
+// serve status polls.
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Create(ctx context.Context, op *Operation) error {
+	record := toModel(op)
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		return err
+	}
+	op.ID = record.ID
+	op.CreatedAt = record.CreatedAt
+	op.UpdatedAt = record.UpdatedAt
+	return nil
+}
+
+func (s *GormStore) Get(ctx context.Context, id string) (*Operation, error) {
+	var record models.Operation
+	if err := s.db.WithContext(ctx).First(&record, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return fromModel(&record), nil
+}
+
+func (s *GormStore) Update(ctx context.Context, op *Operation) error {
+	record := toModel(op)
+	res := s.db.WithContext(ctx).Model(&models.Operation{}).Where("id = ?", op.ID).Updates(map[string]any{
+		"status":       record.Status,
+		"progress":     record.Progress,
+		"result":       record.Result,
+		"error":        record.Error,
+		"updated_at":   record.UpdatedAt,
+		"completed_at": record.CompletedAt,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func toModel(op *Operation) *models.Operation {
+	return &models.Operation{
+		ID:          op.ID,
+		Kind:        op.Kind,
+		Status:      string(op.Status),
+		Progress:    op.Progress,
+		Result:      string(op.Result),
+		Error:       op.Error,
+		WebhookURL:  op.webhookURL,
+		CreatedAt:   op.CreatedAt,
+		UpdatedAt:   op.UpdatedAt,
+		CompletedAt: op.CompletedAt,
+	}
+}
+
+func fromModel(record *models.Operation) *Operation {
+	op := &Operation{
+		ID:          record.ID,
+		Kind:        record.Kind,
+		Status:      Status(record.Status),
+		Progress:    record.Progress,
+		Error:       record.Error,
+		CreatedAt:   record.CreatedAt,
+		UpdatedAt:   record.UpdatedAt,
+		CompletedAt: record.CompletedAt,
+		webhookURL:  record.WebhookURL,
+	}
+	if record.Result != "" {
+		op.Result = json.RawMessage(record.Result)
+	}
+	return op
+}
+
+// Location is the status resource path clients poll for an operation.
+func Location(id string) string {
+	return "/v1/operations/" + id
+}
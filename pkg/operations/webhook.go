@@ -0,0 +1,84 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const SignatureHeader = "X-Operation-Signature"
+
+type webhookSender struct {
+	client     *http.Client
+	secret     []byte
+	attempts   int
+	retryDelay time.Duration
+}
+
+func newWebhookSender(cfg Config) *webhookSender {
+	return &webhookSender{
+		client:     &http.Client{Timeout: cfg.WebhookTimeout},
+		secret:     []byte(cfg.WebhookSecret),
+		attempts:   cfg.WebhookAttempts,
+		retryDelay: time.Second,
+	}
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by
+// the hex HMAC-SHA256 of body under secret.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send POSTs the final operation state, retrying with linear backoff.
+func (w *webhookSender) send(ctx context.Context, op *Operation) error {
+	body, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= w.attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(time.Duration(attempt-1) * w.retryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if lastErr = w.post(ctx, op, body); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func (w *webhookSender) post(ctx context.Context, op *Operation, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, op.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Operation-ID", op.ID)
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Package pdf writes plain-text PDF documents, such as account statements,
+// without a layout engine. Lines of monospaced text fill A4 pages top to
+// bottom, and each page is written out as soon as it is full, so a long
+// document never has to fit in memory.
+//
+//	doc := pdf.NewTextWriter(w, []string{"Date        Amount"})
+//	for _, row := range rows {
+//		if err := doc.WriteLine(row); err != nil {
+//			return err
+//		}
+//	}
+//	return doc.Close()
+//
+// Text is set in Courier with the Windows-1252 character set; other
+// characters print as '?'.
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	pageWidth  = 595 // A4, in points
+	pageHeight = 842
+	margin     = 40
+	fontSize   = 9
+	leading    = 12
+
+	// LineWidth is how many characters fit across a page.
+	LineWidth = (pageWidth - 2*margin) * 10 / (fontSize * 6)
+
+	linesPerPage = (pageHeight - 2*margin) / leading
+
+	catalogObject = 1
+	pagesObject   = 2
+	fontObject    = 3
+)
+
+var errClosed = errors.New("pdf: writer is closed")
+
+// TextWriter lays lines of text out on pages, repeating the header lines at
+// the top of each one.
+type TextWriter struct {
+	w       io.Writer
+	written int64
+	err     error
+	closed  bool
+
+	header  []string
+	offsets []int64 // by object number, from 1
+	pages   []int   // page object numbers
+	lines   []string
+}
+
+// NewTextWriter starts a document on w. Nothing is written until the first
+// page is full or the writer is closed.
+func NewTextWriter(w io.Writer, header []string) *TextWriter {
+	if len(header) >= linesPerPage {
+		header = header[:linesPerPage-1]
+	}
+	return &TextWriter{w: w, header: header}
+}
+
+// WriteLine adds a line, starting a new page when the current one is full.
+// Lines longer than LineWidth run off the page.
+func (t *TextWriter) WriteLine(line string) error {
+	if t.closed {
+		return errClosed
+	}
+	if t.err != nil {
+		return t.err
+	}
+	if len(t.lines) == 0 {
+		t.lines = append(t.lines, t.header...)
+	}
+	t.lines = append(t.lines, line)
+	if len(t.lines) == linesPerPage {
+		t.flushPage()
+	}
+	return t.err
+}
+
+// Close writes the last page and the document trailer. A document with no
+// lines has a single page holding the header.
+func (t *TextWriter) Close() error {
+	if t.closed {
+		return t.err
+	}
+	t.closed = true
+	if len(t.lines) > 0 || len(t.pages) == 0 {
+		if len(t.lines) == 0 {
+			t.lines = append(t.lines, t.header...)
+		}
+		t.flushPage()
+	}
+
+	kids := make([]string, len(t.pages))
+	for i, n := range t.pages {
+		kids[i] = strconv.Itoa(n) + " 0 R"
+	}
+	t.object(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(t.pages)))
+
+	xref := t.written
+	var b strings.Builder
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(t.offsets)+1)
+	for _, offset := range t.offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(t.offsets)+1, catalogObject, xref)
+	t.write(b.String())
+	return t.err
+}
+
+func (t *TextWriter) flushPage() {
+	if t.written == 0 {
+		t.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+		t.object(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+		t.object(fontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	}
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", fontSize, leading, margin, pageHeight-margin-fontSize)
+	for _, line := range t.lines {
+		content.WriteString("(")
+		writeEscaped(&content, line)
+		content.WriteString(") Tj T*\n")
+	}
+	content.WriteString("ET")
+	t.lines = t.lines[:0]
+
+	contentObject := t.nextObject()
+	t.object(contentObject, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.Bytes()))
+	page := t.nextObject()
+	t.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pagesObject, pageWidth, pageHeight, fontObject, contentObject))
+	t.pages = append(t.pages, page)
+}
+
+func (t *TextWriter) nextObject() int {
+	return max(len(t.offsets)+1, fontObject+1)
+}
+
+// object writes object n and records where it starts for the xref table.
+func (t *TextWriter) object(n int, body string) {
+	for len(t.offsets) < n {
+		t.offsets = append(t.offsets, 0)
+	}
+	t.offsets[n-1] = t.written
+	t.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", n, body))
+}
+
+func (t *TextWriter) write(s string) {
+	if t.err != nil {
+		return
+	}
+	n, err := io.WriteString(t.w, s)
+	t.written += int64(n)
+	t.err = err
+}
+
+// writeEscaped writes s as the body of a PDF string in Windows-1252.
+func writeEscaped(b *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			// Windows-1252 matches Latin-1 here.
+			fmt.Fprintf(b, "\\%03o", r)
+		case r == '€':
+			b.WriteString("\\200")
+		default:
+			b.WriteByte('?')
+		}
+	}
+}
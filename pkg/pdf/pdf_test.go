@@ -0,0 +1,101 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// checkStructure verifies the trailer points at the xref table and that every
+// xref entry points at its object.
+func checkStructure(t *testing.T, doc []byte) {
+	t.Helper()
+	if !bytes.HasPrefix(doc, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(doc, []byte("%%EOF\n")) {
+		t.Fatalf("missing header or trailer: %q...%q", doc[:min(20, len(doc))], doc[max(0, len(doc)-20):])
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(doc)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(doc[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(doc[xref:], -1)
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(doc[offset:], []byte(want)) {
+			t.Fatalf("xref entry %d points at %q, want %q", i+1, doc[offset:offset+10], want)
+		}
+	}
+	for _, stream := range regexp.MustCompile(`(?s)/Length (\d+) >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(doc, -1) {
+		if n, _ := strconv.Atoi(string(stream[1])); n != len(stream[2]) {
+			t.Fatalf("stream /Length %d, actual %d", n, len(stream[2]))
+		}
+	}
+}
+
+func TestTextWriter(t *testing.T) {
+	var buf bytes.Buffer
+	doc := NewTextWriter(&buf, []string{"Statement", "Date  Amount"})
+	for i := range 150 {
+		if err := doc.WriteLine(fmt.Sprintf("line %d (café) \\ €5 ✓", i)); err != nil {
+			t.Fatalf("WriteLine: %v", err)
+		}
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	out := buf.Bytes()
+	checkStructure(t, out)
+
+	// 150 lines and a two-line header on each page of 63 lines.
+	if !bytes.Contains(out, []byte("/Count 3 >>")) {
+		t.Fatal("expected three pages")
+	}
+	if got := bytes.Count(out, []byte("(Date  Amount) Tj")); got != 3 {
+		t.Fatalf("header printed %d times, want 3", got)
+	}
+	if !bytes.Contains(out, []byte(`(line 7 \(caf\351\) \\ \2005 ?) Tj`)) {
+		t.Fatal("line not escaped as expected")
+	}
+	if err := doc.WriteLine("late"); err == nil {
+		t.Fatal("expected an error writing after Close")
+	}
+}
+
+func TestTextWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewTextWriter(&buf, []string{"No entries"}).Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	checkStructure(t, buf.Bytes())
+	if !strings.Contains(buf.String(), "/Count 1 >>") || !strings.Contains(buf.String(), "(No entries) Tj") {
+		t.Fatalf("expected one page with the header:\n%s", buf.String())
+	}
+}
+
+type failingWriter struct{ after int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.after -= len(p); w.after < 0 {
+		return 0, errors.New("disk full")
+	}
+	return len(p), nil
+}
+
+func TestTextWriter_WriteError(t *testing.T) {
+	doc := NewTextWriter(&failingWriter{after: 100}, nil)
+	var err error
+	for i := 0; i < 200 && err == nil; i++ {
+		err = doc.WriteLine("row")
+	}
+	if err == nil || doc.Close() == nil {
+		t.Fatal("expected the write error to be returned")
+	}
+}
@@ -0,0 +1,37 @@
+package principal
+
+import (
+	"context"
+	"slices"
+)
+
+type Kind string
+
+const (
+	KindService Kind = "service"
+	KindUser    Kind = "user"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject    string            `json:"subject"`
+	Kind       Kind              `json:"kind"`
+	Roles      []string          `json:"roles,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && slices.Contains(p.Roles, role)
+}
+
+type contextKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the request principal, or nil for anonymous requests.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(contextKey{}).(*Principal)
+	return p
+}
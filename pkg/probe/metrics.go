@@ -0,0 +1,47 @@
+package probe
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	upDesc = prometheus.NewDesc(
+		"dependency_up",
+		"Whether the latest check of the dependency succeeded.",
+		[]string{"dependency"}, nil,
+	)
+	errorRateDesc = prometheus.NewDesc(
+		"dependency_error_rate",
+		"Share of failed checks in the recent window.",
+		[]string{"dependency"}, nil,
+	)
+	latencyDesc = prometheus.NewDesc(
+		"dependency_latency_seconds",
+		"Check latency percentiles over the recent window.",
+		[]string{"dependency", "quantile"}, nil,
+	)
+)
+
+// Describe implements prometheus.Collector.
+func (p *Prober) Describe(ch chan<- *prometheus.Desc) {
+	ch <- upDesc
+	ch <- errorRateDesc
+	ch <- latencyDesc
+}
+
+// Collect implements prometheus.Collector with the current Snapshot.
+// Dependencies that have not been checked yet are left out.
+func (p *Prober) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range p.Snapshot() {
+		if s.Samples == 0 {
+			continue
+		}
+		up := 0.0
+		if s.Healthy {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, up, s.Name)
+		ch <- prometheus.MustNewConstMetric(errorRateDesc, prometheus.GaugeValue, s.ErrorRate, s.Name)
+		for quantile, ms := range map[string]float64{"0.5": s.LatencyP50, "0.95": s.LatencyP95, "0.99": s.LatencyP99} {
+			ch <- prometheus.MustNewConstMetric(latencyDesc, prometheus.GaugeValue, ms/1000, s.Name, quantile)
+		}
+	}
+}
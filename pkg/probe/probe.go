@@ -0,0 +1,283 @@
+// Package probe checks dependencies (database, cache, external providers) in
+// the background and keeps a sliding window of results, so health endpoints
+// can report latency percentiles and error rates without doing the work on
+// the request path.
+package probe
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Check pings one dependency. A nil error means it is healthy.
+type Check func(ctx context.Context) error
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type Config struct {
+	// Interval between checks of each dependency.
+	Interval time.Duration
+	// Timeout bounds a single check; a check that times out counts as an error.
+	Timeout time.Duration
+	// Window is how many recent results per dependency the statistics cover.
+	Window int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval: 15 * time.Second,
+		Timeout:  2 * time.Second,
+		Window:   40,
+	}
+}
+
+// Stats summarizes the recent results of one dependency. Latencies are in
+// milliseconds and include failed checks.
+type Stats struct {
+	Name          string    `json:"name"`
+	Healthy       bool      `json:"healthy"`
+	Samples       int       `json:"samples"`
+	ErrorRate     float64   `json:"error_rate"`
+	LatencyP50    float64   `json:"latency_p50_ms"`
+	LatencyP95    float64   `json:"latency_p95_ms"`
+	LatencyP99    float64   `json:"latency_p99_ms"`
+	LatencyMax    float64   `json:"latency_max_ms"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastCheckedAt time.Time `json:"last_checked_at"`
+}
+
+type sample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// CheckOptions overrides the prober's interval and timeout for one dependency,
+// e.g. for an expensive end-to-end check. Zero values keep the defaults.
+type CheckOptions struct {
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+type dependency struct {
+	name  string
+	check Check
+	opts  CheckOptions
+
+	mu          sync.Mutex
+	samples     []sample // ring buffer of the last cfg.Window results
+	next        int
+	lastErr     error
+	lastChecked time.Time
+}
+
+// ResultFunc is told the outcome of every check.
+type ResultFunc func(name string, latency time.Duration, err error)
+
+// Prober runs the registered checks on their own goroutines until Stop.
+type Prober struct {
+	logger   Logger
+	cfg      Config
+	onResult ResultFunc
+
+	mu      sync.Mutex
+	deps    []*dependency
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	now    func() time.Time
+}
+
+func NewProber(logger Logger, cfg Config) *Prober {
+	defaults := DefaultConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaults.Window
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Prober{logger: logger, cfg: cfg, ctx: ctx, cancel: cancel, now: time.Now}
+}
+
+// Register adds a dependency. Dependencies registered after Start are probed
+// immediately.
+func (p *Prober) Register(name string, check Check) {
+	p.RegisterWithOptions(name, check, CheckOptions{})
+}
+
+// RegisterWithOptions adds a dependency with its own interval and timeout.
+func (p *Prober) RegisterWithOptions(name string, check Check, opts CheckOptions) {
+	if opts.Interval <= 0 {
+		opts.Interval = p.cfg.Interval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = p.cfg.Timeout
+	}
+	dep := &dependency{name: name, check: check, opts: opts, samples: make([]sample, 0, p.cfg.Window)}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.deps = append(p.deps, dep)
+	if p.started {
+		p.launch(dep)
+	}
+}
+
+// OnResult sets a function told the outcome of every check, e.g. to keep a
+// longer history than the window. Call it before Start.
+func (p *Prober) OnResult(fn ResultFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onResult = fn
+}
+
+// Start probes every registered dependency now and then every Interval.
+func (p *Prober) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return
+	}
+	p.started = true
+	for _, dep := range p.deps {
+		p.launch(dep)
+	}
+}
+
+// Stop ends probing and waits for in-flight checks.
+func (p *Prober) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *Prober) launch(dep *dependency) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(dep.opts.Interval)
+		defer ticker.Stop()
+		for {
+			p.probe(dep)
+			select {
+			case <-ticker.C:
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (p *Prober) probe(dep *dependency) {
+	ctx, cancel := context.WithTimeout(p.ctx, dep.opts.Timeout)
+	defer cancel()
+
+	start := p.now()
+	err := dep.check(ctx)
+	latency := p.now().Sub(start)
+	if err != nil && p.ctx.Err() != nil {
+		return // Shutting down; not the dependency's fault.
+	}
+	p.record(dep, latency, err)
+
+	p.mu.Lock()
+	onResult := p.onResult
+	p.mu.Unlock()
+	if onResult != nil {
+		onResult(dep.name, latency, err)
+	}
+}
+
+func (p *Prober) record(dep *dependency, latency time.Duration, err error) {
+	dep.mu.Lock()
+	defer dep.mu.Unlock()
+
+	s := sample{latency: latency, failed: err != nil}
+	if len(dep.samples) < p.cfg.Window {
+		dep.samples = append(dep.samples, s)
+	} else {
+		dep.samples[dep.next] = s
+	}
+	dep.next = (dep.next + 1) % p.cfg.Window
+
+	if err != nil && (dep.lastErr == nil || dep.lastErr.Error() != err.Error()) {
+		p.logger.Warn("Dependency check failed", "dependency", dep.name, "error", err)
+	}
+	if err == nil && dep.lastErr != nil {
+		p.logger.Info("Dependency recovered", "dependency", dep.name)
+	}
+	dep.lastErr = err
+	dep.lastChecked = p.now()
+}
+
+// Snapshot returns the statistics of every dependency, sorted by name. A
+// dependency is healthy when its latest check succeeded.
+func (p *Prober) Snapshot() []Stats {
+	p.mu.Lock()
+	deps := slices.Clone(p.deps)
+	p.mu.Unlock()
+
+	out := make([]Stats, 0, len(deps))
+	for _, dep := range deps {
+		out = append(out, dep.stats())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (dep *dependency) stats() Stats {
+	dep.mu.Lock()
+	defer dep.mu.Unlock()
+
+	s := Stats{Name: dep.name, Samples: len(dep.samples), LastCheckedAt: dep.lastChecked}
+	if len(dep.samples) == 0 {
+		return s
+	}
+	s.Healthy = dep.lastErr == nil
+	if dep.lastErr != nil {
+		s.LastError = dep.lastErr.Error()
+	}
+
+	latencies := make([]time.Duration, 0, len(dep.samples))
+	failed := 0
+	for _, sm := range dep.samples {
+		latencies = append(latencies, sm.latency)
+		if sm.failed {
+			failed++
+		}
+	}
+	slices.Sort(latencies)
+
+	s.ErrorRate = round(float64(failed) / float64(len(dep.samples)))
+	s.LatencyP50 = millis(percentile(latencies, 50))
+	s.LatencyP95 = millis(percentile(latencies, 95))
+	s.LatencyP99 = millis(percentile(latencies, 99))
+	s.LatencyMax = millis(latencies[len(latencies)-1])
+	return s
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+func millis(d time.Duration) float64 {
+	return round(float64(d) / float64(time.Millisecond))
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
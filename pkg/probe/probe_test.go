@@ -0,0 +1,128 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+func TestStats_PercentilesAndErrorRate(t *testing.T) {
+	p := NewProber(nopLogger{}, Config{Window: 10})
+	p.Register("db", func(context.Context) error { return nil })
+	dep := p.deps[0]
+
+	// 1ms..10ms, of which the 9ms sample failed.
+	for i := 1; i <= 10; i++ {
+		var err error
+		if i == 9 {
+			err = errors.New("timeout")
+		}
+		p.record(dep, time.Duration(i)*time.Millisecond, err)
+	}
+
+	s := p.Snapshot()[0]
+	if s.Samples != 10 || s.ErrorRate != 0.1 {
+		t.Fatalf("unexpected samples/error rate: %+v", s)
+	}
+	if s.LatencyP50 != 5 || s.LatencyP95 != 10 || s.LatencyMax != 10 {
+		t.Fatalf("unexpected percentiles: %+v", s)
+	}
+	if !s.Healthy || s.LastError != "" {
+		t.Fatalf("latest check succeeded, expected healthy: %+v", s)
+	}
+
+	// The window slides: ten more fast failures replace every earlier sample.
+	for range 10 {
+		p.record(dep, time.Millisecond, errors.New("refused"))
+	}
+	s = p.Snapshot()[0]
+	if s.ErrorRate != 1 || s.LatencyMax != 1 || s.Healthy || s.LastError != "refused" {
+		t.Fatalf("unexpected stats after window slid: %+v", s)
+	}
+}
+
+func TestProber_RunsChecksInBackground(t *testing.T) {
+	p := NewProber(nopLogger{}, Config{Interval: 5 * time.Millisecond, Timeout: 20 * time.Millisecond, Window: 5})
+	var calls atomic.Int32
+	p.Register("cache", func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+	p.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if got := p.Snapshot(); got[0].Samples != 0 {
+		t.Fatalf("expected no samples before Start, got %+v", got)
+	}
+
+	p.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for calls.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(30 * time.Millisecond)
+	p.Stop()
+
+	stats := p.Snapshot()
+	if stats[0].Name != "cache" || !stats[0].Healthy || stats[0].Samples == 0 {
+		t.Fatalf("unexpected cache stats %+v", stats[0])
+	}
+	if stats[1].Name != "slow" || stats[1].Healthy || stats[1].ErrorRate != 1 {
+		t.Fatalf("expected timed out checks to count as errors, got %+v", stats[1])
+	}
+}
+
+func TestProber_CheckOptionsOverrideDefaults(t *testing.T) {
+	p := NewProber(nopLogger{}, Config{Interval: time.Hour, Timeout: time.Millisecond})
+	p.RegisterWithOptions("synthetic", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return nil
+		}
+	}, CheckOptions{Timeout: time.Second})
+
+	p.probe(p.deps[0])
+	if s := p.Snapshot()[0]; !s.Healthy {
+		t.Fatalf("expected the longer per-check timeout to apply, got %+v", s)
+	}
+	if p.deps[0].opts.Interval != time.Hour {
+		t.Fatalf("expected the default interval, got %s", p.deps[0].opts.Interval)
+	}
+}
+
+func TestProber_CollectsMetrics(t *testing.T) {
+	p := NewProber(nopLogger{}, Config{Window: 4})
+	p.Register("db", func(context.Context) error { return nil })
+	p.Register("cache", func(context.Context) error { return nil })
+	p.record(p.deps[0], 2*time.Millisecond, nil)
+	p.record(p.deps[0], 4*time.Millisecond, errors.New("refused"))
+
+	expected := `
+# HELP dependency_error_rate Share of failed checks in the recent window.
+# TYPE dependency_error_rate gauge
+dependency_error_rate{dependency="db"} 0.5
+# HELP dependency_up Whether the latest check of the dependency succeeded.
+# TYPE dependency_up gauge
+dependency_up{dependency="db"} 0
+`
+	if err := testutil.CollectAndCompare(p, strings.NewReader(expected), "dependency_up", "dependency_error_rate"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(p, "dependency_latency_seconds"); n != 3 {
+		t.Fatalf("expected three latency quantiles for the checked dependency, got %d", n)
+	}
+}
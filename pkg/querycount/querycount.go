@@ -0,0 +1,80 @@
+// Package querycount counts the database statements a unit of work runs,
+// such as an HTTP request, so loops that query once per row (N+1 patterns)
+// show up in logs and metrics.
+package querycount
+
+import (
+	"context"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// Counter counts the statements run with a context that carries it. It is
+// safe for concurrent use.
+type Counter struct {
+	n atomic.Int64
+}
+
+// Inc counts one statement.
+func (c *Counter) Inc() {
+	c.n.Add(1)
+}
+
+// Count returns the statements counted so far.
+func (c *Counter) Count() int64 {
+	return c.n.Load()
+}
+
+type counterKey struct{}
+
+// WithCounter returns a context carrying a new counter, and the counter.
+func WithCounter(ctx context.Context) (context.Context, *Counter) {
+	c := &Counter{}
+	return context.WithValue(ctx, counterKey{}, c), c
+}
+
+// FromContext returns the counter ctx carries, or nil.
+func FromContext(ctx context.Context) *Counter {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(counterKey{}).(*Counter)
+	return c
+}
+
+// Plugin is a GORM plugin that counts each statement run through GORM,
+// preloads included, on the counter of the statement's context. Statements
+// without a counter, dry runs and SQL run on the *sql.DB directly are not
+// counted.
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "querycount"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().After("gorm:create").Register("querycount:create", count),
+		cb.Query().After("gorm:query").Register("querycount:query", count),
+		cb.Update().After("gorm:update").Register("querycount:update", count),
+		cb.Delete().After("gorm:delete").Register("querycount:delete", count),
+		cb.Row().After("gorm:row").Register("querycount:row", count),
+		cb.Raw().After("gorm:raw").Register("querycount:raw", count),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func count(db *gorm.DB) {
+	if db.DryRun || db.Statement.SQL.Len() == 0 {
+		return
+	}
+	if c := FromContext(db.Statement.Context); c != nil {
+		c.Inc()
+	}
+}
@@ -0,0 +1,78 @@
+package querycount
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type author struct {
+	ID    uint
+	Name  string
+	Books []book
+}
+
+type book struct {
+	ID       uint
+	AuthorID uint
+	Title    string
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&author{}, &book{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := db.Use(Plugin{}); err != nil {
+		t.Fatalf("use plugin: %v", err)
+	}
+	return db
+}
+
+func TestPlugin_CountsStatementsPerContext(t *testing.T) {
+	db := newTestDB(t)
+	ctx, counter := WithCounter(context.Background())
+
+	a := author{Name: "Ada", Books: []book{{Title: "Notes"}}}
+	if err := db.WithContext(ctx).Create(&a).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// The author and, through the association, the book.
+	if got := counter.Count(); got != 2 {
+		t.Fatalf("expected 2 statements for the create, got %d", got)
+	}
+
+	// The authors and their books.
+	var authors []author
+	if err := db.WithContext(ctx).Preload("Books").Find(&authors).Error; err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	var n int64
+	db.WithContext(ctx).Model(&book{}).Count(&n)
+	db.WithContext(ctx).Model(&author{ID: a.ID}).Update("name", "Ada L.")
+	db.WithContext(ctx).Exec("DELETE FROM books WHERE title = ?", "none")
+	db.WithContext(ctx).Where("id = ?", 0).Delete(&book{})
+	if got := counter.Count(); got != 8 {
+		t.Fatalf("expected 8 statements, got %d", got)
+	}
+
+	// Other contexts and dry runs are not counted.
+	db.WithContext(context.Background()).Find(&authors)
+	db.WithContext(ctx).Session(&gorm.Session{DryRun: true}).Find(&authors)
+	if got := counter.Count(); got != 8 {
+		t.Fatalf("expected uncounted statements to be skipped, got %d", got)
+	}
+	if FromContext(context.Background()) != nil {
+		t.Fatal("expected no counter in a plain context")
+	}
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
+)
+
+// FallbackRateLimiter counts in a Redis limiter while its breaker is closed,
+// and in an in-memory limiter with the same quota while Redis keeps failing,
+// so requests are still limited without waiting on Redis. Each instance
+// then enforces the quota on its own, so a client spread over N instances
+// gets up to N times it until the breaker closes.
+type FallbackRateLimiter struct {
+	primary  RateLimiter
+	fallback *InMemoryRateLimiter
+	breaker  *circuitbreaker.Breaker
+}
+
+func NewFallbackRateLimiter(primary RateLimiter, breaker *circuitbreaker.Breaker) *FallbackRateLimiter {
+	requests, window := primary.GetLimitDetails()
+	return &FallbackRateLimiter{
+		primary:  primary,
+		fallback: NewInMemoryRateLimiter(requests, window),
+		breaker:  breaker,
+	}
+}
+
+func (r *FallbackRateLimiter) GetLimitDetails() (int, time.Duration) {
+	return r.primary.GetLimitDetails()
+}
+
+// IsLimited counts the request in Redis, or in memory while the breaker is
+// open or when Redis fails.
+func (r *FallbackRateLimiter) IsLimited(key string) (Decision, error) {
+	generation, err := r.breaker.Allow()
+	if err != nil {
+		return r.fallback.IsLimited(key)
+	}
+	decision, err := r.primary.IsLimited(key)
+	r.breaker.Done(generation, err != nil)
+	if err != nil {
+		return r.fallback.IsLimited(key)
+	}
+	return decision, nil
+}
+
+// Inspect reads the key's Redis counter; it does not fall back, as the
+// in-memory counts cover only this instance.
+func (r *FallbackRateLimiter) Inspect(ctx context.Context, key string) (KeyState, error) {
+	inspector, ok := r.primary.(Inspector)
+	if !ok {
+		return KeyState{}, errors.ErrUnsupported
+	}
+	return inspector.Inspect(ctx, key)
+}
+
+// Reset clears the key in Redis and in memory.
+func (r *FallbackRateLimiter) Reset(ctx context.Context, key string) error {
+	_ = r.fallback.Reset(ctx, key)
+	inspector, ok := r.primary.(Inspector)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	return inspector.Reset(ctx, key)
+}
+
+func (r *FallbackRateLimiter) Close() error {
+	return errors.Join(r.primary.Close(), r.fallback.Close())
+}
@@ -5,10 +5,13 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
 	"github.com/go-redis/redis/v8"
 	"golang.org/x/time/rate"
 )
@@ -28,10 +31,68 @@ func generateUniqueID() string {
 // RateLimiter defines the strategy interface for rate limiting
 type RateLimiter interface {
 	GetLimitDetails() (int, time.Duration)
-	IsLimited(key string) (bool, error)
+	// IsLimited counts a request against key's quota, unless the quota is
+	// used up, and reports the outcome.
+	IsLimited(key string) (Decision, error)
 	Close() error
 }
 
+// Decision is the outcome of one request against a key's quota, with the
+// backoff hints sent to clients.
+type Decision struct {
+	Limited bool
+	// Remaining is how many more requests the key may make right away.
+	Remaining int
+	// RetryAfter is how long a limited key must wait before a request is
+	// allowed; zero when the request was allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the key has its whole quota again, if it
+	// makes no more requests.
+	Reset time.Duration
+}
+
+// Algorithm selects how the Redis limiter counts. The in-memory limiter is
+// always a token bucket.
+type Algorithm string
+
+const (
+	// AlgorithmSlidingWindow allows Requests in any Window: a burst uses
+	// the quota until its first request leaves the window.
+	AlgorithmSlidingWindow Algorithm = "sliding_window"
+	// AlgorithmTokenBucket holds Requests tokens, refilled evenly over
+	// Window, so quota comes back gradually after a burst.
+	AlgorithmTokenBucket Algorithm = "token_bucket"
+)
+
+// ParseAlgorithm reads an Algorithm; "" is AlgorithmSlidingWindow.
+func ParseAlgorithm(s string) (Algorithm, error) {
+	switch a := Algorithm(strings.ToLower(strings.TrimSpace(s))); a {
+	case "":
+		return AlgorithmSlidingWindow, nil
+	case AlgorithmSlidingWindow, AlgorithmTokenBucket:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown rate limit algorithm %q", s)
+}
+
+// KeyState is how much of its quota a key has used in the current window.
+type KeyState struct {
+	Key       string `json:"key"`
+	Limit     int    `json:"limit"`
+	Window    string `json:"window"`
+	Used      int    `json:"used"`
+	Remaining int    `json:"remaining"`
+	Limited   bool   `json:"limited"`
+}
+
+// Inspector is implemented by limiters whose counters operators can read
+// and reset, such as after a false positive during an incident. Both
+// built-in limiters implement it.
+type Inspector interface {
+	Inspect(ctx context.Context, key string) (KeyState, error)
+	Reset(ctx context.Context, key string) error
+}
+
 // InMemoryRateLimiter implements token bucket rate limiting for single instances
 type InMemoryRateLimiter struct {
 	requests int
@@ -55,11 +116,15 @@ func NewInMemoryRateLimiter(requests int, window time.Duration) *InMemoryRateLim
 	}
 }
 
-func (r *InMemoryRateLimiter) IsLimited(key string) (bool, error) {
+func inMemoryKey(key string) string {
 	if key == "" {
-		key = "__empty__"
+		return "__empty__"
 	}
+	return key
+}
 
+func (r *InMemoryRateLimiter) IsLimited(key string) (Decision, error) {
+	key = inMemoryKey(key)
 	now := time.Now()
 
 	r.mu.Lock()
@@ -90,7 +155,45 @@ func (r *InMemoryRateLimiter) IsLimited(key string) (bool, error) {
 		}
 	}
 
-	return !k.limiter.Allow(), nil
+	limited := !k.limiter.AllowN(now, 1)
+
+	// A token comes back every window/requests.
+	refill := float64(r.window) / float64(max(r.requests, 1))
+	tokens := k.limiter.TokensAt(now)
+	decision := Decision{
+		Limited:   limited,
+		Remaining: min(max(int(math.Floor(tokens)), 0), r.requests),
+		Reset:     time.Duration((float64(r.requests) - tokens) * refill),
+	}
+	if limited {
+		decision.RetryAfter = time.Duration((1 - tokens) * refill)
+	}
+	return decision, nil
+}
+
+// Inspect reports the whole requests the key's bucket holds. A key not seen
+// recently has its full quota.
+func (r *InMemoryRateLimiter) Inspect(_ context.Context, key string) (KeyState, error) {
+	state := KeyState{Key: key, Limit: r.requests, Window: r.window.String(), Remaining: r.requests}
+
+	r.mu.Lock()
+	k, ok := r.limiters[inMemoryKey(key)]
+	if ok {
+		state.Remaining = min(max(int(math.Floor(k.limiter.Tokens())), 0), r.requests)
+	}
+	r.mu.Unlock()
+
+	state.Used = r.requests - state.Remaining
+	state.Limited = state.Remaining == 0
+	return state, nil
+}
+
+// Reset forgets the key, restoring its full quota.
+func (r *InMemoryRateLimiter) Reset(_ context.Context, key string) error {
+	r.mu.Lock()
+	delete(r.limiters, inMemoryKey(key))
+	r.mu.Unlock()
+	return nil
 }
 
 func (r *InMemoryRateLimiter) Close() error {
@@ -124,53 +227,102 @@ func (r *InMemoryRateLimiter) GetLimitDetails() (int, time.Duration) {
 	return r.requests, r.window
 }
 
-func (r *RedisRateLimiter) IsLimited(key string) (bool, error) {
-	ctx := context.Background()
-	fullKey := key
-	if r.keyPrefix != "" && !strings.HasPrefix(key, r.keyPrefix) {
-		fullKey = r.keyPrefix + key
+func (r *RedisRateLimiter) fullKey(key string) string {
+	return prefixedKey(r.keyPrefix, key)
+}
+
+func prefixedKey(prefix, key string) string {
+	if prefix != "" && !strings.HasPrefix(key, prefix) {
+		return prefix + key
+	}
+	return key
+}
+
+// slidingWindowScript logs each allowed request in a sorted set scored by
+// its time in milliseconds. It returns whether the request is limited, the
+// requests remaining, and the milliseconds until the next request is
+// allowed and until the whole quota is back.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local member = ARGV[4]
+
+-- Forget requests that left the window.
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+
+local count = redis.call('ZCARD', key)
+if count >= limit then
+	-- Quota comes back as the oldest request leaves the window, and in full
+	-- once the newest has.
+	local retry, reset = window, window
+	local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+	if oldest[2] then
+		retry = tonumber(oldest[2]) + window - now
+		reset = tonumber(redis.call('ZRANGE', key, -1, -1, 'WITHSCORES')[2]) + window - now
+	end
+	return {1, 0, retry, reset}
+end
+
+redis.call('ZADD', key, now, member)
+redis.call('PEXPIRE', key, window)
+return {0, limit - count - 1, 0, window}
+`)
+
+func (r *RedisRateLimiter) IsLimited(key string) (Decision, error) {
+	fullKey := r.fullKey(key)
+	return runDecisionScript(r.client, r.logger, slidingWindowScript, fullKey,
+		time.Now().UnixMilli(), r.window.Milliseconds(), r.requests, generateUniqueID())
+}
+
+// runDecisionScript runs a limiter script that returns limited (0 or 1),
+// remaining, and the retry and reset times in milliseconds.
+func runDecisionScript(client *redis.Client, logger Logger, script *redis.Script, fullKey string, args ...interface{}) (Decision, error) {
+	result, err := script.Run(context.Background(), client, []string{fullKey}, args...).Int64Slice()
+	if err == nil && len(result) != 4 {
+		err = fmt.Errorf("unexpected script result %v", result)
 	}
-	now := time.Now().Unix()
-	memberID := generateUniqueID()
-
-	// Atomic sliding window rate limiting.
-	script := `
-		local key = KEYS[1]
-		local now = tonumber(ARGV[1])
-		local window = tonumber(ARGV[2])
-		local limit = tonumber(ARGV[3])
-		local expire = tonumber(ARGV[4])
-		local memberId = ARGV[5]
-		
-		-- Remove old entries outside the window
-		redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
-		
-		-- Count current requests in window
-		local count = redis.call('ZCARD', key)
-		
-		-- Check if limit exceeded
-		if count >= limit then
-			return 1 -- Rate limited
-		end
-		
-		-- Add current request with unique member ID
-		redis.call('ZADD', key, now, memberId)
-		
-		-- Set expiration on the key for cleanup
-		redis.call('EXPIRE', key, expire)
-		
-		return 0 -- Not rate limited
-	`
-
-	result, err := r.client.Eval(ctx, script, []string{fullKey}, now, int64(r.window.Seconds()), r.requests, int64((r.window * 2).Seconds()), memberID).Result()
 	if err != nil {
-		if r.logger != nil {
-			r.logger.Error("Redis rate limit script execution failed", "key", fullKey, "error", err)
+		if logger != nil {
+			logger.Error("Redis rate limit script execution failed", "key", fullKey, "error", err)
 		}
 		// Return error instead of silently allowing: limiting is a security control.
-		return false, fmt.Errorf("rate limiter Redis error: %w", err)
+		return Decision{}, fmt.Errorf("rate limiter Redis error: %w", err)
+	}
+	return Decision{
+		Limited:    result[0] == 1,
+		Remaining:  int(result[1]),
+		RetryAfter: time.Duration(result[2]) * time.Millisecond,
+		Reset:      time.Duration(result[3]) * time.Millisecond,
+	}, nil
+}
+
+// Inspect counts the key's requests in the current window without recording
+// one.
+func (r *RedisRateLimiter) Inspect(ctx context.Context, key string) (KeyState, error) {
+	since := time.Now().Add(-r.window).UnixMilli()
+	used, err := r.client.ZCount(ctx, r.fullKey(key), "("+strconv.FormatInt(since, 10), "+inf").Result()
+	if err != nil {
+		return KeyState{}, fmt.Errorf("rate limiter Redis error: %w", err)
 	}
-	return result.(int64) == 1, nil
+	return KeyState{
+		Key:       key,
+		Limit:     r.requests,
+		Window:    r.window.String(),
+		Used:      int(used),
+		Remaining: max(r.requests-int(used), 0),
+		Limited:   int(used) >= r.requests,
+	}, nil
+}
+
+// Reset deletes the key's window. Every Redis limiter with the same prefix
+// counts the key in that window, so they are all reset.
+func (r *RedisRateLimiter) Reset(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, r.fullKey(key)).Err(); err != nil {
+		return fmt.Errorf("rate limiter Redis error: %w", err)
+	}
+	return nil
 }
 
 // The Redis client is owned by the ApplicationConfig and closed there
@@ -184,12 +336,26 @@ type RateLimitConfig struct {
 	Window   time.Duration
 	Redis    *redis.Client // Optional, if nil uses in-memory
 	Logger   Logger        // Optional logger for Redis operations
+	// Algorithm selects the Redis limiter; AlgorithmSlidingWindow when empty.
+	Algorithm Algorithm
+	// Breaker, when set, guards the Redis limiter: while it is open,
+	// requests are counted in memory instead (see FallbackRateLimiter).
+	Breaker *circuitbreaker.Breaker
 }
 
 // NewRateLimiter creates a rate limiter based on configuration
 func NewRateLimiter(config *RateLimitConfig) RateLimiter {
 	if config.Redis != nil {
-		return NewRedisRateLimiter(config.Redis, config.Requests, config.Window, config.Logger)
+		var limiter RateLimiter
+		if config.Algorithm == AlgorithmTokenBucket {
+			limiter = NewRedisTokenBucketLimiter(config.Redis, config.Requests, config.Window, config.Logger)
+		} else {
+			limiter = NewRedisRateLimiter(config.Redis, config.Requests, config.Window, config.Logger)
+		}
+		if config.Breaker != nil {
+			limiter = NewFallbackRateLimiter(limiter, config.Breaker)
+		}
+		return limiter
 	}
 	return NewInMemoryRateLimiter(config.Requests, config.Window)
 }
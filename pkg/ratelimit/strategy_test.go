@@ -1,34 +1,136 @@
 package ratelimit
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
 )
 
 func TestInMemoryRateLimiter_IsLimited_IsPerKey(t *testing.T) {
 	limiter := NewInMemoryRateLimiter(1, time.Second)
 
-	limited, err := limiter.IsLimited("client-a")
+	decision, err := limiter.IsLimited("client-a")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if limited {
+	if decision.Limited {
 		t.Fatalf("first request for client-a should not be limited")
 	}
 
-	limited, err = limiter.IsLimited("client-a")
+	decision, err = limiter.IsLimited("client-a")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !limited {
+	if !decision.Limited {
 		t.Fatalf("second immediate request for client-a should be limited")
 	}
 
-	limited, err = limiter.IsLimited("client-b")
+	decision, err = limiter.IsLimited("client-b")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if limited {
+	if decision.Limited {
 		t.Fatalf("first request for client-b should not be limited (per-key limiter)")
 	}
 }
+
+func TestInMemoryRateLimiter_InspectAndReset(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewInMemoryRateLimiter(2, time.Hour)
+
+	state, err := limiter.Inspect(ctx, "client-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Used != 0 || state.Remaining != 2 || state.Limited {
+		t.Fatalf("unseen key should have its full quota, got %+v", state)
+	}
+
+	for range 3 {
+		_, _ = limiter.IsLimited("client-a")
+	}
+	state, _ = limiter.Inspect(ctx, "client-a")
+	if state.Used != 2 || state.Remaining != 0 || !state.Limited || state.Limit != 2 || state.Window != "1h0m0s" {
+		t.Fatalf("expected an exhausted quota, got %+v", state)
+	}
+
+	if err := limiter.Reset(ctx, "client-a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decision, _ := limiter.IsLimited("client-a"); decision.Limited {
+		t.Fatalf("reset key should not be limited")
+	}
+}
+
+func TestInMemoryRateLimiter_Decision(t *testing.T) {
+	limiter := NewInMemoryRateLimiter(4, time.Minute)
+
+	decision, _ := limiter.IsLimited("client-a")
+	if decision.Limited || decision.Remaining != 3 || decision.RetryAfter != 0 {
+		t.Fatalf("expected an allowed request with 3 remaining, got %+v", decision)
+	}
+	// One token comes back every 15s.
+	if decision.Reset < 14*time.Second || decision.Reset > 15*time.Second {
+		t.Fatalf("expected the quota to be full in about 15s, got %v", decision.Reset)
+	}
+
+	for range 3 {
+		_, _ = limiter.IsLimited("client-a")
+	}
+	decision, _ = limiter.IsLimited("client-a")
+	if !decision.Limited || decision.Remaining != 0 {
+		t.Fatalf("expected the fifth request to be limited, got %+v", decision)
+	}
+	if decision.RetryAfter <= 0 || decision.RetryAfter > 15*time.Second {
+		t.Fatalf("expected to wait at most one token's refill, got %v", decision.RetryAfter)
+	}
+	if decision.Reset < 59*time.Second || decision.Reset > time.Minute {
+		t.Fatalf("expected the quota to be full in about a minute, got %v", decision.Reset)
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for in, want := range map[string]Algorithm{
+		"":               AlgorithmSlidingWindow,
+		"sliding_window": AlgorithmSlidingWindow,
+		" Token_Bucket ": AlgorithmTokenBucket,
+	} {
+		if got, err := ParseAlgorithm(in); err != nil || got != want {
+			t.Fatalf("ParseAlgorithm(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseAlgorithm("leaky"); err == nil {
+		t.Fatal("expected an unknown algorithm to be rejected")
+	}
+}
+
+// failingLimiter stands in for a Redis limiter that cannot reach Redis.
+type failingLimiter struct {
+	calls int
+}
+
+func (f *failingLimiter) GetLimitDetails() (int, time.Duration) { return 1, time.Hour }
+func (f *failingLimiter) Close() error                          { return nil }
+func (f *failingLimiter) IsLimited(string) (Decision, error) {
+	f.calls++
+	return Decision{}, errors.New("connection refused")
+}
+
+func TestFallbackRateLimiter_LimitsInMemoryWhileRedisFails(t *testing.T) {
+	primary := &failingLimiter{}
+	breaker := circuitbreaker.New("redis_rate_limit", circuitbreaker.Config{FailureThreshold: 2, OpenTimeout: time.Hour})
+	limiter := NewFallbackRateLimiter(primary, breaker)
+
+	for i, want := range []bool{false, true, true, true} {
+		decision, err := limiter.IsLimited("client-a")
+		if err != nil || decision.Limited != want {
+			t.Fatalf("request %d: expected limited=%v without an error, got %+v (%v)", i+1, want, decision, err)
+		}
+	}
+	if primary.calls != 2 || breaker.State() != circuitbreaker.StateOpen {
+		t.Fatalf("expected the breaker to open after 2 Redis failures and skip Redis, got %d calls (%s)", primary.calls, breaker.State())
+	}
+}
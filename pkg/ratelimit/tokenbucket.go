@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// tokenBucketSuffix keeps buckets apart from sliding windows of the same
+// key, which are a different Redis type, while the algorithm is switched.
+const tokenBucketSuffix = ":bucket"
+
+// tokenBucketScript keeps a bucket as a hash of its tokens and when they
+// were counted, in milliseconds. It returns what slidingWindowScript does.
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+-- Milliseconds per token.
+local refill = window / capacity
+
+local state = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+-- Instances' clocks differ slightly; never refill backwards.
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) / refill)
+	ts = now
+end
+
+local limited, retry = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	limited = 1
+	retry = math.ceil((1 - tokens) * refill)
+end
+local reset = math.ceil((capacity - tokens) * refill)
+
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', tostring(ts))
+-- A bucket left alone refills; it need not outlive that.
+redis.call('PEXPIRE', key, math.max(reset, 1))
+return {limited, math.floor(tokens), retry, reset}
+`)
+
+// RedisTokenBucketLimiter implements token bucket rate limiting for
+// distributed systems: each key holds up to requests tokens, refilled
+// evenly over window, as the in-memory limiter does per instance.
+type RedisTokenBucketLimiter struct {
+	client    *redis.Client
+	requests  int
+	window    time.Duration
+	keyPrefix string
+	logger    Logger
+}
+
+func NewRedisTokenBucketLimiter(client *redis.Client, requests int, window time.Duration, logger Logger) *RedisTokenBucketLimiter {
+	return &RedisTokenBucketLimiter{
+		client:    client,
+		requests:  requests,
+		window:    window,
+		keyPrefix: "ratelimit:",
+		logger:    logger,
+	}
+}
+
+func (r *RedisTokenBucketLimiter) GetLimitDetails() (int, time.Duration) {
+	return r.requests, r.window
+}
+
+func (r *RedisTokenBucketLimiter) fullKey(key string) string {
+	return prefixedKey(r.keyPrefix, key) + tokenBucketSuffix
+}
+
+func (r *RedisTokenBucketLimiter) IsLimited(key string) (Decision, error) {
+	if r.requests <= 0 {
+		return Decision{Limited: true, RetryAfter: r.window, Reset: r.window}, nil
+	}
+	return runDecisionScript(r.client, r.logger, tokenBucketScript, r.fullKey(key),
+		time.Now().UnixMilli(), r.window.Milliseconds(), r.requests)
+}
+
+// Inspect reports the whole tokens the key's bucket holds now, without
+// taking one.
+func (r *RedisTokenBucketLimiter) Inspect(ctx context.Context, key string) (KeyState, error) {
+	state := KeyState{Key: key, Limit: r.requests, Window: r.window.String(), Remaining: r.requests}
+	values, err := r.client.HMGet(ctx, r.fullKey(key), "tokens", "ts").Result()
+	if err != nil {
+		return KeyState{}, fmt.Errorf("rate limiter Redis error: %w", err)
+	}
+	tokensRaw, _ := values[0].(string)
+	tsRaw, _ := values[1].(string)
+	tokens, terr := strconv.ParseFloat(tokensRaw, 64)
+	ts, serr := strconv.ParseFloat(tsRaw, 64)
+	if terr == nil && serr == nil && r.requests > 0 {
+		elapsed := max(float64(time.Now().UnixMilli())-ts, 0)
+		tokens = math.Min(float64(r.requests), tokens+elapsed*float64(r.requests)/float64(r.window.Milliseconds()))
+		state.Remaining = min(max(int(math.Floor(tokens)), 0), r.requests)
+	}
+	state.Used = r.requests - state.Remaining
+	state.Limited = state.Remaining == 0
+	return state, nil
+}
+
+// Reset deletes the key's bucket, for every token bucket limiter with the
+// same prefix.
+func (r *RedisTokenBucketLimiter) Reset(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, r.fullKey(key)).Err(); err != nil {
+		return fmt.Errorf("rate limiter Redis error: %w", err)
+	}
+	return nil
+}
+
+// The Redis client is owned by the ApplicationConfig and closed there
+func (r *RedisTokenBucketLimiter) Close() error {
+	return nil
+}
@@ -0,0 +1,160 @@
+package rawsql
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// trustedDirective marks a call whose SQL text is built at run time from
+// values that cannot come from a request, such as a quoted table name. Put it
+// on the call's line or the line above.
+const trustedDirective = "//rawsql:trusted"
+
+// queryArgs and methodArgs give the position of the query argument of the
+// rawsql functions and of the SQL-running methods Lint checks. Methods are
+// matched by name on any receiver, which covers *gorm.DB and database/sql.
+var (
+	queryArgs  = map[string]int{"Select": 3, "Get": 3, "Exec": 2}
+	methodArgs = map[string]int{
+		"Raw":             0,
+		"Exec":            0,
+		"ExecContext":     1,
+		"QueryContext":    1,
+		"QueryRowContext": 1,
+	}
+)
+
+// Issue is a call whose SQL text is not a constant.
+type Issue struct {
+	Pos  token.Position
+	Call string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: SQL text must be a constant; pass values as parameters", i.Pos, i.Call)
+}
+
+// Lint reports SQL calls in the non-test Go files under dir whose query is
+// not a string literal, a constant, or a concatenation of those. Building SQL
+// with + or fmt.Sprintf from variables is how injection gets in, so such
+// calls must bind parameters instead or carry //rawsql:trusted.
+func Lint(dir string) ([]Issue, error) {
+	packages := make(map[string][]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			packages[filepath.Dir(path)] = append(packages[filepath.Dir(path)], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	fset := token.NewFileSet()
+	for _, pkgDir := range slices.Sorted(maps.Keys(packages)) {
+		files := make([]*ast.File, 0, len(packages[pkgDir]))
+		for _, path := range packages[pkgDir] {
+			file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+		}
+		consts := constNames(files)
+		for _, file := range files {
+			issues = append(issues, lintFile(fset, file, consts)...)
+		}
+	}
+	return issues, nil
+}
+
+func lintFile(fset *token.FileSet, file *ast.File, consts map[string]bool) []Issue {
+	trusted := make(map[int]bool)
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if strings.HasPrefix(c.Text, trustedDirective) {
+				line := fset.Position(c.Slash).Line
+				trusted[line] = true
+				trusted[line+1] = true
+			}
+		}
+	}
+
+	var issues []Issue
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		arg, ok := methodArgs[sel.Sel.Name]
+		name := sel.Sel.Name
+		if pkg, isIdent := sel.X.(*ast.Ident); isIdent && pkg.Name == "rawsql" {
+			arg, ok = queryArgs[sel.Sel.Name]
+			name = "rawsql." + name
+		}
+		if !ok || arg >= len(call.Args) || isConstant(call.Args[arg], consts) {
+			return true
+		}
+		pos := fset.Position(call.Pos())
+		if !trusted[pos.Line] {
+			issues = append(issues, Issue{Pos: pos, Call: name})
+		}
+		return true
+	})
+	return issues
+}
+
+// isConstant reports whether expr is built only from string literals and
+// constants declared in the package.
+func isConstant(expr ast.Expr, consts map[string]bool) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return e.Kind == token.STRING
+	case *ast.Ident:
+		return consts[e.Name]
+	case *ast.ParenExpr:
+		return isConstant(e.X, consts)
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD && isConstant(e.X, consts) && isConstant(e.Y, consts)
+	}
+	return false
+}
+
+func constNames(files []*ast.File) map[string]bool {
+	names := make(map[string]bool)
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if decl, ok := n.(*ast.GenDecl); ok && decl.Tok == token.CONST {
+				for _, spec := range decl.Specs {
+					for _, name := range spec.(*ast.ValueSpec).Names {
+						names[name.Name] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	return names
+}
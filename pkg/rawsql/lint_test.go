@@ -0,0 +1,38 @@
+package rawsql
+
+import "testing"
+
+func TestLint(t *testing.T) {
+	issues, err := Lint("testdata/lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		line int
+		call string
+	}{
+		{20, "Raw"},
+		{21, "rawsql.Exec"},
+		{23, "rawsql.Select"},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("expected %d issues, got %v", len(want), issues)
+	}
+	for i, w := range want {
+		if issues[i].Pos.Line != w.line || issues[i].Call != w.call {
+			t.Errorf("issue %d: expected %s on line %d, got %v", i, w.call, w.line, issues[i])
+		}
+	}
+}
+
+// TestLintModule keeps the repository itself free of SQL built at run time,
+// so go test catches what make lint would.
+func TestLintModule(t *testing.T) {
+	issues, err := Lint("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range issues {
+		t.Error(issue)
+	}
+}
@@ -0,0 +1,174 @@
+// Package rawsql runs hand-written SQL, such as reporting queries that GORM
+// expresses poorly, without giving up the safety of the query builder.
+// Statements name their parameters, @account_id rather than ?, and are always
+// bound, never formatted into the text. Every statement gets a deadline and a
+// span, and rows scan into structs by column name as GORM maps them.
+//
+//	const balancesByCurrency = `
+//		SELECT currency, SUM(balance) AS total FROM accounts
+//		WHERE account_type = @type GROUP BY currency`
+//
+//	var totals []struct {
+//		Currency string
+//		Total    int64
+//	}
+//	err := rawsql.Select(ctx, db, &totals, balancesByCurrency, rawsql.Params{"type": "USER"})
+//
+// Query text must be a constant; Lint reports calls that build it at run time.
+package rawsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
+)
+
+// DefaultTimeout bounds statements whose context has no earlier deadline.
+const DefaultTimeout = 30 * time.Second
+
+// ErrNoRows is returned by Get when the query matches nothing.
+var ErrNoRows = sql.ErrNoRows
+
+// Params holds the values of a statement's @name parameters. A slice value
+// expands to a parenthesised list, for use as "IN @ids".
+type Params map[string]any
+
+const tracerName = "github.com/akeren/go-api-foundry/pkg/rawsql"
+
+// Select runs query and scans every row into dest, a pointer to a slice of
+// structs or of single values.
+func Select(ctx context.Context, db *gorm.DB, dest any, query string, params Params) error {
+	_, err := run(ctx, "rawsql.Select", query, params, func(ctx context.Context, q string, args []any) (int64, error) {
+		result := db.WithContext(ctx).Raw(q, args...).Scan(dest) //rawsql:trusted bound by Bind
+		return result.RowsAffected, result.Error
+	})
+	return err
+}
+
+// Get runs query and scans its first row into dest, a pointer to a struct or
+// a single value. It returns ErrNoRows when there is no row.
+func Get(ctx context.Context, db *gorm.DB, dest any, query string, params Params) error {
+	_, err := run(ctx, "rawsql.Get", query, params, func(ctx context.Context, q string, args []any) (int64, error) {
+		result := db.WithContext(ctx).Raw(q, args...).Scan(dest) //rawsql:trusted bound by Bind
+		if result.Error == nil && result.RowsAffected == 0 {
+			return 0, ErrNoRows
+		}
+		return result.RowsAffected, result.Error
+	})
+	return err
+}
+
+// Exec runs a statement that returns no rows and reports how many rows it
+// changed.
+func Exec(ctx context.Context, db *gorm.DB, query string, params Params) (int64, error) {
+	return run(ctx, "rawsql.Exec", query, params, func(ctx context.Context, q string, args []any) (int64, error) {
+		result := db.WithContext(ctx).Exec(q, args...) //rawsql:trusted bound by Bind
+		return result.RowsAffected, result.Error
+	})
+}
+
+func run(ctx context.Context, op, query string, params Params, do func(context.Context, string, []any) (int64, error)) (int64, error) {
+	q, args, err := Bind(query, params)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	ctx, span := otel.Tracer(tracerName).Start(ctx, op)
+	defer span.End()
+	// The statement holds placeholders only, so it is safe to record.
+	span.SetAttributes(attribute.String("db.statement", strings.TrimSpace(query)))
+
+	rows, err := do(ctx, q, args)
+	span.SetAttributes(attribute.Int64("db.rows_affected", rows))
+	if err != nil && !errors.Is(err, ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return rows, err
+}
+
+// Bind rewrites query's @name parameters as ? placeholders and returns their
+// values in order. Every parameter must be given and every value used, so a
+// misspelt name fails here rather than in the database. Names inside string
+// literals, quoted identifiers and comments are left alone, as is @@.
+func Bind(query string, params Params) (string, []any, error) {
+	var b strings.Builder
+	var args []any
+	used := make(map[string]bool, len(params))
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				return "", nil, fmt.Errorf("rawsql: unterminated %c quote", c)
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 2
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return "", nil, errors.New("rawsql: unterminated comment")
+			}
+			b.WriteString(query[i : i+end+4])
+			i += end + 4
+		case strings.HasPrefix(query[i:], "@@"):
+			b.WriteString("@@")
+			i += 2
+		case c == '@' && i+1 < len(query) && isNameStart(query[i+1]):
+			end := i + 2
+			for end < len(query) && isNamePart(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			value, ok := params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("rawsql: missing parameter @%s", name)
+			}
+			used[name] = true
+			b.WriteByte('?')
+			args = append(args, value)
+			i = end
+		case c == '?':
+			return "", nil, errors.New("rawsql: use @name parameters, not ?")
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		if !used[name] {
+			return "", nil, fmt.Errorf("rawsql: unused parameter %s", name)
+		}
+	}
+	return b.String(), args, nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || '0' <= c && c <= '9'
+}
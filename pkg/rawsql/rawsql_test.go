@@ -0,0 +1,154 @@
+package rawsql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		params  Params
+		want    string
+		args    []any
+		wantErr string
+	}{
+		{
+			name:   "named parameters in order",
+			query:  "SELECT * FROM t WHERE a = @a AND b IN @ids AND c = @a",
+			params: Params{"a": 1, "ids": []string{"x", "y"}},
+			want:   "SELECT * FROM t WHERE a = ? AND b IN ? AND c = ?",
+			args:   []any{1, []string{"x", "y"}, 1},
+		},
+		{
+			name:   "quotes and comments are left alone",
+			query:  "SELECT '@a', \"@b\" -- @c\n/* @d */ FROM t WHERE x @@ q AND y = @y",
+			params: Params{"y": 2},
+			want:   "SELECT '@a', \"@b\" -- @c\n/* @d */ FROM t WHERE x @@ q AND y = ?",
+			args:   []any{2},
+		},
+		{name: "missing parameter", query: "SELECT @a", wantErr: "rawsql: missing parameter @a"},
+		{name: "unused parameter", query: "SELECT 1", params: Params{"a": 1}, wantErr: "rawsql: unused parameter a"},
+		{name: "positional placeholder", query: "SELECT ?", wantErr: "rawsql: use @name parameters, not ?"},
+		{name: "unterminated quote", query: "SELECT 'a", wantErr: "rawsql: unterminated ' quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args, err := Bind(tt.query, tt.params)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || !reflect.DeepEqual(args, tt.args) {
+				t.Fatalf("expected %q %v, got %q %v", tt.want, tt.args, got, args)
+			}
+		})
+	}
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.Exec("CREATE TABLE accounts (id TEXT, currency TEXT, balance INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+const insertAccount = `INSERT INTO accounts (id, currency, balance) VALUES (@id, @currency, @balance)`
+
+func TestSelectGetExec(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	for _, p := range []Params{
+		{"id": "a", "currency": "USD", "balance": 100},
+		{"id": "b", "currency": "USD", "balance": 50},
+		{"id": "c", "currency": "EUR", "balance": 70},
+	} {
+		if _, err := Exec(ctx, db, insertAccount, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var totals []struct {
+		Currency string
+		Total    int64
+	}
+	err := Select(ctx, db, &totals, `
+		SELECT currency, SUM(balance) AS total FROM accounts
+		WHERE id IN @ids GROUP BY currency ORDER BY currency`, Params{"ids": []string{"a", "b", "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(totals) != 2 || totals[0].Currency != "EUR" || totals[0].Total != 70 || totals[1].Total != 150 {
+		t.Fatalf("unexpected totals: %+v", totals)
+	}
+
+	var balance int64
+	if err := Get(ctx, db, &balance, `SELECT balance FROM accounts WHERE id = @id`, Params{"id": "b"}); err != nil || balance != 50 {
+		t.Fatalf("expected 50, got %d (err %v)", balance, err)
+	}
+	if err := Get(ctx, db, &balance, `SELECT balance FROM accounts WHERE id = @id`, Params{"id": "z"}); !errors.Is(err, ErrNoRows) {
+		t.Fatalf("expected ErrNoRows, got %v", err)
+	}
+
+	changed, err := Exec(ctx, db, `UPDATE accounts SET balance = 0 WHERE currency = @currency`, Params{"currency": "USD"})
+	if err != nil || changed != 2 {
+		t.Fatalf("expected 2 rows changed, got %d (err %v)", changed, err)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db := openTestDB(t)
+	if _, err := Exec(context.Background(), db, insertAccount, Params{"id": "a", "currency": "USD", "balance": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Select(context.Background(), db, &[]int{}, `SELECT nope FROM accounts`, nil); err == nil {
+		t.Fatal("expected an error for an unknown column")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "rawsql.Exec" || spans[1].Name() != "rawsql.Select" {
+		t.Fatalf("unexpected spans: %v", spans)
+	}
+	attrs := make(map[string]any)
+	for _, kv := range spans[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	if attrs["db.statement"] != insertAccount || attrs["db.rows_affected"] != int64(1) {
+		t.Fatalf("unexpected attributes: %v", attrs)
+	}
+	if spans[1].Status().Code.String() != "Error" {
+		t.Fatalf("expected the failed query's span to be an error, got %v", spans[1].Status())
+	}
+}
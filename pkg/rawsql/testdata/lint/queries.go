@@ -0,0 +1,29 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akeren/go-api-foundry/pkg/rawsql"
+	"gorm.io/gorm"
+)
+
+const byID = `SELECT * FROM accounts WHERE id = @id`
+
+func constant(ctx context.Context, db *gorm.DB, dest any) {
+	rawsql.Select(ctx, db, dest, byID, rawsql.Params{"id": "a"})
+	rawsql.Get(ctx, db, dest, byID+" LIMIT 1", rawsql.Params{"id": "a"})
+	db.Raw("SELECT 1").Scan(dest)
+}
+
+func concatenated(ctx context.Context, db *gorm.DB, dest any, id, table string) {
+	db.Raw("SELECT * FROM accounts WHERE id = '" + id + "'").Scan(dest)
+	rawsql.Exec(ctx, db, fmt.Sprintf("DELETE FROM %s", table), nil)
+	query := byID
+	rawsql.Select(ctx, db, dest, query, rawsql.Params{"id": id})
+}
+
+func trusted(db *gorm.DB, table string) {
+	//rawsql:trusted table comes from configuration
+	db.Exec("TRUNCATE " + table)
+}
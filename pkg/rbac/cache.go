@@ -0,0 +1,95 @@
+package rbac
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/principal"
+)
+
+const (
+	DefaultCacheTTL = time.Minute
+	// maxCachedSubjects bounds memory when many distinct callers are seen;
+	// the cache is simply cleared when it fills up.
+	maxCachedSubjects = 10000
+)
+
+type cacheKey struct {
+	kind    principal.Kind
+	subject string
+}
+
+type cacheEntry struct {
+	roles   []string
+	expires time.Time
+}
+
+// CachedStore caches role lookups in front of another store so authorizing a
+// request does not cost a database round trip. Subjects without bindings are
+// cached too. Grants and revokes made through the cache take effect
+// immediately on this instance; other instances see them within the TTL.
+type CachedStore struct {
+	Store
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+	now     func() time.Time
+}
+
+func NewCachedStore(store Store, ttl time.Duration) *CachedStore {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &CachedStore{Store: store, ttl: ttl, entries: make(map[cacheKey]cacheEntry), now: time.Now}
+}
+
+func (s *CachedStore) Roles(ctx context.Context, kind principal.Kind, subject string) ([]string, error) {
+	key := cacheKey{kind: kind, subject: subject}
+
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	s.mu.Unlock()
+	if ok && s.now().Before(entry.expires) {
+		return entry.roles, nil
+	}
+
+	roles, err := s.Store.Roles(ctx, kind, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) >= maxCachedSubjects {
+		clear(s.entries)
+	}
+	s.entries[key] = cacheEntry{roles: roles, expires: s.now().Add(s.ttl)}
+	return roles, nil
+}
+
+func (s *CachedStore) Grant(ctx context.Context, b Binding) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if err := s.Store.Grant(ctx, b); err != nil {
+		return err
+	}
+	s.invalidate(b)
+	return nil
+}
+
+func (s *CachedStore) Revoke(ctx context.Context, b Binding) error {
+	if err := s.Store.Revoke(ctx, b); err != nil {
+		return err
+	}
+	s.invalidate(b)
+	return nil
+}
+
+func (s *CachedStore) invalidate(b Binding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, cacheKey{kind: b.Kind, subject: b.Subject})
+}
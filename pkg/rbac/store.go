@@ -0,0 +1,193 @@
+// Package rbac stores role bindings: roles granted to users and services on
+// top of the roles carried by their credentials.
+package rbac
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/principal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotFound       = errors.New("role binding not found")
+	ErrInvalidBinding = errors.New("role binding requires a kind of user or service, a subject and a role")
+)
+
+// Binding grants Role to the principal identified by Kind and Subject.
+type Binding struct {
+	Kind      principal.Kind `json:"kind"`
+	Subject   string         `json:"subject"`
+	Role      string         `json:"role"`
+	CreatedAt time.Time      `json:"created_at,omitempty"`
+}
+
+// Validate trims the binding's fields and checks they are complete.
+func (b *Binding) Validate() error {
+	b.Subject = strings.TrimSpace(b.Subject)
+	b.Role = strings.TrimSpace(b.Role)
+	if (b.Kind != principal.KindUser && b.Kind != principal.KindService) || b.Subject == "" || b.Role == "" {
+		return ErrInvalidBinding
+	}
+	return nil
+}
+
+// Store persists role bindings. Implementations must be safe for concurrent use.
+type Store interface {
+	// Roles returns the roles bound to the principal, sorted.
+	Roles(ctx context.Context, kind principal.Kind, subject string) ([]string, error)
+	// Bindings lists bindings, optionally filtered by kind and subject.
+	Bindings(ctx context.Context, kind principal.Kind, subject string) ([]Binding, error)
+	// Grant adds a binding; granting an existing binding is a no-op.
+	Grant(ctx context.Context, b Binding) error
+	// Revoke removes a binding, returning ErrNotFound when it does not exist.
+	Revoke(ctx context.Context, b Binding) error
+}
+
+// MemoryStore keeps bindings in process memory; use it for tests and
+// deployments that configure roles at startup.
+type MemoryStore struct {
+	mu       sync.Mutex
+	bindings map[Binding]struct{}
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{bindings: make(map[Binding]struct{})}
+}
+
+func (s *MemoryStore) Roles(ctx context.Context, kind principal.Kind, subject string) ([]string, error) {
+	bindings, err := s.Bindings(ctx, kind, subject)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		roles = append(roles, b.Role)
+	}
+	return roles, nil
+}
+
+func (s *MemoryStore) Bindings(_ context.Context, kind principal.Kind, subject string) ([]Binding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Binding, 0)
+	for b := range s.bindings {
+		if (kind == "" || b.Kind == kind) && (subject == "" || b.Subject == subject) {
+			out = append(out, b)
+		}
+	}
+	sortBindings(out)
+	return out, nil
+}
+
+func (s *MemoryStore) Grant(_ context.Context, b Binding) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bindings[Binding{Kind: b.Kind, Subject: b.Subject, Role: b.Role}] = struct{}{}
+	return nil
+}
+
+func (s *MemoryStore) Revoke(_ context.Context, b Binding) error {
+	key := Binding{Kind: b.Kind, Subject: b.Subject, Role: b.Role}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.bindings[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.bindings, key)
+	return nil
+}
+
+// GormStore persists bindings in the role_bindings table.
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Roles(ctx context.Context, kind principal.Kind, subject string) ([]string, error) {
+	var roles []string
+	err := s.db.WithContext(ctx).Model(&models.RoleBinding{}).
+		Where("kind = ? AND subject = ?", string(kind), subject).
+		Order("role").
+		Pluck("role", &roles).Error
+	return roles, err
+}
+
+func (s *GormStore) Bindings(ctx context.Context, kind principal.Kind, subject string) ([]Binding, error) {
+	query := s.db.WithContext(ctx).Model(&models.RoleBinding{})
+	if kind != "" {
+		query = query.Where("kind = ?", string(kind))
+	}
+	if subject != "" {
+		query = query.Where("subject = ?", subject)
+	}
+
+	var records []models.RoleBinding
+	if err := query.Order("kind, subject, role").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	out := make([]Binding, 0, len(records))
+	for _, r := range records {
+		out = append(out, Binding{Kind: principal.Kind(r.Kind), Subject: r.Subject, Role: r.Role, CreatedAt: r.CreatedAt})
+	}
+	return out, nil
+}
+
+func (s *GormStore) Grant(ctx context.Context, b Binding) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	record := &models.RoleBinding{Kind: string(b.Kind), Subject: b.Subject, Role: b.Role}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(record).Error
+}
+
+func (s *GormStore) Revoke(ctx context.Context, b Binding) error {
+	res := s.db.WithContext(ctx).
+		Where("kind = ? AND subject = ? AND role = ?", string(b.Kind), b.Subject, b.Role).
+		Delete(&models.RoleBinding{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func sortBindings(bindings []Binding) {
+	sort.Slice(bindings, func(i, j int) bool {
+		a, b := bindings[i], bindings[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Subject != b.Subject {
+			return a.Subject < b.Subject
+		}
+		return a.Role < b.Role
+	})
+}
+
+// Merge returns roles with extra appended, skipping duplicates.
+func Merge(roles, extra []string) []string {
+	out := slices.Clone(roles)
+	for _, role := range extra {
+		if !slices.Contains(out, role) {
+			out = append(out, role)
+		}
+	}
+	return out
+}
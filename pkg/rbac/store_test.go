@@ -0,0 +1,119 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/principal"
+)
+
+func TestMemoryStore_GrantAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	for _, b := range []Binding{
+		{Kind: principal.KindUser, Subject: "user-1", Role: "auditor"},
+		{Kind: principal.KindUser, Subject: " user-1 ", Role: "admin"},
+		{Kind: principal.KindUser, Subject: "user-1", Role: "admin"},
+		{Kind: principal.KindService, Subject: "user-1", Role: "operator"},
+	} {
+		if err := s.Grant(ctx, b); err != nil {
+			t.Fatalf("Grant %+v: %v", b, err)
+		}
+	}
+
+	roles, err := s.Roles(ctx, principal.KindUser, "user-1")
+	if err != nil {
+		t.Fatalf("Roles: %v", err)
+	}
+	if len(roles) != 2 || roles[0] != "admin" || roles[1] != "auditor" {
+		t.Fatalf("expected [admin auditor], got %v", roles)
+	}
+
+	all, _ := s.Bindings(ctx, "", "")
+	if len(all) != 3 {
+		t.Fatalf("expected 3 bindings, got %v", all)
+	}
+
+	if err := s.Revoke(ctx, Binding{Kind: principal.KindUser, Subject: "user-1", Role: "admin"}); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if err := s.Revoke(ctx, Binding{Kind: principal.KindUser, Subject: "user-1", Role: "admin"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestBinding_Validate(t *testing.T) {
+	for _, b := range []Binding{
+		{Kind: "robot", Subject: "x", Role: "admin"},
+		{Kind: principal.KindUser, Subject: " ", Role: "admin"},
+		{Kind: principal.KindUser, Subject: "x"},
+	} {
+		if err := b.Validate(); !errors.Is(err, ErrInvalidBinding) {
+			t.Fatalf("expected ErrInvalidBinding for %+v, got %v", b, err)
+		}
+	}
+}
+
+type countingStore struct {
+	Store
+	lookups int
+	err     error
+}
+
+func (s *countingStore) Roles(ctx context.Context, kind principal.Kind, subject string) ([]string, error) {
+	s.lookups++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.Store.Roles(ctx, kind, subject)
+}
+
+func TestCachedStore(t *testing.T) {
+	ctx := context.Background()
+	backing := &countingStore{Store: NewMemoryStore()}
+	s := NewCachedStore(backing, time.Minute)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	// Subjects without bindings are cached as well.
+	for range 3 {
+		if roles, err := s.Roles(ctx, principal.KindUser, "user-1"); err != nil || len(roles) != 0 {
+			t.Fatalf("expected no roles, got %v, %v", roles, err)
+		}
+	}
+	if backing.lookups != 1 {
+		t.Fatalf("expected 1 lookup, got %d", backing.lookups)
+	}
+
+	// Grants through the cache are visible immediately.
+	if err := s.Grant(ctx, Binding{Kind: principal.KindUser, Subject: "user-1", Role: "admin"}); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+	if roles, _ := s.Roles(ctx, principal.KindUser, "user-1"); len(roles) != 1 {
+		t.Fatalf("expected admin after grant, got %v", roles)
+	}
+
+	// Changes made elsewhere show up once the entry expires.
+	_ = backing.Store.Revoke(ctx, Binding{Kind: principal.KindUser, Subject: "user-1", Role: "admin"})
+	if roles, _ := s.Roles(ctx, principal.KindUser, "user-1"); len(roles) != 1 {
+		t.Fatalf("expected cached admin role, got %v", roles)
+	}
+	now = now.Add(time.Minute)
+	if roles, _ := s.Roles(ctx, principal.KindUser, "user-1"); len(roles) != 0 {
+		t.Fatalf("expected revoke to be visible after TTL, got %v", roles)
+	}
+
+	// Lookup failures are not cached.
+	backing.err = errors.New("db down")
+	now = now.Add(time.Minute)
+	if _, err := s.Roles(ctx, principal.KindUser, "user-1"); err == nil {
+		t.Fatal("expected lookup error")
+	}
+	backing.err = nil
+	if _, err := s.Roles(ctx, principal.KindUser, "user-1"); err != nil {
+		t.Fatalf("expected recovery, got %v", err)
+	}
+}
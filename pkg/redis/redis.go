@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
 	"github.com/go-redis/redis/v8"
 )
 
 type RedisCache struct {
-	client *redis.Client
+	client  *redis.Client
+	breaker *circuitbreaker.Breaker
 }
 
 type Config struct {
@@ -17,6 +19,10 @@ type Config struct {
 	Port     string
 	Password string
 	DB       int
+	// Breaker, when set, fails cache calls with circuitbreaker.ErrOpen while
+	// Redis keeps failing, instead of letting each wait for its timeout.
+	// Ping bypasses it, so health checks see Redis itself.
+	Breaker *circuitbreaker.Breaker
 }
 
 func NewRedisCache(cfg *Config) (*RedisCache, error) {
@@ -30,23 +36,139 @@ func NewRedisCache(cfg *Config) (*RedisCache, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &RedisCache{client: client}, nil
+	return &RedisCache{client: client, breaker: cfg.Breaker}, nil
 }
 
 func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
-	val, err := r.client.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return "", nil
-	}
+	var val string
+	err := r.breaker.Do(func() (err error) {
+		val, err = r.client.Get(ctx, key).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		return err
+	})
 	return val, err
 }
 
+func (r *RedisCache) GetWithTTL(ctx context.Context, key string) (string, time.Duration, error) {
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	err := r.breaker.Do(func() error {
+		_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			get = pipe.Get(ctx, key)
+			pttl = pipe.PTTL(ctx, key)
+			return nil
+		})
+		if err == redis.Nil {
+			get = nil
+			return nil
+		}
+		return err
+	})
+	if err != nil || get == nil {
+		return "", 0, err
+	}
+	// PTTL is negative for a key without an expiry.
+	return get.Val(), max(pttl.Val(), 0), nil
+}
+
+func (r *RedisCache) MGet(ctx context.Context, keys ...string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	var vals []any
+	err := r.breaker.Do(func() (err error) {
+		vals, err = r.client.MGet(ctx, keys...).Result()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(vals))
+	for i, v := range vals {
+		out[i], _ = v.(string)
+	}
+	return out, nil
+}
+
 func (r *RedisCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
-	return r.client.Set(ctx, key, value, ttl).Err()
+	return r.breaker.Do(func() error {
+		return r.client.Set(ctx, key, value, ttl).Err()
+	})
+}
+
+func (r *RedisCache) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	var set bool
+	err := r.breaker.Do(func() (err error) {
+		set, err = r.client.SetNX(ctx, key, value, ttl).Result()
+		return err
+	})
+	return set, err
+}
+
+// incrByScript increments and sets the expiry in one step, so a counter is
+// never left without one.
+var incrByScript = redis.NewScript(`
+local v = redis.call("INCRBY", KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return v
+`)
+
+func (r *RedisCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return r.IncrBy(ctx, key, 1, ttl)
+}
+
+func (r *RedisCache) IncrBy(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	var n int64
+	err := r.breaker.Do(func() (err error) {
+		n, err = incrByScript.Run(ctx, r.client, []string{key}, delta, ttl.Milliseconds()).Int64()
+		return err
+	})
+	return n, err
 }
 
 func (r *RedisCache) Delete(ctx context.Context, key string) error {
-	return r.client.Del(ctx, key).Err()
+	return r.breaker.Do(func() error {
+		return r.client.Del(ctx, key).Err()
+	})
+}
+
+// deleteBatchSize is how many keys DeleteByPattern scans for and unlinks at
+// a time.
+const deleteBatchSize = 500
+
+func (r *RedisCache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	err := r.breaker.Do(func() (err error) {
+		deleted, err = r.deleteByPattern(ctx, pattern)
+		return err
+	})
+	return deleted, err
+}
+
+func (r *RedisCache) deleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, deleteBatchSize).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := r.client.Unlink(ctx, keys...).Result()
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
 }
 
 func (r *RedisCache) Ping(ctx context.Context) error {
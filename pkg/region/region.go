@@ -0,0 +1,90 @@
+// Package region identifies the region an instance runs in, for active-active
+// deployments. IDs generated in a region carry its numeric code, so a proxy
+// can route a request for a resource to its home region from the ID alone.
+package region
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Header names the region that served a response.
+	Header = "X-Region"
+	// HomeHeader names the region a rejected write must be sent to.
+	HomeHeader = "X-Home-Region"
+
+	// MaxCode is the largest code that fits in an ID.
+	MaxCode = 1<<12 - 1
+)
+
+// Region is a deployment region: Name is what operators and headers use, and
+// Code, between 1 and MaxCode, is what IDs carry.
+type Region struct {
+	Name string
+	Code uint16
+}
+
+// New validates a region.
+func New(name string, code uint16) (*Region, error) {
+	if name == "" {
+		return nil, errors.New("region name is required")
+	}
+	if code == 0 || code > MaxCode {
+		return nil, fmt.Errorf("region code must be between 1 and %d", MaxCode)
+	}
+	return &Region{Name: name, Code: code}, nil
+}
+
+var local atomic.Pointer[Region]
+
+// SetLocal sets the region this instance runs in; nil means a single-region
+// deployment. It is set once at startup, before any IDs are generated.
+func SetLocal(r *Region) {
+	local.Store(r)
+}
+
+// Local returns the region this instance runs in, or nil.
+func Local() *Region {
+	return local.Load()
+}
+
+// LocalName returns the name of the local region, or "".
+func LocalName() string {
+	if r := Local(); r != nil {
+		return r.Name
+	}
+	return ""
+}
+
+// NewID returns a new UUID. In a region it is a version 8 UUID carrying the
+// region's code in the 12 bits after the version; the other 110 bits are
+// random. Without a region it is a random version 4 UUID.
+func NewID() string {
+	r := Local()
+	if r == nil {
+		return uuid.NewString()
+	}
+
+	var id uuid.UUID
+	_, _ = rand.Read(id[:])
+	id[6] = 0x80 | byte(r.Code>>8)&0x0f
+	id[7] = byte(r.Code)
+	id[8] = 0x80 | id[8]&0x3f
+	return id.String()
+}
+
+// CodeOf returns the region code carried by an ID from NewID, and false for
+// IDs generated outside a region or not generated by NewID.
+func CodeOf(id string) (uint16, bool) {
+	parsed, err := uuid.Parse(id)
+	if err != nil || parsed.Version() != 8 || parsed.Variant() != uuid.RFC4122 {
+		return 0, false
+	}
+	code := uint16(parsed[6]&0x0f)<<8 | uint16(parsed[7])
+	return code, code != 0
+}
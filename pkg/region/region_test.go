@@ -0,0 +1,61 @@
+package region
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewID_CarriesLocalRegion(t *testing.T) {
+	r, err := New("eu-west-1", 0xabc)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	SetLocal(r)
+	t.Cleanup(func() { SetLocal(nil) })
+
+	seen := make(map[string]bool)
+	for range 100 {
+		id := NewID()
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			t.Fatalf("NewID returned %q: %v", id, err)
+		}
+		if parsed.Version() != 8 || parsed.Variant() != uuid.RFC4122 {
+			t.Fatalf("expected an RFC 9562 version 8 UUID, got %q", id)
+		}
+		if code, ok := CodeOf(id); !ok || code != 0xabc {
+			t.Fatalf("CodeOf(%q) = %d, %v", id, code, ok)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate ID %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestNewID_WithoutRegion(t *testing.T) {
+	SetLocal(nil)
+
+	id := NewID()
+	if parsed, err := uuid.Parse(id); err != nil || parsed.Version() != 4 {
+		t.Fatalf("expected a version 4 UUID, got %q (%v)", id, err)
+	}
+	if _, ok := CodeOf(id); ok {
+		t.Fatalf("expected no region code in %q", id)
+	}
+	if _, ok := CodeOf("not-an-id"); ok {
+		t.Fatal("expected no region code in an invalid ID")
+	}
+}
+
+func TestNew_Validates(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		code uint16
+	}{{"", 1}, {"eu", 0}, {"eu", MaxCode + 1}} {
+		if _, err := New(tt.name, tt.code); err == nil {
+			t.Errorf("New(%q, %d): expected an error", tt.name, tt.code)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const (
+	layoutsDir  = "layouts"
+	partialsDir = "partials"
+	pagesDir    = "pages"
+)
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+// Config describes where templates live. Templates are read from FS (usually
+// an embed.FS) using the following layout under Root:
+//
+//	layouts/   shared page skeletons, e.g. {{define "layout"}}...{{end}}
+//	partials/  reusable fragments, e.g. {{define "footer"}}...{{end}}
+//	pages/     one file per page, rendered by name ("status", "emails/invite")
+//
+// Every page is parsed together with all layouts and partials, so a page opts
+// into a layout by calling it: {{template "layout" .}}{{define "content"}}...{{end}}
+type Config struct {
+	FS        fs.FS
+	Root      string
+	Extension string // Default: ".html"
+	Funcs     template.FuncMap
+}
+
+// Engine holds one parsed template set per page.
+type Engine struct {
+	pages map[string]*template.Template
+}
+
+func New(cfg Config) (*Engine, error) {
+	if cfg.FS == nil {
+		return nil, fmt.Errorf("render: FS is nil")
+	}
+
+	root := strings.Trim(cfg.Root, "/")
+	if root == "" {
+		root = "."
+	}
+
+	ext := cfg.Extension
+	if ext == "" {
+		ext = ".html"
+	}
+
+	base := template.New("").Funcs(cfg.Funcs)
+	for _, dir := range []string{layoutsDir, partialsDir} {
+		if err := parseDir(base, cfg.FS, path.Join(root, dir), ext); err != nil {
+			return nil, err
+		}
+	}
+
+	engine := &Engine{pages: make(map[string]*template.Template)}
+
+	pagesRoot := path.Join(root, pagesDir)
+	err := walkTemplates(cfg.FS, pagesRoot, ext, func(name string, content []byte) error {
+		page, err := base.Clone()
+		if err != nil {
+			return fmt.Errorf("render: clone base templates: %w", err)
+		}
+		if _, err := page.New(name).Parse(string(content)); err != nil {
+			return fmt.Errorf("render: parse page %q: %w", name, err)
+		}
+		engine.pages[name] = page
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(engine.pages) == 0 {
+		return nil, fmt.Errorf("render: no pages found in %q", pagesRoot)
+	}
+
+	return engine, nil
+}
+
+// Render executes the named page into w.
+func (e *Engine) Render(w io.Writer, name string, data any) error {
+	page, ok := e.pages[name]
+	if !ok {
+		return fmt.Errorf("render: %w: %s", ErrTemplateNotFound, name)
+	}
+
+	if err := page.ExecuteTemplate(w, name, data); err != nil {
+		return fmt.Errorf("render: execute %q: %w", name, err)
+	}
+	return nil
+}
+
+// Has reports whether a page with the given name was loaded.
+func (e *Engine) Has(name string) bool {
+	_, ok := e.pages[name]
+	return ok
+}
+
+func parseDir(set *template.Template, fsys fs.FS, dir, ext string) error {
+	return walkTemplates(fsys, dir, ext, func(name string, content []byte) error {
+		if _, err := set.New(name).Parse(string(content)); err != nil {
+			return fmt.Errorf("render: parse %q: %w", path.Join(dir, name), err)
+		}
+		return nil
+	})
+}
+
+// walkTemplates calls fn for every file with the given extension under dir,
+// passing its slash-separated name relative to dir without the extension.
+// A missing dir is not an error: layouts and partials are optional.
+func walkTemplates(fsys fs.FS, dir, ext string, fn func(name string, content []byte) error) error {
+	if _, err := fs.Stat(fsys, dir); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("render: walk %q: %w", p, err)
+		}
+		if d.IsDir() || path.Ext(p) != ext {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("render: read %q: %w", p, err)
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(p, dir+"/"), ext)
+		return fn(name, content)
+	})
+}
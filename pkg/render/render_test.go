@@ -0,0 +1,99 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"templates/layouts/base.html": {Data: []byte(
+			`{{define "layout"}}<html><title>{{block "title" .}}Foundry{{end}}</title><body>{{template "content" .}}{{template "footer" .}}</body></html>{{end}}`,
+		)},
+		"templates/partials/footer.html": {Data: []byte(`{{define "footer"}}<footer>{{upper .Team}}</footer>{{end}}`)},
+		"templates/pages/status.html": {Data: []byte(
+			`{{template "layout" .}}{{define "title"}}Status{{end}}{{define "content"}}<p>{{.Message}}</p>{{end}}`,
+		)},
+		"templates/pages/emails/invite.txt.html": {Data: []byte(`Hello {{.Name}}`)},
+		"templates/pages/README.md":              {Data: []byte(`ignored`)},
+	}
+}
+
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+
+	engine, err := New(Config{
+		FS:    testFS(),
+		Root:  "templates",
+		Funcs: template.FuncMap{"upper": strings.ToUpper},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return engine
+}
+
+func TestRender_PageWithLayoutAndPartial(t *testing.T) {
+	engine := newTestEngine(t)
+
+	var buf bytes.Buffer
+	err := engine.Render(&buf, "status", map[string]string{"Message": "<ok>", "Team": "ops"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `<html><title>Status</title><body><p>&lt;ok&gt;</p><footer>OPS</footer></body></html>`
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n got: %s\nwant: %s", buf.String(), want)
+	}
+}
+
+func TestRender_NestedStandalonePage(t *testing.T) {
+	engine := newTestEngine(t)
+
+	if !engine.Has("emails/invite.txt") {
+		t.Fatalf("expected nested page to be loaded")
+	}
+	if engine.Has("README") {
+		t.Fatalf("files with other extensions must be ignored")
+	}
+
+	var buf bytes.Buffer
+	if err := engine.Render(&buf, "emails/invite.txt", map[string]string{"Name": "Ada"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "Hello Ada" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestRender_UnknownPage(t *testing.T) {
+	engine := newTestEngine(t)
+
+	err := engine.Render(&bytes.Buffer{}, "missing", nil)
+	if !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("expected ErrTemplateNotFound, got %v", err)
+	}
+}
+
+func TestNew_RequiresPages(t *testing.T) {
+	_, err := New(Config{FS: fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`{{define "layout"}}{{end}}`)},
+	}})
+	if err == nil {
+		t.Fatalf("expected error when no pages exist")
+	}
+}
+
+func TestNew_ParseError(t *testing.T) {
+	_, err := New(Config{FS: fstest.MapFS{
+		"pages/broken.html": {Data: []byte(`{{if}}`)},
+	}})
+	if err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
@@ -0,0 +1,62 @@
+package replica
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	lagDesc = prometheus.NewDesc(
+		"db_replica_lag_seconds",
+		"Read-after-write latency of the replica at the latest measurement.",
+		nil, nil,
+	)
+	upDesc = prometheus.NewDesc(
+		"db_replica_up",
+		"Whether the latest replica lag measurement succeeded.",
+		nil, nil,
+	)
+)
+
+type metrics struct {
+	readAfterWrite prometheus.Histogram
+	fallbacks      prometheus.Counter
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		readAfterWrite: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "db_replica_read_after_write_seconds",
+			Help:    "Time for a write on the primary to become readable on the replica.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}),
+		fallbacks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "db_replica_fallback_reads_total",
+			Help: "Reads sent to the primary because the replica lagged or was unavailable.",
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Monitor) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lagDesc
+	ch <- upDesc
+	m.metrics.readAfterWrite.Describe(ch)
+	m.metrics.fallbacks.Describe(ch)
+}
+
+// Collect implements prometheus.Collector. The lag gauges are left out until
+// the first measurement.
+func (m *Monitor) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	measured, lag, lastErr := m.measured, m.lag, m.lastErr
+	m.mu.Unlock()
+
+	if measured {
+		up := 0.0
+		if lastErr == nil {
+			up = 1
+			ch <- prometheus.MustNewConstMetric(lagDesc, prometheus.GaugeValue, lag.Seconds())
+		}
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, up)
+	}
+	m.metrics.readAfterWrite.Collect(ch)
+	m.metrics.fallbacks.Collect(ch)
+}
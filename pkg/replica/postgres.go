@@ -0,0 +1,50 @@
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// pollInterval is how often PostgresReadAfterWrite asks the replica whether
+// it has caught up.
+const pollInterval = 10 * time.Millisecond
+
+// PostgresReadAfterWrite measures lag as the time a PostgreSQL streaming
+// replica takes to replay the primary's current WAL position, which is how
+// long a write made now takes to become readable there. Unlike the age of the
+// last replayed transaction, it stays near zero while the primary is idle.
+// A replica that has not caught up when ctx ends is reported as behind by the
+// time waited. A server that is not in recovery counts as caught up.
+func PostgresReadAfterWrite(primary, replica *sql.DB) MeasureFunc {
+	return func(ctx context.Context) (time.Duration, error) {
+		start := time.Now()
+		var lsn string
+		if err := primary.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&lsn); err != nil {
+			return 0, fmt.Errorf("read primary WAL position: %w", err)
+		}
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			var replayed bool
+			err := replica.QueryRowContext(ctx,
+				"SELECT COALESCE(pg_last_wal_replay_lsn() >= $1::pg_lsn, true)", lsn).Scan(&replayed)
+			if ctx.Err() != nil {
+				return time.Since(start), nil
+			}
+			if err != nil {
+				return 0, fmt.Errorf("read replica WAL position: %w", err)
+			}
+			if replayed {
+				return time.Since(start), nil
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return time.Since(start), nil
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package replica routes database reads to a read replica while it keeps up
+// with the primary. A Monitor measures the replica's read-after-write latency
+// in the background and sends reads back to the primary when the replica
+// lags or cannot be reached.
+package replica
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrLagging is reported by Check while the replica is further behind
+	// the primary than MaxLag.
+	ErrLagging = errors.New("replica lag exceeds the maximum")
+	// ErrNotMeasured is reported by Check before the first measurement.
+	ErrNotMeasured = errors.New("replica lag not measured yet")
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// MeasureFunc returns how far the replica is behind the primary.
+type MeasureFunc func(ctx context.Context) (time.Duration, error)
+
+type Config struct {
+	// Interval between measurements.
+	Interval time.Duration
+	// Timeout bounds a single measurement. A replica that has not caught up
+	// by then is reported as at least Timeout behind.
+	Timeout time.Duration
+	// MaxLag is the most a replica may be behind and still serve reads that
+	// must see recent writes.
+	MaxLag time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval: 5 * time.Second,
+		Timeout:  5 * time.Second,
+		MaxLag:   time.Second,
+	}
+}
+
+// Monitor measures replication lag every Interval and picks the connection
+// each read uses.
+type Monitor struct {
+	cfg     Config
+	logger  Logger
+	primary *gorm.DB
+	replica *gorm.DB
+	measure MeasureFunc
+	metrics *metrics
+
+	mu       sync.Mutex
+	measured bool
+	lag      time.Duration
+	lastErr  error
+
+	startMu sync.Mutex
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewMonitor(logger Logger, primary, replica *gorm.DB, measure MeasureFunc, cfg Config) *Monitor {
+	defaults := DefaultConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.MaxLag <= 0 {
+		cfg.MaxLag = defaults.MaxLag
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Monitor{
+		cfg:     cfg,
+		logger:  logger,
+		primary: primary,
+		replica: replica,
+		measure: measure,
+		metrics: newMetrics(),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+}
+
+// Start measures the lag now and then every Interval until Stop. Until the
+// first measurement completes every read goes to the primary.
+func (m *Monitor) Start() {
+	m.startMu.Lock()
+	defer m.startMu.Unlock()
+	if m.started {
+		return
+	}
+	m.started = true
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			_ = m.Refresh(m.ctx)
+			select {
+			case <-ticker.C:
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the measurements and waits for one in flight.
+func (m *Monitor) Stop() {
+	m.startMu.Lock()
+	started := m.started
+	m.startMu.Unlock()
+
+	m.cancel()
+	if started {
+		<-m.done
+	}
+}
+
+// Refresh measures the lag once and records the result.
+func (m *Monitor) Refresh(ctx context.Context) error {
+	measureCtx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	lag, err := m.measure(measureCtx)
+	if ctx.Err() != nil {
+		// Cancelled rather than timed out, e.g. by Stop; keep the last result.
+		return ctx.Err()
+	}
+
+	m.mu.Lock()
+	wasUsable := m.measured && m.lastErr == nil && m.lag <= m.cfg.MaxLag
+	m.measured = true
+	m.lastErr = err
+	if err == nil {
+		m.lag = lag
+	}
+	usable := err == nil && lag <= m.cfg.MaxLag
+	m.mu.Unlock()
+
+	if err == nil {
+		m.metrics.readAfterWrite.Observe(lag.Seconds())
+	}
+	switch {
+	case err != nil && wasUsable:
+		m.logger.Warn("Replica lag check failed; reading from the primary", "error", err)
+	case err == nil && !usable && wasUsable:
+		m.logger.Warn("Replica lagging; consistent reads use the primary", "lag", lag, "max_lag", m.cfg.MaxLag)
+	case usable && !wasUsable:
+		m.logger.Info("Replica caught up", "lag", lag)
+	}
+	return err
+}
+
+// Lag returns the latest measurement, or the error that prevented it.
+func (m *Monitor) Lag() (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.measured {
+		return 0, ErrNotMeasured
+	}
+	return m.lag, m.lastErr
+}
+
+// Check reports the replica as unhealthy while it lags by more than MaxLag
+// or its lag cannot be measured. It reads the latest measurement, so it is
+// cheap enough to register as a dependency probe.
+func (m *Monitor) Check(context.Context) error {
+	lag, err := m.Lag()
+	if err != nil {
+		return err
+	}
+	if lag > m.cfg.MaxLag {
+		return fmt.Errorf("%w: %s behind", ErrLagging, lag)
+	}
+	return nil
+}
+
+// DB returns the connection for a read. Consistent reads, which must see
+// writes made moments earlier, use the replica only while it lags by at most
+// MaxLag; other reads use it whenever its lag could be measured. Everything
+// else goes to the primary.
+func (m *Monitor) DB(consistent bool) *gorm.DB {
+	m.mu.Lock()
+	usable := m.measured && m.lastErr == nil && (!consistent || m.lag <= m.cfg.MaxLag)
+	m.mu.Unlock()
+
+	if usable {
+		return m.replica
+	}
+	m.metrics.fallbacks.Inc()
+	return m.primary
+}
+
+// Replica returns the replica connection, for closing it on shutdown.
+func (m *Monitor) Replica() *gorm.DB {
+	return m.replica
+}
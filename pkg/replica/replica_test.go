@@ -0,0 +1,134 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// fakeLag is a MeasureFunc whose result the test sets.
+type fakeLag struct {
+	lag atomic.Int64
+	err atomic.Pointer[error]
+}
+
+func (f *fakeLag) measure(context.Context) (time.Duration, error) {
+	if err := f.err.Load(); err != nil {
+		return 0, *err
+	}
+	return time.Duration(f.lag.Load()), nil
+}
+
+func TestMonitor_RoutesReadsByLag(t *testing.T) {
+	primary, replica := openTestDB(t), openTestDB(t)
+	lag := &fakeLag{}
+	m := NewMonitor(nopLogger{}, primary, replica, lag.measure, Config{MaxLag: 100 * time.Millisecond})
+	ctx := context.Background()
+
+	if m.DB(false) != primary || !errors.Is(m.Check(ctx), ErrNotMeasured) {
+		t.Fatal("expected reads on the primary before the first measurement")
+	}
+
+	lag.lag.Store(int64(20 * time.Millisecond))
+	if err := m.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if m.DB(true) != replica || m.DB(false) != replica || m.Check(ctx) != nil {
+		t.Fatal("expected reads on the replica while it keeps up")
+	}
+
+	lag.lag.Store(int64(time.Second))
+	_ = m.Refresh(ctx)
+	if m.DB(true) != primary || m.DB(false) != replica {
+		t.Fatal("expected only consistent reads on the primary while the replica lags")
+	}
+	if err := m.Check(ctx); !errors.Is(err, ErrLagging) {
+		t.Fatalf("expected ErrLagging, got %v", err)
+	}
+
+	down := errors.New("connection refused")
+	lag.err.Store(&down)
+	if err := m.Refresh(ctx); !errors.Is(err, down) {
+		t.Fatalf("expected the measurement error, got %v", err)
+	}
+	if m.DB(false) != primary || !errors.Is(m.Check(ctx), down) {
+		t.Fatal("expected every read on the primary while the replica is unreachable")
+	}
+	if got := testutil.ToFloat64(m.metrics.fallbacks); got != 3 {
+		t.Fatalf("expected 3 fallback reads, got %v", got)
+	}
+}
+
+func TestMonitor_Metrics(t *testing.T) {
+	lag := &fakeLag{}
+	lag.lag.Store(int64(250 * time.Millisecond))
+	m := NewMonitor(nopLogger{}, openTestDB(t), openTestDB(t), lag.measure, Config{})
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `
+# HELP db_replica_lag_seconds Read-after-write latency of the replica at the latest measurement.
+# TYPE db_replica_lag_seconds gauge
+db_replica_lag_seconds 0.25
+# HELP db_replica_up Whether the latest replica lag measurement succeeded.
+# TYPE db_replica_up gauge
+db_replica_up 1
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want), "db_replica_lag_seconds", "db_replica_up"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(m, "db_replica_read_after_write_seconds"); n != 1 {
+		t.Fatalf("expected the read-after-write histogram, got %d series", n)
+	}
+}
+
+func TestMonitor_StopKeepsLastResult(t *testing.T) {
+	measured := make(chan struct{}, 1)
+	m := NewMonitor(nopLogger{}, openTestDB(t), openTestDB(t), func(ctx context.Context) (time.Duration, error) {
+		select {
+		case measured <- struct{}{}:
+			return time.Millisecond, nil
+		default:
+		}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, Config{Interval: time.Millisecond, Timeout: time.Minute})
+
+	m.Start()
+	<-measured
+	time.Sleep(10 * time.Millisecond)
+	m.Stop()
+
+	if lag, err := m.Lag(); err != nil || lag != time.Millisecond {
+		t.Fatalf("expected the measurement before Stop to be kept, got %v, %v", lag, err)
+	}
+}
@@ -0,0 +1,107 @@
+package respcache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	redisKeyPrefix = "respcache:"
+	redisTagPrefix = "respcache:tag:"
+
+	// tagTTL is how long a tag's generation is kept after it was last set.
+	// One that expires gets a new generation, which only costs a miss.
+	tagTTL = 7 * 24 * time.Hour
+)
+
+// RedisStore keeps responses in Redis, shared by every instance, so a write
+// on one instance evicts the responses every instance serves. A tag's
+// generation is a random token, replaced on invalidation; keys expire on
+// their own.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Generation(ctx context.Context, tags []string) (string, error) {
+	if len(tags) == 0 {
+		return "", nil
+	}
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = redisTagPrefix + tag
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, len(tags))
+	for i, value := range values {
+		token, ok := value.(string)
+		if !ok {
+			// First use of the tag: give it a token, or take the one a
+			// concurrent request gave it first.
+			if err := s.client.SetNX(ctx, keys[i], newToken(), tagTTL).Err(); err != nil {
+				return "", err
+			}
+			if token, err = s.client.Get(ctx, keys[i]).Result(); err != nil {
+				return "", err
+			}
+		}
+		parts[i] = token
+	}
+	return strings.Join(parts, "."), nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (*Response, error) {
+	raw, err := s.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, resp Response, ttl time.Duration) error {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisKeyPrefix+key, raw, ttl).Err()
+}
+
+func (s *RedisStore) Invalidate(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range tags {
+			pipe.Set(ctx, redisTagPrefix+tag, newToken(), tagTTL)
+		}
+		return nil
+	})
+	return err
+}
+
+func newToken() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,106 @@
+// Package respcache stores successful GET responses so repeated reads are
+// answered without running their handler. Responses are grouped by tags,
+// and invalidating a tag evicts every response stored under it.
+package respcache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Response is a stored response, served as is.
+type Response struct {
+	StatusCode int               `json:"status_code"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       []byte            `json:"body"`
+}
+
+// Store keeps responses by key. Implementations must be safe for concurrent
+// use.
+//
+// Tags are not stored with a response. Callers instead put the tags'
+// generation in the key: invalidating a tag changes its generation, so the
+// responses stored under the old one are never looked up again and expire.
+// A response built while its tags were invalidated is stored under the
+// generation read before the handler ran, which is already stale.
+type Store interface {
+	// Generation returns a value that changes whenever any of tags is
+	// invalidated, and "" for no tags.
+	Generation(ctx context.Context, tags []string) (string, error)
+	// Get returns the response stored under key, or nil.
+	Get(ctx context.Context, key string) (*Response, error)
+	// Set stores resp under key for ttl.
+	Set(ctx context.Context, key string, resp Response, ttl time.Duration) error
+	// Invalidate evicts the responses stored under any of tags.
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+// MemoryStore keeps responses in process memory, for tests and
+// single-instance deployments.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	// generations counts each tag's invalidations; tags never invalidated
+	// are absent.
+	generations map[string]uint64
+}
+
+type memoryEntry struct {
+	response  Response
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), generations: make(map[string]uint64)}
+}
+
+func (s *MemoryStore) Generation(_ context.Context, tags []string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parts := make([]string, len(tags))
+	for i, tag := range tags {
+		parts[i] = strconv.FormatUint(s.generations[tag], 10)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if !time.Now().Before(e.expiresAt) {
+		delete(s.entries, key)
+		return nil, nil
+	}
+	resp := e.response
+	return &resp, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, resp Response, ttl time.Duration) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = memoryEntry{response: resp, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Invalidate(_ context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range tags {
+		s.generations[tag]++
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package respcache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_StoresUntilExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if resp, err := store.Get(ctx, "key-1"); err != nil || resp != nil {
+		t.Fatalf("expected a miss, got %v, %v", resp, err)
+	}
+	want := Response{StatusCode: 200, Header: map[string]string{"Content-Type": "application/json"}, Body: []byte(`{"id":1}`)}
+	if err := store.Set(ctx, "key-1", want, time.Hour); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	resp, err := store.Get(ctx, "key-1")
+	if err != nil || resp == nil || resp.StatusCode != 200 || string(resp.Body) != `{"id":1}` || resp.Header["Content-Type"] != "application/json" {
+		t.Fatalf("unexpected stored response %+v, %v", resp, err)
+	}
+
+	if err := store.Set(ctx, "key-2", want, time.Nanosecond); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if resp, _ := store.Get(ctx, "key-2"); resp != nil {
+		t.Fatalf("expected the expired response to be gone, got %+v", resp)
+	}
+}
+
+func TestMemoryStore_InvalidateChangesGeneration(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if gen, _ := store.Generation(ctx, nil); gen != "" {
+		t.Fatalf("expected no generation without tags, got %q", gen)
+	}
+	before, _ := store.Generation(ctx, []string{"a", "b"})
+	if again, _ := store.Generation(ctx, []string{"a", "b"}); again != before {
+		t.Fatalf("expected a stable generation, got %q and %q", before, again)
+	}
+
+	if err := store.Invalidate(ctx, "b"); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if after, _ := store.Generation(ctx, []string{"a", "b"}); after == before {
+		t.Fatalf("expected invalidating a tag to change the generation, still %q", after)
+	}
+	if other, _ := store.Generation(ctx, []string{"a"}); other != "0" {
+		t.Fatalf("expected other tags to keep their generation, got %q", other)
+	}
+}
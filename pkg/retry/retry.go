@@ -0,0 +1,105 @@
+// Package retry computes exponential backoff delays and retries operations
+// with them.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff grows the delay between attempts by Multiplier, from Initial up to
+// Max.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	// Jitter varies each delay by up to this fraction (0 to 1) either way, so
+	// clients that failed together do not retry together.
+	Jitter float64
+}
+
+func DefaultBackoff() Backoff {
+	return Backoff{
+		Initial:    time.Second,
+		Max:        time.Minute,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+}
+
+// Delay returns how long to wait after the given failed attempt (1-based).
+func (b Backoff) Delay(attempt int) time.Duration {
+	defaults := DefaultBackoff()
+	if b.Initial <= 0 {
+		b.Initial = defaults.Initial
+	}
+	if b.Max < b.Initial {
+		b.Max = max(defaults.Max, b.Initial)
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = defaults.Multiplier
+	}
+	attempt = max(attempt, 1)
+
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(attempt-1))
+	delay = min(delay, float64(b.Max))
+	if jitter := min(max(b.Jitter, 0), 1); jitter > 0 {
+		delay *= 1 + jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// permanentError stops Do from retrying the error it wraps.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying: Do returns it, unwrapped, at
+// once. It returns nil for a nil err.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Do calls fn until it succeeds, attempts calls have failed, fn returns a
+// Permanent error or ctx ends, waiting b.Delay between calls. It returns the
+// last error from fn, or the context's error when ctx ends while waiting.
+func Do(ctx context.Context, b Backoff, attempts int, fn func(ctx context.Context) error) error {
+	attempts = max(attempts, 1)
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt == attempts {
+			return err
+		}
+
+		timer := time.NewTimer(b.Delay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
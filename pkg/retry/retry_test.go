@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDelay_GrowsUpToMax(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 3}
+	want := []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := b.Delay(i + 1); got != w {
+			t.Fatalf("attempt %d: expected %s, got %s", i+1, w, got)
+		}
+	}
+}
+
+func TestDelay_JitterStaysInRange(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: time.Minute, Multiplier: 2, Jitter: 0.5}
+	for range 100 {
+		if got := b.Delay(2); got < time.Second || got > 3*time.Second {
+			t.Fatalf("expected 2s ± 50%%, got %s", got)
+		}
+	}
+}
+
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), Backoff{Initial: time.Millisecond}, 5, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("expected success on the third call, got %v after %d calls", err, calls)
+	}
+}
+
+func TestDo_ReturnsLastErrorAfterAttempts(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), Backoff{Initial: time.Millisecond}, 2, func(context.Context) error {
+		calls++
+		return errors.New("unavailable")
+	})
+	if err == nil || calls != 2 {
+		t.Fatalf("expected the error after 2 calls, got %v after %d calls", err, calls)
+	}
+}
+
+func TestDo_StopsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Do(ctx, Backoff{Initial: time.Hour}, 3, func(context.Context) error {
+		cancel()
+		return errors.New("unavailable")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDo_StopsOnPermanentError(t *testing.T) {
+	invalid := errors.New("invalid request")
+	calls := 0
+	err := Do(context.Background(), Backoff{Initial: time.Millisecond}, 5, func(context.Context) error {
+		calls++
+		return Permanent(invalid)
+	})
+	if err != invalid || calls != 1 {
+		t.Fatalf("expected the unwrapped error after 1 call, got %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,52 @@
+// Package scaleout audits whether the components an instance was configured
+// with keep working when several replicas of the API serve traffic: state
+// kept in process memory or on local disk is only seen by the replica that
+// wrote it.
+package scaleout
+
+import "fmt"
+
+// Component describes where one component keeps its state.
+type Component struct {
+	Name string `json:"name"`
+	// Backend names the store, such as "redis", "database" or "memory".
+	Backend string `json:"backend"`
+	// Shared is true when every replica sees the same state.
+	Shared bool `json:"shared"`
+	// Impact says what goes wrong with several replicas when the state is
+	// not shared.
+	Impact string `json:"impact,omitempty"`
+}
+
+type Report struct {
+	// Replicas is the number of instances the deployment expects to run.
+	Replicas int `json:"replicas"`
+	// Ready is false when more than one replica is expected and a component
+	// is not shared.
+	Ready      bool        `json:"ready"`
+	Components []Component `json:"components"`
+	Warnings   []string    `json:"warnings"`
+}
+
+// Audit reports the components whose state is not shared. They only warn
+// when replicas is above one; a single instance is always ready.
+func Audit(replicas int, components []Component) Report {
+	if replicas < 1 {
+		replicas = 1
+	}
+	report := Report{Replicas: replicas, Ready: true, Components: components, Warnings: []string{}}
+	if report.Components == nil {
+		report.Components = []Component{}
+	}
+	if replicas == 1 {
+		return report
+	}
+	for _, component := range components {
+		if component.Shared {
+			continue
+		}
+		report.Ready = false
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s uses %s, which is not shared between replicas: %s", component.Name, component.Backend, component.Impact))
+	}
+	return report
+}
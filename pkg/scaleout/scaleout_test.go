@@ -0,0 +1,36 @@
+package scaleout
+
+import (
+	"strings"
+	"testing"
+)
+
+var testComponents = []Component{
+	{Name: "rate_limiter", Backend: "memory", Impact: "each replica counts requests separately"},
+	{Name: "idempotency", Backend: "database", Shared: true},
+}
+
+func TestAudit_SingleReplicaIsReady(t *testing.T) {
+	report := Audit(1, testComponents)
+	if !report.Ready || len(report.Warnings) != 0 {
+		t.Fatalf("expected a single replica to be ready without warnings, got %+v", report)
+	}
+
+	if report := Audit(0, nil); report.Replicas != 1 || report.Components == nil {
+		t.Fatalf("expected defaults for zero replicas, got %+v", report)
+	}
+}
+
+func TestAudit_WarnsAboutUnsharedComponents(t *testing.T) {
+	report := Audit(3, testComponents)
+	if report.Ready {
+		t.Fatal("expected an in-memory component to make the deployment not ready")
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "rate_limiter uses memory") {
+		t.Fatalf("expected one warning for the rate limiter, got %v", report.Warnings)
+	}
+
+	if report := Audit(3, testComponents[1:]); !report.Ready {
+		t.Fatalf("expected shared components to be ready, got %+v", report)
+	}
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// StatusError is a response from the engine outside 2xx.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("search: engine returned %d: %s", e.StatusCode, e.Body)
+}
+
+// client sends JSON requests to an engine's HTTP API.
+type client struct {
+	name   string
+	base   *url.URL
+	http   *http.Client
+	header http.Header
+}
+
+func newClient(name, rawURL string, timeout time.Duration, header http.Header) (*client, error) {
+	base, err := url.Parse(strings.TrimRight(rawURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("search: invalid %s URL %q", name, rawURL)
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &client{name: name, base: base, http: &http.Client{Timeout: timeout}, header: header}, nil
+}
+
+// do sends body, JSON-encoded unless it is already a []byte, to path and
+// decodes the response into out when out is not nil.
+func (c *client) do(ctx context.Context, method, path, contentType string, body any, out any) error {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			return fmt.Errorf("search: encode request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.base.String()+path, reader)
+	if err != nil {
+		return err
+	}
+	for name, values := range c.header {
+		req.Header[name] = values
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("search: %s request: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(detail))}
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("search: decode %s response: %w", c.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenSearchIndexer(t *testing.T) {
+	var bulkLines []map[string]any
+	var searchBody map[string]any
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/_bulk":
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				var line map[string]any
+				if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+					t.Errorf("bulk line is not JSON: %s", scanner.Bytes())
+				}
+				bulkLines = append(bulkLines, line)
+			}
+			io.WriteString(w, `{"errors":true,"items":[{"delete":{"_id":"gone","status":404}}]}`)
+		case "/docs/_search":
+			json.NewDecoder(r.Body).Decode(&searchBody)
+			io.WriteString(w, `{"hits":{"total":{"value":7},"hits":[{"_score":3.5,"_source":{"id":"t1","type":"transaction","tenants":["acme"],"title":"TRANSFER","text":"Invoice"}}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	idx, err := NewOpenSearchIndexer(OpenSearchConfig{URL: server.URL, Index: "docs", Username: "svc", Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewOpenSearchIndexer: %v", err)
+	}
+	ctx := context.Background()
+	if err := idx.Index(ctx, testDocs[0]); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if len(bulkLines) != 2 || bulkLines[0]["index"].(map[string]any)["_id"] != "t1" || bulkLines[1]["text"] != testDocs[0].Text {
+		t.Fatalf("unexpected bulk body %v", bulkLines)
+	}
+	if !strings.HasPrefix(authorization, "Basic ") {
+		t.Fatalf("expected basic auth, got %q", authorization)
+	}
+	if err := idx.Delete(ctx, "gone"); err != nil {
+		t.Fatalf("deleting a missing document should succeed, got %v", err)
+	}
+
+	result, err := idx.Search(ctx, Query{Text: "invoice", Tenant: "acme", Types: []string{"transaction"}, Limit: 5})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if result.Total != 7 || len(result.Hits) != 1 || result.Hits[0].ID != "t1" || result.Hits[0].Score != 3.5 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	encoded, _ := json.Marshal(searchBody)
+	for _, want := range []string{`{"term":{"tenants":"acme"}}`, `{"terms":{"type":["transaction"]}}`, `"fuzziness":"AUTO"`, `"size":5`} {
+		if !strings.Contains(string(encoded), want) {
+			t.Fatalf("search body %s lacks %s", encoded, want)
+		}
+	}
+
+	if _, err := NewOpenSearchIndexer(OpenSearchConfig{URL: server.URL, Index: "_bad"}); err == nil {
+		t.Fatal("expected an invalid index name to be refused")
+	}
+}
+
+func TestOpenSearchIndexer_ReportsFailedItems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"errors":true,"items":[{"index":{"_id":"t1","status":400,"error":{"type":"mapper_parsing_exception"}}}]}`)
+	}))
+	defer server.Close()
+
+	idx, _ := NewOpenSearchIndexer(OpenSearchConfig{URL: server.URL, Index: "docs"})
+	if err := idx.Index(context.Background(), testDocs[0]); err == nil || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Fatalf("expected the item error, got %v", err)
+	}
+}
+
+func TestMeilisearchIndexer(t *testing.T) {
+	var indexed []Document
+	var searchBody map[string]any
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/indexes/docs/documents":
+			if r.URL.Query().Get("primaryKey") != "id" {
+				t.Errorf("expected primaryKey=id, got %s", r.URL.RawQuery)
+			}
+			json.NewDecoder(r.Body).Decode(&indexed)
+			w.WriteHeader(http.StatusAccepted)
+			io.WriteString(w, `{"taskUid":1}`)
+		case "/indexes/docs/search":
+			json.NewDecoder(r.Body).Decode(&searchBody)
+			io.WriteString(w, `{"hits":[{"id":"t1","type":"transaction","title":"TRANSFER","text":"Invoice","_rankingScore":0.9}],"estimatedTotalHits":3}`)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"code":"invalid_api_key"}`)
+		}
+	}))
+	defer server.Close()
+
+	idx, err := NewMeilisearchIndexer(MeilisearchConfig{URL: server.URL, Index: "docs", APIKey: "key"})
+	if err != nil {
+		t.Fatalf("NewMeilisearchIndexer: %v", err)
+	}
+	ctx := context.Background()
+	if err := idx.Index(ctx, testDocs[:2]...); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if len(indexed) != 2 || indexed[1].ID != "t2" || authorization != "Bearer key" {
+		t.Fatalf("unexpected documents %+v with %q", indexed, authorization)
+	}
+
+	result, err := idx.Search(ctx, Query{Text: "invoice", Tenant: `ac"me`, Types: []string{"transaction", "note"}, Limit: 5, Offset: 5})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if result.Total != 3 || len(result.Hits) != 1 || result.Hits[0].ID != "t1" || result.Hits[0].Score != 0.9 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	encoded, _ := json.Marshal(searchBody["filter"])
+	if want := `["tenants = \"ac\\\"me\"",["type = \"transaction\"","type = \"note\""]]`; string(encoded) != want {
+		t.Fatalf("expected filter %s, got %s", want, encoded)
+	}
+
+	var status *StatusError
+	if err := idx.Delete(ctx, "t1"); err == nil || !errors.As(err, &status) || status.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected a 401 StatusError, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Meilisearch index UIDs are alphanumeric with - and _.
+var meilisearchIndexPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type MeilisearchConfig struct {
+	// URL is the instance endpoint, e.g. http://meilisearch:7700.
+	URL     string
+	Index   string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// MeilisearchIndexer keeps documents in one Meilisearch index, whose typo
+// tolerance provides the fuzzy matching. Writes are queued by Meilisearch
+// and become searchable shortly after they return.
+type MeilisearchIndexer struct {
+	client *client
+	path   string
+}
+
+func NewMeilisearchIndexer(cfg MeilisearchConfig) (*MeilisearchIndexer, error) {
+	if !meilisearchIndexPattern.MatchString(cfg.Index) {
+		return nil, fmt.Errorf("search: invalid Meilisearch index %q", cfg.Index)
+	}
+	header := http.Header{}
+	if cfg.APIKey != "" {
+		header.Set("Authorization", "Bearer "+cfg.APIKey)
+	}
+	c, err := newClient("Meilisearch", cfg.URL, cfg.Timeout, header)
+	if err != nil {
+		return nil, err
+	}
+	return &MeilisearchIndexer{client: c, path: "/indexes/" + url.PathEscape(cfg.Index)}, nil
+}
+
+// EnsureIndex makes tenants and types filterable and searches titles before
+// text. Meilisearch creates the index if it does not exist.
+func (s *MeilisearchIndexer) EnsureIndex(ctx context.Context) error {
+	settings := map[string]any{
+		"filterableAttributes": []string{"tenants", "type"},
+		"searchableAttributes": []string{"title", "text"},
+		"sortableAttributes":   []string{"created_at"},
+	}
+	return s.client.do(ctx, http.MethodPatch, s.path+"/settings", "application/json", settings, nil)
+}
+
+func (s *MeilisearchIndexer) Index(ctx context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return s.client.do(ctx, http.MethodPost, s.path+"/documents?primaryKey=id", "application/json", docs, nil)
+}
+
+func (s *MeilisearchIndexer) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.client.do(ctx, http.MethodPost, s.path+"/documents/delete-batch", "application/json", ids, nil)
+}
+
+func (s *MeilisearchIndexer) Search(ctx context.Context, q Query) (*Result, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	filter := []any{"tenants = " + meilisearchString(q.Tenant)}
+	if len(q.Types) > 0 {
+		types := make([]string, len(q.Types))
+		for i, t := range q.Types {
+			types[i] = "type = " + meilisearchString(t)
+		}
+		// Inner arrays are ORed.
+		filter = append(filter, types)
+	}
+	request := map[string]any{
+		"q":                q.Text,
+		"filter":           filter,
+		"limit":            q.Limit,
+		"offset":           q.Offset,
+		"showRankingScore": true,
+	}
+
+	var resp struct {
+		Hits []struct {
+			Document
+			RankingScore float64 `json:"_rankingScore"`
+		} `json:"hits"`
+		EstimatedTotalHits int64 `json:"estimatedTotalHits"`
+	}
+	if err := s.client.do(ctx, http.MethodPost, s.path+"/search", "application/json", request, &resp); err != nil {
+		return nil, err
+	}
+	result := &Result{Hits: make([]Hit, 0, len(resp.Hits)), Total: resp.EstimatedTotalHits}
+	for _, h := range resp.Hits {
+		result.Hits = append(result.Hits, h.Document.hit(h.RankingScore))
+	}
+	return result, nil
+}
+
+// meilisearchString quotes s for a filter expression.
+func meilisearchString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// MemoryIndexer keeps documents in process and matches words allowing one
+// typo in words of five letters or more. It suits development and tests; its
+// index is lost on restart and not shared between instances.
+type MemoryIndexer struct {
+	mu   sync.RWMutex
+	docs map[string]Document
+}
+
+func NewMemoryIndexer() *MemoryIndexer {
+	return &MemoryIndexer{docs: make(map[string]Document)}
+}
+
+func (m *MemoryIndexer) Index(_ context.Context, docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, doc := range docs {
+		m.docs[doc.ID] = doc
+	}
+	return nil
+}
+
+func (m *MemoryIndexer) Delete(_ context.Context, ids ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		delete(m.docs, id)
+	}
+	return nil
+}
+
+// Search returns the tenant's documents containing every word of the query,
+// the title counting twice, best first.
+func (m *MemoryIndexer) Search(_ context.Context, q Query) (*Result, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	terms := words(q.Text)
+
+	m.mu.RLock()
+	var hits []Hit
+	for _, doc := range m.docs {
+		if !slices.Contains(doc.Tenants, q.Tenant) || (len(q.Types) > 0 && !slices.Contains(q.Types, doc.Type)) {
+			continue
+		}
+		if score := match(terms, words(doc.Title), words(doc.Text)); score > 0 {
+			hits = append(hits, doc.hit(score))
+		}
+	}
+	m.mu.RUnlock()
+
+	slices.SortFunc(hits, func(a, b Hit) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	result := &Result{Hits: []Hit{}, Total: int64(len(hits))}
+	if q.Offset < len(hits) {
+		hits = hits[q.Offset:]
+		if q.Limit > 0 && q.Limit < len(hits) {
+			hits = hits[:q.Limit]
+		}
+		result.Hits = hits
+	}
+	return result, nil
+}
+
+// match scores a document by its words matching terms, or 0 when a term
+// matches none of them.
+func match(terms, title, text []string) float64 {
+	var score float64
+	for _, term := range terms {
+		switch {
+		case slices.ContainsFunc(title, func(w string) bool { return similar(term, w) }):
+			score += 2
+		case slices.ContainsFunc(text, func(w string) bool { return similar(term, w) }):
+			score++
+		default:
+			return 0
+		}
+	}
+	return score
+}
+
+// similar reports whether word starts with term or, for terms of five
+// letters or more, is one edit away from it.
+func similar(term, word string) bool {
+	if strings.HasPrefix(word, term) {
+		return true
+	}
+	return len(term) >= 5 && editDistance(term, word, 1) <= 1
+}
+
+// editDistance returns the edit distance between a and b, counting a swap
+// of adjacent letters as one edit as the engines do, or limit+1 once it is
+// known to exceed limit.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+	before := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		best := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], before[j-2]+1)
+			}
+			best = min(best, cur[j])
+		}
+		if best > limit {
+			return limit + 1
+		}
+		before, prev, cur = prev, cur, before
+	}
+	return prev[len(rb)]
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
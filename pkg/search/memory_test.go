@@ -0,0 +1,94 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testDocs = []Document{
+	{ID: "t1", Type: "transaction", Tenants: []string{"acme"}, Title: "TRANSFER", Text: "Invoice 2291 for consulting", CreatedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+	{ID: "t2", Type: "transaction", Tenants: []string{"acme", "globex"}, Title: "DEPOSIT", Text: "Quarterly consulting retainer", CreatedAt: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+	{ID: "t3", Type: "transaction", Tenants: []string{"globex"}, Title: "TRANSFER", Text: "Consulting invoice 77", CreatedAt: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)},
+	{ID: "n1", Type: "note", Tenants: []string{"acme"}, Title: "Consulting", Text: "Call notes", CreatedAt: time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)},
+}
+
+func ids(result *Result) []string {
+	var out []string
+	for _, h := range result.Hits {
+		out = append(out, h.ID)
+	}
+	return out
+}
+
+func TestMemoryIndexer_ScopesToTenant(t *testing.T) {
+	idx := NewMemoryIndexer()
+	ctx := context.Background()
+	if err := idx.Index(ctx, testDocs...); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+
+	result, err := idx.Search(ctx, Query{Text: "consulting", Tenant: "acme", Limit: 10})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	// The title match ranks first, then the newest.
+	if got := ids(result); len(got) != 3 || got[0] != "n1" || got[1] != "t2" || got[2] != "t1" || result.Total != 3 {
+		t.Fatalf("expected n1, t2, t1, got %v (total %d)", got, result.Total)
+	}
+
+	result, _ = idx.Search(ctx, Query{Text: "consulting", Tenant: "acme", Types: []string{"transaction"}, Limit: 1, Offset: 1})
+	if got := ids(result); len(got) != 1 || got[0] != "t1" || result.Total != 2 {
+		t.Fatalf("expected the second transaction t1 of 2, got %v (total %d)", got, result.Total)
+	}
+
+	result, _ = idx.Search(ctx, Query{Text: "invoice", Tenant: "initech", Limit: 10})
+	if len(result.Hits) != 0 {
+		t.Fatalf("expected nothing for another tenant, got %v", ids(result))
+	}
+}
+
+func TestMemoryIndexer_ToleratesTyposAndPrefixes(t *testing.T) {
+	idx := NewMemoryIndexer()
+	ctx := context.Background()
+	_ = idx.Index(ctx, testDocs...)
+
+	for text, want := range map[string]string{
+		"invoce 2291":  "t1",
+		"invioce 2291": "t1",
+		"quart":        "t2",
+		"retainr":      "t2",
+	} {
+		result, err := idx.Search(ctx, Query{Text: text, Tenant: "acme", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search %q: %v", text, err)
+		}
+		if got := ids(result); len(got) != 1 || got[0] != want {
+			t.Fatalf("%q: expected %s, got %v", text, want, got)
+		}
+	}
+
+	if result, _ := idx.Search(ctx, Query{Text: "imvoise 2291", Tenant: "acme", Limit: 10}); len(result.Hits) != 0 {
+		t.Fatalf("expected no match two edits away, got %v", ids(result))
+	}
+}
+
+func TestMemoryIndexer_ReplacesAndDeletes(t *testing.T) {
+	idx := NewMemoryIndexer()
+	ctx := context.Background()
+	_ = idx.Index(ctx, testDocs...)
+
+	updated := testDocs[0]
+	updated.Text = "Refund"
+	_ = idx.Index(ctx, updated)
+	_ = idx.Delete(ctx, "t2")
+
+	result, _ := idx.Search(ctx, Query{Text: "consulting", Tenant: "acme", Types: []string{"transaction"}, Limit: 10})
+	if len(result.Hits) != 0 {
+		t.Fatalf("expected the replaced and deleted documents gone, got %v", ids(result))
+	}
+	if _, err := idx.Search(ctx, Query{Text: "refund"}); !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("expected ErrInvalidQuery without a tenant, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// OpenSearch index names are lowercase and cannot start with _, - or +.
+var openSearchIndexPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+type OpenSearchConfig struct {
+	// URL is the cluster endpoint, e.g. https://opensearch:9200.
+	URL      string
+	Index    string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// OpenSearchIndexer keeps documents in one OpenSearch (or Elasticsearch)
+// index and matches queries with fuzziness AUTO.
+type OpenSearchIndexer struct {
+	client *client
+	index  string
+}
+
+func NewOpenSearchIndexer(cfg OpenSearchConfig) (*OpenSearchIndexer, error) {
+	if !openSearchIndexPattern.MatchString(cfg.Index) {
+		return nil, fmt.Errorf("search: invalid OpenSearch index %q", cfg.Index)
+	}
+	header := http.Header{}
+	if cfg.Username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(cfg.Username + ":" + cfg.Password))
+		header.Set("Authorization", "Basic "+credentials)
+	}
+	c, err := newClient("OpenSearch", cfg.URL, cfg.Timeout, header)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenSearchIndexer{client: c, index: cfg.Index}, nil
+}
+
+// EnsureIndex creates the index with its mapping unless it exists. Tenants
+// and types are keywords so filters match them exactly.
+func (s *OpenSearchIndexer) EnsureIndex(ctx context.Context) error {
+	mapping := map[string]any{
+		"mappings": map[string]any{
+			"properties": map[string]any{
+				"type":       map[string]any{"type": "keyword"},
+				"tenants":    map[string]any{"type": "keyword"},
+				"title":      map[string]any{"type": "text"},
+				"text":       map[string]any{"type": "text"},
+				"fields":     map[string]any{"type": "object", "enabled": false},
+				"created_at": map[string]any{"type": "date"},
+			},
+		},
+	}
+	err := s.client.do(ctx, http.MethodPut, "/"+s.index, "application/json", mapping, nil)
+	var status *StatusError
+	if errors.As(err, &status) && status.StatusCode == http.StatusBadRequest && strings.Contains(status.Body, "resource_already_exists_exception") {
+		return nil
+	}
+	return err
+}
+
+func (s *OpenSearchIndexer) Index(ctx context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]any{"index": map[string]string{"_index": s.index, "_id": doc.ID}}
+		if err := enc.Encode(action); err != nil {
+			return fmt.Errorf("search: encode document %s: %w", doc.ID, err)
+		}
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("search: encode document %s: %w", doc.ID, err)
+		}
+	}
+	return s.bulk(ctx, body.Bytes())
+}
+
+func (s *OpenSearchIndexer) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, id := range ids {
+		if err := enc.Encode(map[string]any{"delete": map[string]string{"_index": s.index, "_id": id}}); err != nil {
+			return fmt.Errorf("search: encode delete %s: %w", id, err)
+		}
+	}
+	return s.bulk(ctx, body.Bytes())
+}
+
+// bulk sends a _bulk request. OpenSearch answers 200 even when items fail,
+// so the first failed item is returned as the error; deleting a missing
+// document is not a failure.
+func (s *OpenSearchIndexer) bulk(ctx context.Context, body []byte) error {
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string          `json:"_id"`
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := s.client.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body, &resp); err != nil {
+		return err
+	}
+	if !resp.Errors {
+		return nil
+	}
+	for _, item := range resp.Items {
+		for action, result := range item {
+			if result.Status >= 300 && !(action == "delete" && result.Status == http.StatusNotFound) {
+				return fmt.Errorf("search: %s %s failed with %d: %s", action, result.ID, result.Status, result.Error)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *OpenSearchIndexer) Search(ctx context.Context, q Query) (*Result, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	filter := []any{map[string]any{"term": map[string]any{"tenants": q.Tenant}}}
+	if len(q.Types) > 0 {
+		filter = append(filter, map[string]any{"terms": map[string]any{"type": q.Types}})
+	}
+	request := map[string]any{
+		"from":             q.Offset,
+		"size":             q.Limit,
+		"track_total_hits": true,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"must": map[string]any{
+					"multi_match": map[string]any{
+						"query":     q.Text,
+						"fields":    []string{"title^2", "text"},
+						"fuzziness": "AUTO",
+						"operator":  "and",
+					},
+				},
+				"filter": filter,
+			},
+		},
+	}
+
+	var resp struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Score  float64  `json:"_score"`
+				Source Document `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := s.client.do(ctx, http.MethodPost, "/"+url.PathEscape(s.index)+"/_search", "application/json", request, &resp); err != nil {
+		return nil, err
+	}
+	result := &Result{Hits: make([]Hit, 0, len(resp.Hits.Hits)), Total: resp.Hits.Total.Value}
+	for _, h := range resp.Hits.Hits {
+		result.Hits = append(result.Hits, h.Source.hit(h.Score))
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+// Package search indexes documents in a full-text search engine and queries
+// them with typo tolerance, for the searches SQL LIKE does poorly. Every
+// document belongs to one or more tenants and every query is scoped to one,
+// so callers only ever see their own documents.
+package search
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidQuery is returned for a query without text or tenant.
+var ErrInvalidQuery = errors.New("search: query needs text and a tenant")
+
+// Document is one searchable record. Title is weighted above Text; Fields
+// are stored and returned with hits but not searched.
+type Document struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Tenants   []string       `json:"tenants"`
+	Title     string         `json:"title"`
+	Text      string         `json:"text"`
+	Fields    map[string]any `json:"fields,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Query finds the documents of Tenant matching Text, optionally only those
+// of the given Types.
+type Query struct {
+	Text   string
+	Tenant string
+	Types  []string
+	Limit  int
+	Offset int
+}
+
+type Hit struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Text      string         `json:"text"`
+	Fields    map[string]any `json:"fields,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	Score     float64        `json:"score"`
+}
+
+type Result struct {
+	Hits []Hit `json:"hits"`
+	// Total is the number of matches, which the engines may estimate.
+	Total int64 `json:"total"`
+}
+
+// Indexer writes documents to a search engine and queries them. Index
+// replaces documents with the same ID.
+type Indexer interface {
+	Index(ctx context.Context, docs ...Document) error
+	Delete(ctx context.Context, ids ...string) error
+	Search(ctx context.Context, q Query) (*Result, error)
+}
+
+func (q Query) validate() error {
+	if q.Text == "" || q.Tenant == "" {
+		return ErrInvalidQuery
+	}
+	return nil
+}
+
+func (d Document) hit(score float64) Hit {
+	return Hit{ID: d.ID, Type: d.Type, Title: d.Title, Text: d.Text, Fields: d.Fields, CreatedAt: d.CreatedAt, Score: score}
+}
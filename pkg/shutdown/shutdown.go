@@ -0,0 +1,159 @@
+// Package shutdown runs teardown hooks in priority order, each bounded by its
+// own timeout, so one component that hangs while closing (a Redis client, a
+// stuck subscriber) cannot stall the rest of the shutdown or pod termination.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned for a hook that did not finish within its timeout.
+var ErrTimeout = errors.New("shutdown hook timed out")
+
+// Hook releases one component. It should return once ctx is done; hooks that
+// do not are abandoned when their timeout expires.
+type Hook func(ctx context.Context) error
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// DefaultTimeout applies to hooks registered without a timeout.
+const DefaultTimeout = 10 * time.Second
+
+type hook struct {
+	name     string
+	priority int
+	timeout  time.Duration
+	fn       Hook
+	seq      int
+}
+
+// Manager collects hooks and runs them once.
+type Manager struct {
+	logger Logger
+
+	mu    sync.Mutex
+	hooks []hook
+	ran   bool
+}
+
+func NewManager(logger Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Register adds a hook. Hooks run in ascending priority; hooks with the same
+// priority run concurrently.
+func (m *Manager) Register(name string, priority int, timeout time.Duration, fn Hook) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, priority: priority, timeout: timeout, fn: fn, seq: len(m.hooks)})
+}
+
+// Run executes the hooks. A hook that panics, fails or outlives its timeout
+// is logged by name and the teardown moves on. When ctx ends first, the
+// remaining hooks are skipped. Run only runs the hooks the first time it is
+// called; it returns the hooks' errors joined.
+func (m *Manager) Run(ctx context.Context) error {
+	m.mu.Lock()
+	if m.ran {
+		m.mu.Unlock()
+		return nil
+	}
+	m.ran = true
+	hooks := append([]hook(nil), m.hooks...)
+	m.mu.Unlock()
+
+	sort.Slice(hooks, func(i, j int) bool {
+		if hooks[i].priority != hooks[j].priority {
+			return hooks[i].priority < hooks[j].priority
+		}
+		return hooks[i].seq < hooks[j].seq
+	})
+
+	var errs []error
+	for start := 0; start < len(hooks); {
+		end := start
+		for end < len(hooks) && hooks[end].priority == hooks[start].priority {
+			end++
+		}
+		group := hooks[start:end]
+		start = end
+
+		if err := ctx.Err(); err != nil {
+			for _, h := range group {
+				m.logger.Error("Shutdown hook skipped", "component", h.name, "error", err)
+				errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			}
+			continue
+		}
+
+		results := make([]error, len(group))
+		var wg sync.WaitGroup
+		for i, h := range group {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = m.run(ctx, h)
+			}()
+		}
+		wg.Wait()
+		for _, err := range results {
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) run(parent context.Context, h hook) error {
+	ctx, cancel := context.WithTimeout(parent, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- h.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		select {
+		case err = <-done: // Finished just as the context ended.
+		default:
+			// The hook ignored its context; leave it running and move on.
+			err = ErrTimeout
+			if parent.Err() != nil {
+				err = parent.Err()
+			}
+			m.logger.Error("Shutdown hook blocked teardown; continuing without it",
+				"component", h.name, "timeout", h.timeout, "error", err)
+			return fmt.Errorf("%s: %w", h.name, err)
+		}
+	}
+
+	if err != nil {
+		m.logger.Error("Shutdown hook failed", "component", h.name, "error", err, "duration", time.Since(start))
+		return fmt.Errorf("%s: %w", h.name, err)
+	}
+	m.logger.Info("Shutdown hook completed", "component", h.name, "duration", time.Since(start))
+	return nil
+}
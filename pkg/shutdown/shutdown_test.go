@@ -0,0 +1,148 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (l *recordingLogger) Info(string, ...any) {}
+func (l *recordingLogger) Warn(string, ...any) {}
+func (l *recordingLogger) Error(msg string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == "component" {
+			msg += " " + args[i+1].(string)
+		}
+	}
+	l.errors = append(l.errors, msg)
+}
+
+func TestRun_OrdersByPriority(t *testing.T) {
+	m := NewManager(&recordingLogger{})
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) Hook {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+	m.Register("database", 50, 0, record("database"))
+	m.Register("probes", 10, 0, record("probes"))
+	m.Register("events", 30, 0, record("events"))
+
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := strings.Join(order, ","); got != "probes,events,database" {
+		t.Fatalf("unexpected order %s", got)
+	}
+
+	if err := m.Run(context.Background()); err != nil || len(order) != 3 {
+		t.Fatalf("expected a second Run to do nothing, got %v", order)
+	}
+}
+
+func TestRun_SamePriorityRunsConcurrently(t *testing.T) {
+	m := NewManager(&recordingLogger{})
+	both := make(chan struct{})
+	var once sync.Once
+	var wg sync.WaitGroup
+	wg.Add(2)
+	wait := func(context.Context) error {
+		wg.Done()
+		wg.Wait()
+		once.Do(func() { close(both) })
+		return nil
+	}
+	m.Register("a", 10, time.Second, wait)
+	m.Register("b", 10, time.Second, wait)
+
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatalf("expected both hooks to run together, got %v", err)
+	}
+	<-both
+}
+
+func TestRun_AbandonsBlockedHook(t *testing.T) {
+	logger := &recordingLogger{}
+	m := NewManager(logger)
+	release := make(chan struct{})
+	defer close(release)
+
+	closed := false
+	m.Register("redis", 10, 20*time.Millisecond, func(context.Context) error {
+		<-release // Ignores its context, like a hung client Close.
+		return nil
+	})
+	m.Register("database", 20, time.Second, func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	start := time.Now()
+	err := m.Run(context.Background())
+	if !errors.Is(err, ErrTimeout) || !strings.Contains(err.Error(), "redis") {
+		t.Fatalf("expected a timeout naming redis, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("expected the blocked hook to be abandoned at its timeout")
+	}
+	if !closed {
+		t.Fatal("expected later hooks to run after a blocked one")
+	}
+	if len(logger.errors) != 1 || !strings.HasSuffix(logger.errors[0], "redis") {
+		t.Fatalf("expected the blocked component to be logged, got %v", logger.errors)
+	}
+}
+
+func TestRun_RecoversPanics(t *testing.T) {
+	m := NewManager(&recordingLogger{})
+	m.Register("broken", 10, time.Second, func(context.Context) error {
+		panic("boom")
+	})
+	ran := false
+	m.Register("later", 20, time.Second, func(context.Context) error {
+		ran = true
+		return nil
+	})
+
+	err := m.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "broken: panic: boom") {
+		t.Fatalf("expected the panic to be reported, got %v", err)
+	}
+	if !ran {
+		t.Fatal("expected hooks after a panic to run")
+	}
+}
+
+func TestRun_SkipsHooksAfterDeadline(t *testing.T) {
+	m := NewManager(&recordingLogger{})
+	ctx, cancel := context.WithCancel(context.Background())
+	m.Register("first", 10, time.Second, func(context.Context) error {
+		cancel()
+		return nil
+	})
+	ran := false
+	m.Register("later", 20, time.Second, func(context.Context) error {
+		ran = true
+		return nil
+	})
+
+	err := m.Run(ctx)
+	if ran || !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "later") {
+		t.Fatalf("expected hooks after the deadline to be skipped, got %v", err)
+	}
+}
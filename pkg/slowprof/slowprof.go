@@ -0,0 +1,206 @@
+// Package slowprof captures an execution trace and a goroutine dump while a
+// slow request is still running, so rare tail-latency events can be
+// diagnosed after they happened.
+package slowprof
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"runtime/trace"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	filePrefix       = "slow-"
+	traceSuffix      = ".trace"
+	goroutinesSuffix = ".goroutines.txt"
+)
+
+type Config struct {
+	// Dir holds the captures; it is created if missing.
+	Dir string
+	// TraceDuration is how long the execution trace runs.
+	TraceDuration time.Duration
+	// MinInterval is the least time between captures, so a burst of slow
+	// requests costs one capture.
+	MinInterval time.Duration
+	// Keep is how many captures Dir keeps; older ones are deleted.
+	Keep int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		TraceDuration: time.Second,
+		MinInterval:   time.Minute,
+		Keep:          20,
+	}
+}
+
+// Capture names the files of one capture. Trace is empty when the execution
+// trace could not start, which happens while another trace is running.
+type Capture struct {
+	Trace      string
+	Goroutines string
+}
+
+// Profiler takes captures. The execution tracer is process-wide, so there is
+// one capture at a time.
+type Profiler struct {
+	cfg  Config
+	now  func() time.Time
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	mu     sync.Mutex
+	last   time.Time
+	closed bool
+}
+
+func New(cfg Config) (*Profiler, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("slowprof: no directory")
+	}
+	defaults := DefaultConfig()
+	if cfg.TraceDuration <= 0 {
+		cfg.TraceDuration = defaults.TraceDuration
+	}
+	if cfg.MinInterval <= 0 {
+		cfg.MinInterval = defaults.MinInterval
+	}
+	if cfg.Keep <= 0 {
+		cfg.Keep = defaults.Keep
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("slowprof: %w", err)
+	}
+	return &Profiler{cfg: cfg, now: time.Now, stop: make(chan struct{})}, nil
+}
+
+// Capture writes a goroutine dump, which shows each goroutine's pprof
+// labels, and starts an execution trace that runs for TraceDuration in the
+// background. name, such as a correlation ID, goes into the file names. It
+// returns nil when a capture was taken less than MinInterval ago or the
+// profiler is closed.
+func (p *Profiler) Capture(name string) (*Capture, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if p.closed || (!p.last.IsZero() && now.Sub(p.last) < p.cfg.MinInterval) {
+		return nil, nil
+	}
+	p.last = now
+
+	base := filepath.Join(p.cfg.Dir, filePrefix+now.UTC().Format("20060102T150405.000Z")+"-"+sanitize(name))
+	capture := &Capture{Goroutines: base + goroutinesSuffix}
+	if err := writeGoroutines(capture.Goroutines); err != nil {
+		return nil, fmt.Errorf("slowprof: goroutine dump: %w", err)
+	}
+
+	f, err := os.Create(base + traceSuffix)
+	if err != nil {
+		return capture, fmt.Errorf("slowprof: trace: %w", err)
+	}
+	if err := trace.Start(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		p.prune()
+		return capture, nil
+	}
+	capture.Trace = f.Name()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		timer := time.NewTimer(p.cfg.TraceDuration)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-p.stop:
+		}
+		trace.Stop()
+		_ = f.Close()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.prune()
+	}()
+	return capture, nil
+}
+
+// Close stops a running trace early and waits for it to be written.
+func (p *Profiler) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.stop)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+func writeGoroutines(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.Lookup("goroutine").WriteTo(f, 1); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// prune deletes all but the newest Keep captures. File names start with the
+// capture time, so they sort oldest first. The caller holds p.mu.
+func (p *Profiler) prune() {
+	entries, err := os.ReadDir(p.cfg.Dir)
+	if err != nil {
+		return
+	}
+	files := make(map[string][]string)
+	var captures []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) {
+			continue
+		}
+		base := strings.TrimSuffix(strings.TrimSuffix(name, goroutinesSuffix), traceSuffix)
+		if _, ok := files[base]; !ok {
+			captures = append(captures, base)
+		}
+		files[base] = append(files[base], name)
+	}
+	slices.Sort(captures)
+	for _, base := range captures[:max(len(captures)-p.cfg.Keep, 0)] {
+		for _, name := range files[base] {
+			_ = os.Remove(filepath.Join(p.cfg.Dir, name))
+		}
+	}
+}
+
+// sanitize keeps name safe for a file name: letters, digits, '-' and '_',
+// at most 64 of them.
+func sanitize(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if b.Len() == 64 {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "request"
+	}
+	return b.String()
+}
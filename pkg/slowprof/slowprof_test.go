@@ -0,0 +1,70 @@
+package slowprof
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfiler_CapturesTraceAndGoroutines(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profiles")
+	p, err := New(Config{Dir: dir, TraceDuration: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+
+	capture, err := p.Capture("req/1")
+	if err != nil || capture == nil {
+		t.Fatalf("expected a capture, got %v, %v", capture, err)
+	}
+	if capture.Trace == "" || !strings.HasSuffix(capture.Trace, "-req_1.trace") {
+		t.Fatalf("unexpected trace file %q", capture.Trace)
+	}
+	dump, err := os.ReadFile(capture.Goroutines)
+	if err != nil || !strings.Contains(string(dump), "goroutine profile") {
+		t.Fatalf("expected a goroutine dump, got %v", err)
+	}
+
+	// A second slow request within MinInterval is not captured.
+	if again, err := p.Capture("req-2"); again != nil || err != nil {
+		t.Fatalf("expected no capture within MinInterval, got %v, %v", again, err)
+	}
+
+	p.Close()
+	if info, err := os.Stat(capture.Trace); err != nil || info.Size() == 0 {
+		t.Fatalf("expected the trace to be written by Close, got %v", err)
+	}
+	if after, _ := p.Capture("req-3"); after != nil {
+		t.Fatal("expected no capture after Close")
+	}
+}
+
+func TestProfiler_KeepsNewestCaptures(t *testing.T) {
+	dir := t.TempDir()
+	p, err := New(Config{Dir: dir, TraceDuration: time.Millisecond, MinInterval: time.Nanosecond, Keep: 2})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { now = now.Add(time.Second); return now }
+
+	for _, name := range []string{"a", "b", "c"} {
+		if _, err := p.Capture(name); err != nil {
+			t.Fatalf("Capture: %v", err)
+		}
+		p.wg.Wait()
+	}
+	p.Close()
+
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 4 || strings.Contains(strings.Join(names, " "), "-a.") {
+		t.Fatalf("expected the two newest captures, got %v", names)
+	}
+}
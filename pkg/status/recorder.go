@@ -0,0 +1,145 @@
+package status
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type Config struct {
+	// FlushInterval is how often counted checks are added to the store.
+	FlushInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{FlushInterval: time.Minute}
+}
+
+// Recorder counts health-check results in memory and adds them to the store
+// every FlushInterval, so checks cost one write per component per flush.
+type Recorder struct {
+	store  Store
+	logger Logger
+	cfg    Config
+
+	mu      sync.Mutex
+	pending map[bucketKey]*Bucket
+	started bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+	now  func() time.Time
+}
+
+func NewRecorder(store Store, logger Logger, cfg Config) *Recorder {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultConfig().FlushInterval
+	}
+	return &Recorder{
+		store:   store,
+		logger:  logger,
+		cfg:     cfg,
+		pending: make(map[bucketKey]*Bucket),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		now:     time.Now,
+	}
+}
+
+// Store returns the store the recorder writes to.
+func (r *Recorder) Store() Store {
+	return r.store
+}
+
+// Observe counts one check of component.
+func (r *Recorder) Observe(component string, healthy bool) {
+	hour := r.now().UTC().Truncate(time.Hour)
+	key := bucketKey{component, hour.Unix()}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.pending[key]
+	if !ok {
+		b = &Bucket{Component: component, Hour: hour}
+		r.pending[key] = b
+	}
+	b.Checks++
+	if !healthy {
+		b.Failures++
+	}
+}
+
+// Start flushes in the background until Stop.
+func (r *Recorder) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return
+	}
+	r.started = true
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.cfg.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.Flush(context.Background())
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends background flushing and writes the remaining counts.
+func (r *Recorder) Stop(ctx context.Context) {
+	r.mu.Lock()
+	started := r.started
+	r.mu.Unlock()
+
+	r.once.Do(func() { close(r.stop) })
+	if started {
+		<-r.done
+	}
+	r.Flush(ctx)
+}
+
+// Flush adds the pending counts to the store. Counts that fail to write are
+// kept for the next flush.
+func (r *Recorder) Flush(ctx context.Context) {
+	r.mu.Lock()
+	if len(r.pending) == 0 {
+		r.mu.Unlock()
+		return
+	}
+	batch := make([]Bucket, 0, len(r.pending))
+	for _, b := range r.pending {
+		batch = append(batch, *b)
+	}
+	r.pending = make(map[bucketKey]*Bucket)
+	r.mu.Unlock()
+
+	if err := r.store.AddChecks(ctx, batch); err != nil {
+		r.logger.Error("Failed to store status checks", "error", err)
+		r.mu.Lock()
+		for _, b := range batch {
+			key := bucketKey{b.Component, b.Hour.Unix()}
+			if p, ok := r.pending[key]; ok {
+				p.Checks += b.Checks
+				p.Failures += b.Failures
+			} else {
+				b := b
+				r.pending[key] = &b
+			}
+		}
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,143 @@
+package status
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+)
+
+// Overall statuses of a Report
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusMajorOutage = "major_outage"
+)
+
+const (
+	reportDays    = 7
+	incidentsDays = 7
+)
+
+// Report summarizes component health over the last day and week, for the
+// status page. Uptimes are percentages and nil for periods without checks.
+type Report struct {
+	Status      string            `json:"status"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Components  []ComponentStatus `json:"components"`
+	Incidents   []Incident        `json:"incidents"`
+}
+
+type ComponentStatus struct {
+	Name string `json:"name"`
+	// Healthy is the result of the component's latest check.
+	Healthy   bool          `json:"healthy"`
+	Uptime24h *float64      `json:"uptime_24h"`
+	Uptime7d  *float64      `json:"uptime_7d"`
+	Daily     []DailyUptime `json:"daily"`
+}
+
+// DailyUptime is the uptime of one UTC day, oldest first.
+type DailyUptime struct {
+	Date   string   `json:"date"`
+	Uptime *float64 `json:"uptime"`
+}
+
+// BuildReport reads the last week of history from store. current holds the
+// latest check result per component; components only known from history
+// count as healthy until checked again.
+func BuildReport(ctx context.Context, store Store, current map[string]bool, now time.Time) (*Report, error) {
+	now = now.UTC()
+	today := now.Truncate(24 * time.Hour)
+	firstDay := today.AddDate(0, 0, -(reportDays - 1))
+
+	buckets, err := store.Buckets(ctx, firstDay)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := store.Incidents(ctx, now.AddDate(0, 0, -incidentsDays))
+	if err != nil {
+		return nil, err
+	}
+
+	type counts struct{ checks, failures int64 }
+	type history struct {
+		day, week counts
+		daily     [reportDays]counts
+	}
+	byComponent := make(map[string]*history)
+	for name := range current {
+		byComponent[name] = &history{}
+	}
+	dayAgo := now.Add(-24 * time.Hour)
+	for _, b := range buckets {
+		h, ok := byComponent[b.Component]
+		if !ok {
+			h = &history{}
+			byComponent[b.Component] = h
+		}
+		h.week.checks += b.Checks
+		h.week.failures += b.Failures
+		// An hour bucket counts for the last 24h once any of it falls inside.
+		if b.Hour.Add(time.Hour).After(dayAgo) {
+			h.day.checks += b.Checks
+			h.day.failures += b.Failures
+		}
+		if i := int(b.Hour.Sub(firstDay) / (24 * time.Hour)); i >= 0 && i < reportDays {
+			h.daily[i].checks += b.Checks
+			h.daily[i].failures += b.Failures
+		}
+	}
+
+	uptime := func(c counts) *float64 {
+		if c.checks == 0 {
+			return nil
+		}
+		v := math.Round((1-float64(c.failures)/float64(c.checks))*100000) / 1000
+		return &v
+	}
+
+	report := &Report{
+		Status:      StatusOperational,
+		GeneratedAt: now,
+		Components:  make([]ComponentStatus, 0, len(byComponent)),
+		Incidents:   incidents,
+	}
+	if report.Incidents == nil {
+		report.Incidents = []Incident{}
+	}
+	for name, h := range byComponent {
+		healthy, checked := current[name]
+		c := ComponentStatus{
+			Name:      name,
+			Healthy:   healthy || !checked,
+			Uptime24h: uptime(h.day),
+			Uptime7d:  uptime(h.week),
+			Daily:     make([]DailyUptime, reportDays),
+		}
+		for i := range reportDays {
+			c.Daily[i] = DailyUptime{Date: firstDay.AddDate(0, 0, i).Format(time.DateOnly), Uptime: uptime(h.daily[i])}
+		}
+		if !c.Healthy {
+			report.Status = StatusDegraded
+		}
+		report.Components = append(report.Components, c)
+	}
+	sort.Slice(report.Components, func(i, j int) bool { return report.Components[i].Name < report.Components[j].Name })
+
+	for _, incident := range incidents {
+		if !incident.Ongoing() {
+			continue
+		}
+		if incident.Impact == models.IncidentImpactMinor {
+			if report.Status == StatusOperational {
+				report.Status = StatusDegraded
+			}
+			continue
+		}
+		report.Status = StatusMajorOutage
+	}
+	return report, nil
+}
@@ -0,0 +1,133 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+type failingStore struct {
+	*MemoryStore
+	fail bool
+}
+
+func (s *failingStore) AddChecks(ctx context.Context, buckets []Bucket) error {
+	if s.fail {
+		return errors.New("database unavailable")
+	}
+	return s.MemoryStore.AddChecks(ctx, buckets)
+}
+
+func TestRecorder_CountsPerHourAndKeepsFailedFlushes(t *testing.T) {
+	store := &failingStore{MemoryStore: NewMemoryStore(), fail: true}
+	r := NewRecorder(store, nopLogger{}, Config{})
+	now := time.Date(2026, 3, 1, 10, 59, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	r.Observe("database", true)
+	r.Observe("database", false)
+	now = now.Add(2 * time.Minute)
+	r.Observe("database", true)
+
+	r.Flush(context.Background())
+	if buckets, _ := store.Buckets(context.Background(), time.Time{}); len(buckets) != 0 {
+		t.Fatalf("expected nothing stored while the store fails, got %+v", buckets)
+	}
+
+	store.fail = false
+	r.Observe("database", true)
+	r.Stop(context.Background())
+
+	buckets, _ := store.Buckets(context.Background(), time.Time{})
+	if len(buckets) != 2 {
+		t.Fatalf("expected one bucket per hour, got %+v", buckets)
+	}
+	if b := buckets[0]; b.Hour.Hour() != 10 || b.Checks != 2 || b.Failures != 1 {
+		t.Fatalf("unexpected 10:00 bucket %+v", b)
+	}
+	if b := buckets[1]; b.Hour.Hour() != 11 || b.Checks != 2 || b.Failures != 0 {
+		t.Fatalf("unexpected 11:00 bucket %+v", b)
+	}
+}
+
+func TestBuildReport_UptimeWindows(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, 3, 8, 12, 30, 0, 0, time.UTC)
+	_ = store.AddChecks(ctx, []Bucket{
+		// Within the last 24 hours: 1 failure in 100 checks.
+		{Component: "database", Hour: now.Truncate(time.Hour), Checks: 100, Failures: 1},
+		// Three days ago: half failed.
+		{Component: "database", Hour: now.AddDate(0, 0, -3).Truncate(time.Hour), Checks: 100, Failures: 50},
+		// Older than the week; ignored.
+		{Component: "database", Hour: now.AddDate(0, 0, -10), Checks: 100, Failures: 100},
+		{Component: "cache", Hour: now.Truncate(time.Hour), Checks: 10},
+	})
+
+	report, err := BuildReport(ctx, store, map[string]bool{"database": true, "cache": false, "search": true}, now)
+	if err != nil {
+		t.Fatalf("build report: %v", err)
+	}
+	if report.Status != StatusDegraded {
+		t.Fatalf("expected degraded with the cache down, got %s", report.Status)
+	}
+	if len(report.Components) != 3 || report.Components[1].Name != "database" {
+		t.Fatalf("expected components sorted by name, got %+v", report.Components)
+	}
+
+	db := report.Components[1]
+	if *db.Uptime24h != 99 || *db.Uptime7d != 74.5 {
+		t.Fatalf("unexpected database uptime: 24h %v, 7d %v", *db.Uptime24h, *db.Uptime7d)
+	}
+	if len(db.Daily) != 7 || db.Daily[6].Date != "2026-03-08" || *db.Daily[6].Uptime != 99 || *db.Daily[3].Uptime != 50 || db.Daily[0].Uptime != nil {
+		t.Fatalf("unexpected daily history %+v", db.Daily)
+	}
+	if search := report.Components[2]; search.Uptime24h != nil || !search.Healthy {
+		t.Fatalf("expected no uptime yet for a new component, got %+v", search)
+	}
+}
+
+func TestBuildReport_Incidents(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	longAgo := now.AddDate(0, 0, -30)
+
+	minor := &Incident{Title: " Slow transfers ", Impact: "minor", Components: []string{"ledger", " ledger", ""}, StartedAt: now.Add(-time.Hour)}
+	if err := store.SaveIncident(ctx, minor); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if minor.Title != "Slow transfers" || len(minor.Components) != 1 {
+		t.Fatalf("expected the incident to be normalised, got %+v", minor)
+	}
+	old := &Incident{Title: "Old outage", Impact: "critical", StartedAt: longAgo, ResolvedAt: &longAgo}
+	_ = store.SaveIncident(ctx, old)
+	if err := store.SaveIncident(ctx, &Incident{Title: "Bad", Impact: "catastrophic"}); !errors.Is(err, ErrInvalidIncident) {
+		t.Fatalf("expected ErrInvalidIncident, got %v", err)
+	}
+
+	report, _ := BuildReport(ctx, store, map[string]bool{"database": true}, now)
+	if report.Status != StatusDegraded || len(report.Incidents) != 1 || report.Incidents[0].ID != minor.ID {
+		t.Fatalf("expected only the ongoing minor incident, got %s %+v", report.Status, report.Incidents)
+	}
+
+	minor.Impact = "major"
+	_ = store.SaveIncident(ctx, minor)
+	if report, _ := BuildReport(ctx, store, nil, now); report.Status != StatusMajorOutage {
+		t.Fatalf("expected a major outage, got %s", report.Status)
+	}
+
+	resolved := now
+	minor.ResolvedAt = &resolved
+	_ = store.SaveIncident(ctx, minor)
+	if report, _ := BuildReport(ctx, store, nil, now); report.Status != StatusOperational || len(report.Incidents) != 1 {
+		t.Fatalf("expected operational with the resolved incident still listed, got %s %+v", report.Status, report.Incidents)
+	}
+}
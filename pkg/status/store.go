@@ -0,0 +1,297 @@
+// Package status keeps the history behind a public status page: hourly
+// health-check counts per component, and incidents annotated by operators.
+package status
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotFound        = errors.New("incident not found")
+	ErrInvalidIncident = errors.New("incident requires a title and an impact of minor, major or critical")
+)
+
+// Bucket counts the checks of one component within the hour starting at Hour.
+type Bucket struct {
+	Component string
+	Hour      time.Time
+	Checks    int64
+	Failures  int64
+}
+
+// Incident is an operator annotation. An empty Components list affects every
+// component; a nil ResolvedAt means the incident is ongoing.
+type Incident struct {
+	ID         string     `json:"id"`
+	Title      string     `json:"title"`
+	Message    string     `json:"message,omitempty"`
+	Impact     string     `json:"impact"`
+	Components []string   `json:"components,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Validate trims the incident's fields and checks they are complete.
+func (i *Incident) Validate() error {
+	i.Title = strings.TrimSpace(i.Title)
+	i.Message = strings.TrimSpace(i.Message)
+	components := i.Components[:0]
+	for _, c := range i.Components {
+		if c = strings.TrimSpace(c); c != "" && !slices.Contains(components, c) {
+			components = append(components, c)
+		}
+	}
+	i.Components = components
+
+	switch i.Impact {
+	case models.IncidentImpactMinor, models.IncidentImpactMajor, models.IncidentImpactCritical:
+	default:
+		return ErrInvalidIncident
+	}
+	if i.Title == "" {
+		return ErrInvalidIncident
+	}
+	return nil
+}
+
+// Ongoing reports whether the incident is unresolved.
+func (i Incident) Ongoing() bool {
+	return i.ResolvedAt == nil
+}
+
+type Store interface {
+	// AddChecks adds the counts to the stored buckets, creating them as needed.
+	AddChecks(ctx context.Context, buckets []Bucket) error
+	// Buckets returns the buckets of every component from since onwards.
+	Buckets(ctx context.Context, since time.Time) ([]Bucket, error)
+	// SaveIncident creates the incident when its ID is empty and otherwise
+	// replaces it, returning ErrNotFound for unknown IDs.
+	SaveIncident(ctx context.Context, incident *Incident) error
+	Incident(ctx context.Context, id string) (*Incident, error)
+	// Incidents returns incidents that were ongoing at any time since then,
+	// most recent first.
+	Incidents(ctx context.Context, since time.Time) ([]Incident, error)
+}
+
+// MemoryStore keeps the history in process memory; for tests and single
+// instance development.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[bucketKey]Bucket
+	incidents map[string]Incident
+}
+
+type bucketKey struct {
+	component string
+	hour      int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[bucketKey]Bucket), incidents: make(map[string]Incident)}
+}
+
+func (s *MemoryStore) AddChecks(_ context.Context, buckets []Bucket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, b := range buckets {
+		key := bucketKey{b.Component, b.Hour.Unix()}
+		stored := s.buckets[key]
+		stored.Component, stored.Hour = b.Component, b.Hour
+		stored.Checks += b.Checks
+		stored.Failures += b.Failures
+		s.buckets[key] = stored
+	}
+	return nil
+}
+
+func (s *MemoryStore) Buckets(_ context.Context, since time.Time) ([]Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Bucket
+	for _, b := range s.buckets {
+		if !b.Hour.Before(since) {
+			out = append(out, b)
+		}
+	}
+	sortBuckets(out)
+	return out, nil
+}
+
+func (s *MemoryStore) SaveIncident(_ context.Context, incident *Incident) error {
+	if err := incident.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if incident.ID == "" {
+		incident.ID = region.NewID()
+	} else if _, ok := s.incidents[incident.ID]; !ok {
+		return ErrNotFound
+	}
+	saved := *incident
+	saved.Components = slices.Clone(incident.Components)
+	s.incidents[incident.ID] = saved
+	return nil
+}
+
+func (s *MemoryStore) Incident(_ context.Context, id string) (*Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	incident, ok := s.incidents[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	incident.Components = slices.Clone(incident.Components)
+	return &incident, nil
+}
+
+func (s *MemoryStore) Incidents(_ context.Context, since time.Time) ([]Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Incident
+	for _, incident := range s.incidents {
+		if incident.ResolvedAt == nil || !incident.ResolvedAt.Before(since) {
+			incident.Components = slices.Clone(incident.Components)
+			out = append(out, incident)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	return out, nil
+}
+
+// GormStore persists the history in the status_checks and incidents tables.
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) AddChecks(ctx context.Context, buckets []Bucket) error {
+	if len(buckets) == 0 {
+		return nil
+	}
+	records := make([]models.StatusCheck, 0, len(buckets))
+	for _, b := range buckets {
+		records = append(records, models.StatusCheck{Component: b.Component, Hour: b.Hour, Checks: b.Checks, Failures: b.Failures})
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "component"}, {Name: "hour"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"checks":   gorm.Expr("status_checks.checks + excluded.checks"),
+			"failures": gorm.Expr("status_checks.failures + excluded.failures"),
+		}),
+	}).Create(&records).Error
+}
+
+func (s *GormStore) Buckets(ctx context.Context, since time.Time) ([]Bucket, error) {
+	var records []models.StatusCheck
+	if err := s.db.WithContext(ctx).Where("hour >= ?", since).Order("component, hour").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	out := make([]Bucket, 0, len(records))
+	for _, r := range records {
+		out = append(out, Bucket{Component: r.Component, Hour: r.Hour.UTC(), Checks: r.Checks, Failures: r.Failures})
+	}
+	return out, nil
+}
+
+func (s *GormStore) SaveIncident(ctx context.Context, incident *Incident) error {
+	if err := incident.Validate(); err != nil {
+		return err
+	}
+	record := &models.Incident{
+		ID:         incident.ID,
+		Title:      incident.Title,
+		Message:    incident.Message,
+		Impact:     incident.Impact,
+		Components: strings.Join(incident.Components, ","),
+		StartedAt:  incident.StartedAt,
+		ResolvedAt: incident.ResolvedAt,
+	}
+	if record.ID == "" {
+		if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+			return err
+		}
+		incident.ID = record.ID
+		return nil
+	}
+
+	res := s.db.WithContext(ctx).Model(&models.Incident{}).Where("id = ?", record.ID).
+		Select("title", "message", "impact", "components", "started_at", "resolved_at", "updated_at").
+		Updates(record)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *GormStore) Incident(ctx context.Context, id string) (*Incident, error) {
+	var record models.Incident
+	if err := s.db.WithContext(ctx).First(&record, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	incident := fromModel(record)
+	return &incident, nil
+}
+
+func (s *GormStore) Incidents(ctx context.Context, since time.Time) ([]Incident, error) {
+	var records []models.Incident
+	err := s.db.WithContext(ctx).
+		Where("resolved_at IS NULL OR resolved_at >= ?", since).
+		Order("started_at DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Incident, 0, len(records))
+	for _, r := range records {
+		out = append(out, fromModel(r))
+	}
+	return out, nil
+}
+
+func fromModel(r models.Incident) Incident {
+	incident := Incident{
+		ID:        r.ID,
+		Title:     r.Title,
+		Message:   r.Message,
+		Impact:    r.Impact,
+		StartedAt: r.StartedAt.UTC(),
+	}
+	if r.Components != "" {
+		incident.Components = strings.Split(r.Components, ",")
+	}
+	if r.ResolvedAt != nil {
+		resolved := r.ResolvedAt.UTC()
+		incident.ResolvedAt = &resolved
+	}
+	return incident
+}
+
+func sortBuckets(buckets []Bucket) {
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Component != buckets[j].Component {
+			return buckets[i].Component < buckets[j].Component
+		}
+		return buckets[i].Hour.Before(buckets[j].Hour)
+	})
+}
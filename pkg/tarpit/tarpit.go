@@ -0,0 +1,94 @@
+package tarpit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Severity string
+
+const (
+	SeverityRateLimited Severity = "rate_limited"
+	SeveritySuspicious  Severity = "suspicious"
+	SeverityBot         Severity = "bot"
+	SeverityBanned      Severity = "banned"
+)
+
+// Tarpit delays responses to abusive clients to raise their cost. Holding a
+// request ties up a goroutine and a connection, so the number of concurrent
+// holds is bounded; once all slots are taken, clients are answered immediately.
+type Tarpit struct {
+	delays map[Severity]time.Duration
+	slots  chan struct{}
+}
+
+func New(delays map[Severity]time.Duration, maxConcurrent int) *Tarpit {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	return &Tarpit{
+		delays: delays,
+		slots:  make(chan struct{}, maxConcurrent),
+	}
+}
+
+// ParseDelays parses "rate_limited=2s,banned=10s".
+func ParseDelays(spec string) (map[Severity]time.Duration, error) {
+	delays := make(map[Severity]time.Duration)
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("tarpit delay %q: expected severity=duration", part)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("tarpit delay %q: invalid duration", part)
+		}
+
+		severity := Severity(strings.ToLower(strings.TrimSpace(name)))
+		switch severity {
+		case SeverityRateLimited, SeveritySuspicious, SeverityBot, SeverityBanned:
+			delays[severity] = d
+		default:
+			return nil, fmt.Errorf("tarpit delay %q: unknown severity", part)
+		}
+	}
+
+	return delays, nil
+}
+
+// Hold blocks for the delay configured for severity. It returns false without
+// waiting when the severity has no delay or every slot is busy, and returns
+// early if ctx is cancelled (the client gave up).
+func (t *Tarpit) Hold(ctx context.Context, severity Severity) bool {
+	if t == nil {
+		return false
+	}
+	delay := t.delays[severity]
+	if delay <= 0 {
+		return false
+	}
+
+	select {
+	case t.slots <- struct{}{}:
+	default:
+		return false
+	}
+	defer func() { <-t.slots }()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return true
+}
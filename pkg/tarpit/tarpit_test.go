@@ -0,0 +1,85 @@
+package tarpit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseDelays(t *testing.T) {
+	delays, err := ParseDelays("rate_limited=2s, BANNED=10s,")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if delays[SeverityRateLimited] != 2*time.Second || delays[SeverityBanned] != 10*time.Second {
+		t.Fatalf("unexpected delays: %v", delays)
+	}
+
+	for _, spec := range []string{"banned", "banned=soon", "angry=1s"} {
+		if _, err := ParseDelays(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestHold_DelaysConfiguredSeverities(t *testing.T) {
+	tp := New(map[Severity]time.Duration{SeverityBanned: 50 * time.Millisecond}, 4)
+
+	start := time.Now()
+	if !tp.Hold(context.Background(), SeverityBanned) {
+		t.Fatalf("expected banned clients to be held")
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatalf("expected hold to last the configured delay")
+	}
+
+	if tp.Hold(context.Background(), SeverityRateLimited) {
+		t.Fatalf("unconfigured severities must not be held")
+	}
+}
+
+func TestHold_ReturnsWhenClientGoesAway(t *testing.T) {
+	tp := New(map[Severity]time.Duration{SeverityBanned: time.Hour}, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		tp.Hold(ctx, SeverityBanned)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("hold did not return after context cancellation")
+	}
+}
+
+func TestHold_BoundedConcurrency(t *testing.T) {
+	tp := New(map[Severity]time.Duration{SeverityBanned: 200 * time.Millisecond}, 1)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tp.Hold(context.Background(), SeverityBanned)
+	}()
+
+	// Wait for the first hold to take the only slot.
+	deadline := time.Now().Add(time.Second)
+	for len(tp.slots) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if tp.Hold(context.Background(), SeverityBanned) {
+		t.Fatalf("expected hold to be skipped when all slots are busy")
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Fatalf("skipped hold must return immediately")
+	}
+	wg.Wait()
+}
@@ -0,0 +1,114 @@
+package tiercache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultChannel is the Redis channel invalidations are published on.
+const DefaultChannel = "cache:invalidations"
+
+// Invalidation tells instances to drop a key, or the keys matching a
+// glob-style pattern, from their local tier.
+type Invalidation struct {
+	Origin  string `json:"origin"`
+	Key     string `json:"key,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Bus carries invalidations between instances. Delivery is best effort;
+// LocalTTL bounds the cost of a lost one.
+type Bus interface {
+	Publish(ctx context.Context, inv Invalidation) error
+	// Subscribe delivers every invalidation published from then on, the
+	// subscriber's own included, to fn from one goroutine, until the
+	// returned function is called.
+	Subscribe(ctx context.Context, fn func(Invalidation)) (func() error, error)
+}
+
+// RedisBus publishes invalidations on a Redis pub/sub channel. Messages
+// published while a subscriber reconnects are lost.
+type RedisBus struct {
+	client  *redis.Client
+	channel string
+	logger  Logger
+}
+
+func NewRedisBus(client *redis.Client, channel string, logger Logger) *RedisBus {
+	if channel == "" {
+		channel = DefaultChannel
+	}
+	return &RedisBus{client: client, channel: channel, logger: logger}
+}
+
+func (b *RedisBus) Publish(ctx context.Context, inv Invalidation) error {
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+func (b *RedisBus) Subscribe(ctx context.Context, fn func(Invalidation)) (func() error, error) {
+	sub := b.client.Subscribe(ctx, b.channel)
+	// Wait for the subscription, so no invalidation after New is missed.
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range sub.Channel() {
+			var inv Invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				b.logger.Warn("Ignoring malformed cache invalidation", "channel", b.channel, "error", err)
+				continue
+			}
+			fn(inv)
+		}
+	}()
+	return func() error {
+		err := sub.Close()
+		<-done
+		return err
+	}, nil
+}
+
+// MemoryBus connects caches in one process, for tests.
+type MemoryBus struct {
+	mu          sync.Mutex
+	subscribers map[int]func(Invalidation)
+	next        int
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{subscribers: make(map[int]func(Invalidation))}
+}
+
+// Publish delivers inv to every subscriber before it returns.
+func (b *MemoryBus) Publish(_ context.Context, inv Invalidation) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, fn := range b.subscribers {
+		fn(inv)
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(_ context.Context, fn func(Invalidation)) (func() error, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.subscribers[id] = fn
+	return func() error {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, id)
+		return nil
+	}, nil
+}
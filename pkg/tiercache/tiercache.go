@@ -0,0 +1,301 @@
+// Package tiercache layers a process-local LRU cache over a shared remote
+// cache, such as Redis. Reads are served locally when they can be; misses
+// for the same key share one remote read; and writes are published so every
+// instance drops its local copy.
+package tiercache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/lrucache"
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultLocalTTL bounds how long a value is served locally when Config
+// leaves LocalTTL unset.
+const DefaultLocalTTL = 30 * time.Second
+
+// Remote is the shared cache behind the local tier, with the semantics of
+// config.Cache.
+type Remote interface {
+	Get(ctx context.Context, key string) (string, error)
+	GetWithTTL(ctx context.Context, key string) (string, time.Duration, error)
+	MGet(ctx context.Context, keys ...string) ([]string, error)
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	IncrBy(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	Delete(ctx context.Context, key string) error
+	DeleteByPattern(ctx context.Context, pattern string) (int64, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+type Logger interface {
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type Config struct {
+	// LocalTTL is the longest a value is served from the local tier. It
+	// bounds how stale a value can be when an invalidation is lost, for
+	// example while the bus reconnects. It defaults to DefaultLocalTTL.
+	LocalTTL time.Duration
+	// LocalMaxEntries bounds the local tier; see lrucache.Config.
+	LocalMaxEntries int
+}
+
+// Cache reads through a local LRU tier to Remote. Writes go to Remote first,
+// then replace the local copy and publish an invalidation on the Bus, which
+// every other instance applies to its own local tier. Counters are kept in
+// Remote only, since they change on every instance.
+type Cache struct {
+	remote   Remote
+	local    *lrucache.LRUCache
+	bus      Bus
+	logger   Logger
+	origin   string
+	localTTL time.Duration
+
+	// reads shares remote reads of a key between concurrent misses, and
+	// loads the calls GetOrLoad makes to rebuild one.
+	reads singleflight.Group
+	loads singleflight.Group
+	// generation changes on every invalidation, so a remote read that raced
+	// one is not stored locally.
+	generation atomic.Uint64
+
+	closeOnce   sync.Once
+	unsubscribe func() error
+}
+
+// New subscribes to bus and returns the layered cache. Close unsubscribes
+// and closes remote.
+func New(ctx context.Context, remote Remote, bus Bus, logger Logger, cfg Config) (*Cache, error) {
+	if cfg.LocalTTL <= 0 {
+		cfg.LocalTTL = DefaultLocalTTL
+	}
+	c := &Cache{
+		remote:   remote,
+		local:    lrucache.NewLRUCache(&lrucache.Config{MaxEntries: cfg.LocalMaxEntries, MaxTTL: cfg.LocalTTL}),
+		bus:      bus,
+		logger:   logger,
+		origin:   newOrigin(),
+		localTTL: cfg.LocalTTL,
+	}
+	unsubscribe, err := bus.Subscribe(ctx, c.apply)
+	if err != nil {
+		return nil, err
+	}
+	c.unsubscribe = unsubscribe
+	return c, nil
+}
+
+func (c *Cache) Get(ctx context.Context, key string) (string, error) {
+	if value, err := c.local.Get(ctx, key); value != "" || err != nil {
+		return value, err
+	}
+	value, err, _ := c.reads.Do(key, func() (any, error) {
+		gen := c.generation.Load()
+		value, ttl, err := c.remote.GetWithTTL(ctx, key)
+		if err != nil || value == "" {
+			return value, err
+		}
+		c.storeLocal(ctx, gen, key, value, ttl)
+		return value, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// GetOrLoad returns key's value, or calls load and stores what it returns
+// for ttl. Concurrent misses for the same key on one instance share one call
+// to load, so an expired hot key is rebuilt once rather than by every
+// request waiting for it.
+func (c *Cache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (string, error)) (string, error) {
+	if value, err := c.Get(ctx, key); value != "" || err != nil {
+		return value, err
+	}
+	value, err, _ := c.loads.Do(key, func() (any, error) {
+		// Another caller may have loaded it since the miss.
+		if value, err := c.remote.Get(ctx, key); value != "" || err != nil {
+			return value, err
+		}
+		value, err := load(ctx)
+		if err != nil {
+			return "", err
+		}
+		return value, c.Set(ctx, key, value, ttl)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// GetWithTTL reads Remote, which knows how long the key has left.
+func (c *Cache) GetWithTTL(ctx context.Context, key string) (string, time.Duration, error) {
+	return c.remote.GetWithTTL(ctx, key)
+}
+
+func (c *Cache) MGet(ctx context.Context, keys ...string) ([]string, error) {
+	values, err := c.local.MGet(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	var at []int
+	for i, value := range values {
+		if value == "" {
+			missing = append(missing, keys[i])
+			at = append(at, i)
+		}
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+	gen := c.generation.Load()
+	fetched, err := c.remote.MGet(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range fetched {
+		values[at[i]] = value
+		if value != "" {
+			// MGET does not say how long keys have left; LocalTTL bounds it.
+			c.storeLocal(ctx, gen, missing[i], value, 0)
+		}
+	}
+	return values, nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if err := c.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	c.invalidate(ctx, Invalidation{Key: key})
+	c.storeLocal(ctx, c.generation.Load(), key, value, ttl)
+	return nil
+}
+
+func (c *Cache) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	set, err := c.remote.SetNX(ctx, key, value, ttl)
+	if err != nil || !set {
+		return set, err
+	}
+	c.invalidate(ctx, Invalidation{Key: key})
+	c.storeLocal(ctx, c.generation.Load(), key, value, ttl)
+	return true, nil
+}
+
+func (c *Cache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return c.IncrBy(ctx, key, 1, ttl)
+}
+
+// IncrBy counts in Remote. A local copy of the counter, from a Get, is
+// dropped here and on the other instances.
+func (c *Cache) IncrBy(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	n, err := c.remote.IncrBy(ctx, key, delta, ttl)
+	if err != nil {
+		return 0, err
+	}
+	c.invalidate(ctx, Invalidation{Key: key})
+	return n, nil
+}
+
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if err := c.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+	c.invalidate(ctx, Invalidation{Key: key})
+	return nil
+}
+
+func (c *Cache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	deleted, err := c.remote.DeleteByPattern(ctx, pattern)
+	// Keys may have been deleted before an error; drop them locally anyway.
+	c.invalidate(ctx, Invalidation{Pattern: pattern})
+	return deleted, err
+}
+
+func (c *Cache) Ping(ctx context.Context) error {
+	return c.remote.Ping(ctx)
+}
+
+// Close unsubscribes from the bus and closes Remote.
+func (c *Cache) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.unsubscribe != nil {
+			if uerr := c.unsubscribe(); uerr != nil {
+				c.logger.Warn("Failed to unsubscribe from cache invalidations", "error", uerr)
+			}
+		}
+		_ = c.local.Close()
+		err = c.remote.Close()
+	})
+	return err
+}
+
+// GetClient exposes the Redis client of a Redis remote, so features that
+// need Redis itself, such as distributed rate limiting, still find it.
+func (c *Cache) GetClient() *redis.Client {
+	if provider, ok := c.remote.(interface{ GetClient() *redis.Client }); ok {
+		return provider.GetClient()
+	}
+	return nil
+}
+
+// storeLocal keeps value locally for the shorter of ttl and LocalTTL, unless
+// an invalidation arrived since gen was read.
+func (c *Cache) storeLocal(ctx context.Context, gen uint64, key, value string, ttl time.Duration) {
+	if c.generation.Load() != gen {
+		return
+	}
+	_ = c.local.Set(ctx, key, value, min(ttl, c.localTTL))
+}
+
+// invalidate drops keys locally and tells the other instances to.
+func (c *Cache) invalidate(ctx context.Context, inv Invalidation) {
+	c.drop(inv)
+	inv.Origin = c.origin
+	if err := c.bus.Publish(ctx, inv); err != nil {
+		// Other instances serve their copy until LocalTTL.
+		c.logger.Error("Failed to publish cache invalidation", "key", inv.Key, "pattern", inv.Pattern, "error", err)
+	}
+}
+
+// apply handles an invalidation from the bus; this instance's own were
+// applied when they were published.
+func (c *Cache) apply(inv Invalidation) {
+	if inv.Origin == c.origin {
+		return
+	}
+	c.drop(inv)
+}
+
+func (c *Cache) drop(inv Invalidation) {
+	c.generation.Add(1)
+	ctx := context.Background()
+	if inv.Pattern != "" {
+		if _, err := c.local.DeleteByPattern(ctx, inv.Pattern); err != nil {
+			c.logger.Warn("Invalid cache invalidation pattern", "pattern", inv.Pattern, "error", err)
+		}
+		return
+	}
+	_ = c.local.Delete(ctx, inv.Key)
+}
+
+func newOrigin() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,165 @@
+package tiercache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/lrucache"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+// countingRemote counts the reads that reach the remote tier.
+type countingRemote struct {
+	*lrucache.LRUCache
+	reads atomic.Int64
+	// gate, when set, holds reads until it is closed.
+	gate chan struct{}
+}
+
+func (r *countingRemote) GetWithTTL(ctx context.Context, key string) (string, time.Duration, error) {
+	r.reads.Add(1)
+	if r.gate != nil {
+		<-r.gate
+	}
+	return r.LRUCache.GetWithTTL(ctx, key)
+}
+
+func (r *countingRemote) MGet(ctx context.Context, keys ...string) ([]string, error) {
+	r.reads.Add(1)
+	return r.LRUCache.MGet(ctx, keys...)
+}
+
+// newInstances returns two caches, as on two instances, sharing a remote and
+// a bus.
+func newInstances(t *testing.T) (*Cache, *Cache, *countingRemote) {
+	t.Helper()
+	remote := &countingRemote{LRUCache: lrucache.NewLRUCache(nil)}
+	bus := NewMemoryBus()
+	var caches []*Cache
+	for range 2 {
+		c, err := New(context.Background(), remote, bus, nopLogger{}, Config{})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		caches = append(caches, c)
+	}
+	return caches[0], caches[1], remote
+}
+
+func TestCache_ServesReadsLocally(t *testing.T) {
+	a, b, remote := newInstances(t)
+	ctx := context.Background()
+
+	if err := a.Set(ctx, "user:1", "alice", time.Hour); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	for range 3 {
+		if v, _ := a.Get(ctx, "user:1"); v != "alice" {
+			t.Fatalf("expected alice, got %q", v)
+		}
+	}
+	if remote.reads.Load() != 0 {
+		t.Fatalf("expected the writer to read its own copy, got %d remote reads", remote.reads.Load())
+	}
+
+	for range 3 {
+		if v, _ := b.Get(ctx, "user:1"); v != "alice" {
+			t.Fatalf("expected alice, got %q", v)
+		}
+	}
+	if remote.reads.Load() != 1 {
+		t.Fatalf("expected one remote read for the other instance, got %d", remote.reads.Load())
+	}
+
+	values, _ := b.MGet(ctx, "user:1", "user:2")
+	if values[0] != "alice" || values[1] != "" || remote.reads.Load() != 2 {
+		t.Fatalf("expected only the missing key to be read remotely, got %q after %d reads", values, remote.reads.Load())
+	}
+}
+
+func TestCache_WritesInvalidateOtherInstances(t *testing.T) {
+	a, b, _ := newInstances(t)
+	ctx := context.Background()
+
+	_ = a.Set(ctx, "user:1", "alice", time.Hour)
+	_ = a.Set(ctx, "user:2", "bob", time.Hour)
+	b.Get(ctx, "user:1")
+	b.Get(ctx, "user:2")
+
+	_ = a.Set(ctx, "user:1", "alicia", time.Hour)
+	if v, _ := b.Get(ctx, "user:1"); v != "alicia" {
+		t.Fatalf("expected the other instance to see the new value, got %q", v)
+	}
+
+	if _, err := a.DeleteByPattern(ctx, "user:*"); err != nil {
+		t.Fatalf("DeleteByPattern: %v", err)
+	}
+	if v, _ := b.Get(ctx, "user:2"); v != "" {
+		t.Fatalf("expected the pattern delete to reach the other instance, got %q", v)
+	}
+
+	b.Get(ctx, "hits")
+	if n, _ := a.Incr(ctx, "hits", time.Minute); n != 1 {
+		t.Fatalf("expected 1, got %d", n)
+	}
+	if v, _ := b.Get(ctx, "hits"); v != "1" {
+		t.Fatalf("expected the counter to be read from the remote, got %q", v)
+	}
+}
+
+func TestCache_ConcurrentMissesShareOneRead(t *testing.T) {
+	a, b, remote := newInstances(t)
+	ctx := context.Background()
+	_ = a.Set(ctx, "hot", "value", time.Hour)
+	remote.gate = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if v, _ := b.Get(ctx, "hot"); v != "value" {
+				t.Errorf("expected value, got %q", v)
+			}
+		})
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(remote.gate)
+	wg.Wait()
+	if remote.reads.Load() != 1 {
+		t.Fatalf("expected concurrent misses to share one remote read, got %d", remote.reads.Load())
+	}
+}
+
+func TestCache_GetOrLoadRebuildsOnce(t *testing.T) {
+	a, b, _ := newInstances(t)
+	ctx := context.Background()
+	var loads atomic.Int64
+	load := func(context.Context) (string, error) {
+		loads.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return "built", nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if v, err := a.GetOrLoad(ctx, "report", time.Hour, load); v != "built" || err != nil {
+				t.Errorf("expected built, got %q, %v", v, err)
+			}
+		})
+	}
+	wg.Wait()
+	if v, _ := b.GetOrLoad(ctx, "report", time.Hour, load); v != "built" {
+		t.Fatalf("expected the other instance to find the stored value, got %q", v)
+	}
+	if loads.Load() != 1 {
+		t.Fatalf("expected one load, got %d", loads.Load())
+	}
+}
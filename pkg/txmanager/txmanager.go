@@ -0,0 +1,57 @@
+// Package txmanager runs units of work that span several repositories in one
+// database transaction. The transaction travels in the context, and
+// repositories look for it there before using their own connection:
+//
+//	err := manager.WithinTransaction(ctx, func(ctx context.Context) error {
+//		if err := accounts.Create(ctx, account); err != nil {
+//			return err
+//		}
+//		return audit.Record(ctx, entry)
+//	})
+//
+// Inside a repository, DB(ctx, r.db) returns the context's transaction, or
+// r.db when there is none.
+package txmanager
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type contextKey struct{}
+
+// Manager starts transactions on one database.
+type Manager struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Manager {
+	return &Manager{db: db}
+}
+
+// WithinTransaction runs fn in a transaction, committed when fn returns nil
+// and rolled back when it returns an error or panics. Repositories called
+// with the context fn receives use the transaction. Called within another
+// unit of work, fn runs in a savepoint of its transaction, so an error rolls
+// back only fn's writes.
+func (m *Manager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return DB(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, contextKey{}, tx))
+	})
+}
+
+// From returns the transaction of the unit of work ctx belongs to.
+func From(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(contextKey{}).(*gorm.DB)
+	return tx, ok
+}
+
+// DB returns the transaction ctx carries, or db when it carries none, bound
+// to ctx.
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := From(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
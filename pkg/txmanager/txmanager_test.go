@@ -0,0 +1,115 @@
+package txmanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type note struct {
+	ID   uint
+	Text string
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// One connection, so the transaction and the checks see one database.
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&note{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func insert(ctx context.Context, db *gorm.DB, text string) error {
+	return DB(ctx, db).Create(&note{Text: text}).Error
+}
+
+func count(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&note{}).Count(&n).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n
+}
+
+func TestWithinTransaction_CommitsAndRollsBack(t *testing.T) {
+	db := newTestDB(t)
+	manager := New(db)
+	ctx := context.Background()
+
+	if _, ok := From(ctx); ok {
+		t.Fatalf("expected no transaction outside a unit of work")
+	}
+
+	err := manager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, ok := From(ctx); !ok {
+			t.Fatalf("expected a transaction inside the unit of work")
+		}
+		if err := insert(ctx, db, "a"); err != nil {
+			return err
+		}
+		return insert(ctx, db, "b")
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := count(t, db); n != 2 {
+		t.Fatalf("expected 2 committed notes, got %d", n)
+	}
+
+	failure := errors.New("second repository failed")
+	err = manager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := insert(ctx, db, "c"); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the unit of work's error, got %v", err)
+	}
+	if n := count(t, db); n != 2 {
+		t.Fatalf("expected the failed unit of work to be rolled back, got %d notes", n)
+	}
+}
+
+func TestWithinTransaction_NestedRollsBackToSavepoint(t *testing.T) {
+	db := newTestDB(t)
+	manager := New(db)
+
+	err := manager.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := insert(ctx, db, "outer"); err != nil {
+			return err
+		}
+		inner := manager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := insert(ctx, db, "inner"); err != nil {
+				return err
+			}
+			return errors.New("inner failed")
+		})
+		if inner == nil {
+			t.Fatalf("expected the inner unit of work to fail")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var texts []string
+	db.Model(&note{}).Pluck("text", &texts)
+	if len(texts) != 1 || texts[0] != "outer" {
+		t.Fatalf("expected only the outer note, got %v", texts)
+	}
+}
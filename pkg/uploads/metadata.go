@@ -0,0 +1,47 @@
+package uploads
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidMetadata = errors.New("uploads: Upload-Metadata must be comma-separated 'key base64value' pairs")
+
+// ParseMetadata decodes a tus Upload-Metadata header:
+// "filename d29ybGQuY3N2,import bGVkZ2VyLnRyYW5zZmVycw==". Values are optional.
+func ParseMetadata(header string) (map[string]string, error) {
+	meta := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return meta, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" || strings.ContainsAny(key, " ,") {
+			return nil, ErrInvalidMetadata
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, ErrInvalidMetadata
+		}
+		meta[key] = string(value)
+	}
+	return meta, nil
+}
+
+// EncodeMetadata is the inverse of ParseMetadata, with keys sorted.
+func EncodeMetadata(meta map[string]string) string {
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+" "+base64.StdEncoding.EncodeToString([]byte(meta[k])))
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,262 @@
+// Package uploads stores resumable uploads (tus protocol) chunk by chunk so
+// an interrupted transfer can continue from the last received byte.
+package uploads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound       = errors.New("uploads: upload not found")
+	ErrOffsetMismatch = errors.New("uploads: offset does not match the upload")
+)
+
+// Info describes an upload. Offset is the number of bytes received so far.
+type Info struct {
+	ID          string            `json:"id"`
+	Size        int64             `json:"size"`
+	Offset      int64             `json:"offset"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	OperationID string            `json:"operation_id,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+}
+
+// Done reports whether every byte of the upload has been received.
+func (i *Info) Done() bool {
+	return i.Offset >= i.Size
+}
+
+// Store persists uploads. Append must reject writes whose offset is not the
+// current upload offset, which makes concurrent or replayed chunks harmless.
+type Store interface {
+	Create(ctx context.Context, info *Info) error
+	Get(ctx context.Context, id string) (*Info, error)
+	// Append writes r at offset and returns the updated info. Bytes written
+	// before a read error (e.g. a dropped connection) are kept.
+	Append(ctx context.Context, id string, offset int64, r io.Reader) (*Info, error)
+	// SetOperation records the operation processing a completed upload.
+	SetOperation(ctx context.Context, id, operationID string) error
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// FileStore keeps each upload as a data file and a JSON info file in one
+// directory. Point it at a mounted volume or object storage gateway to share
+// uploads between instances.
+type FileStore struct {
+	dir   string
+	locks sync.Map // upload ID -> *sync.Mutex
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("uploads: create directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) Create(_ context.Context, info *Info) error {
+	if info.ID == "" {
+		info.ID = region.NewID()
+	}
+	f, err := os.OpenFile(s.dataPath(info.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return s.writeInfo(info)
+}
+
+func (s *FileStore) Get(_ context.Context, id string) (*Info, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	return s.readInfo(id)
+}
+
+func (s *FileStore) Append(_ context.Context, id string, offset int64, r io.Reader) (*Info, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	unlock := s.lock(id)
+	defer unlock()
+
+	info, err := s.readInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != info.Offset {
+		return info, ErrOffsetMismatch
+	}
+
+	f, err := os.OpenFile(s.dataPath(id), os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	n, copyErr := io.Copy(f, io.LimitReader(r, info.Size-offset))
+	if err := f.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+
+	info.Offset += n
+	if err := s.writeInfo(info); err != nil {
+		return nil, err
+	}
+	return info, copyErr
+}
+
+func (s *FileStore) SetOperation(_ context.Context, id, operationID string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+	unlock := s.lock(id)
+	defer unlock()
+
+	info, err := s.readInfo(id)
+	if err != nil {
+		return err
+	}
+	info.OperationID = operationID
+	return s.writeInfo(info)
+}
+
+func (s *FileStore) Open(_ context.Context, id string) (io.ReadCloser, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(s.dataPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *FileStore) Delete(_ context.Context, id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+	unlock := s.lock(id)
+	defer unlock()
+
+	if _, err := os.Stat(s.infoPath(id)); errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err := os.Remove(s.dataPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(s.infoPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	s.locks.Delete(id)
+	return nil
+}
+
+// PurgeExpired deletes uploads whose expiry is before now and returns how
+// many were removed.
+func (s *FileStore) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".info")
+		if !ok || !validID(id) {
+			continue
+		}
+		info, err := s.readInfo(id)
+		if err != nil || !info.ExpiresAt.Before(now) {
+			continue
+		}
+		if err := s.Delete(ctx, id); err == nil {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *FileStore) lock(id string) func() {
+	mu, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	m := mu.(*sync.Mutex)
+	m.Lock()
+	return m.Unlock
+}
+
+func (s *FileStore) readInfo(id string) (*Info, error) {
+	b, err := os.ReadFile(s.infoPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var info Info
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, fmt.Errorf("uploads: corrupt info for %s: %w", id, err)
+	}
+	return &info, nil
+}
+
+// writeInfo replaces the info file atomically so readers never see a
+// partially written file.
+func (s *FileStore) writeInfo(info *Info) error {
+	b, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	tmp := s.infoPath(info.ID) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.infoPath(info.ID))
+}
+
+func (s *FileStore) dataPath(id string) string {
+	return filepath.Join(s.dir, id+".bin")
+}
+
+func (s *FileStore) infoPath(id string) string {
+	return filepath.Join(s.dir, id+".info")
+}
+
+// validID guards file paths against traversal; IDs are always UUIDs.
+func validID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+// Config bounds uploads accepted by the server.
+type Config struct {
+	// MaxSize is the largest Upload-Length accepted, in bytes.
+	MaxSize int64
+	// Expiry is how long an upload is kept after creation, complete or not.
+	Expiry time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxSize: 10 << 30,
+		Expiry:  24 * time.Hour,
+	}
+}
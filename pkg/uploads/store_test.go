@@ -0,0 +1,107 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingReader struct {
+	data string
+	read bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, errors.New("connection reset")
+	}
+	r.read = true
+	return copy(p, r.data), nil
+}
+
+func TestFileStore_ResumesAfterInterruptedChunk(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+
+	info := &Info{Size: 10, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := store.Create(ctx, info); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	updated, err := store.Append(ctx, info.ID, 0, &failingReader{data: "hello"})
+	if err == nil || updated == nil || updated.Offset != 5 {
+		t.Fatalf("expected partial write to be kept, got %+v, %v", updated, err)
+	}
+
+	if _, err := store.Append(ctx, info.ID, 0, strings.NewReader("again")); !errors.Is(err, ErrOffsetMismatch) {
+		t.Fatalf("expected offset mismatch, got %v", err)
+	}
+
+	updated, err = store.Append(ctx, info.ID, 5, strings.NewReader("world!!"))
+	if err != nil || !updated.Done() || updated.Offset != 10 {
+		t.Fatalf("expected completed upload capped at its size, got %+v, %v", updated, err)
+	}
+
+	f, err := store.Open(ctx, info.ID)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	if string(data) != "helloworld" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
+
+func TestFileStore_RejectsNonUUIDIDs(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	if _, err := store.Get(context.Background(), "../../etc/passwd"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFileStore_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	store, _ := NewFileStore(t.TempDir())
+
+	expired := &Info{Size: 1, ExpiresAt: time.Now().Add(-time.Minute)}
+	live := &Info{Size: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	store.Create(ctx, expired)
+	store.Create(ctx, live)
+
+	n, err := store.PurgeExpired(ctx, time.Now())
+	if err != nil || n != 1 {
+		t.Fatalf("expected one purged upload, got %d, %v", n, err)
+	}
+	if _, err := store.Get(ctx, expired.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected expired upload to be gone")
+	}
+	if _, err := store.Get(ctx, live.ID); err != nil {
+		t.Fatalf("expected live upload to remain: %v", err)
+	}
+}
+
+func TestMetadata_RoundTrip(t *testing.T) {
+	meta, err := ParseMetadata("filename d29ybGQuY3N2, import bGVkZ2VyLnRyYW5zZmVycw==,empty")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if meta["filename"] != "world.csv" || meta["import"] != "ledger.transfers" || meta["empty"] != "" {
+		t.Fatalf("unexpected metadata: %v", meta)
+	}
+
+	again, err := ParseMetadata(EncodeMetadata(meta))
+	if err != nil || len(again) != 3 || again["import"] != "ledger.transfers" {
+		t.Fatalf("round trip failed: %v, %v", again, err)
+	}
+
+	if _, err := ParseMetadata("filename not-base64!"); !errors.Is(err, ErrInvalidMetadata) {
+		t.Fatalf("expected ErrInvalidMetadata, got %v", err)
+	}
+}
@@ -0,0 +1,524 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/retry"
+	"golang.org/x/time/rate"
+)
+
+// Headers sent with each delivery besides events.SignatureHeader, X-Event-ID
+// and X-Event-Type. Receivers deduplicate by X-Event-ID.
+const (
+	DeliveryHeader = "X-Webhook-Delivery"
+	AttemptHeader  = "X-Webhook-Attempt"
+)
+
+var errEndpointDisabled = errors.New("endpoint is disabled")
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type Config struct {
+	// Backoff spaces the attempts of a delivery.
+	Backoff retry.Backoff
+	// MaxAttempts is how many attempts a delivery gets before it fails.
+	MaxAttempts int
+	// Timeout bounds each request.
+	Timeout time.Duration
+	// PollInterval is how often due retries are looked for. New deliveries
+	// are sent straight away.
+	PollInterval time.Duration
+	// BatchSize caps the deliveries claimed at once.
+	BatchSize int
+	// MaxInFlight caps the deliveries claimed and not yet done, in flight
+	// or waiting for their host, across all endpoints.
+	MaxInFlight int
+	// MaxPerHost caps the requests in flight, and the connections, to one
+	// host; further deliveries to it wait, so a slow receiver holds at most
+	// this many of MaxInFlight.
+	MaxPerHost int
+	// IdleConnTimeout is how long a kept-alive connection to a receiver
+	// may sit unused. Up to MaxPerHost idle connections are kept per host.
+	IdleConnTimeout time.Duration
+	// Breakers, when set, holds a breaker per receiver host, named
+	// "webhook:" and the host. Transport errors, 429s and 5xx responses
+	// count against it. While it is open, deliveries to the host are not
+	// sent and their attempts are not counted; they go out once it lets a
+	// trial request through and the trial succeeds.
+	Breakers *circuitbreaker.Registry
+}
+
+// DefaultConfig retries a failing delivery for about two hours.
+func DefaultConfig() Config {
+	return Config{
+		Backoff:         retry.Backoff{Initial: 10 * time.Second, Max: time.Hour, Multiplier: 3, Jitter: 0.2},
+		MaxAttempts:     8,
+		Timeout:         10 * time.Second,
+		PollInterval:    5 * time.Second,
+		BatchSize:       20,
+		MaxInFlight:     64,
+		MaxPerHost:      4,
+		IdleConnTimeout: 90 * time.Second,
+	}
+}
+
+// Dispatcher queues events for the endpoints subscribed to them and delivers
+// the queue until Stop.
+type Dispatcher struct {
+	store  Store
+	logger Logger
+	cfg    Config
+	client *http.Client
+
+	mu      sync.Mutex
+	started bool
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+
+	// pool tracks the deliveries claimed and the requests in flight per
+	// host; attempts counts the attempts running.
+	pool     pool
+	attempts sync.WaitGroup
+
+	// tripped holds the endpoints whose host's breaker rejected a delivery,
+	// which are not claimed for while it stays open.
+	trippedMu sync.Mutex
+	tripped   map[string]*circuitbreaker.Breaker
+
+	// ctx is cancelled when Stop gives up waiting for attempts in flight.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	now func() time.Time
+}
+
+func NewDispatcher(store Store, logger Logger, cfg Config) *Dispatcher {
+	defaults := DefaultConfig()
+	if cfg.Backoff == (retry.Backoff{}) {
+		cfg.Backoff = defaults.Backoff
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaults.MaxAttempts
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = defaults.MaxInFlight
+	}
+	if cfg.MaxPerHost <= 0 {
+		cfg.MaxPerHost = defaults.MaxPerHost
+	}
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = defaults.IdleConnTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = cfg.MaxPerHost
+	transport.MaxIdleConnsPerHost = cfg.MaxPerHost
+	transport.MaxIdleConns = cfg.MaxInFlight
+	transport.IdleConnTimeout = cfg.IdleConnTimeout
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		store:   store,
+		logger:  logger,
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout, Transport: transport},
+		pool:    newPool(cfg.MaxInFlight, cfg.MaxPerHost),
+		tripped: make(map[string]*circuitbreaker.Breaker),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+		now:     time.Now,
+	}
+}
+
+// Store returns the store behind the dispatcher, for the admin API.
+func (d *Dispatcher) Store() Store {
+	return d.store
+}
+
+// Handle queues event for every active endpoint subscribed to it. Subscribe
+// it to the event bus with the pattern "*".
+func (d *Dispatcher) Handle(ctx context.Context, event events.Event) {
+	endpoints, err := d.store.Endpoints(ctx)
+	if err != nil {
+		d.logger.Error("Failed to load webhook endpoints", "type", event.Type, "id", event.ID, "error", err)
+		return
+	}
+
+	var payload []byte
+	var deliveries []Delivery
+	for _, e := range endpoints {
+		if !e.Subscribed(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				d.logger.Error("Failed to encode webhook payload", "type", event.Type, "id", event.ID, "error", err)
+				return
+			}
+		}
+		deliveries = append(deliveries, Delivery{EndpointID: e.ID, EventID: event.ID, EventType: event.Type, OccurredAt: event.OccurredAt, Payload: payload})
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	if err := d.store.Enqueue(ctx, deliveries, d.now().UTC()); err != nil {
+		d.logger.Error("Failed to queue webhook deliveries", "type", event.Type, "id", event.ID, "error", err)
+		return
+	}
+	d.notify()
+}
+
+// Replay makes a failed delivery pending again with a fresh set of attempts.
+func (d *Dispatcher) Replay(ctx context.Context, id string) error {
+	if err := d.store.Replay(ctx, id, d.now().UTC()); err != nil {
+		return err
+	}
+	d.notify()
+	return nil
+}
+
+// ReplayFailed replays the failed deliveries of endpointID, or of every
+// endpoint when it is empty, and returns how many it replayed.
+func (d *Dispatcher) ReplayFailed(ctx context.Context, endpointID string) (int64, error) {
+	n, err := d.store.ReplayFailed(ctx, endpointID, d.now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		d.notify()
+	}
+	return n, nil
+}
+
+// ReplayProgress reports how far ReplayRange has got.
+type ReplayProgress struct {
+	// Total is how many deliveries the range held when the replay began.
+	Total int64 `json:"total"`
+	// Done is how many of them the replay has passed.
+	Done int64 `json:"done"`
+	// Replayed is how many of those were queued again; the others were
+	// still pending.
+	Replayed int64 `json:"replayed"`
+}
+
+// ReplayRange sends an endpoint the events that occurred in r again, oldest
+// first, such as to catch a receiver up after an outage. Each delivery not
+// already pending is made pending with a fresh set of attempts, at most
+// perSecond a second, so the replay does not flood the receiver or crowd out
+// its new events. onProgress, when set, is called after each batch. It
+// returns ErrNotFound for an unknown endpoint, and ctx's error when ctx ends
+// first; deliveries queued by then stay queued.
+func (d *Dispatcher) ReplayRange(ctx context.Context, r ReplayRange, perSecond int, onProgress func(ReplayProgress)) (ReplayProgress, error) {
+	if _, err := d.store.Endpoint(ctx, r.EndpointID); err != nil {
+		return ReplayProgress{}, err
+	}
+	total, err := d.store.CountRange(ctx, r)
+	if err != nil {
+		return ReplayProgress{}, err
+	}
+
+	progress := ReplayProgress{Total: total}
+	perSecond = max(perSecond, 1)
+	limiter := rate.NewLimiter(rate.Limit(perSecond), perSecond)
+	var cursor ReplayCursor
+	for {
+		if err := limiter.WaitN(ctx, perSecond); err != nil {
+			return progress, err
+		}
+		batch, err := d.store.ReplayRange(ctx, r, cursor, perSecond, d.now().UTC())
+		if err != nil {
+			return progress, err
+		}
+		cursor = batch.Next
+		progress.Done += int64(batch.Taken)
+		progress.Replayed += batch.Replayed
+		if batch.Replayed > 0 {
+			d.notify()
+		}
+		if onProgress != nil {
+			onProgress(progress)
+		}
+		if batch.Taken < perSecond {
+			return progress, nil
+		}
+	}
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start delivers queued events until Stop.
+func (d *Dispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.started {
+		return
+	}
+	d.started = true
+	go func() {
+		defer close(d.done)
+		defer d.attempts.Wait()
+		ticker := time.NewTicker(d.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			d.dispatch(d.ctx)
+			select {
+			case <-ticker.C:
+			case <-d.wake:
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops delivering and waits for the attempts in flight until ctx ends.
+// Attempts still running then are abandoned and retried by the next instance
+// to claim them.
+func (d *Dispatcher) Stop(ctx context.Context) {
+	d.mu.Lock()
+	started := d.started
+	d.mu.Unlock()
+
+	d.once.Do(func() { close(d.stop) })
+	defer d.client.CloseIdleConnections()
+	defer d.cancel()
+	if !started {
+		return
+	}
+	select {
+	case <-d.done:
+	case <-ctx.Done():
+		d.cancel()
+		<-d.done
+	}
+}
+
+// dispatch claims due deliveries, a batch at a time, while MaxInFlight has
+// room, and starts attempting them without waiting for the attempts. Each
+// attempt that ends wakes the dispatcher to claim more. It returns how many
+// it claimed.
+func (d *Dispatcher) dispatch(ctx context.Context) int {
+	// A claim outlasts the request, so no other instance sends the delivery
+	// while this one is still waiting for the response.
+	lease := 2 * d.cfg.Timeout
+
+	claimed := 0
+	for {
+		room, busy := d.pool.room()
+		if room == 0 {
+			return claimed
+		}
+		busy = append(busy, d.trippedEndpoints()...)
+		now := d.now().UTC()
+		batch, err := d.store.Claim(ctx, now, lease, min(d.cfg.BatchSize, room), busy)
+		if err != nil {
+			if ctx.Err() == nil {
+				d.logger.Error("Failed to claim webhook deliveries", "error", err)
+			}
+			return claimed
+		}
+		if len(batch) == 0 {
+			return claimed
+		}
+		claimed += len(batch)
+
+		// A delivery that waits for its host must start while its claim
+		// still covers a whole request.
+		startBy := now.Add(lease - d.cfg.Timeout)
+		endpoints := make(map[string]*Endpoint)
+		for _, delivery := range batch {
+			endpoint, ok := endpoints[delivery.EndpointID]
+			if !ok {
+				endpoint = d.endpoint(ctx, delivery.EndpointID)
+				endpoints[delivery.EndpointID] = endpoint
+			}
+			if endpoint == nil {
+				continue
+			}
+			if next, ok := d.pool.add(pending{delivery: delivery, endpoint: endpoint, startBy: startBy}); ok {
+				d.start(ctx, next)
+			}
+		}
+
+		select {
+		case <-d.stop:
+			return claimed
+		default:
+		}
+	}
+}
+
+// endpoint loads a delivery's endpoint, or returns nil when it cannot be
+// loaded; the delivery is then left for its claim to expire.
+func (d *Dispatcher) endpoint(ctx context.Context, id string) *Endpoint {
+	endpoint, err := d.store.Endpoint(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil // Deleted together with its deliveries.
+	}
+	if err != nil {
+		d.logger.Error("Failed to load webhook endpoint", "endpoint_id", id, "error", err)
+		return nil
+	}
+	return endpoint
+}
+
+// start attempts p in the background and, when the attempt ends, starts the
+// next delivery waiting for the same host.
+func (d *Dispatcher) start(ctx context.Context, p pending) {
+	d.attempts.Add(1)
+	go func() {
+		defer d.attempts.Done()
+		for {
+			d.attempt(ctx, p.endpoint, p.delivery)
+			next, ok := d.pool.done(p.host, d.now().UTC(), d.stopped())
+			d.notify()
+			if !ok {
+				return
+			}
+			p = next
+		}
+	}()
+}
+
+func (d *Dispatcher) stopped() bool {
+	select {
+	case <-d.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, endpoint *Endpoint, delivery Delivery) {
+	start := d.now()
+	delivery.Attempts++
+	code, err := 0, errEndpointDisabled
+	if endpoint.Active {
+		breaker := d.cfg.Breakers.Get("webhook:" + hostOf(endpoint.URL))
+		generation, breakerErr := breaker.Allow()
+		if breakerErr != nil {
+			// The host keeps failing; the claim expires and the attempt is
+			// not counted.
+			d.trip(endpoint.ID, breaker)
+			return
+		}
+		code, err = d.post(ctx, endpoint, delivery)
+		breaker.Done(generation, err != nil && ctx.Err() == nil && (code == 0 || code == http.StatusTooManyRequests || code >= 500))
+	}
+	if err != nil && ctx.Err() != nil {
+		// Interrupted by Stop; the claim expires and the attempt is not counted.
+		return
+	}
+
+	now := d.now().UTC()
+	log := Attempt{
+		Attempt:     delivery.Attempts,
+		StatusCode:  code,
+		DurationMs:  now.Sub(start).Milliseconds(),
+		AttemptedAt: start.UTC(),
+	}
+	delivery.LastStatusCode = code
+	switch {
+	case err == nil:
+		delivery.Status = StatusSucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case !endpoint.Active || delivery.Attempts >= d.cfg.MaxAttempts:
+		log.Error = err.Error()
+		delivery.Status = StatusFailed
+		delivery.LastError = log.Error
+		delivery.NextAttemptAt = nil
+		d.logger.Warn("Webhook delivery failed; replay it from the admin API",
+			"delivery_id", delivery.ID, "endpoint_id", endpoint.ID, "type", delivery.EventType, "attempts", delivery.Attempts, "error", err)
+	default:
+		log.Error = err.Error()
+		delivery.LastError = log.Error
+		next := now.Add(d.cfg.Backoff.Delay(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := d.store.Record(ctx, &delivery, log); err != nil && !errors.Is(err, ErrNotFound) {
+		d.logger.Error("Failed to record webhook attempt", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+func (d *Dispatcher) trip(endpointID string, breaker *circuitbreaker.Breaker) {
+	d.trippedMu.Lock()
+	defer d.trippedMu.Unlock()
+	d.tripped[endpointID] = breaker
+}
+
+// trippedEndpoints returns the endpoints whose host's breaker is still
+// open, and forgets the others.
+func (d *Dispatcher) trippedEndpoints() []string {
+	d.trippedMu.Lock()
+	defer d.trippedMu.Unlock()
+	var ids []string
+	for id, breaker := range d.tripped {
+		if breaker.State() == circuitbreaker.StateOpen {
+			ids = append(ids, id)
+		} else {
+			delete(d.tripped, id)
+		}
+	}
+	return ids
+}
+
+func (d *Dispatcher) post(ctx context.Context, endpoint *Endpoint, delivery Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", delivery.EventID)
+	req.Header.Set("X-Event-Type", delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(AttemptHeader, strconv.Itoa(delivery.Attempts))
+	req.Header.Set(events.SignatureHeader, events.Sign([]byte(endpoint.Secret), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pending is a claimed delivery to be attempted.
+type pending struct {
+	delivery Delivery
+	endpoint *Endpoint
+	host     string
+	// startBy is when the delivery's claim stops covering a whole request;
+	// one still waiting for its host then is left for the claim to expire.
+	startBy time.Time
+}
+
+// pool bounds the deliveries a dispatcher holds: at most maxInFlight
+// claimed, in flight or waiting for their host, and at most perHost in
+// flight to one host. Waiting deliveries of a host start in claim order as
+// its requests end.
+type pool struct {
+	maxInFlight int
+	perHost     int
+
+	mu      sync.Mutex
+	claimed int
+	hosts   map[string]*hostSlots
+}
+
+type hostSlots struct {
+	inFlight int
+	waiting  []pending
+	// endpoints are those seen at the host, which are not claimed for
+	// while it is busy.
+	endpoints map[string]struct{}
+}
+
+func newPool(maxInFlight, perHost int) pool {
+	return pool{maxInFlight: maxInFlight, perHost: perHost, hosts: make(map[string]*hostSlots)}
+}
+
+// room returns how many more deliveries may be claimed, and the endpoints
+// whose host has no free slot, which are better left to other instances.
+func (p *pool) room() (int, []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var busy []string
+	for _, h := range p.hosts {
+		if h.inFlight >= p.perHost || len(h.waiting) > 0 {
+			for id := range h.endpoints {
+				busy = append(busy, id)
+			}
+		}
+	}
+	return max(p.maxInFlight-p.claimed, 0), busy
+}
+
+// add holds a claimed delivery. It returns the delivery, with its host set,
+// when the host has a free slot and the delivery should start now.
+func (p *pool) add(next pending) (pending, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	next.host = hostOf(next.endpoint.URL)
+	h := p.hosts[next.host]
+	if h == nil {
+		h = &hostSlots{endpoints: make(map[string]struct{})}
+		p.hosts[next.host] = h
+	}
+	h.endpoints[next.endpoint.ID] = struct{}{}
+	p.claimed++
+	if h.inFlight < p.perHost {
+		h.inFlight++
+		return next, true
+	}
+	h.waiting = append(h.waiting, next)
+	return pending{}, false
+}
+
+// done ends a request to host at now. It returns the next delivery waiting
+// for host, which takes over the slot, unless none may start.
+func (p *pool) done(host string, now time.Time, stopped bool) (pending, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.claimed--
+	h := p.hosts[host]
+	for len(h.waiting) > 0 {
+		next := h.waiting[0]
+		h.waiting[0] = pending{}
+		h.waiting = h.waiting[1:]
+		if !stopped && now.Before(next.startBy) {
+			return next, true
+		}
+		p.claimed--
+	}
+	h.inFlight--
+	if h.inFlight == 0 {
+		delete(p.hosts, host)
+	}
+	return pending{}, false
+}
+
+// hostOf returns the host and port requests to rawURL connect to.
+func hostOf(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return strings.ToLower(u.Host)
+	}
+	return rawURL
+}
@@ -0,0 +1,803 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/fieldcrypt"
+	"github.com/akeren/go-api-foundry/pkg/region"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store persists endpoints, deliveries and attempts. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// CreateEndpoint validates and saves a new endpoint, generating its
+	// secret when none is set.
+	CreateEndpoint(ctx context.Context, e *Endpoint) error
+	// UpdateEndpoint saves the URL, event types, description and active and
+	// ordered flags.
+	UpdateEndpoint(ctx context.Context, e *Endpoint) error
+	// DeleteEndpoint removes the endpoint with its deliveries.
+	DeleteEndpoint(ctx context.Context, id string) error
+	Endpoint(ctx context.Context, id string) (*Endpoint, error)
+	Endpoints(ctx context.Context) ([]Endpoint, error)
+
+	// Enqueue adds pending deliveries, due at now. A delivery of an event the
+	// endpoint already has is skipped, so republished events are sent once.
+	// A zero OccurredAt is set to now.
+	Enqueue(ctx context.Context, deliveries []Delivery, now time.Time) error
+	// Claim returns up to limit pending deliveries due by now, except those
+	// of the endpoints in skip, and moves their next attempt lease later, so
+	// other instances skip them meanwhile. A delivery to an Ordered endpoint
+	// is only due once no delivery of an earlier event is pending, so one
+	// that failed for good does not hold back the rest. Deliveries sort by
+	// OccurredAt, then ID.
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int, skip []string) ([]Delivery, error)
+	// Record saves an attempt and the delivery's resulting state.
+	Record(ctx context.Context, d *Delivery, attempt Attempt) error
+	// Delivery returns a delivery with its payload and attempt log.
+	Delivery(ctx context.Context, id string) (*Delivery, error)
+	// Deliveries returns a page of deliveries without payloads, and the total
+	// matching filter.
+	Deliveries(ctx context.Context, filter DeliveryFilter) ([]Delivery, int64, error)
+	// Replay makes a failed delivery pending again, due at now with a fresh
+	// set of attempts. Its attempt log is kept.
+	Replay(ctx context.Context, id string, now time.Time) error
+	// ReplayFailed replays every failed delivery of endpointID, or of all
+	// endpoints when endpointID is empty, and returns how many it replayed.
+	ReplayFailed(ctx context.Context, endpointID string, now time.Time) (int64, error)
+	// CountRange returns how many deliveries r selects.
+	CountRange(ctx context.Context, r ReplayRange) (int64, error)
+	// ReplayRange takes up to limit deliveries r selects after cursor, and
+	// makes those not already pending due at now with a fresh set of
+	// attempts, whatever their state. Attempt logs are kept.
+	ReplayRange(ctx context.Context, r ReplayRange, cursor ReplayCursor, limit int, now time.Time) (ReplayBatch, error)
+}
+
+// newSecret returns a random signing secret.
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func prepareEndpoint(e *Endpoint) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	if e.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return err
+		}
+		e.Secret = secret
+	}
+	return nil
+}
+
+// MemoryStore keeps webhooks in process memory, for tests and single-instance
+// deployments.
+type MemoryStore struct {
+	mu         sync.Mutex
+	endpoints  map[string]Endpoint
+	deliveries map[string]Delivery
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		endpoints:  make(map[string]Endpoint),
+		deliveries: make(map[string]Delivery),
+	}
+}
+
+func (s *MemoryStore) CreateEndpoint(_ context.Context, e *Endpoint) error {
+	if err := prepareEndpoint(e); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.ID = region.NewID()
+	e.CreatedAt = time.Now().UTC()
+	e.UpdatedAt = e.CreatedAt
+	s.endpoints[e.ID] = copyEndpoint(*e)
+	return nil
+}
+
+func (s *MemoryStore) UpdateEndpoint(_ context.Context, e *Endpoint) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.endpoints[e.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.URL = e.URL
+	stored.EventTypes = slices.Clone(e.EventTypes)
+	stored.Description = e.Description
+	stored.Active = e.Active
+	stored.Ordered = e.Ordered
+	stored.UpdatedAt = time.Now().UTC()
+	s.endpoints[e.ID] = stored
+	e.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (s *MemoryStore) DeleteEndpoint(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.endpoints[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.endpoints, id)
+	for deliveryID, d := range s.deliveries {
+		if d.EndpointID == id {
+			delete(s.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Endpoint(_ context.Context, id string) (*Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.endpoints[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	e = copyEndpoint(e)
+	return &e, nil
+}
+
+func (s *MemoryStore) Endpoints(_ context.Context) ([]Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Endpoint, 0, len(s.endpoints))
+	for _, e := range s.endpoints {
+		out = append(out, copyEndpoint(e))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *MemoryStore) Enqueue(_ context.Context, deliveries []Delivery, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range deliveries {
+		if s.hasDelivery(d.EndpointID, d.EventID) {
+			continue
+		}
+		d.ID = region.NewID()
+		d.Status = StatusPending
+		d.Attempts = 0
+		if d.OccurredAt.IsZero() {
+			d.OccurredAt = now
+		}
+		d.NextAttemptAt = &now
+		d.CreatedAt = now
+		d.UpdatedAt = now
+		d.Payload = slices.Clone(d.Payload)
+		d.Log = nil
+		s.deliveries[d.ID] = d
+	}
+	return nil
+}
+
+func (s *MemoryStore) hasDelivery(endpointID, eventID string) bool {
+	for _, d := range s.deliveries {
+		if d.EndpointID == endpointID && d.EventID == eventID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) Claim(_ context.Context, now time.Time, lease time.Duration, limit int, skip []string) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []Delivery
+	for _, d := range s.deliveries {
+		if d.Status == StatusPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) && !slices.Contains(skip, d.EndpointID) &&
+			(!s.endpoints[d.EndpointID].Ordered || !s.hasEarlierPending(d)) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	leased := now.Add(lease)
+	out := make([]Delivery, 0, len(due))
+	for _, d := range due {
+		d.NextAttemptAt = &leased
+		s.deliveries[d.ID] = d
+		out = append(out, copyDelivery(d, false))
+	}
+	return out, nil
+}
+
+// hasEarlierPending reports whether a delivery of an event before d's to the
+// same endpoint is still pending. The caller holds s.mu.
+func (s *MemoryStore) hasEarlierPending(d Delivery) bool {
+	for _, other := range s.deliveries {
+		if other.EndpointID == d.EndpointID && other.Status == StatusPending && deliveredBefore(other, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliveredBefore reports whether a sorts before b in an Ordered endpoint's
+// queue.
+func deliveredBefore(a, b Delivery) bool {
+	if !a.OccurredAt.Equal(b.OccurredAt) {
+		return a.OccurredAt.Before(b.OccurredAt)
+	}
+	return a.ID < b.ID
+}
+
+func (s *MemoryStore) Record(_ context.Context, d *Delivery, attempt Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.deliveries[d.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Status = d.Status
+	stored.Attempts = d.Attempts
+	if d.NextAttemptAt != nil {
+		stored.NextAttemptAt = d.NextAttemptAt
+	}
+	stored.LastError = d.LastError
+	stored.LastStatusCode = d.LastStatusCode
+	stored.DeliveredAt = d.DeliveredAt
+	stored.UpdatedAt = time.Now().UTC()
+	stored.Log = append(stored.Log, attempt)
+	s.deliveries[d.ID] = stored
+	return nil
+}
+
+func (s *MemoryStore) Delivery(_ context.Context, id string) (*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	d = copyDelivery(d, true)
+	return &d, nil
+}
+
+func (s *MemoryStore) Deliveries(_ context.Context, filter DeliveryFilter) ([]Delivery, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []Delivery
+	for _, d := range s.deliveries {
+		if (filter.EndpointID == "" || d.EndpointID == filter.EndpointID) && (filter.Status == "" || d.Status == filter.Status) {
+			d = copyDelivery(d, false)
+			d.Payload = nil
+			matched = append(matched, d)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+
+	total := int64(len(matched))
+	start := min(max(filter.Offset, 0), len(matched))
+	end := len(matched)
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, end)
+	}
+	return matched[start:end], total, nil
+}
+
+func (s *MemoryStore) Replay(_ context.Context, id string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deliveries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if d.Status != StatusFailed {
+		return ErrNotFailed
+	}
+	s.deliveries[id] = replayed(d, now)
+	return nil
+}
+
+func (s *MemoryStore) ReplayFailed(_ context.Context, endpointID string, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, d := range s.deliveries {
+		if d.Status == StatusFailed && (endpointID == "" || d.EndpointID == endpointID) {
+			s.deliveries[id] = replayed(d, now)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) CountRange(_ context.Context, r ReplayRange) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, d := range s.deliveries {
+		if inRange(d, r) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) ReplayRange(_ context.Context, r ReplayRange, cursor ReplayCursor, limit int, now time.Time) (ReplayBatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	after := Delivery{OccurredAt: cursor.OccurredAt, ID: cursor.ID}
+	var matched []Delivery
+	for _, d := range s.deliveries {
+		if inRange(d, r) && (cursor.ID == "" || deliveredBefore(after, d)) {
+			matched = append(matched, d)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return deliveredBefore(matched[i], matched[j]) })
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+
+	var batch ReplayBatch
+	for _, d := range matched {
+		if d.Status != StatusPending {
+			s.deliveries[d.ID] = replayed(d, now)
+			batch.Replayed++
+		}
+	}
+	batch.Taken = len(matched)
+	if batch.Taken > 0 {
+		last := matched[batch.Taken-1]
+		batch.Next = ReplayCursor{OccurredAt: last.OccurredAt, ID: last.ID}
+	}
+	return batch, nil
+}
+
+func inRange(d Delivery, r ReplayRange) bool {
+	return d.EndpointID == r.EndpointID && !d.OccurredAt.Before(r.From) && d.OccurredAt.Before(r.To)
+}
+
+func replayed(d Delivery, now time.Time) Delivery {
+	d.Status = StatusPending
+	d.Attempts = 0
+	d.NextAttemptAt = &now
+	d.UpdatedAt = now
+	return d
+}
+
+func copyEndpoint(e Endpoint) Endpoint {
+	e.EventTypes = slices.Clone(e.EventTypes)
+	return e
+}
+
+func copyDelivery(d Delivery, withLog bool) Delivery {
+	if d.Status != StatusPending {
+		d.NextAttemptAt = nil
+	}
+	d.Payload = slices.Clone(d.Payload)
+	if withLog {
+		d.Log = slices.Clone(d.Log)
+	} else {
+		d.Log = nil
+	}
+	return d
+}
+
+// secretAAD binds encrypted endpoint secrets to their column.
+const secretAAD = "webhook_endpoints.secret"
+
+// GormStore persists webhooks in the webhook_endpoints, webhook_deliveries
+// and webhook_attempts tables so every instance shares the queue. Endpoint
+// secrets are encrypted when a cipher is configured.
+type GormStore struct {
+	db     *gorm.DB
+	cipher *fieldcrypt.Cipher
+}
+
+func NewGormStore(db *gorm.DB, cipher *fieldcrypt.Cipher) *GormStore {
+	return &GormStore{db: db, cipher: cipher}
+}
+
+func (s *GormStore) CreateEndpoint(ctx context.Context, e *Endpoint) error {
+	if err := prepareEndpoint(e); err != nil {
+		return err
+	}
+	secret, err := s.cipher.Encrypt(e.Secret, secretAAD)
+	if err != nil {
+		return err
+	}
+	record := &models.WebhookEndpoint{
+		URL:         e.URL,
+		Secret:      secret,
+		EventTypes:  strings.Join(e.EventTypes, ","),
+		Description: e.Description,
+		Active:      e.Active,
+		Ordered:     e.Ordered,
+	}
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		return err
+	}
+	e.ID = record.ID
+	e.CreatedAt = record.CreatedAt
+	e.UpdatedAt = record.UpdatedAt
+	return nil
+}
+
+func (s *GormStore) UpdateEndpoint(ctx context.Context, e *Endpoint) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	e.UpdatedAt = time.Now().UTC()
+	res := s.db.WithContext(ctx).Model(&models.WebhookEndpoint{}).Where("id = ?", e.ID).Updates(map[string]any{
+		"url":         e.URL,
+		"event_types": strings.Join(e.EventTypes, ","),
+		"description": e.Description,
+		"active":      e.Active,
+		"ordered":     e.Ordered,
+		"updated_at":  e.UpdatedAt,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *GormStore) DeleteEndpoint(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deliveries := tx.Model(&models.WebhookDelivery{}).Select("id").Where("endpoint_id = ?", id)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&models.WebhookAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("endpoint_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		res := tx.Where("id = ?", id).Delete(&models.WebhookEndpoint{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *GormStore) Endpoint(ctx context.Context, id string) (*Endpoint, error) {
+	var record models.WebhookEndpoint
+	if err := s.db.WithContext(ctx).First(&record, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return s.endpointFromModel(&record)
+}
+
+func (s *GormStore) Endpoints(ctx context.Context) ([]Endpoint, error) {
+	var records []models.WebhookEndpoint
+	if err := s.db.WithContext(ctx).Order("created_at, id").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	out := make([]Endpoint, 0, len(records))
+	for i := range records {
+		e, err := s.endpointFromModel(&records[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, nil
+}
+
+func (s *GormStore) endpointFromModel(record *models.WebhookEndpoint) (*Endpoint, error) {
+	secret, err := s.cipher.Decrypt(record.Secret, secretAAD)
+	if err != nil {
+		return nil, err
+	}
+	e := &Endpoint{
+		ID:          record.ID,
+		URL:         record.URL,
+		Secret:      secret,
+		Description: record.Description,
+		Active:      record.Active,
+		Ordered:     record.Ordered,
+		CreatedAt:   record.CreatedAt,
+		UpdatedAt:   record.UpdatedAt,
+	}
+	if record.EventTypes != "" {
+		e.EventTypes = strings.Split(record.EventTypes, ",")
+	}
+	return e, nil
+}
+
+func (s *GormStore) Enqueue(ctx context.Context, deliveries []Delivery, now time.Time) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	records := make([]models.WebhookDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		occurredAt := d.OccurredAt
+		if occurredAt.IsZero() {
+			occurredAt = now
+		}
+		records = append(records, models.WebhookDelivery{
+			EndpointID:    d.EndpointID,
+			EventID:       d.EventID,
+			EventType:     d.EventType,
+			OccurredAt:    occurredAt.UTC(),
+			Payload:       string(d.Payload),
+			Status:        string(StatusPending),
+			NextAttemptAt: now.UTC(),
+		})
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "endpoint_id"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).Create(&records).Error
+}
+
+func (s *GormStore) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int, skip []string) ([]Delivery, error) {
+	query := s.db.WithContext(ctx).Where("status = ? AND next_attempt_at <= ?", string(StatusPending), now)
+	if len(skip) > 0 {
+		query = query.Where("endpoint_id NOT IN ?", skip)
+	}
+	query = query.Where(`NOT EXISTS (
+		SELECT 1 FROM webhook_endpoints e JOIN webhook_deliveries p ON p.endpoint_id = e.id
+		WHERE e.id = webhook_deliveries.endpoint_id AND e.ordered AND p.status = ?
+		AND (p.occurred_at < webhook_deliveries.occurred_at OR (p.occurred_at = webhook_deliveries.occurred_at AND p.id < webhook_deliveries.id)))`,
+		string(StatusPending))
+	var due []models.WebhookDelivery
+	err := query.
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Another instance may claim the same rows between the query and the
+	// update; only the update that still finds the row due wins it.
+	leased := now.Add(lease)
+	out := make([]Delivery, 0, len(due))
+	for i := range due {
+		res := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", due[i].ID, string(StatusPending), now).
+			Update("next_attempt_at", leased)
+		if res.Error != nil {
+			return out, res.Error
+		}
+		if res.RowsAffected == 1 {
+			due[i].NextAttemptAt = leased
+			out = append(out, deliveryFromModel(&due[i]))
+		}
+	}
+	return out, nil
+}
+
+func (s *GormStore) Record(ctx context.Context, d *Delivery, attempt Attempt) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]any{
+			"status":           string(d.Status),
+			"attempts":         d.Attempts,
+			"last_error":       d.LastError,
+			"last_status_code": d.LastStatusCode,
+			"delivered_at":     d.DeliveredAt,
+			"updated_at":       time.Now().UTC(),
+		}
+		if d.NextAttemptAt != nil {
+			updates["next_attempt_at"] = d.NextAttemptAt.UTC()
+		}
+		res := tx.Model(&models.WebhookDelivery{}).Where("id = ?", d.ID).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Create(&models.WebhookAttempt{
+			DeliveryID:  d.ID,
+			Attempt:     attempt.Attempt,
+			StatusCode:  attempt.StatusCode,
+			Error:       attempt.Error,
+			DurationMs:  attempt.DurationMs,
+			AttemptedAt: attempt.AttemptedAt.UTC(),
+		}).Error
+	})
+}
+
+func (s *GormStore) Delivery(ctx context.Context, id string) (*Delivery, error) {
+	var record models.WebhookDelivery
+	if err := s.db.WithContext(ctx).First(&record, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var attempts []models.WebhookAttempt
+	if err := s.db.WithContext(ctx).Where("delivery_id = ?", id).Order("attempted_at, attempt").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	d := deliveryFromModel(&record)
+	for _, a := range attempts {
+		d.Log = append(d.Log, Attempt{
+			Attempt:     a.Attempt,
+			StatusCode:  a.StatusCode,
+			Error:       a.Error,
+			DurationMs:  a.DurationMs,
+			AttemptedAt: a.AttemptedAt,
+		})
+	}
+	return &d, nil
+}
+
+func (s *GormStore) Deliveries(ctx context.Context, filter DeliveryFilter) ([]Delivery, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.WebhookDelivery{})
+	if filter.EndpointID != "" {
+		query = query.Where("endpoint_id = ?", filter.EndpointID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", string(filter.Status))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var records []models.WebhookDelivery
+	page := query.Omit("payload").Order("created_at DESC, id DESC").Offset(max(filter.Offset, 0))
+	if filter.Limit > 0 {
+		page = page.Limit(filter.Limit)
+	}
+	if err := page.Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+	out := make([]Delivery, 0, len(records))
+	for i := range records {
+		out = append(out, deliveryFromModel(&records[i]))
+	}
+	return out, total, nil
+}
+
+func (s *GormStore) Replay(ctx context.Context, id string, now time.Time) error {
+	res := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ?", id, string(StatusFailed)).
+		Updates(replayUpdates(now))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrNotFailed
+}
+
+func (s *GormStore) ReplayFailed(ctx context.Context, endpointID string, now time.Time) (int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("status = ?", string(StatusFailed))
+	if endpointID != "" {
+		query = query.Where("endpoint_id = ?", endpointID)
+	}
+	res := query.Updates(replayUpdates(now))
+	return res.RowsAffected, res.Error
+}
+
+func (s *GormStore) CountRange(ctx context.Context, r ReplayRange) (int64, error) {
+	var n int64
+	err := s.rangeQuery(ctx, r).Count(&n).Error
+	return n, err
+}
+
+func (s *GormStore) ReplayRange(ctx context.Context, r ReplayRange, cursor ReplayCursor, limit int, now time.Time) (ReplayBatch, error) {
+	query := s.rangeQuery(ctx, r)
+	if cursor.ID != "" {
+		at := cursor.OccurredAt.UTC()
+		query = query.Where("(occurred_at > ? OR (occurred_at = ? AND id > ?))", at, at, cursor.ID)
+	}
+	query = query.Select("id", "occurred_at", "status").Order("occurred_at, id")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var records []models.WebhookDelivery
+	if err := query.Find(&records).Error; err != nil {
+		return ReplayBatch{}, err
+	}
+
+	batch := ReplayBatch{Taken: len(records)}
+	if batch.Taken == 0 {
+		return batch, nil
+	}
+	last := records[batch.Taken-1]
+	batch.Next = ReplayCursor{OccurredAt: last.OccurredAt, ID: last.ID}
+
+	var ids []string
+	for _, record := range records {
+		if record.Status != string(StatusPending) {
+			ids = append(ids, record.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return batch, nil
+	}
+	// A delivery claimed and sent since the select is left as it is now.
+	res := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id IN ? AND status <> ?", ids, string(StatusPending)).
+		Updates(replayUpdates(now))
+	batch.Replayed = res.RowsAffected
+	return batch, res.Error
+}
+
+func (s *GormStore) rangeQuery(ctx context.Context, r ReplayRange) *gorm.DB {
+	return s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("endpoint_id = ? AND occurred_at >= ? AND occurred_at < ?", r.EndpointID, r.From.UTC(), r.To.UTC())
+}
+
+func replayUpdates(now time.Time) map[string]any {
+	return map[string]any{
+		"status":          string(StatusPending),
+		"attempts":        0,
+		"next_attempt_at": now.UTC(),
+		"updated_at":      now.UTC(),
+	}
+}
+
+func deliveryFromModel(record *models.WebhookDelivery) Delivery {
+	d := Delivery{
+		ID:             record.ID,
+		EndpointID:     record.EndpointID,
+		EventID:        record.EventID,
+		EventType:      record.EventType,
+		OccurredAt:     record.OccurredAt,
+		Status:         Status(record.Status),
+		Attempts:       record.Attempts,
+		LastError:      record.LastError,
+		LastStatusCode: record.LastStatusCode,
+		DeliveredAt:    record.DeliveredAt,
+		CreatedAt:      record.CreatedAt,
+		UpdatedAt:      record.UpdatedAt,
+	}
+	if record.Payload != "" {
+		d.Payload = []byte(record.Payload)
+	}
+	if d.Status == StatusPending {
+		next := record.NextAttemptAt
+		d.NextAttemptAt = &next
+	}
+	return d
+}
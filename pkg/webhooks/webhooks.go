@@ -0,0 +1,143 @@
+// Package webhooks delivers events to HTTP endpoints registered at runtime.
+// Every event an endpoint subscribes to becomes a persisted delivery that is
+// signed, retried with exponential backoff and kept, with each attempt, so
+// operators can inspect failures and replay them.
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/akeren/go-api-foundry/internal/models"
+	"github.com/akeren/go-api-foundry/pkg/events"
+)
+
+var (
+	ErrNotFound        = errors.New("webhook not found")
+	ErrInvalidEndpoint = errors.New("webhook endpoint requires an http or https URL and at least one event type")
+	// ErrNotFailed is returned when replaying a delivery that has not failed.
+	ErrNotFailed = errors.New("only failed deliveries can be replayed")
+)
+
+type Status string
+
+const (
+	StatusPending   Status = models.WebhookDeliveryPending
+	StatusSucceeded Status = models.WebhookDeliverySucceeded
+	StatusFailed    Status = models.WebhookDeliveryFailed
+)
+
+// Endpoint is a registered receiver. EventTypes holds patterns matched with
+// events.Matches, such as "transaction.posted" or "account.*". Secret is only
+// returned to the operator who creates the endpoint.
+//
+// An Ordered endpoint receives its deliveries one at a time, in the order
+// their events occurred: a delivery is only sent once no earlier one is
+// pending, so a retrying delivery holds back the rest. One that runs out of
+// attempts fails and lets the rest go; replaying it sends it out of order.
+// Other endpoints receive deliveries in parallel, in no particular order.
+type Endpoint struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"`
+	EventTypes  []string  `json:"event_types"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	Ordered     bool      `json:"ordered"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Validate trims the endpoint's fields and checks they are complete.
+func (e *Endpoint) Validate() error {
+	e.URL = strings.TrimSpace(e.URL)
+	e.Description = strings.TrimSpace(e.Description)
+	types := e.EventTypes[:0]
+	for _, t := range e.EventTypes {
+		if t = strings.TrimSpace(t); t != "" && !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	e.EventTypes = types
+
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(e.EventTypes) == 0 {
+		return ErrInvalidEndpoint
+	}
+	return nil
+}
+
+// Subscribed reports whether the endpoint receives events of eventType.
+func (e *Endpoint) Subscribed(eventType string) bool {
+	return e.Active && slices.ContainsFunc(e.EventTypes, func(p string) bool { return events.Matches(p, eventType) })
+}
+
+// Delivery is one event queued for one endpoint. OccurredAt is the event's,
+// which orders the deliveries of Ordered endpoints. Attempts counts the
+// attempts since the delivery was created or last replayed; Log lists every
+// attempt and is only filled in by Store.Delivery.
+type Delivery struct {
+	ID             string          `json:"id"`
+	EndpointID     string          `json:"endpoint_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	Status         Status          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Log            []Attempt       `json:"attempt_log,omitempty"`
+}
+
+// Attempt is one HTTP request made for a delivery. StatusCode is zero when no
+// response was received.
+type Attempt struct {
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// DeliveryFilter selects deliveries, newest first. Empty fields match all.
+type DeliveryFilter struct {
+	EndpointID string
+	Status     Status
+	Offset     int
+	Limit      int
+}
+
+// ReplayRange selects an endpoint's deliveries of the events that occurred
+// in [From, To), oldest event first.
+type ReplayRange struct {
+	EndpointID string
+	From       time.Time
+	To         time.Time
+}
+
+// ReplayCursor is the last delivery a range replay has passed: the zero
+// cursor is before the first.
+type ReplayCursor struct {
+	OccurredAt time.Time
+	ID         string
+}
+
+// ReplayBatch is one step of a range replay.
+type ReplayBatch struct {
+	// Taken is how many deliveries the step passed; zero ends the range.
+	Taken int
+	// Replayed is how many of them were made pending again. The others were
+	// still pending.
+	Replayed int64
+	// Next is the cursor to pass to the next step.
+	Next ReplayCursor
+}
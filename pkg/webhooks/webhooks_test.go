@@ -0,0 +1,502 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akeren/go-api-foundry/pkg/circuitbreaker"
+	"github.com/akeren/go-api-foundry/pkg/events"
+	"github.com/akeren/go-api-foundry/pkg/retry"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+func createEndpoint(t *testing.T, store Store, url string, active bool, types ...string) *Endpoint {
+	t.Helper()
+	e := &Endpoint{URL: url, EventTypes: types, Active: active}
+	if err := store.CreateEndpoint(context.Background(), e); err != nil {
+		t.Fatalf("create endpoint: %v", err)
+	}
+	return e
+}
+
+// dispatchAndWait claims due deliveries and waits for their attempts.
+func dispatchAndWait(d *Dispatcher) int {
+	n := d.dispatch(context.Background())
+	d.attempts.Wait()
+	return n
+}
+
+// drain dispatches until no delivery is due, failing the test if deliveries
+// keep coming.
+func drain(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	for range 100 {
+		if dispatchAndWait(d) == 0 {
+			return
+		}
+	}
+	t.Fatal("expected the queue to drain within 100 dispatches")
+}
+
+func testEvent(id string) events.Event {
+	return events.Event{ID: id, Type: "transaction.posted", OccurredAt: time.Now().UTC(), Data: map[string]string{"reference": "ref-1"}}
+}
+
+func TestEndpoint_Validate(t *testing.T) {
+	for _, e := range []Endpoint{
+		{URL: "ftp://example.com", EventTypes: []string{"*"}},
+		{URL: "https://", EventTypes: []string{"*"}},
+		{URL: "https://example.com", EventTypes: []string{" ", ""}},
+	} {
+		if err := e.Validate(); err != ErrInvalidEndpoint {
+			t.Fatalf("expected %+v to be invalid, got %v", e, err)
+		}
+	}
+
+	e := Endpoint{URL: " https://example.com/hooks ", EventTypes: []string{"transaction.*", " transaction.* ", "account.created"}}
+	if err := e.Validate(); err != nil || e.URL != "https://example.com/hooks" || len(e.EventTypes) != 2 {
+		t.Fatalf("expected a trimmed, deduplicated endpoint, got %+v (%v)", e, err)
+	}
+}
+
+func TestHandle_QueuesSubscribedEndpointsOnce(t *testing.T) {
+	store := NewMemoryStore()
+	subscribed := createEndpoint(t, store, "https://a.example.com", true, "transaction.*")
+	createEndpoint(t, store, "https://b.example.com", true, "account.*")
+	createEndpoint(t, store, "https://c.example.com", false, "transaction.posted")
+	if subscribed.Secret == "" {
+		t.Fatal("expected a generated secret")
+	}
+
+	d := NewDispatcher(store, nopLogger{}, Config{})
+	d.Handle(context.Background(), testEvent("evt-1"))
+	d.Handle(context.Background(), testEvent("evt-1")) // Republished, e.g. by an idempotent replay.
+
+	list, total, err := store.Deliveries(context.Background(), DeliveryFilter{})
+	if err != nil || total != 1 || list[0].EndpointID != subscribed.ID || list[0].Status != StatusPending {
+		t.Fatalf("expected one pending delivery to the subscribed endpoint, got %+v (%v)", list, err)
+	}
+}
+
+func TestDispatch_SignsDeliveries(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	endpoint := createEndpoint(t, store, server.URL, true, "*")
+	d := NewDispatcher(store, nopLogger{}, Config{})
+	d.Handle(context.Background(), testEvent("evt-1"))
+
+	if n := dispatchAndWait(d); n != 1 {
+		t.Fatalf("expected one attempt, got %d", n)
+	}
+	if sig := got.Header.Get(events.SignatureHeader); sig != events.Sign([]byte(endpoint.Secret), body) {
+		t.Fatalf("unexpected signature %q", sig)
+	}
+	if got.Header.Get("X-Event-ID") != "evt-1" || got.Header.Get(AttemptHeader) != "1" || got.Header.Get(DeliveryHeader) == "" {
+		t.Fatalf("missing delivery headers: %v", got.Header)
+	}
+
+	delivery, err := store.Delivery(context.Background(), got.Header.Get(DeliveryHeader))
+	if err != nil || delivery.Status != StatusSucceeded || delivery.DeliveredAt == nil || len(delivery.Log) != 1 || delivery.Log[0].StatusCode != http.StatusOK {
+		t.Fatalf("expected a succeeded delivery with one attempt, got %+v (%v)", delivery, err)
+	}
+}
+
+func TestDispatch_BacksOffFailsAndReplays(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	createEndpoint(t, store, server.URL, true, "*")
+	d := NewDispatcher(store, nopLogger{}, Config{
+		Backoff:     retry.Backoff{Initial: time.Minute, Max: time.Hour, Multiplier: 2},
+		MaxAttempts: 3,
+	})
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	d.Handle(context.Background(), testEvent("evt-1"))
+
+	delivery := func() Delivery {
+		list, _, _ := store.Deliveries(context.Background(), DeliveryFilter{})
+		return list[0]
+	}
+
+	for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+		if n := dispatchAndWait(d); n != 1 {
+			t.Fatalf("attempt %d: expected one attempt, got %d", attempt+1, n)
+		}
+		got := delivery()
+		if got.Status != StatusPending || got.LastStatusCode != http.StatusBadGateway || !got.NextAttemptAt.Equal(now.UTC().Add(wait)) {
+			t.Fatalf("attempt %d: expected a retry in %s, got %+v", attempt+1, wait, got)
+		}
+		if n := dispatchAndWait(d); n != 0 {
+			t.Fatalf("expected no attempt before the backoff elapses, got %d", n)
+		}
+		now = now.Add(wait)
+	}
+
+	dispatchAndWait(d)
+	failed := delivery()
+	if failed.Status != StatusFailed || failed.Attempts != 3 || failed.LastError == "" {
+		t.Fatalf("expected the delivery to fail after 3 attempts, got %+v", failed)
+	}
+
+	healthy.Store(true)
+	if err := d.Replay(context.Background(), failed.ID); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	dispatchAndWait(d)
+	replayed, _ := store.Delivery(context.Background(), failed.ID)
+	if replayed.Status != StatusSucceeded || replayed.Attempts != 1 || len(replayed.Log) != 4 {
+		t.Fatalf("expected the replay to succeed and keep the attempt log, got %+v", replayed)
+	}
+	if err := d.Replay(context.Background(), failed.ID); err != ErrNotFailed {
+		t.Fatalf("expected a succeeded delivery not to be replayed, got %v", err)
+	}
+}
+
+func TestReplayRange_RequeuesEventsInRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	endpoint := createEndpoint(t, store, server.URL, true, "*")
+	d := NewDispatcher(store, nopLogger{}, Config{})
+	base := time.Now().UTC().Add(-time.Hour)
+	for i := range 4 {
+		event := testEvent("evt-" + strconv.Itoa(i))
+		event.OccurredAt = base.Add(time.Duration(i) * time.Minute)
+		d.Handle(context.Background(), event)
+	}
+	if n := dispatchAndWait(d); n != 4 {
+		t.Fatalf("expected four attempts, got %d", n)
+	}
+	d.Handle(context.Background(), testEvent("evt-new")) // Still pending, outside the range.
+
+	var reports []ReplayProgress
+	r := ReplayRange{EndpointID: endpoint.ID, From: base.Add(time.Minute), To: base.Add(3 * time.Minute)}
+	got, err := d.ReplayRange(context.Background(), r, 100, func(p ReplayProgress) { reports = append(reports, p) })
+	want := ReplayProgress{Total: 2, Done: 2, Replayed: 2}
+	if err != nil || got != want || len(reports) != 1 || reports[0] != want {
+		t.Fatalf("expected both events in range replayed, got %+v %+v (%v)", got, reports, err)
+	}
+
+	pending, _, _ := store.Deliveries(context.Background(), DeliveryFilter{Status: StatusPending})
+	var ids []string
+	for _, p := range pending {
+		ids = append(ids, p.EventID)
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{"evt-1", "evt-2", "evt-new"}) {
+		t.Fatalf("expected evt-1 and evt-2 pending again, got %v", ids)
+	}
+
+	got, err = d.ReplayRange(context.Background(), r, 100, nil)
+	if err != nil || got != (ReplayProgress{Total: 2, Done: 2}) {
+		t.Fatalf("expected pending deliveries to be left queued, got %+v (%v)", got, err)
+	}
+	if _, err := d.ReplayRange(context.Background(), ReplayRange{EndpointID: "missing", To: time.Now()}, 100, nil); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for an unknown endpoint, got %v", err)
+	}
+}
+
+func TestDispatch_DeliversOrderedEndpointsInEventOrder(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string][]string{}
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], r.Header.Get("X-Event-ID"))
+		mu.Unlock()
+		if r.URL.Path == "/ordered" && !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	ordered := &Endpoint{URL: server.URL + "/ordered", EventTypes: []string{"*"}, Active: true, Ordered: true}
+	if err := store.CreateEndpoint(context.Background(), ordered); err != nil {
+		t.Fatal(err)
+	}
+	createEndpoint(t, store, server.URL+"/parallel", true, "*")
+	d := NewDispatcher(store, nopLogger{}, Config{
+		Backoff:     retry.Backoff{Initial: time.Minute, Max: time.Hour, Multiplier: 2},
+		MaxAttempts: 2,
+	})
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	start := now.UTC()
+	for i, id := range []string{"evt-3", "evt-1", "evt-2"} {
+		event := testEvent(id)
+		event.OccurredAt = start.Add(time.Duration([]int{3, 1, 2}[i]) * time.Second)
+		d.Handle(context.Background(), event)
+	}
+
+	if n := dispatchAndWait(d); n != 4 {
+		t.Fatalf("expected the head of the ordered queue and every parallel delivery, got %d attempts", n)
+	}
+	if n := dispatchAndWait(d); n != 0 {
+		t.Fatalf("expected a retrying delivery to hold back the ordered queue, got %d attempts", n)
+	}
+
+	// Its last attempt fails it, and the rest of the queue goes out.
+	now = now.Add(time.Minute)
+	if n := dispatchAndWait(d); n != 1 {
+		t.Fatalf("expected the retry, got %d attempts", n)
+	}
+	healthy.Store(true)
+	drain(t, d)
+	if n, err := d.ReplayFailed(context.Background(), ordered.ID); err != nil || n != 1 {
+		t.Fatalf("expected one failed delivery replayed, got %d (%v)", n, err)
+	}
+	drain(t, d)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(received["/ordered"], ","); got != "evt-1,evt-1,evt-2,evt-3,evt-1" {
+		t.Fatalf("expected the ordered endpoint to receive events in order, past the failed one, got %s", got)
+	}
+	if len(received["/parallel"]) != 3 {
+		t.Fatalf("expected each event once at the parallel endpoint, got %v", received["/parallel"])
+	}
+}
+
+func TestDispatch_SkipsHostsWithOpenBreakers(t *testing.T) {
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	createEndpoint(t, store, server.URL, true, "*")
+	breakers := circuitbreaker.NewRegistry(nopLogger{}, circuitbreaker.Config{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond})
+	d := NewDispatcher(store, nopLogger{}, Config{MaxPerHost: 1, Breakers: breakers})
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	for i := range 4 {
+		d.Handle(context.Background(), testEvent("evt-"+strconv.Itoa(i)))
+	}
+
+	dispatchAndWait(d)
+	if requests.Load() != 2 || breakers.Get("webhook:"+hostOf(server.URL)).State() != circuitbreaker.StateOpen {
+		t.Fatalf("expected the breaker to open after 2 failed requests, got %d requests", requests.Load())
+	}
+	now = now.Add(time.Hour)
+	if n := dispatchAndWait(d); n != 0 {
+		t.Fatalf("expected no deliveries claimed for a host whose breaker is open, got %d", n)
+	}
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	dispatchAndWait(d)
+	list, _, _ := store.Deliveries(context.Background(), DeliveryFilter{})
+	attempts := 0
+	for _, delivery := range list {
+		if delivery.Status != StatusSucceeded {
+			t.Fatalf("expected every delivery to succeed once the host recovered, got %+v", delivery)
+		}
+		attempts += delivery.Attempts
+	}
+	if requests.Load() != 6 || attempts != 6 {
+		t.Fatalf("expected deliveries rejected by the breaker not to count as attempts, got %d requests and %d attempts", requests.Load(), attempts)
+	}
+}
+
+func TestDispatch_FailsDisabledEndpoints(t *testing.T) {
+	store := NewMemoryStore()
+	endpoint := createEndpoint(t, store, "https://example.invalid", true, "*")
+	d := NewDispatcher(store, nopLogger{}, Config{})
+	d.Handle(context.Background(), testEvent("evt-1"))
+
+	endpoint.Active = false
+	if err := store.UpdateEndpoint(context.Background(), endpoint); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	dispatchAndWait(d)
+
+	list, _, _ := store.Deliveries(context.Background(), DeliveryFilter{Status: StatusFailed})
+	if len(list) != 1 || list[0].LastError != errEndpointDisabled.Error() {
+		t.Fatalf("expected the delivery to fail without a request, got %+v", list)
+	}
+	if n, err := d.ReplayFailed(context.Background(), endpoint.ID); err != nil || n != 1 {
+		t.Fatalf("expected one failed delivery to be replayed, got %d (%v)", n, err)
+	}
+}
+
+func TestStart_DeliversNewEvents(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("X-Event-ID")
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	createEndpoint(t, store, server.URL, true, "transaction.posted")
+	d := NewDispatcher(store, nopLogger{}, Config{PollInterval: time.Hour})
+	d.Start()
+	defer d.Stop(context.Background())
+
+	d.Handle(context.Background(), testEvent("evt-1"))
+	select {
+	case id := <-received:
+		if id != "evt-1" {
+			t.Fatalf("unexpected event %q", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the event to be delivered without waiting for the poll interval")
+	}
+}
+
+func TestStop_WithoutStart(t *testing.T) {
+	d := NewDispatcher(NewMemoryStore(), nopLogger{}, Config{})
+	d.Stop(context.Background())
+}
+
+// blockingServer holds requests until release is closed, recording how
+// many it held at once.
+func blockingServer(t *testing.T) (server *httptest.Server, release chan struct{}, peak *atomic.Int32) {
+	t.Helper()
+	release = make(chan struct{})
+	var held atomic.Int32
+	peak = new(atomic.Int32)
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := held.Add(1)
+		defer held.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	return server, release, peak
+}
+
+func TestDispatch_LimitsRequestsPerHost(t *testing.T) {
+	slow, release, peak := blockingServer(t)
+	received := make(chan string, 1)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("X-Event-ID")
+	}))
+	defer fast.Close()
+
+	store := NewMemoryStore()
+	createEndpoint(t, store, slow.URL, true, "transaction.*")
+	createEndpoint(t, store, fast.URL, true, "account.*")
+	d := NewDispatcher(store, nopLogger{}, Config{MaxPerHost: 2})
+	for i := range 5 {
+		d.Handle(context.Background(), testEvent("evt-"+strconv.Itoa(i)))
+	}
+	d.Handle(context.Background(), events.Event{ID: "evt-fast", Type: "account.created", OccurredAt: time.Now().UTC()})
+
+	if n := d.dispatch(context.Background()); n != 6 {
+		t.Fatalf("expected every delivery to be claimed, got %d", n)
+	}
+	select {
+	case id := <-received:
+		if id != "evt-fast" {
+			t.Fatalf("unexpected event %q", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the fast receiver not to wait for the slow one")
+	}
+
+	d.Handle(context.Background(), testEvent("evt-5"))
+	if n := d.dispatch(context.Background()); n != 0 {
+		t.Fatalf("expected no claims for a host at its limit, got %d", n)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); peak.Load() < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	d.attempts.Wait()
+	if got := peak.Load(); got != 2 {
+		t.Fatalf("expected at most 2 requests to the slow host at once, got %d", got)
+	}
+	succeeded, _, _ := store.Deliveries(context.Background(), DeliveryFilter{Status: StatusSucceeded})
+	if len(succeeded) != 6 {
+		t.Fatalf("expected the waiting deliveries to be sent as slots freed, got %d succeeded", len(succeeded))
+	}
+	if n := dispatchAndWait(d); n != 1 {
+		t.Fatalf("expected the skipped delivery to be claimed once the host is free, got %d", n)
+	}
+}
+
+func TestDispatch_LimitsDeliveriesInFlight(t *testing.T) {
+	server, release, _ := blockingServer(t)
+	store := NewMemoryStore()
+	createEndpoint(t, store, server.URL, true, "*")
+	d := NewDispatcher(store, nopLogger{}, Config{MaxInFlight: 3, MaxPerHost: 1})
+	for i := range 5 {
+		d.Handle(context.Background(), testEvent("evt-"+strconv.Itoa(i)))
+	}
+
+	if n := d.dispatch(context.Background()); n != 3 {
+		t.Fatalf("expected MaxInFlight deliveries to be claimed, got %d", n)
+	}
+	if n := d.dispatch(context.Background()); n != 0 {
+		t.Fatalf("expected no claims while MaxInFlight are held, got %d", n)
+	}
+	close(release)
+	d.attempts.Wait()
+	if n := dispatchAndWait(d); n != 2 {
+		t.Fatalf("expected the rest to be claimed once attempts ended, got %d", n)
+	}
+}
+
+func TestPool_LeavesExpiredClaims(t *testing.T) {
+	p := newPool(10, 1)
+	now := time.Now()
+	endpoint := &Endpoint{ID: "ep-1", URL: "https://Example.com/hooks"}
+	first, ok := p.add(pending{endpoint: endpoint, startBy: now.Add(time.Second)})
+	if !ok || first.host != "example.com" {
+		t.Fatalf("expected the first delivery to start, got %+v %v", first, ok)
+	}
+	if _, ok := p.add(pending{endpoint: endpoint, startBy: now.Add(time.Second)}); ok {
+		t.Fatal("expected the second delivery to wait for the host")
+	}
+	if room, busy := p.room(); room != 8 || len(busy) != 1 || busy[0] != "ep-1" {
+		t.Fatalf("expected 8 free and ep-1 busy, got %d %v", room, busy)
+	}
+
+	if _, ok := p.done(first.host, now.Add(2*time.Second), false); ok {
+		t.Fatal("expected a delivery past its start deadline to be left")
+	}
+	if room, busy := p.room(); room != 10 || len(busy) != 0 {
+		t.Fatalf("expected the pool to be empty, got %d %v", room, busy)
+	}
+}
@@ -0,0 +1,33 @@
+#!/usr/bin/env bash
+# Benchmarks a base revision and the working tree with the same settings,
+# shows the comparison with benchstat, then fails if any benchmark regressed
+# significantly (cli bench-gate). See "Performance benchmarks" in
+# docs/developer-guide.md.
+#
+#   scripts/bench-compare.sh [base]   # base defaults to $BENCH_BASE or origin/main
+set -euo pipefail
+
+base="${1:-${BENCH_BASE:-origin/main}}"
+packages="${BENCH_PACKAGES:-./config/router/}"
+pattern="${BENCH_PATTERN:-.}"
+count="${BENCH_COUNT:-10}"
+out="${BENCH_OUT:-bench}"
+
+mkdir -p "$out"
+worktree="$(mktemp -d)"
+trap 'git worktree remove --force "$worktree" >/dev/null 2>&1 || rm -rf "$worktree"' EXIT
+git worktree add --detach "$worktree" "$base" >/dev/null
+
+run() {
+	# shellcheck disable=SC2086 # BENCH_PACKAGES may list several packages.
+	(cd "$1" && go test -run '^$' -bench "$pattern" -benchmem -count "$count" $packages)
+}
+
+echo "Benchmarking $base" >&2
+run "$worktree" >"$out/old.txt"
+echo "Benchmarking the working tree" >&2
+run . >"$out/new.txt"
+
+go run golang.org/x/perf/cmd/benchstat@latest "$out/old.txt" "$out/new.txt" ||
+	echo "benchstat is unavailable; the gate below still compares the runs" >&2
+go run ./cmd/cli bench-gate -max-regression "${BENCH_MAX_REGRESSION:-10}" "$out/old.txt" "$out/new.txt"